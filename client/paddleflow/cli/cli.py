@@ -25,6 +25,7 @@ import configparser
 from paddleflow.client import Client
 from paddleflow.cli.output import OutputFormat
 from paddleflow.cli.user import user
+from paddleflow.cli.project import project
 from paddleflow.cli.queue import queue
 from paddleflow.cli.fs import fs
 from paddleflow.cli.job import job
@@ -46,8 +47,9 @@ DEFAULT_PADDLEFLOW_PORT = 8999
 @click.option('--output', type=click.Choice(list(map(lambda x: x.name, OutputFormat))),
               default=OutputFormat.table.name, show_default=True,
               help='The formatting style for command output.')
+@click.option('--impersonate', help='send requests on behalf of the user, only root is allowed and requests are audited.')
 @click.pass_context
-def cli(ctx, pf_config=None, output=OutputFormat.table.name):
+def cli(ctx, pf_config=None, output=OutputFormat.table.name, impersonate=None):
     """paddleflow is the command line interface to paddleflow service.\n
        provide `user`, `queue`, `fs`, `run`, `pipeline`, `cluster`, `flavour` operation commands
     """
@@ -79,6 +81,8 @@ def cli(ctx, pf_config=None, output=OutputFormat.table.name):
     name = config['user']['name']
     password = config['user']['password']
     ctx.obj['client'].login(name, password)
+    if impersonate and ctx.obj['client'].header:
+        ctx.obj['client'].impersonate(impersonate)
     ctx.obj['output'] = output
 
 
@@ -87,6 +91,7 @@ def main():
     """
     logging.basicConfig(format='%(message)s', level=logging.INFO)
     cli.add_command(user)
+    cli.add_command(project)
     cli.add_command(queue)
     cli.add_command(fs)
     cli.add_command(run)
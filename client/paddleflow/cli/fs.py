@@ -36,12 +36,13 @@ def fs():
 @fs.command()
 @click.option('-u', '--username', help='List the specified fs by username, only useful for root.')
 @click.option('-m', '--maxsize', default=100, help="Max size of the listed fs.")
+@click.option('-p', '--project', help="List the fs in the project.")
 @click.pass_context
-def list(ctx, username=None, maxsize=100):
+def list(ctx, username=None, maxsize=100, project=None):
     """list fs """
     client = ctx.obj['client']
     output_format = ctx.obj['output']
-    valid, response = client.list_fs(username, maxsize)
+    valid, response = client.list_fs(username, maxsize, project)
     if valid:
         if len(response):
             _print_fs(response, output_format)
@@ -436,6 +437,51 @@ def listlink(ctx, fsname, username=None, maxsize=100):
         sys.exit(1)
 
 
+@fs.command()
+@click.argument('fsname')
+@click.argument('localpath')
+@click.argument('fspath')
+@click.option('-o', '--overwrite', is_flag=True, help='Overwrite the file if it exists.')
+@click.option('-u', '--username', help='Upload to the fs of the specified user, only useful for root.')
+@click.pass_context
+def upload(ctx, fsname, localpath, fspath, overwrite=False, username=None):
+    """
+    upload local file to fs, run it again to resume an interrupted upload\n
+    FSNAME: fs name\n
+    LOCALPATH: local file path\n
+    FSPATH: file path in fs
+    """
+    client = ctx.obj['client']
+    valid, response = client.upload_file(fsname, localpath, fspath, overwrite, username=username)
+    if valid:
+        click.echo("upload %s to fs[%s] %s success" % (localpath, fsname, response))
+    else:
+        click.echo("fs upload failed with message[%s]" % response)
+        sys.exit(1)
+
+
+@fs.command()
+@click.argument('fsname')
+@click.argument('fspath')
+@click.argument('localpath')
+@click.option('-u', '--username', help='Download from the fs of the specified user, only useful for root.')
+@click.pass_context
+def download(ctx, fsname, fspath, localpath, username=None):
+    """
+    download file of fs, an existing partial local file is resumed\n
+    FSNAME: fs name\n
+    FSPATH: file path in fs\n
+    LOCALPATH: local file path
+    """
+    client = ctx.obj['client']
+    valid, response = client.download_file(fsname, fspath, localpath, username=username)
+    if valid:
+        click.echo("download fs[%s] %s to %s success" % (fsname, fspath, response))
+    else:
+        click.echo("fs download failed with message[%s]" % response)
+        sys.exit(1)
+
+
 def _print_fs(fslist, out_format):
     """print fs """
     headers = ['name', 'owner', 'type', 'server address', 'sub path', 'properties']
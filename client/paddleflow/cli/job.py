@@ -85,6 +85,12 @@ def _print_job(job_info, out_format):
     headers = ['job id', 'job name', 'queue', 'priority', 'status', 'accept time', 'start time', 'finish time']
     data = [[job_info.job_id, job_info.job_name, job_info.queue, job_info.priority, job_info.status,
             job_info.accept_time, job_info.start_time, job_info.finish_time]]
+    if job_info.effective_priority and job_info.effective_priority != job_info.priority:
+        headers.insert(4, 'effective priority')
+        data[0].insert(4, job_info.effective_priority)
+    if job_info.burst_from_queue:
+        headers.insert(3, 'burst from queue')
+        data[0].insert(3, job_info.burst_from_queue)
     print_output(data, headers, out_format, table_format='grid')
     print("job config and runtime info: ")
     # print job fs
@@ -122,8 +128,10 @@ def _print_job(job_info, out_format):
 @click.option('-m', '--maxkeys', help="Max size of the listed job.")
 @click.option('-mk', '--marker', help="Next page ")
 @click.option('-fl', '--fieldlist', help="show the specificed field list")
+@click.option('-p', '--project', help="List the job submitted to queues of the project.")
 @click.pass_context
-def list(ctx, status=None, timestamp=None, starttime=None, queue=None, labels=None, maxkeys=None, marker=None, fieldlist=None):
+def list(ctx, status=None, timestamp=None, starttime=None, queue=None, labels=None, maxkeys=None, marker=None, fieldlist=None,
+         project=None):
     """
     list job\n
     """
@@ -136,7 +144,7 @@ def list(ctx, status=None, timestamp=None, starttime=None, queue=None, labels=No
             v = i.split("=")
             label_map[v[0]] = v[1]
         labels = label_map
-    valid, response, nextmarker = client.list_job(status, timestamp, starttime, queue, labels, maxkeys, marker)
+    valid, response, nextmarker = client.list_job(status, timestamp, starttime, queue, labels, maxkeys, marker, project)
     if valid:
         _print_job_list(response, output_format, fieldlist)
         click.echo('marker: {}'.format(nextmarker))
@@ -198,6 +206,33 @@ def create(ctx, jobtype, jsonpath):
         sys.exit(1)
 
 
+@job.command()
+@click.argument('jobtype')
+@click.argument('jsonpath')
+@click.pass_context
+def simulate(ctx, jobtype, jsonpath):
+    """ simulate scheduling of job without creating it.\n
+    JOBTYPE: single, distributed or serving.
+    JSONPATH: path of json file, same as job create.
+    """
+    client = ctx.obj['client']
+    output_format = ctx.obj['output']
+    with open(jsonpath, 'r', encoding='utf8') as read_content:
+        job_request_dict = json.load(read_content)
+
+    valid, response = client.simulate_job(jobtype, job_request_dict)
+    if not valid:
+        click.echo("job simulate failed with message[%s]" % response)
+        sys.exit(1)
+    headers = ['result', 'estimated wait time', 'jobs ahead', 'request resources', 'idle resources']
+    wait_time = response.get('estimatedWaitTime')
+    data = [[response['result'], '-' if wait_time is None else '%ss' % wait_time, response.get('jobsAhead', 0),
+             response.get('requestResources'), response.get('idleResources')]]
+    print_output(data, headers, output_format, table_format='grid')
+    for reason in response.get('reasons') or []:
+        click.echo("reason: %s" % reason)
+
+
 @job.command()
 @click.argument('jobid')
 @click.option('-p', '--priority', help="Update the priority of job, such as: low, normal, high, e.g. --priority high")
@@ -247,6 +282,38 @@ def stop(ctx, jobid):
         sys.exit(1)
 
 
+@job.command()
+@click.argument('jobid')
+@click.pass_context
+def approve(ctx, jobid):
+    """approve the job waiting for approval, root only.\n
+    JOBID: the id of the specificed job.
+    """
+    client = ctx.obj['client']
+    valid, response = client.approve_job(jobid)
+    if valid:
+        click.echo("jobid[%s] approve success" % jobid)
+    else:
+        click.echo("job approve failed with message[%s]" % response)
+        sys.exit(1)
+
+
+@job.command()
+@click.argument('jobid')
+@click.pass_context
+def reject(ctx, jobid):
+    """reject the job waiting for approval, root only.\n
+    JOBID: the id of the specificed job.
+    """
+    client = ctx.obj['client']
+    valid, response = client.reject_job(jobid)
+    if valid:
+        click.echo("jobid[%s] reject success" % jobid)
+    else:
+        click.echo("job reject failed with message[%s]" % response)
+        sys.exit(1)
+
+
 @job.command()
 @click.argument('jobid')
 @click.pass_context
@@ -264,3 +331,49 @@ def delete(ctx, jobid):
     else:
         click.echo("job delete failed with message[%s]" % response)
         sys.exit(1)
+
+
+@job.command()
+@click.argument('jobid')
+@click.option('-t', '--timeout', type=int, help="Max seconds to wait, wait until job finished by default.")
+@click.pass_context
+def watch(ctx, jobid, timeout=None):
+    """ watch job status until it finished.\n
+    JOBID: the id of the specificed job.
+    """
+    client = ctx.obj['client']
+    if not jobid:
+        click.echo('job watch must provide jobid.', err=True)
+        sys.exit(1)
+    valid, response = client.wait_job(jobid, timeout,
+                                      lambda job_info: click.echo("job[%s] status: %s" % (jobid, job_info.status)))
+    if not valid:
+        click.echo("job watch failed with message[%s]" % response)
+        sys.exit(1)
+
+
+@job.command()
+@click.option('-q', '--queue', required=True, help="Show the running jobs in the queue.")
+@click.option('-s', '--sortby', type=click.Choice(['cpu', 'memory', 'gpu', 'gpuMemory']), default='cpu',
+              help="Sort the jobs by the resource usage.")
+@click.pass_context
+def top(ctx, queue, sortby):
+    """ show live cpu/gpu/memory usage of running jobs in queue.\n
+    """
+    client = ctx.obj['client']
+    output_format = ctx.obj['output']
+    valid, response = client.job_top(queue, sortby)
+    if not valid:
+        click.echo("job top failed with message[%s]" % response)
+        sys.exit(1)
+    _print_job_top(response, output_format)
+
+
+def _print_job_top(info, out_format):
+    """print job top like kubectl top"""
+    headers = ['job id', 'job name', 'user', 'tasks', 'cpu(cores)', 'memory(Mi)', 'gpu util', 'gpu memory(Mi)']
+    data = [[job.job_id, job.job_name, job.username, job.task_count, "%.2f" % job.cpu_usage,
+             "%.0f" % (job.memory_usage / 1024 / 1024), "%.2f%%" % (job.gpu_util * 100),
+             "%.0f" % (job.gpu_memory_usage / 1024 / 1024)] for job in info.job_list]
+    print_output(data, headers, out_format, table_format='grid')
+    click.echo("update time: %s" % info.update_time)
@@ -64,6 +64,101 @@ def show(ctx, runid, jobid=None, pagesize=None, pageno=None, logfileposition=Non
         sys.exit(1)
 
 
+@log.command(name='job', context_settings=dict(max_content_width=2000), cls=command_required_option_from_option())
+@click.argument('jobid')
+@click.option('-t', '--taskid', help="task id, the first task of job by default")
+@click.option('-f', '--follow', is_flag=True, help="keep streaming until the task exits")
+@click.option('-n', '--taillines', type=int, help="only show the last n lines")
+@click.pass_context
+def job_log(ctx, jobid, taskid=None, follow=False, taillines=None):
+    """
+
+    stream job log\n
+    JOBID: the id of the specificed job.
+
+    """
+    client = ctx.obj['client']
+    if not jobid:
+        click.echo('log job must provide jobid.', err=True)
+        sys.exit(1)
+    valid, response = client.stream_job_log(jobid, taskid, follow, taillines)
+    if not valid:
+        click.echo("stream job log failed with message[%s]" % response)
+        sys.exit(1)
+    try:
+        for line in response.lines():
+            click.echo(line)
+    except KeyboardInterrupt:
+        response.close()
+
+
+@log.command(name='step', context_settings=dict(max_content_width=2000))
+@click.argument('runid')
+@click.argument('stepname')
+@click.option('-t', '--taskid', help="task id, the first task of job by default")
+@click.option('-f', '--follow', is_flag=True, help="keep streaming until the task exits")
+@click.option('-n', '--taillines', type=int, help="only show the last n lines")
+@click.pass_context
+def step_log(ctx, runid, stepname, taskid=None, follow=False, taillines=None):
+    """
+
+    stream log of a step in run, the latest job of the step is used\n
+    RUNID: the id of the specificed run.
+    STEPNAME: the name of the step.
+
+    """
+    client = ctx.obj['client']
+    valid, response = client.stream_step_log(runid, stepname, taskid, follow, taillines)
+    if not valid:
+        click.echo("stream step log failed with message[%s]" % response)
+        sys.exit(1)
+    try:
+        for line in response.lines():
+            click.echo(line)
+    except KeyboardInterrupt:
+        response.close()
+
+
+@log.command(context_settings=dict(max_content_width=2000))
+@click.argument('pattern')
+@click.option('-r', '--runid', help="search jobs of the run")
+@click.option('-l', '--labels', help="search jobs selected by labels, e.g. k1=v1,k2=v2")
+@click.option('-i', '--ignorecase', is_flag=True, help="case insensitive match")
+@click.option('-n', '--taillines', type=int, help="only search the last n lines of each task")
+@click.option('-m', '--maxmatches', type=int, help="max matched lines to return; default value 1000")
+@click.pass_context
+def search(ctx, pattern, runid=None, labels=None, ignorecase=False, taillines=None, maxmatches=None):
+    """
+
+    search logs of jobs in run or selected by labels\n
+    PATTERN: the regular expression to match.
+
+    """
+    client = ctx.obj['client']
+    output_format = ctx.obj['output']
+    if not runid and not labels:
+        click.echo('log search must provide runid or labels.', err=True)
+        sys.exit(1)
+    label_map = None
+    if labels:
+        label_map = dict()
+        for item in labels.split(","):
+            k, _, v = item.partition("=")
+            label_map[k] = v
+    valid, response = client.search_job_log(pattern, runid, label_map, ignorecase, taillines, maxmatches)
+    if not valid:
+        click.echo("search log failed with message[%s]" % response)
+        sys.exit(1)
+    headers = ['job id', 'step', 'task id', 'line no', 'line']
+    data = [[m.jobid, m.step_name, m.taskid, m.line_no, m.line] for m in response['matches']]
+    print_output(data, headers, output_format, table_format='grid')
+    click.echo("searched tasks: %d" % response['searchedTasks'])
+    for taskid, message in response['failedTasks'].items():
+        click.echo("search task[%s] failed: %s" % (taskid, message))
+    if response['truncated']:
+        click.echo("results has been truncated, use -m to raise the limit")
+
+
 def _print_run_log(loginfo, out_format):
     """print run log """
     submit_loginfo = loginfo['submitLog']
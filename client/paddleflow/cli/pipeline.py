@@ -17,6 +17,7 @@ limitations under the License.
 # -*- coding:utf8 -*-
 
 import sys
+import base64
 import subprocess
 import time
 import json
@@ -56,17 +57,51 @@ def create(ctx, fs_name, yaml_path=None, desc=None, username=None):
         sys.exit(1)
 
 
+@pipeline.command()
+@click.option('-f', '--fsname', 'fs_name', help="name of storage volume where the yaml file is.")
+@click.option('-yp', '--yamlpath', 'yaml_path', help="relative path of yaml file under storage volume.")
+@click.option('-yf', '--yamlfile', 'yaml_file', help="local yaml file, has higher priority than fsname.")
+@click.option('-u', '--username', help="Only the root user can specify other users.")
+@click.pass_context
+def validate(ctx, fs_name=None, yaml_path=None, yaml_file=None, username=None):
+    """ validate pipeline yaml without creating it, exit with 1 if any error is found.\n
+    """
+    client = ctx.obj['client']
+    yaml_raw = None
+    if yaml_file:
+        with open(yaml_file, 'rb') as f:
+            yaml_raw = base64.b64encode(f.read()).decode()
+    elif not fs_name:
+        click.echo('pipeline validate must provide fs name or yaml file.', err=True)
+        sys.exit(1)
+    valid, response = client.validate_pipeline(fs_name, yaml_path, yaml_raw, username)
+    if not valid:
+        click.echo("pipeline validate failed with message[%s]" % response)
+        sys.exit(1)
+    if response['valid']:
+        click.echo("pipeline[%s] is valid" % response['name'])
+        return
+    for err in response['errors']:
+        if err['component']:
+            click.echo("[%s] %s" % (err['component'], err['message']), err=True)
+        else:
+            click.echo(err['message'], err=True)
+    click.echo("pipeline[%s] is invalid, %d error(s) found" % (response['name'], len(response['errors'])), err=True)
+    sys.exit(1)
+
+
 @pipeline.command()
 @click.option('-u', '--userfilter', 'user_filter', help="List the pipeline by user.")
 @click.option('-n', '--namefilter', 'name_filter', help="List the pipeline by name.")
 @click.option('-m', '--maxkeys', 'max_keys', help="Max size of the listed pipeline.")
 @click.option('-mk', '--marker', help="Next page ")
+@click.option('-p', '--project', help="List the pipelines in the project.")
 @click.pass_context
-def list(ctx, user_filter=None, name_filter=None, max_keys=None, marker=None):
+def list(ctx, user_filter=None, name_filter=None, max_keys=None, marker=None, project=None):
     """list pipeline. \n"""
     client = ctx.obj['client']
     output_format = ctx.obj['output']
-    valid, response = client.list_pipeline(user_filter, name_filter, max_keys, marker)
+    valid, response = client.list_pipeline(user_filter, name_filter, max_keys, marker, project)
     if valid:
         pipeline_list, next_marker = response['pipelineList'], response['nextMarker']
         if len(pipeline_list):
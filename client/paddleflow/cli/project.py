@@ -0,0 +1,184 @@
+"""
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+"""
+
+#!/usr/bin/env python3
+# -*- coding:utf8 -*-
+
+import sys
+import click
+
+from paddleflow.cli.output import print_output, OutputFormat
+
+
+@click.group()
+def project():
+    """manage projects grouping users, queues, fs and pipelines"""
+    pass
+
+
+def _quota(cpu=None, mem=None, scalar=None):
+    """build quota of project, return None if nothing is set"""
+    quota = {}
+    if cpu:
+        quota['cpu'] = cpu
+    if mem:
+        quota['mem'] = mem
+    if scalar:
+        quota['scalarResources'] = dict(kv.split('=', 1) for kv in scalar.split(','))
+    return quota or None
+
+
+@project.command()
+@click.option('-m', '--maxsize', default=100, help="Max size of the listed projects.")
+@click.option('-mk', '--marker', help="Next page ")
+@click.pass_context
+def list(ctx, maxsize=100, marker=None):
+    """list project. """
+    client = ctx.obj['client']
+    output_format = ctx.obj['output']
+    valid, response, nextmarker = client.list_project(maxsize, marker)
+    if valid:
+        if len(response):
+            _print_projects(response, output_format)
+            click.echo('marker: {}'.format(nextmarker))
+        else:
+            click.echo("no project found ")
+    else:
+        click.echo("project list failed with message[%s]" % response)
+        sys.exit(1)
+
+
+@project.command()
+@click.argument('name')
+@click.pass_context
+def show(ctx, name):
+    """ show project info with members.\n
+    NAME: the name of project
+    """
+    client = ctx.obj['client']
+    output_format = ctx.obj['output']
+    valid, response = client.show_project(name)
+    if valid:
+        _print_projects([response], output_format)
+        headers = ['resource type', 'resource id', 'role', 'create time']
+        data = [[m.resourceType, m.resourceID, m.role, m.createTime] for m in response.members]
+        print_output(data, headers, output_format, table_format='grid')
+    else:
+        click.echo("project show failed with message[%s]" % response)
+        sys.exit(1)
+
+
+@project.command()
+@click.argument('name')
+@click.option('-d', '--description', help='the description of project')
+@click.option('--maxcpu', help='the cpu quota of all queues in project, e.g. --maxcpu 100')
+@click.option('--maxmem', help='the memory quota of all queues in project, e.g. --maxmem 200Gi')
+@click.option('--maxscalar', help='the scalar resource quota of project, e.g. --maxscalar nvidia.com/gpu=8')
+@click.pass_context
+def create(ctx, name, description=None, maxcpu=None, maxmem=None, maxscalar=None):
+    """ create project, root is needed.\n
+    NAME: the name of project
+    """
+    client = ctx.obj['client']
+    valid, response = client.create_project(name, description, _quota(maxcpu, maxmem, maxscalar))
+    if valid:
+        click.echo("project[%s] create success" % response)
+    else:
+        click.echo("project create failed with message[%s]" % response)
+        sys.exit(1)
+
+
+@project.command()
+@click.argument('name')
+@click.option('-d', '--description', help='the description of project')
+@click.option('--maxcpu', help='the cpu quota of all queues in project, root is needed')
+@click.option('--maxmem', help='the memory quota of all queues in project, root is needed')
+@click.option('--maxscalar', help='the scalar resource quota of project, root is needed')
+@click.pass_context
+def update(ctx, name, description=None, maxcpu=None, maxmem=None, maxscalar=None):
+    """ update project.\n
+    NAME: the name of project
+    """
+    client = ctx.obj['client']
+    valid, response = client.update_project(name, description, _quota(maxcpu, maxmem, maxscalar))
+    if valid:
+        click.echo("project[%s] update success" % name)
+    else:
+        click.echo("project update failed with message[%s]" % response)
+        sys.exit(1)
+
+
+@project.command()
+@click.argument('name')
+@click.pass_context
+def delete(ctx, name):
+    """ delete project, resources in project are kept.\n
+    NAME: the name of project
+    """
+    client = ctx.obj['client']
+    valid, response = client.delete_project(name)
+    if valid:
+        click.echo("project[%s] delete success" % name)
+    else:
+        click.echo("project delete failed with message[%s]" % response)
+        sys.exit(1)
+
+
+@project.command()
+@click.argument('name')
+@click.argument('resourcetype', type=click.Choice(['user', 'queue', 'fs', 'pipeline']))
+@click.argument('resourceid')
+@click.option('-r', '--role', type=click.Choice(['admin', 'member']), help='the role of user in project')
+@click.pass_context
+def add(ctx, name, resourcetype, resourceid, role=None):
+    """ add member to project.\n
+    NAME: the name of project \n
+    RESOURCETYPE: user/queue/fs/pipeline \n
+    RESOURCEID: user name, queue name, fs id or pipeline id
+    """
+    client = ctx.obj['client']
+    valid, response = client.add_project_member(name, resourcetype, resourceid, role)
+    if valid:
+        click.echo("%s[%s] is added to project[%s]" % (resourcetype, resourceid, name))
+    else:
+        click.echo("project add member failed with message[%s]" % response)
+        sys.exit(1)
+
+
+@project.command()
+@click.argument('name')
+@click.argument('resourcetype', type=click.Choice(['user', 'queue', 'fs', 'pipeline']))
+@click.argument('resourceid')
+@click.pass_context
+def remove(ctx, name, resourcetype, resourceid):
+    """ remove member from project.\n
+    NAME: the name of project \n
+    RESOURCETYPE: user/queue/fs/pipeline \n
+    RESOURCEID: user name, queue name, fs id or pipeline id
+    """
+    client = ctx.obj['client']
+    valid, response = client.remove_project_member(name, resourcetype, resourceid)
+    if valid:
+        click.echo("%s[%s] is removed from project[%s]" % (resourcetype, resourceid, name))
+    else:
+        click.echo("project remove member failed with message[%s]" % response)
+        sys.exit(1)
+
+
+def _print_projects(projects, out_format):
+    """print projects """
+    headers = ['name', 'description', 'max resources', 'used resources', 'create time', 'update time']
+    data = [[p.name, p.description, p.maxResources, p.usedResources, p.createTime, p.updateTime] for p in projects]
+    print_output(data, headers, out_format, table_format='grid')
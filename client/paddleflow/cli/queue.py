@@ -36,12 +36,13 @@ def queue():
 @queue.command()
 @click.option('-m', '--maxsize', default=100, help="Max size of the listed queues.")
 @click.option('-mk', '--marker', help="Next page ")
+@click.option('-p', '--project', help="List the queues in the project.")
 @click.pass_context
-def list(ctx, maxsize=100, marker=None):
+def list(ctx, maxsize=100, marker=None, project=None):
     """list queue. """
     client = ctx.obj['client']
     output_format = ctx.obj['output']
-    valid, response, nextmarker = client.list_queue(maxsize, marker)
+    valid, response, nextmarker = client.list_queue(maxsize, marker, project)
     if valid:
         if len(response):
             _print_queues(response, output_format)
@@ -87,9 +88,14 @@ def show(ctx, queuename):
 @click.option('--location', help='the node location of queue, such as Kubernetes is node labels, e.g. --location label1=value1,label2=value2')
 @click.option('--quota', help='the quota type of queue, such as elasticQuota, volcanoCapabilityQuota, default is elasticQuota')
 @click.option('--clustername', help='the owner cluster name of queue, e.g. --clustername default-cluster')
+@click.option('--aginginterval', type=int, help='raise priority of waiting jobs one level every interval seconds, 0 disables it, e.g. --aginginterval 600')
+@click.option('--agingmax', help='the max priority raised by aging, default is HIGH, e.g. --agingmax HIGH')
+@click.option('--burstqueue', help='the queue of other cluster which waiting jobs burst to, empty string disables it, e.g. --burstqueue cloud-queue')
+@click.option('--burstwait', type=int, help='burst jobs waiting longer than seconds, default is 300, e.g. --burstwait 600')
 @click.pass_context
 def create(ctx, name, namespace, maxcpu, maxmem, maxscalar=None, mincpu=None, minmem=None, minscalar=None,
-            policy=None, location=None, quota=None, clustername=None):
+            policy=None, location=None, quota=None, clustername=None, aginginterval=None, agingmax=None,
+            burstqueue=None, burstwait=None):
     """ create queue.\n
     NAME: the name of queue.
     NAMESPACE: the namespace to which it belongs.
@@ -136,7 +142,8 @@ def create(ctx, name, namespace, maxcpu, maxmem, maxscalar=None, mincpu=None, mi
         locationDict = dict([item.split("=") for item in args])
 
     valid, response = client.add_queue(name, namespace, clustername, maxresources, minresources,
-                                       schedulingPolicy, locationDict, quota)
+                                       schedulingPolicy, locationDict, quota, _priority_aging(aginginterval, agingmax),
+                                       _burst_policy(burstqueue, burstwait))
     if valid:
         click.echo("queue[%s] create success " % name)
     else:
@@ -154,8 +161,13 @@ def create(ctx, name, namespace, maxcpu, maxmem, maxscalar=None, mincpu=None, mi
 @click.option('--minscalar', help='the min scalar resource of queue, e.g. --minscalar a=b,c=d')
 @click.option('--policy', help='the scheduling policy for job on queue, e.g. --policy priority,weight')
 @click.option('--location', help='the node location of queue, such as Kubernetes is node labels, e.g. --location label1=value1,label2=value2')
+@click.option('--aginginterval', type=int, help='raise priority of waiting jobs one level every interval seconds, 0 disables it, e.g. --aginginterval 600')
+@click.option('--agingmax', help='the max priority raised by aging, default is HIGH, e.g. --agingmax HIGH')
+@click.option('--burstqueue', help='the queue of other cluster which waiting jobs burst to, empty string disables it, e.g. --burstqueue cloud-queue')
+@click.option('--burstwait', type=int, help='burst jobs waiting longer than seconds, default is 300, e.g. --burstwait 600')
 @click.pass_context
-def update(ctx, name, maxcpu=None, maxmem=None, maxscalar=None, mincpu=None, minmem=None, minscalar=None, policy=None, location=None):
+def update(ctx, name, maxcpu=None, maxmem=None, maxscalar=None, mincpu=None, minmem=None, minscalar=None, policy=None, location=None,
+           aginginterval=None, agingmax=None, burstqueue=None, burstwait=None):
     """ update queue.\n
     NAME: the name of queue.
     """
@@ -191,7 +203,8 @@ def update(ctx, name, maxcpu=None, maxmem=None, maxscalar=None, mincpu=None, min
         locationDict = dict([item.split("=") for item in args])
 
     valid, response = client.update_queue(name, maxresources, minresources,
-                                       schedulingPolicy, locationDict)
+                                       schedulingPolicy, locationDict, _priority_aging(aginginterval, agingmax),
+                                       _burst_policy(burstqueue, burstwait))
     if valid:
         click.echo("queue[%s] update success " % name)
     else:
@@ -280,6 +293,26 @@ def ungrant(ctx, username, queuename):
         sys.exit(1)
 
 
+def _priority_aging(interval, max_priority):
+    """build priority aging of queue from options"""
+    if interval is None:
+        return None
+    priority_aging = {'interval': interval}
+    if max_priority:
+        priority_aging['maxPriority'] = max_priority
+    return priority_aging
+
+
+def _burst_policy(queue, wait_seconds):
+    """build burst policy of queue from options"""
+    if queue is None:
+        return None
+    burst_policy = {'queue': queue}
+    if wait_seconds:
+        burst_policy['waitSeconds'] = wait_seconds
+    return burst_policy
+
+
 def _print_queues(queues, out_format):
     """print queues """
     headers = ['name', 'namespace', 'status', 'cluster name', 'create time', 'update time']
@@ -311,6 +344,12 @@ def _print_queue_info(queue, out_format):
     if queue.location:
         headers.append('location')
         data[0].append(queue.location)
+    if queue.priorityAging:
+        headers.append('priority aging')
+        data[0].append(queue.priorityAging)
+    if queue.burstPolicy:
+        headers.append('burst policy')
+        data[0].append(queue.burstPolicy)
     print_output(data, headers, "json", table_format='grid')
 
 
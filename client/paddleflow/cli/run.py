@@ -157,6 +157,43 @@ def stop(ctx, run_id, force):
         sys.exit(1)
 
 
+@run.command()
+@click.argument('run_id')
+@click.pass_context
+def events(ctx, run_id):
+    """list events of run and its steps in chronological order.\n
+    RUN_ID: the id of the specified run.
+    """
+    client = ctx.obj['client']
+    output_format = ctx.obj['output']
+    valid, response = client.list_run_events(run_id)
+    if valid:
+        _print_run_events(response, output_format)
+    else:
+        click.echo("run events failed with message[%s]" % response)
+        sys.exit(1)
+
+
+@run.command()
+@click.argument('run_id')
+@click.argument('job_id')
+@click.option('-r', '--reject', is_flag=True, help="Reject instead of approve.")
+@click.option('-c', '--comment', help="comment of the approval.")
+@click.pass_context
+def approve(ctx, run_id, job_id, reject, comment=None):
+    """approve or reject a job waiting for approval.\n
+    RUN_ID: the id of the specified run.
+    JOB_ID: the id of the approval job.
+    """
+    client = ctx.obj['client']
+    valid, response = client.approve_run_job(run_id, job_id, approved=not reject, comment=comment)
+    if valid:
+        click.echo("job[%s] of run[%s] %s success" % (job_id, run_id, "reject" if reject else "approve"))
+    else:
+        click.echo("run approve failed with message[%s]" % response)
+        sys.exit(1)
+
+
 @run.command()
 @click.argument('run_id')
 @click.pass_context
@@ -301,6 +338,15 @@ def _print_run_list(run_list, out_format):
     print_output(data, headers, out_format, table_format='grid')
 
 
+def _print_run_events(events, out_format):
+    """print run events """
+
+    headers = ['time', 'type', 'step name', 'job id', 'status', 'message']
+    data = [[event['time'], event['type'], event.get('stepName', ''), event.get('jobID', ''),
+             event.get('status', ''), event.get('message', '')] for event in events]
+    print_output(data, headers, out_format, table_format='grid')
+
+
 def _print_run_cache(caches, out_format):
     """print cache list """
 
@@ -370,8 +416,8 @@ def _trans_comps_to_dict(comps):
 def _print_artifact(runs, out_format):
     """ print artifact info"""
     headers = ['run id', 'fsname', 'username', 'artifact path', 'type', 'step', 'artifact name', 'meta',  
-            'create time', 'update time']
+            'pruned', 'create time', 'update time']
     data = [[run.run_id, run.fs_name, run.username, run.artifact_path, run.type, run.step, run.artifact_name,
-             run.meta, run.create_time, run.update_time] for run in runs]
+             run.meta, run.pruned, run.create_time, run.update_time] for run in runs]
     print_output(data, headers, out_format, table_format='grid')
     
@@ -42,6 +42,29 @@ def job(ctx, jobid, detail, start, end, step):
         _get_job_statistics(client, output_format, jobid)
 
 
+@statistics.command()
+@click.pass_context
+@click.option('-m', '--month', help="month like 2022-10, default current month")
+def costcenter(ctx, month):
+    """ show gpu hours of jobs grouped by cost center and cluster.\n
+    only root user is allowed.
+    """
+    client = ctx.obj['client']
+    output_format = ctx.obj['output']
+    valid, response = client.get_cost_center_report(month)
+    if not valid:
+        click.echo("get cost center report failed with message[%s]" % response)
+        sys.exit(1)
+    if len(response.items) == 0:
+        click.echo("no data")
+        return
+    headers = ['cost center', 'cluster id', 'gpu hours', 'job count']
+    data = [[item.cost_center or '-', item.cluster_id or '-', item.gpu_hours, item.job_count]
+            for item in response.items]
+    click.echo("month: %s" % response.month)
+    print_output(data, headers, output_format, table_format='grid')
+
+
 def _get_job_statistics(cli, output_format, jobid):
     valid, response = cli.get_statistics(jobid)
     if valid:
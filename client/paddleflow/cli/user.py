@@ -34,12 +34,13 @@ def user():
 
 @user.command()
 @click.option('-m', '--maxsize', default=100, help="Max size of the listed users.")
+@click.option('-p', '--project', help="List the users in the project, project admin is allowed.")
 @click.pass_context
-def list(ctx, maxsize=100):
+def list(ctx, maxsize=100, project=None):
     """list user """
     client = ctx.obj['client']
     output_format = ctx.obj['output']
-    valid, response = client.list_user(maxsize)
+    valid, response = client.list_user(maxsize, project)
     if valid:
         if len(response):
             _print_users(response, output_format)
@@ -53,8 +54,9 @@ def list(ctx, maxsize=100):
 @user.command()
 @click.argument('username')
 @click.argument('password')
+@click.option('-e', '--email', help="the email receiving password reset tokens")
 @click.pass_context
-def add(ctx, username, password):
+def add(ctx, username, password, email=None):
     """
     add user arguments.\n
     USERNAME: the new user's name \n
@@ -64,7 +66,7 @@ def add(ctx, username, password):
     if not username or not password:
         click.echo('user add  must provide username and password.', err=True)
         sys.exit(1)
-    valid, response = client.add_user(username, password)
+    valid, response = client.add_user(username, password, email)
     if valid:
         click.echo("user[%s] add success" % username)
     else:
@@ -93,6 +95,63 @@ def set(ctx, username, password):
         sys.exit(1)
 
 
+@user.command()
+@click.argument('username')
+@click.argument('email')
+@click.pass_context
+def email(ctx, username, email):
+    """update user's email which receives password reset tokens.\n
+    USERNAME: the user's name \n
+    EMAIL: the new email of the user
+    """
+    client = ctx.obj['client']
+    valid, response = client.update_email(username, email)
+    if valid:
+        click.echo("user[%s] email update success" % username)
+    else:
+        click.echo("user[%s] email update failed with message[%s]" % (username, response))
+        sys.exit(1)
+
+
+@user.command(name='forgot-password')
+@click.argument('username')
+@click.pass_context
+def forgot_password(ctx, username):
+    """send a password reset token to the user's email.\n
+    USERNAME: the user's name
+    """
+    client = ctx.obj['client']
+    valid, response = client.forgot_password(username)
+    if valid:
+        click.echo("reset token has been sent to the email of user[%s] if it exists" % username)
+    else:
+        click.echo("user[%s] forgot password failed with message[%s]" % (username, response))
+        sys.exit(1)
+
+
+@user.command(name='reset-password')
+@click.argument('username')
+@click.argument('password')
+@click.option('-t', '--token', help="the reset token received by email")
+@click.option('-o', '--old-password', help="the old password, used to rotate an expired password")
+@click.pass_context
+def reset_password(ctx, username, password, token=None, old_password=None):
+    """reset user's password with the emailed token or the old password.\n
+    USERNAME: the user's name \n
+    PASSWORD: the new password of the user
+    """
+    client = ctx.obj['client']
+    if not token and not old_password:
+        click.echo('user reset-password must provide --token or --old-password.', err=True)
+        sys.exit(1)
+    valid, response = client.reset_password(username, password, token, old_password)
+    if valid:
+        click.echo("user[%s] reset password success" % username)
+    else:
+        click.echo("user[%s] reset password failed with message[%s]" % (username, response))
+        sys.exit(1)
+
+
 @user.command()
 @click.argument('username')
 @click.pass_context
@@ -112,6 +171,212 @@ def delete(ctx, username):
         sys.exit(1)
 
 
+@user.group()
+def preference():
+    """manage default settings used when creating jobs"""
+    pass
+
+
+@preference.command(name='show')
+@click.option('-u', '--username', help="the user's name, the login user by default")
+@click.pass_context
+def show_preference(ctx, username=None):
+    """show default queue/flavour/image/fs of user"""
+    client = ctx.obj['client']
+    output_format = ctx.obj['output']
+    valid, response = client.get_user_preference(username)
+    if valid:
+        _print_preference(response, output_format)
+    else:
+        click.echo("user preference show failed with message[%s]" % response)
+        sys.exit(1)
+
+
+@preference.command(name='set')
+@click.option('-q', '--queue', help="default queue")
+@click.option('-f', '--flavour', help="default flavour")
+@click.option('-i', '--image', help="default image")
+@click.option('-fs', '--fsname', help="default fs")
+@click.option('-u', '--username', help="the user's name, the login user by default")
+@click.pass_context
+def set_preference(ctx, queue=None, flavour=None, image=None, fsname=None, username=None):
+    """set default settings of user, the previous settings are overwritten"""
+    client = ctx.obj['client']
+    output_format = ctx.obj['output']
+    valid, response = client.set_user_preference(queue, flavour, image, fsname, username)
+    if valid:
+        _print_preference(response, output_format)
+    else:
+        click.echo("user preference set failed with message[%s]" % response)
+        sys.exit(1)
+
+
+@preference.command(name='delete')
+@click.option('-u', '--username', help="the user's name, the login user by default")
+@click.pass_context
+def delete_preference(ctx, username=None):
+    """delete default settings of user"""
+    client = ctx.obj['client']
+    valid, response = client.del_user_preference(username)
+    if valid:
+        click.echo("user preference delete success")
+    else:
+        click.echo("user preference delete failed with message[%s]" % response)
+        sys.exit(1)
+
+
+@user.group()
+def quota():
+    """manage job limits of user, independent of queue capacity"""
+    pass
+
+
+@quota.command(name='show')
+@click.option('-u', '--username', help="the user's name, the login user by default")
+@click.pass_context
+def show_quota(ctx, username=None):
+    """show max concurrent jobs, max gpus and max job duration of user"""
+    client = ctx.obj['client']
+    output_format = ctx.obj['output']
+    valid, response = client.get_user_quota(username)
+    if valid:
+        _print_quota(response, output_format)
+    else:
+        click.echo("user quota show failed with message[%s]" % response)
+        sys.exit(1)
+
+
+@quota.command(name='set')
+@click.argument('username')
+@click.option('-j', '--maxjobs', type=int, default=0, help="max jobs submitted to cluster at the same time, 0 means no limit")
+@click.option('-g', '--maxgpus', type=int, default=0, help="max gpus of jobs submitted to cluster, 0 means no limit")
+@click.option('-d', '--maxduration', type=int, default=0, help="max running seconds of a job, 0 means no limit")
+@click.pass_context
+def set_quota(ctx, username, maxjobs=0, maxgpus=0, maxduration=0):
+    """set job limits of user, the previous quota is overwritten. only root is allowed"""
+    client = ctx.obj['client']
+    output_format = ctx.obj['output']
+    valid, response = client.set_user_quota(username, maxjobs, maxgpus, maxduration)
+    if valid:
+        _print_quota(response, output_format)
+    else:
+        click.echo("user quota set failed with message[%s]" % response)
+        sys.exit(1)
+
+
+@quota.command(name='delete')
+@click.argument('username')
+@click.pass_context
+def delete_quota(ctx, username):
+    """delete job limits of user. only root is allowed"""
+    client = ctx.obj['client']
+    valid, response = client.del_user_quota(username)
+    if valid:
+        click.echo("user quota delete success")
+    else:
+        click.echo("user quota delete failed with message[%s]" % response)
+        sys.exit(1)
+
+
+@user.group()
+def session():
+    """manage login sessions of user"""
+    pass
+
+
+@session.command(name='list')
+@click.option('-u', '--username', help="the user's name, the login user by default")
+@click.pass_context
+def list_session(ctx, username=None):
+    """list active login sessions of user"""
+    client = ctx.obj['client']
+    output_format = ctx.obj['output']
+    valid, response = client.list_user_sessions(username)
+    if valid:
+        _print_sessions(response, output_format)
+    else:
+        click.echo("user session list failed with message[%s]" % response)
+        sys.exit(1)
+
+
+@session.command(name='revoke')
+@click.argument('sessionid', required=False)
+@click.option('-u', '--username', help="the user's name, the login user by default")
+@click.option('-a', '--all', 'revoke_all', is_flag=True, help="revoke all sessions of the user")
+@click.pass_context
+def revoke_session(ctx, sessionid=None, username=None, revoke_all=False):
+    """revoke login session of user, the token of the session is rejected at once.\n
+    SESSIONID: the session to revoke, use -a to revoke all sessions of the user
+    """
+    client = ctx.obj['client']
+    if revoke_all:
+        valid, response = client.revoke_user_sessions(username or client.user_id)
+        if valid:
+            click.echo("%s sessions revoked" % response)
+            return
+    elif sessionid:
+        valid, response = client.revoke_user_session(sessionid, username)
+        if valid:
+            click.echo("session[%s] revoked" % sessionid)
+            return
+    else:
+        click.echo('user session revoke must provide sessionid or -a.', err=True)
+        sys.exit(1)
+    click.echo("user session revoke failed with message[%s]" % response)
+    sys.exit(1)
+
+
+@user.command()
+@click.option('-o', '--operator', help="the root user who sent the requests")
+@click.option('-u', '--username', help="the impersonated user")
+@click.option('-m', '--maxsize', default=100, help="Max size of the listed audits.")
+@click.option('-mk', '--marker', help="next page.")
+@click.pass_context
+def audit(ctx, operator=None, username=None, maxsize=100, marker=None):
+    """list requests sent by root on behalf of other users, newest first. only root is allowed"""
+    client = ctx.obj['client']
+    output_format = ctx.obj['output']
+    valid, response, next_marker = client.list_impersonation_audit(operator, username, maxsize, marker)
+    if valid:
+        if len(response):
+            _print_audits(response, output_format)
+            click.echo('marker: {}'.format(next_marker))
+        else:
+            click.echo("no impersonation audits found")
+    else:
+        click.echo("user audit failed with message[%s]" % response)
+        sys.exit(1)
+
+
+def _print_audits(audits, out_format):
+    """print impersonation audits """
+    headers = ['operator', 'user', 'method', 'path', 'status', 'client ip', 'create time']
+    data = [[a.operator, a.name, a.method, a.path, a.status_code, a.client_ip, a.create_time] for a in audits]
+    print_output(data, headers, out_format, table_format='grid')
+
+
+def _print_sessions(sessions, out_format):
+    """print user sessions """
+    headers = ['session id', 'client ip', 'user agent', 'create time', 'last active time', 'expire time']
+    data = [[s.session_id, s.client_ip, s.user_agent, s.create_time, s.last_active_time, s.expire_time]
+            for s in sessions]
+    print_output(data, headers, out_format, table_format='grid')
+
+
+def _print_quota(quota, out_format):
+    """print user quota """
+    headers = ['name', 'max concurrent jobs', 'max gpus', 'max job duration(s)']
+    data = [[quota.name, quota.max_concurrent_jobs, quota.max_gpus, quota.max_job_duration]]
+    print_output(data, headers, out_format, table_format='grid')
+
+
+def _print_preference(preference, out_format):
+    """print user preference """
+    headers = ['name', 'queue', 'flavour', 'image', 'fs']
+    data = [[preference.name, preference.queue, preference.flavour, preference.image, preference.fs]]
+    print_output(data, headers, out_format, table_format='grid')
+
+
 def _print_users(users, out_format):
     """print users """
     headers = ['name', 'create time']
@@ -16,7 +16,12 @@ limitations under the License.
 #!/usr/bin/env python3
 # -*- coding:utf8 -*-
 
+import io
 import json
+import os
+import tarfile
+import time
+import uuid
 from urllib import parse
 from paddleflow.common.exception.paddleflow_sdk_exception import PaddleFlowSDKException
 from paddleflow.common import api
@@ -24,8 +29,10 @@ from paddleflow.job import JobServiceApi,JobRequest
 from paddleflow.log import LogServiceApi
 from paddleflow.statistics import StatisticsServiceApi
 from paddleflow.user import UserServiceApi
+from paddleflow.project import ProjectServiceApi
 from paddleflow.queue import QueueServiceApi
 from paddleflow.fs import FSServiceApi
+from paddleflow.fs.fs_api import DEFAULT_CHUNK_SIZE
 from paddleflow.run import RunServiceApi
 from paddleflow.pipeline import PipelineServiceApi
 from paddleflow.schedule import ScheduleServiceApi
@@ -37,6 +44,9 @@ from paddleflow.version import VersionServiceApi
 
 class Client(object):
     """Client class """
+    # 与服务端上传文件大小限制保持一致
+    MAX_CODE_PACKAGE_SIZE = 32 * 1024 * 1024
+    FINAL_JOB_STATUS = ('succeeded', 'failed', 'terminated', 'skipped', 'cancelled')
 
     def __init__(self, paddleflow_server_host, username, password, paddleflow_server_port=8999):
         """
@@ -86,6 +96,17 @@ class Client(object):
         }
         return True, None
 
+    def impersonate(self, user_name=None):
+        """
+        send the following requests on behalf of user_name to debug permission issues, root is needed.
+        requests are audited by server, and only read requests are allowed by default. none to stop impersonating
+        """
+        self.pre_check()
+        if user_name:
+            self.header["Impersonate-User"] = user_name
+        else:
+            self.header.pop("Impersonate-User", None)
+
     def pre_check(self):
         """
         precheck to check header
@@ -100,12 +121,14 @@ class Client(object):
         self.pre_check()
         return VersionServiceApi.get_version(self.paddleflow_server, self.header)
 
-    def add_user(self, user_name, password):
+    def add_user(self, user_name, password, email=None):
         """
         :param user_name: 
         :type user_name: str
         :param passWord
         :type password: str
+        :param email: receives password reset tokens
+        :type email: str
         :return 
         true, None   if success 
         false, message   if failed
@@ -115,7 +138,7 @@ class Client(object):
             raise PaddleFlowSDKException("InvalidUser", "user_name should not be none or empty")
         if password is None or password.strip() == "":
             raise PaddleFlowSDKException("InvalidPassWord", "password should not be none or empty")
-        return UserServiceApi.add_user(self.paddleflow_server, user_name, password, self.header)
+        return UserServiceApi.add_user(self.paddleflow_server, user_name, password, self.header, email)
 
     def del_user(self, user_name):
         """
@@ -132,10 +155,10 @@ class Client(object):
             raise PaddleFlowSDKException("InvalidUser", "user_name should not be none or empty")
         return UserServiceApi.del_user(self.paddleflow_server, user_name, self.header)
 
-    def list_user(self, maxsize=100):
-        """list user info"""
+    def list_user(self, maxsize=100, project=None):
+        """list user info, project admin can list users of the project"""
         self.pre_check()
-        return UserServiceApi.list_user(self.paddleflow_server, self.header, maxsize)
+        return UserServiceApi.list_user(self.paddleflow_server, self.header, maxsize, project)
 
     def update_password(self, name, password):
         """update name's password"""
@@ -146,9 +169,145 @@ class Client(object):
             raise PaddleFlowSDKException("InvalidPassWord", "password should not be none or empty")
         return UserServiceApi.update_password(self.paddleflow_server, name, password, self.header)
 
+    def update_email(self, name, email):
+        """update name's email which receives password reset tokens"""
+        self.pre_check()
+        if name is None or name.strip() == "":
+            raise PaddleFlowSDKException("InvalidUser", "user_name should not be none or empty")
+        if email is None or email.strip() == "":
+            raise PaddleFlowSDKException("InvalidEmail", "email should not be none or empty")
+        return UserServiceApi.update_email(self.paddleflow_server, name, email, self.header)
+
+    def forgot_password(self, user_name):
+        """send a password reset token to the user's email, no login is needed"""
+        if user_name is None or user_name.strip() == "":
+            raise PaddleFlowSDKException("InvalidUser", "user_name should not be none or empty")
+        return UserServiceApi.forgot_password(self.paddleflow_server, user_name)
+
+    def reset_password(self, user_name, password, token=None, old_password=None):
+        """
+        reset password with the emailed token, or with the old password when it has expired.
+        no login is needed
+        """
+        if user_name is None or user_name.strip() == "":
+            raise PaddleFlowSDKException("InvalidUser", "user_name should not be none or empty")
+        if password is None or password.strip() == "":
+            raise PaddleFlowSDKException("InvalidPassWord", "password should not be none or empty")
+        if not token and not old_password:
+            raise PaddleFlowSDKException("InvalidRequest", "token or old_password should be provided")
+        return UserServiceApi.reset_password(self.paddleflow_server, user_name, password, token, old_password)
+
+    def get_user_preference(self, name=None):
+        """get default queue/flavour/image/fs of user, the login user by default"""
+        self.pre_check()
+        return UserServiceApi.get_preference(self.paddleflow_server, name or self.user_id, self.header)
+
+    def set_user_preference(self, queue=None, flavour=None, image=None, fs=None, name=None):
+        """set default queue/flavour/image/fs used by jobs omitting them, the previous settings are overwritten"""
+        self.pre_check()
+        return UserServiceApi.set_preference(self.paddleflow_server, name or self.user_id, queue, flavour, image, fs,
+                                             self.header)
+
+    def del_user_preference(self, name=None):
+        """delete default settings of user"""
+        self.pre_check()
+        return UserServiceApi.del_preference(self.paddleflow_server, name or self.user_id, self.header)
+
+    def get_user_quota(self, name=None):
+        """get max concurrent jobs, max gpus and max job duration of user, the login user by default"""
+        self.pre_check()
+        return UserServiceApi.get_quota(self.paddleflow_server, name or self.user_id, self.header)
+
+    def set_user_quota(self, name, max_concurrent_jobs=0, max_gpus=0, max_job_duration=0):
+        """set job limits of user, 0 means no limit, only root is allowed"""
+        self.pre_check()
+        if not name:
+            raise PaddleFlowSDKException("InvalidUser", "name should not be none or empty")
+        return UserServiceApi.set_quota(self.paddleflow_server, name, max_concurrent_jobs, max_gpus,
+                                        max_job_duration, self.header)
+
+    def del_user_quota(self, name):
+        """delete job limits of user, only root is allowed"""
+        self.pre_check()
+        if not name:
+            raise PaddleFlowSDKException("InvalidUser", "name should not be none or empty")
+        return UserServiceApi.del_quota(self.paddleflow_server, name, self.header)
+
+    def list_user_sessions(self, name=None):
+        """list active login sessions of user, the login user by default"""
+        self.pre_check()
+        return UserServiceApi.list_sessions(self.paddleflow_server, name or self.user_id, self.header)
+
+    def revoke_user_session(self, session_id, name=None):
+        """revoke a login session of user, the login user by default. the token of the session is rejected at once"""
+        self.pre_check()
+        if not session_id:
+            raise PaddleFlowSDKException("InvalidSession", "session_id should not be none or empty")
+        return UserServiceApi.revoke_session(self.paddleflow_server, name or self.user_id, session_id, self.header)
+
+    def revoke_user_sessions(self, name):
+        """revoke all login sessions of user, including the current one when name is the login user"""
+        self.pre_check()
+        if not name:
+            raise PaddleFlowSDKException("InvalidUser", "name should not be none or empty")
+        return UserServiceApi.revoke_session(self.paddleflow_server, name, None, self.header)
+
+    def list_impersonation_audit(self, operator=None, name=None, maxsize=100, marker=None):
+        """list requests sent by root on behalf of other users, newest first. root is needed"""
+        self.pre_check()
+        return UserServiceApi.list_impersonation_audit(self.paddleflow_server, self.header, operator, name,
+                                                       maxsize, marker)
+
+    def create_project(self, name, description=None, maxResources=None):
+        """create project, root is needed"""
+        self.pre_check()
+        if name is None or name.strip() == "":
+            raise PaddleFlowSDKException("InvalidProjectName", "name should not be none or empty")
+        return ProjectServiceApi.create_project(self.paddleflow_server, name, description, maxResources, self.header)
+
+    def update_project(self, name, description=None, maxResources=None):
+        """update description or quota of project, updating quota needs root"""
+        self.pre_check()
+        if name is None or name.strip() == "":
+            raise PaddleFlowSDKException("InvalidProjectName", "name should not be none or empty")
+        return ProjectServiceApi.update_project(self.paddleflow_server, name, description, maxResources, self.header)
+
+    def delete_project(self, name):
+        """delete project, resources in project are not deleted"""
+        self.pre_check()
+        if name is None or name.strip() == "":
+            raise PaddleFlowSDKException("InvalidProjectName", "name should not be none or empty")
+        return ProjectServiceApi.delete_project(self.paddleflow_server, name, self.header)
+
+    def show_project(self, name):
+        """show project with members and used resources"""
+        self.pre_check()
+        if name is None or name.strip() == "":
+            raise PaddleFlowSDKException("InvalidProjectName", "name should not be none or empty")
+        return ProjectServiceApi.show_project(self.paddleflow_server, name, self.header)
+
+    def list_project(self, maxsize=100, marker=None):
+        """list projects, normal user only sees projects joined"""
+        self.pre_check()
+        return ProjectServiceApi.list_project(self.paddleflow_server, self.header, maxsize, marker)
+
+    def add_project_member(self, name, resource_type, resource_id, role=None):
+        """add user/queue/fs/pipeline to project, role(admin/member) is only for user"""
+        self.pre_check()
+        if resource_type not in ("user", "queue", "fs", "pipeline"):
+            raise PaddleFlowSDKException("InvalidResourceType", "resource_type should be user/queue/fs/pipeline")
+        return ProjectServiceApi.add_member(self.paddleflow_server, name, resource_type, resource_id, role,
+                                            self.header)
+
+    def remove_project_member(self, name, resource_type, resource_id):
+        """remove user/queue/fs/pipeline from project"""
+        self.pre_check()
+        return ProjectServiceApi.remove_member(self.paddleflow_server, name, resource_type, resource_id, self.header)
+
     def add_queue(self, name, namespace, clusterName, maxResources, minResources=None,
-                  schedulingPolicy=None, location=None, quotaType=None):
-        """ add queue"""
+                  schedulingPolicy=None, location=None, quotaType=None, priorityAging=None, burstPolicy=None):
+        """ add queue, priorityAging such as {"interval": 600, "maxPriority": "HIGH"} raises priority of waiting jobs,
+        burstPolicy such as {"queue": "cloud-queue", "waitSeconds": 300} bursts waiting jobs to queue of other cluster"""
         self.pre_check()
         if namespace is None or namespace.strip() == "":
             raise PaddleFlowSDKException("InvalidNameSpace", "namesapce should not be none or empty")
@@ -159,15 +318,18 @@ class Client(object):
                                          "queue maxResources cpu or mem should not be none or empty")
 
         return QueueServiceApi.add_queue(self.paddleflow_server, name, namespace, clusterName, maxResources,
-                                         minResources, schedulingPolicy, location, quotaType, self.header)
+                                         minResources, schedulingPolicy, location, quotaType, self.header,
+                                         priorityAging, burstPolicy)
 
-    def update_queue(self, queuename, maxResources, minResources=None, schedulingPolicy=None, location=None):
-        """ update queue"""
+    def update_queue(self, queuename, maxResources, minResources=None, schedulingPolicy=None, location=None,
+                     priorityAging=None, burstPolicy=None):
+        """ update queue, priorityAging with interval 0 disables priority aging,
+        and burstPolicy with empty queue disables bursting"""
         self.pre_check()
         if queuename is None or queuename.strip() == "":
             raise PaddleFlowSDKException("InvalidQueueName", "queuename should not be none or empty")
         return QueueServiceApi.update_queue(self.paddleflow_server, queuename, maxResources, minResources,
-                                            schedulingPolicy, location, self.header)
+                                            schedulingPolicy, location, self.header, priorityAging, burstPolicy)
 
     def grant_queue(self, username, queuename):
         """ grant queue"""
@@ -201,12 +363,12 @@ class Client(object):
             raise PaddleFlowSDKException("InvalidQueueName", "queuename should not be none or empty")
         return QueueServiceApi.del_queue(self.paddleflow_server, queuename, self.header)
 
-    def list_queue(self, maxsize=100, marker=None):
+    def list_queue(self, maxsize=100, marker=None, project=None):
         """
         list queue
         """
         self.pre_check()
-        return QueueServiceApi.list_queue(self.paddleflow_server, self.header, maxsize, marker)
+        return QueueServiceApi.list_queue(self.paddleflow_server, self.header, maxsize, marker, project)
 
     def show_queue(self, queuename):
         """
@@ -300,7 +462,7 @@ class Client(object):
         userinfo = {'header': self.header, 'name': username, 'host': self.paddleflow_server}
         return FSServiceApi.delete_fs(self.paddleflow_server, fsname, self.user_id, userinfo)
 
-    def list_fs(self, username=None, maxsize=100):
+    def list_fs(self, username=None, maxsize=100, project=None):
         """
         list fs
         """
@@ -308,7 +470,7 @@ class Client(object):
         if username and username.strip() == "":
             raise PaddleFlowSDKException("InvalidUserName", "username should not be none or empty")
         userinfo = {'header': self.header, 'name': username, 'host': self.paddleflow_server}
-        return FSServiceApi.list_fs(self.paddleflow_server, self.user_id, userinfo, maxsize)
+        return FSServiceApi.list_fs(self.paddleflow_server, self.user_id, userinfo, maxsize, project)
 
     def mount(self, fsname, path, mountOptions, username=None):
         """
@@ -373,6 +535,30 @@ class Client(object):
         userinfo = {'header': self.header, 'name': username, 'host': self.paddleflow_server}
         return FSServiceApi.delete_cache(self.paddleflow_server, fsname, userinfo)
 
+    def upload_file(self, fsname, local_path, fs_path, overwrite=False, chunk_size=None, username=None):
+        """
+        upload local file to fs in chunks, call it again to resume an interrupted upload
+        """
+        self.pre_check()
+        if fsname == "" or not fsname:
+            raise PaddleFlowSDKException("InvalidFsName", "fsname should not be none or empty")
+        if not os.path.isfile(local_path):
+            raise PaddleFlowSDKException("InvalidLocalPath", "{} is not a file".format(local_path))
+        userinfo = {'header': self.header, 'name': username, 'host': self.paddleflow_server}
+        return FSServiceApi.upload_file_chunked(self.paddleflow_server, fsname, fs_path, local_path, overwrite,
+                                                chunk_size or DEFAULT_CHUNK_SIZE, userinfo)
+
+    def download_file(self, fsname, fs_path, local_path, chunk_size=None, username=None):
+        """
+        download file of fs, an existing partial local file is resumed
+        """
+        self.pre_check()
+        if fsname == "" or not fsname:
+            raise PaddleFlowSDKException("InvalidFsName", "fsname should not be none or empty")
+        userinfo = {'header': self.header, 'name': username, 'host': self.paddleflow_server}
+        return FSServiceApi.download_file(self.paddleflow_server, fsname, fs_path, local_path,
+                                          chunk_size or DEFAULT_CHUNK_SIZE, userinfo)
+
     def add_link(self, fsname, fspath, url, username=None, properties=None):
         """
         add link
@@ -474,6 +660,26 @@ class Client(object):
             raise PaddleFlowSDKException("InvalidParam", "the Parameter [force] should be an instance of bool")
         return RunServiceApi.stop_run(self.paddleflow_server, run_id, self.header, force)
 
+    def list_run_events(self, run_id):
+        """
+        list events of run and its steps in chronological order
+        """
+        self.pre_check()
+        if run_id is None or run_id.strip() == "":
+            raise PaddleFlowSDKException("InvalidRunID", "runid should not be none or empty")
+        return RunServiceApi.list_run_events(self.paddleflow_server, run_id, self.header)
+
+    def approve_run_job(self, run_id, job_id, approved=True, comment=None):
+        """
+        approve or reject a job waiting for approval
+        """
+        self.pre_check()
+        if run_id is None or run_id.strip() == "":
+            raise PaddleFlowSDKException("InvalidRunID", "runid should not be none or empty")
+        if job_id is None or job_id.strip() == "":
+            raise PaddleFlowSDKException("InvalidJobID", "jobid should not be none or empty")
+        return RunServiceApi.approve_run_job(self.paddleflow_server, run_id, job_id, approved, comment, self.header)
+
     def create_cluster(self, clustername, endpoint, clustertype, credential=None,
                        description=None, source=None, setting=None, status=None, namespacelist=None, version=None):
         """
@@ -545,13 +751,23 @@ class Client(object):
         return PipelineServiceApi.create_pipeline(self.paddleflow_server, fs_name, yaml_path, desc,
                                                   username, self.header)
 
-    def list_pipeline(self, user_filter=None, name_filter=None, max_keys=None, marker=None):
+    def validate_pipeline(self, fs_name=None, yaml_path=None, yaml_raw=None, username=None):
+        """
+        validate pipeline, yaml_raw is base64 encoded and has higher priority than fs_name + yaml_path
+        """
+        self.pre_check()
+        if not yaml_raw and (fs_name is None or fs_name.strip() == ""):
+            raise PaddleFlowSDKException("InvalidRequest", "one of fsname and yaml_raw should be provided")
+        return PipelineServiceApi.validate_pipeline(self.paddleflow_server, fs_name, yaml_path, yaml_raw,
+                                                    username, self.header)
+
+    def list_pipeline(self, user_filter=None, name_filter=None, max_keys=None, marker=None, project=None):
         """
         list pipeline
         """
         self.pre_check()
         return PipelineServiceApi.list_pipeline(self.paddleflow_server, user_filter,
-                                                name_filter, max_keys, marker, self.header)
+                                                name_filter, max_keys, marker, self.header, project)
 
     def show_pipeline(self, pipeline_id, fs_filter=None, max_keys=None, marker=None):
         """
@@ -745,12 +961,56 @@ class Client(object):
             job_request.get('args', None), job_request.get('port', None),
             job_request.get('extensionTemplate', None),
             job_request.get('framework', None),
-            job_request.get('members', None)
+            job_request.get('members', None),
+            self._prepare_code_package(job_request.get('codePackage', None)),
+            job_request.get('schedulingPolicy', {}).get('concurrencyGroup', None),
+            job_request.get('schedulingPolicy', {}).get('concurrencyLimit', None),
+            job_request.get('outputArtifacts', None)
         )
         # if job_request.queue is None or job_request.queue == '':
         #     raise PaddleFlowSDKException("InvalidJobRequest", "job_request queue should not be none or empty")
         return JobServiceApi.create_job(self.paddleflow_server, job_type, job_request_obj, self.header)
 
+    def simulate_job(self, job_type, job_request):
+        """
+        simulate_job reports whether the job would run immediately, wait or be rejected, the job is not created
+        """
+        self.pre_check()
+        if job_type not in ('single', 'distributed', 'serving'):
+            raise PaddleFlowSDKException("InvalidJobType", "job_type should be single, distributed or serving")
+        return JobServiceApi.simulate_job(self.paddleflow_server, job_type, job_request, self.header)
+
+    def _prepare_code_package(self, code_package):
+        """
+        pack code_package['localDir'] into tar.gz and upload it to fs, return codePackage of job request
+        """
+        if not code_package or 'localDir' not in code_package:
+            return code_package
+        local_dir = code_package['localDir']
+        if not os.path.isdir(local_dir):
+            raise PaddleFlowSDKException("InvalidCodePackage", "localDir {} is not a directory".format(local_dir))
+        fs_name = code_package.get('fsName', None)
+        if not fs_name:
+            raise PaddleFlowSDKException("InvalidCodePackage", "fsName of code package should not be none or empty")
+        buf = io.BytesIO()
+        with tarfile.open(fileobj=buf, mode="w:gz") as tar:
+            for name in os.listdir(local_dir):
+                tar.add(os.path.join(local_dir, name), arcname=name)
+        if buf.tell() > self.MAX_CODE_PACKAGE_SIZE:
+            raise PaddleFlowSDKException("InvalidCodePackage",
+                                         "code package of {} exceeds {} bytes".format(local_dir,
+                                                                                      self.MAX_CODE_PACKAGE_SIZE))
+        fs_path = code_package.get('path', None) or "/.paddleflow/code/{}.tar.gz".format(uuid.uuid4().hex)
+        userinfo = {'header': self.header, 'name': code_package.get('username', None),
+                    'host': self.paddleflow_server}
+        ok, msg = FSServiceApi.upload_file(self.paddleflow_server, fs_name, fs_path, buf.getvalue(), True, userinfo)
+        if not ok:
+            raise PaddleFlowSDKException("UploadCodePackageError", msg)
+        request = {'fsName': fs_name, 'path': fs_path}
+        if code_package.get('workDir', None):
+            request['workDir'] = code_package['workDir']
+        return request
+
     def show_job(self, jobid):
         """
         show_job
@@ -761,13 +1021,13 @@ class Client(object):
         return JobServiceApi.show_job(self.paddleflow_server, jobid, self.header)
 
     def list_job(self, status=None, timestamp=None, start_time=None, queue=None, labels=None, maxkeys=None,
-                 marker=None):
+                 marker=None, project=None):
         """
         list_job
         """
         self.pre_check()
         return JobServiceApi.list_job(self.paddleflow_server, status, timestamp, start_time, queue, labels, maxkeys,
-                                      marker, self.header)
+                                      marker, self.header, project)
 
     def update_job(self, jobid, priority=None, labels=None, annotations=None):
         """
@@ -787,6 +1047,24 @@ class Client(object):
             raise PaddleFlowSDKException("InvalidJobID", "jobid should not be none or empty")
         return JobServiceApi.stop_job(self.paddleflow_server, jobid, self.header)
 
+    def approve_job(self, jobid):
+        """
+        approve_job lets job waiting for approval be scheduled, root only
+        """
+        self.pre_check()
+        if jobid is None or jobid == "":
+            raise PaddleFlowSDKException("InvalidJobID", "jobid should not be none or empty")
+        return JobServiceApi.review_job(self.paddleflow_server, jobid, True, self.header)
+
+    def reject_job(self, jobid):
+        """
+        reject_job fails job waiting for approval, root only
+        """
+        self.pre_check()
+        if jobid is None or jobid == "":
+            raise PaddleFlowSDKException("InvalidJobID", "jobid should not be none or empty")
+        return JobServiceApi.review_job(self.paddleflow_server, jobid, False, self.header)
+
     def delete_job(self, jobid):
         """
         delete_job
@@ -796,6 +1074,88 @@ class Client(object):
             raise PaddleFlowSDKException("InvalidJobID", "jobid should not be none or empty")
         return JobServiceApi.delete_job(self.paddleflow_server, jobid, self.header)
 
+    def watch_job(self, jobid, status=None, timeout_seconds=None):
+        """
+        wait until job status differs from status, return latest job info when status changed or timeout
+        """
+        self.pre_check()
+        if jobid is None or jobid == "":
+            raise PaddleFlowSDKException("InvalidJobID", "jobid should not be none or empty")
+        return JobServiceApi.watch_job(self.paddleflow_server, jobid, status, timeout_seconds, self.header)
+
+    def list_job_artifacts(self, jobid):
+        """
+        list output artifacts of job, each artifact has fsName/path/status and manifest of files once collected
+        """
+        self.pre_check()
+        if jobid is None or jobid == "":
+            raise PaddleFlowSDKException("InvalidJobID", "jobid should not be none or empty")
+        return JobServiceApi.list_job_artifacts(self.paddleflow_server, jobid, self.header)
+
+    def wait_job(self, jobid, timeout=None, callback=None):
+        """
+        block until job finished or timeout seconds elapsed, callback(job_info) is called on every status change
+        """
+        deadline = time.time() + timeout if timeout else None
+        status = None
+        while True:
+            wait_seconds = None
+            if deadline is not None:
+                wait_seconds = int(deadline - time.time())
+                if wait_seconds <= 0:
+                    return False, "wait job {} timeout, last status is {}".format(jobid, status)
+            ret, job_info = self.watch_job(jobid, status, wait_seconds)
+            if not ret:
+                return ret, job_info
+            if job_info.status != status:
+                status = job_info.status
+                if callback:
+                    callback(job_info)
+            if status in self.FINAL_JOB_STATUS:
+                return True, job_info
+
+    def stream_job_log(self, jobid, taskid=None, follow=False, tail_lines=None):
+        """
+        stream log of a task of job, return LogStream whose lines() yields log lines
+        """
+        self.pre_check()
+        if jobid is None or jobid == "":
+            raise PaddleFlowSDKException("InvalidJobID", "jobid should not be none or empty")
+        return LogServiceApi.stream_job_log(self.paddleflow_server, jobid, taskid, follow, tail_lines, self.header)
+
+    def stream_step_log(self, runid, step_name, taskid=None, follow=False, tail_lines=None):
+        """
+        stream log of a step of run, the latest job of the step is used, return LogStream whose lines() yields log lines
+        """
+        self.pre_check()
+        if runid is None or runid == "":
+            raise PaddleFlowSDKException("InvalidRunID", "runid should not be none or empty")
+        if step_name is None or step_name == "":
+            raise PaddleFlowSDKException("InvalidStepName", "step name should not be none or empty")
+        return LogServiceApi.stream_step_log(self.paddleflow_server, runid, step_name, taskid, follow, tail_lines,
+                                             self.header)
+
+    def search_job_log(self, pattern, runid=None, labels=None, ignore_case=False, tail_lines=None, max_matches=None):
+        """
+        search logs of jobs in run or selected by labels, return matched lines with job/step/task context
+        """
+        self.pre_check()
+        if pattern is None or pattern == "":
+            raise PaddleFlowSDKException("InvalidPattern", "pattern should not be none or empty")
+        if not runid and not labels:
+            raise PaddleFlowSDKException("InvalidRequest", "one of runid and labels should be provided")
+        return LogServiceApi.search_job_log(self.paddleflow_server, pattern, runid, labels, ignore_case, tail_lines,
+                                            max_matches, self.header)
+
+    def get_job_metrics(self, jobid, metric_keys=None):
+        """
+        get metrics parsed from logs of job, returns a dict from metric key to points with taskID/value/step/timestamp
+        """
+        self.pre_check()
+        if jobid is None or jobid == "":
+            raise PaddleFlowSDKException("InvalidJobID", "jobid should not be none or empty")
+        return LogServiceApi.get_job_metrics(self.paddleflow_server, jobid, metric_keys, self.header)
+
     def get_statistics(self, jobid: str, runid: str = None):
         """
         get_statistics
@@ -805,6 +1165,22 @@ class Client(object):
             raise PaddleFlowSDKException("InvalidJobID", "jobid should not be none or empty")
         return StatisticsServiceApi.get_statistics(self.paddleflow_server, jobid, run_id=runid, header=self.header)
 
+    def job_top(self, queuename: str, sortby: str = None):
+        """
+        get live cpu/gpu/memory usage of running jobs in queue
+        """
+        self.pre_check()
+        if queuename is None or queuename == "":
+            raise PaddleFlowSDKException("InvalidQueueName", "queuename should not be none or empty")
+        return StatisticsServiceApi.get_queue_job_top(self.paddleflow_server, queuename, sortby, header=self.header)
+
+    def get_cost_center_report(self, month: str = None):
+        """
+        get monthly gpu hours of jobs grouped by cost center label
+        """
+        self.pre_check()
+        return StatisticsServiceApi.get_cost_center_report(self.paddleflow_server, month, header=self.header)
+
     def get_statistics_detail(self, jobid: str, start: int = None, end: int = None, step: int = None,
                               runid: str = None) :
         """
@@ -23,6 +23,8 @@ PADDLE_FLOW_LOGIN = '/api/paddleflow/v%d/login' % PADDLE_FLOW_VERSION
 PADDLE_FLOW_USER = '/api/paddleflow/v%d/user' % PADDLE_FLOW_VERSION
 PADDLE_FLOW_QUEUE = '/api/paddleflow/v%d/queue' % PADDLE_FLOW_VERSION
 PADDLE_FLOW_GRANT = '/api/paddleflow/v%d/grant' % PADDLE_FLOW_VERSION
+PADDLE_FLOW_PROJECT = '/api/paddleflow/v%d/project' % PADDLE_FLOW_VERSION
+PADDLE_FLOW_IMPERSONATION_AUDIT = '/api/paddleflow/v%d/impersonation/audit' % PADDLE_FLOW_VERSION
 PADDLE_FLOW_FS = '/api/paddleflow/v%d/fs' % FS_SERVER_VERSION
 PADDLE_FLOW_FS_CACHE = '/api/paddleflow/v%d/fsCache' % FS_SERVER_VERSION
 PADDLE_FLOW_RUN = '/api/paddleflow/v%d/run' % PADDLE_FLOW_VERSION
@@ -34,6 +36,8 @@ PADDLE_FLOW_ARTIFACT = '/api/paddleflow/v%d/artifact' % PADDLE_FLOW_VERSION
 PADDLE_FLOW_SCHEDULE = '/api/paddleflow/v%d/schedule' % PADDLE_FLOW_VERSION
 PADDLE_FLOW_FLAVOUR = '/api/paddleflow/v%d/flavour' % PADDLE_FLOW_VERSION
 PADDLE_FLOW_LOG = '/api/paddleflow/v%d/log/run' % PADDLE_FLOW_VERSION
+PADDLE_FLOW_JOB_LOG = '/api/paddleflow/v%d/log/job' % PADDLE_FLOW_VERSION
+PADDLE_FLOW_LOG_SEARCH = '/api/paddleflow/v%d/log/search' % PADDLE_FLOW_VERSION
 PADDLE_FLOW_JOB = '/api/paddleflow/v%d/job' % PADDLE_FLOW_VERSION
 PADDLE_FLOW_STATISTIC = '/api/paddleflow/v%d/statistics' % PADDLE_FLOW_VERSION
 PADDLE_FLOW_SERVER_VERSION = '/api/paddleflow/v%d/version' % PADDLE_FLOW_VERSION
\ No newline at end of file
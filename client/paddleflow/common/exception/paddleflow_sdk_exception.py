@@ -18,13 +18,28 @@ limitations under the License.
 
 import sys
 
+# 服务端返回的常用错误码
+ACCESS_DENIED = "AccessDenied"
+ACTION_NOT_ALLOWED = "ActionNotAllowed"
+INTERNAL_ERROR = "InternalError"
+RECORD_NOT_FOUND = "RecordNotFound"
+DUPLICATED_NAME = "DuplicatedName"
+INVALID_ARGUMENTS = "InvalidArguments"
+JOB_NOT_FOUND = "JobNotFound"
+PATH_NOT_FOUND = "PathNotFound"
+RESOURCE_CONFLICT = "ResourceConflict"
+SERVICE_UNAVAILABLE = "ServiceUnavailable"
+
+
 class PaddleFlowSDKException(Exception):
     """paddleflowapi sdk 异常类"""
 
-    def __init__(self, code=None, message=None, requestId=None):
+    def __init__(self, code=None, message=None, requestId=None, details=None, retriable=False):
         self.code = code
         self.message = message
         self.requestId = requestId
+        self.details = details or {}
+        self.retriable = retriable
 
     def __str__(self):
         s = "[PaddleFlowSDKException] code:%s message:%s requestId:%s" % (
@@ -41,4 +56,12 @@ class PaddleFlowSDKException(Exception):
 
     def get_request_id(self):
         """get request_id"""
-        return self.requestId
\ No newline at end of file
+        return self.requestId
+
+    def get_details(self):
+        """get details"""
+        return self.details
+
+    def is_retriable(self):
+        """whether the request can be retried as is"""
+        return self.retriable
@@ -21,12 +21,16 @@ import os
 import time
 import subprocess
 from urllib import parse
-from paddleflow.common.exception.paddleflow_sdk_exception import PaddleFlowSDKException
+from paddleflow.common.exception.paddleflow_sdk_exception import PaddleFlowSDKException, PATH_NOT_FOUND
 from paddleflow.utils import api_client
 from paddleflow.common import api
 from paddleflow.fs.fs_info import FSInfo, LinkInfo, CacheConfigInfo
 import signal
 
+# 服务端单个分片的大小上限为32MiB
+MAX_CHUNK_SIZE = 32 * 1024 * 1024
+DEFAULT_CHUNK_SIZE = 8 * 1024 * 1024
+
 
 def callback_func_mount_time_out(*args):
     """
@@ -148,7 +152,7 @@ class FSServiceApi(object):
         return True, None
 
     @classmethod
-    def list_fs(self, host, userid, userinfo={'header': '', 'name': '', 'host': ''}, maxsize=100):
+    def list_fs(self, host, userid, userinfo={'header': '', 'name': '', 'host': ''}, maxsize=100, project=None):
         """
         list fs
         """
@@ -161,6 +165,8 @@ class FSServiceApi(object):
         }
         if userinfo['name']:
             params['username'] = userinfo['name']
+        if project:
+            params['project'] = project
         response = api_client.call_api(method="GET", url=parse.urljoin(host, api.PADDLE_FLOW_FS),
                                        headers=userinfo['header'], params=params)
         if not response:
@@ -427,6 +433,129 @@ class FSServiceApi(object):
             return False, "no link found"
         return True, linkList
 
+    @classmethod
+    def upload_file(self, host, fsname, fspath, content, overwrite=False,
+                    userinfo={'header': '', 'name': '', 'host': ''}):
+        """
+        upload file content to fspath of fs
+        """
+        if not userinfo['header']:
+            raise PaddleFlowSDKException("Invalid request", "please login paddleflow first")
+        params = {
+            "path": fspath,
+            "overwrite": "true" if overwrite else "false",
+        }
+        if userinfo['name']:
+            params['username'] = userinfo['name']
+        response = api_client.call_api(method="POST",
+                                       url=parse.urljoin(host, api.PADDLE_FLOW_FS + "/%s/files/upload" % fsname),
+                                       headers=userinfo['header'], params=params, data=content)
+        if not response:
+            raise PaddleFlowSDKException("Upload file error", response.text)
+        data = json.loads(response.text) if response.text else {}
+        if 'message' in data:
+            return False, data['message']
+        return True, fspath
+
+    @classmethod
+    def stat_file(self, host, fsname, fspath, userinfo={'header': '', 'name': '', 'host': ''}):
+        """
+        stat file or directory of fs, return None when fspath not exist
+        """
+        if not userinfo['header']:
+            raise PaddleFlowSDKException("Invalid request", "please login paddleflow first")
+        params = {"path": fspath}
+        if userinfo['name']:
+            params['username'] = userinfo['name']
+        try:
+            response = api_client.call_api(method="GET",
+                                           url=parse.urljoin(host, api.PADDLE_FLOW_FS + "/%s/files/stat" % fsname),
+                                           headers=userinfo['header'], params=params)
+        except PaddleFlowSDKException as e:
+            if e.get_code() == PATH_NOT_FOUND:
+                return True, None
+            raise
+        if not response:
+            raise PaddleFlowSDKException("Stat file error", "stat file failed due to connection error")
+        return True, json.loads(response.text)
+
+    @classmethod
+    def upload_file_chunked(self, host, fsname, fspath, local_path, overwrite=False, chunk_size=DEFAULT_CHUNK_SIZE,
+                            userinfo={'header': '', 'name': '', 'host': ''}):
+        """
+        upload local file to fspath of fs in chunks, parts already uploaded are skipped so an
+        interrupted upload can be resumed by calling it again
+        """
+        if not userinfo['header']:
+            raise PaddleFlowSDKException("Invalid request", "please login paddleflow first")
+        if chunk_size <= 0 or chunk_size > MAX_CHUNK_SIZE:
+            raise PaddleFlowSDKException("InvalidChunkSize", "chunk_size should be in (0, {}]".format(MAX_CHUNK_SIZE))
+        params = {"path": fspath}
+        if userinfo['name']:
+            params['username'] = userinfo['name']
+        url = parse.urljoin(host, api.PADDLE_FLOW_FS + "/%s/files/parts" % fsname)
+        response = api_client.call_api(method="GET", url=url, headers=userinfo['header'], params=params)
+        if not response:
+            raise PaddleFlowSDKException("Upload file error", "list uploaded parts failed due to connection error")
+        uploaded = {part['partNumber']: part['size'] for part in json.loads(response.text)['parts']}
+
+        file_size = os.path.getsize(local_path)
+        parts = max(1, (file_size + chunk_size - 1) // chunk_size)
+        with open(local_path, 'rb') as f:
+            for part_number in range(1, parts + 1):
+                expected = min(chunk_size, file_size - (part_number - 1) * chunk_size)
+                if uploaded.get(part_number) == expected:
+                    continue
+                f.seek((part_number - 1) * chunk_size)
+                part_params = dict(params, partNumber=part_number)
+                response = api_client.call_api(method="PUT", url=url, headers=userinfo['header'],
+                                               params=part_params, data=f.read(expected))
+                if not response:
+                    raise PaddleFlowSDKException("Upload file error",
+                                                 "upload part {} failed due to connection error".format(part_number))
+
+        complete_params = dict(params, parts=parts, overwrite="true" if overwrite else "false")
+        response = api_client.call_api(method="POST", url=url + "/complete", headers=userinfo['header'],
+                                       params=complete_params)
+        if not response:
+            raise PaddleFlowSDKException("Upload file error", "complete upload failed due to connection error")
+        return True, fspath
+
+    @classmethod
+    def download_file(self, host, fsname, fspath, local_path, chunk_size=DEFAULT_CHUNK_SIZE,
+                      userinfo={'header': '', 'name': '', 'host': ''}):
+        """
+        download file of fs to local_path, an existing smaller local file is treated as an
+        interrupted download and continued from its size
+        """
+        if not userinfo['header']:
+            raise PaddleFlowSDKException("Invalid request", "please login paddleflow first")
+        _, info = self.stat_file(host, fsname, fspath, userinfo)
+        if info is None:
+            return False, "path {} not exist".format(fspath)
+        if info['isDir']:
+            return False, "path {} is a directory".format(fspath)
+        offset = os.path.getsize(local_path) if os.path.exists(local_path) else 0
+        if offset > info['size']:
+            offset = 0
+        params = {"path": fspath}
+        if userinfo['name']:
+            params['username'] = userinfo['name']
+        url = parse.urljoin(host, api.PADDLE_FLOW_FS + "/%s/files/download" % fsname)
+        with open(local_path, 'ab' if offset > 0 else 'wb') as f:
+            while offset < info['size']:
+                part_params = dict(params, offset=offset, length=chunk_size)
+                response = api_client.call_api(method="GET", url=url, headers=userinfo['header'],
+                                               params=part_params)
+                if not response:
+                    raise PaddleFlowSDKException("Download file error",
+                                                 "download from offset {} failed due to connection error".format(offset))
+                if not response.content:
+                    break
+                f.write(response.content)
+                offset += len(response.content)
+        return True, local_path
+
     @classmethod
     def getMountOptions(self, mount_options):
         """
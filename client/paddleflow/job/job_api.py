@@ -23,6 +23,9 @@ from paddleflow.common.exception import PaddleFlowSDKException
 from paddleflow.job.job_info import JobInfo, Member
 from paddleflow.utils import api_client
 
+# 服务端单次watch最长等待60秒
+WATCH_REQUEST_TIMEOUT = 90
+
 
 class JobServiceApi(object):
     """
@@ -49,8 +52,16 @@ class JobServiceApi(object):
             raise PaddleFlowSDKException("InvalidRequest", "paddleflow should login first")
         body = {}
         cls.convert_to_job_spec_body(body, job_request)
+        if job_request.concurrency_group:
+            body['schedulingPolicy']['concurrencyGroup'] = job_request.concurrency_group
+        if job_request.concurrency_limit:
+            body['schedulingPolicy']['concurrencyLimit'] = job_request.concurrency_limit
         if job_request.framework:
             body['framework'] = job_request.framework
+        if job_request.code_package:
+            body['codePackage'] = job_request.code_package
+        if job_request.output_artifacts:
+            body['outputArtifacts'] = job_request.output_artifacts
         if job_request.member_list:
             body['members'] = list()
             for member in job_request.member_list:
@@ -67,6 +78,11 @@ class JobServiceApi(object):
                                                                  member.get('env', None), member.get('command', None),
                                                                  member.get('args', None), member.get('port', None),
                                                                  member.get('extensionTemplate', None)))
+                # 代码包对所有成员生效
+                if job_request.code_package:
+                    member_dict['codePackage'] = job_request.code_package
+                if member.get('outputArtifacts', None):
+                    member_dict['outputArtifacts'] = member['outputArtifacts']
                 body['members'].append(member_dict)
         response = api_client.call_api(method="POST",
                                        url=parse.urljoin(
@@ -80,6 +96,29 @@ class JobServiceApi(object):
             return False, data['message']
         return True, data['id']
 
+    @classmethod
+    def simulate_job(cls, host, job_type, job_request, header=None):
+        """
+        simulate scheduling of job against current queue state without creating it
+        :param host:
+        :param job_type: single, distributed or serving
+        :param job_request: dict of job request, same as create job
+        :param header:
+        :return: result, reasons, estimatedWaitTime, etc.
+        """
+        if not header:
+            raise PaddleFlowSDKException("InvalidRequest", "paddleflow should login first")
+        response = api_client.call_api(method="POST",
+                                       url=parse.urljoin(host, api.PADDLE_FLOW_JOB + "/simulate"),
+                                       headers=header, params={"jobType": job_type},
+                                       json=job_request)
+        if not response:
+            raise PaddleFlowSDKException("Simulate job error", response.text)
+        data = json.loads(response.text)
+        if 'message' in data:
+            return False, data['message']
+        return True, data
+
     @classmethod
     def convert_to_job_spec_body(cls, body, job_request):
         body['schedulingPolicy'] = dict()
@@ -160,6 +199,52 @@ class JobServiceApi(object):
         data = json.loads(response.text)
         if 'message' in data and response.status_code != 200:
             return False, data['message']
+        return True, cls._to_job_info(data)
+
+    @classmethod
+    def watch_job(cls, host, job_id, status=None, timeout_seconds=None, header=None):
+        """
+        wait until job status differs from status or timeout_seconds elapsed, return latest job info
+        """
+        if not header:
+            raise PaddleFlowSDKException("InvalidRequest", "paddleflow should login first")
+        params = {}
+        if status:
+            params['status'] = status
+        if timeout_seconds:
+            params['timeoutSeconds'] = timeout_seconds
+        response = api_client.call_api(method="GET",
+                                       url=parse.urljoin(host, api.PADDLE_FLOW_JOB + "/%s/watch" % job_id),
+                                       headers=header, params=params, timeout=WATCH_REQUEST_TIMEOUT)
+        if not response:
+            raise PaddleFlowSDKException("Watch job error", "watch job failed due to connection error")
+        data = json.loads(response.text)
+        if 'message' in data and response.status_code != 200:
+            return False, data['message']
+        return True, cls._to_job_info(data)
+
+    @classmethod
+    def list_job_artifacts(cls, host, job_id, header=None):
+        """
+        list output artifacts of job
+        """
+        if not header:
+            raise PaddleFlowSDKException("InvalidRequest", "paddleflow should login first")
+        response = api_client.call_api(method="GET",
+                                       url=parse.urljoin(host, api.PADDLE_FLOW_JOB + "/%s/artifacts" % job_id),
+                                       headers=header)
+        if not response:
+            raise PaddleFlowSDKException("List job artifacts error", response.text)
+        data = json.loads(response.text)
+        if 'message' in data and response.status_code != 200:
+            return False, data['message']
+        return True, data['artifacts']
+
+    @classmethod
+    def _to_job_info(cls, data):
+        """
+        convert job response to JobInfo
+        """
         priority = None
         if 'priority' in data['schedulingPolicy']:
             priority = data['schedulingPolicy']['priority']
@@ -186,11 +271,15 @@ class JobServiceApi(object):
                            extension_template=data['extensionTemplate'], framework=framework, member_list=members,
                            status=data['status'], message=data['message'], accept_time=data['acceptTime'],
                            start_time=data['startTime'], finish_time=data['finishTime'], runtime=runtime,
-                           distributed_runtime=distributed_runtime, workflow_runtime=workflow_runtime)
-        return True, job_info
+                           distributed_runtime=distributed_runtime, workflow_runtime=workflow_runtime,
+                           effective_priority=data.get('effectivePriority'),
+                           requeue_times=data.get('requeueTimes'), requeue_reason=data.get('requeueReason'),
+                           burst_from_queue=data.get('burstFromQueue'))
+        return job_info
 
     @classmethod
-    def list_job(cls, host, status, timestamp, start_time, queue, labels, maxsize=100, marker=None, header=None):
+    def list_job(cls, host, status, timestamp, start_time, queue, labels, maxsize=100, marker=None, header=None,
+                 project=None):
         """
 
         :param host:
@@ -202,11 +291,14 @@ class JobServiceApi(object):
         :param maxsize:
         :param marker:
         :param header:
+        :param project:
         :return:
         """
         if not header:
             raise PaddleFlowSDKException("InvalidRequest", "paddleflow should login first")
         params = {}
+        if project is not None:
+            params['project'] = project
         if status is not None:
             params['status'] = status
         if timestamp is not None:
@@ -309,6 +401,30 @@ class JobServiceApi(object):
             return False, data['message']
         return True, None
 
+    @classmethod
+    def review_job(cls, host, job_id, approve, header=None):
+        """
+        approve or reject job waiting for approval, root only
+        :param host:
+        :param job_id:
+        :param approve: True to approve job, False to reject it
+        :param header:
+        :return:
+        """
+        if not header:
+            raise PaddleFlowSDKException("InvalidRequest", "paddleflow should login first")
+        params = {'action': 'approve' if approve else 'reject'}
+        response = api_client.call_api(method="PUT", url=parse.urljoin(host, api.PADDLE_FLOW_JOB + "/%s" % job_id),
+                                       headers=header, params=params)
+        if not response:
+            raise PaddleFlowSDKException("Review job error", response.text)
+        if not response.text:
+            return True, None
+        data = json.loads(response.text)
+        if 'message' in data:
+            return False, data['message']
+        return True, None
+
     @classmethod
     def stop_job(cls, host, job_id, header=None):
         """
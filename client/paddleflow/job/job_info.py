@@ -24,7 +24,9 @@ class JobInfo(object):
 
     def __init__(self, job_id, job_name, labels, annotations, username, queue, priority, flavour, fs, extra_fs_list,
                  image, env, command, args_list, port, extension_template, framework, member_list, status, message,
-                 accept_time, start_time, finish_time, runtime, distributed_runtime, workflow_runtime):
+                 accept_time, start_time, finish_time, runtime, distributed_runtime, workflow_runtime,
+                 effective_priority=None, requeue_times=None, requeue_reason=None,
+                 burst_from_queue=None):
         """
 
         :param job_id:
@@ -53,6 +55,10 @@ class JobInfo(object):
         :param runtime:
         :param distributed_runtime:
         :param workflow_runtime:
+        :param effective_priority: the priority after aging by queue
+        :param requeue_times: times of the job requeued due to node failure
+        :param requeue_reason: node failure reason of the last requeue
+        :param burst_from_queue: the queue which the job is burst from to other cluster
         """
         self.job_id = job_id
         self.job_name = job_name
@@ -80,6 +86,10 @@ class JobInfo(object):
         self.runtime = runtime
         self.distributed_runtime = distributed_runtime
         self.workflow_runtime = workflow_runtime
+        self.effective_priority = effective_priority
+        self.requeue_times = requeue_times
+        self.requeue_reason = requeue_reason
+        self.burst_from_queue = burst_from_queue
 
 
 class JobRequest(object):
@@ -89,7 +99,8 @@ class JobRequest(object):
 
     def __init__(self, queue, image=None, job_id=None, job_name=None, labels=None, annotations=None, priority=None,
                  flavour=None, fs=None, extra_fs_list=None, env=None, command=None, args_list=None, port=None,
-                 extension_template=None, framework=None, member_list=None):
+                 extension_template=None, framework=None, member_list=None, code_package=None,
+                 concurrency_group=None, concurrency_limit=None, output_artifacts=None):
         """
 
         :param queue:
@@ -109,6 +120,10 @@ class JobRequest(object):
         :param extension_template:
         :param framework:
         :param member_list:
+        :param code_package:
+        :param concurrency_group:
+        :param concurrency_limit:
+        :param output_artifacts: list of {'name', 'fsName', 'path'}, collected after job succeeded
         """
         self.job_id = job_id
         self.job_name = job_name
@@ -127,6 +142,10 @@ class JobRequest(object):
         self.extension_template = extension_template
         self.framework = framework
         self.member_list = member_list
+        self.code_package = code_package
+        self.concurrency_group = concurrency_group
+        self.concurrency_limit = concurrency_limit
+        self.output_artifacts = output_artifacts
 
 
 class Member(object):
@@ -17,4 +17,4 @@ limitations under the License.
 # -*- coding:utf8 -*-
 
 from .log_api import LogServiceApi
-from .log_info import LogInfo
\ No newline at end of file
+from .log_info import LogInfo, LogMatch
\ No newline at end of file
@@ -21,11 +21,13 @@ from urllib import parse
 
 from paddleflow.common import api
 from paddleflow.common.exception.paddleflow_sdk_exception import PaddleFlowSDKException
-from paddleflow.log.log_info import LogInfo
+from paddleflow.log.log_info import LogInfo, LogMatch, LogStream
 from paddleflow.utils import api_client
 
 DEFAULT_PAGESIZE = 100
 DEFAULT_PAGENO = 1
+TASK_ID_HEADER = 'X-PF-Task-ID'
+JOB_ID_HEADER = 'X-PF-Job-ID'
 
 class LogServiceApi(object):
     """
@@ -76,4 +78,83 @@ class LogServiceApi(object):
         log_info_dict = {'submitLog': data['submitLog'], 'runLog': loginfo_list}
         return True, log_info_dict
 
+    @classmethod
+    def stream_job_log(self, host, jobid, taskid=None, follow=False, tail_lines=None, header=None):
+        """ stream log of a task of job, follow keeps the stream open until the task exits
+        """
+        if not header:
+            raise PaddleFlowSDKException("InvalidRequest", "paddleflow should login first")
+        return self._stream_log(api.PADDLE_FLOW_JOB_LOG + "/%s/stream" % jobid, host, taskid, follow, tail_lines,
+                                header)
+
+    @classmethod
+    def stream_step_log(self, host, runid, step_name, taskid=None, follow=False, tail_lines=None, header=None):
+        """ stream log of a step of run, the latest job of the step is used
+        """
+        if not header:
+            raise PaddleFlowSDKException("InvalidRequest", "paddleflow should login first")
+        return self._stream_log(api.PADDLE_FLOW_LOG + "/%s/step/%s/stream" % (runid, step_name), host, taskid,
+                                follow, tail_lines, header)
+
+    @classmethod
+    def _stream_log(self, path, host, taskid, follow, tail_lines, header):
+        params = {'follow': 'true' if follow else 'false'}
+        if taskid:
+            params['taskID'] = taskid
+        if tail_lines:
+            params['tailLines'] = tail_lines
+        # follow时日志可能长时间没有输出，不设置读超时
+        response = api_client.call_api(method="GET", url=parse.urljoin(host, path),
+                                       headers=header, params=params, stream=True,
+                                       timeout=(10, None) if follow else 60)
+        if not response:
+            raise PaddleFlowSDKException("Connection Error", "stream log failed due to connection error")
+        return True, LogStream(response.headers.get(JOB_ID_HEADER), response.headers.get(TASK_ID_HEADER), response)
 
+    @classmethod
+    def search_job_log(self, host, pattern, runid=None, labels=None, ignore_case=False, tail_lines=None,
+                       max_matches=None, header=None):
+        """ search logs of jobs in run or selected by labels for pattern
+        """
+        if not header:
+            raise PaddleFlowSDKException("InvalidRequest", "paddleflow should login first")
+        body = {'pattern': pattern, 'ignoreCase': ignore_case}
+        if runid:
+            body['runID'] = runid
+        if labels:
+            body['labels'] = labels
+        if tail_lines:
+            body['tailLines'] = int(tail_lines)
+        if max_matches:
+            body['maxMatches'] = int(max_matches)
+        response = api_client.call_api(method="POST", url=parse.urljoin(host, api.PADDLE_FLOW_LOG_SEARCH),
+                                       headers=header, json=body)
+        if not response:
+            raise PaddleFlowSDKException("Connection Error", "search log failed due to HTTPError")
+        data = json.loads(response.text)
+        if 'message' in data:
+            return False, data['message']
+        matches = [LogMatch(jobid=m['jobID'], job_name=m.get('jobName', ''), step_name=m.get('stepName', ''),
+                            taskid=m['taskID'], line_no=m['lineNo'], line=m['line']) for m in data['matches']]
+        result = {'matches': matches, 'truncated': data['truncated'], 'searchedTasks': data['searchedTasks'],
+                  'failedTasks': data.get('failedTasks') or {}}
+        return True, result
+
+    @classmethod
+    def get_job_metrics(self, host, jobid, metric_keys=None, header=None):
+        """ get metrics parsed from "PF_METRIC key=value step=n" lines in logs of job
+        """
+        if not header:
+            raise PaddleFlowSDKException("InvalidRequest", "paddleflow should login first")
+        params = {}
+        if metric_keys:
+            params['metricKeys'] = ",".join(metric_keys)
+        response = api_client.call_api(method="GET",
+                                       url=parse.urljoin(host, api.PADDLE_FLOW_JOB_LOG + "/%s/metrics" % jobid),
+                                       headers=header, params=params)
+        if not response:
+            raise PaddleFlowSDKException("Connection Error", "get job metrics failed due to HTTPError")
+        data = json.loads(response.text)
+        if 'message' in data:
+            return False, data['message']
+        return True, data['metrics']
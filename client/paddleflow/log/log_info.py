@@ -39,3 +39,43 @@ class LogInfo(object):
         self.pageno = pageno
         # 具体的日志内容
         self.log_content = log_content
+
+
+class LogMatch(object):
+
+    """the class of a log line matched by log search"""
+
+    def __init__(self, jobid, job_name, step_name, taskid, line_no, line):
+        """init """
+        self.jobid = jobid
+        self.job_name = job_name
+        # 作业所属的工作流节点，非工作流作业为空
+        self.step_name = step_name
+        self.taskid = taskid
+        # 匹配行在任务日志中的行号
+        self.line_no = line_no
+        self.line = line
+
+
+class LogStream(object):
+
+    """the class of streaming log of a job task"""
+
+    def __init__(self, jobid, taskid, response):
+        """init """
+        self.jobid = jobid
+        # 日志所属的task，即pod名称
+        self.taskid = taskid
+        self._response = response
+
+    def lines(self):
+        """iterate log lines until the stream ends"""
+        try:
+            for line in self._response.iter_lines(decode_unicode=True):
+                yield line
+        finally:
+            self.close()
+
+    def close(self):
+        """close the stream"""
+        self._response.close()
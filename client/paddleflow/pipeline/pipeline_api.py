@@ -61,9 +61,41 @@ class PipelineServiceApi(object):
             return False, data['message']
         return True, {'name': data['name'], 'pplID': data['pipelineID'], 'pplVerID': data['pipelineVersionID']}
 
+    @classmethod
+    def validate_pipeline(self, host, fs_name=None, yaml_path=None, yaml_raw=None, username=None, header=None):
+        """
+            validate pipeline yaml without creating it
+            this method returns whether the yaml is valid and all errors found
+        """
+        if not header:
+            raise PaddleFlowSDKException("InvalidRequest",
+                                         "paddleflow should login first")
+        body = {}
+        if fs_name:
+            body['fsName'] = fs_name
+        if yaml_path:
+            body['yamlPath'] = yaml_path
+        if yaml_raw:
+            body['yamlRaw'] = yaml_raw
+        if username:
+            body['username'] = username
+
+        response = api_client.call_api(method="POST",
+                                       url=parse.urljoin(
+                                           host, api.PADDLE_FLOW_PIPELINE + "/validate"),
+                                       headers=header,
+                                       json=body)
+        if not response:
+            raise PaddleFlowSDKException(
+                "Connection Error", "validate pipeline failed due to HTTPError")
+        data = json.loads(response.text)
+        if 'message' in data:
+            return False, data['message']
+        return True, {'valid': data['valid'], 'name': data['name'], 'errors': data['errors']}
+
     @classmethod
     def list_pipeline(self, host, user_filter=None, name_filter=None, max_keys=None,
-                      marker=None, header=None):
+                      marker=None, header=None, project=None):
         """list pipeline
         """
         if not header:
@@ -78,6 +110,8 @@ class PipelineServiceApi(object):
             params['maxKeys'] = max_keys
         if marker:
             params['marker'] = marker
+        if project:
+            params['project'] = project
         response = api_client.call_api(method="GET",
                                        url=parse.urljoin(
                                            host, api.PADDLE_FLOW_PIPELINE),
@@ -0,0 +1,179 @@
+"""
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+"""
+
+#!/usr/bin/env python3
+# -*- coding:utf8 -*-
+
+import json
+from urllib import parse
+from paddleflow.common.exception.paddleflow_sdk_exception import PaddleFlowSDKException
+from paddleflow.utils import api_client
+from paddleflow.common import api
+from paddleflow.project.project_info import ProjectInfo, ProjectMemberInfo
+
+
+def _to_project_info(data):
+    """convert response of server to ProjectInfo"""
+    members = [ProjectMemberInfo(m['resourceType'], m['resourceID'], m.get('role', ''), m['createTime'])
+               for m in (data.get('members') or [])]
+    return ProjectInfo(data['name'], data.get('description', ''), data.get('maxResources'),
+                       data['createTime'], data['updateTime'], data.get('usedResources'), members)
+
+
+class ProjectServiceApi(object):
+    """project service api"""
+
+    def __init__(self):
+        """
+        """
+
+    @classmethod
+    def create_project(self, host, name, description=None, maxResources=None, header=None):
+        """call create project api"""
+        if not header:
+            raise PaddleFlowSDKException("InvalidRequest", "paddleflow should login first")
+        body = {
+            "name": name,
+        }
+        if description:
+            body['description'] = description
+        if maxResources:
+            body['maxResources'] = maxResources
+        response = api_client.call_api(method="POST", url=parse.urljoin(host, api.PADDLE_FLOW_PROJECT),
+                                       headers=header, json=body)
+        if not response:
+            raise PaddleFlowSDKException("Connection Error", "create project failed due to HTTPError")
+        data = json.loads(response.text)
+        if 'message' in data:
+            return False, data['message']
+        return True, data['projectName']
+
+    @classmethod
+    def update_project(self, host, name, description=None, maxResources=None, header=None):
+        """call update project api"""
+        if not header:
+            raise PaddleFlowSDKException("InvalidRequest", "paddleflow should login first")
+        body = {}
+        if description:
+            body['description'] = description
+        if maxResources:
+            body['maxResources'] = maxResources
+        response = api_client.call_api(method="PUT", url=parse.urljoin(host, api.PADDLE_FLOW_PROJECT + "/%s" % name),
+                                       headers=header, json=body)
+        if not response:
+            raise PaddleFlowSDKException("Connection Error", "update project failed due to HTTPError")
+        if not response.text:
+            return True, None
+        data = json.loads(response.text)
+        if data and 'message' in data:
+            return False, data['message']
+        return True, None
+
+    @classmethod
+    def delete_project(self, host, name, header=None):
+        """call delete project api"""
+        if not header:
+            raise PaddleFlowSDKException("InvalidRequest", "paddleflow should login first")
+        response = api_client.call_api(method="DELETE",
+                                       url=parse.urljoin(host, api.PADDLE_FLOW_PROJECT + "/%s" % name),
+                                       headers=header)
+        if not response:
+            raise PaddleFlowSDKException("Connection Error", "delete project failed due to HTTPError")
+        if not response.text:
+            return True, None
+        data = json.loads(response.text)
+        if data and 'message' in data:
+            return False, data['message']
+        return True, None
+
+    @classmethod
+    def show_project(self, host, name, header=None):
+        """call get project api"""
+        if not header:
+            raise PaddleFlowSDKException("InvalidRequest", "paddleflow should login first")
+        response = api_client.call_api(method="GET", url=parse.urljoin(host, api.PADDLE_FLOW_PROJECT + "/%s" % name),
+                                       headers=header)
+        if not response:
+            raise PaddleFlowSDKException("Connection Error", "show project failed due to HTTPError")
+        data = json.loads(response.text)
+        if 'message' in data:
+            return False, data['message']
+        return True, _to_project_info(data)
+
+    @classmethod
+    def list_project(self, host, header=None, maxsize=100, marker=None):
+        """call list project api"""
+        if not header:
+            raise PaddleFlowSDKException("InvalidRequest", "paddleflow should login first")
+        if not isinstance(maxsize, int) or maxsize <= 0:
+            raise PaddleFlowSDKException("InvalidRequest", "maxsize should be int and greater than 0")
+        params = {
+            "maxKeys": maxsize
+        }
+        if marker:
+            params['marker'] = marker
+        response = api_client.call_api(method="GET", url=parse.urljoin(host, api.PADDLE_FLOW_PROJECT),
+                                       headers=header, params=params)
+        if not response:
+            raise PaddleFlowSDKException("Connection Error", "list project failed due to HTTPError")
+        data = json.loads(response.text)
+        if 'message' in data:
+            return False, data['message']
+        projectList = [_to_project_info(p) for p in (data.get('projectList') or [])]
+        return True, projectList, data.get('nextMarker', None)
+
+    @classmethod
+    def add_member(self, host, name, resource_type, resource_id, role=None, header=None):
+        """call add project member api"""
+        if not header:
+            raise PaddleFlowSDKException("InvalidRequest", "paddleflow should login first")
+        body = {
+            "resourceType": resource_type,
+            "resourceID": resource_id,
+        }
+        if role:
+            body['role'] = role
+        response = api_client.call_api(method="POST",
+                                       url=parse.urljoin(host, api.PADDLE_FLOW_PROJECT + "/%s/member" % name),
+                                       headers=header, json=body)
+        if not response:
+            raise PaddleFlowSDKException("Connection Error", "add project member failed due to HTTPError")
+        if not response.text:
+            return True, None
+        data = json.loads(response.text)
+        if data and 'message' in data:
+            return False, data['message']
+        return True, None
+
+    @classmethod
+    def remove_member(self, host, name, resource_type, resource_id, header=None):
+        """call remove project member api"""
+        if not header:
+            raise PaddleFlowSDKException("InvalidRequest", "paddleflow should login first")
+        params = {
+            "resourceType": resource_type,
+            "resourceID": resource_id,
+        }
+        response = api_client.call_api(method="DELETE",
+                                       url=parse.urljoin(host, api.PADDLE_FLOW_PROJECT + "/%s/member" % name),
+                                       headers=header, params=params)
+        if not response:
+            raise PaddleFlowSDKException("Connection Error", "remove project member failed due to HTTPError")
+        if not response.text:
+            return True, None
+        data = json.loads(response.text)
+        if data and 'message' in data:
+            return False, data['message']
+        return True, None
@@ -0,0 +1,41 @@
+"""
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+"""
+
+#!/usr/bin/env python3
+# -*- coding:utf8 -*-
+
+class ProjectInfo(object):
+    """the class of project info"""
+
+    def __init__(self, name, description, maxResources, createTime, updateTime, usedResources=None, members=None):
+        """init """
+        self.name = name
+        self.description = description
+        self.maxResources = maxResources
+        self.usedResources = usedResources
+        self.members = members or []
+        self.createTime = createTime
+        self.updateTime = updateTime
+
+
+class ProjectMemberInfo(object):
+    """the class of project member, resourceType is one of user/queue/fs/pipeline"""
+
+    def __init__(self, resourceType, resourceID, role, createTime):
+        """init """
+        self.resourceType = resourceType
+        self.resourceID = resourceID
+        self.role = role
+        self.createTime = createTime
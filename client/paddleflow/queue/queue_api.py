@@ -32,7 +32,8 @@ class QueueServiceApi(object):
 
     @classmethod
     def add_queue(self, host, name, namespace, clusterName, maxResources, minResources=None,
-                    schedulingPolicy=None, location=None, quotaType=None, header=None):
+                    schedulingPolicy=None, location=None, quotaType=None, header=None, priorityAging=None,
+                    burstPolicy=None):
         """
         add queue 
         """
@@ -52,6 +53,10 @@ class QueueServiceApi(object):
             body['location'] = location
         if quotaType:
             body['quotaType'] = quotaType
+        if priorityAging:
+            body['priorityAging'] = priorityAging
+        if burstPolicy:
+            body['burstPolicy'] = burstPolicy
         response = api_client.call_api(method="POST", url=parse.urljoin(host, api.PADDLE_FLOW_QUEUE), headers=header,
                                        json=body)
         if not response:
@@ -63,7 +68,7 @@ class QueueServiceApi(object):
 
     @classmethod
     def update_queue(self, host, queuename, maxResources, minResources=None, schedulingPolicy=None,
-                        location=None, header=None):
+                        location=None, header=None, priorityAging=None, burstPolicy=None):
         """
         update queue
         """
@@ -78,6 +83,10 @@ class QueueServiceApi(object):
             body['schedulingPolicy'] = schedulingPolicy
         if location:
             body['location'] = location
+        if priorityAging is not None:
+            body['priorityAging'] = priorityAging
+        if burstPolicy is not None:
+            body['burstPolicy'] = burstPolicy
         response = api_client.call_api(method="PUT", url=parse.urljoin(host, api.PADDLE_FLOW_QUEUE+ "/%s" % queuename),
                                         headers=header, json=body)
         if not response:
@@ -153,7 +162,7 @@ class QueueServiceApi(object):
         return True, None
 
     @classmethod
-    def list_queue(self, host, header=None, maxsize=100, marker=None):
+    def list_queue(self, host, header=None, maxsize=100, marker=None, project=None):
         """
         list queue
         """
@@ -166,6 +175,8 @@ class QueueServiceApi(object):
         }
         if marker:
             params['marker'] = marker
+        if project:
+            params['project'] = project
         response = api_client.call_api(method="GET", url=parse.urljoin(host, api.PADDLE_FLOW_QUEUE),
                                        params=params, headers=header)
         if not response:
@@ -198,7 +209,8 @@ class QueueServiceApi(object):
             return False, data['message']
         queueInfo = QueueInfo(data['name'], data['status'], data['namespace'], data['clusterName'], data['quotaType'],
                               data['maxResources'], data.get('minResources'), data['usedResources'], data['idleResources'],
-                              data.get('location'), data.get('schedulingPolicy'), data['createTime'], data['updateTime'])
+                              data.get('location'), data.get('schedulingPolicy'), data['createTime'], data['updateTime'],
+                              data.get('priorityAging'), data.get('burstPolicy'))
         return True, queueInfo
         
     @classmethod
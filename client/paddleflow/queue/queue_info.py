@@ -20,7 +20,8 @@ class QueueInfo(object):
     """the class of queue info"""   
 
     def __init__(self, name, status, namespace, clusterName, quotaType,
-                    maxResources, minResources, usedResources, idleResources, location, schedulingPolicy, createTime, updateTime):
+                    maxResources, minResources, usedResources, idleResources, location, schedulingPolicy, createTime, updateTime,
+                    priorityAging=None, burstPolicy=None):
         """init """
         self.name = name
         self.namespace = namespace
@@ -33,6 +34,8 @@ class QueueInfo(object):
         self.idleResources = idleResources
         self.location = location
         self.schedulingPolicy = schedulingPolicy
+        self.priorityAging = priorityAging
+        self.burstPolicy = burstPolicy
         self.createTime = createTime
         self.updateTime = updateTime
 
@@ -203,6 +203,42 @@ class RunServiceApi(object):
             return False, data['message']
         return True, None
 
+    @classmethod
+    def list_run_events(self, host, run_id, header=None):
+        """list events of run and its steps in chronological order
+        """
+        if not header:
+            raise PaddleFlowSDKException("InvalidRequest", "paddleflow should login first")
+        url = host + api.PADDLE_FLOW_RUN + "/%s/events" % run_id
+        response = api_client.call_api(method="GET", url=url, headers=header)
+        if not response:
+            raise PaddleFlowSDKException("Connection Error", "list run events failed due to HTTPError")
+        data = json.loads(response.text)
+        if 'message' in data:
+            return False, data['message']
+        return True, data['events']
+
+    @classmethod
+    def approve_run_job(self, host, run_id, job_id, approved=True, comment=None, header=None):
+        """approve or reject a job waiting for approval in run
+        """
+        if not header:
+            raise PaddleFlowSDKException("InvalidRequest", "paddleflow should login first")
+        url = host + api.PADDLE_FLOW_RUN + "/%s/approve" % run_id
+        body = {"jobID": job_id, "approved": approved}
+        if comment:
+            body['comment'] = comment
+
+        response = api_client.call_api(method="POST", url=url, headers=header, json=body)
+        if not response:
+            raise PaddleFlowSDKException("Connection Error", "approve run job failed due to HTTPError")
+        if not response.text:
+            return True, None
+        data = json.loads(response.text)
+        if 'message' in data:
+            return False, data['message']
+        return True, None
+
     @classmethod
     def delete_run(self, host, run_id, check_cache=True, header=None):
         """delete run
@@ -352,6 +388,6 @@ class RunServiceApi(object):
         for i in data['artifactEventList']:
             actifact = ArtifactInfo(i['runID'], i['fsname'], i['username'], i['artifactPath'],
                     i['step'], i['type'], i['artifactName'], i['meta'],
-                    i['createTime'], i['updateTime'])
+                    i['createTime'], i['updateTime'], i.get('pruned', False))
             actiface_list.append(actifact)
         return True, {'artifactList': actiface_list, 'nextMarker': data.get('nextMarker', None)}
@@ -154,7 +154,7 @@ class ArtifactInfo(object):
     """ the class of artifact info"""
 
     def __init__(self, run_id, fs_name, username, artifact_path, a_type, step, artifact_name, meta,
-                 create_time, update_time):
+                 create_time, update_time, pruned=False):
         self.run_id = run_id
         self.fs_name = fs_name
         self.username = username
@@ -164,4 +164,5 @@ class ArtifactInfo(object):
         self.artifact_name = artifact_name
         self.meta = meta
         self.create_time = create_time
-        self.update_time = update_time
\ No newline at end of file
+        self.update_time = update_time
+        self.pruned = pruned
\ No newline at end of file
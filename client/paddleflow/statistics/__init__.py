@@ -18,4 +18,5 @@ limitations under the License.
 # -*- coding:utf8 -*-
 
 from .statistics_api import StatisticsServiceApi
-from .statistics_info import StatisticsJobInfo, StatisticsJobDetailInfo
+from .statistics_info import StatisticsJobInfo, StatisticsJobDetailInfo, JobTopInfo, QueueJobTopInfo, \
+    CostCenterUsageInfo, CostCenterReportInfo
@@ -23,7 +23,8 @@ from urllib import parse
 from paddleflow.common.exception.paddleflow_sdk_exception import PaddleFlowSDKException
 from paddleflow.utils import api_client
 from paddleflow.common import api
-from paddleflow.statistics.statistics_info import StatisticsJobInfo, StatisticsJobDetailInfo
+from paddleflow.statistics.statistics_info import StatisticsJobInfo, StatisticsJobDetailInfo, QueueJobTopInfo, \
+    CostCenterReportInfo
 
 
 class StatisticsServiceApi(object):
@@ -113,3 +114,56 @@ class StatisticsServiceApi(object):
             return False, data['message']
         statistics_job_detail_info = StatisticsJobDetailInfo.from_json(data)
         return True, statistics_job_detail_info
+
+    @classmethod
+    def get_queue_job_top(cls, host, queue_name: str, sort_by: str = None, header=None):
+        """
+        get live resource usage of running jobs in queue
+        @param host: host url
+        @param queue_name: queue name
+        @param sort_by: cpu, memory, gpu or gpuMemory, default cpu
+        @param header: request header
+        @return: success: bool, resp: QueueJobTopInfo
+        """
+        if not header:
+            raise PaddleFlowSDKException("InvalidRequest", "paddleflow should login first")
+
+        pram = {}
+        if sort_by:
+            pram['sortBy'] = sort_by
+        resp = api_client.call_api(method="GET",
+                                   url=parse.urljoin(host, api.PADDLE_FLOW_STATISTIC + "/queue/%s/top" % queue_name),
+                                   headers=header,
+                                   params=pram)
+        if not resp:
+            raise PaddleFlowSDKException("Connection Error", "get job top failed due to HTTPError")
+        data = json.loads(resp.text)
+        if 'message' in data:
+            return False, data['message']
+        return True, QueueJobTopInfo.from_json(data)
+
+    @classmethod
+    def get_cost_center_report(cls, host, month: str = None, header=None):
+        """
+        get gpu hours of jobs grouped by cost center label, only root user is allowed
+        @param host: host url
+        @param month: month like 2022-10, default current month
+        @param header: request header
+        @return: success: bool, resp: CostCenterReportInfo
+        """
+        if not header:
+            raise PaddleFlowSDKException("InvalidRequest", "paddleflow should login first")
+
+        pram = {}
+        if month:
+            pram['month'] = month
+        resp = api_client.call_api(method="GET",
+                                   url=parse.urljoin(host, api.PADDLE_FLOW_STATISTIC + "/costcenter"),
+                                   headers=header,
+                                   params=pram)
+        if not resp:
+            raise PaddleFlowSDKException("Connection Error", "get cost center report failed due to HTTPError")
+        data = json.loads(resp.text)
+        if 'message' in data:
+            return False, data['message']
+        return True, CostCenterReportInfo.from_json(data)
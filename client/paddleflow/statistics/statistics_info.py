@@ -92,3 +92,98 @@ class StatisticsJobDetailInfo:
                 result.task_info.append(task_info)
             statistics_job_detail_info.result.append(result)
         return statistics_job_detail_info
+
+
+class JobTopInfo:
+    """the class of live resource usage of a running job"""
+    job_id: str
+    job_name: str
+    username: str
+    task_count: int
+    cpu_usage: float
+    memory_usage: float
+    gpu_util: float
+    gpu_memory_usage: float
+
+    def __init__(self, job_id, job_name, username, task_count, cpu_usage, memory_usage, gpu_util, gpu_memory_usage):
+        self.job_id = job_id
+        self.job_name = job_name
+        self.username = username
+        self.task_count = task_count
+        self.cpu_usage = cpu_usage
+        self.memory_usage = memory_usage
+        self.gpu_util = gpu_util
+        self.gpu_memory_usage = gpu_memory_usage
+
+    @staticmethod
+    def from_json(json_dic):
+        return JobTopInfo(
+            job_id=json_dic['jobID'],
+            job_name=json_dic.get('jobName', ''),
+            username=json_dic.get('userName', ''),
+            task_count=json_dic.get('taskCount', 0),
+            cpu_usage=json_dic.get('cpuUsage', 0),
+            memory_usage=json_dic.get('memoryUsage', 0),
+            gpu_util=json_dic.get('gpuUtil', 0),
+            gpu_memory_usage=json_dic.get('gpuMemoryUsage', 0),
+        )
+
+
+class QueueJobTopInfo:
+    """the class of live resource usage of running jobs in a queue"""
+    queue_name: str
+    update_time: str
+    job_list: List[JobTopInfo]
+
+    def __init__(self, queue_name: str, update_time: str, job_list: List[JobTopInfo]) -> None:
+        self.queue_name = queue_name
+        self.update_time = update_time
+        self.job_list = job_list
+
+    @staticmethod
+    def from_json(json_dic):
+        return QueueJobTopInfo(
+            queue_name=json_dic['queueName'],
+            update_time=json_dic.get('updateTime', ''),
+            job_list=[JobTopInfo.from_json(job) for job in json_dic.get('jobList') or []],
+        )
+
+
+class CostCenterUsageInfo:
+    """the class of gpu hours used by a cost center"""
+    cost_center: str
+    cluster_id: str
+    gpu_hours: float
+    job_count: int
+
+    def __init__(self, cost_center: str, gpu_hours: float, job_count: int, cluster_id: str = '') -> None:
+        self.cost_center = cost_center
+        self.cluster_id = cluster_id
+        self.gpu_hours = gpu_hours
+        self.job_count = job_count
+
+    @staticmethod
+    def from_json(json_dic):
+        return CostCenterUsageInfo(
+            cost_center=json_dic.get('costCenter', ''),
+            cluster_id=json_dic.get('clusterID', ''),
+            gpu_hours=json_dic.get('gpuHours', 0),
+            job_count=json_dic.get('jobCount', 0),
+        )
+
+
+class CostCenterReportInfo:
+    """the class of monthly gpu hours report grouped by cost center"""
+    month: str
+    items: List[CostCenterUsageInfo]
+
+    def __init__(self, month: str, items: List[CostCenterUsageInfo]) -> None:
+        self.month = month
+        self.items = items
+
+    @staticmethod
+    def from_json(json_dic):
+        return CostCenterReportInfo(
+            month=json_dic.get('month', ''),
+            items=[CostCenterUsageInfo.from_json(item) for item in json_dic.get('items') or []],
+        )
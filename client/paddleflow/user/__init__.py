@@ -17,4 +17,4 @@ limitations under the License.
 # -*- coding:utf8 -*-
 
 from .user_api import UserServiceApi
-from .user_info import UserInfo
\ No newline at end of file
+from .user_info import UserInfo, UserPreferenceInfo, UserQuotaInfo
\ No newline at end of file
@@ -21,7 +21,8 @@ from urllib import parse
 from paddleflow.common.exception.paddleflow_sdk_exception import PaddleFlowSDKException
 from paddleflow.utils import api_client
 from paddleflow.common import api
-from paddleflow.user.user_info import UserInfo
+from paddleflow.user.user_info import UserInfo, UserPreferenceInfo, UserQuotaInfo, UserSessionInfo, \
+    ImpersonationAuditInfo
 
 
 class UserServiceApi(object):
@@ -31,7 +32,7 @@ class UserServiceApi(object):
         """
 
     @classmethod
-    def add_user(self, host, name, password, header=None):
+    def add_user(self, host, name, password, header=None, email=None):
         """call add user api"""
         if not header:
             raise PaddleFlowSDKException("InvalidRequest", "paddleflow should login first")
@@ -40,6 +41,8 @@ class UserServiceApi(object):
             "username": name,
             "password": password
         }
+        if email:
+            body['email'] = email
         response = api_client.call_api(method="POST", url=parse.urljoin(host, api.PADDLE_FLOW_USER),
                                        json=body, headers=header)
         if not response:
@@ -66,7 +69,7 @@ class UserServiceApi(object):
         return True, None
 
     @classmethod        
-    def list_user(self, host, header=None, maxsize=100):
+    def list_user(self, host, header=None, maxsize=100, project=None):
         """call list user api"""
         if not header:
             raise PaddleFlowSDKException("InvalidRequest", "paddleflow should login first")
@@ -77,6 +80,8 @@ class UserServiceApi(object):
         params = {
             "maxKeys": maxsize 
         }
+        if project:
+            params['project'] = project
         response = api_client.call_api(method="GET", url=parse.urljoin(host, api.PADDLE_FLOW_USER),
                                        headers=header, params=params)
         if not response:
@@ -107,4 +112,235 @@ class UserServiceApi(object):
         data = json.loads(response.text)
         if data and 'message' in data:
             return False, data['message']
-        return True, None
\ No newline at end of file
+        return True, None
+
+    @classmethod
+    def update_email(self, host, name, email, header=None):
+        """call update user api to set the email receiving password reset tokens"""
+        if not header:
+            raise PaddleFlowSDKException("InvalidRequest", "paddleflow should login first")
+        body = {
+            "email": email
+        }
+        response = api_client.call_api(method="PUT", url=parse.urljoin(host, api.PADDLE_FLOW_USER + "/%s" % name),
+                                       headers=header, json=body)
+        if not response:
+            raise PaddleFlowSDKException("Connection Error", "update email failed due to HTTPError")
+        if not response.text:
+            return True, None
+        data = json.loads(response.text)
+        if data and 'message' in data:
+            return False, data['message']
+        return True, None
+
+    @classmethod
+    def forgot_password(self, host, name):
+        """call forgot password api, no login is needed"""
+        body = {
+            "username": name
+        }
+        response = api_client.call_api(method="POST", url=parse.urljoin(host, api.PADDLE_FLOW_USER + "/password/forgot"),
+                                       json=body)
+        if not response:
+            raise PaddleFlowSDKException("Connection Error", "forgot password failed due to HTTPError")
+        if not response.text:
+            return True, None
+        data = json.loads(response.text)
+        if data and 'message' in data:
+            return False, data['message']
+        return True, None
+
+    @classmethod
+    def reset_password(self, host, name, password, token=None, old_password=None):
+        """call reset password api with the emailed token or the old password, no login is needed"""
+        body = {
+            "username": name,
+            "password": password
+        }
+        if token:
+            body['token'] = token
+        if old_password:
+            body['oldPassword'] = old_password
+        response = api_client.call_api(method="POST", url=parse.urljoin(host, api.PADDLE_FLOW_USER + "/password/reset"),
+                                       json=body)
+        if not response:
+            raise PaddleFlowSDKException("Connection Error", "reset password failed due to HTTPError")
+        if not response.text:
+            return True, None
+        data = json.loads(response.text)
+        if data and 'message' in data:
+            return False, data['message']
+        return True, None
+
+    @classmethod
+    def get_preference(self, host, name, header=None):
+        """call get user preference api"""
+        if not header:
+            raise PaddleFlowSDKException("InvalidRequest", "paddleflow should login first")
+        response = api_client.call_api(method="GET",
+                                       url=parse.urljoin(host, api.PADDLE_FLOW_USER + "/%s/preference" % name),
+                                       headers=header)
+        if not response:
+            raise PaddleFlowSDKException("Connection Error", "get user preference failed due to HTTPError")
+        data = json.loads(response.text)
+        if 'message' in data:
+            return False, data['message']
+        return True, UserPreferenceInfo(data['userName'], data['queue'], data['flavour'], data['image'], data['fs'])
+
+    @classmethod
+    def set_preference(self, host, name, queue=None, flavour=None, image=None, fs=None, header=None):
+        """call set user preference api, the previous settings are overwritten"""
+        if not header:
+            raise PaddleFlowSDKException("InvalidRequest", "paddleflow should login first")
+        body = {
+            "queue": queue or "",
+            "flavour": flavour or "",
+            "image": image or "",
+            "fs": fs or "",
+        }
+        response = api_client.call_api(method="PUT",
+                                       url=parse.urljoin(host, api.PADDLE_FLOW_USER + "/%s/preference" % name),
+                                       headers=header, json=body)
+        if not response:
+            raise PaddleFlowSDKException("Connection Error", "set user preference failed due to HTTPError")
+        data = json.loads(response.text)
+        if 'message' in data:
+            return False, data['message']
+        return True, UserPreferenceInfo(data['userName'], data['queue'], data['flavour'], data['image'], data['fs'])
+
+    @classmethod
+    def del_preference(self, host, name, header=None):
+        """call delete user preference api"""
+        if not header:
+            raise PaddleFlowSDKException("InvalidRequest", "paddleflow should login first")
+        response = api_client.call_api(method="DELETE",
+                                       url=parse.urljoin(host, api.PADDLE_FLOW_USER + "/%s/preference" % name),
+                                       headers=header)
+        if not response:
+            raise PaddleFlowSDKException("Connection Error", "delete user preference failed due to HTTPError")
+        if not response.text:
+            return True, None
+        data = json.loads(response.text)
+        if data and 'message' in data:
+            return False, data['message']
+        return True, None
+
+    @classmethod
+    def get_quota(self, host, name, header=None):
+        """call get user quota api"""
+        if not header:
+            raise PaddleFlowSDKException("InvalidRequest", "paddleflow should login first")
+        response = api_client.call_api(method="GET",
+                                       url=parse.urljoin(host, api.PADDLE_FLOW_USER + "/%s/quota" % name),
+                                       headers=header)
+        if not response:
+            raise PaddleFlowSDKException("Connection Error", "get user quota failed due to HTTPError")
+        data = json.loads(response.text)
+        if 'message' in data:
+            return False, data['message']
+        return True, UserQuotaInfo(data['userName'], data['maxConcurrentJobs'], data['maxGPUs'], data['maxJobDuration'])
+
+    @classmethod
+    def set_quota(self, host, name, max_concurrent_jobs=0, max_gpus=0, max_job_duration=0, header=None):
+        """call set user quota api, the previous quota is overwritten"""
+        if not header:
+            raise PaddleFlowSDKException("InvalidRequest", "paddleflow should login first")
+        body = {
+            "maxConcurrentJobs": max_concurrent_jobs or 0,
+            "maxGPUs": max_gpus or 0,
+            "maxJobDuration": max_job_duration or 0,
+        }
+        response = api_client.call_api(method="PUT",
+                                       url=parse.urljoin(host, api.PADDLE_FLOW_USER + "/%s/quota" % name),
+                                       headers=header, json=body)
+        if not response:
+            raise PaddleFlowSDKException("Connection Error", "set user quota failed due to HTTPError")
+        data = json.loads(response.text)
+        if 'message' in data:
+            return False, data['message']
+        return True, UserQuotaInfo(data['userName'], data['maxConcurrentJobs'], data['maxGPUs'], data['maxJobDuration'])
+
+    @classmethod
+    def del_quota(self, host, name, header=None):
+        """call delete user quota api"""
+        if not header:
+            raise PaddleFlowSDKException("InvalidRequest", "paddleflow should login first")
+        response = api_client.call_api(method="DELETE",
+                                       url=parse.urljoin(host, api.PADDLE_FLOW_USER + "/%s/quota" % name),
+                                       headers=header)
+        if not response:
+            raise PaddleFlowSDKException("Connection Error", "delete user quota failed due to HTTPError")
+        if not response.text:
+            return True, None
+        data = json.loads(response.text)
+        if data and 'message' in data:
+            return False, data['message']
+        return True, None
+
+    @classmethod
+    def list_sessions(self, host, name, header=None):
+        """call list user sessions api"""
+        if not header:
+            raise PaddleFlowSDKException("InvalidRequest", "paddleflow should login first")
+        response = api_client.call_api(method="GET",
+                                       url=parse.urljoin(host, api.PADDLE_FLOW_USER + "/%s/session" % name),
+                                       headers=header)
+        if not response:
+            raise PaddleFlowSDKException("Connection Error", "list user sessions failed due to HTTPError")
+        data = json.loads(response.text)
+        if 'message' in data:
+            return False, data['message']
+        sessions = []
+        for session in data['sessionList'] or []:
+            sessions.append(UserSessionInfo(session['id'], session['userName'], session['clientIP'],
+                                            session['userAgent'], session['createTime'], session['lastActiveTime'],
+                                            session.get('expireTime')))
+        return True, sessions
+
+    @classmethod
+    def revoke_session(self, host, name, session_id=None, header=None):
+        """call revoke user session api, all sessions of user are revoked if session_id is none"""
+        if not header:
+            raise PaddleFlowSDKException("InvalidRequest", "paddleflow should login first")
+        url = api.PADDLE_FLOW_USER + "/%s/session" % name
+        if session_id:
+            url += "/%s" % session_id
+        response = api_client.call_api(method="DELETE", url=parse.urljoin(host, url), headers=header)
+        if not response:
+            raise PaddleFlowSDKException("Connection Error", "revoke user session failed due to HTTPError")
+        if not response.text:
+            return True, None
+        data = json.loads(response.text)
+        if data and 'message' in data:
+            return False, data['message']
+        return True, data.get('revoked') if data else None
+
+    @classmethod
+    def list_impersonation_audit(self, host, header=None, operator=None, name=None, maxsize=100, marker=None):
+        """call list impersonation audit api, root is needed"""
+        if not header:
+            raise PaddleFlowSDKException("InvalidRequest", "paddleflow should login first")
+        if not isinstance(maxsize, int) or maxsize <= 0:
+            raise PaddleFlowSDKException("InvalidRequest", "maxsize should be int and greater than 0")
+        params = {
+            "maxKeys": maxsize
+        }
+        if operator:
+            params['operator'] = operator
+        if name:
+            params['user'] = name
+        if marker:
+            params['marker'] = marker
+        response = api_client.call_api(method="GET", url=parse.urljoin(host, api.PADDLE_FLOW_IMPERSONATION_AUDIT),
+                                       headers=header, params=params)
+        if not response:
+            raise PaddleFlowSDKException("Connection Error", "list impersonation audit failed due to HTTPError")
+        data = json.loads(response.text)
+        if 'message' in data:
+            return False, data['message'], None
+        audits = []
+        for audit in data['auditList'] or []:
+            audits.append(ImpersonationAuditInfo(audit['operator'], audit['userName'], audit['method'], audit['path'],
+                                                 audit['statusCode'], audit['clientIP'], audit['createTime'],
+                                                 audit.get('requestID')))
+        return True, audits, data.get('nextMarker')
@@ -27,4 +27,56 @@ class UserInfo(object):
 
     
 
-    
\ No newline at end of file
+    
+
+
+class UserPreferenceInfo(object):
+    """the class of default settings used when creating jobs"""
+
+    def __init__(self, name, queue, flavour, image, fs):
+        """init """
+        self.name = name
+        self.queue = queue
+        self.flavour = flavour
+        self.image = image
+        self.fs = fs
+
+
+class UserQuotaInfo(object):
+    """the class of job limits of user, 0 means no limit"""
+
+    def __init__(self, name, max_concurrent_jobs, max_gpus, max_job_duration):
+        """init """
+        self.name = name
+        self.max_concurrent_jobs = max_concurrent_jobs
+        self.max_gpus = max_gpus
+        self.max_job_duration = max_job_duration
+
+
+class UserSessionInfo(object):
+    """the class of login session, the token of a revoked session is rejected"""
+
+    def __init__(self, session_id, name, client_ip, user_agent, create_time, last_active_time, expire_time=None):
+        """init """
+        self.session_id = session_id
+        self.name = name
+        self.client_ip = client_ip
+        self.user_agent = user_agent
+        self.create_time = create_time
+        self.last_active_time = last_active_time
+        self.expire_time = expire_time
+
+
+class ImpersonationAuditInfo(object):
+    """the class of a request sent by root on behalf of another user"""
+
+    def __init__(self, operator, name, method, path, status_code, client_ip, create_time, request_id=None):
+        """init """
+        self.operator = operator
+        self.name = name
+        self.method = method
+        self.path = path
+        self.status_code = status_code
+        self.client_ip = client_ip
+        self.create_time = create_time
+        self.request_id = request_id
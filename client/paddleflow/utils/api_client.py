@@ -24,6 +24,15 @@ from paddleflow.common.exception.paddleflow_sdk_exception import PaddleFlowSDKEx
 REQUESRID = 'requestID'
 MESSAGE = 'message'
 CODE = 'code'
+DETAILS = 'details'
+RETRIABLE = 'retriable'
+
+
+def _service_exception(resp):
+    """build sdk exception from error response"""
+    data = json.loads(resp.text)
+    return PaddleFlowSDKException(data[CODE], data[MESSAGE], data[REQUESRID],
+                                  data.get(DETAILS), data.get(RETRIABLE, False))
 
 def call_api(**kwargs):
     """call api function"""
@@ -71,8 +80,7 @@ def call_api(**kwargs):
             done = True
             break
         except requests.exceptions.HTTPError as errh:
-            data = json.loads(resp.text)
-            raise PaddleFlowSDKException(data[CODE], data[MESSAGE], data[REQUESRID])
+            raise _service_exception(resp)
         except requests.exceptions.ConnectionError as errc:
             done = False
             time.sleep(3)
@@ -80,8 +88,7 @@ def call_api(**kwargs):
             done = False
             time.sleep(3)
         except requests.exceptions.RequestException as errr:
-            data = json.loads(resp.text)
-            raise PaddleFlowSDKException(data[CODE], data[MESSAGE], data[REQUESRID])
+            raise _service_exception(resp)
 
     if not done:
         return None
@@ -17,9 +17,13 @@ limitations under the License.
 package main
 
 import (
+	"fmt"
+	"net/http"
 	"os"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	log "github.com/sirupsen/logrus"
 	"github.com/urfave/cli/v2"
 
@@ -132,6 +136,11 @@ func act(c *cli.Context) error {
 		return err
 	}
 
+	// remount metrics of mount point controller
+	if c.Bool("metrics-service-on") {
+		exposeMetricsService(c.Int("metrics-service-port"))
+	}
+
 	stopChan := make(chan struct{})
 	defer close(stopChan)
 	ctrl := controller.GetMountPointController(c.String("node-id"))
@@ -142,3 +151,15 @@ func act(c *cli.Context) error {
 	d.Run()
 	return nil
 }
+
+func exposeMetricsService(port int) {
+	mx := http.NewServeMux()
+	mx.Handle("/metrics", promhttp.HandlerFor(prometheus.DefaultGatherer, promhttp.HandlerOpts{}))
+	metricsAddr := fmt.Sprintf(":%d", port)
+	go func() {
+		if err := http.ListenAndServe(metricsAddr, mx); err != nil {
+			log.Errorf("metrics ListenAndServe error: %s", err)
+		}
+	}()
+	log.Infof("metrics listening on %s", metricsAddr)
+}
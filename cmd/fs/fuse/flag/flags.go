@@ -25,6 +25,7 @@ import (
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/client/fuse"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/client/kv"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/client/meta"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/client/ufs"
 )
 
@@ -51,9 +52,10 @@ func BasicFlags() []cli.Flag {
 			Usage: "filesystem config",
 		},
 		&cli.StringFlag{
-			Name:  schema.FuseKeyFsInfo,
-			Value: "",
-			Usage: "filesystem config in json string",
+			Name:    schema.FuseKeyFsInfo,
+			Value:   "",
+			Usage:   "filesystem config in json string",
+			EnvVars: []string{schema.EnvKeyFsInfo},
 		},
 		&cli.StringFlag{
 			Name:  "local-root",
@@ -78,7 +80,12 @@ func CacheFlags(fuseConf *fuse.FuseConfig) []cli.Flag {
 		&cli.StringFlag{
 			Name:  "meta-cache-driver",
 			Value: kv.MemType,
-			Usage: "meta cache driver, e.g. mem, disk",
+			Usage: "meta cache driver, e.g. mem, disk, redis, etcd",
+		},
+		&cli.StringFlag{
+			Name:  "meta-cache-address",
+			Value: "",
+			Usage: "address of shared meta cache driver, e.g. redis://:password@host:6379/0, etcd://host1:2379,host2:2379",
 		},
 		&cli.StringFlag{
 			Name:  "meta-cache-path",
@@ -90,6 +97,51 @@ func CacheFlags(fuseConf *fuse.FuseConfig) []cli.Flag {
 			Value: 0,
 			Usage: "data cache expire",
 		},
+		&cli.Int64Flag{
+			Name:  "data-cache-capacity",
+			Value: 0,
+			Usage: "max total size in bytes of data cache blocks, 0 means limited by disk only",
+		},
+		&cli.StringFlag{
+			Name:  "data-cache-evict-policy",
+			Value: schema.FsCacheEvictLRU,
+			Usage: "data cache eviction policy when capacity is reached, e.g. lru, lfu, ttl",
+		},
+		&cli.Int64Flag{
+			Name:  "data-cache-mem-size",
+			Value: 0,
+			Usage: "size in bytes of memory tier for hot data cache blocks, 0 means disabled",
+		},
+		&cli.StringFlag{
+			Name:  "data-cache-peer-addr",
+			Value: "",
+			Usage: "address to serve cached blocks to other nodes, e.g. :28790, empty means peer sharing disabled",
+		},
+		&cli.StringFlag{
+			Name:  "data-cache-peer-registry-driver",
+			Value: kv.MemType,
+			Usage: "kv driver of registry recording which node cached which block, e.g. redis, etcd, shares meta-cache-address",
+		},
+		&cli.StringFlag{
+			Name:  "data-cache-compression",
+			Value: "",
+			Usage: "compress data cache blocks on local disk: zstd or s2, empty means no compression",
+		},
+		&cli.StringFlag{
+			Name:  "data-cache-key-file",
+			Value: "",
+			Usage: "file of the fs key to encrypt data cache blocks on local disk with AES-GCM, empty means no encryption",
+		},
+		&cli.StringFlag{
+			Name:  "write-back-path",
+			Value: "",
+			Usage: "local dir to stage written files before uploading asynchronously, empty means write-back disabled",
+		},
+		&cli.Int64Flag{
+			Name:  "write-back-dirty-limit",
+			Value: 0,
+			Usage: "max bytes of staged data not yet uploaded, close blocks on upload when exceeded, 0 means unlimited",
+		},
 		&cli.DurationFlag{
 			Name:  "meta-cache-expire",
 			Value: 5 * time.Second,
@@ -105,6 +157,11 @@ func CacheFlags(fuseConf *fuse.FuseConfig) []cli.Flag {
 			Value: 1 * time.Second,
 			Usage: "path cache expire",
 		},
+		&cli.DurationFlag{
+			Name:  "negative-entry-cache-expire",
+			Value: 0,
+			Usage: "cache expire of not existing entries, 0 disables negative cache",
+		},
 		&cli.IntFlag{
 			Name:  "block-size",
 			Value: 20971520,
@@ -127,6 +184,16 @@ func CacheFlags(fuseConf *fuse.FuseConfig) []cli.Flag {
 			Value: 200 * 1024 * 1024,
 			Usage: "size of read-ahead data",
 		},
+		&cli.IntFlag{
+			Name:  "data-prefetch-blocks",
+			Value: 0,
+			Usage: "max blocks prefetched into data cache on sequential read, 0 disables prefetch",
+		},
+		&cli.IntFlag{
+			Name:  "data-prefetch-workers",
+			Value: 4,
+			Usage: "concurrent workers for data prefetch",
+		},
 		&cli.BoolFlag{
 			Name:  "clean-cache",
 			Value: false,
@@ -154,6 +221,11 @@ func MountFlags(fuseConf *fuse.FuseConfig) []cli.Flag {
 			Value: true,
 			Usage: "kernel does not issue anyXAttr operations at all",
 		},
+		&cli.StringFlag{
+			Name:  "atime-mode",
+			Value: meta.AtimeModeNoatime,
+			Usage: "when to update atime of files on read: noatime, relatime or strictatime",
+		},
 		&cli.IntFlag{
 			Name:        "dir-mode",
 			Value:       ufs.DefaultDirMode,
@@ -192,14 +264,16 @@ func LinkFlags() []cli.Flag {
 func UserFlags(fuseConf *fuse.FuseConfig) []cli.Flag {
 	return []cli.Flag{
 		&cli.StringFlag{
-			Name:  "user-name",
-			Value: "root",
-			Usage: "fs server api username",
+			Name:    "user-name",
+			Value:   "root",
+			Usage:   "fs server api username",
+			EnvVars: []string{schema.EnvKeyServerUserName},
 		},
 		&cli.StringFlag{
-			Name:  "password",
-			Value: "paddleflow",
-			Usage: "fs server api password for fs username",
+			Name:    "password",
+			Value:   "paddleflow",
+			Usage:   "fs server api password for fs username",
+			EnvVars: []string{schema.EnvKeyServerPassword},
 		},
 		&cli.BoolFlag{
 			Name:  "allow-other",
@@ -221,6 +295,22 @@ func UserFlags(fuseConf *fuse.FuseConfig) []cli.Flag {
 	}
 }
 
+// StandaloneFlags 独立挂载模式（pfs-fuse mount FS-NAME MOUNTPOINT）的参数，服务地址及凭证从客户端配置文件读取
+func StandaloneFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:  "pf-config",
+			Value: "",
+			Usage: "paddleflow client config file for standalone mount, default ~/.paddleflow/paddleflow.ini",
+		},
+		&cli.StringFlag{
+			Name:  "fs-owner",
+			Value: "",
+			Usage: "owner of the filesystem for standalone mount, default the user in config",
+		},
+	}
+}
+
 func ExpandFlags(compoundFlags [][]cli.Flag) []cli.Flag {
 	var flags []cli.Flag
 	for _, flag := range compoundFlags {
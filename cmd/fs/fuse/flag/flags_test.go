@@ -40,7 +40,7 @@ func TestCacheFlags(t *testing.T) {
 			args: args{
 				fuseConf: fuse.FuseConf,
 			},
-			want: 12,
+			want: 25,
 		},
 	}
 	for _, tt := range tests {
@@ -51,3 +51,9 @@ func TestCacheFlags(t *testing.T) {
 		})
 	}
 }
+
+func TestStandaloneFlags(t *testing.T) {
+	if got := StandaloneFlags(); len(got) != 2 {
+		t.Errorf("StandaloneFlags() = %v, want %v", len(got), 2)
+	}
+}
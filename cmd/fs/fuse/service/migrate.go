@@ -0,0 +1,99 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/client/kv"
+)
+
+func CmdMetaMigrate() *cli.Command {
+	return &cli.Command{
+		Name:     "meta-migrate",
+		Action:   metaMigrate,
+		Category: "TOOL",
+		Usage:    "Migrate cache metadata of a file system between shared meta drivers",
+		Description: `
+mem and disk meta caches belong to a single mount and are rebuilt on mount, so only redis and etcd can be migrated.
+Mount pods of the file system should be stopped before migrating.
+
+Examples:
+$ pfs-fuse meta-migrate --fs-id fs-root-abc --from-driver redis --from-address redis://:password@127.0.0.1:6379/0 \
+    --to-driver etcd --to-address etcd://127.0.0.1:2379`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "fs-id",
+				Required: true,
+				Usage:    "file system id",
+			},
+			&cli.StringFlag{
+				Name:     "from-driver",
+				Required: true,
+				Usage:    "source meta driver, e.g. redis, etcd",
+			},
+			&cli.StringFlag{
+				Name:     "from-address",
+				Required: true,
+				Usage:    "source meta driver address",
+			},
+			&cli.StringFlag{
+				Name:     "to-driver",
+				Required: true,
+				Usage:    "target meta driver, e.g. redis, etcd",
+			},
+			&cli.StringFlag{
+				Name:     "to-address",
+				Required: true,
+				Usage:    "target meta driver address",
+			},
+			&cli.IntFlag{
+				Name:  "batch",
+				Value: 1000,
+				Usage: "number of keys written in one transaction",
+			},
+		},
+	}
+}
+
+func metaMigrate(c *cli.Context) error {
+	fsID := c.String("fs-id")
+	clients := make([]kv.KvClient, 2)
+	for i, prefix := range []string{"from", "to"} {
+		driver := c.String(prefix + "-driver")
+		if !kv.IsShared(driver) {
+			return fmt.Errorf("%s-driver[%s] not supported, must be redis or etcd", prefix, driver)
+		}
+		client, err := kv.NewClient(kv.Config{FsID: fsID, Driver: driver, Address: c.String(prefix + "-address")})
+		if err != nil {
+			log.Errorf("init %s meta driver[%s] failed: %v", prefix, driver, err)
+			return err
+		}
+		clients[i] = client
+	}
+	copied, err := kv.Copy(clients[0], clients[1], c.Int("batch"))
+	if err != nil {
+		log.Errorf("migrate meta of fs[%s] failed after %d keys: %v", fsID, copied, err)
+		return err
+	}
+	log.Infof("migrate meta of fs[%s] from %s to %s finished, %d keys copied",
+		fsID, c.String("from-driver"), c.String("to-driver"), copied)
+	return nil
+}
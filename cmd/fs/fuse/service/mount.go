@@ -25,6 +25,7 @@ import (
 	_ "net/http/pprof"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"syscall"
@@ -71,6 +72,7 @@ func CmdMount() *cli.Command {
 		flag.BasicFlags(),
 		flag.CacheFlags(fuse.FuseConf),
 		flag.UserFlags(fuse.FuseConf),
+		flag.StandaloneFlags(),
 		logger.LogFlags(&logConf),
 		monitor.MetricsFlags(),
 	}
@@ -79,9 +81,13 @@ func CmdMount() *cli.Command {
 		Action:    mount,
 		Category:  "SERVICE",
 		Usage:     "Mount a volume",
-		ArgsUsage: "META-URL MOUNTPOINT",
+		ArgsUsage: "[FS-NAME MOUNTPOINT]",
 		Description: `
-Usage please refer to docs`,
+Usage please refer to docs
+
+Examples:
+# mount fs outside kubernetes, server and user are read from ~/.paddleflow/paddleflow.ini
+$ pfs-fuse mount myfs /mnt/myfs`,
 		Flags: flag.ExpandFlags(compoundFlags),
 	}
 }
@@ -136,6 +142,10 @@ func setup(c *cli.Context) error {
 		return err
 	}
 	go monitor.UpdateBaseMetrics()
+	// 缓存命中统计写入缓存根目录，由挂载pod中的cache-worker上报
+	if dataCachePath := c.String("data-cache-path"); dataCachePath != "" {
+		go cache.DumpStatsLoop(filepath.Dir(filepath.Clean(dataCachePath)))
+	}
 	// whether start metrics server
 	if c.Bool("metrics-service-on") {
 		metricsAddr := exposeMetricsService(c.String("server"), c.Int("metrics-service-port"))
@@ -201,6 +211,10 @@ func wrapRegister(mountPoint string) *prometheus.Registry {
 }
 
 func mount(c *cli.Context) error {
+	if err := prepareStandalone(c); err != nil {
+		log.Errorf("mount prepareStandalone() err: %v", err)
+		return err
+	}
 	log.Tracef("mount setup VFS")
 	if err := setup(c); err != nil {
 		log.Errorf("mount setup() err: %v", err)
@@ -273,6 +287,8 @@ func InitVFS(c *cli.Context, registry *prometheus.Registry) error {
 		fsMeta.Properties = fs.PropertiesMap
 		fsMeta.UfsType = fs.Type
 		fsMeta.Type = "fs"
+		fsMeta.CapacityQuota = fs.CapacityQuota
+		fsMeta.InodeQuota = fs.InodeQuota
 	} else if c.String("config") != "" {
 		reader, err := os.Open(c.String("config"))
 		if err != nil {
@@ -348,28 +364,76 @@ func InitVFS(c *cli.Context, registry *prometheus.Registry) error {
 			return err
 		}
 	}
+	if !meta.IsValidAtimeMode(c.String("atime-mode")) {
+		log.Errorf("invalid atime-mode: [%s]", c.String("atime-mode"))
+		return fmt.Errorf("invalid atime-mode: [%s]", c.String("atime-mode"))
+	}
 	m := meta.Config{
-		AttrCacheExpire:  c.Duration("meta-cache-expire"),
-		EntryCacheExpire: c.Duration("entry-cache-expire"),
-		PathCacheExpire:  c.Duration("path-cache-expire"),
+		AttrCacheExpire:     c.Duration("meta-cache-expire"),
+		EntryCacheExpire:    c.Duration("entry-cache-expire"),
+		PathCacheExpire:     c.Duration("path-cache-expire"),
+		NegEntryCacheExpire: c.Duration("negative-entry-cache-expire"),
+		AtimeMode:           c.String("atime-mode"),
 		Config: kv.Config{
 			FsID:      fsMeta.ID,
 			Driver:    c.String("meta-cache-driver"),
 			CachePath: c.String("meta-cache-path"),
+			Address:   c.String("meta-cache-address"),
 		},
 	}
 	d := cache.Config{
-		BlockSize:    c.Int("block-size"),
-		MaxReadAhead: c.Int("data-read-ahead-size"),
-		Expire:       c.Duration("data-cache-expire"),
+		BlockSize:       c.Int("block-size"),
+		MaxReadAhead:    c.Int("data-read-ahead-size"),
+		Expire:          c.Duration("data-cache-expire"),
+		MaxSize:         c.Int64("data-cache-capacity"),
+		EvictPolicy:     c.String("data-cache-evict-policy"),
+		MemSize:         c.Int64("data-cache-mem-size"),
+		PeerAddr:        c.String("data-cache-peer-addr"),
+		Compression:     c.String("data-cache-compression"),
+		PrefetchBlocks:  c.Int("data-prefetch-blocks"),
+		PrefetchWorkers: c.Int("data-prefetch-workers"),
 		Config: kv.Config{
 			CachePath: c.String("data-cache-path"),
 		},
 	}
+	if !schema.IsValidFsCacheCompression(d.Compression) {
+		log.Errorf("invalid data-cache-compression: [%s]", d.Compression)
+		return fmt.Errorf("invalid data-cache-compression: [%s]", d.Compression)
+	}
+	if keyFile := c.String("data-cache-key-file"); keyFile != "" {
+		content, err := ioutil.ReadFile(keyFile)
+		if err != nil {
+			log.Errorf("read data cache key file[%s] failed: %v", keyFile, err)
+			return err
+		}
+		if d.EncryptKey, err = cache.ParseEncryptKey(content); err != nil {
+			log.Errorf("parse data cache key file[%s] failed: %v", keyFile, err)
+			return err
+		}
+	}
+	if d.PeerAddr != "" {
+		registry, err := kv.NewClient(kv.Config{
+			FsID:      fsMeta.ID + "-peers",
+			Driver:    c.String("data-cache-peer-registry-driver"),
+			CachePath: c.String("data-cache-path"),
+			Address:   c.String("meta-cache-address"),
+		})
+		if err != nil {
+			log.Errorf("init data cache peer registry failed: %v", err)
+			return err
+		}
+		d.PeerRegistry = registry
+	}
 	vfsOptions := []vfs.Option{
 		vfs.WithDataCacheConfig(d),
 		vfs.WithMetaConfig(m),
 	}
+	if writeBackPath := c.String("write-back-path"); writeBackPath != "" {
+		vfsOptions = append(vfsOptions, vfs.WithWriteBack(vfs.WriteBackConfig{
+			Dir:        writeBackPath,
+			DirtyLimit: c.Int64("write-back-dirty-limit"),
+		}))
+	}
 	if !fuse.FuseConf.RawOwner {
 		vfsOptions = append(vfsOptions, vfs.WithOwner(
 			uint32(fuse.FuseConf.Uid),
@@ -0,0 +1,138 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+)
+
+const (
+	defaultPfConfigDir  = ".paddleflow"
+	defaultPfConfigFile = "paddleflow.ini"
+	defaultServerPort   = "8999"
+)
+
+// prepareStandalone 独立挂载模式：pfs-fuse mount FS-NAME MOUNTPOINT，用于k8s集群外（如开发机、macOS）挂载存储。
+// 服务地址及用户凭证读取paddleflow客户端的配置文件，命令行显式指定的参数优先
+func prepareStandalone(c *cli.Context) error {
+	if c.NArg() == 0 {
+		return nil
+	}
+	if c.NArg() != 2 {
+		return fmt.Errorf("standalone mount needs FS-NAME and MOUNTPOINT, got %v", c.Args().Slice())
+	}
+	fsName, mountPoint := c.Args().Get(0), c.Args().Get(1)
+	mountPoint, err := filepath.Abs(mountPoint)
+	if err != nil {
+		return err
+	}
+
+	configPath := c.String("pf-config")
+	if configPath == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return err
+		}
+		configPath = filepath.Join(home, defaultPfConfigDir, defaultPfConfigFile)
+	}
+	conf, err := loadPfConfig(configPath)
+	if err != nil {
+		log.Errorf("load paddleflow config[%s] failed: %v", configPath, err)
+		return err
+	}
+	port := conf["server"]["paddleflow_server_port"]
+	if port == "" {
+		port = defaultServerPort
+	}
+	server := ""
+	if host := conf["server"]["paddleflow_server_host"]; host != "" {
+		server = host + ":" + port
+	}
+	userName := conf["user"]["name"]
+	if c.IsSet("user-name") {
+		userName = c.String("user-name")
+	}
+	owner := c.String("fs-owner")
+	if owner == "" {
+		owner = userName
+	}
+	fsID := schema.ID(owner, fsName)
+
+	cacheDir := filepath.Join(filepath.Dir(configPath), "cache", fsID)
+	values := []struct {
+		name, value string
+	}{
+		{"mount-point", mountPoint},
+		{"fs-id", fsID},
+		{"server", server},
+		{"user-name", userName},
+		{"password", conf["user"]["password"]},
+		{"data-cache-path", filepath.Join(cacheDir, "data-cache")},
+		{"meta-cache-path", filepath.Join(cacheDir, "meta-cache")},
+	}
+	for _, v := range values {
+		if c.IsSet(v.name) || v.value == "" {
+			continue
+		}
+		if err := c.Set(v.name, v.value); err != nil {
+			return err
+		}
+	}
+	log.Infof("standalone mount fs[%s] to %s with server[%s]", fsID, mountPoint, c.String("server"))
+	return nil
+}
+
+// loadPfConfig 解析paddleflow.ini，返回section -> key -> value
+func loadPfConfig(path string) (map[string]map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	conf := map[string]map[string]string{}
+	section := ""
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid line in config: %s", line)
+		}
+		if conf[section] == nil {
+			conf[section] = map[string]string{}
+		}
+		conf[section][strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+	return conf, scanner.Err()
+}
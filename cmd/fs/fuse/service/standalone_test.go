@@ -0,0 +1,79 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli/v2"
+
+	"github.com/PaddlePaddle/PaddleFlow/cmd/fs/fuse/flag"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/client/fuse"
+)
+
+func TestPrepareStandalone(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "paddleflow.ini")
+	err := os.WriteFile(configPath, []byte("[user]\nname = alice\npassword = secret\n"+
+		"[server]\n# paddleflow server 地址\npaddleflow_server_host = 10.0.0.1\n"), 0600)
+	assert.Nil(t, err)
+
+	conf, err := loadPfConfig(configPath)
+	assert.Nil(t, err)
+	assert.Equal(t, "alice", conf["user"]["name"])
+	assert.Equal(t, "10.0.0.1", conf["server"]["paddleflow_server_host"])
+
+	var got map[string]string
+	app := &cli.App{
+		Commands: []*cli.Command{{
+			Name: "mount",
+			Flags: flag.ExpandFlags([][]cli.Flag{flag.MountFlags(fuse.FuseConf), flag.BasicFlags(),
+				flag.CacheFlags(fuse.FuseConf), flag.UserFlags(fuse.FuseConf), flag.StandaloneFlags()}),
+			Action: func(c *cli.Context) error {
+				if err := prepareStandalone(c); err != nil {
+					return err
+				}
+				got = map[string]string{}
+				for _, name := range []string{"mount-point", "fs-id", "server", "user-name", "password", "data-cache-path"} {
+					got[name] = c.String(name)
+				}
+				return nil
+			},
+		}},
+	}
+	err = app.Run([]string{"pfs-fuse", "mount", "--pf-config", configPath, "myfs", "/mnt/myfs"})
+	assert.Nil(t, err)
+	assert.Equal(t, "/mnt/myfs", got["mount-point"])
+	assert.Equal(t, "fs-alice-myfs", got["fs-id"])
+	assert.Equal(t, "10.0.0.1:8999", got["server"])
+	assert.Equal(t, "alice", got["user-name"])
+	assert.Equal(t, "secret", got["password"])
+	assert.Equal(t, filepath.Join(dir, "cache", "fs-alice-myfs", "data-cache"), got["data-cache-path"])
+
+	// flags take precedence over config
+	err = app.Run([]string{"pfs-fuse", "mount", "--pf-config", configPath, "--fs-owner", "root",
+		"--server", "127.0.0.1:8999", "myfs", "/mnt/myfs"})
+	assert.Nil(t, err)
+	assert.Equal(t, "fs-root-myfs", got["fs-id"])
+	assert.Equal(t, "127.0.0.1:8999", got["server"])
+
+	err = app.Run([]string{"pfs-fuse", "mount", "--pf-config", configPath, "myfs"})
+	assert.NotNil(t, err)
+}
@@ -15,15 +15,27 @@ import (
 	_ "go.uber.org/automaxprocs"
 
 	"github.com/PaddlePaddle/PaddleFlow/cmd/server/flag"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/controller/alert"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/controller/cluster"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/controller/event"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/controller/export"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/controller/fs"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/controller/imagebuild"
 	jobCtrl "github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/controller/job"
+	runLog "github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/controller/log"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/controller/pipeline"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/controller/queue"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/controller/statistics"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/controller/user"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/controller/visualization"
 	router "github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/router/v1"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/config"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/envelope"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/http/outbound"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/uuid"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/job"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/metrics"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
@@ -112,6 +124,28 @@ func start() error {
 	stopChan := make(chan struct{})
 	defer close(stopChan)
 	go fs.MountPodController(ServerConf.Fs.MountPodExpire, ServerConf.Fs.MountPodIntervalTime, stopChan)
+	go visualization.Controller(stopChan)
+	go fs.DataLoadController(stopChan)
+	go fs.TransferController(stopChan)
+	go fs.FsUsageController(stopChan)
+	go fs.FsReplicationController(stopChan)
+	go fs.FsLifecycleController(stopChan)
+	go imagebuild.Controller(stopChan)
+	go jobCtrl.JobDurationController(stopChan)
+	go jobCtrl.JobArtifactController(stopChan)
+	go jobCtrl.JobPriorityAgingController(stopChan)
+	go jobCtrl.JobBurstController(stopChan)
+	go jobCtrl.JobAutoscaleController(stopChan)
+	go queue.QueueCapacityController(stopChan)
+	go pipeline.RunLimitController(stopChan)
+	go pipeline.ArtifactGCController(stopChan)
+	go user.SessionGCController(stopChan)
+	go statistics.RollupController(stopChan)
+	go alert.AlertController(stopChan)
+	go export.ExportController(stopChan)
+	go event.EventController(stopChan)
+	go runLog.JobMetricController(stopChan)
+	go config.WatchServerConfig(stopChan)
 
 	trace_logger.Start(ServerConf.TraceLog)
 
@@ -191,6 +225,23 @@ func setup() {
 
 	log.Infof("The final server config is: %s ", config.PrettyFormat(ServerConf))
 
+	if err := envelope.Init(ServerConf.Encryption); err != nil {
+		log.Errorf("init encryption err: %v", err)
+		gracefullyExit(err)
+	}
+
+	common.InitTrackingToken(ServerConf.ApiServer.TrackingTokenSecret)
+
+	if err := outbound.Init(ServerConf.Outbound); err != nil {
+		log.Errorf("init outbound http err: %v", err)
+		gracefullyExit(err)
+	}
+
+	if err := uuid.Init(ServerConf.IDGenerator); err != nil {
+		log.Errorf("init id generator err: %v", err)
+		gracefullyExit(err)
+	}
+
 	dbConf := &ServerConf.Storage
 	if err := driver.InitStorage(&config.StorageConfig{
 		Driver:   dbConf.Driver,
@@ -204,6 +255,17 @@ func setup() {
 		gracefullyExit(err)
 	}
 
+	if err := event.Init(ServerConf.EventBus); err != nil {
+		log.Errorf("init event bus err: %v", err)
+		gracefullyExit(err)
+	}
+
+	// encrypt credentials saved before encryption is enabled, and re-wrap them with the active key
+	if err := cluster.RotateCredentials(); err != nil {
+		log.Errorf("rotate cluster credentials err: %v", err)
+		gracefullyExit(err)
+	}
+
 	if err := newAndStartJobManager(); err != nil {
 		log.Errorf("create pfjob manager failed, err %v", err)
 		gracefullyExit(err)
@@ -259,7 +321,7 @@ func startMetricsService(port int) (err error) {
 		}
 	}()
 	listQueue := func() []model.Queue {
-		queues, err := storage.Queue.ListQueue(0, 0, "", "root")
+		queues, err := storage.Queue.ListQueue(0, 0, "", "root", "")
 		if err != nil {
 			log.Errorf("%s", err)
 		}
@@ -271,8 +333,24 @@ func startMetricsService(port int) (err error) {
 		return jobs
 	}
 
+	listFsCache := func() []model.FSCache {
+		caches, err := storage.FsCache.List("", "")
+		if err != nil {
+			log.Errorf("%s", err)
+		}
+		return caches
+	}
+
+	listFsReplication := func() []model.FsReplication {
+		replications, err := storage.FsReplication.ListReplication(log.NewEntry(log.StandardLogger()))
+		if err != nil {
+			log.Errorf("%s", err)
+		}
+		return replications
+	}
+
 	//  TODO: add job func
-	metrics.StartMetricsService(port, listQueue, listJobByStatus)
+	metrics.StartMetricsService(port, listQueue, listJobByStatus, listFsCache, listFsReplication)
 	return
 }
 
@@ -51,6 +51,10 @@ CREATE TABLE IF NOT EXISTS `queue` (
     `location` text DEFAULT NULL,
     `status` varchar(20) DEFAULT NULL,
     `scheduling_policy` varchar(2048) DEFAULT NULL,
+    `priority_aging` varchar(255) DEFAULT NULL COMMENT 'priority aging of waiting jobs',
+    `burst_policy` text DEFAULT NULL COMMENT 'burst policy of waiting jobs to other cluster',
+    `node_pool` varchar(64) NOT NULL DEFAULT '' COMMENT 'node pool that jobs of queue run on',
+    `capacity_schedule` text DEFAULT NULL COMMENT 'time windows of queue capacity',
     `created_at` datetime(3) DEFAULT NULL,
     `updated_at` datetime(3) DEFAULT NULL,
     `deleted_at` datetime(3) DEFAULT NULL,
@@ -77,13 +81,66 @@ CREATE TABLE IF NOT EXISTS `job` (
     `members` mediumtext DEFAULT NULL,
     `extension_template` mediumtext DEFAULT NULL,
     `parent_job` varchar(60) DEFAULT NULL,
+    `run_id` varchar(60) DEFAULT '' COMMENT 'run of pipeline job',
+    `step_name` varchar(512) DEFAULT '' COMMENT 'step of pipeline job',
+    `concurrency_group` varchar(255) DEFAULT '' COMMENT 'jobs of user in the same group run with limited concurrency',
+    `requeue_times` int DEFAULT 0 COMMENT 'times of job requeued due to node failure',
+    `requeue_reason` varchar(1024) DEFAULT '' COMMENT 'node failure reason of the last requeue',
+    `requeuing` tinyint(1) DEFAULT 0 COMMENT 'job is waiting for cluster job deleted before requeued',
+    `burst_from_queue` varchar(255) DEFAULT '' COMMENT 'queue of on-prem cluster which the job is burst from',
     `created_at` datetime(3) NULL DEFAULT CURRENT_TIMESTAMP(3),
     `activated_at` datetime(3) DEFAULT NULL,
     `updated_at` datetime(3) NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3),
     `deleted_at` varchar(64) DEFAULT '',
     PRIMARY KEY (`pk`),
     UNIQUE KEY `job_id` (`id`, `deleted_at`),
-    INDEX `status_queue_deleted` (`queue_id`, `status`, `deleted_at`)
+    INDEX `status_queue_deleted` (`queue_id`, `status`, `deleted_at`),
+    INDEX `idx_status_queue` (`status`, `deleted_at`, `queue_id`),
+    INDEX `idx_run_id` (`run_id`),
+    INDEX `idx_concurrency_group` (`concurrency_group`)
+) ENGINE=InnoDB DEFAULT CHARACTER SET utf8 COLLATE utf8_bin;
+
+CREATE TABLE IF NOT EXISTS `job_metric` (
+    `pk` bigint(20) NOT NULL AUTO_INCREMENT,
+    `job_id` varchar(60) NOT NULL,
+    `task_id` varchar(255) NOT NULL,
+    `key` varchar(256) NOT NULL,
+    `value` double NOT NULL,
+    `step` bigint(20) NOT NULL DEFAULT 0,
+    `timestamp` bigint(20) NOT NULL DEFAULT 0 COMMENT 'time of the log line in nanoseconds',
+    `created_at` datetime(3) DEFAULT NULL,
+    PRIMARY KEY (`pk`),
+    INDEX `idx_job_metric` (`job_id`, `task_id`)
+) ENGINE=InnoDB DEFAULT CHARACTER SET utf8 COLLATE utf8_bin;
+
+CREATE TABLE IF NOT EXISTS `job_artifact` (
+    `pk` bigint(20) NOT NULL AUTO_INCREMENT,
+    `job_id` varchar(60) NOT NULL,
+    `name` varchar(128) NOT NULL,
+    `fs_id` varchar(200) NOT NULL,
+    `fs_name` varchar(200) NOT NULL,
+    `path` varchar(1024) NOT NULL COMMENT 'path of artifact in file system',
+    `status` varchar(32) NOT NULL,
+    `digest` varchar(64) DEFAULT NULL,
+    `file_count` bigint(20) DEFAULT NULL,
+    `total_size` bigint(20) DEFAULT NULL,
+    `message` text,
+    `manifest` longtext,
+    `created_at` datetime(3) DEFAULT NULL,
+    `updated_at` datetime(3) DEFAULT NULL,
+    PRIMARY KEY (`pk`),
+    UNIQUE KEY `idx_job_artifact` (`job_id`, `name`),
+    INDEX `idx_job_artifact_status` (`status`)
+) ENGINE=InnoDB DEFAULT CHARACTER SET utf8 COLLATE utf8_bin;
+
+CREATE TABLE IF NOT EXISTS `job_timeline` (
+    `pk` bigint(20) NOT NULL AUTO_INCREMENT,
+    `job_id` varchar(60) NOT NULL,
+    `type` varchar(32) NOT NULL,
+    `message` text,
+    `created_at` datetime(3) DEFAULT NULL,
+    PRIMARY KEY (`pk`),
+    INDEX `idx_job_timeline_job` (`job_id`)
 ) ENGINE=InnoDB DEFAULT CHARACTER SET utf8 COLLATE utf8_bin;
 
 CREATE TABLE IF NOT EXISTS `job_label` (
@@ -121,6 +178,12 @@ CREATE TABLE IF NOT EXISTS `user` (
     `pk` bigint(20) NOT NULL AUTO_INCREMENT,
     `name` VARCHAR(60) NOT NULL COMMENT 'unique identify',
     `password` VARCHAR(256) NOT NULL COMMENT 'encode password',
+    `email` VARCHAR(256) NOT NULL DEFAULT '' COMMENT 'email for password reset',
+    `failed_logins` int NOT NULL DEFAULT 0 COMMENT 'consecutive failed logins',
+    `locked_until` datetime DEFAULT NULL COMMENT 'locked until this time after too many failed logins',
+    `password_updated_at` datetime DEFAULT NULL COMMENT 'last password update time',
+    `reset_token_hash` VARCHAR(64) NOT NULL DEFAULT '' COMMENT 'sha256 of password reset token',
+    `reset_token_expire_at` datetime DEFAULT NULL COMMENT 'password reset token expire time',
     `created_at` datetime DEFAULT NULL COMMENT 'create time',
     `updated_at` datetime DEFAULT NULL COMMENT 'update time',
     `deleted_at` datetime DEFAULT NULL COMMENT 'delete time',
@@ -158,6 +221,7 @@ CREATE TABLE IF NOT EXISTS `run` (
     `fs_name` varchar(60) NOT NULL,
     `description` text NOT NULL,
     `parameters_json` text NOT NULL,
+    `resolved_parameters_json` text,
     `run_yaml` text NOT NULL,
     `docker_env` varchar(128) NOT NULL,
     `disabled` text NOT NULL,
@@ -196,6 +260,7 @@ CREATE TABLE IF NOT EXISTS `run_job` (
     `cache_run_id` varchar(60),
     `cache_job_id` varchar(60),
     `extra_fs_json` text,
+    `attempts_json` text,
     `created_at` datetime(3) DEFAULT NULL,
     `activated_at` datetime(3) DEFAULT NULL,
     `updated_at` datetime(3) DEFAULT NULL,
@@ -289,6 +354,8 @@ CREATE TABLE IF NOT EXISTS `schedule` (
     `start_at` datetime(3) DEFAULT NULL,
     `end_at` datetime(3) DEFAULT NULL,
     `next_run_at` datetime(3) DEFAULT NULL,
+    `trigger_watermark` datetime(3) DEFAULT NULL,
+    `trigger_file` varchar(4096) NOT NULL DEFAULT '',
     `created_at` datetime(3) DEFAULT NULL,
     `updated_at` datetime(3) DEFAULT NULL,
     `deleted_at` datetime(3) DEFAULT NULL,
@@ -332,6 +399,7 @@ CREATE TABLE IF NOT EXISTS `artifact_event` (
     `artifact_name` varchar(32) Not Null,
     `type` varchar(16) Not Null,
     `meta` text,
+    `pruned` tinyint(1) NOT NULL DEFAULT 0,
     `created_at` datetime(3) DEFAULT NULL,
     `updated_at` datetime(3) DEFAULT NULL,
     `deleted_at` datetime(3) DEFAULT NULL,
@@ -341,6 +409,86 @@ CREATE TABLE IF NOT EXISTS `artifact_event` (
     INDEX (`run_id`)
 ) ENGINE=InnoDB DEFAULT CHARACTER SET utf8 COLLATE utf8_bin;
 
+CREATE TABLE IF NOT EXISTS `run_metric` (
+    `pk` bigint(20) NOT NULL AUTO_INCREMENT,
+    `run_id` varchar(60) NOT NULL,
+    `job_id` varchar(60) DEFAULT NULL,
+    `key` varchar(256) NOT NULL,
+    `value` double NOT NULL,
+    `step` bigint(20) NOT NULL DEFAULT 0,
+    `timestamp` bigint(20) NOT NULL DEFAULT 0,
+    `created_at` datetime(3) DEFAULT NULL,
+    PRIMARY KEY (`pk`),
+    INDEX (`run_id`)
+) ENGINE=InnoDB DEFAULT CHARACTER SET utf8 COLLATE utf8_bin;
+
+CREATE TABLE IF NOT EXISTS `run_param` (
+    `pk` bigint(20) NOT NULL AUTO_INCREMENT,
+    `run_id` varchar(60) NOT NULL,
+    `job_id` varchar(60) DEFAULT NULL,
+    `type` varchar(16) NOT NULL,
+    `key` varchar(256) NOT NULL,
+    `value` text,
+    `created_at` datetime(3) DEFAULT NULL,
+    `updated_at` datetime(3) DEFAULT NULL,
+    PRIMARY KEY (`pk`),
+    INDEX (`run_id`)
+) ENGINE=InnoDB DEFAULT CHARACTER SET utf8 COLLATE utf8_bin;
+
+CREATE TABLE IF NOT EXISTS `visualization` (
+    `pk` bigint(20) NOT NULL AUTO_INCREMENT,
+    `id` varchar(60) NOT NULL,
+    `type` varchar(32) NOT NULL,
+    `user_name` varchar(60) NOT NULL,
+    `job_id` varchar(60) DEFAULT NULL,
+    `fs_id` varchar(200) DEFAULT NULL,
+    `fs_name` varchar(200) DEFAULT NULL,
+    `log_dir` varchar(1024) DEFAULT NULL,
+    `cluster_id` varchar(60) DEFAULT NULL,
+    `namespace` varchar(64) DEFAULT NULL,
+    `status` varchar(32) DEFAULT NULL,
+    `url` varchar(256) DEFAULT NULL,
+    `message` text,
+    `expired_at` datetime(3) DEFAULT NULL,
+    `created_at` datetime(3) DEFAULT NULL,
+    `updated_at` datetime(3) DEFAULT NULL,
+    PRIMARY KEY (`pk`),
+    UNIQUE KEY (`id`),
+    INDEX (`job_id`),
+    INDEX (`status`)
+) ENGINE=InnoDB DEFAULT CHARACTER SET utf8 COLLATE utf8_bin;
+
+CREATE TABLE IF NOT EXISTS `dataset` (
+    `pk` bigint(20) NOT NULL AUTO_INCREMENT,
+    `id` varchar(60) NOT NULL,
+    `name` varchar(128) NOT NULL,
+    `user_name` varchar(60) NOT NULL,
+    `fs_id` varchar(200) NOT NULL,
+    `fs_name` varchar(200) NOT NULL,
+    `path` varchar(1024) NOT NULL,
+    `description` varchar(1024) DEFAULT NULL,
+    `latest_version` varchar(32) DEFAULT NULL,
+    `created_at` datetime(3) DEFAULT NULL,
+    `updated_at` datetime(3) DEFAULT NULL,
+    PRIMARY KEY (`pk`),
+    UNIQUE KEY (`id`),
+    UNIQUE KEY `idx_dataset_user_name` (`name`, `user_name`)
+) ENGINE=InnoDB DEFAULT CHARACTER SET utf8 COLLATE utf8_bin;
+
+CREATE TABLE IF NOT EXISTS `dataset_version` (
+    `pk` bigint(20) NOT NULL AUTO_INCREMENT,
+    `dataset_id` varchar(60) NOT NULL,
+    `version` varchar(32) NOT NULL,
+    `digest` varchar(64) NOT NULL,
+    `file_count` bigint(20) DEFAULT NULL,
+    `total_size` bigint(20) DEFAULT NULL,
+    `description` varchar(1024) DEFAULT NULL,
+    `manifest` longtext,
+    `created_at` datetime(3) DEFAULT NULL,
+    PRIMARY KEY (`pk`),
+    UNIQUE KEY `idx_dataset_version` (`dataset_id`, `version`)
+) ENGINE=InnoDB DEFAULT CHARACTER SET utf8 COLLATE utf8_bin;
+
 CREATE TABLE IF NOT EXISTS `filesystem` (
     `pk` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'pk',
     `id` varchar(200) NOT NULL COMMENT 'id',
@@ -350,6 +498,8 @@ CREATE TABLE IF NOT EXISTS `filesystem` (
     `subpath` varchar(1024) NOT NULL COMMENT 'subpath',
     `user_name` varchar(256) NOT NULL,
     `independent_mount_process` tinyint(1) NOT NULL default 0 COMMENT 'csi mount use independent mount process',
+    `capacity_quota` bigint(20) NOT NULL default 0 COMMENT 'capacity quota in bytes, 0 means unlimited',
+    `inode_quota` bigint(20) NOT NULL default 0 COMMENT 'inode quota, 0 means unlimited',
     `created_at` datetime NOT NULL,
     `updated_at` datetime NOT NULL,
     `properties` TEXT,
@@ -379,7 +529,22 @@ CREATE TABLE IF NOT EXISTS `fs_cache_config` (
     `cache_dir` varchar(4096) NOT NULL COMMENT 'cache dir, e.g. /var/pfs_cache',
     `quota` bigint(20) NOT NULL COMMENT 'cache quota',
     `block_size` int(5) NOT NULL COMMENT 'cache block size',
-    `meta_driver` varchar(32) NOT NULL COMMENT 'meta_driver，e.g. mem/disk',
+    `max_cache_size` varchar(32) NOT NULL DEFAULT '' COMMENT 'max size of data cache, e.g. 100Gi',
+    `eviction_policy` varchar(32) NOT NULL DEFAULT '' COMMENT 'data cache eviction policy, e.g. lru/lfu/ttl',
+    `cache_ttl` varchar(32) NOT NULL DEFAULT '' COMMENT 'expire time of each cached block, e.g. 24h',
+    `mem_cache_size` varchar(32) NOT NULL DEFAULT '' COMMENT 'size of memory tier of data cache in fuse process, e.g. 1Gi',
+    `write_back` tinyint(1) NOT NULL DEFAULT 0 COMMENT 'stage written files in cache dir and upload them asynchronously',
+    `write_back_dirty_limit` varchar(32) NOT NULL DEFAULT '' COMMENT 'max size of staged data not yet uploaded, e.g. 10Gi',
+    `mount_pod_policy` varchar(32) NOT NULL DEFAULT '' COMMENT 'shared or dedicated mount pod on each node',
+    `cache_compression` varchar(16) NOT NULL DEFAULT '' COMMENT 'compression of cached blocks on local disk, e.g. zstd/s2',
+    `cache_encryption` tinyint(1) NOT NULL DEFAULT 0 COMMENT 'encrypt cached blocks on local disk with the per-fs key',
+    `prefetch_blocks` int(11) NOT NULL DEFAULT 0 COMMENT 'max blocks prefetched into data cache on sequential read, 0 disables prefetch',
+    `prefetch_workers` int(11) NOT NULL DEFAULT 0 COMMENT 'concurrent prefetch workers, 0 uses fuse default',
+    `attr_cache_ttl` varchar(32) NOT NULL DEFAULT '' COMMENT 'ttl of cached file attributes in fuse, e.g. 5s',
+    `entry_cache_ttl` varchar(32) NOT NULL DEFAULT '' COMMENT 'ttl of cached directory listings in fuse, e.g. 5s',
+    `negative_cache_ttl` varchar(32) NOT NULL DEFAULT '' COMMENT 'ttl of cached not existing entries in fuse, empty disables it',
+    `meta_driver` varchar(32) NOT NULL COMMENT 'meta_driver，e.g. mem/disk/redis/etcd',
+    `meta_address` varchar(1024) NOT NULL DEFAULT '' COMMENT 'address of shared meta driver, e.g. redis://:password@host:6379/0',
     `debug` tinyint(1) NOT NULL COMMENT 'turn on debug log',
     `clean_cache` tinyint(1) NOT NULL default 0 COMMENT 'whether clean cache after mount pod vanishes',
     `resource` text COMMENT 'resource limit for mount pod',
@@ -402,6 +567,12 @@ CREATE TABLE IF NOT EXISTS `fs_cache` (
     `cache_dir` varchar(4096) NOT NULL COMMENT 'cache dir, e.g. /var/pfs_cache',
     `nodename` varchar(255) NOT NULL COMMENT 'node name',
     `usedsize` bigint(20) NOT NULL COMMENT 'cache used size on cache dir',
+    `cache_hits` bigint(20) NOT NULL DEFAULT 0 COMMENT 'cache hit count',
+    `cache_misses` bigint(20) NOT NULL DEFAULT 0 COMMENT 'cache miss count',
+    `hit_bytes` bigint(20) NOT NULL DEFAULT 0 COMMENT 'bytes read from cache',
+    `miss_bytes` bigint(20) NOT NULL DEFAULT 0 COMMENT 'bytes read from storage on cache miss',
+    `evictions` bigint(20) NOT NULL DEFAULT 0 COMMENT 'evicted cache blocks',
+    `read_throughput` bigint(20) NOT NULL DEFAULT 0 COMMENT 'read throughput in bytes per second',
     `created_at` datetime NOT NULL COMMENT 'create time',
     `updated_at` datetime NOT NULL COMMENT 'update time',
     `deleted_at` datetime(3) DEFAULT NULL  COMMENT 'delete time',
@@ -411,6 +582,350 @@ CREATE TABLE IF NOT EXISTS `fs_cache` (
     INDEX idx_fs_id_nodename (`fs_id`,`nodename`)
     )ENGINE=InnoDB DEFAULT CHARACTER SET utf8 COLLATE utf8_bin ROW_FORMAT=COMPRESSED KEY_BLOCK_SIZE=8 COMMENT='manage file system cache ';
 
+CREATE TABLE IF NOT EXISTS `fs_data_load` (
+    `pk` bigint(20) NOT NULL AUTO_INCREMENT,
+    `id` varchar(60) NOT NULL,
+    `user_name` varchar(60) NOT NULL,
+    `fs_id` varchar(200) DEFAULT NULL,
+    `fs_name` varchar(200) DEFAULT NULL,
+    `cluster_id` varchar(60) DEFAULT NULL,
+    `namespace` varchar(64) DEFAULT NULL,
+    `paths` text COMMENT 'path patterns to load, json list',
+    `nodes` text COMMENT 'load progress of each node, json list',
+    `total_files` bigint(20) DEFAULT NULL,
+    `total_bytes` bigint(20) DEFAULT NULL,
+    `status` varchar(32) DEFAULT NULL,
+    `message` text,
+    `created_at` datetime(3) DEFAULT NULL,
+    `updated_at` datetime(3) DEFAULT NULL,
+    PRIMARY KEY (`pk`),
+    UNIQUE KEY (`id`),
+    INDEX (`fs_id`),
+    INDEX (`status`)
+) ENGINE=InnoDB DEFAULT CHARACTER SET utf8 COLLATE utf8_bin COMMENT='file system cache data load';
+
+CREATE TABLE IF NOT EXISTS `fs_transfer` (
+    `pk` bigint(20) NOT NULL AUTO_INCREMENT,
+    `id` varchar(60) NOT NULL,
+    `user_name` varchar(60) NOT NULL,
+    `src_fs_id` varchar(200) DEFAULT NULL,
+    `src_fs_name` varchar(200) DEFAULT NULL,
+    `src_path` varchar(1024) DEFAULT NULL,
+    `dst_fs_id` varchar(200) DEFAULT NULL,
+    `dst_fs_name` varchar(200) DEFAULT NULL,
+    `dst_path` varchar(1024) DEFAULT NULL,
+    `cluster_id` varchar(60) DEFAULT NULL,
+    `namespace` varchar(64) DEFAULT NULL,
+    `mode` varchar(16) DEFAULT NULL COMMENT 'copy or sync',
+    `parallelism` bigint(20) DEFAULT NULL COMMENT 'number of files transferred in parallel',
+    `bandwidth_limit` varchar(32) DEFAULT NULL COMMENT 'max bytes transferred per second, e.g. 100Mi',
+    `checksum` tinyint(1) DEFAULT NULL COMMENT 'compare files by checksum instead of size and modify time',
+    `attempt` bigint(20) DEFAULT NULL,
+    `pod_name` varchar(128) DEFAULT NULL,
+    `transferred_files` bigint(20) DEFAULT NULL,
+    `transferred_bytes` bigint(20) DEFAULT NULL,
+    `total_files` bigint(20) DEFAULT NULL,
+    `total_bytes` bigint(20) DEFAULT NULL,
+    `checked_files` bigint(20) DEFAULT NULL,
+    `errors` bigint(20) DEFAULT NULL,
+    `status` varchar(32) DEFAULT NULL,
+    `message` text,
+    `created_at` datetime(3) DEFAULT NULL,
+    `updated_at` datetime(3) DEFAULT NULL,
+    PRIMARY KEY (`pk`),
+    UNIQUE KEY (`id`),
+    INDEX (`src_fs_id`),
+    INDEX (`dst_fs_id`),
+    INDEX (`status`)
+) ENGINE=InnoDB DEFAULT CHARACTER SET utf8 COLLATE utf8_bin COMMENT='data transfer between file systems';
+
+CREATE TABLE IF NOT EXISTS `fs_replication` (
+    `pk` bigint(20) NOT NULL AUTO_INCREMENT,
+    `fs_id` varchar(200) NOT NULL COMMENT 'primary fs',
+    `fs_name` varchar(200) DEFAULT NULL,
+    `user_name` varchar(60) DEFAULT NULL,
+    `replica_fs_id` varchar(200) NOT NULL COMMENT 'replica fs, usually in another region',
+    `replica_fs_name` varchar(200) DEFAULT NULL,
+    `status` varchar(32) DEFAULT NULL COMMENT 'active or failedOver',
+    `pending_files` bigint(20) DEFAULT NULL,
+    `pending_bytes` bigint(20) DEFAULT NULL,
+    `lag_seconds` bigint(20) DEFAULT NULL,
+    `replicated_files` bigint(20) DEFAULT NULL,
+    `replicated_bytes` bigint(20) DEFAULT NULL,
+    `last_synced_at` datetime(3) DEFAULT NULL COMMENT 'files written before this time are all replicated',
+    `message` text,
+    `created_at` datetime(3) DEFAULT NULL,
+    `updated_at` datetime(3) DEFAULT NULL,
+    PRIMARY KEY (`pk`),
+    UNIQUE KEY (`fs_id`),
+    UNIQUE KEY (`replica_fs_id`)
+) ENGINE=InnoDB DEFAULT CHARACTER SET utf8 COLLATE utf8_bin COMMENT='asynchronous replication of file systems';
+
+CREATE TABLE IF NOT EXISTS `fs_lifecycle_rule` (
+    `pk` bigint(20) NOT NULL AUTO_INCREMENT,
+    `fs_id` varchar(200) NOT NULL,
+    `name` varchar(200) NOT NULL,
+    `fs_name` varchar(200) DEFAULT NULL,
+    `user_name` varchar(60) DEFAULT NULL,
+    `path` varchar(4096) DEFAULT NULL COMMENT 'directory the rule applies to',
+    `action` varchar(32) DEFAULT NULL COMMENT 'archive or delete',
+    `days` bigint(20) DEFAULT NULL COMMENT 'files not modified for days are matched',
+    `storage_class` varchar(64) DEFAULT NULL COMMENT 'target storage class of archive',
+    `dry_run` tinyint(1) DEFAULT NULL COMMENT 'only report matched files',
+    `matched_files` bigint(20) DEFAULT NULL,
+    `matched_bytes` bigint(20) DEFAULT NULL,
+    `processed_files` bigint(20) DEFAULT NULL,
+    `processed_bytes` bigint(20) DEFAULT NULL,
+    `last_run_at` datetime(3) DEFAULT NULL,
+    `message` text,
+    `created_at` datetime(3) DEFAULT NULL,
+    `updated_at` datetime(3) DEFAULT NULL,
+    PRIMARY KEY (`pk`),
+    UNIQUE KEY `idx_fs_lifecycle_rule` (`fs_id`, `name`)
+) ENGINE=InnoDB DEFAULT CHARACTER SET utf8 COLLATE utf8_bin COMMENT='lifecycle rules of file systems';
+
+CREATE TABLE IF NOT EXISTS `image_build` (
+    `pk` bigint(20) NOT NULL AUTO_INCREMENT,
+    `id` varchar(60) NOT NULL,
+    `user_name` varchar(60) NOT NULL,
+    `name` varchar(128) DEFAULT NULL,
+    `tag` varchar(128) DEFAULT NULL,
+    `queue_name` varchar(255) DEFAULT NULL,
+    `cluster_id` varchar(60) DEFAULT NULL,
+    `namespace` varchar(64) DEFAULT NULL,
+    `dockerfile` text,
+    `base_image` varchar(512) DEFAULT NULL,
+    `requirements` text COMMENT 'pip requirements in json',
+    `image` varchar(512) DEFAULT NULL COMMENT 'image pushed to registry',
+    `pod_name` varchar(128) DEFAULT NULL,
+    `status` varchar(32) DEFAULT NULL,
+    `message` text,
+    `created_at` datetime(3) DEFAULT NULL,
+    `updated_at` datetime(3) DEFAULT NULL,
+    PRIMARY KEY (`pk`),
+    UNIQUE KEY (`id`),
+    INDEX (`status`)
+) ENGINE=InnoDB DEFAULT CHARACTER SET utf8 COLLATE utf8_bin COMMENT='container image builds';
+
+CREATE TABLE IF NOT EXISTS `user_preference` (
+    `pk` bigint(20) NOT NULL AUTO_INCREMENT,
+    `user_name` varchar(60) NOT NULL,
+    `queue` varchar(255) DEFAULT '' COMMENT 'default queue name',
+    `flavour` varchar(255) DEFAULT '' COMMENT 'default flavour name',
+    `image` varchar(512) DEFAULT '' COMMENT 'default image',
+    `fs` varchar(255) DEFAULT '' COMMENT 'default fs name',
+    `created_at` datetime(3) DEFAULT NULL,
+    `updated_at` datetime(3) DEFAULT NULL,
+    PRIMARY KEY (`pk`),
+    UNIQUE KEY (`user_name`)
+) ENGINE=InnoDB DEFAULT CHARACTER SET utf8 COLLATE utf8_bin COMMENT='default settings of job creation per user';
+
+CREATE TABLE IF NOT EXISTS `project` (
+    `pk` bigint(20) NOT NULL AUTO_INCREMENT,
+    `name` varchar(255) NOT NULL,
+    `description` varchar(1024) DEFAULT '',
+    `max_resources` text COMMENT 'quota of all queues in project',
+    `created_at` datetime(3) DEFAULT NULL,
+    `updated_at` datetime(3) DEFAULT NULL,
+    PRIMARY KEY (`pk`),
+    UNIQUE KEY (`name`)
+) ENGINE=InnoDB DEFAULT CHARACTER SET utf8 COLLATE utf8_bin COMMENT='projects grouping users and resources';
+
+CREATE TABLE IF NOT EXISTS `project_member` (
+    `pk` bigint(20) NOT NULL AUTO_INCREMENT,
+    `project_name` varchar(255) NOT NULL,
+    `resource_type` varchar(36) NOT NULL COMMENT 'user/queue/fs/pipeline',
+    `resource_id` varchar(255) NOT NULL,
+    `role` varchar(36) DEFAULT '' COMMENT 'admin/member, only for user',
+    `created_at` datetime(3) DEFAULT NULL,
+    PRIMARY KEY (`pk`),
+    UNIQUE KEY `idx_project_member` (`project_name`, `resource_type`, `resource_id`)
+) ENGINE=InnoDB DEFAULT CHARACTER SET utf8 COLLATE utf8_bin COMMENT='members and resources of projects';
+
+CREATE TABLE IF NOT EXISTS `user_quota` (
+    `pk` bigint(20) NOT NULL AUTO_INCREMENT,
+    `user_name` varchar(60) NOT NULL,
+    `max_concurrent_jobs` int(11) DEFAULT 0 COMMENT 'max jobs submitted to cluster at the same time, 0 means no limit',
+    `max_gpus` bigint(20) DEFAULT 0 COMMENT 'max gpus of jobs submitted to cluster, 0 means no limit',
+    `max_job_duration` bigint(20) DEFAULT 0 COMMENT 'max running seconds of a job, 0 means no limit',
+    `created_at` datetime(3) DEFAULT NULL,
+    `updated_at` datetime(3) DEFAULT NULL,
+    PRIMARY KEY (`pk`),
+    UNIQUE KEY (`user_name`)
+) ENGINE=InnoDB DEFAULT CHARACTER SET utf8 COLLATE utf8_bin COMMENT='job limits per user, independent of queue capacity';
+
+CREATE TABLE IF NOT EXISTS `user_session` (
+    `pk` bigint(20) NOT NULL AUTO_INCREMENT,
+    `id` varchar(60) NOT NULL,
+    `user_name` varchar(60) NOT NULL,
+    `client_ip` varchar(64) DEFAULT '',
+    `user_agent` varchar(256) DEFAULT '',
+    `expire_at` datetime(3) DEFAULT NULL COMMENT 'same as token expiration, null means never expire',
+    `last_active_at` datetime(3) DEFAULT NULL,
+    `revoked_at` datetime(3) DEFAULT NULL,
+    `created_at` datetime(3) DEFAULT NULL,
+    `updated_at` datetime(3) DEFAULT NULL,
+    PRIMARY KEY (`pk`),
+    UNIQUE KEY (`id`),
+    INDEX idx_user_name (`user_name`)
+) ENGINE=InnoDB DEFAULT CHARACTER SET utf8 COLLATE utf8_bin COMMENT='login sessions, tokens of revoked sessions are rejected';
+
+CREATE TABLE IF NOT EXISTS `impersonation_audit` (
+    `pk` bigint(20) NOT NULL AUTO_INCREMENT,
+    `request_id` varchar(60) DEFAULT '',
+    `operator` varchar(60) NOT NULL COMMENT 'the root user who sent the request',
+    `user_name` varchar(60) NOT NULL COMMENT 'the impersonated user',
+    `method` varchar(16) NOT NULL,
+    `path` varchar(1024) NOT NULL,
+    `status_code` int(11) DEFAULT 0,
+    `client_ip` varchar(64) DEFAULT '',
+    `created_at` datetime(3) DEFAULT NULL,
+    PRIMARY KEY (`pk`),
+    INDEX idx_operator (`operator`),
+    INDEX idx_user_name (`user_name`)
+) ENGINE=InnoDB DEFAULT CHARACTER SET utf8 COLLATE utf8_bin COMMENT='audit of requests sent by root on behalf of other users';
+
+CREATE TABLE IF NOT EXISTS `fs_usage` (
+    `pk` bigint(20) NOT NULL AUTO_INCREMENT,
+    `fs_id` varchar(200) NOT NULL,
+    `path` varchar(1024) NOT NULL COMMENT '/ for the whole fs, otherwise a top-level directory',
+    `size` bigint(20) DEFAULT NULL,
+    `files` bigint(20) DEFAULT NULL,
+    `dirs` bigint(20) DEFAULT NULL,
+    `scan_time` datetime(3) DEFAULT NULL,
+    `created_at` datetime(3) DEFAULT NULL,
+    PRIMARY KEY (`pk`),
+    INDEX `idx_fs_usage_scan` (`fs_id`, `scan_time`)
+) ENGINE=InnoDB DEFAULT CHARACTER SET utf8 COLLATE utf8_bin COMMENT='fs usage scanned in background';
+
+CREATE TABLE IF NOT EXISTS `fs_acl` (
+    `pk` bigint(20) NOT NULL AUTO_INCREMENT,
+    `fs_id` varchar(200) NOT NULL,
+    `grantee_type` varchar(32) NOT NULL COMMENT 'user or queue',
+    `grantee_name` varchar(255) NOT NULL,
+    `permission` varchar(32) NOT NULL COMMENT 'r or rw',
+    `created_at` datetime(3) DEFAULT NULL,
+    `updated_at` datetime(3) DEFAULT NULL,
+    PRIMARY KEY (`pk`),
+    UNIQUE INDEX `idx_fs_acl_grantee` (`fs_id`, `grantee_type`, `grantee_name`)
+) ENGINE=InnoDB DEFAULT CHARACTER SET utf8 COLLATE utf8_bin COMMENT='fs access granted by owner';
+
+CREATE TABLE IF NOT EXISTS `stat_queue_hourly` (
+    `pk` bigint(20) NOT NULL AUTO_INCREMENT,
+    `bucket` datetime(3) NOT NULL COMMENT 'start of the hour',
+    `queue_name` varchar(255) NOT NULL,
+    `pending_jobs` int(11) DEFAULT 0 COMMENT 'pending jobs at the end of the hour',
+    `running_jobs` int(11) DEFAULT 0 COMMENT 'running jobs at the end of the hour',
+    `succeeded_jobs` int(11) DEFAULT 0,
+    `failed_jobs` int(11) DEFAULT 0,
+    `terminated_jobs` int(11) DEFAULT 0,
+    `gpu_hours` double DEFAULT 0,
+    `cpu_hours` double DEFAULT 0,
+    `capacity_gpus` double DEFAULT 0,
+    `capacity_cpu` double DEFAULT 0,
+    `created_at` datetime(3) DEFAULT NULL,
+    PRIMARY KEY (`pk`),
+    UNIQUE INDEX `idx_queue_bucket` (`bucket`, `queue_name`)
+) ENGINE=InnoDB DEFAULT CHARACTER SET utf8 COLLATE utf8_bin COMMENT='hourly job status and resource usage of queues';
+
+CREATE TABLE IF NOT EXISTS `stat_user_hourly` (
+    `pk` bigint(20) NOT NULL AUTO_INCREMENT,
+    `bucket` datetime(3) NOT NULL COMMENT 'start of the hour',
+    `user_name` varchar(60) NOT NULL,
+    `gpu_hours` double DEFAULT 0,
+    `job_count` int(11) DEFAULT 0,
+    `created_at` datetime(3) DEFAULT NULL,
+    PRIMARY KEY (`pk`),
+    UNIQUE INDEX `idx_user_bucket` (`bucket`, `user_name`)
+) ENGINE=InnoDB DEFAULT CHARACTER SET utf8 COLLATE utf8_bin COMMENT='hourly gpu hours of users';
+
+CREATE TABLE IF NOT EXISTS `stat_image_hourly` (
+    `pk` bigint(20) NOT NULL AUTO_INCREMENT,
+    `bucket` datetime(3) NOT NULL COMMENT 'start of the hour',
+    `image` varchar(255) NOT NULL,
+    `finished_jobs` int(11) DEFAULT 0,
+    `failed_jobs` int(11) DEFAULT 0,
+    `created_at` datetime(3) DEFAULT NULL,
+    PRIMARY KEY (`pk`),
+    UNIQUE INDEX `idx_image_bucket` (`bucket`, `image`)
+) ENGINE=InnoDB DEFAULT CHARACTER SET utf8 COLLATE utf8_bin COMMENT='hourly finished and failed jobs of images';
+
+CREATE TABLE IF NOT EXISTS `alert_rule` (
+    `pk` bigint(20) NOT NULL AUTO_INCREMENT,
+    `id` varchar(60) NOT NULL,
+    `name` varchar(128) NOT NULL,
+    `metric` varchar(64) NOT NULL COMMENT 'queue_pending_jobs, user_failure_rate or fs_cache_hit_rate',
+    `target` varchar(255) DEFAULT '' COMMENT 'queue name, user name or fs id, empty means all',
+    `operator` varchar(8) NOT NULL,
+    `threshold` double DEFAULT 0,
+    `for_seconds` int(11) DEFAULT 0,
+    `window_seconds` int(11) DEFAULT 0,
+    `channels` text COMMENT 'json of emails, slack and webhooks',
+    `enabled` tinyint(1) DEFAULT 1,
+    `description` varchar(1024) DEFAULT '',
+    `created_by` varchar(60) DEFAULT '',
+    `created_at` datetime(3) DEFAULT NULL,
+    `updated_at` datetime(3) DEFAULT NULL,
+    PRIMARY KEY (`pk`),
+    UNIQUE INDEX `idx_alert_rule_id` (`id`),
+    UNIQUE INDEX `idx_alert_rule_name` (`name`)
+) ENGINE=InnoDB DEFAULT CHARACTER SET utf8 COLLATE utf8_bin COMMENT='alert rules evaluated periodically';
+
+CREATE TABLE IF NOT EXISTS `export_watermark` (
+    `name` varchar(64) NOT NULL COMMENT 'type of exported records',
+    `time` datetime(3) DEFAULT NULL COMMENT 'time of the last exported record',
+    `record_pk` bigint(20) DEFAULT 0 COMMENT 'pk of the last exported record',
+    `updated_at` datetime(3) DEFAULT NULL,
+    PRIMARY KEY (`name`)
+) ENGINE=InnoDB DEFAULT CHARACTER SET utf8 COLLATE utf8_bin COMMENT='watermarks of records exported to warehouse';
+
+CREATE TABLE IF NOT EXISTS `resource_event` (
+    `pk` bigint(20) NOT NULL AUTO_INCREMENT,
+    `id` varchar(64) NOT NULL COMMENT 'event id',
+    `resource_type` varchar(32) NOT NULL COMMENT 'job, run, queue or fs',
+    `resource_id` varchar(64) NOT NULL,
+    `type` varchar(32) NOT NULL COMMENT 'created, status_changed or deleted',
+    `status` varchar(32) DEFAULT '',
+    `previous_status` varchar(32) DEFAULT '',
+    `user_name` varchar(60) DEFAULT '',
+    `published` tinyint(1) NOT NULL DEFAULT 0,
+    `created_at` datetime(3) DEFAULT NULL,
+    `published_at` datetime(3) DEFAULT NULL,
+    PRIMARY KEY (`pk`),
+    UNIQUE KEY `idx_resource_event_id` (`id`),
+    INDEX `idx_resource_event_published` (`published`)
+) ENGINE=InnoDB DEFAULT CHARACTER SET utf8 COLLATE utf8_bin COMMENT='outbox of resource events published to message bus';
+
+CREATE TABLE IF NOT EXISTS `cluster_component` (
+    `pk` bigint(20) NOT NULL AUTO_INCREMENT,
+    `cluster_id` varchar(60) NOT NULL COMMENT 'cluster id',
+    `name` varchar(64) NOT NULL COMMENT 'component name, e.g. volcano, paddle-operator or csi-driver',
+    `version` varchar(128) NOT NULL DEFAULT '' COMMENT 'pinned version of component images',
+    `status` varchar(32) NOT NULL DEFAULT '' COMMENT 'installing, installed or failed',
+    `message` text DEFAULT NULL,
+    `created_at` datetime(3) DEFAULT NULL,
+    `updated_at` datetime(3) DEFAULT NULL,
+    PRIMARY KEY (`pk`),
+    UNIQUE KEY `idx_cluster_component` (`cluster_id`, `name`)
+) ENGINE=InnoDB DEFAULT CHARACTER SET utf8 COLLATE utf8_bin COMMENT='components provisioned into clusters by paddleflow';
+
+CREATE TABLE IF NOT EXISTS `node_pool` (
+    `pk` bigint(20) NOT NULL AUTO_INCREMENT,
+    `id` varchar(60) NOT NULL COMMENT 'node pool id',
+    `name` varchar(64) NOT NULL COMMENT 'node pool name, unique in cluster',
+    `cluster_id` varchar(60) NOT NULL COMMENT 'cluster id',
+    `gpu_type` varchar(64) NOT NULL DEFAULT '' COMMENT 'gpu type of nodes, e.g. v100',
+    `zone` varchar(64) NOT NULL DEFAULT '' COMMENT 'zone of nodes',
+    `team` varchar(64) NOT NULL DEFAULT '' COMMENT 'team that nodes are dedicated to',
+    `description` varchar(1024) NOT NULL DEFAULT '',
+    `nodes` text DEFAULT NULL COMMENT 'json type, names of nodes in pool',
+    `created_at` datetime(3) DEFAULT NULL,
+    `updated_at` datetime(3) DEFAULT NULL,
+    PRIMARY KEY (`pk`),
+    UNIQUE KEY `idx_node_pool_id` (`id`),
+    UNIQUE KEY `idx_node_pool_name` (`cluster_id`, `name`)
+) ENGINE=InnoDB DEFAULT CHARACTER SET utf8 COLLATE utf8_bin COMMENT='node pools labeled by paddleflow';
+
 CREATE TABLE IF NOT EXISTS `paddleflow_node_info` (
     `pk` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'pk',
     `cluster_id` varchar(255) NOT NULL DEFAULT '',
@@ -0,0 +1,25 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package installer 内嵌PaddleFlow可以安装到集群中的组件的部署文件
+package installer
+
+import "embed"
+
+//go:embed deploys/volcano/pf-volcano-deploy.yaml
+//go:embed deploys/paddleflow-csi-plugin/paddleflow-csi-plugin-deploy.yaml
+//go:embed deploys/paddle-operator/paddle-operator-deploy.yaml
+var Manifests embed.FS
@@ -19,15 +19,25 @@ package common
 const (
 	SeparatorComma = ","
 
-	PrefixSchedule   = "schedule-"
-	PrefixRun        = "run-"
-	PrefixPipeline   = "ppl-"
-	PrefixCache      = "cch-"
-	PrefixGrant      = "grant"
-	PrefixQueue      = "queue"
-	PrefixCluster    = "cluster"
-	PrefixFlavour    = "flavour"
-	PrefixConnection = "conn"
+	PrefixSchedule      = "schedule-"
+	PrefixRun           = "run-"
+	PrefixPipeline      = "ppl-"
+	PrefixCache         = "cch-"
+	PrefixGrant         = "grant"
+	PrefixQueue         = "queue"
+	PrefixCluster       = "cluster"
+	PrefixFlavour       = "flavour"
+	PrefixConnection    = "conn"
+	PrefixTrackingToken = "tracking-"
+	PrefixVisualization = "vis"
+	PrefixDataset       = "ds"
+	PrefixDataLoad      = "dataload"
+	PrefixTransfer      = "transfer"
+	PrefixImageBuild    = "imagebuild"
+	PrefixSession       = "session"
+	PrefixAlertRule     = "alert"
+	PrefixEvent         = "event"
+	PrefixNodePool      = "pool"
 
 	ResourceTypeSchedule      = "schedule"
 	ResourceTypeRun           = "run"
@@ -40,11 +50,19 @@ const (
 	ResourceTypePipeline      = "pipeline"
 	ResourceTypeCluster       = "cluster"
 	ResourceTypeJob           = "job"
+	ResourceTypeVisualization = "visualization"
+	ResourceTypeDataset       = "dataset"
+	ResourceTypeImageBuild    = "image_build"
+	ResourceTypeProject       = "project"
+	ResourceTypeAlertRule     = "alert_rule"
 
 	HeaderKeyRequestID     = "x-pf-request-id"
 	HeaderKeyUserName      = "x-pf-user-name"
 	HeaderKeyAuthorization = "x-pf-authorization"
 	HeaderClientIDKey      = "x-pf-client-id"
+	HeaderKeyTrackingToken = "x-pf-tracking-token"
+	// HeaderKeyImpersonateUser root以该用户的身份访问接口，用于排查权限问题
+	HeaderKeyImpersonateUser = "Impersonate-User"
 
 	ResponseCode      = "code"
 	ResponseMessage   = "message"
@@ -62,6 +80,9 @@ const (
 	LogPageSizeDefault = 100
 	LogPageNoDefault   = 1
 
+	LogSearchMaxMatchesDefault = 1000
+	LogSearchMaxMatchesMax     = 10000
+
 	Pod = "pod"
 
 	StsMaxSeqData = 1000
@@ -50,6 +50,8 @@ const (
 	InvalidArguments     = "InvalidArguments"
 	RecordNotFound       = "RecordNotFound"
 	RequiredFieldEmpty   = "RequiredFieldEmpty"
+	ResourceConflict     = "ResourceConflict"   // 资源已被并发修改，重新获取后可重试
+	ServiceUnavailable   = "ServiceUnavailable" // 数据库或集群暂时不可用，可稍后重试
 
 	AuthWithoutToken = "AuthWithoutToken" // 请求没有携带token
 	AuthInvalidToken = "AuthInvalidToken" // 无效token
@@ -58,9 +60,14 @@ const (
 
 	DBUpdateFailed = "UpdateDatabaseFailed"
 
-	UserNameDuplicated = "UserNameDuplicated"
-	UserNotExist       = "UserNotExist"
-	UserPasswordWeak   = "UserPasswordWeak"
+	UserNameDuplicated  = "UserNameDuplicated"
+	UserNotExist        = "UserNotExist"
+	UserPasswordWeak    = "UserPasswordWeak"
+	UserLocked          = "UserLocked"
+	UserPasswordExpired = "UserPasswordExpired"
+	InvalidResetToken   = "InvalidResetToken"
+	SessionNotFound     = "SessionNotFound"
+	ImpersonationDenied = "ImpersonationDenied"
 
 	InvalidComputeResource = "InvalidComputeResource"
 
@@ -79,6 +86,14 @@ const (
 	GrantAlreadyExist         = "GrantAlreadyExist"
 	GrantRootActionNotSupport = "GrantRootActionNotSupport"
 
+	ProjectNotFound       = "ProjectNotFound"
+	ProjectNameDuplicated = "ProjectNameDuplicated"
+	ProjectMemberExist    = "ProjectMemberExist"
+	ProjectMemberNotFound = "ProjectMemberNotFound"
+	ProjectQuotaExceeded  = "ProjectQuotaExceeded"
+
+	UserQuotaExceeded = "UserQuotaExceeded"
+
 	RunNameDuplicated     = "RunNameDuplicated"
 	RunNotFound           = "RunNotFound"
 	PipelineNotFound      = "PipelineNotFound"
@@ -125,6 +140,8 @@ const (
 	InvalidPVClaimsParams       = "InvalidPVClaimsParams"
 	GetNamespaceFail            = "GetNamespaceFail"
 	LinkMetaPersistError        = "LinkMetaPersistError"
+	FileSystemPathExist         = "FileSystemPathExist"
+	FileSystemFileTooLarge      = "FileSystemFileTooLarge"
 )
 
 var errorHTTPStatus = map[string]int{
@@ -154,10 +171,17 @@ var errorHTTPStatus = map[string]int{
 	InvalidArguments:     http.StatusBadRequest,
 	RecordNotFound:       http.StatusNotFound,
 	RequiredFieldEmpty:   http.StatusBadRequest,
-
-	UserNameDuplicated: http.StatusForbidden,
-	UserNotExist:       http.StatusBadRequest,
-	UserPasswordWeak:   http.StatusBadRequest,
+	ResourceConflict:     http.StatusConflict,
+	ServiceUnavailable:   http.StatusServiceUnavailable,
+
+	UserNameDuplicated:  http.StatusForbidden,
+	UserNotExist:        http.StatusBadRequest,
+	UserPasswordWeak:    http.StatusBadRequest,
+	UserLocked:          http.StatusForbidden,
+	UserPasswordExpired: http.StatusForbidden,
+	InvalidResetToken:   http.StatusBadRequest,
+	SessionNotFound:     http.StatusNotFound,
+	ImpersonationDenied: http.StatusForbidden,
 
 	AuthWithoutToken: http.StatusBadRequest,
 	AuthInvalidToken: http.StatusBadRequest,
@@ -185,6 +209,13 @@ var errorHTTPStatus = map[string]int{
 	GrantAlreadyExist:         http.StatusBadRequest,
 	GrantRootActionNotSupport: http.StatusBadRequest,
 
+	ProjectNotFound:       http.StatusNotFound,
+	ProjectNameDuplicated: http.StatusBadRequest,
+	ProjectMemberExist:    http.StatusBadRequest,
+	ProjectMemberNotFound: http.StatusNotFound,
+	ProjectQuotaExceeded:  http.StatusBadRequest,
+	UserQuotaExceeded:     http.StatusBadRequest,
+
 	FlavourNotFound:     http.StatusNotFound,
 	FlavourNameEmpty:    http.StatusBadRequest,
 	FlavourInvalidField: http.StatusBadRequest,
@@ -224,6 +255,8 @@ var errorHTTPStatus = map[string]int{
 	InvalidPVClaimsParams:       http.StatusBadRequest,
 	GetNamespaceFail:            http.StatusInternalServerError,
 	LinkMetaPersistError:        http.StatusBadRequest,
+	FileSystemPathExist:         http.StatusConflict,
+	FileSystemFileTooLarge:      http.StatusRequestEntityTooLarge,
 }
 
 var errorMessage = map[string]string{
@@ -251,10 +284,17 @@ var errorMessage = map[string]string{
 	InvalidArguments:     "invalid arguments",
 	RecordNotFound:       "record not found",
 	RequiredFieldEmpty:   "Field is not set",
-
-	UserNameDuplicated: "The user name already exists",
-	UserNotExist:       "User not exist",
-	UserPasswordWeak:   "Password must consist of at least one number and one letter, and length must be greater than 6",
+	ResourceConflict:     "The resource has been modified, please retry",
+	ServiceUnavailable:   "Service is temporarily unavailable, please retry later",
+
+	UserNameDuplicated:  "The user name already exists",
+	UserNotExist:        "User not exist",
+	UserPasswordWeak:    "Password must consist of at least one number and one letter, and length must be greater than 6",
+	UserLocked:          "The user is locked due to too many failed logins, please retry later",
+	UserPasswordExpired: "Password has expired, please reset it",
+	InvalidResetToken:   "Password reset token is invalid or expired",
+	SessionNotFound:     "Session not found",
+	ImpersonationDenied: "Only root can impersonate an existing non-root user, and only read requests are allowed unless auth.allowImpersonationWrite is enabled",
 
 	AuthWithoutToken: "Request should login first",
 	AuthInvalidToken: "Invalid token. Please re-login",
@@ -284,6 +324,13 @@ var errorMessage = map[string]string{
 	GrantAlreadyExist:         "This user already have the grant of the resource",
 	GrantRootActionNotSupport: "Can not delete or create root's grant",
 
+	ProjectNotFound:       "Project not found",
+	ProjectNameDuplicated: "Project name is duplicated",
+	ProjectMemberExist:    "The resource is already a member of the project",
+	ProjectMemberNotFound: "The resource is not a member of the project",
+	ProjectQuotaExceeded:  "Total max resources of queues exceed the quota of project",
+	UserQuotaExceeded:     "Job exceeds the quota of user",
+
 	ClusterNameNotFound:      "ClusterName does not exist",
 	ClusterIdNotFound:        "ClusterId does not exist",
 	ClusterNotFound:          "Cluster not found",
@@ -315,12 +362,22 @@ var errorMessage = map[string]string{
 	ConnectivityFailed:         "Connectivity failed",
 	InvalidPVClaimsParams:      "Invalid persistent volume claims params",
 	GetNamespaceFail:           "Get namespace fail",
+	FileSystemPathExist:        "File or directory has exist",
+	FileSystemFileTooLarge:     "File is too large",
+}
+
+// retriableErrorCodes 客户端可原样重试的错误码
+var retriableErrorCodes = map[string]bool{
+	ResourceConflict:   true,
+	ServiceUnavailable: true,
 }
 
 type ErrorResponse struct {
-	RequestID    string `json:"requestID"`
-	ErrorCode    string `json:"code"`
-	ErrorMessage string `json:"message"`
+	RequestID    string            `json:"requestID"`
+	ErrorCode    string            `json:"code"`
+	ErrorMessage string            `json:"message"`
+	Details      map[string]string `json:"details,omitempty"`
+	Retriable    bool              `json:"retriable"`
 }
 
 func GetMessageByCode(code string) string {
@@ -331,6 +388,10 @@ func GetHttpStatusByCode(code string) int {
 	return errorHTTPStatus[code]
 }
 
+func IsRetriable(code string) bool {
+	return retriableErrorCodes[code]
+}
+
 func NoAccessError(user, resourceType, resourceID string) error {
 	return fmt.Errorf("user[%s] has no access to resource[%s] with Name[%s]", user, resourceType, resourceID)
 }
@@ -19,8 +19,11 @@ package common
 import (
 	"encoding/json"
 	"io/ioutil"
+	"net"
 	"net/http"
+	"strings"
 
+	"github.com/go-chi/chi"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
@@ -33,6 +36,8 @@ func GetRequestContext(r *http.Request) logger.RequestContext {
 	return logger.RequestContext{
 		RequestID: requestID,
 		UserName:  userName,
+		// the same as util.ParamKeyJobID, logs of requests on a job are attached with job id
+		JobID: chi.URLParam(r, "jobID"),
 	}
 }
 
@@ -47,3 +52,18 @@ func BindJSON(r *http.Request, data interface{}) error {
 	}
 	return nil
 }
+
+// ClientIP 优先使用代理转发的客户端地址，用于会话及审计记录
+func ClientIP(r *http.Request) string {
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		return strings.TrimSpace(strings.Split(forwarded, ",")[0])
+	}
+	if ip := r.Header.Get("X-Real-Ip"); ip != "" {
+		return ip
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -18,6 +18,7 @@ package common
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	log "github.com/sirupsen/logrus"
@@ -33,6 +34,7 @@ func RenderErr(w http.ResponseWriter, requestID string, code string) {
 		RequestID:    requestID,
 		ErrorCode:    code,
 		ErrorMessage: message,
+		Retriable:    IsRetriable(code),
 	}
 	Render(w, httpCode, errorResponse)
 }
@@ -49,6 +51,7 @@ func RenderErrWithMessage(w http.ResponseWriter, requestID string, code string,
 		RequestID:    requestID,
 		ErrorCode:    code,
 		ErrorMessage: message,
+		Retriable:    IsRetriable(code),
 	}
 	// code没有设置对应的http状态码
 	if httpCode == 0 {
@@ -57,6 +60,33 @@ func RenderErrWithMessage(w http.ResponseWriter, requestID string, code string,
 	Render(w, httpCode, errorResponse)
 }
 
+// RenderError 若err为ServiceError则使用其错误码及详情，否则等同于RenderErrWithMessage
+func RenderError(w http.ResponseWriter, requestID string, code string, err error) {
+	var svcErr *ServiceError
+	if !errors.As(err, &svcErr) {
+		RenderErrWithMessage(w, requestID, code, err.Error())
+		return
+	}
+	if svcErr.Code != "" {
+		code = svcErr.Code
+	}
+	if code == "" {
+		code = InternalError
+	}
+	httpCode := GetHttpStatusByCode(code)
+	if httpCode == 0 {
+		httpCode = http.StatusInternalServerError
+	}
+	errorResponse := ErrorResponse{
+		RequestID:    requestID,
+		ErrorCode:    code,
+		ErrorMessage: svcErr.Error(),
+		Details:      svcErr.Details,
+		Retriable:    IsRetriable(code),
+	}
+	Render(w, httpCode, errorResponse)
+}
+
 func RenderStatus(w http.ResponseWriter, httpCode int) {
 	Render(w, httpCode, nil)
 }
@@ -0,0 +1,113 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"strings"
+
+	"gorm.io/gorm"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+
+	pferrors "github.com/PaddlePaddle/PaddleFlow/pkg/common/errors"
+)
+
+// ServiceError 携带错误码及结构化详情的错误，由RenderError输出给客户端
+type ServiceError struct {
+	Code    string
+	Message string
+	Details map[string]string
+}
+
+func (e *ServiceError) Error() string {
+	if e.Message == "" {
+		return GetMessageByCode(e.Code)
+	}
+	return e.Message
+}
+
+func NewServiceError(code, message string, details map[string]string) *ServiceError {
+	return &ServiceError{
+		Code:    code,
+		Message: message,
+		Details: details,
+	}
+}
+
+// ErrorCodeOf 将gorm、kubernetes等底层错误映射为稳定的服务错误码，无法识别时返回defaultCode
+func ErrorCodeOf(err error, defaultCode string) string {
+	if err == nil {
+		return defaultCode
+	}
+	var svcErr *ServiceError
+	if errors.As(err, &svcErr) && svcErr.Code != "" {
+		return svcErr.Code
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return RecordNotFound
+	}
+	if isUnavailableError(err) {
+		return ServiceUnavailable
+	}
+	if code, ok := k8sErrorCode(err); ok {
+		return code
+	}
+	if isDuplicatedKeyError(err) {
+		return DuplicatedName
+	}
+	return defaultCode
+}
+
+func k8sErrorCode(err error) (string, bool) {
+	switch {
+	case k8serrors.IsNotFound(err):
+		return RecordNotFound, true
+	case k8serrors.IsAlreadyExists(err):
+		return DuplicatedName, true
+	case k8serrors.IsConflict(err):
+		return ResourceConflict, true
+	case k8serrors.IsInvalid(err), k8serrors.IsBadRequest(err):
+		return InvalidArguments, true
+	case k8serrors.IsForbidden(err), k8serrors.IsUnauthorized(err):
+		return ActionNotAllowed, true
+	case k8serrors.IsTimeout(err), k8serrors.IsServerTimeout(err),
+		k8serrors.IsTooManyRequests(err), k8serrors.IsServiceUnavailable(err):
+		return ServiceUnavailable, true
+	}
+	return "", false
+}
+
+func isUnavailableError(err error) bool {
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) ||
+		errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+func isDuplicatedKeyError(err error) bool {
+	if pferrors.GetErrorCode(err) == pferrors.ErrorKeyIsDuplicated {
+		return true
+	}
+	// sqlite
+	return strings.Contains(err.Error(), "UNIQUE constraint failed")
+}
@@ -0,0 +1,119 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestErrorCodeOf(t *testing.T) {
+	gr := schema.GroupResource{Group: "batch.volcano.sh", Resource: "jobs"}
+	testCases := []struct {
+		name     string
+		err      error
+		wantCode string
+	}{
+		{
+			name:     "unknown error",
+			err:      fmt.Errorf("unknown"),
+			wantCode: InternalError,
+		},
+		{
+			name:     "service error",
+			err:      fmt.Errorf("wrapped: %w", NewServiceError(JobNotFound, "", nil)),
+			wantCode: JobNotFound,
+		},
+		{
+			name:     "gorm record not found",
+			err:      fmt.Errorf("get job failed: %w", gorm.ErrRecordNotFound),
+			wantCode: RecordNotFound,
+		},
+		{
+			name:     "sqlite duplicated key",
+			err:      fmt.Errorf("UNIQUE constraint failed: job.id"),
+			wantCode: DuplicatedName,
+		},
+		{
+			name:     "bad connection",
+			err:      driver.ErrBadConn,
+			wantCode: ServiceUnavailable,
+		},
+		{
+			name:     "k8s not found",
+			err:      k8serrors.NewNotFound(gr, "job-1"),
+			wantCode: RecordNotFound,
+		},
+		{
+			name:     "k8s already exists",
+			err:      k8serrors.NewAlreadyExists(gr, "job-1"),
+			wantCode: DuplicatedName,
+		},
+		{
+			name:     "k8s conflict",
+			err:      k8serrors.NewConflict(gr, "job-1", fmt.Errorf("object has been modified")),
+			wantCode: ResourceConflict,
+		},
+		{
+			name:     "k8s too many requests",
+			err:      k8serrors.NewTooManyRequests("throttled", 1),
+			wantCode: ServiceUnavailable,
+		},
+		{
+			name:     "k8s forbidden",
+			err:      k8serrors.NewForbidden(gr, "job-1", fmt.Errorf("exceeded quota")),
+			wantCode: ActionNotAllowed,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.wantCode, ErrorCodeOf(tc.err, InternalError))
+		})
+	}
+}
+
+func TestRenderError(t *testing.T) {
+	w := httptest.NewRecorder()
+	RenderError(w, "req-1", InternalError, NewServiceError(ServiceUnavailable, "", map[string]string{"jobID": "job-1"}))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	resp := ErrorResponse{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, ServiceUnavailable, resp.ErrorCode)
+	assert.Equal(t, GetMessageByCode(ServiceUnavailable), resp.ErrorMessage)
+	assert.Equal(t, "job-1", resp.Details["jobID"])
+	assert.True(t, resp.Retriable)
+
+	w = httptest.NewRecorder()
+	RenderError(w, "req-2", JobNotFound, fmt.Errorf("job not found"))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	resp = ErrorResponse{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, JobNotFound, resp.ErrorCode)
+	assert.Equal(t, "job not found", resp.ErrorMessage)
+	assert.Nil(t, resp.Details)
+	assert.False(t, resp.Retriable)
+}
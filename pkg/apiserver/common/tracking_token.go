@@ -0,0 +1,100 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	trackingSecretOnce sync.Once
+	trackingSecret     []byte
+)
+
+// InitTrackingToken 设置签发tracking token的密钥，每个部署单独配置
+func InitTrackingToken(secret string) {
+	if secret != "" {
+		trackingSecretOnce.Do(func() {
+			trackingSecret = []byte(secret)
+		})
+	}
+}
+
+// trackingTokenSecret 返回签发tracking token的密钥，未配置时使用随机生成的密钥，服务重启或多副本时token失效
+func trackingTokenSecret() []byte {
+	trackingSecretOnce.Do(func() {
+		log.Warningf("trackingTokenSecret of apiServer is not set, use a random secret")
+		trackingSecret = make([]byte, 32)
+		if _, err := rand.Read(trackingSecret); err != nil {
+			log.Errorf("generate tracking token secret failed, err: %v", err)
+		}
+	})
+	return trackingSecret
+}
+
+func signTracking(payload string) string {
+	mac := hmac.New(sha256.New, trackingTokenSecret())
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// GenerateTrackingToken 生成run维度的tracking token，作业通过该token以run所有者的身份上报指标，无需用户的登录凭证。
+// token格式为 base64(runID|userName|过期时间).HMAC-SHA256签名
+func GenerateTrackingToken(runID, userName string, expireAt time.Time) (string, error) {
+	if runID == "" || userName == "" {
+		return "", fmt.Errorf("runID and userName of tracking token are required")
+	}
+	payload := strings.Join([]string{PrefixTrackingToken + runID, userName, strconv.FormatInt(expireAt.Unix(), 10)}, "|")
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + signTracking(payload), nil
+}
+
+// ParseTrackingToken 校验tracking token属于该run且未过期，返回run所有者的用户名
+func ParseTrackingToken(token, runID string, now time.Time) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 || runID == "" {
+		return "", fmt.Errorf("tracking token is malformed")
+	}
+	payloadBytes, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return "", fmt.Errorf("tracking token is malformed")
+	}
+	payload := string(payloadBytes)
+	if subtle.ConstantTimeCompare([]byte(signTracking(payload)), []byte(parts[1])) != 1 {
+		return "", fmt.Errorf("signature of tracking token is invalid")
+	}
+	fields := strings.Split(payload, "|")
+	if len(fields) != 3 || fields[0] != PrefixTrackingToken+runID || fields[1] == "" {
+		return "", fmt.Errorf("tracking token does not belong to run %s", runID)
+	}
+	expireAt, err := strconv.ParseInt(fields[2], 10, 64)
+	if err != nil || now.Unix() > expireAt {
+		return "", fmt.Errorf("tracking token is expired")
+	}
+	return fields[1], nil
+}
@@ -45,8 +45,12 @@ const (
 	dns1123LabelErrMsg    = "a lowercase RFC 1123 label must consist of lower case alphanumeric characters or '-'," +
 		" and must start and end with an alphanumeric character"
 
-	JobNameMaxLength = 512
-	JobPortMaximums  = 65535
+	JobNameMaxLength          = 512
+	JobPortMaximums           = 65535
+	ConcurrencyGroupMaxLength = 255
+
+	// RegPatternMountOption 单个mount参数，形如 key 或 key=value
+	RegPatternMountOption = "^[A-Za-z0-9_.-]+(=[A-Za-z0-9_.:/@+-]+)?$"
 
 	IPDomainOrIPDomainPortPattern = "^([a-zA-Z0-9][-a-zA-Z0-9]{0,62}(\\.[a-zA-Z0-9][-a-zA-Z0-9]{0,62})+)" +
 		"(:([1-9]|[1-9]\\d{1,3}|[1-5]\\d{4}|6[0-4]\\d{3}|65[0-4]\\d{2}|655[0-2]\\d|6553[0-5]))?$"
@@ -141,10 +145,10 @@ func InformationFromURL(url string, properties map[string]string) (fileSystemTyp
 		if properties[common.KeyTabData] != "" {
 			fileSystemType = common.HDFSWithKerberosType
 		}
-	case common.SFTPType:
+	case common.SFTPType, common.NFSType, common.CephFSType:
 		serverAddress = urlSplit[ServerAddressSplit]
 		subPath = "/" + SubPathFromUrl(urlSplit, HDFSSplit)
-	case common.S3Type:
+	case common.S3Type, common.GCSType, common.ABSType, common.OSSType, common.COSType:
 		serverAddress = properties[common.Endpoint]
 		subPath = "/" + SubPathFromUrl(urlSplit, S3Split)
 	case common.CFSType:
@@ -206,6 +210,39 @@ func CheckKerberosProperties(properties map[string]string) error {
 		return InvalidField(common.Realm, fmt.Sprintf("kerberos hdfs, %s must be provided", common.Realm))
 	}
 
+	switch properties[common.DataTransferProtection] {
+	case "", "authentication", "integrity", "privacy":
+	default:
+		return InvalidField(common.DataTransferProtection,
+			"kerberos.data.transfer.protection should be one of authentication, integrity and privacy")
+	}
+
+	return nil
+}
+
+// reservedMountOptions 由PaddleFlow管理或会改变挂载语义的参数，不允许用户指定
+var reservedMountOptions = map[string]bool{
+	"name":       true,
+	"secret":     true,
+	"secretfile": true,
+	"bind":       true,
+	"rbind":      true,
+	"remount":    true,
+}
+
+// CheckMountOptions 校验内核挂载的mountOptions，多个参数以逗号分隔
+func CheckMountOptions(options string) error {
+	if options == "" {
+		return nil
+	}
+	for _, option := range strings.Split(options, ",") {
+		if matched, _ := regexp.MatchString(RegPatternMountOption, option); !matched {
+			return InvalidField(common.MountOptions, fmt.Sprintf("mount option[%s] is invalid", option))
+		}
+		if reservedMountOptions[strings.SplitN(option, "=", 2)[0]] {
+			return InvalidField(common.MountOptions, fmt.Sprintf("mount option[%s] is not allowed", option))
+		}
+	}
 	return nil
 }
 
@@ -229,11 +266,12 @@ func CheckFsNested(path1, path2 string) bool {
 	return true
 }
 
+var dns1123LabelRegexp = regexp.MustCompile("^" + DNS1123LabelFmt + "$")
+
 // IsDNS1123Label tests for a string that conforms to the definition of a label in
 // DNS (RFC 1123).
 func IsDNS1123Label(value string) []string {
 	var errs []string
-	var dns1123LabelRegexp = regexp.MustCompile("^" + DNS1123LabelFmt + "$")
 	if len(value) > DNS1123LabelMaxLength {
 		errs = append(errs, fmt.Sprintf("must be no more than %d characters", DNS1123LabelMaxLength))
 	}
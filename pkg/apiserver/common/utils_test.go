@@ -295,3 +295,26 @@ func TestCheckPermission(t *testing.T) {
 	}
 
 }
+
+func TestCheckMountOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		options string
+		wantErr bool
+	}{
+		{name: "empty", options: "", wantErr: false},
+		{name: "nfs options", options: "vers=4.1,hard,timeo=600,noresvport", wantErr: false},
+		{name: "cephfs options", options: "mds_namespace=cephfs,fs=data", wantErr: false},
+		{name: "empty option", options: "vers=4.1,,hard", wantErr: true},
+		{name: "invalid character", options: "hard;rm -rf /", wantErr: true},
+		{name: "reserved secret", options: "secret=xxx", wantErr: true},
+		{name: "reserved remount", options: "remount", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := CheckMountOptions(tt.options); (err != nil) != tt.wantErr {
+				t.Errorf("CheckMountOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
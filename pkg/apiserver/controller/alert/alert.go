@@ -0,0 +1,302 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alert
+
+import (
+	"fmt"
+	"regexp"
+
+	"gorm.io/gorm"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/uuid"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+)
+
+const (
+	// MetricQueuePendingJobs 队列中排队的作业数
+	MetricQueuePendingJobs = "queue_pending_jobs"
+	// MetricUserFailureRate 用户在统计窗口内结束的作业中失败的比例
+	MetricUserFailureRate = "user_failure_rate"
+	// MetricFsCacheHitRate 存储缓存的读命中率
+	MetricFsCacheHitRate = "fs_cache_hit_rate"
+
+	OperatorGreater      = ">"
+	OperatorGreaterEqual = ">="
+	OperatorLess         = "<"
+	OperatorLessEqual    = "<="
+
+	defaultWindowSeconds = 3600
+	ruleNameMaxLength    = 128
+)
+
+var ruleNameRegex = regexp.MustCompile("^[a-zA-Z][a-zA-Z0-9_-]*$")
+
+type CreateAlertRuleRequest struct {
+	Name          string              `json:"name"`
+	Metric        string              `json:"metric"`
+	Target        string              `json:"target"`
+	Operator      string              `json:"operator"`
+	Threshold     float64             `json:"threshold"`
+	ForSeconds    int                 `json:"forSeconds"`
+	WindowSeconds int                 `json:"windowSeconds"`
+	Channels      model.AlertChannels `json:"channels"`
+	// Enabled 为空时默认启用
+	Enabled     *bool  `json:"enabled"`
+	Description string `json:"description"`
+}
+
+type CreateAlertRuleResponse struct {
+	ID string `json:"id"`
+}
+
+// UpdateAlertRuleRequest 只更新请求中设置的字段
+type UpdateAlertRuleRequest struct {
+	Metric        *string              `json:"metric"`
+	Target        *string              `json:"target"`
+	Operator      *string              `json:"operator"`
+	Threshold     *float64             `json:"threshold"`
+	ForSeconds    *int                 `json:"forSeconds"`
+	WindowSeconds *int                 `json:"windowSeconds"`
+	Channels      *model.AlertChannels `json:"channels"`
+	Enabled       *bool                `json:"enabled"`
+	Description   *string              `json:"description"`
+}
+
+type ListAlertRuleResponse struct {
+	common.MarkerInfo
+	RuleList []model.AlertRule `json:"ruleList"`
+}
+
+type GetAlertRuleResponse struct {
+	model.AlertRule
+	// Alerts 规则当前处于等待或告警状态的对象
+	Alerts []AlertStatus `json:"alerts"`
+}
+
+// CreateAlertRule 创建告警规则，仅root用户可用
+func CreateAlertRule(ctx *logger.RequestContext, request CreateAlertRuleRequest) (CreateAlertRuleResponse, error) {
+	ctx.Logging().Debugf("begin create alert rule: %+v", request)
+	if err := checkRoot(ctx); err != nil {
+		return CreateAlertRuleResponse{}, err
+	}
+	rule := model.AlertRule{
+		ID:            uuid.GenerateID(common.PrefixAlertRule),
+		Name:          request.Name,
+		Metric:        request.Metric,
+		Target:        request.Target,
+		Operator:      request.Operator,
+		Threshold:     request.Threshold,
+		ForSeconds:    request.ForSeconds,
+		WindowSeconds: request.WindowSeconds,
+		Channels:      request.Channels,
+		Enabled:       request.Enabled == nil || *request.Enabled,
+		Description:   request.Description,
+		CreatedBy:     ctx.UserName,
+	}
+	if err := validateAlertRule(ctx, &rule); err != nil {
+		ctx.Logging().Errorf("validate alert rule failed. error: %v", err)
+		return CreateAlertRuleResponse{}, err
+	}
+	if _, err := storage.Alert.GetAlertRule(ctx.Logging(), rule.Name); err == nil {
+		ctx.ErrorCode = common.DuplicatedName
+		return CreateAlertRuleResponse{}, fmt.Errorf("alert rule[%s] already exists", rule.Name)
+	}
+	if err := storage.Alert.CreateAlertRule(ctx.Logging(), &rule); err != nil {
+		ctx.ErrorCode = common.InternalError
+		return CreateAlertRuleResponse{}, err
+	}
+	ctx.Logging().Infof("alert rule[%s] created with id[%s]", rule.Name, rule.ID)
+	return CreateAlertRuleResponse{ID: rule.ID}, nil
+}
+
+func validateAlertRule(ctx *logger.RequestContext, rule *model.AlertRule) error {
+	if rule.Name == "" || rule.Metric == "" || rule.Operator == "" {
+		ctx.ErrorCode = common.RequiredFieldEmpty
+		return fmt.Errorf("name, metric and operator are required")
+	}
+	if len(rule.Name) > ruleNameMaxLength || !ruleNameRegex.MatchString(rule.Name) {
+		ctx.ErrorCode = common.InvalidArguments
+		return fmt.Errorf("name[%s] of alert rule is invalid, it should start with a letter and only contain "+
+			"letters, numbers, '_' and '-', no more than %d characters", rule.Name, ruleNameMaxLength)
+	}
+	switch rule.Metric {
+	case MetricQueuePendingJobs:
+	case MetricUserFailureRate, MetricFsCacheHitRate:
+		if rule.Threshold < 0 || rule.Threshold > 1 {
+			ctx.ErrorCode = common.InvalidArguments
+			return fmt.Errorf("threshold of metric[%s] should be a ratio between 0 and 1", rule.Metric)
+		}
+	default:
+		ctx.ErrorCode = common.InvalidArguments
+		return fmt.Errorf("metric[%s] is not supported, must be one of %s, %s and %s", rule.Metric,
+			MetricQueuePendingJobs, MetricUserFailureRate, MetricFsCacheHitRate)
+	}
+	switch rule.Operator {
+	case OperatorGreater, OperatorGreaterEqual, OperatorLess, OperatorLessEqual:
+	default:
+		ctx.ErrorCode = common.InvalidArguments
+		return fmt.Errorf("operator[%s] is not supported, must be one of >, >=, < and <=", rule.Operator)
+	}
+	if rule.ForSeconds < 0 || rule.WindowSeconds < 0 {
+		ctx.ErrorCode = common.InvalidArguments
+		return fmt.Errorf("forSeconds and windowSeconds should not be negative")
+	}
+	if rule.Metric == MetricUserFailureRate && rule.WindowSeconds == 0 {
+		rule.WindowSeconds = defaultWindowSeconds
+	}
+	return nil
+}
+
+func GetAlertRule(ctx *logger.RequestContext, name string) (GetAlertRuleResponse, error) {
+	if err := checkRoot(ctx); err != nil {
+		return GetAlertRuleResponse{}, err
+	}
+	rule, err := getAlertRule(ctx, name)
+	if err != nil {
+		return GetAlertRuleResponse{}, err
+	}
+	return GetAlertRuleResponse{
+		AlertRule: rule,
+		Alerts:    alertStates.list(rule.ID),
+	}, nil
+}
+
+func getAlertRule(ctx *logger.RequestContext, name string) (model.AlertRule, error) {
+	rule, err := storage.Alert.GetAlertRule(ctx.Logging(), name)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			ctx.ErrorCode = common.RecordNotFound
+			return model.AlertRule{}, common.NotFoundError(common.ResourceTypeAlertRule, name)
+		}
+		ctx.ErrorCode = common.InternalError
+		return model.AlertRule{}, err
+	}
+	return rule, nil
+}
+
+func ListAlertRule(ctx *logger.RequestContext, marker string, maxKeys int) (ListAlertRuleResponse, error) {
+	response := ListAlertRuleResponse{RuleList: []model.AlertRule{}}
+	if err := checkRoot(ctx); err != nil {
+		return response, err
+	}
+	var pk int64
+	var err error
+	if marker != "" {
+		pk, err = common.DecryptPk(marker)
+		if err != nil {
+			ctx.ErrorCode = common.InvalidMarker
+			ctx.Logging().Errorf("DecryptPk marker[%s] failed. err:[%s]", marker, err.Error())
+			return response, err
+		}
+	}
+	// 多查询一条，用于判断是否还有下一页
+	rules, err := storage.Alert.ListAlertRule(ctx.Logging(), pk, maxKeys+1, false)
+	if err != nil {
+		ctx.ErrorCode = common.InternalError
+		return response, err
+	}
+	if len(rules) > maxKeys {
+		rules = rules[:maxKeys]
+		nextMarker, err := common.EncryptPk(rules[len(rules)-1].Pk)
+		if err != nil {
+			ctx.ErrorCode = common.InternalError
+			return response, err
+		}
+		response.IsTruncated = true
+		response.NextMarker = nextMarker
+	}
+	response.MaxKeys = maxKeys
+	response.RuleList = append(response.RuleList, rules...)
+	return response, nil
+}
+
+// UpdateAlertRule 更新告警规则，规则条件变化后重新开始评估
+func UpdateAlertRule(ctx *logger.RequestContext, name string, request UpdateAlertRuleRequest) (model.AlertRule, error) {
+	if err := checkRoot(ctx); err != nil {
+		return model.AlertRule{}, err
+	}
+	rule, err := getAlertRule(ctx, name)
+	if err != nil {
+		return model.AlertRule{}, err
+	}
+	if request.Metric != nil {
+		rule.Metric = *request.Metric
+	}
+	if request.Target != nil {
+		rule.Target = *request.Target
+	}
+	if request.Operator != nil {
+		rule.Operator = *request.Operator
+	}
+	if request.Threshold != nil {
+		rule.Threshold = *request.Threshold
+	}
+	if request.ForSeconds != nil {
+		rule.ForSeconds = *request.ForSeconds
+	}
+	if request.WindowSeconds != nil {
+		rule.WindowSeconds = *request.WindowSeconds
+	}
+	if request.Channels != nil {
+		rule.Channels = *request.Channels
+	}
+	if request.Enabled != nil {
+		rule.Enabled = *request.Enabled
+	}
+	if request.Description != nil {
+		rule.Description = *request.Description
+	}
+	if err := validateAlertRule(ctx, &rule); err != nil {
+		ctx.Logging().Errorf("validate alert rule failed. error: %v", err)
+		return model.AlertRule{}, err
+	}
+	if err := storage.Alert.UpdateAlertRule(ctx.Logging(), &rule); err != nil {
+		ctx.ErrorCode = common.InternalError
+		return model.AlertRule{}, err
+	}
+	alertStates.reset(rule.ID)
+	return rule, nil
+}
+
+func DeleteAlertRule(ctx *logger.RequestContext, name string) error {
+	if err := checkRoot(ctx); err != nil {
+		return err
+	}
+	rule, err := getAlertRule(ctx, name)
+	if err != nil {
+		return err
+	}
+	if err := storage.Alert.DeleteAlertRule(ctx.Logging(), rule.Name); err != nil {
+		ctx.ErrorCode = common.InternalError
+		return err
+	}
+	alertStates.reset(rule.ID)
+	return nil
+}
+
+func checkRoot(ctx *logger.RequestContext) error {
+	if !common.IsRootUser(ctx.UserName) {
+		ctx.ErrorCode = common.OnlyRootAllowed
+		err := fmt.Errorf("only root user can manage alert rules")
+		ctx.Logging().Errorln(err.Error())
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alert
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/config"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage/driver"
+)
+
+func TestAlertRuleCRUD(t *testing.T) {
+	driver.InitMockDB()
+	alertStates = &stateCache{rules: make(map[string]map[string]*alertState)}
+
+	ctx := &logger.RequestContext{UserName: "user1"}
+	_, err := CreateAlertRule(ctx, CreateAlertRuleRequest{Name: "pending", Metric: MetricQueuePendingJobs, Operator: ">"})
+	assert.Error(t, err)
+	assert.Equal(t, common.OnlyRootAllowed, ctx.ErrorCode)
+
+	for _, request := range []CreateAlertRuleRequest{
+		{Name: "pending", Metric: "queue_running_jobs", Operator: ">"},
+		{Name: "pending", Metric: MetricQueuePendingJobs, Operator: "=="},
+		{Name: "1pending", Metric: MetricQueuePendingJobs, Operator: ">"},
+		{Name: "failure", Metric: MetricUserFailureRate, Operator: ">", Threshold: 30},
+	} {
+		ctx = &logger.RequestContext{UserName: "root"}
+		_, err = CreateAlertRule(ctx, request)
+		assert.Error(t, err)
+		assert.Equal(t, common.InvalidArguments, ctx.ErrorCode)
+	}
+
+	ctx = &logger.RequestContext{UserName: "root"}
+	_, err = CreateAlertRule(ctx, CreateAlertRuleRequest{Name: "failure", Metric: MetricUserFailureRate,
+		Target: "user1", Operator: ">", Threshold: 0.3,
+		Channels: model.AlertChannels{Webhooks: []string{"http://localhost/hook"}}})
+	assert.NoError(t, err)
+	_, err = CreateAlertRule(ctx, CreateAlertRuleRequest{Name: "failure", Metric: MetricUserFailureRate, Operator: ">"})
+	assert.Error(t, err)
+	assert.Equal(t, common.DuplicatedName, ctx.ErrorCode)
+
+	rule, err := GetAlertRule(ctx, "failure")
+	assert.NoError(t, err)
+	assert.True(t, rule.Enabled)
+	assert.Equal(t, defaultWindowSeconds, rule.WindowSeconds)
+	assert.Equal(t, []string{"http://localhost/hook"}, rule.Channels.Webhooks)
+	assert.Empty(t, rule.Alerts)
+
+	enabled, forSeconds := false, 600
+	updated, err := UpdateAlertRule(ctx, "failure", UpdateAlertRuleRequest{Enabled: &enabled, ForSeconds: &forSeconds})
+	assert.NoError(t, err)
+	assert.False(t, updated.Enabled)
+	assert.Equal(t, 600, updated.ForSeconds)
+	assert.Equal(t, "user1", updated.Target)
+
+	list, err := ListAlertRule(ctx, "", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(list.RuleList))
+	enabledRules, err := storage.Alert.ListAlertRule(ctx.Logging(), 0, 0, true)
+	assert.NoError(t, err)
+	assert.Empty(t, enabledRules)
+
+	assert.NoError(t, DeleteAlertRule(ctx, "failure"))
+	_, err = GetAlertRule(ctx, "failure")
+	assert.Error(t, err)
+	assert.Equal(t, common.RecordNotFound, ctx.ErrorCode)
+}
+
+func TestEvaluateRules(t *testing.T) {
+	driver.InitMockDB()
+	config.GlobalServerConfig = &config.ServerConfig{}
+	alertStates = &stateCache{rules: make(map[string]map[string]*alertState)}
+	var received []AlertNotification
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var notification AlertNotification
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&notification))
+		received = append(received, notification)
+	}))
+	defer server.Close()
+	var mails []string
+	sendMailFunc = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		mails = append(mails, string(msg))
+		return nil
+	}
+	defer func() { sendMailFunc = smtp.SendMail }()
+	config.GlobalServerConfig.Notification.SMTP = config.SMTPConfig{Host: "smtp.example.com", Port: 25, From: "pf@example.com"}
+	config.GlobalServerConfig.Notification.Emails = []string{"admin@example.com"}
+
+	cluster := model.ClusterInfo{Model: model.Model{ID: "cluster-1"}, Name: "cluster-1", ClusterType: schema.KubernetesType}
+	assert.NoError(t, storage.Cluster.CreateCluster(&cluster))
+	for _, name := range []string{"queue-1", "queue-2"} {
+		queue := model.Queue{Model: model.Model{ID: name}, Name: name, Namespace: "default", ClusterId: cluster.ID}
+		assert.NoError(t, storage.Queue.CreateQueue(&queue))
+	}
+	for _, id := range []string{"job-1", "job-2", "job-3"} {
+		job := model.Job{ID: id, QueueID: "queue-1", Status: schema.StatusJobPending, Config: &schema.Conf{}}
+		assert.NoError(t, storage.Job.CreateJob(&job))
+	}
+
+	ctx := &logger.RequestContext{UserName: "root"}
+	// 使用全局邮件通知
+	_, err := CreateAlertRule(ctx, CreateAlertRuleRequest{Name: "pending", Metric: MetricQueuePendingJobs,
+		Operator: ">", Threshold: 2, ForSeconds: 600})
+	assert.NoError(t, err)
+	_, err = CreateAlertRule(ctx, CreateAlertRuleRequest{Name: "pending-webhook", Metric: MetricQueuePendingJobs,
+		Target: "queue-1", Operator: ">=", Threshold: 1, Channels: model.AlertChannels{Webhooks: []string{server.URL}}})
+	assert.NoError(t, err)
+
+	now := time.Now()
+	evaluateRules(now)
+	assert.Equal(t, 1, len(received))
+	assert.Equal(t, "queue-1", received[0].Target)
+	assert.Equal(t, StateFiring, received[0].State)
+	assert.Equal(t, 3.0, received[0].Value)
+	// 持续时间未达到ForSeconds
+	assert.Empty(t, mails)
+	rule, err := GetAlertRule(ctx, "pending")
+	assert.NoError(t, err)
+	assert.Equal(t, []AlertStatus{{Target: "queue-1", State: StatePending, Value: 3,
+		Since: now.Format(model.TimeFormat)}}, rule.Alerts)
+
+	evaluateRules(now.Add(10 * time.Minute))
+	assert.Equal(t, 1, len(mails))
+	assert.Contains(t, mails[0], "alert[pending] firing on queue-1")
+	assert.Equal(t, 1, len(received))
+
+	for _, id := range []string{"job-1", "job-2", "job-3"} {
+		assert.NoError(t, storage.Job.UpdateJobStatus(id, "", schema.StatusJobRunning))
+	}
+	evaluateRules(now.Add(11 * time.Minute))
+	assert.Equal(t, 2, len(mails))
+	assert.Contains(t, mails[1], "alert[pending] resolved on queue-1")
+	assert.Equal(t, 2, len(received))
+	assert.Equal(t, StateResolved, received[1].State)
+	rule, err = GetAlertRule(ctx, "pending")
+	assert.NoError(t, err)
+	assert.Empty(t, rule.Alerts)
+}
+
+func TestUserFailureRate(t *testing.T) {
+	driver.InitMockDB()
+	now := time.Now()
+	for i, status := range []schema.JobStatus{schema.StatusJobFailed, schema.StatusJobSucceeded,
+		schema.StatusJobFailed, schema.StatusJobRunning} {
+		job := model.Job{ID: "job-" + string(rune('a'+i)), UserName: "user1", Status: status,
+			Config: &schema.Conf{}, UpdatedAt: now.Add(-10 * time.Minute)}
+		assert.NoError(t, storage.Job.CreateJob(&job))
+	}
+	job := model.Job{ID: "job-old", UserName: "user2", Status: schema.StatusJobFailed,
+		Config: &schema.Conf{}, UpdatedAt: now.Add(-2 * time.Hour)}
+	assert.NoError(t, storage.Job.CreateJob(&job))
+
+	values, err := userFailureRate(now.Add(-time.Hour), now)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(values))
+	assert.InDelta(t, 2.0/3, values["user1"], 1e-9)
+}
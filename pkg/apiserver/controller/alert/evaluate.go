@@ -0,0 +1,272 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alert
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+)
+
+const (
+	StatePending  = "pending"
+	StateFiring   = "firing"
+	StateResolved = "resolved"
+)
+
+// EvaluateInterval 告警规则的评估周期
+var EvaluateInterval = time.Minute
+
+// AlertStatus 规则在某个对象上的告警状态
+type AlertStatus struct {
+	Target string  `json:"target"`
+	State  string  `json:"state"`
+	Value  float64 `json:"value"`
+	// Since 条件开始满足的时间
+	Since string `json:"since"`
+}
+
+type alertState struct {
+	since time.Time
+	value float64
+	// firing 已发送告警通知，条件不再满足时需要发送恢复通知
+	firing bool
+}
+
+// stateCache 记录规则在各对象上的告警状态，服务重启后重新开始评估
+type stateCache struct {
+	sync.Mutex
+	rules map[string]map[string]*alertState
+}
+
+var alertStates = &stateCache{rules: make(map[string]map[string]*alertState)}
+
+func (c *stateCache) list(ruleID string) []AlertStatus {
+	c.Lock()
+	defer c.Unlock()
+	alerts := make([]AlertStatus, 0, len(c.rules[ruleID]))
+	for target, state := range c.rules[ruleID] {
+		status := AlertStatus{Target: target, State: StatePending, Value: state.value,
+			Since: state.since.Format(model.TimeFormat)}
+		if state.firing {
+			status.State = StateFiring
+		}
+		alerts = append(alerts, status)
+	}
+	sort.Slice(alerts, func(i, j int) bool {
+		return alerts[i].Target < alerts[j].Target
+	})
+	return alerts
+}
+
+func (c *stateCache) reset(ruleID string) {
+	c.Lock()
+	defer c.Unlock()
+	delete(c.rules, ruleID)
+}
+
+// AlertController 定期评估启用的告警规则
+func AlertController(stopChan chan struct{}) {
+	for {
+		evaluateRules(time.Now())
+		select {
+		case <-stopChan:
+			log.Info("alert controller stopped")
+			return
+		case <-time.After(EvaluateInterval):
+		}
+	}
+}
+
+func evaluateRules(now time.Time) {
+	rules, err := storage.Alert.ListAlertRule(log.NewEntry(log.StandardLogger()), 0, 0, true)
+	if err != nil {
+		log.Errorf("list alert rules failed. error: %v", err)
+		return
+	}
+	ruleIDs := make(map[string]bool, len(rules))
+	for _, rule := range rules {
+		ruleIDs[rule.ID] = true
+		values, err := metricValues(rule, now)
+		if err != nil {
+			log.Errorf("get metric[%s] of alert rule[%s] failed. error: %v", rule.Metric, rule.Name, err)
+			continue
+		}
+		for _, notification := range alertStates.update(rule, values, now) {
+			notify(rule, notification)
+		}
+	}
+	// 规则被删除或停用后不再保留其状态
+	alertStates.Lock()
+	for ruleID := range alertStates.rules {
+		if !ruleIDs[ruleID] {
+			delete(alertStates.rules, ruleID)
+		}
+	}
+	alertStates.Unlock()
+}
+
+// update 根据本次评估的指标值更新状态，返回需要发送的告警及恢复通知
+func (c *stateCache) update(rule model.AlertRule, values map[string]float64, now time.Time) []AlertNotification {
+	c.Lock()
+	defer c.Unlock()
+	states, ok := c.rules[rule.ID]
+	if !ok {
+		states = make(map[string]*alertState)
+		c.rules[rule.ID] = states
+	}
+	var notifications []AlertNotification
+	for target, value := range values {
+		if !compare(value, rule.Operator, rule.Threshold) {
+			continue
+		}
+		state, ok := states[target]
+		if !ok {
+			state = &alertState{since: now}
+			states[target] = state
+		}
+		state.value = value
+		if !state.firing && now.Sub(state.since) >= time.Duration(rule.ForSeconds)*time.Second {
+			state.firing = true
+			notifications = append(notifications, newAlertNotification(rule, target, StateFiring, state, now))
+		}
+	}
+	for target, state := range states {
+		value, ok := values[target]
+		if ok && compare(value, rule.Operator, rule.Threshold) {
+			continue
+		}
+		delete(states, target)
+		if state.firing {
+			state.value = value
+			notifications = append(notifications, newAlertNotification(rule, target, StateResolved, state, now))
+		}
+	}
+	sort.Slice(notifications, func(i, j int) bool {
+		return notifications[i].Target < notifications[j].Target
+	})
+	return notifications
+}
+
+func compare(value float64, operator string, threshold float64) bool {
+	switch operator {
+	case OperatorGreater:
+		return value > threshold
+	case OperatorGreaterEqual:
+		return value >= threshold
+	case OperatorLess:
+		return value < threshold
+	case OperatorLessEqual:
+		return value <= threshold
+	}
+	return false
+}
+
+// metricValues 返回规则指标在各对象上的当前值，rule.Target不为空时只返回该对象
+func metricValues(rule model.AlertRule, now time.Time) (map[string]float64, error) {
+	var values map[string]float64
+	var err error
+	switch rule.Metric {
+	case MetricQueuePendingJobs:
+		values, err = queuePendingJobs()
+	case MetricUserFailureRate:
+		window := time.Duration(rule.WindowSeconds) * time.Second
+		values, err = userFailureRate(now.Add(-window), now)
+	case MetricFsCacheHitRate:
+		values, err = fsCacheHitRate()
+	default:
+		err = fmt.Errorf("metric[%s] is not supported", rule.Metric)
+	}
+	if err != nil || rule.Target == "" {
+		return values, err
+	}
+	value, ok := values[rule.Target]
+	if !ok {
+		return map[string]float64{}, nil
+	}
+	return map[string]float64{rule.Target: value}, nil
+}
+
+// queuePendingJobs 各队列中排队的作业数，没有排队作业的队列值为0
+func queuePendingJobs() (map[string]float64, error) {
+	queues, err := storage.Queue.ListQueue(0, 0, "", common.UserRoot, "")
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]float64, len(queues))
+	queueNames := make(map[string]string, len(queues))
+	for _, queue := range queues {
+		values[queue.Name] = 0
+		queueNames[queue.ID] = queue.Name
+	}
+	for _, job := range storage.Job.ListJobByStatus(schema.StatusJobPending) {
+		if name, ok := queueNames[job.QueueID]; ok {
+			values[name]++
+		}
+	}
+	return values, nil
+}
+
+// userFailureRate 各用户在[start, end)内结束的作业中失败的比例，只包含有作业结束的用户
+func userFailureRate(start, end time.Time) (map[string]float64, error) {
+	jobs, err := storage.Job.ListJobFinishedBetween(start, end)
+	if err != nil {
+		return nil, err
+	}
+	finished := make(map[string]int)
+	failed := make(map[string]int)
+	for _, job := range jobs {
+		finished[job.UserName]++
+		if job.Status == schema.StatusJobFailed {
+			failed[job.UserName]++
+		}
+	}
+	values := make(map[string]float64, len(finished))
+	for userName, count := range finished {
+		values[userName] = float64(failed[userName]) / float64(count)
+	}
+	return values, nil
+}
+
+// fsCacheHitRate 各存储所有缓存节点的整体读命中率，只包含有读取的存储
+func fsCacheHitRate() (map[string]float64, error) {
+	caches, err := storage.FsCache.List("", "")
+	if err != nil {
+		return nil, err
+	}
+	hits := make(map[string]int64)
+	reads := make(map[string]int64)
+	for _, cache := range caches {
+		hits[cache.FsID] += cache.CacheHits
+		reads[cache.FsID] += cache.CacheHits + cache.CacheMisses
+	}
+	values := make(map[string]float64, len(reads))
+	for fsID, count := range reads {
+		if count > 0 {
+			values[fsID] = float64(hits[fsID]) / float64(count)
+		}
+	}
+	return values, nil
+}
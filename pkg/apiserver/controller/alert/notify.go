@@ -0,0 +1,150 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alert
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/config"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+)
+
+const defaultNotificationTimeout = 10 * time.Second
+
+var sendMailFunc = smtp.SendMail
+
+// AlertNotification 告警触发或恢复时发送的通知内容，webhook会直接收到该结构的json
+type AlertNotification struct {
+	RuleName  string  `json:"ruleName"`
+	Metric    string  `json:"metric"`
+	Target    string  `json:"target"`
+	Operator  string  `json:"operator"`
+	Threshold float64 `json:"threshold"`
+	Value     float64 `json:"value"`
+	// State firing或resolved
+	State    string `json:"state"`
+	StartsAt string `json:"startsAt"`
+	Time     string `json:"time"`
+}
+
+func newAlertNotification(rule model.AlertRule, target, state string, s *alertState, now time.Time) AlertNotification {
+	return AlertNotification{
+		RuleName:  rule.Name,
+		Metric:    rule.Metric,
+		Target:    target,
+		Operator:  rule.Operator,
+		Threshold: rule.Threshold,
+		Value:     s.value,
+		State:     state,
+		StartsAt:  s.since.Format(model.TimeFormat),
+		Time:      now.Format(model.TimeFormat),
+	}
+}
+
+func (n AlertNotification) Title() string {
+	return fmt.Sprintf("[PaddleFlow] alert[%s] %s on %s", n.RuleName, n.State, n.Target)
+}
+
+func (n AlertNotification) Text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "rule: %s\n", n.RuleName)
+	fmt.Fprintf(&b, "condition: %s %s %g\n", n.Metric, n.Operator, n.Threshold)
+	fmt.Fprintf(&b, "target: %s\n", n.Target)
+	fmt.Fprintf(&b, "value: %g\n", n.Value)
+	fmt.Fprintf(&b, "state: %s\n", n.State)
+	fmt.Fprintf(&b, "starts at: %s\n", n.StartsAt)
+	fmt.Fprintf(&b, "time: %s\n", n.Time)
+	return b.String()
+}
+
+// notify 发送到规则的通知渠道，规则未配置渠道时使用全局通知配置
+func notify(rule model.AlertRule, content AlertNotification) {
+	channels := rule.Channels
+	if len(channels.Emails) == 0 && len(channels.Slack) == 0 && len(channels.Webhooks) == 0 && config.GlobalServerConfig != nil {
+		conf := config.GlobalServerConfig.Notification
+		channels = model.AlertChannels{Emails: conf.Emails, Slack: conf.Slack, Webhooks: conf.Webhooks}
+	}
+	log.Infof("alert rule[%s] %s on target[%s], value: %g", rule.Name, content.State, content.Target, content.Value)
+	for _, url := range channels.Webhooks {
+		body, err := json.Marshal(content)
+		if err == nil {
+			err = postNotification(url, body)
+		}
+		if err != nil {
+			log.Errorf("send webhook notification of alert rule[%s] to [%s] failed: %v", rule.Name, url, err)
+		}
+	}
+	for _, url := range channels.Slack {
+		body, err := json.Marshal(map[string]string{
+			"text": fmt.Sprintf("*%s*\n%s", content.Title(), content.Text()),
+		})
+		if err == nil {
+			err = postNotification(url, body)
+		}
+		if err != nil {
+			log.Errorf("send slack notification of alert rule[%s] failed: %v", rule.Name, err)
+		}
+	}
+	if len(channels.Emails) > 0 {
+		if err := sendEmailNotification(channels.Emails, content); err != nil {
+			log.Errorf("send email notification of alert rule[%s] to %v failed: %v", rule.Name, channels.Emails, err)
+		}
+	}
+}
+
+func postNotification(url string, body []byte) error {
+	client := &http.Client{Timeout: notificationTimeout()}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("response status code %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func sendEmailNotification(emails []string, content AlertNotification) error {
+	if config.GlobalServerConfig == nil || config.GlobalServerConfig.Notification.SMTP.Host == "" {
+		return fmt.Errorf("smtp server is not configured")
+	}
+	smtpConf := config.GlobalServerConfig.Notification.SMTP
+	addr := fmt.Sprintf("%s:%d", smtpConf.Host, smtpConf.Port)
+	var auth smtp.Auth
+	if smtpConf.Username != "" {
+		auth = smtp.PlainAuth("", smtpConf.Username, smtpConf.Password, smtpConf.Host)
+	}
+	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\n\r\n%s",
+		smtpConf.From, strings.Join(emails, ","), content.Title(), content.Text())
+	return sendMailFunc(addr, auth, smtpConf.From, emails, []byte(msg))
+}
+
+func notificationTimeout() time.Duration {
+	if config.GlobalServerConfig != nil && config.GlobalServerConfig.Notification.TimeoutSeconds > 0 {
+		return time.Duration(config.GlobalServerConfig.Notification.TimeoutSeconds) * time.Second
+	}
+	return defaultNotificationTimeout
+}
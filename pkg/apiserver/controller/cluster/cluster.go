@@ -29,6 +29,7 @@ import (
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/controller/queue"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/router/util"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/config"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/envelope"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/uuid"
@@ -52,14 +53,23 @@ type ClusterCommonInfo struct {
 type CreateClusterRequest struct {
 	ClusterCommonInfo
 	Name string `json:"clusterName"` // 集群名字
+	// DryRun 只探测集群并返回就绪报告，不注册集群
+	DryRun bool `json:"dryRun"`
+	// Provision 注册后安装到集群中的组件
+	Provision []ComponentVersion `json:"provision"`
 }
 
 type CreateClusterResponse struct {
 	model.ClusterInfo
+	// Readiness kubernetes集群的能力探测报告
+	Readiness *ClusterReadiness `json:"readiness,omitempty"`
+	// Components 注册时开始安装的组件
+	Components []model.ClusterComponent `json:"components,omitempty"`
 }
 
 type GetClusterResponse struct {
 	model.ClusterInfo
+	NodePools []model.NodePool `json:"nodePools"`
 }
 
 type ListClusterRequest struct {
@@ -285,6 +295,29 @@ func CreateCluster(ctx *logger.RequestContext, request *CreateClusterRequest) (*
 		NamespaceList: request.NamespaceList,
 	}
 
+	var plans []provisionPlan
+	if len(request.Provision) != 0 {
+		if clusterInfo.ClusterType != schema.KubernetesType {
+			ctx.ErrorCode = common.InvalidArguments
+			return nil, fmt.Errorf("provisioning is only supported for %s cluster", schema.KubernetesType)
+		}
+		var err error
+		if plans, err = newProvisionPlans(request.Provision); err != nil {
+			ctx.ErrorCode = common.InvalidArguments
+			return nil, err
+		}
+	}
+
+	if request.DryRun {
+		if clusterInfo.ClusterType != schema.KubernetesType {
+			ctx.ErrorCode = common.InvalidArguments
+			return nil, fmt.Errorf("dry run is only supported for %s cluster", schema.KubernetesType)
+		}
+		response := CreateClusterResponse{ClusterInfo: clusterInfo, Readiness: ProbeCluster(clusterInfo)}
+		response.Credential = ""
+		return &response, nil
+	}
+
 	if err := validateConnectivity(clusterInfo); err != nil {
 		ctx.Logging().Errorf("get cluster [%s] client failed. %s", clusterInfo.Name, err.Error())
 		ctx.ErrorCode = common.InvalidCredential
@@ -292,7 +325,20 @@ func CreateCluster(ctx *logger.RequestContext, request *CreateClusterRequest) (*
 	}
 
 	err := storage.Cluster.CreateCluster(&clusterInfo)
-	response := CreateClusterResponse{clusterInfo}
+	response := CreateClusterResponse{ClusterInfo: clusterInfo}
+	if err == nil && clusterInfo.ClusterType == schema.KubernetesType {
+		response.Readiness = ProbeCluster(clusterInfo)
+		if !response.Readiness.Ready {
+			ctx.Logging().Warnf("cluster [%s] is created but not ready, readiness: %+v", clusterInfo.Name, response.Readiness)
+		}
+	}
+	if err == nil && len(plans) != 0 {
+		// 组件安装失败不影响集群注册，可以通过组件接口查看状态并重试
+		if response.Components, err = startProvision(clusterInfo, plans); err != nil {
+			ctx.Logging().Errorf("provision cluster [%s] failed. %v", clusterInfo.Name, err)
+			err = nil
+		}
+	}
 	return &response, err
 }
 
@@ -382,7 +428,13 @@ func GetCluster(ctx *logger.RequestContext, clusterName string) (*GetClusterResp
 		ctx.Logging().Errorf("get cluster failed. clusterName:[%s]", clusterName)
 		return nil, err
 	}
-	return &GetClusterResponse{clusterInfo}, nil
+	nodePools, err := storage.NodePool.ListNodePools(clusterInfo.ID)
+	if err != nil {
+		ctx.ErrorCode = common.InternalError
+		ctx.Logging().Errorf("list node pools of cluster failed. clusterName:[%s]", clusterName)
+		return nil, err
+	}
+	return &GetClusterResponse{ClusterInfo: clusterInfo, NodePools: nodePools}, nil
 }
 
 func DeleteCluster(ctx *logger.RequestContext, clusterName string) error {
@@ -519,6 +571,28 @@ func ListClusterQuota(ctx *logger.RequestContext, clusterNameList []string) (map
 	return response, nil
 }
 
+// RotateCredentials 加密启用加密前保存的集群凭证，并将凭证的数据密钥改用当前主密钥加密
+func RotateCredentials() error {
+	clusters, err := storage.Cluster.ListCluster(0, 0, nil, "")
+	if err != nil {
+		return err
+	}
+	for _, clusterInfo := range clusters {
+		credential, changed, err := envelope.Rotate(clusterInfo.Credential)
+		if err != nil {
+			return fmt.Errorf("rotate credential of cluster[%s] failed, err: %v", clusterInfo.Name, err)
+		}
+		if !changed {
+			continue
+		}
+		if err = storage.Cluster.UpdateClusterCredential(clusterInfo.ID, credential); err != nil {
+			return err
+		}
+		log.Infof("credential of cluster[%s] is rotated", clusterInfo.Name)
+	}
+	return nil
+}
+
 // InitDefaultCluster init default cluster for single cluster environment
 func InitDefaultCluster() error {
 	log.Info("starting init data for single cluster: initDefaultCluster")
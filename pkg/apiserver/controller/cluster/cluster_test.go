@@ -17,16 +17,20 @@ limitations under the License.
 package cluster
 
 import (
+	"encoding/base64"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/agiledragon/gomonkey/v2"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/envelope"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
 	runtime "github.com/PaddlePaddle/PaddleFlow/pkg/job/runtime_v2"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/storage/driver"
 )
 
@@ -123,3 +127,39 @@ func TestDeleteCluster(t *testing.T) {
 	err := DeleteCluster(ctx, MockClusterName)
 	assert.Nil(t, err)
 }
+
+func TestRotateCredentials(t *testing.T) {
+	driver.InitMockDB()
+	defer func() {
+		_ = envelope.Init(envelope.Config{})
+	}()
+	credential := "YXBpVmVyc2lvbjogdjE="
+	// credential saved before encryption is enabled
+	clusterInfo := model.ClusterInfo{Model: model.Model{ID: "cluster-encrypt"}, Name: "encrypt", Credential: credential}
+	assert.NoError(t, storage.Cluster.CreateCluster(&clusterInfo))
+
+	keys := map[string]string{"k1": base64.StdEncoding.EncodeToString([]byte(strings.Repeat("a", 32)))}
+	assert.NoError(t, envelope.Init(envelope.Config{ActiveKey: "k1", Keys: keys}))
+	assert.NoError(t, RotateCredentials())
+	saved, err := storage.Cluster.GetClusterById(clusterInfo.ID)
+	assert.NoError(t, err)
+	assert.True(t, envelope.IsEncrypted(saved.Credential))
+	assert.True(t, strings.HasPrefix(saved.Credential, "pfenc:v1:k1:"))
+
+	// new credential is encrypted when saved
+	saved.Credential = credential
+	assert.NoError(t, storage.Cluster.UpdateCluster(saved.ID, &saved))
+	saved, err = storage.Cluster.GetClusterById(clusterInfo.ID)
+	assert.NoError(t, err)
+	assert.True(t, envelope.IsEncrypted(saved.Credential))
+
+	keys["k2"] = base64.StdEncoding.EncodeToString([]byte(strings.Repeat("b", 32)))
+	assert.NoError(t, envelope.Init(envelope.Config{ActiveKey: "k2", Keys: keys}))
+	assert.NoError(t, RotateCredentials())
+	saved, err = storage.Cluster.GetClusterById(clusterInfo.ID)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(saved.Credential, "pfenc:v1:k2:"))
+	plaintext, err := envelope.Decrypt(saved.Credential)
+	assert.NoError(t, err)
+	assert.Equal(t, credential, plaintext)
+}
@@ -0,0 +1,426 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/k8s"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/uuid"
+	runtime "github.com/PaddlePaddle/PaddleFlow/pkg/job/runtime_v2"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+)
+
+type CreateNodePoolRequest struct {
+	Name        string   `json:"name"`
+	GPUType     string   `json:"gpuType"`
+	Zone        string   `json:"zone"`
+	Team        string   `json:"team"`
+	Description string   `json:"description"`
+	Nodes       []string `json:"nodes"`
+}
+
+// UpdateNodePoolRequest 字段为空时不修改，Nodes不为空时替换节点池的节点
+type UpdateNodePoolRequest struct {
+	GPUType     *string  `json:"gpuType"`
+	Zone        *string  `json:"zone"`
+	Team        *string  `json:"team"`
+	Description *string  `json:"description"`
+	Nodes       []string `json:"nodes"`
+}
+
+// NodePoolResponse 节点池及绑定到节点池的队列
+type NodePoolResponse struct {
+	model.NodePool
+	Queues []string `json:"queues"`
+}
+
+type ListNodePoolsResponse struct {
+	NodePools []NodePoolResponse `json:"nodePools"`
+}
+
+// nodeLabeler 修改节点的标签，值为nil时删除标签
+type nodeLabeler interface {
+	labelNode(nodeName string, labels map[string]interface{}) error
+}
+
+var getNodeLabeler = func(clusterInfo model.ClusterInfo) (nodeLabeler, error) {
+	runtimeSvc, err := runtime.GetOrCreateRuntime(clusterInfo)
+	if err != nil {
+		return nil, err
+	}
+	kubeRuntime, ok := runtimeSvc.(*runtime.KubeRuntime)
+	if !ok {
+		return nil, fmt.Errorf("runtime of cluster[%s] does not support node labeling", clusterInfo.Name)
+	}
+	return &kubeNodeLabeler{runtime: kubeRuntime}, nil
+}
+
+type kubeNodeLabeler struct {
+	runtime *runtime.KubeRuntime
+}
+
+func (kl *kubeNodeLabeler) labelNode(nodeName string, labels map[string]interface{}) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{"labels": labels},
+	})
+	if err != nil {
+		return err
+	}
+	return kl.runtime.PatchObject("", nodeName, k8s.NodeGVK, patch)
+}
+
+// CreateNodePool 创建节点池并给节点打上节点池的标签
+func CreateNodePool(ctx *logger.RequestContext, clusterName string, request *CreateNodePoolRequest) (*NodePoolResponse, error) {
+	if !common.IsRootUser(ctx.UserName) {
+		ctx.ErrorCode = common.OnlyRootAllowed
+		err := fmt.Errorf("only root user can create node pool")
+		ctx.Logging().Errorln(err.Error())
+		return nil, err
+	}
+	clusterInfo, err := getNodePoolCluster(ctx, clusterName)
+	if err != nil {
+		return nil, err
+	}
+	if errStr := common.IsDNS1123Label(request.Name); len(errStr) != 0 {
+		ctx.ErrorCode = common.InvalidArguments
+		err = fmt.Errorf("name[%s] of node pool is invalid, err: %s", request.Name, strings.Join(errStr, ","))
+		ctx.Logging().Errorln(err.Error())
+		return nil, err
+	}
+	if _, err = storage.NodePool.GetNodePool(clusterInfo.ID, request.Name); err == nil {
+		ctx.ErrorCode = common.DuplicatedName
+		err = fmt.Errorf("node pool %s already exists in cluster %s", request.Name, clusterName)
+		ctx.Logging().Errorln(err.Error())
+		return nil, err
+	}
+	pool := &model.NodePool{
+		ID:          uuid.GenerateID(common.PrefixNodePool),
+		Name:        request.Name,
+		ClusterID:   clusterInfo.ID,
+		GPUType:     request.GPUType,
+		Zone:        request.Zone,
+		Team:        request.Team,
+		Description: request.Description,
+		Nodes:       request.Nodes,
+	}
+	if err = validateNodePool(pool); err != nil {
+		ctx.ErrorCode = common.InvalidArguments
+		ctx.Logging().Errorf("create node pool failed, err: %v", err)
+		return nil, err
+	}
+	if err = syncNodePoolLabels(clusterInfo, nil, pool); err != nil {
+		ctx.ErrorCode = common.InternalError
+		ctx.Logging().Errorf("create node pool %s failed, err: %v", pool.Name, err)
+		return nil, err
+	}
+	if err = storage.NodePool.CreateNodePool(pool); err != nil {
+		ctx.ErrorCode = common.InternalError
+		ctx.Logging().Errorf("create node pool %s failed, err: %v", pool.Name, err)
+		revertNodePoolLabels(clusterInfo, pool, nil)
+		return nil, err
+	}
+	return &NodePoolResponse{NodePool: *pool, Queues: []string{}}, nil
+}
+
+// UpdateNodePool 修改节点池的属性或节点，并同步节点的标签
+func UpdateNodePool(ctx *logger.RequestContext, clusterName, poolName string, request *UpdateNodePoolRequest) (*NodePoolResponse, error) {
+	if !common.IsRootUser(ctx.UserName) {
+		ctx.ErrorCode = common.OnlyRootAllowed
+		err := fmt.Errorf("only root user can update node pool")
+		ctx.Logging().Errorln(err.Error())
+		return nil, err
+	}
+	clusterInfo, err := getNodePoolCluster(ctx, clusterName)
+	if err != nil {
+		return nil, err
+	}
+	oldPool, err := getNodePool(ctx, clusterInfo, poolName)
+	if err != nil {
+		return nil, err
+	}
+	pool := oldPool
+	if request.GPUType != nil {
+		pool.GPUType = *request.GPUType
+	}
+	if request.Zone != nil {
+		pool.Zone = *request.Zone
+	}
+	if request.Team != nil {
+		pool.Team = *request.Team
+	}
+	if request.Description != nil {
+		pool.Description = *request.Description
+	}
+	if request.Nodes != nil {
+		pool.Nodes = request.Nodes
+	}
+	if err = validateNodePool(&pool); err != nil {
+		ctx.ErrorCode = common.InvalidArguments
+		ctx.Logging().Errorf("update node pool failed, err: %v", err)
+		return nil, err
+	}
+	if err = syncNodePoolLabels(clusterInfo, &oldPool, &pool); err != nil {
+		ctx.ErrorCode = common.InternalError
+		ctx.Logging().Errorf("update node pool %s failed, err: %v", poolName, err)
+		return nil, err
+	}
+	if err = storage.NodePool.UpdateNodePool(&pool); err != nil {
+		ctx.ErrorCode = common.InternalError
+		ctx.Logging().Errorf("update node pool %s failed, err: %v", poolName, err)
+		revertNodePoolLabels(clusterInfo, &pool, &oldPool)
+		return nil, err
+	}
+	return &NodePoolResponse{NodePool: pool, Queues: boundQueues(clusterInfo.ID, poolName)}, nil
+}
+
+// DeleteNodePool 删除节点池并移除节点的标签，有队列绑定时不能删除
+func DeleteNodePool(ctx *logger.RequestContext, clusterName, poolName string) error {
+	if !common.IsRootUser(ctx.UserName) {
+		ctx.ErrorCode = common.OnlyRootAllowed
+		err := fmt.Errorf("only root user can delete node pool")
+		ctx.Logging().Errorln(err.Error())
+		return err
+	}
+	clusterInfo, err := getNodePoolCluster(ctx, clusterName)
+	if err != nil {
+		return err
+	}
+	pool, err := getNodePool(ctx, clusterInfo, poolName)
+	if err != nil {
+		return err
+	}
+	if queues := boundQueues(clusterInfo.ID, poolName); len(queues) > 0 {
+		ctx.ErrorCode = common.ActionNotAllowed
+		err = fmt.Errorf("node pool %s is bound by queues %v", poolName, queues)
+		ctx.Logging().Errorln(err.Error())
+		return err
+	}
+	if err = syncNodePoolLabels(clusterInfo, &pool, nil); err != nil {
+		ctx.ErrorCode = common.InternalError
+		ctx.Logging().Errorf("delete node pool %s failed, err: %v", poolName, err)
+		return err
+	}
+	if err = storage.NodePool.DeleteNodePool(clusterInfo.ID, poolName); err != nil {
+		ctx.ErrorCode = common.InternalError
+		ctx.Logging().Errorf("delete node pool %s failed, err: %v", poolName, err)
+		revertNodePoolLabels(clusterInfo, nil, &pool)
+		return err
+	}
+	return nil
+}
+
+func GetNodePool(ctx *logger.RequestContext, clusterName, poolName string) (*NodePoolResponse, error) {
+	clusterInfo, err := storage.Cluster.GetClusterByName(clusterName)
+	if err != nil {
+		ctx.ErrorCode = common.ClusterNameNotFound
+		ctx.Logging().Errorf("get cluster failed. clusterName:[%s]", clusterName)
+		return nil, err
+	}
+	pool, err := getNodePool(ctx, clusterInfo, poolName)
+	if err != nil {
+		return nil, err
+	}
+	return &NodePoolResponse{NodePool: pool, Queues: boundQueues(clusterInfo.ID, poolName)}, nil
+}
+
+func ListNodePools(ctx *logger.RequestContext, clusterName string) (*ListNodePoolsResponse, error) {
+	clusterInfo, err := storage.Cluster.GetClusterByName(clusterName)
+	if err != nil {
+		ctx.ErrorCode = common.ClusterNameNotFound
+		ctx.Logging().Errorf("get cluster failed. clusterName:[%s]", clusterName)
+		return nil, err
+	}
+	pools, err := storage.NodePool.ListNodePools(clusterInfo.ID)
+	if err != nil {
+		ctx.ErrorCode = common.InternalError
+		ctx.Logging().Errorf("list node pools of cluster %s failed, err: %v", clusterName, err)
+		return nil, err
+	}
+	queues := map[string][]string{}
+	for _, q := range storage.Queue.ListQueuesByCluster(clusterInfo.ID) {
+		if q.NodePool != "" {
+			queues[q.NodePool] = append(queues[q.NodePool], q.Name)
+		}
+	}
+	response := &ListNodePoolsResponse{NodePools: make([]NodePoolResponse, 0, len(pools))}
+	for _, pool := range pools {
+		bound := queues[pool.Name]
+		if bound == nil {
+			bound = []string{}
+		}
+		response.NodePools = append(response.NodePools, NodePoolResponse{NodePool: pool, Queues: bound})
+	}
+	return response, nil
+}
+
+func getNodePoolCluster(ctx *logger.RequestContext, clusterName string) (model.ClusterInfo, error) {
+	clusterInfo, err := storage.Cluster.GetClusterByName(clusterName)
+	if err != nil {
+		ctx.ErrorCode = common.ClusterNameNotFound
+		ctx.Logging().Errorf("get cluster failed. clusterName:[%s]", clusterName)
+		return clusterInfo, err
+	}
+	if clusterInfo.ClusterType != schema.KubernetesType {
+		ctx.ErrorCode = common.ActionNotAllowed
+		err = fmt.Errorf("node pool is not supported in clusterType %s", clusterInfo.ClusterType)
+		ctx.Logging().Errorln(err.Error())
+		return clusterInfo, err
+	}
+	return clusterInfo, nil
+}
+
+func getNodePool(ctx *logger.RequestContext, clusterInfo model.ClusterInfo, poolName string) (model.NodePool, error) {
+	pool, err := storage.NodePool.GetNodePool(clusterInfo.ID, poolName)
+	if err != nil {
+		ctx.ErrorCode = common.RecordNotFound
+		err = fmt.Errorf("node pool %s is not found in cluster %s", poolName, clusterInfo.Name)
+		ctx.Logging().Errorln(err.Error())
+		return pool, err
+	}
+	return pool, nil
+}
+
+// validateNodePool 检查节点池的标签值，且节点不能属于其他节点池
+func validateNodePool(pool *model.NodePool) error {
+	for key, value := range pool.Labels() {
+		if errStr := validation.IsValidLabelValue(value); len(errStr) != 0 {
+			return fmt.Errorf("value[%s] of label %s is invalid, err: %s", value, key, strings.Join(errStr, ","))
+		}
+	}
+	nodes := map[string]bool{}
+	for _, node := range pool.Nodes {
+		if node == "" || nodes[node] {
+			return fmt.Errorf("node name[%s] is empty or duplicated", node)
+		}
+		nodes[node] = true
+	}
+	pools, err := storage.NodePool.ListNodePools(pool.ClusterID)
+	if err != nil {
+		return err
+	}
+	for _, other := range pools {
+		if other.Name == pool.Name {
+			continue
+		}
+		for _, node := range other.Nodes {
+			if nodes[node] {
+				return fmt.Errorf("node %s already belongs to node pool %s", node, other.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// boundQueues 返回绑定到节点池的队列名称
+func boundQueues(clusterID, poolName string) []string {
+	queues := []string{}
+	for _, q := range storage.Queue.ListQueuesByCluster(clusterID) {
+		if q.NodePool == poolName {
+			queues = append(queues, q.Name)
+		}
+	}
+	return queues
+}
+
+// nodePoolLabels 节点属于pool时需要的标签，pool为nil或属性为空时删除对应的标签
+func nodePoolLabels(pool *model.NodePool) map[string]interface{} {
+	labels := map[string]interface{}{
+		schema.NodePoolLabel:        nil,
+		schema.NodePoolGPUTypeLabel: nil,
+		schema.NodePoolZoneLabel:    nil,
+		schema.NodePoolTeamLabel:    nil,
+	}
+	if pool != nil {
+		for key, value := range pool.Labels() {
+			labels[key] = value
+		}
+	}
+	return labels
+}
+
+// syncNodePoolLabels 把节点的标签从节点池from改为节点池to，from为nil时为新建，to为nil时为删除
+func syncNodePoolLabels(clusterInfo model.ClusterInfo, from, to *model.NodePool) error {
+	var nodes []string
+	members := map[string]bool{}
+	if to != nil {
+		for _, node := range to.Nodes {
+			members[node] = true
+			nodes = append(nodes, node)
+		}
+	}
+	if from != nil {
+		for _, node := range from.Nodes {
+			if !members[node] {
+				nodes = append(nodes, node)
+			}
+		}
+	}
+	if len(nodes) == 0 {
+		return nil
+	}
+	labeler, err := getNodeLabeler(clusterInfo)
+	if err != nil {
+		return err
+	}
+	for i, node := range nodes {
+		labels := nodePoolLabels(nil)
+		if members[node] {
+			labels = nodePoolLabels(to)
+		}
+		if err = labeler.labelNode(node, labels); err != nil {
+			err = fmt.Errorf("label node %s failed: %v", node, err)
+			// 恢复已修改节点的标签
+			for _, labeled := range nodes[:i] {
+				revert := nodePoolLabels(nil)
+				if from != nil && contains(from.Nodes, labeled) {
+					revert = nodePoolLabels(from)
+				}
+				if revertErr := labeler.labelNode(labeled, revert); revertErr != nil {
+					log.Errorf("revert labels of node %s failed, err: %v", labeled, revertErr)
+				}
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// revertNodePoolLabels 节点池保存失败时恢复节点的标签
+func revertNodePoolLabels(clusterInfo model.ClusterInfo, from, to *model.NodePool) {
+	if err := syncNodePoolLabels(clusterInfo, from, to); err != nil {
+		log.Errorf("revert labels of node pool failed, err: %v", err)
+	}
+}
+
+func contains(items []string, item string) bool {
+	for _, i := range items {
+		if i == item {
+			return true
+		}
+	}
+	return false
+}
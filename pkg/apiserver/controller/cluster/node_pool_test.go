@@ -0,0 +1,118 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage/driver"
+)
+
+// fakeLabeler 按patch语义记录节点的标签
+type fakeLabeler struct {
+	labels   map[string]map[string]string
+	failNode string
+}
+
+func (f *fakeLabeler) labelNode(nodeName string, labels map[string]interface{}) error {
+	if nodeName == f.failNode {
+		return fmt.Errorf("node %s not found", nodeName)
+	}
+	if f.labels[nodeName] == nil {
+		f.labels[nodeName] = map[string]string{}
+	}
+	for key, value := range labels {
+		if value == nil {
+			delete(f.labels[nodeName], key)
+		} else {
+			f.labels[nodeName][key] = value.(string)
+		}
+	}
+	return nil
+}
+
+func TestNodePool(t *testing.T) {
+	driver.InitMockDB()
+	clusterInfo := model.ClusterInfo{Model: model.Model{ID: "cluster-1"}, Name: MockClusterName,
+		ClusterType: schema.KubernetesType}
+	assert.NoError(t, storage.Cluster.CreateCluster(&clusterInfo))
+	labeler := &fakeLabeler{labels: map[string]map[string]string{}}
+	getNodeLabeler = func(clusterInfo model.ClusterInfo) (nodeLabeler, error) {
+		return labeler, nil
+	}
+	rootCtx := &logger.RequestContext{UserName: MockRootUser}
+
+	request := &CreateNodePoolRequest{Name: "a100", GPUType: "A100", Team: "nlp", Nodes: []string{"node-1", "node-2"}}
+	_, err := CreateNodePool(&logger.RequestContext{UserName: "user1"}, MockClusterName, request)
+	assert.Error(t, err)
+	_, err = CreateNodePool(rootCtx, MockClusterName, &CreateNodePoolRequest{Name: "a100", GPUType: "A100 80G"})
+	assert.Error(t, err)
+
+	pool, err := CreateNodePool(rootCtx, MockClusterName, request)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"node-1", "node-2"}, pool.Nodes)
+	assert.Equal(t, map[string]string{schema.NodePoolLabel: "a100", schema.NodePoolGPUTypeLabel: "A100",
+		schema.NodePoolTeamLabel: "nlp"}, labeler.labels["node-1"])
+	_, err = CreateNodePool(rootCtx, MockClusterName, request)
+	assert.Error(t, err)
+	// 节点不能属于多个节点池
+	_, err = CreateNodePool(rootCtx, MockClusterName, &CreateNodePoolRequest{Name: "v100", Nodes: []string{"node-2"}})
+	assert.Error(t, err)
+
+	// 移出节点池的节点删除标签，清空的属性删除对应的标签
+	team := ""
+	pool, err = UpdateNodePool(rootCtx, MockClusterName, "a100", &UpdateNodePoolRequest{Team: &team,
+		Nodes: []string{"node-2", "node-3"}})
+	assert.NoError(t, err)
+	assert.Empty(t, labeler.labels["node-1"])
+	assert.Equal(t, map[string]string{schema.NodePoolLabel: "a100", schema.NodePoolGPUTypeLabel: "A100"},
+		labeler.labels["node-3"])
+
+	// 打标签失败时恢复已修改的节点
+	labeler.failNode = "node-4"
+	_, err = UpdateNodePool(rootCtx, MockClusterName, "a100", &UpdateNodePoolRequest{Nodes: []string{"node-1", "node-4"}})
+	assert.Error(t, err)
+	assert.Empty(t, labeler.labels["node-1"])
+	assert.Equal(t, "a100", labeler.labels["node-2"][schema.NodePoolLabel])
+	labeler.failNode = ""
+
+	queue := model.Queue{Model: model.Model{ID: "queue-1"}, Name: "queue-1", ClusterId: clusterInfo.ID,
+		NodePool: "a100", Status: schema.StatusQueueOpen}
+	assert.NoError(t, storage.Queue.CreateQueue(&queue))
+	pools, err := ListNodePools(rootCtx, MockClusterName)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(pools.NodePools))
+	assert.Equal(t, []string{"queue-1"}, pools.NodePools[0].Queues)
+	cluster, err := GetCluster(rootCtx, MockClusterName)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(cluster.NodePools))
+
+	// 有队列绑定时不能删除
+	assert.Error(t, DeleteNodePool(rootCtx, MockClusterName, "a100"))
+	assert.NoError(t, storage.Queue.UpdateQueueNodePool("queue-1", ""))
+	assert.NoError(t, DeleteNodePool(rootCtx, MockClusterName, "a100"))
+	assert.Empty(t, labeler.labels["node-2"])
+	_, err = GetNodePool(rootCtx, MockClusterName, "a100")
+	assert.Error(t, err)
+}
@@ -0,0 +1,301 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sschema "k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/k8s"
+	runtime "github.com/PaddlePaddle/PaddleFlow/pkg/job/runtime_v2"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/job/runtime_v2/client"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+)
+
+const (
+	// csiDriverName PaddleFlow存储插件的CSI driver名称
+	csiDriverName = "paddleflowstorage"
+)
+
+// ClusterReadiness 集群能力探测报告，Ready为true时API可访问、权限完整且必需的组件均已安装
+type ClusterReadiness struct {
+	Ready       bool              `json:"ready"`
+	APIServer   APIServerCheck    `json:"apiServer"`
+	Permissions []PermissionCheck `json:"permissions"`
+	Components  []ComponentCheck  `json:"components"`
+	CRDs        []CRDCheck        `json:"crds"`
+}
+
+type APIServerCheck struct {
+	Reachable bool   `json:"reachable"`
+	Version   string `json:"version,omitempty"`
+	Message   string `json:"message,omitempty"`
+}
+
+// PermissionCheck 集群凭证在Namespace中对资源执行Verb操作的权限，Namespace为空时为集群级别
+type PermissionCheck struct {
+	Group     string `json:"group"`
+	Resource  string `json:"resource"`
+	Verb      string `json:"verb"`
+	Namespace string `json:"namespace,omitempty"`
+	Allowed   bool   `json:"allowed"`
+	Message   string `json:"message,omitempty"`
+}
+
+type ComponentCheck struct {
+	Name      string `json:"name"`
+	Required  bool   `json:"required"`
+	Installed bool   `json:"installed"`
+	Message   string `json:"message,omitempty"`
+}
+
+// CRDCheck 集群中CRD的版本，Supported表示集群提供了PaddleFlow使用的版本
+type CRDCheck struct {
+	Kind            string   `json:"kind"`
+	Group           string   `json:"group"`
+	ExpectedVersion string   `json:"expectedVersion"`
+	ServedVersions  []string `json:"servedVersions"`
+	Supported       bool     `json:"supported"`
+}
+
+type permissionRule struct {
+	group      string
+	resource   string
+	verbs      []string
+	namespaced bool
+}
+
+// requiredPermissions PaddleFlow创建作业、队列和存储所需的权限
+var requiredPermissions = []permissionRule{
+	{resource: "pods", verbs: []string{"create", "delete", "get", "list", "watch"}, namespaced: true},
+	{resource: "pods/log", verbs: []string{"get"}, namespaced: true},
+	{resource: "services", verbs: []string{"create", "delete"}, namespaced: true},
+	{resource: "configmaps", verbs: []string{"create", "delete"}, namespaced: true},
+	{resource: "persistentvolumeclaims", verbs: []string{"create", "delete"}, namespaced: true},
+	{resource: "events", verbs: []string{"list"}, namespaced: true},
+	{resource: "nodes", verbs: []string{"list", "watch"}},
+	{resource: "persistentvolumes", verbs: []string{"create", "delete"}},
+	{group: k8s.PodGroupGVK.Group, resource: "podgroups", verbs: []string{"create", "list"}, namespaced: true},
+	{group: k8s.VCQueueGVK.Group, resource: "queues", verbs: []string{"create", "update", "delete"}},
+	{group: k8s.PaddleJobGVK.Group, resource: "paddlejobs", verbs: []string{"create", "delete", "list"}, namespaced: true},
+}
+
+// probedCRDs 探测版本的CRD，required为true的CRD缺失时集群未就绪
+var probedCRDs = []struct {
+	gvk       k8sschema.GroupVersionKind
+	component string
+	required  bool
+}{
+	{gvk: k8s.PaddleJobGVK, component: "paddle-operator", required: true},
+	{gvk: k8s.PodGroupGVK, component: "volcano", required: true},
+	{gvk: k8s.VCQueueGVK, component: "volcano", required: true},
+	{gvk: k8s.EQuotaGVK, component: "volcano"},
+	{gvk: k8s.TFJobGVK, component: "training-operator"},
+	{gvk: k8s.PyTorchJobGVK, component: "training-operator"},
+	{gvk: k8s.MPIJobGVK, component: "training-operator"},
+	{gvk: k8s.RayJobGVK, component: "kuberay-operator"},
+	{gvk: k8s.SparkAppGVK, component: "spark-operator"},
+	{gvk: k8s.ArgoWorkflowGVK, component: "argo-workflows"},
+}
+
+var getProbeClient = func(clusterInfo model.ClusterInfo) (kubernetes.Interface, error) {
+	runtimeSvc, err := runtime.CreateRuntime(clusterInfo)
+	if err != nil {
+		return nil, err
+	}
+	kubeClient, ok := runtimeSvc.Client().(*client.KubeRuntimeClient)
+	if !ok || kubeClient.Client == nil {
+		return nil, fmt.Errorf("cluster[%s] does not support probing", clusterInfo.Name)
+	}
+	return kubeClient.Client, nil
+}
+
+// ProbeCluster 探测集群的API可达性、凭证权限、必需组件和CRD版本，探测失败的项记录在报告中
+func ProbeCluster(clusterInfo model.ClusterInfo) *ClusterReadiness {
+	report := &ClusterReadiness{
+		Permissions: []PermissionCheck{},
+		Components:  []ComponentCheck{},
+		CRDs:        []CRDCheck{},
+	}
+	kubeClient, err := getProbeClient(clusterInfo)
+	if err != nil {
+		report.APIServer.Message = err.Error()
+		return report
+	}
+	info, err := kubeClient.Discovery().ServerVersion()
+	if err != nil {
+		report.APIServer.Message = fmt.Sprintf("api server is unreachable: %v", err)
+		return report
+	}
+	report.APIServer.Reachable = true
+	report.APIServer.Version = info.GitVersion
+
+	ready := true
+	namespaces := clusterInfo.NamespaceList
+	if len(namespaces) == 0 {
+		namespaces = []string{metav1.NamespaceDefault}
+	}
+	for _, rule := range requiredPermissions {
+		scopes := []string{""}
+		if rule.namespaced {
+			scopes = namespaces
+		}
+		for _, namespace := range scopes {
+			for _, verb := range rule.verbs {
+				check := checkPermission(kubeClient, rule.group, rule.resource, verb, namespace)
+				ready = ready && check.Allowed
+				report.Permissions = append(report.Permissions, check)
+			}
+		}
+	}
+
+	crdChecks, err := checkCRDs(kubeClient)
+	if err != nil {
+		report.APIServer.Message = fmt.Sprintf("discover api groups failed: %v", err)
+		return report
+	}
+	report.CRDs = crdChecks
+	// 组件提供了所有必需的CRD且至少提供了一个CRD时视为已安装
+	components := map[string]*ComponentCheck{}
+	missing := map[string][]string{}
+	var names []string
+	for i, crd := range probedCRDs {
+		component, ok := components[crd.component]
+		if !ok {
+			component = &ComponentCheck{Name: crd.component, Installed: true}
+			components[crd.component] = component
+			names = append(names, crd.component)
+		}
+		component.Required = component.Required || crd.required
+		if !crdChecks[i].Supported {
+			missing[crd.component] = append(missing[crd.component], crd.gvk.Kind)
+			if crd.required {
+				component.Installed = false
+			}
+		}
+	}
+	for _, name := range names {
+		component := components[name]
+		if len(missing[name]) > 0 {
+			if !component.Required && len(missing[name]) == countCRDs(name) {
+				component.Installed = false
+			}
+			component.Message = fmt.Sprintf("%s not served", strings.Join(missing[name], ", "))
+		}
+		report.Components = append(report.Components, *component)
+	}
+	report.Components = append(report.Components, checkCSIDriver(kubeClient))
+	for _, component := range report.Components {
+		if component.Required && !component.Installed {
+			ready = false
+		}
+	}
+	report.Ready = ready
+	return report
+}
+
+func countCRDs(component string) int {
+	var count int
+	for _, crd := range probedCRDs {
+		if crd.component == component {
+			count++
+		}
+	}
+	return count
+}
+
+func checkPermission(kubeClient kubernetes.Interface, group, resource, verb, namespace string) PermissionCheck {
+	check := PermissionCheck{Group: group, Resource: resource, Verb: verb, Namespace: namespace}
+	review := &authorizationv1.SelfSubjectAccessReview{
+		Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace: namespace,
+				Verb:      verb,
+				Group:     group,
+				Resource:  resource,
+			},
+		},
+	}
+	// 子资源需要单独设置，如pods/log
+	if i := strings.Index(resource, "/"); i > 0 {
+		review.Spec.ResourceAttributes.Resource = resource[:i]
+		review.Spec.ResourceAttributes.Subresource = resource[i+1:]
+	}
+	result, err := kubeClient.AuthorizationV1().SelfSubjectAccessReviews().Create(context.TODO(), review, metav1.CreateOptions{})
+	if err != nil {
+		check.Message = err.Error()
+		return check
+	}
+	check.Allowed = result.Status.Allowed
+	check.Message = result.Status.Reason
+	return check
+}
+
+// checkCRDs 按probedCRDs的顺序返回各CRD在集群中的版本
+func checkCRDs(kubeClient kubernetes.Interface) ([]CRDCheck, error) {
+	groupList, err := kubeClient.Discovery().ServerGroups()
+	if err != nil {
+		return nil, err
+	}
+	served := map[string][]string{}
+	for _, group := range groupList.Groups {
+		for _, version := range group.Versions {
+			served[group.Name] = append(served[group.Name], version.Version)
+		}
+	}
+	checks := make([]CRDCheck, 0, len(probedCRDs))
+	for _, crd := range probedCRDs {
+		check := CRDCheck{
+			Kind:            crd.gvk.Kind,
+			Group:           crd.gvk.Group,
+			ExpectedVersion: crd.gvk.Version,
+			ServedVersions:  []string{},
+		}
+		for _, version := range served[crd.gvk.Group] {
+			resources, err := kubeClient.Discovery().ServerResourcesForGroupVersion(
+				k8sschema.GroupVersion{Group: crd.gvk.Group, Version: version}.String())
+			if err != nil {
+				continue
+			}
+			for _, resource := range resources.APIResources {
+				if resource.Kind == crd.gvk.Kind {
+					check.ServedVersions = append(check.ServedVersions, version)
+					check.Supported = check.Supported || version == crd.gvk.Version
+					break
+				}
+			}
+		}
+		checks = append(checks, check)
+	}
+	return checks, nil
+}
+
+func checkCSIDriver(kubeClient kubernetes.Interface) ComponentCheck {
+	check := ComponentCheck{Name: "csi-driver", Required: true}
+	if _, err := kubeClient.StorageV1().CSIDrivers().Get(context.TODO(), csiDriverName, metav1.GetOptions{}); err != nil {
+		check.Message = fmt.Sprintf("csi driver %s is not found: %v", csiDriverName, err)
+		return check
+	}
+	check.Installed = true
+	return check
+}
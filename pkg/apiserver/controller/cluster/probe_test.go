@@ -0,0 +1,135 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage/driver"
+)
+
+func newProbeClient(withCSI bool, denied string) *fake.Clientset {
+	var objects []k8sruntime.Object
+	if withCSI {
+		objects = append(objects, &storagev1.CSIDriver{ObjectMeta: metav1.ObjectMeta{Name: csiDriverName}})
+	}
+	client := fake.NewSimpleClientset(objects...)
+	client.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+		review.Status.Allowed = review.Spec.ResourceAttributes.Resource != denied
+		return true, review, nil
+	})
+	discovery := client.Discovery().(*fakediscovery.FakeDiscovery)
+	discovery.FakedServerVersion = &version.Info{GitVersion: "v1.20.1"}
+	discovery.Resources = []*metav1.APIResourceList{
+		{GroupVersion: "batch.paddlepaddle.org/v1", APIResources: []metav1.APIResource{{Name: "paddlejobs", Kind: "PaddleJob"}}},
+		{GroupVersion: "scheduling.volcano.sh/v1beta1", APIResources: []metav1.APIResource{
+			{Name: "podgroups", Kind: "PodGroup"}, {Name: "queues", Kind: "Queue"}}},
+		{GroupVersion: "kubeflow.org/v1", APIResources: []metav1.APIResource{{Name: "tfjobs", Kind: "TFJob"}}},
+		{GroupVersion: "kubeflow.org/v2beta1", APIResources: []metav1.APIResource{{Name: "mpijobs", Kind: "MPIJob"}}},
+	}
+	return client
+}
+
+func TestProbeCluster(t *testing.T) {
+	clusterInfo := model.ClusterInfo{Name: MockClusterName, ClusterType: schema.KubernetesType, NamespaceList: []string{"n1", "n2"}}
+	client := newProbeClient(true, "")
+	getProbeClient = func(clusterInfo model.ClusterInfo) (kubernetes.Interface, error) {
+		return client, nil
+	}
+
+	report := ProbeCluster(clusterInfo)
+	assert.True(t, report.Ready)
+	assert.Equal(t, APIServerCheck{Reachable: true, Version: "v1.20.1"}, report.APIServer)
+	for _, check := range report.Permissions {
+		assert.True(t, check.Allowed)
+	}
+	// pods在两个namespace中各检查5个操作
+	var podChecks int
+	for _, check := range report.Permissions {
+		if check.Resource == "pods" {
+			podChecks++
+		}
+	}
+	assert.Equal(t, 10, podChecks)
+	crds := map[string]CRDCheck{}
+	for _, crd := range report.CRDs {
+		crds[crd.Kind] = crd
+	}
+	assert.True(t, crds["PaddleJob"].Supported)
+	assert.True(t, crds["TFJob"].Supported)
+	assert.Equal(t, CRDCheck{Kind: "MPIJob", Group: "kubeflow.org", ExpectedVersion: "v1",
+		ServedVersions: []string{"v2beta1"}}, crds["MPIJob"])
+	assert.False(t, crds["RayJob"].Supported)
+	components := map[string]ComponentCheck{}
+	for _, component := range report.Components {
+		components[component.Name] = component
+	}
+	// ElasticResourceQuota不是必需的CRD
+	assert.Equal(t, ComponentCheck{Name: "volcano", Required: true, Installed: true,
+		Message: "ElasticResourceQuota not served"}, components["volcano"])
+	assert.True(t, components["paddle-operator"].Installed)
+	assert.True(t, components["training-operator"].Installed)
+	assert.False(t, components["kuberay-operator"].Installed)
+	assert.True(t, components["csi-driver"].Installed)
+
+	// 缺少csi driver和权限时未就绪
+	client = newProbeClient(false, "nodes")
+	report = ProbeCluster(clusterInfo)
+	assert.False(t, report.Ready)
+	for _, check := range report.Permissions {
+		assert.Equal(t, check.Resource != "nodes", check.Allowed)
+	}
+}
+
+func TestCreateClusterDryRun(t *testing.T) {
+	driver.InitMockDB()
+	client := newProbeClient(true, "")
+	getProbeClient = func(clusterInfo model.ClusterInfo) (kubernetes.Interface, error) {
+		return client, nil
+	}
+	request := CreateClusterRequest{Name: MockClusterName, DryRun: true}
+	request.Endpoint = "127.0.0.1"
+	request.ClusterType = schema.KubernetesType
+	request.Version = "1.20"
+	request.Credential = "credential"
+	resp, err := CreateCluster(&logger.RequestContext{UserName: MockRootUser}, &request)
+	assert.NoError(t, err)
+	assert.NotNil(t, resp.Readiness)
+	assert.Empty(t, resp.Credential)
+	_, err = storage.Cluster.GetClusterByName(MockClusterName)
+	assert.Error(t, err)
+
+	request.ClusterType = schema.LocalType
+	_, err = CreateCluster(&logger.RequestContext{UserName: MockRootUser}, &request)
+	assert.Error(t, err)
+}
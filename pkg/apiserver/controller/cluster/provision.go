@@ -0,0 +1,321 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+
+	log "github.com/sirupsen/logrus"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/yaml"
+
+	"github.com/PaddlePaddle/PaddleFlow/installer"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	runtime "github.com/PaddlePaddle/PaddleFlow/pkg/job/runtime_v2"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+)
+
+const (
+	ComponentVolcano        = "volcano"
+	ComponentPaddleOperator = "paddle-operator"
+	ComponentCSIDriver      = "csi-driver"
+)
+
+var versionRegexp = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,127}$`)
+
+// component 可以由PaddleFlow安装的组件，版本为images中镜像的tag
+type component struct {
+	name           string
+	manifest       string
+	images         []string
+	defaultVersion string
+}
+
+// provisionComponents 按安装顺序排列，volcano提供的PodGroup需要先于paddle-operator安装
+var provisionComponents = []component{
+	{
+		name:     ComponentVolcano,
+		manifest: "deploys/volcano/pf-volcano-deploy.yaml",
+		images: []string{"paddleflow/vc-webhook-manager", "paddleflow/vc-controller-manager",
+			"paddleflow/vc-scheduler"},
+		defaultVersion: "pf-1.4-vc-1.3",
+	},
+	{
+		name:           ComponentPaddleOperator,
+		manifest:       "deploys/paddle-operator/paddle-operator-deploy.yaml",
+		images:         []string{"registry.baidubce.com/paddle-operator/controller"},
+		defaultVersion: "v0.4",
+	},
+	{
+		name:           ComponentCSIDriver,
+		manifest:       "deploys/paddleflow-csi-plugin/paddleflow-csi-plugin-deploy.yaml",
+		images:         []string{"paddleflow/pfs-csi-plugin"},
+		defaultVersion: "1.4.5",
+	},
+}
+
+// ComponentVersion 安装的组件及固定的版本，Version为空时使用内置部署文件中的版本
+type ComponentVersion struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type ProvisionRequest struct {
+	// Components 为空时安装所有组件
+	Components []ComponentVersion `json:"components"`
+}
+
+type ListComponentsResponse struct {
+	Components []model.ClusterComponent `json:"components"`
+}
+
+// provisionPlan 渲染后待应用到集群的组件对象
+type provisionPlan struct {
+	name    string
+	version string
+	objects []*unstructured.Unstructured
+}
+
+// manifestApplier 把对象创建到集群中，已存在时更新
+type manifestApplier interface {
+	apply(obj *unstructured.Unstructured) error
+}
+
+var getManifestApplier = func(clusterInfo model.ClusterInfo) (manifestApplier, error) {
+	runtimeSvc, err := runtime.GetOrCreateRuntime(clusterInfo)
+	if err != nil {
+		return nil, err
+	}
+	kubeRuntime, ok := runtimeSvc.(*runtime.KubeRuntime)
+	if !ok {
+		return nil, fmt.Errorf("runtime of cluster[%s] does not support provisioning", clusterInfo.Name)
+	}
+	return &kubeApplier{runtime: kubeRuntime}, nil
+}
+
+type kubeApplier struct {
+	runtime *runtime.KubeRuntime
+}
+
+func (ka *kubeApplier) apply(obj *unstructured.Unstructured) error {
+	existing, err := ka.runtime.GetObject(obj.GetNamespace(), obj.GetName(), obj.GroupVersionKind())
+	if k8serrors.IsNotFound(err) {
+		return ka.runtime.CreateObject(obj)
+	} else if err != nil {
+		return err
+	}
+	current, ok := existing.(*unstructured.Unstructured)
+	if !ok {
+		return fmt.Errorf("unexpected type %T of %s %s", existing, obj.GetKind(), obj.GetName())
+	}
+	switch obj.GetKind() {
+	case "Job":
+		// Job的pod模板不可修改，已存在时跳过
+		return nil
+	case "Service":
+		clusterIP, _, _ := unstructured.NestedString(current.Object, "spec", "clusterIP")
+		if clusterIP != "" {
+			_ = unstructured.SetNestedField(obj.Object, clusterIP, "spec", "clusterIP")
+		}
+	}
+	obj.SetResourceVersion(current.GetResourceVersion())
+	return ka.runtime.UpdateObject(obj)
+}
+
+// ProvisionCluster 在集群中安装或升级组件，安装在后台进行，返回各组件的安装状态
+func ProvisionCluster(ctx *logger.RequestContext, clusterName string, request *ProvisionRequest) (*ListComponentsResponse, error) {
+	if !common.IsRootUser(ctx.UserName) {
+		ctx.ErrorCode = common.OnlyRootAllowed
+		err := fmt.Errorf("only root user can provision cluster")
+		ctx.Logging().Errorln(err.Error())
+		return nil, err
+	}
+	clusterInfo, err := storage.Cluster.GetClusterByName(clusterName)
+	if err != nil {
+		ctx.ErrorCode = common.ClusterNameNotFound
+		ctx.Logging().Errorf("get cluster failed. clusterName:[%s]", clusterName)
+		return nil, err
+	}
+	if clusterInfo.ClusterType != schema.KubernetesType {
+		ctx.ErrorCode = common.ActionNotAllowed
+		return nil, fmt.Errorf("provisioning is not supported in clusterType %s", clusterInfo.ClusterType)
+	}
+	plans, err := newProvisionPlans(request.Components)
+	if err != nil {
+		ctx.ErrorCode = common.InvalidArguments
+		ctx.Logging().Errorf("provision cluster %s failed, err: %v", clusterName, err)
+		return nil, err
+	}
+	components, err := startProvision(clusterInfo, plans)
+	if err != nil {
+		ctx.ErrorCode = common.ActionNotAllowed
+		ctx.Logging().Errorf("provision cluster %s failed, err: %v", clusterName, err)
+		return nil, err
+	}
+	return &ListComponentsResponse{Components: components}, nil
+}
+
+// ListClusterComponents 列出PaddleFlow在集群中安装的组件及安装状态
+func ListClusterComponents(ctx *logger.RequestContext, clusterName string) (*ListComponentsResponse, error) {
+	clusterInfo, err := storage.Cluster.GetClusterByName(clusterName)
+	if err != nil {
+		ctx.ErrorCode = common.ClusterNameNotFound
+		ctx.Logging().Errorf("get cluster failed. clusterName:[%s]", clusterName)
+		return nil, err
+	}
+	components, err := storage.Cluster.ListClusterComponents(clusterInfo.ID)
+	if err != nil {
+		ctx.ErrorCode = common.InternalError
+		return nil, err
+	}
+	return &ListComponentsResponse{Components: components}, nil
+}
+
+// newProvisionPlans 检查组件和版本，并按版本渲染组件的部署文件
+func newProvisionPlans(versions []ComponentVersion) ([]provisionPlan, error) {
+	pinned := make(map[string]string)
+	for _, v := range versions {
+		if _, ok := pinned[v.Name]; ok {
+			return nil, fmt.Errorf("component %s is duplicated", v.Name)
+		}
+		if v.Version != "" && !versionRegexp.MatchString(v.Version) {
+			return nil, fmt.Errorf("version %s of component %s is invalid", v.Version, v.Name)
+		}
+		pinned[v.Name] = v.Version
+	}
+	var plans []provisionPlan
+	for _, c := range provisionComponents {
+		version, ok := pinned[c.name]
+		if !ok && len(versions) != 0 {
+			continue
+		}
+		delete(pinned, c.name)
+		if version == "" {
+			version = c.defaultVersion
+		}
+		objects, err := renderManifest(c, version)
+		if err != nil {
+			return nil, fmt.Errorf("render manifest of component %s failed: %v", c.name, err)
+		}
+		plans = append(plans, provisionPlan{name: c.name, version: version, objects: objects})
+	}
+	for name := range pinned {
+		return nil, fmt.Errorf("component %s is not supported, must be %s, %s or %s", name,
+			ComponentVolcano, ComponentPaddleOperator, ComponentCSIDriver)
+	}
+	return plans, nil
+}
+
+// renderManifest 把部署文件中组件镜像的tag替换为version，并解析为对象
+func renderManifest(c component, version string) ([]*unstructured.Unstructured, error) {
+	data, err := installer.Manifests.ReadFile(c.manifest)
+	if err != nil {
+		return nil, err
+	}
+	for _, image := range c.images {
+		re := regexp.MustCompile(`(image:\s*["']?)` + regexp.QuoteMeta(image) + `:[\w.-]+`)
+		data = re.ReplaceAll(data, []byte("${1}"+image+":"+version))
+	}
+	var objects []*unstructured.Unstructured
+	decoder := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096)
+	for {
+		obj := map[string]interface{}{}
+		if err := decoder.Decode(&obj); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		if len(obj) == 0 {
+			continue
+		}
+		objects = append(objects, &unstructured.Unstructured{Object: obj})
+	}
+	return objects, nil
+}
+
+// startProvision 记录组件为安装中并在后台安装，同一组件正在安装时不能重复安装
+func startProvision(clusterInfo model.ClusterInfo, plans []provisionPlan) ([]model.ClusterComponent, error) {
+	existing, err := storage.Cluster.ListClusterComponents(clusterInfo.ID)
+	if err != nil {
+		return nil, err
+	}
+	installing := make(map[string]bool)
+	for _, c := range existing {
+		installing[c.Name] = c.Status == model.ComponentInstalling
+	}
+	components := make([]model.ClusterComponent, 0, len(plans))
+	for _, plan := range plans {
+		if installing[plan.name] {
+			return nil, fmt.Errorf("component %s is being installed", plan.name)
+		}
+		components = append(components, model.ClusterComponent{
+			ClusterID: clusterInfo.ID,
+			Name:      plan.name,
+			Version:   plan.version,
+			Status:    model.ComponentInstalling,
+		})
+	}
+	for i := range components {
+		if err := storage.Cluster.SaveClusterComponent(&components[i]); err != nil {
+			return nil, err
+		}
+	}
+	go provision(clusterInfo, plans)
+	return components, nil
+}
+
+// provision 依次应用各组件的对象，一个组件失败时继续安装其他组件
+func provision(clusterInfo model.ClusterInfo, plans []provisionPlan) {
+	applier, err := getManifestApplier(clusterInfo)
+	for _, plan := range plans {
+		component := &model.ClusterComponent{
+			ClusterID: clusterInfo.ID,
+			Name:      plan.name,
+			Version:   plan.version,
+			Status:    model.ComponentInstalled,
+			Message:   fmt.Sprintf("%d objects applied", len(plan.objects)),
+		}
+		if err != nil {
+			component.Status = model.ComponentFailed
+			component.Message = err.Error()
+		} else if applyErr := applyObjects(applier, plan.objects); applyErr != nil {
+			component.Status = model.ComponentFailed
+			component.Message = applyErr.Error()
+		}
+		log.Infof("component %s:%s of cluster %s is %s, %s", plan.name, plan.version, clusterInfo.Name,
+			component.Status, component.Message)
+		if saveErr := storage.Cluster.SaveClusterComponent(component); saveErr != nil {
+			log.Errorf("save status of component %s failed, err: %v", plan.name, saveErr)
+		}
+	}
+}
+
+func applyObjects(applier manifestApplier, objects []*unstructured.Unstructured) error {
+	for _, obj := range objects {
+		if err := applier.apply(obj.DeepCopy()); err != nil {
+			return fmt.Errorf("apply %s %s/%s failed: %v", obj.GetKind(), obj.GetNamespace(), obj.GetName(), err)
+		}
+	}
+	return nil
+}
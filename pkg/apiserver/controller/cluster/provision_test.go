@@ -0,0 +1,136 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage/driver"
+)
+
+type fakeApplier struct {
+	mu       sync.Mutex
+	applied  []*unstructured.Unstructured
+	failKind string
+}
+
+func (f *fakeApplier) apply(obj *unstructured.Unstructured) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if obj.GetKind() == f.failKind {
+		return fmt.Errorf("forbidden")
+	}
+	f.applied = append(f.applied, obj)
+	return nil
+}
+
+func containerImages(objects []*unstructured.Unstructured) []string {
+	var images []string
+	for _, obj := range objects {
+		containers, _, _ := unstructured.NestedSlice(obj.Object, "spec", "template", "spec", "containers")
+		for _, c := range containers {
+			images = append(images, c.(map[string]interface{})["image"].(string))
+		}
+	}
+	return images
+}
+
+func TestNewProvisionPlans(t *testing.T) {
+	plans, err := newProvisionPlans(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(plans))
+	for _, plan := range plans {
+		assert.NotEmpty(t, plan.objects)
+	}
+	assert.Contains(t, containerImages(plans[1].objects), "registry.baidubce.com/paddle-operator/controller:v0.4")
+
+	plans, err = newProvisionPlans([]ComponentVersion{{Name: ComponentVolcano, Version: "pf-1.5-vc-1.3"}})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(plans))
+	images := containerImages(plans[0].objects)
+	assert.Contains(t, images, "paddleflow/vc-scheduler:pf-1.5-vc-1.3")
+	for _, image := range images {
+		assert.False(t, strings.HasSuffix(image, "pf-1.4-vc-1.3"))
+	}
+
+	_, err = newProvisionPlans([]ComponentVersion{{Name: "kubeflow"}})
+	assert.Error(t, err)
+	_, err = newProvisionPlans([]ComponentVersion{{Name: ComponentCSIDriver, Version: "1.4.5; rm"}})
+	assert.Error(t, err)
+	_, err = newProvisionPlans([]ComponentVersion{{Name: ComponentCSIDriver}, {Name: ComponentCSIDriver}})
+	assert.Error(t, err)
+}
+
+func TestProvisionCluster(t *testing.T) {
+	driver.InitMockDB()
+	clusterInfo := model.ClusterInfo{Model: model.Model{ID: "cluster-1"}, Name: MockClusterName,
+		ClusterType: schema.KubernetesType}
+	assert.NoError(t, storage.Cluster.CreateCluster(&clusterInfo))
+	applier := &fakeApplier{failKind: "CSIDriver"}
+	getManifestApplier = func(clusterInfo model.ClusterInfo) (manifestApplier, error) {
+		return applier, nil
+	}
+
+	request := &ProvisionRequest{Components: []ComponentVersion{{Name: ComponentPaddleOperator}, {Name: ComponentCSIDriver}}}
+	_, err := ProvisionCluster(&logger.RequestContext{UserName: "user1"}, MockClusterName, request)
+	assert.Error(t, err)
+
+	response, err := ProvisionCluster(&logger.RequestContext{UserName: MockRootUser}, MockClusterName, request)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(response.Components))
+	assert.Equal(t, model.ComponentInstalling, response.Components[0].Status)
+
+	var components []model.ClusterComponent
+	assert.Eventually(t, func() bool {
+		list, err := ListClusterComponents(&logger.RequestContext{UserName: MockRootUser}, MockClusterName)
+		assert.NoError(t, err)
+		components = list.Components
+		for _, c := range components {
+			if c.Status == model.ComponentInstalling {
+				return false
+			}
+		}
+		return true
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, 2, len(components))
+	assert.Equal(t, ComponentCSIDriver, components[0].Name)
+	assert.Equal(t, model.ComponentFailed, components[0].Status)
+	assert.Contains(t, components[0].Message, "apply CSIDriver")
+	assert.Equal(t, model.ComponentInstalled, components[1].Status)
+	assert.Equal(t, "v0.4", components[1].Version)
+
+	// 升级时更新版本
+	applier.failKind = ""
+	_, err = ProvisionCluster(&logger.RequestContext{UserName: MockRootUser}, MockClusterName,
+		&ProvisionRequest{Components: []ComponentVersion{{Name: ComponentPaddleOperator, Version: "v0.5"}}})
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		list, _ := storage.Cluster.ListClusterComponents(clusterInfo.ID)
+		return len(list) == 2 && list[1].Version == "v0.5" && list[1].Status == model.ComponentInstalled
+	}, 5*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,335 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dataset
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/handler"
+	gormErrors "github.com/PaddlePaddle/PaddleFlow/pkg/common/errors"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/uuid"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+)
+
+const (
+	// MaxManifestFiles 单个数据集版本允许记录的最大文件数
+	MaxManifestFiles     = 100000
+	datasetNameMaxLength = 128
+	createVersionRetries = 3
+)
+
+var datasetNameRegex = regexp.MustCompile("^[a-zA-Z][a-zA-Z0-9_-]*$")
+
+type CreateDatasetRequest struct {
+	Name        string `json:"name"`
+	FsName      string `json:"fsName"`
+	Path        string `json:"path"`
+	Description string `json:"description"`
+}
+
+type CreateDatasetResponse struct {
+	ID string `json:"id"`
+}
+
+type ListDatasetResponse struct {
+	common.MarkerInfo
+	DatasetList []model.Dataset `json:"datasetList"`
+}
+
+type GetDatasetResponse struct {
+	model.Dataset
+	Versions []model.DatasetVersion `json:"versions"`
+}
+
+type CreateVersionRequest struct {
+	Description string `json:"description"`
+}
+
+// DatasetRef 作业中引用的数据集，Version为空时使用最新版本
+type DatasetRef struct {
+	Name      string `json:"name"`
+	Version   string `json:"version,omitempty"`
+	MountPath string `json:"mountPath,omitempty"`
+}
+
+// CreateDataset 将存储中的目录注册为数据集，目录在注册时必须存在
+func CreateDataset(ctx *logger.RequestContext, request CreateDatasetRequest) (CreateDatasetResponse, error) {
+	ctx.Logging().Debugf("begin create dataset: %+v", request)
+	if err := validateCreateDataset(ctx, &request); err != nil {
+		ctx.Logging().Errorf("validate dataset request failed. error: %v", err)
+		return CreateDatasetResponse{}, err
+	}
+	fsID := common.ID(ctx.UserName, request.FsName)
+	if _, err := storage.Filesystem.GetFileSystemWithFsID(fsID); err != nil {
+		ctx.ErrorCode = common.RecordNotFound
+		ctx.Logging().Errorf("get filesystem[%s] failed. error: %v", fsID, err)
+		return CreateDatasetResponse{}, fmt.Errorf("filesystem[%s] not found", request.FsName)
+	}
+	if _, err := storage.Dataset.GetDataset(ctx.Logging(), ctx.UserName, request.Name); err == nil {
+		ctx.ErrorCode = common.DuplicatedName
+		return CreateDatasetResponse{}, fmt.Errorf("dataset[%s] already exists", request.Name)
+	}
+	fsHandler, err := handler.NewFsHandlerWithServer(fsID, ctx.Logging())
+	if err != nil {
+		ctx.ErrorCode = common.InternalError
+		return CreateDatasetResponse{}, err
+	}
+	if isDir, err := fsHandler.IsDir(request.Path); err != nil || !isDir {
+		ctx.ErrorCode = common.InvalidArguments
+		return CreateDatasetResponse{}, fmt.Errorf("path[%s] is not a directory in filesystem[%s]", request.Path, request.FsName)
+	}
+
+	dataset := &model.Dataset{
+		ID:          uuid.GenerateID(common.PrefixDataset),
+		Name:        request.Name,
+		UserName:    ctx.UserName,
+		FsID:        fsID,
+		FsName:      request.FsName,
+		Path:        request.Path,
+		Description: request.Description,
+	}
+	if err := storage.Dataset.CreateDataset(ctx.Logging(), dataset); err != nil {
+		ctx.ErrorCode = common.InternalError
+		return CreateDatasetResponse{}, err
+	}
+	ctx.Logging().Infof("dataset[%s] created with id[%s]", dataset.Name, dataset.ID)
+	return CreateDatasetResponse{ID: dataset.ID}, nil
+}
+
+func validateCreateDataset(ctx *logger.RequestContext, request *CreateDatasetRequest) error {
+	if request.Name == "" || request.FsName == "" {
+		ctx.ErrorCode = common.RequiredFieldEmpty
+		return fmt.Errorf("name and fsName are required")
+	}
+	if len(request.Name) > datasetNameMaxLength || !datasetNameRegex.MatchString(request.Name) {
+		ctx.ErrorCode = common.InvalidArguments
+		return fmt.Errorf("name[%s] of dataset is invalid, it should start with a letter and only contain "+
+			"letters, numbers, '_' and '-', no more than %d characters", request.Name, datasetNameMaxLength)
+	}
+	if strings.Contains(request.Path, "..") {
+		ctx.ErrorCode = common.InvalidArguments
+		return fmt.Errorf("path[%s] is invalid", request.Path)
+	}
+	request.Path = path.Clean("/" + request.Path)
+	return nil
+}
+
+func GetDataset(ctx *logger.RequestContext, name string) (GetDatasetResponse, error) {
+	dataset, err := getDataset(ctx, name)
+	if err != nil {
+		return GetDatasetResponse{}, err
+	}
+	versions, err := storage.Dataset.ListDatasetVersion(ctx.Logging(), dataset.ID)
+	if err != nil {
+		ctx.ErrorCode = common.InternalError
+		return GetDatasetResponse{}, err
+	}
+	return GetDatasetResponse{
+		Dataset:  dataset,
+		Versions: versions,
+	}, nil
+}
+
+func getDataset(ctx *logger.RequestContext, name string) (model.Dataset, error) {
+	dataset, err := storage.Dataset.GetDataset(ctx.Logging(), ctx.UserName, name)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			ctx.ErrorCode = common.RecordNotFound
+			return model.Dataset{}, common.NotFoundError(common.ResourceTypeDataset, name)
+		}
+		ctx.ErrorCode = common.InternalError
+		return model.Dataset{}, err
+	}
+	return dataset, nil
+}
+
+func ListDataset(ctx *logger.RequestContext, marker string, maxKeys int, fsName string) (ListDatasetResponse, error) {
+	response := ListDatasetResponse{DatasetList: []model.Dataset{}}
+	var pk int64
+	var err error
+	if marker != "" {
+		pk, err = common.DecryptPk(marker)
+		if err != nil {
+			ctx.ErrorCode = common.InvalidMarker
+			ctx.Logging().Errorf("DecryptPk marker[%s] failed. err:[%s]", marker, err.Error())
+			return response, err
+		}
+	}
+	// 多查询一条，用于判断是否还有下一页
+	datasets, err := storage.Dataset.ListDataset(ctx.Logging(), pk, maxKeys+1, ctx.UserName, fsName)
+	if err != nil {
+		ctx.ErrorCode = common.InternalError
+		return response, err
+	}
+	if len(datasets) > maxKeys {
+		datasets = datasets[:maxKeys]
+		nextMarker, err := common.EncryptPk(datasets[len(datasets)-1].Pk)
+		if err != nil {
+			ctx.ErrorCode = common.InternalError
+			return response, err
+		}
+		response.IsTruncated = true
+		response.NextMarker = nextMarker
+	}
+	response.MaxKeys = maxKeys
+	response.DatasetList = append(response.DatasetList, datasets...)
+	return response, nil
+}
+
+// DeleteDataset 删除数据集注册信息及版本记录，不会删除存储中的数据
+func DeleteDataset(ctx *logger.RequestContext, name string) error {
+	dataset, err := getDataset(ctx, name)
+	if err != nil {
+		return err
+	}
+	if err := storage.Dataset.DeleteDataset(ctx.Logging(), dataset.ID); err != nil {
+		ctx.ErrorCode = common.InternalError
+		return err
+	}
+	return nil
+}
+
+// CreateVersion 计算数据集目录下所有文件的摘要生成快照，内容未变化时返回最新版本
+func CreateVersion(ctx *logger.RequestContext, name string, request CreateVersionRequest) (model.DatasetVersion, error) {
+	dataset, err := getDataset(ctx, name)
+	if err != nil {
+		return model.DatasetVersion{}, err
+	}
+	version, err := snapshot(ctx.Logging(), dataset)
+	if err != nil {
+		ctx.ErrorCode = common.InternalError
+		ctx.Logging().Errorf("snapshot dataset[%s] failed. error: %v", dataset.Name, err)
+		return model.DatasetVersion{}, err
+	}
+	if dataset.LatestVersion != "" {
+		latest, err := storage.Dataset.GetDatasetVersion(ctx.Logging(), dataset.ID, dataset.LatestVersion)
+		if err == nil && latest.Digest == version.Digest {
+			ctx.Logging().Infof("dataset[%s] is not changed since version[%s]", dataset.Name, latest.Version)
+			return latest, nil
+		}
+	}
+	version.Description = request.Description
+	// 版本号在事务中生成，并发创建时唯一索引冲突则重试
+	for i := 0; ; i++ {
+		err = storage.Dataset.CreateDatasetVersion(ctx.Logging(), &version)
+		if err == nil || i >= createVersionRetries-1 || gormErrors.GetErrorCode(err) != gormErrors.ErrorKeyIsDuplicated {
+			break
+		}
+		ctx.Logging().Warningf("version[%s] of dataset[%s] is duplicated, retry", version.Version, dataset.Name)
+	}
+	if err != nil {
+		ctx.ErrorCode = common.InternalError
+		return model.DatasetVersion{}, err
+	}
+	ctx.Logging().Infof("version[%s] of dataset[%s] created, digest: %s", version.Version, dataset.Name, version.Digest)
+	return version, nil
+}
+
+func snapshot(logEntry *log.Entry, dataset model.Dataset) (model.DatasetVersion, error) {
+	fsHandler, err := handler.NewFsHandlerWithServer(dataset.FsID, logEntry)
+	if err != nil {
+		return model.DatasetVersion{}, err
+	}
+	digests, err := fsHandler.FileDigests(dataset.Path, MaxManifestFiles)
+	if err != nil {
+		return model.DatasetVersion{}, err
+	}
+	version := model.DatasetVersion{
+		DatasetID: dataset.ID,
+		Manifest:  make([]model.DatasetFile, 0, len(digests)),
+	}
+	// 摘要由按路径排序的文件清单计算得到，用于判断两个版本内容是否一致
+	hash := sha256.New()
+	for _, digest := range digests {
+		version.Manifest = append(version.Manifest, model.DatasetFile{
+			Path: digest.Path,
+			Size: digest.Size,
+			Hash: digest.Hash,
+		})
+		version.FileCount++
+		version.TotalSize += digest.Size
+		fmt.Fprintf(hash, "%s %d %s\n", digest.Path, digest.Size, digest.Hash)
+	}
+	version.Digest = hex.EncodeToString(hash.Sum(nil))
+	return version, nil
+}
+
+func GetVersion(ctx *logger.RequestContext, name, version string) (model.DatasetVersion, error) {
+	dataset, err := getDataset(ctx, name)
+	if err != nil {
+		return model.DatasetVersion{}, err
+	}
+	datasetVersion, err := storage.Dataset.GetDatasetVersion(ctx.Logging(), dataset.ID, version)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			ctx.ErrorCode = common.RecordNotFound
+			return model.DatasetVersion{}, fmt.Errorf("version[%s] of dataset[%s] not found", version, name)
+		}
+		ctx.ErrorCode = common.InternalError
+		return model.DatasetVersion{}, err
+	}
+	return datasetVersion, nil
+}
+
+// ResolveDatasets 将作业引用的数据集转换为只读挂载的存储，并返回形如 name@version 的版本记录。
+// 挂载的是数据集目录的当前内容，版本记录用于结合文件清单追溯作业使用的数据
+func ResolveDatasets(logEntry *log.Entry, userName string, refs []DatasetRef) ([]schema.FileSystem, []string, error) {
+	fileSystems := make([]schema.FileSystem, 0, len(refs))
+	versions := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		dataset, err := storage.Dataset.GetDataset(logEntry, userName, ref.Name)
+		if err != nil {
+			return nil, nil, fmt.Errorf("get dataset[%s] failed, err: %v", ref.Name, err)
+		}
+		version := ref.Version
+		if version == "" {
+			version = dataset.LatestVersion
+		}
+		if version == "" {
+			return nil, nil, fmt.Errorf("dataset[%s] has no version, please create a version first", ref.Name)
+		}
+		if _, err := storage.Dataset.GetDatasetVersion(logEntry, dataset.ID, version); err != nil {
+			return nil, nil, fmt.Errorf("get version[%s] of dataset[%s] failed, err: %v", version, ref.Name, err)
+		}
+		mountPath := ref.MountPath
+		if mountPath == "" {
+			mountPath = filepath.Join(schema.DefaultFSMountPath, "datasets", dataset.Name)
+		}
+		fileSystems = append(fileSystems, schema.FileSystem{
+			ID:        dataset.FsID,
+			Name:      dataset.FsName,
+			MountPath: mountPath,
+			SubPath:   strings.TrimPrefix(dataset.Path, "/"),
+			ReadOnly:  true,
+		})
+		versions = append(versions, fmt.Sprintf("%s@%s", dataset.Name, version))
+	}
+	return fileSystems, versions, nil
+}
@@ -0,0 +1,146 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dataset
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/handler"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage/driver"
+)
+
+const (
+	mockRootUser = "root"
+	mockFsName   = "fs1"
+	mockDataDir  = "./mock_fs_handler/data"
+)
+
+func initDatasetTest(t *testing.T) {
+	driver.InitMockDB()
+	handler.NewFsHandlerWithServer = handler.MockerNewFsHandlerWithServer
+	fs := model.FileSystem{
+		Model: model.Model{
+			ID: common.ID(mockRootUser, mockFsName),
+		},
+		Name:     mockFsName,
+		Type:     "local",
+		UserName: mockRootUser,
+	}
+	assert.Nil(t, storage.Filesystem.CreatFileSystem(&fs))
+	assert.Nil(t, os.MkdirAll(mockDataDir+"/sub", 0755))
+	assert.Nil(t, os.WriteFile(mockDataDir+"/a.txt", []byte("hello"), 0644))
+	assert.Nil(t, os.WriteFile(mockDataDir+"/sub/b.txt", []byte("world"), 0644))
+}
+
+func TestDatasetVersioning(t *testing.T) {
+	initDatasetTest(t)
+	defer os.RemoveAll("./mock_fs_handler")
+	ctx := &logger.RequestContext{UserName: mockRootUser}
+
+	// invalid name
+	_, err := CreateDataset(ctx, CreateDatasetRequest{Name: "1-data", FsName: mockFsName, Path: "data"})
+	assert.NotNil(t, err)
+	assert.Equal(t, common.InvalidArguments, ctx.ErrorCode)
+
+	// path not exist
+	ctx = &logger.RequestContext{UserName: mockRootUser}
+	_, err = CreateDataset(ctx, CreateDatasetRequest{Name: "mnist", FsName: mockFsName, Path: "notexist"})
+	assert.NotNil(t, err)
+
+	ctx = &logger.RequestContext{UserName: mockRootUser}
+	resp, err := CreateDataset(ctx, CreateDatasetRequest{Name: "mnist", FsName: mockFsName, Path: "data"})
+	assert.Nil(t, err)
+	assert.NotEmpty(t, resp.ID)
+
+	_, err = CreateDataset(ctx, CreateDatasetRequest{Name: "mnist", FsName: mockFsName, Path: "data"})
+	assert.NotNil(t, err)
+	assert.Equal(t, common.DuplicatedName, ctx.ErrorCode)
+
+	// job referencing a dataset without version
+	ctx = &logger.RequestContext{UserName: mockRootUser}
+	_, _, err = ResolveDatasets(ctx.Logging(), mockRootUser, []DatasetRef{{Name: "mnist"}})
+	assert.NotNil(t, err)
+
+	v1, err := CreateVersion(ctx, "mnist", CreateVersionRequest{Description: "init"})
+	assert.Nil(t, err)
+	assert.Equal(t, "v1", v1.Version)
+	assert.Equal(t, int64(2), v1.FileCount)
+	assert.Equal(t, int64(10), v1.TotalSize)
+	assert.Equal(t, "a.txt", v1.Manifest[0].Path)
+	assert.Equal(t, "sub/b.txt", v1.Manifest[1].Path)
+
+	// content unchanged, latest version returned
+	same, err := CreateVersion(ctx, "mnist", CreateVersionRequest{})
+	assert.Nil(t, err)
+	assert.Equal(t, "v1", same.Version)
+
+	assert.Nil(t, os.WriteFile(mockDataDir+"/a.txt", []byte("hello paddle"), 0644))
+	v2, err := CreateVersion(ctx, "mnist", CreateVersionRequest{})
+	assert.Nil(t, err)
+	assert.Equal(t, "v2", v2.Version)
+	assert.NotEqual(t, v1.Digest, v2.Digest)
+
+	got, err := GetVersion(ctx, "mnist", "v1")
+	assert.Nil(t, err)
+	assert.Equal(t, v1.Digest, got.Digest)
+	assert.Equal(t, 2, len(got.Manifest))
+
+	_, err = GetVersion(ctx, "mnist", "v3")
+	assert.NotNil(t, err)
+	assert.Equal(t, common.RecordNotFound, ctx.ErrorCode)
+
+	ctx = &logger.RequestContext{UserName: mockRootUser}
+	dataset, err := GetDataset(ctx, "mnist")
+	assert.Nil(t, err)
+	assert.Equal(t, "v2", dataset.LatestVersion)
+	assert.Equal(t, 2, len(dataset.Versions))
+
+	list, err := ListDataset(ctx, "", 50, mockFsName)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(list.DatasetList))
+	assert.False(t, list.IsTruncated)
+
+	fileSystems, versions, err := ResolveDatasets(ctx.Logging(), mockRootUser, []DatasetRef{{Name: "mnist"}})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"mnist@v2"}, versions)
+	assert.True(t, fileSystems[0].ReadOnly)
+	assert.Equal(t, "data", fileSystems[0].SubPath)
+	assert.Equal(t, "/home/paddleflow/storage/mnt/datasets/mnist", fileSystems[0].MountPath)
+
+	_, versions, err = ResolveDatasets(ctx.Logging(), mockRootUser, []DatasetRef{{Name: "mnist", Version: "v1", MountPath: "/data"}})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"mnist@v1"}, versions)
+
+	// version number is max+1, not reused after earlier versions are removed
+	assert.Nil(t, storage.DB.Where("dataset_id = ? AND version = ?", dataset.ID, "v1").Delete(&model.DatasetVersion{}).Error)
+	assert.Nil(t, os.WriteFile(mockDataDir+"/a.txt", []byte("hello paddleflow"), 0644))
+	v3, err := CreateVersion(ctx, "mnist", CreateVersionRequest{})
+	assert.Nil(t, err)
+	assert.Equal(t, "v3", v3.Version)
+
+	assert.Nil(t, DeleteDataset(ctx, "mnist"))
+	_, err = GetDataset(ctx, "mnist")
+	assert.NotNil(t, err)
+	assert.Equal(t, common.RecordNotFound, ctx.ErrorCode)
+}
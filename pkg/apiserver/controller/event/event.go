@@ -0,0 +1,144 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package event
+
+import (
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/config"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+)
+
+const (
+	// SpecVersion 事件格式版本，字段只增不改，不兼容的修改需要升级版本
+	SpecVersion = "1.0"
+
+	defaultPublishInterval = 5 * time.Second
+	defaultBatchSize       = 500
+	defaultRetention       = 7 * 24 * time.Hour
+)
+
+// Event 发布到消息总线的事件，Type为<资源类型>.<事件类型>，如job.status_changed，
+// 事件至少投递一次，订阅方可按ID去重
+type Event struct {
+	SpecVersion    string `json:"specVersion"`
+	ID             string `json:"id"`
+	Type           string `json:"type"`
+	ResourceType   string `json:"resourceType"`
+	ResourceID     string `json:"resourceID"`
+	Status         string `json:"status"`
+	PreviousStatus string `json:"previousStatus"`
+	UserName       string `json:"userName"`
+	Time           string `json:"time"`
+}
+
+func newEvent(e model.ResourceEvent) Event {
+	return Event{
+		SpecVersion:    SpecVersion,
+		ID:             e.ID,
+		Type:           e.ResourceType + "." + e.Type,
+		ResourceType:   e.ResourceType,
+		ResourceID:     e.ResourceID,
+		Status:         e.Status,
+		PreviousStatus: e.PreviousStatus,
+		UserName:       e.UserName,
+		Time:           e.CreatedAt.Format(time.RFC3339Nano),
+	}
+}
+
+var bus publisher
+
+// Init 开启事件总线时检查配置并开始记录资源状态变化事件
+func Init(conf config.EventBusConfig) error {
+	if !conf.Enable {
+		return nil
+	}
+	p, err := newPublisher(conf)
+	if err != nil {
+		return err
+	}
+	bus = p
+	storage.EnableEvents()
+	return nil
+}
+
+// EventController 定期把未发布的事件按写入顺序发布到消息总线，并清理过期的已发布事件
+func EventController(stopChan chan struct{}) {
+	if bus == nil {
+		return
+	}
+	conf := config.GlobalServerConfig.EventBus
+	interval := time.Duration(conf.IntervalSeconds) * time.Second
+	if interval <= 0 {
+		interval = defaultPublishInterval
+	}
+	batchSize := conf.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+	retention := time.Duration(conf.RetentionHours) * time.Hour
+	if retention <= 0 {
+		retention = defaultRetention
+	}
+	for {
+		if _, err := publishEvents(bus, batchSize); err != nil {
+			// 未标记为已发布的事件下一轮重新发布
+			log.Errorf("publish events failed. error: %v", err)
+		}
+		if count, err := storage.Event.DeletePublishedBefore(time.Now().Add(-retention)); err != nil {
+			log.Errorf("delete published events failed. error: %v", err)
+		} else if count > 0 {
+			log.Infof("%d published events deleted", count)
+		}
+		select {
+		case <-stopChan:
+			log.Info("event controller stopped")
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// publishEvents 分批发布所有未发布的事件，返回发布的数量
+func publishEvents(p publisher, limit int) (int, error) {
+	var count int
+	for {
+		records, err := storage.Event.ListUnpublished(limit)
+		if err != nil || len(records) == 0 {
+			return count, err
+		}
+		events := make([]Event, 0, len(records))
+		pks := make([]int64, 0, len(records))
+		for _, r := range records {
+			events = append(events, newEvent(r))
+			pks = append(pks, r.Pk)
+		}
+		if err := p.publish(events); err != nil {
+			return count, err
+		}
+		if err := storage.Event.MarkPublished(pks, time.Now()); err != nil {
+			return count, err
+		}
+		count += len(records)
+		if len(records) < limit {
+			return count, nil
+		}
+	}
+}
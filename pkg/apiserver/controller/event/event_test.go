@@ -0,0 +1,156 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package event
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/config"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage/driver"
+)
+
+type kafkaRequest struct {
+	Records []kafkaRecord `json:"records"`
+}
+
+func TestPublishEvents(t *testing.T) {
+	driver.InitMockDB()
+	storage.EnableEvents()
+
+	job := model.Job{ID: "job-1", UserName: "user1", Status: schema.StatusJobPending, Config: &schema.Conf{}}
+	assert.NoError(t, storage.Job.CreateJob(&job))
+	assert.NoError(t, storage.Job.UpdateJobStatus("job-1", "", schema.StatusJobRunning))
+	// 状态未变化时不记录事件
+	_, err := storage.Job.UpdateJob("job-1", schema.StatusJobRunning, nil, nil, "")
+	assert.NoError(t, err)
+	queue := model.Queue{Model: model.Model{ID: "queue-1"}, Name: "queue-1", Status: "open"}
+	assert.NoError(t, storage.Queue.CreateQueue(&queue))
+	assert.NoError(t, storage.Queue.UpdateQueueStatus("queue-1", "closed"))
+	assert.NoError(t, storage.Job.DeleteJob("job-1"))
+
+	requests := make(map[string]kafkaRequest)
+	fail := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		assert.Equal(t, kafkaContentType, r.Header.Get("Content-Type"))
+		var request kafkaRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		requests[r.URL.Path] = request
+	}))
+	defer server.Close()
+	p, err := newPublisher(config.EventBusConfig{Type: BusKafka,
+		Kafka: config.KafkaRestProxyConfig{RestProxy: server.URL, TopicPrefix: "pf."}})
+	assert.NoError(t, err)
+
+	_, err = publishEvents(p, 2)
+	assert.Error(t, err)
+	unpublished, err := storage.Event.ListUnpublished(10)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, len(unpublished))
+
+	fail = false
+	count, err := publishEvents(p, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, count)
+	unpublished, err = storage.Event.ListUnpublished(10)
+	assert.NoError(t, err)
+	assert.Empty(t, unpublished)
+
+	// 每批2条，job topic最后收到的一批只包含删除事件
+	jobRecords := requests["/topics/pf.job"].Records
+	assert.Equal(t, 1, len(jobRecords))
+	assert.Equal(t, "job.deleted", jobRecords[0].Value.Type)
+	queueRecords := requests["/topics/pf.queue"].Records
+	assert.Equal(t, 2, len(queueRecords))
+	assert.Equal(t, "queue.created", queueRecords[0].Value.Type)
+	assert.Equal(t, "queue-1", queueRecords[1].Key)
+	assert.Equal(t, Event{SpecVersion: SpecVersion, ID: queueRecords[1].Value.ID, Type: "queue.status_changed",
+		ResourceType: "queue", ResourceID: "queue-1", Status: "closed", PreviousStatus: "open",
+		Time: queueRecords[1].Value.Time}, queueRecords[1].Value)
+
+	deleted, err := storage.Event.DeletePublishedBefore(time.Now().Add(time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), deleted)
+}
+
+func TestNATSPublisher(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer listener.Close()
+	received := make(chan []string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.Write([]byte("INFO {\"server_id\":\"test\"}\r\n"))
+		reader := bufio.NewReader(conn)
+		var lines []string
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimSpace(line)
+			if line == "PING" {
+				conn.Write([]byte("PONG\r\n"))
+				received <- lines
+				return
+			}
+			lines = append(lines, line)
+		}
+	}()
+
+	p, err := newPublisher(config.EventBusConfig{Type: BusNATS,
+		NATS: config.NATSConfig{Address: "nats://" + listener.Addr().String(), SubjectPrefix: "pf."}})
+	assert.NoError(t, err)
+	event := Event{SpecVersion: SpecVersion, ID: "event-1", Type: "run.created", ResourceType: "run", ResourceID: "run-000001"}
+	assert.NoError(t, p.publish([]Event{event}))
+
+	lines := <-received
+	assert.Equal(t, 3, len(lines))
+	assert.True(t, strings.HasPrefix(lines[0], "CONNECT "))
+	payload, _ := json.Marshal(event)
+	assert.Equal(t, fmt.Sprintf("PUB pf.run %d", len(payload)), lines[1])
+	assert.Equal(t, string(payload), lines[2])
+}
+
+func TestNewPublisher(t *testing.T) {
+	_, err := newPublisher(config.EventBusConfig{Type: "rabbitmq"})
+	assert.Error(t, err)
+	_, err = newPublisher(config.EventBusConfig{Type: BusKafka})
+	assert.Error(t, err)
+	_, err = newPublisher(config.EventBusConfig{Type: BusNATS})
+	assert.Error(t, err)
+}
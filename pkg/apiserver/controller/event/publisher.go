@@ -0,0 +1,190 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package event
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/config"
+)
+
+const (
+	BusKafka = "kafka"
+	BusNATS  = "nats"
+
+	kafkaContentType = "application/vnd.kafka.json.v2+json"
+	defaultTimeout   = 10 * time.Second
+)
+
+// publisher 消息总线，publish返回nil时事件均已被消息总线接收
+type publisher interface {
+	publish(events []Event) error
+}
+
+func newPublisher(conf config.EventBusConfig) (publisher, error) {
+	switch conf.Type {
+	case BusKafka:
+		if conf.Kafka.RestProxy == "" {
+			return nil, fmt.Errorf("kafka rest proxy of event bus is not configured")
+		}
+		return &kafkaPublisher{
+			restProxy:   strings.TrimSuffix(conf.Kafka.RestProxy, "/"),
+			topicPrefix: conf.Kafka.TopicPrefix,
+			client:      &http.Client{Timeout: defaultTimeout},
+		}, nil
+	case BusNATS:
+		if conf.NATS.Address == "" {
+			return nil, fmt.Errorf("nats address of event bus is not configured")
+		}
+		timeout := time.Duration(conf.NATS.TimeoutSeconds) * time.Second
+		if timeout <= 0 {
+			timeout = defaultTimeout
+		}
+		return &natsPublisher{
+			address:       strings.TrimPrefix(conf.NATS.Address, "nats://"),
+			username:      conf.NATS.Username,
+			password:      conf.NATS.Password,
+			subjectPrefix: conf.NATS.SubjectPrefix,
+			timeout:       timeout,
+		}, nil
+	default:
+		return nil, fmt.Errorf("event bus type[%s] is not supported, must be %s or %s", conf.Type, BusKafka, BusNATS)
+	}
+}
+
+// kafkaPublisher 通过Kafka REST Proxy发送事件，topic为TopicPrefix加资源类型，以资源ID为key保证同一资源的事件有序
+type kafkaPublisher struct {
+	restProxy   string
+	topicPrefix string
+	client      *http.Client
+}
+
+type kafkaRecord struct {
+	Key   string `json:"key"`
+	Value Event  `json:"value"`
+}
+
+func (k *kafkaPublisher) publish(events []Event) error {
+	// 按资源类型分topic发送，保持事件的相对顺序
+	var topics []string
+	records := make(map[string][]kafkaRecord)
+	for _, e := range events {
+		if _, ok := records[e.ResourceType]; !ok {
+			topics = append(topics, e.ResourceType)
+		}
+		records[e.ResourceType] = append(records[e.ResourceType], kafkaRecord{Key: e.ResourceID, Value: e})
+	}
+	for _, topic := range topics {
+		body, err := json.Marshal(map[string]interface{}{"records": records[topic]})
+		if err != nil {
+			return err
+		}
+		url := fmt.Sprintf("%s/topics/%s%s", k.restProxy, k.topicPrefix, topic)
+		resp, err := k.client.Post(url, kafkaContentType, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+			return fmt.Errorf("send events to topic[%s%s] failed, response status code %d", k.topicPrefix, topic, resp.StatusCode)
+		}
+	}
+	return nil
+}
+
+// natsPublisher 使用NATS文本协议发布事件，subject为SubjectPrefix加资源类型，
+// 发送PING并收到PONG后确认服务端已处理之前的所有PUB
+type natsPublisher struct {
+	address       string
+	username      string
+	password      string
+	subjectPrefix string
+	timeout       time.Duration
+}
+
+type natsConnectOptions struct {
+	Verbose  bool   `json:"verbose"`
+	Pedantic bool   `json:"pedantic"`
+	Name     string `json:"name"`
+	Lang     string `json:"lang"`
+	Version  string `json:"version"`
+	User     string `json:"user,omitempty"`
+	Pass     string `json:"pass,omitempty"`
+}
+
+func (n *natsPublisher) publish(events []Event) error {
+	conn, err := net.DialTimeout("tcp", n.address, n.timeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(time.Now().Add(n.timeout)); err != nil {
+		return err
+	}
+	reader := bufio.NewReader(conn)
+	info, err := reader.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(info, "INFO") {
+		return fmt.Errorf("unexpected message from nats server: %s", strings.TrimSpace(info))
+	}
+	options, err := json.Marshal(natsConnectOptions{Name: "paddleflow", Lang: "go", Version: SpecVersion,
+		User: n.username, Pass: n.password})
+	if err != nil {
+		return err
+	}
+	writer := bufio.NewWriter(conn)
+	fmt.Fprintf(writer, "CONNECT %s\r\n", options)
+	for _, e := range events {
+		payload, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(writer, "PUB %s%s %d\r\n", n.subjectPrefix, e.ResourceType, len(payload))
+		writer.Write(payload)
+		writer.WriteString("\r\n")
+	}
+	writer.WriteString("PING\r\n")
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "PONG":
+			return nil
+		case strings.HasPrefix(line, "-ERR"):
+			return fmt.Errorf("nats server error: %s", line)
+		case line == "PING":
+			if _, err := conn.Write([]byte("PONG\r\n")); err != nil {
+				return err
+			}
+		}
+	}
+}
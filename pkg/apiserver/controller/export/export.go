@@ -0,0 +1,251 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package export
+
+import (
+	"database/sql"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/models"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/config"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+)
+
+const (
+	DatasetJob        = "job"
+	DatasetRun        = "run"
+	DatasetQueueUsage = "queue_usage"
+	DatasetUserUsage  = "user_usage"
+
+	defaultExportInterval = time.Hour
+	defaultBatchSize      = 10000
+	// 只导出该时间之前更新的记录，避免漏掉同一时刻仍在写入的记录
+	exportDelay = time.Minute
+)
+
+// row 一条导出记录，t和pk用于推进水位
+type row struct {
+	t      time.Time
+	pk     int64
+	values []interface{}
+}
+
+// dataset 一类导出记录，按timeColumn增量导出，记录更新后会被再次导出，数仓中可按id去重
+type dataset struct {
+	name       string
+	table      string
+	timeColumn string
+	columns    []string
+	// list 列出水位之后的记录并转换为行
+	list func(d dataset, watermark model.ExportWatermark, end time.Time, limit int) ([]row, error)
+}
+
+var datasets = []dataset{
+	{
+		name:       DatasetJob,
+		table:      "job",
+		timeColumn: "updated_at",
+		columns: []string{"id", "name", "user_name", "queue_id", "queue_name", "type", "framework", "status",
+			"run_id", "step_name", "image", "gpus", "created_at", "activated_at", "updated_at", "deleted"},
+		list: listJobRows,
+	},
+	{
+		name:       DatasetRun,
+		table:      "run",
+		timeColumn: "updated_at",
+		columns: []string{"id", "name", "source", "user_name", "fs_name", "status", "schedule_id",
+			"created_at", "activated_at", "updated_at", "deleted"},
+		list: listRunRows,
+	},
+	{
+		name:       DatasetQueueUsage,
+		table:      model.QueueHourlyStat{}.TableName(),
+		timeColumn: "created_at",
+		columns: []string{"time", "queue_name", "pending_jobs", "running_jobs", "succeeded_jobs", "failed_jobs",
+			"terminated_jobs", "gpu_hours", "cpu_hours", "capacity_gpus", "capacity_cpu"},
+		list: listQueueUsageRows,
+	},
+	{
+		name:       DatasetUserUsage,
+		table:      model.UserHourlyStat{}.TableName(),
+		timeColumn: "created_at",
+		columns:    []string{"time", "user_name", "gpu_hours", "job_count"},
+		list:       listUserUsageRows,
+	},
+}
+
+// ExportController 定期把作业、run及用量记录导出到配置的s3路径或kafka
+func ExportController(stopChan chan struct{}) {
+	conf := config.GlobalServerConfig.Export
+	if !conf.Enable {
+		return
+	}
+	s, err := newSink(conf)
+	if err != nil {
+		log.Errorf("export is disabled, init sink failed. error: %v", err)
+		return
+	}
+	interval := time.Duration(conf.IntervalSeconds) * time.Second
+	if interval <= 0 {
+		interval = defaultExportInterval
+	}
+	for {
+		exportAll(s, batchSize(conf), time.Now())
+		select {
+		case <-stopChan:
+			log.Info("export controller stopped")
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+func batchSize(conf config.ExportConfig) int {
+	if conf.BatchSize > 0 {
+		return conf.BatchSize
+	}
+	return defaultBatchSize
+}
+
+func exportAll(s sink, limit int, now time.Time) {
+	for _, d := range datasets {
+		count, err := exportDataset(s, d, limit, now.Add(-exportDelay))
+		if err != nil {
+			// 水位未推进，下一轮重新导出
+			log.Errorf("export %s records failed after %d records exported. error: %v", d.name, count, err)
+			continue
+		}
+		if count > 0 {
+			log.Infof("%d %s records exported", count, d.name)
+		}
+	}
+}
+
+// exportDataset 分批导出水位之后、早于end的记录，每批写入成功后推进水位
+func exportDataset(s sink, d dataset, limit int, end time.Time) (int, error) {
+	watermark, err := storage.Export.GetWatermark(d.name)
+	if err != nil {
+		return 0, err
+	}
+	var count int
+	for {
+		rows, err := d.list(d, watermark, end, limit)
+		if err != nil {
+			return count, err
+		}
+		if len(rows) == 0 {
+			return count, nil
+		}
+		values := make([][]interface{}, 0, len(rows))
+		for _, r := range rows {
+			values = append(values, r.values)
+		}
+		if err := s.write(d.name, d.columns, values); err != nil {
+			return count, err
+		}
+		last := rows[len(rows)-1]
+		watermark.Time, watermark.RecordPk = last.t, last.pk
+		if err := storage.Export.SaveWatermark(&watermark); err != nil {
+			return count, err
+		}
+		count += len(rows)
+		if len(rows) < limit {
+			return count, nil
+		}
+	}
+}
+
+func listJobRows(d dataset, watermark model.ExportWatermark, end time.Time, limit int) ([]row, error) {
+	var jobs []model.Job
+	if err := storage.Export.ListAfterWatermark(d.table, d.timeColumn, watermark, end, limit, &jobs); err != nil {
+		return nil, err
+	}
+	rows := make([]row, 0, len(jobs))
+	for i := range jobs {
+		job := &jobs[i]
+		var queueName, image string
+		if job.Config != nil {
+			queueName = job.Config.GetQueueName()
+			image = job.Config.GetImage()
+		}
+		rows = append(rows, row{t: job.UpdatedAt, pk: job.Pk, values: []interface{}{
+			job.ID, job.Name, job.UserName, job.QueueID, queueName, job.Type, string(job.Framework), string(job.Status),
+			job.RunID, job.StepName, image, model.JobGPUs(job), formatTime(job.CreatedAt), formatNullTime(job.ActivatedAt),
+			formatTime(job.UpdatedAt), job.DeletedAt != "",
+		}})
+	}
+	return rows, nil
+}
+
+func listRunRows(d dataset, watermark model.ExportWatermark, end time.Time, limit int) ([]row, error) {
+	var runs []models.Run
+	if err := storage.Export.ListAfterWatermark(d.table, d.timeColumn, watermark, end, limit, &runs); err != nil {
+		return nil, err
+	}
+	rows := make([]row, 0, len(runs))
+	for _, run := range runs {
+		rows = append(rows, row{t: run.UpdatedAt, pk: run.Pk, values: []interface{}{
+			run.ID, run.Name, run.Source, run.UserName, run.FsName, run.Status, run.ScheduleID,
+			formatTime(run.CreatedAt), formatNullTime(run.ActivatedAt), formatTime(run.UpdatedAt), run.DeletedAt.Valid,
+		}})
+	}
+	return rows, nil
+}
+
+func listQueueUsageRows(d dataset, watermark model.ExportWatermark, end time.Time, limit int) ([]row, error) {
+	var stats []model.QueueHourlyStat
+	if err := storage.Export.ListAfterWatermark(d.table, d.timeColumn, watermark, end, limit, &stats); err != nil {
+		return nil, err
+	}
+	rows := make([]row, 0, len(stats))
+	for _, stat := range stats {
+		rows = append(rows, row{t: stat.CreatedAt, pk: stat.Pk, values: []interface{}{
+			formatTime(stat.Bucket), stat.QueueName, stat.PendingJobs, stat.RunningJobs, stat.SucceededJobs,
+			stat.FailedJobs, stat.TerminatedJobs, stat.GPUHours, stat.CPUHours, stat.CapacityGPUs, stat.CapacityCPU,
+		}})
+	}
+	return rows, nil
+}
+
+func listUserUsageRows(d dataset, watermark model.ExportWatermark, end time.Time, limit int) ([]row, error) {
+	var stats []model.UserHourlyStat
+	if err := storage.Export.ListAfterWatermark(d.table, d.timeColumn, watermark, end, limit, &stats); err != nil {
+		return nil, err
+	}
+	rows := make([]row, 0, len(stats))
+	for _, stat := range stats {
+		rows = append(rows, row{t: stat.CreatedAt, pk: stat.Pk, values: []interface{}{
+			formatTime(stat.Bucket), stat.UserName, stat.GPUHours, stat.JobCount,
+		}})
+	}
+	return rows, nil
+}
+
+func formatTime(t time.Time) string {
+	return t.Format(time.RFC3339)
+}
+
+// formatNullTime 未设置的时间导出为null
+func formatNullTime(t sql.NullTime) interface{} {
+	if !t.Valid {
+		return nil
+	}
+	return formatTime(t.Time)
+}
@@ -0,0 +1,131 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package export
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/config"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage/driver"
+)
+
+type fakeSink struct {
+	rows map[string][][]interface{}
+	err  error
+}
+
+func (f *fakeSink) write(name string, columns []string, rows [][]interface{}) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.rows[name] = append(f.rows[name], rows...)
+	return nil
+}
+
+type fakeS3Client struct {
+	keys   []string
+	bodies []string
+}
+
+func (f *fakeS3Client) PutObject(input *s3.PutObjectInput) (*s3.PutObjectOutput, error) {
+	body, _ := ioutil.ReadAll(input.Body)
+	f.keys = append(f.keys, *input.Key)
+	f.bodies = append(f.bodies, string(body))
+	return &s3.PutObjectOutput{}, nil
+}
+
+func TestExportDataset(t *testing.T) {
+	driver.InitMockDB()
+	now := time.Now()
+	for i := 0; i < 5; i++ {
+		job := model.Job{ID: fmt.Sprintf("job-%d", i), UserName: "user1", Status: schema.StatusJobRunning,
+			Config: &schema.Conf{}, UpdatedAt: now.Add(time.Duration(i-10) * time.Minute)}
+		assert.NoError(t, storage.Job.CreateJob(&job))
+	}
+	// 导出延迟内更新的记录本轮不导出
+	job := model.Job{ID: "job-recent", Status: schema.StatusJobRunning, Config: &schema.Conf{}, UpdatedAt: now}
+	assert.NoError(t, storage.Job.CreateJob(&job))
+
+	s := &fakeSink{rows: make(map[string][][]interface{}), err: fmt.Errorf("sink unavailable")}
+	exportAll(s, 2, now)
+	watermark, err := storage.Export.GetWatermark(DatasetJob)
+	assert.NoError(t, err)
+	assert.True(t, watermark.Time.IsZero())
+
+	s.err = nil
+	exportAll(s, 2, now)
+	assert.Equal(t, 5, len(s.rows[DatasetJob]))
+	assert.Equal(t, "job-0", s.rows[DatasetJob][0][0])
+	assert.Equal(t, "job-4", s.rows[DatasetJob][4][0])
+	watermark, err = storage.Export.GetWatermark(DatasetJob)
+	assert.NoError(t, err)
+	assert.Equal(t, now.Add(-6*time.Minute).Unix(), watermark.Time.Unix())
+
+	// 记录更新后再次导出
+	assert.NoError(t, storage.Job.UpdateJobStatus("job-1", "", schema.StatusJobSucceeded))
+	exportAll(s, 2, now.Add(2*time.Minute))
+	assert.Equal(t, 7, len(s.rows[DatasetJob]))
+	assert.Equal(t, "job-recent", s.rows[DatasetJob][5][0])
+	assert.Equal(t, "job-1", s.rows[DatasetJob][6][0])
+	assert.Equal(t, string(schema.StatusJobSucceeded), s.rows[DatasetJob][6][7])
+}
+
+func TestS3Sink(t *testing.T) {
+	columns := []string{"id", "activated_at", "gpus"}
+	rows := [][]interface{}{{"job-1", nil, 2.0}, {"job,2", "2022-01-01T00:00:00Z", 0.5}}
+
+	client := &fakeS3Client{}
+	s := &s3Sink{client: client, bucket: "bucket", prefix: "paddleflow", format: FormatCSV}
+	assert.NoError(t, s.write(DatasetJob, columns, rows))
+	assert.True(t, strings.HasPrefix(client.keys[0], "paddleflow/job/dt="))
+	assert.True(t, strings.HasSuffix(client.keys[0], ".csv"))
+	assert.Equal(t, "id,activated_at,gpus\njob-1,,2\n\"job,2\",2022-01-01T00:00:00Z,0.5\n", client.bodies[0])
+
+	s.format = FormatJSON
+	assert.NoError(t, s.write(DatasetJob, columns, rows))
+	assert.True(t, strings.HasSuffix(client.keys[1], ".json"))
+	assert.Equal(t, "{\"activated_at\":null,\"gpus\":2,\"id\":\"job-1\"}\n"+
+		"{\"activated_at\":\"2022-01-01T00:00:00Z\",\"gpus\":0.5,\"id\":\"job,2\"}\n", client.bodies[1])
+}
+
+func TestNewSink(t *testing.T) {
+	_, err := newSink(config.ExportConfig{Sink: SinkS3, Format: "parquet", S3: config.ExportS3Config{Bucket: "bucket"}})
+	assert.Error(t, err)
+	_, err = newSink(config.ExportConfig{Sink: SinkS3})
+	assert.Error(t, err)
+	_, err = newSink(config.ExportConfig{Sink: SinkKafka})
+	assert.Error(t, err)
+	_, err = newSink(config.ExportConfig{Sink: "hdfs"})
+	assert.Error(t, err)
+
+	s, err := newSink(config.ExportConfig{Sink: SinkS3, S3: config.ExportS3Config{Bucket: "bucket", Region: "bj"}})
+	assert.NoError(t, err)
+	assert.Equal(t, FormatCSV, s.(*s3Sink).format)
+	s, err = newSink(config.ExportConfig{Sink: SinkKafka, Kafka: config.KafkaRestProxyConfig{RestProxy: "http://proxy/"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "http://proxy", s.(*kafkaSink).restProxy)
+}
@@ -0,0 +1,210 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/config"
+)
+
+const (
+	SinkS3    = "s3"
+	SinkKafka = "kafka"
+
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+
+	kafkaContentType = "application/vnd.kafka.json.v2+json"
+	kafkaTimeout     = 30 * time.Second
+)
+
+// sink 导出目标，写入失败时整批重试
+type sink interface {
+	write(name string, columns []string, rows [][]interface{}) error
+}
+
+func newSink(conf config.ExportConfig) (sink, error) {
+	switch conf.Sink {
+	case SinkS3:
+		return newS3Sink(conf)
+	case SinkKafka:
+		if conf.Kafka.RestProxy == "" {
+			return nil, fmt.Errorf("kafka rest proxy is not configured")
+		}
+		return &kafkaSink{
+			restProxy:   strings.TrimSuffix(conf.Kafka.RestProxy, "/"),
+			topicPrefix: conf.Kafka.TopicPrefix,
+			client:      &http.Client{Timeout: kafkaTimeout},
+		}, nil
+	default:
+		return nil, fmt.Errorf("sink[%s] is not supported, must be %s or %s", conf.Sink, SinkS3, SinkKafka)
+	}
+}
+
+// s3Sink 每批记录写为一个文件，按记录类型及导出日期分区
+type s3Sink struct {
+	client s3Client
+	bucket string
+	prefix string
+	format string
+}
+
+// s3Client 测试中替换为fake
+type s3Client interface {
+	PutObject(input *s3.PutObjectInput) (*s3.PutObjectOutput, error)
+}
+
+func newS3Sink(conf config.ExportConfig) (*s3Sink, error) {
+	format := conf.Format
+	switch format {
+	case "":
+		format = FormatCSV
+	case FormatCSV, FormatJSON:
+	default:
+		// 需要引入parquet编码库，暂不支持
+		return nil, fmt.Errorf("format[%s] is not supported, must be %s or %s", format, FormatCSV, FormatJSON)
+	}
+	if conf.S3.Bucket == "" {
+		return nil, fmt.Errorf("s3 bucket is not configured")
+	}
+	awsConfig := &aws.Config{
+		Region:           aws.String(conf.S3.Region),
+		S3ForcePathStyle: aws.Bool(conf.S3.ForcePathStyle),
+	}
+	if conf.S3.Endpoint != "" {
+		awsConfig.Endpoint = aws.String(conf.S3.Endpoint)
+	}
+	if conf.S3.AccessKey != "" {
+		awsConfig.Credentials = credentials.NewStaticCredentials(conf.S3.AccessKey, conf.S3.SecretKey, "")
+	}
+	sess, err := session.NewSession(awsConfig)
+	if err != nil {
+		return nil, fmt.Errorf("create s3 session failed: %v", err)
+	}
+	return &s3Sink{
+		client: s3.New(sess),
+		bucket: conf.S3.Bucket,
+		prefix: strings.Trim(conf.S3.Prefix, "/"),
+		format: format,
+	}, nil
+}
+
+func (s *s3Sink) write(name string, columns []string, rows [][]interface{}) error {
+	var body []byte
+	var err error
+	if s.format == FormatJSON {
+		body, err = encodeJSONLines(columns, rows)
+	} else {
+		body, err = encodeCSV(columns, rows)
+	}
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	key := path.Join(s.prefix, name, "dt="+now.Format("2006-01-02"),
+		fmt.Sprintf("%s-%d.%s", name, now.UnixNano(), s.format))
+	_, err = s.client.PutObject(&s3.PutObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+		Body:   bytes.NewReader(body),
+	})
+	return err
+}
+
+// kafkaSink 通过Kafka REST Proxy的v2接口发送记录，每条记录为一个json对象
+type kafkaSink struct {
+	restProxy   string
+	topicPrefix string
+	client      *http.Client
+}
+
+func (k *kafkaSink) write(name string, columns []string, rows [][]interface{}) error {
+	records := make([]map[string]interface{}, 0, len(rows))
+	for _, r := range rows {
+		records = append(records, map[string]interface{}{"value": toObject(columns, r)})
+	}
+	body, err := json.Marshal(map[string]interface{}{"records": records})
+	if err != nil {
+		return err
+	}
+	url := fmt.Sprintf("%s/topics/%s%s", k.restProxy, k.topicPrefix, name)
+	resp, err := k.client.Post(url, kafkaContentType, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("send records to topic[%s%s] failed, response status code %d", k.topicPrefix, name, resp.StatusCode)
+	}
+	return nil
+}
+
+// encodeCSV 第一行为列名，null导出为空字符串
+func encodeCSV(columns []string, rows [][]interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write(columns); err != nil {
+		return nil, err
+	}
+	record := make([]string, len(columns))
+	for _, r := range rows {
+		for i, value := range r {
+			if value == nil {
+				record[i] = ""
+			} else {
+				record[i] = fmt.Sprint(value)
+			}
+		}
+		if err := w.Write(record); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+func encodeJSONLines(columns []string, rows [][]interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, r := range rows {
+		if err := encoder.Encode(toObject(columns, r)); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+func toObject(columns []string, values []interface{}) map[string]interface{} {
+	object := make(map[string]interface{}, len(columns))
+	for i, column := range columns {
+		object[column] = values[i]
+	}
+	return object
+}
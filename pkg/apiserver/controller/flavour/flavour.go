@@ -227,7 +227,7 @@ func GetFlavourWithCheck(reqFlavour schema.Flavour) (schema.Flavour, error) {
 		}
 		return reqFlavour, nil
 	}
-	flavour, err := storage.Flavour.GetFlavour(reqFlavour.Name)
+	flavour, err := storage.Flavour.GetCachedFlavour(reqFlavour.Name)
 	if err != nil {
 		log.Errorf("Get flavour by name %s failed when creating job, err:%v", reqFlavour.Name, err)
 		return schema.Flavour{}, fmt.Errorf("get flavour[%s] failed, err:%v", reqFlavour.Name, err)
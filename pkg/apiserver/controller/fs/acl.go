@@ -0,0 +1,140 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fs
+
+import (
+	"errors"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+)
+
+type FsAclRequest struct {
+	GranteeType string `json:"granteeType"`
+	GranteeName string `json:"granteeName"`
+	Permission  string `json:"permission"`
+}
+
+type ListFsAclResponse struct {
+	FsName   string        `json:"fsName"`
+	Username string        `json:"username"`
+	AclList  []model.FsAcl `json:"aclList"`
+}
+
+func checkGrantee(ctx *logger.RequestContext, fs model.FileSystem, granteeType, granteeName string) error {
+	if granteeName == "" {
+		ctx.ErrorCode = common.InvalidArguments
+		return fmt.Errorf("granteeName is empty")
+	}
+	switch granteeType {
+	case model.FsGranteeUser:
+		if granteeName == fs.UserName || common.IsRootUser(granteeName) {
+			ctx.ErrorCode = common.InvalidArguments
+			return fmt.Errorf("user[%s] already has full access to fs[%s]", granteeName, fs.Name)
+		}
+		if _, err := storage.Auth.GetUserByName(ctx, granteeName); err != nil {
+			ctx.ErrorCode = common.UserNotExist
+			return fmt.Errorf("user[%s] not found", granteeName)
+		}
+	case model.FsGranteeQueue:
+		if _, err := storage.Queue.GetQueueByName(granteeName); err != nil {
+			ctx.ErrorCode = common.QueueNameNotFound
+			return fmt.Errorf("queue[%s] not found", granteeName)
+		}
+	default:
+		ctx.ErrorCode = common.InvalidArguments
+		return fmt.Errorf("granteeType[%s] should be %s or %s", granteeType, model.FsGranteeUser, model.FsGranteeQueue)
+	}
+	return nil
+}
+
+// GrantFileSystemAccess 所有者将fs的只读或读写权限授予其他用户或队列，重复授权时覆盖原权限
+func GrantFileSystemAccess(ctx *logger.RequestContext, fs model.FileSystem, req FsAclRequest) error {
+	if req.Permission != model.FsPermissionRead && req.Permission != model.FsPermissionReadWrite {
+		ctx.ErrorCode = common.InvalidArguments
+		return fmt.Errorf("permission[%s] should be %s or %s", req.Permission, model.FsPermissionRead, model.FsPermissionReadWrite)
+	}
+	if err := checkGrantee(ctx, fs, req.GranteeType, req.GranteeName); err != nil {
+		ctx.Logging().Errorf("grant fs[%s] access failed: %v", fs.ID, err)
+		return err
+	}
+	acl := &model.FsAcl{
+		FsID:        fs.ID,
+		GranteeType: req.GranteeType,
+		GranteeName: req.GranteeName,
+		Permission:  req.Permission,
+	}
+	if err := storage.FsAcl.SaveAcl(ctx.Logging(), acl); err != nil {
+		ctx.ErrorCode = common.FileSystemDataBaseError
+		return err
+	}
+	return nil
+}
+
+func RevokeFileSystemAccess(ctx *logger.RequestContext, fs model.FileSystem, granteeType, granteeName string) error {
+	if err := storage.FsAcl.DeleteAcl(ctx.Logging(), fs.ID, granteeType, granteeName); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.ErrorCode = common.RecordNotFound
+			return fmt.Errorf("%s[%s] has no access to fs[%s]", granteeType, granteeName, fs.Name)
+		}
+		ctx.ErrorCode = common.FileSystemDataBaseError
+		return err
+	}
+	return nil
+}
+
+func ListFileSystemAcl(ctx *logger.RequestContext, fs model.FileSystem) (*ListFsAclResponse, error) {
+	acls, err := storage.FsAcl.ListAcl(ctx.Logging(), fs.ID)
+	if err != nil {
+		ctx.ErrorCode = common.FileSystemDataBaseError
+		return nil, err
+	}
+	return &ListFsAclResponse{
+		FsName:   fs.Name,
+		Username: fs.UserName,
+		AclList:  acls,
+	}, nil
+}
+
+// CheckFileSystemAccess 返回用户以queueName提交作业时对fs的权限，所有者和root拥有读写权限，
+// 其余用户取本人和队列授权中较大者，无授权时返回错误
+func CheckFileSystemAccess(logEntry *log.Entry, fs model.FileSystem, userName, queueName string) (string, error) {
+	if fs.UserName == userName || common.IsRootUser(userName) {
+		return model.FsPermissionReadWrite, nil
+	}
+	acls, err := storage.FsAcl.ListGranteeAcl(logEntry, fs.ID, userName, queueName)
+	if err != nil {
+		return "", err
+	}
+	permission := ""
+	for _, acl := range acls {
+		if acl.Permission == model.FsPermissionReadWrite {
+			return model.FsPermissionReadWrite, nil
+		}
+		permission = acl.Permission
+	}
+	if permission == "" {
+		return "", fmt.Errorf("user[%s] has no access to fs[%s] of user[%s]", userName, fs.Name, fs.UserName)
+	}
+	return permission, nil
+}
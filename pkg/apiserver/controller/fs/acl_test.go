@@ -0,0 +1,107 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fs
+
+import (
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage/driver"
+)
+
+func TestFileSystemAcl(t *testing.T) {
+	driver.InitMockDB()
+	ctx := &logger.RequestContext{UserName: "owner"}
+	logEntry := log.NewEntry(log.StandardLogger())
+	for _, name := range []string{"owner", "alice", "bob"} {
+		assert.NoError(t, storage.Auth.CreateUser(ctx, &model.User{UserInfo: model.UserInfo{Name: name, Password: "123456"}}))
+	}
+	clusterInfo := &model.ClusterInfo{Name: "cluster-acl", ClusterType: schema.KubernetesType}
+	assert.NoError(t, storage.Cluster.CreateCluster(clusterInfo))
+	assert.NoError(t, storage.Queue.CreateQueue(&model.Queue{Name: "train", Namespace: "default", ClusterId: clusterInfo.ID}))
+	fs := model.FileSystem{Model: model.Model{ID: "fs-owner-data"}, Name: "data", UserName: "owner"}
+	assert.NoError(t, storage.Filesystem.CreatFileSystem(&fs))
+
+	// invalid requests
+	err := GrantFileSystemAccess(ctx, fs, FsAclRequest{GranteeType: model.FsGranteeUser, GranteeName: "alice", Permission: "x"})
+	assert.Error(t, err)
+	err = GrantFileSystemAccess(ctx, fs, FsAclRequest{GranteeType: "group", GranteeName: "alice", Permission: model.FsPermissionRead})
+	assert.Error(t, err)
+	err = GrantFileSystemAccess(ctx, fs, FsAclRequest{GranteeType: model.FsGranteeUser, GranteeName: "owner", Permission: model.FsPermissionRead})
+	assert.Error(t, err)
+	ctx.ErrorCode = ""
+	err = GrantFileSystemAccess(ctx, fs, FsAclRequest{GranteeType: model.FsGranteeUser, GranteeName: "nobody", Permission: model.FsPermissionRead})
+	assert.Error(t, err)
+	assert.Equal(t, common.UserNotExist, ctx.ErrorCode)
+	err = GrantFileSystemAccess(ctx, fs, FsAclRequest{GranteeType: model.FsGranteeQueue, GranteeName: "nothing", Permission: model.FsPermissionRead})
+	assert.Error(t, err)
+	assert.Equal(t, common.QueueNameNotFound, ctx.ErrorCode)
+
+	// owner and root always have full access, others need grants
+	permission, err := CheckFileSystemAccess(logEntry, fs, "owner", "")
+	assert.NoError(t, err)
+	assert.Equal(t, model.FsPermissionReadWrite, permission)
+	permission, err = CheckFileSystemAccess(logEntry, fs, "root", "")
+	assert.NoError(t, err)
+	assert.Equal(t, model.FsPermissionReadWrite, permission)
+	_, err = CheckFileSystemAccess(logEntry, fs, "alice", "train")
+	assert.Error(t, err)
+
+	assert.NoError(t, GrantFileSystemAccess(ctx, fs, FsAclRequest{GranteeType: model.FsGranteeUser, GranteeName: "alice", Permission: model.FsPermissionRead}))
+	assert.NoError(t, GrantFileSystemAccess(ctx, fs, FsAclRequest{GranteeType: model.FsGranteeQueue, GranteeName: "train", Permission: model.FsPermissionReadWrite}))
+	permission, err = CheckFileSystemAccess(logEntry, fs, "alice", "default")
+	assert.NoError(t, err)
+	assert.Equal(t, model.FsPermissionRead, permission)
+	// queue grant applies to any user submitting to the queue
+	permission, err = CheckFileSystemAccess(logEntry, fs, "alice", "train")
+	assert.NoError(t, err)
+	assert.Equal(t, model.FsPermissionReadWrite, permission)
+	permission, err = CheckFileSystemAccess(logEntry, fs, "bob", "train")
+	assert.NoError(t, err)
+	assert.Equal(t, model.FsPermissionReadWrite, permission)
+	_, err = CheckFileSystemAccess(logEntry, fs, "bob", "default")
+	assert.Error(t, err)
+
+	// grant again overwrites the permission
+	assert.NoError(t, GrantFileSystemAccess(ctx, fs, FsAclRequest{GranteeType: model.FsGranteeUser, GranteeName: "alice", Permission: model.FsPermissionReadWrite}))
+	resp, err := ListFileSystemAcl(ctx, fs)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(resp.AclList))
+	assert.Equal(t, model.FsGranteeQueue, resp.AclList[0].GranteeType)
+	assert.Equal(t, "alice", resp.AclList[1].GranteeName)
+	assert.Equal(t, model.FsPermissionReadWrite, resp.AclList[1].Permission)
+
+	assert.NoError(t, RevokeFileSystemAccess(ctx, fs, model.FsGranteeQueue, "train"))
+	err = RevokeFileSystemAccess(ctx, fs, model.FsGranteeQueue, "train")
+	assert.Error(t, err)
+	assert.Equal(t, common.RecordNotFound, ctx.ErrorCode)
+	_, err = CheckFileSystemAccess(logEntry, fs, "bob", "train")
+	assert.Error(t, err)
+
+	// acl is removed with the file system
+	assert.NoError(t, storage.FsAcl.DeleteFsAcl(nil, fs.ID))
+	acls, err := storage.FsAcl.ListAcl(logEntry, fs.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(acls))
+}
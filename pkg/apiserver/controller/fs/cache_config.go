@@ -32,6 +32,8 @@ import (
 const (
 	MaxMountPodCpuLimit = "2"
 	MaxMountPodMemLimit = "8Gi"
+	MaxPrefetchBlocks   = 256
+	MaxPrefetchWorkers  = 64
 )
 
 func (req *CreateFileSystemCacheRequest) toModel() model.FSCacheConfig {
@@ -40,7 +42,22 @@ func (req *CreateFileSystemCacheRequest) toModel() model.FSCacheConfig {
 		CacheDir:               req.CacheDir,
 		Quota:                  req.Quota,
 		MetaDriver:             req.MetaDriver,
+		MetaAddress:            req.MetaAddress,
 		BlockSize:              req.BlockSize,
+		MaxCacheSize:           req.MaxCacheSize,
+		EvictionPolicy:         req.EvictionPolicy,
+		CacheTTL:               req.CacheTTL,
+		MemCacheSize:           req.MemCacheSize,
+		WriteBack:              req.WriteBack,
+		WriteBackDirtyLimit:    req.WriteBackDirtyLimit,
+		MountPodPolicy:         req.MountPodPolicy,
+		CacheCompression:       req.CacheCompression,
+		CacheEncryption:        req.CacheEncryption,
+		PrefetchBlocks:         req.PrefetchBlocks,
+		PrefetchWorkers:        req.PrefetchWorkers,
+		AttrCacheTTL:           req.AttrCacheTTL,
+		EntryCacheTTL:          req.EntryCacheTTL,
+		NegativeCacheTTL:       req.NegativeCacheTTL,
 		Debug:                  req.Debug,
 		CleanCache:             req.CleanCache,
 		Resource:               req.Resource,
@@ -56,7 +73,22 @@ type CreateFileSystemCacheRequest struct {
 	CacheDir            string                 `json:"cacheDir"`
 	Quota               int                    `json:"quota"`
 	MetaDriver          string                 `json:"metaDriver"`
+	MetaAddress         string                 `json:"metaAddress"`
 	BlockSize           int                    `json:"blockSize"`
+	MaxCacheSize        string                 `json:"maxCacheSize"`
+	EvictionPolicy      string                 `json:"evictionPolicy"`
+	CacheTTL            string                 `json:"cacheTTL"`
+	MemCacheSize        string                 `json:"memCacheSize"`
+	WriteBack           bool                   `json:"writeBack"`
+	WriteBackDirtyLimit string                 `json:"writeBackDirtyLimit"`
+	MountPodPolicy      string                 `json:"mountPodPolicy"`
+	CacheCompression    string                 `json:"cacheCompression"`
+	CacheEncryption     bool                   `json:"cacheEncryption"`
+	PrefetchBlocks      int                    `json:"prefetchBlocks"`
+	PrefetchWorkers     int                    `json:"prefetchWorkers"`
+	AttrCacheTTL        string                 `json:"attrCacheTTL"`
+	EntryCacheTTL       string                 `json:"entryCacheTTL"`
+	NegativeCacheTTL    string                 `json:"negativeCacheTTL"`
 	Debug               bool                   `json:"debug"`
 	CleanCache          bool                   `json:"cleanCache"`
 	Resource            model.ResourceLimit    `json:"resource"`
@@ -68,7 +100,22 @@ type FileSystemCacheResponse struct {
 	CacheDir            string                 `json:"cacheDir"`
 	Quota               int                    `json:"quota"`
 	MetaDriver          string                 `json:"metaDriver"`
+	MetaAddress         string                 `json:"metaAddress"`
 	BlockSize           int                    `json:"blockSize"`
+	MaxCacheSize        string                 `json:"maxCacheSize"`
+	EvictionPolicy      string                 `json:"evictionPolicy"`
+	CacheTTL            string                 `json:"cacheTTL"`
+	MemCacheSize        string                 `json:"memCacheSize"`
+	WriteBack           bool                   `json:"writeBack"`
+	WriteBackDirtyLimit string                 `json:"writeBackDirtyLimit"`
+	MountPodPolicy      string                 `json:"mountPodPolicy"`
+	CacheCompression    string                 `json:"cacheCompression"`
+	CacheEncryption     bool                   `json:"cacheEncryption"`
+	PrefetchBlocks      int                    `json:"prefetchBlocks"`
+	PrefetchWorkers     int                    `json:"prefetchWorkers"`
+	AttrCacheTTL        string                 `json:"attrCacheTTL"`
+	EntryCacheTTL       string                 `json:"entryCacheTTL"`
+	NegativeCacheTTL    string                 `json:"negativeCacheTTL"`
 	CleanCache          bool                   `json:"cleanCache"`
 	Resource            model.ResourceLimit    `json:"resource"`
 	NodeTaintToleration map[string]interface{} `json:"nodeTaintToleration"`
@@ -83,7 +130,22 @@ func (resp *FileSystemCacheResponse) fromModel(config model.FSCacheConfig) {
 	resp.CacheDir = config.CacheDir
 	resp.Quota = config.Quota
 	resp.MetaDriver = config.MetaDriver
+	resp.MetaAddress = config.MetaAddress
 	resp.BlockSize = config.BlockSize
+	resp.MaxCacheSize = config.MaxCacheSize
+	resp.EvictionPolicy = config.EvictionPolicy
+	resp.CacheTTL = config.CacheTTL
+	resp.MemCacheSize = config.MemCacheSize
+	resp.WriteBack = config.WriteBack
+	resp.WriteBackDirtyLimit = config.WriteBackDirtyLimit
+	resp.MountPodPolicy = config.MountPodPolicy
+	resp.CacheCompression = config.CacheCompression
+	resp.CacheEncryption = config.CacheEncryption
+	resp.PrefetchBlocks = config.PrefetchBlocks
+	resp.PrefetchWorkers = config.PrefetchWorkers
+	resp.AttrCacheTTL = config.AttrCacheTTL
+	resp.EntryCacheTTL = config.EntryCacheTTL
+	resp.NegativeCacheTTL = config.NegativeCacheTTL
 	resp.CleanCache = config.CleanCache
 	resp.Resource = config.Resource
 	resp.NodeTaintToleration = config.NodeTaintTolerationMap
@@ -17,15 +17,20 @@ limitations under the License.
 package fs
 
 import (
+	"encoding/json"
 	"fmt"
 	"strconv"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 	k8sCore "k8s.io/api/core/v1"
 	k8sMeta "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/csiplugin/csiconfig"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/utils"
 	runtime "github.com/PaddlePaddle/PaddleFlow/pkg/job/runtime_v2"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
@@ -84,6 +89,9 @@ func updateMountPodCacheStats(clusterID string, k8sRuntime *runtime.KubeRuntime)
 	}
 
 	for _, pod := range pods.Items {
+		if !isServerMountPod(pod) {
+			continue
+		}
 		if err = syncCacheFromMountPod(&pod, clusterID); err != nil {
 			log.Errorf("syncCacheFromMountPod[%s] in cluster[%s] failed: %v", pod.Name, clusterID, err)
 		}
@@ -132,6 +140,15 @@ func syncCacheFromMountPod(pod *k8sCore.Pod, clusterID string) error {
 		log.Errorf(errRet.Error())
 		return errRet
 	}
+	// 旧版本的cache-worker不上报命中统计
+	if statsStr, ok := pod.Annotations[schema.AnnotationKeyCacheStats]; ok {
+		stats := schema.CacheStats{}
+		if err := json.Unmarshal([]byte(statsStr), &stats); err != nil {
+			log.Errorf("mount pod[%s] cache stats[%s] unmarshal failed: %v", pod.Name, statsStr, err)
+		} else {
+			setCacheStats(fsCache, stats)
+		}
+	}
 
 	if err := addOrUpdateFSCache(fsCache); err != nil {
 		errRet := fmt.Errorf("addOrUpdateFSCache[%+v] for pod[%s] failed: %v", *fsCache, pod.Name, err)
@@ -141,6 +158,25 @@ func syncCacheFromMountPod(pod *k8sCore.Pod, clusterID string) error {
 	return nil
 }
 
+// setCacheStats 根据上一次同步的记录计算读取速率，挂载进程重启后计数归零时速率按0计
+func setCacheStats(fsCache *model.FSCache, stats schema.CacheStats) {
+	fsCache.CacheHits = stats.Hits
+	fsCache.CacheMisses = stats.Misses
+	fsCache.HitBytes = stats.HitBytes
+	fsCache.MissBytes = stats.MissBytes
+	fsCache.Evictions = stats.Evictions
+
+	last, err := storage.FsCache.Get(fsCache.FsID, fsCache.CacheID)
+	if err != nil || last == nil {
+		return
+	}
+	readBytes := stats.HitBytes + stats.MissBytes - last.HitBytes - last.MissBytes
+	elapsed := time.Since(last.UpdatedAt).Seconds()
+	if readBytes > 0 && elapsed > 0 {
+		fsCache.ReadThroughput = int64(float64(readBytes) / elapsed)
+	}
+}
+
 func addOrUpdateFSCache(fsCache *model.FSCache) error {
 	n, err := storage.FsCache.Update(fsCache)
 	if err != nil {
@@ -156,3 +192,56 @@ func addOrUpdateFSCache(fsCache *model.FSCache) error {
 	}
 	return nil
 }
+
+type FileSystemCacheStatsResponse struct {
+	FsName   string `json:"fsName"`
+	Username string `json:"username"`
+	// UsedSize 各节点缓存目录已用容量之和，单位KB
+	UsedSize       int64                      `json:"usedSize"`
+	CacheHits      int64                      `json:"cacheHits"`
+	CacheMisses    int64                      `json:"cacheMisses"`
+	HitBytes       int64                      `json:"hitBytes"`
+	MissBytes      int64                      `json:"missBytes"`
+	HitRate        float64                    `json:"hitRate"`
+	Evictions      int64                      `json:"evictions"`
+	ReadThroughput int64                      `json:"readThroughput"`
+	Nodes          []FileSystemCacheNodeStats `json:"nodes"`
+}
+
+type FileSystemCacheNodeStats struct {
+	model.FSCache
+	HitRate float64 `json:"hitRate"`
+}
+
+// GetFileSystemCacheStats 汇总存储在各节点上的缓存用量与命中统计
+func GetFileSystemCacheStats(ctx *logger.RequestContext, fsID string) (*FileSystemCacheStatsResponse, error) {
+	if _, err := storage.Filesystem.GetFileSystemWithFsID(fsID); err != nil {
+		ctx.ErrorCode = common.RecordNotFound
+		ctx.Logging().Errorf("GetFileSystemCacheStats fs[%s] not found: %v", fsID, err)
+		return nil, fmt.Errorf("fs[%s] not exist", fsID)
+	}
+	caches, err := storage.FsCache.List(fsID, "")
+	if err != nil {
+		ctx.ErrorCode = common.FileSystemDataBaseError
+		ctx.Logging().Errorf("GetFileSystemCacheStats list fs[%s] cache err: %v", fsID, err)
+		return nil, err
+	}
+	resp := &FileSystemCacheStatsResponse{Nodes: make([]FileSystemCacheNodeStats, 0, len(caches))}
+	resp.FsName, resp.Username, _ = utils.GetFsNameAndUserNameByFsID(fsID)
+	total := model.FSCache{}
+	for _, cache := range caches {
+		resp.UsedSize += int64(cache.UsedSize)
+		total.CacheHits += cache.CacheHits
+		total.CacheMisses += cache.CacheMisses
+		total.HitBytes += cache.HitBytes
+		total.MissBytes += cache.MissBytes
+		total.Evictions += cache.Evictions
+		total.ReadThroughput += cache.ReadThroughput
+		resp.Nodes = append(resp.Nodes, FileSystemCacheNodeStats{FSCache: cache, HitRate: cache.HitRate()})
+	}
+	resp.CacheHits, resp.CacheMisses = total.CacheHits, total.CacheMisses
+	resp.HitBytes, resp.MissBytes = total.HitBytes, total.MissBytes
+	resp.Evictions, resp.ReadThroughput = total.Evictions, total.ReadThroughput
+	resp.HitRate = total.HitRate()
+	return resp, nil
+}
@@ -24,6 +24,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	k8sCore "k8s.io/api/core/v1"
 
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
 	runtime "github.com/PaddlePaddle/PaddleFlow/pkg/job/runtime_v2"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
@@ -171,3 +172,62 @@ func Test_syncCacheFromMountPod(t *testing.T) {
 		})
 	}
 }
+
+func Test_syncCacheStatsFromMountPod(t *testing.T) {
+	driver.InitMockDB()
+	pod := mountPodWithCacheStats()
+	pod.Annotations[schema.AnnotationKeyCacheStats] = `{"hits":30,"misses":10,"hitBytes":3000,"missBytes":1000,"evictions":2}`
+	assert.Nil(t, syncCacheFromMountPod(&pod, mockClusterID))
+	listCache, err := storage.FsCache.List(mockFSID, "")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(listCache))
+	assert.Equal(t, int64(30), listCache[0].CacheHits)
+	assert.Equal(t, int64(2), listCache[0].Evictions)
+	assert.Equal(t, 0.75, listCache[0].HitRate())
+	// 首次同步无法计算速率
+	assert.Equal(t, int64(0), listCache[0].ReadThroughput)
+
+	pod.Annotations[schema.AnnotationKeyCacheStats] = `{"hits":40,"misses":10,"hitBytes":13000,"missBytes":1000,"evictions":2}`
+	assert.Nil(t, syncCacheFromMountPod(&pod, mockClusterID))
+	listCache, err = storage.FsCache.List(mockFSID, "")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(40), listCache[0].CacheHits)
+	assert.True(t, listCache[0].ReadThroughput > 0)
+
+	// 挂载进程重启后计数归零
+	pod.Annotations[schema.AnnotationKeyCacheStats] = `{"hits":0,"misses":0,"hitBytes":0,"missBytes":0,"evictions":0}`
+	assert.Nil(t, syncCacheFromMountPod(&pod, mockClusterID))
+	listCache, err = storage.FsCache.List(mockFSID, "")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), listCache[0].CacheHits)
+	assert.Equal(t, int64(0), listCache[0].ReadThroughput)
+}
+
+func TestGetFileSystemCacheStats(t *testing.T) {
+	driver.InitMockDB()
+	ctx := &logger.RequestContext{UserName: "root"}
+	_, err := GetFileSystemCacheStats(ctx, mockFSID)
+	assert.NotNil(t, err)
+
+	assert.Nil(t, storage.Filesystem.CreatFileSystem(&model.FileSystem{
+		Model:    model.Model{ID: mockFSID},
+		Name:     mockFSName,
+		UserName: "root",
+	}))
+	cache1 := buildFSCache()
+	cache1.CacheHits, cache1.CacheMisses, cache1.ReadThroughput = 30, 10, 100
+	cache2 := buildFSCache()
+	cache2.NodeName = "node2"
+	cache2.CacheHits, cache2.CacheMisses, cache2.ReadThroughput = 10, 30, 200
+	assert.Nil(t, storage.FsCache.Add(&cache1))
+	assert.Nil(t, storage.FsCache.Add(&cache2))
+
+	stats, err := GetFileSystemCacheStats(ctx, mockFSID)
+	assert.Nil(t, err)
+	assert.Equal(t, mockFSName, stats.FsName)
+	assert.Equal(t, 2, len(stats.Nodes))
+	assert.Equal(t, int64(200), stats.UsedSize)
+	assert.Equal(t, int64(40), stats.CacheHits)
+	assert.Equal(t, 0.5, stats.HitRate)
+	assert.Equal(t, int64(300), stats.ReadThroughput)
+}
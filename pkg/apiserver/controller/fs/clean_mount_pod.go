@@ -24,6 +24,7 @@ import (
 	k8sCore "k8s.io/api/core/v1"
 	k8sMeta "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/config"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/csiplugin/csiconfig"
 	runtime "github.com/PaddlePaddle/PaddleFlow/pkg/job/runtime_v2"
@@ -92,7 +93,7 @@ func expiredMountedPodsSingleCluster(cluster model.ClusterInfo, expireDuration t
 	}
 	podsToClean := make([]k8sCore.Pod, 0)
 	for _, po := range pods.Items {
-		if checkMountPodMounted(po) {
+		if !isServerMountPod(po) || checkMountPodMounted(po) {
 			continue
 		}
 		expired, err := checkMountPodExpired(po, expireDuration)
@@ -114,6 +115,15 @@ func expiredMountedPodsSingleCluster(cluster model.ClusterInfo, expireDuration t
 
 }
 
+// isServerMountPod 多个apiserver共用集群时只处理本apiserver的挂载pod，未标记apiserver的挂载pod仍按原方式处理
+func isServerMountPod(po k8sCore.Pod) bool {
+	server, ok := po.Labels[schema.LabelKeyServer]
+	if !ok || config.GlobalServerConfig == nil {
+		return true
+	}
+	return server == schema.ServerID(config.GetServiceAddress())
+}
+
 func checkMountPodExpired(po k8sCore.Pod, expireDuration time.Duration) (bool, error) {
 	modifiedTimeStr := po.Annotations[schema.AnnotationKeyMTime]
 	modifyTime, errParseTime := time.Parse(model.TimeFormat, modifiedTimeStr)
@@ -27,6 +27,7 @@ import (
 	k8sCore "k8s.io/api/core/v1"
 	k8sMeta "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/config"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
 	runtime "github.com/PaddlePaddle/PaddleFlow/pkg/job/runtime_v2"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
@@ -207,3 +208,18 @@ func TestExpireTime(t *testing.T) {
 
 	assert.True(t, expireTime.Before(time.Now()))
 }
+
+func TestIsServerMountPod(t *testing.T) {
+	old := config.GlobalServerConfig
+	defer func() { config.GlobalServerConfig = old }()
+	config.GlobalServerConfig = &config.ServerConfig{}
+	config.GlobalServerConfig.ApiServer.Host = "10.0.0.1"
+	config.GlobalServerConfig.Fs.ServicePort = 8999
+
+	pod := k8sCore.Pod{}
+	assert.True(t, isServerMountPod(pod))
+	pod.Labels = map[string]string{schema.LabelKeyServer: schema.ServerID("10.0.0.1:8999")}
+	assert.True(t, isServerMountPod(pod))
+	pod.Labels[schema.LabelKeyServer] = schema.ServerID("10.0.0.2:8999")
+	assert.False(t, isServerMountPod(pod))
+}
@@ -0,0 +1,527 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fs
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	k8sMeta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/handler"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/config"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/uuid"
+	runtime "github.com/PaddlePaddle/PaddleFlow/pkg/job/runtime_v2"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+)
+
+const (
+	dataLoadMountPath     = "/home/paddleflow/storage/mnt"
+	defaultDataLoadImage  = "busybox:1.35"
+	dataLoadSyncInterval  = 10 * time.Second
+	dataLoadLogTailLines  = 1
+	dataLoadReportPerFile = 100
+
+	labelDataLoadID = "paddleflow-data-load-id"
+)
+
+// 路径会原样拼接到预热脚本中由shell展开通配符，只允许不会被shell解释的字符
+var dataLoadPathRegexp = regexp.MustCompile(`^[A-Za-z0-9_.\-/*?\[\]]+$`)
+
+type CreateDataLoadRequest struct {
+	FsName   string `json:"fsName"`
+	Username string `json:"username"`
+	// Paths 需要预热的路径，相对于存储根目录，支持*?[]通配符，匹配到目录时预热目录下的所有文件
+	Paths []string `json:"paths"`
+	// ClusterName Nodes 在指定集群的节点上预热，为空时使用该存储已有缓存的集群和节点
+	ClusterName string   `json:"clusterName"`
+	Nodes       []string `json:"nodes"`
+	Namespace   string   `json:"namespace"`
+}
+
+type CreateDataLoadResponse struct {
+	ID string `json:"id"`
+}
+
+type DataLoadResponse struct {
+	model.FSDataLoad
+	Progress float64 `json:"progress"`
+}
+
+type ListDataLoadResponse struct {
+	common.MarkerInfo
+	DataLoadList []DataLoadResponse `json:"dataLoadList"`
+}
+
+// dataLoadRuntime 预热任务所需的集群操作
+type dataLoadRuntime interface {
+	CreatePV(namespace, fsID string) (string, error)
+	CreatePVC(namespace, fsId, pv string) error
+	CreatePod(pod *corev1.Pod) error
+	DeletePod(namespace, name string) error
+	ListPods(namespace string, listOptions k8sMeta.ListOptions) (*corev1.PodList, error)
+	GetPodLogTail(namespace, name string, tailLines int64) (string, error)
+}
+
+var getDataLoadRuntime = func(cluster model.ClusterInfo) (dataLoadRuntime, error) {
+	if cluster.ClusterType != schema.KubernetesType {
+		return nil, fmt.Errorf("data load is not supported on cluster[%s] with type[%s]", cluster.Name, cluster.ClusterType)
+	}
+	runtimeSvc, err := runtime.GetOrCreateRuntime(cluster)
+	if err != nil {
+		return nil, err
+	}
+	kubeRuntime, ok := runtimeSvc.(*runtime.KubeRuntime)
+	if !ok {
+		return nil, fmt.Errorf("runtime of cluster[%s] is not kubernetes runtime", cluster.Name)
+	}
+	return kubeRuntime, nil
+}
+
+// dataLoadUsage 统计需要预热的文件数与数据量，测试时替换
+var dataLoadUsage = func(fsID string, paths []string, logEntry *log.Entry) (int64, int64, error) {
+	fsHandler, err := handler.NewFsHandlerWithServer(fsID, logEntry)
+	if err != nil {
+		return 0, 0, err
+	}
+	return fsHandler.GlobUsage(paths)
+}
+
+// CreateDataLoad 在各节点上启动预热pod读取指定路径下的数据，使其进入节点缓存。
+// 文件总量在后台统计，各节点的进度由DataLoadController根据pod日志更新
+func (s *FileSystemService) CreateDataLoad(ctx *logger.RequestContext, req *CreateDataLoadRequest) (*CreateDataLoadResponse, error) {
+	dataLoad, cluster, err := buildDataLoad(ctx, req)
+	if err != nil {
+		ctx.Logging().Errorf("create data load failed. error: %v", err)
+		return nil, err
+	}
+	rt, err := getDataLoadRuntime(cluster)
+	if err != nil {
+		ctx.ErrorCode = common.InternalError
+		ctx.Logging().Errorf("get runtime of cluster[%s] failed. error: %v", cluster.Name, err)
+		return nil, err
+	}
+	pvName, err := rt.CreatePV(dataLoad.Namespace, dataLoad.FsID)
+	if err == nil {
+		err = rt.CreatePVC(dataLoad.Namespace, dataLoad.FsID, pvName)
+	}
+	if err != nil {
+		ctx.ErrorCode = common.K8sOperatorError
+		ctx.Logging().Errorf("prepare storage of fs[%s] for data load failed. error: %v", dataLoad.FsID, err)
+		return nil, err
+	}
+
+	if err := storage.FsDataLoad.CreateDataLoad(ctx.Logging(), dataLoad); err != nil {
+		ctx.ErrorCode = common.FileSystemDataBaseError
+		return nil, err
+	}
+	for i := range dataLoad.Nodes {
+		if err := rt.CreatePod(buildDataLoadPod(dataLoad, dataLoad.Nodes[i])); err != nil {
+			ctx.ErrorCode = common.K8sOperatorError
+			ctx.Logging().Errorf("create data load pod on node[%s] failed. error: %v", dataLoad.Nodes[i].NodeName, err)
+			deleteDataLoadPods(rt, dataLoad)
+			failDataLoad(dataLoad.ID, fmt.Sprintf("create pod on node[%s] failed: %v", dataLoad.Nodes[i].NodeName, err))
+			return nil, err
+		}
+	}
+	go countDataLoadTotal(*dataLoad)
+	ctx.Logging().Infof("data load[%s] of fs[%s] created on %d nodes", dataLoad.ID, dataLoad.FsID, len(dataLoad.Nodes))
+	return &CreateDataLoadResponse{ID: dataLoad.ID}, nil
+}
+
+func buildDataLoad(ctx *logger.RequestContext, req *CreateDataLoadRequest) (*model.FSDataLoad, model.ClusterInfo, error) {
+	if req.FsName == "" {
+		ctx.ErrorCode = common.RequiredFieldEmpty
+		return nil, model.ClusterInfo{}, fmt.Errorf("fsName is required")
+	}
+	userName := ctx.UserName
+	if common.IsRootUser(ctx.UserName) && req.Username != "" {
+		userName = req.Username
+	}
+	fsID := common.ID(userName, req.FsName)
+	if _, err := storage.Filesystem.GetFileSystemWithFsID(fsID); err != nil {
+		ctx.ErrorCode = common.RecordNotFound
+		return nil, model.ClusterInfo{}, fmt.Errorf("fs[%s] not exist", fsID)
+	}
+	paths, err := validateDataLoadPaths(req.Paths)
+	if err != nil {
+		ctx.ErrorCode = common.InvalidArguments
+		return nil, model.ClusterInfo{}, err
+	}
+
+	cluster, nodes, err := getDataLoadNodes(fsID, req.ClusterName, req.Nodes)
+	if err != nil {
+		ctx.ErrorCode = common.InvalidArguments
+		return nil, model.ClusterInfo{}, err
+	}
+	namespace := req.Namespace
+	if namespace == "" {
+		namespace = config.DefaultNamespace
+	}
+
+	dataLoad := &model.FSDataLoad{
+		ID:         uuid.GenerateID(common.PrefixDataLoad),
+		UserName:   ctx.UserName,
+		FsID:       fsID,
+		FsName:     req.FsName,
+		ClusterID:  cluster.ID,
+		Namespace:  namespace,
+		Paths:      paths,
+		TotalFiles: -1,
+		TotalBytes: -1,
+		Status:     model.DataLoadStatusPending,
+	}
+	for i, node := range nodes {
+		dataLoad.Nodes = append(dataLoad.Nodes, model.DataLoadNodeStatus{
+			NodeName: node,
+			PodName:  fmt.Sprintf("%s-%d", dataLoad.ID, i),
+			Phase:    string(corev1.PodPending),
+		})
+	}
+	return dataLoad, cluster, nil
+}
+
+func validateDataLoadPaths(paths []string) ([]string, error) {
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("paths is required")
+	}
+	cleaned := make([]string, 0, len(paths))
+	for _, p := range paths {
+		if !dataLoadPathRegexp.MatchString(p) {
+			return nil, fmt.Errorf("path[%s] is invalid, only letters, digits, _.-/ and wildcards *?[] are allowed", p)
+		}
+		if _, err := path.Match(p, ""); err != nil {
+			return nil, fmt.Errorf("path[%s] is invalid: %v", p, err)
+		}
+		p = strings.Trim(path.Clean("/"+p), "/")
+		if p == "" {
+			p = "."
+		}
+		cleaned = append(cleaned, p)
+	}
+	return cleaned, nil
+}
+
+// getDataLoadNodes 未指定节点时使用该存储在集群中已有缓存的节点
+func getDataLoadNodes(fsID, clusterName string, nodes []string) (model.ClusterInfo, []string, error) {
+	var cluster model.ClusterInfo
+	var err error
+	if clusterName != "" {
+		if cluster, err = storage.Cluster.GetClusterByName(clusterName); err != nil {
+			return model.ClusterInfo{}, nil, fmt.Errorf("cluster[%s] not found", clusterName)
+		}
+	}
+	if len(nodes) > 0 {
+		if cluster.ID == "" {
+			return model.ClusterInfo{}, nil, fmt.Errorf("clusterName is required when nodes are specified")
+		}
+		return cluster, nodes, nil
+	}
+
+	caches, err := storage.FsCache.List(fsID, "")
+	if err != nil {
+		return model.ClusterInfo{}, nil, err
+	}
+	nodeSet := make(map[string]bool)
+	for _, cache := range caches {
+		if cluster.ID == "" {
+			if cluster, err = storage.Cluster.GetClusterById(cache.ClusterID); err != nil {
+				return model.ClusterInfo{}, nil, fmt.Errorf("cluster[%s] of fs cache not found", cache.ClusterID)
+			}
+		}
+		if cache.ClusterID == cluster.ID && !nodeSet[cache.NodeName] {
+			nodeSet[cache.NodeName] = true
+			nodes = append(nodes, cache.NodeName)
+		}
+	}
+	if len(nodes) == 0 {
+		return model.ClusterInfo{}, nil, fmt.Errorf("fs[%s] has no cache node, clusterName and nodes are required", fsID)
+	}
+	return cluster, nodes, nil
+}
+
+// dataLoadScript 读取匹配的所有文件，每读取dataLoadReportPerFile个文件输出一次"loaded <文件数> <字节数>"
+func dataLoadScript(paths []string) string {
+	return fmt.Sprintf(`cd %s && find %s -type f | { files=0; bytes=0; `+
+		`while IFS= read -r f; do n=$(cat "$f" | wc -c) || exit 1; files=$((files+1)); bytes=$((bytes+n)); `+
+		`[ $((files%%%d)) -eq 0 ] && echo "loaded $files $bytes"; done; echo "loaded $files $bytes"; }`,
+		dataLoadMountPath, strings.Join(paths, " "), dataLoadReportPerFile)
+}
+
+func buildDataLoadPod(dataLoad *model.FSDataLoad, node model.DataLoadNodeStatus) *corev1.Pod {
+	image := defaultDataLoadImage
+	if config.GlobalServerConfig != nil && config.GlobalServerConfig.Fs.DataLoadImage != "" {
+		image = config.GlobalServerConfig.Fs.DataLoadImage
+	}
+	return &corev1.Pod{
+		ObjectMeta: k8sMeta.ObjectMeta{
+			Name:      node.PodName,
+			Namespace: dataLoad.Namespace,
+			Labels:    map[string]string{labelDataLoadID: dataLoad.ID},
+		},
+		Spec: corev1.PodSpec{
+			NodeName:      node.NodeName,
+			RestartPolicy: corev1.RestartPolicyNever,
+			Containers: []corev1.Container{
+				{
+					Name:    "data-load",
+					Image:   image,
+					Command: []string{"sh", "-c", dataLoadScript(dataLoad.Paths)},
+					VolumeMounts: []corev1.VolumeMount{
+						{Name: dataLoad.FsID, MountPath: dataLoadMountPath, ReadOnly: true},
+					},
+				},
+			},
+			Volumes: []corev1.Volume{
+				{
+					Name: dataLoad.FsID,
+					VolumeSource: corev1.VolumeSource{
+						PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+							ClaimName: schema.ConcatenatePVCName(dataLoad.FsID),
+							ReadOnly:  true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func countDataLoadTotal(dataLoad model.FSDataLoad) {
+	logEntry := log.WithField("dataLoad", dataLoad.ID)
+	files, size, err := dataLoadUsage(dataLoad.FsID, dataLoad.Paths, logEntry)
+	if err != nil {
+		logEntry.Errorf("count files of data load failed. error: %v", err)
+		return
+	}
+	if err := storage.FsDataLoad.UpdateDataLoadTotal(logEntry, dataLoad.ID, files, size); err != nil {
+		logEntry.Errorf("update total of data load failed. error: %v", err)
+	}
+}
+
+func failDataLoad(id, message string) {
+	err := storage.FsDataLoad.UpdateDataLoad(log.NewEntry(log.StandardLogger()), id, &model.FSDataLoad{
+		Status:  model.DataLoadStatusFailed,
+		Message: message,
+	})
+	if err != nil {
+		log.Errorf("update data load[%s] failed. error: %v", id, err)
+	}
+}
+
+func deleteDataLoadPods(rt dataLoadRuntime, dataLoad *model.FSDataLoad) error {
+	for _, node := range dataLoad.Nodes {
+		if err := rt.DeletePod(dataLoad.Namespace, node.PodName); err != nil && !k8serrors.IsNotFound(err) {
+			log.Errorf("delete pod[%s] of data load[%s] failed. error: %v", node.PodName, dataLoad.ID, err)
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *FileSystemService) GetDataLoad(ctx *logger.RequestContext, id string) (*DataLoadResponse, error) {
+	dataLoad, err := storage.FsDataLoad.GetDataLoad(ctx.Logging(), id)
+	if err != nil {
+		ctx.ErrorCode = common.RecordNotFound
+		return nil, fmt.Errorf("data load[%s] not found", id)
+	}
+	if err := common.CheckPermission(ctx.UserName, dataLoad.UserName, common.ResourceTypeFs, id); err != nil {
+		ctx.ErrorCode = common.AccessDenied
+		return nil, err
+	}
+	return &DataLoadResponse{FSDataLoad: dataLoad, Progress: dataLoad.Progress()}, nil
+}
+
+func (s *FileSystemService) ListDataLoad(ctx *logger.RequestContext, marker string, maxKeys int, fsName string) (*ListDataLoadResponse, error) {
+	response := &ListDataLoadResponse{DataLoadList: []DataLoadResponse{}}
+	var pk int64
+	var err error
+	if marker != "" {
+		pk, err = common.DecryptPk(marker)
+		if err != nil {
+			ctx.ErrorCode = common.InvalidMarker
+			ctx.Logging().Errorf("DecryptPk marker[%s] failed. err:[%s]", marker, err.Error())
+			return nil, err
+		}
+	}
+	// 多查询一条，用于判断是否还有下一页
+	dataLoads, err := storage.FsDataLoad.ListDataLoad(ctx.Logging(), pk, maxKeys+1, ctx.UserName, fsName)
+	if err != nil {
+		ctx.ErrorCode = common.FileSystemDataBaseError
+		return nil, err
+	}
+	if len(dataLoads) > maxKeys {
+		dataLoads = dataLoads[:maxKeys]
+		nextMarker, err := common.EncryptPk(dataLoads[len(dataLoads)-1].Pk)
+		if err != nil {
+			ctx.ErrorCode = common.InternalError
+			return nil, err
+		}
+		response.IsTruncated = true
+		response.NextMarker = nextMarker
+	}
+	response.MaxKeys = maxKeys
+	for _, dataLoad := range dataLoads {
+		response.DataLoadList = append(response.DataLoadList, DataLoadResponse{FSDataLoad: dataLoad, Progress: dataLoad.Progress()})
+	}
+	return response, nil
+}
+
+// DeleteDataLoad 删除预热pod及记录，已进入缓存的数据不会被清理
+func (s *FileSystemService) DeleteDataLoad(ctx *logger.RequestContext, id string) error {
+	dataLoad, err := s.GetDataLoad(ctx, id)
+	if err != nil {
+		return err
+	}
+	cluster, err := storage.Cluster.GetClusterById(dataLoad.ClusterID)
+	if err == nil {
+		var rt dataLoadRuntime
+		if rt, err = getDataLoadRuntime(cluster); err == nil {
+			err = deleteDataLoadPods(rt, &dataLoad.FSDataLoad)
+		}
+	}
+	if err != nil {
+		ctx.ErrorCode = common.K8sOperatorError
+		ctx.Logging().Errorf("delete pods of data load[%s] failed. error: %v", id, err)
+		return err
+	}
+	if err := storage.FsDataLoad.DeleteDataLoad(ctx.Logging(), id); err != nil {
+		ctx.ErrorCode = common.FileSystemDataBaseError
+		return err
+	}
+	return nil
+}
+
+// DataLoadController 定期根据预热pod的状态和日志更新进度
+func DataLoadController(stopChan chan struct{}) {
+	for {
+		syncDataLoads()
+		select {
+		case <-stopChan:
+			log.Info("data load controller stopped")
+			return
+		case <-time.After(dataLoadSyncInterval):
+		}
+	}
+}
+
+func syncDataLoads() {
+	logEntry := log.NewEntry(log.StandardLogger())
+	dataLoads, err := storage.FsDataLoad.ListDataLoadWithStatus(logEntry, model.DataLoadStatusPending, model.DataLoadStatusRunning)
+	if err != nil {
+		log.Errorf("list unfinished data load failed. error: %v", err)
+		return
+	}
+	for i := range dataLoads {
+		if err := syncDataLoad(&dataLoads[i]); err != nil {
+			log.Errorf("sync data load[%s] failed. error: %v", dataLoads[i].ID, err)
+		}
+	}
+}
+
+func syncDataLoad(dataLoad *model.FSDataLoad) error {
+	cluster, err := storage.Cluster.GetClusterById(dataLoad.ClusterID)
+	if err != nil {
+		return err
+	}
+	rt, err := getDataLoadRuntime(cluster)
+	if err != nil {
+		return err
+	}
+	pods, err := rt.ListPods(dataLoad.Namespace, k8sMeta.ListOptions{LabelSelector: labelDataLoadID + "=" + dataLoad.ID})
+	if err != nil {
+		return err
+	}
+	podMap := make(map[string]corev1.Pod, len(pods.Items))
+	for _, pod := range pods.Items {
+		podMap[pod.Name] = pod
+	}
+
+	started, succeeded, failed := false, 0, 0
+	var messages []string
+	for i := range dataLoad.Nodes {
+		node := &dataLoad.Nodes[i]
+		pod, ok := podMap[node.PodName]
+		if !ok {
+			// 已结束的pod被外部删除时保留最后一次同步的状态
+			if node.Phase != string(corev1.PodSucceeded) && node.Phase != string(corev1.PodFailed) {
+				node.Phase = string(corev1.PodFailed)
+				messages = append(messages, fmt.Sprintf("pod[%s] on node[%s] not found", node.PodName, node.NodeName))
+			}
+		} else {
+			node.Phase = string(pod.Status.Phase)
+			if pod.Status.Phase != corev1.PodPending {
+				if logs, err := rt.GetPodLogTail(dataLoad.Namespace, pod.Name, dataLoadLogTailLines); err == nil {
+					node.LoadedFiles, node.LoadedBytes = parseDataLoadProgress(logs, node.LoadedFiles, node.LoadedBytes)
+				} else {
+					log.Warningf("get log of data load pod[%s] failed. error: %v", pod.Name, err)
+				}
+			}
+			if pod.Status.Phase == corev1.PodFailed {
+				messages = append(messages, fmt.Sprintf("pod[%s] on node[%s] failed", node.PodName, node.NodeName))
+			}
+		}
+		switch node.Phase {
+		case string(corev1.PodSucceeded):
+			succeeded++
+		case string(corev1.PodFailed):
+			failed++
+		case string(corev1.PodPending):
+		default:
+			started = true
+		}
+	}
+
+	update := &model.FSDataLoad{Nodes: dataLoad.Nodes, Message: strings.Join(messages, "; ")}
+	switch {
+	case succeeded+failed < len(dataLoad.Nodes):
+		if started || succeeded+failed > 0 {
+			update.Status = model.DataLoadStatusRunning
+		}
+	case failed > 0:
+		update.Status = model.DataLoadStatusFailed
+	default:
+		update.Status = model.DataLoadStatusSucceeded
+	}
+	return storage.FsDataLoad.UpdateDataLoad(log.NewEntry(log.StandardLogger()), dataLoad.ID, update)
+}
+
+// parseDataLoadProgress 解析预热脚本输出的最后一行"loaded <文件数> <字节数>"，无法解析时保持原值
+func parseDataLoadProgress(logs string, files, bytes int64) (int64, int64) {
+	lines := strings.Split(strings.TrimSpace(logs), "\n")
+	fields := strings.Fields(lines[len(lines)-1])
+	if len(fields) != 3 || fields[0] != "loaded" {
+		return files, bytes
+	}
+	loadedFiles, err1 := strconv.ParseInt(fields[1], 10, 64)
+	loadedBytes, err2 := strconv.ParseInt(fields[2], 10, 64)
+	if err1 != nil || err2 != nil {
+		return files, bytes
+	}
+	return loadedFiles, loadedBytes
+}
@@ -0,0 +1,169 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fs
+
+import (
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	k8sMeta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage/driver"
+)
+
+type fakeDataLoadRuntime struct {
+	pods map[string]*corev1.Pod
+	logs map[string]string
+}
+
+func (f *fakeDataLoadRuntime) CreatePV(namespace, fsID string) (string, error) {
+	return "pfs-" + fsID + "-" + namespace + "-pv", nil
+}
+
+func (f *fakeDataLoadRuntime) CreatePVC(namespace, fsId, pv string) error {
+	return nil
+}
+
+func (f *fakeDataLoadRuntime) CreatePod(pod *corev1.Pod) error {
+	f.pods[pod.Name] = pod
+	return nil
+}
+
+func (f *fakeDataLoadRuntime) DeletePod(namespace, name string) error {
+	delete(f.pods, name)
+	return nil
+}
+
+func (f *fakeDataLoadRuntime) ListPods(namespace string, listOptions k8sMeta.ListOptions) (*corev1.PodList, error) {
+	podList := &corev1.PodList{}
+	for _, pod := range f.pods {
+		podList.Items = append(podList.Items, *pod)
+	}
+	return podList, nil
+}
+
+func (f *fakeDataLoadRuntime) GetPodLogTail(namespace, name string, tailLines int64) (string, error) {
+	return f.logs[name], nil
+}
+
+func initDataLoadTest(t *testing.T) *fakeDataLoadRuntime {
+	driver.InitMockDB()
+	cluster := model.ClusterInfo{
+		Model:       model.Model{ID: "cluster-000001"},
+		Name:        "cluster-000001",
+		ClusterType: schema.KubernetesType,
+	}
+	assert.Nil(t, storage.Cluster.CreateCluster(&cluster))
+	assert.Nil(t, storage.Filesystem.CreatFileSystem(&model.FileSystem{
+		Model:    model.Model{ID: "fs-root-data"},
+		Name:     "data",
+		UserName: "root",
+	}))
+	assert.Nil(t, storage.FsCache.Add(&model.FSCache{FsID: "fs-root-data", CacheID: "cache1", NodeName: "node1", ClusterID: cluster.ID}))
+	assert.Nil(t, storage.FsCache.Add(&model.FSCache{FsID: "fs-root-data", CacheID: "cache2", NodeName: "node2", ClusterID: cluster.ID}))
+
+	rt := &fakeDataLoadRuntime{pods: map[string]*corev1.Pod{}, logs: map[string]string{}}
+	getDataLoadRuntime = func(cluster model.ClusterInfo) (dataLoadRuntime, error) {
+		return rt, nil
+	}
+	dataLoadUsage = func(fsID string, paths []string, logEntry *log.Entry) (int64, int64, error) {
+		return 200, 2048, nil
+	}
+	return rt
+}
+
+func TestValidateDataLoadPaths(t *testing.T) {
+	paths, err := validateDataLoadPaths([]string{"/", "train/*.jpg", "./val/../test/"})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{".", "train/*.jpg", "test"}, paths)
+
+	_, err = validateDataLoadPaths(nil)
+	assert.NotNil(t, err)
+	_, err = validateDataLoadPaths([]string{"a; rm -rf /"})
+	assert.NotNil(t, err)
+	_, err = validateDataLoadPaths([]string{"a/[b"})
+	assert.NotNil(t, err)
+}
+
+func TestParseDataLoadProgress(t *testing.T) {
+	files, bytes := parseDataLoadProgress("loaded 100 1024\nloaded 150 2048\n", 0, 0)
+	assert.Equal(t, int64(150), files)
+	assert.Equal(t, int64(2048), bytes)
+
+	files, bytes = parseDataLoadProgress("cat: a: Permission denied", 100, 1024)
+	assert.Equal(t, int64(100), files)
+	assert.Equal(t, int64(1024), bytes)
+}
+
+func TestDataLoad(t *testing.T) {
+	rt := initDataLoadTest(t)
+	ctx := &logger.RequestContext{UserName: "root"}
+	service := GetFileSystemService()
+
+	resp, err := service.CreateDataLoad(ctx, &CreateDataLoadRequest{FsName: "data", Paths: []string{"train"}})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(rt.pods))
+	pod := rt.pods[resp.ID+"-0"]
+	assert.NotNil(t, pod)
+	assert.Equal(t, "node1", pod.Spec.NodeName)
+	assert.Equal(t, schema.ConcatenatePVCName("fs-root-data"), pod.Spec.Volumes[0].PersistentVolumeClaim.ClaimName)
+
+	countDataLoadTotal(model.FSDataLoad{ID: resp.ID, FsID: "fs-root-data"})
+	dataLoad, err := service.GetDataLoad(ctx, resp.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, model.DataLoadStatusPending, dataLoad.Status)
+	assert.Equal(t, int64(200), dataLoad.TotalFiles)
+
+	// 一个节点完成，另一个节点运行中
+	rt.pods[resp.ID+"-0"].Status.Phase = corev1.PodSucceeded
+	rt.pods[resp.ID+"-1"].Status.Phase = corev1.PodRunning
+	rt.logs[resp.ID+"-0"] = "loaded 100 1024\nloaded 200 2048\n"
+	rt.logs[resp.ID+"-1"] = "loaded 100 1024\n"
+	syncDataLoads()
+	dataLoad, err = service.GetDataLoad(ctx, resp.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, model.DataLoadStatusRunning, dataLoad.Status)
+	assert.Equal(t, float64(75), dataLoad.Progress)
+
+	rt.pods[resp.ID+"-1"].Status.Phase = corev1.PodSucceeded
+	rt.logs[resp.ID+"-1"] = "loaded 200 2048\n"
+	syncDataLoads()
+	dataLoad, err = service.GetDataLoad(ctx, resp.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, model.DataLoadStatusSucceeded, dataLoad.Status)
+	assert.Equal(t, float64(100), dataLoad.Progress)
+
+	listResp, err := service.ListDataLoad(ctx, "", 10, "data")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(listResp.DataLoadList))
+
+	// 其他用户无权访问
+	_, err = service.GetDataLoad(&logger.RequestContext{UserName: "user1"}, resp.ID)
+	assert.NotNil(t, err)
+
+	err = service.DeleteDataLoad(ctx, resp.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(rt.pods))
+	_, err = service.GetDataLoad(ctx, resp.ID)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,455 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fs
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/handler"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/client/vfs"
+)
+
+// MaxUploadFileSize 通过apiserver上传的单个文件大小上限，更大的文件需要挂载后写入
+const MaxUploadFileSize = 32 * 1024 * 1024
+
+var errFileTooLarge = fmt.Errorf("file size exceeds limit %d bytes", MaxUploadFileSize)
+
+// uploadReader 读取超过MaxUploadFileSize时返回errFileTooLarge，已写入的部分由WriteFile删除
+type uploadReader struct {
+	reader io.Reader
+	read   int64
+}
+
+func (r *uploadReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.read += int64(n)
+	if r.read > MaxUploadFileSize {
+		return n, errFileTooLarge
+	}
+	return n, err
+}
+
+type FsFileInfo struct {
+	Name    string    `json:"name"`
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	IsDir   bool      `json:"isDir"`
+	Mode    string    `json:"mode"`
+	ModTime time.Time `json:"modTime"`
+}
+
+type ListFilesRequest struct {
+	Path    string `json:"path"`
+	Marker  string `json:"marker"`
+	MaxKeys int    `json:"maxKeys"`
+}
+
+// ListFilesResponse 目录下的文件按名称排序，NextMarker为本页最后一个文件名
+type ListFilesResponse struct {
+	Path       string       `json:"path"`
+	Marker     string       `json:"marker"`
+	Truncated  bool         `json:"truncated"`
+	NextMarker string       `json:"nextMarker"`
+	Files      []FsFileInfo `json:"files"`
+}
+
+// CleanFsPath 将用户传入的路径转换为存储根目录下的绝对路径，不允许访问根目录下的内部节点
+func CleanFsPath(filePath string) (string, error) {
+	cleaned := path.Clean("/" + filePath)
+	if first := strings.SplitN(strings.TrimPrefix(cleaned, "/"), "/", 2)[0]; first != "" && vfs.IsSpecialName(first) {
+		return "", fmt.Errorf("path[%s] is reserved", filePath)
+	}
+	return cleaned, nil
+}
+
+func fsFileInfo(filePath string, info os.FileInfo) FsFileInfo {
+	return FsFileInfo{
+		Name:    info.Name(),
+		Path:    filePath,
+		Size:    info.Size(),
+		IsDir:   info.IsDir(),
+		Mode:    info.Mode().String(),
+		ModTime: info.ModTime(),
+	}
+}
+
+func newFileHandler(ctx *logger.RequestContext, fsID string) (*handler.FsHandler, error) {
+	fsHandler, err := handler.NewFsHandlerWithServer(fsID, ctx.Logging())
+	if err != nil {
+		ctx.Logging().Errorf("new fs handler with fsID[%s] err: %v", fsID, err)
+		ctx.ErrorCode = common.InternalError
+		return nil, err
+	}
+	return fsHandler, nil
+}
+
+func setFileErrorCode(ctx *logger.RequestContext, err error) {
+	if os.IsNotExist(err) {
+		ctx.ErrorCode = common.PathNotFound
+	} else {
+		ctx.ErrorCode = common.InternalError
+	}
+}
+
+// ListFiles 列出目录下的文件与子目录
+func ListFiles(ctx *logger.RequestContext, fsID string, req ListFilesRequest) (*ListFilesResponse, error) {
+	fsHandler, err := newFileHandler(ctx, fsID)
+	if err != nil {
+		return nil, err
+	}
+	dir := req.Path
+	infos, err := fsHandler.ReadDir(dir)
+	if err != nil {
+		setFileErrorCode(ctx, err)
+		return nil, err
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Name() < infos[j].Name()
+	})
+	resp := &ListFilesResponse{Path: dir, Marker: req.Marker, Files: make([]FsFileInfo, 0)}
+	for _, info := range infos {
+		if req.Marker != "" && info.Name() <= req.Marker {
+			continue
+		}
+		if req.MaxKeys > 0 && len(resp.Files) == req.MaxKeys {
+			resp.Truncated = true
+			resp.NextMarker = resp.Files[len(resp.Files)-1].Name
+			break
+		}
+		resp.Files = append(resp.Files, fsFileInfo(path.Join(dir, info.Name()), info))
+	}
+	return resp, nil
+}
+
+func StatFile(ctx *logger.RequestContext, fsID, filePath string) (*FsFileInfo, error) {
+	fsHandler, err := newFileHandler(ctx, fsID)
+	if err != nil {
+		return nil, err
+	}
+	info, err := fsHandler.Stat(filePath)
+	if err != nil {
+		setFileErrorCode(ctx, err)
+		return nil, err
+	}
+	fileInfo := fsFileInfo(filePath, info)
+	return &fileInfo, nil
+}
+
+// OpenFile 打开文件用于下载，调用方负责关闭reader，目录不能下载
+func OpenFile(ctx *logger.RequestContext, fsID, filePath string) (io.ReadCloser, *FsFileInfo, error) {
+	fsHandler, err := newFileHandler(ctx, fsID)
+	if err != nil {
+		return nil, nil, err
+	}
+	info, err := fsHandler.Stat(filePath)
+	if err != nil {
+		setFileErrorCode(ctx, err)
+		return nil, nil, err
+	}
+	if info.IsDir() {
+		ctx.ErrorCode = common.ActionNotAllowed
+		return nil, nil, fmt.Errorf("path[%s] is a directory and cannot be downloaded", filePath)
+	}
+	reader, err := fsHandler.Open(filePath)
+	if err != nil {
+		setFileErrorCode(ctx, err)
+		return nil, nil, err
+	}
+	fileInfo := fsFileInfo(filePath, info)
+	return reader, &fileInfo, nil
+}
+
+// prepareUploadTarget 检查filePath是否可以写入并创建上级目录
+func prepareUploadTarget(ctx *logger.RequestContext, fsHandler *handler.FsHandler, filePath string, overwrite bool) error {
+	info, err := fsHandler.Stat(filePath)
+	if err == nil {
+		if info.IsDir() || !overwrite {
+			ctx.ErrorCode = common.FileSystemPathExist
+			return fmt.Errorf("path[%s] has exist", filePath)
+		}
+	} else if !os.IsNotExist(err) {
+		ctx.ErrorCode = common.InternalError
+		return err
+	}
+	if err = fsHandler.MkdirAll(path.Dir(filePath), 0755); err != nil {
+		ctx.Logging().Errorf("mkdir parent of [%s] err: %v", filePath, err)
+		ctx.ErrorCode = common.InternalError
+		return err
+	}
+	return nil
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// OpenFileRange 从offset开始读取文件，length大于0时最多读取length字节，返回reader及可读取的字节数，用于分段及断点续传下载
+func OpenFileRange(ctx *logger.RequestContext, fsID, filePath string, offset, length int64) (io.ReadCloser, *FsFileInfo, int64, error) {
+	if offset < 0 || length < 0 {
+		ctx.ErrorCode = common.InvalidURI
+		return nil, nil, 0, fmt.Errorf("offset and length should not be negative")
+	}
+	reader, info, err := OpenFile(ctx, fsID, filePath)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	if offset > info.Size {
+		reader.Close()
+		ctx.ErrorCode = common.InvalidURI
+		return nil, nil, 0, fmt.Errorf("offset[%d] exceeds file size %d", offset, info.Size)
+	}
+	if offset > 0 {
+		// 部分存储不支持Seek，此时跳过offset之前的内容
+		seeker, ok := reader.(io.Seeker)
+		if !ok {
+			_, err = io.CopyN(io.Discard, reader, offset)
+		} else if _, err = seeker.Seek(offset, io.SeekStart); err != nil {
+			_, err = io.CopyN(io.Discard, reader, offset)
+		}
+		if err != nil {
+			reader.Close()
+			ctx.ErrorCode = common.InternalError
+			return nil, nil, 0, err
+		}
+	}
+	remaining := info.Size - offset
+	if length > 0 && length < remaining {
+		remaining = length
+	}
+	return readCloser{Reader: io.LimitReader(reader, remaining), Closer: reader}, info, remaining, nil
+}
+
+// UploadFile 将reader中的内容写入filePath，上级目录不存在时创建，overwrite为false时不覆盖已存在的文件
+func UploadFile(ctx *logger.RequestContext, fsID, filePath string, reader io.Reader, overwrite bool) (*FsFileInfo, error) {
+	if filePath == "/" {
+		ctx.ErrorCode = common.InvalidURI
+		return nil, fmt.Errorf("upload path should be a file")
+	}
+	fsHandler, err := newFileHandler(ctx, fsID)
+	if err != nil {
+		return nil, err
+	}
+	if err = prepareUploadTarget(ctx, fsHandler, filePath, overwrite); err != nil {
+		return nil, err
+	}
+	if _, err = fsHandler.WriteFile(filePath, &uploadReader{reader: reader}); err != nil {
+		if errors.Is(err, errFileTooLarge) {
+			ctx.ErrorCode = common.FileSystemFileTooLarge
+			return nil, err
+		}
+		ctx.ErrorCode = common.InternalError
+		return nil, err
+	}
+	return StatFile(ctx, fsID, filePath)
+}
+
+const (
+	// MaxUploadParts 分片上传的分片数上限，分片编号从1开始
+	MaxUploadParts = 10000
+
+	uploadStagingPrefix = ".pfupload."
+)
+
+type FilePart struct {
+	PartNumber int   `json:"partNumber"`
+	Size       int64 `json:"size"`
+}
+
+type ListFilePartsResponse struct {
+	Path  string     `json:"path"`
+	Parts []FilePart `json:"parts"`
+}
+
+// uploadStagingDir 分片暂存在目标文件同级的隐藏目录中，合并后删除
+func uploadStagingDir(filePath string) string {
+	return path.Join(path.Dir(filePath), uploadStagingPrefix+path.Base(filePath))
+}
+
+func uploadPartPath(filePath string, partNumber int) string {
+	return path.Join(uploadStagingDir(filePath), fmt.Sprintf("%05d", partNumber))
+}
+
+// UploadFilePart 上传filePath的第partNumber个分片，重复上传同一分片时覆盖，用于断点续传
+func UploadFilePart(ctx *logger.RequestContext, fsID, filePath string, partNumber int, reader io.Reader) (*FilePart, error) {
+	if filePath == "/" {
+		ctx.ErrorCode = common.InvalidURI
+		return nil, fmt.Errorf("upload path should be a file")
+	}
+	if partNumber < 1 || partNumber > MaxUploadParts {
+		ctx.ErrorCode = common.InvalidURI
+		return nil, fmt.Errorf("partNumber should be in [1, %d]", MaxUploadParts)
+	}
+	fsHandler, err := newFileHandler(ctx, fsID)
+	if err != nil {
+		return nil, err
+	}
+	if err = fsHandler.MkdirAll(uploadStagingDir(filePath), 0755); err != nil {
+		ctx.Logging().Errorf("mkdir staging dir of [%s] in fs[%s] err: %v", filePath, fsID, err)
+		ctx.ErrorCode = common.InternalError
+		return nil, err
+	}
+	n, err := fsHandler.WriteFile(uploadPartPath(filePath, partNumber), &uploadReader{reader: reader})
+	if err != nil {
+		if errors.Is(err, errFileTooLarge) {
+			ctx.ErrorCode = common.FileSystemFileTooLarge
+			return nil, err
+		}
+		ctx.ErrorCode = common.InternalError
+		return nil, err
+	}
+	return &FilePart{PartNumber: partNumber, Size: n}, nil
+}
+
+// ListFileParts 列出filePath已上传的分片，按分片编号排序
+func ListFileParts(ctx *logger.RequestContext, fsID, filePath string) (*ListFilePartsResponse, error) {
+	fsHandler, err := newFileHandler(ctx, fsID)
+	if err != nil {
+		return nil, err
+	}
+	return listFileParts(ctx, fsHandler, filePath)
+}
+
+func listFileParts(ctx *logger.RequestContext, fsHandler *handler.FsHandler, filePath string) (*ListFilePartsResponse, error) {
+	resp := &ListFilePartsResponse{Path: filePath, Parts: make([]FilePart, 0)}
+	infos, err := fsHandler.ReadDir(uploadStagingDir(filePath))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return resp, nil
+		}
+		ctx.ErrorCode = common.InternalError
+		return nil, err
+	}
+	for _, info := range infos {
+		partNumber, err := strconv.Atoi(info.Name())
+		if err != nil || info.IsDir() {
+			continue
+		}
+		resp.Parts = append(resp.Parts, FilePart{PartNumber: partNumber, Size: info.Size()})
+	}
+	sort.Slice(resp.Parts, func(i, j int) bool {
+		return resp.Parts[i].PartNumber < resp.Parts[j].PartNumber
+	})
+	return resp, nil
+}
+
+// partsReader 依次读取各个分片
+type partsReader struct {
+	fsHandler *handler.FsHandler
+	paths     []string
+	current   io.ReadCloser
+}
+
+func (r *partsReader) Read(p []byte) (int, error) {
+	for {
+		if r.current == nil {
+			if len(r.paths) == 0 {
+				return 0, io.EOF
+			}
+			reader, err := r.fsHandler.Open(r.paths[0])
+			if err != nil {
+				return 0, err
+			}
+			r.current, r.paths = reader, r.paths[1:]
+		}
+		n, err := r.current.Read(p)
+		if err == io.EOF {
+			r.current.Close()
+			r.current = nil
+			if n == 0 {
+				continue
+			}
+			err = nil
+		}
+		return n, err
+	}
+}
+
+func (r *partsReader) Close() {
+	if r.current != nil {
+		r.current.Close()
+	}
+}
+
+// CompleteFileUpload 将分片1到parts按序合并为filePath，成功后删除分片
+func CompleteFileUpload(ctx *logger.RequestContext, fsID, filePath string, parts int, overwrite bool) (*FsFileInfo, error) {
+	if filePath == "/" {
+		ctx.ErrorCode = common.InvalidURI
+		return nil, fmt.Errorf("upload path should be a file")
+	}
+	if parts < 1 || parts > MaxUploadParts {
+		ctx.ErrorCode = common.InvalidURI
+		return nil, fmt.Errorf("parts should be in [1, %d]", MaxUploadParts)
+	}
+	fsHandler, err := newFileHandler(ctx, fsID)
+	if err != nil {
+		return nil, err
+	}
+	uploaded, err := listFileParts(ctx, fsHandler, filePath)
+	if err != nil {
+		return nil, err
+	}
+	exists := make(map[int]bool, len(uploaded.Parts))
+	for _, part := range uploaded.Parts {
+		exists[part.PartNumber] = true
+	}
+	paths := make([]string, 0, parts)
+	for i := 1; i <= parts; i++ {
+		if !exists[i] {
+			ctx.ErrorCode = common.InvalidArguments
+			return nil, common.NewServiceError(ctx.ErrorCode, fmt.Sprintf("part %d of [%s] is not uploaded", i, filePath),
+				map[string]string{"missingPart": strconv.Itoa(i)})
+		}
+		paths = append(paths, uploadPartPath(filePath, i))
+	}
+	if err = prepareUploadTarget(ctx, fsHandler, filePath, overwrite); err != nil {
+		return nil, err
+	}
+	reader := &partsReader{fsHandler: fsHandler, paths: paths}
+	defer reader.Close()
+	if _, err = fsHandler.WriteFile(filePath, reader); err != nil {
+		ctx.ErrorCode = common.InternalError
+		return nil, err
+	}
+	if err = fsHandler.RemoveAll(uploadStagingDir(filePath)); err != nil {
+		ctx.Logging().Warningf("remove upload parts of [%s] in fs[%s] err: %v", filePath, fsID, err)
+	}
+	return StatFile(ctx, fsID, filePath)
+}
+
+// AbortFileUpload 删除filePath已上传的分片
+func AbortFileUpload(ctx *logger.RequestContext, fsID, filePath string) error {
+	fsHandler, err := newFileHandler(ctx, fsID)
+	if err != nil {
+		return err
+	}
+	if err = fsHandler.RemoveAll(uploadStagingDir(filePath)); err != nil {
+		ctx.ErrorCode = common.InternalError
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fs
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/handler"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+)
+
+func TestCleanFsPath(t *testing.T) {
+	cases := map[string]string{
+		"":               "/",
+		"data/a.txt":     "/data/a.txt",
+		"/data/../a.txt": "/a.txt",
+		"../../etc":      "/etc",
+		"/data/.stats":   "/data/.stats",
+	}
+	for input, expected := range cases {
+		cleaned, err := CleanFsPath(input)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, cleaned)
+	}
+	_, err := CleanFsPath("/.stats")
+	assert.Error(t, err)
+}
+
+func TestUploadFileTooLarge(t *testing.T) {
+	origin := handler.NewFsHandlerWithServer
+	handler.NewFsHandlerWithServer = handler.MockerNewFsHandlerWithServer
+	defer func() {
+		handler.NewFsHandlerWithServer = origin
+		os.RemoveAll("./mock_fs_handler")
+	}()
+
+	ctx := &logger.RequestContext{UserName: mockRootName}
+	_, err := UploadFile(ctx, mockFSID, "/big", bytes.NewReader(make([]byte, MaxUploadFileSize+1)), false)
+	assert.Error(t, err)
+	assert.Equal(t, common.FileSystemFileTooLarge, ctx.ErrorCode)
+	// 不完整的文件被删除
+	_, err = os.Stat("./mock_fs_handler/big")
+	assert.True(t, os.IsNotExist(err))
+
+	ctx = &logger.RequestContext{UserName: mockRootName}
+	info, err := UploadFile(ctx, mockFSID, "/small", bytes.NewReader([]byte("abc")), false)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), info.Size)
+}
+
+func TestUploadFileParts(t *testing.T) {
+	origin := handler.NewFsHandlerWithServer
+	handler.NewFsHandlerWithServer = handler.MockerNewFsHandlerWithServer
+	defer func() {
+		handler.NewFsHandlerWithServer = origin
+		os.RemoveAll("./mock_fs_handler")
+	}()
+
+	ctx := &logger.RequestContext{UserName: mockRootName}
+	_, err := UploadFilePart(ctx, mockFSID, "/data/model.bin", 0, bytes.NewReader([]byte("abc")))
+	assert.Error(t, err)
+	assert.Equal(t, common.InvalidURI, ctx.ErrorCode)
+
+	// 分片可以乱序上传
+	for partNumber, content := range map[int]string{2: "def", 1: "abc"} {
+		ctx = &logger.RequestContext{UserName: mockRootName}
+		part, err := UploadFilePart(ctx, mockFSID, "/data/model.bin", partNumber, bytes.NewReader([]byte(content)))
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), part.Size)
+	}
+	parts, err := ListFileParts(ctx, mockFSID, "/data/model.bin")
+	assert.NoError(t, err)
+	assert.Equal(t, []FilePart{{PartNumber: 1, Size: 3}, {PartNumber: 2, Size: 3}}, parts.Parts)
+
+	// 缺少分片时不能合并
+	ctx = &logger.RequestContext{UserName: mockRootName}
+	_, err = CompleteFileUpload(ctx, mockFSID, "/data/model.bin", 3, false)
+	assert.Error(t, err)
+	assert.Equal(t, common.InvalidArguments, ctx.ErrorCode)
+
+	ctx = &logger.RequestContext{UserName: mockRootName}
+	info, err := CompleteFileUpload(ctx, mockFSID, "/data/model.bin", 2, false)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(6), info.Size)
+	content, err := os.ReadFile("./mock_fs_handler/data/model.bin")
+	assert.NoError(t, err)
+	assert.Equal(t, "abcdef", string(content))
+	// 合并后分片被删除
+	parts, err = ListFileParts(ctx, mockFSID, "/data/model.bin")
+	assert.NoError(t, err)
+	assert.Empty(t, parts.Parts)
+
+	reader, _, size, err := OpenFileRange(ctx, mockFSID, "/data/model.bin", 2, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), size)
+	data, err := io.ReadAll(reader)
+	reader.Close()
+	assert.NoError(t, err)
+	assert.Equal(t, "cde", string(data))
+
+	ctx = &logger.RequestContext{UserName: mockRootName}
+	_, _, _, err = OpenFileRange(ctx, mockFSID, "/data/model.bin", 7, 0)
+	assert.Error(t, err)
+	assert.Equal(t, common.InvalidURI, ctx.ErrorCode)
+
+	_, err = UploadFilePart(ctx, mockFSID, "/data/other.bin", 1, bytes.NewReader([]byte("abc")))
+	assert.NoError(t, err)
+	assert.NoError(t, AbortFileUpload(ctx, mockFSID, "/data/other.bin"))
+	parts, err = ListFileParts(ctx, mockFSID, "/data/other.bin")
+	assert.NoError(t, err)
+	assert.Empty(t, parts.Parts)
+}
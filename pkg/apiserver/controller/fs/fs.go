@@ -30,7 +30,10 @@ import (
 	k8sMeta "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/controller/project"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/handler"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/models"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/config"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/csiplugin/csiconfig"
@@ -68,6 +71,9 @@ type CreateFileSystemRequest struct {
 	Properties              map[string]string `json:"properties"`
 	Username                string            `json:"username"`
 	IndependentMountProcess bool              `json:"independentMountProcess"`
+	// CapacityQuota 容量配额(字节)，InodeQuota 文件与目录总数配额，0表示不限制
+	CapacityQuota int64 `json:"capacityQuota"`
+	InodeQuota    int64 `json:"inodeQuota"`
 }
 
 type ListFileSystemRequest struct {
@@ -75,6 +81,7 @@ type ListFileSystemRequest struct {
 	MaxKeys  int32  `json:"maxKeys"`
 	Username string `json:"username"`
 	FsName   string `json:"fsName"`
+	Project  string `json:"project"`
 }
 
 type GetFileSystemRequest struct {
@@ -95,6 +102,8 @@ type GetFileSystemResponse struct {
 	SubPath       string            `json:"subPath"`
 	Username      string            `json:"username"`
 	Properties    map[string]string `json:"properties"`
+	CapacityQuota int64             `json:"capacityQuota"`
+	InodeQuota    int64             `json:"inodeQuota"`
 }
 
 type CreateFileSystemResponse struct {
@@ -118,12 +127,22 @@ type FileSystemResponse struct {
 	Username                string            `json:"username"`
 	Properties              map[string]string `json:"properties"`
 	IndependentMountProcess bool              `json:"independentMountProcess"`
+	CapacityQuota           int64             `json:"capacityQuota"`
+	InodeQuota              int64             `json:"inodeQuota"`
 }
 
 type CreateFileSystemClaimsResponse struct {
 	Message string `json:"message"`
 }
 
+// FileSystemUsageResponse 文件系统配额及当前用量，配额为0表示不限制
+type FileSystemUsageResponse struct {
+	CapacityQuota int64 `json:"capacityQuota"`
+	InodeQuota    int64 `json:"inodeQuota"`
+	UsedCapacity  int64 `json:"usedCapacity"`
+	UsedInodes    int64 `json:"usedInodes"`
+}
+
 func MountPodController(mountPodExpire, interval time.Duration,
 	stopChan chan struct{}) {
 	times := 0
@@ -179,6 +198,8 @@ func (s *FileSystemService) CreateFileSystem(ctx *logger.RequestContext, req *Cr
 		SubPath:                 subPath,
 		UserName:                req.Username,
 		IndependentMountProcess: req.IndependentMountProcess,
+		CapacityQuota:           req.CapacityQuota,
+		InodeQuota:              req.InodeQuota,
 	}
 	fs.ID = common.ID(req.Username, req.Name)
 
@@ -201,6 +222,28 @@ func (s *FileSystemService) GetFileSystem(username, fsName string) (model.FileSy
 	return modelsFs, err
 }
 
+// GetFileSystemUsage 遍历文件系统统计当前用量
+func (s *FileSystemService) GetFileSystemUsage(ctx *logger.RequestContext, fs model.FileSystem) (*FileSystemUsageResponse, error) {
+	fsHandler, err := handler.NewFsHandlerWithServer(fs.ID, ctx.Logging())
+	if err != nil {
+		ctx.Logging().Errorf("new fs handler with fsID[%s] err: %v", fs.ID, err)
+		ctx.ErrorCode = common.InternalError
+		return nil, err
+	}
+	size, inodes, err := fsHandler.Usage("/")
+	if err != nil {
+		ctx.Logging().Errorf("get usage of fs[%s] err: %v", fs.ID, err)
+		ctx.ErrorCode = common.InternalError
+		return nil, err
+	}
+	return &FileSystemUsageResponse{
+		CapacityQuota: fs.CapacityQuota,
+		InodeQuota:    fs.InodeQuota,
+		UsedCapacity:  size,
+		UsedInodes:    inodes,
+	}, nil
+}
+
 // DeleteFileSystem the function which performs the operation of delete file system
 func (s *FileSystemService) DeleteFileSystem(ctx *logger.RequestContext, fsID string) error {
 	isMounted, cleanPodMap, err := s.checkFsMountedAllClustersAndScheduledJobs(fsID)
@@ -222,7 +265,7 @@ func (s *FileSystemService) DeleteFileSystem(ctx *logger.RequestContext, fsID st
 		return err
 	}
 
-	// delete filesystem, links, cache config in DB
+	// delete filesystem, links, usage, acl, cache config in DB
 	return storage.WithTransaction(storage.DB, func(tx *gorm.DB) error {
 		// delete filesystem
 		if err := storage.Filesystem.DeleteFileSystem(tx, fsID); err != nil {
@@ -236,6 +279,31 @@ func (s *FileSystemService) DeleteFileSystem(ctx *logger.RequestContext, fsID st
 			ctx.ErrorCode = common.FileSystemDataBaseError
 			return err
 		}
+		if err := storage.FsUsage.DeleteFsUsage(tx, fsID); err != nil {
+			ctx.Logging().Errorf("delete usage with fsID[%s] err: %v", fsID, err)
+			ctx.ErrorCode = common.FileSystemDataBaseError
+			return err
+		}
+		if err := storage.FsReplication.DeleteFsReplication(tx, fsID); err != nil {
+			ctx.Logging().Errorf("delete replication with fsID[%s] err: %v", fsID, err)
+			ctx.ErrorCode = common.FileSystemDataBaseError
+			return err
+		}
+		if err := storage.FsLifecycle.DeleteFsLifecycleRule(tx, fsID); err != nil {
+			ctx.Logging().Errorf("delete lifecycle rules with fsID[%s] err: %v", fsID, err)
+			ctx.ErrorCode = common.FileSystemDataBaseError
+			return err
+		}
+		if err := storage.FsAcl.DeleteFsAcl(tx, fsID); err != nil {
+			ctx.Logging().Errorf("delete acl with fsID[%s] err: %v", fsID, err)
+			ctx.ErrorCode = common.FileSystemDataBaseError
+			return err
+		}
+		if err := storage.Project.DeleteProjectMemberByResource(tx, common.ResourceTypeFs, fsID); err != nil {
+			ctx.Logging().Errorf("remove fs[%s] from projects err: %v", fsID, err)
+			ctx.ErrorCode = common.FileSystemDataBaseError
+			return err
+		}
 		// delete cache config if exists
 		if err := storage.Filesystem.DeleteFSCacheConfig(tx, fsID); err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -314,12 +382,17 @@ func checkFsMountedSingleCluster(cluster model.ClusterInfo, fsID string) (bool,
 		log.Errorf("list mount pods failed: %v", err)
 		return false, nil, nil, err
 	}
+	serverPods := make([]k8sCore.Pod, 0, len(pods.Items))
 	for _, po := range pods.Items {
+		if !isServerMountPod(po) {
+			continue
+		}
 		if checkMountPodMounted(po) {
 			return true, nil, nil, nil
 		}
+		serverPods = append(serverPods, po)
 	}
-	return false, k8sRuntime, pods.Items, nil
+	return false, k8sRuntime, serverPods, nil
 }
 
 func checkMountPodMounted(po k8sCore.Pod) bool {
@@ -408,6 +481,13 @@ func deletePvPvc(runtimePodsMap map[*runtime.KubeRuntime][]k8sCore.Pod, fsID str
 				log.Errorf(err.Error())
 				return err
 			}
+			// secret only exists when mountInfoSecret is enabled
+			secretName := schema.MountInfoSecretName(fsID, config.GetServiceAddress())
+			if err := k8sRuntime.DeleteSecret(ns, secretName, k8sMeta.DeleteOptions{}); err != nil && !k8sErrors.IsNotFound(err) {
+				err := fmt.Errorf("delete secret[%s/%s] err: %v", ns, secretName, err)
+				log.Errorf(err.Error())
+				return err
+			}
 		}
 	}
 	return nil
@@ -441,8 +521,15 @@ func (s *FileSystemService) ListFileSystem(ctx *logger.RequestContext, req *List
 	if req.Username == common.UserRoot {
 		listUserName = ""
 	}
+	if req.Project != "" {
+		if err := project.CheckProjectMember(ctx, req.Project); err != nil {
+			return nil, "", err
+		}
+		// 项目成员可以看到项目中所有的存储
+		listUserName = ""
+	}
 
-	items, err := storage.Filesystem.ListFileSystem(int(limit), listUserName, marker, req.FsName)
+	items, err := storage.Filesystem.ListFileSystem(int(limit), listUserName, marker, req.FsName, req.Project)
 	if err != nil {
 		ctx.Logging().Errorf("list file systems err[%v]", err)
 		ctx.ErrorCode = common.FileSystemDataBaseError
@@ -28,6 +28,7 @@ import (
 	k8sMeta "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/config"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/csiplugin/csiconfig"
@@ -229,6 +230,16 @@ func Test_cleanFsResources(t *testing.T) {
 			return nil
 		})
 	defer p3.Reset()
+	var deletedSecrets []string
+	p4 := gomonkey.ApplyMethod(reflect.TypeOf(mockRuntime), "DeleteSecret",
+		func(_ *runtime.KubeRuntime, namespace, name string, deleteOptions k8sMeta.DeleteOptions) error {
+			deletedSecrets = append(deletedSecrets, name)
+			return nil
+		})
+	defer p4.Reset()
+	oldConfig := config.GlobalServerConfig
+	defer func() { config.GlobalServerConfig = oldConfig }()
+	config.GlobalServerConfig = &config.ServerConfig{}
 
 	err = GetFileSystemService().cleanFsResources(runtimePodsMap, mockFSID)
 	assert.Nil(t, err)
@@ -239,6 +250,7 @@ func Test_cleanFsResources(t *testing.T) {
 	l, err = storage.FsCache.List(mockFSID2, "")
 	assert.Nil(t, err)
 	assert.Equal(t, 1, len(l))
+	assert.Contains(t, deletedSecrets, schema.MountInfoSecretName(mockFSID, config.GetServiceAddress()))
 
 	notMountedFs1.Name = "notValid"
 	runtimePodsMap[mockRuntime.(*runtime.KubeRuntime)] = []k8sCore.Pod{notMountedFs1}
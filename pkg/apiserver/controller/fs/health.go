@@ -0,0 +1,313 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fs
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	k8sCore "k8s.io/api/core/v1"
+	k8sMeta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/handler"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/csiplugin/csiconfig"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+)
+
+const (
+	FsHealthModeFsck = "fsck"
+
+	FsckIssueBackendRootNotDir  = "backendRootNotDir"
+	FsckIssueStaleCacheRecord   = "staleCacheRecord"
+	FsckIssueMissingCacheRecord = "missingCacheRecord"
+	FsckIssueCacheDirMismatch   = "cacheDirMismatch"
+)
+
+// 后端返回的错误中包含这些关键字时，认为是认证信息失效而不是网络不通
+var credentialErrorKeywords = []string{"AccessDenied", "InvalidAccessKeyId", "SignatureDoesNotMatch",
+	"Forbidden", "403", "Unauthorized", "401", "permission denied", "authentication failed"}
+
+type FileSystemHealthRequest struct {
+	Mode   string `json:"mode"`
+	Repair bool   `json:"repair"`
+}
+
+type FileSystemHealthResponse struct {
+	FsName    string           `json:"fsName"`
+	Username  string           `json:"username"`
+	Healthy   bool             `json:"healthy"`
+	Backend   BackendHealth    `json:"backend"`
+	MountPods []MountPodHealth `json:"mountPods"`
+	Messages  []string         `json:"messages,omitempty"`
+	Fsck      *FsckResult      `json:"fsck,omitempty"`
+}
+
+type BackendHealth struct {
+	Connected       bool   `json:"connected"`
+	CredentialValid bool   `json:"credentialValid"`
+	LatencyMs       int64  `json:"latencyMs"`
+	Message         string `json:"message,omitempty"`
+}
+
+type MountPodHealth struct {
+	ClusterID string `json:"clusterID"`
+	NodeName  string `json:"nodeName"`
+	PodName   string `json:"podName"`
+	Phase     string `json:"phase"`
+	Ready     bool   `json:"ready"`
+	Restarts  int32  `json:"restarts"`
+	Refs      int    `json:"refs"`
+	CacheDir  string `json:"cacheDir"`
+}
+
+type FsckResult struct {
+	CheckedCacheRecords int         `json:"checkedCacheRecords"`
+	CheckedMountPods    int         `json:"checkedMountPods"`
+	Issues              []FsckIssue `json:"issues"`
+}
+
+type FsckIssue struct {
+	Type     string `json:"type"`
+	Target   string `json:"target"`
+	Message  string `json:"message"`
+	Repaired bool   `json:"repaired"`
+}
+
+type clusterMountPod struct {
+	clusterID string
+	pod       k8sCore.Pod
+}
+
+type fsMountPods struct {
+	pods []clusterMountPod
+	// 成功列出挂载pod的集群，无法访问的集群中的缓存记录不做校验
+	listedClusters map[string]bool
+	messages       []string
+}
+
+// GetFileSystemHealth 检查存储后端连通性、认证信息与各节点挂载pod状态，fsck模式下再校验缓存记录与挂载pod是否一致
+func GetFileSystemHealth(ctx *logger.RequestContext, fs model.FileSystem, req FileSystemHealthRequest) (*FileSystemHealthResponse, error) {
+	if req.Mode != "" && req.Mode != FsHealthModeFsck {
+		ctx.ErrorCode = common.InvalidHTTPRequest
+		return nil, fmt.Errorf("health check mode[%s] not supported, only support %s", req.Mode, FsHealthModeFsck)
+	}
+	resp := &FileSystemHealthResponse{
+		FsName:    fs.Name,
+		Username:  fs.UserName,
+		MountPods: make([]MountPodHealth, 0),
+	}
+	isDir := checkBackendHealth(ctx, fs, &resp.Backend)
+
+	mountPods := listFsMountPods(fs.ID)
+	resp.Messages = mountPods.messages
+	podsReady := true
+	for _, mp := range mountPods.pods {
+		podHealth := mountPodHealth(mp)
+		podsReady = podsReady && podHealth.Ready
+		resp.MountPods = append(resp.MountPods, podHealth)
+	}
+	resp.Healthy = resp.Backend.Connected && resp.Backend.CredentialValid && podsReady
+
+	if req.Mode == FsHealthModeFsck {
+		result, err := fsck(fs, isDir, mountPods, req.Repair)
+		if err != nil {
+			ctx.ErrorCode = common.FileSystemDataBaseError
+			ctx.Logging().Errorf("fsck fs[%s] err: %v", fs.ID, err)
+			return nil, err
+		}
+		for _, issue := range result.Issues {
+			if !issue.Repaired {
+				resp.Healthy = false
+			}
+		}
+		resp.Fsck = result
+	}
+	return resp, nil
+}
+
+// checkBackendHealth 访问存储根目录，返回根目录是否为目录
+func checkBackendHealth(ctx *logger.RequestContext, fs model.FileSystem, backend *BackendHealth) (isDir bool) {
+	start := time.Now()
+	fsHandler, err := handler.NewFsHandlerWithServer(fs.ID, ctx.Logging())
+	if err == nil {
+		var fi os.FileInfo
+		if fi, err = fsHandler.Stat("/"); err == nil {
+			isDir = fi.IsDir()
+		}
+	}
+	backend.LatencyMs = time.Since(start).Milliseconds()
+	if err != nil {
+		ctx.Logging().Errorf("check backend of fs[%s] err: %v", fs.ID, err)
+		backend.Message = err.Error()
+		backend.CredentialValid = !isCredentialError(err)
+		// 认证失败说明后端可以访问
+		backend.Connected = !backend.CredentialValid
+		return false
+	}
+	backend.Connected, backend.CredentialValid = true, true
+	return isDir
+}
+
+func isCredentialError(err error) bool {
+	msg := strings.ToLower(err.Error())
+	for _, keyword := range credentialErrorKeywords {
+		if strings.Contains(msg, strings.ToLower(keyword)) {
+			return true
+		}
+	}
+	return false
+}
+
+// listFsMountPods 列出各集群中该存储的挂载pod，无法访问的集群记录在messages中
+func listFsMountPods(fsID string) fsMountPods {
+	mountPods := fsMountPods{pods: make([]clusterMountPod, 0), listedClusters: make(map[string]bool)}
+	crm, err := getClusterRuntimeMap()
+	if err != nil {
+		mountPods.messages = []string{err.Error()}
+		return mountPods
+	}
+	label := csiconfig.PodTypeKey + "=" + csiconfig.PodMount + "," + schema.LabelKeyFsID + "=" + fsID
+	for clusterID, k8sRuntime := range crm {
+		pods, err := k8sRuntime.ListPods(schema.MountPodNamespace, k8sMeta.ListOptions{LabelSelector: label})
+		if err != nil {
+			log.Errorf("list mount pods of fs[%s] in cluster[%s] failed: %v", fsID, clusterID, err)
+			mountPods.messages = append(mountPods.messages, fmt.Sprintf("list mount pods in cluster[%s] failed: %v", clusterID, err))
+			continue
+		}
+		mountPods.listedClusters[clusterID] = true
+		for _, po := range pods.Items {
+			if !isServerMountPod(po) {
+				continue
+			}
+			mountPods.pods = append(mountPods.pods, clusterMountPod{clusterID: clusterID, pod: po})
+		}
+	}
+	return mountPods
+}
+
+func mountPodHealth(mp clusterMountPod) MountPodHealth {
+	po := mp.pod
+	podHealth := MountPodHealth{
+		ClusterID: mp.clusterID,
+		NodeName:  po.Spec.NodeName,
+		PodName:   po.Name,
+		Phase:     string(po.Status.Phase),
+		CacheDir:  po.Annotations[schema.AnnotationKeyCacheDir],
+	}
+	for _, cond := range po.Status.Conditions {
+		if cond.Type == k8sCore.PodReady && cond.Status == k8sCore.ConditionTrue {
+			podHealth.Ready = true
+		}
+	}
+	for _, cs := range po.Status.ContainerStatuses {
+		podHealth.Restarts += cs.RestartCount
+	}
+	for key := range po.Annotations {
+		if strings.HasPrefix(key, schema.AnnotationKeyMountPrefix) {
+			podHealth.Refs++
+		}
+	}
+	return podHealth
+}
+
+// fsck 校验缓存记录与挂载pod是否一致，repair为true时删除残留的缓存记录、补全缺失的缓存记录
+func fsck(fs model.FileSystem, backendIsDir bool, mountPods fsMountPods, repair bool) (*FsckResult, error) {
+	result := &FsckResult{Issues: make([]FsckIssue, 0), CheckedMountPods: len(mountPods.pods)}
+	if !backendIsDir {
+		result.Issues = append(result.Issues, FsckIssue{
+			Type:    FsckIssueBackendRootNotDir,
+			Target:  fs.SubPath,
+			Message: "root of fs in backend is not an accessible directory",
+		})
+	}
+
+	caches, err := storage.FsCache.List(fs.ID, "")
+	if err != nil {
+		return nil, err
+	}
+	result.CheckedCacheRecords = len(caches)
+
+	recordMap := make(map[string]model.FSCache, len(caches))
+	for _, c := range caches {
+		recordMap[c.CacheID] = c
+	}
+	podCacheIDs := make(map[string]bool)
+	configCacheDir := ""
+	if config, err := storage.Filesystem.GetFSCacheConfig(fs.ID); err == nil {
+		configCacheDir = config.CacheDir
+	}
+	for _, mp := range mountPods.pods {
+		po := mp.pod
+		cacheID := po.Labels[schema.LabelKeyCacheID]
+		cacheDir := po.Annotations[schema.AnnotationKeyCacheDir]
+		if configCacheDir != "" && cacheDir != "" && cacheDir != configCacheDir {
+			result.Issues = append(result.Issues, FsckIssue{
+				Type:   FsckIssueCacheDirMismatch,
+				Target: po.Name,
+				Message: fmt.Sprintf("mount pod uses cache dir[%s] while cache config is [%s], "+
+					"it takes effect after the mount pod is recreated", cacheDir, configCacheDir),
+			})
+		}
+		if cacheID == "" || cacheDir == "" {
+			continue
+		}
+		podCacheIDs[cacheID] = true
+		if _, ok := recordMap[cacheID]; ok || po.Status.Phase != k8sCore.PodRunning {
+			continue
+		}
+		issue := FsckIssue{
+			Type:    FsckIssueMissingCacheRecord,
+			Target:  cacheID,
+			Message: fmt.Sprintf("running mount pod[%s] on node[%s] has no cache record", po.Name, po.Spec.NodeName),
+		}
+		if repair {
+			if err := syncCacheFromMountPod(&po, mp.clusterID); err != nil {
+				issue.Message += fmt.Sprintf(", repair failed: %v", err)
+			} else {
+				issue.Repaired = true
+			}
+		}
+		result.Issues = append(result.Issues, issue)
+	}
+
+	for _, c := range caches {
+		if podCacheIDs[c.CacheID] || !mountPods.listedClusters[c.ClusterID] {
+			continue
+		}
+		issue := FsckIssue{
+			Type:    FsckIssueStaleCacheRecord,
+			Target:  c.CacheID,
+			Message: fmt.Sprintf("cache record of node[%s] dir[%s] has no mount pod", c.NodeName, c.CacheDir),
+		}
+		if repair {
+			if err := storage.FsCache.Delete(fs.ID, c.CacheID); err != nil {
+				issue.Message += fmt.Sprintf(", repair failed: %v", err)
+			} else {
+				issue.Repaired = true
+			}
+		}
+		result.Issues = append(result.Issues, issue)
+	}
+	return result, nil
+}
@@ -0,0 +1,96 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fs
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	k8sCore "k8s.io/api/core/v1"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage/driver"
+)
+
+func Test_isCredentialError(t *testing.T) {
+	assert.True(t, isCredentialError(errors.New("InvalidAccessKeyId: The AWS Access Key Id you provided does not exist")))
+	assert.True(t, isCredentialError(errors.New("status code: 403, request id: xxx")))
+	assert.False(t, isCredentialError(errors.New("dial tcp 10.0.0.1:80: i/o timeout")))
+}
+
+func Test_mountPodHealth(t *testing.T) {
+	pod := mountPodWithCacheStats()
+	pod.Spec.NodeName = mockNodename
+	pod.Annotations[schema.AnnotationKeyMountPrefix+"abc"] = testTargetPath
+	pod.Status.ContainerStatuses = []k8sCore.ContainerStatus{{RestartCount: 2}, {RestartCount: 1}}
+	health := mountPodHealth(clusterMountPod{clusterID: mockClusterID, pod: pod})
+	assert.True(t, health.Ready)
+	assert.Equal(t, 1, health.Refs)
+	assert.Equal(t, int32(3), health.Restarts)
+	assert.Equal(t, mockNodename, health.NodeName)
+	assert.Equal(t, mockCacheDir, health.CacheDir)
+}
+
+func Test_fsck(t *testing.T) {
+	driver.InitMockDB()
+	fs := model.FileSystem{Model: model.Model{ID: mockFSID}, Name: mockFSName, UserName: mockRootName}
+	// 挂载pod已不存在的缓存记录
+	stale := buildFSCache()
+	stale.NodeName = mockNodename2
+	assert.Nil(t, storage.FsCache.Add(&stale))
+	// 无法访问的集群中的缓存记录不做校验
+	other := buildFSCache()
+	other.ClusterID = "cluster-other"
+	assert.Nil(t, storage.FsCache.Add(&other))
+
+	mountPods := fsMountPods{
+		pods:           []clusterMountPod{{clusterID: mockClusterID, pod: mountPodWithCacheStats()}},
+		listedClusters: map[string]bool{mockClusterID: true},
+	}
+	result, err := fsck(fs, true, mountPods, false)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, result.CheckedCacheRecords)
+	assert.Equal(t, 2, len(result.Issues))
+	issueTypes := map[string]bool{}
+	for _, issue := range result.Issues {
+		issueTypes[issue.Type] = true
+		assert.False(t, issue.Repaired)
+	}
+	assert.True(t, issueTypes[FsckIssueStaleCacheRecord])
+	assert.True(t, issueTypes[FsckIssueMissingCacheRecord])
+
+	result, err = fsck(fs, false, mountPods, true)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(result.Issues))
+	assert.Equal(t, FsckIssueBackendRootNotDir, result.Issues[0].Type)
+	assert.True(t, result.Issues[1].Repaired)
+	assert.True(t, result.Issues[2].Repaired)
+
+	caches, err := storage.FsCache.List(mockFSID, "")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(caches))
+	for _, c := range caches {
+		assert.NotEqual(t, mockNodename2, c.NodeName)
+	}
+
+	result, err = fsck(fs, true, mountPods, false)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(result.Issues))
+}
@@ -0,0 +1,279 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fs
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/handler"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	fsCommon "github.com/PaddlePaddle/PaddleFlow/pkg/fs/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+)
+
+// lifecycleInterval 两次执行生命周期规则的间隔，规则以天为单位，无需频繁执行
+var lifecycleInterval = time.Hour
+
+type CreateLifecycleRuleRequest struct {
+	Name string `json:"name"`
+	// Path 规则作用的目录，为空时作用于整个文件系统，delete规则必须指定目录
+	Path   string `json:"path"`
+	Action string `json:"action"`
+	// Days 修改时间早于Days天前的文件被规则命中
+	Days int `json:"days"`
+	// StorageClass archive规则转换的目标存储类型，如GLACIER、ARCHIVE，取值与对象存储服务商相关
+	StorageClass string `json:"storageClass"`
+	DryRun       bool   `json:"dryRun"`
+}
+
+type UpdateLifecycleRuleRequest struct {
+	Days   *int  `json:"days"`
+	DryRun *bool `json:"dryRun"`
+}
+
+type ListLifecycleRuleResponse struct {
+	RuleList []model.FsLifecycleRule `json:"ruleList"`
+}
+
+// CreateLifecycleRule 创建生命周期规则，由后台FsLifecycleController定期执行，dryRun时只生成报告
+func CreateLifecycleRule(ctx *logger.RequestContext, fs model.FileSystem, req CreateLifecycleRuleRequest) (*model.FsLifecycleRule, error) {
+	rule := &model.FsLifecycleRule{
+		FsID:         fs.ID,
+		Name:         req.Name,
+		FsName:       fs.Name,
+		UserName:     fs.UserName,
+		Path:         path.Clean("/" + req.Path),
+		Action:       req.Action,
+		Days:         req.Days,
+		StorageClass: req.StorageClass,
+		DryRun:       req.DryRun,
+	}
+	if err := validateLifecycleRule(fs, rule); err != nil {
+		ctx.ErrorCode = common.InvalidArguments
+		return nil, err
+	}
+	if _, err := storage.FsLifecycle.GetLifecycleRule(ctx.Logging(), fs.ID, req.Name); err == nil {
+		ctx.ErrorCode = common.DuplicatedName
+		return nil, fmt.Errorf("lifecycle rule[%s] of fs[%s] already exists", req.Name, fs.Name)
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.ErrorCode = common.FileSystemDataBaseError
+		return nil, err
+	}
+	if err := storage.FsLifecycle.CreateLifecycleRule(ctx.Logging(), rule); err != nil {
+		ctx.ErrorCode = common.FileSystemDataBaseError
+		return nil, err
+	}
+	ctx.Logging().Infof("lifecycle rule[%s] of fs[%s] created: %+v", rule.Name, fs.ID, req)
+	return rule, nil
+}
+
+func validateLifecycleRule(fs model.FileSystem, rule *model.FsLifecycleRule) error {
+	if rule.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if rule.Days <= 0 {
+		return fmt.Errorf("days[%d] should be positive", rule.Days)
+	}
+	switch rule.Action {
+	case model.LifecycleActionArchive:
+		if fs.Type != fsCommon.S3Type {
+			return fmt.Errorf("archive is not supported by fs[%s] of type %s", fs.Name, fs.Type)
+		}
+		if rule.StorageClass == "" {
+			return fmt.Errorf("storageClass is required for archive")
+		}
+	case model.LifecycleActionDelete:
+		// 避免误删整个文件系统
+		if rule.Path == "/" {
+			return fmt.Errorf("path is required for delete")
+		}
+		rule.StorageClass = ""
+	default:
+		return fmt.Errorf("action[%s] is invalid, must be %s or %s", rule.Action,
+			model.LifecycleActionArchive, model.LifecycleActionDelete)
+	}
+	return nil
+}
+
+func ListLifecycleRule(ctx *logger.RequestContext, fs model.FileSystem) (*ListLifecycleRuleResponse, error) {
+	rules, err := storage.FsLifecycle.ListLifecycleRule(ctx.Logging(), fs.ID)
+	if err != nil {
+		ctx.ErrorCode = common.FileSystemDataBaseError
+		return nil, err
+	}
+	return &ListLifecycleRuleResponse{RuleList: rules}, nil
+}
+
+func getLifecycleRule(ctx *logger.RequestContext, fs model.FileSystem, name string) (*model.FsLifecycleRule, error) {
+	rule, err := storage.FsLifecycle.GetLifecycleRule(ctx.Logging(), fs.ID, name)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.ErrorCode = common.RecordNotFound
+			return nil, fmt.Errorf("lifecycle rule[%s] of fs[%s] not found", name, fs.Name)
+		}
+		ctx.ErrorCode = common.FileSystemDataBaseError
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// UpdateLifecycleRule 修改规则的天数或dry-run模式，通常在确认dry-run报告后关闭dryRun使规则生效
+func UpdateLifecycleRule(ctx *logger.RequestContext, fs model.FileSystem, name string, req UpdateLifecycleRuleRequest) (*model.FsLifecycleRule, error) {
+	rule, err := getLifecycleRule(ctx, fs, name)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]interface{})
+	if req.Days != nil {
+		if *req.Days <= 0 {
+			ctx.ErrorCode = common.InvalidArguments
+			return nil, fmt.Errorf("days[%d] should be positive", *req.Days)
+		}
+		rule.Days = *req.Days
+		values["days"] = rule.Days
+	}
+	if req.DryRun != nil {
+		rule.DryRun = *req.DryRun
+		values["dry_run"] = rule.DryRun
+	}
+	if len(values) == 0 {
+		return rule, nil
+	}
+	if err := storage.FsLifecycle.UpdateLifecycleRule(ctx.Logging(), fs.ID, name, values); err != nil {
+		ctx.ErrorCode = common.FileSystemDataBaseError
+		return nil, err
+	}
+	return rule, nil
+}
+
+func DeleteLifecycleRule(ctx *logger.RequestContext, fs model.FileSystem, name string) error {
+	if _, err := getLifecycleRule(ctx, fs, name); err != nil {
+		return err
+	}
+	if err := storage.FsLifecycle.DeleteLifecycleRule(ctx.Logging(), fs.ID, name); err != nil {
+		ctx.ErrorCode = common.FileSystemDataBaseError
+		return err
+	}
+	return nil
+}
+
+// FsLifecycleController 定期执行各文件系统的生命周期规则，并记录每条规则命中及处理的文件
+func FsLifecycleController(stopChan chan struct{}) {
+	for {
+		runLifecycleRules(time.Now())
+		select {
+		case <-stopChan:
+			log.Info("fs lifecycle controller stopped")
+			return
+		case <-time.After(lifecycleInterval):
+		}
+	}
+}
+
+func runLifecycleRules(now time.Time) {
+	logEntry := log.NewEntry(log.StandardLogger())
+	rules, err := storage.FsLifecycle.ListLifecycleRule(logEntry, "")
+	if err != nil {
+		return
+	}
+	for i := range rules {
+		if err := runLifecycleRule(logEntry, &rules[i], now); err != nil {
+			logEntry.Errorf("run lifecycle rule[%s] of fs[%s] failed: %v", rules[i].Name, rules[i].FsID, err)
+		}
+	}
+}
+
+func runLifecycleRule(logEntry *log.Entry, rule *model.FsLifecycleRule, now time.Time) error {
+	var report model.LifecycleReport
+	fsHandler, err := handler.NewFsHandlerWithServer(rule.FsID, logEntry)
+	var matched []handler.FileStat
+	if err == nil {
+		matched, err = matchLifecycleFiles(fsHandler, rule, now)
+	}
+	if err != nil {
+		_ = storage.FsLifecycle.UpdateLifecycleReport(logEntry, rule.FsID, rule.Name, report, now, err.Error())
+		return err
+	}
+	for _, file := range matched {
+		report.MatchedFiles++
+		report.MatchedBytes += file.Size
+	}
+	var message string
+	if !rule.DryRun {
+		for _, file := range matched {
+			if err = applyLifecycleAction(fsHandler, rule, file.Path); err != nil {
+				message = fmt.Sprintf("%s file[%s] failed: %v", rule.Action, file.Path, err)
+				break
+			}
+			report.ProcessedFiles++
+			report.ProcessedBytes += file.Size
+		}
+	}
+	if err := storage.FsLifecycle.UpdateLifecycleReport(logEntry, rule.FsID, rule.Name, report, now, message); err != nil {
+		return err
+	}
+	if message != "" {
+		return errors.New(message)
+	}
+	return nil
+}
+
+// matchLifecycleFiles 返回规则目录下修改时间早于Days天前的文件，archive规则跳过已是目标存储类型的文件
+func matchLifecycleFiles(fsHandler *handler.FsHandler, rule *model.FsLifecycleRule, now time.Time) ([]handler.FileStat, error) {
+	exist, err := fsHandler.Exist(rule.Path)
+	if err != nil || !exist {
+		return nil, err
+	}
+	files, err := fsHandler.ListFileStats(rule.Path)
+	if err != nil {
+		return nil, err
+	}
+	cutoff := now.AddDate(0, 0, -rule.Days)
+	matched := make([]handler.FileStat, 0)
+	for _, file := range files {
+		if !file.ModTime.Before(cutoff) {
+			continue
+		}
+		if rule.Action == model.LifecycleActionArchive {
+			storageClass, err := fsHandler.StorageClass(file.Path)
+			if err != nil {
+				return nil, err
+			}
+			if storageClass == rule.StorageClass {
+				continue
+			}
+		}
+		matched = append(matched, file)
+	}
+	return matched, nil
+}
+
+// applyLifecycleAction delete规则只删除文件，清空后的目录保留
+func applyLifecycleAction(fsHandler *handler.FsHandler, rule *model.FsLifecycleRule, filePath string) error {
+	if rule.Action == model.LifecycleActionArchive {
+		return fsHandler.SetStorageClass(filePath, rule.StorageClass)
+	}
+	return fsHandler.RemoveAll(filePath)
+}
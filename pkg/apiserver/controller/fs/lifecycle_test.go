@@ -0,0 +1,91 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fs
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage/driver"
+)
+
+func TestFsLifecycleRule(t *testing.T) {
+	driver.InitMockDB()
+	defer mockFsHandlerPerFs()()
+	fs := model.FileSystem{Model: model.Model{ID: "fs-root-lifecycle"}, Name: "lifecycle", UserName: mockRootName,
+		Type: "local", SubPath: "/data"}
+	assert.NoError(t, storage.Filesystem.CreatFileSystem(&fs))
+
+	ctx := &logger.RequestContext{UserName: mockRootName}
+	// 非对象存储不支持归档
+	_, err := CreateLifecycleRule(ctx, fs, CreateLifecycleRuleRequest{Name: "archive", Action: model.LifecycleActionArchive,
+		Days: 30, StorageClass: "GLACIER"})
+	assert.Error(t, err)
+	assert.Equal(t, common.InvalidArguments, ctx.ErrorCode)
+	_, err = CreateLifecycleRule(ctx, fs, CreateLifecycleRuleRequest{Name: "tmp", Action: model.LifecycleActionDelete, Days: 7})
+	assert.Error(t, err)
+	_, err = CreateLifecycleRule(ctx, fs, CreateLifecycleRuleRequest{Name: "tmp", Path: "tmp", Action: model.LifecycleActionDelete})
+	assert.Error(t, err)
+
+	rule, err := CreateLifecycleRule(ctx, fs, CreateLifecycleRuleRequest{Name: "tmp", Path: "tmp", Action: model.LifecycleActionDelete,
+		Days: 7, DryRun: true})
+	assert.NoError(t, err)
+	assert.Equal(t, "/tmp", rule.Path)
+	ctx = &logger.RequestContext{UserName: mockRootName}
+	_, err = CreateLifecycleRule(ctx, fs, CreateLifecycleRuleRequest{Name: "tmp", Path: "tmp", Action: model.LifecycleActionDelete, Days: 7})
+	assert.Equal(t, common.DuplicatedName, ctx.ErrorCode)
+
+	now := time.Now()
+	oldFile := writeMockFsFile(t, filepath.Join(fs.ID, "tmp/dir/old.txt"), []byte("data"), now.AddDate(0, 0, -10))
+	newFile := writeMockFsFile(t, filepath.Join(fs.ID, "tmp/new.txt"), []byte("data"), now.AddDate(0, 0, -1))
+	keepFile := writeMockFsFile(t, filepath.Join(fs.ID, "data/old.txt"), []byte("data"), now.AddDate(0, 0, -10))
+
+	// dry-run只生成报告
+	runLifecycleRules(now)
+	resp, err := ListLifecycleRule(ctx, fs)
+	assert.NoError(t, err)
+	assert.Len(t, resp.RuleList, 1)
+	assert.Equal(t, model.LifecycleReport{MatchedFiles: 1, MatchedBytes: 4}, resp.RuleList[0].LifecycleReport)
+	assert.NotNil(t, resp.RuleList[0].LastRunAt)
+	assert.FileExists(t, oldFile)
+
+	dryRun := false
+	rule, err = UpdateLifecycleRule(ctx, fs, "tmp", UpdateLifecycleRuleRequest{DryRun: &dryRun})
+	assert.NoError(t, err)
+	assert.False(t, rule.DryRun)
+	runLifecycleRules(now)
+	resp, err = ListLifecycleRule(ctx, fs)
+	assert.NoError(t, err)
+	assert.Equal(t, model.LifecycleReport{MatchedFiles: 1, MatchedBytes: 4, ProcessedFiles: 1, ProcessedBytes: 4},
+		resp.RuleList[0].LifecycleReport)
+	assert.Empty(t, resp.RuleList[0].Message)
+	assert.NoFileExists(t, oldFile)
+	assert.FileExists(t, newFile)
+	assert.FileExists(t, keepFile)
+
+	assert.NoError(t, DeleteLifecycleRule(ctx, fs, "tmp"))
+	ctx = &logger.RequestContext{UserName: mockRootName}
+	assert.Error(t, DeleteLifecycleRule(ctx, fs, "tmp"))
+	assert.Equal(t, common.RecordNotFound, ctx.ErrorCode)
+}
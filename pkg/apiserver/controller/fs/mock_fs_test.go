@@ -0,0 +1,55 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/handler"
+)
+
+// mockFsDir 测试中模拟文件系统的本地目录，与 handler.MockerNewFsHandlerWithServer 使用的目录一致
+const mockFsDir = "./mock_fs_handler"
+
+// writeMockFsFile 在 mockFsDir 下写入测试文件，modTime 非零时设置文件的修改时间，返回文件路径
+func writeMockFsFile(t *testing.T, name string, data []byte, modTime time.Time) string {
+	name = filepath.Join(mockFsDir, name)
+	assert.NoError(t, os.MkdirAll(filepath.Dir(name), 0755))
+	assert.NoError(t, os.WriteFile(name, data, 0644))
+	if !modTime.IsZero() {
+		assert.NoError(t, os.Chtimes(name, modTime, modTime))
+	}
+	return name
+}
+
+// mockFsHandlerPerFs 每个文件系统对应 mockFsDir 下以fsID命名的子目录，返回的函数恢复fsHandler并清理目录
+func mockFsHandlerPerFs() func() {
+	origin := handler.NewFsHandlerWithServer
+	handler.NewFsHandlerWithServer = func(fsID string, logEntry *log.Entry) (*handler.FsHandler, error) {
+		return handler.MockerNewFsHandlerWithSubPath(filepath.Join(mockFsDir, fsID), logEntry)
+	}
+	return func() {
+		handler.NewFsHandlerWithServer = origin
+		os.RemoveAll(mockFsDir)
+	}
+}
@@ -0,0 +1,300 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fs
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/handler"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+)
+
+const replicationSyncInterval = 30 * time.Second
+
+// replicationRoundBudget 每轮复制的时长上限，未复制完的文件在下一轮继续
+var replicationRoundBudget = 20 * time.Second
+
+type CreateReplicationRequest struct {
+	// ReplicaFsName 副本存储，须为同一用户的另一个文件系统
+	ReplicaFsName string `json:"replicaFsName"`
+}
+
+// CreateReplication 开启fs到副本存储的异步复制，由后台FsReplicationController定期复制新增或变化的文件
+func CreateReplication(ctx *logger.RequestContext, fs model.FileSystem, req CreateReplicationRequest) (*model.FsReplication, error) {
+	if req.ReplicaFsName == "" {
+		ctx.ErrorCode = common.RequiredFieldEmpty
+		return nil, fmt.Errorf("replicaFsName is required")
+	}
+	if req.ReplicaFsName == fs.Name {
+		ctx.ErrorCode = common.InvalidArguments
+		return nil, fmt.Errorf("replica fs should not be fs[%s] itself", fs.Name)
+	}
+	replicaFsID := common.ID(fs.UserName, req.ReplicaFsName)
+	if _, err := storage.Filesystem.GetFileSystemWithFsID(replicaFsID); err != nil {
+		ctx.ErrorCode = common.RecordNotFound
+		return nil, fmt.Errorf("replica fs[%s] of user[%s] not exist", req.ReplicaFsName, fs.UserName)
+	}
+	// 一个文件系统只能参与一个复制关系，避免链式复制
+	for _, fsID := range []string{fs.ID, replicaFsID} {
+		if r, err := storage.FsReplication.GetReplicationByAnyFs(ctx.Logging(), fsID); err == nil {
+			ctx.ErrorCode = common.ActionNotAllowed
+			return nil, fmt.Errorf("fs[%s] is already in replication from fs[%s] to fs[%s]", fsID, r.FsName, r.ReplicaFsName)
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.ErrorCode = common.FileSystemDataBaseError
+			return nil, err
+		}
+	}
+	replication := &model.FsReplication{
+		FsID:          fs.ID,
+		FsName:        fs.Name,
+		UserName:      fs.UserName,
+		ReplicaFsID:   replicaFsID,
+		ReplicaFsName: req.ReplicaFsName,
+		Status:        model.ReplicationStatusActive,
+	}
+	if err := storage.FsReplication.CreateReplication(ctx.Logging(), replication); err != nil {
+		ctx.ErrorCode = common.FileSystemDataBaseError
+		return nil, err
+	}
+	ctx.Logging().Infof("replication from fs[%s] to fs[%s] created", fs.ID, replicaFsID)
+	return replication, nil
+}
+
+func GetReplication(ctx *logger.RequestContext, fs model.FileSystem) (*model.FsReplication, error) {
+	replication, err := storage.FsReplication.GetReplication(ctx.Logging(), fs.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.ErrorCode = common.RecordNotFound
+			return nil, fmt.Errorf("fs[%s] has no replication", fs.Name)
+		}
+		ctx.ErrorCode = common.FileSystemDataBaseError
+		return nil, err
+	}
+	return &replication, nil
+}
+
+// DeleteReplication 停止复制，副本中已复制的数据保留。故障切换后须先切回才能删除
+func DeleteReplication(ctx *logger.RequestContext, fs model.FileSystem) error {
+	replication, err := GetReplication(ctx, fs)
+	if err != nil {
+		return err
+	}
+	if replication.Status == model.ReplicationStatusFailedOver {
+		ctx.ErrorCode = common.ActionNotAllowed
+		return fmt.Errorf("fs[%s] is failed over to replica, please fail back before deleting replication", fs.Name)
+	}
+	if err := storage.FsReplication.DeleteReplication(ctx.Logging(), fs.ID); err != nil {
+		ctx.ErrorCode = common.FileSystemDataBaseError
+		return err
+	}
+	return nil
+}
+
+// FailoverReplication 互换主存储与副本存储的存储地址，之后新挂载的fs从副本读取，复制暂停
+func FailoverReplication(ctx *logger.RequestContext, fs model.FileSystem) error {
+	return switchReplication(ctx, fs, model.ReplicationStatusActive, model.ReplicationStatusFailedOver)
+}
+
+// FailbackReplication 将存储地址换回主存储并恢复复制，故障切换期间写入副本的数据不会同步回主存储
+func FailbackReplication(ctx *logger.RequestContext, fs model.FileSystem) error {
+	return switchReplication(ctx, fs, model.ReplicationStatusFailedOver, model.ReplicationStatusActive)
+}
+
+func switchReplication(ctx *logger.RequestContext, fs model.FileSystem, from, to string) error {
+	replication, err := GetReplication(ctx, fs)
+	if err != nil {
+		return err
+	}
+	if replication.Status != from {
+		ctx.ErrorCode = common.ActionNotAllowed
+		return fmt.Errorf("replication of fs[%s] is %s, expect %s", fs.Name, replication.Status, from)
+	}
+	replica, err := storage.Filesystem.GetFileSystemWithFsID(replication.ReplicaFsID)
+	if err != nil {
+		ctx.ErrorCode = common.RecordNotFound
+		return fmt.Errorf("replica fs[%s] not exist", replication.ReplicaFsName)
+	}
+	primaryTarget, replicaTarget := replica, fs
+	primaryTarget.ID, replicaTarget.ID = fs.ID, replica.ID
+	message := fmt.Sprintf("%s by %s", to, ctx.UserName)
+	err = storage.WithTransaction(storage.DB, func(tx *gorm.DB) error {
+		if err := storage.Filesystem.UpdateFileSystemTarget(tx, &primaryTarget); err != nil {
+			return err
+		}
+		if err := storage.Filesystem.UpdateFileSystemTarget(tx, &replicaTarget); err != nil {
+			return err
+		}
+		return storage.FsReplication.UpdateReplicationStatus(tx, fs.ID, to, message)
+	})
+	if err != nil {
+		ctx.ErrorCode = common.FileSystemDataBaseError
+		ctx.Logging().Errorf("switch fs[%s] and replica fs[%s] failed: %v", fs.ID, replica.ID, err)
+		return err
+	}
+	ctx.Logging().Infof("replication of fs[%s] switched from %s to %s", fs.ID, from, to)
+	return nil
+}
+
+// FsReplicationController 定期将各主存储中新增或变化的文件复制到副本存储，并更新复制延迟
+func FsReplicationController(stopChan chan struct{}) {
+	for {
+		syncReplications(time.Now().Add(replicationRoundBudget))
+		select {
+		case <-stopChan:
+			log.Info("fs replication controller stopped")
+			return
+		case <-time.After(replicationSyncInterval):
+		}
+	}
+}
+
+func syncReplications(deadline time.Time) {
+	logEntry := log.NewEntry(log.StandardLogger())
+	replications, err := storage.FsReplication.ListReplication(logEntry, model.ReplicationStatusActive)
+	if err != nil {
+		return
+	}
+	// 各复制关系平分每轮的时长，避免大文件系统阻塞其他文件系统的复制
+	for i := range replications {
+		remaining := len(replications) - i
+		budget := time.Until(deadline) / time.Duration(remaining)
+		if budget <= 0 {
+			return
+		}
+		if err := syncReplication(logEntry, &replications[i], time.Now().Add(budget)); err != nil {
+			logEntry.Errorf("sync replication of fs[%s] failed: %v", replications[i].FsID, err)
+		}
+	}
+}
+
+// syncReplication 比较主存储与副本中文件的大小与修改时间，按修改时间从早到晚复制缺失或变化的文件，
+// 主存储中删除的文件不会从副本中删除
+func syncReplication(logEntry *log.Entry, replication *model.FsReplication, deadline time.Time) error {
+	scanTime := time.Now()
+	stats := replication.ReplicationStats
+	primaryHandler, replicaHandler, err := replicationHandlers(logEntry, replication)
+	var pending []handler.FileStat
+	if err == nil {
+		pending, err = pendingReplicationFiles(primaryHandler, replicaHandler)
+	}
+	if err != nil {
+		_ = storage.FsReplication.UpdateReplicationStats(logEntry, replication.FsID, stats, nil, err.Error())
+		return err
+	}
+	var message string
+	for len(pending) > 0 && time.Now().Before(deadline) {
+		file := pending[0]
+		if err = copyReplicationFile(primaryHandler, replicaHandler, file.Path); err != nil {
+			message = fmt.Sprintf("replicate file[%s] failed: %v", file.Path, err)
+			break
+		}
+		stats.ReplicatedFiles++
+		stats.ReplicatedBytes += file.Size
+		pending = pending[1:]
+	}
+
+	stats.PendingFiles, stats.PendingBytes, stats.LagSeconds = int64(len(pending)), 0, 0
+	for _, file := range pending {
+		stats.PendingBytes += file.Size
+	}
+	var lastSyncedAt *time.Time
+	if len(pending) == 0 {
+		lastSyncedAt = &scanTime
+	} else {
+		stats.LagSeconds = int64(time.Since(pending[0].ModTime) / time.Second)
+	}
+	if err := storage.FsReplication.UpdateReplicationStats(logEntry, replication.FsID, stats, lastSyncedAt, message); err != nil {
+		return err
+	}
+	if message != "" {
+		return errors.New(message)
+	}
+	return nil
+}
+
+func replicationHandlers(logEntry *log.Entry, replication *model.FsReplication) (*handler.FsHandler, *handler.FsHandler, error) {
+	primaryHandler, err := handler.NewFsHandlerWithServer(replication.FsID, logEntry)
+	if err != nil {
+		return nil, nil, err
+	}
+	replicaHandler, err := handler.NewFsHandlerWithServer(replication.ReplicaFsID, logEntry)
+	if err != nil {
+		return nil, nil, err
+	}
+	return primaryHandler, replicaHandler, nil
+}
+
+// pendingReplicationFiles 返回副本中缺失、大小不同或早于主存储修改的文件，按修改时间升序排列
+func pendingReplicationFiles(primaryHandler, replicaHandler *handler.FsHandler) ([]handler.FileStat, error) {
+	primaryFiles, err := primaryHandler.ListFileStats("/")
+	if err != nil {
+		return nil, err
+	}
+	replicaFiles, err := replicaHandler.ListFileStats("/")
+	if err != nil {
+		return nil, err
+	}
+	replicaMap := make(map[string]handler.FileStat, len(replicaFiles))
+	for _, file := range replicaFiles {
+		replicaMap[file.Path] = file
+	}
+	pending := make([]handler.FileStat, 0)
+	for _, file := range primaryFiles {
+		replicated, ok := replicaMap[file.Path]
+		if ok && replicated.Size == file.Size && !replicated.ModTime.Before(file.ModTime) {
+			continue
+		}
+		pending = append(pending, file)
+	}
+	sort.SliceStable(pending, func(i, j int) bool {
+		return pending[i].ModTime.Before(pending[j].ModTime)
+	})
+	return pending, nil
+}
+
+// copyReplicationFile 复制单个文件，副本中已有的旧文件先删除再写入
+func copyReplicationFile(primaryHandler, replicaHandler *handler.FsHandler, filePath string) error {
+	reader, err := primaryHandler.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	exist, err := replicaHandler.Exist(filePath)
+	if err != nil {
+		return err
+	}
+	if exist {
+		err = replicaHandler.RemoveAll(filePath)
+	} else {
+		err = replicaHandler.MkdirAll(path.Dir(filePath), 0755)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = replicaHandler.WriteFile(filePath, reader)
+	return err
+}
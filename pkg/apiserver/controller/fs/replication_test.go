@@ -0,0 +1,107 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage/driver"
+)
+
+func TestFsReplication(t *testing.T) {
+	driver.InitMockDB()
+	// 每个文件系统对应一个本地目录
+	defer mockFsHandlerPerFs()()
+	primary := model.FileSystem{Model: model.Model{ID: "fs-root-primary"}, Name: "primary", UserName: mockRootName,
+		Type: "s3", ServerAddress: "s3.bj.example.com", PropertiesMap: map[string]string{"bucket": "bj"}}
+	replica := model.FileSystem{Model: model.Model{ID: "fs-root-replica"}, Name: "replica", UserName: mockRootName,
+		Type: "s3", ServerAddress: "s3.gz.example.com", PropertiesMap: map[string]string{"bucket": "gz"}}
+	assert.NoError(t, storage.Filesystem.CreatFileSystem(&primary))
+	assert.NoError(t, storage.Filesystem.CreatFileSystem(&replica))
+
+	ctx := &logger.RequestContext{UserName: mockRootName}
+	_, err := CreateReplication(ctx, primary, CreateReplicationRequest{ReplicaFsName: "primary"})
+	assert.Error(t, err)
+	_, err = CreateReplication(ctx, primary, CreateReplicationRequest{ReplicaFsName: "notexist"})
+	assert.Error(t, err)
+	_, err = CreateReplication(ctx, primary, CreateReplicationRequest{ReplicaFsName: "replica"})
+	assert.NoError(t, err)
+	// 副本不能再作为其他复制关系的主存储
+	ctx = &logger.RequestContext{UserName: mockRootName}
+	_, err = CreateReplication(ctx, replica, CreateReplicationRequest{ReplicaFsName: "primary"})
+	assert.Error(t, err)
+	assert.Equal(t, common.ActionNotAllowed, ctx.ErrorCode)
+
+	writeMockFsFile(t, filepath.Join(primary.ID, "a.txt"), []byte("hello"), time.Time{})
+	writeMockFsFile(t, filepath.Join(primary.ID, "dir/b.txt"), []byte("world!"), time.Time{})
+	writeMockFsFile(t, filepath.Join(replica.ID, "dir/b.txt"), []byte("old"), time.Time{})
+	syncReplications(time.Now().Add(time.Minute))
+
+	content, err := os.ReadFile(filepath.Join(mockFsDir, replica.ID, "dir/b.txt"))
+	assert.NoError(t, err)
+	assert.Equal(t, "world!", string(content))
+	replication, err := GetReplication(ctx, primary)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), replication.ReplicatedFiles)
+	assert.Equal(t, int64(11), replication.ReplicatedBytes)
+	assert.Equal(t, int64(0), replication.PendingFiles)
+	assert.NotNil(t, replication.LastSyncedAt)
+
+	// 截止时间已过，新写入的文件留到下一轮复制
+	writeMockFsFile(t, filepath.Join(primary.ID, "c.txt"), []byte("new"), time.Time{})
+	assert.NoError(t, syncReplication(log.NewEntry(log.StandardLogger()), replication, time.Now()))
+	replication, err = GetReplication(ctx, primary)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), replication.PendingFiles)
+	assert.Equal(t, int64(3), replication.PendingBytes)
+
+	// 故障切换后主存储指向副本的存储地址
+	assert.NoError(t, FailoverReplication(ctx, primary))
+	switched, err := storage.Filesystem.GetFileSystemWithFsID(primary.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "s3.gz.example.com", switched.ServerAddress)
+	assert.Equal(t, "gz", switched.PropertiesMap["bucket"])
+	switched, err = storage.Filesystem.GetFileSystemWithFsID(replica.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "s3.bj.example.com", switched.ServerAddress)
+	ctx = &logger.RequestContext{UserName: mockRootName}
+	assert.Error(t, DeleteReplication(ctx, primary))
+	assert.Equal(t, common.ActionNotAllowed, ctx.ErrorCode)
+	replications, err := storage.FsReplication.ListReplication(log.NewEntry(log.StandardLogger()), model.ReplicationStatusActive)
+	assert.NoError(t, err)
+	assert.Empty(t, replications)
+
+	assert.NoError(t, FailbackReplication(ctx, primary))
+	switched, err = storage.Filesystem.GetFileSystemWithFsID(primary.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "s3.bj.example.com", switched.ServerAddress)
+	assert.Error(t, FailbackReplication(ctx, primary))
+
+	assert.NoError(t, DeleteReplication(ctx, primary))
+	_, err = GetReplication(ctx, primary)
+	assert.Error(t, err)
+}
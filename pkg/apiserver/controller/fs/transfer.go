@@ -0,0 +1,579 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fs
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	k8sMeta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/config"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/uuid"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+)
+
+const (
+	transferSrcMountPath      = "/home/paddleflow/storage/src"
+	transferDstMountPath      = "/home/paddleflow/storage/dst"
+	defaultTransferImage      = "rclone/rclone:1.59"
+	defaultTransferParallel   = 4
+	maxTransferParallel       = 64
+	transferSyncInterval      = 10 * time.Second
+	transferStatsInterval     = "10s"
+	transferLogTailLines      = 20
+	minTransferBandwidthLimit = 1024
+
+	labelTransferID = "paddleflow-transfer-id"
+)
+
+type CreateTransferRequest struct {
+	Username string `json:"username"`
+	// SrcFsName SrcPath 源存储及其中的路径，路径相对于存储根目录，为目录时复制目录下的所有文件
+	SrcFsName string `json:"srcFsName"`
+	SrcPath   string `json:"srcPath"`
+	DstFsName string `json:"dstFsName"`
+	DstPath   string `json:"dstPath"`
+	// ClusterName 运行迁移pod的集群，源存储与目标存储需要都能在该集群中挂载
+	ClusterName string `json:"clusterName"`
+	Namespace   string `json:"namespace"`
+	// Mode copy或sync，默认为copy
+	Mode string `json:"mode"`
+	// Parallelism 同时传输的文件数，默认为4
+	Parallelism int `json:"parallelism"`
+	// BandwidthLimit 每秒传输的数据量上限，如100Mi
+	BandwidthLimit string `json:"bandwidthLimit"`
+	Checksum       bool   `json:"checksum"`
+}
+
+type CreateTransferResponse struct {
+	ID string `json:"id"`
+}
+
+type TransferResponse struct {
+	model.FSTransfer
+	Progress float64 `json:"progress"`
+}
+
+type ListTransferResponse struct {
+	common.MarkerInfo
+	TransferList []TransferResponse `json:"transferList"`
+}
+
+// rcloneStats rclone以json格式定期输出的传输统计
+type rcloneStats struct {
+	Bytes          int64 `json:"bytes"`
+	TotalBytes     int64 `json:"totalBytes"`
+	Transfers      int64 `json:"transfers"`
+	TotalTransfers int64 `json:"totalTransfers"`
+	Checks         int64 `json:"checks"`
+	Errors         int64 `json:"errors"`
+}
+
+// CreateTransfer 启动迁移pod同时挂载源存储与目标存储，由rclone并行复制数据并校验。
+// 迁移中断后可以恢复，已复制且未变化的文件会被跳过，进度由TransferController根据pod日志更新
+func (s *FileSystemService) CreateTransfer(ctx *logger.RequestContext, req *CreateTransferRequest) (*CreateTransferResponse, error) {
+	transfer, cluster, err := buildTransfer(ctx, req)
+	if err != nil {
+		ctx.Logging().Errorf("create transfer failed. error: %v", err)
+		return nil, err
+	}
+	rt, err := getDataLoadRuntime(cluster)
+	if err != nil {
+		ctx.ErrorCode = common.InternalError
+		ctx.Logging().Errorf("get runtime of cluster[%s] failed. error: %v", cluster.Name, err)
+		return nil, err
+	}
+	for _, fsID := range transferFsIDs(transfer) {
+		pvName, err := rt.CreatePV(transfer.Namespace, fsID)
+		if err == nil {
+			err = rt.CreatePVC(transfer.Namespace, fsID, pvName)
+		}
+		if err != nil {
+			ctx.ErrorCode = common.K8sOperatorError
+			ctx.Logging().Errorf("prepare storage of fs[%s] for transfer failed. error: %v", fsID, err)
+			return nil, err
+		}
+	}
+
+	if err := storage.FsTransfer.CreateTransfer(ctx.Logging(), transfer); err != nil {
+		ctx.ErrorCode = common.FileSystemDataBaseError
+		return nil, err
+	}
+	if err := rt.CreatePod(buildTransferPod(transfer)); err != nil {
+		ctx.ErrorCode = common.K8sOperatorError
+		ctx.Logging().Errorf("create transfer pod[%s] failed. error: %v", transfer.PodName, err)
+		failTransfer(transfer.ID, fmt.Sprintf("create pod failed: %v", err))
+		return nil, err
+	}
+	ctx.Logging().Infof("transfer[%s] from fs[%s] to fs[%s] created", transfer.ID, transfer.SrcFsID, transfer.DstFsID)
+	return &CreateTransferResponse{ID: transfer.ID}, nil
+}
+
+func buildTransfer(ctx *logger.RequestContext, req *CreateTransferRequest) (*model.FSTransfer, model.ClusterInfo, error) {
+	if req.SrcFsName == "" || req.DstFsName == "" {
+		ctx.ErrorCode = common.RequiredFieldEmpty
+		return nil, model.ClusterInfo{}, fmt.Errorf("srcFsName and dstFsName are required")
+	}
+	if req.ClusterName == "" {
+		ctx.ErrorCode = common.RequiredFieldEmpty
+		return nil, model.ClusterInfo{}, fmt.Errorf("clusterName is required")
+	}
+	userName := ctx.UserName
+	if common.IsRootUser(ctx.UserName) && req.Username != "" {
+		userName = req.Username
+	}
+	srcFsID, dstFsID := common.ID(userName, req.SrcFsName), common.ID(userName, req.DstFsName)
+	for _, fsID := range []string{srcFsID, dstFsID} {
+		if _, err := storage.Filesystem.GetFileSystemWithFsID(fsID); err != nil {
+			ctx.ErrorCode = common.RecordNotFound
+			return nil, model.ClusterInfo{}, fmt.Errorf("fs[%s] not exist", fsID)
+		}
+	}
+	cluster, err := storage.Cluster.GetClusterByName(req.ClusterName)
+	if err != nil {
+		ctx.ErrorCode = common.InvalidArguments
+		return nil, model.ClusterInfo{}, fmt.Errorf("cluster[%s] not found", req.ClusterName)
+	}
+
+	transfer := &model.FSTransfer{
+		ID:             uuid.GenerateID(common.PrefixTransfer),
+		UserName:       ctx.UserName,
+		SrcFsID:        srcFsID,
+		SrcFsName:      req.SrcFsName,
+		SrcPath:        cleanTransferPath(req.SrcPath),
+		DstFsID:        dstFsID,
+		DstFsName:      req.DstFsName,
+		DstPath:        cleanTransferPath(req.DstPath),
+		ClusterID:      cluster.ID,
+		Namespace:      req.Namespace,
+		Mode:           req.Mode,
+		Parallelism:    req.Parallelism,
+		BandwidthLimit: req.BandwidthLimit,
+		Checksum:       req.Checksum,
+		Status:         model.TransferStatusPending,
+	}
+	if transfer.Namespace == "" {
+		transfer.Namespace = config.DefaultNamespace
+	}
+	if transfer.Mode == "" {
+		transfer.Mode = model.TransferModeCopy
+	}
+	if transfer.Parallelism == 0 {
+		transfer.Parallelism = defaultTransferParallel
+	}
+	if err := validateTransfer(transfer); err != nil {
+		ctx.ErrorCode = common.InvalidArguments
+		return nil, model.ClusterInfo{}, err
+	}
+	transfer.PodName = transferPodName(transfer)
+	return transfer, cluster, nil
+}
+
+func cleanTransferPath(p string) string {
+	return strings.Trim(path.Clean("/"+p), "/")
+}
+
+func validateTransfer(transfer *model.FSTransfer) error {
+	if transfer.Mode != model.TransferModeCopy && transfer.Mode != model.TransferModeSync {
+		return fmt.Errorf("mode[%s] is invalid, should be %s or %s", transfer.Mode, model.TransferModeCopy, model.TransferModeSync)
+	}
+	if transfer.Parallelism < 1 || transfer.Parallelism > maxTransferParallel {
+		return fmt.Errorf("parallelism[%d] should be in [1, %d]", transfer.Parallelism, maxTransferParallel)
+	}
+	if transfer.BandwidthLimit != "" {
+		limit, err := resource.ParseQuantity(transfer.BandwidthLimit)
+		if err != nil || limit.Value() < minTransferBandwidthLimit {
+			return fmt.Errorf("bandwidthLimit[%s] should be a quantity not less than 1Ki, e.g. 100Mi", transfer.BandwidthLimit)
+		}
+	}
+	// 同一存储内源路径与目标路径不能互相包含，否则sync会删除源数据或无限复制
+	if transfer.SrcFsID == transfer.DstFsID && pathOverlaps(transfer.SrcPath, transfer.DstPath) {
+		return fmt.Errorf("srcPath[%s] and dstPath[%s] in the same fs should not contain each other",
+			transfer.SrcPath, transfer.DstPath)
+	}
+	return nil
+}
+
+func pathOverlaps(a, b string) bool {
+	if a == "" || b == "" || a == b {
+		return true
+	}
+	return strings.HasPrefix(a, b+"/") || strings.HasPrefix(b, a+"/")
+}
+
+func transferPodName(transfer *model.FSTransfer) string {
+	return fmt.Sprintf("%s-%d", transfer.ID, transfer.Attempt)
+}
+
+func transferFsIDs(transfer *model.FSTransfer) []string {
+	if transfer.SrcFsID == transfer.DstFsID {
+		return []string{transfer.SrcFsID}
+	}
+	return []string{transfer.SrcFsID, transfer.DstFsID}
+}
+
+// transferArgs rclone的参数，路径直接作为参数传入，不经过shell解析
+func transferArgs(transfer *model.FSTransfer) []string {
+	args := []string{
+		transfer.Mode,
+		path.Join(transferSrcMountPath, transfer.SrcPath),
+		path.Join(transferDstMountPath, transfer.DstPath),
+		fmt.Sprintf("--transfers=%d", transfer.Parallelism),
+		fmt.Sprintf("--checkers=%d", 2*transfer.Parallelism),
+		"--use-json-log",
+		"--log-level=NOTICE",
+		"--stats-log-level=NOTICE",
+		"--stats=" + transferStatsInterval,
+	}
+	if transfer.Checksum {
+		args = append(args, "--checksum")
+	}
+	if transfer.BandwidthLimit != "" {
+		// rclone的带宽单位默认为KiB/s
+		limit := resource.MustParse(transfer.BandwidthLimit)
+		args = append(args, fmt.Sprintf("--bwlimit=%dK", limit.Value()/1024))
+	}
+	return args
+}
+
+func buildTransferPod(transfer *model.FSTransfer) *corev1.Pod {
+	image := defaultTransferImage
+	if config.GlobalServerConfig != nil && config.GlobalServerConfig.Fs.DataTransferImage != "" {
+		image = config.GlobalServerConfig.Fs.DataTransferImage
+	}
+	srcVolume, dstVolume := "src", "dst"
+	volumes := []corev1.Volume{transferVolume(srcVolume, transfer.SrcFsID)}
+	if transfer.SrcFsID == transfer.DstFsID {
+		// 同一存储只挂载一次pvc，源路径以只读方式挂载
+		dstVolume = srcVolume
+	} else {
+		volumes = append(volumes, transferVolume(dstVolume, transfer.DstFsID))
+	}
+	return &corev1.Pod{
+		ObjectMeta: k8sMeta.ObjectMeta{
+			Name:      transfer.PodName,
+			Namespace: transfer.Namespace,
+			Labels:    map[string]string{labelTransferID: transfer.ID},
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy: corev1.RestartPolicyNever,
+			Containers: []corev1.Container{
+				{
+					Name:    "data-transfer",
+					Image:   image,
+					Command: []string{"rclone"},
+					Args:    transferArgs(transfer),
+					VolumeMounts: []corev1.VolumeMount{
+						{Name: srcVolume, MountPath: transferSrcMountPath, ReadOnly: true},
+						{Name: dstVolume, MountPath: transferDstMountPath},
+					},
+				},
+			},
+			Volumes: volumes,
+		},
+	}
+}
+
+func transferVolume(name, fsID string) corev1.Volume {
+	return corev1.Volume{
+		Name: name,
+		VolumeSource: corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: schema.ConcatenatePVCName(fsID),
+			},
+		},
+	}
+}
+
+func failTransfer(id, message string) {
+	err := storage.FsTransfer.UpdateTransfer(log.NewEntry(log.StandardLogger()), id, &model.FSTransfer{
+		Status:  model.TransferStatusFailed,
+		Message: message,
+	})
+	if err != nil {
+		log.Errorf("update transfer[%s] failed. error: %v", id, err)
+	}
+}
+
+func deleteTransferPod(rt dataLoadRuntime, transfer *model.FSTransfer) error {
+	if err := rt.DeletePod(transfer.Namespace, transfer.PodName); err != nil && !k8serrors.IsNotFound(err) {
+		log.Errorf("delete pod[%s] of transfer[%s] failed. error: %v", transfer.PodName, transfer.ID, err)
+		return err
+	}
+	return nil
+}
+
+func (s *FileSystemService) GetTransfer(ctx *logger.RequestContext, id string) (*TransferResponse, error) {
+	transfer, err := storage.FsTransfer.GetTransfer(ctx.Logging(), id)
+	if err != nil {
+		ctx.ErrorCode = common.RecordNotFound
+		return nil, fmt.Errorf("transfer[%s] not found", id)
+	}
+	if err := common.CheckPermission(ctx.UserName, transfer.UserName, common.ResourceTypeFs, id); err != nil {
+		ctx.ErrorCode = common.AccessDenied
+		return nil, err
+	}
+	return &TransferResponse{FSTransfer: transfer, Progress: transfer.Progress()}, nil
+}
+
+func (s *FileSystemService) ListTransfer(ctx *logger.RequestContext, marker string, maxKeys int, fsName string) (*ListTransferResponse, error) {
+	response := &ListTransferResponse{TransferList: []TransferResponse{}}
+	var pk int64
+	var err error
+	if marker != "" {
+		pk, err = common.DecryptPk(marker)
+		if err != nil {
+			ctx.ErrorCode = common.InvalidMarker
+			ctx.Logging().Errorf("DecryptPk marker[%s] failed. err:[%s]", marker, err.Error())
+			return nil, err
+		}
+	}
+	// 多查询一条，用于判断是否还有下一页
+	transfers, err := storage.FsTransfer.ListTransfer(ctx.Logging(), pk, maxKeys+1, ctx.UserName, fsName)
+	if err != nil {
+		ctx.ErrorCode = common.FileSystemDataBaseError
+		return nil, err
+	}
+	if len(transfers) > maxKeys {
+		transfers = transfers[:maxKeys]
+		nextMarker, err := common.EncryptPk(transfers[len(transfers)-1].Pk)
+		if err != nil {
+			ctx.ErrorCode = common.InternalError
+			return nil, err
+		}
+		response.IsTruncated = true
+		response.NextMarker = nextMarker
+	}
+	response.MaxKeys = maxKeys
+	for _, transfer := range transfers {
+		response.TransferList = append(response.TransferList, TransferResponse{FSTransfer: transfer, Progress: transfer.Progress()})
+	}
+	return response, nil
+}
+
+func getTransferWithRuntime(ctx *logger.RequestContext, s *FileSystemService, id string) (*TransferResponse, dataLoadRuntime, error) {
+	transfer, err := s.GetTransfer(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	cluster, err := storage.Cluster.GetClusterById(transfer.ClusterID)
+	if err != nil {
+		ctx.ErrorCode = common.InternalError
+		return nil, nil, fmt.Errorf("cluster[%s] of transfer[%s] not found", transfer.ClusterID, id)
+	}
+	rt, err := getDataLoadRuntime(cluster)
+	if err != nil {
+		ctx.ErrorCode = common.InternalError
+		return nil, nil, err
+	}
+	return transfer, rt, nil
+}
+
+// StopTransfer 删除迁移pod，已复制的数据保留在目标存储中
+func (s *FileSystemService) StopTransfer(ctx *logger.RequestContext, id string) error {
+	transfer, rt, err := getTransferWithRuntime(ctx, s, id)
+	if err != nil {
+		return err
+	}
+	if transfer.Status != model.TransferStatusPending && transfer.Status != model.TransferStatusRunning {
+		ctx.ErrorCode = common.ActionNotAllowed
+		return fmt.Errorf("transfer[%s] with status[%s] can not be stopped", id, transfer.Status)
+	}
+	if err := deleteTransferPod(rt, &transfer.FSTransfer); err != nil {
+		ctx.ErrorCode = common.K8sOperatorError
+		return err
+	}
+	if err := storage.FsTransfer.UpdateTransfer(ctx.Logging(), id, &model.FSTransfer{
+		Status:  model.TransferStatusTerminated,
+		Message: "stopped by " + ctx.UserName,
+	}); err != nil {
+		ctx.ErrorCode = common.FileSystemDataBaseError
+		return err
+	}
+	return nil
+}
+
+// ResumeTransfer 为已失败或已停止的任务启动新的迁移pod，已复制且未变化的文件会被跳过
+func (s *FileSystemService) ResumeTransfer(ctx *logger.RequestContext, id string) error {
+	transfer, rt, err := getTransferWithRuntime(ctx, s, id)
+	if err != nil {
+		return err
+	}
+	if transfer.Status != model.TransferStatusFailed && transfer.Status != model.TransferStatusTerminated {
+		ctx.ErrorCode = common.ActionNotAllowed
+		return fmt.Errorf("transfer[%s] with status[%s] can not be resumed", id, transfer.Status)
+	}
+	if err := deleteTransferPod(rt, &transfer.FSTransfer); err != nil {
+		ctx.ErrorCode = common.K8sOperatorError
+		return err
+	}
+	resumed := transfer.FSTransfer
+	resumed.Attempt++
+	resumed.PodName = transferPodName(&resumed)
+	if err := rt.CreatePod(buildTransferPod(&resumed)); err != nil {
+		ctx.ErrorCode = common.K8sOperatorError
+		ctx.Logging().Errorf("create transfer pod[%s] failed. error: %v", resumed.PodName, err)
+		return err
+	}
+	logEntry := ctx.Logging()
+	err = storage.FsTransfer.UpdateTransferStats(logEntry, id, model.TransferStats{})
+	if err == nil {
+		err = storage.FsTransfer.UpdateTransfer(logEntry, id, &model.FSTransfer{
+			Attempt: resumed.Attempt,
+			PodName: resumed.PodName,
+			Status:  model.TransferStatusPending,
+			Message: fmt.Sprintf("resumed by %s", ctx.UserName),
+		})
+	}
+	if err != nil {
+		ctx.ErrorCode = common.FileSystemDataBaseError
+		return err
+	}
+	return nil
+}
+
+// DeleteTransfer 删除迁移pod及记录，已复制的数据保留在目标存储中
+func (s *FileSystemService) DeleteTransfer(ctx *logger.RequestContext, id string) error {
+	transfer, rt, err := getTransferWithRuntime(ctx, s, id)
+	if err != nil {
+		return err
+	}
+	if err := deleteTransferPod(rt, &transfer.FSTransfer); err != nil {
+		ctx.ErrorCode = common.K8sOperatorError
+		return err
+	}
+	if err := storage.FsTransfer.DeleteTransfer(ctx.Logging(), id); err != nil {
+		ctx.ErrorCode = common.FileSystemDataBaseError
+		return err
+	}
+	return nil
+}
+
+// TransferController 定期根据迁移pod的状态和日志更新进度
+func TransferController(stopChan chan struct{}) {
+	for {
+		syncTransfers()
+		select {
+		case <-stopChan:
+			log.Info("transfer controller stopped")
+			return
+		case <-time.After(transferSyncInterval):
+		}
+	}
+}
+
+func syncTransfers() {
+	logEntry := log.NewEntry(log.StandardLogger())
+	transfers, err := storage.FsTransfer.ListTransferWithStatus(logEntry, model.TransferStatusPending, model.TransferStatusRunning)
+	if err != nil {
+		log.Errorf("list unfinished transfer failed. error: %v", err)
+		return
+	}
+	for i := range transfers {
+		if err := syncTransfer(&transfers[i]); err != nil {
+			log.Errorf("sync transfer[%s] failed. error: %v", transfers[i].ID, err)
+		}
+	}
+}
+
+func syncTransfer(transfer *model.FSTransfer) error {
+	cluster, err := storage.Cluster.GetClusterById(transfer.ClusterID)
+	if err != nil {
+		return err
+	}
+	rt, err := getDataLoadRuntime(cluster)
+	if err != nil {
+		return err
+	}
+	pods, err := rt.ListPods(transfer.Namespace, k8sMeta.ListOptions{LabelSelector: labelTransferID + "=" + transfer.ID})
+	if err != nil {
+		return err
+	}
+	var pod *corev1.Pod
+	for i := range pods.Items {
+		if pods.Items[i].Name == transfer.PodName {
+			pod = &pods.Items[i]
+		}
+	}
+
+	logEntry := log.NewEntry(log.StandardLogger())
+	update := &model.FSTransfer{}
+	switch {
+	case pod == nil:
+		update.Status = model.TransferStatusFailed
+		update.Message = fmt.Sprintf("pod[%s] not found", transfer.PodName)
+	case pod.Status.Phase == corev1.PodPending:
+		return nil
+	default:
+		if logs, err := rt.GetPodLogTail(transfer.Namespace, pod.Name, transferLogTailLines); err == nil {
+			if stats, ok := parseTransferStats(logs); ok {
+				if err := storage.FsTransfer.UpdateTransferStats(logEntry, transfer.ID, stats); err != nil {
+					return err
+				}
+			}
+		} else {
+			log.Warningf("get log of transfer pod[%s] failed. error: %v", pod.Name, err)
+		}
+		switch pod.Status.Phase {
+		case corev1.PodSucceeded:
+			update.Status = model.TransferStatusSucceeded
+		case corev1.PodFailed:
+			update.Status = model.TransferStatusFailed
+			update.Message = fmt.Sprintf("pod[%s] failed, resume the transfer to continue", pod.Name)
+		default:
+			update.Status = model.TransferStatusRunning
+		}
+	}
+	if update.Status == transfer.Status {
+		return nil
+	}
+	return storage.FsTransfer.UpdateTransfer(logEntry, transfer.ID, update)
+}
+
+// parseTransferStats 解析rclone日志中最后一条统计，没有统计时ok为false
+func parseTransferStats(logs string) (model.TransferStats, bool) {
+	lines := strings.Split(strings.TrimSpace(logs), "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		var entry struct {
+			Stats *rcloneStats `json:"stats"`
+		}
+		if err := json.Unmarshal([]byte(lines[i]), &entry); err != nil || entry.Stats == nil {
+			continue
+		}
+		return model.TransferStats{
+			TransferredFiles: entry.Stats.Transfers,
+			TransferredBytes: entry.Stats.Bytes,
+			TotalFiles:       entry.Stats.TotalTransfers,
+			TotalBytes:       entry.Stats.TotalBytes,
+			CheckedFiles:     entry.Stats.Checks,
+			Errors:           entry.Stats.Errors,
+		}, true
+	}
+	return model.TransferStats{}, false
+}
@@ -0,0 +1,141 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+)
+
+func TestValidateTransfer(t *testing.T) {
+	transfer := &model.FSTransfer{
+		SrcFsID:     "fs-root-data",
+		SrcPath:     cleanTransferPath("/train/"),
+		DstFsID:     "fs-root-data",
+		DstPath:     cleanTransferPath("backup/train"),
+		Mode:        model.TransferModeSync,
+		Parallelism: 8,
+	}
+	assert.Equal(t, "train", transfer.SrcPath)
+	assert.Nil(t, validateTransfer(transfer))
+
+	transfer.DstPath = "train/backup"
+	assert.NotNil(t, validateTransfer(transfer))
+	transfer.DstPath = ""
+	assert.NotNil(t, validateTransfer(transfer))
+
+	transfer.DstFsID = "fs-root-backup"
+	assert.Nil(t, validateTransfer(transfer))
+	transfer.Mode = "move"
+	assert.NotNil(t, validateTransfer(transfer))
+	transfer.Mode = model.TransferModeCopy
+	transfer.Parallelism = 100
+	assert.NotNil(t, validateTransfer(transfer))
+	transfer.Parallelism = 4
+	transfer.BandwidthLimit = "100"
+	assert.NotNil(t, validateTransfer(transfer))
+	transfer.BandwidthLimit = "100Mi"
+	assert.Nil(t, validateTransfer(transfer))
+}
+
+func TestParseTransferStats(t *testing.T) {
+	logs := `{"level":"notice","msg":"\nTransferred: 1 GiB","stats":{"bytes":1024,"checks":3,"errors":0,"totalBytes":4096,"totalTransfers":8,"transfers":2},"time":"2022-08-01T00:00:00Z"}
+{"level":"error","msg":"Failed to copy: a.txt","time":"2022-08-01T00:00:01Z"}
+`
+	stats, ok := parseTransferStats(logs)
+	assert.True(t, ok)
+	assert.Equal(t, model.TransferStats{TransferredFiles: 2, TransferredBytes: 1024, TotalFiles: 8,
+		TotalBytes: 4096, CheckedFiles: 3}, stats)
+
+	_, ok = parseTransferStats("rclone: command not found")
+	assert.False(t, ok)
+}
+
+func TestTransfer(t *testing.T) {
+	rt := initDataLoadTest(t)
+	assert.Nil(t, storage.Filesystem.CreatFileSystem(&model.FileSystem{
+		Model:    model.Model{ID: "fs-root-backup"},
+		Name:     "backup",
+		UserName: "root",
+	}))
+	ctx := &logger.RequestContext{UserName: "root"}
+	service := GetFileSystemService()
+
+	_, err := service.CreateTransfer(ctx, &CreateTransferRequest{SrcFsName: "data", DstFsName: "backup"})
+	assert.NotNil(t, err)
+
+	resp, err := service.CreateTransfer(ctx, &CreateTransferRequest{SrcFsName: "data", SrcPath: "train",
+		DstFsName: "backup", ClusterName: "cluster-000001", BandwidthLimit: "10Mi", Checksum: true})
+	assert.Nil(t, err)
+	pod := rt.pods[resp.ID+"-0"]
+	assert.NotNil(t, pod)
+	assert.Equal(t, []string{"rclone"}, pod.Spec.Containers[0].Command)
+	assert.Equal(t, []string{"copy", transferSrcMountPath + "/train", transferDstMountPath,
+		"--transfers=4", "--checkers=8", "--use-json-log", "--log-level=NOTICE", "--stats-log-level=NOTICE",
+		"--stats=10s", "--checksum", "--bwlimit=10240K"}, pod.Spec.Containers[0].Args)
+	assert.Equal(t, 2, len(pod.Spec.Volumes))
+	assert.Equal(t, schema.ConcatenatePVCName("fs-root-backup"), pod.Spec.Volumes[1].PersistentVolumeClaim.ClaimName)
+
+	pod.Status.Phase = corev1.PodRunning
+	rt.logs[pod.Name] = `{"level":"notice","stats":{"bytes":1024,"totalBytes":4096,"transfers":1,"totalTransfers":4}}`
+	syncTransfers()
+	transfer, err := service.GetTransfer(ctx, resp.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, model.TransferStatusRunning, transfer.Status)
+	assert.Equal(t, float64(25), transfer.Progress)
+
+	// 失败后恢复，使用新的pod继续复制
+	pod.Status.Phase = corev1.PodFailed
+	syncTransfers()
+	transfer, err = service.GetTransfer(ctx, resp.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, model.TransferStatusFailed, transfer.Status)
+	assert.Nil(t, service.ResumeTransfer(ctx, resp.ID))
+	assert.Nil(t, rt.pods[resp.ID+"-0"])
+	assert.NotNil(t, rt.pods[resp.ID+"-1"])
+	transfer, err = service.GetTransfer(ctx, resp.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, model.TransferStatusPending, transfer.Status)
+	assert.Equal(t, int64(0), transfer.TransferredBytes)
+	assert.NotNil(t, service.ResumeTransfer(ctx, resp.ID))
+
+	rt.pods[resp.ID+"-1"].Status.Phase = corev1.PodSucceeded
+	syncTransfers()
+	transfer, err = service.GetTransfer(ctx, resp.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, model.TransferStatusSucceeded, transfer.Status)
+	assert.Equal(t, float64(100), transfer.Progress)
+
+	listResp, err := service.ListTransfer(ctx, "", 10, "backup")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(listResp.TransferList))
+
+	_, err = service.GetTransfer(&logger.RequestContext{UserName: "user1"}, resp.ID)
+	assert.NotNil(t, err)
+
+	assert.Nil(t, service.DeleteTransfer(ctx, resp.ID))
+	assert.Equal(t, 0, len(rt.pods))
+	_, err = service.GetTransfer(ctx, resp.ID)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,360 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fs
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/handler"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/utils"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+)
+
+const (
+	usageScanInterval = 30 * time.Second
+	// DefaultUsageGrowthDays 默认计算最近7天的增长，MaxUsageGrowthDays 与扫描记录保留时间一致
+	DefaultUsageGrowthDays = 7
+	MaxUsageGrowthDays     = 30
+)
+
+var (
+	// usageScanBudget 每轮扫描的时长上限，未扫描完的一级目录在下一轮继续
+	usageScanBudget = 20 * time.Second
+	// usageRescanInterval 同一文件系统两次完整扫描的最小间隔
+	usageRescanInterval = time.Hour
+	usageRetention      = MaxUsageGrowthDays * 24 * time.Hour
+)
+
+type FsUsageStat struct {
+	Path        string `json:"path"`
+	Size        int64  `json:"size"`
+	Files       int64  `json:"files"`
+	Dirs        int64  `json:"dirs"`
+	SizeGrowth  int64  `json:"sizeGrowth"`
+	FilesGrowth int64  `json:"filesGrowth"`
+}
+
+type FsUsagePoint struct {
+	ScanTime time.Time `json:"scanTime"`
+	Size     int64     `json:"size"`
+	Files    int64     `json:"files"`
+}
+
+// FileSystemDuResponse 最近一次扫描的用量，增长为与BaseTime时扫描结果的差值
+type FileSystemDuResponse struct {
+	FsName      string         `json:"fsName"`
+	Username    string         `json:"username"`
+	ScanTime    time.Time      `json:"scanTime"`
+	BaseTime    time.Time      `json:"baseTime"`
+	Total       FsUsageStat    `json:"total"`
+	Directories []FsUsageStat  `json:"directories"`
+	History     []FsUsagePoint `json:"history"`
+}
+
+type FsUsageSummary struct {
+	FsName   string    `json:"fsName"`
+	Username string    `json:"username"`
+	Size     int64     `json:"size"`
+	Files    int64     `json:"files"`
+	Dirs     int64     `json:"dirs"`
+	ScanTime time.Time `json:"scanTime"`
+}
+
+type ListFileSystemDuResponse struct {
+	FsList []FsUsageSummary `json:"fsList"`
+}
+
+// GetFileSystemDu 返回后台扫描缓存的用量，不实时遍历文件系统
+func GetFileSystemDu(ctx *logger.RequestContext, fs model.FileSystem, days int) (*FileSystemDuResponse, error) {
+	since := time.Now().Add(-time.Duration(days) * 24 * time.Hour)
+	history, err := storage.FsUsage.ListUsageHistory(ctx.Logging(), fs.ID, model.FsUsageRootPath, since)
+	if err != nil {
+		ctx.ErrorCode = common.FileSystemDataBaseError
+		return nil, err
+	}
+	if len(history) == 0 {
+		// 最近一次扫描早于since时仍返回该次结果
+		latest, err := latestFsUsage(ctx, fs.ID)
+		if err != nil {
+			return nil, err
+		}
+		history = []model.FsUsage{latest}
+	}
+	base, latest := history[0], history[len(history)-1]
+	resp := &FileSystemDuResponse{
+		FsName:      fs.Name,
+		Username:    fs.UserName,
+		ScanTime:    latest.ScanTime,
+		BaseTime:    base.ScanTime,
+		Total:       usageStat(latest, base),
+		Directories: make([]FsUsageStat, 0),
+		History:     make([]FsUsagePoint, 0, len(history)),
+	}
+	for _, u := range history {
+		resp.History = append(resp.History, FsUsagePoint{ScanTime: u.ScanTime, Size: u.Size, Files: u.Files})
+	}
+
+	dirs, err := storage.FsUsage.ListUsage(ctx.Logging(), fs.ID, latest.ScanTime)
+	if err != nil {
+		ctx.ErrorCode = common.FileSystemDataBaseError
+		return nil, err
+	}
+	baseDirs, err := storage.FsUsage.ListUsage(ctx.Logging(), fs.ID, base.ScanTime)
+	if err != nil {
+		ctx.ErrorCode = common.FileSystemDataBaseError
+		return nil, err
+	}
+	baseMap := make(map[string]model.FsUsage, len(baseDirs))
+	for _, u := range baseDirs {
+		baseMap[u.Path] = u
+	}
+	for _, u := range dirs {
+		if u.Path == model.FsUsageRootPath {
+			continue
+		}
+		// 基准扫描之后新建的目录，增长即当前用量
+		resp.Directories = append(resp.Directories, usageStat(u, baseMap[u.Path]))
+	}
+	sort.SliceStable(resp.Directories, func(i, j int) bool {
+		return resp.Directories[i].Size > resp.Directories[j].Size
+	})
+	return resp, nil
+}
+
+func latestFsUsage(ctx *logger.RequestContext, fsID string) (model.FsUsage, error) {
+	usages, err := storage.FsUsage.ListLatestUsage(ctx.Logging())
+	if err != nil {
+		ctx.ErrorCode = common.FileSystemDataBaseError
+		return model.FsUsage{}, err
+	}
+	for _, u := range usages {
+		if u.FsID == fsID {
+			return u, nil
+		}
+	}
+	ctx.ErrorCode = common.RecordNotFound
+	return model.FsUsage{}, fmt.Errorf("usage of fs[%s] has not been scanned yet, please retry later", fsID)
+}
+
+func usageStat(u, base model.FsUsage) FsUsageStat {
+	return FsUsageStat{
+		Path:        u.Path,
+		Size:        u.Size,
+		Files:       u.Files,
+		Dirs:        u.Dirs,
+		SizeGrowth:  u.Size - base.Size,
+		FilesGrowth: u.Files - base.Files,
+	}
+}
+
+// ListFileSystemDu 各文件系统最近一次扫描的用量，按大小降序排列，username为空时返回所有用户的文件系统
+func ListFileSystemDu(ctx *logger.RequestContext, username string) (*ListFileSystemDuResponse, error) {
+	usages, err := storage.FsUsage.ListLatestUsage(ctx.Logging())
+	if err != nil {
+		ctx.ErrorCode = common.FileSystemDataBaseError
+		return nil, err
+	}
+	resp := &ListFileSystemDuResponse{FsList: make([]FsUsageSummary, 0, len(usages))}
+	for _, u := range usages {
+		fsName, owner, err := utils.GetFsNameAndUserNameByFsID(u.FsID)
+		if err != nil {
+			ctx.Logging().Warningf("parse fsID[%s] of usage err: %v", u.FsID, err)
+			continue
+		}
+		if username != "" && owner != username {
+			continue
+		}
+		resp.FsList = append(resp.FsList, FsUsageSummary{
+			FsName:   fsName,
+			Username: owner,
+			Size:     u.Size,
+			Files:    u.Files,
+			Dirs:     u.Dirs,
+			ScanTime: u.ScanTime,
+		})
+	}
+	sort.SliceStable(resp.FsList, func(i, j int) bool {
+		return resp.FsList[i].Size > resp.FsList[j].Size
+	})
+	return resp, nil
+}
+
+// usageScan 一次进行中的扫描，一级目录逐个统计，可以跨多轮完成
+type usageScan struct {
+	fsID      string
+	scanTime  time.Time
+	fsHandler *handler.FsHandler
+	pending   []string
+	total     model.FsUsage
+	dirs      []model.FsUsage
+}
+
+// usageScanner 依次扫描各文件系统，每轮最多占用usageScanBudget，扫描完成后整体写入数据库
+type usageScanner struct {
+	current *usageScan
+	// lastScan 各文件系统最近一次完成(或失败)扫描的时间
+	lastScan map[string]time.Time
+}
+
+// FsUsageController 后台扫描文件系统用量并保存历史记录
+func FsUsageController(stopChan chan struct{}) {
+	scanner := &usageScanner{}
+	for {
+		scanner.round(time.Now().Add(usageScanBudget))
+		select {
+		case <-stopChan:
+			log.Info("fs usage controller stopped")
+			return
+		case <-time.After(usageScanInterval):
+		}
+	}
+}
+
+func (s *usageScanner) round(deadline time.Time) {
+	logEntry := log.NewEntry(log.StandardLogger())
+	if s.lastScan == nil {
+		usages, err := storage.FsUsage.ListLatestUsage(logEntry)
+		if err != nil {
+			return
+		}
+		s.lastScan = make(map[string]time.Time, len(usages))
+		for _, u := range usages {
+			s.lastScan[u.FsID] = u.ScanTime
+		}
+	}
+	for time.Now().Before(deadline) {
+		if s.current == nil {
+			fsID := s.nextFs(logEntry)
+			if fsID == "" {
+				return
+			}
+			scan, err := startUsageScan(logEntry, fsID)
+			if err != nil {
+				logEntry.Errorf("start usage scan of fs[%s] failed: %v", fsID, err)
+				// 出错的文件系统等到下个扫描周期再重试
+				s.lastScan[fsID] = time.Now()
+				continue
+			}
+			s.current = scan
+		}
+		if err := s.current.scanNextDir(); err != nil {
+			logEntry.Errorf("usage scan of fs[%s] failed: %v", s.current.fsID, err)
+			s.lastScan[s.current.fsID] = time.Now()
+			s.current = nil
+			continue
+		}
+		if len(s.current.pending) == 0 {
+			s.finish(logEntry)
+		}
+	}
+}
+
+// nextFs 选择最久未扫描且距上次扫描超过usageRescanInterval的文件系统
+func (s *usageScanner) nextFs(logEntry *log.Entry) string {
+	// marker精确到秒，加一秒以包含刚创建的文件系统
+	marker := time.Now().Add(time.Second).Format(model.TimeFormat)
+	fileSystems, err := storage.Filesystem.ListFileSystem(-1, "", marker, "", "")
+	if err != nil {
+		logEntry.Errorf("list file systems for usage scan failed: %v", err)
+		return ""
+	}
+	next, nextTime := "", time.Now().Add(-usageRescanInterval)
+	for _, fs := range fileSystems {
+		if last := s.lastScan[fs.ID]; !last.After(nextTime) {
+			next, nextTime = fs.ID, last
+		}
+	}
+	return next
+}
+
+func startUsageScan(logEntry *log.Entry, fsID string) (*usageScan, error) {
+	fsHandler, err := handler.NewFsHandlerWithServer(fsID, logEntry)
+	if err != nil {
+		return nil, err
+	}
+	infos, err := fsHandler.ReadDir("/")
+	if err != nil {
+		return nil, err
+	}
+	scan := &usageScan{
+		fsID:      fsID,
+		scanTime:  time.Now().Truncate(time.Millisecond),
+		fsHandler: fsHandler,
+		total:     model.FsUsage{FsID: fsID, Path: model.FsUsageRootPath},
+	}
+	for _, info := range infos {
+		if info.IsDir() {
+			scan.pending = append(scan.pending, path.Join("/", info.Name()))
+			continue
+		}
+		scan.total.Files++
+		scan.total.Size += info.Size()
+	}
+	sort.Strings(scan.pending)
+	return scan, nil
+}
+
+func (scan *usageScan) scanNextDir() error {
+	if len(scan.pending) == 0 {
+		return nil
+	}
+	dir := scan.pending[0]
+	size, files, dirs, err := scan.fsHandler.CountUsage(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	scan.pending = scan.pending[1:]
+	if err != nil {
+		// 扫描过程中被删除的目录
+		return nil
+	}
+	scan.dirs = append(scan.dirs, model.FsUsage{FsID: scan.fsID, Path: dir, Size: size, Files: files, Dirs: dirs})
+	scan.total.Size += size
+	scan.total.Files += files
+	scan.total.Dirs += dirs + 1
+	return nil
+}
+
+func (s *usageScanner) finish(logEntry *log.Entry) {
+	scan := s.current
+	s.current = nil
+	s.lastScan[scan.fsID] = scan.scanTime
+	// 扫描期间文件系统被删除
+	if _, err := storage.Filesystem.GetFileSystemWithFsID(scan.fsID); err != nil {
+		logEntry.Warningf("drop usage scan of fs[%s]: %v", scan.fsID, err)
+		return
+	}
+	usages := append([]model.FsUsage{scan.total}, scan.dirs...)
+	for i := range usages {
+		usages[i].ScanTime = scan.scanTime
+	}
+	if err := storage.FsUsage.CreateUsages(logEntry, usages); err != nil {
+		return
+	}
+	if err := storage.FsUsage.DeleteUsageBefore(logEntry, time.Now().Add(-usageRetention)); err != nil {
+		logEntry.Errorf("delete expired usage failed: %v", err)
+	}
+}
@@ -0,0 +1,106 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fs
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/handler"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage/driver"
+)
+
+func TestFsUsageScan(t *testing.T) {
+	driver.InitMockDB()
+	origin := handler.NewFsHandlerWithServer
+	handler.NewFsHandlerWithServer = handler.MockerNewFsHandlerWithServer
+	defer func() {
+		handler.NewFsHandlerWithServer = origin
+		os.RemoveAll(mockFsDir)
+	}()
+	fs := model.FileSystem{Model: model.Model{ID: mockFSID}, Name: mockFSName, UserName: mockRootName}
+	assert.NoError(t, storage.Filesystem.CreatFileSystem(&fs))
+	writeMockFsFile(t, "a.txt", make([]byte, 5), time.Time{})
+	writeMockFsFile(t, "dir1/b", make([]byte, 10), time.Time{})
+	writeMockFsFile(t, "dir1/sub/c", make([]byte, 3), time.Time{})
+	writeMockFsFile(t, "dir2/d", make([]byte, 20), time.Time{})
+
+	ctx := &logger.RequestContext{UserName: mockRootName}
+	_, err := GetFileSystemDu(ctx, fs, DefaultUsageGrowthDays)
+	assert.Error(t, err)
+	assert.Equal(t, common.RecordNotFound, ctx.ErrorCode)
+
+	scanner := &usageScanner{}
+	scanner.round(time.Now().Add(time.Minute))
+	assert.Nil(t, scanner.current)
+	resp, err := GetFileSystemDu(ctx, fs, DefaultUsageGrowthDays)
+	assert.NoError(t, err)
+	assert.Equal(t, FsUsageStat{Path: "/", Size: 38, Files: 4, Dirs: 3}, resp.Total)
+	assert.Equal(t, []FsUsageStat{
+		{Path: "/dir2", Size: 20, Files: 1},
+		{Path: "/dir1", Size: 13, Files: 2, Dirs: 1},
+	}, resp.Directories)
+
+	// 未到重新扫描的时间
+	scanner.round(time.Now().Add(time.Minute))
+	history, err := storage.FsUsage.ListUsageHistory(log.NewEntry(log.StandardLogger()), mockFSID, "/", time.Time{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(history))
+
+	// 一级目录逐个扫描，可以跨多轮完成
+	time.Sleep(2 * time.Millisecond)
+	writeMockFsFile(t, "dir2/e", make([]byte, 7), time.Time{})
+	writeMockFsFile(t, "dir3/f", make([]byte, 1), time.Time{})
+	scan, err := startUsageScan(log.NewEntry(log.StandardLogger()), mockFSID)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"/dir1", "/dir2", "/dir3"}, scan.pending)
+	assert.NoError(t, scan.scanNextDir())
+	assert.Equal(t, 2, len(scan.pending))
+	scanner.current = scan
+	scanner.round(time.Now().Add(time.Minute))
+	assert.Nil(t, scanner.current)
+
+	resp, err = GetFileSystemDu(ctx, fs, DefaultUsageGrowthDays)
+	assert.NoError(t, err)
+	assert.Equal(t, FsUsageStat{Path: "/", Size: 46, Files: 6, Dirs: 4, SizeGrowth: 8, FilesGrowth: 2}, resp.Total)
+	assert.Equal(t, FsUsageStat{Path: "/dir2", Size: 27, Files: 2, SizeGrowth: 7, FilesGrowth: 1}, resp.Directories[0])
+	assert.Equal(t, FsUsageStat{Path: "/dir3", Size: 1, Files: 1, SizeGrowth: 1, FilesGrowth: 1}, resp.Directories[2])
+	assert.Equal(t, 2, len(resp.History))
+	assert.True(t, resp.BaseTime.Before(resp.ScanTime))
+
+	list, err := ListFileSystemDu(ctx, "")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(list.FsList))
+	assert.Equal(t, mockFSName, list.FsList[0].FsName)
+	assert.Equal(t, int64(46), list.FsList[0].Size)
+	list, err = ListFileSystemDu(ctx, "other")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(list.FsList))
+
+	assert.NoError(t, storage.FsUsage.DeleteFsUsage(nil, mockFSID))
+	list, err = ListFileSystemDu(ctx, "")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(list.FsList))
+}
@@ -0,0 +1,503 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package imagebuild
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/config"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/uuid"
+	runtime "github.com/PaddlePaddle/PaddleFlow/pkg/job/runtime_v2"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+)
+
+const (
+	contextMountPath      = "/workspace"
+	dockerConfigMountPath = "/kaniko/.docker"
+
+	defaultBuilderImage  = "gcr.io/kaniko-project/executor:v1.9.1"
+	defaultInitImage     = "busybox:1.35"
+	defaultCheckInterval = 10 * time.Second
+	maxDockerfileSize    = 64 * 1024
+	buildLogTailLines    = 20
+
+	envDockerfile   = "PF_DOCKERFILE"
+	envRequirements = "PF_REQUIREMENTS"
+
+	labelImageBuildID = "paddleflow-image-build-id"
+)
+
+var (
+	// imageNameRegex 镜像仓库名称，小写字母数字以及.-_分隔，可以包含多级路径
+	imageNameRegex = regexp.MustCompile(`^[a-z0-9]+(?:[._-][a-z0-9]+)*(?:/[a-z0-9]+(?:[._-][a-z0-9]+)*)*$`)
+	imageTagRegex  = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]{0,127}$`)
+)
+
+type CreateImageBuildRequest struct {
+	// Name Tag 构建出的镜像名称及标签，镜像推送到{registry}/{userName}/{name}:{tag}，tag默认为构建任务ID
+	Name string `json:"name"`
+	Tag  string `json:"tag"`
+	// QueueName 构建pod运行在队列所在的集群及namespace中
+	QueueName string `json:"queueName"`
+	// Dockerfile 与BaseImage二选一，构建上下文中只有Dockerfile与requirements.txt
+	Dockerfile string `json:"dockerfile"`
+	BaseImage  string `json:"baseImage"`
+	// Requirements pip依赖，每个元素为requirements.txt中的一行
+	Requirements []string `json:"requirements"`
+}
+
+type CreateImageBuildResponse struct {
+	ID    string `json:"id"`
+	Image string `json:"image"`
+}
+
+type ListImageBuildResponse struct {
+	common.MarkerInfo
+	ImageBuildList []model.ImageBuild `json:"imageBuildList"`
+}
+
+// buildRuntime 镜像构建所需的集群操作
+type buildRuntime interface {
+	CreatePod(pod *corev1.Pod) error
+	DeletePod(namespace, name string) error
+	ListPods(namespace string, listOptions metav1.ListOptions) (*corev1.PodList, error)
+	GetPodLogTail(namespace, name string, tailLines int64) (string, error)
+}
+
+var getBuildRuntime = func(clusterID string) (buildRuntime, error) {
+	cluster, err := storage.Cluster.GetClusterById(clusterID)
+	if err != nil {
+		return nil, err
+	}
+	if cluster.ClusterType != schema.KubernetesType {
+		return nil, fmt.Errorf("image build is not supported on cluster[%s] with type[%s]", cluster.Name, cluster.ClusterType)
+	}
+	runtimeSvc, err := runtime.GetOrCreateRuntime(cluster)
+	if err != nil {
+		return nil, err
+	}
+	kubeRuntime, ok := runtimeSvc.(*runtime.KubeRuntime)
+	if !ok {
+		return nil, fmt.Errorf("runtime of cluster[%s] is not kubernetes runtime", cluster.Name)
+	}
+	return kubeRuntime, nil
+}
+
+func buildConfig() config.ImageBuildConfig {
+	if config.GlobalServerConfig == nil {
+		return config.ImageBuildConfig{}
+	}
+	return config.GlobalServerConfig.ImageBuild
+}
+
+// registry 镜像推送的仓库，未配置imageBuild.registry时使用imageRepository的配置
+func registry() string {
+	if conf := buildConfig(); conf.Registry != "" {
+		return strings.TrimSuffix(conf.Registry, "/")
+	}
+	if config.GlobalServerConfig == nil || config.GlobalServerConfig.ImageConf.Server == "" {
+		return ""
+	}
+	imageConf := config.GlobalServerConfig.ImageConf
+	if imageConf.Namespace == "" {
+		return strings.TrimSuffix(imageConf.Server, "/")
+	}
+	return strings.TrimSuffix(imageConf.Server, "/") + "/" + imageConf.Namespace
+}
+
+// CreateImageBuild 在队列所在集群中启动kaniko构建pod，构建完成后镜像被推送到仓库，返回的镜像地址可直接用于作业
+func CreateImageBuild(ctx *logger.RequestContext, request CreateImageBuildRequest) (CreateImageBuildResponse, error) {
+	ctx.Logging().Debugf("begin create image build: %+v", request)
+	build, err := newImageBuild(ctx, request)
+	if err != nil {
+		ctx.Logging().Errorf("create image build failed. error: %v", err)
+		return CreateImageBuildResponse{}, err
+	}
+	rt, err := getBuildRuntime(build.ClusterID)
+	if err != nil {
+		ctx.ErrorCode = common.InternalError
+		ctx.Logging().Errorf("get runtime of cluster[%s] failed. error: %v", build.ClusterID, err)
+		return CreateImageBuildResponse{}, err
+	}
+	if err := storage.ImageBuild.CreateImageBuild(ctx.Logging(), build); err != nil {
+		ctx.ErrorCode = common.InternalError
+		return CreateImageBuildResponse{}, err
+	}
+	if err := rt.CreatePod(buildPod(build)); err != nil {
+		ctx.ErrorCode = common.K8sOperatorError
+		ctx.Logging().Errorf("create image build pod[%s] failed. error: %v", build.PodName, err)
+		updateStatus(build.ID, model.ImageBuildStatusFailed, fmt.Sprintf("create pod failed: %v", err))
+		return CreateImageBuildResponse{}, err
+	}
+	ctx.Logging().Infof("image build[%s] created, image: %s", build.ID, build.Image)
+	return CreateImageBuildResponse{ID: build.ID, Image: build.Image}, nil
+}
+
+func newImageBuild(ctx *logger.RequestContext, request CreateImageBuildRequest) (*model.ImageBuild, error) {
+	if request.Name == "" || request.QueueName == "" {
+		ctx.ErrorCode = common.RequiredFieldEmpty
+		return nil, fmt.Errorf("name and queueName are required")
+	}
+	registry := registry()
+	if registry == "" {
+		ctx.ErrorCode = common.ActionNotAllowed
+		return nil, fmt.Errorf("image build is disabled, registry is not configured")
+	}
+	if err := validateImageBuild(&request); err != nil {
+		ctx.ErrorCode = common.InvalidArguments
+		return nil, err
+	}
+	queue, err := storage.Queue.GetQueueByName(request.QueueName)
+	if err != nil {
+		ctx.ErrorCode = common.QueueNameNotFound
+		return nil, fmt.Errorf("queue[%s] not found", request.QueueName)
+	}
+	if !storage.Auth.HasAccessToResource(ctx, common.ResourceTypeQueue, queue.Name) {
+		ctx.ErrorCode = common.AccessDenied
+		return nil, common.NoAccessError(ctx.UserName, common.ResourceTypeQueue, queue.Name)
+	}
+
+	build := &model.ImageBuild{
+		ID:           uuid.GenerateID(common.PrefixImageBuild),
+		UserName:     ctx.UserName,
+		Name:         request.Name,
+		Tag:          request.Tag,
+		QueueName:    queue.Name,
+		ClusterID:    queue.ClusterId,
+		Namespace:    queue.Namespace,
+		Dockerfile:   request.Dockerfile,
+		BaseImage:    request.BaseImage,
+		Requirements: request.Requirements,
+		Status:       model.ImageBuildStatusPending,
+	}
+	if build.Tag == "" {
+		build.Tag = build.ID
+	}
+	build.Image = fmt.Sprintf("%s/%s/%s:%s", registry, strings.ToLower(ctx.UserName), build.Name, build.Tag)
+	build.PodName = build.ID
+	return build, nil
+}
+
+func validateImageBuild(request *CreateImageBuildRequest) error {
+	if !imageNameRegex.MatchString(request.Name) {
+		return fmt.Errorf("name[%s] is invalid, it should consist of lower case alphanumeric characters and separators", request.Name)
+	}
+	if request.Tag != "" && !imageTagRegex.MatchString(request.Tag) {
+		return fmt.Errorf("tag[%s] is invalid", request.Tag)
+	}
+	if (request.Dockerfile == "") == (request.BaseImage == "") {
+		return fmt.Errorf("one and only one of dockerfile and baseImage should be specified")
+	}
+	if strings.ContainsAny(request.BaseImage, " \t\r\n") {
+		return fmt.Errorf("baseImage[%s] is invalid", request.BaseImage)
+	}
+	if len(request.Dockerfile) > maxDockerfileSize {
+		return fmt.Errorf("dockerfile exceeds %d bytes", maxDockerfileSize)
+	}
+	for _, requirement := range request.Requirements {
+		if strings.TrimSpace(requirement) == "" || strings.ContainsAny(requirement, "\r\n") {
+			return fmt.Errorf("requirement[%s] is invalid, each requirement should be a single non-empty line", requirement)
+		}
+	}
+	return nil
+}
+
+// generateDockerfile 指定基础镜像时生成Dockerfile，在基础镜像上安装pip依赖
+func generateDockerfile(build *model.ImageBuild) string {
+	if build.Dockerfile != "" {
+		return build.Dockerfile
+	}
+	lines := []string{"FROM " + build.BaseImage}
+	if len(build.Requirements) != 0 {
+		lines = append(lines,
+			"COPY requirements.txt /tmp/requirements.txt",
+			"RUN pip install --no-cache-dir -r /tmp/requirements.txt && rm -f /tmp/requirements.txt")
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// builderArgs kaniko的参数，构建上下文由init容器写入emptyDir
+func builderArgs(build *model.ImageBuild) []string {
+	args := []string{
+		"--dockerfile=" + contextMountPath + "/Dockerfile",
+		"--context=dir://" + contextMountPath,
+		"--destination=" + build.Image,
+	}
+	if buildConfig().Insecure {
+		args = append(args, "--insecure", "--skip-tls-verify")
+	}
+	return args
+}
+
+func buildPod(build *model.ImageBuild) *corev1.Pod {
+	conf := buildConfig()
+	builderImage, initImage := conf.BuilderImage, conf.InitImage
+	if builderImage == "" {
+		builderImage = defaultBuilderImage
+	}
+	if initImage == "" {
+		initImage = defaultInitImage
+	}
+	contextVolume := corev1.Volume{
+		Name:         "context",
+		VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+	}
+	// 通过环境变量传入Dockerfile与依赖，避免经过shell解析
+	prepareCommand := fmt.Sprintf(`printf '%%s' "$%s" > %s/Dockerfile && printf '%%s\n' "$%s" > %s/requirements.txt`,
+		envDockerfile, contextMountPath, envRequirements, contextMountPath)
+	builder := corev1.Container{
+		Name:         "builder",
+		Image:        builderImage,
+		Args:         builderArgs(build),
+		VolumeMounts: []corev1.VolumeMount{{Name: contextVolume.Name, MountPath: contextMountPath}},
+	}
+	volumes := []corev1.Volume{contextVolume}
+	if conf.PushSecret != "" {
+		volumes = append(volumes, corev1.Volume{
+			Name: "docker-config",
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: conf.PushSecret,
+					Items:      []corev1.KeyToPath{{Key: corev1.DockerConfigJsonKey, Path: "config.json"}},
+				},
+			},
+		})
+		builder.VolumeMounts = append(builder.VolumeMounts,
+			corev1.VolumeMount{Name: "docker-config", MountPath: dockerConfigMountPath, ReadOnly: true})
+	}
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      build.PodName,
+			Namespace: build.Namespace,
+			Labels:    map[string]string{labelImageBuildID: build.ID},
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy: corev1.RestartPolicyNever,
+			InitContainers: []corev1.Container{
+				{
+					Name:    "prepare-context",
+					Image:   initImage,
+					Command: []string{"sh", "-c", prepareCommand},
+					Env: []corev1.EnvVar{
+						{Name: envDockerfile, Value: generateDockerfile(build)},
+						{Name: envRequirements, Value: strings.Join(build.Requirements, "\n")},
+					},
+					VolumeMounts: []corev1.VolumeMount{{Name: contextVolume.Name, MountPath: contextMountPath}},
+				},
+			},
+			Containers: []corev1.Container{builder},
+			Volumes:    volumes,
+		},
+	}
+}
+
+func updateStatus(id, status, message string) {
+	err := storage.ImageBuild.UpdateImageBuild(log.NewEntry(log.StandardLogger()), id, &model.ImageBuild{
+		Status:  status,
+		Message: message,
+	})
+	if err != nil {
+		log.Errorf("update image build[%s] failed. error: %v", id, err)
+	}
+}
+
+func GetImageBuild(ctx *logger.RequestContext, id string) (model.ImageBuild, error) {
+	build, err := storage.ImageBuild.GetImageBuild(ctx.Logging(), id)
+	if err != nil {
+		ctx.ErrorCode = common.RecordNotFound
+		return model.ImageBuild{}, common.NotFoundError(common.ResourceTypeImageBuild, id)
+	}
+	if err := common.CheckPermission(ctx.UserName, build.UserName, common.ResourceTypeImageBuild, id); err != nil {
+		ctx.ErrorCode = common.AccessDenied
+		return model.ImageBuild{}, err
+	}
+	return build, nil
+}
+
+func ListImageBuild(ctx *logger.RequestContext, marker string, maxKeys int, name string) (ListImageBuildResponse, error) {
+	response := ListImageBuildResponse{ImageBuildList: []model.ImageBuild{}}
+	var pk int64
+	var err error
+	if marker != "" {
+		pk, err = common.DecryptPk(marker)
+		if err != nil {
+			ctx.ErrorCode = common.InvalidMarker
+			ctx.Logging().Errorf("DecryptPk marker[%s] failed. err:[%s]", marker, err.Error())
+			return response, err
+		}
+	}
+	// 多查询一条，用于判断是否还有下一页
+	builds, err := storage.ImageBuild.ListImageBuild(ctx.Logging(), pk, maxKeys+1, ctx.UserName, name)
+	if err != nil {
+		ctx.ErrorCode = common.InternalError
+		return response, err
+	}
+	if len(builds) > maxKeys {
+		builds = builds[:maxKeys]
+		nextMarker, err := common.EncryptPk(builds[len(builds)-1].Pk)
+		if err != nil {
+			ctx.ErrorCode = common.InternalError
+			return response, err
+		}
+		response.IsTruncated = true
+		response.NextMarker = nextMarker
+	}
+	response.MaxKeys = maxKeys
+	response.ImageBuildList = append(response.ImageBuildList, builds...)
+	return response, nil
+}
+
+func deleteBuildPod(build model.ImageBuild) error {
+	rt, err := getBuildRuntime(build.ClusterID)
+	if err != nil {
+		return err
+	}
+	if err := rt.DeletePod(build.Namespace, build.PodName); err != nil && !k8serrors.IsNotFound(err) {
+		log.Errorf("delete pod[%s] of image build[%s] failed. error: %v", build.PodName, build.ID, err)
+		return err
+	}
+	return nil
+}
+
+func isFinished(status string) bool {
+	return status != model.ImageBuildStatusPending && status != model.ImageBuildStatusRunning
+}
+
+// StopImageBuild 删除构建pod，未完成的构建被终止
+func StopImageBuild(ctx *logger.RequestContext, id string) error {
+	build, err := GetImageBuild(ctx, id)
+	if err != nil {
+		return err
+	}
+	if isFinished(build.Status) {
+		ctx.ErrorCode = common.ActionNotAllowed
+		return fmt.Errorf("image build[%s] with status[%s] can not be stopped", id, build.Status)
+	}
+	if err := deleteBuildPod(build); err != nil {
+		ctx.ErrorCode = common.K8sOperatorError
+		return err
+	}
+	if err := storage.ImageBuild.UpdateImageBuild(ctx.Logging(), id, &model.ImageBuild{
+		Status:  model.ImageBuildStatusTerminated,
+		Message: "stopped by " + ctx.UserName,
+	}); err != nil {
+		ctx.ErrorCode = common.InternalError
+		return err
+	}
+	return nil
+}
+
+// DeleteImageBuild 删除构建pod及记录，已推送到仓库的镜像不受影响
+func DeleteImageBuild(ctx *logger.RequestContext, id string) error {
+	build, err := GetImageBuild(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := deleteBuildPod(build); err != nil {
+		ctx.ErrorCode = common.K8sOperatorError
+		return err
+	}
+	if err := storage.ImageBuild.DeleteImageBuild(ctx.Logging(), id); err != nil {
+		ctx.ErrorCode = common.InternalError
+		return err
+	}
+	return nil
+}
+
+// Controller 定期根据构建pod的状态更新构建任务
+func Controller(stopChan chan struct{}) {
+	interval := defaultCheckInterval
+	if conf := buildConfig(); conf.CheckIntervalSeconds > 0 {
+		interval = time.Duration(conf.CheckIntervalSeconds) * time.Second
+	}
+	for {
+		syncImageBuilds()
+		select {
+		case <-stopChan:
+			log.Info("image build controller stopped")
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+func syncImageBuilds() {
+	logEntry := log.NewEntry(log.StandardLogger())
+	builds, err := storage.ImageBuild.ListImageBuildWithStatus(logEntry, model.ImageBuildStatusPending, model.ImageBuildStatusRunning)
+	if err != nil {
+		log.Errorf("list unfinished image build failed. error: %v", err)
+		return
+	}
+	for i := range builds {
+		if err := syncImageBuild(&builds[i]); err != nil {
+			log.Errorf("sync image build[%s] failed. error: %v", builds[i].ID, err)
+		}
+	}
+}
+
+func syncImageBuild(build *model.ImageBuild) error {
+	rt, err := getBuildRuntime(build.ClusterID)
+	if err != nil {
+		return err
+	}
+	pods, err := rt.ListPods(build.Namespace, metav1.ListOptions{LabelSelector: labelImageBuildID + "=" + build.ID})
+	if err != nil {
+		return err
+	}
+	var pod *corev1.Pod
+	for i := range pods.Items {
+		if pods.Items[i].Name == build.PodName {
+			pod = &pods.Items[i]
+		}
+	}
+
+	update := &model.ImageBuild{}
+	switch {
+	case pod == nil:
+		update.Status = model.ImageBuildStatusFailed
+		update.Message = fmt.Sprintf("pod[%s] not found", build.PodName)
+	case pod.Status.Phase == corev1.PodPending:
+		return nil
+	case pod.Status.Phase == corev1.PodSucceeded:
+		update.Status = model.ImageBuildStatusSucceeded
+	case pod.Status.Phase == corev1.PodFailed:
+		update.Status = model.ImageBuildStatusFailed
+		update.Message = fmt.Sprintf("pod[%s] failed", pod.Name)
+		// 构建失败时保留最后几行日志，便于定位Dockerfile或依赖的问题
+		if logs, err := rt.GetPodLogTail(build.Namespace, pod.Name, buildLogTailLines); err == nil && logs != "" {
+			update.Message = fmt.Sprintf("%s, logs:\n%s", update.Message, logs)
+		}
+	default:
+		update.Status = model.ImageBuildStatusRunning
+	}
+	if update.Status == build.Status {
+		return nil
+	}
+	return storage.ImageBuild.UpdateImageBuild(log.NewEntry(log.StandardLogger()), build.ID, update)
+}
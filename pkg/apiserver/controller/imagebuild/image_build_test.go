@@ -0,0 +1,209 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package imagebuild
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/config"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage/driver"
+)
+
+type fakeRuntime struct {
+	pods map[string]*corev1.Pod
+	logs map[string]string
+}
+
+func (f *fakeRuntime) CreatePod(pod *corev1.Pod) error {
+	f.pods[pod.Name] = pod
+	return nil
+}
+
+func (f *fakeRuntime) DeletePod(namespace, name string) error {
+	delete(f.pods, name)
+	return nil
+}
+
+func (f *fakeRuntime) ListPods(namespace string, listOptions metav1.ListOptions) (*corev1.PodList, error) {
+	podList := &corev1.PodList{}
+	for _, pod := range f.pods {
+		podList.Items = append(podList.Items, *pod)
+	}
+	return podList, nil
+}
+
+func (f *fakeRuntime) GetPodLogTail(namespace, name string, tailLines int64) (string, error) {
+	return f.logs[name], nil
+}
+
+func initImageBuildTest(t *testing.T) *fakeRuntime {
+	driver.InitMockDB()
+	config.GlobalServerConfig = &config.ServerConfig{}
+	config.GlobalServerConfig.ImageBuild = config.ImageBuildConfig{
+		Registry:   "registry.example.com/paddleflow/",
+		PushSecret: "registry-secret",
+	}
+
+	cluster := model.ClusterInfo{
+		Model:       model.Model{ID: "cluster-000001"},
+		Name:        "cluster-000001",
+		ClusterType: schema.KubernetesType,
+	}
+	assert.Nil(t, storage.Cluster.CreateCluster(&cluster))
+	queue := model.Queue{
+		Model:     model.Model{ID: "queue-000001"},
+		Name:      "queue-000001",
+		Namespace: "paddleflow",
+		ClusterId: cluster.ID,
+	}
+	assert.Nil(t, storage.Queue.CreateQueue(&queue))
+	ctx := &logger.RequestContext{UserName: "root"}
+	assert.Nil(t, storage.Auth.CreateGrant(ctx, &model.Grant{
+		ID: "grant-000001", UserName: "user1", ResourceType: "queue", ResourceID: queue.Name,
+	}))
+
+	rt := &fakeRuntime{pods: map[string]*corev1.Pod{}, logs: map[string]string{}}
+	getBuildRuntime = func(clusterID string) (buildRuntime, error) {
+		return rt, nil
+	}
+	return rt
+}
+
+func TestValidateImageBuild(t *testing.T) {
+	tests := []struct {
+		request CreateImageBuildRequest
+		wantErr bool
+	}{
+		{request: CreateImageBuildRequest{Name: "train/paddle", BaseImage: "python:3.9", Requirements: []string{"numpy==1.23.0"}}},
+		{request: CreateImageBuildRequest{Name: "train", Tag: "v1.0", Dockerfile: "FROM python:3.9\n"}},
+		{request: CreateImageBuildRequest{Name: "Train", BaseImage: "python:3.9"}, wantErr: true},
+		{request: CreateImageBuildRequest{Name: "train", Tag: "-v1", BaseImage: "python:3.9"}, wantErr: true},
+		{request: CreateImageBuildRequest{Name: "train"}, wantErr: true},
+		{request: CreateImageBuildRequest{Name: "train", BaseImage: "python:3.9", Dockerfile: "FROM python:3.9"}, wantErr: true},
+		{request: CreateImageBuildRequest{Name: "train", BaseImage: "python:3.9\nRUN id"}, wantErr: true},
+		{request: CreateImageBuildRequest{Name: "train", BaseImage: "python:3.9", Requirements: []string{"numpy\nRUN id"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		err := validateImageBuild(&tt.request)
+		if tt.wantErr {
+			assert.Error(t, err, tt.request)
+			continue
+		}
+		assert.NoError(t, err)
+	}
+}
+
+func TestGenerateDockerfile(t *testing.T) {
+	build := &model.ImageBuild{BaseImage: "python:3.9", Requirements: []string{"numpy"}}
+	assert.Equal(t, "FROM python:3.9\nCOPY requirements.txt /tmp/requirements.txt\n"+
+		"RUN pip install --no-cache-dir -r /tmp/requirements.txt && rm -f /tmp/requirements.txt\n", generateDockerfile(build))
+	build.Requirements = nil
+	assert.Equal(t, "FROM python:3.9\n", generateDockerfile(build))
+	build.Dockerfile = "FROM ubuntu:20.04"
+	assert.Equal(t, "FROM ubuntu:20.04", generateDockerfile(build))
+}
+
+func TestImageBuild(t *testing.T) {
+	rt := initImageBuildTest(t)
+	ctx := &logger.RequestContext{UserName: "user1"}
+
+	_, err := CreateImageBuild(&logger.RequestContext{UserName: "user2"}, CreateImageBuildRequest{
+		Name: "train", QueueName: "queue-000001", BaseImage: "python:3.9"})
+	assert.NotNil(t, err)
+
+	resp, err := CreateImageBuild(ctx, CreateImageBuildRequest{Name: "train", Tag: "v1", QueueName: "queue-000001",
+		BaseImage: "python:3.9", Requirements: []string{"numpy==1.23.0", "paddlepaddle"}})
+	assert.Nil(t, err)
+	assert.Equal(t, "registry.example.com/paddleflow/user1/train:v1", resp.Image)
+	pod := rt.pods[resp.ID]
+	assert.NotNil(t, pod)
+	assert.Equal(t, "paddleflow", pod.Namespace)
+	assert.Equal(t, defaultBuilderImage, pod.Spec.Containers[0].Image)
+	assert.Contains(t, pod.Spec.Containers[0].Args, "--destination=registry.example.com/paddleflow/user1/train:v1")
+	assert.Equal(t, "numpy==1.23.0\npaddlepaddle", pod.Spec.InitContainers[0].Env[1].Value)
+	assert.Equal(t, "registry-secret", pod.Spec.Volumes[1].Secret.SecretName)
+	assert.Equal(t, dockerConfigMountPath, pod.Spec.Containers[0].VolumeMounts[1].MountPath)
+
+	pod.Status.Phase = corev1.PodRunning
+	syncImageBuilds()
+	build, err := GetImageBuild(ctx, resp.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, model.ImageBuildStatusRunning, build.Status)
+	assert.Equal(t, []string{"numpy==1.23.0", "paddlepaddle"}, build.Requirements)
+
+	pod.Status.Phase = corev1.PodSucceeded
+	syncImageBuilds()
+	build, err = GetImageBuild(ctx, resp.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, model.ImageBuildStatusSucceeded, build.Status)
+	assert.NotNil(t, StopImageBuild(ctx, resp.ID))
+
+	// 构建失败时记录构建日志
+	resp2, err := CreateImageBuild(ctx, CreateImageBuildRequest{Name: "infer", QueueName: "queue-000001",
+		Dockerfile: "FROM python:3.9\nRUN exit 1\n"})
+	assert.Nil(t, err)
+	assert.Equal(t, "registry.example.com/paddleflow/user1/infer:"+resp2.ID, resp2.Image)
+	rt.pods[resp2.ID].Status.Phase = corev1.PodFailed
+	rt.logs[resp2.ID] = "error building image: RUN exit 1"
+	syncImageBuilds()
+	build, err = GetImageBuild(ctx, resp2.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, model.ImageBuildStatusFailed, build.Status)
+	assert.Contains(t, build.Message, "RUN exit 1")
+
+	listResp, err := ListImageBuild(ctx, "", 1, "")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(listResp.ImageBuildList))
+	assert.True(t, listResp.IsTruncated)
+	_, err = GetImageBuild(&logger.RequestContext{UserName: "user2"}, resp.ID)
+	assert.NotNil(t, err)
+
+	assert.Nil(t, DeleteImageBuild(ctx, resp.ID))
+	assert.Nil(t, rt.pods[resp.ID])
+	_, err = GetImageBuild(ctx, resp.ID)
+	assert.NotNil(t, err)
+}
+
+func TestStopImageBuild(t *testing.T) {
+	rt := initImageBuildTest(t)
+	ctx := &logger.RequestContext{UserName: "user1"}
+
+	resp, err := CreateImageBuild(ctx, CreateImageBuildRequest{Name: "train", QueueName: "queue-000001", BaseImage: "python:3.9"})
+	assert.Nil(t, err)
+	assert.Nil(t, StopImageBuild(ctx, resp.ID))
+	assert.Equal(t, 0, len(rt.pods))
+	build, err := GetImageBuild(ctx, resp.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, model.ImageBuildStatusTerminated, build.Status)
+
+	// 未配置仓库时不能构建
+	config.GlobalServerConfig.ImageBuild.Registry = ""
+	_, err = CreateImageBuild(ctx, CreateImageBuildRequest{Name: "train", QueueName: "queue-000001", BaseImage: "python:3.9"})
+	assert.NotNil(t, err)
+	config.GlobalServerConfig.ImageConf.Server = "registry.example.com"
+	resp, err = CreateImageBuild(ctx, CreateImageBuildRequest{Name: "train", QueueName: "queue-000001", BaseImage: "python:3.9"})
+	assert.Nil(t, err)
+	assert.Equal(t, "registry.example.com/user1/train:"+resp.ID, resp.Image)
+}
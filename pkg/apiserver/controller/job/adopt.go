@@ -0,0 +1,263 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package job
+
+import (
+	"encoding/json"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	k8sschema "k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/k8s"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	runtime "github.com/PaddlePaddle/PaddleFlow/pkg/job/runtime_v2"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+)
+
+const (
+	AdoptKindPaddleJob  = "PaddleJob"
+	AdoptKindTFJob      = "TFJob"
+	AdoptKindDeployment = "Deployment"
+
+	// maxAdoptJobIDLength 工作负载名称作为作业ID，不能超过job表id字段的长度
+	maxAdoptJobIDLength = 60
+)
+
+// adoptKind 可纳管的工作负载类型及其对应的作业类型
+type adoptKind struct {
+	gvk       k8sschema.GroupVersionKind
+	jobType   schema.JobType
+	framework schema.Framework
+}
+
+var adoptKinds = map[string]adoptKind{
+	AdoptKindPaddleJob:  {gvk: k8s.PaddleJobGVK, jobType: schema.TypeDistributed, framework: schema.FrameworkPaddle},
+	AdoptKindTFJob:      {gvk: k8s.TFJobGVK, jobType: schema.TypeDistributed, framework: schema.FrameworkTF},
+	AdoptKindDeployment: {gvk: k8s.DeploymentGVK, jobType: schema.TypeServing, framework: schema.FrameworkStandalone},
+}
+
+// AdoptJobsRequest 纳管队列所在namespace中匹配标签选择器的已有工作负载
+type AdoptJobsRequest struct {
+	Queue string `json:"queue"`
+	// Kinds 扫描的工作负载类型，可选PaddleJob、TFJob和Deployment，为空时扫描所有类型
+	Kinds         []string `json:"kinds"`
+	LabelSelector string   `json:"labelSelector"`
+	// UserName 纳管后作业的所属用户，为空时为root
+	UserName string `json:"userName"`
+	// DryRun 只返回会被纳管的工作负载，不创建作业
+	DryRun bool `json:"dryRun"`
+}
+
+type AdoptedWorkload struct {
+	Kind   string           `json:"kind"`
+	Name   string           `json:"name"`
+	JobID  string           `json:"jobID,omitempty"`
+	Status schema.JobStatus `json:"status,omitempty"`
+	// Reason 未被纳管的原因
+	Reason string `json:"reason,omitempty"`
+}
+
+type AdoptJobsResponse struct {
+	Namespace string            `json:"namespace"`
+	Adopted   []AdoptedWorkload `json:"adopted"`
+	Skipped   []AdoptedWorkload `json:"skipped"`
+}
+
+// adoptRuntime 纳管集群中已有工作负载所需的集群操作
+type adoptRuntime interface {
+	ListObjects(namespace string, gvk k8sschema.GroupVersionKind, listOptions metav1.ListOptions) ([]unstructured.Unstructured, error)
+	PatchObject(namespace, name string, gvk k8sschema.GroupVersionKind, data []byte) error
+}
+
+var getAdoptRuntime = func(clusterInfo model.ClusterInfo) (adoptRuntime, error) {
+	runtimeSvc, err := runtime.GetOrCreateRuntime(clusterInfo)
+	if err != nil {
+		return nil, err
+	}
+	kubeRuntime, ok := runtimeSvc.(*runtime.KubeRuntime)
+	if !ok {
+		return nil, fmt.Errorf("runtime of cluster[%s] does not support adopting workloads", clusterInfo.Name)
+	}
+	return kubeRuntime, nil
+}
+
+// AdoptJobs 把namespace中已有的工作负载导入为作业，并为工作负载添加PaddleFlow标签，之后由作业同步更新作业状态
+func AdoptJobs(ctx *logger.RequestContext, request AdoptJobsRequest) (*AdoptJobsResponse, error) {
+	if !common.IsRootUser(ctx.UserName) {
+		ctx.ErrorCode = common.OnlyRootAllowed
+		err := fmt.Errorf("only root user can adopt workloads")
+		ctx.Logging().Errorln(err.Error())
+		return nil, err
+	}
+	kinds, err := validateAdoptRequest(ctx, &request)
+	if err != nil {
+		ctx.Logging().Errorf("validate adopt request failed, err: %v", err)
+		return nil, err
+	}
+	queue, err := storage.Queue.GetQueueByName(request.Queue)
+	if err != nil {
+		ctx.ErrorCode = common.QueueNameNotFound
+		ctx.Logging().Errorf("get queue %s failed, err: %v", request.Queue, err)
+		return nil, err
+	}
+	clusterInfo, err := storage.Cluster.GetClusterById(queue.ClusterId)
+	if err != nil {
+		ctx.ErrorCode = common.ClusterNotFound
+		ctx.Logging().Errorf("get cluster of queue %s failed, err: %v", queue.Name, err)
+		return nil, err
+	}
+	rt, err := getAdoptRuntime(clusterInfo)
+	if err != nil {
+		ctx.ErrorCode = common.InternalError
+		ctx.Logging().Errorf("get runtime of cluster %s failed, err: %v", clusterInfo.Name, err)
+		return nil, err
+	}
+
+	response := &AdoptJobsResponse{
+		Namespace: queue.Namespace,
+		Adopted:   []AdoptedWorkload{},
+		Skipped:   []AdoptedWorkload{},
+	}
+	for _, kind := range kinds {
+		objects, err := rt.ListObjects(queue.Namespace, adoptKinds[kind].gvk, metav1.ListOptions{LabelSelector: request.LabelSelector})
+		if err != nil {
+			ctx.ErrorCode = common.InternalError
+			ctx.Logging().Errorf("list %s in namespace %s failed, err: %v", kind, queue.Namespace, err)
+			return nil, err
+		}
+		for i := range objects {
+			workload := adoptWorkload(ctx, rt, &queue, kind, &objects[i], request)
+			if workload.Reason != "" {
+				response.Skipped = append(response.Skipped, workload)
+			} else {
+				response.Adopted = append(response.Adopted, workload)
+			}
+		}
+	}
+	ctx.Logging().Infof("%d workloads adopted and %d skipped in namespace %s", len(response.Adopted),
+		len(response.Skipped), queue.Namespace)
+	return response, nil
+}
+
+func validateAdoptRequest(ctx *logger.RequestContext, request *AdoptJobsRequest) ([]string, error) {
+	if request.Queue == "" || request.LabelSelector == "" {
+		ctx.ErrorCode = common.RequiredFieldEmpty
+		return nil, fmt.Errorf("queue and labelSelector are required")
+	}
+	if _, err := labels.Parse(request.LabelSelector); err != nil {
+		ctx.ErrorCode = common.InvalidArguments
+		return nil, fmt.Errorf("labelSelector %s is invalid, err: %v", request.LabelSelector, err)
+	}
+	kinds := request.Kinds
+	if len(kinds) == 0 {
+		kinds = []string{AdoptKindPaddleJob, AdoptKindTFJob, AdoptKindDeployment}
+	}
+	for _, kind := range kinds {
+		if _, ok := adoptKinds[kind]; !ok {
+			ctx.ErrorCode = common.InvalidArguments
+			return nil, fmt.Errorf("kind %s cannot be adopted, must be %s, %s or %s", kind,
+				AdoptKindPaddleJob, AdoptKindTFJob, AdoptKindDeployment)
+		}
+	}
+	if request.UserName == "" {
+		request.UserName = common.UserRoot
+	} else if _, err := storage.Auth.GetUserByName(ctx, request.UserName); err != nil {
+		ctx.ErrorCode = common.UserNotExist
+		return nil, fmt.Errorf("user %s does not exist", request.UserName)
+	}
+	return kinds, nil
+}
+
+// adoptWorkload 为工作负载创建作业记录并添加标签，返回的Reason不为空时表示未纳管
+func adoptWorkload(ctx *logger.RequestContext, rt adoptRuntime, queue *model.Queue, kind string,
+	obj *unstructured.Unstructured, request AdoptJobsRequest) AdoptedWorkload {
+	name := obj.GetName()
+	workload := AdoptedWorkload{Kind: kind, Name: name}
+	if obj.GetLabels()[schema.JobOwnerLabel] == schema.JobOwnerValue {
+		workload.Reason = "workload is already managed by PaddleFlow"
+		return workload
+	}
+	if len(name) > maxAdoptJobIDLength {
+		workload.Reason = fmt.Sprintf("name is longer than %d characters", maxAdoptJobIDLength)
+		return workload
+	}
+	if _, err := storage.Job.GetUnscopedJobByID(name); err == nil {
+		workload.Reason = fmt.Sprintf("job %s already exists", name)
+		return workload
+	}
+	k := adoptKinds[kind]
+	workload.JobID = name
+	workload.Status = schema.StatusJobPending
+	if getStatus, ok := k8s.GVKJobStatusMap[k.gvk]; ok {
+		if statusInfo, err := getStatus(obj); err == nil && statusInfo.Status != "" {
+			workload.Status = statusInfo.Status
+		}
+	}
+	if request.DryRun {
+		return workload
+	}
+
+	conf := &schema.Conf{Name: name}
+	conf.SetQueueID(queue.ID)
+	conf.SetQueueName(queue.Name)
+	conf.SetClusterID(queue.ClusterId)
+	conf.SetNamespace(queue.Namespace)
+	conf.SetUserName(request.UserName)
+	job := &model.Job{
+		ID:        name,
+		Name:      name,
+		UserName:  request.UserName,
+		QueueID:   queue.ID,
+		Type:      string(k.jobType),
+		Framework: k.framework,
+		Status:    workload.Status,
+		Message:   fmt.Sprintf("adopted from %s %s/%s", kind, queue.Namespace, name),
+		Config:    conf,
+	}
+	if err := storage.Job.CreateJob(job); err != nil {
+		ctx.Logging().Errorf("create job for %s %s/%s failed, err: %v", kind, queue.Namespace, name, err)
+		workload.Reason = fmt.Sprintf("create job failed: %v", err)
+		return workload
+	}
+	// 添加标签后，作业同步会处理工作负载的更新事件并同步作业状态
+	patchJSON, _ := json.Marshal(struct {
+		metav1.ObjectMeta `json:"metadata,omitempty"`
+	}{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: map[string]string{
+				schema.JobOwnerLabel: schema.JobOwnerValue,
+				schema.JobIDLabel:    name,
+			},
+		},
+	})
+	if err := rt.PatchObject(queue.Namespace, name, k.gvk, patchJSON); err != nil {
+		ctx.Logging().Errorf("patch labels of %s %s/%s failed, err: %v", kind, queue.Namespace, name, err)
+		if err := storage.Job.DeleteJob(name); err != nil {
+			ctx.Logging().Errorf("delete job %s after patching labels failed, err: %v", name, err)
+		}
+		workload.Reason = fmt.Sprintf("add labels failed: %v", err)
+		return workload
+	}
+	ctx.Logging().Infof("%s %s/%s is adopted as job %s", kind, queue.Namespace, name, name)
+	return workload
+}
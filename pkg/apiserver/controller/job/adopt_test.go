@@ -0,0 +1,140 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package job
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8sschema "k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/k8s"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage/driver"
+)
+
+type fakeAdoptRuntime struct {
+	objects   map[k8sschema.GroupVersionKind][]unstructured.Unstructured
+	selectors []string
+	patched   map[string]string
+	patchErr  map[string]error
+}
+
+func (f *fakeAdoptRuntime) ListObjects(namespace string, gvk k8sschema.GroupVersionKind, listOptions metav1.ListOptions) ([]unstructured.Unstructured, error) {
+	f.selectors = append(f.selectors, listOptions.LabelSelector)
+	return f.objects[gvk], nil
+}
+
+func (f *fakeAdoptRuntime) PatchObject(namespace, name string, gvk k8sschema.GroupVersionKind, data []byte) error {
+	if err := f.patchErr[name]; err != nil {
+		return err
+	}
+	f.patched[name] = string(data)
+	return nil
+}
+
+func newAdoptObject(gvk k8sschema.GroupVersionKind, name string, labels map[string]string) unstructured.Unstructured {
+	obj := unstructured.Unstructured{}
+	obj.SetGroupVersionKind(gvk)
+	obj.SetNamespace("default")
+	obj.SetName(name)
+	obj.SetLabels(labels)
+	return obj
+}
+
+func TestAdoptJobs(t *testing.T) {
+	driver.InitMockDB()
+	cluster := model.ClusterInfo{Model: model.Model{ID: "cluster-1"}, Name: "cluster-1", ClusterType: schema.KubernetesType}
+	assert.NoError(t, storage.Cluster.CreateCluster(&cluster))
+	queue := model.Queue{Model: model.Model{ID: MockQueueID}, Name: MockQueueName, Namespace: "default",
+		ClusterId: cluster.ID, Status: schema.StatusQueueOpen}
+	assert.NoError(t, storage.Queue.CreateQueue(&queue))
+	assert.NoError(t, storage.Job.CreateJob(&model.Job{ID: "existed", QueueID: MockQueueID, Config: &schema.Conf{}}))
+
+	selector := map[string]string{"app": "train"}
+	rt := &fakeAdoptRuntime{
+		objects: map[k8sschema.GroupVersionKind][]unstructured.Unstructured{
+			k8s.PaddleJobGVK: {
+				newAdoptObject(k8s.PaddleJobGVK, "paddle-1", selector),
+				newAdoptObject(k8s.PaddleJobGVK, "existed", selector),
+				newAdoptObject(k8s.PaddleJobGVK, "managed", map[string]string{schema.JobOwnerLabel: schema.JobOwnerValue}),
+				newAdoptObject(k8s.PaddleJobGVK, strings.Repeat("a", 61), selector),
+			},
+			k8s.DeploymentGVK: {
+				newAdoptObject(k8s.DeploymentGVK, "serving-1", selector),
+				newAdoptObject(k8s.DeploymentGVK, "serving-2", selector),
+			},
+		},
+		patched:  map[string]string{},
+		patchErr: map[string]error{"serving-2": fmt.Errorf("forbidden")},
+	}
+	getAdoptRuntime = func(clusterInfo model.ClusterInfo) (adoptRuntime, error) {
+		return rt, nil
+	}
+
+	request := AdoptJobsRequest{Queue: MockQueueName, LabelSelector: "app=train"}
+	_, err := AdoptJobs(&logger.RequestContext{UserName: "user1"}, request)
+	assert.Error(t, err)
+	_, err = AdoptJobs(&logger.RequestContext{UserName: mockRootUser},
+		AdoptJobsRequest{Queue: MockQueueName, LabelSelector: "app in ("})
+	assert.Error(t, err)
+	_, err = AdoptJobs(&logger.RequestContext{UserName: mockRootUser},
+		AdoptJobsRequest{Queue: MockQueueName, LabelSelector: "app=train", Kinds: []string{"Pod"}})
+	assert.Error(t, err)
+
+	// dry run不创建作业
+	response, err := AdoptJobs(&logger.RequestContext{UserName: mockRootUser},
+		AdoptJobsRequest{Queue: MockQueueName, LabelSelector: "app=train", DryRun: true})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(response.Adopted))
+	assert.Equal(t, 3, len(response.Skipped))
+	_, err = storage.Job.GetJobByID("paddle-1")
+	assert.Error(t, err)
+
+	response, err = AdoptJobs(&logger.RequestContext{UserName: mockRootUser}, request)
+	assert.NoError(t, err)
+	assert.Equal(t, "default", response.Namespace)
+	assert.Equal(t, []string{"app=train", "app=train", "app=train"}, rt.selectors[len(rt.selectors)-3:])
+	assert.Equal(t, []AdoptedWorkload{
+		{Kind: AdoptKindPaddleJob, Name: "paddle-1", JobID: "paddle-1", Status: schema.StatusJobPending},
+		{Kind: AdoptKindDeployment, Name: "serving-1", JobID: "serving-1", Status: schema.StatusJobPending},
+	}, response.Adopted)
+	assert.Equal(t, 4, len(response.Skipped))
+
+	job, err := storage.Job.GetJobByID("paddle-1")
+	assert.NoError(t, err)
+	assert.Equal(t, string(schema.TypeDistributed), job.Type)
+	assert.Equal(t, schema.FrameworkPaddle, job.Framework)
+	assert.Equal(t, mockRootUser, job.UserName)
+	assert.Equal(t, MockQueueName, job.Config.GetQueueName())
+	assert.Equal(t, "default", job.Config.GetNamespace())
+	assert.Contains(t, rt.patched["paddle-1"], fmt.Sprintf("%q:%q", schema.JobIDLabel, "paddle-1"))
+
+	job, err = storage.Job.GetJobByID("serving-1")
+	assert.NoError(t, err)
+	assert.Equal(t, string(schema.TypeServing), job.Type)
+	// 添加标签失败时删除作业
+	_, err = storage.Job.GetJobByID("serving-2")
+	assert.Error(t, err)
+}
@@ -0,0 +1,153 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package job
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/config"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/job/hook"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+)
+
+const approvalNotifyTimeout = 10 * time.Second
+
+// ApprovalNotification 作业等待审批时发送给审批人webhook的内容
+type ApprovalNotification struct {
+	JobID     string `json:"jobID"`
+	JobName   string `json:"jobName"`
+	UserName  string `json:"username"`
+	QueueName string `json:"queueName"`
+	Reason    string `json:"reason"`
+}
+
+// needApproval 作业申请的资源超过审批阈值时返回原因
+func needApproval(job *model.Job) (string, bool) {
+	if config.GlobalServerConfig == nil {
+		return "", false
+	}
+	approval := config.GlobalServerConfig.Job.Approval
+	if approval.MaxGPUs > 0 {
+		if gpus := model.JobGPUs(job); gpus > approval.MaxGPUs {
+			return fmt.Sprintf("job requests %d gpus, more than %d gpus needs approval", gpus, approval.MaxGPUs), true
+		}
+	}
+	if approval.MaxCPU > 0 {
+		if cpu := jobResources(job).CPU(); int64(cpu) > approval.MaxCPU*1000 {
+			return fmt.Sprintf("job requests %s cpu, more than %d cpu needs approval", cpu.MilliString(), approval.MaxCPU), true
+		}
+	}
+	return "", false
+}
+
+// notifyApprovers 通知审批人作业等待审批
+func notifyApprovers(job *model.Job) {
+	if config.GlobalServerConfig == nil {
+		return
+	}
+	notification := ApprovalNotification{
+		JobID:    job.ID,
+		JobName:  job.Name,
+		UserName: job.UserName,
+		Reason:   job.Message,
+	}
+	if job.Config != nil {
+		notification.QueueName = job.Config.GetQueueName()
+	}
+	body, err := json.Marshal(notification)
+	if err != nil {
+		log.Errorf("marshal approval notification of job %s failed, err: %v", job.ID, err)
+		return
+	}
+	client := &http.Client{Timeout: approvalNotifyTimeout}
+	for _, url := range config.GlobalServerConfig.Job.Approval.Webhooks {
+		resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+		if err != nil {
+			log.Errorf("notify approvers of job %s to %s failed, err: %v", job.ID, url, err)
+			continue
+		}
+		resp.Body.Close()
+		if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+			log.Errorf("notify approvers of job %s to %s failed, response status code %d", job.ID, url, resp.StatusCode)
+		}
+	}
+}
+
+// ApproveJob 管理员审批通过后作业转为init状态，等待调度
+func ApproveJob(ctx *logger.RequestContext, jobID string) error {
+	job, err := getJobToReview(ctx, jobID)
+	if err != nil {
+		return err
+	}
+	msg := fmt.Sprintf("job is approved by %s", ctx.UserName)
+	if err = storage.Job.UpdateJobStatus(job.ID, msg, schema.StatusJobInit); err != nil {
+		ctx.ErrorCode = common.ErrorCodeOf(err, common.DBUpdateFailed)
+		ctx.Logging().Errorf("approve job %s failed, err: %v", jobID, err)
+		return err
+	}
+	ctx.Logging().Infof("job %s is approved by %s", jobID, ctx.UserName)
+	return nil
+}
+
+// RejectJob 管理员拒绝后作业置为failed
+func RejectJob(ctx *logger.RequestContext, jobID string) error {
+	job, err := getJobToReview(ctx, jobID)
+	if err != nil {
+		return err
+	}
+	msg := fmt.Sprintf("job is rejected by %s", ctx.UserName)
+	if err = storage.Job.UpdateJobStatus(job.ID, msg, schema.StatusJobFailed); err != nil {
+		ctx.ErrorCode = common.ErrorCodeOf(err, common.DBUpdateFailed)
+		ctx.Logging().Errorf("reject job %s failed, err: %v", jobID, err)
+		return err
+	}
+	hook.NotifyJobFinished(job.ID, job.Status, schema.StatusJobFailed)
+	ctx.Logging().Infof("job %s is rejected by %s", jobID, ctx.UserName)
+	return nil
+}
+
+func getJobToReview(ctx *logger.RequestContext, jobID string) (*model.Job, error) {
+	if !common.IsRootUser(ctx.UserName) {
+		ctx.ErrorCode = common.OnlyRootAllowed
+		err := fmt.Errorf("only root user can review jobs")
+		ctx.Logging().Errorln(err.Error())
+		return nil, err
+	}
+	job, err := storage.Job.GetJobByID(jobID)
+	if err != nil {
+		ctx.ErrorCode = jobErrorCode(err, common.JobNotFound)
+		ctx.Logging().Errorf("get job %s from database failed, err: %v", jobID, err)
+		return nil, common.NewServiceError(ctx.ErrorCode, err.Error(), map[string]string{"jobID": jobID})
+	}
+	if job.Status != schema.StatusJobPendingApproval {
+		ctx.ErrorCode = common.ActionNotAllowed
+		err = fmt.Errorf("job %s status is %s, only job waiting for approval can be reviewed", jobID, job.Status)
+		ctx.Logging().Errorln(err.Error())
+		return nil, err
+	}
+	return &job, nil
+}
@@ -0,0 +1,205 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package job
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/controller/dataset"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/handler"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+)
+
+const (
+	defaultArtifactCollectInterval = 30 * time.Second
+	// artifactCollectBatchSize 每轮最多检查的待收集产物个数
+	artifactCollectBatchSize = 100
+)
+
+type ListJobArtifactsResponse struct {
+	JobID     string              `json:"jobID"`
+	Artifacts []model.JobArtifact `json:"artifacts"`
+}
+
+// buildJobArtifacts 将作业各成员声明的输出产物转换为待收集的记录，产物路径换算为存储中的路径
+func buildJobArtifacts(job *model.Job) ([]model.JobArtifact, error) {
+	confs := make([]schema.Conf, 0, len(job.Members))
+	for _, member := range job.Members {
+		confs = append(confs, member.Conf)
+	}
+	if len(confs) == 0 && job.Config != nil {
+		confs = append(confs, *job.Config)
+	}
+
+	var artifacts []model.JobArtifact
+	names := make(map[string]bool)
+	for _, conf := range confs {
+		for _, output := range conf.GetOutputArtifacts() {
+			if names[output.Name] {
+				return nil, fmt.Errorf("output artifact name %s is duplicated", output.Name)
+			}
+			names[output.Name] = true
+			var fsMounted *schema.FileSystem
+			for _, fs := range conf.GetAllFileSystem() {
+				if fs.Name == output.FsName {
+					fsMounted = &fs
+					break
+				}
+			}
+			if fsMounted == nil {
+				return nil, fmt.Errorf("fs %s of output artifact %s is not mounted by job", output.FsName, output.Name)
+			}
+			artifacts = append(artifacts, model.JobArtifact{
+				JobID:  job.ID,
+				Name:   output.Name,
+				FsID:   fsMounted.ID,
+				FsName: fsMounted.Name,
+				Path:   path.Join("/", fsMounted.SubPath, output.Path),
+				Status: model.JobArtifactPending,
+			})
+		}
+	}
+	return artifacts, nil
+}
+
+// ListJobArtifacts 返回作业的输出产物，产物的fsName与path可作为流水线或其他作业的输入
+func ListJobArtifacts(ctx *logger.RequestContext, jobID string) (*ListJobArtifactsResponse, error) {
+	job, err := storage.Job.GetJobByID(jobID)
+	if err != nil {
+		ctx.ErrorCode = jobErrorCode(err, common.JobNotFound)
+		ctx.Logging().Errorln(err.Error())
+		msg := err.Error()
+		if ctx.ErrorCode == common.JobNotFound {
+			msg = common.NotFoundError(common.ResourceTypeJob, jobID).Error()
+		}
+		return nil, common.NewServiceError(ctx.ErrorCode, msg, map[string]string{"jobID": jobID})
+	}
+	if err = common.CheckPermission(ctx.UserName, job.UserName, common.ResourceTypeJob, job.ID); err != nil {
+		ctx.ErrorCode = common.ActionNotAllowed
+		ctx.Logging().Errorln(err.Error())
+		return nil, err
+	}
+	artifacts, err := storage.JobArtifact.ListJobArtifacts(ctx.Logging(), jobID)
+	if err != nil {
+		ctx.ErrorCode = common.InternalError
+		return nil, err
+	}
+	return &ListJobArtifactsResponse{
+		JobID:     jobID,
+		Artifacts: artifacts,
+	}, nil
+}
+
+// JobArtifactController 定期收集已结束作业的输出产物
+func JobArtifactController(stopChan chan struct{}) {
+	for {
+		collectJobArtifacts()
+		select {
+		case <-stopChan:
+			log.Info("job artifact controller stopped")
+			return
+		case <-time.After(defaultArtifactCollectInterval):
+		}
+	}
+}
+
+func collectJobArtifacts() {
+	logEntry := log.WithField("controller", "jobArtifact")
+	artifacts, err := storage.JobArtifact.ListJobArtifactsByStatus(logEntry, model.JobArtifactPending, artifactCollectBatchSize)
+	if err != nil {
+		return
+	}
+	jobStatus := make(map[string]schema.JobStatus)
+	for i := range artifacts {
+		artifact := &artifacts[i]
+		status, ok := jobStatus[artifact.JobID]
+		if !ok {
+			job, err := storage.Job.GetJobByID(artifact.JobID)
+			if err != nil {
+				logEntry.Warnf("get job %s of artifact %s failed, err: %v", artifact.JobID, artifact.Name, err)
+				continue
+			}
+			status = job.Status
+			jobStatus[artifact.JobID] = status
+		}
+		if !schema.IsImmutableJobStatus(status) {
+			continue
+		}
+		if status != schema.StatusJobSucceeded {
+			artifact.Status = model.JobArtifactSkipped
+			artifact.Message = fmt.Sprintf("job is %s, artifact is not collected", status)
+		} else if err = collectJobArtifact(logEntry, artifact); err != nil {
+			logEntry.Errorf("collect artifact %s of job %s failed, err: %v", artifact.Name, artifact.JobID, err)
+			artifact.Status = model.JobArtifactFailed
+			artifact.Message = err.Error()
+		} else {
+			artifact.Status = model.JobArtifactCollected
+			artifact.Message = ""
+		}
+		if err = storage.JobArtifact.UpdateJobArtifact(logEntry, artifact); err != nil {
+			logEntry.Errorf("update artifact %s of job %s failed, err: %v", artifact.Name, artifact.JobID, err)
+		}
+	}
+}
+
+// collectJobArtifact 记录产物的文件清单，摘要的计算方式与数据集版本一致
+func collectJobArtifact(logEntry *log.Entry, artifact *model.JobArtifact) error {
+	fsHandler, err := handler.NewFsHandlerWithServer(artifact.FsID, logEntry)
+	if err != nil {
+		return err
+	}
+	exist, err := fsHandler.Exist(artifact.Path)
+	if err != nil {
+		return err
+	}
+	if !exist {
+		return fmt.Errorf("path %s not found in fs %s", artifact.Path, artifact.FsName)
+	}
+	digests, err := fsHandler.FileDigests(artifact.Path, dataset.MaxManifestFiles)
+	if err != nil {
+		return err
+	}
+	artifact.Manifest = make([]model.DatasetFile, 0, len(digests))
+	artifact.FileCount, artifact.TotalSize = 0, 0
+	hash := sha256.New()
+	for _, digest := range digests {
+		// 产物为单个文件时，清单中记录其文件名
+		if digest.Path == "." {
+			digest.Path = path.Base(artifact.Path)
+		}
+		artifact.Manifest = append(artifact.Manifest, model.DatasetFile{
+			Path: digest.Path,
+			Size: digest.Size,
+			Hash: digest.Hash,
+		})
+		artifact.FileCount++
+		artifact.TotalSize += digest.Size
+		fmt.Fprintf(hash, "%s %d %s\n", digest.Path, digest.Size, digest.Hash)
+	}
+	artifact.Digest = hex.EncodeToString(hash.Sum(nil))
+	return nil
+}
@@ -0,0 +1,114 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package job
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/handler"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage/driver"
+)
+
+func newArtifactJob(id string, status schema.JobStatus, outputs ...schema.OutputArtifact) *model.Job {
+	conf := schema.Conf{
+		FileSystem:      schema.FileSystem{ID: "fs-root-data", Name: "data", SubPath: "exp"},
+		OutputArtifacts: outputs,
+	}
+	return &model.Job{
+		ID:       id,
+		UserName: "root",
+		Status:   status,
+		Config:   &conf,
+		Members:  []schema.Member{{Replicas: 1, Conf: conf}},
+	}
+}
+
+func TestBuildJobArtifacts(t *testing.T) {
+	job := newArtifactJob("job-1", schema.StatusJobInit, schema.OutputArtifact{Name: "model", FsName: "data", Path: "/output/model"})
+	artifacts, err := buildJobArtifacts(job)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(artifacts))
+	assert.Equal(t, "/exp/output/model", artifacts[0].Path)
+	assert.Equal(t, "fs-root-data", artifacts[0].FsID)
+	assert.Equal(t, model.JobArtifactPending, artifacts[0].Status)
+
+	// duplicated name in different members
+	job.Members = append(job.Members, job.Members[0])
+	_, err = buildJobArtifacts(job)
+	assert.Error(t, err)
+}
+
+func TestCollectJobArtifacts(t *testing.T) {
+	driver.InitMockDB()
+	handler.NewFsHandlerWithServer = handler.MockerNewFsHandlerWithServer
+	defer os.RemoveAll("./mock_fs_handler")
+	assert.NoError(t, os.MkdirAll("./mock_fs_handler/exp/model", 0755))
+	assert.NoError(t, os.WriteFile("./mock_fs_handler/exp/model/a.pdparams", []byte("params"), 0644))
+	assert.NoError(t, os.WriteFile("./mock_fs_handler/exp/log.txt", []byte("log"), 0644))
+
+	outputs := []schema.OutputArtifact{
+		{Name: "model", FsName: "data", Path: "/model"},
+		{Name: "log", FsName: "data", Path: "/log.txt"},
+		{Name: "missing", FsName: "data", Path: "/missing"},
+	}
+	jobs := []*model.Job{
+		newArtifactJob("job-succeeded", schema.StatusJobSucceeded, outputs...),
+		newArtifactJob("job-failed", schema.StatusJobFailed, outputs[0]),
+		newArtifactJob("job-running", schema.StatusJobRunning, outputs[0]),
+	}
+	for _, job := range jobs {
+		assert.NoError(t, storage.Job.CreateJob(job))
+		artifacts, err := buildJobArtifacts(job)
+		assert.NoError(t, err)
+		assert.NoError(t, storage.JobArtifact.CreateJobArtifacts(logger.LoggerForJob(job.ID), artifacts))
+	}
+
+	collectJobArtifacts()
+
+	ctx := &logger.RequestContext{UserName: "root"}
+	response, err := ListJobArtifacts(ctx, "job-succeeded")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(response.Artifacts))
+	modelArtifact := response.Artifacts[0]
+	assert.Equal(t, model.JobArtifactCollected, modelArtifact.Status)
+	assert.Equal(t, int64(1), modelArtifact.FileCount)
+	assert.Equal(t, int64(len("params")), modelArtifact.TotalSize)
+	assert.Equal(t, "a.pdparams", modelArtifact.Manifest[0].Path)
+	assert.Equal(t, 64, len(modelArtifact.Digest))
+	logArtifact := response.Artifacts[1]
+	assert.Equal(t, model.JobArtifactCollected, logArtifact.Status)
+	assert.Equal(t, "log.txt", logArtifact.Manifest[0].Path)
+	assert.Equal(t, model.JobArtifactFailed, response.Artifacts[2].Status)
+
+	response, err = ListJobArtifacts(ctx, "job-failed")
+	assert.NoError(t, err)
+	assert.Equal(t, model.JobArtifactSkipped, response.Artifacts[0].Status)
+
+	response, err = ListJobArtifacts(ctx, "job-running")
+	assert.NoError(t, err)
+	assert.Equal(t, model.JobArtifactPending, response.Artifacts[0].Status)
+
+	_, err = ListJobArtifacts(&logger.RequestContext{UserName: "user1"}, "job-succeeded")
+	assert.Error(t, err)
+}
@@ -0,0 +1,366 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package job
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/config"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/k8s"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/job/hook"
+	runtime "github.com/PaddlePaddle/PaddleFlow/pkg/job/runtime_v2"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/job/runtime_v2/client"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+)
+
+const defaultJobAutoscaleInterval = 30 * time.Second
+
+// autoscaleRuntime 查询作业等待调度的pod，并通过pod状态或ProvisioningRequest通知cluster autoscaler
+type autoscaleRuntime interface {
+	listUnscheduledPods(namespace, jobID string) ([]corev1.Pod, error)
+	markPodUnschedulable(pod *corev1.Pod, message string) error
+	createObject(obj *unstructured.Unstructured) error
+}
+
+var getAutoscaleRuntime = func(clusterInfo model.ClusterInfo) (autoscaleRuntime, error) {
+	runtimeSvc, err := runtime.GetOrCreateRuntime(clusterInfo)
+	if err != nil {
+		return nil, err
+	}
+	kubeRuntime, ok := runtimeSvc.(*runtime.KubeRuntime)
+	if !ok {
+		return nil, fmt.Errorf("runtime of cluster[%s] does not support autoscaling", clusterInfo.Name)
+	}
+	kubeClient, ok := kubeRuntime.Client().(*client.KubeRuntimeClient)
+	if !ok || kubeClient.Client == nil {
+		return nil, fmt.Errorf("runtime of cluster[%s] does not support autoscaling", clusterInfo.Name)
+	}
+	return &kubeAutoscaleRuntime{runtime: kubeRuntime, client: kubeClient.Client}, nil
+}
+
+type kubeAutoscaleRuntime struct {
+	runtime *runtime.KubeRuntime
+	client  kubernetes.Interface
+}
+
+func (kr *kubeAutoscaleRuntime) listUnscheduledPods(namespace, jobID string) ([]corev1.Pod, error) {
+	podList, err := kr.client.CoreV1().Pods(namespace).List(context.TODO(), metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", schema.JobIDLabel, jobID),
+	})
+	if err != nil {
+		return nil, err
+	}
+	var pods []corev1.Pod
+	for _, pod := range podList.Items {
+		if pod.Status.Phase == corev1.PodPending && pod.Spec.NodeName == "" && pod.DeletionTimestamp == nil {
+			pods = append(pods, pod)
+		}
+	}
+	return pods, nil
+}
+
+func (kr *kubeAutoscaleRuntime) markPodUnschedulable(pod *corev1.Pod, message string) error {
+	pod = pod.DeepCopy()
+	setPodUnschedulable(pod, message, time.Now())
+	_, err := kr.client.CoreV1().Pods(pod.Namespace).UpdateStatus(context.TODO(), pod, metav1.UpdateOptions{})
+	return err
+}
+
+func (kr *kubeAutoscaleRuntime) createObject(obj *unstructured.Unstructured) error {
+	return kr.runtime.CreateObject(obj)
+}
+
+// JobAutoscaleController 定期检查因资源不足等待调度的作业，通知cluster autoscaler和扩容钩子为作业扩容节点，
+// 每个作业只通知一次，并记录在作业的时间线上
+func JobAutoscaleController(stopChan chan struct{}) {
+	autoscale := config.GlobalServerConfig.Job.Autoscale
+	switch autoscale.Signal {
+	case "", config.AutoscaleSignalPodCondition, config.AutoscaleSignalProvisioningRequest:
+	default:
+		log.Errorf("autoscale signal %s is not supported, job autoscale controller is disabled", autoscale.Signal)
+		return
+	}
+	if autoscale.Signal == "" && len(config.GlobalServerConfig.Job.Hooks.ScaleUp) == 0 {
+		log.Info("job autoscale controller is disabled")
+		return
+	}
+	for {
+		signalPendingJobs(autoscale, time.Now())
+		select {
+		case <-stopChan:
+			log.Info("job autoscale controller stopped")
+			return
+		case <-time.After(defaultJobAutoscaleInterval):
+		}
+	}
+}
+
+func signalPendingJobs(autoscale config.JobAutoscaleConfig, now time.Time) {
+	pendingTime := autoscale.GetPendingTime()
+	clusters := make(map[string]*model.ClusterInfo)
+	jobs := storage.Job.ListJobByStatus(schema.StatusJobPending)
+	for i := range jobs {
+		job := &jobs[i]
+		if job.Config == nil || job.ParentJob != "" || job.Type == string(schema.TypeWorkflow) ||
+			now.Sub(job.CreatedAt) < pendingTime {
+			continue
+		}
+		if signaled, err := storage.JobTimeline.HasJobTimelineEvent(job.ID, model.TimelineScaleTriggered); err != nil || signaled {
+			continue
+		}
+		clusterID := job.Config.GetClusterID()
+		clusterInfo, find := clusters[clusterID]
+		if !find {
+			c, err := storage.Cluster.GetClusterById(clusterID)
+			if err != nil {
+				log.Errorf("get cluster %s of job %s failed, err: %v", clusterID, job.ID, err)
+				continue
+			}
+			clusterInfo = &c
+			clusters[clusterID] = clusterInfo
+		}
+		if clusterInfo.ClusterType != schema.KubernetesType {
+			continue
+		}
+		if err := signalScaleUp(autoscale, job, clusterInfo, now); err != nil {
+			log.Errorf("signal scale up for job %s failed, err: %v", job.ID, err)
+		}
+	}
+}
+
+// signalScaleUp 作业有等待调度超过PendingSeconds的pod时，按配置标记pod为Unschedulable或创建ProvisioningRequest，
+// 然后调用扩容钩子
+func signalScaleUp(autoscale config.JobAutoscaleConfig, job *model.Job, clusterInfo *model.ClusterInfo, now time.Time) error {
+	rt, err := getAutoscaleRuntime(*clusterInfo)
+	if err != nil {
+		return err
+	}
+	namespace := job.Config.GetNamespace()
+	unscheduled, err := rt.listUnscheduledPods(namespace, job.ID)
+	if err != nil {
+		return err
+	}
+	var pods []corev1.Pod
+	for _, pod := range unscheduled {
+		if now.Sub(pod.CreationTimestamp.Time) >= autoscale.GetPendingTime() {
+			pods = append(pods, pod)
+		}
+	}
+	if len(pods) == 0 {
+		return nil
+	}
+	requests := podsRequests(pods)
+
+	var signals []string
+	switch autoscale.Signal {
+	case config.AutoscaleSignalPodCondition:
+		message := fmt.Sprintf("job %s is pending due to insufficient resources", job.ID)
+		for i := range pods {
+			if isPodUnschedulable(&pods[i]) {
+				continue
+			}
+			if err = rt.markPodUnschedulable(&pods[i], message); err != nil {
+				return fmt.Errorf("mark pod %s unschedulable failed: %v", pods[i].Name, err)
+			}
+		}
+		signals = append(signals, "unschedulable pods")
+	case config.AutoscaleSignalProvisioningRequest:
+		objects, err := newProvisioningRequest(job.ID, autoscale.GetProvisioningClass(), pods)
+		if err != nil {
+			return err
+		}
+		for _, obj := range objects {
+			if err = rt.createObject(obj); err != nil && !k8serrors.IsAlreadyExists(err) {
+				return fmt.Errorf("create %s %s failed: %v", obj.GetKind(), obj.GetName(), err)
+			}
+		}
+		signals = append(signals, fmt.Sprintf("provisioning request %s", job.ID))
+	}
+	if len(config.GlobalServerConfig.Job.Hooks.ScaleUp) > 0 {
+		err = hook.ScaleUp(job, &hook.ScaleUpRequest{
+			ClusterName: clusterInfo.Name,
+			Namespace:   namespace,
+			NodePool:    job.Config.GetNodePool(),
+			Pods:        len(pods),
+			Requests:    requests,
+		})
+		if err != nil {
+			return err
+		}
+		signals = append(signals, "scale-up hooks")
+	}
+
+	event := &model.JobTimelineEvent{
+		JobID: job.ID,
+		Type:  model.TimelineScaleTriggered,
+		Message: fmt.Sprintf("%d pods requesting %s are pending, scale up is requested by %s",
+			len(pods), formatRequests(requests), strings.Join(signals, " and ")),
+	}
+	log.Infof("job %s: %s", job.ID, event.Message)
+	return storage.JobTimeline.AddJobTimelineEvent(event)
+}
+
+func isPodUnschedulable(pod *corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodScheduled && condition.Status == corev1.ConditionFalse &&
+			condition.Reason == corev1.PodReasonUnschedulable {
+			return true
+		}
+	}
+	return false
+}
+
+// setPodUnschedulable 设置cluster autoscaler识别的PodScheduled=False且原因为Unschedulable的condition
+func setPodUnschedulable(pod *corev1.Pod, message string, now time.Time) {
+	condition := corev1.PodCondition{
+		Type:               corev1.PodScheduled,
+		Status:             corev1.ConditionFalse,
+		Reason:             corev1.PodReasonUnschedulable,
+		Message:            message,
+		LastTransitionTime: metav1.NewTime(now),
+	}
+	for i := range pod.Status.Conditions {
+		if pod.Status.Conditions[i].Type == corev1.PodScheduled {
+			pod.Status.Conditions[i] = condition
+			return
+		}
+	}
+	pod.Status.Conditions = append(pod.Status.Conditions, condition)
+}
+
+// newProvisioningRequest 按调度约束和资源相同的pod分组生成PodTemplate，并生成引用这些PodTemplate的ProvisioningRequest，
+// 各对象的owner为作业的pod，作业删除后由集群回收
+func newProvisioningRequest(jobID, provisioningClass string, pods []corev1.Pod) ([]*unstructured.Unstructured, error) {
+	owner := metav1.OwnerReference{APIVersion: "v1", Kind: "Pod", Name: pods[0].Name, UID: pods[0].UID}
+	var keys []string
+	groups := map[string][]corev1.Pod{}
+	for _, pod := range pods {
+		key, err := podSchedulingKey(&pod)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], pod)
+	}
+
+	var objects []*unstructured.Unstructured
+	var podSets []interface{}
+	for i, key := range keys {
+		pod := groups[key][0]
+		spec := pod.Spec.DeepCopy()
+		spec.NodeName = ""
+		template := &corev1.PodTemplate{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            fmt.Sprintf("%s-%d", jobID, i),
+				Namespace:       pod.Namespace,
+				Labels:          map[string]string{schema.JobIDLabel: jobID},
+				OwnerReferences: []metav1.OwnerReference{owner},
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: pod.Labels},
+				Spec:       *spec,
+			},
+		}
+		obj, err := k8sruntime.DefaultUnstructuredConverter.ToUnstructured(template)
+		if err != nil {
+			return nil, err
+		}
+		podTemplate := &unstructured.Unstructured{Object: obj}
+		podTemplate.SetGroupVersionKind(k8s.PodTemplateGVK)
+		objects = append(objects, podTemplate)
+		podSets = append(podSets, map[string]interface{}{
+			"podTemplateRef": map[string]interface{}{"name": template.Name},
+			"count":          int64(len(groups[key])),
+		})
+	}
+
+	request := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"provisioningClassName": provisioningClass,
+			"podSets":               podSets,
+		},
+	}}
+	request.SetGroupVersionKind(k8s.ProvisioningRequestGVK)
+	request.SetName(jobID)
+	request.SetNamespace(pods[0].Namespace)
+	request.SetLabels(map[string]string{schema.JobIDLabel: jobID})
+	request.SetOwnerReferences([]metav1.OwnerReference{owner})
+	return append(objects, request), nil
+}
+
+// podSchedulingKey 影响调度的pod字段，相同的pod可以使用同一个PodTemplate
+func podSchedulingKey(pod *corev1.Pod) (string, error) {
+	var containerResources []corev1.ResourceRequirements
+	for _, c := range pod.Spec.Containers {
+		containerResources = append(containerResources, c.Resources)
+	}
+	key, err := json.Marshal(struct {
+		Resources    []corev1.ResourceRequirements `json:"resources"`
+		NodeSelector map[string]string             `json:"nodeSelector"`
+		Tolerations  []corev1.Toleration           `json:"tolerations"`
+		Affinity     *corev1.Affinity              `json:"affinity"`
+	}{containerResources, pod.Spec.NodeSelector, pod.Spec.Tolerations, pod.Spec.Affinity})
+	return string(key), err
+}
+
+// podsRequests 返回pod中容器请求的资源总量
+func podsRequests(pods []corev1.Pod) map[string]string {
+	total := corev1.ResourceList{}
+	for _, pod := range pods {
+		for _, c := range pod.Spec.Containers {
+			for name, quantity := range c.Resources.Requests {
+				sum := total[name]
+				sum.Add(quantity)
+				total[name] = sum
+			}
+		}
+	}
+	requests := make(map[string]string, len(total))
+	for name, quantity := range total {
+		requests[string(name)] = quantity.String()
+	}
+	return requests
+}
+
+func formatRequests(requests map[string]string) string {
+	if len(requests) == 0 {
+		return "no resources"
+	}
+	var items []string
+	for name, quantity := range requests {
+		items = append(items, fmt.Sprintf("%s=%s", name, quantity))
+	}
+	sort.Strings(items)
+	return strings.Join(items, ",")
+}
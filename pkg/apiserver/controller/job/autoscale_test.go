@@ -0,0 +1,152 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package job
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/config"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/job/hook"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage/driver"
+)
+
+type fakeAutoscaleRuntime struct {
+	pods    map[string][]corev1.Pod
+	marked  []string
+	created []*unstructured.Unstructured
+}
+
+func (f *fakeAutoscaleRuntime) listUnscheduledPods(namespace, jobID string) ([]corev1.Pod, error) {
+	return f.pods[jobID], nil
+}
+
+func (f *fakeAutoscaleRuntime) markPodUnschedulable(pod *corev1.Pod, message string) error {
+	f.marked = append(f.marked, pod.Name)
+	return nil
+}
+
+func (f *fakeAutoscaleRuntime) createObject(obj *unstructured.Unstructured) error {
+	f.created = append(f.created, obj)
+	return nil
+}
+
+func newPendingPod(name, cpu string, created time.Time, unschedulable bool) corev1.Pod {
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", CreationTimestamp: metav1.NewTime(created)},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "main", Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu)},
+		}}}},
+		Status: corev1.PodStatus{Phase: corev1.PodPending},
+	}
+	if unschedulable {
+		setPodUnschedulable(&pod, "0/3 nodes are available", created)
+	}
+	return pod
+}
+
+func TestSignalPendingJobs(t *testing.T) {
+	driver.InitMockDB()
+	var scaleUp hook.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&scaleUp)
+	}))
+	defer server.Close()
+	config.GlobalServerConfig = &config.ServerConfig{}
+	config.GlobalServerConfig.Job.Hooks.ScaleUp = []config.JobHook{{Name: "nodegroup", URL: server.URL}}
+
+	cluster := model.ClusterInfo{Model: model.Model{ID: "cluster-1"}, Name: "cluster-1", ClusterType: schema.KubernetesType}
+	assert.NoError(t, storage.Cluster.CreateCluster(&cluster))
+	now := time.Now()
+	for _, id := range []string{"job-1", "job-2", "job-new"} {
+		conf := &schema.Conf{}
+		conf.SetClusterID(cluster.ID)
+		conf.SetNamespace("default")
+		conf.SetNodePool("a100")
+		assert.NoError(t, storage.Job.CreateJob(&model.Job{ID: id, QueueID: MockQueueID, Status: schema.StatusJobPending,
+			Config: conf}))
+	}
+	// 作业创建时间早于等待时间
+	for _, id := range []string{"job-1", "job-2"} {
+		assert.NoError(t, storage.DB.Model(&model.Job{}).Where("id = ?", id).
+			Update("created_at", now.Add(-10*time.Minute)).Error)
+	}
+
+	rt := &fakeAutoscaleRuntime{pods: map[string][]corev1.Pod{
+		"job-1": {
+			newPendingPod("job-1-worker-0", "2", now.Add(-10*time.Minute), false),
+			newPendingPod("job-1-worker-1", "2", now.Add(-10*time.Minute), true),
+			newPendingPod("job-1-worker-2", "2", now, false),
+		},
+		"job-new": {newPendingPod("job-new-worker-0", "1", now.Add(-10*time.Minute), false)},
+	}}
+	getAutoscaleRuntime = func(clusterInfo model.ClusterInfo) (autoscaleRuntime, error) {
+		return rt, nil
+	}
+
+	autoscale := config.JobAutoscaleConfig{Signal: config.AutoscaleSignalPodCondition}
+	signalPendingJobs(autoscale, now)
+	// 已标记为Unschedulable和刚创建的pod不再标记
+	assert.Equal(t, []string{"job-1-worker-0"}, rt.marked)
+	assert.Equal(t, hook.EventScaleUp, scaleUp.Event)
+	assert.Equal(t, &hook.ScaleUpRequest{ClusterName: "cluster-1", Namespace: "default", NodePool: "a100", Pods: 2,
+		Requests: map[string]string{"cpu": "4"}}, scaleUp.ScaleUp)
+	timeline, err := storage.JobTimeline.ListJobTimeline("job-1")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(timeline))
+	assert.Equal(t, model.TimelineScaleTriggered, timeline[0].Type)
+	assert.Contains(t, timeline[0].Message, "cpu=4")
+	// 没有等待调度的pod时不记录
+	timeline, _ = storage.JobTimeline.ListJobTimeline("job-2")
+	assert.Empty(t, timeline)
+
+	// 每个作业只通知一次
+	signalPendingJobs(autoscale, now.Add(time.Minute))
+	assert.Equal(t, 1, len(rt.marked))
+
+	rt.pods["job-2"] = []corev1.Pod{
+		newPendingPod("job-2-worker-0", "1", now.Add(-10*time.Minute), false),
+		newPendingPod("job-2-worker-1", "1", now.Add(-10*time.Minute), false),
+		newPendingPod("job-2-ps-0", "4", now.Add(-10*time.Minute), false),
+	}
+	signalPendingJobs(config.JobAutoscaleConfig{Signal: config.AutoscaleSignalProvisioningRequest}, now)
+	assert.Equal(t, 3, len(rt.created))
+	request := rt.created[2]
+	assert.Equal(t, "ProvisioningRequest", request.GetKind())
+	assert.Equal(t, "job-2", request.GetName())
+	assert.Equal(t, "job-2-worker-0", request.GetOwnerReferences()[0].Name)
+	className, _, _ := unstructured.NestedString(request.Object, "spec", "provisioningClassName")
+	assert.Equal(t, config.DefaultProvisioningClass, className)
+	podSets, _, _ := unstructured.NestedSlice(request.Object, "spec", "podSets")
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"podTemplateRef": map[string]interface{}{"name": "job-2-0"}, "count": int64(2)},
+		map[string]interface{}{"podTemplateRef": map[string]interface{}{"name": "job-2-1"}, "count": int64(1)},
+	}, podSets)
+	assert.Equal(t, "PodTemplate", rt.created[0].GetKind())
+}
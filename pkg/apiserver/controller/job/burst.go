@@ -0,0 +1,178 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package job
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/job/api"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/job/hook"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+)
+
+const defaultJobBurstInterval = 30 * time.Second
+
+// JobBurstController 定期将本地集群上等待过久的作业按队列的溢出策略溢出到其他集群的队列
+func JobBurstController(stopChan chan struct{}) {
+	for {
+		burstPendingJobs(time.Now())
+		select {
+		case <-stopChan:
+			log.Info("job burst controller stopped")
+			return
+		case <-time.After(defaultJobBurstInterval):
+		}
+	}
+}
+
+func burstPendingJobs(now time.Time) {
+	queues := make(map[string]*model.Queue)
+	jobs := storage.Job.ListJobByStatus(schema.StatusJobPending)
+	for i := range jobs {
+		job := &jobs[i]
+		queue, find := queues[job.QueueID]
+		if !find {
+			q, err := storage.Queue.GetQueueByID(job.QueueID)
+			if err != nil {
+				log.Errorf("get queue %s of job %s failed, err: %v", job.QueueID, job.ID, err)
+				continue
+			}
+			queue = &q
+			queues[job.QueueID] = queue
+		}
+		if !isJobBurstable(job, queue, now) {
+			continue
+		}
+		targetQueue, err := burstTargetQueue(job, queue)
+		if err != nil {
+			log.Warnf("job %s cannot burst from queue %s, err: %v", job.ID, queue.Name, err)
+			continue
+		}
+		if err = burstJob(job, queue, targetQueue); err != nil {
+			log.Errorf("burst job %s from queue %s to queue %s failed, err: %v", job.ID, queue.Name, targetQueue.Name, err)
+		}
+	}
+}
+
+// isJobBurstable 只有等待超过WaitSeconds且符合溢出策略的作业才会溢出，每个作业最多溢出一次
+func isJobBurstable(job *model.Job, queue *model.Queue, now time.Time) bool {
+	burstPolicy := queue.BurstPolicy
+	if burstPolicy == nil || job.Config == nil {
+		return false
+	}
+	if job.Status != schema.StatusJobPending || job.Requeuing || job.BurstFromQueue != "" ||
+		job.ParentJob != "" || job.Type == string(schema.TypeWorkflow) {
+		return false
+	}
+	if now.Sub(job.CreatedAt) < burstPolicy.GetWaitTime() {
+		return false
+	}
+	return burstPolicy.IsEligible(job.Config.GetLabels())
+}
+
+// burstTargetQueue 目标队列必须处于open状态、属于其他集群，且最大资源能够满足作业
+func burstTargetQueue(job *model.Job, queue *model.Queue) (*model.Queue, error) {
+	targetQueue, err := storage.Queue.GetQueueByName(queue.BurstPolicy.Queue)
+	if err != nil {
+		return nil, fmt.Errorf("get target queue %s failed, err: %v", queue.BurstPolicy.Queue, err)
+	}
+	if targetQueue.Status != schema.StatusQueueOpen {
+		return nil, fmt.Errorf("target queue %s is %s", targetQueue.Name, targetQueue.Status)
+	}
+	if targetQueue.ClusterId == queue.ClusterId {
+		return nil, fmt.Errorf("target queue %s belongs to the same cluster", targetQueue.Name)
+	}
+	cluster, err := storage.Cluster.GetClusterById(targetQueue.ClusterId)
+	if err != nil {
+		return nil, fmt.Errorf("get cluster of target queue %s failed, err: %v", targetQueue.Name, err)
+	}
+	if cluster.Status != model.ClusterStatusOnLine {
+		return nil, fmt.Errorf("cluster %s of target queue %s is %s", cluster.Name, targetQueue.Name, cluster.Status)
+	}
+	if targetQueue.MaxResources != nil && !jobResources(job).LessEqual(targetQueue.MaxResources) {
+		return nil, fmt.Errorf("max resources of target queue %s are insufficient", targetQueue.Name)
+	}
+	return &targetQueue, nil
+}
+
+// burstJob 调用数据预置钩子同步作业所需的存储路径后，将作业切换到目标队列并删除本地集群中的作业，
+// 本地集群同步到作业删除后作业恢复为init状态，由job manager提交到目标队列所在的集群
+func burstJob(job *model.Job, queue, targetQueue *model.Queue) error {
+	runtimeSvc, err := getRuntimeByQueue(&logger.RequestContext{}, job.QueueID)
+	if err != nil {
+		return err
+	}
+	if err = hook.StageData(job, targetQueue); err != nil {
+		return err
+	}
+	conf, members, err := burstJobConf(job, targetQueue)
+	if err != nil {
+		return err
+	}
+	marked, err := storage.Job.MarkJobBursting(job.ID, queue.Name, targetQueue.ID, conf, members)
+	if err != nil || !marked {
+		return err
+	}
+	log.Infof("job %s is bursting from queue %s to queue %s", job.ID, queue.Name, targetQueue.Name)
+	pfjob, err := api.NewJobInfo(job)
+	if err != nil {
+		return err
+	}
+	if err = runtimeSvc.DeleteJob(pfjob); err != nil {
+		if revertErr := storage.Job.RevertJobBursting(job); revertErr != nil {
+			log.Errorf("revert bursting of job %s failed, err: %v", job.ID, revertErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// burstJobConf 基于作业保存的配置生成切换到目标队列后的配置，不修改作业本身
+func burstJobConf(job *model.Job, targetQueue *model.Queue) (*schema.Conf, []schema.Member, error) {
+	conf := &schema.Conf{}
+	if err := json.Unmarshal([]byte(job.ConfigJson), conf); err != nil {
+		return nil, nil, err
+	}
+	setConfQueue(conf, targetQueue)
+	var members []schema.Member
+	if job.MembersJson != "" {
+		if err := json.Unmarshal([]byte(job.MembersJson), &members); err != nil {
+			return nil, nil, err
+		}
+	}
+	for i := range members {
+		if members[i].Conf.GetQueueID() != "" {
+			setConfQueue(&members[i].Conf, targetQueue)
+		}
+	}
+	return conf, members, nil
+}
+
+func setConfQueue(conf *schema.Conf, queue *model.Queue) {
+	conf.SetQueueID(queue.ID)
+	conf.SetQueueName(queue.Name)
+	conf.SetClusterID(queue.ClusterId)
+	conf.SetNamespace(queue.Namespace)
+	conf.SetNodePool(queue.NodePool)
+}
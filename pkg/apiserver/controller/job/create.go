@@ -19,6 +19,7 @@ package job
 import (
 	"encoding/json"
 	"fmt"
+	"path"
 	"path/filepath"
 	"strconv"
 	"strings"
@@ -26,9 +27,13 @@ import (
 
 	"github.com/ghodss/yaml"
 	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/validation"
 
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/controller/dataset"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/controller/flavour"
+	fsctrl "github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/controller/fs"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/config"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/errors"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
@@ -38,6 +43,7 @@ import (
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/uuid"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/metrics"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	pplcommon "github.com/PaddlePaddle/PaddleFlow/pkg/pipeline/common"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
 )
 
@@ -48,7 +54,11 @@ type CreateJobInfo struct {
 	Type              schema.JobType         `json:"type"`
 	Mode              string                 `json:"mode,omitempty"`
 	Members           []MemberSpec           `json:"members"`
+	Serving           *ServingSpec           `json:"serving,omitempty"`
 	ExtensionTemplate map[string]interface{} `json:"extensionTemplate,omitempty"`
+	// RunID StepName 工作流创建的作业所属的run及节点，由CreatePPLJob填充
+	RunID    string `json:"-"`
+	StepName string `json:"-"`
 }
 
 // CreatePFJob handler for creating job
@@ -60,6 +70,7 @@ func CreatePFJob(ctx *logger.RequestContext, request *CreateJobInfo) (*CreateJob
 	if request.ID == "" {
 		request.ID = uuid.GenerateIDWithLength(schema.JobPrefix, uuid.JobIDLength)
 	}
+	ctx.JobID = request.ID
 	if err := common.CheckPermission(ctx.UserName, ctx.UserName, common.ResourceTypeJob, request.ID); err != nil {
 		ctx.ErrorCode = common.ActionNotAllowed
 		ctx.Logging().Errorln(err.Error())
@@ -78,19 +89,75 @@ func CreatePFJob(ctx *logger.RequestContext, request *CreateJobInfo) (*CreateJob
 		ctx.Logging().Errorf("patch envs when creating job %s failed, err=%v", request.CommonJobInfo.Name, err)
 		return nil, err
 	}
+	ctx.QueueID = jobInfo.QueueID
+	if err = checkUserQuota(ctx, jobInfo); err != nil {
+		ctx.Logging().Errorf("check quota of user[%s] failed, err: %v", jobInfo.UserName, err)
+		return nil, err
+	}
+	if reason, ok := needApproval(jobInfo); ok {
+		jobInfo.Status = schema.StatusJobPendingApproval
+		jobInfo.Message = reason
+	}
+	artifacts, err := buildJobArtifacts(jobInfo)
+	if err != nil {
+		ctx.ErrorCode = common.JobInvalidField
+		ctx.Logging().Errorf("build output artifacts of job %s failed, err: %v", jobInfo.ID, err)
+		return nil, err
+	}
 
 	ctx.Logging().Debugf("create distributed job %#v", jobInfo)
 	if err = storage.Job.CreateJob(jobInfo); err != nil {
+		ctx.ErrorCode = jobErrorCode(err, common.InternalError)
 		ctx.Logging().Errorf("create job[%s] in database faield, err: %v", jobInfo.Config.GetName(), err)
 		return nil, fmt.Errorf("create job[%s] in database faield, err: %v", jobInfo.Config.GetName(), err)
 	}
+	if err = storage.JobArtifact.CreateJobArtifacts(ctx.Logging(), artifacts); err != nil {
+		ctx.Logging().Errorf("create output artifacts of job %s failed, err: %v", jobInfo.ID, err)
+	}
 
+	if jobInfo.Status == schema.StatusJobPendingApproval {
+		go notifyApprovers(jobInfo)
+	}
 	ctx.Logging().Infof("create job[%s] successful.", jobInfo.ID)
 	return &CreateJobResponse{
 		ID: jobInfo.ID,
 	}, nil
 }
 
+// FillJobDefaults fills omitted queue/flavour/image/fs of single and serving job with user preference,
+// and then with the server defaults
+func FillJobDefaults(ctx *logger.RequestContext, commonJobInfo *CommonJobInfo, jobSpec *JobSpec) error {
+	preference, err := storage.Auth.GetUserPreference(ctx, ctx.UserName)
+	if err != nil && common.ErrorCodeOf(err, common.InternalError) != common.RecordNotFound {
+		ctx.ErrorCode = common.ErrorCodeOf(err, common.InternalError)
+		ctx.Logging().Errorf("get preference of user[%s] failed, err: %v", ctx.UserName, err)
+		return err
+	}
+	defaults := config.GlobalServerConfig.Job.Defaults
+	firstNonEmpty := func(values ...string) string {
+		for _, v := range values {
+			if v != "" {
+				return v
+			}
+		}
+		return ""
+	}
+	if commonJobInfo.SchedulingPolicy.Queue == "" {
+		commonJobInfo.SchedulingPolicy.Queue = firstNonEmpty(preference.Queue, defaults.Queue)
+	}
+	flavour := &jobSpec.Flavour
+	if flavour.Name == "" && flavour.CPU == "" && flavour.Mem == "" && len(flavour.ScalarResources) == 0 {
+		flavour.Name = firstNonEmpty(preference.Flavour, defaults.Flavour)
+	}
+	if jobSpec.Image == "" {
+		jobSpec.Image = firstNonEmpty(preference.Image, defaults.Image)
+	}
+	if jobSpec.FileSystem.Name == "" && jobSpec.FileSystem.ID == "" {
+		jobSpec.FileSystem.Name = firstNonEmpty(preference.FileSystem, defaults.FileSystem)
+	}
+	return nil
+}
+
 func validateJob(ctx *logger.RequestContext, request *CreateJobInfo) error {
 	if err := validateCommonJobInfo(ctx, &request.CommonJobInfo); err != nil {
 		ctx.Logging().Errorf("validateCommonJobInfo failed, err: %v", err)
@@ -103,6 +170,20 @@ func validateJob(ctx *logger.RequestContext, request *CreateJobInfo) error {
 		return err
 	}
 
+	if request.Type == schema.TypeServing {
+		if err := validateServing(ctx, request); err != nil {
+			ctx.Logging().Errorf("validate serving failed, err: %v", err)
+			return err
+		}
+	}
+
+	if request.Framework == schema.FrameworkCustom {
+		if err := validateCustomJob(ctx, request); err != nil {
+			ctx.Logging().Errorf("validate custom job failed, err: %v", err)
+			return err
+		}
+	}
+
 	if len(request.ExtensionTemplate) != 0 {
 		// extension template from user
 		ctx.Logging().Infof("request ExtensionTemplate is not empty, pass validate members")
@@ -116,6 +197,23 @@ func validateJob(ctx *logger.RequestContext, request *CreateJobInfo) error {
 	return nil
 }
 
+// validateCustomJob checks extension template of custom job, whose kind must have status mapping in server config
+func validateCustomJob(ctx *logger.RequestContext, request *CreateJobInfo) error {
+	apiVersion, _ := request.ExtensionTemplate["apiVersion"].(string)
+	kind, _ := request.ExtensionTemplate["kind"].(string)
+	if apiVersion == "" || kind == "" {
+		ctx.ErrorCode = common.JobInvalidField
+		return fmt.Errorf("apiVersion and kind of extensionTemplate are required for custom job")
+	}
+	for _, mapping := range config.GlobalServerConfig.Job.StatusMappings {
+		if mapping.APIVersion == apiVersion && mapping.Kind == kind {
+			return nil
+		}
+	}
+	ctx.ErrorCode = common.JobInvalidField
+	return fmt.Errorf("kind %s of %s is not supported for custom job, status mapping of the kind is required", kind, apiVersion)
+}
+
 func validateCommonJobInfo(ctx *logger.RequestContext, requestCommonJobInfo *CommonJobInfo) error {
 	// validate job id
 	if requestCommonJobInfo.ID != "" {
@@ -142,6 +240,16 @@ func validateCommonJobInfo(ctx *logger.RequestContext, requestCommonJobInfo *Com
 		ctx.ErrorCode = common.JobInvalidField
 		return err
 	}
+	if err := validateCostCenter(requestCommonJobInfo.Labels); err != nil {
+		ctx.Logging().Errorf("validate cost center failed, err: %v", err)
+		ctx.ErrorCode = common.JobInvalidField
+		return err
+	}
+	if err := validateConcurrencyGroup(&requestCommonJobInfo.SchedulingPolicy); err != nil {
+		ctx.Logging().Errorf("validate concurrency group failed, err: %v", err)
+		ctx.ErrorCode = common.JobInvalidField
+		return err
+	}
 
 	return nil
 }
@@ -194,6 +302,10 @@ func validateJobMembers(ctx *logger.RequestContext, request *CreateJobInfo) erro
 		ctx.Logging().Errorf(errMsg)
 		return fmt.Errorf(errMsg)
 	}
+	if request.Type == schema.TypeServing {
+		// replicas of serving job are scaled between minReplicas and maxReplicas
+		return nil
+	}
 	var err error
 	request.Mode, err = checkMemberRole(request.Framework, frameworkRoles)
 	if err != nil {
@@ -221,7 +333,7 @@ func validateMember(ctx *logger.RequestContext, member *MemberSpec, framework sc
 	}
 	frameworkRoles[memberRole] = frameworkRoles[memberRole] + member.Replicas
 	// TODO: move more check to checkJobSpec
-	err := checkJobSpec(ctx, &member.JobSpec)
+	err := checkJobSpec(ctx, &member.JobSpec, schedulingPolicy.Queue)
 	if err != nil {
 		ctx.Logging().Errorf("Failed to check Members: %v", err)
 		return err
@@ -239,7 +351,7 @@ func validateMember(ctx *logger.RequestContext, member *MemberSpec, framework sc
 	return nil
 }
 
-func checkJobSpec(ctx *logger.RequestContext, jobSpec *JobSpec) error {
+func checkJobSpec(ctx *logger.RequestContext, jobSpec *JobSpec, queueName string) error {
 	port := jobSpec.Port
 	if port != 0 && !(port > 0 && port < common.JobPortMaximums) {
 		err := fmt.Errorf("port must be in range [0, %d], but got %d", common.JobPortMaximums, port)
@@ -255,11 +367,54 @@ func checkJobSpec(ctx *logger.RequestContext, jobSpec *JobSpec) error {
 		ctx.ErrorCode = common.RequiredFieldEmpty
 		return err
 	}
+	// mount datasets as read-only filesystems
+	if err := resolveDatasets(ctx, jobSpec); err != nil {
+		ctx.Logging().Errorf("resolve datasets failed, requestJobSpec[%v], err: %v", jobSpec, err)
+		return err
+	}
 	// validate FileSystem
-	if err := validateFileSystems(jobSpec, ctx.UserName); err != nil {
+	if err := validateFileSystems(jobSpec, ctx.UserName, queueName); err != nil {
 		ctx.Logging().Errorf("validateFileSystem failed, requestJobSpec[%v], err: %v", jobSpec, err)
 		return err
 	}
+	// validate ephemeral volumes
+	if err := validateEphemeralVolumes(jobSpec); err != nil {
+		ctx.Logging().Errorf("validate ephemeral volumes failed, requestJobSpec[%v], err: %v", jobSpec, err)
+		ctx.ErrorCode = common.JobInvalidField
+		return err
+	}
+	// validate code package
+	if err := validateCodePackage(jobSpec); err != nil {
+		ctx.Logging().Errorf("validate code package failed, requestJobSpec[%v], err: %v", jobSpec, err)
+		ctx.ErrorCode = common.JobInvalidField
+		return err
+	}
+	// validate output artifacts
+	if err := validateOutputArtifacts(jobSpec); err != nil {
+		ctx.Logging().Errorf("validate output artifacts failed, requestJobSpec[%v], err: %v", jobSpec, err)
+		ctx.ErrorCode = common.JobInvalidField
+		return err
+	}
+	return nil
+}
+
+// resolveDatasets append datasets to extra filesystems, and record dataset versions in env
+func resolveDatasets(ctx *logger.RequestContext, jobSpec *JobSpec) error {
+	if len(jobSpec.Datasets) == 0 {
+		return nil
+	}
+	fileSystems, versions, err := dataset.ResolveDatasets(ctx.Logging(), ctx.UserName, jobSpec.Datasets)
+	if err != nil {
+		ctx.ErrorCode = common.JobInvalidField
+		return err
+	}
+	jobSpec.ExtraFileSystems = append(jobSpec.ExtraFileSystems, fileSystems...)
+	if jobSpec.Env == nil {
+		jobSpec.Env = make(map[string]string)
+	}
+	jobSpec.Env[schema.EnvJobDatasets] = strings.Join(versions, ",")
+	// datasets have been converted to filesystems
+	jobSpec.Datasets = nil
 	return nil
 }
 
@@ -275,14 +430,12 @@ func validateQueue(ctx *logger.RequestContext, schedulingPolicy *SchedulingPolic
 		}
 	}
 	queueName := schedulingPolicy.Queue
-	queue, err := storage.Queue.GetQueueByName(queueName)
+	queue, err := storage.Queue.GetCachedQueueByName(queueName)
 	if err != nil {
-		if errors.GetErrorCode(err) == errors.ErrorKeyIsDuplicated {
-			ctx.ErrorCode = common.QueueNameDuplicated
-		} else {
-			ctx.ErrorCode = common.InternalError
+		ctx.ErrorCode = common.ErrorCodeOf(err, common.InternalError)
+		if ctx.ErrorCode == common.RecordNotFound {
+			ctx.ErrorCode = common.QueueNameNotFound
 		}
-		ctx.ErrorCode = common.InternalError
 		err = fmt.Errorf("get queue failed when creating job, err=%v", err)
 		ctx.Logging().Error(err)
 		return err
@@ -293,9 +446,52 @@ func validateQueue(ctx *logger.RequestContext, schedulingPolicy *SchedulingPolic
 		return fmt.Errorf(errMsg)
 	}
 	schedulingPolicy.QueueID = queue.ID
-	schedulingPolicy.MaxResources = queue.MaxResources
+	schedulingPolicy.MaxResources = queue.PeakMaxResources()
 	schedulingPolicy.ClusterId = queue.ClusterId
 	schedulingPolicy.Namespace = queue.Namespace
+	schedulingPolicy.NodePool = queue.NodePool
+	return nil
+}
+
+// validateCostCenter 开启cost-center准入策略时，作业必须设置合法的cost-center标签
+func validateCostCenter(labels map[string]string) error {
+	policy := config.GlobalServerConfig.Job.Policy
+	if !policy.RequireCostCenter {
+		return nil
+	}
+	label := policy.GetCostCenterLabel()
+	costCenter := labels[label]
+	if costCenter == "" {
+		return fmt.Errorf("label %s is required", label)
+	}
+	if len(policy.CostCenters) == 0 {
+		return nil
+	}
+	for _, c := range policy.CostCenters {
+		if c == costCenter {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s %s is not allowed, must be one of %v", label, costCenter, policy.CostCenters)
+}
+
+// validateConcurrencyGroup 校验并发组，设置并发组但未设置上限时同组作业逐个运行
+func validateConcurrencyGroup(schedulingPolicy *SchedulingPolicy) error {
+	if schedulingPolicy.ConcurrencyGroup == "" {
+		if schedulingPolicy.ConcurrencyLimit != 0 {
+			return fmt.Errorf("concurrencyLimit is set without concurrencyGroup")
+		}
+		return nil
+	}
+	if len(schedulingPolicy.ConcurrencyGroup) > common.ConcurrencyGroupMaxLength {
+		return fmt.Errorf("length of concurrencyGroup must be no more than %d characters", common.ConcurrencyGroupMaxLength)
+	}
+	if schedulingPolicy.ConcurrencyLimit < 0 {
+		return fmt.Errorf("concurrencyLimit[%d] should not be negative", schedulingPolicy.ConcurrencyLimit)
+	}
+	if schedulingPolicy.ConcurrencyLimit == 0 {
+		schedulingPolicy.ConcurrencyLimit = 1
+	}
 	return nil
 }
 
@@ -335,9 +531,9 @@ func validateMembersQueue(ctx *logger.RequestContext, member *MemberSpec, schePo
 	return nil
 }
 
-func validateFileSystems(jobSpec *JobSpec, userName string) error {
+func validateFileSystems(jobSpec *JobSpec, userName, queueName string) error {
 	if jobSpec.FileSystem.Name != "" {
-		if err := validateFileSystem(userName, &jobSpec.FileSystem); err != nil {
+		if err := validateFileSystem(userName, queueName, &jobSpec.FileSystem); err != nil {
 			err = fmt.Errorf("validateFileSystem failed, err: %v", err)
 			log.Error(err)
 			return err
@@ -345,7 +541,7 @@ func validateFileSystems(jobSpec *JobSpec, userName string) error {
 	}
 
 	for index, _ := range jobSpec.ExtraFileSystems {
-		if err := validateFileSystem(userName, &jobSpec.ExtraFileSystems[index]); err != nil {
+		if err := validateFileSystem(userName, queueName, &jobSpec.ExtraFileSystems[index]); err != nil {
 			err = fmt.Errorf("validate extraFileSystems failed, err: %v", err)
 			log.Error(err)
 			return err
@@ -354,7 +550,9 @@ func validateFileSystems(jobSpec *JobSpec, userName string) error {
 	return nil
 }
 
-func validateFileSystem(userName string, fs *schema.FileSystem) error {
+// validateFileSystem 未指定fsID时使用提交用户名下的同名存储，指定fsID时可使用其他用户共享的存储，
+// 提交用户需为所有者或通过本人、作业队列获得授权，只读授权的存储只能以只读方式挂载
+func validateFileSystem(userName, queueName string, fs *schema.FileSystem) error {
 	fsName := fs.Name
 	fsID := fs.ID
 	if fsID == "" {
@@ -371,12 +569,24 @@ func validateFileSystem(userName string, fs *schema.FileSystem) error {
 		log.Errorf("validateFileSystem failed, err: %v", err)
 		return err
 	}
+	if err := validateFsSubPath(fs); err != nil {
+		log.Errorf("validateFileSystem failed, err: %v", err)
+		return err
+	}
 
 	fileSystem, err := storage.Filesystem.GetFileSystemWithFsID(fsID)
 	if err != nil {
 		log.Errorf("get filesystem by userName[%s] fsName[%s] fsID[%s] failed, err: %v", userName, fsName, fsID, err)
 		return fmt.Errorf("find file system %s failed, err: %v", fsName, err)
 	}
+	permission, err := fsctrl.CheckFileSystemAccess(log.NewEntry(log.StandardLogger()), fileSystem, userName, queueName)
+	if err != nil {
+		log.Errorf("check access of filesystem[%s] for user[%s] queue[%s] failed, err: %v", fsID, userName, queueName, err)
+		return err
+	}
+	if permission == model.FsPermissionRead && !fs.ReadOnly {
+		return fmt.Errorf("user %s only has read permission on file system %s, readOnly must be set", userName, fileSystem.Name)
+	}
 	// fill back
 	fs.ID = fileSystem.ID
 	fs.Name = fileSystem.Name
@@ -388,6 +598,172 @@ func validateFileSystem(userName string, fs *schema.FileSystem) error {
 	return nil
 }
 
+// validateFsSubPath subPath必须是存储内的相对路径，容器中只能看到该子目录
+func validateFsSubPath(fs *schema.FileSystem) error {
+	if fs.SubPath == "" {
+		return nil
+	}
+	subPath := filepath.Clean(fs.SubPath)
+	if filepath.IsAbs(subPath) || subPath == "." || subPath == ".." || strings.HasPrefix(subPath, "../") {
+		return fmt.Errorf("subPath %s in fsName: %s must be a relative path within the file system", fs.SubPath, fs.Name)
+	}
+	fs.SubPath = subPath
+	return nil
+}
+
+// validateEphemeralVolumes 校验临时卷：名称唯一、挂载路径合法且不与文件系统冲突、容量可解析
+func validateEphemeralVolumes(jobSpec *JobSpec) error {
+	if len(jobSpec.EphemeralVolumes) == 0 {
+		return nil
+	}
+	// 临时卷与文件系统共用pod volumes，名称和挂载路径均不能冲突
+	mountPaths := make(map[string]string)
+	names := make(map[string]bool)
+	for _, fs := range append([]schema.FileSystem{jobSpec.FileSystem}, jobSpec.ExtraFileSystems...) {
+		if fs.Name != "" {
+			names[fs.Name] = true
+		}
+		if fs.MountPath != "" {
+			mountPaths[utils.MountPathClean(fs.MountPath)] = fs.Name
+		}
+	}
+	for index := range jobSpec.EphemeralVolumes {
+		volume := &jobSpec.EphemeralVolumes[index]
+		if errs := validation.IsDNS1123Label(volume.Name); len(errs) != 0 {
+			return fmt.Errorf("ephemeral volume name %s is invalid: %s", volume.Name, strings.Join(errs, ","))
+		}
+		if names[volume.Name] {
+			return fmt.Errorf("ephemeral volume name %s is duplicated", volume.Name)
+		}
+		names[volume.Name] = true
+
+		if !filepath.IsAbs(volume.MountPath) {
+			return fmt.Errorf("mountPath of ephemeral volume %s must be an absolute path, got %s", volume.Name, volume.MountPath)
+		}
+		mountPath := utils.MountPathClean(volume.MountPath)
+		if mountPath == "/" {
+			return fmt.Errorf("mountPath of ephemeral volume %s cannot be '/'", volume.Name)
+		}
+		if owner, exist := mountPaths[mountPath]; exist {
+			return fmt.Errorf("mountPath %s of ephemeral volume %s conflicts with %s", volume.MountPath, volume.Name, owner)
+		}
+		mountPaths[mountPath] = volume.Name
+		volume.MountPath = mountPath
+
+		if volume.Size != "" {
+			quantity, err := resource.ParseQuantity(volume.Size)
+			if err != nil || quantity.Sign() <= 0 {
+				return fmt.Errorf("size %s of ephemeral volume %s is invalid", volume.Size, volume.Name)
+			}
+		}
+		if volume.StorageClass != "" {
+			if volume.Size == "" {
+				return fmt.Errorf("size of ephemeral volume %s is required when storageClass is set", volume.Name)
+			}
+			if volume.Medium != "" {
+				return fmt.Errorf("medium of ephemeral volume %s is not supported when storageClass is set", volume.Name)
+			}
+		} else if volume.Medium != "" && volume.Medium != schema.EphemeralMediumMemory {
+			return fmt.Errorf("medium %s of ephemeral volume %s is invalid, only support %s", volume.Medium,
+				volume.Name, schema.EphemeralMediumMemory)
+		}
+	}
+	return nil
+}
+
+// validateCodePackage 校验代码包：所在存储须为作业挂载的存储，解压目录不能与其他挂载路径冲突
+func validateCodePackage(jobSpec *JobSpec) error {
+	codePackage := jobSpec.CodePackage
+	if codePackage == nil {
+		return nil
+	}
+	fsMounted := false
+	mountPaths := make(map[string]string)
+	for _, fs := range append([]schema.FileSystem{jobSpec.FileSystem}, jobSpec.ExtraFileSystems...) {
+		if fs.Name == schema.CodePackageVolumeName {
+			return fmt.Errorf("fs name %s is reserved for code package", fs.Name)
+		}
+		if fs.Name != "" && fs.Name == codePackage.FsName {
+			fsMounted = true
+		}
+		if fs.MountPath != "" {
+			mountPaths[utils.MountPathClean(fs.MountPath)] = fs.Name
+		}
+	}
+	for _, volume := range jobSpec.EphemeralVolumes {
+		if volume.Name == schema.CodePackageVolumeName {
+			return fmt.Errorf("ephemeral volume name %s is reserved for code package", volume.Name)
+		}
+		mountPaths[utils.MountPathClean(volume.MountPath)] = volume.Name
+	}
+	if !fsMounted {
+		return fmt.Errorf("fs %s of code package is not mounted by job", codePackage.FsName)
+	}
+
+	codePath := path.Clean("/" + codePackage.Path)
+	if codePath == "/" {
+		return fmt.Errorf("path of code package is required")
+	}
+	if !strings.HasSuffix(codePath, ".tar.gz") && !strings.HasSuffix(codePath, ".tgz") {
+		return fmt.Errorf("code package %s should be a tar.gz file", codePackage.Path)
+	}
+	codePackage.Path = codePath
+
+	if codePackage.WorkDir == "" {
+		codePackage.WorkDir = schema.DefaultCodeWorkDir
+	}
+	if !filepath.IsAbs(codePackage.WorkDir) {
+		return fmt.Errorf("workDir of code package must be an absolute path, got %s", codePackage.WorkDir)
+	}
+	workDir := utils.MountPathClean(codePackage.WorkDir)
+	if workDir == "/" {
+		return fmt.Errorf("workDir of code package cannot be '/'")
+	}
+	if owner, exist := mountPaths[workDir]; exist {
+		return fmt.Errorf("workDir %s of code package conflicts with %s", codePackage.WorkDir, owner)
+	}
+	codePackage.WorkDir = workDir
+	return nil
+}
+
+// validateOutputArtifacts 校验输出产物：名称唯一，所在存储须为作业以读写方式挂载的存储，路径不能超出挂载目录
+func validateOutputArtifacts(jobSpec *JobSpec) error {
+	if len(jobSpec.OutputArtifacts) == 0 {
+		return nil
+	}
+	fileSystems := make(map[string]schema.FileSystem)
+	for _, fs := range append([]schema.FileSystem{jobSpec.FileSystem}, jobSpec.ExtraFileSystems...) {
+		if fs.Name != "" {
+			fileSystems[fs.Name] = fs
+		}
+	}
+	names := make(map[string]bool)
+	for index := range jobSpec.OutputArtifacts {
+		artifact := &jobSpec.OutputArtifacts[index]
+		if artifact.Name == "" {
+			return fmt.Errorf("name of output artifact is required")
+		}
+		if names[artifact.Name] {
+			return fmt.Errorf("output artifact name %s is duplicated", artifact.Name)
+		}
+		names[artifact.Name] = true
+
+		fs, ok := fileSystems[artifact.FsName]
+		if !ok {
+			return fmt.Errorf("fs %s of output artifact %s is not mounted by job", artifact.FsName, artifact.Name)
+		}
+		if fs.ReadOnly {
+			return fmt.Errorf("fs %s of output artifact %s is mounted read-only", artifact.FsName, artifact.Name)
+		}
+		artifactPath := path.Clean("/" + artifact.Path)
+		if artifactPath == "/" {
+			return fmt.Errorf("path of output artifact %s is required", artifact.Name)
+		}
+		artifact.Path = artifactPath
+	}
+	return nil
+}
+
 func checkEmptyField(request *JobSpec) []string {
 	var emptyFields []string
 	if request.Image == "" {
@@ -396,18 +772,43 @@ func checkEmptyField(request *JobSpec) []string {
 	return emptyFields
 }
 
+// validateServing validate replicas and autoscaling policy of serving job
+func validateServing(ctx *logger.RequestContext, request *CreateJobInfo) error {
+	var err error
+	serving := request.Serving
+	switch {
+	case serving == nil || len(request.Members) != 1:
+		err = fmt.Errorf("serving job must have one member and serving spec")
+	case serving.MinReplicas < 1:
+		err = fmt.Errorf("minReplicas of serving job must be greater than 0")
+	case serving.MaxReplicas < serving.MinReplicas:
+		err = fmt.Errorf("maxReplicas of serving job must be no less than minReplicas")
+	case serving.Metric != "" && serving.Metric != schema.ServingMetricQPS && serving.Metric != schema.ServingMetricGPU:
+		err = fmt.Errorf("metric %s of serving job is not supported, only support %s and %s",
+			serving.Metric, schema.ServingMetricQPS, schema.ServingMetricGPU)
+	case serving.TargetValue < 0:
+		err = fmt.Errorf("targetValue of serving job must be greater than 0")
+	case serving.HealthPath != "" && request.Members[0].Port == 0:
+		err = fmt.Errorf("port must be set when healthPath of serving job is set")
+	}
+	if err != nil {
+		ctx.ErrorCode = common.JobInvalidField
+	}
+	return err
+}
+
 // validateJobFramework validate job type and framework
 func validateJobFramework(ctx *logger.RequestContext, jobType schema.JobType, framework schema.Framework) error {
 	var err error
 	switch jobType {
-	case schema.TypeSingle:
+	case schema.TypeSingle, schema.TypeServing:
 		if framework != schema.FrameworkStandalone {
-			err = fmt.Errorf("framework for single job must be standalone")
+			err = fmt.Errorf("framework for %s job must be standalone", jobType)
 		}
 	case schema.TypeDistributed:
 		switch framework {
 		case schema.FrameworkSpark, schema.FrameworkPaddle, schema.FrameworkTF,
-			schema.FrameworkPytorch, schema.FrameworkMXNet, schema.FrameworkRay:
+			schema.FrameworkPytorch, schema.FrameworkMXNet, schema.FrameworkRay, schema.FrameworkCustom:
 			err = nil
 		case schema.FrameworkMPI:
 			err = fmt.Errorf("framework: %s for distributed job will be supported in the future", framework)
@@ -514,6 +915,9 @@ func buildJob(request *CreateJobInfo) (*model.Job, error) {
 		Members:           members,
 		Framework:         request.Framework,
 		ExtensionTemplate: templateJson,
+		RunID:             request.RunID,
+		StepName:          request.StepName,
+		ConcurrencyGroup:  request.SchedulingPolicy.ConcurrencyGroup,
 	}
 	return jobInfo, nil
 }
@@ -522,18 +926,22 @@ func buildMainConf(request *CreateJobInfo) *schema.Conf {
 	var conf = &schema.Conf{
 		Name: request.Name,
 	}
-	if request.Type == schema.TypeSingle && len(request.Members) == 1 {
-		// build conf for single job
+	if (request.Type == schema.TypeSingle || request.Type == schema.TypeServing) && len(request.Members) == 1 {
+		// build conf for single job and serving job
 		conf = &schema.Conf{
-			Name:            request.Name,
-			FileSystem:      request.Members[0].FileSystem,
-			ExtraFileSystem: request.Members[0].ExtraFileSystems,
-			Flavour:         request.Members[0].Flavour,
-			Env:             request.Members[0].Env,
-			Image:           request.Members[0].Image,
-			Command:         request.Members[0].Command,
-			Port:            request.Members[0].Port,
-			Args:            request.Members[0].Args,
+			Name:             request.Name,
+			FileSystem:       request.Members[0].FileSystem,
+			ExtraFileSystem:  request.Members[0].ExtraFileSystems,
+			EphemeralVolumes: request.Members[0].EphemeralVolumes,
+			SharedVolume:     request.Members[0].SharedVolume,
+			CodePackage:      request.Members[0].CodePackage,
+			OutputArtifacts:  request.Members[0].OutputArtifacts,
+			Flavour:          request.Members[0].Flavour,
+			Env:              request.Members[0].Env,
+			Image:            request.Members[0].Image,
+			Command:          request.Members[0].Command,
+			Port:             request.Members[0].Port,
+			Args:             request.Members[0].Args,
 		}
 	}
 	// fields in request.CommonJobInfo
@@ -542,8 +950,17 @@ func buildMainConf(request *CreateJobInfo) *schema.Conf {
 	if request.SchedulingPolicy.Priority != "" {
 		conf.Priority = request.SchedulingPolicy.Priority
 	}
+	conf.ConcurrencyGroup = request.SchedulingPolicy.ConcurrencyGroup
+	conf.ConcurrencyLimit = request.SchedulingPolicy.ConcurrencyLimit
 	// TODO: remove job mode
 	conf.SetEnv(schema.EnvJobMode, request.Mode)
+	if request.Type == schema.TypeServing && request.Serving != nil {
+		conf.SetEnv(schema.EnvServingMinReplicas, strconv.Itoa(request.Serving.MinReplicas))
+		conf.SetEnv(schema.EnvServingMaxReplicas, strconv.Itoa(request.Serving.MaxReplicas))
+		conf.SetEnv(schema.EnvServingMetric, request.Serving.Metric)
+		conf.SetEnv(schema.EnvServingTargetValue, strconv.Itoa(request.Serving.TargetValue))
+		conf.SetEnv(schema.EnvServingHealthPath, request.Serving.HealthPath)
+	}
 	return conf
 }
 
@@ -570,6 +987,7 @@ func buildCommonInfo(conf *schema.Conf, commonJobInfo *CommonJobInfo) {
 	conf.SetPriority(schedulingPolicy.Priority)
 	conf.SetClusterID(schedulingPolicy.ClusterId)
 	conf.SetNamespace(schedulingPolicy.Namespace)
+	conf.SetNodePool(schedulingPolicy.NodePool)
 }
 
 // newMember convert request.Member to models.member
@@ -577,8 +995,12 @@ func newMember(member MemberSpec, role schema.MemberRole) schema.Member {
 	conf := schema.Conf{
 		Name: member.Name,
 		// 存储资源
-		FileSystem:      member.FileSystem,
-		ExtraFileSystem: member.ExtraFileSystems,
+		FileSystem:       member.FileSystem,
+		ExtraFileSystem:  member.ExtraFileSystems,
+		EphemeralVolumes: member.EphemeralVolumes,
+		SharedVolume:     member.SharedVolume,
+		CodePackage:      member.CodePackage,
+		OutputArtifacts:  member.OutputArtifacts,
 		// 计算资源
 		Flavour:  member.Flavour,
 		Priority: member.SchedulingPolicy.Priority,
@@ -736,6 +1158,8 @@ func jobConfToCreateJobInfo(conf schema.PFJobConf) (*CreateJobInfo, error) {
 	jobInfo := &CreateJobInfo{
 		Type:      jobType,
 		Framework: framework,
+		RunID:     conf.GetEnvValue(pplcommon.SysParamNamePFRunID),
+		StepName:  conf.GetEnvValue(pplcommon.SysParamNamePFStepName),
 	}
 
 	fillCommonJobInfo(jobInfo, conf)
@@ -789,6 +1213,7 @@ func fillStandaloneJobInfo(jobInfo *CreateJobInfo, conf schema.PFJobConf) {
 				},
 				FileSystem:       conf.GetFileSystem(),
 				ExtraFileSystems: conf.GetExtraFS(),
+				SharedVolume:     conf.GetSharedVolume(),
 				Image:            conf.GetImage(),
 				Env:              conf.GetEnv(),
 				Command:          conf.GetCommand(),
@@ -821,6 +1246,7 @@ func fillRayJobHeaderMember(jobInfo *CreateJobInfo, conf schema.PFJobConf) {
 			},
 			FileSystem:       conf.GetFileSystem(),
 			ExtraFileSystems: conf.GetExtraFS(),
+			SharedVolume:     conf.GetSharedVolume(),
 			Image:            conf.GetEnvValue(schema.EnvRayJobHeaderImage),
 			Command:          conf.GetEnvValue(schema.EnvRayJobEntryPoint),
 			Env:              conf.GetEnv(),
@@ -857,6 +1283,7 @@ func fillRayJobWorkerMember(jobInfo *CreateJobInfo, conf schema.PFJobConf) error
 			},
 			FileSystem:       conf.GetFileSystem(),
 			ExtraFileSystems: conf.GetExtraFS(),
+			SharedVolume:     conf.GetSharedVolume(),
 			Image:            conf.GetEnvValue(schema.EnvRayJobWorkerImage),
 			Env:              conf.GetEnv(),
 			Args:             args,
@@ -1,13 +1,18 @@
 package job
 
 import (
+	"path/filepath"
 	"testing"
 
+	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/config"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/storage/driver"
 )
 
@@ -82,3 +87,344 @@ func TestCreatePFJob(t *testing.T) {
 	}
 
 }
+
+func TestValidateServing(t *testing.T) {
+	ctx := &logger.RequestContext{UserName: mockRootUser}
+	tests := []struct {
+		name    string
+		req     CreateServingJobRequest
+		wantErr bool
+	}{
+		{
+			name: "default replicas",
+			req:  CreateServingJobRequest{},
+		},
+		{
+			name: "max replicas less than min replicas",
+			req: CreateServingJobRequest{
+				Serving: ServingSpec{MinReplicas: 3, MaxReplicas: 2},
+			},
+			wantErr: true,
+		},
+		{
+			name: "unsupported metric",
+			req: CreateServingJobRequest{
+				Serving: ServingSpec{MinReplicas: 1, MaxReplicas: 2, Metric: "cpu"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "health path without port",
+			req: CreateServingJobRequest{
+				Serving: ServingSpec{MinReplicas: 1, MaxReplicas: 2, HealthPath: "/health"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "autoscaling with gpu",
+			req: CreateServingJobRequest{
+				JobSpec: JobSpec{Port: 8080},
+				Serving: ServingSpec{MinReplicas: 1, MaxReplicas: 4, Metric: schema.ServingMetricGPU,
+					TargetValue: 60, HealthPath: "/health"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jobInfo := tt.req.ToJobInfo()
+			err := validateServing(ctx, jobInfo)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			conf := buildMainConf(jobInfo)
+			assert.Equal(t, schema.TypeServing, jobInfo.Type)
+			assert.Equal(t, tt.req.Port, conf.Port)
+			assert.NotEmpty(t, conf.GetEnvValue(schema.EnvServingMinReplicas))
+			assert.NotEmpty(t, conf.GetEnvValue(schema.EnvServingMaxReplicas))
+		})
+	}
+}
+
+func TestValidateFsSubPath(t *testing.T) {
+	tests := []struct {
+		subPath string
+		want    string
+		wantErr bool
+	}{
+		{subPath: "", want: ""},
+		{subPath: "dataset/train/", want: "dataset/train"},
+		{subPath: "a/../b", want: "b"},
+		{subPath: "/dataset", wantErr: true},
+		{subPath: "../other", wantErr: true},
+		{subPath: "a/../..", wantErr: true},
+		{subPath: ".", wantErr: true},
+	}
+	for _, tt := range tests {
+		fs := &schema.FileSystem{Name: "data", SubPath: tt.subPath}
+		err := validateFsSubPath(fs)
+		if tt.wantErr {
+			assert.Error(t, err, tt.subPath)
+			continue
+		}
+		assert.NoError(t, err)
+		assert.Equal(t, tt.want, fs.SubPath)
+	}
+}
+
+func TestValidateEphemeralVolumes(t *testing.T) {
+	fs := schema.FileSystem{Name: "data", MountPath: "/home/data"}
+	tests := []struct {
+		volume  schema.EphemeralVolume
+		wantErr bool
+	}{
+		{volume: schema.EphemeralVolume{Name: "shuffle", MountPath: "/mnt/shuffle/", Size: "10Gi"}},
+		{volume: schema.EphemeralVolume{Name: "shm", MountPath: "/dev/shm", Medium: "Memory"}},
+		{volume: schema.EphemeralVolume{Name: "scratch", MountPath: "/mnt/scratch", Size: "1Ti", StorageClass: "ssd"}},
+		{volume: schema.EphemeralVolume{Name: "Bad_Name", MountPath: "/mnt/tmp"}, wantErr: true},
+		{volume: schema.EphemeralVolume{Name: "data", MountPath: "/mnt/tmp"}, wantErr: true},
+		{volume: schema.EphemeralVolume{Name: "tmp", MountPath: "tmp"}, wantErr: true},
+		{volume: schema.EphemeralVolume{Name: "tmp", MountPath: "/"}, wantErr: true},
+		{volume: schema.EphemeralVolume{Name: "tmp", MountPath: "/home/data"}, wantErr: true},
+		{volume: schema.EphemeralVolume{Name: "tmp", MountPath: "/mnt/tmp", Size: "abc"}, wantErr: true},
+		{volume: schema.EphemeralVolume{Name: "tmp", MountPath: "/mnt/tmp", StorageClass: "ssd"}, wantErr: true},
+		{volume: schema.EphemeralVolume{Name: "tmp", MountPath: "/mnt/tmp", Medium: "HugePages"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		jobSpec := &JobSpec{FileSystem: fs, EphemeralVolumes: []schema.EphemeralVolume{tt.volume}}
+		err := validateEphemeralVolumes(jobSpec)
+		if tt.wantErr {
+			assert.Error(t, err, tt.volume.Name)
+			continue
+		}
+		assert.NoError(t, err)
+	}
+
+	// duplicated name
+	jobSpec := &JobSpec{EphemeralVolumes: []schema.EphemeralVolume{
+		{Name: "tmp", MountPath: "/mnt/tmp1"},
+		{Name: "tmp", MountPath: "/mnt/tmp2"},
+	}}
+	assert.Error(t, validateEphemeralVolumes(jobSpec))
+}
+
+func TestValidateCodePackage(t *testing.T) {
+	fs := schema.FileSystem{Name: "data", MountPath: "/home/data"}
+	tests := []struct {
+		codePackage schema.CodePackage
+		wantErr     bool
+	}{
+		{codePackage: schema.CodePackage{FsName: "data", Path: ".paddleflow/code/a.tar.gz"}},
+		{codePackage: schema.CodePackage{FsName: "data", Path: "/code/a.tgz", WorkDir: "/workspace/"}},
+		{codePackage: schema.CodePackage{FsName: "other", Path: "/code/a.tar.gz"}, wantErr: true},
+		{codePackage: schema.CodePackage{FsName: "data"}, wantErr: true},
+		{codePackage: schema.CodePackage{FsName: "data", Path: "/code/a.zip"}, wantErr: true},
+		{codePackage: schema.CodePackage{FsName: "data", Path: "/code/a.tar.gz", WorkDir: "code"}, wantErr: true},
+		{codePackage: schema.CodePackage{FsName: "data", Path: "/code/a.tar.gz", WorkDir: "/"}, wantErr: true},
+		{codePackage: schema.CodePackage{FsName: "data", Path: "/code/a.tar.gz", WorkDir: "/home/data"}, wantErr: true},
+		{codePackage: schema.CodePackage{FsName: "data", Path: "/code/a.tar.gz", WorkDir: "/mnt/tmp"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		codePackage := tt.codePackage
+		jobSpec := &JobSpec{
+			FileSystem:       fs,
+			EphemeralVolumes: []schema.EphemeralVolume{{Name: "tmp", MountPath: "/mnt/tmp"}},
+			CodePackage:      &codePackage,
+		}
+		err := validateCodePackage(jobSpec)
+		if tt.wantErr {
+			assert.Error(t, err, tt.codePackage.Path)
+			continue
+		}
+		assert.NoError(t, err)
+		assert.True(t, filepath.IsAbs(codePackage.Path))
+		assert.NotEmpty(t, codePackage.WorkDir)
+	}
+}
+
+func TestValidateSharedFileSystem(t *testing.T) {
+	driver.InitMockDB()
+	fs := model.FileSystem{Model: model.Model{ID: "fs-owner-data"}, Name: "data", UserName: "owner"}
+	assert.NoError(t, storage.Filesystem.CreatFileSystem(&fs))
+
+	// fs of other users is not accessible without grants
+	jobSpec := &JobSpec{FileSystem: schema.FileSystem{ID: fs.ID, Name: fs.Name}}
+	assert.Error(t, validateFileSystems(jobSpec, "alice", "train"))
+	// fsID is not derived from other user's fs
+	jobSpec = &JobSpec{FileSystem: schema.FileSystem{Name: fs.Name}}
+	assert.Error(t, validateFileSystems(jobSpec, "alice", "train"))
+
+	assert.NoError(t, storage.FsAcl.SaveAcl(log.NewEntry(log.StandardLogger()), &model.FsAcl{
+		FsID: fs.ID, GranteeType: model.FsGranteeQueue, GranteeName: "train", Permission: model.FsPermissionRead}))
+	jobSpec = &JobSpec{FileSystem: schema.FileSystem{ID: fs.ID, Name: fs.Name}}
+	assert.Error(t, validateFileSystems(jobSpec, "alice", "train"))
+	jobSpec = &JobSpec{FileSystem: schema.FileSystem{ID: fs.ID, Name: fs.Name, ReadOnly: true}}
+	assert.NoError(t, validateFileSystems(jobSpec, "alice", "train"))
+	assert.Error(t, validateFileSystems(jobSpec, "alice", "default"))
+	// owner has full access
+	jobSpec = &JobSpec{FileSystem: schema.FileSystem{Name: fs.Name}}
+	assert.NoError(t, validateFileSystems(jobSpec, "owner", "default"))
+	assert.Equal(t, fs.ID, jobSpec.FileSystem.ID)
+}
+
+func TestFillJobDefaults(t *testing.T) {
+	driver.InitMockDB()
+	config.GlobalServerConfig = &config.ServerConfig{}
+	config.GlobalServerConfig.Job.Defaults = config.JobDefaults{Queue: "default-queue", Image: "paddle:latest"}
+	ctx := &logger.RequestContext{UserName: "alice"}
+
+	// no preference, use server defaults
+	request := CreateSingleJobRequest{}
+	assert.NoError(t, FillJobDefaults(ctx, &request.CommonJobInfo, &request.JobSpec))
+	assert.Equal(t, "default-queue", request.SchedulingPolicy.Queue)
+	assert.Equal(t, "paddle:latest", request.Image)
+	assert.Equal(t, "", request.Flavour.Name)
+
+	assert.NoError(t, storage.Auth.SaveUserPreference(ctx, &model.UserPreference{
+		UserName: "alice", Queue: "train", Flavour: "flavour1", FileSystem: "data"}))
+	request = CreateSingleJobRequest{}
+	assert.NoError(t, FillJobDefaults(ctx, &request.CommonJobInfo, &request.JobSpec))
+	assert.Equal(t, "train", request.SchedulingPolicy.Queue)
+	assert.Equal(t, "flavour1", request.Flavour.Name)
+	assert.Equal(t, "paddle:latest", request.Image)
+	assert.Equal(t, "data", request.FileSystem.Name)
+
+	// fields in request take precedence
+	request = CreateSingleJobRequest{
+		CommonJobInfo: CommonJobInfo{SchedulingPolicy: SchedulingPolicy{Queue: "q1"}},
+		JobSpec: JobSpec{
+			Flavour: schema.Flavour{ResourceInfo: schema.ResourceInfo{CPU: "1", Mem: "1Gi"}},
+			Image:   "my-image",
+		},
+	}
+	assert.NoError(t, FillJobDefaults(ctx, &request.CommonJobInfo, &request.JobSpec))
+	assert.Equal(t, "q1", request.SchedulingPolicy.Queue)
+	assert.Equal(t, "", request.Flavour.Name)
+	assert.Equal(t, "my-image", request.Image)
+	assert.Equal(t, "data", request.FileSystem.Name)
+}
+
+func TestCheckUserQuota(t *testing.T) {
+	driver.InitMockDB()
+	ctx := &logger.RequestContext{UserName: "alice"}
+	jobInfo := &model.Job{
+		UserName: "alice",
+		Members: []schema.Member{
+			{
+				Replicas: 2,
+				Conf: schema.Conf{Flavour: schema.Flavour{ResourceInfo: schema.ResourceInfo{
+					CPU: "4", Mem: "8Gi", ScalarResources: schema.ScalarResourcesType{"nvidia.com/gpu": "4"}}}},
+			},
+		},
+	}
+	// no quota
+	assert.NoError(t, checkUserQuota(ctx, jobInfo))
+
+	assert.NoError(t, storage.Auth.SaveUserQuota(ctx, &model.UserQuota{UserName: "alice", MaxGPUs: 4}))
+	assert.Error(t, checkUserQuota(ctx, jobInfo))
+	assert.Equal(t, common.UserQuotaExceeded, ctx.ErrorCode)
+
+	assert.NoError(t, storage.Auth.SaveUserQuota(ctx, &model.UserQuota{UserName: "alice", MaxGPUs: 8}))
+	assert.NoError(t, checkUserQuota(ctx, jobInfo))
+}
+
+func TestValidateCostCenter(t *testing.T) {
+	config.GlobalServerConfig = &config.ServerConfig{}
+	assert.NoError(t, validateCostCenter(nil))
+
+	config.GlobalServerConfig.Job.Policy = config.JobPolicyConfig{RequireCostCenter: true}
+	assert.Error(t, validateCostCenter(map[string]string{"team": "nlp"}))
+	assert.NoError(t, validateCostCenter(map[string]string{"cost-center": "nlp"}))
+
+	config.GlobalServerConfig.Job.Policy.CostCenterLabel = "billing"
+	config.GlobalServerConfig.Job.Policy.CostCenters = []string{"cv", "nlp"}
+	assert.Error(t, validateCostCenter(map[string]string{"cost-center": "nlp"}))
+	assert.Error(t, validateCostCenter(map[string]string{"billing": "ocr"}))
+	assert.NoError(t, validateCostCenter(map[string]string{"billing": "cv"}))
+}
+
+func TestValidateCustomJob(t *testing.T) {
+	config.GlobalServerConfig = &config.ServerConfig{}
+	ctx := &logger.RequestContext{UserName: "root"}
+	request := &CreateJobInfo{
+		Type:      schema.TypeDistributed,
+		Framework: schema.FrameworkCustom,
+		ExtensionTemplate: map[string]interface{}{
+			"apiVersion": "kubeflow.org/v1",
+			"kind":       "MPIJob",
+		},
+	}
+	assert.NoError(t, validateJobFramework(ctx, request.Type, request.Framework))
+	assert.Error(t, validateCustomJob(ctx, request))
+
+	config.GlobalServerConfig.Job.StatusMappings = []config.JobStatusMapping{
+		{APIVersion: "kubeflow.org/v1", Kind: "MPIJob", PhasePath: "status.phase"},
+	}
+	assert.NoError(t, validateCustomJob(ctx, request))
+
+	request.ExtensionTemplate = map[string]interface{}{"kind": "MPIJob"}
+	assert.Error(t, validateCustomJob(ctx, request))
+}
+
+func TestJobConfToCreateJobInfo(t *testing.T) {
+	conf := &schema.Conf{
+		Name: "run-000001-train",
+		Env: map[string]string{
+			"PF_RUN_ID":    "run-000001",
+			"PF_STEP_NAME": "train",
+		},
+	}
+	jobInfo, err := jobConfToCreateJobInfo(conf)
+	assert.NoError(t, err)
+	assert.Equal(t, "run-000001", jobInfo.RunID)
+	assert.Equal(t, "train", jobInfo.StepName)
+
+	job, err := buildJob(jobInfo)
+	assert.NoError(t, err)
+	assert.Equal(t, "run-000001", job.RunID)
+	assert.Equal(t, "train", job.StepName)
+}
+
+func TestValidateConcurrencyGroup(t *testing.T) {
+	sp := &SchedulingPolicy{}
+	assert.NoError(t, validateConcurrencyGroup(sp))
+	sp.ConcurrencyLimit = 2
+	assert.Error(t, validateConcurrencyGroup(sp))
+
+	sp = &SchedulingPolicy{ConcurrencyGroup: "retrain"}
+	assert.NoError(t, validateConcurrencyGroup(sp))
+	assert.Equal(t, 1, sp.ConcurrencyLimit)
+	sp.ConcurrencyLimit = -1
+	assert.Error(t, validateConcurrencyGroup(sp))
+}
+
+func TestValidateOutputArtifacts(t *testing.T) {
+	jobSpec := &JobSpec{
+		FileSystem:       schema.FileSystem{Name: "data"},
+		ExtraFileSystems: []schema.FileSystem{{Name: "dataset", ReadOnly: true}},
+		OutputArtifacts: []schema.OutputArtifact{
+			{Name: "model", FsName: "data", Path: "output/../model"},
+		},
+	}
+	assert.NoError(t, validateOutputArtifacts(jobSpec))
+	assert.Equal(t, "/model", jobSpec.OutputArtifacts[0].Path)
+
+	tests := []schema.OutputArtifact{
+		{FsName: "data", Path: "/model"},
+		{Name: "model", FsName: "other", Path: "/model"},
+		{Name: "model", FsName: "dataset", Path: "/model"},
+		{Name: "model", FsName: "data", Path: "/"},
+	}
+	for _, artifact := range tests {
+		jobSpec.OutputArtifacts = []schema.OutputArtifact{artifact}
+		assert.Error(t, validateOutputArtifacts(jobSpec), artifact)
+	}
+	// duplicated name
+	jobSpec.OutputArtifacts = []schema.OutputArtifact{
+		{Name: "model", FsName: "data", Path: "/model1"},
+		{Name: "model", FsName: "data", Path: "/model2"},
+	}
+	assert.Error(t, validateOutputArtifacts(jobSpec))
+}
@@ -18,12 +18,15 @@ package job
 
 import (
 	"encoding/json"
+	"fmt"
+	"strconv"
 	"time"
 
 	log "github.com/sirupsen/logrus"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/controller/project"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
@@ -49,6 +52,7 @@ type ListJobRequest struct {
 	Timestamp int64             `json:"timestamp,omitempty"`
 	StartTime string            `json:"startTime,omitempty"`
 	Labels    map[string]string `json:"labels,omitempty"`
+	Project   string            `json:"project,omitempty"`
 	Marker    string            `json:"marker"`
 	MaxKeys   int               `json:"maxKeys"`
 }
@@ -69,7 +73,17 @@ type GetJobResponse struct {
 	Runtime                *RuntimeInfo            `json:"runtime,omitempty"`
 	DistributedRuntime     *DistributedRuntimeInfo `json:"distributedRuntime,omitempty"`
 	WorkflowRuntime        *WorkflowRuntimeInfo    `json:"workflowRuntime,omitempty"`
-	UpdateTime             time.Time               `json:"-"`
+	Serving                *ServingInfo            `json:"serving,omitempty"`
+	// EffectivePriority 按队列优先级提升策略计算的当前优先级
+	EffectivePriority string `json:"effectivePriority,omitempty"`
+	// RequeueTimes 作业因节点故障重新排队的次数，RequeueReason为最近一次的节点故障原因
+	RequeueTimes  int    `json:"requeueTimes,omitempty"`
+	RequeueReason string `json:"requeueReason,omitempty"`
+	// BurstFromQueue 作业溢出到其他集群前所在的队列
+	BurstFromQueue string `json:"burstFromQueue,omitempty"`
+	// Timeline 作业时间线上的事件，如通知集群扩容
+	Timeline   []model.JobTimelineEvent `json:"timeline,omitempty"`
+	UpdateTime time.Time                `json:"-"`
 }
 
 type RuntimeInfo struct {
@@ -96,6 +110,19 @@ type WorkflowRuntimeInfo struct {
 	Nodes     []DistributedRuntimeInfo `json:"nodes,omitempty"`
 }
 
+// ServingInfo defines the serving policy, endpoint and health of serving job
+type ServingInfo struct {
+	ServingSpec `json:",inline"`
+	Endpoint    string `json:"endpoint,omitempty"`
+	Health      string `json:"health"`
+}
+
+const (
+	ServingHealthy     = "healthy"
+	ServingStarting    = "starting"
+	ServingUnavailable = "unavailable"
+)
+
 func ListJob(ctx *logger.RequestContext, request ListJobRequest) (*ListJobResponse, error) {
 	ctx.Logging().Debugf("begin list job.")
 	if err := common.CheckPermission(ctx.UserName, ctx.UserName, common.ResourceTypeJob, ""); err != nil {
@@ -131,11 +158,21 @@ func ListJob(ctx *logger.RequestContext, request ListJobRequest) (*ListJobRespon
 		}
 		queueID = queue.ID
 	}
+	userFilter := ctx.UserName
+	if request.Project != "" {
+		if err = project.CheckProjectMember(ctx, request.Project); err != nil {
+			return nil, err
+		}
+		// 项目管理员可以查看项目队列中所有用户的作业
+		if project.IsProjectAdmin(ctx, request.Project) {
+			userFilter = ""
+		}
+	}
 	// model list
-	jobList, err := storage.Job.ListJob(pk, request.MaxKeys, queueID, request.Status, request.StartTime, timestampStr, ctx.UserName, request.Labels)
+	jobList, err := storage.Job.ListJob(pk, request.MaxKeys, queueID, request.Status, request.StartTime, timestampStr, userFilter, request.Labels, request.Project)
 	if err != nil {
 		ctx.Logging().Errorf("list job failed. err:[%s]", err.Error())
-		ctx.ErrorCode = common.InternalError
+		ctx.ErrorCode = common.ErrorCodeOf(err, common.InternalError)
 		return nil, err
 	}
 	listJobResponse := ListJobResponse{JobList: []*GetJobResponse{}}
@@ -172,9 +209,13 @@ func ListJob(ctx *logger.RequestContext, request ListJobRequest) (*ListJobRespon
 func GetJob(ctx *logger.RequestContext, jobID string) (*GetJobResponse, error) {
 	job, err := storage.Job.GetJobByID(jobID)
 	if err != nil {
-		ctx.ErrorCode = common.JobNotFound
+		ctx.ErrorCode = jobErrorCode(err, common.JobNotFound)
 		ctx.Logging().Errorln(err.Error())
-		return nil, common.NotFoundError(common.ResourceTypeJob, jobID)
+		msg := err.Error()
+		if ctx.ErrorCode == common.JobNotFound {
+			msg = common.NotFoundError(common.ResourceTypeJob, jobID).Error()
+		}
+		return nil, common.NewServiceError(ctx.ErrorCode, msg, map[string]string{"jobID": jobID})
 	}
 	if err = common.CheckPermission(ctx.UserName, job.UserName, common.ResourceTypeJob, job.ID); err != nil {
 		ctx.ErrorCode = common.ActionNotAllowed
@@ -186,9 +227,51 @@ func GetJob(ctx *logger.RequestContext, jobID string) (*GetJobResponse, error) {
 	if err != nil {
 		return nil, err
 	}
+	if job.Config != nil {
+		var priorityAging *model.PriorityAging
+		if queue, err := storage.Queue.GetQueueByID(job.QueueID); err == nil {
+			priorityAging = queue.PriorityAging
+		}
+		response.EffectivePriority = jobEffectivePriority(&job, priorityAging, time.Now())
+	}
+	if timeline, err := storage.JobTimeline.ListJobTimeline(job.ID); err == nil {
+		response.Timeline = timeline
+	} else {
+		ctx.Logging().Warnf("list timeline of job %s failed, err: %v", job.ID, err)
+	}
 	return &response, nil
 }
 
+// MaxWatchJobTimeout 单次watch请求最长的等待时间
+const MaxWatchJobTimeout = 60 * time.Second
+
+var watchJobInterval = time.Second
+
+// WatchJob 等待作业状态变得与status不同后返回作业详情，超时或done关闭时返回当前详情，客户端据此长轮询作业状态
+func WatchJob(ctx *logger.RequestContext, jobID, status string, timeout time.Duration, done <-chan struct{}) (*GetJobResponse, error) {
+	if timeout > MaxWatchJobTimeout {
+		timeout = MaxWatchJobTimeout
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		response, err := GetJob(ctx, jobID)
+		if err != nil {
+			return nil, err
+		}
+		if response.Status != status {
+			return response, nil
+		}
+		select {
+		case <-timer.C:
+			return response, nil
+		case <-done:
+			return response, nil
+		case <-time.After(watchJobInterval):
+		}
+	}
+}
+
 func isLastJobPk(ctx *logger.RequestContext, pk int64) bool {
 	lastJob, err := storage.Job.GetLastJob()
 	if err != nil {
@@ -223,8 +306,10 @@ func convertJobToResponse(job model.Job, runtimeFlag bool) (GetJobResponse, erro
 	response.ID = job.ID
 	response.Name = job.Name
 	response.SchedulingPolicy = SchedulingPolicy{
-		Queue:    job.Config.GetQueueName(),
-		Priority: job.Config.Priority,
+		Queue:            job.Config.GetQueueName(),
+		Priority:         job.Config.Priority,
+		ConcurrencyGroup: job.Config.ConcurrencyGroup,
+		ConcurrencyLimit: job.Config.ConcurrencyLimit,
 	}
 	if job.Config != nil {
 		response.Labels = job.Config.Labels
@@ -246,6 +331,32 @@ func convertJobToResponse(job model.Job, runtimeFlag bool) (GetJobResponse, erro
 			return response, err
 		}
 		response.CreateSingleJobRequest.JobSpec = jobSpec
+	case string(schema.TypeServing):
+		if runtimeFlag && job.RuntimeInfo != nil {
+			runtimes, err := getTaskRuntime(job.ID)
+			if err != nil {
+				return response, err
+			}
+			k8sMeta, err := parseK8sMeta(job.RuntimeInfo)
+			if err != nil {
+				log.Errorf("parse serving job[%s] runtimeinfo job meta failed, error:[%s]", job.ID, err.Error())
+				return response, err
+			}
+			response.DistributedRuntime = &DistributedRuntimeInfo{
+				ID:        string(k8sMeta.UID),
+				Name:      k8sMeta.Name,
+				Namespace: k8sMeta.Namespace,
+				Status:    job.Message,
+				Runtimes:  runtimes,
+			}
+		}
+		var jobSpec JobSpec
+		if err := json.Unmarshal([]byte(job.ConfigJson), &jobSpec); err != nil {
+			log.Errorf("parse job[%s] config failed, error:[%s]", job.ID, err.Error())
+			return response, err
+		}
+		response.CreateSingleJobRequest.JobSpec = jobSpec
+		response.Serving = getServingInfo(job)
 	case string(schema.TypeDistributed):
 		if runtimeFlag && job.RuntimeInfo != nil {
 			k8sMeta, err := parseK8sMeta(job.RuntimeInfo)
@@ -309,6 +420,34 @@ func convertJobToResponse(job model.Job, runtimeFlag bool) (GetJobResponse, erro
 	return response, nil
 }
 
+func getServingInfo(job model.Job) *ServingInfo {
+	if job.Config == nil {
+		return nil
+	}
+	conf := job.Config
+	servingInfo := &ServingInfo{
+		ServingSpec: ServingSpec{
+			Metric:     conf.GetEnvValue(schema.EnvServingMetric),
+			HealthPath: conf.GetEnvValue(schema.EnvServingHealthPath),
+		},
+	}
+	servingInfo.MinReplicas, _ = strconv.Atoi(conf.GetEnvValue(schema.EnvServingMinReplicas))
+	servingInfo.MaxReplicas, _ = strconv.Atoi(conf.GetEnvValue(schema.EnvServingMaxReplicas))
+	servingInfo.TargetValue, _ = strconv.Atoi(conf.GetEnvValue(schema.EnvServingTargetValue))
+	if conf.Port > 0 {
+		servingInfo.Endpoint = fmt.Sprintf("http://%s.%s.svc:%d", job.ID, conf.GetNamespace(), conf.Port)
+	}
+	switch job.Status {
+	case schema.StatusJobRunning:
+		servingInfo.Health = ServingHealthy
+	case schema.StatusJobInit, schema.StatusJobPending:
+		servingInfo.Health = ServingStarting
+	default:
+		servingInfo.Health = ServingUnavailable
+	}
+	return servingInfo
+}
+
 func parseK8sMeta(runtimeInfo interface{}) (metav1.ObjectMeta, error) {
 	var k8sMeta metav1.ObjectMeta
 	metaData := runtimeInfo.(map[string]interface{})["metadata"]
@@ -0,0 +1,118 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package job
+
+import (
+	"time"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+)
+
+// JobDetail v2作业详情，字段按元数据、规格、状态分组，时间为RFC3339格式
+type JobDetail struct {
+	Metadata JobDetailMetadata `json:"metadata"`
+	Spec     JobDetailSpec     `json:"spec"`
+	Status   JobDetailStatus   `json:"status"`
+}
+
+type JobDetailMetadata struct {
+	ID          string            `json:"id"`
+	Name        string            `json:"name"`
+	UserName    string            `json:"userName"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	CreateTime  string            `json:"createTime"`
+}
+
+type JobDetailSpec struct {
+	SchedulingPolicy SchedulingPolicy `json:"schedulingPolicy"`
+	Framework        schema.Framework `json:"framework,omitempty"`
+	// Members 各类作业的规格均在members中，单机作业及服务作业只有一个member
+	Members []schema.Member `json:"members"`
+	Serving *ServingInfo    `json:"serving,omitempty"`
+}
+
+type JobDetailStatus struct {
+	Phase              string                  `json:"phase"`
+	Message            string                  `json:"message"`
+	StartTime          string                  `json:"startTime,omitempty"`
+	FinishTime         string                  `json:"finishTime,omitempty"`
+	EffectivePriority  string                  `json:"effectivePriority,omitempty"`
+	RequeueTimes       int                     `json:"requeueTimes,omitempty"`
+	RequeueReason      string                  `json:"requeueReason,omitempty"`
+	BurstFromQueue     string                  `json:"burstFromQueue,omitempty"`
+	Runtime            *RuntimeInfo            `json:"runtime,omitempty"`
+	DistributedRuntime *DistributedRuntimeInfo `json:"distributedRuntime,omitempty"`
+	WorkflowRuntime    *WorkflowRuntimeInfo    `json:"workflowRuntime,omitempty"`
+}
+
+// GetJobDetail returns job in v2 format
+func GetJobDetail(ctx *logger.RequestContext, jobID string) (*JobDetail, error) {
+	response, err := GetJob(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	return NewJobDetail(response), nil
+}
+
+// NewJobDetail converts v1 job response to v2 format
+func NewJobDetail(response *GetJobResponse) *JobDetail {
+	detail := &JobDetail{
+		Metadata: JobDetailMetadata{
+			ID:          response.ID,
+			Name:        response.Name,
+			UserName:    response.UserName,
+			Labels:      response.Labels,
+			Annotations: response.Annotations,
+			CreateTime:  toRFC3339(response.AcceptTime),
+		},
+		Spec: JobDetailSpec{
+			SchedulingPolicy: response.SchedulingPolicy,
+			Framework:        response.Framework,
+			Members:          response.Members,
+			Serving:          response.Serving,
+		},
+		Status: JobDetailStatus{
+			Phase:              response.Status,
+			Message:            response.Message,
+			StartTime:          toRFC3339(response.StartTime),
+			FinishTime:         toRFC3339(response.FinishTime),
+			EffectivePriority:  response.EffectivePriority,
+			RequeueTimes:       response.RequeueTimes,
+			RequeueReason:      response.RequeueReason,
+			BurstFromQueue:     response.BurstFromQueue,
+			Runtime:            response.Runtime,
+			DistributedRuntime: response.DistributedRuntime,
+			WorkflowRuntime:    response.WorkflowRuntime,
+		},
+	}
+	return detail
+}
+
+// toRFC3339 converts time in model.TimeFormat to RFC3339, and returns empty string if time is not set
+func toRFC3339(value string) string {
+	if value == "" {
+		return ""
+	}
+	t, err := time.ParseInLocation(model.TimeFormat, value, time.Local)
+	if err != nil {
+		return value
+	}
+	return t.Format(time.RFC3339)
+}
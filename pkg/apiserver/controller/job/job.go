@@ -22,10 +22,12 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/controller/dataset"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/resources"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/job/api"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/job/hook"
 	runtime "github.com/PaddlePaddle/PaddleFlow/pkg/job/runtime_v2"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
@@ -72,6 +74,46 @@ func (ds CreateDisJobRequest) ToJobInfo() *CreateJobInfo {
 	}
 }
 
+// CreateServingJobRequest convey request for create serving job
+type CreateServingJobRequest struct {
+	CommonJobInfo `json:",inline"`
+	JobSpec       `json:",inline"`
+	Serving       ServingSpec `json:"serving"`
+}
+
+// ServingSpec defines replicas and autoscaling policy for serving job
+type ServingSpec struct {
+	MinReplicas int    `json:"minReplicas"`
+	MaxReplicas int    `json:"maxReplicas"`
+	Metric      string `json:"metric,omitempty"`
+	TargetValue int    `json:"targetValue,omitempty"`
+	HealthPath  string `json:"healthPath,omitempty"`
+}
+
+func (sj CreateServingJobRequest) ToJobInfo() *CreateJobInfo {
+	if sj.Serving.MinReplicas == 0 {
+		sj.Serving.MinReplicas = 1
+	}
+	if sj.Serving.MaxReplicas == 0 {
+		sj.Serving.MaxReplicas = sj.Serving.MinReplicas
+	}
+	return &CreateJobInfo{
+		CommonJobInfo: sj.CommonJobInfo,
+		Framework:     schema.FrameworkStandalone,
+		Type:          schema.TypeServing,
+		Members: []MemberSpec{
+			{
+				CommonJobInfo: sj.CommonJobInfo,
+				JobSpec:       sj.JobSpec,
+				Role:          string(schema.RoleWorker),
+				Replicas:      sj.Serving.MinReplicas,
+			},
+		},
+		Serving:           &sj.Serving,
+		ExtensionTemplate: sj.JobSpec.ExtensionTemplate,
+	}
+}
+
 // CreateWfJobRequest convey request for create workflow job
 type CreateWfJobRequest struct {
 	CommonJobInfo     `json:",inline"`
@@ -97,20 +139,29 @@ type SchedulingPolicy struct {
 	MaxResources *resources.Resource `json:"-"`
 	ClusterId    string              `json:"-"`
 	Namespace    string              `json:"-"`
+	NodePool     string              `json:"-"`
 	Priority     string              `json:"priority,omitempty"`
+	// ConcurrencyGroup 同一用户同组的作业最多同时运行ConcurrencyLimit个（默认1个），其余作业在init状态排队
+	ConcurrencyGroup string `json:"concurrencyGroup,omitempty"`
+	ConcurrencyLimit int    `json:"concurrencyLimit,omitempty"`
 }
 
 // JobSpec the spec fields for jobs
 type JobSpec struct {
-	Flavour           schema.Flavour         `json:"flavour"`
-	FileSystem        schema.FileSystem      `json:"fs"`
-	ExtraFileSystems  []schema.FileSystem    `json:"extraFS"`
-	Image             string                 `json:"image"`
-	Env               map[string]string      `json:"env"`
-	Command           string                 `json:"command"`
-	Args              []string               `json:"args"`
-	Port              int                    `json:"port"`
-	ExtensionTemplate map[string]interface{} `json:"extensionTemplate"`
+	Flavour           schema.Flavour           `json:"flavour"`
+	FileSystem        schema.FileSystem        `json:"fs"`
+	ExtraFileSystems  []schema.FileSystem      `json:"extraFS"`
+	EphemeralVolumes  []schema.EphemeralVolume `json:"ephemeralVolumes,omitempty"`
+	SharedVolume      *schema.RunSharedVolume  `json:"-"`
+	CodePackage       *schema.CodePackage      `json:"codePackage,omitempty"`
+	OutputArtifacts   []schema.OutputArtifact  `json:"outputArtifacts,omitempty"`
+	Image             string                   `json:"image"`
+	Env               map[string]string        `json:"env"`
+	Command           string                   `json:"command"`
+	Args              []string                 `json:"args"`
+	Port              int                      `json:"port"`
+	Datasets          []dataset.DatasetRef     `json:"datasets,omitempty"`
+	ExtensionTemplate map[string]interface{}   `json:"extensionTemplate"`
 }
 
 type MemberSpec struct {
@@ -135,10 +186,10 @@ type CreateJobResponse struct {
 func DeleteJob(ctx *logger.RequestContext, jobID string) error {
 	job, err := storage.Job.GetJobByID(jobID)
 	if err != nil {
-		ctx.ErrorCode = common.JobNotFound
+		ctx.ErrorCode = jobErrorCode(err, common.JobNotFound)
 		msg := fmt.Sprintf("get job %s failed, err: %v", jobID, err)
 		log.Errorf(msg)
-		return fmt.Errorf(msg)
+		return common.NewServiceError(ctx.ErrorCode, msg, map[string]string{"jobID": jobID})
 	}
 	if err = common.CheckPermission(ctx.UserName, job.UserName, common.ResourceTypeJob, jobID); err != nil {
 		ctx.ErrorCode = common.ActionNotAllowed
@@ -155,19 +206,25 @@ func DeleteJob(ctx *logger.RequestContext, jobID string) error {
 	}
 	err = storage.Job.DeleteJob(jobID)
 	if err != nil {
-		ctx.ErrorCode = common.InternalError
+		ctx.ErrorCode = jobErrorCode(err, common.InternalError)
 		log.Errorf("delete job %s from cluster failed, err: %v", jobID, err)
 		return err
 	}
+	if err = storage.JobMetric.DeleteJobMetrics(ctx.Logging(), jobID); err != nil {
+		log.Warnf("delete metrics of job %s failed, err: %v", jobID, err)
+	}
+	if err = storage.JobArtifact.DeleteJobArtifacts(ctx.Logging(), jobID); err != nil {
+		log.Warnf("delete artifacts of job %s failed, err: %v", jobID, err)
+	}
 	return nil
 }
 
 func StopJob(ctx *logger.RequestContext, jobID string) error {
 	job, err := storage.Job.GetJobByID(jobID)
 	if err != nil {
-		ctx.ErrorCode = common.JobNotFound
+		ctx.ErrorCode = jobErrorCode(err, common.JobNotFound)
 		log.Errorf("get job %s from database failed, err: %v", jobID, err)
-		return err
+		return common.NewServiceError(ctx.ErrorCode, err.Error(), map[string]string{"jobID": jobID})
 	}
 	if err = common.CheckPermission(ctx.UserName, job.UserName, common.ResourceTypeJob, jobID); err != nil {
 		ctx.ErrorCode = common.ActionNotAllowed
@@ -176,12 +233,13 @@ func StopJob(ctx *logger.RequestContext, jobID string) error {
 	}
 	// check job status
 	if schema.IsImmutableJobStatus(job.Status) {
+		ctx.ErrorCode = common.ActionNotAllowed
 		msg := fmt.Sprintf("job %s status is already %s, and job cannot be stopped", jobID, job.Status)
 		log.Errorf(msg)
 		return fmt.Errorf(msg)
 	}
 
-	if job.Status == schema.StatusJobInit {
+	if job.Status == schema.StatusJobInit || job.Status == schema.StatusJobPendingApproval {
 		err = storage.Job.UpdateJobStatus(jobID, "job is terminated.", schema.StatusJobTerminated)
 	} else {
 		var runtimeSvc runtime.RuntimeService
@@ -206,18 +264,22 @@ func StopJob(ctx *logger.RequestContext, jobID string) error {
 		err = storage.Job.UpdateJobStatus(jobID, "job is terminating.", schema.StatusJobTerminating)
 	}
 	if err != nil {
+		ctx.ErrorCode = common.ErrorCodeOf(err, common.DBUpdateFailed)
 		log.Errorf("update job[%s] status to [%s] failed, err: %v", jobID, schema.StatusJobTerminating, err)
 		return err
 	}
+	if job.Status == schema.StatusJobInit || job.Status == schema.StatusJobPendingApproval {
+		hook.NotifyJobFinished(jobID, job.Status, schema.StatusJobTerminated)
+	}
 	return nil
 }
 
 func UpdateJob(ctx *logger.RequestContext, request *UpdateJobRequest) error {
 	job, err := storage.Job.GetJobByID(request.JobID)
 	if err != nil {
-		ctx.ErrorCode = common.JobNotFound
-		log.Errorf("get job %s from database failed, err: %v", job.ID, err)
-		return err
+		ctx.ErrorCode = jobErrorCode(err, common.JobNotFound)
+		log.Errorf("get job %s from database failed, err: %v", request.JobID, err)
+		return common.NewServiceError(ctx.ErrorCode, err.Error(), map[string]string{"jobID": request.JobID})
 	}
 	if err = common.CheckPermission(ctx.UserName, job.UserName, common.ResourceTypeJob, request.JobID); err != nil {
 		ctx.ErrorCode = common.ActionNotAllowed
@@ -247,7 +309,7 @@ func UpdateJob(ctx *logger.RequestContext, request *UpdateJobRequest) error {
 		// update job on cluster
 		err = updateRuntimeJob(ctx, &job, request)
 		if err != nil {
-			ctx.ErrorCode = common.InternalError
+			ctx.ErrorCode = jobErrorCode(err, common.InternalError)
 			log.Errorf("update job %s on cluster failed, err: %v", job.ID, err)
 			return err
 		}
@@ -256,6 +318,8 @@ func UpdateJob(ctx *logger.RequestContext, request *UpdateJobRequest) error {
 	// update job on database
 	if request.Priority != "" {
 		job.Config.Priority = request.Priority
+		// priority set by user overrides the aged priority
+		job.Config.EffectivePriority = ""
 	}
 	for label, value := range request.Labels {
 		job.Config.SetLabels(label, value)
@@ -267,7 +331,7 @@ func UpdateJob(ctx *logger.RequestContext, request *UpdateJobRequest) error {
 	err = storage.Job.UpdateJobConfig(job.ID, job.Config)
 	if err != nil {
 		log.Errorf("update job %s on database failed, err: %v", job.ID, err)
-		ctx.ErrorCode = common.DBUpdateFailed
+		ctx.ErrorCode = common.ErrorCodeOf(err, common.DBUpdateFailed)
 	}
 	return err
 }
@@ -296,6 +360,15 @@ func updateRuntimeJob(ctx *logger.RequestContext, job *model.Job, request *Updat
 	return runtimeSvc.UpdateJob(pfjob)
 }
 
+// jobErrorCode 将数据库、集群返回的错误映射为作业接口的错误码
+func jobErrorCode(err error, defaultCode string) string {
+	code := common.ErrorCodeOf(err, defaultCode)
+	if code == common.RecordNotFound {
+		return common.JobNotFound
+	}
+	return code
+}
+
 func getRuntimeByQueue(ctx *logger.RequestContext, queueID string) (runtime.RuntimeService, error) {
 	queue, err := storage.Queue.GetQueueByID(queueID)
 	if err != nil {
@@ -311,7 +384,7 @@ func getRuntimeByQueue(ctx *logger.RequestContext, queueID string) (runtime.Runt
 	}
 	runtimeSvc, err := runtime.GetOrCreateRuntime(clusterInfo)
 	if err != nil {
-		ctx.ErrorCode = common.InternalError
+		ctx.ErrorCode = common.ErrorCodeOf(err, common.InternalError)
 		ctx.Logging().Errorf("get or create runtime failed, err: %v", err)
 		return nil, fmt.Errorf("delete queue failed")
 	}
@@ -1,10 +1,13 @@
 package job
 
 import (
+	"database/sql"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/config"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/resources"
@@ -75,3 +78,284 @@ func TestDeleteJob(t *testing.T) {
 		})
 	}
 }
+
+func TestWatchJob(t *testing.T) {
+	driver.InitMockDB()
+	watchJobInterval = 10 * time.Millisecond
+	defer func() {
+		watchJobInterval = time.Second
+	}()
+	mockJob := model.Job{
+		ID:       "job-watch-000001",
+		UserName: mockRootUser,
+		Type:     string(schema.TypeSingle),
+		Status:   schema.StatusJobPending,
+		Config:   &schema.Conf{},
+	}
+	assert.NoError(t, storage.Job.CreateJob(&mockJob))
+	ctx := &logger.RequestContext{UserName: mockRootUser}
+
+	// 状态与客户端已知的不同，立即返回
+	resp, err := WatchJob(ctx, mockJob.ID, "", time.Minute, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, string(schema.StatusJobPending), resp.Status)
+
+	// 等待状态变化
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		_ = storage.Job.UpdateJobStatus(mockJob.ID, "job is running", schema.StatusJobRunning)
+	}()
+	resp, err = WatchJob(ctx, mockJob.ID, string(schema.StatusJobPending), 10*time.Second, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, string(schema.StatusJobRunning), resp.Status)
+
+	// 超时返回当前状态
+	resp, err = WatchJob(ctx, mockJob.ID, string(schema.StatusJobRunning), 50*time.Millisecond, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, string(schema.StatusJobRunning), resp.Status)
+
+	ctx = &logger.RequestContext{UserName: mockRootUser}
+	_, err = WatchJob(ctx, "job-not-exist", "", time.Second, nil)
+	assert.Error(t, err)
+	assert.Equal(t, common.JobNotFound, ctx.ErrorCode)
+}
+
+func TestJobEffectivePriority(t *testing.T) {
+	now := time.Now()
+	aging := &model.PriorityAging{Interval: 600}
+	tests := []struct {
+		name          string
+		job           *model.Job
+		priorityAging *model.PriorityAging
+		expected      string
+	}{
+		{
+			name: "waiting less than interval",
+			job: &model.Job{Status: schema.StatusJobPending, CreatedAt: now.Add(-5 * time.Minute),
+				Config: &schema.Conf{Priority: schema.EnvJobLowPriority}},
+			priorityAging: aging,
+			expected:      schema.EnvJobLowPriority,
+		},
+		{
+			name: "raise one level",
+			job: &model.Job{Status: schema.StatusJobPending, CreatedAt: now.Add(-15 * time.Minute),
+				Config: &schema.Conf{Priority: schema.EnvJobLowPriority}},
+			priorityAging: aging,
+			expected:      schema.EnvJobNormalPriority,
+		},
+		{
+			name: "limited by default max priority",
+			job: &model.Job{Status: schema.StatusJobPending, CreatedAt: now.Add(-2 * time.Hour),
+				Config: &schema.Conf{Priority: schema.EnvJobVeryLowPriority}},
+			priorityAging: aging,
+			expected:      schema.EnvJobHighPriority,
+		},
+		{
+			name: "limited by max priority",
+			job: &model.Job{Status: schema.StatusJobInit, CreatedAt: now.Add(-2 * time.Hour),
+				Config: &schema.Conf{Priority: schema.EnvJobVeryLowPriority}},
+			priorityAging: &model.PriorityAging{Interval: 600, MaxPriority: schema.EnvJobNormalPriority},
+			expected:      schema.EnvJobNormalPriority,
+		},
+		{
+			name: "not lower than effective priority",
+			job: &model.Job{Status: schema.StatusJobPending, CreatedAt: now.Add(-15 * time.Minute),
+				Config: &schema.Conf{Priority: schema.EnvJobLowPriority, EffectivePriority: schema.EnvJobHighPriority}},
+			priorityAging: aging,
+			expected:      schema.EnvJobHighPriority,
+		},
+		{
+			name: "running job is not raised",
+			job: &model.Job{Status: schema.StatusJobRunning, CreatedAt: now.Add(-2 * time.Hour),
+				Config: &schema.Conf{Priority: schema.EnvJobLowPriority}},
+			priorityAging: aging,
+			expected:      schema.EnvJobLowPriority,
+		},
+		{
+			name: "queue without priority aging",
+			job: &model.Job{Status: schema.StatusJobPending, CreatedAt: now.Add(-2 * time.Hour),
+				Config: &schema.Conf{Priority: schema.EnvJobLowPriority}},
+			expected: schema.EnvJobLowPriority,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, jobEffectivePriority(test.job, test.priorityAging, now))
+		})
+	}
+}
+
+func TestSimulateQueue(t *testing.T) {
+	driver.InitMockDB()
+	mockQueue := model.Queue{
+		Name:         "simulate-queue",
+		Model:        model.Model{ID: "simulate-queue"},
+		MaxResources: &resources.Resource{Resources: map[string]resources.Quantity{"cpu": 8000, "mem": 16 * 1024 * 1024 * 1024}},
+		Status:       schema.StatusQueueOpen,
+	}
+	assert.NoError(t, storage.Queue.CreateQueue(&mockQueue))
+	newJob := func(id string, status schema.JobStatus, cpu string, activatedAt time.Time) *model.Job {
+		return &model.Job{
+			ID:      id,
+			QueueID: mockQueue.ID,
+			Status:  status,
+			Config:  &schema.Conf{Priority: schema.EnvJobNormalPriority},
+			Members: []schema.Member{{Replicas: 1, Conf: schema.Conf{
+				Flavour: schema.Flavour{ResourceInfo: schema.ResourceInfo{CPU: cpu, Mem: "4Gi"}}}}},
+			ActivatedAt: sql.NullTime{Time: activatedAt, Valid: !activatedAt.IsZero()},
+		}
+	}
+	now := time.Now()
+	request := newJob("job-simulate", schema.StatusJobInit, "8", time.Time{})
+	idle := &resources.Resource{Resources: map[string]resources.Quantity{"cpu": 4000, "mem": 8 * 1024 * 1024 * 1024}}
+
+	// idle resources are enough
+	response := &SimulateJobResponse{Result: SimulateResultRun, RequestResources: jobResources(newJob("job-small", schema.StatusJobInit, "2", time.Time{})), IdleResources: idle}
+	simulateQueue(response, request, &mockQueue, now)
+	assert.Equal(t, SimulateResultRun, response.Result)
+	assert.Equal(t, int64(0), *response.EstimatedWaitTime)
+
+	// no finished jobs to estimate wait time
+	response = &SimulateJobResponse{Result: SimulateResultRun, RequestResources: jobResources(request), IdleResources: idle}
+	simulateQueue(response, request, &mockQueue, now)
+	assert.Equal(t, SimulateResultWait, response.Result)
+	assert.Nil(t, response.EstimatedWaitTime)
+
+	// running job releases resources after the average duration of finished jobs
+	assert.NoError(t, storage.Job.CreateJob(newJob("job-finished", schema.StatusJobSucceeded, "4", now.Add(-time.Hour))))
+	assert.NoError(t, storage.Job.CreateJob(newJob("job-running", schema.StatusJobRunning, "4", now.Add(-10*time.Minute))))
+	response = &SimulateJobResponse{Result: SimulateResultRun, RequestResources: jobResources(request), IdleResources: idle}
+	simulateQueue(response, request, &mockQueue, now)
+	assert.Equal(t, SimulateResultWait, response.Result)
+	assert.NotNil(t, response.EstimatedWaitTime)
+	assert.InDelta(t, 50*60, *response.EstimatedWaitTime, 5)
+
+	// waiting jobs with higher priority are scheduled first
+	assert.NoError(t, storage.Job.CreateJob(newJob("job-pending", schema.StatusJobPending, "2", time.Time{})))
+	small := newJob("job-small", schema.StatusJobInit, "2", time.Time{})
+	response = &SimulateJobResponse{Result: SimulateResultRun, RequestResources: jobResources(small), IdleResources: idle}
+	simulateQueue(response, small, &mockQueue, now)
+	assert.Equal(t, SimulateResultRun, response.Result)
+	assert.Equal(t, 1, response.JobsAhead)
+}
+
+func TestJobApproval(t *testing.T) {
+	driver.InitMockDB()
+	config.GlobalServerConfig = &config.ServerConfig{}
+	config.GlobalServerConfig.Job.Approval.MaxGPUs = 8
+	newJob := func(id, gpus string) *model.Job {
+		return &model.Job{
+			ID:       id,
+			UserName: "user1",
+			Status:   schema.StatusJobPendingApproval,
+			Config:   &schema.Conf{},
+			Members: []schema.Member{{Replicas: 2, Conf: schema.Conf{Flavour: schema.Flavour{ResourceInfo: schema.ResourceInfo{
+				CPU: "4", Mem: "8Gi", ScalarResources: schema.ScalarResourcesType{"nvidia.com/gpu": gpus}}}}}},
+		}
+	}
+
+	_, ok := needApproval(newJob("job-small", "4"))
+	assert.False(t, ok)
+	reason, ok := needApproval(newJob("job-large", "8"))
+	assert.True(t, ok)
+	assert.Contains(t, reason, "16 gpus")
+
+	assert.NoError(t, storage.Job.CreateJob(newJob("job-approve", "8")))
+	assert.NoError(t, storage.Job.CreateJob(newJob("job-reject", "8")))
+	userCtx := &logger.RequestContext{UserName: "user1"}
+	assert.Error(t, ApproveJob(userCtx, "job-approve"))
+	assert.Equal(t, common.OnlyRootAllowed, userCtx.ErrorCode)
+
+	rootCtx := &logger.RequestContext{UserName: mockRootUser}
+	assert.NoError(t, ApproveJob(rootCtx, "job-approve"))
+	job, err := storage.Job.GetJobByID("job-approve")
+	assert.NoError(t, err)
+	assert.Equal(t, schema.StatusJobInit, job.Status)
+	// approved job cannot be reviewed again
+	rootCtx = &logger.RequestContext{UserName: mockRootUser}
+	assert.Error(t, RejectJob(rootCtx, "job-approve"))
+	assert.Equal(t, common.ActionNotAllowed, rootCtx.ErrorCode)
+
+	assert.NoError(t, RejectJob(&logger.RequestContext{UserName: mockRootUser}, "job-reject"))
+	job, err = storage.Job.GetJobByID("job-reject")
+	assert.NoError(t, err)
+	assert.Equal(t, schema.StatusJobFailed, job.Status)
+}
+
+func TestJobBurst(t *testing.T) {
+	driver.InitMockDB()
+	onPremCluster := model.ClusterInfo{Model: model.Model{ID: "cluster-onprem"}, Name: "onprem", Status: model.ClusterStatusOnLine}
+	cloudCluster := model.ClusterInfo{Model: model.Model{ID: "cluster-cloud"}, Name: "cloud", Status: model.ClusterStatusOnLine}
+	assert.NoError(t, storage.Cluster.CreateCluster(&onPremCluster))
+	assert.NoError(t, storage.Cluster.CreateCluster(&cloudCluster))
+	maxResources := &resources.Resource{Resources: map[string]resources.Quantity{"cpu": 8000, "mem": 16 * 1024 * 1024 * 1024}}
+	cloudQueue := model.Queue{Model: model.Model{ID: "queue-cloud"}, Name: "cloud-queue", Namespace: "cloud",
+		ClusterId: cloudCluster.ID, MaxResources: maxResources, Status: schema.StatusQueueOpen}
+	queue := model.Queue{Model: model.Model{ID: "queue-onprem"}, Name: "onprem-queue", Namespace: "default",
+		ClusterId: onPremCluster.ID, MaxResources: maxResources, Status: schema.StatusQueueOpen,
+		BurstPolicy: &model.BurstPolicy{Queue: cloudQueue.Name, WaitSeconds: 600, Labels: map[string]string{"burst": "true"}}}
+	assert.NoError(t, storage.Queue.CreateQueue(&cloudQueue))
+	assert.NoError(t, storage.Queue.CreateQueue(&queue))
+
+	now := time.Now()
+	newJob := func(id string, status schema.JobStatus, labels map[string]string, cpu string, createdAt time.Time) *model.Job {
+		conf := &schema.Conf{Labels: labels, QueueID: queue.ID, QueueName: queue.Name, ClusterID: queue.ClusterId}
+		conf.SetNamespace(queue.Namespace)
+		return &model.Job{
+			ID:        id,
+			QueueID:   queue.ID,
+			Status:    status,
+			Config:    conf,
+			Members:   []schema.Member{{Replicas: 1, Conf: schema.Conf{QueueID: queue.ID, Flavour: schema.Flavour{ResourceInfo: schema.ResourceInfo{CPU: cpu, Mem: "4Gi"}}}}},
+			CreatedAt: createdAt,
+		}
+	}
+	labels := map[string]string{"burst": "true"}
+	assert.True(t, isJobBurstable(newJob("job-1", schema.StatusJobPending, labels, "4", now.Add(-time.Hour)), &queue, now))
+	// waiting less than wait seconds
+	assert.False(t, isJobBurstable(newJob("job-2", schema.StatusJobPending, labels, "4", now.Add(-time.Minute)), &queue, now))
+	// job without eligible labels
+	assert.False(t, isJobBurstable(newJob("job-3", schema.StatusJobPending, nil, "4", now.Add(-time.Hour)), &queue, now))
+	// running job
+	assert.False(t, isJobBurstable(newJob("job-4", schema.StatusJobRunning, labels, "4", now.Add(-time.Hour)), &queue, now))
+
+	// max resources of target queue are insufficient
+	_, err := burstTargetQueue(newJob("job-5", schema.StatusJobPending, labels, "16", now), &queue)
+	assert.Error(t, err)
+	job := newJob("job-burst", schema.StatusJobPending, labels, "4", now.Add(-time.Hour))
+	target, err := burstTargetQueue(job, &queue)
+	assert.NoError(t, err)
+	assert.Equal(t, cloudQueue.ID, target.ID)
+
+	// switch job to target queue and revert it
+	assert.NoError(t, storage.Job.CreateJob(job))
+	saved, err := storage.Job.GetJobByID(job.ID)
+	assert.NoError(t, err)
+	conf, members, err := burstJobConf(&saved, target)
+	assert.NoError(t, err)
+	assert.Equal(t, cloudCluster.ID, conf.GetClusterID())
+	assert.Equal(t, "cloud", conf.GetNamespace())
+	assert.Equal(t, cloudQueue.ID, members[0].Conf.GetQueueID())
+	assert.Equal(t, queue.ID, saved.Config.GetQueueID())
+	marked, err := storage.Job.MarkJobBursting(job.ID, queue.Name, target.ID, conf, members)
+	assert.NoError(t, err)
+	assert.True(t, marked)
+	bursting, err := storage.Job.GetJobByID(job.ID)
+	assert.NoError(t, err)
+	assert.True(t, bursting.Requeuing)
+	assert.Equal(t, cloudQueue.ID, bursting.QueueID)
+	assert.Equal(t, queue.Name, bursting.BurstFromQueue)
+	assert.Equal(t, cloudQueue.Name, bursting.Config.GetQueueName())
+	// job is burst only once
+	marked, err = storage.Job.MarkJobBursting(job.ID, queue.Name, target.ID, conf, members)
+	assert.NoError(t, err)
+	assert.False(t, marked)
+
+	assert.NoError(t, storage.Job.RevertJobBursting(&saved))
+	reverted, err := storage.Job.GetJobByID(job.ID)
+	assert.NoError(t, err)
+	assert.False(t, reverted.Requeuing)
+	assert.Equal(t, queue.ID, reverted.QueueID)
+	assert.Equal(t, "", reverted.BurstFromQueue)
+	assert.Equal(t, queue.ClusterId, reverted.Config.GetClusterID())
+}
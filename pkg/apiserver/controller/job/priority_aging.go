@@ -0,0 +1,115 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package job
+
+import (
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/job/api"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+)
+
+const defaultPriorityAgingInterval = time.Minute
+
+// JobPriorityAgingController 定期按队列的优先级提升策略提升集群上等待调度的作业的优先级
+func JobPriorityAgingController(stopChan chan struct{}) {
+	for {
+		agePendingJobs(time.Now())
+		select {
+		case <-stopChan:
+			log.Info("job priority aging controller stopped")
+			return
+		case <-time.After(defaultPriorityAgingInterval):
+		}
+	}
+}
+
+func agePendingJobs(now time.Time) {
+	queues := make(map[string]*model.PriorityAging)
+	jobs := storage.Job.ListJobByStatus(schema.StatusJobPending)
+	for i := range jobs {
+		job := &jobs[i]
+		priorityAging, find := queues[job.QueueID]
+		if !find {
+			queue, err := storage.Queue.GetQueueByID(job.QueueID)
+			if err != nil {
+				log.Errorf("get queue %s of job %s failed, err: %v", job.QueueID, job.ID, err)
+				continue
+			}
+			priorityAging = queue.PriorityAging
+			queues[job.QueueID] = priorityAging
+		}
+		if priorityAging == nil {
+			continue
+		}
+		priority := jobEffectivePriority(job, priorityAging, now)
+		if priority == currentJobPriority(job) {
+			continue
+		}
+		if err := updateJobEffectivePriority(job, priority); err != nil {
+			log.Errorf("raise priority of job %s to %s failed, err: %v", job.ID, priority, err)
+		}
+	}
+}
+
+// currentJobPriority 返回作业在集群上生效的优先级
+func currentJobPriority(job *model.Job) string {
+	if job.Config == nil {
+		return ""
+	}
+	if job.Config.EffectivePriority != "" {
+		return job.Config.EffectivePriority
+	}
+	return job.Config.Priority
+}
+
+// jobEffectivePriority 返回等待中的作业按优先级提升策略计算的优先级，不会低于当前生效的优先级
+func jobEffectivePriority(job *model.Job, priorityAging *model.PriorityAging, now time.Time) string {
+	current := currentJobPriority(job)
+	if job.Config == nil || job.Status != schema.StatusJobInit && job.Status != schema.StatusJobPending {
+		return current
+	}
+	priority := priorityAging.EffectivePriority(job.Config.Priority, now.Sub(job.CreatedAt))
+	if model.JobPriorityLevel(priority) > model.JobPriorityLevel(current) {
+		return priority
+	}
+	return current
+}
+
+func updateJobEffectivePriority(job *model.Job, priority string) error {
+	ctx := &logger.RequestContext{}
+	runtimeSvc, err := getRuntimeByQueue(ctx, job.QueueID)
+	if err != nil {
+		return err
+	}
+	pfjob, err := api.NewJobInfo(job)
+	if err != nil {
+		return err
+	}
+	pfjob.UpdateJobPriority(priority)
+	if err = runtimeSvc.UpdateJob(pfjob); err != nil {
+		return err
+	}
+	log.Infof("priority of job %s is raised from %s to %s", job.ID, currentJobPriority(job), priority)
+	job.Config.EffectivePriority = priority
+	return storage.Job.UpdateJobConfig(job.ID, job.Config)
+}
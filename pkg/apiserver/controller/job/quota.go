@@ -0,0 +1,83 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package job
+
+import (
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+)
+
+const defaultDurationCheckInterval = time.Minute
+
+// checkUserQuota rejects job which can never be dispatched under the quota of user,
+// the concurrent limits are checked by job manager when dispatching
+func checkUserQuota(ctx *logger.RequestContext, jobInfo *model.Job) error {
+	quota, err := storage.Auth.GetCachedUserQuota(ctx, jobInfo.UserName)
+	if err != nil {
+		if common.ErrorCodeOf(err, common.InternalError) == common.RecordNotFound {
+			return nil
+		}
+		ctx.ErrorCode = common.ErrorCodeOf(err, common.InternalError)
+		return err
+	}
+	if err = quota.CheckJob(jobInfo); err != nil {
+		ctx.ErrorCode = common.UserQuotaExceeded
+		return err
+	}
+	return nil
+}
+
+// JobDurationController 定期停止运行时间超过用户最长运行时间的作业
+func JobDurationController(stopChan chan struct{}) {
+	for {
+		stopTimeoutJobs(time.Now())
+		select {
+		case <-stopChan:
+			log.Info("job duration controller stopped")
+			return
+		case <-time.After(defaultDurationCheckInterval):
+		}
+	}
+}
+
+func stopTimeoutJobs(now time.Time) {
+	ctx := &logger.RequestContext{UserName: common.UserRoot}
+	quotas, err := storage.Auth.ListUserQuotaWithDuration(ctx)
+	if err != nil {
+		log.Errorf("list user quota failed. error: %v", err)
+		return
+	}
+	for _, quota := range quotas {
+		jobs := storage.Job.ListUserJob(quota.UserName, []schema.JobStatus{schema.StatusJobRunning})
+		for i := range jobs {
+			if !quota.IsJobTimeout(&jobs[i], now) {
+				continue
+			}
+			log.Infof("job %s of user %s runs longer than %d seconds, stop it", jobs[i].ID, quota.UserName, quota.MaxJobDuration)
+			if err = StopJob(ctx, jobs[i].ID); err != nil {
+				log.Errorf("stop timeout job %s failed. error: %v", jobs[i].ID, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,230 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package job
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/controller/queue"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/resources"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+)
+
+const (
+	SimulateResultRun    = "run"
+	SimulateResultWait   = "wait"
+	SimulateResultReject = "reject"
+
+	// simulateHistoryJobs 估算等待时间时参考的队列中最近完成的作业数
+	simulateHistoryJobs = 100
+)
+
+// SimulateJobResponse 作业模拟调度的结果
+type SimulateJobResponse struct {
+	// Result is one of run, wait and reject
+	Result  string   `json:"result"`
+	Reasons []string `json:"reasons,omitempty"`
+	// EstimatedWaitTime 预计等待时间，单位秒，无法估算时为空
+	EstimatedWaitTime *int64              `json:"estimatedWaitTime,omitempty"`
+	RequestResources  *resources.Resource `json:"requestResources,omitempty"`
+	IdleResources     *resources.Resource `json:"idleResources,omitempty"`
+	// JobsAhead 队列中排在该作业之前等待调度的作业数
+	JobsAhead int `json:"jobsAhead"`
+}
+
+// SimulateJob 按队列和集群的当前状态模拟调度作业，返回作业提交后能否立即运行、需要等待或会被拒绝，不会创建作业
+func SimulateJob(ctx *logger.RequestContext, request *CreateJobInfo) (*SimulateJobResponse, error) {
+	request.UserName = ctx.UserName
+	if err := common.CheckPermission(ctx.UserName, ctx.UserName, common.ResourceTypeJob, request.ID); err != nil {
+		ctx.ErrorCode = common.ActionNotAllowed
+		ctx.Logging().Errorln(err.Error())
+		return nil, err
+	}
+	if err := validateJob(ctx, request); err != nil {
+		ctx.Logging().Infof("simulated job is rejected by validation, err: %v", err)
+		ctx.ErrorCode = ""
+		return &SimulateJobResponse{Result: SimulateResultReject, Reasons: []string{err.Error()}}, nil
+	}
+	jobInfo, err := buildJob(request)
+	if err != nil {
+		ctx.Logging().Errorf("build simulated job failed, err: %v", err)
+		return nil, err
+	}
+
+	response := &SimulateJobResponse{Result: SimulateResultRun}
+	if reason, ok := needApproval(jobInfo); ok {
+		response.Result = SimulateResultWait
+		response.Reasons = append(response.Reasons, reason)
+	}
+	quota, err := storage.Auth.GetUserQuota(ctx, jobInfo.UserName)
+	if err != nil && common.ErrorCodeOf(err, common.InternalError) != common.RecordNotFound {
+		ctx.ErrorCode = common.ErrorCodeOf(err, common.InternalError)
+		ctx.Logging().Errorf("get quota of user[%s] failed, err: %v", jobInfo.UserName, err)
+		return nil, err
+	}
+	if err == nil {
+		if err = quota.CheckJob(jobInfo); err != nil {
+			response.Result = SimulateResultReject
+			response.Reasons = append(response.Reasons, err.Error())
+			return response, nil
+		}
+		activeJobs := storage.Job.ListUserJob(jobInfo.UserName,
+			[]schema.JobStatus{schema.StatusJobPending, schema.StatusJobRunning, schema.StatusJobTerminating})
+		if err = quota.CheckDispatch(jobInfo, activeJobs); err != nil {
+			response.Result = SimulateResultWait
+			response.Reasons = append(response.Reasons, err.Error())
+		}
+	}
+
+	queueInfo, err := queue.GetQueueByName(ctx, request.SchedulingPolicy.Queue)
+	if err != nil {
+		ctx.Logging().Errorf("get queue %s of simulated job failed, err: %v", request.SchedulingPolicy.Queue, err)
+		return nil, err
+	}
+	response.IdleResources = queueInfo.IdleResources
+	response.RequestResources = jobResources(jobInfo)
+	simulateQueue(response, jobInfo, &queueInfo.Queue, time.Now())
+	return response, nil
+}
+
+// simulateQueue 根据队列空闲资源、排在前面的作业和正在运行的作业判断作业能否立即运行并估算等待时间
+func simulateQueue(response *SimulateJobResponse, jobInfo *model.Job, queueInfo *model.Queue, now time.Time) {
+	// 优先级不低于该作业的等待中作业会先被调度
+	required := response.RequestResources.Clone()
+	level := model.JobPriorityLevel(jobInfo.Config.Priority)
+	waitingJobs := storage.Job.ListQueueJob(queueInfo.ID, []schema.JobStatus{schema.StatusJobInit, schema.StatusJobPending})
+	for i := range waitingJobs {
+		if model.JobPriorityLevel(currentJobPriority(&waitingJobs[i])) >= level {
+			response.JobsAhead++
+			required.Add(jobResources(&waitingJobs[i]))
+		}
+	}
+	if response.JobsAhead > 0 {
+		response.Reasons = append(response.Reasons, fmt.Sprintf("%d jobs are waiting ahead in queue %s", response.JobsAhead, queueInfo.Name))
+	}
+	if required.LessEqual(response.IdleResources) {
+		if response.Result == SimulateResultRun {
+			response.EstimatedWaitTime = new(int64)
+		}
+		return
+	}
+	response.Result = SimulateResultWait
+	if !response.RequestResources.LessEqual(response.IdleResources) {
+		response.Reasons = append(response.Reasons, fmt.Sprintf("idle resources %v of queue %s are insufficient for %v",
+			response.IdleResources, queueInfo.Name, response.RequestResources))
+	}
+	if waitTime, ok := estimateWaitTime(queueInfo.ID, required, response.IdleResources, now); ok {
+		seconds := int64(waitTime / time.Second)
+		response.EstimatedWaitTime = &seconds
+	} else {
+		response.Reasons = append(response.Reasons, "wait time cannot be estimated without finished jobs in queue")
+	}
+}
+
+// estimateWaitTime 以队列中最近完成作业的平均运行时长估算运行中作业的剩余时间，
+// 按剩余时间依次释放资源，直到空闲资源满足所需资源
+func estimateWaitTime(queueID string, required, idle *resources.Resource, now time.Time) (time.Duration, bool) {
+	avgDuration, ok := averageJobDuration(queueID)
+	if !ok {
+		return 0, false
+	}
+	type runningJob struct {
+		remaining time.Duration
+		resource  *resources.Resource
+	}
+	var running []runningJob
+	for _, job := range storage.Job.ListQueueJob(queueID, []schema.JobStatus{schema.StatusJobRunning}) {
+		remaining := avgDuration
+		if job.ActivatedAt.Valid {
+			remaining -= now.Sub(job.ActivatedAt.Time)
+		}
+		if remaining < 0 {
+			remaining = 0
+		}
+		running = append(running, runningJob{remaining: remaining, resource: jobResources(&job)})
+	}
+	sort.Slice(running, func(i, j int) bool {
+		return running[i].remaining < running[j].remaining
+	})
+	available := idle.Clone()
+	for _, job := range running {
+		available.Add(job.resource)
+		if required.LessEqual(available) {
+			return job.remaining, true
+		}
+	}
+	return 0, false
+}
+
+// averageJobDuration 返回队列中最近完成作业的平均运行时长
+func averageJobDuration(queueID string) (time.Duration, bool) {
+	jobs := storage.Job.ListQueueJob(queueID, []schema.JobStatus{schema.StatusJobSucceeded})
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].UpdatedAt.After(jobs[j].UpdatedAt)
+	})
+	var total time.Duration
+	count := 0
+	for _, job := range jobs {
+		if count >= simulateHistoryJobs {
+			break
+		}
+		if !job.ActivatedAt.Valid || job.UpdatedAt.Before(job.ActivatedAt.Time) {
+			continue
+		}
+		total += job.UpdatedAt.Sub(job.ActivatedAt.Time)
+		count++
+	}
+	if count == 0 {
+		return 0, false
+	}
+	return total / time.Duration(count), true
+}
+
+// jobResources 返回作业所有成员申请的资源之和
+func jobResources(job *model.Job) *resources.Resource {
+	sum := resources.EmptyResource()
+	if len(job.Members) == 0 {
+		if job.Resource != nil {
+			sum.Add(job.Resource)
+		} else if job.Config != nil {
+			addFlavour(sum, job.Config.Flavour, 1, job.ID)
+		}
+		return sum
+	}
+	for _, member := range job.Members {
+		addFlavour(sum, member.Flavour, member.Replicas, job.ID)
+	}
+	return sum
+}
+
+func addFlavour(sum *resources.Resource, flavour schema.Flavour, replicas int, jobID string) {
+	res, err := resources.NewResourceFromMap(flavour.ResourceInfo.ToMap())
+	if err != nil {
+		log.Warningf("parse flavour %v of job %s failed, err: %v", flavour, jobID, err)
+		return
+	}
+	res.Multi(replicas)
+	sum.Add(res)
+}
@@ -17,11 +17,17 @@ limitations under the License.
 package log
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io"
+	"sort"
 	"strings"
 
 	"gorm.io/gorm"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/models"
@@ -46,6 +52,30 @@ type GetRunLogResponse struct {
 	RunID     string              `json:"runID"`
 }
 
+type StreamJobLogRequest struct {
+	TaskID    string `json:"taskID"`
+	Follow    bool   `json:"follow"`
+	TailLines int64  `json:"tailLines"`
+}
+
+// logRuntime 流式获取作业日志所需的集群操作
+type logRuntime interface {
+	ListPods(namespace string, listOptions metav1.ListOptions) (*corev1.PodList, error)
+	StreamPodLog(ctx context.Context, namespace, name string, logOptions *corev1.PodLogOptions) (io.ReadCloser, error)
+}
+
+var getLogRuntime = func(clusterInfo model.ClusterInfo) (logRuntime, error) {
+	runtimeSvc, err := runtime.GetOrCreateRuntime(clusterInfo)
+	if err != nil {
+		return nil, err
+	}
+	kubeRuntime, ok := runtimeSvc.(*runtime.KubeRuntime)
+	if !ok {
+		return nil, fmt.Errorf("runtime of cluster[%s] does not support log streaming", clusterInfo.Name)
+	}
+	return kubeRuntime, nil
+}
+
 func GetRunLog(ctx *logger.RequestContext, runID string, request GetRunLogRequest) (*GetRunLogResponse, error) {
 	run, err := models.GetRunByID(ctx.Logging(), runID)
 	if err != nil {
@@ -173,3 +203,111 @@ func getClusterQueueByQueueID(ctx *logger.RequestContext, queueID string) (*mode
 	}
 	return &clusterInfo, &queue, nil
 }
+
+// StreamJobLog 返回作业中任务的日志流及任务ID，未指定任务时选择名称最小的任务，reqCtx结束时日志流关闭
+func StreamJobLog(ctx *logger.RequestContext, reqCtx context.Context, jobID string, request StreamJobLogRequest) (io.ReadCloser, string, error) {
+	job, err := storage.Job.GetJobByID(jobID)
+	if err != nil {
+		ctx.ErrorCode = common.ErrorCodeOf(err, common.InternalError)
+		if ctx.ErrorCode == common.RecordNotFound {
+			ctx.ErrorCode = common.JobNotFound
+		}
+		ctx.Logging().Errorf("get job[%s] failed. error:%s", jobID, err.Error())
+		return nil, "", err
+	}
+	if err = common.CheckPermission(ctx.UserName, job.UserName, common.ResourceTypeJob, jobID); err != nil {
+		ctx.ErrorCode = common.ActionNotAllowed
+		return nil, "", err
+	}
+	clusterInfo, queue, err := getClusterQueueByQueueID(ctx, job.QueueID)
+	if err != nil {
+		ctx.ErrorCode = common.ErrorCodeOf(err, common.InternalError)
+		ctx.Logging().Errorf("get cluster by queue[%s] failed. error:%s.", job.QueueID, err.Error())
+		return nil, "", err
+	}
+	rt, err := getLogRuntime(*clusterInfo)
+	if err != nil {
+		ctx.ErrorCode = common.ErrorCodeOf(err, common.InternalError)
+		ctx.Logging().Errorf("get cluster client failed. error:%s.", err.Error())
+		return nil, "", err
+	}
+	listOptions := metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(map[string]string{schema.JobIDLabel: jobID}).String(),
+	}
+	podList, err := rt.ListPods(queue.Namespace, listOptions)
+	if err != nil {
+		ctx.ErrorCode = common.ErrorCodeOf(err, common.InternalError)
+		ctx.Logging().Errorf("list pods of job[%s] failed. error:%s.", jobID, err.Error())
+		return nil, "", err
+	}
+	taskNames := make([]string, 0, len(podList.Items))
+	for _, pod := range podList.Items {
+		if request.TaskID == "" || pod.Name == request.TaskID {
+			taskNames = append(taskNames, pod.Name)
+		}
+	}
+	if len(taskNames) == 0 {
+		ctx.ErrorCode = common.RecordNotFound
+		return nil, "", common.NewServiceError(ctx.ErrorCode, fmt.Sprintf("no task of job[%s] found", jobID),
+			map[string]string{"jobID": jobID, "taskID": request.TaskID, "status": string(job.Status)})
+	}
+	sort.Strings(taskNames)
+	taskID := taskNames[0]
+
+	logOptions := &corev1.PodLogOptions{Follow: request.Follow}
+	if request.TailLines > 0 {
+		logOptions.TailLines = &request.TailLines
+	}
+	stream, err := rt.StreamPodLog(reqCtx, queue.Namespace, taskID, logOptions)
+	if err != nil {
+		ctx.ErrorCode = common.ErrorCodeOf(err, common.InternalError)
+		ctx.Logging().Errorf("stream log of task[%s] failed. error:%s.", taskID, err.Error())
+		return nil, "", err
+	}
+	return stream, taskID, nil
+}
+
+// StreamRunStepLog 按节点名称流式获取run中节点的日志，返回日志流、作业ID及任务ID。
+// 节点对应多个作业时（如循环或失败重试）选择最新创建的作业
+func StreamRunStepLog(ctx *logger.RequestContext, reqCtx context.Context, runID, stepName string, request StreamJobLogRequest) (io.ReadCloser, string, string, error) {
+	run, err := models.GetRunByID(ctx.Logging(), runID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.ErrorCode = common.RunNotFound
+			return nil, "", "", common.NotFoundError(common.ResourceTypeRun, runID)
+		}
+		ctx.ErrorCode = common.InternalError
+		ctx.Logging().Errorf("get the run[%s] failed. error:%s", runID, err.Error())
+		return nil, "", "", err
+	}
+	if !common.IsRootUser(ctx.UserName) && ctx.UserName != run.UserName {
+		ctx.ErrorCode = common.AccessDenied
+		return nil, "", "", common.NoAccessError(ctx.UserName, common.ResourceTypeRun, runID)
+	}
+	jobList, err := getJobListByRunID(ctx, runID, "")
+	if err != nil {
+		ctx.ErrorCode = common.InternalError
+		ctx.Logging().Errorf("runID[%s] get job list failed. error:%s.", runID, err.Error())
+		return nil, "", "", err
+	}
+	var stepJob *model.Job
+	for i := range jobList {
+		job := &jobList[i]
+		if job.StepName != stepName {
+			continue
+		}
+		if stepJob == nil || job.CreatedAt.After(stepJob.CreatedAt) {
+			stepJob = job
+		}
+	}
+	if stepJob == nil {
+		ctx.ErrorCode = common.RecordNotFound
+		return nil, "", "", common.NewServiceError(ctx.ErrorCode, fmt.Sprintf("no job of step[%s] found in run[%s]", stepName, runID),
+			map[string]string{"runID": runID, "stepName": stepName})
+	}
+	stream, taskID, err := StreamJobLog(ctx, reqCtx, stepJob.ID, request)
+	if err != nil {
+		return nil, "", "", err
+	}
+	return stream, stepJob.ID, taskID, nil
+}
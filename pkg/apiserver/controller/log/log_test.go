@@ -0,0 +1,182 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package log
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/models"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage/driver"
+)
+
+type fakeLogRuntime struct {
+	pods []string
+}
+
+func (f *fakeLogRuntime) ListPods(namespace string, listOptions metav1.ListOptions) (*corev1.PodList, error) {
+	podList := &corev1.PodList{}
+	for _, name := range f.pods {
+		podList.Items = append(podList.Items, corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}})
+	}
+	return podList, nil
+}
+
+func (f *fakeLogRuntime) StreamPodLog(ctx context.Context, namespace, name string, logOptions *corev1.PodLogOptions) (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader("log of " + name)), nil
+}
+
+func TestStreamJobLog(t *testing.T) {
+	driver.InitMockDB()
+	rt := &fakeLogRuntime{}
+	origin := getLogRuntime
+	getLogRuntime = func(clusterInfo model.ClusterInfo) (logRuntime, error) {
+		return rt, nil
+	}
+	defer func() {
+		getLogRuntime = origin
+	}()
+
+	cluster := model.ClusterInfo{
+		Model:       model.Model{ID: "cluster-000001"},
+		Name:        "cluster-000001",
+		ClusterType: schema.KubernetesType,
+	}
+	assert.NoError(t, storage.Cluster.CreateCluster(&cluster))
+	queue := model.Queue{
+		Model:     model.Model{ID: "queue-000001"},
+		Name:      "queue-000001",
+		Namespace: "paddleflow",
+		ClusterId: cluster.ID,
+	}
+	assert.NoError(t, storage.Queue.CreateQueue(&queue))
+	job := model.Job{
+		ID:       "job-000001",
+		UserName: "root",
+		QueueID:  queue.ID,
+		Type:     string(schema.TypeDistributed),
+		Status:   schema.StatusJobPending,
+		Config:   &schema.Conf{},
+	}
+	assert.NoError(t, storage.Job.CreateJob(&job))
+
+	// 任务尚未创建
+	ctx := &logger.RequestContext{UserName: "root"}
+	_, _, err := StreamJobLog(ctx, context.TODO(), job.ID, StreamJobLogRequest{})
+	assert.Error(t, err)
+	assert.Equal(t, common.RecordNotFound, ctx.ErrorCode)
+
+	rt.pods = []string{"job-000001-worker-1", "job-000001-ps-0", "job-000001-worker-0"}
+	ctx = &logger.RequestContext{UserName: "root"}
+	stream, taskID, err := StreamJobLog(ctx, context.TODO(), job.ID, StreamJobLogRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, "job-000001-ps-0", taskID)
+	stream.Close()
+
+	stream, taskID, err = StreamJobLog(ctx, context.TODO(), job.ID, StreamJobLogRequest{TaskID: "job-000001-worker-1", Follow: true})
+	assert.NoError(t, err)
+	assert.Equal(t, "job-000001-worker-1", taskID)
+	content, _ := io.ReadAll(stream)
+	assert.Equal(t, "log of job-000001-worker-1", string(content))
+	stream.Close()
+
+	ctx = &logger.RequestContext{UserName: "test"}
+	_, _, err = StreamJobLog(ctx, context.TODO(), job.ID, StreamJobLogRequest{})
+	assert.Error(t, err)
+	assert.Equal(t, common.ActionNotAllowed, ctx.ErrorCode)
+}
+
+func TestStreamRunStepLog(t *testing.T) {
+	driver.InitMockDB()
+	rt := &fakeLogRuntime{}
+	origin := getLogRuntime
+	getLogRuntime = func(clusterInfo model.ClusterInfo) (logRuntime, error) {
+		return rt, nil
+	}
+	defer func() {
+		getLogRuntime = origin
+	}()
+
+	cluster := model.ClusterInfo{Model: model.Model{ID: "cluster-000001"}, Name: "cluster-000001", ClusterType: schema.KubernetesType}
+	assert.NoError(t, storage.Cluster.CreateCluster(&cluster))
+	queue := model.Queue{Model: model.Model{ID: "queue-000001"}, Name: "queue-000001", Namespace: "paddleflow", ClusterId: cluster.ID}
+	assert.NoError(t, storage.Queue.CreateQueue(&queue))
+
+	run := models.Run{
+		Name:     "step-log",
+		UserName: "user1",
+		Status:   common.StatusRunRunning,
+		RunYaml:  "name: step-log\nentry_points:\n  train:\n    command: echo train\n",
+	}
+	assert.NoError(t, run.Encode())
+	runID, err := models.CreateRun(logger.Logger(), &run)
+	assert.NoError(t, err)
+
+	// 重试后的作业创建时间更晚
+	now := time.Now()
+	for i, jobID := range []string{"job-train-0", "job-train-1"} {
+		job := model.Job{
+			ID:        jobID,
+			UserName:  "user1",
+			QueueID:   queue.ID,
+			Type:      string(schema.TypeSingle),
+			Status:    schema.StatusJobRunning,
+			RunID:     runID,
+			StepName:  "train",
+			Config:    &schema.Conf{},
+			CreatedAt: now.Add(time.Duration(i) * time.Minute),
+		}
+		assert.NoError(t, storage.Job.CreateJob(&job))
+	}
+	rt.pods = []string{"job-train-1-pod"}
+
+	ctx := &logger.RequestContext{UserName: "user1"}
+	stream, jobID, taskID, err := StreamRunStepLog(ctx, context.TODO(), runID, "train", StreamJobLogRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, "job-train-1", jobID)
+	assert.Equal(t, "job-train-1-pod", taskID)
+	content, _ := io.ReadAll(stream)
+	assert.Equal(t, "log of job-train-1-pod", string(content))
+	stream.Close()
+
+	ctx = &logger.RequestContext{UserName: "user1"}
+	_, _, _, err = StreamRunStepLog(ctx, context.TODO(), runID, "evaluate", StreamJobLogRequest{})
+	assert.Error(t, err)
+	assert.Equal(t, common.RecordNotFound, ctx.ErrorCode)
+
+	ctx = &logger.RequestContext{UserName: "user2"}
+	_, _, _, err = StreamRunStepLog(ctx, context.TODO(), runID, "train", StreamJobLogRequest{})
+	assert.Error(t, err)
+	assert.Equal(t, common.AccessDenied, ctx.ErrorCode)
+
+	ctx = &logger.RequestContext{UserName: "root"}
+	_, _, _, err = StreamRunStepLog(ctx, context.TODO(), "run-not-exist", "train", StreamJobLogRequest{})
+	assert.Error(t, err)
+	assert.Equal(t, common.RunNotFound, ctx.ErrorCode)
+}
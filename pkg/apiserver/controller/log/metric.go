@@ -0,0 +1,242 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package log
+
+import (
+	"bufio"
+	"context"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+)
+
+const (
+	// JobMetricLinePrefix 作业日志中以该标记开头的行会被解析为指标，例如 PF_METRIC loss=0.12 step=100
+	JobMetricLinePrefix = "PF_METRIC"
+	jobMetricStepKey    = "step"
+	jobMetricKeyMaxLen  = 256
+)
+
+var jobMetricCollectInterval = time.Minute
+
+type JobMetricPoint struct {
+	TaskID    string  `json:"taskID"`
+	Value     float64 `json:"value"`
+	Step      int64   `json:"step"`
+	Timestamp int64   `json:"timestamp"` // 毫秒
+}
+
+type GetJobMetricsResponse struct {
+	JobID   string                      `json:"jobID"`
+	Metrics map[string][]JobMetricPoint `json:"metrics"`
+}
+
+// GetJobMetrics 返回从作业日志中解析出的指标，keys为空时返回全部指标
+func GetJobMetrics(ctx *logger.RequestContext, jobID string, keys []string) (*GetJobMetricsResponse, error) {
+	job, err := storage.Job.GetJobByID(jobID)
+	if err != nil {
+		ctx.ErrorCode = common.ErrorCodeOf(err, common.InternalError)
+		if ctx.ErrorCode == common.RecordNotFound {
+			ctx.ErrorCode = common.JobNotFound
+		}
+		ctx.Logging().Errorf("get job[%s] failed. error:%s", jobID, err.Error())
+		return nil, err
+	}
+	if err = common.CheckPermission(ctx.UserName, job.UserName, common.ResourceTypeJob, jobID); err != nil {
+		ctx.ErrorCode = common.ActionNotAllowed
+		return nil, err
+	}
+	metrics, err := storage.JobMetric.ListJobMetrics(ctx.Logging(), jobID, keys)
+	if err != nil {
+		ctx.ErrorCode = common.InternalError
+		return nil, err
+	}
+	response := &GetJobMetricsResponse{
+		JobID:   jobID,
+		Metrics: map[string][]JobMetricPoint{},
+	}
+	for _, metric := range metrics {
+		response.Metrics[metric.Key] = append(response.Metrics[metric.Key], JobMetricPoint{
+			TaskID:    metric.TaskID,
+			Value:     metric.Value,
+			Step:      metric.Step,
+			Timestamp: metric.Timestamp / int64(time.Millisecond),
+		})
+	}
+	return response, nil
+}
+
+// JobMetricController 定期读取运行中及刚结束的作业的任务日志，将PF_METRIC行保存为作业指标
+func JobMetricController(stopChan chan struct{}) {
+	collector := newJobMetricCollector()
+	for {
+		collector.collect(time.Now())
+		select {
+		case <-stopChan:
+			log.Info("job metric controller stopped")
+			return
+		case <-time.After(jobMetricCollectInterval):
+		}
+	}
+}
+
+type jobMetricCollector struct {
+	// cursors 任务已解析到的日志时间，单位纳秒，key为作业ID/任务ID
+	cursors map[string]int64
+}
+
+func newJobMetricCollector() *jobMetricCollector {
+	return &jobMetricCollector{cursors: make(map[string]int64)}
+}
+
+func (c *jobMetricCollector) collect(now time.Time) {
+	ctx := &logger.RequestContext{UserName: common.UserRoot}
+	jobs := storage.Job.ListJobByStatus(schema.StatusJobRunning)
+	// 刚结束的作业在任务被回收前再读取一次日志
+	updatedJobs, err := storage.Job.ListJobByUpdateTime(now.Add(-2 * jobMetricCollectInterval).Format(model.TimeFormat))
+	if err != nil {
+		log.Errorf("list jobs updated recently failed. error: %v", err)
+	}
+	for _, job := range updatedJobs {
+		if schema.IsImmutableJobStatus(job.Status) && job.ActivatedAt.Valid {
+			jobs = append(jobs, job)
+		}
+	}
+
+	cursors := make(map[string]int64)
+	for i := range jobs {
+		tasks, err := listSearchTasks(ctx, jobs[i:i+1])
+		if err != nil {
+			log.Warnf("list tasks of job[%s] for metrics failed. error: %v", jobs[i].ID, err)
+			continue
+		}
+		for _, task := range tasks {
+			key := task.job.ID + "/" + task.taskID
+			cursor, err := c.collectTaskMetrics(task, key)
+			if err != nil {
+				log.Warnf("collect metrics of job[%s] task[%s] failed. error: %v", task.job.ID, task.taskID, err)
+			}
+			cursors[key] = cursor
+		}
+	}
+	// 丢弃已回收任务的游标
+	c.cursors = cursors
+}
+
+// collectTaskMetrics 解析任务上次游标之后的日志，返回新的游标
+func (c *jobMetricCollector) collectTaskMetrics(task searchLogTask, key string) (int64, error) {
+	logEntry := log.WithFields(log.Fields{"jobID": task.job.ID, "taskID": task.taskID})
+	cursor, ok := c.cursors[key]
+	if !ok {
+		var err error
+		if cursor, err = storage.JobMetric.GetLastJobMetricTimestamp(logEntry, task.job.ID, task.taskID); err != nil {
+			return 0, err
+		}
+	}
+	logOptions := &corev1.PodLogOptions{Timestamps: true}
+	if cursor > 0 {
+		sinceTime := metav1.NewTime(time.Unix(0, cursor))
+		logOptions.SinceTime = &sinceTime
+	}
+	stream, err := task.rt.StreamPodLog(context.Background(), task.namespace, task.taskID, logOptions)
+	if err != nil {
+		return cursor, err
+	}
+	defer stream.Close()
+
+	var metrics []model.JobMetric
+	lastCursor := cursor
+	scanner := bufio.NewScanner(stream)
+	scanner.Buffer(make([]byte, 64*1024), searchLogMaxLineSize)
+	for scanner.Scan() {
+		timestamp, line, ok := splitLogTimestamp(scanner.Text())
+		// SinceTime精确到秒，跳过已解析的行
+		if !ok || timestamp <= lastCursor {
+			continue
+		}
+		cursor = timestamp
+		for _, metric := range parseJobMetricLine(line) {
+			metric.JobID = task.job.ID
+			metric.TaskID = task.taskID
+			metric.Timestamp = timestamp
+			metrics = append(metrics, metric)
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return lastCursor, err
+	}
+	if err = storage.JobMetric.CreateJobMetrics(logEntry, metrics); err != nil {
+		return lastCursor, err
+	}
+	return cursor, nil
+}
+
+// splitLogTimestamp 拆分Timestamps为true时日志行开头的RFC3339Nano时间
+func splitLogTimestamp(line string) (int64, string, bool) {
+	idx := strings.IndexByte(line, ' ')
+	if idx < 0 {
+		return 0, "", false
+	}
+	t, err := time.Parse(time.RFC3339Nano, line[:idx])
+	if err != nil {
+		return 0, "", false
+	}
+	return t.UnixNano(), line[idx+1:], true
+}
+
+// parseJobMetricLine 解析形如 PF_METRIC loss=0.12 acc=0.9 step=100 的行，step为所有指标共用的步数，
+// 无法解析的字段被忽略
+func parseJobMetricLine(line string) []model.JobMetric {
+	idx := strings.Index(line, JobMetricLinePrefix+" ")
+	if idx < 0 {
+		return nil
+	}
+	var step int64
+	var metrics []model.JobMetric
+	for _, field := range strings.Fields(line[idx+len(JobMetricLinePrefix):]) {
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 || kv[0] == "" || len(kv[0]) > jobMetricKeyMaxLen {
+			continue
+		}
+		if kv[0] == jobMetricStepKey {
+			if v, err := strconv.ParseInt(kv[1], 10, 64); err == nil {
+				step = v
+			}
+			continue
+		}
+		value, err := strconv.ParseFloat(kv[1], 64)
+		if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+			continue
+		}
+		metrics = append(metrics, model.JobMetric{Key: kv[0], Value: value})
+	}
+	for i := range metrics {
+		metrics[i].Step = step
+	}
+	return metrics
+}
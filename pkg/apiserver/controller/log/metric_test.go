@@ -0,0 +1,114 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package log
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage/driver"
+)
+
+func TestParseJobMetricLine(t *testing.T) {
+	metrics := parseJobMetricLine("PF_METRIC loss=0.12 acc=0.9 step=100")
+	assert.Equal(t, []model.JobMetric{
+		{Key: "loss", Value: 0.12, Step: 100},
+		{Key: "acc", Value: 0.9, Step: 100},
+	}, metrics)
+
+	// 允许日志框架添加的前缀，忽略无法解析的字段
+	metrics = parseJobMetricLine("[INFO] 2022-11-11 PF_METRIC lr=1e-4 name=resnet bad nan=NaN")
+	assert.Equal(t, []model.JobMetric{{Key: "lr", Value: 0.0001}}, metrics)
+
+	assert.Nil(t, parseJobMetricLine("loss=0.12 step=100"))
+	assert.Nil(t, parseJobMetricLine("PF_METRICS loss=0.12"))
+}
+
+func TestCollectJobMetrics(t *testing.T) {
+	driver.InitMockDB()
+	rt := &fakeSearchRuntime{logs: map[string]map[string]string{
+		"job-000001": {
+			"job-000001-worker-0": "2022-11-11T10:00:00.000000001Z start training\n" +
+				"2022-11-11T10:00:01.000000001Z PF_METRIC loss=0.5 step=1\n" +
+				"2022-11-11T10:00:02.000000001Z PF_METRIC loss=0.3 acc=0.8 step=2\n",
+		},
+	}}
+	origin := getLogRuntime
+	getLogRuntime = func(clusterInfo model.ClusterInfo) (logRuntime, error) {
+		return rt, nil
+	}
+	defer func() {
+		getLogRuntime = origin
+	}()
+
+	cluster := model.ClusterInfo{
+		Model:       model.Model{ID: "cluster-000001"},
+		Name:        "cluster-000001",
+		ClusterType: schema.KubernetesType,
+	}
+	assert.NoError(t, storage.Cluster.CreateCluster(&cluster))
+	queue := model.Queue{
+		Model:     model.Model{ID: "queue-000001"},
+		Name:      "queue-000001",
+		Namespace: "paddleflow",
+		ClusterId: cluster.ID,
+	}
+	assert.NoError(t, storage.Queue.CreateQueue(&queue))
+	assert.NoError(t, storage.Job.CreateJob(&model.Job{
+		ID:       "job-000001",
+		UserName: "user1",
+		QueueID:  queue.ID,
+		Status:   schema.StatusJobRunning,
+		Config:   &schema.Conf{},
+	}))
+
+	collector := newJobMetricCollector()
+	collector.collect(time.Now())
+	// 同一段日志再次读取时不会重复保存
+	collector.collect(time.Now())
+	rt.logs["job-000001"]["job-000001-worker-0"] += "2022-11-11T10:00:03.000000001Z PF_METRIC loss=0.2 step=3\n"
+	// 重启后从数据库中最后一条指标的时间继续解析
+	newJobMetricCollector().collect(time.Now())
+
+	ctx := &logger.RequestContext{UserName: "user1"}
+	resp, err := GetJobMetrics(ctx, "job-000001", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(resp.Metrics["loss"]))
+	assert.Equal(t, 1, len(resp.Metrics["acc"]))
+	assert.Equal(t, JobMetricPoint{
+		TaskID:    "job-000001-worker-0",
+		Value:     0.2,
+		Step:      3,
+		Timestamp: time.Date(2022, 11, 11, 10, 0, 3, 0, time.UTC).UnixNano() / int64(time.Millisecond),
+	}, resp.Metrics["loss"][2])
+
+	resp, err = GetJobMetrics(ctx, "job-000001", []string{"acc"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(resp.Metrics))
+
+	ctx = &logger.RequestContext{UserName: "user2"}
+	_, err = GetJobMetrics(ctx, "job-000001", nil)
+	assert.Error(t, err)
+	assert.Equal(t, common.ActionNotAllowed, ctx.ErrorCode)
+}
@@ -0,0 +1,331 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package log
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/models"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	pplcommon "github.com/PaddlePaddle/PaddleFlow/pkg/pipeline/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+)
+
+// searchLogWorkers 同时读取日志的任务数
+var searchLogWorkers = 8
+
+// searchLogMaxLineSize 单行日志的最大长度，超出的部分会被截断
+const searchLogMaxLineSize = 1024 * 1024
+
+type SearchJobLogRequest struct {
+	RunID      string            `json:"runID"`
+	Labels     map[string]string `json:"labels"`
+	Pattern    string            `json:"pattern"`
+	IgnoreCase bool              `json:"ignoreCase"`
+	// TailLines 只搜索每个任务最后的行数，为0时搜索全部日志
+	TailLines  int64 `json:"tailLines"`
+	MaxMatches int   `json:"maxMatches"`
+}
+
+type JobLogMatch struct {
+	JobID    string `json:"jobID"`
+	JobName  string `json:"jobName"`
+	StepName string `json:"stepName,omitempty"`
+	TaskID   string `json:"taskID"`
+	LineNo   int    `json:"lineNo"`
+	Line     string `json:"line"`
+}
+
+type SearchJobLogResponse struct {
+	Matches   []JobLogMatch `json:"matches"`
+	Truncated bool          `json:"truncated"`
+	// SearchedTasks 实际读取了日志的任务数
+	SearchedTasks int `json:"searchedTasks"`
+	// FailedTasks 读取日志失败的任务，key为任务ID，value为失败原因
+	FailedTasks map[string]string `json:"failedTasks,omitempty"`
+}
+
+type searchLogTask struct {
+	job       *model.Job
+	stepName  string
+	namespace string
+	rt        logRuntime
+	taskID    string
+}
+
+type searchLogResult struct {
+	taskID  string
+	matches []JobLogMatch
+	err     error
+}
+
+// SearchJobLog 在run或者标签选中的作业的任务日志中搜索匹配pattern的行，只能搜索集群中仍保留的日志
+func SearchJobLog(ctx *logger.RequestContext, reqCtx context.Context, request SearchJobLogRequest) (*SearchJobLogResponse, error) {
+	if err := validateSearchJobLogRequest(ctx, &request); err != nil {
+		return nil, err
+	}
+	expr := request.Pattern
+	if request.IgnoreCase {
+		expr = "(?i)" + expr
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		ctx.ErrorCode = common.InvalidArguments
+		ctx.Logging().Errorf("compile pattern[%s] failed. error:%s", request.Pattern, err.Error())
+		return nil, common.NewServiceError(ctx.ErrorCode, fmt.Sprintf("pattern is invalid: %s", err.Error()),
+			map[string]string{"pattern": request.Pattern})
+	}
+
+	jobs, err := listSearchJobs(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := listSearchTasks(ctx, jobs)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &SearchJobLogResponse{
+		Matches:       make([]JobLogMatch, 0),
+		SearchedTasks: len(tasks),
+	}
+	results := searchTasksLog(reqCtx, tasks, re, request)
+	for _, result := range results {
+		if result.err != nil {
+			if response.FailedTasks == nil {
+				response.FailedTasks = make(map[string]string)
+			}
+			response.FailedTasks[result.taskID] = result.err.Error()
+			continue
+		}
+		response.Matches = append(response.Matches, result.matches...)
+	}
+	sort.SliceStable(response.Matches, func(i, j int) bool {
+		mi, mj := response.Matches[i], response.Matches[j]
+		if mi.JobID != mj.JobID {
+			return mi.JobID < mj.JobID
+		}
+		if mi.TaskID != mj.TaskID {
+			return mi.TaskID < mj.TaskID
+		}
+		return mi.LineNo < mj.LineNo
+	})
+	if len(response.Matches) > request.MaxMatches {
+		response.Matches = response.Matches[:request.MaxMatches]
+		response.Truncated = true
+	}
+	return response, nil
+}
+
+func validateSearchJobLogRequest(ctx *logger.RequestContext, request *SearchJobLogRequest) error {
+	ctx.ErrorCode = common.InvalidArguments
+	if request.Pattern == "" {
+		return common.NewServiceError(ctx.ErrorCode, "pattern is required", nil)
+	}
+	if request.RunID == "" && len(request.Labels) == 0 {
+		return common.NewServiceError(ctx.ErrorCode, "one of runID and labels is required", nil)
+	}
+	if request.TailLines < 0 {
+		return common.NewServiceError(ctx.ErrorCode, "tailLines must not be negative", nil)
+	}
+	if request.MaxMatches < 0 || request.MaxMatches > common.LogSearchMaxMatchesMax {
+		return common.NewServiceError(ctx.ErrorCode,
+			fmt.Sprintf("maxMatches must be in [0, %d]", common.LogSearchMaxMatchesMax), nil)
+	}
+	if request.MaxMatches == 0 {
+		request.MaxMatches = common.LogSearchMaxMatchesDefault
+	}
+	ctx.ErrorCode = ""
+	return nil
+}
+
+// listSearchJobs 返回run或者标签选中的作业，非root用户只能搜索自己的作业
+func listSearchJobs(ctx *logger.RequestContext, request SearchJobLogRequest) ([]model.Job, error) {
+	var jobs []model.Job
+	if request.RunID != "" {
+		run, err := models.GetRunByID(ctx.Logging(), request.RunID)
+		if err != nil {
+			ctx.ErrorCode = common.ErrorCodeOf(err, common.InternalError)
+			if ctx.ErrorCode == common.RecordNotFound {
+				ctx.ErrorCode = common.RunNotFound
+			}
+			ctx.Logging().Errorf("get the run[%s] failed. error:%s", request.RunID, err.Error())
+			return nil, err
+		}
+		if err = common.CheckPermission(ctx.UserName, run.UserName, common.ResourceTypeRun, request.RunID); err != nil {
+			ctx.ErrorCode = common.ActionNotAllowed
+			return nil, err
+		}
+		jobs, err = getJobListByRunID(ctx, request.RunID, "")
+		if err != nil {
+			ctx.ErrorCode = common.ErrorCodeOf(err, common.InternalError)
+			ctx.Logging().Errorf("runID[%s] get job list failed. error:%s.", request.RunID, err.Error())
+			return nil, err
+		}
+	} else {
+		jobIDs, err := storage.Job.ListJobIDByLabels(request.Labels)
+		if err != nil {
+			ctx.ErrorCode = common.ErrorCodeOf(err, common.InternalError)
+			return nil, err
+		}
+		for _, jobID := range jobIDs {
+			job, err := storage.Job.GetJobByID(jobID)
+			if err != nil {
+				// 标签对应的作业可能已被删除
+				ctx.Logging().Warnf("get job[%s] failed. error:%s", jobID, err.Error())
+				continue
+			}
+			jobs = append(jobs, job)
+		}
+	}
+
+	result := make([]model.Job, 0, len(jobs))
+	for _, job := range jobs {
+		if common.IsRootUser(ctx.UserName) || ctx.UserName == job.UserName {
+			result = append(result, job)
+		}
+	}
+	return result, nil
+}
+
+// listSearchTasks 列出作业在集群中的任务，作业所在集群的runtime按队列复用
+func listSearchTasks(ctx *logger.RequestContext, jobs []model.Job) ([]searchLogTask, error) {
+	type queueRuntime struct {
+		namespace string
+		rt        logRuntime
+	}
+	runtimes := make(map[string]queueRuntime)
+	tasks := make([]searchLogTask, 0)
+	for i := range jobs {
+		job := &jobs[i]
+		qr, ok := runtimes[job.QueueID]
+		if !ok {
+			clusterInfo, queue, err := getClusterQueueByQueueID(ctx, job.QueueID)
+			if err != nil {
+				ctx.ErrorCode = common.ErrorCodeOf(err, common.InternalError)
+				ctx.Logging().Errorf("get cluster by queue[%s] failed. error:%s.", job.QueueID, err.Error())
+				return nil, err
+			}
+			rt, err := getLogRuntime(*clusterInfo)
+			if err != nil {
+				ctx.ErrorCode = common.ErrorCodeOf(err, common.InternalError)
+				ctx.Logging().Errorf("get cluster client failed. error:%s.", err.Error())
+				return nil, err
+			}
+			qr = queueRuntime{namespace: queue.Namespace, rt: rt}
+			runtimes[job.QueueID] = qr
+		}
+		listOptions := metav1.ListOptions{
+			LabelSelector: labels.SelectorFromSet(map[string]string{schema.JobIDLabel: job.ID}).String(),
+		}
+		// 工作流中的作业通过环境变量PF_STEP_NAME记录所属节点
+		stepName := ""
+		if job.Config != nil {
+			stepName = job.Config.GetEnvValue(pplcommon.SysParamNamePFStepName)
+		}
+		podList, err := qr.rt.ListPods(qr.namespace, listOptions)
+		if err != nil {
+			ctx.ErrorCode = common.ErrorCodeOf(err, common.InternalError)
+			ctx.Logging().Errorf("list pods of job[%s] failed. error:%s.", job.ID, err.Error())
+			return nil, err
+		}
+		for _, pod := range podList.Items {
+			tasks = append(tasks, searchLogTask{
+				job:       job,
+				stepName:  stepName,
+				namespace: qr.namespace,
+				rt:        qr.rt,
+				taskID:    pod.Name,
+			})
+		}
+	}
+	return tasks, nil
+}
+
+func searchTasksLog(reqCtx context.Context, tasks []searchLogTask, re *regexp.Regexp, request SearchJobLogRequest) []searchLogResult {
+	results := make([]searchLogResult, len(tasks))
+	taskCh := make(chan int)
+	wg := sync.WaitGroup{}
+	for w := 0; w < searchLogWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range taskCh {
+				results[i] = searchTaskLog(reqCtx, tasks[i], re, request)
+			}
+		}()
+	}
+	for i := range tasks {
+		taskCh <- i
+	}
+	close(taskCh)
+	wg.Wait()
+	return results
+}
+
+func searchTaskLog(reqCtx context.Context, task searchLogTask, re *regexp.Regexp, request SearchJobLogRequest) searchLogResult {
+	result := searchLogResult{taskID: task.taskID}
+	logOptions := &corev1.PodLogOptions{}
+	if request.TailLines > 0 {
+		logOptions.TailLines = &request.TailLines
+	}
+	stream, err := task.rt.StreamPodLog(reqCtx, task.namespace, task.taskID, logOptions)
+	if err != nil {
+		result.err = err
+		return result
+	}
+	defer stream.Close()
+
+	scanner := bufio.NewScanner(stream)
+	scanner.Buffer(make([]byte, 64*1024), searchLogMaxLineSize)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := scanner.Text()
+		if !re.MatchString(line) {
+			continue
+		}
+		result.matches = append(result.matches, JobLogMatch{
+			JobID:    task.job.ID,
+			JobName:  task.job.Name,
+			StepName: task.stepName,
+			TaskID:   task.taskID,
+			LineNo:   lineNo,
+			Line:     line,
+		})
+		// 每个任务最多返回MaxMatches行，最终结果再统一截断
+		if len(result.matches) > request.MaxMatches {
+			break
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		result.err = err
+	}
+	return result
+}
@@ -0,0 +1,158 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package log
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage/driver"
+)
+
+type fakeSearchRuntime struct {
+	// key为作业ID，value为任务名到日志内容的映射
+	logs map[string]map[string]string
+}
+
+func (f *fakeSearchRuntime) ListPods(namespace string, listOptions metav1.ListOptions) (*corev1.PodList, error) {
+	selector, err := labels.Parse(listOptions.LabelSelector)
+	if err != nil {
+		return nil, err
+	}
+	podList := &corev1.PodList{}
+	for jobID, tasks := range f.logs {
+		if !selector.Matches(labels.Set{schema.JobIDLabel: jobID}) {
+			continue
+		}
+		for name := range tasks {
+			podList.Items = append(podList.Items, corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}})
+		}
+	}
+	return podList, nil
+}
+
+func (f *fakeSearchRuntime) StreamPodLog(ctx context.Context, namespace, name string, logOptions *corev1.PodLogOptions) (io.ReadCloser, error) {
+	for _, tasks := range f.logs {
+		if content, ok := tasks[name]; ok {
+			return io.NopCloser(strings.NewReader(content)), nil
+		}
+	}
+	return nil, fmt.Errorf("pod %s not found", name)
+}
+
+func TestSearchJobLog(t *testing.T) {
+	driver.InitMockDB()
+	rt := &fakeSearchRuntime{logs: map[string]map[string]string{
+		"job-000001": {
+			"job-000001-worker-0": "step 1\nstep 2\n",
+			"job-000001-worker-1": "step 1\nRuntimeError: CUDA out of memory\n",
+		},
+		"job-000002": {
+			"job-000002-worker-0": "cuda out of memory\nexit\n",
+		},
+		"job-000003": {
+			"job-000003-worker-0": "CUDA out of memory\n",
+		},
+	}}
+	origin := getLogRuntime
+	getLogRuntime = func(clusterInfo model.ClusterInfo) (logRuntime, error) {
+		return rt, nil
+	}
+	defer func() {
+		getLogRuntime = origin
+	}()
+
+	cluster := model.ClusterInfo{
+		Model:       model.Model{ID: "cluster-000001"},
+		Name:        "cluster-000001",
+		ClusterType: schema.KubernetesType,
+	}
+	assert.NoError(t, storage.Cluster.CreateCluster(&cluster))
+	queue := model.Queue{
+		Model:     model.Model{ID: "queue-000001"},
+		Name:      "queue-000001",
+		Namespace: "paddleflow",
+		ClusterId: cluster.ID,
+	}
+	assert.NoError(t, storage.Queue.CreateQueue(&queue))
+	for i, userName := range []string{"user1", "user1", "user2"} {
+		job := model.Job{
+			ID:       fmt.Sprintf("job-00000%d", i+1),
+			UserName: userName,
+			QueueID:  queue.ID,
+			Status:   schema.StatusJobRunning,
+			Config:   &schema.Conf{Env: map[string]string{"PF_STEP_NAME": "train"}},
+		}
+		assert.NoError(t, storage.Job.CreateJob(&job))
+		assert.NoError(t, storage.DB.Create(&model.JobLabel{ID: job.ID, Label: "exp=oom", JobID: job.ID}).Error)
+	}
+
+	ctx := &logger.RequestContext{UserName: "root"}
+	_, err := SearchJobLog(ctx, context.TODO(), SearchJobLogRequest{Labels: map[string]string{"exp": "oom"}})
+	assert.Error(t, err)
+	assert.Equal(t, common.InvalidArguments, ctx.ErrorCode)
+
+	ctx = &logger.RequestContext{UserName: "root"}
+	_, err = SearchJobLog(ctx, context.TODO(), SearchJobLogRequest{Labels: map[string]string{"exp": "oom"}, Pattern: "("})
+	assert.Error(t, err)
+	assert.Equal(t, common.InvalidArguments, ctx.ErrorCode)
+
+	ctx = &logger.RequestContext{UserName: "root"}
+	resp, err := SearchJobLog(ctx, context.TODO(), SearchJobLogRequest{
+		Labels:     map[string]string{"exp": "oom"},
+		Pattern:    "CUDA out of memory",
+		IgnoreCase: true,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 4, resp.SearchedTasks)
+	assert.Equal(t, 3, len(resp.Matches))
+	assert.Equal(t, JobLogMatch{
+		JobID:    "job-000001",
+		StepName: "train",
+		TaskID:   "job-000001-worker-1",
+		LineNo:   2,
+		Line:     "RuntimeError: CUDA out of memory",
+	}, resp.Matches[0])
+	assert.Equal(t, "job-000002-worker-0", resp.Matches[1].TaskID)
+	assert.False(t, resp.Truncated)
+
+	// 普通用户只能搜索自己的作业，且区分大小写
+	ctx = &logger.RequestContext{UserName: "user1"}
+	resp, err = SearchJobLog(ctx, context.TODO(), SearchJobLogRequest{
+		Labels:     map[string]string{"exp": "oom"},
+		Pattern:    "CUDA out of memory",
+		MaxMatches: 1,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, resp.SearchedTasks)
+	assert.Equal(t, 1, len(resp.Matches))
+	assert.Equal(t, "job-000001-worker-1", resp.Matches[0].TaskID)
+	assert.False(t, resp.Truncated)
+}
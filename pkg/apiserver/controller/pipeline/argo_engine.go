@@ -0,0 +1,513 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	wfv1 "github.com/argoproj/argo/pkg/apis/workflow/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/controller/job"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/models"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/config"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/k8s"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/resources"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/pipeline"
+	pplcommon "github.com/PaddlePaddle/PaddleFlow/pkg/pipeline/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+)
+
+const (
+	argoEntrypoint                = "pf-entrypoint"
+	defaultArgoCheckInterval      = 3 * time.Second
+	argoVolumePrefix              = "pf-fs-"
+	argoUnsupportedFeatureMessage = "%s is not supported by argo engine"
+)
+
+// argoEngine 将run转换为一个Argo Workflow，以workflow类型作业提交到队列，并轮询作业状态更新run
+// 作业ID与runID相同，服务重启后可以据此恢复对作业的跟踪
+type argoEngine struct {
+	run      models.Run
+	jobID    string
+	interval time.Duration
+
+	lock      sync.Mutex
+	status    string
+	startTime string
+	watching  bool
+}
+
+func newArgoEngine(run models.Run) (*argoEngine, error) {
+	if run.ID == "" {
+		return nil, fmt.Errorf("runID is empty, cannot create argo engine")
+	}
+	interval := defaultArgoCheckInterval
+	if config.GlobalServerConfig != nil && config.GlobalServerConfig.Engine.Argo.CheckIntervalSeconds > 0 {
+		interval = time.Duration(config.GlobalServerConfig.Engine.Argo.CheckIntervalSeconds) * time.Second
+	}
+	return &argoEngine{
+		run:      run,
+		jobID:    run.ID,
+		interval: interval,
+		status:   run.Status,
+	}, nil
+}
+
+func (ae *argoEngine) Start() {
+	if err := ae.submit(); err != nil {
+		logger.LoggerForRun(ae.run.ID).Errorf("submit argo workflow failed. error: %v", err)
+		ae.updateRun(common.StatusRunFailed, err.Error())
+		return
+	}
+	ae.startWatch()
+}
+
+// Resume 服务重启后恢复run，作业尚未提交时重新提交
+func (ae *argoEngine) Resume(entryPointView *schema.DagView, postProcessView schema.PostProcessView, runStatus string, stopForce bool) {
+	ae.setStatus(runStatus)
+	if _, err := storage.Job.GetJobByID(ae.jobID); err != nil {
+		logger.LoggerForRun(ae.run.ID).Infof("argo job[%s] not found, submit it again", ae.jobID)
+		ae.Start()
+	} else {
+		ae.startWatch()
+	}
+	if runStatus == common.StatusRunTerminating {
+		ae.Stop(stopForce)
+	}
+}
+
+// Restart argo引擎不复用原run的节点，重新提交整个workflow
+func (ae *argoEngine) Restart(entryPointView *schema.DagView, postProcessView schema.PostProcessView) {
+	ae.Start()
+}
+
+func (ae *argoEngine) Stop(force bool) {
+	logEntry := logger.LoggerForRun(ae.run.ID)
+	if err := job.StopJobByID(ae.jobID); err != nil {
+		logEntry.Errorf("stop argo job[%s] failed. error: %v", ae.jobID, err)
+		// 强制停止时不再等待作业状态，直接结束run
+		if force {
+			ae.updateRun(common.StatusRunTerminated, err.Error())
+		}
+	}
+}
+
+func (ae *argoEngine) Status() string {
+	ae.lock.Lock()
+	defer ae.lock.Unlock()
+	return ae.status
+}
+
+func (ae *argoEngine) setStatus(status string) {
+	ae.lock.Lock()
+	defer ae.lock.Unlock()
+	ae.status = status
+}
+
+func (ae *argoEngine) submit() error {
+	argoWf, queue, err := buildArgoWorkflow(ae.run)
+	if err != nil {
+		return err
+	}
+	template, err := argoExtensionTemplate(argoWf)
+	if err != nil {
+		return err
+	}
+	ctx := &logger.RequestContext{UserName: ae.run.UserName}
+	request := &job.CreateWfJobRequest{
+		CommonJobInfo: job.CommonJobInfo{
+			ID:   ae.jobID,
+			Name: ae.jobID,
+			SchedulingPolicy: job.SchedulingPolicy{
+				Queue: queue,
+			},
+		},
+		ExtensionTemplate: template,
+	}
+	if _, err := job.CreateWorkflowJob(ctx, request); err != nil {
+		return fmt.Errorf("create argo workflow job failed: %v", err)
+	}
+	logger.LoggerForRun(ae.run.ID).Infof("argo workflow job[%s] submitted to queue[%s]", ae.jobID, queue)
+	return nil
+}
+
+func (ae *argoEngine) startWatch() {
+	ae.lock.Lock()
+	defer ae.lock.Unlock()
+	if ae.watching {
+		return
+	}
+	ae.watching = true
+	go ae.watch()
+}
+
+// watch 轮询workflow作业状态，直到run进入终态
+func (ae *argoEngine) watch() {
+	logEntry := logger.LoggerForRun(ae.run.ID)
+	for {
+		jobInstance, err := storage.Job.GetJobByID(ae.jobID)
+		if err != nil {
+			logEntry.Warnf("get argo job[%s] failed. error: %v", ae.jobID, err)
+			time.Sleep(ae.interval)
+			continue
+		}
+		if jobInstance.ActivatedAt.Valid {
+			ae.startTime = jobInstance.ActivatedAt.Time.Format("2006-01-02 15:04:05")
+		}
+		status := runStatusOfArgoJob(jobInstance.Status)
+		if status != "" && status != ae.Status() {
+			ae.updateRun(status, jobInstance.Message)
+		}
+		if common.IsRunFinalStatus(status) {
+			return
+		}
+		time.Sleep(ae.interval)
+	}
+}
+
+func (ae *argoEngine) updateRun(status, message string) {
+	ae.setStatus(status)
+	extra := map[string]interface{}{
+		common.WfEventKeyRunID:     ae.run.ID,
+		common.WfEventKeyStatus:    status,
+		common.WfEventKeyStartTime: ae.startTime,
+	}
+	wfe := pipeline.NewWorkflowEvent(pipeline.WfEventRunUpdate, message, extra)
+	if _, ok := workflowCallbacks.UpdateRuntimeCb(ae.run.ID, wfe); !ok {
+		logger.LoggerForRun(ae.run.ID).Errorf("update run status to [%s] failed", status)
+	}
+}
+
+// runStatusOfArgoJob 将workflow作业状态转换为run状态，作业排队中时run保持pending
+func runStatusOfArgoJob(status schema.JobStatus) string {
+	switch status {
+	case schema.StatusJobRunning:
+		return common.StatusRunRunning
+	case schema.StatusJobSucceeded:
+		return common.StatusRunSucceeded
+	case schema.StatusJobFailed:
+		return common.StatusRunFailed
+	case schema.StatusJobTerminating:
+		return common.StatusRunTerminating
+	case schema.StatusJobTerminated, schema.StatusJobCancelled:
+		return common.StatusRunTerminated
+	default:
+		return ""
+	}
+}
+
+func argoExtensionTemplate(argoWf *wfv1.Workflow) (map[string]interface{}, error) {
+	data, err := json.Marshal(argoWf)
+	if err != nil {
+		return nil, err
+	}
+	template := map[string]interface{}{}
+	if err := json.Unmarshal(data, &template); err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+// argoWorkflowBuilder 将WorkflowSource中的dag、step转换为argo的dag、container模板
+type argoWorkflowBuilder struct {
+	run       *models.Run
+	wfs       *schema.WorkflowSource
+	queue     string
+	templates []wfv1.Template
+	volumes   map[string]corev1.Volume
+}
+
+// buildArgoWorkflow 将run转换为Argo Workflow，同时返回提交的队列
+// argo引擎只支持容器节点及其依赖关系，condition、loop_argument、artifact、post_process等特性需要使用内置引擎
+func buildArgoWorkflow(run models.Run) (*wfv1.Workflow, string, error) {
+	wfs := run.WorkflowSource
+	if len(wfs.PostProcess) != 0 {
+		return nil, "", fmt.Errorf(argoUnsupportedFeatureMessage, "post_process")
+	}
+	builder := &argoWorkflowBuilder{
+		run:     &run,
+		wfs:     &wfs,
+		volumes: map[string]corev1.Volume{},
+	}
+	if err := builder.addDag(argoEntrypoint, "", wfs.EntryPoints.EntryPoints); err != nil {
+		return nil, "", err
+	}
+
+	queue := builder.queue
+	if queue == "" && config.GlobalServerConfig != nil {
+		queue = config.GlobalServerConfig.Engine.Argo.Queue
+	}
+
+	argoWf := &wfv1.Workflow{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: k8s.ArgoWorkflowGVK.GroupVersion().String(),
+			Kind:       k8s.ArgoWorkflowGVK.Kind,
+		},
+		Spec: wfv1.WorkflowSpec{
+			Entrypoint: argoEntrypoint,
+			Templates:  builder.templates,
+		},
+	}
+	if config.GlobalServerConfig != nil {
+		argoWf.Spec.ServiceAccountName = config.GlobalServerConfig.Engine.Argo.ServiceAccount
+	}
+	if wfs.Parallelism > 0 {
+		parallelism := int64(wfs.Parallelism)
+		argoWf.Spec.Parallelism = &parallelism
+	}
+	volumeNames := make([]string, 0, len(builder.volumes))
+	for name := range builder.volumes {
+		volumeNames = append(volumeNames, name)
+	}
+	sort.Strings(volumeNames)
+	for _, name := range volumeNames {
+		argoWf.Spec.Volumes = append(argoWf.Spec.Volumes, builder.volumes[name])
+	}
+	return argoWf, queue, nil
+}
+
+func (b *argoWorkflowBuilder) addDag(templateName, prefix string, components map[string]schema.Component) error {
+	names := make([]string, 0, len(components))
+	for name := range components {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	failFast := b.wfs.FailureOptions.Strategy != schema.FailureStrategyContinue
+	dag := &wfv1.DAGTemplate{FailFast: &failFast}
+	for _, name := range names {
+		component := components[name]
+		fullName := joinComponentName(prefix, name)
+		if disabled, err := b.wfs.IsDisabled(fullName); err != nil {
+			return err
+		} else if disabled {
+			continue
+		}
+		if component.GetCondition() != "" {
+			return fmt.Errorf(argoUnsupportedFeatureMessage, "condition of component["+fullName+"]")
+		}
+		if component.GetLoopArgument() != nil {
+			return fmt.Errorf(argoUnsupportedFeatureMessage, "loop_argument of component["+fullName+"]")
+		}
+		artifacts := component.GetArtifacts()
+		if len(artifacts.Input) != 0 || len(artifacts.Output) != 0 {
+			return fmt.Errorf(argoUnsupportedFeatureMessage, "artifacts of component["+fullName+"]")
+		}
+
+		task := wfv1.DAGTask{
+			Name:     argoName(name),
+			Template: argoName(fullName),
+		}
+		for _, dep := range component.GetDeps() {
+			if disabled, err := b.wfs.IsDisabled(joinComponentName(prefix, dep)); err == nil && disabled {
+				continue
+			}
+			task.Dependencies = append(task.Dependencies, argoName(dep))
+		}
+
+		switch comp := component.(type) {
+		case *schema.WorkflowSourceStep:
+			if err := b.addStep(task.Template, fullName, comp); err != nil {
+				return err
+			}
+		case *schema.WorkflowSourceDag:
+			if err := b.addDag(task.Template, fullName, comp.EntryPoints); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("component[%s] has unknown type", fullName)
+		}
+		dag.Tasks = append(dag.Tasks, task)
+	}
+	b.templates = append(b.templates, wfv1.Template{Name: templateName, DAG: dag})
+	return nil
+}
+
+func (b *argoWorkflowBuilder) addStep(templateName, fullName string, step *schema.WorkflowSourceStep) error {
+	if step.GetStepType() != schema.StepTypeJob {
+		return fmt.Errorf(argoUnsupportedFeatureMessage, step.GetStepType()+" step["+fullName+"]")
+	}
+	if step.Reference.Component != "" || step.Reference.Pipeline != "" {
+		return fmt.Errorf(argoUnsupportedFeatureMessage, "reference of step["+fullName+"]")
+	}
+
+	// 整个workflow作为一个作业提交，所有step需要使用同一个队列
+	if queue := step.Env[schema.EnvJobQueueName]; queue != "" {
+		if b.queue != "" && b.queue != queue {
+			return fmt.Errorf("steps use different queues[%s, %s], which is not supported by argo engine", b.queue, queue)
+		}
+		b.queue = queue
+	}
+
+	command, err := b.resolveTemplate(fullName, step.Command)
+	if err != nil {
+		return err
+	}
+	image := step.DockerEnv
+	if image == "" {
+		image = b.wfs.DockerEnv
+	}
+	container := &corev1.Container{
+		Image: image,
+	}
+	if command != "" {
+		container.Command = []string{"sh", "-c", command}
+	}
+
+	env := map[string]string{
+		pplcommon.SysParamNamePFRunID:    b.run.ID,
+		pplcommon.SysParamNamePFStepName: fullName,
+		pplcommon.SysParamNamePFUserName: b.run.UserName,
+	}
+	for key, value := range step.Env {
+		if env[key], err = b.resolveTemplate(fullName, value); err != nil {
+			return err
+		}
+	}
+	keys := make([]string, 0, len(env))
+	for key := range env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		container.Env = append(container.Env, corev1.EnvVar{Name: key, Value: env[key]})
+	}
+
+	if flavourName := env[schema.EnvJobFlavour]; flavourName != "" {
+		requirements, err := flavourResourceRequirements(flavourName)
+		if err != nil {
+			return err
+		}
+		container.Resources = requirements
+	}
+
+	fsMounts := step.ExtraFS
+	if b.wfs.FsOptions.MainFS.Name != "" {
+		fsMounts = append([]schema.FsMount{b.wfs.FsOptions.MainFS}, fsMounts...)
+	}
+	for _, fsMount := range fsMounts {
+		container.VolumeMounts = append(container.VolumeMounts, b.addFsVolume(fsMount))
+	}
+
+	template := wfv1.Template{
+		Name:      templateName,
+		Container: container,
+	}
+	if step.Retry.Limit > 0 {
+		limit := intstr.FromInt(step.Retry.Limit)
+		template.RetryStrategy = &wfv1.RetryStrategy{Limit: &limit}
+		if step.Retry.Backoff > 0 {
+			template.RetryStrategy.Backoff = &wfv1.Backoff{Duration: fmt.Sprintf("%ds", step.Retry.Backoff)}
+		}
+	}
+	b.templates = append(b.templates, template)
+	return nil
+}
+
+// resolveTemplate 使用创建run时解析出的参数值及系统变量替换模板，argo引擎不支持引用上游节点的参数
+func (b *argoWorkflowBuilder) resolveTemplate(fullName, value string) (string, error) {
+	prefix := fullName + "."
+	for key, paramValue := range b.run.ResolvedParameters {
+		if !strings.HasPrefix(key, prefix) || strings.Contains(key[len(prefix):], ".") {
+			continue
+		}
+		value = strings.ReplaceAll(value, "{{"+key[len(prefix):]+"}}", fmt.Sprintf("%v", paramValue))
+	}
+	sysParams := map[string]string{
+		pplcommon.SysParamNamePFRunID:    b.run.ID,
+		pplcommon.SysParamNamePFStepName: fullName,
+		pplcommon.SysParamNamePFUserName: b.run.UserName,
+	}
+	for name, paramValue := range sysParams {
+		value = strings.ReplaceAll(value, "{{"+name+"}}", paramValue)
+	}
+	if strings.Contains(value, "{{") {
+		return "", fmt.Errorf(argoUnsupportedFeatureMessage, "template["+value+"] of step["+fullName+"]")
+	}
+	return value, nil
+}
+
+func (b *argoWorkflowBuilder) addFsVolume(fsMount schema.FsMount) corev1.VolumeMount {
+	fsUserName := b.run.RunOptions.FSUsername
+	if fsUserName == "" {
+		fsUserName = b.run.UserName
+	}
+	fsID := common.ID(fsUserName, fsMount.Name)
+	volumeName := argoVolumePrefix + argoName(fsMount.Name)
+	b.volumes[volumeName] = corev1.Volume{
+		Name: volumeName,
+		VolumeSource: corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: schema.ConcatenatePVCName(fsID),
+			},
+		},
+	}
+	mountPath := fsMount.MountPath
+	if mountPath == "" {
+		mountPath = filepath.Join(schema.DefaultFSMountPath, fsID)
+	}
+	return corev1.VolumeMount{
+		Name:      volumeName,
+		MountPath: mountPath,
+		SubPath:   fsMount.SubPath,
+		ReadOnly:  fsMount.ReadOnly,
+	}
+}
+
+func flavourResourceRequirements(flavourName string) (corev1.ResourceRequirements, error) {
+	flavour, err := storage.Flavour.GetFlavour(flavourName)
+	if err != nil {
+		return corev1.ResourceRequirements{}, fmt.Errorf("get flavour[%s] failed: %v", flavourName, err)
+	}
+	resourceInfo := schema.ResourceInfo{
+		CPU:             flavour.CPU,
+		Mem:             flavour.Mem,
+		ScalarResources: flavour.ScalarResources,
+	}
+	flavourResource, err := resources.NewResourceFromMap(resourceInfo.ToMap())
+	if err != nil {
+		return corev1.ResourceRequirements{}, err
+	}
+	return corev1.ResourceRequirements{
+		Requests: k8s.NewResourceList(flavourResource),
+		Limits:   k8s.NewResourceList(flavourResource),
+	}, nil
+}
+
+func joinComponentName(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+// argoName argo的模板名及任务名只能包含小写字母、数字和中划线
+func argoName(name string) string {
+	return strings.ToLower(strings.NewReplacer("_", "-", ".", "-").Replace(name))
+}
@@ -0,0 +1,247 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/models"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/config"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage/driver"
+)
+
+const argoEngineYaml = `
+name: argo_engine
+docker_env: python:3.7
+parallelism: 2
+entry_points:
+  preprocess:
+    command: "python preprocess.py --epoch={{epoch}} --run={{PF_RUN_ID}}"
+    parameters:
+      epoch: 5
+    env:
+      PF_JOB_FLAVOUR: cpu-flavour
+  train_dag:
+    deps: preprocess
+    entry_points:
+      train_step:
+        command: "python train.py"
+        docker_env: paddlepaddle/paddle:2.3.0
+        retry:
+          limit: 2
+          backoff: 10
+  skipped:
+    command: "echo skipped"
+disabled: skipped
+fs_options:
+  main_fs:
+    name: xd
+    mount_path: /home/work
+`
+
+func newArgoMockRun(t *testing.T, runYaml string) models.Run {
+	wfs, err := schema.GetWorkflowSource([]byte(runYaml))
+	assert.Nil(t, err)
+	return models.Run{
+		ID:             "run-000001",
+		UserName:       MockRootUser,
+		WorkflowSource: wfs,
+		RunYaml:        runYaml,
+		RunOptions:     schema.RunOptions{FSUsername: MockRootUser, Engine: EngineArgo},
+		ResolvedParameters: map[string]interface{}{
+			"preprocess.epoch": int64(5),
+		},
+		Status: common.StatusRunPending,
+	}
+}
+
+func TestBuildArgoWorkflow(t *testing.T) {
+	driver.InitMockDB()
+	assert.Nil(t, storage.Flavour.CreateFlavour(&model.Flavour{Name: "cpu-flavour", CPU: "4", Mem: "8Gi"}))
+	config.GlobalServerConfig = &config.ServerConfig{
+		Engine: config.EngineConfig{Argo: config.ArgoEngineConfig{Queue: "argo-queue", ServiceAccount: "argo"}},
+	}
+	defer func() { config.GlobalServerConfig = nil }()
+
+	run := newArgoMockRun(t, argoEngineYaml)
+	argoWf, queue, err := buildArgoWorkflow(run)
+	assert.Nil(t, err)
+	assert.Equal(t, "argo-queue", queue)
+	assert.Equal(t, "Workflow", argoWf.Kind)
+	assert.Equal(t, argoEntrypoint, argoWf.Spec.Entrypoint)
+	assert.Equal(t, "argo", argoWf.Spec.ServiceAccountName)
+	assert.Equal(t, int64(2), *argoWf.Spec.Parallelism)
+	assert.Equal(t, 1, len(argoWf.Spec.Volumes))
+	assert.Equal(t, schema.ConcatenatePVCName(common.ID(MockRootUser, "xd")), argoWf.Spec.Volumes[0].PersistentVolumeClaim.ClaimName)
+
+	templates := map[string]int{}
+	for i, template := range argoWf.Spec.Templates {
+		templates[template.Name] = i
+	}
+	assert.Equal(t, 4, len(templates))
+
+	// 被disabled的节点不会出现在workflow中
+	entry := argoWf.Spec.Templates[templates[argoEntrypoint]]
+	assert.Equal(t, 2, len(entry.DAG.Tasks))
+	assert.Equal(t, "preprocess", entry.DAG.Tasks[0].Name)
+	assert.Equal(t, "train-dag", entry.DAG.Tasks[1].Name)
+	assert.Equal(t, []string{"preprocess"}, entry.DAG.Tasks[1].Dependencies)
+
+	preprocess := argoWf.Spec.Templates[templates["preprocess"]]
+	assert.Equal(t, "python:3.7", preprocess.Container.Image)
+	assert.Equal(t, []string{"sh", "-c", "python preprocess.py --epoch=5 --run=run-000001"}, preprocess.Container.Command)
+	assert.Equal(t, "/home/work", preprocess.Container.VolumeMounts[0].MountPath)
+	cpu := preprocess.Container.Resources.Limits.Cpu()
+	assert.Equal(t, int64(4), cpu.Value())
+
+	trainStep := argoWf.Spec.Templates[templates["train-dag-train-step"]]
+	assert.Equal(t, "paddlepaddle/paddle:2.3.0", trainStep.Container.Image)
+	assert.Equal(t, "2", trainStep.RetryStrategy.Limit.String())
+	assert.Equal(t, "10s", trainStep.RetryStrategy.Backoff.Duration)
+	envs := map[string]string{}
+	for _, env := range trainStep.Container.Env {
+		envs[env.Name] = env.Value
+	}
+	assert.Equal(t, "train_dag.train_step", envs["PF_STEP_NAME"])
+
+	template, err := argoExtensionTemplate(argoWf)
+	assert.Nil(t, err)
+	assert.Equal(t, "argoproj.io/v1alpha1", template["apiVersion"])
+}
+
+func TestBuildArgoWorkflow_Unsupported(t *testing.T) {
+	cases := map[string]string{
+		"condition": `
+name: argo_engine
+docker_env: python:3.7
+entry_points:
+  main:
+    command: "echo main"
+    condition: "1 > 0"
+`,
+		"artifacts": `
+name: argo_engine
+docker_env: python:3.7
+entry_points:
+  main:
+    command: "echo main"
+    artifacts:
+      output:
+        - model
+`,
+		"different queues": `
+name: argo_engine
+docker_env: python:3.7
+entry_points:
+  pre:
+    command: "echo pre"
+    queue: queue-a
+  main:
+    deps: pre
+    command: "echo main"
+    queue: queue-b
+`,
+		"template": `
+name: argo_engine
+docker_env: python:3.7
+entry_points:
+  main:
+    command: "echo {{unknown}}"
+`,
+	}
+	for name, runYaml := range cases {
+		run := newArgoMockRun(t, runYaml)
+		_, _, err := buildArgoWorkflow(run)
+		assert.NotNil(t, err, name)
+	}
+}
+
+func TestArgoEngine(t *testing.T) {
+	driver.InitMockDB()
+	cluster := model.ClusterInfo{Model: model.Model{ID: "cluster-argo"}, Name: "cluster-argo", Status: model.ClusterStatusOnLine}
+	assert.Nil(t, storage.Cluster.CreateCluster(&cluster))
+	assert.Nil(t, storage.Queue.CreateQueue(&model.Queue{Name: "argo-queue", ClusterId: cluster.ID, Namespace: "default", Status: schema.StatusQueueOpen}))
+	assert.Nil(t, storage.Flavour.CreateFlavour(&model.Flavour{Name: "cpu-flavour", CPU: "4", Mem: "8Gi"}))
+	config.GlobalServerConfig = &config.ServerConfig{
+		Engine: config.EngineConfig{Name: EngineArgo, Argo: config.ArgoEngineConfig{Queue: "argo-queue", CheckIntervalSeconds: 1}},
+	}
+	defer func() { config.GlobalServerConfig = nil }()
+
+	engineName, err := configuredEngine()
+	assert.Nil(t, err)
+	assert.Equal(t, EngineArgo, engineName)
+
+	run := newArgoMockRun(t, argoEngineYaml)
+	run.ID = ""
+	assert.Nil(t, run.Encode())
+	runID, err := models.CreateRun(logger.Logger(), &run)
+	assert.Nil(t, err)
+	run.ID = runID
+
+	engine, err := newWorkflowEngine(run, nil)
+	assert.Nil(t, err)
+	wfMap[runID] = engine
+	engine.Start()
+
+	jobInstance, err := storage.Job.GetJobByID(runID)
+	assert.Nil(t, err)
+	assert.Equal(t, string(schema.TypeWorkflow), jobInstance.Type)
+	assert.Contains(t, jobInstance.ExtensionTemplate, argoEntrypoint)
+
+	assert.Nil(t, storage.Job.UpdateJobStatus(runID, "", schema.StatusJobRunning))
+	waitRunStatus(t, runID, common.StatusRunRunning)
+	assert.Equal(t, common.StatusRunRunning, engine.Status())
+
+	assert.Nil(t, storage.Job.UpdateJobStatus(runID, "workflow failed", schema.StatusJobFailed))
+	waitRunStatus(t, runID, common.StatusRunFailed)
+	_, exist := wfMap[runID]
+	assert.False(t, exist)
+}
+
+func waitRunStatus(t *testing.T, runID, status string) {
+	for i := 0; i < 50; i++ {
+		run, err := models.GetRunByID(logger.Logger(), runID)
+		assert.Nil(t, err)
+		if run.Status == status {
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	t.Fatalf("run[%s] does not reach status[%s]", runID, status)
+}
+
+func TestConfiguredEngine(t *testing.T) {
+	engine, err := configuredEngine()
+	assert.Nil(t, err)
+	assert.Equal(t, EngineInternal, engine)
+
+	config.GlobalServerConfig = &config.ServerConfig{Engine: config.EngineConfig{Name: "tekton"}}
+	defer func() { config.GlobalServerConfig = nil }()
+	_, err = configuredEngine()
+	assert.NotNil(t, err)
+
+	_, err = newWorkflowEngine(models.Run{ID: "run-000001", RunOptions: schema.RunOptions{Engine: "tekton"}}, nil)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,134 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"sort"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/handler"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/models"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+)
+
+const defaultArtifactGCInterval = time.Hour
+
+// ArtifactGCController 定期按pipeline的产物保留策略清理已结束run的输出产物
+func ArtifactGCController(stopChan chan struct{}) {
+	for {
+		gcRunArtifacts(time.Now())
+		select {
+		case <-stopChan:
+			log.Info("artifact gc controller stopped")
+			return
+		case <-time.After(defaultArtifactGCInterval):
+		}
+	}
+}
+
+func gcRunArtifacts(now time.Time) {
+	runs, err := models.ListRunsByStatus(logger.Logger(), common.RunFinalStatus)
+	if err != nil {
+		log.Errorf("list finished runs failed. error: %v", err)
+		return
+	}
+	// 同一pipeline(或同一yaml)的run按创建时间倒序排列，以最新run的保留策略为准
+	runsBySource := make(map[string][]*models.Run)
+	for i := range runs {
+		run := &runs[i]
+		runsBySource[run.Source] = append(runsBySource[run.Source], run)
+	}
+	for _, sourceRuns := range runsBySource {
+		sort.Slice(sourceRuns, func(i, j int) bool {
+			return sourceRuns[i].CreatedAt.After(sourceRuns[j].CreatedAt)
+		})
+		retention := sourceRuns[0].WorkflowSource.ArtifactRetention
+		if !retention.IsEnabled() {
+			continue
+		}
+		for i, run := range sourceRuns {
+			if !isArtifactExpired(retention, i, run, now) {
+				continue
+			}
+			if err := pruneRunArtifacts(run); err != nil {
+				logger.LoggerForRun(run.ID).Errorf("prune artifacts of run[%s] failed. error: %v", run.ID, err)
+			}
+		}
+	}
+}
+
+// isArtifactExpired index为run在同一pipeline已结束run中的序号，超出任一保留上限即过期
+func isArtifactExpired(retention *schema.ArtifactRetention, index int, run *models.Run, now time.Time) bool {
+	if retention.KeepRuns > 0 && index >= retention.KeepRuns {
+		return true
+	}
+	if retention.KeepDays > 0 && now.Sub(run.UpdatedAt) > time.Duration(retention.KeepDays)*24*time.Hour {
+		return true
+	}
+	return false
+}
+
+// pruneRunArtifacts 删除run的输出产物并在产物记录中标记为已清理，同时删除该run产生的cache，避免后续run命中已被清理的产物
+func pruneRunArtifacts(run *models.Run) error {
+	logEntry := logger.LoggerForRun(run.ID)
+	artifacts, err := storage.Artifact.ListArtifactEvent(logEntry, 0, 0, nil, nil,
+		[]string{run.ID}, []string{schema.ArtifactTypeOutput}, nil)
+	if err != nil {
+		return err
+	}
+	pruned := 0
+	for _, artifact := range artifacts {
+		if artifact.Pruned {
+			continue
+		}
+		fsHandler, err := handler.NewFsHandlerWithServer(artifact.FsID, logEntry)
+		if err != nil {
+			return err
+		}
+		if err := fsHandler.RemoveAll(artifact.ArtifactPath); err != nil {
+			logEntry.Errorf("remove artifact[%s] of run[%s] failed. error: %v", artifact.ArtifactPath, run.ID, err)
+			continue
+		}
+		// 引用该路径的输入产物记录同样标记为已清理
+		if err := storage.Artifact.UpdateArtifactEvent(logEntry, artifact.FsID, artifact.ArtifactPath,
+			model.ArtifactEvent{Pruned: true}); err != nil {
+			return err
+		}
+		pruned++
+	}
+	if pruned == 0 {
+		return nil
+	}
+	logEntry.Infof("pruned %d artifacts of run[%s]", pruned, run.ID)
+
+	caches, err := models.ListRunCache(logEntry, 0, 0, nil, nil, []string{run.ID})
+	if err != nil {
+		return err
+	}
+	for _, cache := range caches {
+		if err := models.DeleteRunCache(logEntry, cache.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
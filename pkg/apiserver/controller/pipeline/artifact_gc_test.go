@@ -0,0 +1,118 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/handler"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/models"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage/driver"
+)
+
+const artifactRetentionYaml = `name: retention
+entry_points:
+  main:
+    command: "echo main"
+artifact_retention:
+  keep_runs: 1
+`
+
+func TestGCRunArtifacts(t *testing.T) {
+	driver.InitMockDB()
+	origin := handler.NewFsHandlerWithServer
+	handler.NewFsHandlerWithServer = handler.MockerNewFsHandlerWithServer
+	defer func() {
+		handler.NewFsHandlerWithServer = origin
+		os.RemoveAll("./mock_fs_handler")
+	}()
+
+	now := time.Now()
+	runIDs := make([]string, 0)
+	for i := 0; i < 3; i++ {
+		run := models.Run{
+			Name:      fmt.Sprintf("retention-%d", i),
+			Source:    "ppl-000001",
+			UserName:  MockRootUser,
+			FsID:      MockFsID1,
+			Status:    common.StatusRunSucceeded,
+			RunYaml:   artifactRetentionYaml,
+			CreatedAt: now.Add(time.Duration(i-3) * time.Hour),
+		}
+		run.Encode()
+		runID, err := models.CreateRun(logger.Logger(), &run)
+		assert.NoError(t, err)
+		runIDs = append(runIDs, runID)
+
+		artifactPath := fmt.Sprintf(".pipeline/%s/retention/main-0-x/model", runID)
+		assert.NoError(t, os.MkdirAll("./mock_fs_handler/"+artifactPath, 0755))
+		assert.NoError(t, storage.Artifact.CreateArtifactEvent(logger.Logger(), model.ArtifactEvent{
+			RunID:        runID,
+			FsID:         MockFsID1,
+			UserName:     MockRootUser,
+			ArtifactPath: artifactPath,
+			Step:         "main",
+			JobID:        fmt.Sprintf("job-%d", i),
+			Type:         schema.ArtifactTypeOutput,
+			ArtifactName: "model",
+		}))
+		_, err = models.CreateRunCache(logger.Logger(), &models.RunCache{RunID: runID, FsID: MockFsID1})
+		assert.NoError(t, err)
+	}
+	// 运行中的run不参与清理
+	activeRun := models.Run{Source: "ppl-000001", Status: common.StatusRunRunning, RunYaml: artifactRetentionYaml}
+	activeRun.Encode()
+	_, err := models.CreateRun(logger.Logger(), &activeRun)
+	assert.NoError(t, err)
+
+	gcRunArtifacts(now)
+
+	for i, runID := range runIDs {
+		expired := i < 2
+		artifacts, err := storage.Artifact.ListArtifactEvent(logger.Logger(), 0, 0, nil, nil, []string{runID}, nil, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, expired, artifacts[0].Pruned)
+		_, err = os.Stat("./mock_fs_handler/" + artifacts[0].ArtifactPath)
+		assert.Equal(t, expired, os.IsNotExist(err))
+		caches, err := models.ListRunCache(logger.Logger(), 0, 0, nil, nil, []string{runID})
+		assert.NoError(t, err)
+		assert.Equal(t, expired, len(caches) == 0)
+	}
+
+	// 已清理的产物不会重复处理
+	gcRunArtifacts(now)
+}
+
+func TestIsArtifactExpired(t *testing.T) {
+	now := time.Now()
+	run := &models.Run{UpdatedAt: now.Add(-48 * time.Hour)}
+	assert.False(t, isArtifactExpired(&schema.ArtifactRetention{KeepRuns: 2}, 1, run, now))
+	assert.True(t, isArtifactExpired(&schema.ArtifactRetention{KeepRuns: 2}, 2, run, now))
+	assert.False(t, isArtifactExpired(&schema.ArtifactRetention{KeepDays: 3}, 5, run, now))
+	assert.True(t, isArtifactExpired(&schema.ArtifactRetention{KeepDays: 1}, 0, run, now))
+	assert.True(t, isArtifactExpired(&schema.ArtifactRetention{KeepRuns: 10, KeepDays: 1}, 0, run, now))
+}
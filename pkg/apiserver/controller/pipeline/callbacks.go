@@ -160,6 +160,11 @@ func UpdateRunByWfEvent(id string, event interface{}) (int64, bool) {
 			globalScheduler.ConcurrencyChannel <- prevRun.ScheduleID
 			logging.Debugf("send scheduleID[%s] to concurrency channel succeed.", prevRun.ScheduleID)
 		}
+
+		// 发送通知，不阻塞回调
+		go notifyRunFinished(runID)
+		// 释放run级共享卷
+		go cleanRunSharedVolume(runID)
 	}
 
 	return 0, true
@@ -391,13 +396,13 @@ func startWfWithImageUrl(runID, imageUrl string) error {
 	wfs.DockerEnv = imageUrl
 	run.WorkflowSource = wfs
 	// init workflow and start
-	wfPtr, err := newWorkflowByRun(run)
+	engine, err := newEngineByRun(run)
 	if err != nil {
 		logEntry.Debugf("validateAndInitWorkflow failed. err:%v\n", err)
 		return updateRunStatusAndMsg(runID, common.StatusRunFailed, err.Error())
 	}
 	// start workflow with image url
-	wfPtr.Start()
+	engine.Start()
 	logEntry.Debugf("workflow started after image handling. run: %+v", run)
 	// update run's imageUrl
 	return models.UpdateRun(logger.LoggerForRun(run.ID), run.ID,
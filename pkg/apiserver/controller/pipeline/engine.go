@@ -0,0 +1,95 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"fmt"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/models"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/config"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/pipeline"
+)
+
+const (
+	// EngineInternal 内置的DAG调度器，逐个节点创建PaddleFlow作业
+	EngineInternal = "internal"
+	// EngineArgo 将整个run转换为一个Argo Workflow，由集群中已安装的Argo负责调度
+	EngineArgo = "argo"
+)
+
+// WorkflowEngine run的执行引擎，由部署配置workflowEngine.name选择
+type WorkflowEngine interface {
+	Start()
+	Resume(entryPointView *schema.DagView, postProcessView schema.PostProcessView, runStatus string, stopForce bool)
+	Restart(entryPointView *schema.DagView, postProcessView schema.PostProcessView)
+	Stop(force bool)
+	Status() string
+}
+
+var _ WorkflowEngine = &pipeline.Workflow{}
+
+// configuredEngine 返回当前部署配置的执行引擎，新建的run使用该引擎
+func configuredEngine() (string, error) {
+	engine := ""
+	if config.GlobalServerConfig != nil {
+		engine = config.GlobalServerConfig.Engine.Name
+	}
+	switch engine {
+	case "", EngineInternal:
+		return EngineInternal, nil
+	case EngineArgo:
+		return EngineArgo, nil
+	default:
+		return "", fmt.Errorf("pipeline engine[%s] is not supported, should be one of [%s, %s]",
+			engine, EngineInternal, EngineArgo)
+	}
+}
+
+// newWorkflowEngine 按run创建时记录的引擎构造执行引擎，已有run不受部署配置变更影响
+// 内置引擎直接复用校验时生成的workflow
+func newWorkflowEngine(run models.Run, wfPtr *pipeline.Workflow) (WorkflowEngine, error) {
+	switch run.RunOptions.Engine {
+	case "", EngineInternal:
+		// 校验时run还没有ID，需要填写runID后重新初始化runtime
+		if wfPtr.RunID != run.ID {
+			wfPtr.RunID = run.ID
+			if err := wfPtr.NewWorkflowRuntime(); err != nil {
+				return nil, err
+			}
+		}
+		return wfPtr, nil
+	case EngineArgo:
+		return newArgoEngine(run)
+	default:
+		return nil, fmt.Errorf("pipeline engine[%s] of run[%s] is not supported", run.RunOptions.Engine, run.ID)
+	}
+}
+
+// newEngineByRun 校验run的workflow并构造执行引擎，同时填充wfMap
+func newEngineByRun(run models.Run) (WorkflowEngine, error) {
+	wfPtr, err := newWorkflowByRun(run)
+	if err != nil {
+		return nil, err
+	}
+	engine, err := newWorkflowEngine(run, wfPtr)
+	if err != nil {
+		return nil, err
+	}
+	wfMap[run.ID] = engine
+	return engine, nil
+}
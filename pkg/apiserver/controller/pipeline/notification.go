@@ -0,0 +1,233 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/models"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/config"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/http/outbound"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+)
+
+const defaultNotificationTimeout = 10 * time.Second
+
+var sendMailFunc = smtp.SendMail
+
+// RunNotification run结束时发送的通知内容，webhook会直接收到该结构的json
+type RunNotification struct {
+	RunID       string           `json:"runID"`
+	RunName     string           `json:"runName"`
+	Source      string           `json:"source"`
+	UserName    string           `json:"username"`
+	Status      string           `json:"status"`
+	Message     string           `json:"message"`
+	StartTime   string           `json:"startTime"`
+	EndTime     string           `json:"endTime"`
+	Duration    string           `json:"duration"`
+	FailedSteps []FailedStepInfo `json:"failedSteps"`
+}
+
+type FailedStepInfo struct {
+	Name    string `json:"name"`
+	JobID   string `json:"jobID"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+// notifyRunFinished 在run到达终态后，根据通知配置发送邮件、slack或webhook通知
+func notifyRunFinished(runID string) {
+	logging := logger.LoggerForRun(runID)
+	run, err := models.GetRunByID(logging, runID)
+	if err != nil {
+		logging.Errorf("notify run[%s] failed, get run err: %v", runID, err)
+		return
+	}
+
+	notification := getRunNotification(run)
+	if !notification.ShouldNotify(run.Status) {
+		return
+	}
+
+	content, err := buildRunNotification(logging, run)
+	if err != nil {
+		logging.Errorf("notify run[%s] failed, build notification err: %v", runID, err)
+		return
+	}
+
+	for _, url := range notification.Webhooks {
+		if err := sendWebhookNotification(url, content); err != nil {
+			logging.Errorf("send webhook notification of run[%s] to [%s] failed: %v", runID, url, err)
+		}
+	}
+	for _, url := range notification.Slack {
+		if err := sendSlackNotification(url, content); err != nil {
+			logging.Errorf("send slack notification of run[%s] failed: %v", runID, err)
+		}
+	}
+	if len(notification.Emails) > 0 {
+		if err := sendEmailNotification(notification.Emails, content); err != nil {
+			logging.Errorf("send email notification of run[%s] to %v failed: %v", runID, notification.Emails, err)
+		}
+	}
+}
+
+// getRunNotification 通知配置的优先级：run > pipeline yaml > 全局配置
+func getRunNotification(run models.Run) *schema.Notification {
+	if run.RunOptions.Notification != nil {
+		return run.RunOptions.Notification
+	}
+	if run.WorkflowSource.Notification != nil {
+		return run.WorkflowSource.Notification
+	}
+	if config.GlobalServerConfig == nil {
+		return nil
+	}
+	conf := config.GlobalServerConfig.Notification
+	return &schema.Notification{
+		Events:   conf.Events,
+		Emails:   conf.Emails,
+		Slack:    conf.Slack,
+		Webhooks: conf.Webhooks,
+	}
+}
+
+func buildRunNotification(logging *logrus.Entry, run models.Run) (RunNotification, error) {
+	content := RunNotification{
+		RunID:       run.ID,
+		RunName:     run.Name,
+		Source:      run.Source,
+		UserName:    run.UserName,
+		Status:      run.Status,
+		Message:     run.Message,
+		EndTime:     run.UpdatedAt.Format("2006-01-02 15:04:05"),
+		FailedSteps: []FailedStepInfo{},
+	}
+	if run.ActivatedAt.Valid {
+		content.StartTime = run.ActivatedAt.Time.Format("2006-01-02 15:04:05")
+		content.Duration = run.UpdatedAt.Sub(run.ActivatedAt.Time).Round(time.Second).String()
+	}
+
+	jobs, err := models.GetRunJobsOfRun(logging, run.ID)
+	if err != nil {
+		return RunNotification{}, err
+	}
+	for _, job := range jobs {
+		if job.Status == schema.StatusJobFailed || job.Status == schema.StatusJobTerminated {
+			content.FailedSteps = append(content.FailedSteps, FailedStepInfo{
+				Name:    job.Name,
+				JobID:   job.ID,
+				Status:  string(job.Status),
+				Message: job.Message,
+			})
+		}
+	}
+	return content, nil
+}
+
+func (n RunNotification) Title() string {
+	return fmt.Sprintf("[PaddleFlow] run[%s] %s", n.RunID, n.Status)
+}
+
+func (n RunNotification) Text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "run: %s(%s)\n", n.RunName, n.RunID)
+	fmt.Fprintf(&b, "user: %s\n", n.UserName)
+	fmt.Fprintf(&b, "status: %s\n", n.Status)
+	if n.Message != "" {
+		fmt.Fprintf(&b, "message: %s\n", n.Message)
+	}
+	fmt.Fprintf(&b, "start time: %s\n", n.StartTime)
+	fmt.Fprintf(&b, "end time: %s\n", n.EndTime)
+	if n.Duration != "" {
+		fmt.Fprintf(&b, "duration: %s\n", n.Duration)
+	}
+	for _, step := range n.FailedSteps {
+		fmt.Fprintf(&b, "failed step: %s(%s) %s %s\n", step.Name, step.JobID, step.Status, step.Message)
+	}
+	return b.String()
+}
+
+func sendWebhookNotification(url string, content RunNotification) error {
+	body, err := json.Marshal(content)
+	if err != nil {
+		return err
+	}
+	return postNotification(url, body)
+}
+
+func sendSlackNotification(url string, content RunNotification) error {
+	body, err := json.Marshal(map[string]string{
+		"text": fmt.Sprintf("*%s*\n%s", content.Title(), content.Text()),
+	})
+	if err != nil {
+		return err
+	}
+	return postNotification(url, body)
+}
+
+// postNotification 通过出站请求的client发送，拒绝访问不允许的域名及内网地址
+func postNotification(url string, body []byte) error {
+	if err := outbound.CheckURL(url); err != nil {
+		return err
+	}
+	resp, err := outbound.NewClient(notificationTimeout()).Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("response status code %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func sendEmailNotification(emails []string, content RunNotification) error {
+	if config.GlobalServerConfig == nil || config.GlobalServerConfig.Notification.SMTP.Host == "" {
+		return fmt.Errorf("smtp server is not configured")
+	}
+	notification := &schema.Notification{Emails: emails}
+	if err := notification.Validate(); err != nil {
+		return err
+	}
+	smtpConf := config.GlobalServerConfig.Notification.SMTP
+	addr := fmt.Sprintf("%s:%d", smtpConf.Host, smtpConf.Port)
+	var auth smtp.Auth
+	if smtpConf.Username != "" {
+		auth = smtp.PlainAuth("", smtpConf.Username, smtpConf.Password, smtpConf.Host)
+	}
+	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\n\r\n%s",
+		smtpConf.From, strings.Join(emails, ","), content.Title(), content.Text())
+	return sendMailFunc(addr, auth, smtpConf.From, emails, []byte(msg))
+}
+
+func notificationTimeout() time.Duration {
+	if config.GlobalServerConfig != nil && config.GlobalServerConfig.Notification.TimeoutSeconds > 0 {
+		return time.Duration(config.GlobalServerConfig.Notification.TimeoutSeconds) * time.Second
+	}
+	return defaultNotificationTimeout
+}
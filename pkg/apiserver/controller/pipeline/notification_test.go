@@ -0,0 +1,129 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/models"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/config"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/http/outbound"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage/driver"
+)
+
+func TestNotifyRunFinished(t *testing.T) {
+	driver.InitMockDB()
+	logEntry := logger.LoggerForRun("")
+
+	received := map[string][]byte{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		received[r.URL.Path] = body
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	// 测试服务监听在回环地址上
+	assert.NoError(t, outbound.Init(outbound.Config{AllowedCIDRs: []string{"127.0.0.0/8"}}))
+	defer outbound.Init(outbound.Config{})
+
+	mails := []string{}
+	sendMailFunc = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		mails = append(mails, string(msg))
+		return nil
+	}
+	defer func() { sendMailFunc = smtp.SendMail }()
+
+	serverConf := &config.ServerConfig{}
+	serverConf.Notification.SMTP = config.SMTPConfig{Host: "localhost", Port: 25, From: "paddleflow@example.com"}
+	config.GlobalServerConfig = serverConf
+	defer func() { config.GlobalServerConfig = nil }()
+
+	run, err := getMockFullRun()
+	assert.Nil(t, err)
+	run.Status = common.StatusRunFailed
+	run.RunOptions.Notification = &schema.Notification{
+		Events:   []string{common.StatusRunFailed},
+		Emails:   []string{"user@example.com"},
+		Slack:    []string{server.URL + "/slack"},
+		Webhooks: []string{server.URL + "/webhook"},
+	}
+	run.Encode()
+	runID, err := models.CreateRun(logEntry, &run)
+	assert.Nil(t, err)
+
+	job := models.RunJob{ID: "job-000001", RunID: runID, Name: "randint", StepName: "randint", Status: schema.StatusJobFailed, Message: "exit code 1"}
+	_, err = models.CreateRunJob(logEntry, &job)
+	assert.Nil(t, err)
+
+	notifyRunFinished(runID)
+
+	content := RunNotification{}
+	err = json.Unmarshal(received["/webhook"], &content)
+	assert.Nil(t, err)
+	assert.Equal(t, runID, content.RunID)
+	assert.Equal(t, common.StatusRunFailed, content.Status)
+	assert.Equal(t, 1, len(content.FailedSteps))
+	assert.Equal(t, "exit code 1", content.FailedSteps[0].Message)
+
+	slackMsg := map[string]string{}
+	err = json.Unmarshal(received["/slack"], &slackMsg)
+	assert.Nil(t, err)
+	assert.True(t, strings.Contains(slackMsg["text"], "failed step: randint"))
+
+	assert.Equal(t, 1, len(mails))
+	assert.True(t, strings.Contains(mails[0], "To: user@example.com"))
+
+	// 状态不在events中，不发送通知
+	received = map[string][]byte{}
+	models.UpdateRun(logEntry, runID, models.Run{Status: common.StatusRunSucceeded})
+	notifyRunFinished(runID)
+	assert.Equal(t, 0, len(received))
+}
+
+func TestGetRunNotification(t *testing.T) {
+	config.GlobalServerConfig = &config.ServerConfig{
+		Notification: config.NotificationConfig{Webhooks: []string{"http://global"}},
+	}
+	defer func() { config.GlobalServerConfig = nil }()
+
+	run := models.Run{}
+	assert.Equal(t, []string{"http://global"}, getRunNotification(run).Webhooks)
+
+	run.WorkflowSource.Notification = &schema.Notification{Webhooks: []string{"http://pipeline"}}
+	assert.Equal(t, []string{"http://pipeline"}, getRunNotification(run).Webhooks)
+
+	run.RunOptions.Notification = &schema.Notification{Webhooks: []string{"http://run"}}
+	assert.Equal(t, []string{"http://run"}, getRunNotification(run).Webhooks)
+
+	var notification *schema.Notification
+	assert.False(t, notification.ShouldNotify(common.StatusRunFailed))
+	notification = &schema.Notification{Webhooks: []string{"http://run"}}
+	assert.True(t, notification.ShouldNotify(common.StatusRunSucceeded))
+	notification.Events = []string{common.StatusRunFailed}
+	assert.False(t, notification.ShouldNotify(common.StatusRunSucceeded))
+}
@@ -20,9 +20,11 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/pmezard/go-difflib/difflib"
 	"gorm.io/gorm"
 
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/controller/project"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/handler"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/models"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/router/util"
@@ -79,6 +81,18 @@ type GetPipelineVersionResponse struct {
 	PipelineVersion PipelineVersionBrief `json:"pipelineVersion"`
 }
 
+type DiffPipelineVersionResponse struct {
+	PipelineID        string `json:"pipelineID"`
+	BaseVersionID     string `json:"baseVersionID"`
+	PipelineVersionID string `json:"pipelineVersionID"`
+	Diff              string `json:"diff"` // unified diff 格式，两个版本的yaml相同时为空
+}
+
+type RollbackPipelineResponse struct {
+	PipelineID        string `json:"pipelineID"`
+	PipelineVersionID string `json:"pipelineVersionID"`
+}
+
 type PipelineBrief struct {
 	ID         string `json:"pipelineID"`
 	Name       string `json:"name"`
@@ -320,6 +334,12 @@ func validateWorkflowForPipeline(pipelineYaml string, ctxUsername string, reqUse
 		return "", err
 	}
 
+	// 校验引用的pipeline是否存在，并展开后整体校验
+	if _, err := inlinePipelineReferences(ctxUsername, &wfs); err != nil {
+		logger.Logger().Errorf("inline pipeline references failed. err:%v", err)
+		return "", err
+	}
+
 	// fill extra info
 	param := map[string]interface{}{}
 	extra := map[string]string{
@@ -359,7 +379,7 @@ func validateWorkflowForPipeline(pipelineYaml string, ctxUsername string, reqUse
 	return wfs.Name, nil
 }
 
-func ListPipeline(ctx *logger.RequestContext, marker string, maxKeys int, userFilter, nameFilter []string) (ListPipelineResponse, error) {
+func ListPipeline(ctx *logger.RequestContext, marker string, maxKeys int, userFilter, nameFilter []string, projectName string) (ListPipelineResponse, error) {
 	ctx.Logging().Debugf("begin list pipeline.")
 
 	var pk int64
@@ -375,7 +395,13 @@ func ListPipeline(ctx *logger.RequestContext, marker string, maxKeys int, userFi
 	}
 
 	// 只有root用户才能设置userFilter，否则只能查询当前普通用户创建的pipeline列表
-	if !common.IsRootUser(ctx.UserName) {
+	// 按项目过滤时，项目成员可以看到项目中所有的pipeline
+	if projectName != "" {
+		if err = project.CheckProjectMember(ctx, projectName); err != nil {
+			return ListPipelineResponse{}, err
+		}
+	}
+	if !common.IsRootUser(ctx.UserName) && projectName == "" {
 		if len(userFilter) != 0 {
 			ctx.ErrorCode = common.InvalidArguments
 			errMsg := fmt.Sprint("only root user can set userFilter!")
@@ -386,7 +412,7 @@ func ListPipeline(ctx *logger.RequestContext, marker string, maxKeys int, userFi
 		}
 	}
 
-	pipelineList, err := storage.Pipeline.ListPipeline(pk, maxKeys, userFilter, nameFilter)
+	pipelineList, err := storage.Pipeline.ListPipeline(pk, maxKeys, userFilter, nameFilter, projectName)
 	if err != nil {
 		ctx.ErrorCode = common.InternalError
 		ctx.Logging().Errorf("ListPipeline[%d-%s-%s] failed. err: %v", maxKeys, userFilter, nameFilter, err)
@@ -401,7 +427,7 @@ func ListPipeline(ctx *logger.RequestContext, marker string, maxKeys int, userFi
 	listPipelineResponse.IsTruncated = false
 	if len(pipelineList) > 0 {
 		ppl := pipelineList[len(pipelineList)-1]
-		isLastPk, err := storage.Pipeline.IsLastPipelinePk(ctx.Logging(), ppl.Pk, userFilter, nameFilter)
+		isLastPk, err := storage.Pipeline.IsLastPipelinePk(ctx.Logging(), ppl.Pk, userFilter, nameFilter, projectName)
 		if err != nil {
 			ctx.ErrorCode = common.InternalError
 			errMsg := fmt.Sprintf("get last pipeline Pk failed. err:[%s]", err.Error())
@@ -535,6 +561,120 @@ func GetPipelineVersion(ctx *logger.RequestContext, pipelineID string, pipelineV
 	return getPipelineVersionResponse, nil
 }
 
+// DiffPipelineVersion 对比两个pipeline版本的yaml，baseVersionID为空时与上一个版本对比
+func DiffPipelineVersion(ctx *logger.RequestContext, pipelineID, pipelineVersionID, baseVersionID string) (DiffPipelineVersionResponse, error) {
+	ctx.Logging().Debugf("begin diff pipeline[%s] version[%s] with base version[%s]", pipelineID, pipelineVersionID, baseVersionID)
+
+	hasAuth, _, pplVersion, err := CheckPipelineVersionPermission(ctx.UserName, pipelineID, pipelineVersionID)
+	if err != nil {
+		ctx.ErrorCode = common.InvalidArguments
+		errMsg := fmt.Sprintf("diff pipeline[%s] version[%s] failed. err:%v", pipelineID, pipelineVersionID, err)
+		ctx.Logging().Errorf(errMsg)
+		return DiffPipelineVersionResponse{}, fmt.Errorf(errMsg)
+	} else if !hasAuth {
+		ctx.ErrorCode = common.AccessDenied
+		errMsg := fmt.Sprintf("diff pipeline[%s] version[%s] failed. Access denied for user[%s]", pipelineID, pipelineVersionID, ctx.UserName)
+		ctx.Logging().Errorf(errMsg)
+		return DiffPipelineVersionResponse{}, fmt.Errorf(errMsg)
+	}
+
+	var basePplVersion model.PipelineVersion
+	if baseVersionID == "" {
+		basePplVersion, err = storage.Pipeline.GetPreviousPipelineVersion(pipelineID, pplVersion.Pk)
+	} else {
+		basePplVersion, err = storage.Pipeline.GetPipelineVersion(pipelineID, baseVersionID)
+	}
+	if err != nil {
+		ctx.ErrorCode = common.InvalidArguments
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = fmt.Errorf("base version of pipeline[%s] version[%s] not found", pipelineID, pipelineVersionID)
+		}
+		errMsg := fmt.Sprintf("diff pipeline[%s] version[%s] failed. err:%v", pipelineID, pipelineVersionID, err)
+		ctx.Logging().Errorf(errMsg)
+		return DiffPipelineVersionResponse{}, fmt.Errorf(errMsg)
+	}
+
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(basePplVersion.PipelineYaml),
+		B:        difflib.SplitLines(pplVersion.PipelineYaml),
+		FromFile: fmt.Sprintf("version-%s", basePplVersion.ID),
+		ToFile:   fmt.Sprintf("version-%s", pplVersion.ID),
+		Context:  3,
+	})
+	if err != nil {
+		ctx.ErrorCode = common.InternalError
+		errMsg := fmt.Sprintf("diff pipeline[%s] version[%s] failed. err:%v", pipelineID, pipelineVersionID, err)
+		ctx.Logging().Errorf(errMsg)
+		return DiffPipelineVersionResponse{}, fmt.Errorf(errMsg)
+	}
+
+	response := DiffPipelineVersionResponse{
+		PipelineID:        pipelineID,
+		BaseVersionID:     basePplVersion.ID,
+		PipelineVersionID: pplVersion.ID,
+		Diff:              diff,
+	}
+	return response, nil
+}
+
+// RollbackPipeline 将pipeline回滚到指定版本
+// 版本记录不可修改，因此回滚是以指定版本的yaml创建一个新的版本
+func RollbackPipeline(ctx *logger.RequestContext, pipelineID, pipelineVersionID string) (RollbackPipelineResponse, error) {
+	ctx.Logging().Debugf("begin rollback pipeline[%s] to version[%s]", pipelineID, pipelineVersionID)
+
+	hasAuth, ppl, pplVersion, err := CheckPipelineVersionPermission(ctx.UserName, pipelineID, pipelineVersionID)
+	if err != nil {
+		ctx.ErrorCode = common.InvalidArguments
+		errMsg := fmt.Sprintf("rollback pipeline[%s] to version[%s] failed. err:%v", pipelineID, pipelineVersionID, err)
+		ctx.Logging().Errorf(errMsg)
+		return RollbackPipelineResponse{}, fmt.Errorf(errMsg)
+	} else if !hasAuth {
+		ctx.ErrorCode = common.AccessDenied
+		errMsg := fmt.Sprintf("rollback pipeline[%s] to version[%s] failed. Access denied for user[%s]", pipelineID, pipelineVersionID, ctx.UserName)
+		ctx.Logging().Errorf(errMsg)
+		return RollbackPipelineResponse{}, fmt.Errorf(errMsg)
+	}
+
+	lastPplVersion, err := storage.Pipeline.GetLastPipelineVersion(pipelineID)
+	if err != nil {
+		ctx.ErrorCode = common.InternalError
+		errMsg := fmt.Sprintf("rollback pipeline[%s] failed, get last version err:%v", pipelineID, err)
+		ctx.Logging().Errorf(errMsg)
+		return RollbackPipelineResponse{}, fmt.Errorf(errMsg)
+	}
+	if lastPplVersion.ID == pplVersion.ID {
+		ctx.ErrorCode = common.ActionNotAllowed
+		errMsg := fmt.Sprintf("rollback pipeline[%s] failed, version[%s] is already the latest version", pipelineID, pipelineVersionID)
+		ctx.Logging().Errorf(errMsg)
+		return RollbackPipelineResponse{}, fmt.Errorf(errMsg)
+	}
+
+	newPplVersion := model.PipelineVersion{
+		PipelineID:   pipelineID,
+		FsID:         pplVersion.FsID,
+		FsName:       pplVersion.FsName,
+		YamlPath:     pplVersion.YamlPath,
+		PipelineYaml: pplVersion.PipelineYaml,
+		PipelineMd5:  pplVersion.PipelineMd5,
+		UserName:     ctx.UserName,
+	}
+
+	pplID, pplVersionID, err := storage.Pipeline.UpdatePipeline(ctx.Logging(), &ppl, &newPplVersion)
+	if err != nil {
+		ctx.ErrorCode = common.InternalError
+		errMsg := fmt.Sprintf("rollback pipeline failed inserting db. error:%s", err.Error())
+		ctx.Logging().Errorf(errMsg)
+		return RollbackPipelineResponse{}, fmt.Errorf(errMsg)
+	}
+
+	ctx.Logging().Debugf("rollback pipeline[%s] to version[%s] successful, new pplVersionID[%s]", pplID, pipelineVersionID, pplVersionID)
+	response := RollbackPipelineResponse{
+		PipelineID:        pplID,
+		PipelineVersionID: pplVersionID,
+	}
+	return response, nil
+}
+
 func DeletePipeline(ctx *logger.RequestContext, pipelineID string) error {
 	ctx.Logging().Debugf("begin delete pipeline: %s", pipelineID)
 
@@ -571,6 +711,9 @@ func DeletePipeline(ctx *logger.RequestContext, pipelineID string) error {
 		ctx.Logging().Errorf(errMsg)
 		return fmt.Errorf(errMsg)
 	}
+	if err := storage.Project.DeleteProjectMemberByResource(nil, common.ResourceTypePipeline, pipelineID); err != nil {
+		ctx.Logging().Warnf("remove pipeline[%s] from projects failed. error:%s", pipelineID, err.Error())
+	}
 	return nil
 }
 
@@ -0,0 +1,236 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+)
+
+// inlinePipelineReferences 将通过 reference.pipeline 引用其他pipeline的step展开为dag
+// 被引用pipeline的entry_points成为该dag的子节点，components合并到当前工作流中，post_process不会被引入
+// step的parameters中，不带"."的参数作为dag的参数，子节点可以通过 {{PF_PARENT.xxx}} 引用；
+// 形如 "train.epoch" 的参数会覆盖被引用pipeline中对应节点的参数
+// 返回值表示是否有节点被展开
+func inlinePipelineReferences(userName string, wfs *schema.WorkflowSource) (bool, error) {
+	inliner := pipelineInliner{userName: userName, visited: map[string]bool{}}
+	if err := inliner.inlineWorkflow(wfs); err != nil {
+		return false, err
+	}
+	return inliner.inlined, nil
+}
+
+type pipelineInliner struct {
+	userName string
+	// 记录当前展开路径上的pipeline，防止循环引用
+	visited map[string]bool
+	inlined bool
+}
+
+func (pi *pipelineInliner) inlineWorkflow(wfs *schema.WorkflowSource) error {
+	if err := pi.inlineComponents(wfs, wfs.EntryPoints.EntryPoints); err != nil {
+		return err
+	}
+	if err := pi.inlineComponents(wfs, wfs.Components); err != nil {
+		return err
+	}
+	for name, step := range wfs.PostProcess {
+		if step.Reference.Pipeline != "" {
+			return fmt.Errorf("step[%s] in post_process can not reference pipeline", name)
+		}
+	}
+	return nil
+}
+
+func (pi *pipelineInliner) inlineComponents(wfs *schema.WorkflowSource, components map[string]schema.Component) error {
+	for name, comp := range components {
+		switch comp := comp.(type) {
+		case *schema.WorkflowSourceDag:
+			if err := pi.inlineComponents(wfs, comp.EntryPoints); err != nil {
+				return err
+			}
+		case *schema.WorkflowSourceStep:
+			if comp.Reference.Pipeline == "" {
+				continue
+			}
+			dag, err := pi.buildDagByReference(wfs, name, comp)
+			if err != nil {
+				return err
+			}
+			components[name] = dag
+			pi.inlined = true
+		}
+	}
+	return nil
+}
+
+func (pi *pipelineInliner) buildDagByReference(wfs *schema.WorkflowSource, name string, step *schema.WorkflowSourceStep) (*schema.WorkflowSourceDag, error) {
+	ref := step.Reference
+	if ref.Component != "" {
+		return nil, fmt.Errorf("step[%s] can not reference component and pipeline at the same time", name)
+	}
+	if step.Command != "" {
+		return nil, fmt.Errorf("step[%s] referencing pipeline[%s] should not have command", name, ref.Pipeline)
+	}
+
+	ppl, err := storage.Pipeline.GetPipeline(ref.Pipeline, pi.userName)
+	if err != nil {
+		err := fmt.Errorf("get pipeline[%s] of user[%s] referenced by step[%s] failed, err: %v", ref.Pipeline, pi.userName, name, err)
+		logger.Logger().Errorln(err.Error())
+		return nil, err
+	}
+	if pi.visited[ppl.ID] {
+		return nil, fmt.Errorf("pipeline[%s] referenced by step[%s] is recursively referenced", ref.Pipeline, name)
+	}
+
+	var pplVersion model.PipelineVersion
+	if ref.PipelineVersion == "" {
+		pplVersion, err = storage.Pipeline.GetLastPipelineVersion(ppl.ID)
+	} else {
+		pplVersion, err = storage.Pipeline.GetPipelineVersion(ppl.ID, ref.PipelineVersion)
+	}
+	if err != nil {
+		err := fmt.Errorf("get version[%s] of pipeline[%s] referenced by step[%s] failed, err: %v", ref.PipelineVersion, ref.Pipeline, name, err)
+		logger.Logger().Errorln(err.Error())
+		return nil, err
+	}
+
+	subWfs, err := schema.GetWorkflowSource([]byte(pplVersion.PipelineYaml))
+	if err != nil {
+		return nil, fmt.Errorf("parse pipeline[%s] version[%s] referenced by step[%s] failed, err: %v", ref.Pipeline, pplVersion.ID, name, err)
+	}
+
+	// 被引用的pipeline中也可能引用了其他pipeline
+	pi.visited[ppl.ID] = true
+	err = pi.inlineWorkflow(&subWfs)
+	delete(pi.visited, ppl.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 子工作流的全局docker_env只对其自身的节点生效
+	setDefaultDockerEnv(subWfs.EntryPoints.EntryPoints, subWfs.DockerEnv)
+	setDefaultDockerEnv(subWfs.Components, subWfs.DockerEnv)
+
+	if len(subWfs.Components) > 0 && wfs.Components == nil {
+		wfs.Components = map[string]schema.Component{}
+	}
+	for compName, comp := range subWfs.Components {
+		if _, ok := wfs.Components[compName]; ok {
+			return nil, fmt.Errorf("component[%s] in pipeline[%s] referenced by step[%s] conflicts with existing component", compName, ref.Pipeline, name)
+		}
+		wfs.Components[compName] = comp
+	}
+
+	dagParams := map[string]interface{}{}
+	for paramName, value := range step.Parameters {
+		if !strings.Contains(paramName, ".") {
+			dagParams[paramName] = value
+			continue
+		}
+		if err := overrideSubPipelineParam(subWfs.EntryPoints.EntryPoints, paramName, value); err != nil {
+			return nil, fmt.Errorf("replace param[%s] of step[%s] failed, %v", paramName, name, err)
+		}
+	}
+
+	// 被引用pipeline的顶层节点输出的artifact，才可以作为该节点的输出
+	outputs := map[string]string{}
+	for artName := range step.Artifacts.Output {
+		producers := []string{}
+		for subName, subComp := range subWfs.EntryPoints.EntryPoints {
+			if _, ok := subComp.GetArtifacts().Output[artName]; ok {
+				producers = append(producers, subName)
+			}
+		}
+		if len(producers) != 1 {
+			return nil, fmt.Errorf("output artifact[%s] of step[%s] should be produced by exactly one top-level component of pipeline[%s], but got %v",
+				artName, name, ref.Pipeline, producers)
+		}
+		outputs[artName] = fmt.Sprintf("{{%s.%s}}", producers[0], artName)
+	}
+
+	inputs := map[string]string{}
+	for artName, value := range step.Artifacts.Input {
+		inputs[artName] = value
+	}
+
+	dag := &schema.WorkflowSourceDag{
+		Name:         name,
+		LoopArgument: step.LoopArgument,
+		Condition:    step.Condition,
+		Parameters:   dagParams,
+		Deps:         step.Deps,
+		Artifacts: schema.Artifacts{
+			Input:  inputs,
+			Output: outputs,
+		},
+		EntryPoints: subWfs.EntryPoints.EntryPoints,
+	}
+	logger.Logger().Infof("step[%s] is inlined with pipeline[%s] version[%s]", name, ref.Pipeline, pplVersion.ID)
+	return dag, nil
+}
+
+// overrideSubPipelineParam 覆盖子工作流中节点的参数，paramName形如 dag-name.step-name.param
+func overrideSubPipelineParam(components map[string]schema.Component, paramName string, value interface{}) error {
+	names := strings.Split(paramName, ".")
+	for _, compName := range names[:len(names)-2] {
+		dag, ok := components[compName].(*schema.WorkflowSourceDag)
+		if !ok {
+			return fmt.Errorf("dag[%s] not found in referenced pipeline", compName)
+		}
+		components = dag.EntryPoints
+	}
+
+	compName, name := names[len(names)-2], names[len(names)-1]
+	comp, ok := components[compName]
+	if !ok {
+		return fmt.Errorf("component[%s] not found in referenced pipeline", compName)
+	}
+	params := comp.GetParameters()
+	oldValue, ok := params[name]
+	if !ok {
+		return fmt.Errorf("component[%s] in referenced pipeline has no param[%s]", compName, name)
+	}
+	// dict类型的参数只替换默认值，保留类型等校验规则
+	if dictParam, ok := oldValue.(map[string]interface{}); ok {
+		dictParam["default"] = value
+		return nil
+	}
+	params[name] = value
+	return nil
+}
+
+func setDefaultDockerEnv(components map[string]schema.Component, dockerEnv string) {
+	if dockerEnv == "" {
+		return
+	}
+	for _, comp := range components {
+		switch comp := comp.(type) {
+		case *schema.WorkflowSourceDag:
+			setDefaultDockerEnv(comp.EntryPoints, dockerEnv)
+		case *schema.WorkflowSourceStep:
+			if comp.DockerEnv == "" && comp.Reference.Component == "" {
+				comp.DockerEnv = dockerEnv
+			}
+		}
+	}
+}
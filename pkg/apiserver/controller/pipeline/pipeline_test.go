@@ -27,6 +27,7 @@ import (
 	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/handler"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/router/util"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
@@ -259,7 +260,7 @@ func TestListPipeline(t *testing.T) {
 	_, _, _, _ = insertPipeline(t, ctx.Logging())
 
 	// test list
-	resp, err := ListPipeline(ctx, "", 10, []string{}, []string{})
+	resp, err := ListPipeline(ctx, "", 10, []string{}, []string{}, "")
 	assert.Nil(t, err)
 	assert.Equal(t, 2, len(resp.PipelineList))
 	assert.Equal(t, resp.PipelineList[0].ID, "ppl-000001")
@@ -271,7 +272,7 @@ func TestListPipeline(t *testing.T) {
 	fmt.Printf("%s\n", b)
 
 	// test list, 指定maxkeys
-	resp, err = ListPipeline(ctx, "", 1, []string{}, []string{})
+	resp, err = ListPipeline(ctx, "", 1, []string{}, []string{}, "")
 	assert.Nil(t, err)
 	assert.Equal(t, 1, len(resp.PipelineList))
 	assert.Equal(t, resp.PipelineList[0].ID, "ppl-000001")
@@ -282,7 +283,7 @@ func TestListPipeline(t *testing.T) {
 	fmt.Printf("%s\n", b)
 
 	// test list, 指定userfilter
-	resp, err = ListPipeline(ctx, "", 10, []string{"user1", "user2"}, []string{})
+	resp, err = ListPipeline(ctx, "", 10, []string{"user1", "user2"}, []string{}, "")
 	assert.Nil(t, err)
 	assert.Equal(t, 1, len(resp.PipelineList))
 	assert.Equal(t, resp.PipelineList[0].ID, "ppl-000001")
@@ -293,7 +294,7 @@ func TestListPipeline(t *testing.T) {
 	fmt.Printf("%s\n", b)
 
 	// test list, 指定userfilter为root
-	resp, err = ListPipeline(ctx, "", 10, []string{"root"}, []string{})
+	resp, err = ListPipeline(ctx, "", 10, []string{"root"}, []string{}, "")
 	assert.Nil(t, err)
 	assert.Equal(t, 1, len(resp.PipelineList))
 	assert.Equal(t, resp.PipelineList[0].ID, "ppl-000002")
@@ -305,7 +306,7 @@ func TestListPipeline(t *testing.T) {
 
 	// test list, namefilter
 	// 先测试不能匹配前缀，注意不存在匹配记录时，istruncated = false
-	resp, err = ListPipeline(ctx, "", 1, []string{}, []string{"ppl"})
+	resp, err = ListPipeline(ctx, "", 1, []string{}, []string{"ppl"}, "")
 	assert.Nil(t, err)
 	assert.Equal(t, 0, len(resp.PipelineList))
 	assert.Equal(t, resp.IsTruncated, false)
@@ -315,7 +316,7 @@ func TestListPipeline(t *testing.T) {
 	fmt.Printf("%s\n", b)
 
 	// nameFilter必须精确匹配，不支持模糊匹配
-	resp, err = ListPipeline(ctx, "", 1, []string{}, []string{"ppl1"})
+	resp, err = ListPipeline(ctx, "", 1, []string{}, []string{"ppl1"}, "")
 	assert.Nil(t, err)
 	assert.Equal(t, 1, len(resp.PipelineList))
 	assert.Equal(t, resp.PipelineList[0].ID, "ppl-000001")
@@ -327,7 +328,7 @@ func TestListPipeline(t *testing.T) {
 
 	// test list，user非root时，不指定userFilter，只能返回自己有权限的pipeline
 	ctx = &logger.RequestContext{UserName: "user1"}
-	resp, err = ListPipeline(ctx, "", 10, []string{}, []string{})
+	resp, err = ListPipeline(ctx, "", 10, []string{}, []string{}, "")
 	assert.Nil(t, err)
 	assert.Equal(t, 1, len(resp.PipelineList))
 	assert.Equal(t, resp.PipelineList[0].ID, "ppl-000001")
@@ -339,7 +340,7 @@ func TestListPipeline(t *testing.T) {
 	fmt.Printf("%s\n", b)
 
 	// test list，user非root时，指定userfilter时会报错
-	resp, err = ListPipeline(ctx, "", 10, []string{"root"}, []string{})
+	resp, err = ListPipeline(ctx, "", 10, []string{"root"}, []string{}, "")
 	assert.NotNil(t, err)
 	assert.Equal(t, "only root user can set userFilter!", err.Error())
 	println("")
@@ -843,3 +844,197 @@ func TestDeletePipelineVersion(t *testing.T) {
 	assert.Equal(t, pplVersionID6, pplVersion6.ID)
 	assert.Equal(t, pplVersionID6, "4")
 }
+
+func TestDiffAndRollbackPipelineVersion(t *testing.T) {
+	driver.InitMockDB()
+	ctx := &logger.RequestContext{UserName: MockRootUser}
+
+	ppl1 := model.Pipeline{
+		ID:       "ppl-000001",
+		Name:     "ppl1",
+		Desc:     "ppl1",
+		UserName: "user1",
+	}
+	pplVersion1 := model.PipelineVersion{
+		PipelineID:   ppl1.ID,
+		FsID:         "root-fsname",
+		FsName:       "fsname",
+		YamlPath:     "./run.yml",
+		PipelineYaml: "name: ppl1\nentry_points:\n  main:\n    command: echo 1\n",
+		PipelineMd5:  "md5_1",
+		UserName:     "user1",
+	}
+	pplVersion2 := model.PipelineVersion{
+		PipelineID:   ppl1.ID,
+		FsID:         "root-fsname",
+		FsName:       "fsname",
+		YamlPath:     "./run.yml",
+		PipelineYaml: "name: ppl1\nentry_points:\n  main:\n    command: echo 2\n",
+		PipelineMd5:  "md5_2",
+		UserName:     "user1",
+	}
+
+	_, _, err := storage.Pipeline.CreatePipeline(ctx.Logging(), &ppl1, &pplVersion1)
+	assert.Nil(t, err)
+	_, _, err = storage.Pipeline.UpdatePipeline(ctx.Logging(), &ppl1, &pplVersion2)
+	assert.Nil(t, err)
+
+	// diff 失败，第一个版本没有上一个版本
+	_, err = DiffPipelineVersion(ctx, "ppl-000001", "1", "")
+	assert.NotNil(t, err)
+
+	// diff 失败，用户没有权限
+	_, err = DiffPipelineVersion(&logger.RequestContext{UserName: "user2"}, "ppl-000001", "2", "")
+	assert.NotNil(t, err)
+	assert.Equal(t, "diff pipeline[ppl-000001] version[2] failed. Access denied for user[user2]", err.Error())
+
+	// diff 成功，默认与上一个版本对比
+	diffResp, err := DiffPipelineVersion(ctx, "ppl-000001", "2", "")
+	assert.Nil(t, err)
+	assert.Equal(t, "1", diffResp.BaseVersionID)
+	assert.Contains(t, diffResp.Diff, "-    command: echo 1")
+	assert.Contains(t, diffResp.Diff, "+    command: echo 2")
+
+	// diff 相同版本，结果为空
+	diffResp, err = DiffPipelineVersion(ctx, "ppl-000001", "2", "2")
+	assert.Nil(t, err)
+	assert.Equal(t, "", diffResp.Diff)
+
+	// rollback 失败，已经是最新版本
+	_, err = RollbackPipeline(ctx, "ppl-000001", "2")
+	assert.NotNil(t, err)
+	assert.Equal(t, common.ActionNotAllowed, ctx.ErrorCode)
+
+	// rollback 成功，生成新的版本，原版本不变
+	rollbackResp, err := RollbackPipeline(ctx, "ppl-000001", "1")
+	assert.Nil(t, err)
+	assert.Equal(t, "3", rollbackResp.PipelineVersionID)
+
+	pplVersion3, err := storage.Pipeline.GetPipelineVersion("ppl-000001", "3")
+	assert.Nil(t, err)
+	assert.Equal(t, pplVersion1.PipelineYaml, pplVersion3.PipelineYaml)
+	assert.Equal(t, pplVersion1.PipelineMd5, pplVersion3.PipelineMd5)
+	assert.Equal(t, MockRootUser, pplVersion3.UserName)
+
+	versions, err := storage.Pipeline.GetPipelineVersions("ppl-000001")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(versions))
+
+	diffResp, err = DiffPipelineVersion(ctx, "ppl-000001", "3", "1")
+	assert.Nil(t, err)
+	assert.Equal(t, "", diffResp.Diff)
+}
+
+func TestInlinePipelineReferences(t *testing.T) {
+	driver.InitMockDB()
+	ctx := &logger.RequestContext{UserName: "user1"}
+
+	subYaml := `name: preprocess
+docker_env: sub:latest
+entry_points:
+  clean:
+    command: "clean --ratio {{ratio}}"
+    parameters:
+      ratio: 0.5
+      mode: {"type": "string", "default": "fast"}
+    artifacts:
+      input:
+        raw: "{{PF_PARENT.raw}}"
+      output:
+      - data
+`
+	ppl := model.Pipeline{ID: "ppl-000001", Name: "preprocess", UserName: "user1"}
+	pplVersion := model.PipelineVersion{PipelineID: ppl.ID, PipelineYaml: subYaml, UserName: "user1"}
+	_, _, err := storage.Pipeline.CreatePipeline(ctx.Logging(), &ppl, &pplVersion)
+	assert.Nil(t, err)
+
+	parentYaml := `name: main
+docker_env: main:latest
+entry_points:
+  download:
+    command: "download"
+    artifacts:
+      output:
+      - raw
+  prepare:
+    deps: download
+    reference:
+      pipeline: preprocess
+      pipeline_version: 1
+    parameters:
+      clean.ratio: 0.8
+      clean.mode: slow
+    artifacts:
+      input:
+        raw: "{{download.raw}}"
+      output:
+      - data
+  train:
+    deps: prepare
+    command: "train"
+    artifacts:
+      input:
+        data: "{{prepare.data}}"
+`
+	wfs, err := schema.GetWorkflowSource([]byte(parentYaml))
+	assert.Nil(t, err)
+
+	inlined, err := inlinePipelineReferences(ctx.UserName, &wfs)
+	assert.Nil(t, err)
+	assert.True(t, inlined)
+
+	dag, ok := wfs.EntryPoints.EntryPoints["prepare"].(*schema.WorkflowSourceDag)
+	assert.True(t, ok)
+	assert.Equal(t, "download", dag.Deps)
+	assert.Equal(t, "{{clean.data}}", dag.Artifacts.Output["data"])
+	assert.Equal(t, "{{download.raw}}", dag.Artifacts.Input["raw"])
+	assert.Equal(t, 0, len(dag.Parameters))
+
+	clean := dag.EntryPoints["clean"].(*schema.WorkflowSourceStep)
+	assert.Equal(t, 0.8, clean.Parameters["ratio"])
+	assert.Equal(t, "slow", clean.Parameters["mode"].(map[string]interface{})["default"])
+	assert.Equal(t, "sub:latest", clean.DockerEnv)
+
+	// 展开后的yaml可以被重新解析
+	_, runYaml, err := getSourceAndYaml(wfs)
+	assert.Nil(t, err)
+	newWfs, err := schema.GetWorkflowSource([]byte(runYaml))
+	assert.Nil(t, err)
+	_, ok = newWfs.EntryPoints.EntryPoints["prepare"].(*schema.WorkflowSourceDag)
+	assert.True(t, ok)
+
+	// 引用不存在的pipeline
+	wfs, err = schema.GetWorkflowSource([]byte(strings.Replace(parentYaml, "pipeline: preprocess", "pipeline: notExist", 1)))
+	assert.Nil(t, err)
+	_, err = inlinePipelineReferences(ctx.UserName, &wfs)
+	assert.NotNil(t, err)
+
+	// 覆盖不存在的参数
+	wfs, err = schema.GetWorkflowSource([]byte(strings.Replace(parentYaml, "clean.ratio", "clean.notExist", 1)))
+	assert.Nil(t, err)
+	_, err = inlinePipelineReferences(ctx.UserName, &wfs)
+	assert.NotNil(t, err)
+
+	// 输出的artifact在被引用pipeline中不存在
+	wfs, err = schema.GetWorkflowSource([]byte(strings.Replace(parentYaml, "      - data\n  train", "      - model\n  train", 1)))
+	assert.Nil(t, err)
+	_, err = inlinePipelineReferences(ctx.UserName, &wfs)
+	assert.NotNil(t, err)
+
+	// 循环引用
+	selfYaml := `name: loop
+entry_points:
+  self:
+    reference:
+      pipeline: loop
+`
+	loopPpl := model.Pipeline{ID: "ppl-000002", Name: "loop", UserName: "user1"}
+	loopVersion := model.PipelineVersion{PipelineID: loopPpl.ID, PipelineYaml: selfYaml, UserName: "user1"}
+	_, _, err = storage.Pipeline.CreatePipeline(ctx.Logging(), &loopPpl, &loopVersion)
+	assert.Nil(t, err)
+	wfs, err = schema.GetWorkflowSource([]byte(selfYaml))
+	assert.Nil(t, err)
+	_, err = inlinePipelineReferences(ctx.UserName, &wfs)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "recursively referenced")
+}
@@ -0,0 +1,305 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/handler"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	pplcommon "github.com/PaddlePaddle/PaddleFlow/pkg/pipeline/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+)
+
+// ValidatePipelineRequest yaml来源优先级：YamlRaw > FsName + YamlPath
+type ValidatePipelineRequest struct {
+	FsName   string `json:"fsName"`   // optional
+	YamlPath string `json:"yamlPath"` // optional, use "./run.yaml" if not specified
+	YamlRaw  string `json:"yamlRaw"`  // optional, base64 encoded
+	UserName string `json:"username"` // optional, only for root user
+}
+
+type ValidatePipelineResponse struct {
+	Valid  bool                      `json:"valid"`
+	Name   string                    `json:"name"`
+	Errors []PipelineValidationError `json:"errors"`
+}
+
+type PipelineValidationError struct {
+	Component string `json:"component"` // 出错节点的完整名称，如 main.train；为空表示整体错误
+	Message   string `json:"message"`
+}
+
+var upstreamTplRegex = regexp.MustCompile(pplcommon.RegExpIncludingUpstreamTpl)
+
+// ValidatePipeline 对pipeline yaml做静态校验，不创建任何资源，尽可能一次性返回所有错误
+func ValidatePipeline(ctx *logger.RequestContext, request ValidatePipelineRequest) (ValidatePipelineResponse, error) {
+	pipelineYaml, err := getValidatePipelineYaml(ctx, request)
+	if err != nil {
+		ctx.Logging().Errorf(err.Error())
+		return ValidatePipelineResponse{}, err
+	}
+
+	response := ValidatePipelineResponse{Errors: []PipelineValidationError{}}
+	wfs, err := schema.GetWorkflowSource(pipelineYaml)
+	if err != nil {
+		response.Errors = append(response.Errors, PipelineValidationError{Message: err.Error()})
+		return response, nil
+	}
+	response.Name = wfs.Name
+
+	if _, err := inlinePipelineReferences(ctx.UserName, &wfs); err != nil {
+		response.Errors = append(response.Errors, PipelineValidationError{Message: err.Error()})
+	}
+
+	checker := pipelineStaticChecker{wfs: &wfs, errs: []PipelineValidationError{}}
+	checker.check()
+	response.Errors = append(response.Errors, checker.errs...)
+
+	// 结构性错误会让NewWorkflow的报错重复或失真，因此只有在静态检查通过后才做完整校验
+	if len(response.Errors) == 0 {
+		if _, err := validateWorkflowForPipeline(string(pipelineYaml), ctx.UserName, request.UserName); err != nil {
+			response.Errors = append(response.Errors, PipelineValidationError{Message: err.Error()})
+		}
+	}
+
+	response.Valid = len(response.Errors) == 0
+	ctx.Logging().Debugf("validate pipeline[%s] finished, valid: %v, errors: %v", wfs.Name, response.Valid, response.Errors)
+	return response, nil
+}
+
+func getValidatePipelineYaml(ctx *logger.RequestContext, request ValidatePipelineRequest) ([]byte, error) {
+	if request.YamlRaw != "" {
+		pipelineYaml, err := base64.StdEncoding.DecodeString(request.YamlRaw)
+		if err != nil {
+			ctx.ErrorCode = common.InvalidArguments
+			return nil, fmt.Errorf("decode yamlRaw failed. err:%v", err)
+		}
+		return pipelineYaml, nil
+	}
+
+	if request.FsName == "" {
+		ctx.ErrorCode = common.InvalidArguments
+		return nil, fmt.Errorf("validate pipeline failed. one of yamlRaw and fsName shall be set")
+	}
+	fsID, err := CheckFsAndGetID(ctx.UserName, request.UserName, request.FsName)
+	if err != nil {
+		ctx.ErrorCode = common.InvalidArguments
+		return nil, err
+	}
+	if request.YamlPath == "" {
+		request.YamlPath = "./run.yaml"
+	}
+	pipelineYaml, err := handler.ReadFileFromFs(fsID, request.YamlPath, ctx.Logging())
+	if err != nil {
+		ctx.ErrorCode = common.InvalidArguments
+		return nil, fmt.Errorf("readFileFromFs[%s] from fs[%s] failed. err:%v", request.YamlPath, fsID, err)
+	}
+	return pipelineYaml, nil
+}
+
+type pipelineStaticChecker struct {
+	wfs  *schema.WorkflowSource
+	errs []PipelineValidationError
+}
+
+func (c *pipelineStaticChecker) addError(component string, format string, args ...interface{}) {
+	c.errs = append(c.errs, PipelineValidationError{Component: component, Message: fmt.Sprintf(format, args...)})
+}
+
+func (c *pipelineStaticChecker) check() {
+	c.checkDag(schema.EntryPointsStr, &c.wfs.EntryPoints)
+
+	postProcess := map[string]schema.Component{}
+	for name, step := range c.wfs.PostProcess {
+		postProcess[name] = step
+	}
+	c.checkComponents("post_process", nil, postProcess)
+
+	for _, name := range sortedComponentNames(c.wfs.Components) {
+		if dag, ok := c.wfs.Components[name].(*schema.WorkflowSourceDag); ok {
+			c.checkDag("components."+name, dag)
+		} else {
+			c.checkStep("components."+name, nil, c.wfs.Components[name], nil)
+		}
+	}
+}
+
+func (c *pipelineStaticChecker) checkDag(absName string, dag *schema.WorkflowSourceDag) {
+	c.checkComponents(absName, dag, dag.EntryPoints)
+	c.checkCycle(absName, dag.EntryPoints)
+}
+
+func (c *pipelineStaticChecker) checkComponents(parentName string, parent *schema.WorkflowSourceDag, components map[string]schema.Component) {
+	for _, name := range sortedComponentNames(components) {
+		comp := components[name]
+		absName := parentName + "." + name
+		c.checkStep(absName, parent, comp, components)
+		if dag, ok := comp.(*schema.WorkflowSourceDag); ok {
+			c.checkDag(absName, dag)
+		}
+	}
+}
+
+// checkStep 校验单个节点的deps、input artifacts引用以及flavour/queue
+// siblings为nil表示节点是components中的模板，其依赖关系由引用方决定，此时只校验flavour/queue
+func (c *pipelineStaticChecker) checkStep(absName string, parent *schema.WorkflowSourceDag, comp schema.Component, siblings map[string]schema.Component) {
+	if siblings != nil {
+		c.checkStepRefs(absName, parent, comp, siblings)
+	}
+
+	step, ok := comp.(*schema.WorkflowSourceStep)
+	if !ok {
+		return
+	}
+	if step.Reference.Component != "" {
+		if _, ok := c.wfs.Components[step.Reference.Component]; !ok {
+			c.addError(absName, "reference component[%s] not exist", step.Reference.Component)
+		}
+	}
+	if flavour := step.Env[schema.EnvJobFlavour]; flavour != "" && !strings.Contains(flavour, "{{") {
+		if _, err := storage.Flavour.GetFlavour(flavour); err != nil {
+			c.addError(absName, "flavour[%s] not exist", flavour)
+		}
+	}
+	if queue := step.Env[schema.EnvJobQueueName]; queue != "" && !strings.Contains(queue, "{{") {
+		if _, err := storage.Queue.GetQueueByName(queue); err != nil {
+			c.addError(absName, "queue[%s] not exist", queue)
+		}
+	}
+}
+
+func (c *pipelineStaticChecker) checkStepRefs(absName string, parent *schema.WorkflowSourceDag, comp schema.Component, siblings map[string]schema.Component) {
+	deps := comp.GetDeps()
+	for _, dep := range deps {
+		if _, ok := siblings[dep]; !ok {
+			c.addError(absName, "dep[%s] not exist", dep)
+		}
+	}
+
+	inputs := comp.GetArtifacts().Input
+	for _, artName := range sortedStringKeys(inputs) {
+		for _, match := range upstreamTplRegex.FindAllStringSubmatch(inputs[artName], -1) {
+			refList := strings.SplitN(match[2], ".", 2)
+			refComp, refArt := refList[0], refList[1]
+			if refComp == pplcommon.PF_PARENT {
+				if parent == nil {
+					c.addError(absName, "input artifact[%s]: PF_PARENT should used by a child component", artName)
+				} else if _, ok := parent.Artifacts.Input[refArt]; !ok {
+					c.addError(absName, "input artifact[%s]: parent has no input artifact[%s]", artName, refArt)
+				}
+				continue
+			}
+			if !pplcommon.StringsContain(deps, refComp) {
+				c.addError(absName, "input artifact[%s]: component[%s] not in deps", artName, refComp)
+				continue
+			}
+			upstream, ok := siblings[refComp]
+			if !ok {
+				continue
+			}
+			outputs, ok := c.outputArtifacts(upstream)
+			if !ok {
+				continue
+			}
+			if _, ok := outputs[refArt]; !ok {
+				c.addError(absName, "input artifact[%s]: component[%s] has no output artifact[%s]", artName, refComp, refArt)
+			}
+		}
+	}
+}
+
+// outputArtifacts 返回节点的output artifacts，reference节点取被引用component的定义
+func (c *pipelineStaticChecker) outputArtifacts(comp schema.Component) (map[string]string, bool) {
+	visited := map[string]bool{}
+	for {
+		step, ok := comp.(*schema.WorkflowSourceStep)
+		if !ok || step.Reference.Component == "" {
+			return comp.GetArtifacts().Output, true
+		}
+		refName := step.Reference.Component
+		if visited[refName] {
+			return nil, false
+		}
+		visited[refName] = true
+		if comp, ok = c.wfs.Components[refName]; !ok {
+			return nil, false
+		}
+	}
+}
+
+// checkCycle 检查同一层级节点间的依赖是否成环
+func (c *pipelineStaticChecker) checkCycle(dagName string, components map[string]schema.Component) {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := map[string]int{}
+	var visit func(name string, path []string) bool
+	visit = func(name string, path []string) bool {
+		switch state[name] {
+		case visiting:
+			c.addError(dagName, "cycle detected in deps: %s", strings.Join(append(path, name), " -> "))
+			return true
+		case visited:
+			return false
+		}
+		state[name] = visiting
+		for _, dep := range components[name].GetDeps() {
+			if _, ok := components[dep]; !ok {
+				continue
+			}
+			if visit(dep, append(path, name)) {
+				return true
+			}
+		}
+		state[name] = visited
+		return false
+	}
+
+	for _, name := range sortedComponentNames(components) {
+		if state[name] == unvisited && visit(name, nil) {
+			// 同一个dag只报告一个环，避免同一环被多次输出
+			return
+		}
+	}
+}
+
+func sortedComponentNames(components map[string]schema.Component) []string {
+	names := make([]string, 0, len(components))
+	for name := range components {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func sortedStringKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,128 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/agiledragon/gomonkey/v2"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	pkgPipeline "github.com/PaddlePaddle/PaddleFlow/pkg/pipeline"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage/driver"
+)
+
+const validatePipelineYaml = `
+name: validate_demo
+docker_env: python:3.7
+entry_points:
+  preprocess:
+    command: echo preprocess
+    env:
+      PF_JOB_FLAVOUR: flavour1
+      PF_JOB_QUEUE_NAME: queue1
+    artifacts:
+      output:
+      - train_data
+  train:
+    command: echo train
+    deps: preprocess
+    artifacts:
+      input:
+        data: "{{preprocess.train_data}}"
+      output:
+      - model
+`
+
+const invalidPipelineYaml = `
+name: validate_demo
+docker_env: python:3.7
+entry_points:
+  preprocess:
+    command: echo preprocess
+    deps: evaluate
+    env:
+      PF_JOB_FLAVOUR: not-exist-flavour
+      PF_JOB_QUEUE_NAME: not-exist-queue
+  train:
+    command: echo train
+    deps: preprocess,missing
+    artifacts:
+      input:
+        data: "{{preprocess.train_data}}"
+  evaluate:
+    command: echo evaluate
+    deps: train
+`
+
+func TestValidatePipeline(t *testing.T) {
+	driver.InitMockDB()
+	ctx := &logger.RequestContext{UserName: MockRootUser}
+
+	assert.Nil(t, storage.Flavour.CreateFlavour(&model.Flavour{Name: "flavour1", CPU: "1", Mem: "1Gi"}))
+	cluster := model.ClusterInfo{Model: model.Model{ID: "cluster1"}, Name: "cluster1", Status: model.ClusterStatusOnLine}
+	assert.Nil(t, storage.Cluster.CreateCluster(&cluster))
+	assert.Nil(t, storage.Queue.CreateQueue(&model.Queue{Name: "queue1", ClusterId: cluster.ID}))
+
+	patch := gomonkey.ApplyFunc(pkgPipeline.NewWorkflow, func(wfSource schema.WorkflowSource, runID string, params map[string]interface{}, extra map[string]string,
+		callbacks pkgPipeline.WorkflowCallbacks) (*pkgPipeline.Workflow, error) {
+		return &pkgPipeline.Workflow{}, nil
+	})
+	defer patch.Reset()
+
+	// valid yaml
+	resp, err := ValidatePipeline(ctx, ValidatePipelineRequest{
+		YamlRaw: base64.StdEncoding.EncodeToString([]byte(validatePipelineYaml)),
+	})
+	assert.Nil(t, err)
+	assert.True(t, resp.Valid)
+	assert.Equal(t, "validate_demo", resp.Name)
+	assert.Empty(t, resp.Errors)
+
+	// all errors are returned at once
+	resp, err = ValidatePipeline(ctx, ValidatePipelineRequest{
+		YamlRaw: base64.StdEncoding.EncodeToString([]byte(invalidPipelineYaml)),
+	})
+	assert.Nil(t, err)
+	assert.False(t, resp.Valid)
+	messages := []string{}
+	for _, e := range resp.Errors {
+		messages = append(messages, e.Component+": "+e.Message)
+	}
+	assert.Contains(t, messages, "entry_points.preprocess: flavour[not-exist-flavour] not exist")
+	assert.Contains(t, messages, "entry_points.preprocess: queue[not-exist-queue] not exist")
+	assert.Contains(t, messages, "entry_points.train: dep[missing] not exist")
+	assert.Contains(t, messages, "entry_points.train: input artifact[data]: component[preprocess] has no output artifact[train_data]")
+	assert.Contains(t, messages, "entry_points: cycle detected in deps: evaluate -> train -> preprocess -> evaluate")
+
+	// malformed yaml
+	resp, err = ValidatePipeline(ctx, ValidatePipelineRequest{
+		YamlRaw: base64.StdEncoding.EncodeToString([]byte("name: [")),
+	})
+	assert.Nil(t, err)
+	assert.False(t, resp.Valid)
+	assert.Equal(t, 1, len(resp.Errors))
+
+	// no yaml source
+	_, err = ValidatePipeline(ctx, ValidatePipelineRequest{})
+	assert.NotNil(t, err)
+}
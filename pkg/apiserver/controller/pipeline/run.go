@@ -43,7 +43,7 @@ import (
 	"github.com/PaddlePaddle/PaddleFlow/pkg/trace_logger"
 )
 
-var wfMap = make(map[string]*pipeline.Workflow, 0)
+var wfMap = make(map[string]WorkflowEngine, 0)
 
 const (
 	JsonFsOptions   = "fs_options" // 由于在获取BodyMap的FsOptions前已经转为下划线形式，因此这里为fs_options
@@ -56,6 +56,11 @@ const (
 
 	FinalRunStatus = "FINAL_RUN_STATUS"
 	FinalRunMsg    = "FINAL_RUN_MSG"
+
+	// RetryModeFailed 复用原run中成功节点的输出，只重新执行失败的节点及其下游节点
+	RetryModeFailed = "failed"
+	// RetryModeAll 从头重新执行整个run
+	RetryModeAll = "all"
 )
 
 type CreateRunRequest struct {
@@ -69,12 +74,16 @@ type CreateRunRequest struct {
 	// 为了防止字符串或者不同的http客户端对run.yaml
 	// 格式中的特殊字符串做特殊过滤处理导致yaml文件不正确，因此采用runYamlRaw采用base64编码传输
 	Disabled          string `json:"disabled,omitempty"`          // optional
+	FailureStrategy   string `json:"failureStrategy,omitempty"`   // optional. fail_fast or continue, overrides failure_options in yaml
 	RunYamlRaw        string `json:"runYamlRaw,omitempty"`        // optional. one of 3 sources of run. high priority
 	PipelineID        string `json:"pipelineID,omitempty"`        // optional. one of 3 sources of run. medium priority
 	PipelineVersionID string `json:"pipelineVersionID,omitempty"` // optional. one of 3 sources of run. medium priority
 	RunYamlPath       string `json:"runYamlPath,omitempty"`       // optional. one of 3 sources of run. low priority
 	ScheduleID        string `json:"scheduleID"`
 	ScheduledAt       string `json:"scheduledAt"`
+
+	Notification *schema.Notification `json:"notification,omitempty"` // optional. overrides notification in yaml
+	Limits       *schema.RunLimits    `json:"limits,omitempty"`       // optional. overrides limits in yaml
 }
 
 // used for API CreateRunJson to unmarshal steps in entryPoints and postProcess
@@ -98,7 +107,8 @@ type ArtifactsJson struct {
 }
 
 type UpdateRunRequest struct {
-	StopForce bool `json:"stopForce"`
+	StopForce bool   `json:"stopForce"`
+	RetryMode string `json:"retryMode,omitempty"` // optional. failed or all, default failed
 }
 
 type DeleteRunRequest struct {
@@ -209,7 +219,8 @@ func buildWorkflowSource(ctx logger.RequestContext, req CreateRunRequest, fsID s
 		}
 
 		runYaml = pplVersion.PipelineYaml
-		source = fmt.Sprintf("%s-%s", req.PipelineID, req.PipelineVersionID)
+		// 未指定版本时使用最新版本，source中记录实际使用的版本，以便复现
+		source = fmt.Sprintf("%s-%s", req.PipelineID, pplVersion.ID)
 	} else { // low priority: wfs in fs, read from runYamlPath
 		if fsID == "" {
 			err := fmt.Errorf("can not get runYaml without fs")
@@ -244,6 +255,19 @@ func buildWorkflowSource(ctx logger.RequestContext, req CreateRunRequest, fsID s
 		logger.Logger().Errorf("runYamlAndReqToWfs failed. err:%v", err)
 		return schema.WorkflowSource{}, "", "", err
 	}
+
+	// 展开引用的pipeline，run中保存展开后的yaml，保证重跑时使用相同的子工作流
+	inlined, err := inlinePipelineReferences(userName, &wfs)
+	if err != nil {
+		logger.Logger().Errorf("inline pipeline references failed. err:%v", err)
+		return schema.WorkflowSource{}, "", "", err
+	}
+	if inlined {
+		if _, runYaml, err = getSourceAndYaml(wfs); err != nil {
+			logger.Logger().Errorf("get yaml by inlined workflowsource failed. err: %v", err)
+			return schema.WorkflowSource{}, "", "", err
+		}
+	}
 	return wfs, source, runYaml, nil
 }
 
@@ -476,6 +500,9 @@ func runYamlAndReqToWfs(runYaml string, req CreateRunRequest) (schema.WorkflowSo
 	if req.Disabled != "" {
 		wfs.Disabled = req.Disabled
 	}
+	if req.FailureStrategy != "" {
+		wfs.FailureOptions.Strategy = req.FailureStrategy
+	}
 	if req.FsName != "" {
 		if wfs.FsOptions.MainFS.Name == "" {
 			wfs.FsOptions.MainFS.Name = req.FsName
@@ -514,6 +541,15 @@ func CreateRun(ctx logger.RequestContext, request *CreateRunRequest, extra map[s
 	// TODO:// validate flavour
 	// TODO:// validate queue
 
+	if err := request.Limits.Validate(); err != nil {
+		logger.Logger().Errorf("create run failed as limits invalid. error:%v", err)
+		return CreateRunResponse{}, err
+	}
+	if err := request.Notification.Validate(); err != nil {
+		logger.Logger().Errorf("create run failed as notification invalid. error:%v", err)
+		return CreateRunResponse{}, err
+	}
+
 	trace_logger.Key(requestId).Infof("build workflow source for run: %+v", request)
 	wfs, source, runYaml, err := buildWorkflowSource(ctx, *request, fsID)
 	if err != nil {
@@ -563,7 +599,7 @@ func CreateRun(ctx logger.RequestContext, request *CreateRunRequest, extra map[s
 		Disabled:       request.Disabled,
 		ScheduleID:     request.ScheduleID,
 		ScheduledAt:    scheduledAt,
-		RunOptions:     schema.RunOptions{FSUsername: userName},
+		RunOptions:     schema.RunOptions{FSUsername: userName, FailureStrategy: request.FailureStrategy, Notification: request.Notification, Limits: request.Limits},
 		Status:         "", // to be filled later
 		Message:        "", // to be filld later
 	}
@@ -654,6 +690,11 @@ func CreateRunByJson(ctx logger.RequestContext, bodyMap map[string]interface{})
 		return CreateRunResponse{}, err
 	}
 
+	if _, err := inlinePipelineReferences(ctxUserName, &wfs); err != nil {
+		logger.Logger().Errorf("inline pipeline references failed. error:%v", err)
+		return CreateRunResponse{}, err
+	}
+
 	trace_logger.Key(requestId).Infof("get source and yaml for run: %+v", bodyMap)
 	source, runYaml, err := getSourceAndYaml(wfs)
 	if err != nil {
@@ -717,6 +758,33 @@ func ValidateAndCreateRun(ctx logger.RequestContext, run *models.Run, userName s
 		return nil, "", err
 	}
 
+	if err := checkStepQueues(&ctx, &run.WorkflowSource); err != nil {
+		logger.Logger().Errorf("check queues of steps failed. error:%v", err)
+		return nil, "", err
+	}
+
+	// 记录校验后的参数值，便于在run详情中查看
+	run.ResolvedParameters = wfPtr.ResolvedParameters()
+
+	// 记录执行引擎，后续resume、stop时沿用
+	engine, err := configuredEngine()
+	if err != nil {
+		logger.Logger().Errorf("get pipeline engine failed. error:%v", err)
+		return nil, "", err
+	}
+	run.RunOptions.Engine = engine
+	if engine == EngineArgo && !common.IsRunFinalStatus(run.Status) {
+		if _, _, err := buildArgoWorkflow(*run); err != nil {
+			logger.Logger().Errorf("convert run to argo workflow failed. error:%v", err)
+			ctx.ErrorCode = common.InvlidPipeline
+			return nil, "", err
+		}
+	}
+	if err := run.Encode(); err != nil {
+		logger.Logger().Errorf("encode run failed. error:%s", err.Error())
+		return nil, "", err
+	}
+
 	// generate run id here
 	trace_logger.Key(requestId).Infof("create run in db")
 	// create run in db and update run's ID by pk
@@ -927,8 +995,20 @@ func StopRun(logEntry *log.Entry, userName, runID string, request UpdateRunReque
 	return nil
 }
 
-func RetryRun(ctx *logger.RequestContext, runID string) (string, error) {
-	ctx.Logging().Debugf("begin retry run. runID:%s\n", runID)
+func RetryRun(ctx *logger.RequestContext, runID string, request UpdateRunRequest) (string, error) {
+	ctx.Logging().Debugf("begin retry run. runID:%s, retryMode:%s\n", runID, request.RetryMode)
+	retryMode := request.RetryMode
+	if retryMode == "" {
+		retryMode = RetryModeFailed
+	}
+	if retryMode != RetryModeFailed && retryMode != RetryModeAll {
+		err := fmt.Errorf("retry run[%s] failed, retryMode[%s] is invalid, should be one of [%s, %s]",
+			runID, retryMode, RetryModeFailed, RetryModeAll)
+		ctx.ErrorCode = common.InvalidArguments
+		ctx.Logging().Errorln(err.Error())
+		return "", err
+	}
+
 	// check run exist && check user access right
 	run, err := GetRunByID(ctx.Logging(), ctx.UserName, runID)
 	if err != nil {
@@ -946,7 +1026,7 @@ func RetryRun(ctx *logger.RequestContext, runID string) (string, error) {
 	}
 
 	// restart
-	newRunID, err := restartRun(run, false)
+	newRunID, err := restartRun(run, false, retryMode)
 	if err != nil {
 		ctx.Logging().Errorf("retry run[%s] failed resumeRun. run:%+v. error:%s\n",
 			runID, run, err.Error())
@@ -1008,6 +1088,12 @@ func DeleteRun(ctx *logger.RequestContext, id string, request *DeleteRunRequest)
 		}
 	}
 
+	if err := storage.Tracking.DeleteRunTracking(ctx.Logging(), id); err != nil {
+		ctx.ErrorCode = common.InternalError
+		ctx.Logging().Errorf("delete tracking data of run[%s] failed. error:%s", id, err.Error())
+		return err
+	}
+
 	// delete
 	if err := models.DeleteRun(ctx.Logging(), id); err != nil {
 		ctx.ErrorCode = common.InternalError
@@ -1042,7 +1128,7 @@ func resumeActiveRuns() error {
 	go func() {
 		for _, run := range runList {
 			logger.LoggerForRun(run.ID).Debugf("ResumeActiveRuns: run[%s] with status[%s] begins to resume\n", run.ID, run.Status)
-			if _, err := restartRun(run, true); err != nil {
+			if _, err := restartRun(run, true, ""); err != nil {
 				logger.LoggerForRun(run.ID).Warnf("ResumeActiveRuns: run[%s] with status[%s] failed to resume. skipped.", run.ID, run.Status)
 			}
 		}
@@ -1050,21 +1136,14 @@ func resumeActiveRuns() error {
 	return nil
 }
 
-func restartRun(run models.Run, isResume bool) (string, error) {
-	if run.RunCachedIDs != "" {
-		// 由于非成功完成的Run也会被Cache，且重跑会直接对原始的Run记录进行修改，
-		// 因此被Cache的Run不能重跑
-		// TODO: 考虑将重跑逻辑又“直接修改Run记录”改为“根据该Run的设置，重新发起Run”
-		err := fmt.Errorf("can not retry run cached.")
-		logger.LoggerForRun(run.ID).Errorf(err.Error())
-		return "", err
-	}
-
+// restartRun 恢复或重跑run，isResume为false时，retryMode决定重跑时是否复用原run中成功节点的输出
+func restartRun(run models.Run, isResume bool, retryMode string) (string, error) {
 	wfs, err := runYamlAndReqToWfs(run.RunYaml, CreateRunRequest{
-		FsName:    run.FsName,
-		DockerEnv: run.DockerEnv,
-		Name:      run.Name,
-		Disabled:  run.Disabled,
+		FsName:          run.FsName,
+		DockerEnv:       run.DockerEnv,
+		Name:            run.Name,
+		Disabled:        run.Disabled,
+		FailureStrategy: run.RunOptions.FailureStrategy,
 	})
 	if err != nil {
 		logger.LoggerForRun(run.ID).Errorf("get WorkflowSource by yaml failed. yaml: %s \n, err:%v", run.RunYaml, err)
@@ -1090,7 +1169,7 @@ func restartRun(run models.Run, isResume bool) (string, error) {
 		}
 	}()
 
-	runID, err := RestartWf(run, isResume)
+	runID, err := RestartWf(run, isResume, retryMode)
 	if err != nil {
 		logger.LoggerForRun(run.ID).Errorf("resume run[%s] failed RestartWf. DockerEnv[%s] fsID[%s]. error:%s\n",
 			run.ID, run.WorkflowSource.DockerEnv, run.FsID, err.Error())
@@ -1104,61 +1183,70 @@ func StartWf(run models.Run, wfPtr *pipeline.Workflow) error {
 	logEntry.Debugf("StartWf run:%+v", run)
 	trace_logger.Key(run.ID).Debugf("StartWf run:%+v", run)
 
-	// 由于在数据库中创建Run记录之前，没有runID，因此这里需要重新填写好runID后，初始化Runtime，以及填写wfMap
-	wfPtr.RunID = run.ID
-	if err := wfPtr.NewWorkflowRuntime(); err != nil {
+	// 由于在数据库中创建Run记录之前，没有runID，因此这里需要重新填写好runID后，初始化执行引擎，以及填写wfMap
+	engine, err := newWorkflowEngine(run, wfPtr)
+	if err != nil {
 		logEntry.Errorf("StartWf failed, error: %s", err.Error())
 		return err
 	}
-	wfMap[run.ID] = wfPtr
+	wfMap[run.ID] = engine
 
 	if err := models.UpdateRunStatus(logEntry, run.ID, common.StatusRunPending); err != nil {
 		return err
 	}
 
 	trace_logger.Key(run.ID).Infof("start workflow with image url")
-	wfPtr.Start()
+	engine.Start()
 	logEntry.Debugf("workflow started")
 
 	return nil
 }
 
-func RestartWf(run models.Run, isResume bool) (string, error) {
+func RestartWf(run models.Run, isResume bool, retryMode string) (string, error) {
 	logEntry := logger.LoggerForRun(run.ID)
 	logEntry.Debugf("RestartWf run:%+v", run)
 	trace_logger.Key(run.ID).Debugf("RestartWf run:%+v", run)
 
 	// 如果是restart
 	if !isResume {
-		// 获取所有job和dag
-		jobs, err := models.GetRunJobsOfRun(logEntry, run.ID)
-		if err != nil {
-			return "", err
-		}
-		dags, err := models.GetRunDagsOfRun(logEntry, run.ID)
-		if err != nil {
-			return "", err
-		}
-
-		// 剔除canceled的job、dag，剔除failed、termiated的job
+		originRunID := run.ID
 		newJobs := []models.RunJob{}
-		for _, job := range jobs {
-			if job.Status != schema.StatusJobCancelled &&
-				job.Status != schema.StatusJobFailed && job.Status != schema.StatusJobTerminated {
-				newJobs = append(newJobs, job)
+		newDags := []models.RunDag{}
+		// RetryModeAll 不拷贝原run的任何节点，从头开始执行
+		if retryMode != RetryModeAll {
+			// 获取所有job和dag
+			jobs, err := models.GetRunJobsOfRun(logEntry, run.ID)
+			if err != nil {
+				return "", err
+			}
+			dags, err := models.GetRunDagsOfRun(logEntry, run.ID)
+			if err != nil {
+				return "", err
 			}
-		}
 
-		newDags := []models.RunDag{}
-		for _, dag := range dags {
-			if dag.Status != schema.StatusJobCancelled {
-				newDags = append(newDags, dag)
+			// 剔除canceled的job、dag，剔除failed、termiated的job
+			for _, job := range jobs {
+				if job.Status != schema.StatusJobCancelled &&
+					job.Status != schema.StatusJobFailed && job.Status != schema.StatusJobTerminated {
+					// 成功节点的输出artifact仍在原run目录下，以cache的形式记录，避免原run删除时被清理
+					if job.Status == schema.StatusJobSucceeded && job.CacheRunID == "" {
+						job.CacheRunID = originRunID
+					}
+					newJobs = append(newJobs, job)
+				}
+			}
+
+			for _, dag := range dags {
+				if dag.Status != schema.StatusJobCancelled {
+					newDags = append(newDags, dag)
+				}
 			}
 		}
 
 		// 创建新Run记录，拷贝runtime中的所有dag和job
 		run.Pk = 0
 		run.ID = ""
+		run.RunCachedIDs = ""
 		run.RunOptions.StopForce = false
 		run.Encode()
 		if _, err := models.CreateRun(logEntry, &run); err != nil {
@@ -1180,6 +1268,10 @@ func RestartWf(run models.Run, isResume bool) (string, error) {
 			if _, err := models.CreateRunJob(logEntry, &job); err != nil {
 				return "", err
 			}
+			jobView := job.Trans2JobView()
+			if err := updateRunCache(logEntry, &jobView, run.ID); err != nil {
+				return "", err
+			}
 			newJobs[i] = job
 		}
 
@@ -1189,7 +1281,7 @@ func RestartWf(run models.Run, isResume bool) (string, error) {
 		}
 	}
 
-	wfPtr, err := newWorkflowByRun(run)
+	engine, err := newEngineByRun(run)
 	if err != nil {
 		return "", err
 	}
@@ -1203,13 +1295,12 @@ func RestartWf(run models.Run, isResume bool) (string, error) {
 	}
 
 	if isResume {
-		wfPtr.Resume(entryPointDagView, run.PostProcess, run.Status, run.RunOptions.StopForce)
+		engine.Resume(entryPointDagView, run.PostProcess, run.Status, run.RunOptions.StopForce)
 	} else {
-		wfMap[run.ID] = wfPtr
 		if err := models.UpdateRunStatus(logEntry, run.ID, common.StatusRunPending); err != nil {
 			return "", err
 		}
-		wfPtr.Restart(entryPointDagView, run.PostProcess)
+		engine.Restart(entryPointDagView, run.PostProcess)
 	}
 	logEntry.Debugf("workflow restarted, run:%+v", run)
 
@@ -1233,9 +1324,5 @@ func newWorkflowByRun(run models.Run) (*pipeline.Workflow, error) {
 		logger.LoggerForRun(run.ID).Errorln(err.Error())
 		return nil, err
 	}
-	// 如果此时没有runID的话，那么在后续有runID之后，需要：1. 填充wfMap 2. 初始化wf.runtime
-	if run.ID != "" {
-		wfMap[run.ID] = wfPtr
-	}
 	return wfPtr, nil
 }
@@ -0,0 +1,104 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/models"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/pipeline"
+	pplcommon "github.com/PaddlePaddle/PaddleFlow/pkg/pipeline/common"
+)
+
+type ApproveRunJobRequest struct {
+	JobID    string `json:"jobID"`
+	Approved bool   `json:"approved"`
+	Comment  string `json:"comment"` // optional
+}
+
+// ApproveRunJob 批准或拒绝run中等待审批的节点
+// 节点未配置approvers时，仅run的创建者与root可以审批；配置了approvers时，仅approvers与root可以审批
+func ApproveRunJob(ctx *logger.RequestContext, runID string, request ApproveRunJobRequest) error {
+	ctx.Logging().Debugf("begin approve job[%s] of run[%s], approved: %v", request.JobID, runID, request.Approved)
+	run, err := models.GetRunByID(ctx.Logging(), runID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.ErrorCode = common.RunNotFound
+			err = common.NotFoundError(common.ResourceTypeRun, runID)
+		} else {
+			ctx.ErrorCode = common.InternalError
+		}
+		ctx.Logging().Errorln(err.Error())
+		return err
+	}
+
+	if common.IsRunFinalStatus(run.Status) {
+		ctx.ErrorCode = common.ActionNotAllowed
+		err := fmt.Errorf("cannot approve job of run[%s] as run is already in status[%s]", runID, run.Status)
+		ctx.Logging().Errorln(err.Error())
+		return err
+	}
+
+	approvalJob, ok := pipeline.GetPendingApproval(request.JobID)
+	if !ok || approvalJob.Job().Env[pplcommon.SysParamNamePFRunID] != runID {
+		ctx.ErrorCode = common.InvalidArguments
+		err := fmt.Errorf("job[%s] of run[%s] is not waiting for approval", request.JobID, runID)
+		ctx.Logging().Errorln(err.Error())
+		return err
+	}
+
+	if !canApprove(ctx.UserName, run.UserName, approvalJob.Approvers()) {
+		ctx.ErrorCode = common.AccessDenied
+		err := common.NoAccessError(ctx.UserName, common.ResourceTypeRun, runID)
+		ctx.Logging().Errorln(err.Error())
+		return err
+	}
+
+	decision := pipeline.ApprovalDecision{
+		Approved: request.Approved,
+		UserName: ctx.UserName,
+		Comment:  request.Comment,
+	}
+	if err := approvalJob.Decide(decision); err != nil {
+		ctx.ErrorCode = common.ActionNotAllowed
+		ctx.Logging().Errorln(err.Error())
+		return err
+	}
+	ctx.Logging().Infof("job[%s] of run[%s] is approved by user[%s], approved: %v", request.JobID, runID,
+		ctx.UserName, request.Approved)
+	return nil
+}
+
+func canApprove(userName, runOwner string, approvers []string) bool {
+	if common.IsRootUser(userName) {
+		return true
+	}
+	if len(approvers) == 0 {
+		return userName == runOwner
+	}
+	for _, approver := range approvers {
+		if approver == userName {
+			return true
+		}
+	}
+	return false
+}
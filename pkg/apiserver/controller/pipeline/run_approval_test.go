@@ -0,0 +1,71 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/models"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	pkgPipeline "github.com/PaddlePaddle/PaddleFlow/pkg/pipeline"
+	pplcommon "github.com/PaddlePaddle/PaddleFlow/pkg/pipeline/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage/driver"
+)
+
+func TestApproveRunJob(t *testing.T) {
+	driver.InitMockDB()
+	ctx := &logger.RequestContext{UserName: MockNormalUser}
+
+	run := getMockRunWithoutRuntime()
+	run.UserName = MockNormalUser
+	run.Encode()
+	runID, err := models.CreateRun(ctx.Logging(), &run)
+	assert.Nil(t, err)
+
+	eventChan := make(chan pkgPipeline.WorkflowEvent, 10)
+	job := pkgPipeline.NewApprovalJob("gate", &schema.ApprovalStep{Approvers: []string{"alice"}}, eventChan)
+	job.Update("", nil, map[string]string{pplcommon.SysParamNamePFRunID: runID}, nil)
+	jobID, err := job.Start()
+	assert.Nil(t, err)
+	<-eventChan
+
+	// job not waiting for approval
+	err = ApproveRunJob(ctx, runID, ApproveRunJobRequest{JobID: "approval-not-exist", Approved: true})
+	assert.NotNil(t, err)
+	assert.Equal(t, common.InvalidArguments, ctx.ErrorCode)
+
+	// run owner is not in approvers
+	ctx = &logger.RequestContext{UserName: MockNormalUser}
+	err = ApproveRunJob(ctx, runID, ApproveRunJobRequest{JobID: jobID, Approved: true})
+	assert.NotNil(t, err)
+	assert.Equal(t, common.AccessDenied, ctx.ErrorCode)
+
+	ctx = &logger.RequestContext{UserName: "alice"}
+	err = ApproveRunJob(ctx, runID, ApproveRunJobRequest{JobID: jobID, Approved: true, Comment: "lgtm"})
+	assert.Nil(t, err)
+	event := <-eventChan
+	assert.Equal(t, schema.StatusJobSucceeded, event.Extra["status"])
+	assert.Equal(t, "approved by alice: lgtm", event.Message)
+
+	assert.True(t, canApprove(MockRootUser, MockNormalUser, []string{"alice"}))
+	assert.True(t, canApprove(MockNormalUser, MockNormalUser, nil))
+	assert.False(t, canApprove("alice", MockNormalUser, nil))
+}
@@ -0,0 +1,302 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	pplcommon "github.com/PaddlePaddle/PaddleFlow/pkg/pipeline/common"
+)
+
+const (
+	RunDagNodeTypeStep = "step"
+	RunDagNodeTypeDag  = "dag"
+
+	RunDagEdgeTypeDependency = "dependency"
+	RunDagEdgeTypeArtifact   = "artifact"
+
+	// artifact模板中引用父节点的关键字，如 {{PF_PARENT.xxx}}
+	runDagParentRef = "PF_PARENT"
+)
+
+type GetRunDagResponse struct {
+	RunID  string       `json:"runID"`
+	Status string       `json:"status"`
+	Nodes  []RunDagNode `json:"nodes"`
+	Edges  []RunDagEdge `json:"edges"`
+}
+
+// RunDagNode 对应yaml中的一个节点，循环节点的每次运行记录在Instances中
+type RunDagNode struct {
+	ID            string           `json:"id"` // 节点全名，如 square-loop.square
+	Name          string           `json:"name"`
+	Type          string           `json:"type"` // step or dag
+	ParentID      string           `json:"parentID"`
+	IsPostProcess bool             `json:"isPostProcess"`
+	Status        string           `json:"status"` // 为空表示节点还未被调度
+	StartTime     string           `json:"startTime"`
+	EndTime       string           `json:"endTime"`
+	Deps          []string         `json:"deps"`
+	Instances     []RunDagInstance `json:"instances"`
+}
+
+type RunDagInstance struct {
+	ID        string `json:"id"` // jobID or dagID
+	LoopSeq   int    `json:"loopSeq"`
+	Status    string `json:"status"`
+	StartTime string `json:"startTime"`
+	EndTime   string `json:"endTime"`
+	Message   string `json:"message"`
+}
+
+type RunDagEdge struct {
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Type     string `json:"type"`               // dependency or artifact
+	Artifact string `json:"artifact,omitempty"` // artifact边对应的artifact名称
+}
+
+// 多个实例的状态聚合时的优先级，靠前的优先
+var runDagStatusPriority = []schema.JobStatus{
+	schema.StatusJobRunning,
+	schema.StatusJobTerminating,
+	schema.StatusJobPending,
+	schema.StatusJobInit,
+	schema.StatusJobFailed,
+	schema.StatusJobTerminated,
+	schema.StatusJobCancelled,
+	schema.StatusJobSucceeded,
+	schema.StatusJobSkipped,
+}
+
+// GetRunDag 返回run的DAG结构，包括节点状态、依赖关系与artifact传递关系，便于前端直接渲染
+func GetRunDag(ctx *logger.RequestContext, runID string) (GetRunDagResponse, error) {
+	ctx.Logging().Debugf("begin get dag of run[%s]", runID)
+	run, err := GetRunByID(ctx.Logging(), ctx.UserName, runID)
+	if err != nil {
+		ctx.ErrorCode = common.InvalidArguments
+		ctx.Logging().Errorf("get dag of run[%s] failed. error:%s", runID, err.Error())
+		return GetRunDagResponse{}, err
+	}
+
+	wfs := run.WorkflowSource
+	if run.Disabled != "" {
+		wfs.Disabled = run.Disabled
+	}
+
+	instances := map[string][]schema.ComponentView{}
+	collectRunDagInstances(run.RemoveOuterDagView(run.Runtime), "", instances)
+
+	builder := &runDagBuilder{
+		wfs:       &wfs,
+		instances: instances,
+		nodeIDs:   map[string]bool{},
+	}
+	builder.addComponents(wfs.EntryPoints.EntryPoints, "", false)
+
+	postProcess := map[string]schema.Component{}
+	for name, step := range wfs.PostProcess {
+		postProcess[name] = step
+	}
+	for name, jobView := range run.PostProcess {
+		builder.instances[name] = []schema.ComponentView{jobView}
+	}
+	builder.addComponents(postProcess, "", true)
+
+	// 去掉指向不存在节点(如disabled节点)的边
+	edges := make([]RunDagEdge, 0, len(builder.edges))
+	for _, edge := range builder.edges {
+		if builder.nodeIDs[edge.From] && builder.nodeIDs[edge.To] {
+			edges = append(edges, edge)
+		}
+	}
+
+	response := GetRunDagResponse{
+		RunID:  run.ID,
+		Status: run.Status,
+		Nodes:  builder.nodes,
+		Edges:  edges,
+	}
+	return response, nil
+}
+
+type runDagBuilder struct {
+	wfs       *schema.WorkflowSource
+	instances map[string][]schema.ComponentView
+	nodeIDs   map[string]bool
+	nodes     []RunDagNode
+	edges     []RunDagEdge
+}
+
+func (b *runDagBuilder) addComponents(components map[string]schema.Component, parentID string, isPostProcess bool) {
+	// 按名称排序，保证多次请求返回的顺序一致
+	names := make([]string, 0, len(components))
+	for name := range components {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		nodeID := joinRunDagName(parentID, name)
+		if disabled, _ := b.wfs.IsDisabled(nodeID); disabled {
+			continue
+		}
+		comp := b.resolveReference(components[name])
+
+		node := RunDagNode{
+			ID:            nodeID,
+			Name:          name,
+			Type:          RunDagNodeTypeStep,
+			ParentID:      parentID,
+			IsPostProcess: isPostProcess,
+			Deps:          []string{},
+			Instances:     []RunDagInstance{},
+		}
+		for _, dep := range comp.GetDeps() {
+			depID := joinRunDagName(parentID, dep)
+			node.Deps = append(node.Deps, depID)
+			b.edges = append(b.edges, RunDagEdge{From: depID, To: nodeID, Type: RunDagEdgeTypeDependency})
+		}
+		for artName, value := range comp.GetArtifacts().Input {
+			if from := b.refComponentID(value, parentID, parentID); from != "" {
+				b.edges = append(b.edges, RunDagEdge{From: from, To: nodeID, Type: RunDagEdgeTypeArtifact, Artifact: artName})
+			}
+		}
+		b.fillStatus(&node, b.instances[nodeID])
+
+		b.nodeIDs[nodeID] = true
+		b.nodes = append(b.nodes, node)
+
+		if dag, ok := comp.(*schema.WorkflowSourceDag); ok {
+			b.nodes[len(b.nodes)-1].Type = RunDagNodeTypeDag
+			// dag的输出artifact来自于子节点
+			for artName, value := range dag.Artifacts.Output {
+				if from := b.refComponentID(value, nodeID, ""); from != "" {
+					b.edges = append(b.edges, RunDagEdge{From: from, To: nodeID, Type: RunDagEdgeTypeArtifact, Artifact: artName})
+				}
+			}
+			b.addComponents(dag.EntryPoints, nodeID, isPostProcess)
+		}
+	}
+}
+
+// resolveReference 如果step引用了components中的节点，则返回最终引用的节点
+func (b *runDagBuilder) resolveReference(comp schema.Component) schema.Component {
+	for {
+		step, ok := comp.(*schema.WorkflowSourceStep)
+		if !ok || step.Reference.Component == "" {
+			return comp
+		}
+		referred, ok := b.wfs.Components[step.Reference.Component]
+		if !ok {
+			return comp
+		}
+		comp = referred
+	}
+}
+
+// refComponentID 解析形如 {{comp.art}} 的artifact模板，返回被引用节点的ID
+// siblingPrefix 为被引用节点的父节点ID，parentID 为 PF_PARENT 所指代的节点ID
+func (b *runDagBuilder) refComponentID(value, siblingPrefix, parentID string) string {
+	reg := regexp.MustCompile(pplcommon.RegExpIncludingTpl)
+	matches := reg.FindStringSubmatch(value)
+	if len(matches) < 3 {
+		return ""
+	}
+	refs := strings.SplitN(matches[2], ".", 2)
+	if len(refs) != 2 {
+		return ""
+	}
+	if refs[0] == runDagParentRef {
+		return parentID
+	}
+	return joinRunDagName(siblingPrefix, refs[0])
+}
+
+func (b *runDagBuilder) fillStatus(node *RunDagNode, views []schema.ComponentView) {
+	if len(views) == 0 {
+		return
+	}
+
+	statusSet := map[schema.JobStatus]bool{}
+	allFinal := true
+	for _, view := range views {
+		instance := RunDagInstance{
+			LoopSeq:   view.GetSeq(),
+			Status:    string(view.GetStatus()),
+			StartTime: view.GetStartTime(),
+			EndTime:   view.GetEndTime(),
+			Message:   view.GetMsg(),
+		}
+		switch v := view.(type) {
+		case *schema.JobView:
+			instance.ID = v.JobID
+		case *schema.DagView:
+			instance.ID = v.DagID
+		}
+		node.Instances = append(node.Instances, instance)
+
+		statusSet[view.GetStatus()] = true
+		if !schema.IsImmutableJobStatus(view.GetStatus()) {
+			allFinal = false
+		}
+		if instance.StartTime != "" && (node.StartTime == "" || instance.StartTime < node.StartTime) {
+			node.StartTime = instance.StartTime
+		}
+		if instance.EndTime > node.EndTime {
+			node.EndTime = instance.EndTime
+		}
+	}
+	sort.Slice(node.Instances, func(i, j int) bool {
+		return node.Instances[i].LoopSeq < node.Instances[j].LoopSeq
+	})
+
+	for _, status := range runDagStatusPriority {
+		if statusSet[status] {
+			node.Status = string(status)
+			break
+		}
+	}
+	if !allFinal {
+		node.EndTime = ""
+	}
+}
+
+// collectRunDagInstances 将runtime树按节点全名展开，循环节点会有多个实例
+func collectRunDagInstances(views map[string][]schema.ComponentView, prefix string, instances map[string][]schema.ComponentView) {
+	for name, compList := range views {
+		fullName := joinRunDagName(prefix, name)
+		for _, comp := range compList {
+			instances[fullName] = append(instances[fullName], comp)
+			if dagView, ok := comp.(*schema.DagView); ok {
+				collectRunDagInstances(dagView.EntryPoints, fullName, instances)
+			}
+		}
+	}
+}
+
+func joinRunDagName(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
@@ -0,0 +1,100 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/models"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage/driver"
+)
+
+func TestGetRunDag(t *testing.T) {
+	driver.InitMockDB()
+	ctx := &logger.RequestContext{UserName: MockRootUser}
+
+	run, err := getMockFullRun()
+	assert.Nil(t, err)
+	run.Disabled = ""
+	runID, err := models.CreateRun(ctx.Logging(), &run)
+	assert.Nil(t, err)
+
+	entryDag := models.RunDag{ID: "dag-000001", RunID: runID, Name: "myproject", DagName: "myproject", Status: schema.StatusJobRunning}
+	_, err = models.CreateRunDag(ctx.Logging(), &entryDag)
+	assert.Nil(t, err)
+	loopDag := models.RunDag{ID: "dag-000002", RunID: runID, ParentDagID: entryDag.ID, Name: "square-loop", DagName: "square-loop", Status: schema.StatusJobRunning}
+	_, err = models.CreateRunDag(ctx.Logging(), &loopDag)
+	assert.Nil(t, err)
+
+	jobs := []models.RunJob{
+		{ID: "job-000001", ParentDagID: entryDag.ID, Name: "randint", StepName: "randint", Status: schema.StatusJobSucceeded},
+		{ID: "job-000002", ParentDagID: loopDag.ID, Name: "square", StepName: "square", LoopSeq: 0, Status: schema.StatusJobSucceeded},
+		{ID: "job-000003", ParentDagID: loopDag.ID, Name: "square", StepName: "square", LoopSeq: 1, Status: schema.StatusJobRunning},
+	}
+	for i := range jobs {
+		jobs[i].RunID = runID
+		_, err = models.CreateRunJob(ctx.Logging(), &jobs[i])
+		assert.Nil(t, err)
+	}
+
+	// 用户没有权限
+	_, err = GetRunDag(&logger.RequestContext{UserName: "another"}, runID)
+	assert.NotNil(t, err)
+
+	resp, err := GetRunDag(ctx, runID)
+	assert.Nil(t, err)
+	assert.Equal(t, runID, resp.RunID)
+
+	nodes := map[string]RunDagNode{}
+	for _, node := range resp.Nodes {
+		nodes[node.ID] = node
+	}
+
+	randint, ok := nodes["randint"]
+	assert.True(t, ok)
+	assert.Equal(t, RunDagNodeTypeStep, randint.Type)
+	assert.Equal(t, string(schema.StatusJobSucceeded), randint.Status)
+	assert.Equal(t, "job-000001", randint.Instances[0].ID)
+
+	loop, ok := nodes["square-loop"]
+	assert.True(t, ok)
+	assert.Equal(t, RunDagNodeTypeDag, loop.Type)
+	assert.Equal(t, []string{"randint"}, loop.Deps)
+
+	// 循环节点有多个实例，状态按优先级聚合
+	square, ok := nodes["square-loop.square"]
+	assert.True(t, ok)
+	assert.Equal(t, "square-loop", square.ParentID)
+	assert.Equal(t, 2, len(square.Instances))
+	assert.Equal(t, string(schema.StatusJobRunning), square.Status)
+
+	// 未调度的节点状态为空
+	sum, ok := nodes["sum"]
+	assert.True(t, ok)
+	assert.Equal(t, "", sum.Status)
+	assert.Equal(t, 0, len(sum.Instances))
+
+	assert.Contains(t, resp.Edges, RunDagEdge{From: "randint", To: "square-loop", Type: RunDagEdgeTypeDependency})
+	assert.Contains(t, resp.Edges, RunDagEdge{From: "randint", To: "square-loop", Type: RunDagEdgeTypeArtifact, Artifact: "random_int"})
+	assert.Contains(t, resp.Edges, RunDagEdge{From: "square-loop", To: "square-loop.square", Type: RunDagEdgeTypeArtifact, Artifact: "in"})
+	assert.Contains(t, resp.Edges, RunDagEdge{From: "square-loop.square", To: "square-loop", Type: RunDagEdgeTypeArtifact, Artifact: "square_result"})
+	assert.Contains(t, resp.Edges, RunDagEdge{From: "square-loop", To: "sum", Type: RunDagEdgeTypeArtifact, Artifact: "nums"})
+}
@@ -0,0 +1,127 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/models"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+)
+
+const (
+	RunEventRunCreated   = "RunCreated"
+	RunEventRunStarted   = "RunStarted"
+	RunEventRunFinished  = "RunFinished"
+	RunEventStepCreated  = "StepCreated"
+	RunEventStepStarted  = "StepStarted"
+	RunEventStepRetried  = "StepRetried"
+	RunEventStepCached   = "StepCached"
+	RunEventStepFinished = "StepFinished"
+)
+
+type ListRunEventsResponse struct {
+	RunID  string     `json:"runID"`
+	Events []RunEvent `json:"events"`
+}
+
+// RunEvent run及其各节点的状态变化，StepName、JobID为空表示run级别的事件
+type RunEvent struct {
+	Time     string `json:"time"`
+	Type     string `json:"type"`
+	StepName string `json:"stepName,omitempty"`
+	JobID    string `json:"jobID,omitempty"`
+	Status   string `json:"status,omitempty"`
+	Message  string `json:"message,omitempty"`
+
+	timestamp time.Time
+}
+
+// ListRunEvents 汇总run及其所有节点的事件并按时间排序，节点通过名称标识，无需再查询节点对应的作业
+func ListRunEvents(ctx *logger.RequestContext, runID string) (*ListRunEventsResponse, error) {
+	ctx.Logging().Debugf("begin list events of run[%s]", runID)
+	run, err := GetRunByID(ctx.Logging(), ctx.UserName, runID)
+	if err != nil {
+		ctx.ErrorCode = common.InvalidArguments
+		ctx.Logging().Errorf("list events of run[%s] failed. error:%s", runID, err.Error())
+		return nil, err
+	}
+	runJobs, err := models.GetRunJobsOfRun(ctx.Logging(), runID)
+	if err != nil {
+		ctx.ErrorCode = common.InternalError
+		return nil, err
+	}
+
+	events := buildRunEvents(&run, runJobs)
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].timestamp.Before(events[j].timestamp)
+	})
+	for i := range events {
+		events[i].Time = events[i].timestamp.Format(model.TimeFormat)
+	}
+	return &ListRunEventsResponse{RunID: runID, Events: events}, nil
+}
+
+func buildRunEvents(run *models.Run, runJobs []models.RunJob) []RunEvent {
+	events := []RunEvent{{timestamp: run.CreatedAt, Type: RunEventRunCreated, Status: common.StatusRunInitiating}}
+	if run.ActivatedAt.Valid {
+		events = append(events, RunEvent{timestamp: run.ActivatedAt.Time, Type: RunEventRunStarted, Status: common.StatusRunRunning})
+	}
+	if common.IsRunFinalStatus(run.Status) {
+		events = append(events, RunEvent{timestamp: run.UpdatedAt, Type: RunEventRunFinished, Status: run.Status, Message: run.Message})
+	}
+
+	for _, runJob := range runJobs {
+		stepEvent := func(timestamp time.Time, eventType, jobID, status, message string) RunEvent {
+			return RunEvent{
+				timestamp: timestamp,
+				Type:      eventType,
+				StepName:  runJob.StepName,
+				JobID:     jobID,
+				Status:    status,
+				Message:   message,
+			}
+		}
+		events = append(events, stepEvent(runJob.CreatedAt, RunEventStepCreated, runJob.ID, string(schema.StatusJobInit), ""))
+		// 每次失败重试的作业在attempts中记录，重试后run_job中记录的是最新的作业
+		for _, attempt := range runJob.Attempts {
+			if startTime, err := time.ParseInLocation(model.TimeFormat, attempt.StartTime, time.Local); err == nil {
+				events = append(events, stepEvent(startTime, RunEventStepStarted, attempt.JobID, string(schema.StatusJobRunning), ""))
+			}
+			if endTime, err := time.ParseInLocation(model.TimeFormat, attempt.EndTime, time.Local); err == nil {
+				events = append(events, stepEvent(endTime, RunEventStepRetried, attempt.JobID, string(attempt.Status), attempt.Message))
+			}
+		}
+		if runJob.CacheJobID != "" {
+			msg := fmt.Sprintf("use cache of job[%s] in run[%s]", runJob.CacheJobID, runJob.CacheRunID)
+			events = append(events, stepEvent(runJob.UpdatedAt, RunEventStepCached, runJob.ID, string(runJob.Status), msg))
+			continue
+		}
+		if runJob.ActivatedAt.Valid {
+			events = append(events, stepEvent(runJob.ActivatedAt.Time, RunEventStepStarted, runJob.ID, string(schema.StatusJobRunning), ""))
+		}
+		if schema.IsImmutableJobStatus(runJob.Status) {
+			events = append(events, stepEvent(runJob.UpdatedAt, RunEventStepFinished, runJob.ID, string(runJob.Status), runJob.Message))
+		}
+	}
+	return events
+}
@@ -0,0 +1,112 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/models"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage/driver"
+)
+
+func TestListRunEvents(t *testing.T) {
+	driver.InitMockDB()
+	logEntry := logger.LoggerForRun(MockRunID3)
+	start := time.Now().Add(-time.Hour).Truncate(time.Second)
+
+	run := getMockRun1_3()
+	run.Status = common.StatusRunFailed
+	run.Message = "step train failed"
+	run.CreatedAt = start
+	run.ActivatedAt = sql.NullTime{Time: start.Add(time.Second), Valid: true}
+	runID, err := models.CreateRun(logEntry, &run)
+	assert.NoError(t, err)
+
+	runJobs := []models.RunJob{
+		{
+			ID:          "job-preprocess",
+			RunID:       runID,
+			StepName:    "preprocess",
+			Status:      schema.StatusJobSucceeded,
+			CacheRunID:  "run-000001",
+			CacheJobID:  "job-cached",
+			CreatedAt:   start.Add(2 * time.Second),
+			ActivatedAt: sql.NullTime{Time: start.Add(2 * time.Second), Valid: true},
+			UpdatedAt:   start.Add(3 * time.Second),
+		},
+		{
+			ID:          "job-train-1",
+			RunID:       runID,
+			StepName:    "train",
+			Status:      schema.StatusJobFailed,
+			Message:     "exit code 1",
+			CreatedAt:   start.Add(4 * time.Second),
+			ActivatedAt: sql.NullTime{Time: start.Add(8 * time.Second), Valid: true},
+			UpdatedAt:   start.Add(9 * time.Second),
+			Attempts: []schema.JobAttempt{{
+				JobID:     "job-train-0",
+				Status:    schema.StatusJobFailed,
+				StartTime: start.Add(5 * time.Second).Format(model.TimeFormat),
+				EndTime:   start.Add(6 * time.Second).Format(model.TimeFormat),
+				Message:   "oom killed",
+			}},
+		},
+	}
+	for i := range runJobs {
+		assert.NoError(t, runJobs[i].Encode())
+		_, err = models.CreateRunJob(logEntry, &runJobs[i])
+		assert.NoError(t, err)
+	}
+	// run的更新时间晚于所有节点
+	assert.NoError(t, models.UpdateRun(logEntry, runID, models.Run{Message: run.Message}))
+
+	ctx := &logger.RequestContext{UserName: MockRootUser}
+	response, err := ListRunEvents(ctx, runID)
+	assert.NoError(t, err)
+	types := make([]string, 0, len(response.Events))
+	for _, event := range response.Events {
+		types = append(types, event.StepName+":"+event.Type)
+	}
+	assert.Equal(t, []string{
+		":" + RunEventRunCreated,
+		":" + RunEventRunStarted,
+		"preprocess:" + RunEventStepCreated,
+		"preprocess:" + RunEventStepCached,
+		"train:" + RunEventStepCreated,
+		"train:" + RunEventStepStarted,
+		"train:" + RunEventStepRetried,
+		"train:" + RunEventStepStarted,
+		"train:" + RunEventStepFinished,
+		":" + RunEventRunFinished,
+	}, types)
+	retried := response.Events[6]
+	assert.Equal(t, "job-train-0", retried.JobID)
+	assert.Equal(t, "oom killed", retried.Message)
+	assert.Equal(t, "exit code 1", response.Events[8].Message)
+	assert.Equal(t, start.Format(model.TimeFormat), response.Events[0].Time)
+
+	_, err = ListRunEvents(&logger.RequestContext{UserName: MockNormalUser}, runID)
+	assert.Error(t, err)
+}
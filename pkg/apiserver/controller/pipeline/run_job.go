@@ -0,0 +1,84 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"sort"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+)
+
+type ListRunJobResponse struct {
+	RunID   string   `json:"runID"`
+	JobList []RunJob `json:"jobList"`
+}
+
+// RunJob run中节点与作业的对应关系，状态以作业表中的状态为准
+type RunJob struct {
+	StepName   string           `json:"stepName"`
+	JobID      string           `json:"jobID"`
+	JobName    string           `json:"jobName"`
+	Status     schema.JobStatus `json:"status"`
+	Message    string           `json:"message"`
+	AcceptTime string           `json:"acceptTime"`
+	StartTime  string           `json:"startTime"`
+	FinishTime string           `json:"finishTime"`
+}
+
+// ListRunJob 返回run创建的所有作业，按创建时间排序
+func ListRunJob(ctx *logger.RequestContext, runID string) (*ListRunJobResponse, error) {
+	ctx.Logging().Debugf("begin list jobs of run[%s]", runID)
+	if _, err := GetRunByID(ctx.Logging(), ctx.UserName, runID); err != nil {
+		ctx.ErrorCode = common.InvalidArguments
+		ctx.Logging().Errorf("list jobs of run[%s] failed. error:%s", runID, err.Error())
+		return nil, err
+	}
+	jobs, err := storage.Job.GetJobsByRunID(runID, "")
+	if err != nil {
+		ctx.ErrorCode = common.InternalError
+		return nil, err
+	}
+	sort.SliceStable(jobs, func(i, j int) bool {
+		return jobs[i].CreatedAt.Before(jobs[j].CreatedAt)
+	})
+	response := &ListRunJobResponse{
+		RunID:   runID,
+		JobList: make([]RunJob, 0, len(jobs)),
+	}
+	for _, job := range jobs {
+		runJob := RunJob{
+			StepName:   job.StepName,
+			JobID:      job.ID,
+			JobName:    job.Name,
+			Status:     job.Status,
+			Message:    job.Message,
+			AcceptTime: job.CreatedAt.Format(model.TimeFormat),
+		}
+		if job.ActivatedAt.Valid {
+			runJob.StartTime = job.ActivatedAt.Time.Format(model.TimeFormat)
+		}
+		if schema.IsImmutableJobStatus(job.Status) {
+			runJob.FinishTime = job.UpdatedAt.Format(model.TimeFormat)
+		}
+		response.JobList = append(response.JobList, runJob)
+	}
+	return response, nil
+}
@@ -0,0 +1,69 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/models"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage/driver"
+)
+
+func TestListRunJob(t *testing.T) {
+	driver.InitMockDB()
+	ctx := &logger.RequestContext{UserName: MockRootUser}
+
+	run, err := getMockFullRun()
+	assert.Nil(t, err)
+	runID, err := models.CreateRun(ctx.Logging(), &run)
+	assert.Nil(t, err)
+
+	now := time.Now()
+	jobs := []model.Job{
+		{ID: "job-" + runID + "-square-1", Name: runID + "-square-1", RunID: runID, StepName: "square",
+			Status: schema.StatusJobRunning, CreatedAt: now},
+		{ID: "job-" + runID + "-randint", Name: runID + "-randint", RunID: runID, StepName: "randint",
+			Status: schema.StatusJobSucceeded, CreatedAt: now.Add(-time.Minute)},
+		{ID: "job-other", Name: "other", Status: schema.StatusJobRunning},
+	}
+	for i := range jobs {
+		jobs[i].Config = &schema.Conf{}
+		assert.Nil(t, storage.Job.CreateJob(&jobs[i]))
+	}
+
+	// 用户没有权限
+	_, err = ListRunJob(&logger.RequestContext{UserName: "another"}, runID)
+	assert.NotNil(t, err)
+
+	resp, err := ListRunJob(ctx, runID)
+	assert.Nil(t, err)
+	assert.Equal(t, runID, resp.RunID)
+	assert.Len(t, resp.JobList, 2)
+	assert.Equal(t, "randint", resp.JobList[0].StepName)
+	assert.Equal(t, schema.StatusJobSucceeded, resp.JobList[0].Status)
+	assert.NotEmpty(t, resp.JobList[0].FinishTime)
+	assert.Equal(t, "square", resp.JobList[1].StepName)
+	assert.Equal(t, jobs[0].ID, resp.JobList[1].JobID)
+	assert.Empty(t, resp.JobList[1].FinishTime)
+}
@@ -0,0 +1,136 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/models"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+)
+
+const defaultRunLimitCheckInterval = time.Minute
+
+// RunLimitController 定期停止运行时间或GPU卡时超出上限的run
+func RunLimitController(stopChan chan struct{}) {
+	for {
+		stopExceededRuns(time.Now())
+		select {
+		case <-stopChan:
+			log.Info("run limit controller stopped")
+			return
+		case <-time.After(defaultRunLimitCheckInterval):
+		}
+	}
+}
+
+func stopExceededRuns(now time.Time) {
+	runs, err := models.ListRunsByStatus(logger.Logger(), []string{common.StatusRunPending, common.StatusRunRunning})
+	if err != nil {
+		log.Errorf("list active runs failed. error: %v", err)
+		return
+	}
+	for i := range runs {
+		run := &runs[i]
+		if run.Limits == nil {
+			continue
+		}
+		usage, err := GetRunUsage(run, now)
+		if err != nil {
+			logger.LoggerForRun(run.ID).Errorf("get usage of run[%s] failed. error: %v", run.ID, err)
+			continue
+		}
+		msg := checkRunLimits(run.Limits, usage)
+		if msg == "" {
+			continue
+		}
+		logger.LoggerForRun(run.ID).Infof("stop run[%s]: %s", run.ID, msg)
+		if err := stopRunWithMessage(run.ID, msg); err != nil {
+			logger.LoggerForRun(run.ID).Errorf("stop run[%s] exceeding limits failed. error: %v", run.ID, err)
+		}
+	}
+}
+
+// checkRunLimits 返回run超出上限的原因，未超出时返回空字符串
+func checkRunLimits(limits *schema.RunLimits, usage schema.RunUsage) string {
+	if timeout := limits.GetTimeout(); timeout > 0 && time.Duration(usage.ElapsedSeconds)*time.Second > timeout {
+		return fmt.Sprintf("run exceeded timeout[%s], it has run for %s", limits.Timeout,
+			time.Duration(usage.ElapsedSeconds)*time.Second)
+	}
+	if limits.MaxGPUHours > 0 && usage.GPUHours > limits.MaxGPUHours {
+		return fmt.Sprintf("run exceeded gpu hours budget[%v], it has consumed %v gpu hours",
+			limits.MaxGPUHours, usage.GPUHours)
+	}
+	return ""
+}
+
+// GetRunUsage 统计run的运行时长及其所有作业消耗的GPU卡时，GPU卡时为作业申请的GPU数乘以作业运行时长
+func GetRunUsage(run *models.Run, now time.Time) (schema.RunUsage, error) {
+	usage := schema.RunUsage{}
+	start := run.CreatedAt
+	if run.ActivatedAt.Valid {
+		start = run.ActivatedAt.Time
+	}
+	end := now
+	if common.IsRunFinalStatus(run.Status) {
+		end = run.UpdatedAt
+	}
+	if end.After(start) {
+		usage.ElapsedSeconds = int64(end.Sub(start).Seconds())
+	}
+
+	jobs, err := storage.Job.GetJobsByRunID(run.ID, "")
+	if err != nil {
+		return usage, err
+	}
+	for i := range jobs {
+		job := &jobs[i]
+		if !job.ActivatedAt.Valid {
+			continue
+		}
+		gpus := model.JobGPUs(job)
+		if gpus == 0 {
+			continue
+		}
+		jobEnd := now
+		if schema.IsImmutableJobStatus(job.Status) {
+			jobEnd = job.UpdatedAt
+		}
+		if jobEnd.After(job.ActivatedAt.Time) {
+			usage.GPUHours += float64(gpus) * jobEnd.Sub(job.ActivatedAt.Time).Hours()
+		}
+	}
+	usage.GPUHours = math.Round(usage.GPUHours*100) / 100
+	return usage, nil
+}
+
+// stopRunWithMessage 先记录停止原因再停止run，run的message只保留第一条，因此停止后的回调不会覆盖该原因
+func stopRunWithMessage(runID, msg string) error {
+	logEntry := logger.LoggerForRun(runID)
+	if err := models.UpdateRun(logEntry, runID, models.Run{Message: msg}); err != nil {
+		return err
+	}
+	return StopRun(logEntry, common.UserRoot, runID, UpdateRunRequest{})
+}
@@ -0,0 +1,98 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/models"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage/driver"
+)
+
+func TestRunLimits(t *testing.T) {
+	driver.InitMockDB()
+	logEntry := logger.LoggerForRun(MockRunID3)
+	now := time.Now()
+
+	run := getMockRun1_3()
+	run.RunOptions.Limits = &schema.RunLimits{Timeout: "1h", MaxGPUHours: 3}
+	run.Encode()
+	runID, err := models.CreateRun(logEntry, &run)
+	assert.NoError(t, err)
+	err = models.UpdateRun(logEntry, runID, models.Run{
+		ActivatedAt: sql.NullTime{Time: now.Add(-2 * time.Hour), Valid: true},
+	})
+	assert.NoError(t, err)
+
+	job := &model.Job{
+		ID:          "job-run-limit",
+		RunID:       runID,
+		Status:      schema.StatusJobRunning,
+		ActivatedAt: sql.NullTime{Time: now.Add(-2 * time.Hour), Valid: true},
+		Config:      &schema.Conf{},
+		Members: []schema.Member{
+			{
+				Replicas: 1,
+				Conf: schema.Conf{
+					Flavour: schema.Flavour{
+						ResourceInfo: schema.ResourceInfo{
+							CPU: "1",
+							Mem: "1Gi",
+							ScalarResources: schema.ScalarResourcesType{
+								"nvidia.com/gpu": "2",
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	assert.NoError(t, storage.Job.CreateJob(job))
+
+	run, err = models.GetRunByID(logEntry, runID)
+	assert.NoError(t, err)
+	assert.Equal(t, "1h", run.Limits.Timeout)
+	usage, err := GetRunUsage(&run, now)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7200), usage.ElapsedSeconds)
+	assert.Equal(t, float64(4), usage.GPUHours)
+
+	msg := checkRunLimits(run.Limits, usage)
+	assert.True(t, strings.HasPrefix(msg, "run exceeded timeout[1h]"))
+	msg = checkRunLimits(&schema.RunLimits{MaxGPUHours: 3}, usage)
+	assert.True(t, strings.HasPrefix(msg, "run exceeded gpu hours budget[3]"))
+	msg = checkRunLimits(&schema.RunLimits{Timeout: "3h", MaxGPUHours: 5}, usage)
+	assert.Equal(t, "", msg)
+
+	// workflow不在当前实例中时停止失败，但停止原因已经记录
+	stopExceededRuns(now)
+	run, err = models.GetRunByID(logEntry, runID)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(run.Message, "run exceeded timeout[1h]"))
+
+	assert.NotNil(t, (&schema.RunLimits{Timeout: "abc"}).Validate())
+	assert.NotNil(t, (&schema.RunLimits{MaxGPUHours: -1}).Validate())
+}
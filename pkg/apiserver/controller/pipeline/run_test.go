@@ -19,8 +19,10 @@ package pipeline
 import (
 	"encoding/json"
 	"fmt"
+	"reflect"
 	"testing"
 
+	"github.com/agiledragon/gomonkey/v2"
 	"github.com/stretchr/testify/assert"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -295,3 +297,56 @@ func TestCreateRunByJson(t *testing.T) {
 	assert.Nil(t, err)
 	fmt.Println(wfPtr.Source.EntryPoints.EntryPoints["main"].(*schema.WorkflowSourceStep).Cache)
 }
+
+func TestRestartWf(t *testing.T) {
+	driver.InitMockDB()
+	logEntry := logger.LoggerForRun("")
+
+	patch := gomonkey.ApplyMethod(reflect.TypeOf(&pipeline.Workflow{}), "Restart",
+		func(_ *pipeline.Workflow, _ *schema.DagView, _ schema.PostProcessView) {})
+	defer patch.Reset()
+
+	run, err := getMockFullRun()
+	assert.Nil(t, err)
+	run.Status = common.StatusRunFailed
+	originRunID, err := models.CreateRun(logEntry, &run)
+	assert.Nil(t, err)
+
+	dag := models.RunDag{ID: "dag-000001", RunID: originRunID, Name: "myproject", DagName: "myproject", Status: schema.StatusJobFailed}
+	_, err = models.CreateRunDag(logEntry, &dag)
+	assert.Nil(t, err)
+	jobSucceeded := models.RunJob{ID: "job-000001", RunID: originRunID, ParentDagID: dag.ID, Name: "randint", StepName: "randint", Status: schema.StatusJobSucceeded}
+	_, err = models.CreateRunJob(logEntry, &jobSucceeded)
+	assert.Nil(t, err)
+	jobFailed := models.RunJob{ID: "job-000002", RunID: originRunID, ParentDagID: dag.ID, Name: "sum", StepName: "sum", Status: schema.StatusJobFailed}
+	_, err = models.CreateRunJob(logEntry, &jobFailed)
+	assert.Nil(t, err)
+
+	// 复用成功节点，只重新执行失败节点
+	originRun, err := models.GetRunByID(logEntry, originRunID)
+	assert.Nil(t, err)
+	newRunID, err := RestartWf(originRun, false, RetryModeFailed)
+	assert.Nil(t, err)
+	assert.NotEqual(t, originRunID, newRunID)
+
+	newJobs, err := models.GetRunJobsOfRun(logEntry, newRunID)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(newJobs))
+	assert.Equal(t, "randint", newJobs[0].Name)
+	assert.Equal(t, originRunID, newJobs[0].CacheRunID)
+
+	// 原run被新run cache，删除时需要检查
+	originRun, err = models.GetRunByID(logEntry, originRunID)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{newRunID}, originRun.GetRunCacheIDList())
+
+	// 被cache的run也可以重跑，从头执行时不拷贝任何节点
+	newRunID2, err := RestartWf(originRun, false, RetryModeAll)
+	assert.Nil(t, err)
+	newJobs, err = models.GetRunJobsOfRun(logEntry, newRunID2)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(newJobs))
+	newRun2, err := models.GetRunByID(logEntry, newRunID2)
+	assert.Nil(t, err)
+	assert.Equal(t, "", newRun2.RunCachedIDs)
+}
@@ -35,18 +35,19 @@ import (
 )
 
 type CreateScheduleRequest struct {
-	Name              string `json:"name"`
-	Desc              string `json:"desc"` // optional
-	PipelineID        string `json:"pipelineID"`
-	PipelineVersionID string `json:"pipelineVersionID"`
-	Crontab           string `json:"crontab"`
-	StartTime         string `json:"startTime"`         // optional
-	EndTime           string `json:"endTime"`           // optional
-	Concurrency       int    `json:"concurrency"`       // optional, 默认 0, 表示不限制
-	ConcurrencyPolicy string `json:"concurrencyPolicy"` // optional, 默认 suspend
-	ExpireInterval    int    `json:"expireInterval"`    // optional, 默认 0, 表示不限制
-	Catchup           bool   `json:"catchup"`           // optional, 默认 false
-	UserName          string `json:"username"`          // optional, 只有root用户使用其他用户fsname时，需要指定对应username
+	Name              string            `json:"name"`
+	Desc              string            `json:"desc"` // optional
+	PipelineID        string            `json:"pipelineID"`
+	PipelineVersionID string            `json:"pipelineVersionID"`
+	Crontab           string            `json:"crontab"`
+	StartTime         string            `json:"startTime"`         // optional
+	EndTime           string            `json:"endTime"`           // optional
+	Concurrency       int               `json:"concurrency"`       // optional, 默认 0, 表示不限制
+	ConcurrencyPolicy string            `json:"concurrencyPolicy"` // optional, 默认 suspend
+	ExpireInterval    int               `json:"expireInterval"`    // optional, 默认 0, 表示不限制
+	Catchup           bool              `json:"catchup"`           // optional, 默认 false
+	UserName          string            `json:"username"`          // optional, 只有root用户使用其他用户fsname时，需要指定对应username
+	FsTrigger         *models.FsTrigger `json:"fsTrigger"`         // optional, 配置后只有在监听路径下出现新文件时才会发起run
 }
 
 type CreateScheduleResponse struct {
@@ -92,7 +93,12 @@ func (b *ScheduleBrief) updateFromScheduleModel(schedule models.Schedule) (err e
 	b.Crontab = schedule.Crontab
 	b.CreateTime = schedule.CreatedAt.Format("2006-01-02 15:04:05")
 	b.UpdateTime = schedule.UpdatedAt.Format("2006-01-02 15:04:05")
-	b.NextRunTime = schedule.NextRunAt.Format("2006-01-02 15:04:05")
+	// 终态的schedule不会再发起run，不返回下次运行时间
+	if models.IsScheduleFinalStatus(schedule.Status) {
+		b.NextRunTime = ""
+	} else {
+		b.NextRunTime = schedule.NextRunAt.Format("2006-01-02 15:04:05")
+	}
 	b.Message = schedule.Message
 	b.Status = schedule.Status
 
@@ -186,6 +192,30 @@ func CheckFsAndGetID(userName, fsUserName, fsName string) (fsID string, err erro
 	return fsID, nil
 }
 
+// 校验fsTrigger配置，fsName为空时默认使用pipeline的main_fs
+func validateFsTrigger(userName string, request *CreateScheduleRequest, wfs *schema.WorkflowSource) error {
+	fsTrigger := request.FsTrigger
+	if fsTrigger.FsName == "" {
+		fsTrigger.FsName = wfs.FsOptions.MainFS.Name
+	}
+
+	if err := fsTrigger.Validate(); err != nil {
+		return err
+	}
+
+	// replace策略会停止正在运行的run，导致已触发的文件没有被处理完
+	if request.ConcurrencyPolicy == models.ConcurrencyPolicyReplace {
+		return fmt.Errorf("concurrency policy[%s] not supported with fsTrigger", request.ConcurrencyPolicy)
+	}
+
+	// paramName支持 param 和 stepName.param 两种形式，是否存在于pipeline中，在发起run时校验
+	if _, err := CheckFsAndGetID(userName, request.UserName, fsTrigger.FsName); err != nil {
+		return err
+	}
+
+	return nil
+}
+
 func CreateSchedule(ctx *logger.RequestContext, request *CreateScheduleRequest) (CreateScheduleResponse, error) {
 	// check schedule name pattern
 	if !schema.CheckReg(request.Name, common.RegPatternScheduleName) {
@@ -255,6 +285,17 @@ func CreateSchedule(ctx *logger.RequestContext, request *CreateScheduleRequest)
 		}
 	}
 
+	// 校验 fsTrigger
+	if request.FsTrigger != nil {
+		if err := validateFsTrigger(ctx.UserName, request, &wfs); err != nil {
+			ctx.ErrorCode = common.InvalidArguments
+			errMsg := fmt.Sprintf("create schedule failed, check fsTrigger error:[%s]", err.Error())
+			ctx.Logging().Errorf(errMsg)
+			return CreateScheduleResponse{}, fmt.Errorf(errMsg)
+		}
+		options.FsTrigger = request.FsTrigger
+	}
+
 	StrOptions, err := options.Encode(ctx.Logging())
 	if err != nil {
 		ctx.ErrorCode = common.InvalidArguments
@@ -335,6 +376,11 @@ func CreateSchedule(ctx *logger.RequestContext, request *CreateScheduleRequest)
 		NextRunAt:         nextRunAt,
 	}
 
+	// 只有schedule创建之后出现的文件才会触发run
+	if options.FsTrigger != nil {
+		schedule.TriggerWatermark = sql.NullTime{Time: currentTime, Valid: true}
+	}
+
 	scheduleID, err := models.CreateSchedule(ctx.Logging(), schedule)
 	if err != nil {
 		ctx.ErrorCode = common.InternalError
@@ -529,6 +575,86 @@ func StopSchedule(ctx *logger.RequestContext, scheduleID string) error {
 	return nil
 }
 
+// 暂停schedule，暂停期间不会发起新的run，已发起的run不受影响
+func PauseSchedule(ctx *logger.RequestContext, scheduleID string) error {
+	ctx.Logging().Debugf("begin pause schedule: %s", scheduleID)
+	// check schedule exist && user access right
+	schedule, err := getSchedule(ctx, scheduleID)
+	if err != nil {
+		ctx.ErrorCode = common.InvalidArguments
+		err := fmt.Errorf("pause schedule[%s] failed. %s", scheduleID, err.Error())
+		ctx.Logging().Errorf(err.Error())
+		return err
+	}
+
+	// 只有running状态的schedule可以暂停
+	if schedule.Status != models.ScheduleStatusRunning {
+		ctx.ErrorCode = common.ActionNotAllowed
+		err := fmt.Errorf("pause schedule[%s] failed, only schedule in status[%s] can be paused, current status[%s]",
+			scheduleID, models.ScheduleStatusRunning, schedule.Status)
+		ctx.Logging().Errorln(err.Error())
+		return err
+	}
+
+	if err := models.UpdateScheduleStatus(ctx.Logging(), scheduleID, models.ScheduleStatusPaused); err != nil {
+		errMsg := fmt.Sprintf("pause schedule failed updating db")
+		ctx.ErrorCode = common.InternalError
+		return fmt.Errorf(errMsg)
+	}
+
+	// 给scheduler发pause channel信号
+	err = SendSingnal(OpTypePause, scheduleID)
+	if err != nil {
+		ctx.ErrorCode = common.InternalError
+		errMsg := fmt.Sprintf("pause schedule failed in sending pause channel signal. error:%v", err)
+		ctx.Logging().Errorf(errMsg)
+		return fmt.Errorf(errMsg)
+	}
+	ctx.Logging().Debugf("send pause schedule channel succeed. scheduleID:%s", scheduleID)
+
+	return nil
+}
+
+// 恢复已暂停的schedule
+// 暂停期间错过的周期任务，按照catchup和expireInterval配置处理
+func ResumeSchedule(ctx *logger.RequestContext, scheduleID string) error {
+	ctx.Logging().Debugf("begin resume schedule: %s", scheduleID)
+	// check schedule exist && user access right
+	schedule, err := getSchedule(ctx, scheduleID)
+	if err != nil {
+		ctx.ErrorCode = common.InvalidArguments
+		err := fmt.Errorf("resume schedule[%s] failed. %s", scheduleID, err.Error())
+		ctx.Logging().Errorf(err.Error())
+		return err
+	}
+
+	if schedule.Status != models.ScheduleStatusPaused {
+		ctx.ErrorCode = common.ActionNotAllowed
+		err := fmt.Errorf("resume schedule[%s] failed, only schedule in status[%s] can be resumed, current status[%s]",
+			scheduleID, models.ScheduleStatusPaused, schedule.Status)
+		ctx.Logging().Errorln(err.Error())
+		return err
+	}
+
+	if err := models.UpdateScheduleStatus(ctx.Logging(), scheduleID, models.ScheduleStatusRunning); err != nil {
+		errMsg := fmt.Sprintf("resume schedule failed updating db")
+		ctx.ErrorCode = common.InternalError
+		return fmt.Errorf(errMsg)
+	}
+
+	// 给scheduler发resume channel信号，重新计算休眠时间
+	err = SendSingnal(OpTypeResume, scheduleID)
+	if err != nil {
+		ctx.ErrorCode = common.InternalError
+		errMsg := fmt.Sprintf("resume schedule failed in sending resume channel signal. error:%v", err)
+		ctx.Logging().Errorf(errMsg)
+		return fmt.Errorf(errMsg)
+	}
+	ctx.Logging().Debugf("send resume schedule channel succeed. scheduleID:%s", scheduleID)
+
+	return nil
+}
+
 // todo: 支持 StopRun
 func DeleteSchedule(ctx *logger.RequestContext, scheduleID string) error {
 	ctx.Logging().Debugf("begin delete schedule: %s", scheduleID)
@@ -718,6 +718,92 @@ func TestStopSchedule(t *testing.T) {
 	assert.Equal(t, "stop schedule[schedule-000003] failed. user[user1] has no access to resource[schedule] with Name[schedule-000003]", err.Error())
 }
 
+func TestPauseAndResumeSchedule(t *testing.T) {
+	driver.InitMockDB()
+	ctx := &logger.RequestContext{UserName: MockNormalUser}
+
+	patch := gomonkey.ApplyFunc(handler.ReadFileFromFs, func(fsID, runYamlPath string, logEntry *log.Entry) ([]byte, error) {
+		return os.ReadFile(runYamlPath)
+	})
+	patch1 := gomonkey.ApplyFunc(SendSingnal, func(string, string) error {
+		return nil
+	})
+	patch2 := gomonkey.ApplyFunc(CheckFsAndGetID, func(string, string, string) (string, error) {
+		return "", nil
+	})
+
+	defer patch.Reset()
+	defer patch1.Reset()
+	defer patch2.Reset()
+
+	// 创建 pipeline & pipelineVersion
+	pplID1, _, pplVersionID1, _ := insertPipeline(t, ctx.Logging())
+
+	createScheduleReq := CreateScheduleRequest{
+		Name:              "schedule_1",
+		Desc:              "schedule test",
+		PipelineID:        pplID1,
+		PipelineVersionID: pplVersionID1,
+		Crontab:           "* * * * */1",
+		Concurrency:       10,
+		ConcurrencyPolicy: "suspend",
+		ExpireInterval:    100,
+		Catchup:           true,
+	}
+	createResp, err := CreateSchedule(ctx, &createScheduleReq)
+	assert.Nil(t, err)
+	scheduleID := createResp.ScheduleID
+
+	// 失败: running状态的schedule不能resume
+	err = ResumeSchedule(ctx, scheduleID)
+	assert.NotNil(t, err)
+	assert.Equal(t, "resume schedule[schedule-000001] failed, only schedule in status[paused] can be resumed, current status[running]", err.Error())
+
+	// 失败: 普通用户没有权限pause其他普通用户的schedule
+	wrongCtx := &logger.RequestContext{UserName: "wrongUser"}
+	err = PauseSchedule(wrongCtx, scheduleID)
+	assert.NotNil(t, err)
+	assert.Equal(t, "pause schedule[schedule-000001] failed. user[wrongUser] has no access to resource[schedule] with Name[schedule-000001]", err.Error())
+
+	// 成功: pause
+	err = PauseSchedule(ctx, scheduleID)
+	assert.Nil(t, err)
+	getScheduleResp, err := GetSchedule(ctx, scheduleID, "", 10, nil, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, models.ScheduleStatusPaused, getScheduleResp.Status)
+	assert.NotEqual(t, "", getScheduleResp.NextRunTime)
+
+	// 失败: 重复pause
+	err = PauseSchedule(ctx, scheduleID)
+	assert.NotNil(t, err)
+	assert.Equal(t, "pause schedule[schedule-000001] failed, only schedule in status[running] can be paused, current status[paused]", err.Error())
+
+	// 失败: paused不是终态，不能删除
+	err = DeleteSchedule(ctx, scheduleID)
+	assert.NotNil(t, err)
+
+	// 成功: resume
+	err = ResumeSchedule(ctx, scheduleID)
+	assert.Nil(t, err)
+	schedule, err := models.GetSchedule(ctx.Logging(), scheduleID)
+	assert.Nil(t, err)
+	assert.Equal(t, models.ScheduleStatusRunning, schedule.Status)
+
+	// paused状态的schedule可以直接stop，stop后不再返回下次运行时间
+	err = PauseSchedule(ctx, scheduleID)
+	assert.Nil(t, err)
+	err = StopSchedule(ctx, scheduleID)
+	assert.Nil(t, err)
+	getScheduleResp, err = GetSchedule(ctx, scheduleID, "", 10, nil, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, models.ScheduleStatusTerminated, getScheduleResp.Status)
+	assert.Equal(t, "", getScheduleResp.NextRunTime)
+
+	// 失败: 终态schedule不能pause
+	err = PauseSchedule(ctx, scheduleID)
+	assert.NotNil(t, err)
+}
+
 func TestDeleteSchedule(t *testing.T) {
 	driver.InitMockDB()
 	ctx := &logger.RequestContext{UserName: MockNormalUser}
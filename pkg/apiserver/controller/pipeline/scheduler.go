@@ -19,6 +19,7 @@ package pipeline
 import (
 	"database/sql"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
@@ -26,6 +27,7 @@ import (
 	cron "github.com/robfig/cron/v3"
 
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/handler"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/models"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
@@ -35,6 +37,8 @@ const (
 	OpTypeCreate = "create"
 	OpTypeStop   = "stop"
 	OpTypeDelete = "delete"
+	OpTypePause  = "pause"
+	OpTypeResume = "resume"
 )
 
 type OpInfo struct {
@@ -43,7 +47,8 @@ type OpInfo struct {
 }
 
 func NewOpInfo(opType string, scheduleID string) (OpInfo, error) {
-	if opType != OpTypeCreate && opType != OpTypeStop && opType != OpTypeDelete {
+	if opType != OpTypeCreate && opType != OpTypeStop && opType != OpTypeDelete &&
+		opType != OpTypePause && opType != OpTypeResume {
 		errMsg := fmt.Sprintf("optype[%s] not supported", opType)
 		return OpInfo{}, fmt.Errorf(errMsg)
 	}
@@ -173,7 +178,7 @@ func (s *Scheduler) getTimeout(nextWakeupTime *time.Time) <-chan time.Time {
 // - 计算timeout，如果有 schedule 的 next_run_at 在 expire_interval以外，会直接把 timeout 设置为0
 //   - 有过期任务，此处不会更新next_run_at，而是马上触发dealWithTimeout函数处理
 //
-// 对于 stop/delete 操作，可以不再计算timeout
+// 对于 stop/delete/pause 操作，可以不再计算timeout
 // - 如果停止的schedule，【不是】下一次wakeup要执行的，那对timeout毫无影响
 // - 如果停止的schedule恰好是下一次wakeup要执行的，那只是导致一次无效的wakeup而已
 //   - 一次无效的timeout，代价是一次扫表；但是为了避免无效的timeout，这里也要扫表，代价是一致的。
@@ -181,7 +186,7 @@ func (s *Scheduler) dealWithOps(opInfo OpInfo) (toUpdate bool, timeout *time.Tim
 	logger.Logger().Debugf("begin to deal with shedule op[%s] of schedule[%s]", opInfo.GetOpType(), opInfo.GetScheduleID())
 
 	opType := opInfo.GetOpType()
-	if opType == OpTypeStop || opType == OpTypeDelete {
+	if opType == OpTypeStop || opType == OpTypeDelete || opType == OpTypePause {
 		return false, nil, nil
 	}
 
@@ -289,8 +294,13 @@ func (s *Scheduler) generateRunListForSchedule(schedule models.Schedule, current
 }
 
 func (s *Scheduler) createRun(schedule models.Schedule, fsConfig models.FsConfig, nextRunAt time.Time, status, msg string) {
-	logger.Logger().Infof("start to create run in ScheduledAt[%s] for schedule[%s] with status[%s]",
-		s.formatTime(&nextRunAt), schedule.ID, status)
+	s.createRunWithParams(schedule, fsConfig, nextRunAt, status, msg, nil)
+}
+
+func (s *Scheduler) createRunWithParams(schedule models.Schedule, fsConfig models.FsConfig, nextRunAt time.Time, status, msg string,
+	parameters map[string]interface{}) error {
+	logger.Logger().Infof("start to create run in ScheduledAt[%s] for schedule[%s] with status[%s], parameters[%v]",
+		s.formatTime(&nextRunAt), schedule.ID, status, parameters)
 	createRequest := CreateRunRequest{
 		UserName:          fsConfig.Username,
 		Name:              schedule.Name,
@@ -299,6 +309,7 @@ func (s *Scheduler) createRun(schedule models.Schedule, fsConfig models.FsConfig
 		PipelineVersionID: schedule.PipelineVersionID,
 		ScheduleID:        schedule.ID,
 		ScheduledAt:       s.formatTime(&nextRunAt),
+		Parameters:        parameters,
 	}
 
 	// generate request id for run create
@@ -316,6 +327,7 @@ func (s *Scheduler) createRun(schedule models.Schedule, fsConfig models.FsConfig
 	if err != nil {
 		logger.Logger().Errorf("create run for schedule[%s] in ScheduledAt[%s] failed, err:[%s]", schedule.ID, s.formatTime(&nextRunAt), err.Error())
 	}
+	return err
 }
 
 func (s *Scheduler) stopRun(runID string, schedule models.Schedule) {
@@ -327,8 +339,80 @@ func (s *Scheduler) stopRun(runID string, schedule models.Schedule) {
 	}
 }
 
+// 为fsTrigger轮询新出现的文件，每个文件发起一个run，并更新schedule的水位(TriggerWatermark, TriggerFile)
+// - 文件按(修改时间, 路径)排序，只处理水位之后的文件，修改时间相同的文件不会因为中途停止而被跳过
+// - 有并发度限制时，最多发起 concurrency - activeCount 个run，剩余文件留到下一次轮询处理
+// - 发起run失败时不推进水位，该文件及之后的文件留到下一次轮询重试
+// - 轮询失败只打日志，不影响周期调度
+func (s *Scheduler) processFsTrigger(schedule *models.Schedule, options models.ScheduleOptions, fsConfig models.FsConfig,
+	scheduledAt time.Time, activeCount int) {
+	fsTrigger := options.FsTrigger
+	fsUserName := schedule.UserName
+	if fsConfig.Username != "" {
+		fsUserName = fsConfig.Username
+	}
+	fsID := common.ID(fsUserName, fsTrigger.FsName)
+
+	fsHandler, err := handler.NewFsHandlerWithServer(fsID, logger.Logger())
+	if err != nil {
+		logger.Logger().Errorf("new fsHandler for fsTrigger of schedule[%s] failed, err:[%s]", schedule.ID, err.Error())
+		return
+	}
+
+	files, err := fsHandler.ListFilesModifiedSince(fsTrigger.Path, schedule.TriggerWatermark.Time)
+	if err != nil {
+		logger.Logger().Errorf("list files in path[%s] of fs[%s] for schedule[%s] failed, err:[%s]",
+			fsTrigger.Path, fsID, schedule.ID, err.Error())
+		return
+	}
+	// 水位在数据库中只保存到毫秒，文件修改时间按相同精度比较及排序
+	for i := range files {
+		files[i].ModTime = files[i].ModTime.Truncate(time.Millisecond)
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		if files[i].ModTime.Equal(files[j].ModTime) {
+			return files[i].Path < files[j].Path
+		}
+		return files[i].ModTime.Before(files[j].ModTime)
+	})
+
+	count := 0
+	for _, file := range files {
+		if !afterTriggerWatermark(schedule, file) {
+			continue
+		}
+		if options.Concurrency != 0 && activeCount+count >= options.Concurrency {
+			logger.Logger().Infof("concurrency of schedule[%s] already reach[%d], left files will be triggered in next poll",
+				schedule.ID, options.Concurrency)
+			break
+		}
+
+		// 不匹配的文件也需要推进水位，避免重复扫描
+		if fsTrigger.Match(file.Path) {
+			params := map[string]interface{}{fsTrigger.ParamName: file.Path}
+			if err := s.createRunWithParams(*schedule, fsConfig, scheduledAt, "", "", params); err != nil {
+				break
+			}
+			count += 1
+		}
+		schedule.TriggerWatermark = sql.NullTime{Time: file.ModTime, Valid: true}
+		schedule.TriggerFile = file.Path
+	}
+	logger.Logger().Infof("fsTrigger of schedule[%s] triggered [%d] runs, watermark[%s], file[%s]",
+		schedule.ID, count, s.formatTime(&schedule.TriggerWatermark.Time), schedule.TriggerFile)
+}
+
+// afterTriggerWatermark 判断文件是否在schedule的水位(TriggerWatermark, TriggerFile)之后
+func afterTriggerWatermark(schedule *models.Schedule, file handler.FileModTime) bool {
+	watermark := schedule.TriggerWatermark.Time.Truncate(time.Millisecond)
+	if file.ModTime.Equal(watermark) {
+		return file.Path > schedule.TriggerFile
+	}
+	return file.ModTime.After(watermark)
+}
+
 func (s *Scheduler) processRunList(
-	schedule models.Schedule, options models.ScheduleOptions, fsConfig models.FsConfig, currentTime time.Time,
+	schedule *models.Schedule, options models.ScheduleOptions, fsConfig models.FsConfig, currentTime time.Time,
 	expiredList, skipList, execList []time.Time, stopCount int, activeRuns []models.Run) {
 	// 根据调度时间，先处理expiredList，创建状态为skipped的run，发起任务失败了只打日志，不影响周期调度
 	for _, expiredRunAt := range expiredList {
@@ -336,7 +420,7 @@ func (s *Scheduler) processRunList(
 		runMsg := fmt.Sprintf("skip run of schedule[%s] with schedule time[%s], beyond expire interval[%d] before currentTime[%s]",
 			schedule.ID, s.formatTime(&expiredRunAt), options.ExpireInterval, s.formatTime(&currentTime))
 		logger.Logger().Info(runMsg)
-		s.createRun(schedule, fsConfig, expiredRunAt, status, runMsg)
+		s.createRun(*schedule, fsConfig, expiredRunAt, status, runMsg)
 	}
 
 	if options.ConcurrencyPolicy == models.ConcurrencyPolicyReplace {
@@ -347,13 +431,20 @@ func (s *Scheduler) processRunList(
 			runMsg := fmt.Sprintf("skip run of schedule[%s] with schedule time[%s], concurrency already reach[%d] in policy[%s]",
 				schedule.ID, s.formatTime(&skipRunAt), options.Concurrency, options.ConcurrencyPolicy)
 			logger.Logger().Info(runMsg)
-			s.createRun(schedule, fsConfig, skipRunAt, status, runMsg)
+			s.createRun(*schedule, fsConfig, skipRunAt, status, runMsg)
 		}
 	}
 
 	// 再根据 execList，发起run，发起任务失败了只打日志，不影响周期调度
-	for _, execRunAt := range execList {
-		s.createRun(schedule, fsConfig, execRunAt, "", "")
+	// 配置了fsTrigger时，execList只表示轮询时间点，多个轮询时间点只需要轮询一次
+	if options.FsTrigger != nil {
+		if len(execList) > 0 {
+			s.processFsTrigger(schedule, options, fsConfig, execList[len(execList)-1], len(activeRuns))
+		}
+	} else {
+		for _, execRunAt := range execList {
+			s.createRun(*schedule, fsConfig, execRunAt, "", "")
+		}
 	}
 
 	if options.ConcurrencyPolicy == models.ConcurrencyPolicySkip {
@@ -364,13 +455,13 @@ func (s *Scheduler) processRunList(
 			runMsg := fmt.Sprintf("skip run of schedule[%s] with schedule time[%s], concurrency already reach[%d] in policy[%s]",
 				schedule.ID, s.formatTime(&skipRunAt), options.Concurrency, options.ConcurrencyPolicy)
 			logger.Logger().Info(runMsg)
-			s.createRun(schedule, fsConfig, skipRunAt, status, runMsg)
+			s.createRun(*schedule, fsConfig, skipRunAt, status, runMsg)
 		}
 	}
 
 	// 最后根据 stopCount，stop周期调度前的active run，停止任务失败了只打日志，不影响周期调度
 	for i := 0; i < stopCount; i++ {
-		s.stopRun(activeRuns[i].ID, schedule)
+		s.stopRun(activeRuns[i].ID, *schedule)
 	}
 }
 
@@ -465,7 +556,7 @@ func (s *Scheduler) dealWithTimeout() (nextWakeupTime *time.Time, err error) {
 		// 为expiredList, skipList, execList发起对应任务
 		// 根据stopCount停止activeRuns
 		logger.Logger().Infof("before processRunList, expiredList[%v], execList[%v], skipList[%v], activeCount:[%d], stopCount[%d]", expiredList, execList, skipList, activeCount, stopCount)
-		s.processRunList(schedule, options, fsConfig, currentTime, expiredList, skipList, execList, stopCount, activeRuns)
+		s.processRunList(&schedule, options, fsConfig, currentTime, expiredList, skipList, execList, stopCount, activeRuns)
 
 		// 更新数据库记录（如果nextRunAt，或者status字段有更新的话），以及更新 nextWakeupTime
 		nextWakeupTime = s.updateScheduleAndWakeupTime(schedule, currentTime, nextRunAt, nextWakeupTime)
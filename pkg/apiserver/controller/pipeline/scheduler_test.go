@@ -18,6 +18,7 @@ package pipeline
 
 import (
 	"database/sql"
+	"errors"
 	"os"
 	"testing"
 	"time"
@@ -28,6 +29,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/handler"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/models"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
@@ -486,3 +488,89 @@ func TestConcurrency(t *testing.T) {
 
 	// 带测试：concurrencyPolicy是replace，而且有运行中的任务
 }
+
+func TestProcessFsTrigger(t *testing.T) {
+	driver.InitMockDB()
+
+	os.MkdirAll("./mock_fs_handler/data", 0755)
+	defer os.RemoveAll("./mock_fs_handler")
+
+	watermark := time.Now().Add(-time.Minute).Truncate(time.Millisecond)
+	modTimes := map[string]time.Time{
+		"a.csv": watermark.Add(time.Second),
+		"b.txt": watermark.Add(2 * time.Second),
+		"c.csv": watermark.Add(3 * time.Second),
+		"d.csv": watermark.Add(3 * time.Second),
+	}
+	for name, modTime := range modTimes {
+		path := "./mock_fs_handler/data/" + name
+		err := os.WriteFile(path, []byte("data"), 0644)
+		assert.Nil(t, err)
+		err = os.Chtimes(path, modTime, modTime)
+		assert.Nil(t, err)
+	}
+
+	origin := handler.NewFsHandlerWithServer
+	handler.NewFsHandlerWithServer = handler.MockerNewFsHandlerWithServer
+	defer func() {
+		handler.NewFsHandlerWithServer = origin
+	}()
+
+	createdParams := []map[string]interface{}{}
+	var createErr error
+	patch := gomonkey.ApplyFunc(CreateRun, func(ctx logger.RequestContext, request *CreateRunRequest, extra map[string]string) (CreateRunResponse, error) {
+		if createErr != nil {
+			return CreateRunResponse{}, createErr
+		}
+		createdParams = append(createdParams, request.Parameters)
+		return CreateRunResponse{}, nil
+	})
+	defer patch.Reset()
+
+	schedule := models.Schedule{
+		ID:               "schedule-000001",
+		UserName:         MockRootUser,
+		TriggerWatermark: sql.NullTime{Time: watermark, Valid: true},
+	}
+	options := models.ScheduleOptions{
+		Concurrency: 1,
+		FsTrigger: &models.FsTrigger{
+			FsName:    MockFsName,
+			Path:      "data",
+			Pattern:   "*.csv",
+			ParamName: "data_path",
+		},
+	}
+
+	// 并发度为1，只发起一个run，水位推进到第一个文件
+	s := GetGlobalScheduler()
+	s.processFsTrigger(&schedule, options, models.FsConfig{}, time.Now(), 0)
+	assert.Equal(t, 1, len(createdParams))
+	assert.Equal(t, "data/a.csv", createdParams[0]["data_path"])
+	assert.True(t, schedule.TriggerWatermark.Time.Equal(modTimes["a.csv"]))
+	assert.Equal(t, "data/a.csv", schedule.TriggerFile)
+
+	// 发起run失败时不推进水位，b.txt 不匹配pattern，不发起run，但会推进水位
+	options.Concurrency = 0
+	createErr = errors.New("create run failed")
+	s.processFsTrigger(&schedule, options, models.FsConfig{}, time.Now(), 0)
+	assert.Equal(t, 1, len(createdParams))
+	assert.True(t, schedule.TriggerWatermark.Time.Equal(modTimes["b.txt"]))
+	assert.Equal(t, "data/b.txt", schedule.TriggerFile)
+
+	// 修改时间相同的文件中途停止后，剩余文件在下一次轮询中继续发起
+	createErr = nil
+	options.Concurrency = 1
+	s.processFsTrigger(&schedule, options, models.FsConfig{}, time.Now(), 0)
+	assert.Equal(t, 2, len(createdParams))
+	assert.Equal(t, "data/c.csv", createdParams[1]["data_path"])
+	s.processFsTrigger(&schedule, options, models.FsConfig{}, time.Now(), 0)
+	assert.Equal(t, 3, len(createdParams))
+	assert.Equal(t, "data/d.csv", createdParams[2]["data_path"])
+	assert.True(t, schedule.TriggerWatermark.Time.Equal(modTimes["d.csv"]))
+	assert.Equal(t, "data/d.csv", schedule.TriggerFile)
+
+	// 没有新文件，不发起run
+	s.processFsTrigger(&schedule, options, models.FsConfig{}, time.Now(), 0)
+	assert.Equal(t, 3, len(createdParams))
+}
@@ -0,0 +1,89 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/models"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	runtime "github.com/PaddlePaddle/PaddleFlow/pkg/job/runtime_v2"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+)
+
+// cleanRunSharedVolume 在run到达终态后删除run级共享卷，共享卷在run的各个作业所在集群的命名空间中各有一个。
+// 仍被pod使用的PVC由kubernetes延迟到pod删除后再释放
+func cleanRunSharedVolume(runID string) {
+	logging := logger.LoggerForRun(runID)
+	run, err := models.GetRunByID(logging, runID)
+	if err != nil {
+		logging.Errorf("clean shared volume of run[%s] failed, get run err: %v", runID, err)
+		return
+	}
+	if run.WorkflowSource.SharedVolume == nil {
+		return
+	}
+
+	jobs, err := storage.Job.GetJobsByRunID(runID, "")
+	if err != nil {
+		logging.Errorf("clean shared volume of run[%s] failed, get jobs err: %v", runID, err)
+		return
+	}
+	// 集群ID -> 命名空间集合
+	clusterNamespaces := map[string]map[string]bool{}
+	for _, job := range jobs {
+		if job.Config == nil || job.Config.GetClusterID() == "" || job.Config.GetNamespace() == "" {
+			continue
+		}
+		clusterID := job.Config.GetClusterID()
+		if _, ok := clusterNamespaces[clusterID]; !ok {
+			clusterNamespaces[clusterID] = map[string]bool{}
+		}
+		clusterNamespaces[clusterID][job.Config.GetNamespace()] = true
+	}
+
+	claimName := schema.RunSharedVolumeClaimName(runID)
+	for clusterID, namespaces := range clusterNamespaces {
+		cluster, err := storage.Cluster.GetClusterById(clusterID)
+		if err != nil {
+			logging.Errorf("get cluster[%s] of run[%s] failed, err: %v", clusterID, runID, err)
+			continue
+		}
+		if cluster.ClusterType != schema.KubernetesType {
+			continue
+		}
+		runtimeSvc, err := runtime.GetOrCreateRuntime(cluster)
+		if err != nil {
+			logging.Errorf("get runtime of cluster[%s] failed, err: %v", clusterID, err)
+			continue
+		}
+		k8sRuntime, ok := runtimeSvc.(*runtime.KubeRuntime)
+		if !ok {
+			continue
+		}
+		for namespace := range namespaces {
+			err = k8sRuntime.DeletePersistentVolumeClaim(namespace, claimName, metav1.DeleteOptions{})
+			if err != nil && !k8serrors.IsNotFound(err) {
+				logging.Errorf("delete shared pvc[%s/%s] of run[%s] failed, err: %v", namespace, claimName, runID, err)
+				continue
+			}
+			logging.Infof("shared pvc[%s/%s] of run[%s] is deleted", namespace, claimName, runID)
+		}
+	}
+}
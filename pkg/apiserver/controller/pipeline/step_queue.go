@@ -0,0 +1,89 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+)
+
+// checkStepQueues 创建run时校验各节点指定的队列及套餐，不同节点可以使用不同的队列，如预处理使用CPU队列、训练使用GPU队列
+// 使用参数模板的队列及套餐在运行时才能确定，这里跳过校验
+func checkStepQueues(ctx *logger.RequestContext, wfs *schema.WorkflowSource) error {
+	if err := checkComponentQueues(ctx, schema.EntryPointsStr, wfs.EntryPoints.EntryPoints); err != nil {
+		return err
+	}
+	postProcess := map[string]schema.Component{}
+	for name, step := range wfs.PostProcess {
+		postProcess[name] = step
+	}
+	if err := checkComponentQueues(ctx, "post_process", postProcess); err != nil {
+		return err
+	}
+	return checkComponentQueues(ctx, "components", wfs.Components)
+}
+
+func checkComponentQueues(ctx *logger.RequestContext, parentName string, components map[string]schema.Component) error {
+	for _, name := range sortedComponentNames(components) {
+		absName := parentName + "." + name
+		switch comp := components[name].(type) {
+		case *schema.WorkflowSourceDag:
+			if err := checkComponentQueues(ctx, absName, comp.EntryPoints); err != nil {
+				return err
+			}
+		case *schema.WorkflowSourceStep:
+			queueName, flavourName := comp.Env[schema.EnvJobQueueName], comp.Env[schema.EnvJobFlavour]
+			if err := checkStepQueue(ctx, queueName, flavourName); err != nil {
+				return fmt.Errorf("check queue of step[%s] failed: %v", absName, err)
+			}
+		}
+	}
+	return nil
+}
+
+// checkStepQueue 队列须存在、处于open状态且用户有权限使用，套餐须存在且不属于其他集群
+func checkStepQueue(ctx *logger.RequestContext, queueName, flavourName string) error {
+	clusterID := ""
+	if queueName != "" && !strings.Contains(queueName, "{{") {
+		queue, err := storage.Queue.GetCachedQueueByName(queueName)
+		if err != nil {
+			return fmt.Errorf("queue[%s] not exist", queueName)
+		}
+		if queue.Status != schema.StatusQueueOpen {
+			return fmt.Errorf("queue[%s] status is %s, and only queue with open status can submit jobs", queueName, queue.Status)
+		}
+		if !storage.Auth.HasAccessToResource(ctx, common.ResourceTypeQueue, queueName) {
+			return common.NoAccessError(ctx.UserName, common.ResourceTypeQueue, queueName)
+		}
+		clusterID = queue.ClusterId
+	}
+	if flavourName != "" && !strings.Contains(flavourName, "{{") {
+		flavour, err := storage.Flavour.GetFlavour(flavourName)
+		if err != nil {
+			return fmt.Errorf("flavour[%s] not exist", flavourName)
+		}
+		if clusterID != "" && flavour.ClusterID != "" && flavour.ClusterID != clusterID {
+			return fmt.Errorf("flavour[%s] is not available in the cluster of queue[%s]", flavourName, queueName)
+		}
+	}
+	return nil
+}
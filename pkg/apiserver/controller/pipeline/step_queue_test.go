@@ -0,0 +1,93 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage/driver"
+)
+
+const stepQueueYaml = `
+name: step_queue
+entry_points:
+  preprocess:
+    command: "echo preprocess"
+    queue: cpu-queue
+    flavour: cpu-flavour
+  train:
+    deps: preprocess
+    command: "echo train"
+    queue: gpu-queue
+    flavour: gpu-flavour
+  evaluate:
+    deps: train
+    command: "echo evaluate"
+    queue: "{{queue}}"
+    parameters:
+      queue: cpu-queue
+`
+
+func TestCheckStepQueues(t *testing.T) {
+	driver.InitMockDB()
+	cpuCluster := model.ClusterInfo{Model: model.Model{ID: "cluster-cpu"}, Name: "cluster-cpu", Status: model.ClusterStatusOnLine}
+	assert.Nil(t, storage.Cluster.CreateCluster(&cpuCluster))
+	gpuCluster := model.ClusterInfo{Model: model.Model{ID: "cluster-gpu"}, Name: "cluster-gpu", Status: model.ClusterStatusOnLine}
+	assert.Nil(t, storage.Cluster.CreateCluster(&gpuCluster))
+	assert.Nil(t, storage.Queue.CreateQueue(&model.Queue{Name: "cpu-queue", ClusterId: cpuCluster.ID, Status: schema.StatusQueueOpen}))
+	assert.Nil(t, storage.Queue.CreateQueue(&model.Queue{Name: "gpu-queue", ClusterId: gpuCluster.ID, Status: schema.StatusQueueOpen}))
+	assert.Nil(t, storage.Queue.CreateQueue(&model.Queue{Name: "closed-queue", ClusterId: gpuCluster.ID, Status: schema.StatusQueueClosed}))
+	assert.Nil(t, storage.Flavour.CreateFlavour(&model.Flavour{Name: "cpu-flavour", CPU: "4", Mem: "8Gi", ClusterID: cpuCluster.ID}))
+	assert.Nil(t, storage.Flavour.CreateFlavour(&model.Flavour{Name: "gpu-flavour", CPU: "8", Mem: "32Gi", ClusterID: gpuCluster.ID}))
+
+	wfs, err := schema.GetWorkflowSource([]byte(stepQueueYaml))
+	assert.Nil(t, err)
+	preprocess := wfs.EntryPoints.EntryPoints["preprocess"].(*schema.WorkflowSourceStep)
+	assert.Equal(t, "cpu-queue", preprocess.Env[schema.EnvJobQueueName])
+	assert.Equal(t, "cpu-flavour", preprocess.Env[schema.EnvJobFlavour])
+
+	ctx := &logger.RequestContext{UserName: MockRootUser}
+	assert.Nil(t, checkStepQueues(ctx, &wfs))
+
+	// 普通用户没有队列权限
+	err = checkStepQueues(&logger.RequestContext{UserName: MockNormalUser}, &wfs)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "entry_points.preprocess")
+
+	train := wfs.EntryPoints.EntryPoints["train"].(*schema.WorkflowSourceStep)
+	train.Env[schema.EnvJobFlavour] = "cpu-flavour"
+	err = checkStepQueues(ctx, &wfs)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "flavour[cpu-flavour] is not available in the cluster of queue[gpu-queue]")
+
+	train.Env[schema.EnvJobFlavour] = "gpu-flavour"
+	train.Env[schema.EnvJobQueueName] = "closed-queue"
+	err = checkStepQueues(ctx, &wfs)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "only queue with open status can submit jobs")
+
+	train.Env[schema.EnvJobQueueName] = "not-exist-queue"
+	err = checkStepQueues(ctx, &wfs)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "queue[not-exist-queue] not exist")
+}
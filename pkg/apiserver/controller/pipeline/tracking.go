@@ -0,0 +1,242 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+)
+
+const (
+	trackingKeyMaxLength = 256
+	compareRunsMaxNum    = 20
+)
+
+// LogRunTrackingRequest 作业上报的指标、参数与标签，格式参考MLflow的log-batch接口
+type LogRunTrackingRequest struct {
+	JobID   string           `json:"jobID"`
+	Metrics []TrackingMetric `json:"metrics"`
+	Params  []TrackingKV     `json:"params"`
+	Tags    []TrackingKV     `json:"tags"`
+}
+
+type TrackingMetric struct {
+	Key       string  `json:"key"`
+	Value     float64 `json:"value"`
+	Step      int64   `json:"step"`
+	Timestamp int64   `json:"timestamp"` // 毫秒，为空时使用server时间
+}
+
+type TrackingKV struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+type GetRunTrackingResponse struct {
+	RunID   string                      `json:"runID"`
+	Metrics map[string][]TrackingMetric `json:"metrics"`
+	Params  map[string]string           `json:"params"`
+	Tags    map[string]string           `json:"tags"`
+}
+
+type CompareRunTrackingResponse struct {
+	Runs []RunTrackingBrief `json:"runs"`
+}
+
+// RunTrackingBrief 用于多个run之间的对比，指标只保留最后一次上报的值
+type RunTrackingBrief struct {
+	RunID   string             `json:"runID"`
+	Name    string             `json:"name"`
+	Status  string             `json:"status"`
+	Metrics map[string]float64 `json:"metrics"`
+	Params  map[string]string  `json:"params"`
+	Tags    map[string]string  `json:"tags"`
+}
+
+func LogRunTracking(ctx *logger.RequestContext, runID string, request LogRunTrackingRequest) error {
+	ctx.Logging().Debugf("begin log tracking data of run[%s]: %+v", runID, request)
+	if _, err := GetRunByID(ctx.Logging(), ctx.UserName, runID); err != nil {
+		ctx.ErrorCode = common.InvalidArguments
+		ctx.Logging().Errorf("log tracking data of run[%s] failed. error:%s", runID, err.Error())
+		return err
+	}
+
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	metrics := make([]model.RunMetric, 0, len(request.Metrics))
+	for _, metric := range request.Metrics {
+		if err := validateTrackingKey(metric.Key); err != nil {
+			ctx.ErrorCode = common.InvalidArguments
+			return err
+		}
+		timestamp := metric.Timestamp
+		if timestamp == 0 {
+			timestamp = now
+		}
+		metrics = append(metrics, model.RunMetric{
+			RunID:     runID,
+			JobID:     request.JobID,
+			Key:       metric.Key,
+			Value:     metric.Value,
+			Step:      metric.Step,
+			Timestamp: timestamp,
+		})
+	}
+
+	params := make([]model.RunParam, 0, len(request.Params)+len(request.Tags))
+	for paramType, kvs := range map[string][]TrackingKV{
+		model.RunParamTypeParam: request.Params,
+		model.RunParamTypeTag:   request.Tags,
+	} {
+		for _, kv := range kvs {
+			if err := validateTrackingKey(kv.Key); err != nil {
+				ctx.ErrorCode = common.InvalidArguments
+				return err
+			}
+			params = append(params, model.RunParam{
+				RunID: runID,
+				JobID: request.JobID,
+				Type:  paramType,
+				Key:   kv.Key,
+				Value: kv.Value,
+			})
+		}
+	}
+
+	if err := storage.Tracking.CreateRunMetrics(ctx.Logging(), metrics); err != nil {
+		ctx.ErrorCode = common.InternalError
+		return err
+	}
+	if len(params) > 0 {
+		if err := storage.Tracking.SaveRunParams(ctx.Logging(), params); err != nil {
+			ctx.ErrorCode = common.InternalError
+			return err
+		}
+	}
+	return nil
+}
+
+func GetRunTracking(ctx *logger.RequestContext, runID string, metricKeys []string) (GetRunTrackingResponse, error) {
+	ctx.Logging().Debugf("begin get tracking data of run[%s]", runID)
+	if _, err := GetRunByID(ctx.Logging(), ctx.UserName, runID); err != nil {
+		ctx.ErrorCode = common.InvalidArguments
+		ctx.Logging().Errorf("get tracking data of run[%s] failed. error:%s", runID, err.Error())
+		return GetRunTrackingResponse{}, err
+	}
+
+	metrics, params, err := listRunTracking(ctx, []string{runID}, metricKeys)
+	if err != nil {
+		return GetRunTrackingResponse{}, err
+	}
+	response := GetRunTrackingResponse{
+		RunID:   runID,
+		Metrics: map[string][]TrackingMetric{},
+		Params:  map[string]string{},
+		Tags:    map[string]string{},
+	}
+	for _, metric := range metrics {
+		response.Metrics[metric.Key] = append(response.Metrics[metric.Key], TrackingMetric{
+			Key:       metric.Key,
+			Value:     metric.Value,
+			Step:      metric.Step,
+			Timestamp: metric.Timestamp,
+		})
+	}
+	for _, param := range params {
+		if param.Type == model.RunParamTypeTag {
+			response.Tags[param.Key] = param.Value
+		} else {
+			response.Params[param.Key] = param.Value
+		}
+	}
+	return response, nil
+}
+
+// CompareRunTracking 对比多个run的参数与指标，指标取最大step中最后上报的值
+func CompareRunTracking(ctx *logger.RequestContext, runIDs, metricKeys []string) (CompareRunTrackingResponse, error) {
+	ctx.Logging().Debugf("begin compare tracking data of runs%v", runIDs)
+	if len(runIDs) == 0 || len(runIDs) > compareRunsMaxNum {
+		ctx.ErrorCode = common.InvalidArguments
+		err := fmt.Errorf("the number of runs to compare should be in [1, %d]", compareRunsMaxNum)
+		ctx.Logging().Errorln(err.Error())
+		return CompareRunTrackingResponse{}, err
+	}
+
+	response := CompareRunTrackingResponse{Runs: []RunTrackingBrief{}}
+	briefs := map[string]*RunTrackingBrief{}
+	for _, runID := range runIDs {
+		run, err := GetRunByID(ctx.Logging(), ctx.UserName, runID)
+		if err != nil {
+			ctx.ErrorCode = common.InvalidArguments
+			ctx.Logging().Errorf("compare tracking data failed. error:%s", err.Error())
+			return CompareRunTrackingResponse{}, err
+		}
+		response.Runs = append(response.Runs, RunTrackingBrief{
+			RunID:   run.ID,
+			Name:    run.Name,
+			Status:  run.Status,
+			Metrics: map[string]float64{},
+			Params:  map[string]string{},
+			Tags:    map[string]string{},
+		})
+	}
+	for i := range response.Runs {
+		briefs[response.Runs[i].RunID] = &response.Runs[i]
+	}
+
+	metrics, params, err := listRunTracking(ctx, runIDs, metricKeys)
+	if err != nil {
+		return CompareRunTrackingResponse{}, err
+	}
+	// metrics已按step与时间排序，后面的值覆盖前面的值
+	for _, metric := range metrics {
+		briefs[metric.RunID].Metrics[metric.Key] = metric.Value
+	}
+	for _, param := range params {
+		if param.Type == model.RunParamTypeTag {
+			briefs[param.RunID].Tags[param.Key] = param.Value
+		} else {
+			briefs[param.RunID].Params[param.Key] = param.Value
+		}
+	}
+	return response, nil
+}
+
+func listRunTracking(ctx *logger.RequestContext, runIDs, metricKeys []string) ([]model.RunMetric, []model.RunParam, error) {
+	metrics, err := storage.Tracking.ListRunMetrics(ctx.Logging(), runIDs, metricKeys)
+	if err != nil {
+		ctx.ErrorCode = common.InternalError
+		return nil, nil, err
+	}
+	params, err := storage.Tracking.ListRunParams(ctx.Logging(), runIDs)
+	if err != nil {
+		ctx.ErrorCode = common.InternalError
+		return nil, nil, err
+	}
+	return metrics, params, nil
+}
+
+func validateTrackingKey(key string) error {
+	if key == "" || len(key) > trackingKeyMaxLength {
+		return fmt.Errorf("tracking key[%s] is invalid, length should be in [1, %d]", key, trackingKeyMaxLength)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/models"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage/driver"
+)
+
+func TestRunTracking(t *testing.T) {
+	driver.InitMockDB()
+	ctx := &logger.RequestContext{UserName: MockRootUser}
+
+	var runIDs []string
+	for i := 0; i < 2; i++ {
+		run, err := getMockFullRun()
+		assert.Nil(t, err)
+		runID, err := models.CreateRun(ctx.Logging(), &run)
+		assert.Nil(t, err)
+		runIDs = append(runIDs, runID)
+	}
+
+	request := LogRunTrackingRequest{
+		JobID: "job-000001",
+		Metrics: []TrackingMetric{
+			{Key: "loss", Value: 0.9, Step: 1},
+			{Key: "loss", Value: 0.5, Step: 2},
+			{Key: "acc", Value: 0.8, Step: 2},
+		},
+		Params: []TrackingKV{{Key: "lr", Value: "0.01"}},
+		Tags:   []TrackingKV{{Key: "model", Value: "resnet"}},
+	}
+	err := LogRunTracking(ctx, runIDs[0], request)
+	assert.Nil(t, err)
+	// 重复上报的param以最后一次为准
+	err = LogRunTracking(ctx, runIDs[0], LogRunTrackingRequest{Params: []TrackingKV{{Key: "lr", Value: "0.02"}}})
+	assert.Nil(t, err)
+	err = LogRunTracking(ctx, runIDs[1], LogRunTrackingRequest{Metrics: []TrackingMetric{{Key: "loss", Value: 0.3}}})
+	assert.Nil(t, err)
+
+	// key为空
+	err = LogRunTracking(ctx, runIDs[0], LogRunTrackingRequest{Params: []TrackingKV{{Key: "", Value: "1"}}})
+	assert.NotNil(t, err)
+	assert.Equal(t, common.InvalidArguments, ctx.ErrorCode)
+	// 用户没有权限
+	err = LogRunTracking(&logger.RequestContext{UserName: "another"}, runIDs[0], request)
+	assert.NotNil(t, err)
+
+	resp, err := GetRunTracking(ctx, runIDs[0], nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(resp.Metrics["loss"]))
+	assert.Equal(t, 0.5, resp.Metrics["loss"][1].Value)
+	assert.NotZero(t, resp.Metrics["loss"][0].Timestamp)
+	assert.Equal(t, "0.02", resp.Params["lr"])
+	assert.Equal(t, "resnet", resp.Tags["model"])
+
+	resp, err = GetRunTracking(ctx, runIDs[0], []string{"acc"})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(resp.Metrics))
+
+	compare, err := CompareRunTracking(ctx, runIDs, []string{"loss"})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(compare.Runs))
+	assert.Equal(t, 0.5, compare.Runs[0].Metrics["loss"])
+	assert.Equal(t, "0.02", compare.Runs[0].Params["lr"])
+	assert.Equal(t, 0.3, compare.Runs[1].Metrics["loss"])
+
+	_, err = CompareRunTracking(ctx, []string{}, nil)
+	assert.NotNil(t, err)
+}
+
+func TestTrackingToken(t *testing.T) {
+	now := time.Now()
+	token, err := common.GenerateTrackingToken("run-000001", "user1", now.Add(time.Hour))
+	assert.Nil(t, err)
+	owner, err := common.ParseTrackingToken(token, "run-000001", now)
+	assert.Nil(t, err)
+	assert.Equal(t, "user1", owner)
+	_, err = common.ParseTrackingToken(token, "run-000002", now)
+	assert.NotNil(t, err)
+	_, err = common.ParseTrackingToken(token, "run-000001", now.Add(2*time.Hour))
+	assert.NotNil(t, err)
+	_, err = common.ParseTrackingToken("", "run-000001", now)
+	assert.NotNil(t, err)
+
+	// 篡改用户名后签名不匹配
+	forged := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%srun-000001|root|%d",
+		common.PrefixTrackingToken, now.Add(time.Hour).Unix()))) + token[strings.Index(token, "."):]
+	_, err = common.ParseTrackingToken(forged, "run-000001", now)
+	assert.NotNil(t, err)
+}
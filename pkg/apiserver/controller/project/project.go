@@ -0,0 +1,473 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package project
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	gormErrors "github.com/PaddlePaddle/PaddleFlow/pkg/common/errors"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/resources"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+)
+
+type CreateProjectRequest struct {
+	Name         string              `json:"name"`
+	Description  string              `json:"description"`
+	MaxResources schema.ResourceInfo `json:"maxResources"`
+}
+
+type CreateProjectResponse struct {
+	ProjectName string `json:"projectName"`
+}
+
+// UpdateProjectRequest 字段为空时不更新，修改配额需要root权限
+type UpdateProjectRequest struct {
+	Description  string              `json:"description,omitempty"`
+	MaxResources schema.ResourceInfo `json:"maxResources,omitempty"`
+}
+
+type GetProjectResponse struct {
+	model.Project
+	// UsedResources 项目中所有队列的maxResources之和
+	UsedResources *resources.Resource   `json:"usedResources"`
+	Members       []model.ProjectMember `json:"members"`
+}
+
+type ListProjectResponse struct {
+	common.MarkerInfo
+	ProjectList []model.Project `json:"projectList"`
+}
+
+type ProjectMemberRequest struct {
+	ResourceType string `json:"resourceType"`
+	ResourceID   string `json:"resourceID"`
+	Role         string `json:"role,omitempty"`
+}
+
+// toResource 将配额转换为resource，未填写或为0的资源不做限制
+func toResource(info schema.ResourceInfo) (*resources.Resource, error) {
+	resMap := make(map[string]string)
+	for key, value := range info.ToMap() {
+		if value != "" {
+			resMap[key] = value
+		}
+	}
+	return resources.NewResourceFromMap(resMap)
+}
+
+func CreateProject(ctx *logger.RequestContext, request *CreateProjectRequest) (*CreateProjectResponse, error) {
+	ctx.Logging().Debugf("begin create project. request:%+v", request)
+	if !common.IsRootUser(ctx.UserName) {
+		ctx.ErrorCode = common.OnlyRootAllowed
+		ctx.Logging().Errorln("create project failed. root is needed.")
+		return nil, errors.New("create project failed")
+	}
+	if errStr := common.IsDNS1123Label(request.Name); len(errStr) != 0 {
+		ctx.ErrorCode = common.InvalidArguments
+		err := fmt.Errorf("project name[%s] is invalid: %s", request.Name, strings.Join(errStr, ","))
+		ctx.Logging().Errorln(err.Error())
+		return nil, err
+	}
+	maxResources, err := toResource(request.MaxResources)
+	if err != nil {
+		ctx.ErrorCode = common.InvalidComputeResource
+		ctx.Logging().Errorf("create project failed. error: %s", err.Error())
+		return nil, err
+	}
+	project := &model.Project{
+		Name:         request.Name,
+		Description:  request.Description,
+		MaxResources: maxResources,
+	}
+	if err = storage.Project.CreateProject(ctx, project); err != nil {
+		if gormErrors.GetErrorCode(err) == gormErrors.ErrorKeyIsDuplicated {
+			ctx.ErrorCode = common.ProjectNameDuplicated
+		} else {
+			ctx.ErrorCode = common.InternalError
+		}
+		return nil, err
+	}
+	return &CreateProjectResponse{ProjectName: project.Name}, nil
+}
+
+func UpdateProject(ctx *logger.RequestContext, projectName string, request *UpdateProjectRequest) error {
+	ctx.Logging().Debugf("begin update project[%s]. request:%+v", projectName, request)
+	project, err := getProject(ctx, projectName)
+	if err != nil {
+		return err
+	}
+	if !IsProjectAdmin(ctx, projectName) {
+		ctx.ErrorCode = common.AccessDenied
+		err = common.NoAccessError(ctx.UserName, common.ResourceTypeProject, projectName)
+		ctx.Logging().Errorln(err.Error())
+		return err
+	}
+	if request.Description != "" {
+		project.Description = request.Description
+	}
+	if !isEmptyResourceInfo(request.MaxResources) {
+		if !common.IsRootUser(ctx.UserName) {
+			ctx.ErrorCode = common.OnlyRootAllowed
+			ctx.Logging().Errorln("update project quota failed. root is needed.")
+			return errors.New("only root can update quota of project")
+		}
+		maxResources, err := toResource(request.MaxResources)
+		if err != nil {
+			ctx.ErrorCode = common.InvalidComputeResource
+			ctx.Logging().Errorf("update project failed. error: %s", err.Error())
+			return err
+		}
+		project.MaxResources = maxResources
+		used, err := projectUsedResources(ctx, projectName, "")
+		if err != nil {
+			return err
+		}
+		if err = checkQuota(ctx, project, used); err != nil {
+			return err
+		}
+	}
+	if err = storage.Project.UpdateProject(ctx, &project); err != nil {
+		ctx.ErrorCode = common.InternalError
+		return err
+	}
+	return nil
+}
+
+func isEmptyResourceInfo(info schema.ResourceInfo) bool {
+	return info.CPU == "" && info.Mem == "" && len(info.ScalarResources) == 0
+}
+
+func DeleteProject(ctx *logger.RequestContext, projectName string) error {
+	ctx.Logging().Debugf("begin delete project[%s].", projectName)
+	if !common.IsRootUser(ctx.UserName) {
+		ctx.ErrorCode = common.OnlyRootAllowed
+		ctx.Logging().Errorln("delete project failed. root is needed.")
+		return errors.New("delete project failed")
+	}
+	if _, err := getProject(ctx, projectName); err != nil {
+		return err
+	}
+	if err := storage.Project.DeleteProject(ctx, projectName); err != nil {
+		ctx.ErrorCode = common.InternalError
+		return err
+	}
+	return nil
+}
+
+func GetProject(ctx *logger.RequestContext, projectName string) (*GetProjectResponse, error) {
+	ctx.Logging().Debugf("begin get project[%s].", projectName)
+	project, err := getProject(ctx, projectName)
+	if err != nil {
+		return nil, err
+	}
+	if err = CheckProjectMember(ctx, projectName); err != nil {
+		return nil, err
+	}
+	members, err := storage.Project.ListProjectMember(ctx, projectName, "", "")
+	if err != nil {
+		ctx.ErrorCode = common.InternalError
+		return nil, err
+	}
+	used, err := projectUsedResources(ctx, projectName, "")
+	if err != nil {
+		return nil, err
+	}
+	return &GetProjectResponse{
+		Project:       project,
+		UsedResources: used,
+		Members:       members,
+	}, nil
+}
+
+func ListProject(ctx *logger.RequestContext, marker string, maxKeys int) (*ListProjectResponse, error) {
+	ctx.Logging().Debugf("begin list project.")
+	var pk int64
+	var err error
+	if marker != "" {
+		pk, err = common.DecryptPk(marker)
+		if err != nil {
+			ctx.Logging().Errorf("DecryptPk marker[%s] failed. err:[%s]", marker, err.Error())
+			ctx.ErrorCode = common.InvalidMarker
+			return nil, err
+		}
+	}
+	projects, err := storage.Project.ListProject(ctx, pk, maxKeys, ctx.UserName)
+	if err != nil {
+		ctx.ErrorCode = common.InternalError
+		return nil, err
+	}
+	response := &ListProjectResponse{ProjectList: []model.Project{}}
+	response.IsTruncated = false
+	if len(projects) > 0 {
+		last := projects[len(projects)-1]
+		lastProject, err := storage.Project.GetLastProject(ctx, ctx.UserName)
+		if err == nil && lastProject.Pk != last.Pk {
+			nextMarker, err := common.EncryptPk(last.Pk)
+			if err != nil {
+				ctx.Logging().Errorf("EncryptPk error. pk:[%d] error:[%s]", last.Pk, err.Error())
+				ctx.ErrorCode = common.InternalError
+				return nil, err
+			}
+			response.NextMarker = nextMarker
+			response.IsTruncated = true
+		}
+	}
+	response.MaxKeys = maxKeys
+	response.ProjectList = append(response.ProjectList, projects...)
+	return response, nil
+}
+
+// AddProjectMember 项目管理员可以添加用户、自己的存储和工作流，添加队列需要root权限
+func AddProjectMember(ctx *logger.RequestContext, projectName string, request *ProjectMemberRequest) error {
+	ctx.Logging().Debugf("begin add member to project[%s]. request:%+v", projectName, request)
+	project, err := getProject(ctx, projectName)
+	if err != nil {
+		return err
+	}
+	if err = checkMemberRequest(ctx, projectName, request); err != nil {
+		return err
+	}
+	if _, err = storage.Project.GetProjectMember(ctx, projectName, request.ResourceType, request.ResourceID); err == nil {
+		ctx.ErrorCode = common.ProjectMemberExist
+		err = fmt.Errorf("%s[%s] is already in project[%s]", request.ResourceType, request.ResourceID, projectName)
+		ctx.Logging().Errorln(err.Error())
+		return err
+	}
+
+	member := &model.ProjectMember{
+		ProjectName:  projectName,
+		ResourceType: request.ResourceType,
+		ResourceID:   request.ResourceID,
+	}
+	switch request.ResourceType {
+	case common.ResourceTypeUser:
+		member.Role = request.Role
+		if member.Role == "" {
+			member.Role = model.ProjectRoleMember
+		}
+	case common.ResourceTypeQueue:
+		queue, _ := storage.Queue.GetQueueByName(request.ResourceID)
+		used, err := projectUsedResources(ctx, projectName, "")
+		if err != nil {
+			return err
+		}
+		if queue.MaxResources != nil {
+			used.Add(queue.MaxResources)
+		}
+		if err = checkQuota(ctx, project, used); err != nil {
+			return err
+		}
+	}
+	if err = storage.Project.AddProjectMember(ctx, member); err != nil {
+		ctx.ErrorCode = common.InternalError
+		return err
+	}
+	return nil
+}
+
+func RemoveProjectMember(ctx *logger.RequestContext, projectName, resourceType, resourceID string) error {
+	ctx.Logging().Debugf("begin remove %s[%s] from project[%s].", resourceType, resourceID, projectName)
+	if _, err := getProject(ctx, projectName); err != nil {
+		return err
+	}
+	if !IsProjectAdmin(ctx, projectName) ||
+		(resourceType == common.ResourceTypeQueue && !common.IsRootUser(ctx.UserName)) {
+		ctx.ErrorCode = common.AccessDenied
+		err := common.NoAccessError(ctx.UserName, common.ResourceTypeProject, projectName)
+		ctx.Logging().Errorln(err.Error())
+		return err
+	}
+	if _, err := storage.Project.GetProjectMember(ctx, projectName, resourceType, resourceID); err != nil {
+		ctx.ErrorCode = common.ProjectMemberNotFound
+		return fmt.Errorf("%s[%s] is not in project[%s]", resourceType, resourceID, projectName)
+	}
+	if err := storage.Project.RemoveProjectMember(ctx, projectName, resourceType, resourceID); err != nil {
+		ctx.ErrorCode = common.InternalError
+		return err
+	}
+	return nil
+}
+
+// CheckProjectMember 检查请求用户是否为项目成员，root用户可以访问所有项目
+func CheckProjectMember(ctx *logger.RequestContext, projectName string) error {
+	if common.IsRootUser(ctx.UserName) {
+		return nil
+	}
+	if _, err := storage.Project.GetProjectMember(ctx, projectName, common.ResourceTypeUser, ctx.UserName); err != nil {
+		ctx.ErrorCode = common.AccessDenied
+		err = common.NoAccessError(ctx.UserName, common.ResourceTypeProject, projectName)
+		ctx.Logging().Errorln(err.Error())
+		return err
+	}
+	return nil
+}
+
+// IsProjectAdmin root用户视为所有项目的管理员
+func IsProjectAdmin(ctx *logger.RequestContext, projectName string) bool {
+	if common.IsRootUser(ctx.UserName) {
+		return true
+	}
+	member, err := storage.Project.GetProjectMember(ctx, projectName, common.ResourceTypeUser, ctx.UserName)
+	return err == nil && member.Role == model.ProjectRoleAdmin
+}
+
+// CheckQueueQuota 队列的maxResources调整后，检查其所在项目的配额是否仍然满足
+func CheckQueueQuota(ctx *logger.RequestContext, queueName string, maxResources *resources.Resource) error {
+	members, err := storage.Project.ListProjectMember(ctx, "", common.ResourceTypeQueue, queueName)
+	if err != nil {
+		ctx.ErrorCode = common.InternalError
+		return err
+	}
+	for _, member := range members {
+		project, err := getProject(ctx, member.ProjectName)
+		if err != nil {
+			return err
+		}
+		used, err := projectUsedResources(ctx, member.ProjectName, queueName)
+		if err != nil {
+			return err
+		}
+		if maxResources != nil {
+			used.Add(maxResources)
+		}
+		if err = checkQuota(ctx, project, used); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func getProject(ctx *logger.RequestContext, projectName string) (model.Project, error) {
+	project, err := storage.Project.GetProjectByName(ctx, projectName)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.ErrorCode = common.ProjectNotFound
+			return model.Project{}, fmt.Errorf("project[%s] not found", projectName)
+		}
+		ctx.ErrorCode = common.InternalError
+		return model.Project{}, err
+	}
+	return project, nil
+}
+
+// projectUsedResources 统计项目中队列的maxResources之和，excludeQueue 不计入统计
+func projectUsedResources(ctx *logger.RequestContext, projectName, excludeQueue string) (*resources.Resource, error) {
+	members, err := storage.Project.ListProjectMember(ctx, projectName, common.ResourceTypeQueue, "")
+	if err != nil {
+		ctx.ErrorCode = common.InternalError
+		return nil, err
+	}
+	used := resources.EmptyResource()
+	for _, member := range members {
+		if member.ResourceID == excludeQueue {
+			continue
+		}
+		queue, err := storage.Queue.GetQueueByName(member.ResourceID)
+		if err != nil {
+			ctx.Logging().Warnf("get queue[%s] of project[%s] failed, err: %v", member.ResourceID, projectName, err)
+			continue
+		}
+		if queue.MaxResources != nil {
+			used.Add(queue.MaxResources)
+		}
+	}
+	return used, nil
+}
+
+func checkQuota(ctx *logger.RequestContext, project model.Project, used *resources.Resource) error {
+	if project.MaxResources == nil || len(project.MaxResources.Resources) == 0 {
+		return nil
+	}
+	// 配额为0的资源不做限制
+	for name, quota := range project.MaxResources.Resources {
+		if quota > 0 && used.Resources[name] > quota {
+			ctx.ErrorCode = common.ProjectQuotaExceeded
+			err := fmt.Errorf("%s of queues in project[%s] exceeds the quota, used: %v, quota: %v",
+				name, project.Name, used.Resources[name], quota)
+			ctx.Logging().Errorln(err.Error())
+			return err
+		}
+	}
+	return nil
+}
+
+func checkMemberRequest(ctx *logger.RequestContext, projectName string, request *ProjectMemberRequest) error {
+	if !IsProjectAdmin(ctx, projectName) {
+		ctx.ErrorCode = common.AccessDenied
+		err := common.NoAccessError(ctx.UserName, common.ResourceTypeProject, projectName)
+		ctx.Logging().Errorln(err.Error())
+		return err
+	}
+	var owner string
+	switch request.ResourceType {
+	case common.ResourceTypeUser:
+		if request.Role != "" && request.Role != model.ProjectRoleAdmin && request.Role != model.ProjectRoleMember {
+			ctx.ErrorCode = common.InvalidArguments
+			return fmt.Errorf("role[%s] is invalid, must be %s or %s", request.Role, model.ProjectRoleAdmin, model.ProjectRoleMember)
+		}
+		if common.IsRootUser(request.ResourceID) {
+			ctx.ErrorCode = common.InvalidArguments
+			return fmt.Errorf("root can not be added to project")
+		}
+		if _, err := storage.Auth.GetUserByName(ctx, request.ResourceID); err != nil {
+			ctx.ErrorCode = common.UserNotExist
+			return fmt.Errorf("user[%s] not found", request.ResourceID)
+		}
+		return nil
+	case common.ResourceTypeQueue:
+		if !common.IsRootUser(ctx.UserName) {
+			ctx.ErrorCode = common.OnlyRootAllowed
+			return fmt.Errorf("only root can add queue to project")
+		}
+		if _, err := storage.Queue.GetQueueByName(request.ResourceID); err != nil {
+			ctx.ErrorCode = common.QueueNameNotFound
+			return fmt.Errorf("queue[%s] not found", request.ResourceID)
+		}
+		return nil
+	case common.ResourceTypeFs:
+		fs, err := storage.Filesystem.GetFileSystemWithFsID(request.ResourceID)
+		if err != nil {
+			ctx.ErrorCode = common.FileSystemNotExist
+			return fmt.Errorf("fs[%s] not found", request.ResourceID)
+		}
+		owner = fs.UserName
+	case common.ResourceTypePipeline:
+		ppl, err := storage.Pipeline.GetPipelineByID(request.ResourceID)
+		if err != nil {
+			ctx.ErrorCode = common.PipelineNotFound
+			return fmt.Errorf("pipeline[%s] not found", request.ResourceID)
+		}
+		owner = ppl.UserName
+	default:
+		ctx.ErrorCode = common.InvalidArguments
+		return fmt.Errorf("resourceType[%s] is not supported, must be one of user/queue/fs/pipeline", request.ResourceType)
+	}
+	// 项目管理员只能添加自己的存储和工作流
+	if err := common.CheckPermission(ctx.UserName, owner, request.ResourceType, request.ResourceID); err != nil {
+		ctx.ErrorCode = common.AccessDenied
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package project
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/resources"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage/driver"
+)
+
+const (
+	MockRootUser    = "root"
+	MockAdminUser   = "admin1"
+	MockMemberUser  = "user1"
+	MockOtherUser   = "user2"
+	MockProjectName = "team-a"
+	MockQueue1      = "queue1"
+	MockQueue2      = "queue2"
+	MockClusterName = "fakeCluster"
+)
+
+func mockProjectEnv(t *testing.T) {
+	driver.InitMockDB()
+	ctx := &logger.RequestContext{UserName: MockRootUser}
+	clusterInfo := model.ClusterInfo{
+		Name:          MockClusterName,
+		ClusterType:   schema.KubernetesType,
+		Status:        model.ClusterStatusOnLine,
+		NamespaceList: []string{"default"},
+	}
+	assert.NoError(t, storage.Cluster.CreateCluster(&clusterInfo))
+	cluster, _ := storage.Cluster.GetClusterByName(MockClusterName)
+	for _, name := range []string{MockQueue1, MockQueue2} {
+		maxRes, err := resources.NewResourceFromMap(map[string]string{resources.ResCPU: "10", resources.ResMemory: "20Gi"})
+		assert.NoError(t, err)
+		assert.NoError(t, storage.Queue.CreateQueue(&model.Queue{
+			Model:        model.Model{ID: name},
+			Name:         name,
+			Namespace:    "default",
+			ClusterId:    cluster.ID,
+			MaxResources: maxRes,
+		}))
+	}
+	for _, name := range []string{MockAdminUser, MockMemberUser, MockOtherUser} {
+		assert.NoError(t, storage.Auth.CreateUser(ctx, &model.User{UserInfo: model.UserInfo{Name: name, Password: "fake"}}))
+	}
+}
+
+func TestProject(t *testing.T) {
+	mockProjectEnv(t)
+	rootCtx := &logger.RequestContext{UserName: MockRootUser}
+	adminCtx := &logger.RequestContext{UserName: MockAdminUser}
+	memberCtx := &logger.RequestContext{UserName: MockMemberUser}
+	otherCtx := &logger.RequestContext{UserName: MockOtherUser}
+
+	createReq := &CreateProjectRequest{
+		Name:         MockProjectName,
+		MaxResources: schema.ResourceInfo{CPU: "15"},
+	}
+	_, err := CreateProject(adminCtx, createReq)
+	assert.Error(t, err)
+	assert.Equal(t, common.OnlyRootAllowed, adminCtx.ErrorCode)
+	_, err = CreateProject(rootCtx, createReq)
+	assert.NoError(t, err)
+	_, err = CreateProject(rootCtx, createReq)
+	assert.Error(t, err)
+
+	// root指定项目管理员，管理员添加普通成员
+	assert.NoError(t, AddProjectMember(rootCtx, MockProjectName,
+		&ProjectMemberRequest{ResourceType: common.ResourceTypeUser, ResourceID: MockAdminUser, Role: model.ProjectRoleAdmin}))
+	assert.NoError(t, AddProjectMember(adminCtx, MockProjectName,
+		&ProjectMemberRequest{ResourceType: common.ResourceTypeUser, ResourceID: MockMemberUser}))
+	err = AddProjectMember(memberCtx, MockProjectName,
+		&ProjectMemberRequest{ResourceType: common.ResourceTypeUser, ResourceID: MockOtherUser})
+	assert.Error(t, err)
+	assert.Equal(t, common.AccessDenied, memberCtx.ErrorCode)
+	err = AddProjectMember(adminCtx, MockProjectName,
+		&ProjectMemberRequest{ResourceType: common.ResourceTypeUser, ResourceID: MockMemberUser})
+	assert.Equal(t, common.ProjectMemberExist, adminCtx.ErrorCode)
+
+	// 只有root可以添加队列，且队列的maxResources之和不能超过项目配额
+	adminCtx.ErrorCode = ""
+	err = AddProjectMember(adminCtx, MockProjectName,
+		&ProjectMemberRequest{ResourceType: common.ResourceTypeQueue, ResourceID: MockQueue1})
+	assert.Error(t, err)
+	assert.Equal(t, common.OnlyRootAllowed, adminCtx.ErrorCode)
+	assert.NoError(t, AddProjectMember(rootCtx, MockProjectName,
+		&ProjectMemberRequest{ResourceType: common.ResourceTypeQueue, ResourceID: MockQueue1}))
+	err = AddProjectMember(rootCtx, MockProjectName,
+		&ProjectMemberRequest{ResourceType: common.ResourceTypeQueue, ResourceID: MockQueue2})
+	assert.Error(t, err)
+	assert.Equal(t, common.ProjectQuotaExceeded, rootCtx.ErrorCode)
+
+	// 项目成员可以访问项目中的队列
+	assert.True(t, storage.Auth.HasAccessToResource(memberCtx, common.ResourceTypeQueue, MockQueue1))
+	assert.False(t, storage.Auth.HasAccessToResource(memberCtx, common.ResourceTypeQueue, MockQueue2))
+	assert.False(t, storage.Auth.HasAccessToResource(otherCtx, common.ResourceTypeQueue, MockQueue1))
+	queues, err := storage.Queue.ListQueue(0, 0, "", MockMemberUser, "")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(queues))
+	queues, err = storage.Queue.ListQueue(0, 0, "", MockRootUser, MockProjectName)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(queues))
+	assert.Equal(t, MockQueue1, queues[0].Name)
+
+	// 按项目过滤作业，管理员可以看到项目中所有用户的作业
+	assert.NoError(t, storage.Job.CreateJob(&model.Job{ID: "job-1", UserName: MockMemberUser, QueueID: MockQueue1}))
+	assert.NoError(t, storage.Job.CreateJob(&model.Job{ID: "job-2", UserName: MockMemberUser, QueueID: MockQueue2}))
+	jobs, err := storage.Job.ListJob(0, 0, "", "", "", "", "", nil, MockProjectName)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(jobs))
+	assert.Equal(t, "job-1", jobs[0].ID)
+
+	users, err := storage.Auth.ListUser(rootCtx, 0, 0, MockProjectName)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(users))
+
+	// 查看项目详情
+	resp, err := GetProject(memberCtx, MockProjectName)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(resp.Members))
+	assert.Equal(t, int64(10000), int64(resp.UsedResources.CPU()))
+	_, err = GetProject(otherCtx, MockProjectName)
+	assert.Error(t, err)
+	list, err := ListProject(otherCtx, "", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(list.ProjectList))
+	list, err = ListProject(memberCtx, "", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(list.ProjectList))
+
+	// 配额
+	err = UpdateProject(adminCtx, MockProjectName, &UpdateProjectRequest{MaxResources: schema.ResourceInfo{CPU: "100"}})
+	assert.Error(t, err)
+	assert.NoError(t, UpdateProject(adminCtx, MockProjectName, &UpdateProjectRequest{Description: "team a"}))
+	err = UpdateProject(rootCtx, MockProjectName, &UpdateProjectRequest{MaxResources: schema.ResourceInfo{CPU: "5"}})
+	assert.Error(t, err)
+	assert.Equal(t, common.ProjectQuotaExceeded, rootCtx.ErrorCode)
+	largeRes, _ := resources.NewResourceFromMap(map[string]string{resources.ResCPU: "20"})
+	assert.Error(t, CheckQueueQuota(rootCtx, MockQueue1, largeRes))
+	smallRes, _ := resources.NewResourceFromMap(map[string]string{resources.ResCPU: "8"})
+	assert.NoError(t, CheckQueueQuota(rootCtx, MockQueue1, smallRes))
+
+	// 移除成员及删除项目
+	err = RemoveProjectMember(adminCtx, MockProjectName, common.ResourceTypeQueue, MockQueue1)
+	assert.Error(t, err)
+	assert.NoError(t, RemoveProjectMember(adminCtx, MockProjectName, common.ResourceTypeUser, MockMemberUser))
+	assert.False(t, storage.Auth.HasAccessToResource(memberCtx, common.ResourceTypeQueue, MockQueue1))
+	assert.Error(t, DeleteProject(adminCtx, MockProjectName))
+	assert.NoError(t, DeleteProject(rootCtx, MockProjectName))
+	members, err := storage.Project.ListProjectMember(rootCtx, MockProjectName, "", "")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(members))
+	_, err = GetProject(rootCtx, MockProjectName)
+	assert.Equal(t, common.ProjectNotFound, rootCtx.ErrorCode)
+}
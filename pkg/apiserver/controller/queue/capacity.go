@@ -0,0 +1,110 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/job/api"
+	runtime "github.com/PaddlePaddle/PaddleFlow/pkg/job/runtime_v2"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+)
+
+const defaultQueueCapacityInterval = time.Minute
+
+// baseCapacityWindow 表示队列不在任何时间段内，使用基础容量
+const baseCapacityWindow = -1
+
+// updateClusterQueue 更新集群中队列的配额，可在测试中替换
+var updateClusterQueue = func(clusterInfo model.ClusterInfo, queueInfo *api.QueueInfo) error {
+	runtimeSvc, err := runtime.GetOrCreateRuntime(clusterInfo)
+	if err != nil {
+		return err
+	}
+	return runtimeSvc.UpdateQueue(queueInfo)
+}
+
+// QueueCapacityController 定期按队列的容量时间段更新集群中队列的配额，
+// 容量减少时由调度器回收或抢占超出配额的作业，容量增加时等待中的作业可以借用空闲资源
+func QueueCapacityController(stopChan chan struct{}) {
+	appliedWindows := make(map[string]int)
+	for {
+		applyCapacitySchedules(appliedWindows, time.Now())
+		select {
+		case <-stopChan:
+			log.Info("queue capacity controller stopped")
+			return
+		case <-time.After(defaultQueueCapacityInterval):
+		}
+	}
+}
+
+// applyCapacitySchedules 时间段切换时更新集群中队列的配额，appliedWindows 记录每个队列已生效的时间段
+func applyCapacitySchedules(appliedWindows map[string]int, now time.Time) {
+	queues, err := storage.Queue.ListQueue(0, 0, "", common.UserRoot, "")
+	if err != nil {
+		log.Errorf("list queues for capacity schedule failed, err: %v", err)
+		return
+	}
+	scheduled := make(map[string]bool)
+	for _, queue := range queues {
+		if queue.CapacitySchedule == nil || queue.Status != schema.StatusQueueOpen {
+			continue
+		}
+		scheduled[queue.ID] = true
+		window := activeWindowIndex(queue.CapacitySchedule, now)
+		if applied, find := appliedWindows[queue.ID]; find && applied == window {
+			continue
+		}
+		clusterInfo, err := storage.Cluster.GetClusterById(queue.ClusterId)
+		if err != nil {
+			log.Errorf("get cluster of queue %s failed, err: %v", queue.Name, err)
+			continue
+		}
+		if clusterInfo.Status != model.ClusterStatusOnLine {
+			continue
+		}
+		queueInfo := api.NewQueueInfo(queue)
+		if err = updateClusterQueue(clusterInfo, queueInfo); err != nil {
+			log.Errorf("update capacity of queue %s failed, err: %v", queue.Name, err)
+			continue
+		}
+		log.Infof("capacity of queue %s is updated to max %v, min %v", queue.Name,
+			queueInfo.MaxResources, queueInfo.MinResources)
+		appliedWindows[queue.ID] = window
+	}
+	for queueID := range appliedWindows {
+		if !scheduled[queueID] {
+			delete(appliedWindows, queueID)
+		}
+	}
+}
+
+func activeWindowIndex(capacitySchedule *model.CapacitySchedule, now time.Time) int {
+	window := capacitySchedule.ActiveWindow(now)
+	for i := range capacitySchedule.Windows {
+		if window == &capacitySchedule.Windows[i] {
+			return i
+		}
+	}
+	return baseCapacityWindow
+}
@@ -0,0 +1,132 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/resources"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/job/api"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage/driver"
+)
+
+func gpuResource(t *testing.T, gpu string) *resources.Resource {
+	r, err := resources.NewResourceFromMap(map[string]string{"cpu": "64", "mem": "256Gi", "nvidia.com/gpu": gpu})
+	assert.NoError(t, err)
+	return r
+}
+
+func TestCapacitySchedule(t *testing.T) {
+	queue := model.Queue{
+		MaxResources: gpuResource(t, "16"),
+		MinResources: gpuResource(t, "8"),
+		CapacitySchedule: &model.CapacitySchedule{
+			Timezone: "UTC",
+			Windows: []model.CapacityWindow{
+				{Name: "night", Start: "22:00", End: "08:00", MaxResources: gpuResource(t, "64")},
+				{Name: "weekend", Start: "00:00", End: "00:00", Weekdays: []string{"Sat", "Sun"},
+					MaxResources: gpuResource(t, "32"), MinResources: gpuResource(t, "16")},
+			},
+		},
+	}
+	assert.NoError(t, queue.CapacitySchedule.Validate())
+
+	// 周一白天使用基础容量
+	maxRes, minRes := queue.EffectiveResources(time.Date(2022, 8, 1, 10, 0, 0, 0, time.UTC))
+	assert.Equal(t, queue.MaxResources, maxRes)
+	assert.Equal(t, queue.MinResources, minRes)
+	// 跨越零点的时间段，未设置minResources时使用基础minResources
+	maxRes, minRes = queue.EffectiveResources(time.Date(2022, 8, 2, 3, 0, 0, 0, time.UTC))
+	assert.Equal(t, queue.CapacitySchedule.Windows[0].MaxResources, maxRes)
+	assert.Equal(t, queue.MinResources, minRes)
+	// 周六白天
+	maxRes, minRes = queue.EffectiveResources(time.Date(2022, 8, 6, 12, 0, 0, 0, time.UTC))
+	assert.Equal(t, queue.CapacitySchedule.Windows[1].MaxResources, maxRes)
+	assert.Equal(t, queue.CapacitySchedule.Windows[1].MinResources, minRes)
+	// 时区
+	queue.CapacitySchedule.Timezone = "Asia/Shanghai"
+	maxRes, _ = queue.EffectiveResources(time.Date(2022, 8, 1, 15, 0, 0, 0, time.UTC))
+	assert.Equal(t, queue.CapacitySchedule.Windows[0].MaxResources, maxRes)
+
+	assert.Equal(t, gpuResource(t, "64"), queue.PeakMaxResources())
+
+	invalid := []model.CapacitySchedule{
+		{Timezone: "Mars/Olympus", Windows: []model.CapacityWindow{}},
+		{Windows: []model.CapacityWindow{{Start: "25:00", End: "08:00", MaxResources: gpuResource(t, "8")}}},
+		{Windows: []model.CapacityWindow{{Start: "22:00", End: "08:00", Weekdays: []string{"Someday"},
+			MaxResources: gpuResource(t, "8")}}},
+		{Windows: []model.CapacityWindow{{Start: "22:00", End: "08:00"}}},
+		{Windows: []model.CapacityWindow{{Start: "22:00", End: "08:00", MaxResources: gpuResource(t, "8"),
+			MinResources: gpuResource(t, "16")}}},
+	}
+	for i := range invalid {
+		assert.Error(t, invalid[i].Validate())
+	}
+	// 弹性配额队列的基础minResources不能超过时间段的maxResources
+	schedule := &model.CapacitySchedule{Windows: []model.CapacityWindow{{Start: "08:00", End: "22:00",
+		MaxResources: gpuResource(t, "4")}}}
+	assert.Error(t, validateCapacitySchedule(schema.TypeElasticQuota, gpuResource(t, "8"), schedule))
+	assert.NoError(t, validateCapacitySchedule(schema.TypeVolcanoCapabilityQuota, gpuResource(t, "8"), schedule))
+}
+
+func TestApplyCapacitySchedules(t *testing.T) {
+	driver.InitMockDB()
+	cluster := model.ClusterInfo{Model: model.Model{ID: "cluster-capacity"}, Name: "cluster-capacity",
+		ClusterType: schema.KubernetesType, Status: model.ClusterStatusOnLine}
+	assert.NoError(t, storage.Cluster.CreateCluster(&cluster))
+	queue := model.Queue{
+		Model:        model.Model{ID: "queue-capacity"},
+		Name:         "queue-capacity",
+		ClusterId:    cluster.ID,
+		QuotaType:    schema.TypeVolcanoCapabilityQuota,
+		Status:       schema.StatusQueueOpen,
+		MaxResources: gpuResource(t, "16"),
+		MinResources: resources.EmptyResource(),
+		CapacitySchedule: &model.CapacitySchedule{Timezone: "UTC", Windows: []model.CapacityWindow{
+			{Name: "night", Start: "22:00", End: "08:00", MaxResources: gpuResource(t, "64")},
+		}},
+	}
+	assert.NoError(t, storage.Queue.CreateQueue(&queue))
+
+	var updated []*api.QueueInfo
+	updateClusterQueue = func(clusterInfo model.ClusterInfo, queueInfo *api.QueueInfo) error {
+		updated = append(updated, queueInfo)
+		return nil
+	}
+	appliedWindows := make(map[string]int)
+	day := time.Date(2022, 8, 1, 10, 0, 0, 0, time.UTC)
+	applyCapacitySchedules(appliedWindows, day)
+	assert.Equal(t, 1, len(updated))
+	// 时间段未切换时不更新
+	applyCapacitySchedules(appliedWindows, day.Add(time.Hour))
+	assert.Equal(t, 1, len(updated))
+	applyCapacitySchedules(appliedWindows, day.Add(13*time.Hour))
+	assert.Equal(t, 2, len(updated))
+	assert.Equal(t, 0, appliedWindows[queue.ID])
+
+	// 集群同步回来的配额不覆盖队列的基础容量
+	assert.NoError(t, storage.Queue.UpdateQueueInfo(queue.Name, "", gpuResource(t, "64"), nil))
+	q, err := storage.Queue.GetQueueByName(queue.Name)
+	assert.NoError(t, err)
+	assert.Equal(t, gpuResource(t, "16"), q.MaxResources)
+}
@@ -20,6 +20,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 	"gorm.io/gorm"
@@ -27,6 +28,7 @@ import (
 	"volcano.sh/apis/pkg/apis/scheduling/v1beta1"
 
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/controller/project"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/config"
 	gormErrors "github.com/PaddlePaddle/PaddleFlow/pkg/common/errors"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
@@ -52,7 +54,15 @@ type CreateQueueRequest struct {
 	Location     map[string]string   `json:"location"`
 	// 任务调度策略
 	SchedulingPolicy []string `json:"schedulingPolicy,omitempty"`
-	Status           string   `json:"-"`
+	// 等待中作业的优先级提升策略
+	PriorityAging *model.PriorityAging `json:"priorityAging,omitempty"`
+	// 集群资源不足时等待中作业的溢出策略
+	BurstPolicy *model.BurstPolicy `json:"burstPolicy,omitempty"`
+	// 队列绑定的节点池
+	NodePool string `json:"nodePool,omitempty"`
+	// 按时间段调整的队列容量
+	CapacitySchedule *model.CapacitySchedule `json:"capacitySchedule,omitempty"`
+	Status           string                  `json:"-"`
 }
 
 type UpdateQueueRequest struct {
@@ -65,7 +75,15 @@ type UpdateQueueRequest struct {
 	Location     map[string]string   `json:"location,omitempty"`
 	// 任务调度策略
 	SchedulingPolicy []string `json:"schedulingPolicy,omitempty"`
-	Status           string   `json:"-"`
+	// 等待中作业的优先级提升策略
+	PriorityAging *model.PriorityAging `json:"priorityAging,omitempty"`
+	// 集群资源不足时等待中作业的溢出策略
+	BurstPolicy *model.BurstPolicy `json:"burstPolicy,omitempty"`
+	// 队列绑定的节点池，为空字符串时解除绑定
+	NodePool *string `json:"nodePool,omitempty"`
+	// 按时间段调整的队列容量，windows为空时删除
+	CapacitySchedule *model.CapacitySchedule `json:"capacitySchedule,omitempty"`
+	Status           string                  `json:"-"`
 }
 
 type CreateQueueResponse struct {
@@ -91,7 +109,7 @@ type ListQueueResponse struct {
 	QueueList []model.Queue `json:"queueList"`
 }
 
-func ListQueue(ctx *logger.RequestContext, marker string, maxKeys int, name string) (ListQueueResponse, error) {
+func ListQueue(ctx *logger.RequestContext, marker string, maxKeys int, name, projectName string) (ListQueueResponse, error) {
 	ctx.Logging().Debugf("begin list queue.")
 	listQueueResponse := ListQueueResponse{}
 	listQueueResponse.IsTruncated = false
@@ -109,7 +127,13 @@ func ListQueue(ctx *logger.RequestContext, marker string, maxKeys int, name stri
 		}
 	}
 
-	queueList, err := storage.Queue.ListQueue(pk, maxKeys, name, ctx.UserName)
+	if projectName != "" {
+		if err = project.CheckProjectMember(ctx, projectName); err != nil {
+			ctx.ErrorMessage = err.Error()
+			return listQueueResponse, err
+		}
+	}
+	queueList, err := storage.Queue.ListQueue(pk, maxKeys, name, ctx.UserName, projectName)
 	if err != nil {
 		ctx.Logging().Errorf("models list queue failed. err:[%s]", err.Error())
 		ctx.ErrorCode = common.InternalError
@@ -222,6 +246,22 @@ func CreateQueue(ctx *logger.RequestContext, request *CreateQueueRequest) (Creat
 		return CreateQueueResponse{}, errors.New("request name duplicated")
 	}
 
+	if err := validatePriorityAging(request.PriorityAging); err != nil {
+		ctx.Logging().Errorf("create queue failed. error: %s", err.Error())
+		ctx.ErrorCode = common.InvalidArguments
+		return CreateQueueResponse{}, err
+	}
+	if err := validateBurstPolicy(request.Name, clusterInfo.ID, request.BurstPolicy); err != nil {
+		ctx.Logging().Errorf("create queue failed. error: %s", err.Error())
+		ctx.ErrorCode = common.InvalidArguments
+		return CreateQueueResponse{}, err
+	}
+	if err := validateNodePool(clusterInfo.ID, request.NodePool); err != nil {
+		ctx.Logging().Errorf("create queue failed. error: %s", err.Error())
+		ctx.ErrorCode = common.InvalidArguments
+		return CreateQueueResponse{}, err
+	}
+
 	// check quota type of queue
 	if len(request.QuotaType) == 0 {
 		// TODO: get quota type from cluster info
@@ -267,6 +307,14 @@ func CreateQueue(ctx *logger.RequestContext, request *CreateQueueRequest) (Creat
 			return CreateQueueResponse{}, fmt.Errorf("maxResources less than minResources")
 		}
 	}
+	if err = validateCapacitySchedule(request.QuotaType, minResources, request.CapacitySchedule); err != nil {
+		ctx.Logging().Errorf("create queue failed. error: %s", err.Error())
+		ctx.ErrorCode = common.InvalidArguments
+		return CreateQueueResponse{}, err
+	}
+	if request.CapacitySchedule != nil && len(request.CapacitySchedule.Windows) == 0 {
+		request.CapacitySchedule = nil
+	}
 
 	if request.Location == nil {
 		request.Location = make(map[string]string)
@@ -302,6 +350,10 @@ func CreateQueue(ctx *logger.RequestContext, request *CreateQueueRequest) (Creat
 		MinResources:     minResources,
 		Location:         request.Location,
 		SchedulingPolicy: request.SchedulingPolicy,
+		PriorityAging:    request.PriorityAging,
+		BurstPolicy:      request.BurstPolicy,
+		NodePool:         request.NodePool,
+		CapacitySchedule: request.CapacitySchedule,
 		Status:           schema.StatusQueueCreating,
 	}
 	err = storage.Queue.CreateQueue(&queueInfo)
@@ -421,7 +473,27 @@ func UpdateQueue(ctx *logger.RequestContext, request *UpdateQueueRequest) (Updat
 			return UpdateQueueResponse{}, err
 		}
 	}
+	// capacity schedule changes the quota of queue in cluster, and empty windows removes it
+	if request.CapacitySchedule != nil {
+		if err = validateCapacitySchedule(queueInfo.QuotaType, queueInfo.MinResources, request.CapacitySchedule); err != nil {
+			ctx.Logging().Errorf("update queue failed. error: %s", err.Error())
+			ctx.ErrorCode = common.InvalidArguments
+			return UpdateQueueResponse{}, err
+		}
+		queueInfo.CapacitySchedule = request.CapacitySchedule
+		resourceUpdated = true
+	} else if resourceUpdated {
+		if err = validateCapacitySchedule(queueInfo.QuotaType, queueInfo.MinResources, queueInfo.CapacitySchedule); err != nil {
+			ctx.Logging().Errorf("update queue failed. error: %s", err.Error())
+			ctx.ErrorCode = common.InvalidArguments
+			return UpdateQueueResponse{}, err
+		}
+	}
 	if resourceUpdated {
+		if err = project.CheckQueueQuota(ctx, queueInfo.Name, queueInfo.PeakMaxResources()); err != nil {
+			ctx.Logging().Errorf("update queue failed. error: %s", err.Error())
+			return UpdateQueueResponse{}, err
+		}
 		updateClusterRequired = true
 	}
 
@@ -477,6 +549,35 @@ func UpdateQueue(ctx *logger.RequestContext, request *UpdateQueueRequest) (Updat
 		queueInfo.SchedulingPolicy = sp
 	}
 
+	// priority aging is only used by paddleflow, and interval 0 disables it
+	if request.PriorityAging != nil {
+		if err = validatePriorityAging(request.PriorityAging); err != nil {
+			ctx.Logging().Errorf("update queue failed. error: %s", err.Error())
+			ctx.ErrorCode = common.InvalidArguments
+			return UpdateQueueResponse{}, err
+		}
+		queueInfo.PriorityAging = request.PriorityAging
+	}
+
+	// empty queue of burst policy disables bursting
+	if request.BurstPolicy != nil {
+		if err = validateBurstPolicy(queueInfo.Name, queueInfo.ClusterId, request.BurstPolicy); err != nil {
+			ctx.Logging().Errorf("update queue failed. error: %s", err.Error())
+			ctx.ErrorCode = common.InvalidArguments
+			return UpdateQueueResponse{}, err
+		}
+		queueInfo.BurstPolicy = request.BurstPolicy
+	}
+
+	// node pool is only used by paddleflow to set node selector of jobs
+	if request.NodePool != nil {
+		if err = validateNodePool(queueInfo.ClusterId, *request.NodePool); err != nil {
+			ctx.Logging().Errorf("update queue failed. error: %s", err.Error())
+			ctx.ErrorCode = common.InvalidArguments
+			return UpdateQueueResponse{}, err
+		}
+	}
+
 	// init runtimeSvc if updateCluster is necessary
 	var runtimeSvc runtime.RuntimeService
 	if updateClusterRequired {
@@ -512,6 +613,14 @@ func UpdateQueue(ctx *logger.RequestContext, request *UpdateQueueRequest) (Updat
 			return UpdateQueueResponse{}, err
 		}
 	}
+	if request.NodePool != nil {
+		if err = storage.Queue.UpdateQueueNodePool(queueInfo.Name, *request.NodePool); err != nil {
+			ctx.Logging().Errorf("update queue failed. error:%s", err.Error())
+			ctx.ErrorCode = common.QueueUpdateFailed
+			return UpdateQueueResponse{}, err
+		}
+		queueInfo.NodePool = *request.NodePool
+	}
 
 	ctx.Logging().Debugf("update request success. queueName:%s", queueInfo.Name)
 	response := UpdateQueueResponse{
@@ -520,6 +629,71 @@ func UpdateQueue(ctx *logger.RequestContext, request *UpdateQueueRequest) (Updat
 	return response, nil
 }
 
+func validatePriorityAging(priorityAging *model.PriorityAging) error {
+	if priorityAging == nil {
+		return nil
+	}
+	if priorityAging.Interval < 0 {
+		return fmt.Errorf("interval of priority aging must not be negative")
+	}
+	priorityAging.MaxPriority = strings.ToUpper(priorityAging.MaxPriority)
+	if priorityAging.MaxPriority != "" && !model.IsValidJobPriority(priorityAging.MaxPriority) {
+		return fmt.Errorf("max priority %s of priority aging is invalid", priorityAging.MaxPriority)
+	}
+	return nil
+}
+
+// validateBurstPolicy 溢出的目标队列必须存在且属于其他集群
+func validateBurstPolicy(queueName, clusterID string, burstPolicy *model.BurstPolicy) error {
+	if burstPolicy == nil || burstPolicy.Queue == "" {
+		return nil
+	}
+	if burstPolicy.WaitSeconds < 0 {
+		return fmt.Errorf("waitSeconds of burst policy must not be negative")
+	}
+	if burstPolicy.Queue == queueName {
+		return fmt.Errorf("queue %s cannot burst to itself", queueName)
+	}
+	targetQueue, err := storage.Queue.GetQueueByName(burstPolicy.Queue)
+	if err != nil {
+		return fmt.Errorf("target queue %s of burst policy is not found", burstPolicy.Queue)
+	}
+	if targetQueue.ClusterId == clusterID {
+		return fmt.Errorf("target queue %s of burst policy must belong to other cluster", burstPolicy.Queue)
+	}
+	return nil
+}
+
+// validateCapacitySchedule 检查容量时间段，弹性配额队列的时间段未设置minResources时，基础minResources不能超过时间段的maxResources
+func validateCapacitySchedule(quotaType string, minResources *resources.Resource, capacitySchedule *model.CapacitySchedule) error {
+	if capacitySchedule == nil {
+		return nil
+	}
+	if err := capacitySchedule.Validate(); err != nil {
+		return err
+	}
+	if quotaType != schema.TypeElasticQuota || minResources == nil {
+		return nil
+	}
+	for _, window := range capacitySchedule.Windows {
+		if window.MinResources == nil && !minResources.LessEqual(window.MaxResources) {
+			return fmt.Errorf("minResources of queue is larger than maxResources of capacity window %s", window.Name)
+		}
+	}
+	return nil
+}
+
+// validateNodePool 检查节点池属于队列所在的集群
+func validateNodePool(clusterID, nodePool string) error {
+	if nodePool == "" {
+		return nil
+	}
+	if _, err := storage.NodePool.GetNodePool(clusterID, nodePool); err != nil {
+		return fmt.Errorf("node pool %s is not found in cluster of queue", nodePool)
+	}
+	return nil
+}
+
 func validateQueueResource(rResource schema.ResourceInfo, qResource *resources.Resource) (bool, error) {
 	needUpdate := false
 	if qResource == nil {
@@ -614,7 +788,8 @@ func GetQueueByName(ctx *logger.RequestContext, queueName string) (GetQueueRespo
 			ctx.Logging().Warnf("cannot get queue used quota for cluster type %s", clusterInfo.ClusterType)
 		}
 	}
-	idleResource := queue.MaxResources.Clone()
+	maxResources, _ := queue.EffectiveResources(time.Now())
+	idleResource := maxResources.Clone()
 	idleResource.Sub(usedResource)
 	queue.IdleResources = idleResource
 	queue.UsedResources = usedResource
@@ -672,6 +847,9 @@ func DeleteQueue(ctx *logger.RequestContext, queueName string) error {
 		ctx.Logging().Errorf("delete queue update db failed. queueName:[%s]", queueName)
 		return err
 	}
+	if err = storage.Project.DeleteProjectMemberByResource(nil, common.ResourceTypeQueue, queueName); err != nil {
+		ctx.Logging().Warnf("remove queue[%s] from projects failed. error: %s", queueName, err.Error())
+	}
 
 	ctx.Logging().Debugf("queue is deleting. queueName:%s", queueName)
 	return nil
@@ -131,7 +131,7 @@ func TestListQueue(t *testing.T) {
 
 	ctx := &logger.RequestContext{UserName: MockRootUser}
 
-	if queues, err := ListQueue(ctx, "", 0, MockQueueName); err != nil {
+	if queues, err := ListQueue(ctx, "", 0, MockQueueName, ""); err != nil {
 		t.Error(err)
 	} else {
 		for _, queue := range queues.QueueList {
@@ -173,3 +173,30 @@ func TestMarshalJSONForTime(t *testing.T) {
 	queueStr, err := json.Marshal(queue)
 	t.Logf("json.Marshal(queue)=%+v", string(queueStr))
 }
+
+func TestValidatePriorityAging(t *testing.T) {
+	assert.NoError(t, validatePriorityAging(nil))
+	assert.Error(t, validatePriorityAging(&model.PriorityAging{Interval: -1}))
+	assert.Error(t, validatePriorityAging(&model.PriorityAging{Interval: 600, MaxPriority: "urgent"}))
+
+	priorityAging := &model.PriorityAging{Interval: 600, MaxPriority: "high"}
+	assert.NoError(t, validatePriorityAging(priorityAging))
+	assert.Equal(t, schema.EnvJobHighPriority, priorityAging.MaxPriority)
+}
+
+func TestValidateBurstPolicy(t *testing.T) {
+	driver.InitMockDB()
+	cloudCluster := model.ClusterInfo{Model: model.Model{ID: "cluster-cloud"}, Name: "cloud", Status: model.ClusterStatusOnLine}
+	assert.NoError(t, storage.Cluster.CreateCluster(&cloudCluster))
+	cloudQueue := model.Queue{Model: model.Model{ID: "queue-cloud"}, Name: "cloud-queue", ClusterId: "cluster-cloud"}
+	assert.NoError(t, storage.Queue.CreateQueue(&cloudQueue))
+
+	assert.NoError(t, validateBurstPolicy("q1", "cluster-onprem", nil))
+	// empty queue disables bursting
+	assert.NoError(t, validateBurstPolicy("q1", "cluster-onprem", &model.BurstPolicy{}))
+	assert.Error(t, validateBurstPolicy("q1", "cluster-onprem", &model.BurstPolicy{Queue: "q1"}))
+	assert.Error(t, validateBurstPolicy("q1", "cluster-onprem", &model.BurstPolicy{Queue: "not-exist"}))
+	assert.Error(t, validateBurstPolicy("q1", "cluster-onprem", &model.BurstPolicy{Queue: cloudQueue.Name, WaitSeconds: -1}))
+	assert.Error(t, validateBurstPolicy("q1", "cluster-cloud", &model.BurstPolicy{Queue: cloudQueue.Name}))
+	assert.NoError(t, validateBurstPolicy("q1", "cluster-onprem", &model.BurstPolicy{Queue: cloudQueue.Name, WaitSeconds: 600}))
+}
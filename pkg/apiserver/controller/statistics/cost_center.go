@@ -0,0 +1,138 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statistics
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/config"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+)
+
+const monthFormat = "2006-01"
+
+type CostCenterUsage struct {
+	// CostCenter 为空表示未设置cost-center标签的作业
+	CostCenter string `json:"costCenter"`
+	// ClusterID 作业实际运行的集群，溢出到其他集群的作业单独统计
+	ClusterID string  `json:"clusterID,omitempty"`
+	GPUHours  float64 `json:"gpuHours"`
+	JobCount  int     `json:"jobCount"`
+}
+
+type CostCenterReportResponse struct {
+	Month string            `json:"month"`
+	Items []CostCenterUsage `json:"items"`
+}
+
+// GetCostCenterReport 按cost-center标签和集群汇总作业在指定月份内运行的GPU卡时，仅root用户可用
+func GetCostCenterReport(ctx *logger.RequestContext, month string) (*CostCenterReportResponse, error) {
+	if !common.IsRootUser(ctx.UserName) {
+		ctx.ErrorCode = common.OnlyRootAllowed
+		return nil, fmt.Errorf("only root user can get cost center report")
+	}
+	now := time.Now()
+	if month == "" {
+		month = now.Format(monthFormat)
+	}
+	start, err := time.ParseInLocation(monthFormat, month, time.Local)
+	if err != nil {
+		ctx.ErrorCode = common.InvalidURI
+		return nil, fmt.Errorf("month[%s] is invalid, must be like %s", month, monthFormat)
+	}
+	end := start.AddDate(0, 1, 0)
+	jobs, err := storage.Job.ListJobActivatedBetween(start, end)
+	if err != nil {
+		ctx.ErrorCode = common.InternalError
+		ctx.Logging().Errorf("list jobs of month[%s] failed, error: %v", month, err)
+		return nil, err
+	}
+	return &CostCenterReportResponse{
+		Month: month,
+		Items: aggregateCostCenterUsage(jobs, start, end, now),
+	}, nil
+}
+
+// aggregateCostCenterUsage GPU卡时为作业申请的GPU数乘以作业在[start, end)内的运行时长
+func aggregateCostCenterUsage(jobs []model.Job, start, end, now time.Time) []CostCenterUsage {
+	label := config.DefaultCostCenterLabel
+	if config.GlobalServerConfig != nil {
+		label = config.GlobalServerConfig.Job.Policy.GetCostCenterLabel()
+	}
+	usages := make(map[string]*CostCenterUsage)
+	for i := range jobs {
+		job := &jobs[i]
+		gpus := model.JobGPUs(job)
+		if gpus == 0 {
+			continue
+		}
+		duration := jobRunningDuration(job, start, end, now)
+		if duration <= 0 {
+			continue
+		}
+		var costCenter, clusterID string
+		if job.Config != nil {
+			costCenter = job.Config.Labels[label]
+			clusterID = job.Config.GetClusterID()
+		}
+		key := costCenter + "/" + clusterID
+		usage, ok := usages[key]
+		if !ok {
+			usage = &CostCenterUsage{CostCenter: costCenter, ClusterID: clusterID}
+			usages[key] = usage
+		}
+		usage.GPUHours += float64(gpus) * duration.Hours()
+		usage.JobCount++
+	}
+	items := make([]CostCenterUsage, 0, len(usages))
+	for _, usage := range usages {
+		usage.GPUHours = math.Round(usage.GPUHours*100) / 100
+		items = append(items, *usage)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].GPUHours > items[j].GPUHours
+	})
+	return items
+}
+
+// jobRunningDuration 作业在[start, end)内的运行时长，未结束的作业按运行到now计算
+func jobRunningDuration(job *model.Job, start, end, now time.Time) time.Duration {
+	if !job.ActivatedAt.Valid {
+		return 0
+	}
+	jobStart, jobEnd := job.ActivatedAt.Time, now
+	if schema.IsImmutableJobStatus(job.Status) {
+		jobEnd = job.UpdatedAt
+	}
+	if jobStart.Before(start) {
+		jobStart = start
+	}
+	if jobEnd.After(end) {
+		jobEnd = end
+	}
+	if !jobEnd.After(jobStart) {
+		return 0
+	}
+	return jobEnd.Sub(jobStart)
+}
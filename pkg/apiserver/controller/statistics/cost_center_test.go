@@ -0,0 +1,94 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statistics
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/config"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage/driver"
+)
+
+func TestAggregateCostCenterUsage(t *testing.T) {
+	config.GlobalServerConfig = &config.ServerConfig{}
+	start := time.Date(2022, 10, 1, 0, 0, 0, 0, time.Local)
+	end := start.AddDate(0, 1, 0)
+	now := start.Add(20 * 24 * time.Hour)
+	newJob := func(id, costCenter, gpus string, status schema.JobStatus, activated, updated time.Time) model.Job {
+		conf := &schema.Conf{Labels: map[string]string{}}
+		if costCenter != "" {
+			conf.Labels[config.DefaultCostCenterLabel] = costCenter
+		}
+		conf.Flavour = schema.Flavour{ResourceInfo: schema.ResourceInfo{
+			CPU: "4", Mem: "8Gi", ScalarResources: schema.ScalarResourcesType{"nvidia.com/gpu": gpus}}}
+		return model.Job{
+			ID:          id,
+			Status:      status,
+			Config:      conf,
+			ActivatedAt: sql.NullTime{Time: activated, Valid: true},
+			UpdatedAt:   updated,
+		}
+	}
+	jobs := []model.Job{
+		// 2 gpus for 10 hours
+		newJob("job-1", "nlp", "2", schema.StatusJobSucceeded, start.Add(time.Hour), start.Add(11*time.Hour)),
+		// started in last month, only 5 hours in this month are counted
+		newJob("job-2", "nlp", "1", schema.StatusJobFailed, start.Add(-5*time.Hour), start.Add(5*time.Hour)),
+		// still running, counted until now
+		newJob("job-3", "cv", "1", schema.StatusJobRunning, now.Add(-30*time.Hour), now.Add(-29*time.Hour)),
+		newJob("job-4", "", "4", schema.StatusJobSucceeded, start.Add(time.Hour), start.Add(2*time.Hour)),
+		// cpu job is ignored
+		newJob("job-5", "cv", "", schema.StatusJobSucceeded, start.Add(time.Hour), start.Add(2*time.Hour)),
+		// job burst to cloud cluster is counted separately
+		newJob("job-6", "nlp", "1", schema.StatusJobSucceeded, start.Add(time.Hour), start.Add(4*time.Hour)),
+	}
+	jobs[5].Config.SetClusterID("cluster-cloud")
+
+	items := aggregateCostCenterUsage(jobs, start, end, now)
+	assert.Equal(t, []CostCenterUsage{
+		{CostCenter: "cv", GPUHours: 30, JobCount: 1},
+		{CostCenter: "nlp", GPUHours: 25, JobCount: 2},
+		{CostCenter: "", GPUHours: 4, JobCount: 1},
+		{CostCenter: "nlp", ClusterID: "cluster-cloud", GPUHours: 3, JobCount: 1},
+	}, items)
+}
+
+func TestGetCostCenterReport(t *testing.T) {
+	driver.InitMockDB()
+	config.GlobalServerConfig = &config.ServerConfig{}
+	ctx := &logger.RequestContext{UserName: "user1"}
+	_, err := GetCostCenterReport(ctx, "")
+	assert.Error(t, err)
+	assert.Equal(t, common.OnlyRootAllowed, ctx.ErrorCode)
+
+	ctx = &logger.RequestContext{UserName: "root"}
+	_, err = GetCostCenterReport(ctx, "2022/10")
+	assert.Error(t, err)
+
+	response, err := GetCostCenterReport(&logger.RequestContext{UserName: "root"}, "2022-10")
+	assert.NoError(t, err)
+	assert.Equal(t, "2022-10", response.Month)
+	assert.Empty(t, response.Items)
+}
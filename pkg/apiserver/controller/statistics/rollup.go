@@ -0,0 +1,223 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statistics
+
+import (
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/resources"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+)
+
+const (
+	statRollupInterval = 10 * time.Minute
+	// 首次汇总或长时间停服后，最多补齐最近7天的数据
+	statBackfill  = 7 * 24 * time.Hour
+	statRetention = 180 * 24 * time.Hour
+	maxImageLen   = 255
+)
+
+// RollupController 定期把已结束的整点小时内的作业数据汇总到统计表
+func RollupController(stopChan chan struct{}) {
+	for {
+		rollupStatistics(time.Now())
+		select {
+		case <-stopChan:
+			log.Info("statistics rollup controller stopped")
+			return
+		case <-time.After(statRollupInterval):
+		}
+	}
+}
+
+func rollupStatistics(now time.Time) {
+	last, err := storage.Statistics.GetLastStatBucket()
+	if err != nil {
+		log.Errorf("get last statistics bucket failed. error: %v", err)
+		return
+	}
+	earliest := now.Truncate(time.Hour).Add(-statBackfill)
+	bucket := last.Add(time.Hour)
+	if last.IsZero() || bucket.Before(earliest) {
+		bucket = earliest
+	}
+	for ; !bucket.Add(time.Hour).After(now); bucket = bucket.Add(time.Hour) {
+		if err := rollupHour(bucket, now); err != nil {
+			log.Errorf("rollup statistics of hour[%s] failed. error: %v", bucket.Format(time.RFC3339), err)
+			return
+		}
+	}
+	if err := storage.Statistics.DeleteHourlyStatsBefore(now.Add(-statRetention)); err != nil {
+		log.Errorf("delete expired statistics failed. error: %v", err)
+	}
+}
+
+// rollupHour 汇总[start, start+1h)内的队列、用户及镜像数据
+func rollupHour(start, now time.Time) error {
+	end := start.Add(time.Hour)
+	queues, err := storage.Queue.ListQueue(0, 0, "", common.UserRoot, "")
+	if err != nil {
+		return err
+	}
+	queuesByID := make(map[string]string, len(queues))
+	queueStats := make(map[string]*model.QueueHourlyStat, len(queues))
+	for _, queue := range queues {
+		queuesByID[queue.ID] = queue.Name
+		queueStats[queue.Name] = &model.QueueHourlyStat{
+			Bucket:       start,
+			QueueName:    queue.Name,
+			CapacityGPUs: float64(resourceGPUs(queue.MaxResources)),
+			CapacityCPU:  float64(queue.MaxResources.CPU()) / 1000,
+		}
+	}
+	queueStat := func(job *model.Job) *model.QueueHourlyStat {
+		name, ok := queuesByID[job.QueueID]
+		if !ok && job.Config != nil {
+			name = job.Config.GetQueueName()
+		}
+		if name == "" {
+			name = job.QueueID
+		}
+		stat, ok := queueStats[name]
+		if !ok {
+			// 队列已删除，仍然保留其历史用量
+			stat = &model.QueueHourlyStat{Bucket: start, QueueName: name}
+			queueStats[name] = stat
+		}
+		return stat
+	}
+
+	running, err := storage.Job.ListJobActivatedBetween(start, end)
+	if err != nil {
+		return err
+	}
+	userStats := make(map[string]*model.UserHourlyStat)
+	for i := range running {
+		job := &running[i]
+		hours := jobRunningDuration(job, start, end, now).Hours()
+		if hours <= 0 {
+			continue
+		}
+		gpuHours := float64(model.JobGPUs(job)) * hours
+		stat := queueStat(job)
+		stat.GPUHours += gpuHours
+		stat.CPUHours += jobCPUs(job) * hours
+		userStat, ok := userStats[job.UserName]
+		if !ok {
+			userStat = &model.UserHourlyStat{Bucket: start, UserName: job.UserName}
+			userStats[job.UserName] = userStat
+		}
+		userStat.GPUHours += gpuHours
+		userStat.JobCount++
+	}
+
+	active, err := storage.Job.ListJobActiveAt(end)
+	if err != nil {
+		return err
+	}
+	for i := range active {
+		job := &active[i]
+		if job.ActivatedAt.Valid && job.ActivatedAt.Time.Before(end) {
+			queueStat(job).RunningJobs++
+		} else {
+			queueStat(job).PendingJobs++
+		}
+	}
+
+	finished, err := storage.Job.ListJobFinishedBetween(start, end)
+	if err != nil {
+		return err
+	}
+	imageStats := make(map[string]*model.ImageHourlyStat)
+	for i := range finished {
+		job := &finished[i]
+		stat := queueStat(job)
+		switch job.Status {
+		case schema.StatusJobSucceeded:
+			stat.SucceededJobs++
+		case schema.StatusJobFailed:
+			stat.FailedJobs++
+		case schema.StatusJobTerminated, schema.StatusJobCancelled:
+			stat.TerminatedJobs++
+		}
+		// 只统计实际运行过的作业
+		if job.Config == nil || job.Config.GetImage() == "" || !job.ActivatedAt.Valid {
+			continue
+		}
+		image := job.Config.GetImage()
+		if len(image) > maxImageLen {
+			image = image[:maxImageLen]
+		}
+		imageStat, ok := imageStats[image]
+		if !ok {
+			imageStat = &model.ImageHourlyStat{Bucket: start, Image: image}
+			imageStats[image] = imageStat
+		}
+		imageStat.FinishedJobs++
+		if job.Status == schema.StatusJobFailed {
+			imageStat.FailedJobs++
+		}
+	}
+
+	queueList := make([]model.QueueHourlyStat, 0, len(queueStats))
+	for _, stat := range queueStats {
+		queueList = append(queueList, *stat)
+	}
+	userList := make([]model.UserHourlyStat, 0, len(userStats))
+	for _, stat := range userStats {
+		userList = append(userList, *stat)
+	}
+	imageList := make([]model.ImageHourlyStat, 0, len(imageStats))
+	for _, stat := range imageStats {
+		imageList = append(imageList, *stat)
+	}
+	return storage.Statistics.SaveHourlyStats(start, queueList, userList, imageList)
+}
+
+// jobCPUs 作业所有成员申请的cpu核数之和
+func jobCPUs(job *model.Job) float64 {
+	members := job.Members
+	if len(members) == 0 && job.Config != nil {
+		members = []schema.Member{{Replicas: 1, Conf: *job.Config}}
+	}
+	var milliCPU int64
+	for _, member := range members {
+		quantity, err := resources.ParseMilliQuantity(member.Flavour.CPU)
+		if err != nil {
+			continue
+		}
+		milliCPU += int64(quantity) * int64(member.Replicas)
+	}
+	return float64(milliCPU) / 1000
+}
+
+// resourceGPUs 名称中包含gpu的扩展资源都计为GPU，与model.JobGPUs保持一致
+func resourceGPUs(r *resources.Resource) int64 {
+	var gpus int64
+	for name, quantity := range r.ScalarResources("") {
+		if strings.Contains(strings.ToLower(name), "gpu") {
+			gpus += int64(quantity)
+		}
+	}
+	return gpus
+}
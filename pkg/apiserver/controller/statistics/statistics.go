@@ -133,7 +133,7 @@ func GetJobDetailStatistics(ctx *logger.RequestContext, jobID string, start, end
 	return response, nil
 }
 
-func getMetricByType(metricType string) (monitor.MetricInterface, error) {
+var getMetricByType = func(metricType string) (monitor.MetricInterface, error) {
 	var metric monitor.MetricInterface
 	switch metricType {
 	case schema.KubernetesType:
@@ -0,0 +1,226 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statistics
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/consts"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+)
+
+const (
+	TopSortByCPU       = "cpu"
+	TopSortByMemory    = "memory"
+	TopSortByGPU       = "gpu"
+	TopSortByGPUMemory = "gpuMemory"
+)
+
+// JobTopRefreshInterval 队列作业实时用量的缓存时间，超过后再次访问时从 prometheus 刷新
+var JobTopRefreshInterval = 15 * time.Second
+
+var topMetricList = [...]string{
+	consts.MetricCpuUsage, consts.MetricMemoryUsage,
+	consts.MetricGpuUtil, consts.MetricGpuMemoryUsage}
+
+type JobTopInfo struct {
+	JobID     string `json:"jobID"`
+	JobName   string `json:"jobName"`
+	UserName  string `json:"userName"`
+	TaskCount int    `json:"taskCount"`
+	// CpuUsage 使用的 cpu 核数
+	CpuUsage float64 `json:"cpuUsage"`
+	// MemoryUsage 使用的内存，单位 Bytes
+	MemoryUsage float64 `json:"memoryUsage"`
+	// GpuUtil 各 task gpu 利用率的平均值
+	GpuUtil float64 `json:"gpuUtil"`
+	// GpuMemoryUsage 使用的显存，单位 Bytes
+	GpuMemoryUsage float64 `json:"gpuMemoryUsage"`
+}
+
+type QueueJobTopResponse struct {
+	QueueName  string       `json:"queueName"`
+	UpdateTime string       `json:"updateTime"`
+	JobList    []JobTopInfo `json:"jobList"`
+}
+
+type queueTopItem struct {
+	updateTime time.Time
+	jobs       []JobTopInfo
+}
+
+type queueTopCache struct {
+	sync.Mutex
+	items map[string]*queueTopItem
+}
+
+var jobTopCache = &queueTopCache{items: make(map[string]*queueTopItem)}
+
+// GetQueueJobTop 返回队列中运行作业的实时 cpu/gpu/内存用量，非 root 用户只能看到自己的作业
+func GetQueueJobTop(ctx *logger.RequestContext, queueName, sortBy string) (*QueueJobTopResponse, error) {
+	switch sortBy {
+	case "":
+		sortBy = TopSortByCPU
+	case TopSortByCPU, TopSortByMemory, TopSortByGPU, TopSortByGPUMemory:
+	default:
+		ctx.ErrorCode = common.InvalidURI
+		return nil, fmt.Errorf("sortBy[%s] is invalid, must be one of %s, %s, %s, %s",
+			sortBy, TopSortByCPU, TopSortByMemory, TopSortByGPU, TopSortByGPUMemory)
+	}
+	if !storage.Auth.HasAccessToResource(ctx, common.ResourceTypeQueue, queueName) {
+		ctx.ErrorCode = common.AccessDenied
+		ctx.Logging().Errorf("get top of queue[%s] failed. error: access denied.", queueName)
+		return nil, common.NoAccessError(ctx.UserName, common.ResourceTypeQueue, queueName)
+	}
+	queue, err := storage.Queue.GetQueueByName(queueName)
+	if err != nil {
+		ctx.ErrorCode = common.QueueNameNotFound
+		ctx.Logging().Errorln(err.Error())
+		return nil, common.NotFoundError(common.ResourceTypeQueue, queueName)
+	}
+
+	item, err := jobTopCache.get(ctx, &queue)
+	if err != nil {
+		return nil, err
+	}
+	response := &QueueJobTopResponse{
+		QueueName:  queueName,
+		UpdateTime: item.updateTime.Format(model.TimeFormat),
+		JobList:    make([]JobTopInfo, 0, len(item.jobs)),
+	}
+	for _, job := range item.jobs {
+		if common.IsRootUser(ctx.UserName) || ctx.UserName == job.UserName {
+			response.JobList = append(response.JobList, job)
+		}
+	}
+	sortJobTop(response.JobList, sortBy)
+	return response, nil
+}
+
+func (c *queueTopCache) get(ctx *logger.RequestContext, queue *model.Queue) (*queueTopItem, error) {
+	c.Lock()
+	defer c.Unlock()
+	if item, ok := c.items[queue.ID]; ok && time.Since(item.updateTime) < JobTopRefreshInterval {
+		return item, nil
+	}
+	item, err := refreshQueueTop(ctx, queue)
+	if err != nil {
+		return nil, err
+	}
+	c.items[queue.ID] = item
+	return item, nil
+}
+
+func refreshQueueTop(ctx *logger.RequestContext, queue *model.Queue) (*queueTopItem, error) {
+	cluster, err := storage.Cluster.GetClusterById(queue.ClusterId)
+	if err != nil {
+		ctx.ErrorCode = common.ClusterNotFound
+		ctx.Logging().Errorln(err.Error())
+		return nil, common.NotFoundError(common.ResourceTypeCluster, queue.ClusterId)
+	}
+	metric, err := getMetricByType(cluster.ClusterType)
+	if err != nil {
+		ctx.ErrorCode = common.InternalError
+		ctx.Logging().Errorf("get metric by type[%s] failed, error: %s", cluster.ClusterType, err.Error())
+		return nil, err
+	}
+
+	jobs := storage.Job.ListQueueJob(queue.ID, []schema.JobStatus{schema.StatusJobRunning})
+	item := &queueTopItem{
+		updateTime: time.Now(),
+		jobs:       make([]JobTopInfo, len(jobs)),
+	}
+	// pod 名到作业下标的映射
+	podIndex := make(map[string]int)
+	podNames := make([]string, 0)
+	for i, job := range jobs {
+		item.jobs[i] = JobTopInfo{
+			JobID:    job.ID,
+			JobName:  job.Name,
+			UserName: job.UserName,
+		}
+		tasks, err := storage.Job.ListByJobID(job.ID)
+		if err != nil {
+			ctx.ErrorCode = common.InternalError
+			ctx.Logging().Errorf("list tasks of job[%s] failed, error: %s", job.ID, err.Error())
+			return nil, err
+		}
+		for _, task := range tasks {
+			podIndex[task.Name] = i
+			podNames = append(podNames, task.Name)
+		}
+		item.jobs[i].TaskCount = len(tasks)
+	}
+
+	gpuTaskCount := make([]int, len(jobs))
+	for _, metricName := range topMetricList {
+		values, err := metric.GetTasksInstantMetrics(metricName, podNames)
+		if err != nil {
+			ctx.ErrorCode = common.InternalError
+			ctx.Logging().Errorf("query instant metric[%s] failed, error: %s", metricName, err.Error())
+			return nil, err
+		}
+		for podName, value := range values {
+			i, ok := podIndex[podName]
+			if !ok {
+				continue
+			}
+			switch metricName {
+			case consts.MetricCpuUsage:
+				item.jobs[i].CpuUsage += value
+			case consts.MetricMemoryUsage:
+				item.jobs[i].MemoryUsage += value
+			case consts.MetricGpuUtil:
+				item.jobs[i].GpuUtil += value
+				gpuTaskCount[i]++
+			case consts.MetricGpuMemoryUsage:
+				item.jobs[i].GpuMemoryUsage += value
+			}
+		}
+	}
+	for i := range item.jobs {
+		if gpuTaskCount[i] > 0 {
+			item.jobs[i].GpuUtil /= float64(gpuTaskCount[i])
+		}
+	}
+	return item, nil
+}
+
+func sortJobTop(jobs []JobTopInfo, sortBy string) {
+	key := func(job JobTopInfo) float64 {
+		switch sortBy {
+		case TopSortByMemory:
+			return job.MemoryUsage
+		case TopSortByGPU:
+			return job.GpuUtil
+		case TopSortByGPUMemory:
+			return job.GpuMemoryUsage
+		default:
+			return job.CpuUsage
+		}
+	}
+	sort.SliceStable(jobs, func(i, j int) bool {
+		return key(jobs[i]) > key(jobs[j])
+	})
+}
@@ -0,0 +1,139 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statistics
+
+import (
+	"testing"
+	"time"
+
+	prometheusModel "github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/consts"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/monitor"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage/driver"
+)
+
+type fakeMetric struct {
+	values  map[string]map[string]float64
+	queries int
+}
+
+func (f *fakeMetric) GetJobAvgMetrics(metricName, jobID string) (float64, error) {
+	return 0, nil
+}
+
+func (f *fakeMetric) GetJobSequenceMetrics(metricName, jobID string, start, end, step int64) (prometheusModel.Value, error) {
+	return prometheusModel.Matrix{}, nil
+}
+
+func (f *fakeMetric) GetTasksInstantMetrics(metricName string, podNames []string) (map[string]float64, error) {
+	f.queries++
+	return f.values[metricName], nil
+}
+
+func TestGetQueueJobTop(t *testing.T) {
+	driver.InitMockDB()
+	jobTopCache = &queueTopCache{items: make(map[string]*queueTopItem)}
+	metric := &fakeMetric{
+		values: map[string]map[string]float64{
+			consts.MetricCpuUsage:       {"job-1-worker-0": 1.5, "job-1-worker-1": 0.5, "job-2-worker-0": 4},
+			consts.MetricMemoryUsage:    {"job-1-worker-0": 1024, "job-2-worker-0": 512},
+			consts.MetricGpuUtil:        {"job-1-worker-0": 0.8, "job-1-worker-1": 0.4},
+			consts.MetricGpuMemoryUsage: {"job-1-worker-0": 2048, "job-1-worker-1": 2048},
+		},
+	}
+	origin := getMetricByType
+	getMetricByType = func(metricType string) (monitor.MetricInterface, error) {
+		return metric, nil
+	}
+	defer func() {
+		getMetricByType = origin
+	}()
+
+	cluster := model.ClusterInfo{
+		Model:       model.Model{ID: "cluster-000001"},
+		Name:        "cluster-000001",
+		ClusterType: schema.KubernetesType,
+	}
+	assert.NoError(t, storage.Cluster.CreateCluster(&cluster))
+	queue := model.Queue{
+		Model:     model.Model{ID: "queue-000001"},
+		Name:      "queue-000001",
+		Namespace: "paddleflow",
+		ClusterId: cluster.ID,
+	}
+	assert.NoError(t, storage.Queue.CreateQueue(&queue))
+	for _, job := range []model.Job{
+		{ID: "job-1", UserName: "user1", Status: schema.StatusJobRunning},
+		{ID: "job-2", UserName: "root", Status: schema.StatusJobRunning},
+		{ID: "job-3", UserName: "user1", Status: schema.StatusJobPending},
+	} {
+		job.QueueID = queue.ID
+		job.Config = &schema.Conf{}
+		assert.NoError(t, storage.Job.CreateJob(&job))
+	}
+	for _, name := range []string{"job-1-worker-0", "job-1-worker-1", "job-2-worker-0"} {
+		task := &model.JobTask{ID: name, Name: name, JobID: name[:5]}
+		assert.NoError(t, storage.Job.UpdateTask(task))
+	}
+
+	ctx := &logger.RequestContext{UserName: "root"}
+	_, err := GetQueueJobTop(ctx, queue.Name, "disk")
+	assert.Error(t, err)
+	assert.Equal(t, common.InvalidURI, ctx.ErrorCode)
+
+	ctx = &logger.RequestContext{UserName: "root"}
+	resp, err := GetQueueJobTop(ctx, queue.Name, "")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(resp.JobList))
+	assert.Equal(t, "job-2", resp.JobList[0].JobID)
+	job1 := resp.JobList[1]
+	assert.Equal(t, 2, job1.TaskCount)
+	assert.Equal(t, 2.0, job1.CpuUsage)
+	assert.Equal(t, 1024.0, job1.MemoryUsage)
+	assert.InDelta(t, 0.6, job1.GpuUtil, 1e-9)
+	assert.Equal(t, 4096.0, job1.GpuMemoryUsage)
+
+	// 缓存时间内不再查询监控
+	resp, err = GetQueueJobTop(ctx, queue.Name, TopSortByGPU)
+	assert.NoError(t, err)
+	assert.Equal(t, "job-1", resp.JobList[0].JobID)
+	assert.Equal(t, len(topMetricList), metric.queries)
+
+	// 普通用户只能看到自己的作业
+	ctx = &logger.RequestContext{UserName: "user1"}
+	_, err = GetQueueJobTop(ctx, queue.Name, "")
+	assert.Error(t, err)
+	assert.Equal(t, common.AccessDenied, ctx.ErrorCode)
+	assert.NoError(t, storage.Auth.CreateGrant(ctx, &model.Grant{ID: "grant-1", UserName: "user1",
+		ResourceType: common.ResourceTypeQueue, ResourceID: queue.Name}))
+	JobTopRefreshInterval = 0
+	defer func() {
+		JobTopRefreshInterval = 15 * time.Second
+	}()
+	resp, err = GetQueueJobTop(ctx, queue.Name, TopSortByMemory)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(resp.JobList))
+	assert.Equal(t, "job-1", resp.JobList[0].JobID)
+	assert.Equal(t, 2*len(topMetricList), metric.queries)
+}
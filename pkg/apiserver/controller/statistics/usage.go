@@ -0,0 +1,313 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statistics
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+)
+
+const (
+	IntervalHour = "hour"
+	IntervalDay  = "day"
+
+	defaultTopLimit = 10
+)
+
+// UsageQuery 统计接口的公共查询条件，时间范围为[Start, End)
+type UsageQuery struct {
+	Start     time.Time
+	End       time.Time
+	Interval  string
+	QueueName string
+	Limit     int
+}
+
+type JobStatusPoint struct {
+	Time string `json:"time"`
+	// PendingJobs/RunningJobs 为时间段结束时的作业数
+	PendingJobs    int `json:"pendingJobs"`
+	RunningJobs    int `json:"runningJobs"`
+	SucceededJobs  int `json:"succeededJobs"`
+	FailedJobs     int `json:"failedJobs"`
+	TerminatedJobs int `json:"terminatedJobs"`
+}
+
+type JobStatusTrendResponse struct {
+	QueueName string           `json:"queueName,omitempty"`
+	Interval  string           `json:"interval"`
+	Items     []JobStatusPoint `json:"items"`
+}
+
+type QueueUsagePoint struct {
+	QueueName string  `json:"queueName"`
+	Time      string  `json:"time"`
+	GPUHours  float64 `json:"gpuHours"`
+	CPUHours  float64 `json:"cpuHours"`
+	// GPUUtilization/CPUUtilization 为使用量占队列最大资源的比例，队列未设置该资源时为0
+	GPUUtilization float64 `json:"gpuUtilization"`
+	CPUUtilization float64 `json:"cpuUtilization"`
+}
+
+type QueueUtilizationResponse struct {
+	Interval string            `json:"interval"`
+	Items    []QueueUsagePoint `json:"items"`
+}
+
+type UserGPUHours struct {
+	UserName string  `json:"userName"`
+	GPUHours float64 `json:"gpuHours"`
+	JobCount int     `json:"jobCount"`
+}
+
+type TopUsersResponse struct {
+	Items []UserGPUHours `json:"items"`
+}
+
+type ImageFailureRate struct {
+	Image        string  `json:"image"`
+	FinishedJobs int     `json:"finishedJobs"`
+	FailedJobs   int     `json:"failedJobs"`
+	FailureRate  float64 `json:"failureRate"`
+}
+
+type ImageFailureResponse struct {
+	Items []ImageFailureRate `json:"items"`
+}
+
+// GetJobStatusTrend 返回各时间段的作业状态分布，非root用户必须指定有权限的队列
+func GetJobStatusTrend(ctx *logger.RequestContext, query UsageQuery) (*JobStatusTrendResponse, error) {
+	if err := checkUsageQuery(ctx, &query, true); err != nil {
+		return nil, err
+	}
+	stats, err := listQueueStats(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	// 先按小时合并各队列，排队/运行中的作业数取时间段内最后一个小时的值
+	type hourly struct {
+		bucket time.Time
+		point  JobStatusPoint
+	}
+	hours := make(map[time.Time]*hourly)
+	var buckets []time.Time
+	for _, stat := range stats {
+		h, ok := hours[stat.Bucket]
+		if !ok {
+			h = &hourly{bucket: stat.Bucket}
+			hours[stat.Bucket] = h
+			buckets = append(buckets, stat.Bucket)
+		}
+		h.point.PendingJobs += stat.PendingJobs
+		h.point.RunningJobs += stat.RunningJobs
+		h.point.SucceededJobs += stat.SucceededJobs
+		h.point.FailedJobs += stat.FailedJobs
+		h.point.TerminatedJobs += stat.TerminatedJobs
+	}
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i].Before(buckets[j]) })
+	response := &JobStatusTrendResponse{QueueName: query.QueueName, Interval: query.Interval, Items: []JobStatusPoint{}}
+	for _, bucket := range buckets {
+		h := hours[bucket]
+		label := periodLabel(bucket, query.Interval)
+		n := len(response.Items)
+		if n == 0 || response.Items[n-1].Time != label {
+			response.Items = append(response.Items, JobStatusPoint{Time: label})
+			n++
+		}
+		point := &response.Items[n-1]
+		point.PendingJobs = h.point.PendingJobs
+		point.RunningJobs = h.point.RunningJobs
+		point.SucceededJobs += h.point.SucceededJobs
+		point.FailedJobs += h.point.FailedJobs
+		point.TerminatedJobs += h.point.TerminatedJobs
+	}
+	return response, nil
+}
+
+// GetQueueUtilization 返回各队列每个时间段的资源使用量及利用率，非root用户必须指定有权限的队列
+func GetQueueUtilization(ctx *logger.RequestContext, query UsageQuery) (*QueueUtilizationResponse, error) {
+	if err := checkUsageQuery(ctx, &query, true); err != nil {
+		return nil, err
+	}
+	stats, err := listQueueStats(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	type period struct {
+		point                    QueueUsagePoint
+		gpuCapacity, cpuCapacity float64
+	}
+	periods := make(map[string]*period)
+	var keys []string
+	for _, stat := range stats {
+		label := periodLabel(stat.Bucket, query.Interval)
+		key := stat.QueueName + "/" + label
+		p, ok := periods[key]
+		if !ok {
+			p = &period{point: QueueUsagePoint{QueueName: stat.QueueName, Time: label}}
+			periods[key] = p
+			keys = append(keys, key)
+		}
+		p.point.GPUHours += stat.GPUHours
+		p.point.CPUHours += stat.CPUHours
+		// 每条汇总数据为一小时，容量乘以1小时即为可用的卡时/核时
+		p.gpuCapacity += stat.CapacityGPUs
+		p.cpuCapacity += stat.CapacityCPU
+	}
+	response := &QueueUtilizationResponse{Interval: query.Interval, Items: make([]QueueUsagePoint, 0, len(keys))}
+	for _, key := range keys {
+		p := periods[key]
+		if p.gpuCapacity > 0 {
+			p.point.GPUUtilization = round(p.point.GPUHours / p.gpuCapacity)
+		}
+		if p.cpuCapacity > 0 {
+			p.point.CPUUtilization = round(p.point.CPUHours / p.cpuCapacity)
+		}
+		p.point.GPUHours = round(p.point.GPUHours)
+		p.point.CPUHours = round(p.point.CPUHours)
+		response.Items = append(response.Items, p.point)
+	}
+	sort.SliceStable(response.Items, func(i, j int) bool {
+		if response.Items[i].QueueName != response.Items[j].QueueName {
+			return response.Items[i].QueueName < response.Items[j].QueueName
+		}
+		return response.Items[i].Time < response.Items[j].Time
+	})
+	return response, nil
+}
+
+// GetTopUsers 返回时间范围内GPU卡时最多的用户，仅root用户可用
+func GetTopUsers(ctx *logger.RequestContext, query UsageQuery) (*TopUsersResponse, error) {
+	if err := checkUsageQuery(ctx, &query, false); err != nil {
+		return nil, err
+	}
+	stats, err := storage.Statistics.SumUserGPUHours(query.Start, query.End, query.Limit)
+	if err != nil {
+		ctx.ErrorCode = common.InternalError
+		ctx.Logging().Errorf("sum user gpu hours failed. error: %v", err)
+		return nil, err
+	}
+	response := &TopUsersResponse{Items: make([]UserGPUHours, 0, len(stats))}
+	for _, stat := range stats {
+		response.Items = append(response.Items, UserGPUHours{
+			UserName: stat.UserName,
+			GPUHours: round(stat.GPUHours),
+			JobCount: stat.JobCount,
+		})
+	}
+	return response, nil
+}
+
+// GetImageFailureRate 返回时间范围内失败率最高的镜像，仅root用户可用
+func GetImageFailureRate(ctx *logger.RequestContext, query UsageQuery) (*ImageFailureResponse, error) {
+	if err := checkUsageQuery(ctx, &query, false); err != nil {
+		return nil, err
+	}
+	stats, err := storage.Statistics.SumImageJobs(query.Start, query.End)
+	if err != nil {
+		ctx.ErrorCode = common.InternalError
+		ctx.Logging().Errorf("sum image jobs failed. error: %v", err)
+		return nil, err
+	}
+	items := make([]ImageFailureRate, 0, len(stats))
+	for _, stat := range stats {
+		if stat.FinishedJobs == 0 {
+			continue
+		}
+		items = append(items, ImageFailureRate{
+			Image:        stat.Image,
+			FinishedJobs: stat.FinishedJobs,
+			FailedJobs:   stat.FailedJobs,
+			FailureRate:  round(float64(stat.FailedJobs) / float64(stat.FinishedJobs)),
+		})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].FailureRate != items[j].FailureRate {
+			return items[i].FailureRate > items[j].FailureRate
+		}
+		return items[i].FailedJobs > items[j].FailedJobs
+	})
+	if len(items) > query.Limit {
+		items = items[:query.Limit]
+	}
+	return &ImageFailureResponse{Items: items}, nil
+}
+
+// checkUsageQuery 校验时间范围及权限，queueScoped为false的统计仅root可用
+func checkUsageQuery(ctx *logger.RequestContext, query *UsageQuery, queueScoped bool) error {
+	if !query.End.After(query.Start) {
+		ctx.ErrorCode = common.InvalidURI
+		return common.InvalidStartEndParams()
+	}
+	switch query.Interval {
+	case "":
+		query.Interval = IntervalHour
+	case IntervalHour, IntervalDay:
+	default:
+		ctx.ErrorCode = common.InvalidURI
+		return fmt.Errorf("interval[%s] is invalid, must be %s or %s", query.Interval, IntervalHour, IntervalDay)
+	}
+	if query.Limit <= 0 {
+		query.Limit = defaultTopLimit
+	}
+	if common.IsRootUser(ctx.UserName) {
+		return nil
+	}
+	if !queueScoped {
+		ctx.ErrorCode = common.OnlyRootAllowed
+		return fmt.Errorf("only root user can get statistics of all users")
+	}
+	if query.QueueName == "" || !storage.Auth.HasAccessToResource(ctx, common.ResourceTypeQueue, query.QueueName) {
+		ctx.ErrorCode = common.AccessDenied
+		return common.NoAccessError(ctx.UserName, common.ResourceTypeQueue, query.QueueName)
+	}
+	return nil
+}
+
+func listQueueStats(ctx *logger.RequestContext, query UsageQuery) ([]model.QueueHourlyStat, error) {
+	var queueNames []string
+	if query.QueueName != "" {
+		queueNames = []string{query.QueueName}
+	}
+	stats, err := storage.Statistics.ListQueueHourlyStats(query.Start, query.End, queueNames)
+	if err != nil {
+		ctx.ErrorCode = common.InternalError
+		ctx.Logging().Errorf("list queue statistics failed. error: %v", err)
+		return nil, err
+	}
+	return stats, nil
+}
+
+// periodLabel 返回小时所在时间段的起始时间
+func periodLabel(bucket time.Time, interval string) string {
+	bucket = bucket.Local()
+	if interval == IntervalDay {
+		bucket = time.Date(bucket.Year(), bucket.Month(), bucket.Day(), 0, 0, 0, 0, time.Local)
+	}
+	return bucket.Format(model.TimeFormat)
+}
+
+func round(value float64) float64 {
+	return math.Round(value*100) / 100
+}
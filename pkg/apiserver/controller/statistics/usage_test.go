@@ -0,0 +1,165 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statistics
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/resources"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage/driver"
+)
+
+func TestRollupAndUsage(t *testing.T) {
+	driver.InitMockDB()
+	maxResources, err := resources.NewResourceFromMap(map[string]string{
+		resources.ResCPU: "16", resources.ResMemory: "64Gi", "nvidia.com/gpu": "4"})
+	assert.NoError(t, err)
+	cluster := model.ClusterInfo{
+		Model:       model.Model{ID: "cluster-000001"},
+		Name:        "cluster-000001",
+		ClusterType: schema.KubernetesType,
+	}
+	assert.NoError(t, storage.Cluster.CreateCluster(&cluster))
+	queue := model.Queue{
+		Model:        model.Model{ID: "queue-000001"},
+		Name:         "queue-000001",
+		Namespace:    "paddleflow",
+		ClusterId:    cluster.ID,
+		MaxResources: maxResources,
+	}
+	assert.NoError(t, storage.Queue.CreateQueue(&queue))
+
+	start := time.Date(2022, 10, 1, 8, 0, 0, 0, time.Local)
+	end := start.Add(time.Hour)
+	newJob := func(id, user, image, gpus string, status schema.JobStatus, created, updated time.Time, activated *time.Time) model.Job {
+		job := model.Job{
+			ID:       id,
+			UserName: user,
+			QueueID:  queue.ID,
+			Status:   status,
+			Config: &schema.Conf{Image: image, Flavour: schema.Flavour{ResourceInfo: schema.ResourceInfo{
+				CPU: "4", Mem: "8Gi", ScalarResources: schema.ScalarResourcesType{"nvidia.com/gpu": gpus}}}},
+			CreatedAt: created,
+			UpdatedAt: updated,
+		}
+		if activated != nil {
+			job.ActivatedAt = sql.NullTime{Time: *activated, Valid: true}
+		}
+		return job
+	}
+	at := func(d time.Duration) *time.Time {
+		t := start.Add(d)
+		return &t
+	}
+	for _, job := range []model.Job{
+		// 2 gpus for half an hour
+		newJob("job-1", "user1", "img-a", "2", schema.StatusJobSucceeded,
+			start.Add(-time.Hour), start.Add(30*time.Minute), at(-30*time.Minute)),
+		// 1 gpu for half an hour
+		newJob("job-2", "user2", "img-b", "1", schema.StatusJobFailed,
+			start, start.Add(40*time.Minute), at(10*time.Minute)),
+		// still running during the whole hour
+		newJob("job-3", "user1", "img-a", "1", schema.StatusJobRunning,
+			start.Add(-3*time.Hour), start.Add(-2*time.Hour), at(-2*time.Hour)),
+		newJob("job-4", "user2", "img-a", "1", schema.StatusJobPending,
+			start.Add(5*time.Minute), start.Add(5*time.Minute), nil),
+		// finished before the hour
+		newJob("job-5", "user2", "img-b", "1", schema.StatusJobFailed,
+			start.Add(-3*time.Hour), start.Add(-time.Hour), at(-2*time.Hour)),
+	} {
+		job := job
+		assert.NoError(t, storage.Job.CreateJob(&job))
+	}
+	now := end.Add(10 * time.Minute)
+	assert.NoError(t, rollupHour(start, now))
+	// 重复汇总结果不变
+	assert.NoError(t, rollupHour(start, now))
+	last, err := storage.Statistics.GetLastStatBucket()
+	assert.NoError(t, err)
+	assert.True(t, last.Equal(start))
+
+	root := &logger.RequestContext{UserName: "root"}
+	query := UsageQuery{Start: start, End: end}
+	trend, err := GetJobStatusTrend(root, query)
+	assert.NoError(t, err)
+	assert.Equal(t, IntervalHour, trend.Interval)
+	assert.Equal(t, []JobStatusPoint{{
+		Time:          start.Format(model.TimeFormat),
+		PendingJobs:   1,
+		RunningJobs:   1,
+		SucceededJobs: 1,
+		FailedJobs:    1,
+	}}, trend.Items)
+
+	utilization, err := GetQueueUtilization(root, UsageQuery{Start: start, End: end, Interval: IntervalDay})
+	assert.NoError(t, err)
+	assert.Equal(t, []QueueUsagePoint{{
+		QueueName:      queue.Name,
+		Time:           time.Date(2022, 10, 1, 0, 0, 0, 0, time.Local).Format(model.TimeFormat),
+		GPUHours:       2.5,
+		CPUHours:       8,
+		GPUUtilization: 0.63,
+		CPUUtilization: 0.5,
+	}}, utilization.Items)
+
+	users, err := GetTopUsers(root, query)
+	assert.NoError(t, err)
+	assert.Equal(t, []UserGPUHours{
+		{UserName: "user1", GPUHours: 2, JobCount: 2},
+		{UserName: "user2", GPUHours: 0.5, JobCount: 1},
+	}, users.Items)
+
+	images, err := GetImageFailureRate(root, query)
+	assert.NoError(t, err)
+	assert.Equal(t, []ImageFailureRate{
+		{Image: "img-b", FinishedJobs: 1, FailedJobs: 1, FailureRate: 1},
+		{Image: "img-a", FinishedJobs: 1},
+	}, images.Items)
+
+	// 普通用户只能查看有权限的队列
+	ctx := &logger.RequestContext{UserName: "user1"}
+	_, err = GetTopUsers(ctx, query)
+	assert.Error(t, err)
+	assert.Equal(t, common.OnlyRootAllowed, ctx.ErrorCode)
+	ctx = &logger.RequestContext{UserName: "user1"}
+	_, err = GetJobStatusTrend(ctx, query)
+	assert.Error(t, err)
+	assert.Equal(t, common.AccessDenied, ctx.ErrorCode)
+	assert.NoError(t, storage.Auth.CreateGrant(ctx, &model.Grant{ID: "grant-1", UserName: "user1",
+		ResourceType: common.ResourceTypeQueue, ResourceID: queue.Name}))
+	trend, err = GetJobStatusTrend(ctx, UsageQuery{Start: start, End: end, QueueName: queue.Name})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(trend.Items))
+
+	ctx = &logger.RequestContext{UserName: "root"}
+	_, err = GetJobStatusTrend(ctx, UsageQuery{Start: end, End: start})
+	assert.Error(t, err)
+	assert.Equal(t, common.InvalidURI, ctx.ErrorCode)
+	ctx = &logger.RequestContext{UserName: "root"}
+	_, err = GetQueueUtilization(ctx, UsageQuery{Start: start, End: end, Interval: "week"})
+	assert.Error(t, err)
+	assert.Equal(t, common.InvalidURI, ctx.ErrorCode)
+}
@@ -0,0 +1,97 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package user
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+)
+
+type ListImpersonationAuditResponse struct {
+	common.MarkerInfo
+	Audits []model.ImpersonationAudit `json:"auditList"`
+}
+
+// CheckImpersonation 校验operator能否以userName的身份发起请求
+func CheckImpersonation(ctx *logger.RequestContext, operator, userName, method string) error {
+	if !common.IsRootUser(operator) {
+		ctx.ErrorCode = common.ImpersonationDenied
+		return fmt.Errorf("user[%s] is not allowed to impersonate, root is needed", operator)
+	}
+	if common.IsRootUser(userName) {
+		ctx.ErrorCode = common.ImpersonationDenied
+		return errors.New("impersonating root is meaningless")
+	}
+	if method != http.MethodGet && method != http.MethodHead && !authConfig().AllowImpersonationWrite {
+		ctx.ErrorCode = common.ImpersonationDenied
+		return fmt.Errorf("%s request is not allowed when impersonating, only read requests are allowed", method)
+	}
+	if _, err := storage.Auth.GetUserByName(ctx, userName); err != nil {
+		ctx.ErrorCode = common.UserNotExist
+		return fmt.Errorf("impersonated user[%s] not exist", userName)
+	}
+	return nil
+}
+
+// RecordImpersonation 写入审计记录，写入失败只记录日志，不影响请求本身
+func RecordImpersonation(ctx *logger.RequestContext, audit *model.ImpersonationAudit) {
+	ctx.Logging().Infof("audit: root[%s] impersonated user[%s] %s %s, status %d",
+		audit.Operator, audit.UserName, audit.Method, audit.Path, audit.StatusCode)
+	if err := storage.Auth.CreateImpersonationAudit(ctx, audit); err != nil {
+		ctx.Logging().Errorf("record impersonation audit failed. error:%s", err.Error())
+	}
+}
+
+// ListImpersonationAudit 按时间倒序列出模拟用户请求的审计记录，仅root可以查看
+func ListImpersonationAudit(ctx *logger.RequestContext, marker string, maxKeys int, operator, userName string) (*ListImpersonationAuditResponse, error) {
+	if !common.IsRootUser(ctx.UserName) {
+		ctx.ErrorCode = common.OnlyRootAllowed
+		return nil, errors.New("list impersonation audit failed, root is needed")
+	}
+	var pk int64
+	var err error
+	if marker != "" {
+		pk, err = common.DecryptPk(marker)
+		if err != nil {
+			ctx.Logging().Errorf("DecryptPk marker[%s] failed. err:[%s]", marker, err.Error())
+			ctx.ErrorCode = common.InvalidMarker
+			return nil, err
+		}
+	}
+	audits, err := storage.Auth.ListImpersonationAudit(ctx, pk, maxKeys, operator, userName)
+	if err != nil {
+		ctx.ErrorCode = common.InternalError
+		return nil, err
+	}
+	response := &ListImpersonationAuditResponse{Audits: audits}
+	if maxKeys > 0 && len(audits) == maxKeys {
+		nextMarker, err := common.EncryptPk(audits[len(audits)-1].Pk)
+		if err != nil {
+			ctx.ErrorCode = common.InternalError
+			return nil, err
+		}
+		response.NextMarker = nextMarker
+		response.IsTruncated = true
+	}
+	return response, nil
+}
@@ -0,0 +1,81 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package user
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/config"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+)
+
+func TestCheckImpersonation(t *testing.T) {
+	TestCreateUser(t)
+
+	ctx := &logger.RequestContext{}
+	assert.NotNil(t, CheckImpersonation(ctx, MockUser1, MockRootUser, http.MethodGet))
+	assert.Equal(t, common.ImpersonationDenied, ctx.ErrorCode)
+	ctx = &logger.RequestContext{}
+	assert.NotNil(t, CheckImpersonation(ctx, MockRootUser, "notexist", http.MethodGet))
+	assert.Equal(t, common.UserNotExist, ctx.ErrorCode)
+	assert.Nil(t, CheckImpersonation(&logger.RequestContext{}, MockRootUser, MockUser1, http.MethodGet))
+
+	// 默认只允许只读请求
+	ctx = &logger.RequestContext{}
+	assert.NotNil(t, CheckImpersonation(ctx, MockRootUser, MockUser1, http.MethodPost))
+	assert.Equal(t, common.ImpersonationDenied, ctx.ErrorCode)
+	config.GlobalServerConfig = &config.ServerConfig{Auth: config.AuthConfig{AllowImpersonationWrite: true}}
+	defer func() { config.GlobalServerConfig = nil }()
+	assert.Nil(t, CheckImpersonation(&logger.RequestContext{}, MockRootUser, MockUser1, http.MethodPost))
+}
+
+func TestListImpersonationAudit(t *testing.T) {
+	TestCreateUser(t)
+	ctx := &logger.RequestContext{UserName: MockRootUser}
+	for _, path := range []string{"/api/paddleflow/v1/queue", "/api/paddleflow/v1/job", "/api/paddleflow/v1/fs"} {
+		RecordImpersonation(ctx, &model.ImpersonationAudit{
+			Operator:   MockRootUser,
+			UserName:   MockUser1,
+			Method:     http.MethodGet,
+			Path:       path,
+			StatusCode: http.StatusOK,
+		})
+	}
+
+	_, err := ListImpersonationAudit(&logger.RequestContext{UserName: MockUser1}, "", 10, "", "")
+	assert.NotNil(t, err)
+
+	resp, err := ListImpersonationAudit(ctx, "", 2, "", MockUser1)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(resp.Audits))
+	assert.True(t, resp.IsTruncated)
+	assert.Equal(t, "/api/paddleflow/v1/fs", resp.Audits[0].Path)
+
+	resp, err = ListImpersonationAudit(ctx, resp.NextMarker, 2, MockRootUser, "")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(resp.Audits))
+	assert.Equal(t, "/api/paddleflow/v1/queue", resp.Audits[0].Path)
+
+	resp, err = ListImpersonationAudit(ctx, "", 10, "", "other")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(resp.Audits))
+}
@@ -0,0 +1,246 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package user
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/smtp"
+	"net/url"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/config"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+)
+
+const (
+	defaultPasswordMinLength = 6
+	defaultLockoutDuration   = 15 * time.Minute
+	defaultResetTokenTTL     = 30 * time.Minute
+)
+
+var sendMailFunc = smtp.SendMail
+
+// ForgotPasswordRequest 申请重置密码，重置token会发送到用户的邮箱
+type ForgotPasswordRequest struct {
+	UserName string `json:"username"`
+}
+
+// ResetPasswordRequest 使用邮件中的token或原密码设置新密码，原密码方式用于过期密码的轮换
+type ResetPasswordRequest struct {
+	UserName    string `json:"username"`
+	Token       string `json:"token,omitempty"`
+	OldPassword string `json:"oldPassword,omitempty"`
+	Password    string `json:"password"`
+}
+
+func authConfig() config.AuthConfig {
+	if config.GlobalServerConfig == nil {
+		return config.AuthConfig{}
+	}
+	return config.GlobalServerConfig.Auth
+}
+
+// checkUserLocked 账号处于锁定期时拒绝使用密码登录
+func checkUserLocked(ctx *logger.RequestContext, user *model.User) error {
+	if user.LockedUntil != nil && time.Now().Before(*user.LockedUntil) {
+		ctx.ErrorCode = common.UserLocked
+		ctx.Logging().Errorf("user[%s] is locked until %s", user.Name, user.LockedUntil.Format(time.RFC3339))
+		return errors.New(common.UserLocked)
+	}
+	return nil
+}
+
+// recordLoginResult 记录密码校验结果，连续失败达到上限时锁定账号，返回账号是否被锁定
+func recordLoginResult(ctx *logger.RequestContext, user *model.User, succeeded bool) bool {
+	if succeeded {
+		if user.FailedLogins != 0 || user.LockedUntil != nil {
+			_ = storage.Auth.UpdateUserLoginFailures(ctx, user.Name, 0, nil)
+		}
+		return false
+	}
+	policy := authConfig()
+	if policy.MaxFailedLogins <= 0 {
+		return false
+	}
+	failedLogins := user.FailedLogins + 1
+	var lockedUntil *time.Time
+	if failedLogins >= policy.MaxFailedLogins {
+		lockoutDuration := defaultLockoutDuration
+		if policy.LockoutMinutes > 0 {
+			lockoutDuration = time.Duration(policy.LockoutMinutes) * time.Minute
+		}
+		until := time.Now().Add(lockoutDuration)
+		lockedUntil = &until
+		// 锁定后重新计数，冷却结束后允许再次尝试MaxFailedLogins次
+		failedLogins = 0
+		ctx.Logging().Warnf("user[%s] is locked until %s after %d failed logins",
+			user.Name, until.Format(time.RFC3339), policy.MaxFailedLogins)
+	}
+	if err := storage.Auth.UpdateUserLoginFailures(ctx, user.Name, failedLogins, lockedUntil); err != nil {
+		return false
+	}
+	return lockedUntil != nil
+}
+
+// isPasswordExpired 超过passwordMaxAgeDays未修改密码时需要重置密码，未记录修改时间的用户按创建时间计算
+func isPasswordExpired(user *model.User) bool {
+	maxAgeDays := authConfig().PasswordMaxAgeDays
+	if maxAgeDays <= 0 {
+		return false
+	}
+	updatedAt := user.CreatedAt
+	if user.PasswordUpdatedAt != nil {
+		updatedAt = *user.PasswordUpdatedAt
+	}
+	return time.Since(updatedAt) > time.Duration(maxAgeDays)*24*time.Hour
+}
+
+// ForgotPassword 生成重置密码token并发送到用户邮箱，用户不存在或未设置邮箱时同样返回成功，避免泄露用户信息
+func ForgotPassword(ctx *logger.RequestContext, userName string) error {
+	smtpConf := config.SMTPConfig{}
+	if config.GlobalServerConfig != nil {
+		smtpConf = config.GlobalServerConfig.Notification.SMTP
+	}
+	if smtpConf.Host == "" {
+		ctx.ErrorCode = common.InternalError
+		ctx.Logging().Errorln("forgot password failed. smtp server is not configured")
+		return errors.New("smtp server is not configured")
+	}
+	user, err := storage.Auth.GetUserByName(ctx, userName)
+	if err != nil || user.Email == "" {
+		ctx.Logging().Warnf("skip sending reset password email, user[%s] not exist or has no email", userName)
+		return nil
+	}
+
+	tokenBytes := make([]byte, 32)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		ctx.ErrorCode = common.InternalError
+		ctx.Logging().Errorf("generate reset token failed. error:%s", err.Error())
+		return err
+	}
+	token := hex.EncodeToString(tokenBytes)
+	ttl := defaultResetTokenTTL
+	if minutes := authConfig().ResetTokenTTLMinutes; minutes > 0 {
+		ttl = time.Duration(minutes) * time.Minute
+	}
+	expireAt := time.Now().Add(ttl)
+	if err := storage.Auth.UpdateUserResetToken(ctx, userName, hashResetToken(token), &expireAt); err != nil {
+		ctx.ErrorCode = common.InternalError
+		return err
+	}
+
+	addr := fmt.Sprintf("%s:%d", smtpConf.Host, smtpConf.Port)
+	var auth smtp.Auth
+	if smtpConf.Username != "" {
+		auth = smtp.PlainAuth("", smtpConf.Username, smtpConf.Password, smtpConf.Host)
+	}
+	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\n\r\n%s",
+		smtpConf.From, user.Email, "[PaddleFlow] reset password", resetPasswordText(userName, token, ttl))
+	if err := sendMailFunc(addr, auth, smtpConf.From, []string{user.Email}, []byte(msg)); err != nil {
+		ctx.ErrorCode = common.InternalError
+		ctx.Logging().Errorf("send reset password email to user[%s] failed. error:%s", userName, err.Error())
+		return err
+	}
+	ctx.Logging().Infof("reset password email has been sent to user[%s]", userName)
+	return nil
+}
+
+func resetPasswordText(userName, token string, ttl time.Duration) string {
+	text := fmt.Sprintf("A password reset was requested for PaddleFlow user %s.\n\n", userName)
+	if resetURL := authConfig().ResetURL; resetURL != "" {
+		text += fmt.Sprintf("Open the link below to set a new password:\n%s?username=%s&token=%s\n\n",
+			resetURL, url.QueryEscape(userName), token)
+	} else {
+		text += fmt.Sprintf("Reset token: %s\n\n", token)
+	}
+	text += fmt.Sprintf("The token expires in %d minutes. Ignore this email if you did not request it.\n", int(ttl.Minutes()))
+	return text
+}
+
+func hashResetToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// ResetPassword 校验重置token或原密码后设置新密码，成功后解除锁定并使token失效
+func ResetPassword(ctx *logger.RequestContext, request ResetPasswordRequest) error {
+	ctx.Logging().Debugf("begin reset password. userName:%s", request.UserName)
+	user, err := storage.Auth.GetUserByName(ctx, request.UserName)
+	if request.Token != "" {
+		if err != nil || !checkResetToken(&user, request.Token) {
+			ctx.ErrorCode = common.InvalidResetToken
+			ctx.Logging().Errorf("reset password failed. invalid reset token for user[%s]", request.UserName)
+			return errors.New(common.InvalidResetToken)
+		}
+	} else {
+		if err != nil {
+			ctx.ErrorCode = common.AuthFailed
+			ctx.Logging().Errorf("reset password failed. user[%s] not exist", request.UserName)
+			return errors.New(common.AuthFailed)
+		}
+		if err := checkUserLocked(ctx, &user); err != nil {
+			return err
+		}
+		err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(request.OldPassword))
+		if locked := recordLoginResult(ctx, &user, err == nil); err != nil {
+			ctx.ErrorCode = common.AuthFailed
+			if locked {
+				ctx.ErrorCode = common.UserLocked
+			}
+			ctx.Logging().Errorf("reset password failed. old password of user[%s] mismatched", request.UserName)
+			return errors.New(ctx.ErrorCode)
+		}
+	}
+
+	if err := CheckPasswordLever(request.Password); err != nil {
+		ctx.ErrorCode = common.UserPasswordWeak
+		return err
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(request.Password)) == nil {
+		ctx.ErrorCode = common.UserPasswordWeak
+		return errors.New("new password should be different from the old one")
+	}
+	newPassword, err := EncodePassWord(request.Password)
+	if err != nil {
+		ctx.ErrorCode = common.InternalError
+		return err
+	}
+	if err := storage.Auth.UpdateUser(ctx, request.UserName, newPassword); err != nil {
+		ctx.ErrorCode = common.InternalError
+		return err
+	}
+	revokeSessionsAfterPasswordChange(ctx, request.UserName)
+	ctx.Logging().Infof("password of user[%s] has been reset", request.UserName)
+	return nil
+}
+
+func checkResetToken(user *model.User, token string) bool {
+	if user.ResetTokenHash == "" || user.ResetTokenExpireAt == nil || time.Now().After(*user.ResetTokenExpireAt) {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(hashResetToken(token)), []byte(user.ResetTokenHash)) == 1
+}
@@ -0,0 +1,181 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package user
+
+import (
+	"net/smtp"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/config"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage/driver"
+)
+
+const MockNewPW = "newpw709394"
+
+func TestCheckPasswordLever(t *testing.T) {
+	assert.Nil(t, CheckPasswordLever(MockPW))
+	assert.NotNil(t, CheckPasswordLever("abcdefg"))
+
+	config.GlobalServerConfig = &config.ServerConfig{Auth: config.AuthConfig{
+		PasswordMinLength:      12,
+		PasswordRequireUpper:   true,
+		PasswordRequireSpecial: true,
+	}}
+	defer func() { config.GlobalServerConfig = nil }()
+	assert.NotNil(t, CheckPasswordLever(MockPW))
+	assert.NotNil(t, CheckPasswordLever("mock709394mock"))
+	assert.NotNil(t, CheckPasswordLever("Mock709394mock"))
+	assert.Nil(t, CheckPasswordLever("Mock709394mock!"))
+}
+
+func TestLoginLockout(t *testing.T) {
+	TestCreateUser(t)
+	config.GlobalServerConfig = &config.ServerConfig{Auth: config.AuthConfig{MaxFailedLogins: 3, LockoutMinutes: 10}}
+	defer func() { config.GlobalServerConfig = nil }()
+
+	for i := 0; i < 2; i++ {
+		ctx := &logger.RequestContext{}
+		_, err := Login(ctx, MockUser1, MockWrongPW, false)
+		assert.NotNil(t, err)
+		assert.Equal(t, common.AuthFailed, ctx.ErrorCode)
+	}
+	// 成功登录后重新计数
+	_, err := Login(&logger.RequestContext{}, MockUser1, MockPW, false)
+	assert.Nil(t, err)
+	for i := 0; i < 2; i++ {
+		_, err = Login(&logger.RequestContext{}, MockUser1, MockWrongPW, false)
+		assert.NotNil(t, err)
+	}
+	ctx := &logger.RequestContext{}
+	_, err = Login(ctx, MockUser1, MockWrongPW, false)
+	assert.NotNil(t, err)
+	assert.Equal(t, common.UserLocked, ctx.ErrorCode)
+
+	// 锁定期间正确的密码也无法登录，但已签发的token不受影响
+	ctx = &logger.RequestContext{}
+	_, err = Login(ctx, MockUser1, MockPW, false)
+	assert.NotNil(t, err)
+	assert.Equal(t, common.UserLocked, ctx.ErrorCode)
+	user, err := storage.Auth.GetUserByName(ctx, MockUser1)
+	assert.Nil(t, err)
+	_, err = Login(&logger.RequestContext{}, MockUser1, user.Password, true)
+	assert.Nil(t, err)
+
+	// 冷却结束后可以再次登录
+	past := time.Now().Add(-time.Minute)
+	assert.Nil(t, storage.Auth.UpdateUserLoginFailures(ctx, MockUser1, 0, &past))
+	_, err = Login(&logger.RequestContext{}, MockUser1, MockPW, false)
+	assert.Nil(t, err)
+}
+
+func TestPasswordExpired(t *testing.T) {
+	TestCreateUser(t)
+	config.GlobalServerConfig = &config.ServerConfig{Auth: config.AuthConfig{PasswordMaxAgeDays: 90}}
+	defer func() { config.GlobalServerConfig = nil }()
+
+	_, err := Login(&logger.RequestContext{}, MockUser1, MockPW, false)
+	assert.Nil(t, err)
+
+	storage.DB.Exec("UPDATE user SET password_updated_at = ? WHERE name = ?", time.Now().AddDate(0, 0, -91), MockUser1)
+	ctx := &logger.RequestContext{}
+	_, err = Login(ctx, MockUser1, MockPW, false)
+	assert.NotNil(t, err)
+	assert.Equal(t, common.UserPasswordExpired, ctx.ErrorCode)
+
+	// 使用原密码轮换过期的密码
+	ctx = &logger.RequestContext{}
+	err = ResetPassword(ctx, ResetPasswordRequest{UserName: MockUser1, OldPassword: MockPW, Password: MockPW})
+	assert.NotNil(t, err)
+	assert.Equal(t, common.UserPasswordWeak, ctx.ErrorCode)
+	ctx = &logger.RequestContext{}
+	err = ResetPassword(ctx, ResetPasswordRequest{UserName: MockUser1, OldPassword: MockWrongPW, Password: MockNewPW})
+	assert.NotNil(t, err)
+	assert.Equal(t, common.AuthFailed, ctx.ErrorCode)
+	assert.Nil(t, ResetPassword(&logger.RequestContext{}, ResetPasswordRequest{UserName: MockUser1, OldPassword: MockPW, Password: MockNewPW}))
+	_, err = Login(&logger.RequestContext{}, MockUser1, MockNewPW, false)
+	assert.Nil(t, err)
+}
+
+func TestForgotAndResetPassword(t *testing.T) {
+	driver.InitMockDB()
+	rootCtx := &logger.RequestContext{UserName: MockRootUser}
+	_, err := CreateUser(rootCtx, MockUser1, MockPW, "not-an-email")
+	assert.NotNil(t, err)
+	_, err = CreateUser(rootCtx, MockUser1, MockPW, "u123@example.com")
+	assert.Nil(t, err)
+
+	// 未配置smtp时无法发送邮件
+	ctx := &logger.RequestContext{}
+	assert.NotNil(t, ForgotPassword(ctx, MockUser1))
+
+	config.GlobalServerConfig = &config.ServerConfig{
+		Notification: config.NotificationConfig{SMTP: config.SMTPConfig{Host: "smtp.example.com", Port: 25, From: "pf@example.com"}},
+		Auth:         config.AuthConfig{ResetURL: "https://paddleflow.example.com/reset"},
+	}
+	defer func() { config.GlobalServerConfig = nil }()
+	var sentTo []string
+	var sentMsg string
+	sendMailFunc = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		sentTo = to
+		sentMsg = string(msg)
+		return nil
+	}
+	defer func() { sendMailFunc = smtp.SendMail }()
+
+	// 用户不存在时同样返回成功
+	assert.Nil(t, ForgotPassword(&logger.RequestContext{}, "not-exist"))
+	assert.Nil(t, sentTo)
+
+	assert.Nil(t, ForgotPassword(&logger.RequestContext{}, MockUser1))
+	assert.Equal(t, []string{"u123@example.com"}, sentTo)
+	assert.Contains(t, sentMsg, "https://paddleflow.example.com/reset?username=u123&token=")
+	token := regexp.MustCompile(`token=([0-9a-f]{64})`).FindStringSubmatch(sentMsg)[1]
+
+	ctx = &logger.RequestContext{}
+	err = ResetPassword(ctx, ResetPasswordRequest{UserName: MockUser1, Token: "wrong", Password: MockNewPW})
+	assert.NotNil(t, err)
+	assert.Equal(t, common.InvalidResetToken, ctx.ErrorCode)
+
+	assert.Nil(t, ResetPassword(&logger.RequestContext{}, ResetPasswordRequest{UserName: MockUser1, Token: token, Password: MockNewPW}))
+	_, err = Login(&logger.RequestContext{}, MockUser1, MockNewPW, false)
+	assert.Nil(t, err)
+
+	// token只能使用一次
+	ctx = &logger.RequestContext{}
+	err = ResetPassword(ctx, ResetPasswordRequest{UserName: MockUser1, Token: token, Password: "again709394"})
+	assert.NotNil(t, err)
+	assert.Equal(t, common.InvalidResetToken, ctx.ErrorCode)
+}
+
+func TestUpdateUserEmail(t *testing.T) {
+	TestCreateUser(t)
+	ctx := &logger.RequestContext{UserName: MockUser1}
+	assert.Nil(t, UpdateUserEmail(ctx, MockUser1, "u123@example.com"))
+	assert.NotNil(t, UpdateUserEmail(ctx, MockUser1, "invalid"))
+	assert.NotNil(t, UpdateUserEmail(ctx, MockRootUser, "root@example.com"))
+
+	user, err := GetUserByName(&logger.RequestContext{UserName: MockRootUser}, MockUser1)
+	assert.Nil(t, err)
+	assert.Equal(t, "u123@example.com", user.Email)
+}
@@ -0,0 +1,125 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package user
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+)
+
+// UserPreferenceRequest 覆盖用户的全部默认设置，字段为空表示不设置默认值
+type UserPreferenceRequest struct {
+	Queue      string `json:"queue"`
+	Flavour    string `json:"flavour"`
+	Image      string `json:"image"`
+	FileSystem string `json:"fs"`
+}
+
+// GetUserPreference 返回用户的默认设置，未设置时各字段为空
+func GetUserPreference(ctx *logger.RequestContext, userName string) (*model.UserPreference, error) {
+	if err := checkUserSettingAccess(ctx, userName); err != nil {
+		return nil, err
+	}
+	preference, err := storage.Auth.GetUserPreference(ctx, userName)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &model.UserPreference{UserName: userName}, nil
+		}
+		ctx.ErrorCode = common.ErrorCodeOf(err, common.InternalError)
+		ctx.Logging().Errorf("get preference of user[%s] failed. error:%s", userName, err.Error())
+		return nil, err
+	}
+	return &preference, nil
+}
+
+func UpdateUserPreference(ctx *logger.RequestContext, userName string, request UserPreferenceRequest) (*model.UserPreference, error) {
+	if err := checkUserSettingAccess(ctx, userName); err != nil {
+		return nil, err
+	}
+	if err := validateUserPreference(ctx, userName, request); err != nil {
+		ctx.Logging().Errorf("validate preference of user[%s] failed. error:%s", userName, err.Error())
+		return nil, err
+	}
+	preference := &model.UserPreference{
+		UserName:   userName,
+		Queue:      request.Queue,
+		Flavour:    request.Flavour,
+		Image:      request.Image,
+		FileSystem: request.FileSystem,
+	}
+	if err := storage.Auth.SaveUserPreference(ctx, preference); err != nil {
+		ctx.ErrorCode = common.ErrorCodeOf(err, common.InternalError)
+		return nil, err
+	}
+	return GetUserPreference(ctx, userName)
+}
+
+func DeleteUserPreference(ctx *logger.RequestContext, userName string) error {
+	if err := checkUserSettingAccess(ctx, userName); err != nil {
+		return err
+	}
+	if err := storage.Auth.DeleteUserPreference(ctx, userName); err != nil {
+		ctx.ErrorCode = common.ErrorCodeOf(err, common.InternalError)
+		return err
+	}
+	return nil
+}
+
+// checkUserSettingAccess 用户只能访问自己的默认设置和配额，root可以访问所有用户的
+func checkUserSettingAccess(ctx *logger.RequestContext, userName string) error {
+	if err := common.CheckPermission(ctx.UserName, userName, common.ResourceTypeUser, userName); err != nil {
+		ctx.ErrorCode = common.AccessDenied
+		ctx.Logging().Errorln(err.Error())
+		return err
+	}
+	if _, err := storage.Auth.GetUserByName(ctx, userName); err != nil {
+		ctx.ErrorCode = common.UserNotExist
+		ctx.Logging().Errorf("user[%s] not exist. error:%s", userName, err.Error())
+		return fmt.Errorf("user[%s] not exist", userName)
+	}
+	return nil
+}
+
+// validateUserPreference 默认设置引用的队列、套餐和存储必须存在，存储为用户自己创建的
+func validateUserPreference(ctx *logger.RequestContext, userName string, request UserPreferenceRequest) error {
+	if request.Queue != "" {
+		if _, err := storage.Queue.GetQueueByName(request.Queue); err != nil {
+			ctx.ErrorCode = common.QueueNameNotFound
+			return common.NotFoundError(common.ResourceTypeQueue, request.Queue)
+		}
+	}
+	if request.Flavour != "" {
+		if _, err := storage.Flavour.GetFlavour(request.Flavour); err != nil {
+			ctx.ErrorCode = common.FlavourNotFound
+			return fmt.Errorf("flavour[%s] not found", request.Flavour)
+		}
+	}
+	if request.FileSystem != "" {
+		if _, err := storage.Filesystem.GetFileSystemWithFsID(common.ID(userName, request.FileSystem)); err != nil {
+			ctx.ErrorCode = common.FileSystemNotExist
+			return common.NotFoundError(common.ResourceTypeFs, request.FileSystem)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package user
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+)
+
+// UserQuotaRequest 覆盖用户的全部配额，字段为0表示不限制
+type UserQuotaRequest struct {
+	MaxConcurrentJobs int   `json:"maxConcurrentJobs"`
+	MaxGPUs           int64 `json:"maxGPUs"`
+	// MaxJobDuration 作业最长运行时间，单位秒
+	MaxJobDuration int64 `json:"maxJobDuration"`
+}
+
+// GetUserQuota 返回用户的配额，未设置时各字段为0
+func GetUserQuota(ctx *logger.RequestContext, userName string) (*model.UserQuota, error) {
+	if err := checkUserSettingAccess(ctx, userName); err != nil {
+		return nil, err
+	}
+	quota, err := storage.Auth.GetUserQuota(ctx, userName)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &model.UserQuota{UserName: userName}, nil
+		}
+		ctx.ErrorCode = common.ErrorCodeOf(err, common.InternalError)
+		ctx.Logging().Errorf("get quota of user[%s] failed. error:%s", userName, err.Error())
+		return nil, err
+	}
+	return &quota, nil
+}
+
+// UpdateUserQuota 设置用户配额，仅root可以操作
+func UpdateUserQuota(ctx *logger.RequestContext, userName string, request UserQuotaRequest) (*model.UserQuota, error) {
+	if err := checkQuotaUser(ctx, userName); err != nil {
+		return nil, err
+	}
+	if request.MaxConcurrentJobs < 0 || request.MaxGPUs < 0 || request.MaxJobDuration < 0 {
+		ctx.ErrorCode = common.InvalidArguments
+		err := fmt.Errorf("quota of user[%s] must not be negative", userName)
+		ctx.Logging().Errorln(err.Error())
+		return nil, err
+	}
+	quota := &model.UserQuota{
+		UserName:          userName,
+		MaxConcurrentJobs: request.MaxConcurrentJobs,
+		MaxGPUs:           request.MaxGPUs,
+		MaxJobDuration:    request.MaxJobDuration,
+	}
+	if err := storage.Auth.SaveUserQuota(ctx, quota); err != nil {
+		ctx.ErrorCode = common.ErrorCodeOf(err, common.InternalError)
+		return nil, err
+	}
+	return GetUserQuota(ctx, userName)
+}
+
+// DeleteUserQuota 清除用户配额，仅root可以操作
+func DeleteUserQuota(ctx *logger.RequestContext, userName string) error {
+	if err := checkQuotaUser(ctx, userName); err != nil {
+		return err
+	}
+	if err := storage.Auth.DeleteUserQuota(ctx, userName); err != nil {
+		ctx.ErrorCode = common.ErrorCodeOf(err, common.InternalError)
+		return err
+	}
+	return nil
+}
+
+// checkQuotaUser 用户配额只能由root修改
+func checkQuotaUser(ctx *logger.RequestContext, userName string) error {
+	if !common.IsRootUser(ctx.UserName) {
+		ctx.ErrorCode = common.OnlyRootAllowed
+		err := common.NoAccessError(ctx.UserName, common.ResourceTypeUser, userName)
+		ctx.Logging().Errorln(err.Error())
+		return err
+	}
+	return checkUserSettingAccess(ctx, userName)
+}
@@ -0,0 +1,162 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package user
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/uuid"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+)
+
+const (
+	sessionIDLength = 16
+	// 最近活跃时间按分钟粒度刷新，避免每个请求都写库
+	sessionActiveInterval = time.Minute
+	// 撤销或过期的会话保留一段时间后清理
+	sessionRetention  = 7 * 24 * time.Hour
+	sessionGCInterval = time.Hour
+	maxUserAgentLen   = 256
+)
+
+type ListSessionResponse struct {
+	Sessions []model.UserSession `json:"sessionList"`
+}
+
+type RevokeSessionResponse struct {
+	Revoked int64 `json:"revoked"`
+}
+
+// CreateSession 登录签发token前创建会话，expireAt与token的过期时间一致
+func CreateSession(ctx *logger.RequestContext, userName, clientIP, userAgent string, expireAt *time.Time) (*model.UserSession, error) {
+	if len(userAgent) > maxUserAgentLen {
+		userAgent = userAgent[:maxUserAgentLen]
+	}
+	session := &model.UserSession{
+		ID:           uuid.GenerateIDWithLength(common.PrefixSession, sessionIDLength),
+		UserName:     userName,
+		ClientIP:     clientIP,
+		UserAgent:    userAgent,
+		ExpireAt:     expireAt,
+		LastActiveAt: time.Now(),
+	}
+	if err := storage.Auth.CreateSession(ctx, session); err != nil {
+		ctx.ErrorCode = common.InternalError
+		return nil, err
+	}
+	return session, nil
+}
+
+// CheckSession 校验token对应的会话属于该用户且仍然有效
+func CheckSession(ctx *logger.RequestContext, sessionID, userName string) error {
+	session, err := storage.Auth.GetSession(ctx, sessionID)
+	if err != nil {
+		ctx.ErrorCode = common.AuthInvalidToken
+		ctx.Logging().Errorf("get session[%s] failed. error:%s", sessionID, err.Error())
+		return errors.New(common.AuthInvalidToken)
+	}
+	now := time.Now()
+	if session.UserName != userName || !session.IsActive(now) {
+		ctx.ErrorCode = common.AuthInvalidToken
+		ctx.Logging().Errorf("session[%s] of user[%s] is revoked or expired", sessionID, userName)
+		return errors.New(common.AuthInvalidToken)
+	}
+	if now.Sub(session.LastActiveAt) > sessionActiveInterval {
+		// 刷新失败不影响本次请求
+		_ = storage.Auth.UpdateSessionActiveTime(ctx, sessionID, now)
+	}
+	return nil
+}
+
+// ListSessions 列出用户的有效会话，普通用户只能查看自己的会话
+func ListSessions(ctx *logger.RequestContext, userName string) (*ListSessionResponse, error) {
+	if err := checkUserSettingAccess(ctx, userName); err != nil {
+		return nil, err
+	}
+	sessions, err := storage.Auth.ListActiveSessions(ctx, userName)
+	if err != nil {
+		ctx.ErrorCode = common.InternalError
+		return nil, err
+	}
+	return &ListSessionResponse{Sessions: sessions}, nil
+}
+
+// RevokeSession 撤销用户的单个会话，会话所属用户和root可以操作
+func RevokeSession(ctx *logger.RequestContext, userName, sessionID string) error {
+	if err := checkUserSettingAccess(ctx, userName); err != nil {
+		return err
+	}
+	session, err := storage.Auth.GetSession(ctx, sessionID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.ErrorCode = common.InternalError
+		return err
+	}
+	if err != nil || session.UserName != userName {
+		ctx.ErrorCode = common.SessionNotFound
+		return fmt.Errorf("session[%s] of user[%s] not found", sessionID, userName)
+	}
+	if err := storage.Auth.RevokeSession(ctx, sessionID); err != nil {
+		ctx.ErrorCode = common.InternalError
+		return err
+	}
+	ctx.Logging().Infof("session[%s] of user[%s] revoked by %s", sessionID, session.UserName, ctx.UserName)
+	return nil
+}
+
+// RevokeUserSessions 撤销用户的全部会话，已签发的token立即失效
+func RevokeUserSessions(ctx *logger.RequestContext, userName string) (*RevokeSessionResponse, error) {
+	if err := checkUserSettingAccess(ctx, userName); err != nil {
+		return nil, err
+	}
+	revoked, err := storage.Auth.RevokeUserSessions(ctx, userName, "")
+	if err != nil {
+		ctx.ErrorCode = common.InternalError
+		return nil, err
+	}
+	ctx.Logging().Infof("%d sessions of user[%s] revoked by %s", revoked, userName, ctx.UserName)
+	return &RevokeSessionResponse{Revoked: revoked}, nil
+}
+
+// revokeSessionsAfterPasswordChange 修改密码后旧token均已无法通过校验，同步撤销会话以免仍出现在会话列表中
+func revokeSessionsAfterPasswordChange(ctx *logger.RequestContext, userName string) {
+	if _, err := storage.Auth.RevokeUserSessions(ctx, userName, ""); err != nil {
+		ctx.Logging().Errorf("revoke sessions of user[%s] after password change failed. error:%s", userName, err.Error())
+	}
+}
+
+// SessionGCController 定期清理撤销或过期超过保留时间的会话
+func SessionGCController(stopChan chan struct{}) {
+	for {
+		if err := storage.Auth.DeleteExpiredSessions(&logger.RequestContext{}, time.Now().Add(-sessionRetention)); err != nil {
+			log.Errorf("gc expired sessions failed. error: %v", err)
+		}
+		select {
+		case <-stopChan:
+			log.Info("session gc controller stopped")
+			return
+		case <-time.After(sessionGCInterval):
+		}
+	}
+}
@@ -0,0 +1,91 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package user
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+)
+
+func TestSessionRevoke(t *testing.T) {
+	TestCreateUser(t)
+	userCtx := &logger.RequestContext{UserName: MockUser1}
+	s1, err := CreateSession(userCtx, MockUser1, "127.0.0.1", "paddleflow-cli", nil)
+	assert.Nil(t, err)
+	expired := time.Now().Add(-time.Minute)
+	_, err = CreateSession(userCtx, MockUser1, "", "", &expired)
+	assert.Nil(t, err)
+	s3, err := CreateSession(userCtx, MockUser1, "", "", nil)
+	assert.Nil(t, err)
+
+	assert.Nil(t, CheckSession(userCtx, s1.ID, MockUser1))
+	// 会话不属于该用户时token无效
+	ctx := &logger.RequestContext{}
+	assert.NotNil(t, CheckSession(ctx, s1.ID, MockRootUser))
+	assert.Equal(t, common.AuthInvalidToken, ctx.ErrorCode)
+
+	resp, err := ListSessions(userCtx, MockUser1)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(resp.Sessions))
+
+	// 普通用户不能查看其他用户的会话
+	ctx = &logger.RequestContext{UserName: "other"}
+	_, err = ListSessions(ctx, MockUser1)
+	assert.NotNil(t, err)
+	assert.Equal(t, common.AccessDenied, ctx.ErrorCode)
+
+	ctx = &logger.RequestContext{UserName: MockUser1}
+	assert.NotNil(t, RevokeSession(ctx, MockUser1, "session-notexist"))
+	assert.Equal(t, common.SessionNotFound, ctx.ErrorCode)
+	assert.Nil(t, RevokeSession(userCtx, MockUser1, s1.ID))
+	ctx = &logger.RequestContext{}
+	assert.NotNil(t, CheckSession(ctx, s1.ID, MockUser1))
+	assert.Equal(t, common.AuthInvalidToken, ctx.ErrorCode)
+
+	rootCtx := &logger.RequestContext{UserName: MockRootUser}
+	revoked, err := RevokeUserSessions(rootCtx, MockUser1)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), revoked.Revoked)
+	assert.NotNil(t, CheckSession(&logger.RequestContext{}, s3.ID, MockUser1))
+	resp, err = ListSessions(rootCtx, MockUser1)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(resp.Sessions))
+
+	assert.Nil(t, storage.Auth.DeleteExpiredSessions(rootCtx, time.Now().Add(time.Minute)))
+	_, err = storage.Auth.GetSession(rootCtx, s3.ID)
+	assert.NotNil(t, err)
+}
+
+func TestSessionRevokedAfterPasswordChange(t *testing.T) {
+	TestCreateUser(t)
+	userCtx := &logger.RequestContext{UserName: MockUser1}
+	session, err := CreateSession(userCtx, MockUser1, "", "", nil)
+	assert.Nil(t, err)
+	assert.Nil(t, UpdateUser(userCtx, MockUser1, MockNewPW))
+	assert.NotNil(t, CheckSession(&logger.RequestContext{}, session.ID, MockUser1))
+
+	session, err = CreateSession(userCtx, MockUser1, "", "", nil)
+	assert.Nil(t, err)
+	assert.Nil(t, DeleteUser(&logger.RequestContext{UserName: MockRootUser}, MockUser1))
+	assert.NotNil(t, CheckSession(&logger.RequestContext{}, session.ID, MockUser1))
+}
@@ -19,12 +19,15 @@ package user
 import (
 	"errors"
 	"fmt"
+	"net/mail"
 	"regexp"
 	"strings"
+	"time"
 
 	"golang.org/x/crypto/bcrypt"
 
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/controller/project"
 	gormErrors "github.com/PaddlePaddle/PaddleFlow/pkg/common/errors"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
@@ -35,10 +38,13 @@ import (
 type LoginInfo struct {
 	UserName string `json:"username"`
 	Password string `json:"password"`
+	// Email 用于接收重置密码邮件，仅创建用户时使用
+	Email string `json:"email,omitempty"`
 }
 
 type UpdateUserArgs struct {
 	Password string `json:"password"`
+	Email    string `json:"email,omitempty"`
 }
 
 type CreateUserResponse struct {
@@ -66,23 +72,37 @@ func Login(ctx *logger.RequestContext, userName string, password string, passwor
 			userName, err.Error())
 		return nil, errors.New("verify user failed")
 	}
+	locked := false
 	if passwordEncoded {
 		if user.UserInfo.Password != password {
 			err = ErrMismatchedPassword
 		}
 	} else {
+		// 锁定只限制密码登录，已签发的token不受影响
+		if err := checkUserLocked(ctx, &user); err != nil {
+			return nil, err
+		}
 		err = bcrypt.CompareHashAndPassword([]byte(user.UserInfo.Password), []byte(password))
+		locked = recordLoginResult(ctx, &user, err == nil)
 	}
 	if err != nil {
 		ctx.ErrorCode = common.AuthFailed
+		if locked {
+			ctx.ErrorCode = common.UserLocked
+		}
 		ctx.Logging().Errorf("user verify failed. error:%s",
 			err.Error())
-		return nil, errors.New(common.AuthFailed)
+		return nil, errors.New(ctx.ErrorCode)
+	}
+	if isPasswordExpired(&user) {
+		ctx.ErrorCode = common.UserPasswordExpired
+		ctx.Logging().Errorf("user verify failed. password of user[%s] has expired", userName)
+		return nil, errors.New(common.UserPasswordExpired)
 	}
 	return &user, nil
 }
 
-func CreateUser(ctx *logger.RequestContext, userName, password string) (*CreateUserResponse, error) {
+func CreateUser(ctx *logger.RequestContext, userName, password, email string) (*CreateUserResponse, error) {
 
 	if !schema.CheckReg(userName, common.RegPatternUserName) {
 		ctx.Logging().Errorf("create user failed. username not allowed. userName:%v", userName)
@@ -97,6 +117,11 @@ func CreateUser(ctx *logger.RequestContext, userName, password string) (*CreateU
 		return nil, err
 
 	}
+	if err := checkEmail(email); err != nil {
+		ctx.Logging().Errorf("create user failed. userName:%v, error:%v", userName, err)
+		ctx.ErrorCode = common.InvalidArguments
+		return nil, err
+	}
 	if !common.IsRootUser(ctx.UserName) {
 		ctx.Logging().Errorln("create user failed. root is needed.")
 		ctx.ErrorCode = common.OnlyRootAllowed
@@ -114,8 +139,11 @@ func CreateUser(ctx *logger.RequestContext, userName, password string) (*CreateU
 		Name:     userName,
 		Password: pd,
 	}
+	now := time.Now()
 	user := model.User{
-		UserInfo: userInfo,
+		UserInfo:          userInfo,
+		Email:             email,
+		PasswordUpdatedAt: &now,
 	}
 	if err := storage.Auth.CreateUser(ctx, &user); err != nil {
 		ctx.Logging().Errorln("models create user failed.")
@@ -170,6 +198,42 @@ func UpdateUser(ctx *logger.RequestContext, userName, password string) error {
 		ctx.ErrorCode = common.UserNotExist
 		return err
 	}
+	revokeSessionsAfterPasswordChange(ctx, userName)
+	return nil
+}
+
+// UpdateUserEmail 更新接收重置密码邮件的邮箱，普通用户只能更新自己的邮箱
+func UpdateUserEmail(ctx *logger.RequestContext, userName, email string) error {
+	ctx.Logging().Debugf("begin update user's email. userName:%s", userName)
+	if err := checkEmail(email); err != nil {
+		ctx.ErrorCode = common.InvalidArguments
+		ctx.Logging().Errorf("update user's email failed. error:%v", err)
+		return err
+	}
+	if !common.IsRootUser(ctx.UserName) && !strings.EqualFold(ctx.UserName, userName) {
+		ctx.ErrorCode = common.AccessDenied
+		ctx.Logging().Errorf("update user's email failed. regular user can only update himself. userName:%s", ctx.UserName)
+		return errors.New("update user failed")
+	}
+	if _, err := storage.Auth.GetUserByName(ctx, userName); err != nil {
+		ctx.ErrorCode = common.UserNotExist
+		ctx.Logging().Errorf("update user's email failed. user not exist. userName:%s", userName)
+		return errors.New("update user failed")
+	}
+	if err := storage.Auth.UpdateUserEmail(ctx, userName, email); err != nil {
+		ctx.ErrorCode = common.InternalError
+		return err
+	}
+	return nil
+}
+
+func checkEmail(email string) error {
+	if email == "" {
+		return nil
+	}
+	if _, err := mail.ParseAddress(email); err != nil {
+		return fmt.Errorf("email[%s] is invalid: %v", email, err)
+	}
 	return nil
 }
 
@@ -205,12 +269,33 @@ func DeleteUser(ctx *logger.RequestContext, userName string) error {
 		ctx.Logging().Errorf("models delete user failed. delete user's grant  error:%s", err.Error())
 		return err
 	}
+	if err := storage.Auth.DeleteUserPreference(ctx, userName); err != nil {
+		ctx.ErrorCode = common.InternalError
+		ctx.Logging().Errorf("models delete user failed. delete user's preference error:%s", err.Error())
+		return err
+	}
+	if err := storage.Auth.DeleteUserQuota(ctx, userName); err != nil {
+		ctx.ErrorCode = common.InternalError
+		ctx.Logging().Errorf("models delete user failed. delete user's quota error:%s", err.Error())
+		return err
+	}
+	if _, err := storage.Auth.RevokeUserSessions(ctx, userName, ""); err != nil {
+		ctx.ErrorCode = common.InternalError
+		ctx.Logging().Errorf("models delete user failed. revoke user's sessions error:%s", err.Error())
+		return err
+	}
+	if err := storage.Project.DeleteProjectMemberByResource(nil, common.ResourceTypeUser, userName); err != nil {
+		ctx.ErrorCode = common.InternalError
+		ctx.Logging().Errorf("models delete user failed. remove user from projects error:%s", err.Error())
+		return err
+	}
 	return nil
 }
 
-func ListUser(ctx *logger.RequestContext, marker string, maxKeys int) (*ListUserResponse, error) {
+// ListUser 项目管理员可以按项目列出项目中的用户
+func ListUser(ctx *logger.RequestContext, marker string, maxKeys int, projectName string) (*ListUserResponse, error) {
 	ctx.Logging().Debug("begin list user.")
-	if !common.IsRootUser(ctx.UserName) {
+	if !common.IsRootUser(ctx.UserName) && (projectName == "" || !project.IsProjectAdmin(ctx, projectName)) {
 		ctx.ErrorCode = common.AccessDenied
 		ctx.Logging().Errorln("list user failed. root is needed")
 		return nil, errors.New("list user failed")
@@ -226,7 +311,7 @@ func ListUser(ctx *logger.RequestContext, marker string, maxKeys int) (*ListUser
 			return nil, err
 		}
 	}
-	userList, err := storage.Auth.ListUser(ctx, pk, maxKeys)
+	userList, err := storage.Auth.ListUser(ctx, pk, maxKeys, projectName)
 	if err != nil {
 		ctx.Logging().Errorf("models list user failed. err:[%s]", err.Error())
 		ctx.ErrorCode = common.InternalError
@@ -291,9 +376,15 @@ func GetUserByName(ctx *logger.RequestContext, userName string) (*model.User, er
 	return &user, nil
 }
 
+// CheckPasswordLever 按配置的复杂度策略校验密码，至少包含一个数字和一个小写字母
 func CheckPasswordLever(ps string) error {
-	if len(ps) < 6 {
-		return fmt.Errorf("password len is < 6")
+	policy := authConfig()
+	minLength := defaultPasswordMinLength
+	if policy.PasswordMinLength > 0 {
+		minLength = policy.PasswordMinLength
+	}
+	if len(ps) < minLength {
+		return fmt.Errorf("password len is < %d", minLength)
 	}
 	num := `[0-9]{1}`
 	az := `[a-z]{1}`
@@ -303,5 +394,15 @@ func CheckPasswordLever(ps string) error {
 	if b, err := regexp.MatchString(az, ps); !b || err != nil {
 		return fmt.Errorf("password need a_z :%v", err)
 	}
+	if policy.PasswordRequireUpper {
+		if b, err := regexp.MatchString(`[A-Z]{1}`, ps); !b || err != nil {
+			return fmt.Errorf("password need A_Z :%v", err)
+		}
+	}
+	if policy.PasswordRequireSpecial {
+		if b, err := regexp.MatchString(`[^0-9a-zA-Z]{1}`, ps); !b || err != nil {
+			return fmt.Errorf("password need special character :%v", err)
+		}
+	}
 	return nil
 }
@@ -21,6 +21,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/storage/driver"
 )
@@ -37,10 +38,10 @@ func TestCreateUser(t *testing.T) {
 	ctx := &logger.RequestContext{UserName: MockRootUser}
 
 	// bad case
-	resp, err := CreateUser(ctx, MockUser1, MockWrongPW)
+	resp, err := CreateUser(ctx, MockUser1, MockWrongPW, "")
 	assert.NotNil(t, err)
 
-	resp, err = CreateUser(ctx, MockUser1, MockPW)
+	resp, err = CreateUser(ctx, MockUser1, MockPW, "")
 	assert.Nil(t, err)
 	t.Logf("response=%+v", resp)
 }
@@ -49,7 +50,7 @@ func TestUpdateUser(t *testing.T) {
 	driver.InitMockDB()
 	ctx := &logger.RequestContext{UserName: MockRootUser}
 
-	resp, err := CreateUser(ctx, MockUser1, MockPW)
+	resp, err := CreateUser(ctx, MockUser1, MockPW, "")
 	assert.Nil(t, err)
 	t.Logf("response=%+v", resp)
 }
@@ -58,7 +59,7 @@ func TestListUser(t *testing.T) {
 	TestCreateUser(t)
 	ctx := &logger.RequestContext{UserName: MockRootUser}
 
-	users, err := ListUser(ctx, "", 0)
+	users, err := ListUser(ctx, "", 0, "")
 	assert.Nil(t, err)
 	assert.NotZero(t, len(users.Users))
 	t.Logf("response=%+v", users)
@@ -81,3 +82,78 @@ func TestLogin(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, MockUser1, user.Name)
 }
+
+func TestUserPreference(t *testing.T) {
+	TestCreateUser(t)
+	ctx := &logger.RequestContext{UserName: MockUser1}
+
+	preference, err := GetUserPreference(ctx, MockUser1)
+	assert.Nil(t, err)
+	assert.Equal(t, "", preference.Image)
+
+	_, err = UpdateUserPreference(ctx, MockUser1, UserPreferenceRequest{Queue: "not-exist"})
+	assert.NotNil(t, err)
+	assert.Equal(t, common.QueueNameNotFound, ctx.ErrorCode)
+
+	ctx = &logger.RequestContext{UserName: MockUser1}
+	preference, err = UpdateUserPreference(ctx, MockUser1, UserPreferenceRequest{Image: "paddle:2.3"})
+	assert.Nil(t, err)
+	assert.Equal(t, "paddle:2.3", preference.Image)
+	// 覆盖已有的设置
+	preference, err = UpdateUserPreference(ctx, MockUser1, UserPreferenceRequest{Image: "paddle:2.4"})
+	assert.Nil(t, err)
+	assert.Equal(t, "paddle:2.4", preference.Image)
+
+	// 只有root可以管理其他用户的设置
+	_, err = GetUserPreference(ctx, MockRootUser)
+	assert.NotNil(t, err)
+	assert.Equal(t, common.AccessDenied, ctx.ErrorCode)
+	rootCtx := &logger.RequestContext{UserName: MockRootUser}
+	preference, err = GetUserPreference(rootCtx, MockUser1)
+	assert.Nil(t, err)
+	assert.Equal(t, "paddle:2.4", preference.Image)
+
+	ctx = &logger.RequestContext{UserName: MockUser1}
+	assert.Nil(t, DeleteUserPreference(ctx, MockUser1))
+	preference, err = GetUserPreference(ctx, MockUser1)
+	assert.Nil(t, err)
+	assert.Equal(t, "", preference.Image)
+}
+
+func TestUserQuota(t *testing.T) {
+	TestCreateUser(t)
+	ctx := &logger.RequestContext{UserName: MockUser1}
+
+	quota, err := GetUserQuota(ctx, MockUser1)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, quota.MaxConcurrentJobs)
+
+	// 只有root可以设置配额
+	_, err = UpdateUserQuota(ctx, MockUser1, UserQuotaRequest{MaxConcurrentJobs: 2})
+	assert.NotNil(t, err)
+	assert.Equal(t, common.OnlyRootAllowed, ctx.ErrorCode)
+
+	rootCtx := &logger.RequestContext{UserName: MockRootUser}
+	_, err = UpdateUserQuota(rootCtx, MockUser1, UserQuotaRequest{MaxGPUs: -1})
+	assert.NotNil(t, err)
+	assert.Equal(t, common.InvalidArguments, rootCtx.ErrorCode)
+
+	rootCtx = &logger.RequestContext{UserName: MockRootUser}
+	quota, err = UpdateUserQuota(rootCtx, MockUser1, UserQuotaRequest{MaxConcurrentJobs: 2, MaxGPUs: 8, MaxJobDuration: 3600})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(8), quota.MaxGPUs)
+	quota, err = UpdateUserQuota(rootCtx, MockUser1, UserQuotaRequest{MaxConcurrentJobs: 4})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), quota.MaxGPUs)
+
+	ctx = &logger.RequestContext{UserName: MockUser1}
+	quota, err = GetUserQuota(ctx, MockUser1)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, quota.MaxConcurrentJobs)
+	assert.NotNil(t, DeleteUserQuota(ctx, MockUser1))
+
+	assert.Nil(t, DeleteUserQuota(rootCtx, MockUser1))
+	quota, err = GetUserQuota(ctx, MockUser1)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, quota.MaxConcurrentJobs)
+}
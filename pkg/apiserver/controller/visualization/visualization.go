@@ -0,0 +1,463 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package visualization
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/config"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/uuid"
+	runtime "github.com/PaddlePaddle/PaddleFlow/pkg/job/runtime_v2"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+)
+
+const (
+	tensorBoardPort      = 6006
+	tensorBoardMountPath = "/home/paddleflow/storage/mnt"
+
+	defaultTensorBoardImage = "tensorflow/tensorflow:2.9.1"
+	defaultTTLSeconds       = 2 * 3600
+	defaultCheckInterval    = time.Minute
+
+	labelVisualizationID = "paddleflow-visualization-id"
+)
+
+type CreateVisualizationRequest struct {
+	// JobID 日志所属的作业，TensorBoard与该作业使用相同的队列及存储
+	JobID string `json:"jobID"`
+	// FsName 为空时使用作业的主存储
+	FsName string `json:"fsName"`
+	// LogDir 日志在存储中的路径
+	LogDir     string `json:"logDir"`
+	TTLSeconds int    `json:"ttlSeconds"`
+	Image      string `json:"image"`
+}
+
+type CreateVisualizationResponse struct {
+	ID        string `json:"id"`
+	URL       string `json:"url"`
+	ExpiredAt string `json:"expiredAt"`
+}
+
+type ListVisualizationResponse struct {
+	common.MarkerInfo
+	VisualizationList []model.Visualization `json:"visualizationList"`
+}
+
+// visualizationRuntime 创建可视化服务所需的集群操作
+type visualizationRuntime interface {
+	CreatePV(namespace, fsID string) (string, error)
+	CreatePVC(namespace, fsId, pv string) error
+	CreateDeployment(deploy *appsv1.Deployment) error
+	DeleteDeployment(namespace, name string) error
+	CreateService(svc *corev1.Service) (*corev1.Service, error)
+	DeleteService(namespace, name string) error
+}
+
+var getVisualizationRuntime = func(clusterID string) (visualizationRuntime, error) {
+	cluster, err := storage.Cluster.GetClusterById(clusterID)
+	if err != nil {
+		return nil, err
+	}
+	if cluster.ClusterType != schema.KubernetesType {
+		return nil, fmt.Errorf("visualization is not supported on cluster[%s] with type[%s]", cluster.Name, cluster.ClusterType)
+	}
+	runtimeSvc, err := runtime.GetOrCreateRuntime(cluster)
+	if err != nil {
+		return nil, err
+	}
+	kubeRuntime, ok := runtimeSvc.(*runtime.KubeRuntime)
+	if !ok {
+		return nil, fmt.Errorf("runtime of cluster[%s] is not kubernetes runtime", cluster.Name)
+	}
+	return kubeRuntime, nil
+}
+
+// CreateVisualization 启动挂载作业日志目录的TensorBoard，返回访问地址，服务在TTL到期后自动回收
+func CreateVisualization(ctx *logger.RequestContext, request CreateVisualizationRequest) (CreateVisualizationResponse, error) {
+	ctx.Logging().Debugf("begin create visualization: %+v", request)
+	vis, err := buildVisualization(ctx, request)
+	if err != nil {
+		ctx.Logging().Errorf("create visualization failed. error: %v", err)
+		return CreateVisualizationResponse{}, err
+	}
+
+	rt, err := getVisualizationRuntime(vis.ClusterID)
+	if err != nil {
+		ctx.ErrorCode = common.InternalError
+		ctx.Logging().Errorf("get runtime of cluster[%s] failed. error: %v", vis.ClusterID, err)
+		return CreateVisualizationResponse{}, err
+	}
+	pvName, err := rt.CreatePV(vis.Namespace, vis.FsID)
+	if err == nil {
+		err = rt.CreatePVC(vis.Namespace, vis.FsID, pvName)
+	}
+	if err != nil {
+		ctx.ErrorCode = common.K8sOperatorError
+		ctx.Logging().Errorf("prepare storage of fs[%s] for visualization failed. error: %v", vis.FsID, err)
+		return CreateVisualizationResponse{}, err
+	}
+
+	if err := rt.CreateDeployment(buildTensorBoardDeployment(vis, request.Image)); err != nil {
+		ctx.ErrorCode = common.K8sOperatorError
+		ctx.Logging().Errorf("create tensorboard deployment[%s] failed. error: %v", vis.ID, err)
+		return CreateVisualizationResponse{}, err
+	}
+	svc, err := rt.CreateService(buildTensorBoardService(vis))
+	if err != nil {
+		ctx.ErrorCode = common.K8sOperatorError
+		ctx.Logging().Errorf("create tensorboard service[%s] failed. error: %v", vis.ID, err)
+		if err := rt.DeleteDeployment(vis.Namespace, vis.ID); err != nil {
+			ctx.Logging().Errorf("delete tensorboard deployment[%s] failed. error: %v", vis.ID, err)
+		}
+		return CreateVisualizationResponse{}, err
+	}
+	vis.URL = getAccessURL(svc)
+	vis.Status = model.VisualizationStatusRunning
+
+	if err := storage.Visualization.CreateVisualization(ctx.Logging(), vis); err != nil {
+		ctx.ErrorCode = common.InternalError
+		deleteTensorBoard(rt, vis)
+		return CreateVisualizationResponse{}, err
+	}
+	ctx.Logging().Infof("visualization[%s] created, url: %s", vis.ID, vis.URL)
+	return CreateVisualizationResponse{
+		ID:        vis.ID,
+		URL:       vis.URL,
+		ExpiredAt: vis.ExpiredAt.Format("2006-01-02 15:04:05"),
+	}, nil
+}
+
+func buildVisualization(ctx *logger.RequestContext, request CreateVisualizationRequest) (*model.Visualization, error) {
+	if request.JobID == "" {
+		ctx.ErrorCode = common.RequiredFieldEmpty
+		return nil, fmt.Errorf("jobID is required")
+	}
+	job, err := storage.Job.GetJobByID(request.JobID)
+	if err != nil {
+		ctx.ErrorCode = common.JobNotFound
+		return nil, common.NotFoundError(common.ResourceTypeJob, request.JobID)
+	}
+	if err := common.CheckPermission(ctx.UserName, job.UserName, common.ResourceTypeJob, job.ID); err != nil {
+		ctx.ErrorCode = common.AccessDenied
+		return nil, err
+	}
+	queue, err := storage.Queue.GetQueueByID(job.QueueID)
+	if err != nil {
+		ctx.ErrorCode = common.QueueNameNotFound
+		return nil, fmt.Errorf("get queue[%s] of job[%s] failed, err: %v", job.QueueID, job.ID, err)
+	}
+
+	fsName := request.FsName
+	if fsName == "" {
+		fsName = getJobFsName(job)
+	}
+	if fsName == "" {
+		ctx.ErrorCode = common.InvalidArguments
+		return nil, fmt.Errorf("job[%s] has no filesystem, fsName is required", job.ID)
+	}
+	if strings.Contains(request.LogDir, "..") {
+		ctx.ErrorCode = common.InvalidArguments
+		return nil, fmt.Errorf("logDir[%s] is invalid", request.LogDir)
+	}
+
+	ttl, err := getTTL(request.TTLSeconds)
+	if err != nil {
+		ctx.ErrorCode = common.InvalidArguments
+		return nil, err
+	}
+
+	return &model.Visualization{
+		ID:        uuid.GenerateID(common.PrefixVisualization),
+		Type:      model.VisualizationTypeTensorBoard,
+		UserName:  ctx.UserName,
+		JobID:     job.ID,
+		FsID:      common.ID(job.UserName, fsName),
+		FsName:    fsName,
+		LogDir:    path.Clean("/" + request.LogDir),
+		ClusterID: queue.ClusterId,
+		Namespace: queue.Namespace,
+		ExpiredAt: time.Now().Add(ttl),
+	}, nil
+}
+
+func getJobFsName(job model.Job) string {
+	if job.Config != nil && job.Config.FileSystem.Name != "" {
+		return job.Config.FileSystem.Name
+	}
+	for _, member := range job.Members {
+		if member.Conf.FileSystem.Name != "" {
+			return member.Conf.FileSystem.Name
+		}
+	}
+	return ""
+}
+
+func getTTL(ttlSeconds int) (time.Duration, error) {
+	conf := config.VisualizationConfig{}
+	if config.GlobalServerConfig != nil {
+		conf = config.GlobalServerConfig.Visualization
+	}
+	if ttlSeconds < 0 {
+		return 0, fmt.Errorf("ttlSeconds[%d] should not be negative", ttlSeconds)
+	}
+	if ttlSeconds == 0 {
+		ttlSeconds = conf.DefaultTTLSeconds
+		if ttlSeconds <= 0 {
+			ttlSeconds = defaultTTLSeconds
+		}
+		// 未指定TTL时，默认值不超过最大值
+		if conf.MaxTTLSeconds > 0 && ttlSeconds > conf.MaxTTLSeconds {
+			ttlSeconds = conf.MaxTTLSeconds
+		}
+	}
+	if conf.MaxTTLSeconds > 0 && ttlSeconds > conf.MaxTTLSeconds {
+		return 0, fmt.Errorf("ttlSeconds[%d] exceeds the maximum[%d]", ttlSeconds, conf.MaxTTLSeconds)
+	}
+	return time.Duration(ttlSeconds) * time.Second, nil
+}
+
+func buildTensorBoardDeployment(vis *model.Visualization, image string) *appsv1.Deployment {
+	if image == "" && config.GlobalServerConfig != nil {
+		image = config.GlobalServerConfig.Visualization.TensorBoardImage
+	}
+	if image == "" {
+		image = defaultTensorBoardImage
+	}
+	labels := map[string]string{labelVisualizationID: vis.ID}
+	replicas := int32(1)
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      vis.ID,
+			Namespace: vis.Namespace,
+			Labels:    labels,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:  model.VisualizationTypeTensorBoard,
+							Image: image,
+							Command: []string{
+								"tensorboard",
+								fmt.Sprintf("--logdir=%s", path.Join(tensorBoardMountPath, vis.LogDir)),
+								fmt.Sprintf("--port=%d", tensorBoardPort),
+								"--bind_all",
+							},
+							Ports: []corev1.ContainerPort{{ContainerPort: tensorBoardPort}},
+							VolumeMounts: []corev1.VolumeMount{
+								{Name: vis.FsID, MountPath: tensorBoardMountPath, ReadOnly: true},
+							},
+						},
+					},
+					Volumes: []corev1.Volume{
+						{
+							Name: vis.FsID,
+							VolumeSource: corev1.VolumeSource{
+								PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+									ClaimName: schema.ConcatenatePVCName(vis.FsID),
+									ReadOnly:  true,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func buildTensorBoardService(vis *model.Visualization) *corev1.Service {
+	serviceType := corev1.ServiceTypeNodePort
+	if config.GlobalServerConfig != nil && config.GlobalServerConfig.Visualization.ServiceType != "" {
+		serviceType = corev1.ServiceType(config.GlobalServerConfig.Visualization.ServiceType)
+	}
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      vis.ID,
+			Namespace: vis.Namespace,
+			Labels:    map[string]string{labelVisualizationID: vis.ID},
+		},
+		Spec: corev1.ServiceSpec{
+			Type:     serviceType,
+			Selector: map[string]string{labelVisualizationID: vis.ID},
+			Ports: []corev1.ServicePort{
+				{Port: tensorBoardPort, TargetPort: intstr.FromInt(tensorBoardPort)},
+			},
+		},
+	}
+}
+
+// getAccessURL NodePort类型且配置了accessHost时返回集群外可访问的地址，否则返回集群内地址
+func getAccessURL(svc *corev1.Service) string {
+	accessHost := ""
+	if config.GlobalServerConfig != nil {
+		accessHost = config.GlobalServerConfig.Visualization.AccessHost
+	}
+	if svc.Spec.Type == corev1.ServiceTypeNodePort && accessHost != "" && len(svc.Spec.Ports) > 0 {
+		return fmt.Sprintf("http://%s:%d", accessHost, svc.Spec.Ports[0].NodePort)
+	}
+	return fmt.Sprintf("http://%s.%s.svc:%d", svc.Name, svc.Namespace, tensorBoardPort)
+}
+
+func GetVisualization(ctx *logger.RequestContext, id string) (model.Visualization, error) {
+	vis, err := storage.Visualization.GetVisualization(ctx.Logging(), id)
+	if err != nil {
+		ctx.ErrorCode = common.RecordNotFound
+		return model.Visualization{}, common.NotFoundError(common.ResourceTypeVisualization, id)
+	}
+	if err := common.CheckPermission(ctx.UserName, vis.UserName, common.ResourceTypeVisualization, id); err != nil {
+		ctx.ErrorCode = common.AccessDenied
+		return model.Visualization{}, err
+	}
+	return vis, nil
+}
+
+func ListVisualization(ctx *logger.RequestContext, marker string, maxKeys int, jobID string) (ListVisualizationResponse, error) {
+	response := ListVisualizationResponse{VisualizationList: []model.Visualization{}}
+	var pk int64
+	var err error
+	if marker != "" {
+		pk, err = common.DecryptPk(marker)
+		if err != nil {
+			ctx.ErrorCode = common.InvalidMarker
+			ctx.Logging().Errorf("DecryptPk marker[%s] failed. err:[%s]", marker, err.Error())
+			return response, err
+		}
+	}
+	// 多查询一条，用于判断是否还有下一页
+	visList, err := storage.Visualization.ListVisualization(ctx.Logging(), pk, maxKeys+1, ctx.UserName, jobID)
+	if err != nil {
+		ctx.ErrorCode = common.InternalError
+		return response, err
+	}
+	if len(visList) > maxKeys {
+		visList = visList[:maxKeys]
+		nextMarker, err := common.EncryptPk(visList[len(visList)-1].Pk)
+		if err != nil {
+			ctx.ErrorCode = common.InternalError
+			return response, err
+		}
+		response.IsTruncated = true
+		response.NextMarker = nextMarker
+	}
+	response.MaxKeys = maxKeys
+	response.VisualizationList = append(response.VisualizationList, visList...)
+	return response, nil
+}
+
+// StopVisualization 停止服务并回收集群资源，记录保留
+func StopVisualization(ctx *logger.RequestContext, id string) error {
+	vis, err := GetVisualization(ctx, id)
+	if err != nil {
+		return err
+	}
+	if vis.Status != model.VisualizationStatusRunning {
+		return nil
+	}
+	if err := stopVisualization(vis, "stopped by user"); err != nil {
+		ctx.ErrorCode = common.K8sOperatorError
+		return err
+	}
+	return nil
+}
+
+func DeleteVisualization(ctx *logger.RequestContext, id string) error {
+	if err := StopVisualization(ctx, id); err != nil {
+		return err
+	}
+	if err := storage.Visualization.DeleteVisualization(ctx.Logging(), id); err != nil {
+		ctx.ErrorCode = common.InternalError
+		return err
+	}
+	return nil
+}
+
+func stopVisualization(vis model.Visualization, message string) error {
+	rt, err := getVisualizationRuntime(vis.ClusterID)
+	if err != nil {
+		return err
+	}
+	if err := deleteTensorBoard(rt, &vis); err != nil {
+		return err
+	}
+	return storage.Visualization.UpdateVisualization(log.NewEntry(log.StandardLogger()), vis.ID, model.Visualization{
+		Status:  model.VisualizationStatusTerminated,
+		Message: message,
+	})
+}
+
+func deleteTensorBoard(rt visualizationRuntime, vis *model.Visualization) error {
+	if err := rt.DeleteService(vis.Namespace, vis.ID); err != nil && !k8serrors.IsNotFound(err) {
+		log.Errorf("delete service of visualization[%s] failed. error: %v", vis.ID, err)
+		return err
+	}
+	if err := rt.DeleteDeployment(vis.Namespace, vis.ID); err != nil && !k8serrors.IsNotFound(err) {
+		log.Errorf("delete deployment of visualization[%s] failed. error: %v", vis.ID, err)
+		return err
+	}
+	return nil
+}
+
+// Controller 定期回收已过期的可视化服务
+func Controller(stopChan chan struct{}) {
+	interval := defaultCheckInterval
+	if config.GlobalServerConfig != nil && config.GlobalServerConfig.Visualization.CheckIntervalSeconds > 0 {
+		interval = time.Duration(config.GlobalServerConfig.Visualization.CheckIntervalSeconds) * time.Second
+	}
+	for {
+		cleanExpiredVisualization()
+		select {
+		case <-stopChan:
+			log.Info("visualization controller stopped")
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+func cleanExpiredVisualization() {
+	visList, err := storage.Visualization.ListExpiredVisualization(log.NewEntry(log.StandardLogger()), time.Now())
+	if err != nil {
+		log.Errorf("list expired visualization failed. error: %v", err)
+		return
+	}
+	for _, vis := range visList {
+		log.Infof("visualization[%s] expired at %s, begin to stop", vis.ID, vis.ExpiredAt)
+		if err := stopVisualization(vis, "expired"); err != nil {
+			log.Errorf("stop expired visualization[%s] failed. error: %v", vis.ID, err)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package visualization
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/config"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage/driver"
+)
+
+type fakeRuntime struct {
+	deployments map[string]*appsv1.Deployment
+	services    map[string]*corev1.Service
+}
+
+func (f *fakeRuntime) CreatePV(namespace, fsID string) (string, error) {
+	return "pfs-" + fsID + "-" + namespace + "-pv", nil
+}
+
+func (f *fakeRuntime) CreatePVC(namespace, fsId, pv string) error {
+	return nil
+}
+
+func (f *fakeRuntime) CreateDeployment(deploy *appsv1.Deployment) error {
+	f.deployments[deploy.Name] = deploy
+	return nil
+}
+
+func (f *fakeRuntime) DeleteDeployment(namespace, name string) error {
+	delete(f.deployments, name)
+	return nil
+}
+
+func (f *fakeRuntime) CreateService(svc *corev1.Service) (*corev1.Service, error) {
+	svc.Spec.Ports[0].NodePort = 30006
+	f.services[svc.Name] = svc
+	return svc, nil
+}
+
+func (f *fakeRuntime) DeleteService(namespace, name string) error {
+	delete(f.services, name)
+	return nil
+}
+
+func initVisualizationTest(t *testing.T) *fakeRuntime {
+	driver.InitMockDB()
+	config.GlobalServerConfig = &config.ServerConfig{}
+	config.GlobalServerConfig.Visualization = config.VisualizationConfig{
+		AccessHost:    "10.0.0.1",
+		MaxTTLSeconds: 3600,
+	}
+
+	cluster := model.ClusterInfo{
+		Model:       model.Model{ID: "cluster-000001"},
+		Name:        "cluster-000001",
+		ClusterType: schema.KubernetesType,
+	}
+	assert.Nil(t, storage.Cluster.CreateCluster(&cluster))
+	queue := model.Queue{
+		Model:     model.Model{ID: "queue-000001"},
+		Name:      "queue-000001",
+		Namespace: "paddleflow",
+		ClusterId: cluster.ID,
+	}
+	assert.Nil(t, storage.Queue.CreateQueue(&queue))
+	job := model.Job{
+		ID:       "job-000001",
+		UserName: "user1",
+		QueueID:  queue.ID,
+		Config: &schema.Conf{
+			FileSystem: schema.FileSystem{Name: "fs1"},
+		},
+	}
+	assert.Nil(t, storage.Job.CreateJob(&job))
+
+	rt := &fakeRuntime{deployments: map[string]*appsv1.Deployment{}, services: map[string]*corev1.Service{}}
+	getVisualizationRuntime = func(clusterID string) (visualizationRuntime, error) {
+		return rt, nil
+	}
+	return rt
+}
+
+func TestCreateVisualization(t *testing.T) {
+	rt := initVisualizationTest(t)
+	ctx := &logger.RequestContext{UserName: "user1"}
+
+	resp, err := CreateVisualization(ctx, CreateVisualizationRequest{JobID: "job-000001", LogDir: "output/log"})
+	assert.Nil(t, err)
+	assert.Equal(t, "http://10.0.0.1:30006", resp.URL)
+
+	deploy := rt.deployments[resp.ID]
+	assert.NotNil(t, deploy)
+	assert.Equal(t, "paddleflow", deploy.Namespace)
+	assert.Contains(t, deploy.Spec.Template.Spec.Containers[0].Command, "--logdir=/home/paddleflow/storage/mnt/output/log")
+	assert.Equal(t, schema.ConcatenatePVCName("fs-user1-fs1"), deploy.Spec.Template.Spec.Volumes[0].PersistentVolumeClaim.ClaimName)
+
+	vis, err := GetVisualization(ctx, resp.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, model.VisualizationStatusRunning, vis.Status)
+	assert.Equal(t, "fs1", vis.FsName)
+
+	// 其他用户无权访问
+	_, err = GetVisualization(&logger.RequestContext{UserName: "user2"}, resp.ID)
+	assert.NotNil(t, err)
+	_, err = CreateVisualization(&logger.RequestContext{UserName: "user2"}, CreateVisualizationRequest{JobID: "job-000001"})
+	assert.NotNil(t, err)
+	// 超过最大TTL
+	_, err = CreateVisualization(ctx, CreateVisualizationRequest{JobID: "job-000001", TTLSeconds: 7200})
+	assert.NotNil(t, err)
+
+	listResp, err := ListVisualization(ctx, "", 10, "job-000001")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(listResp.VisualizationList))
+	assert.False(t, listResp.IsTruncated)
+
+	err = StopVisualization(ctx, resp.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(rt.deployments))
+	assert.Equal(t, 0, len(rt.services))
+	vis, err = GetVisualization(ctx, resp.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, model.VisualizationStatusTerminated, vis.Status)
+
+	err = DeleteVisualization(ctx, resp.ID)
+	assert.Nil(t, err)
+	_, err = GetVisualization(ctx, resp.ID)
+	assert.NotNil(t, err)
+}
+
+func TestCleanExpiredVisualization(t *testing.T) {
+	rt := initVisualizationTest(t)
+	ctx := &logger.RequestContext{UserName: "user1"}
+
+	resp, err := CreateVisualization(ctx, CreateVisualizationRequest{JobID: "job-000001", TTLSeconds: 60})
+	assert.Nil(t, err)
+	err = storage.Visualization.UpdateVisualization(ctx.Logging(), resp.ID, model.Visualization{ExpiredAt: time.Now().Add(-time.Minute)})
+	assert.Nil(t, err)
+
+	cleanExpiredVisualization()
+	assert.Equal(t, 0, len(rt.deployments))
+	vis, err := GetVisualization(ctx, resp.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, model.VisualizationStatusTerminated, vis.Status)
+}
@@ -17,16 +17,25 @@ limitations under the License.
 package handler
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io"
 	iofs "io/fs"
 	"io/ioutil"
 	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
 	log "github.com/sirupsen/logrus"
 
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/config"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/client/fs"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/client/ufs"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/client/vfs"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/common"
 )
 
@@ -123,11 +132,16 @@ var NewFsHandlerWithServer = func(fsID string, logEntry *log.Entry) (*FsHandler,
 
 // 方便其余模块调用 fsHandler单测
 func MockerNewFsHandlerWithServer(fsID string, logEntry *log.Entry) (*FsHandler, error) {
-	os.MkdirAll("./mock_fs_handler", 0755)
+	return MockerNewFsHandlerWithSubPath("./mock_fs_handler", logEntry)
+}
+
+// MockerNewFsHandlerWithSubPath 以本地目录subPath模拟文件系统，用于需要多个文件系统的单测
+func MockerNewFsHandlerWithSubPath(subPath string, logEntry *log.Entry) (*FsHandler, error) {
+	os.MkdirAll(subPath, 0755)
 
 	testFsMeta := common.FSMeta{
 		UfsType: common.LocalType,
-		SubPath: "./mock_fs_handler",
+		SubPath: subPath,
 	}
 
 	fsClient, err := fs.NewFSClientForTest(testFsMeta)
@@ -195,6 +209,20 @@ func (fh *FsHandler) MkdirAll(path string, perm os.FileMode) error {
 	return fh.fsClient.MkdirAll(path, perm)
 }
 
+// StorageClass 获取对象的存储类型，仅对象存储类型的文件系统支持
+func (fh *FsHandler) StorageClass(path string) (string, error) {
+	value, err := fh.fsClient.GetXAttr(path, ufs.XAttrStorageClass)
+	if err != nil {
+		return "", err
+	}
+	return string(value), nil
+}
+
+// SetStorageClass 将对象转换为指定的存储类型，如归档存储，仅对象存储类型的文件系统支持
+func (fh *FsHandler) SetStorageClass(path, storageClass string) error {
+	return fh.fsClient.SetXAttr(path, ufs.XAttrStorageClass, []byte(storageClass))
+}
+
 func (fh *FsHandler) ModTime(path string) (time.Time, error) {
 	fh.log.Debugf("begin to get the modtime of file[%s] with fsId[%s]",
 		path, fh.fsID)
@@ -234,3 +262,275 @@ func (fh *FsHandler) LastModTime(path string) (time.Time, error) {
 		}
 	}
 }
+
+// 文件路径及其修改时间
+type FileModTime struct {
+	Path    string
+	ModTime time.Time
+}
+
+// 获取 path 下所有修改时间不早于 since 的文件（不包括目录），结果按修改时间、文件路径升序排列
+func (fh *FsHandler) ListFilesModifiedSince(path string, since time.Time) ([]FileModTime, error) {
+	fh.log.Debugf("begin to list files modified since[%s] in path[%s] with fsId[%s]", since, path, fh.fsID)
+
+	files := []FileModTime{}
+	err := fh.fsClient.Walk(path, func(filePath string, info iofs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && !info.ModTime().Before(since) {
+			files = append(files, FileModTime{Path: filePath, ModTime: info.ModTime()})
+		}
+		return nil
+	})
+	if err != nil {
+		fh.log.Errorf("list files in path[%s] with fsId[%s] failed: %s", path, fh.fsID, err.Error())
+		return nil, err
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].ModTime.Equal(files[j].ModTime) {
+			return files[i].Path < files[j].Path
+		}
+		return files[i].ModTime.Before(files[j].ModTime)
+	})
+	return files, nil
+}
+
+// 文件路径、大小及修改时间
+type FileStat struct {
+	Path    string
+	Size    int64
+	ModTime time.Time
+}
+
+// ListFileStats 获取 path 下所有文件（不包括目录及根目录下的内部节点）的大小与修改时间
+func (fh *FsHandler) ListFileStats(path string) ([]FileStat, error) {
+	fh.log.Debugf("begin to list file stats in path[%s] with fsId[%s]", path, fh.fsID)
+
+	files := []FileStat{}
+	err := fh.fsClient.Walk(path, func(filePath string, info iofs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if filepath.Join("/", filepath.Dir(filePath)) == "/" && vfs.IsSpecialName(info.Name()) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.IsDir() {
+			files = append(files, FileStat{Path: filePath, Size: info.Size(), ModTime: info.ModTime()})
+		}
+		return nil
+	})
+	if err != nil {
+		fh.log.Errorf("list file stats in path[%s] with fsId[%s] failed: %s", path, fh.fsID, err.Error())
+		return nil, err
+	}
+	return files, nil
+}
+
+// 文件相对路径、大小及其sha256摘要
+type FileDigest struct {
+	Path string
+	Size int64
+	Hash string
+}
+
+// 计算 path 下所有文件（不包括目录）的摘要，文件数超过 maxFiles 时返回错误，结果按路径升序排列
+func (fh *FsHandler) FileDigests(path string, maxFiles int) ([]FileDigest, error) {
+	fh.log.Debugf("begin to compute file digests in path[%s] with fsId[%s]", path, fh.fsID)
+
+	digests := []FileDigest{}
+	err := fh.fsClient.Walk(path, func(filePath string, info iofs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		if maxFiles > 0 && len(digests) >= maxFiles {
+			return fmt.Errorf("the number of files in path[%s] exceeds %d", path, maxFiles)
+		}
+		hash, err := fh.fileHash(filePath)
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(path, filePath)
+		if err != nil {
+			return err
+		}
+		digests = append(digests, FileDigest{Path: relPath, Size: info.Size(), Hash: hash})
+		return nil
+	})
+	if err != nil {
+		fh.log.Errorf("compute file digests in path[%s] with fsId[%s] failed: %s", path, fh.fsID, err.Error())
+		return nil, err
+	}
+
+	sort.Slice(digests, func(i, j int) bool {
+		return digests[i].Path < digests[j].Path
+	})
+	return digests, nil
+}
+
+func (fh *FsHandler) fileHash(path string) (string, error) {
+	reader, err := fh.fsClient.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, reader); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// Usage 统计 path 下所有文件的总大小以及文件与目录总数（不包括 path 本身及根目录下的内部节点）
+func (fh *FsHandler) Usage(path string) (size int64, inodes int64, err error) {
+	size, files, dirs, err := fh.CountUsage(path)
+	return size, files + dirs, err
+}
+
+// CountUsage 统计 path 下所有文件的总大小、文件数与目录数（不包括 path 本身及根目录下的内部节点）
+func (fh *FsHandler) CountUsage(path string) (size, files, dirs int64, err error) {
+	fh.log.Debugf("begin to compute usage of path[%s] with fsId[%s]", path, fh.fsID)
+
+	err = fh.fsClient.Walk(path, func(filePath string, info iofs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if filePath == path {
+			return nil
+		}
+		if filepath.Join("/", filepath.Dir(filePath)) == "/" && vfs.IsSpecialName(info.Name()) {
+			return nil
+		}
+		if info.IsDir() {
+			dirs++
+		} else {
+			files++
+			size += info.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		fh.log.Errorf("compute usage of path[%s] with fsId[%s] failed: %s", path, fh.fsID, err.Error())
+		return 0, 0, 0, err
+	}
+	return size, files, dirs, nil
+}
+
+// ReadDir 列出 path 下的文件与目录，不包括根目录下的内部节点
+func (fh *FsHandler) ReadDir(path string) ([]os.FileInfo, error) {
+	infos, err := fh.fsClient.ListDir(path)
+	if err != nil {
+		fh.log.Errorf("list dir[%s] with fsId[%s] failed: %s", path, fh.fsID, err.Error())
+		return nil, err
+	}
+	if filepath.Join("/", path) != "/" {
+		return infos, nil
+	}
+	result := make([]os.FileInfo, 0, len(infos))
+	for _, info := range infos {
+		if !vfs.IsSpecialName(info.Name()) {
+			result = append(result, info)
+		}
+	}
+	return result, nil
+}
+
+// Open 打开文件用于流式读取，调用方负责关闭
+func (fh *FsHandler) Open(path string) (io.ReadCloser, error) {
+	reader, err := fh.fsClient.Open(path)
+	if err != nil {
+		fh.log.Errorf("open file[%s] with fsId[%s] failed: %s", path, fh.fsID, err.Error())
+		return nil, err
+	}
+	return reader, nil
+}
+
+// WriteFile 将 reader 中的内容写入 path，已存在时覆盖，写入失败时删除不完整的文件
+func (fh *FsHandler) WriteFile(path string, reader io.Reader) (int64, error) {
+	writer, err := fh.fsClient.Create(path)
+	if err != nil {
+		fh.log.Errorf("create file[%s] with fsId[%s] failed: %s", path, fh.fsID, err.Error())
+		return 0, err
+	}
+	n, err := io.Copy(writer, reader)
+	if closeErr := writer.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		fh.log.Errorf("write file[%s] with fsId[%s] failed: %s", path, fh.fsID, err.Error())
+		if rmErr := fh.fsClient.Remove(path); rmErr != nil {
+			fh.log.Warningf("remove incomplete file[%s] with fsId[%s] failed: %s", path, fh.fsID, rmErr.Error())
+		}
+		return 0, err
+	}
+	return n, nil
+}
+
+// GlobUsage 统计与任一通配符匹配的文件（匹配到目录时包括目录下的所有文件）的总数及总大小，每个文件只统计一次。
+// 通配符语法与 path.Match 一致且相对于存储根目录，"." 表示整个存储
+func (fh *FsHandler) GlobUsage(patterns []string) (files int64, size int64, err error) {
+	fh.log.Debugf("begin to compute usage of patterns%v with fsId[%s]", patterns, fh.fsID)
+
+	visited := make(map[string]bool)
+	for _, pattern := range patterns {
+		pattern = strings.Trim(path.Clean("/"+pattern), "/")
+		err = fh.fsClient.Walk(globRoot(pattern), func(filePath string, info iofs.FileInfo, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil
+				}
+				return err
+			}
+			relPath := strings.Trim(path.Clean("/"+filePath), "/")
+			if relPath != "" && !strings.Contains(relPath, "/") && vfs.IsSpecialName(relPath) {
+				return nil
+			}
+			if info.IsDir() || visited[relPath] || !GlobMatched(pattern, relPath) {
+				return nil
+			}
+			visited[relPath] = true
+			files++
+			size += info.Size()
+			return nil
+		})
+		if err != nil {
+			fh.log.Errorf("compute usage of pattern[%s] with fsId[%s] failed: %s", pattern, fh.fsID, err.Error())
+			return 0, 0, err
+		}
+	}
+	return files, size, nil
+}
+
+// GlobMatched relPath 或其任一上级目录与通配符匹配
+func GlobMatched(pattern, relPath string) bool {
+	if pattern == "" {
+		return true
+	}
+	patternParts := strings.Split(pattern, "/")
+	pathParts := strings.Split(relPath, "/")
+	if len(pathParts) < len(patternParts) {
+		return false
+	}
+	matched, err := path.Match(pattern, strings.Join(pathParts[:len(patternParts)], "/"))
+	return err == nil && matched
+}
+
+// globRoot 通配符中第一个包含特殊字符的路径之前的部分，遍历时从该目录开始
+func globRoot(pattern string) string {
+	var parts []string
+	for _, part := range strings.Split(pattern, "/") {
+		if strings.ContainsAny(part, "*?[\\") {
+			break
+		}
+		parts = append(parts, part)
+	}
+	return "/" + strings.Join(parts, "/")
+}
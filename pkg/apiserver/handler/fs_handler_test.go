@@ -184,3 +184,61 @@ func TestLastModTime(t *testing.T) {
 	fi, err := os.Lstat("mock_fs_handler/test_path_time/path_time/time/a.txt")
 	assert.Equal(t, modTime.UnixNano(), fi.ModTime().UnixNano())
 }
+
+func TestUsage(t *testing.T) {
+	fsClient, requestContext, err := prepareTestEnv()
+	assert.Equal(t, err, nil)
+
+	fsHandler := FsHandler{
+		fsClient: fsClient,
+		log:      logger.LoggerForRequest(requestContext),
+	}
+
+	size, inodes, err := fsHandler.Usage("/")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, int64(48), size)
+	// run.yaml test_path_time path_time time a.txt test_path_time2
+	assert.Equal(t, int64(6), inodes)
+
+	size, inodes, err = fsHandler.Usage("test_path_time")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, int64(24), size)
+	assert.Equal(t, int64(3), inodes)
+
+	size, files, dirs, err := fsHandler.CountUsage("/")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, int64(48), size)
+	assert.Equal(t, int64(2), files)
+	assert.Equal(t, int64(4), dirs)
+
+	infos, err := fsHandler.ReadDir("/")
+	assert.Equal(t, nil, err)
+	// run.yaml test_path_time test_path_time2
+	assert.Equal(t, 3, len(infos))
+}
+
+func TestGlobUsage(t *testing.T) {
+	fsClient, requestContext, err := prepareTestEnv()
+	assert.Equal(t, err, nil)
+
+	fsHandler := FsHandler{
+		fsClient: fsClient,
+		log:      logger.LoggerForRequest(requestContext),
+	}
+
+	// run.yaml与a.txt，重复匹配的文件只统计一次
+	files, size, err := fsHandler.GlobUsage([]string{".", "test_path_time"})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, int64(2), files)
+	assert.Equal(t, int64(48), size)
+
+	files, size, err = fsHandler.GlobUsage([]string{"test_path_*/path_time", "*.yaml", "not_exist"})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, int64(2), files)
+	assert.Equal(t, int64(48), size)
+
+	assert.True(t, GlobMatched("train/*.jpg", "train/a.jpg"))
+	assert.True(t, GlobMatched("train", "train/sub/a.jpg"))
+	assert.False(t, GlobMatched("train/*.jpg", "train"))
+	assert.Equal(t, "/train", globRoot("train/*/a"))
+}
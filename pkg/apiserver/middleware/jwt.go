@@ -22,16 +22,19 @@ import (
 	"errors"
 	"io/ioutil"
 	"net/http"
+	"regexp"
 	"strings"
 	"time"
 
 	jwtgo "github.com/dgrijalva/jwt-go"
+	chimiddleware "github.com/go-chi/chi/middleware"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/controller/user"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/config"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
 )
 
 type JWT struct {
@@ -83,18 +86,38 @@ func (j *JWT) ParseToken(tokenString string) (*PaddleFlowClaims, error) {
 	return nil, errors.New(common.AuthInvalidToken)
 }
 
-func GenerateToken(userName string, password string) (string, error) {
+// GenerateToken 签发token并创建对应的会话，撤销会话后token立即失效
+func GenerateToken(ctx *logger.RequestContext, userName, password, clientIP, userAgent string) (string, error) {
 	log.Debugf("GenerateToken userName:[%s] password:[%s]", userName, password)
+	var expireAt *time.Time
+	if config.GlobalServerConfig.ApiServer.TokenExpirationHour != -1 {
+		expire := time.Now().Add(time.Duration(config.GlobalServerConfig.ApiServer.TokenExpirationHour) * time.Hour)
+		expireAt = &expire
+	}
+	return generateToken(ctx, userName, password, clientIP, userAgent, expireAt)
+}
+
+// GenerateInternalToken 签发服务内部调用使用的token，不受tokenExpirationHour影响，会话在expireAt过期后由会话清理任务回收
+func GenerateInternalToken(ctx *logger.RequestContext, userName, password, userAgent string, expireAt time.Time) (string, error) {
+	log.Debugf("GenerateInternalToken userName:[%s] expireAt:[%s]", userName, expireAt)
+	return generateToken(ctx, userName, password, "", userAgent, &expireAt)
+}
+
+func generateToken(ctx *logger.RequestContext, userName, password, clientIP, userAgent string, expireAt *time.Time) (string, error) {
 	claim := &PaddleFlowClaims{
 		UserName: userName,
 		Password: password,
 	}
-	if config.GlobalServerConfig.ApiServer.TokenExpirationHour != -1 {
-		expire := time.Now().Add(time.Duration(config.GlobalServerConfig.ApiServer.TokenExpirationHour) * time.Hour)
-		claim.ExpiresAt = expire.Unix()
+	if expireAt != nil {
+		claim.ExpiresAt = expireAt.Unix()
 	}
 	claim.NotBefore = int64(time.Now().Unix()) - 1000
 	claim.Issuer = "paddleflow"
+	session, err := user.CreateSession(ctx, userName, clientIP, userAgent, expireAt)
+	if err != nil {
+		return "", errors.New(common.InternalError)
+	}
+	claim.Id = session.ID
 	token, err := jwtObj.CreateToken(*claim)
 	if err != nil {
 		return "", errors.New(common.InternalError)
@@ -104,7 +127,7 @@ func GenerateToken(userName string, password string) (string, error) {
 
 func BaseAuth(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
-		if strings.HasSuffix(req.URL.Path, "login") || strings.HasSuffix(req.URL.Path, "login/") {
+		if isAnonymousPath(req.URL.Path) {
 			next.ServeHTTP(res, req)
 			return
 		}
@@ -114,6 +137,24 @@ func BaseAuth(next http.Handler) http.Handler {
 		log.Debugf("GetRequestContext requestID:[%s] userName:[%s]", requestID, userName)
 		ctx := logger.RequestContext{RequestID: requestID, UserName: userName}
 		ctx.Logging().Debugf("BaseAuth begin. request:%v", req)
+		// 作业通过run维度的tracking token以run所有者的身份上报指标，仅允许访问该run的tracking接口
+		if trackingToken := req.Header.Get(common.HeaderKeyTrackingToken); trackingToken != "" {
+			runID, ok := parseTrackingRunID(req)
+			if !ok {
+				ctx.Logging().Errorf("BaseAuth tracking token is not allowed. path:[%s]", req.URL.Path)
+				common.RenderErr(res, requestID, common.AuthInvalidToken)
+				return
+			}
+			owner, err := common.ParseTrackingToken(trackingToken, runID, time.Now())
+			if err != nil {
+				ctx.Logging().Errorf("BaseAuth invalid tracking token. path:[%s] error:%s", req.URL.Path, err.Error())
+				common.RenderErr(res, requestID, common.AuthInvalidToken)
+				return
+			}
+			req.Header.Set(common.HeaderKeyUserName, owner)
+			next.ServeHTTP(res, req)
+			return
+		}
 		token := req.Header.Get(common.HeaderKeyAuthorization)
 		if token == "" {
 			ctx.Logging().Errorf("BaseAuth without token. request:%v", req)
@@ -126,10 +167,14 @@ func BaseAuth(next http.Handler) http.Handler {
 			common.RenderErr(res, requestID, common.AuthInvalidToken)
 			return
 		}
-		if !checkUserPermission(req, claims.UserName) {
-			ctx.Logging().Errorf(
-				"BaseAuth user verify error. UserName:[%s] has no permission to operate other user", claims.UserName)
-			common.RenderErr(res, requestID, common.AuthIllegalUser)
+		// 未携带会话的token签发于会话管理之前，无法撤销，需要重新登录
+		if claims.Id == "" {
+			ctx.Logging().Errorf("BaseAuth token of user[%s] without session", claims.UserName)
+			common.RenderErr(res, requestID, common.AuthInvalidToken)
+			return
+		}
+		if err := user.CheckSession(&ctx, claims.Id, claims.UserName); err != nil {
+			common.RenderErr(res, requestID, ctx.ErrorCode)
 			return
 		}
 		_, err = user.Login(&ctx, claims.UserName, claims.Password, true)
@@ -139,13 +184,71 @@ func BaseAuth(next http.Handler) http.Handler {
 			common.RenderErr(res, requestID, ctx.ErrorCode)
 			return
 		}
+		userName = claims.UserName
+		impersonated := req.Header.Get(common.HeaderKeyImpersonateUser)
+		if impersonated != "" {
+			if err := user.CheckImpersonation(&ctx, claims.UserName, impersonated, req.Method); err != nil {
+				ctx.Logging().Errorf("BaseAuth impersonate user[%s] failed. error:%s", impersonated, err.Error())
+				common.RenderErrWithMessage(res, requestID, ctx.ErrorCode, err.Error())
+				return
+			}
+			userName = impersonated
+		}
+		if !checkUserPermission(req, userName) {
+			ctx.Logging().Errorf(
+				"BaseAuth user verify error. UserName:[%s] has no permission to operate other user", userName)
+			common.RenderErr(res, requestID, common.AuthIllegalUser)
+			return
+		}
 
-		ctx.Logging().Debugf("BaseAuth add user-name[%s]", claims.UserName)
-		req.Header.Set(common.HeaderKeyUserName, claims.UserName)
-		next.ServeHTTP(res, req)
+		ctx.Logging().Debugf("BaseAuth add user-name[%s]", userName)
+		req.Header.Set(common.HeaderKeyUserName, userName)
+		if impersonated == "" {
+			next.ServeHTTP(res, req)
+			return
+		}
+		// 模拟用户的请求在处理完成后记录审计
+		ww := chimiddleware.NewWrapResponseWriter(res, req.ProtoMajor)
+		next.ServeHTTP(ww, req)
+		user.RecordImpersonation(&ctx, &model.ImpersonationAudit{
+			RequestID:  requestID,
+			Operator:   claims.UserName,
+			UserName:   impersonated,
+			Method:     req.Method,
+			Path:       req.URL.RequestURI(),
+			StatusCode: ww.Status(),
+			ClientIP:   common.ClientIP(req),
+		})
 	})
 }
 
+// anonymousPathSuffixes 登录及重置密码接口无需token
+var anonymousPathSuffixes = []string{"login", "user/password/forgot", "user/password/reset"}
+
+func isAnonymousPath(path string) bool {
+	path = strings.TrimSuffix(path, "/")
+	for _, suffix := range anonymousPathSuffixes {
+		if strings.HasSuffix(path, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+var trackingPathRegexp = regexp.MustCompile(`^/api/paddleflow/v[12]/run/([^/]+)/tracking/?$`)
+
+// parseTrackingRunID 只有上报tracking数据的请求可以使用tracking token
+func parseTrackingRunID(r *http.Request) (string, bool) {
+	if r.Method != http.MethodPost {
+		return "", false
+	}
+	matches := trackingPathRegexp.FindStringSubmatch(r.URL.Path)
+	if len(matches) != 2 {
+		return "", false
+	}
+	return matches[1], true
+}
+
 type request struct {
 	UserName string `json:"userName"`
 }
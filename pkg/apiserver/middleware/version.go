@@ -0,0 +1,119 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/config"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/metrics"
+)
+
+const (
+	HeaderDeprecation = "Deprecation"
+	HeaderSunset      = "Sunset"
+	HeaderLink        = "Link"
+
+	deprecationDateFormat = "2006-01-02"
+)
+
+// APIVersion 记录各版本API的请求指标，并为已弃用的版本添加Deprecation、Sunset及Link响应头，
+// pathPrefix为该版本路由的前缀，如/api/paddleflow/v1
+func APIVersion(version, pathPrefix string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			setDeprecationHeaders(w, r, version, pathPrefix)
+			startTime := time.Now()
+			ww := &statusWriter{ResponseWriter: w}
+			next.ServeHTTP(ww, r)
+
+			route := ""
+			if rctx := chi.RouteContext(r.Context()); rctx != nil {
+				route = rctx.RoutePattern()
+			}
+			status := ww.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+			metrics.ObserveAPIRequest(version, r.Method, route, status, time.Since(startTime))
+		})
+	}
+}
+
+// statusWriter 记录响应状态码，仅透传Flush，避免改变下游对ResponseWriter能力的判断
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func setDeprecationHeaders(w http.ResponseWriter, r *http.Request, version, pathPrefix string) {
+	if config.GlobalServerConfig == nil {
+		return
+	}
+	deprecation, ok := config.GlobalServerConfig.ApiServer.DeprecatedVersions[version]
+	if !ok {
+		return
+	}
+	w.Header().Set(HeaderDeprecation, httpDate(deprecation.Since, "true"))
+	if deprecation.Sunset != "" {
+		w.Header().Set(HeaderSunset, httpDate(deprecation.Sunset, deprecation.Sunset))
+	}
+	if deprecation.Successor != "" {
+		successorPrefix := strings.TrimSuffix(pathPrefix, version) + deprecation.Successor
+		successor := successorPrefix + strings.TrimPrefix(r.URL.Path, pathPrefix)
+		w.Header().Set(HeaderLink, fmt.Sprintf("<%s>; rel=\"successor-version\"", successor))
+	}
+}
+
+// httpDate converts date to http date, and returns defaultValue if date is empty or invalid
+func httpDate(date, defaultValue string) string {
+	if date == "" {
+		return defaultValue
+	}
+	t, err := time.Parse(deprecationDateFormat, date)
+	if err != nil {
+		log.Warnf("invalid date %s of deprecated api version, err: %v", date, err)
+		return defaultValue
+	}
+	return t.UTC().Format(http.TimeFormat)
+}
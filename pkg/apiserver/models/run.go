@@ -29,6 +29,7 @@ import (
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
 )
 
@@ -65,6 +66,14 @@ type Run struct {
 	ActivatedAt    sql.NullTime           `                                         json:"-"`
 	UpdatedAt      time.Time              `                                         json:"-"`
 	DeletedAt      gorm.DeletedAt         `                                         json:"-"`
+
+	// 校验后各节点实际使用的参数值，key为 <节点全名>.<参数名>
+	ResolvedParametersJson string                 `gorm:"type:text;size:65535"              json:"-"`
+	ResolvedParameters     map[string]interface{} `gorm:"-"                                 json:"resolvedParameters"`
+
+	// 生效的运行时长及资源消耗上限，以及已消耗的资源
+	Limits *schema.RunLimits `gorm:"-" json:"limits,omitempty"`
+	Usage  *schema.RunUsage  `gorm:"-" json:"usage,omitempty"`
 }
 
 func (Run) TableName() string {
@@ -94,6 +103,15 @@ func (r *Run) Encode() error {
 		r.ParametersJson = string(paramRaw)
 	}
 
+	if r.ResolvedParameters != nil {
+		resolvedRaw, err := json.Marshal(r.ResolvedParameters)
+		if err != nil {
+			logger.LoggerForRun(r.ID).Errorf("encode run resolved param failed. error:%v", err)
+			return err
+		}
+		r.ResolvedParametersJson = string(resolvedRaw)
+	}
+
 	optionsJson, err := json.Marshal(r.RunOptions)
 	if err != nil {
 		logger.LoggerForRun(r.ID).Errorf("encode run options failed. error:%v", err)
@@ -129,12 +147,28 @@ func (r *Run) decode() error {
 		r.Parameters = param
 	}
 
+	if len(r.ResolvedParametersJson) > 0 {
+		resolved := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(r.ResolvedParametersJson), &resolved); err != nil {
+			logger.LoggerForRun(r.ID).Errorf("decode run resolved param failed. error:%v", err)
+			return err
+		}
+		r.ResolvedParameters = resolved
+	}
+
 	runOptions := schema.RunOptions{}
 	if err := json.Unmarshal([]byte(r.RunOptionsJson), &runOptions); err != nil {
 		logger.LoggerForRun(r.ID).Errorf("decode run options failed. error:%v", err)
 		return err
 	}
 	r.RunOptions = runOptions
+	if runOptions.FailureStrategy != "" {
+		r.FailureOptions.Strategy = runOptions.FailureStrategy
+	}
+	r.Limits = r.WorkflowSource.Limits
+	if runOptions.Limits != nil {
+		r.Limits = runOptions.Limits
+	}
 
 	r.FsOptions.MainFS = r.WorkflowSource.FsOptions.MainFS
 
@@ -328,7 +362,7 @@ func CreateRun(logEntry *log.Entry, run *Run) (string, error) {
 				run.Pk, result.Error)
 			return result.Error
 		}
-		return nil
+		return storage.RecordEvent(tx, runEvent(run.ID, run.UserName, model.EventCreated, "", run.Status))
 	})
 
 	return run.ID, err
@@ -336,26 +370,61 @@ func CreateRun(logEntry *log.Entry, run *Run) (string, error) {
 
 func UpdateRunStatus(logEntry *log.Entry, runID, status string) error {
 	logEntry.Debugf("begin update run status. runID:%s, status:%s", runID, status)
-	tx := storage.DB.Model(&Run{}).Where("id = ?", runID).Update("status", status)
-	if tx.Error != nil {
+	err := storage.DB.Transaction(func(tx *gorm.DB) error {
+		return updateRunWithEvent(tx, runID, Run{Status: status})
+	})
+	if err != nil {
 		logEntry.Errorf("update run status failed. runID:%s, error:%s",
-			runID, tx.Error.Error())
-		return tx.Error
+			runID, err.Error())
+		return err
 	}
 	return nil
 }
 
 func UpdateRun(logEntry *log.Entry, runID string, run Run) error {
 	logEntry.Debugf("begin update run. runID:%s", runID)
-	tx := storage.DB.Model(&Run{}).Where("id = ?", runID).Updates(run)
-	if tx.Error != nil {
+	err := storage.DB.Transaction(func(tx *gorm.DB) error {
+		return updateRunWithEvent(tx, runID, run)
+	})
+	if err != nil {
 		logEntry.Errorf("update run failed. runID:%s, error:%s",
-			runID, tx.Error.Error())
-		return tx.Error
+			runID, err.Error())
+		return err
 	}
 	return nil
 }
 
+// updateRunWithEvent 更新run，run.Status变化时记录状态变化事件
+func updateRunWithEvent(tx *gorm.DB, runID string, run Run) error {
+	var pre struct {
+		Status   string
+		UserName string
+	}
+	if run.Status != "" {
+		if err := tx.Table("run").Select("status", "user_name").Where("id = ?", runID).Limit(1).Scan(&pre).Error; err != nil {
+			return err
+		}
+	}
+	if err := tx.Model(&Run{}).Where("id = ?", runID).Updates(run).Error; err != nil {
+		return err
+	}
+	if run.Status == "" {
+		return nil
+	}
+	return storage.RecordEvent(tx, runEvent(runID, pre.UserName, model.EventStatusChanged, pre.Status, run.Status))
+}
+
+func runEvent(runID, userName, eventType, preStatus, status string) *model.ResourceEvent {
+	return &model.ResourceEvent{
+		ResourceType:   common.ResourceTypeRun,
+		ResourceID:     runID,
+		Type:           eventType,
+		Status:         status,
+		PreviousStatus: preStatus,
+		UserName:       userName,
+	}
+}
+
 func DeleteRun(logEntry *log.Entry, runID string) error {
 	logEntry.Debugf("begin delete run. runID:%s", runID)
 	err := storage.DB.Transaction(func(tx *gorm.DB) error {
@@ -371,7 +440,7 @@ func DeleteRun(logEntry *log.Entry, runID string) error {
 				runID, result.Error.Error())
 			return result.Error
 		}
-		return nil
+		return storage.RecordEvent(tx, runEvent(runID, "", model.EventDeleted, "", ""))
 	})
 	return err
 }
@@ -21,6 +21,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"path/filepath"
 	"time"
 
 	log "github.com/sirupsen/logrus"
@@ -40,6 +41,7 @@ const (
 	ScheduleStatusRunning    = "running"
 	ScheduleStatusFailed     = "failed"
 	ScheduleStatusTerminated = "terminated"
+	ScheduleStatusPaused     = "paused"
 )
 
 var ConcurrencyPolicyList = []string{
@@ -53,6 +55,7 @@ var ScheduleStatusList = []string{
 	ScheduleStatusRunning,
 	ScheduleStatusFailed,
 	ScheduleStatusTerminated,
+	ScheduleStatusPaused,
 }
 
 var ScheduleFinalStatusList = []string{
@@ -63,6 +66,7 @@ var ScheduleFinalStatusList = []string{
 
 var ScheduleNotFinalStatusList = []string{
 	ScheduleStatusRunning,
+	ScheduleStatusPaused,
 }
 
 type Schedule struct {
@@ -81,6 +85,8 @@ type Schedule struct {
 	StartAt           sql.NullTime   `                                         json:"-"`
 	EndAt             sql.NullTime   `                                         json:"-"`
 	NextRunAt         time.Time      `                                         json:"-"`
+	TriggerWatermark  sql.NullTime   `                                         json:"-"` // fsTrigger已处理到的文件的修改时间
+	TriggerFile       string         `gorm:"type:varchar(4096);not null"       json:"-"` // fsTrigger已处理到的文件路径，与TriggerWatermark组成水位
 	CreatedAt         time.Time      `                                         json:"-"`
 	UpdatedAt         time.Time      `                                         json:"-"`
 	DeletedAt         gorm.DeletedAt `                                         json:"-"`
@@ -116,10 +122,46 @@ func (fc *FsConfig) Encode(logEntry *log.Entry) (string, error) {
 }
 
 type ScheduleOptions struct {
-	Catchup           bool   `json:"catchup"`
-	ExpireInterval    int    `json:"expireInterval"`
-	Concurrency       int    `json:"concurrency"`
-	ConcurrencyPolicy string `json:"concurrencyPolicy"`
+	Catchup           bool       `json:"catchup"`
+	ExpireInterval    int        `json:"expireInterval"`
+	Concurrency       int        `json:"concurrency"`
+	ConcurrencyPolicy string     `json:"concurrencyPolicy"`
+	FsTrigger         *FsTrigger `json:"fsTrigger,omitempty"`
+}
+
+// FsTrigger 文件系统触发器
+// 配置后，schedule每次到达crontab时间时，会轮询Path下新出现的文件，并为每个文件发起一个run，
+// 文件路径通过ParamName指定的pipeline参数传入
+type FsTrigger struct {
+	FsName    string `json:"fsName"`
+	Path      string `json:"path"`
+	Pattern   string `json:"pattern"` // optional, 文件名匹配规则，规则同 filepath.Match
+	ParamName string `json:"paramName"`
+}
+
+func (ft *FsTrigger) Validate() error {
+	if ft.FsName == "" {
+		return fmt.Errorf("fsName of fsTrigger should not be empty")
+	}
+	if ft.Path == "" {
+		return fmt.Errorf("path of fsTrigger should not be empty")
+	}
+	if ft.ParamName == "" {
+		return fmt.Errorf("paramName of fsTrigger should not be empty")
+	}
+	if _, err := filepath.Match(ft.Pattern, ""); err != nil {
+		return fmt.Errorf("pattern[%s] of fsTrigger is invalid: %v", ft.Pattern, err)
+	}
+	return nil
+}
+
+// Match 判断文件名是否满足Pattern，Pattern为空时匹配所有文件
+func (ft *FsTrigger) Match(path string) bool {
+	if ft.Pattern == "" {
+		return true
+	}
+	matched, _ := filepath.Match(ft.Pattern, filepath.Base(path))
+	return matched
 }
 
 func checkContains(val string, list []string) bool {
@@ -384,7 +426,7 @@ func ScheduleUsedFsIDs() (map[string]bool, error) {
 	results := []result{}
 	tx := storage.DB.Model(&Schedule{}).Select("schedule.user_name, schedule.fs_config, pipeline_version.pipeline_yaml").
 		Joins("join pipeline_version on schedule.pipeline_version_id = pipeline_version.id and schedule.pipeline_id = pipeline_version.pipeline_id").
-		Where("schedule.status = ?", ScheduleStatusRunning).Find(&results)
+		Where("schedule.status IN (?)", ScheduleNotFinalStatusList).Find(&results)
 
 	if tx.Error != nil {
 		return nil, tx.Error
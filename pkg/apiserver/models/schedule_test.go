@@ -227,3 +227,28 @@ func initMockDB() {
 	storage.DB = db
 	storage.InitStores(db)
 }
+
+func TestFsTrigger(t *testing.T) {
+	fsTrigger := FsTrigger{
+		FsName:    "fsname",
+		Path:      "/data",
+		Pattern:   "*.csv",
+		ParamName: "data_path",
+	}
+	assert.Nil(t, fsTrigger.Validate())
+	assert.True(t, fsTrigger.Match("/data/2022/a.csv"))
+	assert.False(t, fsTrigger.Match("/data/a.txt"))
+
+	fsTrigger.Pattern = ""
+	assert.True(t, fsTrigger.Match("/data/a.txt"))
+
+	fsTrigger.Pattern = "["
+	err := fsTrigger.Validate()
+	assert.NotNil(t, err)
+
+	fsTrigger.Pattern = ""
+	fsTrigger.ParamName = ""
+	err = fsTrigger.Validate()
+	assert.NotNil(t, err)
+	assert.Equal(t, "paramName of fsTrigger should not be empty", err.Error())
+}
@@ -28,6 +28,9 @@ import (
 const (
 	PaddleflowRouterPrefix    = "/api/paddleflow"
 	PaddleflowRouterVersionV1 = "/v1"
+	PaddleflowRouterVersionV2 = "/v2"
+	APIVersionV1              = "v1"
+	APIVersionV2              = "v2"
 
 	DefaultMaxKeys = 50
 	ListPageMax    = 1000
@@ -40,13 +43,23 @@ const (
 	ParamKeyPipelineID        = "pipelineID"
 	ParamKeyPipelineVersionID = "pipelineVersionID"
 	ParamKeyScheduleID        = "scheduleID"
-
-	QueryKeyAction    = "action"
-	QueryActionStop   = "stop"
-	QueryActionRetry  = "retry"
-	QueryActionDelete = "delete"
-	QueryActionCreate = "create"
-	QueryActionModify = "modify"
+	ParamKeyProjectName       = "projectName"
+	ParamKeyRuleName          = "ruleName"
+	ParamKeySessionID         = "sessionID"
+
+	QueryKeyAction      = "action"
+	QueryActionStop     = "stop"
+	QueryActionRetry    = "retry"
+	QueryActionDelete   = "delete"
+	QueryActionCreate   = "create"
+	QueryActionModify   = "modify"
+	QueryActionPause    = "pause"
+	QueryActionResume   = "resume"
+	QueryActionRollback = "rollback"
+	QueryActionApprove  = "approve"
+	QueryActionReject   = "reject"
+	QueryActionFailover = "failover"
+	QueryActionFailback = "failback"
 
 	QueryKeyMarker  = "marker"
 	QueryKeyMaxKeys = "maxKeys"
@@ -58,11 +71,14 @@ const (
 	QueryKeyFsFilter         = "fsFilter"
 	QueryKeyPplFilter        = "pplFilter"
 	QueryKeyPplVersionFilter = "pplVersionFilter"
+	QueryKeyBaseVersionID    = "baseVersionID"
+	QueryKeyMetricKeys       = "metricKeys"
 	QueryKeyNameFilter       = "nameFilter"
 	QueryKeyRunFilter        = "runFilter"
 	QueryKeyTypeFilter       = "typeFilter"
 	QueryKeyPathFilter       = "pathFilter"
 	QueryKeyUser             = "user"
+	QueryKeyOperator         = "operator"
 	QueryKeyName             = "name"
 	QueryKeyUserName         = "username"
 	QueryResourceType        = "resourceType"
@@ -76,14 +92,36 @@ const (
 	ParamKeyClusterName   = "clusterName"
 	ParamKeyClusterNames  = "clusterNames"
 	ParamKeyClusterStatus = "clusterStatus"
-
-	QueryFsPath     = "fsPath"
-	QueryFsName     = "fsName"
-	QueryFsname     = "fsname"
-	QueryPath       = "path"
-	QueryClusterID  = "clusterID"
-	QueryNodeName   = "nodename"
-	QueryMountPoint = "mountpoint"
+	ParamKeyPoolName      = "poolName"
+
+	QueryFsPath      = "fsPath"
+	QueryFsName      = "fsName"
+	QueryFsname      = "fsname"
+	QueryPath        = "path"
+	QueryClusterID   = "clusterID"
+	QueryNodeName    = "nodename"
+	QueryMountPoint  = "mountpoint"
+	QueryFsMode      = "mode"
+	QueryFsRepair    = "repair"
+	QueryFsDays      = "days"
+	QueryOverwrite   = "overwrite"
+	QueryGranteeType = "granteeType"
+	QueryGranteeName = "granteeName"
+	QueryPartNumber  = "partNumber"
+	QueryParts       = "parts"
+	QueryOffset      = "offset"
+	QueryLength      = "length"
+
+	QueryKeyTimeoutSeconds = "timeoutSeconds"
+	QueryKeyTaskID         = "taskID"
+	QueryKeyFollow         = "follow"
+	QueryKeyTailLines      = "tailLines"
+	QueryKeySortBy         = "sortBy"
+	QueryKeyProject        = "project"
+	QueryKeyJobType        = "jobType"
+	QueryKeyMonth          = "month"
+	QueryKeyInterval       = "interval"
+	QueryKeyLimit          = "limit"
 
 	ParamFlavourName = "flavourName"
 
@@ -95,6 +133,14 @@ const (
 	ParamKeyKind            = "kind"
 	ParamKeyAPIVersion      = "apiVersion"
 	ParamKeyJobID           = "jobID"
+	ParamKeyStepName        = "stepName"
+	ParamKeyVisualizationID = "visualizationID"
+	ParamKeyDataLoadID      = "dataLoadID"
+	ParamKeyTransferID      = "transferID"
+	ParamKeyImageBuildID    = "imageBuildID"
+	ParamKeyDatasetName     = "datasetName"
+	ParamKeyDatasetVersion  = "datasetVersion"
+	ParamKeyAlertRuleName   = "ruleName"
 	ParamKeyPageNo          = "pageNo"
 	ParamKeyPageSize        = "pageSize"
 	ParamKeyLogFilePosition = "logFilePosition"
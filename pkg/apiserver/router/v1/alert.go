@@ -0,0 +1,175 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/controller/alert"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/router/util"
+)
+
+type AlertRouter struct{}
+
+func (ar *AlertRouter) Name() string {
+	return "AlertRouter"
+}
+
+func (ar *AlertRouter) AddRouter(r chi.Router) {
+	log.Info("add alert router")
+	r.Post("/alert/rule", ar.createAlertRule)
+	r.Get("/alert/rule", ar.listAlertRule)
+	r.Get("/alert/rule/{ruleName}", ar.getAlertRule)
+	r.Put("/alert/rule/{ruleName}", ar.updateAlertRule)
+	r.Delete("/alert/rule/{ruleName}", ar.deleteAlertRule)
+}
+
+// createAlertRule
+// @Summary 创建告警规则
+// @Description 创建队列排队作业数、用户作业失败率或存储缓存命中率的告警规则，仅root用户可用
+// @Id createAlertRule
+// @tags Alert
+// @Accept  json
+// @Produce json
+// @Param request body alert.CreateAlertRuleRequest true "创建告警规则请求"
+// @Success 201 {object} alert.CreateAlertRuleResponse "创建告警规则响应"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /alert/rule [POST]
+func (ar *AlertRouter) createAlertRule(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	var request alert.CreateAlertRuleRequest
+	if err := common.BindJSON(r, &request); err != nil {
+		ctx.Logging().Errorf("create alert rule failed parsing request body:%+v. error:%s", r.Body, err.Error())
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	response, err := alert.CreateAlertRule(&ctx, request)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusCreated, response)
+}
+
+// listAlertRule
+// @Summary 获取告警规则列表
+// @Description 获取告警规则列表，仅root用户可用
+// @Id listAlertRule
+// @tags Alert
+// @Accept  json
+// @Produce json
+// @Param marker query string false "查询起始位置"
+// @Param maxKeys query int false "每页条数"
+// @Success 200 {object} alert.ListAlertRuleResponse "告警规则列表"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /alert/rule [GET]
+func (ar *AlertRouter) listAlertRule(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	maxKeys, err := util.GetQueryMaxKeys(&ctx, r)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	marker := r.URL.Query().Get(util.QueryKeyMarker)
+	response, err := alert.ListAlertRule(&ctx, marker, maxKeys)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusOK, response)
+}
+
+// getAlertRule
+// @Summary 获取告警规则详情
+// @Description 获取告警规则及其当前处于等待或告警状态的对象，仅root用户可用
+// @Id getAlertRule
+// @tags Alert
+// @Accept  json
+// @Produce json
+// @Param ruleName path string true "告警规则名称"
+// @Success 200 {object} alert.GetAlertRuleResponse "告警规则详情"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /alert/rule/{ruleName} [GET]
+func (ar *AlertRouter) getAlertRule(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	name := chi.URLParam(r, util.ParamKeyAlertRuleName)
+	response, err := alert.GetAlertRule(&ctx, name)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusOK, response)
+}
+
+// updateAlertRule
+// @Summary 更新告警规则
+// @Description 更新告警规则中设置的字段，仅root用户可用
+// @Id updateAlertRule
+// @tags Alert
+// @Accept  json
+// @Produce json
+// @Param ruleName path string true "告警规则名称"
+// @Param request body alert.UpdateAlertRuleRequest true "更新告警规则请求"
+// @Success 200 {object} model.AlertRule "更新后的告警规则"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /alert/rule/{ruleName} [PUT]
+func (ar *AlertRouter) updateAlertRule(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	name := chi.URLParam(r, util.ParamKeyAlertRuleName)
+	var request alert.UpdateAlertRuleRequest
+	if err := common.BindJSON(r, &request); err != nil {
+		ctx.Logging().Errorf("update alert rule failed parsing request body:%+v. error:%s", r.Body, err.Error())
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	response, err := alert.UpdateAlertRule(&ctx, name, request)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusOK, response)
+}
+
+// deleteAlertRule
+// @Summary 删除告警规则
+// @Description 删除告警规则，仅root用户可用
+// @Id deleteAlertRule
+// @tags Alert
+// @Accept  json
+// @Produce json
+// @Param ruleName path string true "告警规则名称"
+// @Success 200 "删除成功"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /alert/rule/{ruleName} [DELETE]
+func (ar *AlertRouter) deleteAlertRule(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	name := chi.URLParam(r, util.ParamKeyAlertRuleName)
+	if err := alert.DeleteAlertRule(&ctx, name); err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.RenderStatus(w, http.StatusOK)
+}
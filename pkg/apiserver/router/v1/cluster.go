@@ -47,6 +47,13 @@ func (cr *ClusterRouter) AddRouter(r chi.Router) {
 	r.Delete("/cluster/{clusterName}", cr.deleteCluster)
 	r.Put("/cluster/{clusterName}", cr.updateCluster)
 	r.Get("/cluster/resource", cr.listClusterQuota)
+	r.Post("/cluster/{clusterName}/components", cr.provisionCluster)
+	r.Get("/cluster/{clusterName}/components", cr.listClusterComponents)
+	r.Post("/cluster/{clusterName}/pool", cr.createNodePool)
+	r.Get("/cluster/{clusterName}/pool", cr.listNodePools)
+	r.Get("/cluster/{clusterName}/pool/{poolName}", cr.getNodePool)
+	r.Put("/cluster/{clusterName}/pool/{poolName}", cr.updateNodePool)
+	r.Delete("/cluster/{clusterName}/pool/{poolName}", cr.deleteNodePool)
 
 	r.Post("/cluster/{clusterName}/k8s/object", func(w http.ResponseWriter, r *http.Request) {
 		ctx := common.GetRequestContext(r)
@@ -130,6 +137,125 @@ func (cr *ClusterRouter) getClusterDetail(w http.ResponseWriter, r *http.Request
 	common.Render(w, http.StatusOK, response)
 }
 
+// 在集群中安装或升级组件
+func (cr *ClusterRouter) provisionCluster(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	clusterName := strings.TrimSpace(chi.URLParam(r, util.ParamKeyClusterName))
+	var request cluster.ProvisionRequest
+	if r.ContentLength != 0 {
+		if err := common.BindJSON(r, &request); err != nil {
+			ctx.ErrorCode = common.MalformedJSON
+			logger.LoggerForRequest(&ctx).Errorf(
+				"provision cluster failed parsing request body:%+v. error:%s", r.Body, err.Error())
+			common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+			return
+		}
+	}
+
+	response, err := cluster.ProvisionCluster(&ctx, clusterName, &request)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusOK, response)
+}
+
+// 获取集群中组件的安装状态
+func (cr *ClusterRouter) listClusterComponents(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	clusterName := strings.TrimSpace(chi.URLParam(r, util.ParamKeyClusterName))
+
+	response, err := cluster.ListClusterComponents(&ctx, clusterName)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusOK, response)
+}
+
+// 创建节点池并给节点打标签
+func (cr *ClusterRouter) createNodePool(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	clusterName := strings.TrimSpace(chi.URLParam(r, util.ParamKeyClusterName))
+	var request cluster.CreateNodePoolRequest
+	if err := common.BindJSON(r, &request); err != nil {
+		ctx.ErrorCode = common.MalformedJSON
+		logger.LoggerForRequest(&ctx).Errorf(
+			"create node pool failed parsing request body:%+v. error:%s", r.Body, err.Error())
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+
+	response, err := cluster.CreateNodePool(&ctx, clusterName, &request)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusOK, response)
+}
+
+// 获取集群的节点池列表
+func (cr *ClusterRouter) listNodePools(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	clusterName := strings.TrimSpace(chi.URLParam(r, util.ParamKeyClusterName))
+
+	response, err := cluster.ListNodePools(&ctx, clusterName)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusOK, response)
+}
+
+// 获取节点池详情
+func (cr *ClusterRouter) getNodePool(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	clusterName := strings.TrimSpace(chi.URLParam(r, util.ParamKeyClusterName))
+	poolName := strings.TrimSpace(chi.URLParam(r, util.ParamKeyPoolName))
+
+	response, err := cluster.GetNodePool(&ctx, clusterName, poolName)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusOK, response)
+}
+
+// 修改节点池
+func (cr *ClusterRouter) updateNodePool(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	clusterName := strings.TrimSpace(chi.URLParam(r, util.ParamKeyClusterName))
+	poolName := strings.TrimSpace(chi.URLParam(r, util.ParamKeyPoolName))
+	var request cluster.UpdateNodePoolRequest
+	if err := common.BindJSON(r, &request); err != nil {
+		ctx.ErrorCode = common.MalformedJSON
+		logger.LoggerForRequest(&ctx).Errorf(
+			"update node pool failed parsing request body:%+v. error:%s", r.Body, err.Error())
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+
+	response, err := cluster.UpdateNodePool(&ctx, clusterName, poolName, &request)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusOK, response)
+}
+
+// 删除节点池
+func (cr *ClusterRouter) deleteNodePool(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	clusterName := strings.TrimSpace(chi.URLParam(r, util.ParamKeyClusterName))
+	poolName := strings.TrimSpace(chi.URLParam(r, util.ParamKeyPoolName))
+
+	if err := cluster.DeleteNodePool(&ctx, clusterName, poolName); err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.RenderStatus(w, http.StatusOK)
+}
+
 // 删除集群
 func (cr *ClusterRouter) deleteCluster(w http.ResponseWriter, r *http.Request) {
 	ctx := common.GetRequestContext(r)
@@ -0,0 +1,102 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/config"
+)
+
+type ConfigRouter struct{}
+
+// GetConfigResponse 当前生效的服务端配置，密码等敏感字段不返回
+type GetConfigResponse struct {
+	config.ReloadStatus
+	Config *config.ServerConfig `json:"config"`
+}
+
+func (cr *ConfigRouter) Name() string {
+	return "ConfigRouter"
+}
+
+func (cr *ConfigRouter) AddRouter(r chi.Router) {
+	log.Info("add config router")
+	r.Get("/config", cr.getConfig)
+	r.Post("/config/reload", cr.reloadConfig)
+}
+
+// getConfig
+// @Summary 获取当前生效的服务端配置
+// @Description 获取当前生效的服务端配置，仅限root用户
+// @Id getConfig
+// @tags Config
+// @Accept  json
+// @Produce json
+// @Success 200 {object} GetConfigResponse "获取配置的响应"
+// @Failure 403 {object} common.ErrorResponse "FAILURE"
+// @Router /config [GET]
+func (cr *ConfigRouter) getConfig(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	if !common.IsRootUser(ctx.UserName) {
+		ctx.ErrorCode = common.OnlyRootAllowed
+		ctx.Logging().Errorln("get server config failed, root is needed.")
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, "")
+		return
+	}
+	response := GetConfigResponse{
+		ReloadStatus: config.GetReloadStatus(),
+		Config:       config.GlobalServerConfig,
+	}
+	common.Render(w, http.StatusOK, response)
+}
+
+// reloadConfig
+// @Summary 重新加载服务端配置
+// @Description 重新读取配置文件，作业相关配置无需重启即可生效，仅限root用户
+// @Id reloadConfig
+// @tags Config
+// @Accept  json
+// @Produce json
+// @Success 200 {object} GetConfigResponse "重新加载后的配置"
+// @Failure 400 {object} common.ErrorResponse "FAILURE"
+// @Failure 403 {object} common.ErrorResponse "FAILURE"
+// @Router /config/reload [POST]
+func (cr *ConfigRouter) reloadConfig(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	if !common.IsRootUser(ctx.UserName) {
+		ctx.ErrorCode = common.OnlyRootAllowed
+		ctx.Logging().Errorln("reload server config failed, root is needed.")
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, "")
+		return
+	}
+	if err := config.ReloadConfig(); err != nil {
+		ctx.ErrorCode = common.InvalidArguments
+		ctx.Logging().Errorf("reload server config failed, err: %v", err)
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	response := GetConfigResponse{
+		ReloadStatus: config.GetReloadStatus(),
+		Config:       config.GlobalServerConfig,
+	}
+	common.Render(w, http.StatusOK, response)
+}
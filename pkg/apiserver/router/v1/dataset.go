@@ -0,0 +1,205 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/controller/dataset"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/router/util"
+)
+
+type DatasetRouter struct{}
+
+func (dr *DatasetRouter) Name() string {
+	return "DatasetRouter"
+}
+
+func (dr *DatasetRouter) AddRouter(r chi.Router) {
+	log.Info("add dataset router")
+	r.Post("/dataset", dr.createDataset)
+	r.Get("/dataset", dr.listDataset)
+	r.Get("/dataset/{datasetName}", dr.getDataset)
+	r.Delete("/dataset/{datasetName}", dr.deleteDataset)
+	r.Post("/dataset/{datasetName}/version", dr.createVersion)
+	r.Get("/dataset/{datasetName}/version/{datasetVersion}", dr.getVersion)
+}
+
+// createDataset
+// @Summary 注册数据集
+// @Description 将存储中的目录注册为命名数据集
+// @Id createDataset
+// @tags Dataset
+// @Accept  json
+// @Produce json
+// @Param request body dataset.CreateDatasetRequest true "注册数据集请求"
+// @Success 201 {object} dataset.CreateDatasetResponse "注册数据集响应"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /dataset [POST]
+func (dr *DatasetRouter) createDataset(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	var request dataset.CreateDatasetRequest
+	if err := common.BindJSON(r, &request); err != nil {
+		ctx.Logging().Errorf("create dataset failed parsing request body:%+v. error:%s", r.Body, err.Error())
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	response, err := dataset.CreateDataset(&ctx, request)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusCreated, response)
+}
+
+// listDataset
+// @Summary 获取数据集列表
+// @Description 获取数据集列表
+// @Id listDataset
+// @tags Dataset
+// @Accept  json
+// @Produce json
+// @Param marker query string false "查询起始位置"
+// @Param maxKeys query int false "每页条数"
+// @Param fsName query string false "存储名称过滤"
+// @Success 200 {object} dataset.ListDatasetResponse "数据集列表"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /dataset [GET]
+func (dr *DatasetRouter) listDataset(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	maxKeys, err := util.GetQueryMaxKeys(&ctx, r)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	marker := r.URL.Query().Get(util.QueryKeyMarker)
+	fsName := r.URL.Query().Get(util.QueryFsName)
+	response, err := dataset.ListDataset(&ctx, marker, maxKeys, fsName)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusOK, response)
+}
+
+// getDataset
+// @Summary 获取数据集详情
+// @Description 获取数据集详情及其版本列表
+// @Id getDataset
+// @tags Dataset
+// @Accept  json
+// @Produce json
+// @Param datasetName path string true "数据集名称"
+// @Success 200 {object} dataset.GetDatasetResponse "数据集详情"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /dataset/{datasetName} [GET]
+func (dr *DatasetRouter) getDataset(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	name := chi.URLParam(r, util.ParamKeyDatasetName)
+	response, err := dataset.GetDataset(&ctx, name)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusOK, response)
+}
+
+// deleteDataset
+// @Summary 删除数据集
+// @Description 删除数据集及其版本记录，不会删除存储中的数据
+// @Id deleteDataset
+// @tags Dataset
+// @Accept  json
+// @Produce json
+// @Param datasetName path string true "数据集名称"
+// @Success 200 "删除成功"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /dataset/{datasetName} [DELETE]
+func (dr *DatasetRouter) deleteDataset(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	name := chi.URLParam(r, util.ParamKeyDatasetName)
+	if err := dataset.DeleteDataset(&ctx, name); err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.RenderStatus(w, http.StatusOK)
+}
+
+// createVersion
+// @Summary 创建数据集版本
+// @Description 计算数据集目录下所有文件的摘要生成快照，内容未变化时返回最新版本
+// @Id createDatasetVersion
+// @tags Dataset
+// @Accept  json
+// @Produce json
+// @Param datasetName path string true "数据集名称"
+// @Param request body dataset.CreateVersionRequest false "创建版本请求"
+// @Success 201 {object} model.DatasetVersion "数据集版本"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /dataset/{datasetName}/version [POST]
+func (dr *DatasetRouter) createVersion(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	name := chi.URLParam(r, util.ParamKeyDatasetName)
+	var request dataset.CreateVersionRequest
+	if r.ContentLength > 0 {
+		if err := common.BindJSON(r, &request); err != nil {
+			ctx.Logging().Errorf("create dataset version failed parsing request body:%+v. error:%s", r.Body, err.Error())
+			common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+			return
+		}
+	}
+	response, err := dataset.CreateVersion(&ctx, name, request)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusCreated, response)
+}
+
+// getVersion
+// @Summary 获取数据集版本详情
+// @Description 获取数据集版本详情，包含文件摘要清单
+// @Id getDatasetVersion
+// @tags Dataset
+// @Accept  json
+// @Produce json
+// @Param datasetName path string true "数据集名称"
+// @Param datasetVersion path string true "数据集版本"
+// @Success 200 {object} model.DatasetVersion "数据集版本"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /dataset/{datasetName}/version/{datasetVersion} [GET]
+func (dr *DatasetRouter) getVersion(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	name := chi.URLParam(r, util.ParamKeyDatasetName)
+	version := chi.URLParam(r, util.ParamKeyDatasetVersion)
+	response, err := dataset.GetVersion(&ctx, name, version)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusOK, response)
+}
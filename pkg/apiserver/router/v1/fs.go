@@ -17,6 +17,7 @@ limitations under the License.
 package v1
 
 import (
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"net/http"
@@ -35,6 +36,7 @@ import (
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/config"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
 	fuse "github.com/PaddlePaddle/PaddleFlow/pkg/fs/client/fs"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/client/ufs"
 	fsCommon "github.com/PaddlePaddle/PaddleFlow/pkg/fs/common"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/utils"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
@@ -53,11 +55,49 @@ func (pr *PFSRouter) AddRouter(r chi.Router) {
 	r.Post("/fs", pr.createFileSystem)
 	r.Get("/fs", pr.listFileSystem)
 	r.Get("/fs/{fsName}", pr.getFileSystem)
+	r.Get("/fs/{fsName}/usage", pr.getFileSystemUsage)
+	r.Get("/fs/{fsName}/health", pr.getFileSystemHealth)
+	r.Get("/fs/{fsName}/du", pr.getFileSystemDu)
+	// fs file browser
+	r.Get("/fs/{fsName}/files", pr.listFiles)
+	r.Get("/fs/{fsName}/files/stat", pr.statFile)
+	r.Get("/fs/{fsName}/files/download", pr.downloadFile)
+	r.Post("/fs/{fsName}/files/upload", pr.uploadFile)
+	r.Put("/fs/{fsName}/files/parts", pr.uploadFilePart)
+	r.Get("/fs/{fsName}/files/parts", pr.listFileParts)
+	r.Post("/fs/{fsName}/files/parts/complete", pr.completeFileUpload)
+	r.Delete("/fs/{fsName}/files/parts", pr.abortFileUpload)
+	r.Post("/fs/{fsName}/acl", pr.grantFileSystemAccess)
+	r.Get("/fs/{fsName}/acl", pr.listFileSystemAcl)
+	r.Delete("/fs/{fsName}/acl", pr.revokeFileSystemAccess)
+	// fs replication
+	r.Post("/fs/{fsName}/replication", pr.createReplication)
+	r.Get("/fs/{fsName}/replication", pr.getReplication)
+	r.Put("/fs/{fsName}/replication", pr.updateReplication)
+	r.Delete("/fs/{fsName}/replication", pr.deleteReplication)
+	// fs lifecycle rules
+	r.Post("/fs/{fsName}/lifecycle", pr.createLifecycleRule)
+	r.Get("/fs/{fsName}/lifecycle", pr.listLifecycleRule)
+	r.Put("/fs/{fsName}/lifecycle/{ruleName}", pr.updateLifecycleRule)
+	r.Delete("/fs/{fsName}/lifecycle/{ruleName}", pr.deleteLifecycleRule)
 	r.Delete("/fs/{fsName}", pr.deleteFileSystem)
+	r.Get("/fsUsage", pr.listFileSystemDu)
 	// fs cache config
 	r.Post("/fsCache", pr.createFSCacheConfig)
 	r.Get("/fsCache/{fsName}", pr.getFSCacheConfig)
+	r.Get("/fsCache/{fsName}/stats", pr.getFSCacheStats)
 	r.Delete("/fsCache/{fsName}", pr.deleteFSCacheConfig)
+	// fs cache data load
+	r.Post("/fsDataLoad", pr.createDataLoad)
+	r.Get("/fsDataLoad", pr.listDataLoad)
+	r.Get("/fsDataLoad/{dataLoadID}", pr.getDataLoad)
+	r.Delete("/fsDataLoad/{dataLoadID}", pr.deleteDataLoad)
+	// fs data transfer
+	r.Post("/fsTransfer", pr.createTransfer)
+	r.Get("/fsTransfer", pr.listTransfer)
+	r.Get("/fsTransfer/{transferID}", pr.getTransfer)
+	r.Put("/fsTransfer/{transferID}", pr.updateTransfer)
+	r.Delete("/fsTransfer/{transferID}", pr.deleteTransfer)
 }
 
 var URLPrefix = map[string]bool{
@@ -68,6 +108,12 @@ var URLPrefix = map[string]bool{
 	fsCommon.MockType:      true,
 	fsCommon.CFSType:       true,
 	fsCommon.GlusterFSType: true,
+	fsCommon.ABSType:       true,
+	fsCommon.GCSType:       true,
+	fsCommon.OSSType:       true,
+	fsCommon.COSType:       true,
+	fsCommon.NFSType:       true,
+	fsCommon.CephFSType:    true,
 }
 
 const FsNameMaxLen = 63
@@ -150,6 +196,11 @@ func validateCreateFileSystem(ctx *logger.RequestContext, req *api.CreateFileSys
 		ctx.ErrorMessage = common.InvalidField("username and name", fmt.Sprintf("The sum of the lengths of username[%s] and fsName[%s] should be less than %d", req.Username, req.Name, FsnamePlusUsernameMaxLen)).Error()
 		return common.InvalidField("name", fmt.Sprintf("The sum of the lengths of username[%s] and fsName[%s] should be less than %d", req.Username, req.Name, FsNameMaxLen))
 	}
+	if req.CapacityQuota < 0 || req.InodeQuota < 0 {
+		ctx.Logging().Errorf("capacityQuota[%d] or inodeQuota[%d] is negative", req.CapacityQuota, req.InodeQuota)
+		ctx.ErrorCode = common.InvalidFileSystemProperties
+		return common.InvalidField("capacityQuota and inodeQuota", "must not be negative")
+	}
 	urlArr := strings.Split(req.Url, ":")
 	if len(urlArr) < 2 {
 		ctx.Logging().Errorf("[%s] is not a correct file-system url", req.Url)
@@ -226,6 +277,9 @@ func checkProperties(fsType string, req *api.CreateFileSystemRequest) error {
 			return err
 		}
 	}
+	if err := ufs.ValidateMultipartProperties(req.Properties); err != nil {
+		return common.InvalidField("properties", err.Error())
+	}
 	switch fsType {
 	case fsCommon.HDFSType:
 		if req.Properties[fsCommon.KeyTabData] != "" {
@@ -244,7 +298,7 @@ func checkProperties(fsType string, req *api.CreateFileSystemRequest) error {
 		} else {
 			return common.InvalidField("properties", "not correct hdfs properties")
 		}
-		return nil
+		return checkHDFSProperties(req.Properties)
 	case fsCommon.S3Type:
 		if req.Properties[fsCommon.AccessKey] == "" || req.Properties[fsCommon.SecretKey] == "" {
 			log.Error("s3 ak or sk is empty")
@@ -268,6 +322,59 @@ func checkProperties(fsType string, req *api.CreateFileSystemRequest) error {
 		}
 		req.Properties[fsCommon.SecretKey] = encodedSk
 		return nil
+	case fsCommon.GCSType:
+		// gcs通过xml互操作接口访问，accessKey/secretKey为HMAC密钥
+		if req.Properties[fsCommon.AccessKey] == "" || req.Properties[fsCommon.SecretKey] == "" {
+			return common.InvalidField("properties", fmt.Sprintf("key %s or %s is empty", fsCommon.AccessKey, fsCommon.SecretKey))
+		}
+		if req.Properties[fsCommon.Endpoint] == "" {
+			req.Properties[fsCommon.Endpoint] = ufs.GCSDefaultEndpoint
+		}
+		return encryptProperties(req.Properties, fsCommon.SecretKey)
+	case fsCommon.OSSType, fsCommon.COSType:
+		if req.Properties[fsCommon.AccessKey] == "" || req.Properties[fsCommon.SecretKey] == "" {
+			return common.InvalidField("properties", fmt.Sprintf("key %s or %s is empty", fsCommon.AccessKey, fsCommon.SecretKey))
+		}
+		if req.Properties[fsCommon.Endpoint] == "" {
+			region := req.Properties[fsCommon.Region]
+			if region == "" {
+				return common.InvalidField("properties", fmt.Sprintf("key %s or %s must be provided", fsCommon.Endpoint, fsCommon.Region))
+			}
+			// endpoint用于检查目录嵌套，根据region补全
+			if fsType == fsCommon.OSSType {
+				req.Properties[fsCommon.Endpoint] = fmt.Sprintf(ufs.OSSEndpointTemplate, strings.TrimPrefix(region, "oss-"))
+			} else {
+				req.Properties[fsCommon.Endpoint] = fmt.Sprintf(ufs.COSEndpointTemplate, region)
+			}
+		}
+		return encryptProperties(req.Properties, fsCommon.SecretKey)
+	case fsCommon.ABSType:
+		accountName := req.Properties[fsCommon.AccountName]
+		if accountName == "" {
+			return common.InvalidField(fsCommon.AccountName, "key[accountName] cannot be empty")
+		}
+		if req.Properties[fsCommon.AccountKey] == "" && req.Properties[fsCommon.SASToken] == "" {
+			return common.InvalidField("properties", fmt.Sprintf("key %s or %s must be provided", fsCommon.AccountKey, fsCommon.SASToken))
+		}
+		if req.Properties[fsCommon.AccountKey] != "" {
+			if _, err := base64.StdEncoding.DecodeString(req.Properties[fsCommon.AccountKey]); err != nil {
+				return common.InvalidField(fsCommon.AccountKey, "key[accountKey] should be base64 encoded")
+			}
+		}
+		if req.Properties[fsCommon.Endpoint] == "" {
+			req.Properties[fsCommon.Endpoint] = fmt.Sprintf(ufs.ABSEndpointTemplate, accountName)
+		}
+		return encryptProperties(req.Properties, fsCommon.AccountKey, fsCommon.SASToken)
+	case fsCommon.NFSType, fsCommon.GlusterFSType:
+		return common.CheckMountOptions(req.Properties[fsCommon.MountOptions])
+	case fsCommon.CephFSType:
+		if err := common.CheckMountOptions(req.Properties[fsCommon.MountOptions]); err != nil {
+			return err
+		}
+		if req.Properties[fsCommon.CephSecret] != "" && req.Properties[fsCommon.CephUser] == "" {
+			return common.InvalidField(fsCommon.CephUser, "key[cephUser] cannot be empty when cephSecret is set")
+		}
+		return encryptProperties(req.Properties, fsCommon.CephSecret)
 	case fsCommon.SFTPType:
 		if req.Properties[fsCommon.UserKey] == "" {
 			return common.InvalidField(fsCommon.UserKey, "key[user] cannot be empty")
@@ -297,6 +404,22 @@ func checkProperties(fsType string, req *api.CreateFileSystemRequest) error {
 	}
 }
 
+// encryptProperties 加密存储properties中的密钥，值为空时跳过
+func encryptProperties(properties map[string]string, keys ...string) error {
+	for _, key := range keys {
+		if properties[key] == "" {
+			continue
+		}
+		encoded, err := common.AesEncrypt(properties[key], common.AESEncryptKey)
+		if err != nil {
+			log.Errorf("encrypt %s failed: %v", key, err)
+			return err
+		}
+		properties[key] = encoded
+	}
+	return nil
+}
+
 func checkPVCExist(pvc, namespace string) bool {
 	k8sClient, err := utils.GetK8sClient()
 	if err != nil {
@@ -310,11 +433,24 @@ func checkPVCExist(pvc, namespace string) bool {
 	return true
 }
 
+// checkHDFSProperties blockSize和replication为可选项，设置时必须为正整数
+func checkHDFSProperties(properties map[string]string) error {
+	for _, key := range []string{fsCommon.BlockSizeKey, fsCommon.ReplicationKey} {
+		if properties[key] == "" {
+			continue
+		}
+		if value, err := strconv.ParseInt(properties[key], 10, 64); err != nil || value <= 0 {
+			return common.InvalidField("properties", fmt.Sprintf("key[%s] should be a positive integer", key))
+		}
+	}
+	return nil
+}
+
 func checkURLFormat(fsType, url string, properties map[string]string) error {
 	urlSplit := strings.Split(url, "/")
 	// check fs url correct
 	switch fsType {
-	case fsCommon.HDFSType, fsCommon.SFTPType, fsCommon.CFSType:
+	case fsCommon.HDFSType, fsCommon.SFTPType, fsCommon.CFSType, fsCommon.NFSType, fsCommon.CephFSType:
 		if len(urlSplit) < 4 {
 			log.Errorf("%s url split error", fsType)
 			return common.InvalidField("url", fmt.Sprintf("%s url format is wrong", fsType))
@@ -328,7 +464,7 @@ func checkURLFormat(fsType, url string, properties map[string]string) error {
 			log.Errorf("%s path can not be empty or use root path", fsType)
 			return common.InvalidField("url", fmt.Sprintf("%s path can not be empty or use root path", fsType))
 		}
-	case fsCommon.S3Type:
+	case fsCommon.S3Type, fsCommon.GCSType, fsCommon.ABSType, fsCommon.OSSType, fsCommon.COSType:
 		if len(urlSplit) < common.S3SplitLen {
 			log.Errorf("%s url split error", fsType)
 			return common.InvalidField("url", fmt.Sprintf("%s url format is wrong", fsType))
@@ -364,12 +500,12 @@ func checkFsDir(fsType, url string, properties map[string]string) error {
 	switch fsType {
 	case fsCommon.LocalType, fsCommon.MockType:
 		subPath = strings.SplitAfterN(url, "/", 2)[1]
-	case fsCommon.HDFSType, fsCommon.SFTPType, fsCommon.CFSType:
+	case fsCommon.HDFSType, fsCommon.SFTPType, fsCommon.CFSType, fsCommon.NFSType, fsCommon.CephFSType:
 		urlSplit := strings.Split(url, "/")
 		urlRaw := urlSplit[2]
 		inputIPs = strings.Split(urlRaw, ",")
 		subPath = "/" + strings.SplitAfterN(url, "/", 4)[3]
-	case fsCommon.S3Type:
+	case fsCommon.S3Type, fsCommon.GCSType, fsCommon.ABSType, fsCommon.OSSType, fsCommon.COSType:
 		inputIPs = strings.Split(properties[fsCommon.Endpoint], ",")
 		subPath = "/" + strings.SplitAfterN(url, "/", 4)[3]
 	}
@@ -377,6 +513,14 @@ func checkFsDir(fsType, url string, properties map[string]string) error {
 	if err != nil {
 		return err
 	}
+	if fsType == fsCommon.HDFSType {
+		// 开启kerberos认证的hdfs以hdfsWithKerberos类型存储，同样需要检查目录嵌套
+		kerberosList, err := storage.Filesystem.GetSimilarityAddressList(fsCommon.HDFSWithKerberosType, inputIPs)
+		if err != nil {
+			return err
+		}
+		fsList = append(fsList, kerberosList...)
+	}
 	for _, data := range fsList {
 		if common.CheckFsNested(subPath, data.SubPath) {
 			log.Errorf("%s and %s subpath is not allowed up nesting or duplication", subPath, data.SubPath)
@@ -412,6 +556,7 @@ func (pr *PFSRouter) listFileSystem(w http.ResponseWriter, r *http.Request) {
 		Marker:   r.URL.Query().Get(util.QueryKeyMarker),
 		MaxKeys:  int32(maxKeys),
 		Username: r.URL.Query().Get(util.QueryKeyUserName),
+		Project:  r.URL.Query().Get(util.QueryKeyProject),
 	}
 	log.Debugf("list file system with req[%v]", listRequest)
 
@@ -499,7 +644,180 @@ func fsResponseFromModel(fsModel model.FileSystem) *api.FileSystemResponse {
 		Username:                fsModel.UserName,
 		Properties:              fsModel.PropertiesMap,
 		IndependentMountProcess: fsModel.IndependentMountProcess,
+		CapacityQuota:           fsModel.CapacityQuota,
+		InodeQuota:              fsModel.InodeQuota,
+	}
+}
+
+// getFileSystemUsage the function that handle the get file system usage request
+// @Summary getFileSystemUsage
+// @Description 获取文件系统配额及当前用量
+// @tag fs
+// @Accept   json
+// @Produce  json
+// @Param fsName path string true "文件系统名称"
+// @Param username query string false "root用户指定其他用户"
+// @Success 200 {object} fs.FileSystemUsageResponse
+// @Router /fs/{fsName}/usage [get]
+func (pr *PFSRouter) getFileSystemUsage(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+
+	fsName := chi.URLParam(r, util.QueryFsName)
+	realUserName := getRealUserName(&ctx, r.URL.Query().Get(util.QueryKeyUserName))
+	log.Infof("get file system usage with username[%s] and fsName[%s]", realUserName, fsName)
+
+	fileSystemService := api.GetFileSystemService()
+	fsModel, err := fileSystemService.GetFileSystem(realUserName, fsName)
+	if err != nil {
+		ctx.Logging().Errorf("get file system username[%s] fsname[%s] with error[%v]", realUserName, fsName, err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.ErrorCode = common.RecordNotFound
+			ctx.ErrorMessage = fmt.Sprintf("username[%s] not create fsName[%s]", realUserName, fsName)
+		} else {
+			ctx.ErrorCode = common.FileSystemDataBaseError
+			ctx.ErrorMessage = err.Error()
+		}
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, ctx.ErrorMessage)
+		return
 	}
+
+	response, err := fileSystemService.GetFileSystemUsage(&ctx, fsModel)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	ctx.Logging().Debugf("GetFileSystemUsage Fs:%v", string(config.PrettyFormat(response)))
+	common.Render(w, http.StatusOK, response)
+}
+
+// getFileSystemHealth the function that handle the get file system health request
+// @Summary getFileSystemHealth
+// @Description 检查存储后端连通性与挂载pod状态，mode=fsck时校验缓存记录，repair=true时修复不一致
+// @tag fs
+// @Accept   json
+// @Produce  json
+// @Param fsName path string true "文件系统名称"
+// @Param username query string false "root用户指定其他用户"
+// @Param mode query string false "fsck"
+// @Param repair query bool false "是否修复不一致"
+// @Success 200 {object} fs.FileSystemHealthResponse
+// @Router /fs/{fsName}/health [get]
+func (pr *PFSRouter) getFileSystemHealth(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+
+	fsName := chi.URLParam(r, util.QueryFsName)
+	realUserName := getRealUserName(&ctx, r.URL.Query().Get(util.QueryKeyUserName))
+	healthRequest := api.FileSystemHealthRequest{
+		Mode: r.URL.Query().Get(util.QueryFsMode),
+	}
+	if repair := r.URL.Query().Get(util.QueryFsRepair); repair != "" {
+		var err error
+		if healthRequest.Repair, err = strconv.ParseBool(repair); err != nil {
+			ctx.ErrorCode = common.InvalidURI
+			common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, fmt.Sprintf("repair[%s] should be bool", repair))
+			return
+		}
+	}
+	log.Infof("get file system health with username[%s] fsName[%s] and req[%+v]", realUserName, fsName, healthRequest)
+
+	fsModel, err := api.GetFileSystemService().GetFileSystem(realUserName, fsName)
+	if err != nil {
+		ctx.Logging().Errorf("get file system username[%s] fsname[%s] with error[%v]", realUserName, fsName, err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.ErrorCode = common.RecordNotFound
+			ctx.ErrorMessage = fmt.Sprintf("username[%s] not create fsName[%s]", realUserName, fsName)
+		} else {
+			ctx.ErrorCode = common.FileSystemDataBaseError
+			ctx.ErrorMessage = err.Error()
+		}
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, ctx.ErrorMessage)
+		return
+	}
+
+	response, err := api.GetFileSystemHealth(&ctx, fsModel, healthRequest)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	ctx.Logging().Debugf("GetFileSystemHealth Fs:%v", string(config.PrettyFormat(response)))
+	common.Render(w, http.StatusOK, response)
+}
+
+// getFileSystemDu the function that handle the get file system du request
+// @Summary getFileSystemDu
+// @Description 获取后台扫描的文件系统及各一级目录用量，以及最近days天的增长
+// @tag fs
+// @Accept   json
+// @Produce  json
+// @Param fsName path string true "文件系统名称"
+// @Param username query string false "root用户指定其他用户"
+// @Param days query int false "计算增长的天数，默认7天，最多30天"
+// @Success 200 {object} fs.FileSystemDuResponse
+// @Router /fs/{fsName}/du [get]
+func (pr *PFSRouter) getFileSystemDu(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+
+	fsName := chi.URLParam(r, util.QueryFsName)
+	realUserName := getRealUserName(&ctx, r.URL.Query().Get(util.QueryKeyUserName))
+	days := api.DefaultUsageGrowthDays
+	if daysStr := r.URL.Query().Get(util.QueryFsDays); daysStr != "" {
+		var err error
+		if days, err = strconv.Atoi(daysStr); err != nil || days <= 0 || days > api.MaxUsageGrowthDays {
+			ctx.ErrorCode = common.InvalidURI
+			common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode,
+				fmt.Sprintf("days[%s] should be an integer between 1 and %d", daysStr, api.MaxUsageGrowthDays))
+			return
+		}
+	}
+	log.Infof("get file system du with username[%s] fsName[%s] and days[%d]", realUserName, fsName, days)
+
+	fsModel, err := api.GetFileSystemService().GetFileSystem(realUserName, fsName)
+	if err != nil {
+		ctx.Logging().Errorf("get file system username[%s] fsname[%s] with error[%v]", realUserName, fsName, err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.ErrorCode = common.RecordNotFound
+			ctx.ErrorMessage = fmt.Sprintf("username[%s] not create fsName[%s]", realUserName, fsName)
+		} else {
+			ctx.ErrorCode = common.FileSystemDataBaseError
+			ctx.ErrorMessage = err.Error()
+		}
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, ctx.ErrorMessage)
+		return
+	}
+
+	response, err := api.GetFileSystemDu(&ctx, fsModel, days)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	ctx.Logging().Debugf("GetFileSystemDu Fs:%v", string(config.PrettyFormat(response)))
+	common.Render(w, http.StatusOK, response)
+}
+
+// listFileSystemDu the function that handle the list file system du request
+// @Summary listFileSystemDu
+// @Description 按用量降序列出各文件系统最近一次扫描的用量，root用户不指定username时列出所有用户的文件系统
+// @tag fs
+// @Accept   json
+// @Produce  json
+// @Param username query string false "root用户指定其他用户"
+// @Success 200 {object} fs.ListFileSystemDuResponse
+// @Router /fsUsage [get]
+func (pr *PFSRouter) listFileSystemDu(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+
+	username := r.URL.Query().Get(util.QueryKeyUserName)
+	if !common.IsRootUser(ctx.UserName) || username != "" {
+		username = getRealUserName(&ctx, username)
+	}
+	log.Infof("list file system du with username[%s]", username)
+
+	response, err := api.ListFileSystemDu(&ctx, username)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusOK, response)
 }
 
 // deleteFileSystem the function that handle the delete file system request
@@ -0,0 +1,110 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"net/http"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	api "github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/controller/fs"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/router/util"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/config"
+)
+
+// grantFileSystemAccess the function that handle the grant file system access request
+// @Summary grantFileSystemAccess
+// @Description 所有者将文件系统的只读(r)或读写(rw)权限授予其他用户或队列，重复授权时覆盖原权限
+// @tag fs
+// @Accept   json
+// @Produce  json
+// @Param fsName path string true "文件系统名称"
+// @Param username query string false "root用户指定其他用户"
+// @Param request body fs.FsAclRequest true "授权对象及权限"
+// @Success 200
+// @Router /fs/{fsName}/acl [post]
+func (pr *PFSRouter) grantFileSystemAccess(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	var aclRequest api.FsAclRequest
+	if err := common.BindJSON(r, &aclRequest); err != nil {
+		ctx.Logging().Errorf("GrantFileSystemAccess bindjson failed. err:%s", err.Error())
+		common.RenderErrWithMessage(w, ctx.RequestID, common.MalformedJSON, err.Error())
+		return
+	}
+	fsModel, ok := getFsModel(w, r, &ctx)
+	if !ok {
+		return
+	}
+	ctx.Logging().Infof("grant fs[%s] access with req[%+v]", fsModel.ID, aclRequest)
+	if err := api.GrantFileSystemAccess(&ctx, fsModel, aclRequest); err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.RenderStatus(w, http.StatusOK)
+}
+
+// listFileSystemAcl the function that handle the list file system acl request
+// @Summary listFileSystemAcl
+// @Description 列出文件系统授予其他用户或队列的权限
+// @tag fs
+// @Accept   json
+// @Produce  json
+// @Param fsName path string true "文件系统名称"
+// @Param username query string false "root用户指定其他用户"
+// @Success 200 {object} fs.ListFsAclResponse
+// @Router /fs/{fsName}/acl [get]
+func (pr *PFSRouter) listFileSystemAcl(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	fsModel, ok := getFsModel(w, r, &ctx)
+	if !ok {
+		return
+	}
+	response, err := api.ListFileSystemAcl(&ctx, fsModel)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	ctx.Logging().Debugf("ListFileSystemAcl Fs:%v", string(config.PrettyFormat(response)))
+	common.Render(w, http.StatusOK, response)
+}
+
+// revokeFileSystemAccess the function that handle the revoke file system access request
+// @Summary revokeFileSystemAccess
+// @Description 撤销其他用户或队列对文件系统的访问权限
+// @tag fs
+// @Accept   json
+// @Produce  json
+// @Param fsName path string true "文件系统名称"
+// @Param username query string false "root用户指定其他用户"
+// @Param granteeType query string true "授权对象类型，user或queue"
+// @Param granteeName query string true "授权对象名称"
+// @Success 200
+// @Router /fs/{fsName}/acl [delete]
+func (pr *PFSRouter) revokeFileSystemAccess(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	fsModel, ok := getFsModel(w, r, &ctx)
+	if !ok {
+		return
+	}
+	granteeType := r.URL.Query().Get(util.QueryGranteeType)
+	granteeName := r.URL.Query().Get(util.QueryGranteeName)
+	ctx.Logging().Infof("revoke fs[%s] access of %s[%s]", fsModel.ID, granteeType, granteeName)
+	if err := api.RevokeFileSystemAccess(&ctx, fsModel, granteeType, granteeName); err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.RenderStatus(w, http.StatusOK)
+}
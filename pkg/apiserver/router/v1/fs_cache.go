@@ -20,7 +20,10 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi"
 	log "github.com/sirupsen/logrus"
@@ -83,11 +86,35 @@ func validationReturnError(ctx *logger.RequestContext, err error) error {
 	return err
 }
 
+// validateMetaAddress redis/etcd需要指定访问地址，格式与pfs-fuse的meta-cache-address参数一致
+func validateMetaAddress(driver, address string) error {
+	switch driver {
+	case schema.FsMetaRedis:
+		u, err := url.Parse(address)
+		if err != nil || u.Scheme != schema.FsMetaRedis || u.Host == "" {
+			return fmt.Errorf("metaAddress[%s] should be redis://[:password@]host:port[/db]", address)
+		}
+	case schema.FsMetaEtcd:
+		endpoints := strings.TrimPrefix(address, schema.FsMetaEtcd+"://")
+		if endpoints == address || endpoints == "" || strings.Contains(endpoints, "/") {
+			return fmt.Errorf("metaAddress[%s] should be etcd://host1:port1,host2:port2", address)
+		}
+	default:
+		if address != "" {
+			return fmt.Errorf("metaAddress is only allowed for meta driver redis or etcd")
+		}
+	}
+	return nil
+}
+
 func validateCacheConfigCreate(ctx *logger.RequestContext, req *api.CreateFileSystemCacheRequest) error {
 	if req.MetaDriver != "" && !schema.IsValidFsMetaDriver(req.MetaDriver) {
-		return validationReturnError(ctx, fmt.Errorf("fs[%s] cache config: meta driver[%s] not valid, must mem or disk",
+		return validationReturnError(ctx, fmt.Errorf("fs[%s] cache config: meta driver[%s] not valid, must mem, disk, redis or etcd",
 			req.FsID, req.MetaDriver))
 	}
+	if err := validateMetaAddress(req.MetaDriver, req.MetaAddress); err != nil {
+		return validationReturnError(ctx, fmt.Errorf("fs[%s] cache config: %v", req.FsID, err))
+	}
 	// BlockSize
 	if req.BlockSize < 0 {
 		return validationReturnError(ctx, fmt.Errorf("fs[%s] cache config: data cache blockSize[%d] should not be negative",
@@ -103,6 +130,96 @@ func validateCacheConfigCreate(ctx *logger.RequestContext, req *api.CreateFileSy
 		return validationReturnError(ctx, fmt.Errorf("fs[%s] cache config: cacheDir[%s] should be an absolute path when cache in use",
 			req.FsID, req.CacheDir))
 	}
+	// data cache size limit & eviction
+	if req.MaxCacheSize != "" {
+		size, err := resource.ParseQuantity(req.MaxCacheSize)
+		if err != nil || size.Sign() <= 0 {
+			return validationReturnError(ctx, fmt.Errorf("fs[%s] cache config: maxCacheSize[%s] should be a positive quantity, e.g. 100Gi",
+				req.FsID, req.MaxCacheSize))
+		}
+	}
+	if req.EvictionPolicy != "" && !schema.IsValidFsCacheEvictPolicy(req.EvictionPolicy) {
+		return validationReturnError(ctx, fmt.Errorf("fs[%s] cache config: evictionPolicy[%s] not valid, must be lru, lfu or ttl",
+			req.FsID, req.EvictionPolicy))
+	}
+	if req.CacheTTL != "" {
+		ttl, err := time.ParseDuration(req.CacheTTL)
+		if err != nil || ttl <= 0 {
+			return validationReturnError(ctx, fmt.Errorf("fs[%s] cache config: cacheTTL[%s] should be a positive duration, e.g. 24h",
+				req.FsID, req.CacheTTL))
+		}
+	}
+	if (req.MaxCacheSize != "" || req.EvictionPolicy != "" || req.CacheTTL != "") && req.CacheDir == "" {
+		return validationReturnError(ctx, fmt.Errorf("fs[%s] cache config: cacheDir is required when data cache limits are set",
+			req.FsID))
+	}
+	// memory tier lives in mount pod, so it must fit in the pod memory limit
+	if req.MemCacheSize != "" {
+		size, err := resource.ParseQuantity(req.MemCacheSize)
+		if err != nil || size.Sign() <= 0 {
+			return validationReturnError(ctx, fmt.Errorf("fs[%s] cache config: memCacheSize[%s] should be a positive quantity, e.g. 1Gi",
+				req.FsID, req.MemCacheSize))
+		}
+		memLimit := resource.MustParse(api.MaxMountPodMemLimit)
+		if req.Resource.MemoryLimit != "" {
+			if limit, err := resource.ParseQuantity(req.Resource.MemoryLimit); err == nil {
+				memLimit = limit
+			}
+		}
+		if size.Cmp(memLimit) >= 0 {
+			return validationReturnError(ctx, fmt.Errorf("fs[%s] cache config: memCacheSize[%s] should be less than mount pod memory limit[%s]",
+				req.FsID, req.MemCacheSize, memLimit.String()))
+		}
+	}
+	// write-back stages data under cacheDir, which must outlive the mount pod to recover after crash
+	if req.WriteBack && req.CacheDir == "" {
+		return validationReturnError(ctx, fmt.Errorf("fs[%s] cache config: cacheDir is required when writeBack is enabled",
+			req.FsID))
+	}
+	if req.WriteBackDirtyLimit != "" {
+		size, err := resource.ParseQuantity(req.WriteBackDirtyLimit)
+		if err != nil || size.Sign() <= 0 {
+			return validationReturnError(ctx, fmt.Errorf("fs[%s] cache config: writeBackDirtyLimit[%s] should be a positive quantity, e.g. 10Gi",
+				req.FsID, req.WriteBackDirtyLimit))
+		}
+	}
+	if req.MountPodPolicy != "" && !schema.IsValidFsMountPodPolicy(req.MountPodPolicy) {
+		return validationReturnError(ctx, fmt.Errorf("fs[%s] cache config: mountPodPolicy[%s] not valid, must be shared or dedicated",
+			req.FsID, req.MountPodPolicy))
+	}
+	if !schema.IsValidFsCacheCompression(req.CacheCompression) {
+		return validationReturnError(ctx, fmt.Errorf("fs[%s] cache config: cacheCompression[%s] not valid, must be zstd or s2",
+			req.FsID, req.CacheCompression))
+	}
+	// compression and encryption only apply to cached blocks on local disk
+	if (req.CacheCompression != "" || req.CacheEncryption) && req.CacheDir == "" {
+		return validationReturnError(ctx, fmt.Errorf("fs[%s] cache config: cacheDir is required when cacheCompression or cacheEncryption is set",
+			req.FsID))
+	}
+	// prefetched blocks are kept in the data cache, so a memory or disk cache is required
+	if req.PrefetchBlocks < 0 || req.PrefetchBlocks > api.MaxPrefetchBlocks {
+		return validationReturnError(ctx, fmt.Errorf("fs[%s] cache config: prefetchBlocks[%d] should be between 0 and %d",
+			req.FsID, req.PrefetchBlocks, api.MaxPrefetchBlocks))
+	}
+	if req.PrefetchWorkers < 0 || req.PrefetchWorkers > api.MaxPrefetchWorkers {
+		return validationReturnError(ctx, fmt.Errorf("fs[%s] cache config: prefetchWorkers[%d] should be between 0 and %d",
+			req.FsID, req.PrefetchWorkers, api.MaxPrefetchWorkers))
+	}
+	if req.PrefetchBlocks > 0 && req.CacheDir == "" && req.MemCacheSize == "" {
+		return validationReturnError(ctx, fmt.Errorf("fs[%s] cache config: cacheDir or memCacheSize is required when prefetchBlocks is set",
+			req.FsID))
+	}
+	// metadata cache ttl in fuse, 0s disables the cache
+	for name, ttl := range map[string]string{"attrCacheTTL": req.AttrCacheTTL,
+		"entryCacheTTL": req.EntryCacheTTL, "negativeCacheTTL": req.NegativeCacheTTL} {
+		if ttl == "" {
+			continue
+		}
+		if duration, err := time.ParseDuration(ttl); err != nil || duration < 0 {
+			return validationReturnError(ctx, fmt.Errorf("fs[%s] cache config: %s[%s] should be a non-negative duration, e.g. 10s",
+				req.FsID, name, ttl))
+		}
+	}
 
 	// check resource
 	rcs := req.Resource
@@ -130,6 +247,30 @@ func validateCacheConfigCreate(ctx *logger.RequestContext, req *api.CreateFileSy
 				req.FsID, rcs.MemoryLimit, api.MaxMountPodMemLimit))
 		}
 	}
+	if err := validateResourceRequest(rcs.CpuRequest, rcs.CpuLimit, api.MaxMountPodCpuLimit); err != nil {
+		return validationReturnError(ctx, fmt.Errorf("fs[%s] cache config: cpuRequest %v", req.FsID, err))
+	}
+	if err := validateResourceRequest(rcs.MemoryRequest, rcs.MemoryLimit, api.MaxMountPodMemLimit); err != nil {
+		return validationReturnError(ctx, fmt.Errorf("fs[%s] cache config: memoryRequest %v", req.FsID, err))
+	}
+	return nil
+}
+
+// validateResourceRequest 挂载pod的资源请求不能超过资源上限，未设置上限时与允许的最大上限比较
+func validateResourceRequest(request, limit, maxLimit string) error {
+	if request == "" {
+		return nil
+	}
+	req, err := resource.ParseQuantity(request)
+	if err != nil || req.Sign() < 0 {
+		return fmt.Errorf("[%s] should be a non-negative quantity", request)
+	}
+	if limit == "" {
+		limit = maxLimit
+	}
+	if req.Cmp(resource.MustParse(limit)) > 0 {
+		return fmt.Errorf("[%s] should be no greater than limit[%s]", request, limit)
+	}
 	return nil
 }
 
@@ -168,6 +309,35 @@ func (pr *PFSRouter) getFSCacheConfig(w http.ResponseWriter, r *http.Request) {
 	common.Render(w, http.StatusOK, fsCacheConfigResp)
 }
 
+// getFSCacheStats
+// @Summary 获取存储的缓存统计
+// @Description 获取存储在各节点上的缓存用量、命中率、淘汰次数与读取速率
+// @Id getFSCacheStats
+// @tags FSCacheConfig
+// @Accept  json
+// @Produce json
+// @Param fsName path string true "存储名称"
+// @Param username query string false "用户名"
+// @Success 200 {object} fs.FileSystemCacheStatsResponse "缓存统计"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /fsCache/{fsName}/stats [GET]
+func (pr *PFSRouter) getFSCacheStats(w http.ResponseWriter, r *http.Request) {
+	fsName := chi.URLParam(r, util.QueryFsName)
+	username := r.URL.Query().Get(util.QueryKeyUserName)
+	ctx := common.GetRequestContext(r)
+
+	realUserName := getRealUserName(&ctx, username)
+	fsID := common.ID(realUserName, fsName)
+
+	statsResp, err := api.GetFileSystemCacheStats(&ctx, fsID)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusOK, statsResp)
+}
+
 // deleteFSCacheConfig api delete file system cache config request
 // @Summary deleteFSCacheConfig
 // @Description 删除指定文件系统缓存配置
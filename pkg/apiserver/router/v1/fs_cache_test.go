@@ -94,6 +94,16 @@ func TestRouter_FSCacheConfig(t *testing.T) {
 	// test fsToName()
 	assert.Equal(t, createRep.Username, cacheRsp.Username)
 
+	// test get stats
+	result, err = PerformGetRequest(router, urlWithFsID+"/stats")
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, result.Code)
+	statsRsp := fs.FileSystemCacheStatsResponse{}
+	err = ParseBody(result.Body, &statsRsp)
+	assert.Nil(t, err)
+	assert.Equal(t, mockFsName, statsRsp.FsName)
+	assert.Equal(t, 0, len(statsRsp.Nodes))
+
 	// test get failure
 	urlWrong := url + "/666"
 	result, err = PerformGetRequest(router, urlWrong)
@@ -145,4 +155,133 @@ func TestRouter_FSCacheConfig(t *testing.T) {
 	result, err = PerformPostRequest(router, url, createRep)
 	assert.Nil(t, err)
 	assert.Equal(t, http.StatusBadRequest, result.Code)
+
+	// shared meta driver
+	metaRep := buildCreateReq(cacheConf)
+	metaRep.MetaDriver = "redis"
+	result, err = PerformPostRequest(router, url, metaRep)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, result.Code)
+
+	metaRep.MetaDriver = "etcd"
+	metaRep.MetaAddress = "http://127.0.0.1:2379"
+	result, err = PerformPostRequest(router, url, metaRep)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, result.Code)
+
+	metaRep.MetaDriver = "disk"
+	metaRep.MetaAddress = "redis://127.0.0.1:6379"
+	result, err = PerformPostRequest(router, url, metaRep)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, result.Code)
+
+	// data cache limits
+	limitRep := buildCreateReq(cacheConf)
+	limitRep.EvictionPolicy = "fifo"
+	result, err = PerformPostRequest(router, url, limitRep)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, result.Code)
+
+	limitRep.EvictionPolicy = "lfu"
+	limitRep.MaxCacheSize = "-10Gi"
+	result, err = PerformPostRequest(router, url, limitRep)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, result.Code)
+
+	limitRep.MaxCacheSize = "10Gi"
+	limitRep.CacheTTL = "1d"
+	result, err = PerformPostRequest(router, url, limitRep)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, result.Code)
+
+	limitRep.CacheTTL = "24h"
+	limitRep.MemCacheSize = "16Gi"
+	result, err = PerformPostRequest(router, url, limitRep)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, result.Code)
+
+	limitRep.MemCacheSize = "2Gi"
+	limitRep.Resource.MemoryLimit = "1Gi"
+	result, err = PerformPostRequest(router, url, limitRep)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, result.Code)
+
+	limitRep.Resource.MemoryLimit = "4Gi"
+	limitRep.WriteBack = true
+	limitRep.WriteBackDirtyLimit = "0"
+	result, err = PerformPostRequest(router, url, limitRep)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, result.Code)
+
+	limitRep.WriteBackDirtyLimit = "20Gi"
+	limitRep.MountPodPolicy = "perNode"
+	result, err = PerformPostRequest(router, url, limitRep)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, result.Code)
+
+	// mount pod resource requests
+	limitRep.MountPodPolicy = "dedicated"
+	limitRep.Resource.MemoryRequest = "8Gi"
+	result, err = PerformPostRequest(router, url, limitRep)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, result.Code)
+
+	limitRep.Resource.MemoryRequest = "2Gi"
+	limitRep.Resource.CpuRequest = "-1"
+	result, err = PerformPostRequest(router, url, limitRep)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, result.Code)
+
+	limitRep.Resource.CpuRequest = "3"
+	result, err = PerformPostRequest(router, url, limitRep)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, result.Code)
+
+	limitRep.Resource.CpuRequest = "500m"
+	limitRep.CacheCompression = "lzma"
+	result, err = PerformPostRequest(router, url, limitRep)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, result.Code)
+
+	limitRep.CacheCompression = "zstd"
+	limitRep.CacheEncryption = true
+	limitRep.PrefetchBlocks = 1000
+	result, err = PerformPostRequest(router, url, limitRep)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, result.Code)
+
+	limitRep.PrefetchBlocks = 16
+	limitRep.PrefetchWorkers = 8
+	limitRep.NegativeCacheTTL = "-1s"
+	result, err = PerformPostRequest(router, url, limitRep)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, result.Code)
+
+	limitRep.AttrCacheTTL = "10s"
+	limitRep.EntryCacheTTL = "1m"
+	limitRep.NegativeCacheTTL = "3s"
+	result, err = PerformPostRequest(router, url, limitRep)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusCreated, result.Code)
+	result, err = PerformGetRequest(router, urlWithFsID)
+	assert.Nil(t, err)
+	cacheRsp = fs.FileSystemCacheResponse{}
+	err = ParseBody(result.Body, &cacheRsp)
+	assert.Nil(t, err)
+	assert.Equal(t, "10Gi", cacheRsp.MaxCacheSize)
+	assert.Equal(t, "lfu", cacheRsp.EvictionPolicy)
+	assert.Equal(t, "24h", cacheRsp.CacheTTL)
+	assert.Equal(t, "2Gi", cacheRsp.MemCacheSize)
+	assert.True(t, cacheRsp.WriteBack)
+	assert.Equal(t, "20Gi", cacheRsp.WriteBackDirtyLimit)
+	assert.Equal(t, "dedicated", cacheRsp.MountPodPolicy)
+	assert.Equal(t, "500m", cacheRsp.Resource.CpuRequest)
+	assert.Equal(t, "2Gi", cacheRsp.Resource.MemoryRequest)
+	assert.Equal(t, "zstd", cacheRsp.CacheCompression)
+	assert.True(t, cacheRsp.CacheEncryption)
+	assert.Equal(t, 16, cacheRsp.PrefetchBlocks)
+	assert.Equal(t, 8, cacheRsp.PrefetchWorkers)
+	assert.Equal(t, "10s", cacheRsp.AttrCacheTTL)
+	assert.Equal(t, "1m", cacheRsp.EntryCacheTTL)
+	assert.Equal(t, "3s", cacheRsp.NegativeCacheTTL)
 }
@@ -0,0 +1,131 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	api "github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/controller/fs"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/router/util"
+)
+
+// createDataLoad
+// @Summary 创建缓存预热任务
+// @Description 在指定节点上读取存储的数据，使其进入节点缓存
+// @Id createDataLoad
+// @tags FileSystem
+// @Accept  json
+// @Produce json
+// @Param request body fs.CreateDataLoadRequest true "创建预热任务请求"
+// @Success 201 {object} fs.CreateDataLoadResponse "创建预热任务响应"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /fsDataLoad [POST]
+func (pr *PFSRouter) createDataLoad(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	var request api.CreateDataLoadRequest
+	if err := common.BindJSON(r, &request); err != nil {
+		ctx.Logging().Errorf("create data load failed parsing request body:%+v. error:%s", r.Body, err.Error())
+		common.RenderErrWithMessage(w, ctx.RequestID, common.MalformedJSON, err.Error())
+		return
+	}
+	response, err := api.GetFileSystemService().CreateDataLoad(&ctx, &request)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusCreated, response)
+}
+
+// listDataLoad
+// @Summary 获取缓存预热任务列表
+// @Description 获取缓存预热任务列表
+// @Id listDataLoad
+// @tags FileSystem
+// @Accept  json
+// @Produce json
+// @Param marker query string false "查询起始位置"
+// @Param maxKeys query int false "每页条数"
+// @Param fsName query string false "存储名称过滤"
+// @Success 200 {object} fs.ListDataLoadResponse "预热任务列表"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /fsDataLoad [GET]
+func (pr *PFSRouter) listDataLoad(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	maxKeys, err := util.GetQueryMaxKeys(&ctx, r)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	marker := r.URL.Query().Get(util.QueryKeyMarker)
+	fsName := r.URL.Query().Get(util.QueryFsName)
+	response, err := api.GetFileSystemService().ListDataLoad(&ctx, marker, maxKeys, fsName)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusOK, response)
+}
+
+// getDataLoad
+// @Summary 获取缓存预热任务详情
+// @Description 获取缓存预热任务详情及各节点进度
+// @Id getDataLoad
+// @tags FileSystem
+// @Accept  json
+// @Produce json
+// @Param dataLoadID path string true "预热任务ID"
+// @Success 200 {object} fs.DataLoadResponse "预热任务详情"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /fsDataLoad/{dataLoadID} [GET]
+func (pr *PFSRouter) getDataLoad(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	id := chi.URLParam(r, util.ParamKeyDataLoadID)
+	response, err := api.GetFileSystemService().GetDataLoad(&ctx, id)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusOK, response)
+}
+
+// deleteDataLoad
+// @Summary 删除缓存预热任务
+// @Description 停止预热pod并删除任务记录
+// @Id deleteDataLoad
+// @tags FileSystem
+// @Accept  json
+// @Produce json
+// @Param dataLoadID path string true "预热任务ID"
+// @Success 200 "删除成功"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /fsDataLoad/{dataLoadID} [DELETE]
+func (pr *PFSRouter) deleteDataLoad(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	id := chi.URLParam(r, util.ParamKeyDataLoadID)
+	if err := api.GetFileSystemService().DeleteDataLoad(&ctx, id); err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.RenderStatus(w, http.StatusOK)
+}
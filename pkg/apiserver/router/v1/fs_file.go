@@ -0,0 +1,394 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	api "github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/controller/fs"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/router/util"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+)
+
+const uploadFormFile = "file"
+
+// getFsAndPath 获取请求用户有权限访问的文件系统以及请求的路径，失败时已返回错误响应
+func getFsAndPath(w http.ResponseWriter, r *http.Request, ctx *logger.RequestContext) (model.FileSystem, string, bool) {
+	filePath, err := api.CleanFsPath(r.URL.Query().Get(util.QueryPath))
+	if err != nil {
+		ctx.ErrorCode = common.InvalidURI
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return model.FileSystem{}, "", false
+	}
+	fsModel, ok := getFsModel(w, r, ctx)
+	if !ok {
+		return model.FileSystem{}, "", false
+	}
+	return fsModel, filePath, true
+}
+
+// getFsModel 获取请求用户（root可指定username）名下的文件系统，失败时已返回错误响应
+func getFsModel(w http.ResponseWriter, r *http.Request, ctx *logger.RequestContext) (model.FileSystem, bool) {
+	fsName := chi.URLParam(r, util.QueryFsName)
+	realUserName := getRealUserName(ctx, r.URL.Query().Get(util.QueryKeyUserName))
+	fsModel, err := api.GetFileSystemService().GetFileSystem(realUserName, fsName)
+	if err != nil {
+		ctx.Logging().Errorf("get file system username[%s] fsname[%s] with error[%v]", realUserName, fsName, err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.ErrorCode = common.RecordNotFound
+			ctx.ErrorMessage = fmt.Sprintf("username[%s] not create fsName[%s]", realUserName, fsName)
+		} else {
+			ctx.ErrorCode = common.FileSystemDataBaseError
+			ctx.ErrorMessage = err.Error()
+		}
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, ctx.ErrorMessage)
+		return model.FileSystem{}, false
+	}
+	return fsModel, true
+}
+
+// listFiles the function that handle the list files request
+// @Summary listFiles
+// @Description 列出文件系统中目录下的文件与子目录，按名称排序
+// @tag fs
+// @Accept   json
+// @Produce  json
+// @Param fsName path string true "文件系统名称"
+// @Param path query string false "目录路径，默认为根目录"
+// @Param username query string false "root用户指定其他用户"
+// @Param marker query string false "上一页的nextMarker"
+// @Param maxKeys query int false "每页条数"
+// @Success 200 {object} fs.ListFilesResponse
+// @Router /fs/{fsName}/files [get]
+func (pr *PFSRouter) listFiles(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	maxKeys, err := util.GetQueryMaxKeys(&ctx, r)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	fsModel, dir, ok := getFsAndPath(w, r, &ctx)
+	if !ok {
+		return
+	}
+	req := api.ListFilesRequest{
+		Path:    dir,
+		Marker:  r.URL.Query().Get(util.QueryKeyMarker),
+		MaxKeys: maxKeys,
+	}
+	log.Debugf("list files of fs[%s] with req[%+v]", fsModel.ID, req)
+
+	response, err := api.ListFiles(&ctx, fsModel.ID, req)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusOK, response)
+}
+
+// statFile the function that handle the stat file request
+// @Summary statFile
+// @Description 获取文件系统中文件或目录的属性
+// @tag fs
+// @Accept   json
+// @Produce  json
+// @Param fsName path string true "文件系统名称"
+// @Param path query string true "文件路径"
+// @Param username query string false "root用户指定其他用户"
+// @Success 200 {object} fs.FsFileInfo
+// @Router /fs/{fsName}/files/stat [get]
+func (pr *PFSRouter) statFile(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	fsModel, filePath, ok := getFsAndPath(w, r, &ctx)
+	if !ok {
+		return
+	}
+	log.Debugf("stat file[%s] of fs[%s]", filePath, fsModel.ID)
+
+	response, err := api.StatFile(&ctx, fsModel.ID, filePath)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusOK, response)
+}
+
+// getQueryInt64 解析非负整数类型的query参数，未设置时返回0，失败时已返回错误响应
+func getQueryInt64(w http.ResponseWriter, r *http.Request, ctx *logger.RequestContext, key string) (int64, bool) {
+	value := r.URL.Query().Get(key)
+	if value == "" {
+		return 0, true
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n < 0 {
+		ctx.ErrorCode = common.InvalidURI
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, fmt.Sprintf("%s[%s] should be a non-negative integer", key, value))
+		return 0, false
+	}
+	return n, true
+}
+
+// downloadFile the function that handle the download file request
+// @Summary downloadFile
+// @Description 流式下载文件系统中的文件，可通过offset与length分段下载或断点续传
+// @tag fs
+// @Produce  octet-stream
+// @Param fsName path string true "文件系统名称"
+// @Param path query string true "文件路径"
+// @Param offset query int false "开始读取的位置，默认为0"
+// @Param length query int false "最多读取的字节数，默认读取到文件末尾"
+// @Param username query string false "root用户指定其他用户"
+// @Success 200 {file} file
+// @Router /fs/{fsName}/files/download [get]
+func (pr *PFSRouter) downloadFile(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	offset, ok := getQueryInt64(w, r, &ctx, util.QueryOffset)
+	if !ok {
+		return
+	}
+	length, ok := getQueryInt64(w, r, &ctx, util.QueryLength)
+	if !ok {
+		return
+	}
+	fsModel, filePath, ok := getFsAndPath(w, r, &ctx)
+	if !ok {
+		return
+	}
+	log.Infof("download file[%s] of fs[%s] from offset[%d] by user[%s]", filePath, fsModel.ID, offset, ctx.UserName)
+
+	reader, info, size, err := api.OpenFileRange(&ctx, fsModel.ID, filePath, offset, length)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	defer reader.Close()
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": info.Name}))
+	w.Header().Set("Content-Length", strconv.FormatInt(size, 10))
+	w.WriteHeader(http.StatusOK)
+	// 已经开始写响应，出错时只能中断连接
+	if _, err = io.Copy(w, reader); err != nil {
+		ctx.Logging().Errorf("download file[%s] of fs[%s] err: %v", filePath, fsModel.ID, err)
+	}
+}
+
+// uploadFile the function that handle the upload file request
+// @Summary uploadFile
+// @Description 上传小文件到文件系统，请求体为文件内容或multipart表单中的file字段，上级目录不存在时自动创建
+// @tag fs
+// @Accept   octet-stream
+// @Produce  json
+// @Param fsName path string true "文件系统名称"
+// @Param path query string true "文件路径"
+// @Param overwrite query bool false "是否覆盖已存在的文件"
+// @Param username query string false "root用户指定其他用户"
+// @Success 201 {object} fs.FsFileInfo
+// @Router /fs/{fsName}/files/upload [post]
+func (pr *PFSRouter) uploadFile(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	overwrite := false
+	if value := r.URL.Query().Get(util.QueryOverwrite); value != "" {
+		var err error
+		if overwrite, err = strconv.ParseBool(value); err != nil {
+			ctx.ErrorCode = common.InvalidURI
+			common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, fmt.Sprintf("overwrite[%s] should be bool", value))
+			return
+		}
+	}
+	if r.ContentLength > api.MaxUploadFileSize {
+		ctx.ErrorCode = common.FileSystemFileTooLarge
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode,
+			fmt.Sprintf("file size exceeds limit %d bytes", api.MaxUploadFileSize))
+		return
+	}
+	fsModel, filePath, ok := getFsAndPath(w, r, &ctx)
+	if !ok {
+		return
+	}
+	log.Infof("upload file[%s] to fs[%s] by user[%s] with overwrite[%t]", filePath, fsModel.ID, ctx.UserName, overwrite)
+
+	var body io.Reader = r.Body
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); strings.HasPrefix(mediaType, "multipart/") {
+		file, header, err := r.FormFile(uploadFormFile)
+		if err != nil {
+			ctx.ErrorCode = common.InvalidURI
+			common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode,
+				fmt.Sprintf("get form file[%s] err: %v", uploadFormFile, err))
+			return
+		}
+		defer file.Close()
+		body = file
+		if filePath == "/" || strings.HasSuffix(r.URL.Query().Get(util.QueryPath), "/") {
+			// path为目录时使用表单中的文件名
+			filePath = path.Join(filePath, path.Base("/"+header.Filename))
+		}
+	}
+
+	response, err := api.UploadFile(&ctx, fsModel.ID, filePath, body, overwrite)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusCreated, response)
+}
+
+// uploadFilePart the function that handle the upload file part request
+// @Summary uploadFilePart
+// @Description 分片上传大文件，请求体为分片内容，重复上传同一分片时覆盖，全部上传后调用complete合并
+// @tag fs
+// @Accept   octet-stream
+// @Produce  json
+// @Param fsName path string true "文件系统名称"
+// @Param path query string true "文件路径"
+// @Param partNumber query int true "分片编号，从1开始"
+// @Param username query string false "root用户指定其他用户"
+// @Success 200 {object} fs.FilePart
+// @Router /fs/{fsName}/files/parts [put]
+func (pr *PFSRouter) uploadFilePart(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	partNumber, err := strconv.Atoi(r.URL.Query().Get(util.QueryPartNumber))
+	if err != nil {
+		ctx.ErrorCode = common.InvalidURI
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode,
+			fmt.Sprintf("partNumber[%s] should be int", r.URL.Query().Get(util.QueryPartNumber)))
+		return
+	}
+	if r.ContentLength > api.MaxUploadFileSize {
+		ctx.ErrorCode = common.FileSystemFileTooLarge
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode,
+			fmt.Sprintf("part size exceeds limit %d bytes", api.MaxUploadFileSize))
+		return
+	}
+	fsModel, filePath, ok := getFsAndPath(w, r, &ctx)
+	if !ok {
+		return
+	}
+	log.Debugf("upload part[%d] of file[%s] to fs[%s] by user[%s]", partNumber, filePath, fsModel.ID, ctx.UserName)
+
+	response, err := api.UploadFilePart(&ctx, fsModel.ID, filePath, partNumber, r.Body)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusOK, response)
+}
+
+// listFileParts the function that handle the list file parts request
+// @Summary listFileParts
+// @Description 列出已上传的分片，用于断点续传
+// @tag fs
+// @Accept   json
+// @Produce  json
+// @Param fsName path string true "文件系统名称"
+// @Param path query string true "文件路径"
+// @Param username query string false "root用户指定其他用户"
+// @Success 200 {object} fs.ListFilePartsResponse
+// @Router /fs/{fsName}/files/parts [get]
+func (pr *PFSRouter) listFileParts(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	fsModel, filePath, ok := getFsAndPath(w, r, &ctx)
+	if !ok {
+		return
+	}
+	response, err := api.ListFileParts(&ctx, fsModel.ID, filePath)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusOK, response)
+}
+
+// completeFileUpload the function that handle the complete file upload request
+// @Summary completeFileUpload
+// @Description 按序合并已上传的分片为目标文件
+// @tag fs
+// @Accept   json
+// @Produce  json
+// @Param fsName path string true "文件系统名称"
+// @Param path query string true "文件路径"
+// @Param parts query int true "分片总数"
+// @Param overwrite query bool false "是否覆盖已存在的文件"
+// @Param username query string false "root用户指定其他用户"
+// @Success 201 {object} fs.FsFileInfo
+// @Router /fs/{fsName}/files/parts/complete [post]
+func (pr *PFSRouter) completeFileUpload(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	parts, err := strconv.Atoi(r.URL.Query().Get(util.QueryParts))
+	if err != nil {
+		ctx.ErrorCode = common.InvalidURI
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode,
+			fmt.Sprintf("parts[%s] should be int", r.URL.Query().Get(util.QueryParts)))
+		return
+	}
+	overwrite := false
+	if value := r.URL.Query().Get(util.QueryOverwrite); value != "" {
+		if overwrite, err = strconv.ParseBool(value); err != nil {
+			ctx.ErrorCode = common.InvalidURI
+			common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, fmt.Sprintf("overwrite[%s] should be bool", value))
+			return
+		}
+	}
+	fsModel, filePath, ok := getFsAndPath(w, r, &ctx)
+	if !ok {
+		return
+	}
+	log.Infof("complete upload of file[%s] with %d parts to fs[%s] by user[%s]", filePath, parts, fsModel.ID, ctx.UserName)
+
+	response, err := api.CompleteFileUpload(&ctx, fsModel.ID, filePath, parts, overwrite)
+	if err != nil {
+		common.RenderError(w, ctx.RequestID, ctx.ErrorCode, err)
+		return
+	}
+	common.Render(w, http.StatusCreated, response)
+}
+
+// abortFileUpload the function that handle the abort file upload request
+// @Summary abortFileUpload
+// @Description 删除已上传的分片
+// @tag fs
+// @Accept   json
+// @Produce  json
+// @Param fsName path string true "文件系统名称"
+// @Param path query string true "文件路径"
+// @Param username query string false "root用户指定其他用户"
+// @Success 200
+// @Router /fs/{fsName}/files/parts [delete]
+func (pr *PFSRouter) abortFileUpload(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	fsModel, filePath, ok := getFsAndPath(w, r, &ctx)
+	if !ok {
+		return
+	}
+	if err := api.AbortFileUpload(&ctx, fsModel.ID, filePath); err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.RenderStatus(w, http.StatusOK)
+}
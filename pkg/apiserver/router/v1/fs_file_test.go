@@ -0,0 +1,118 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/controller/fs"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/handler"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+)
+
+func TestFileBrowser(t *testing.T) {
+	router, baseUrl := prepareDBAndAPI(t)
+	mockFs := mockFS()
+	err := storage.Filesystem.CreatFileSystem(&mockFs)
+	assert.Nil(t, err)
+
+	newFsHandler := handler.NewFsHandlerWithServer
+	handler.NewFsHandlerWithServer = handler.MockerNewFsHandlerWithServer
+	defer func() {
+		handler.NewFsHandlerWithServer = newFsHandler
+		os.RemoveAll("./mock_fs_handler")
+	}()
+	filesUrl := baseUrl + "/fs/" + mockFsName + "/files"
+
+	// 上传文件，上级目录自动创建
+	req, _ := http.NewRequest("POST", filesUrl+"/upload?path=/data/a.txt", bytes.NewBufferString("hello"))
+	result := httptest.NewRecorder()
+	router.ServeHTTP(result, req)
+	assert.Equal(t, http.StatusCreated, result.Code)
+	info := fs.FsFileInfo{}
+	err = ParseBody(result.Body, &info)
+	assert.Nil(t, err)
+	assert.Equal(t, "/data/a.txt", info.Path)
+	assert.Equal(t, int64(5), info.Size)
+
+	// 不指定overwrite时不覆盖
+	req, _ = http.NewRequest("POST", filesUrl+"/upload?path=/data/a.txt", bytes.NewBufferString("world"))
+	result = httptest.NewRecorder()
+	router.ServeHTTP(result, req)
+	assert.Equal(t, http.StatusConflict, result.Code)
+
+	// 表单上传到目录时使用表单中的文件名
+	result, err = PerformFileUploadRequest(router, filesUrl+"/upload?path=/data/", "b.txt", []byte("abc"), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusCreated, result.Code)
+
+	result, err = PerformGetRequest(router, filesUrl+"?path=/data&maxKeys=1")
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, result.Code)
+	listRsp := fs.ListFilesResponse{}
+	err = ParseBody(result.Body, &listRsp)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(listRsp.Files))
+	assert.Equal(t, "a.txt", listRsp.Files[0].Name)
+	assert.True(t, listRsp.Truncated)
+
+	result, err = PerformGetRequest(router, filesUrl+"?path=/data&marker="+listRsp.NextMarker)
+	assert.Nil(t, err)
+	listRsp = fs.ListFilesResponse{}
+	err = ParseBody(result.Body, &listRsp)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(listRsp.Files))
+	assert.Equal(t, "b.txt", listRsp.Files[0].Name)
+	assert.False(t, listRsp.Truncated)
+
+	result, err = PerformGetRequest(router, filesUrl+"/stat?path=/data")
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, result.Code)
+	info = fs.FsFileInfo{}
+	err = ParseBody(result.Body, &info)
+	assert.Nil(t, err)
+	assert.True(t, info.IsDir)
+
+	result, err = PerformGetRequest(router, filesUrl+"/download?path=/data/a.txt")
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, result.Code)
+	assert.Equal(t, "hello", result.Body.String())
+	assert.Equal(t, "attachment; filename=a.txt", result.Header().Get("Content-Disposition"))
+
+	result, err = PerformGetRequest(router, filesUrl+"/download?path=/data")
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusForbidden, result.Code)
+
+	result, err = PerformGetRequest(router, filesUrl+"/stat?path=/not_exist")
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNotFound, result.Code)
+
+	// 根目录下的内部节点不允许访问
+	result, err = PerformGetRequest(router, filesUrl+"/stat?path=/.stats")
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, result.Code)
+
+	result, err = PerformGetRequest(router, baseUrl+"/fs/notexist/files")
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNotFound, result.Code)
+}
@@ -0,0 +1,146 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	api "github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/controller/fs"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/router/util"
+)
+
+// createLifecycleRule the function that handle the create fs lifecycle rule request
+// @Summary createLifecycleRule
+// @Description 创建生命周期规则，定期将长期未修改的文件转为归档存储或删除，dryRun为true时只生成报告
+// @tag fs
+// @Accept   json
+// @Produce  json
+// @Param fsName path string true "存储名称"
+// @Param username query string false "root用户指定其他用户"
+// @Param request body fs.CreateLifecycleRuleRequest true "生命周期规则"
+// @Success 201 {object} model.FsLifecycleRule
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /fs/{fsName}/lifecycle [post]
+func (pr *PFSRouter) createLifecycleRule(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	var request api.CreateLifecycleRuleRequest
+	if err := common.BindJSON(r, &request); err != nil {
+		ctx.Logging().Errorf("create lifecycle rule bindjson failed. err:%s", err.Error())
+		common.RenderErrWithMessage(w, ctx.RequestID, common.MalformedJSON, err.Error())
+		return
+	}
+	fsModel, ok := getFsModel(w, r, &ctx)
+	if !ok {
+		return
+	}
+	response, err := api.CreateLifecycleRule(&ctx, fsModel, request)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusCreated, response)
+}
+
+// listLifecycleRule the function that handle the list fs lifecycle rule request
+// @Summary listLifecycleRule
+// @Description 获取文件系统的生命周期规则及最近一次执行的报告
+// @tag fs
+// @Accept   json
+// @Produce  json
+// @Param fsName path string true "存储名称"
+// @Param username query string false "root用户指定其他用户"
+// @Success 200 {object} fs.ListLifecycleRuleResponse
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /fs/{fsName}/lifecycle [get]
+func (pr *PFSRouter) listLifecycleRule(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	fsModel, ok := getFsModel(w, r, &ctx)
+	if !ok {
+		return
+	}
+	response, err := api.ListLifecycleRule(&ctx, fsModel)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusOK, response)
+}
+
+// updateLifecycleRule the function that handle the update fs lifecycle rule request
+// @Summary updateLifecycleRule
+// @Description 修改生命周期规则的天数或dry-run模式
+// @tag fs
+// @Accept   json
+// @Produce  json
+// @Param fsName path string true "存储名称"
+// @Param ruleName path string true "规则名称"
+// @Param username query string false "root用户指定其他用户"
+// @Param request body fs.UpdateLifecycleRuleRequest true "修改内容"
+// @Success 200 {object} model.FsLifecycleRule
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /fs/{fsName}/lifecycle/{ruleName} [put]
+func (pr *PFSRouter) updateLifecycleRule(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	var request api.UpdateLifecycleRuleRequest
+	if err := common.BindJSON(r, &request); err != nil {
+		ctx.Logging().Errorf("update lifecycle rule bindjson failed. err:%s", err.Error())
+		common.RenderErrWithMessage(w, ctx.RequestID, common.MalformedJSON, err.Error())
+		return
+	}
+	fsModel, ok := getFsModel(w, r, &ctx)
+	if !ok {
+		return
+	}
+	response, err := api.UpdateLifecycleRule(&ctx, fsModel, chi.URLParam(r, util.ParamKeyRuleName), request)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusOK, response)
+}
+
+// deleteLifecycleRule the function that handle the delete fs lifecycle rule request
+// @Summary deleteLifecycleRule
+// @Description 删除生命周期规则，已归档或删除的文件不会恢复
+// @tag fs
+// @Accept   json
+// @Produce  json
+// @Param fsName path string true "存储名称"
+// @Param ruleName path string true "规则名称"
+// @Param username query string false "root用户指定其他用户"
+// @Success 200 "删除成功"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /fs/{fsName}/lifecycle/{ruleName} [delete]
+func (pr *PFSRouter) deleteLifecycleRule(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	fsModel, ok := getFsModel(w, r, &ctx)
+	if !ok {
+		return
+	}
+	if err := api.DeleteLifecycleRule(&ctx, fsModel, chi.URLParam(r, util.ParamKeyRuleName)); err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.RenderStatus(w, http.StatusOK)
+}
@@ -0,0 +1,148 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	api "github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/controller/fs"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/router/util"
+)
+
+// createReplication the function that handle the create fs replication request
+// @Summary createReplication
+// @Description 开启文件系统到副本存储的异步复制，副本须为同一用户的另一个文件系统
+// @tag fs
+// @Accept   json
+// @Produce  json
+// @Param fsName path string true "主存储名称"
+// @Param username query string false "root用户指定其他用户"
+// @Param request body fs.CreateReplicationRequest true "副本存储"
+// @Success 201 {object} model.FsReplication
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /fs/{fsName}/replication [post]
+func (pr *PFSRouter) createReplication(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	var request api.CreateReplicationRequest
+	if err := common.BindJSON(r, &request); err != nil {
+		ctx.Logging().Errorf("create replication bindjson failed. err:%s", err.Error())
+		common.RenderErrWithMessage(w, ctx.RequestID, common.MalformedJSON, err.Error())
+		return
+	}
+	fsModel, ok := getFsModel(w, r, &ctx)
+	if !ok {
+		return
+	}
+	response, err := api.CreateReplication(&ctx, fsModel, request)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusCreated, response)
+}
+
+// getReplication the function that handle the get fs replication request
+// @Summary getReplication
+// @Description 获取文件系统的复制状态及复制延迟
+// @tag fs
+// @Accept   json
+// @Produce  json
+// @Param fsName path string true "主存储名称"
+// @Param username query string false "root用户指定其他用户"
+// @Success 200 {object} model.FsReplication
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /fs/{fsName}/replication [get]
+func (pr *PFSRouter) getReplication(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	fsModel, ok := getFsModel(w, r, &ctx)
+	if !ok {
+		return
+	}
+	response, err := api.GetReplication(&ctx, fsModel)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusOK, response)
+}
+
+// updateReplication the function that handle the failover or failback request
+// @Summary updateReplication
+// @Description 故障切换时互换主存储与副本的存储地址，之后新挂载的文件系统从副本读取；切回时恢复原地址并继续复制
+// @tag fs
+// @Accept   json
+// @Produce  json
+// @Param fsName path string true "主存储名称"
+// @Param username query string false "root用户指定其他用户"
+// @Param action query string true "failover或failback"
+// @Success 200 "操作成功"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /fs/{fsName}/replication [put]
+func (pr *PFSRouter) updateReplication(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	fsModel, ok := getFsModel(w, r, &ctx)
+	if !ok {
+		return
+	}
+	action := r.URL.Query().Get(util.QueryKeyAction)
+	var err error
+	switch action {
+	case util.QueryActionFailover:
+		err = api.FailoverReplication(&ctx, fsModel)
+	case util.QueryActionFailback:
+		err = api.FailbackReplication(&ctx, fsModel)
+	default:
+		ctx.ErrorCode = common.InvalidURI
+		err = fmt.Errorf("invalid action[%s] for update replication", action)
+	}
+	if err != nil {
+		ctx.Logging().Errorf("update replication of fs[%s] with action[%s] failed. error: %v", fsModel.ID, action, err)
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.RenderStatus(w, http.StatusOK)
+}
+
+// deleteReplication the function that handle the delete fs replication request
+// @Summary deleteReplication
+// @Description 停止复制，副本中已复制的数据保留
+// @tag fs
+// @Accept   json
+// @Produce  json
+// @Param fsName path string true "主存储名称"
+// @Param username query string false "root用户指定其他用户"
+// @Success 200 "删除成功"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /fs/{fsName}/replication [delete]
+func (pr *PFSRouter) deleteReplication(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	fsModel, ok := getFsModel(w, r, &ctx)
+	if !ok {
+		return
+	}
+	if err := api.DeleteReplication(&ctx, fsModel); err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.RenderStatus(w, http.StatusOK)
+}
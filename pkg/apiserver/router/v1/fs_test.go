@@ -22,12 +22,14 @@ import (
 	"os"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/agiledragon/gomonkey/v2"
 	"github.com/stretchr/testify/assert"
 	k8sCore "k8s.io/api/core/v1"
 
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/controller/fs"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/handler"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
 	fsCommon "github.com/PaddlePaddle/PaddleFlow/pkg/fs/common"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/job/runtime"
@@ -108,6 +110,32 @@ func Test_validateCreateFileSystem(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "hdfs blockSize and replication ok",
+			args: args{
+				ctx: ctx,
+				req: &fs.CreateFileSystemRequest{Name: "testname", Username: "testUsername", Url: "hdfs://127.0.0.1:9000/myfs/data", Properties: map[string]string{"user": "test", "group": "test", fsCommon.BlockSizeKey: "134217728", fsCommon.ReplicationKey: "2"}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "hdfs replication wrong",
+			args: args{
+				ctx: ctx,
+				req: &fs.CreateFileSystemRequest{Name: "testname", Username: "testUsername", Url: "hdfs://127.0.0.1:9000/myfs/data", Properties: map[string]string{"user": "test", "group": "test", fsCommon.ReplicationKey: "two"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "hdfs kerberos data transfer protection wrong",
+			args: args{
+				ctx: ctx,
+				req: &fs.CreateFileSystemRequest{Name: "testname", Username: "testUsername", Url: "hdfs://127.0.0.1:9000/myfs/data", Properties: map[string]string{
+					fsCommon.KeyTabData: "dGVzdA==", fsCommon.Principal: "user@EXAMPLE.COM", fsCommon.Kdc: "127.0.0.1",
+					fsCommon.NameNodePrincipal: "nn/host@EXAMPLE.COM", fsCommon.Realm: "EXAMPLE.COM", fsCommon.DataTransferProtection: "none"}},
+			},
+			wantErr: true,
+		},
 		{
 			name: "hdfs url miss address",
 			args: args{
@@ -116,6 +144,102 @@ func Test_validateCreateFileSystem(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "gcs ok",
+			args: args{
+				ctx: ctx,
+				req: &fs.CreateFileSystemRequest{Name: "testname", Username: "testUsername", Url: "gcs://bucket/data", Properties: map[string]string{fsCommon.AccessKey: "testak", fsCommon.SecretKey: "testsk"}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "gcs hmac key empty",
+			args: args{
+				ctx: ctx,
+				req: &fs.CreateFileSystemRequest{Name: "testname", Username: "testUsername", Url: "gcs://bucket/data", Properties: map[string]string{}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "oss region ok",
+			args: args{
+				ctx: ctx,
+				req: &fs.CreateFileSystemRequest{Name: "testname", Username: "testUsername", Url: "oss://bucket/data", Properties: map[string]string{fsCommon.AccessKey: "testak", fsCommon.SecretKey: "testsk", fsCommon.Region: "cn-beijing"}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "cos endpoint and region empty",
+			args: args{
+				ctx: ctx,
+				req: &fs.CreateFileSystemRequest{Name: "testname", Username: "testUsername", Url: "cos://bucket-125/data", Properties: map[string]string{fsCommon.AccessKey: "testak", fsCommon.SecretKey: "testsk"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative capacity quota",
+			args: args{
+				ctx: ctx,
+				req: &fs.CreateFileSystemRequest{Name: "testname", Username: "testUsername", Url: "oss://bucket/data", CapacityQuota: -1, Properties: map[string]string{fsCommon.AccessKey: "testak", fsCommon.SecretKey: "testsk", fsCommon.Region: "cn-beijing"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "abs sas token ok",
+			args: args{
+				ctx: ctx,
+				req: &fs.CreateFileSystemRequest{Name: "testname", Username: "testUsername", Url: "abs://container/data", Properties: map[string]string{fsCommon.AccountName: "account", fsCommon.SASToken: "sv=2020-10-02&sig=xxx"}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "abs account key not base64",
+			args: args{
+				ctx: ctx,
+				req: &fs.CreateFileSystemRequest{Name: "testname", Username: "testUsername", Url: "abs://container/data", Properties: map[string]string{fsCommon.AccountName: "account", fsCommon.AccountKey: "not-base64!"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "abs credential empty",
+			args: args{
+				ctx: ctx,
+				req: &fs.CreateFileSystemRequest{Name: "testname", Username: "testUsername", Url: "abs://container/data", Properties: map[string]string{fsCommon.AccountName: "account"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "nfs ok",
+			args: args{
+				ctx: ctx,
+				req: &fs.CreateFileSystemRequest{Name: "testname", Username: "testUsername", Url: "nfs://127.0.0.1/export/data", Properties: map[string]string{fsCommon.MountOptions: "vers=4.1,hard"}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "nfs mount options wrong",
+			args: args{
+				ctx: ctx,
+				req: &fs.CreateFileSystemRequest{Name: "testname", Username: "testUsername", Url: "nfs://127.0.0.1/export/data", Properties: map[string]string{fsCommon.MountOptions: "hard;reboot"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "cephfs ok",
+			args: args{
+				ctx: ctx,
+				req: &fs.CreateFileSystemRequest{Name: "testname", Username: "testUsername", Url: "cephfs://10.0.0.1:6789,10.0.0.2:6789/volumes/data", Properties: map[string]string{fsCommon.CephUser: "admin", fsCommon.CephSecret: "secret"}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "cephfs secret without user",
+			args: args{
+				ctx: ctx,
+				req: &fs.CreateFileSystemRequest{Name: "testname", Username: "testUsername", Url: "cephfs://10.0.0.1:6789/volumes/data", Properties: map[string]string{fsCommon.CephSecret: "secret"}},
+			},
+			wantErr: true,
+		},
 		{
 			name: "s3 url no path wrong",
 			args: args{
@@ -148,6 +272,24 @@ func Test_validateCreateFileSystem(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "s3 multipart properties",
+			args: args{
+				ctx: ctx,
+				req: &fs.CreateFileSystemRequest{Name: "testname", Username: "testUsername", Url: "s3://bucket/mpu", Properties: map[string]string{fsCommon.Endpoint: "bj.bos.com", fsCommon.Region: "bj", fsCommon.AccessKey: "testak", fsCommon.SecretKey: "testsk",
+					fsCommon.MultipartPartSize: "64Mi", fsCommon.MultipartConcurrency: "16", fsCommon.MultipartBufferPoolSize: "2Gi"}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "s3 multipart part size too small",
+			args: args{
+				ctx: ctx,
+				req: &fs.CreateFileSystemRequest{Name: "testname", Username: "testUsername", Url: "s3://bucket/mpu", Properties: map[string]string{fsCommon.Endpoint: "bj.bos.com", fsCommon.Region: "bj", fsCommon.AccessKey: "testak", fsCommon.SecretKey: "testsk",
+					fsCommon.MultipartPartSize: "1Mi"}},
+			},
+			wantErr: true,
+		},
 		{
 			name: "local url wrong",
 			args: args{
@@ -509,6 +651,93 @@ func TestCreateFSAndDeleteFs(t *testing.T) {
 	assert.Equal(t, http.StatusForbidden, result.Code)
 }
 
+func TestGetFileSystemHealth(t *testing.T) {
+	router, baseUrl := prepareDBAndAPI(t)
+	mockFs := mockFS()
+	err := storage.Filesystem.CreatFileSystem(&mockFs)
+	assert.Nil(t, err)
+
+	newFsHandler := handler.NewFsHandlerWithServer
+	handler.NewFsHandlerWithServer = handler.MockerNewFsHandlerWithServer
+	defer func() {
+		handler.NewFsHandlerWithServer = newFsHandler
+		os.RemoveAll("./mock_fs_handler")
+	}()
+
+	healthUrl := baseUrl + "/fs/" + mockFsName + "/health"
+	result, err := PerformGetRequest(router, healthUrl)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, result.Code)
+	healthRsp := fs.FileSystemHealthResponse{}
+	err = ParseBody(result.Body, &healthRsp)
+	assert.Nil(t, err)
+	assert.True(t, healthRsp.Healthy)
+	assert.True(t, healthRsp.Backend.Connected)
+	assert.Nil(t, healthRsp.Fsck)
+
+	result, err = PerformGetRequest(router, healthUrl+"?mode=fsck&repair=true")
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, result.Code)
+	healthRsp = fs.FileSystemHealthResponse{}
+	err = ParseBody(result.Body, &healthRsp)
+	assert.Nil(t, err)
+	assert.NotNil(t, healthRsp.Fsck)
+
+	result, err = PerformGetRequest(router, healthUrl+"?mode=deep")
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, result.Code)
+
+	result, err = PerformGetRequest(router, healthUrl+"?repair=yes")
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, result.Code)
+
+	result, err = PerformGetRequest(router, baseUrl+"/fs/notexist/health")
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNotFound, result.Code)
+}
+
+func TestGetFileSystemDu(t *testing.T) {
+	router, baseUrl := prepareDBAndAPI(t)
+	mockFs := mockFS()
+	err := storage.Filesystem.CreatFileSystem(&mockFs)
+	assert.Nil(t, err)
+
+	duUrl := baseUrl + "/fs/" + mockFsName + "/du"
+	result, err := PerformGetRequest(router, duUrl+"?days=0")
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, result.Code)
+
+	// 尚未扫描
+	result, err = PerformGetRequest(router, duUrl)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNotFound, result.Code)
+
+	scanTime := time.Now().Truncate(time.Millisecond)
+	err = storage.FsUsage.CreateUsages(logger.LoggerForRequest(&logger.RequestContext{}), []model.FsUsage{
+		{FsID: mockFs.ID, Path: model.FsUsageRootPath, Size: 30, Files: 3, Dirs: 1, ScanTime: scanTime},
+		{FsID: mockFs.ID, Path: "/data", Size: 20, Files: 2, ScanTime: scanTime},
+	})
+	assert.Nil(t, err)
+	result, err = PerformGetRequest(router, duUrl+"?days=3")
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, result.Code)
+	duRsp := fs.FileSystemDuResponse{}
+	err = ParseBody(result.Body, &duRsp)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(30), duRsp.Total.Size)
+	assert.Equal(t, 1, len(duRsp.Directories))
+	assert.Equal(t, "/data", duRsp.Directories[0].Path)
+
+	result, err = PerformGetRequest(router, baseUrl+"/fsUsage")
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, result.Code)
+	listRsp := fs.ListFileSystemDuResponse{}
+	err = ParseBody(result.Body, &listRsp)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(listRsp.FsList))
+	assert.Equal(t, mockFsName, listRsp.FsList[0].FsName)
+}
+
 func RandomString(n int) string {
 	var letterRunes = []rune("0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ")
 
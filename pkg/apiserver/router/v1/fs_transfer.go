@@ -0,0 +1,167 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	api "github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/controller/fs"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/router/util"
+)
+
+// createTransfer
+// @Summary 创建存储间数据迁移任务
+// @Description 启动迁移pod挂载源存储与目标存储，并行复制数据并校验
+// @Id createTransfer
+// @tags FileSystem
+// @Accept  json
+// @Produce json
+// @Param request body fs.CreateTransferRequest true "创建迁移任务请求"
+// @Success 201 {object} fs.CreateTransferResponse "创建迁移任务响应"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /fsTransfer [POST]
+func (pr *PFSRouter) createTransfer(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	var request api.CreateTransferRequest
+	if err := common.BindJSON(r, &request); err != nil {
+		ctx.Logging().Errorf("create transfer failed parsing request body:%+v. error:%s", r.Body, err.Error())
+		common.RenderErrWithMessage(w, ctx.RequestID, common.MalformedJSON, err.Error())
+		return
+	}
+	response, err := api.GetFileSystemService().CreateTransfer(&ctx, &request)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusCreated, response)
+}
+
+// listTransfer
+// @Summary 获取迁移任务列表
+// @Description 获取迁移任务列表
+// @Id listTransfer
+// @tags FileSystem
+// @Accept  json
+// @Produce json
+// @Param marker query string false "查询起始位置"
+// @Param maxKeys query int false "每页条数"
+// @Param fsName query string false "源存储或目标存储名称过滤"
+// @Success 200 {object} fs.ListTransferResponse "迁移任务列表"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /fsTransfer [GET]
+func (pr *PFSRouter) listTransfer(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	maxKeys, err := util.GetQueryMaxKeys(&ctx, r)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	marker := r.URL.Query().Get(util.QueryKeyMarker)
+	fsName := r.URL.Query().Get(util.QueryFsName)
+	response, err := api.GetFileSystemService().ListTransfer(&ctx, marker, maxKeys, fsName)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusOK, response)
+}
+
+// getTransfer
+// @Summary 获取迁移任务详情
+// @Description 获取迁移任务详情及进度
+// @Id getTransfer
+// @tags FileSystem
+// @Accept  json
+// @Produce json
+// @Param transferID path string true "迁移任务ID"
+// @Success 200 {object} fs.TransferResponse "迁移任务详情"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /fsTransfer/{transferID} [GET]
+func (pr *PFSRouter) getTransfer(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	id := chi.URLParam(r, util.ParamKeyTransferID)
+	response, err := api.GetFileSystemService().GetTransfer(&ctx, id)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusOK, response)
+}
+
+// updateTransfer
+// @Summary 停止或恢复迁移任务
+// @Description 根据action参数停止或恢复迁移任务，恢复时跳过已复制的文件
+// @Id updateTransfer
+// @tags FileSystem
+// @Accept  json
+// @Produce json
+// @Param transferID path string true "迁移任务ID"
+// @Param action query string true "stop或resume"
+// @Success 200 "操作成功"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /fsTransfer/{transferID} [PUT]
+func (pr *PFSRouter) updateTransfer(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	id := chi.URLParam(r, util.ParamKeyTransferID)
+	action := r.URL.Query().Get(util.QueryKeyAction)
+	var err error
+	switch action {
+	case util.QueryActionStop:
+		err = api.GetFileSystemService().StopTransfer(&ctx, id)
+	case util.QueryActionResume:
+		err = api.GetFileSystemService().ResumeTransfer(&ctx, id)
+	default:
+		ctx.ErrorCode = common.InvalidURI
+		err = fmt.Errorf("invalid action[%s] for update transfer", action)
+	}
+	if err != nil {
+		ctx.Logging().Errorf("update transfer[%s] with action[%s] failed. error: %v", id, action, err)
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.RenderStatus(w, http.StatusOK)
+}
+
+// deleteTransfer
+// @Summary 删除迁移任务
+// @Description 停止迁移pod并删除任务记录，已复制的数据保留
+// @Id deleteTransfer
+// @tags FileSystem
+// @Accept  json
+// @Produce json
+// @Param transferID path string true "迁移任务ID"
+// @Success 200 "删除成功"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /fsTransfer/{transferID} [DELETE]
+func (pr *PFSRouter) deleteTransfer(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	id := chi.URLParam(r, util.ParamKeyTransferID)
+	if err := api.GetFileSystemService().DeleteTransfer(&ctx, id); err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.RenderStatus(w, http.StatusOK)
+}
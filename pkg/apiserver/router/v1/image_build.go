@@ -0,0 +1,178 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/controller/imagebuild"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/router/util"
+)
+
+type ImageBuildRouter struct{}
+
+func (ir *ImageBuildRouter) Name() string {
+	return "ImageBuildRouter"
+}
+
+func (ir *ImageBuildRouter) AddRouter(r chi.Router) {
+	log.Info("add image build router")
+	r.Post("/imageBuild", ir.createImageBuild)
+	r.Get("/imageBuild", ir.listImageBuild)
+	r.Get("/imageBuild/{imageBuildID}", ir.getImageBuild)
+	r.Put("/imageBuild/{imageBuildID}", ir.updateImageBuild)
+	r.Delete("/imageBuild/{imageBuildID}", ir.deleteImageBuild)
+}
+
+// createImageBuild
+// @Summary 创建镜像构建任务
+// @Description 由Dockerfile或基础镜像加pip依赖构建镜像并推送到仓库，返回镜像地址
+// @Id createImageBuild
+// @tags ImageBuild
+// @Accept  json
+// @Produce json
+// @Param request body imagebuild.CreateImageBuildRequest true "创建镜像构建任务请求"
+// @Success 201 {object} imagebuild.CreateImageBuildResponse "创建镜像构建任务响应"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /imageBuild [POST]
+func (ir *ImageBuildRouter) createImageBuild(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	var request imagebuild.CreateImageBuildRequest
+	if err := common.BindJSON(r, &request); err != nil {
+		ctx.Logging().Errorf("create image build failed parsing request body:%+v. error:%s", r.Body, err.Error())
+		common.RenderErrWithMessage(w, ctx.RequestID, common.MalformedJSON, err.Error())
+		return
+	}
+	response, err := imagebuild.CreateImageBuild(&ctx, request)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusCreated, response)
+}
+
+// listImageBuild
+// @Summary 获取镜像构建任务列表
+// @Description 获取镜像构建任务列表
+// @Id listImageBuild
+// @tags ImageBuild
+// @Accept  json
+// @Produce json
+// @Param marker query string false "查询起始位置"
+// @Param maxKeys query int false "每页条数"
+// @Param name query string false "镜像名称过滤"
+// @Success 200 {object} imagebuild.ListImageBuildResponse "镜像构建任务列表"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /imageBuild [GET]
+func (ir *ImageBuildRouter) listImageBuild(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	maxKeys, err := util.GetQueryMaxKeys(&ctx, r)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	marker := r.URL.Query().Get(util.QueryKeyMarker)
+	name := r.URL.Query().Get(util.QueryKeyName)
+	response, err := imagebuild.ListImageBuild(&ctx, marker, maxKeys, name)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusOK, response)
+}
+
+// getImageBuild
+// @Summary 获取镜像构建任务详情
+// @Description 获取镜像构建任务详情，构建失败时message中包含构建日志的最后几行
+// @Id getImageBuild
+// @tags ImageBuild
+// @Accept  json
+// @Produce json
+// @Param imageBuildID path string true "镜像构建任务ID"
+// @Success 200 {object} model.ImageBuild "镜像构建任务详情"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /imageBuild/{imageBuildID} [GET]
+func (ir *ImageBuildRouter) getImageBuild(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	id := chi.URLParam(r, util.ParamKeyImageBuildID)
+	response, err := imagebuild.GetImageBuild(&ctx, id)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusOK, response)
+}
+
+// updateImageBuild
+// @Summary 停止镜像构建任务
+// @Description 删除构建pod，终止未完成的构建
+// @Id updateImageBuild
+// @tags ImageBuild
+// @Accept  json
+// @Produce json
+// @Param imageBuildID path string true "镜像构建任务ID"
+// @Param action query string true "修改动作"
+// @Success 200 "停止成功"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /imageBuild/{imageBuildID} [PUT]
+func (ir *ImageBuildRouter) updateImageBuild(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	id := chi.URLParam(r, util.ParamKeyImageBuildID)
+	action := r.URL.Query().Get(util.QueryKeyAction)
+	if action != util.QueryActionStop {
+		ctx.ErrorCode = common.InvalidURI
+		err := fmt.Errorf("invalid action[%s] for update image build", action)
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	if err := imagebuild.StopImageBuild(&ctx, id); err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.RenderStatus(w, http.StatusOK)
+}
+
+// deleteImageBuild
+// @Summary 删除镜像构建任务
+// @Description 删除构建pod及记录，已推送的镜像不受影响
+// @Id deleteImageBuild
+// @tags ImageBuild
+// @Accept  json
+// @Produce json
+// @Param imageBuildID path string true "镜像构建任务ID"
+// @Success 200 "删除成功"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /imageBuild/{imageBuildID} [DELETE]
+func (ir *ImageBuildRouter) deleteImageBuild(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	id := chi.URLParam(r, util.ParamKeyImageBuildID)
+	if err := imagebuild.DeleteImageBuild(&ctx, id); err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.RenderStatus(w, http.StatusOK)
+}
@@ -41,6 +41,8 @@ import (
 // JobRouter is job api router
 type JobRouter struct{}
 
+const defaultWatchJobTimeout = 30 * time.Second
+
 var (
 	upgrader = websocket.Upgrader{
 		CheckOrigin: func(r *http.Request) bool {
@@ -60,6 +62,9 @@ func (jr *JobRouter) AddRouter(r chi.Router) {
 	r.Post("/job/single", jr.CreateSingleJob)
 	r.Post("/job/distributed", jr.CreateDistributedJob)
 	r.Post("/job/workflow", jr.CreateWorkflowJob)
+	r.Post("/job/serving", jr.CreateServingJob)
+	r.Post("/job/simulate", jr.SimulateJob)
+	r.Post("/job/adopt", jr.AdoptJobs)
 
 	r.Delete("/job/{jobID}", jr.DeleteJob)
 	r.Put("/job/{jobID}", func(w http.ResponseWriter, r *http.Request) {
@@ -70,6 +75,10 @@ func (jr *JobRouter) AddRouter(r chi.Router) {
 			jr.StopJob(w, r)
 		case util.QueryActionModify:
 			jr.UpdateJob(w, r)
+		case util.QueryActionApprove:
+			jr.ApproveJob(w, r)
+		case util.QueryActionReject:
+			jr.RejectJob(w, r)
 		default:
 			common.RenderErr(w, ctx.RequestID, common.ActionNotAllowed)
 		}
@@ -78,6 +87,8 @@ func (jr *JobRouter) AddRouter(r chi.Router) {
 	r.Get("/wsjob", jr.GetJobByWebsocket)
 	r.Get("/job", jr.ListJob)
 	r.Get("/job/{jobID}", jr.GetJob)
+	r.Get("/job/{jobID}/watch", jr.WatchJob)
+	r.Get("/job/{jobID}/artifacts", jr.ListJobArtifacts)
 }
 
 // CreateSingleJob create single job
@@ -97,18 +108,22 @@ func (jr *JobRouter) CreateSingleJob(w http.ResponseWriter, r *http.Request) {
 	if err := common.BindJSON(r, &request); err != nil {
 		ctx.ErrorCode = common.MalformedJSON
 		logger.LoggerForRequest(&ctx).Errorf("parsing request body failed:%+v. error:%s", r.Body, err.Error())
-		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		common.RenderError(w, ctx.RequestID, ctx.ErrorCode, err)
 		return
 	}
 	log.Debugf("create single job request:%#v", request)
 
 	request.CommonJobInfo.UserName = ctx.UserName
+	if err := job.FillJobDefaults(&ctx, &request.CommonJobInfo, &request.JobSpec); err != nil {
+		common.RenderError(w, ctx.RequestID, ctx.ErrorCode, err)
+		return
+	}
 
 	response, err := job.CreatePFJob(&ctx, request.ToJobInfo())
 	if err != nil {
 		ctx.ErrorCode = common.JobCreateFailed
 		ctx.Logging().Errorf("create job failed. job request:%v error:%s", request, err.Error())
-		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		common.RenderError(w, ctx.RequestID, ctx.ErrorCode, err)
 		return
 	}
 	ctx.Logging().Debugf("CreateJob job:%v", string(config.PrettyFormat(response)))
@@ -132,7 +147,7 @@ func (jr *JobRouter) CreateDistributedJob(w http.ResponseWriter, r *http.Request
 	if err := common.BindJSON(r, &request); err != nil {
 		ctx.ErrorCode = common.MalformedJSON
 		logger.LoggerForRequest(&ctx).Errorf("parsing request body failed:%+v. error:%s", r.Body, err.Error())
-		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		common.RenderError(w, ctx.RequestID, ctx.ErrorCode, err)
 		return
 	}
 	log.Debugf("create distributed job request:%+v", request)
@@ -141,7 +156,7 @@ func (jr *JobRouter) CreateDistributedJob(w http.ResponseWriter, r *http.Request
 	if err != nil {
 		ctx.ErrorCode = common.JobCreateFailed
 		ctx.Logging().Errorf("create job failed. job request:%v error:%s", request, err.Error())
-		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		common.RenderError(w, ctx.RequestID, ctx.ErrorCode, err)
 		return
 	}
 	ctx.Logging().Debugf("CreateJob job:%v", string(config.PrettyFormat(response)))
@@ -165,7 +180,7 @@ func (jr *JobRouter) CreateWorkflowJob(w http.ResponseWriter, r *http.Request) {
 	if err := common.BindJSON(r, &request); err != nil {
 		ctx.ErrorCode = common.MalformedJSON
 		logger.LoggerForRequest(&ctx).Errorf("parsing request body failed:%+v. error:%s", r.Body, err.Error())
-		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		common.RenderError(w, ctx.RequestID, ctx.ErrorCode, err)
 		return
 	}
 	request.CommonJobInfo.UserName = ctx.UserName
@@ -175,13 +190,147 @@ func (jr *JobRouter) CreateWorkflowJob(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		ctx.ErrorCode = common.JobCreateFailed
 		ctx.Logging().Errorf("create job failed. job request:%v error:%s", request, err.Error())
-		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		common.RenderError(w, ctx.RequestID, ctx.ErrorCode, err)
 		return
 	}
 	ctx.Logging().Debugf("CreateJob job:%v", string(config.PrettyFormat(response)))
 	common.Render(w, http.StatusOK, response)
 }
 
+// CreateServingJob create serving job
+// @Summary 创建Serving类型作业
+// @Description 创建Serving类型作业，以Deployment和Service的形式部署模型推理服务，并支持基于QPS或GPU利用率的自动扩缩容
+// @Id createServingJob
+// @tags Job
+// @Accept  json
+// @Produce json
+// @Success 200 {object} job.CreateJobResponse "创建serving类型作业的响应"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Router /job/serving [POST]
+func (jr *JobRouter) CreateServingJob(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+
+	var request job.CreateServingJobRequest
+	if err := common.BindJSON(r, &request); err != nil {
+		ctx.ErrorCode = common.MalformedJSON
+		logger.LoggerForRequest(&ctx).Errorf("parsing request body failed:%+v. error:%s", r.Body, err.Error())
+		common.RenderError(w, ctx.RequestID, ctx.ErrorCode, err)
+		return
+	}
+	log.Debugf("create serving job request:%#v", request)
+
+	request.CommonJobInfo.UserName = ctx.UserName
+	if err := job.FillJobDefaults(&ctx, &request.CommonJobInfo, &request.JobSpec); err != nil {
+		common.RenderError(w, ctx.RequestID, ctx.ErrorCode, err)
+		return
+	}
+
+	response, err := job.CreatePFJob(&ctx, request.ToJobInfo())
+	if err != nil {
+		ctx.ErrorCode = common.JobCreateFailed
+		ctx.Logging().Errorf("create job failed. job request:%v error:%s", request, err.Error())
+		common.RenderError(w, ctx.RequestID, ctx.ErrorCode, err)
+		return
+	}
+	ctx.Logging().Debugf("CreateJob job:%v", string(config.PrettyFormat(response)))
+	common.Render(w, http.StatusOK, response)
+}
+
+// SimulateJob simulate scheduling of job
+// @Summary 模拟调度作业
+// @Description 按队列和集群的当前状态模拟调度作业，返回作业能否立即运行、需要等待或会被拒绝，不会创建作业
+// @Id simulateJob
+// @tags Job
+// @Accept  json
+// @Produce json
+// @Param jobType query string false "作业类型，single、distributed或serving，默认single"
+// @Success 200 {object} job.SimulateJobResponse "模拟调度作业的响应"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Router /job/simulate [POST]
+func (jr *JobRouter) SimulateJob(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+
+	var jobInfo *job.CreateJobInfo
+	var err error
+	switch jobType := r.URL.Query().Get(util.QueryKeyJobType); schema.JobType(jobType) {
+	case "", schema.TypeSingle:
+		var request job.CreateSingleJobRequest
+		if err = common.BindJSON(r, &request); err == nil {
+			request.CommonJobInfo.UserName = ctx.UserName
+			if err = job.FillJobDefaults(&ctx, &request.CommonJobInfo, &request.JobSpec); err != nil {
+				common.RenderError(w, ctx.RequestID, ctx.ErrorCode, err)
+				return
+			}
+			jobInfo = request.ToJobInfo()
+		}
+	case schema.TypeDistributed:
+		var request job.CreateDisJobRequest
+		if err = common.BindJSON(r, &request); err == nil {
+			jobInfo = request.ToJobInfo()
+		}
+	case schema.TypeServing:
+		var request job.CreateServingJobRequest
+		if err = common.BindJSON(r, &request); err == nil {
+			request.CommonJobInfo.UserName = ctx.UserName
+			if err = job.FillJobDefaults(&ctx, &request.CommonJobInfo, &request.JobSpec); err != nil {
+				common.RenderError(w, ctx.RequestID, ctx.ErrorCode, err)
+				return
+			}
+			jobInfo = request.ToJobInfo()
+		}
+	default:
+		ctx.ErrorCode = common.InvalidURI
+		err = fmt.Errorf("job type %s cannot be simulated", jobType)
+		common.RenderError(w, ctx.RequestID, ctx.ErrorCode, err)
+		return
+	}
+	if err != nil {
+		ctx.ErrorCode = common.MalformedJSON
+		logger.LoggerForRequest(&ctx).Errorf("parsing request body failed:%+v. error:%s", r.Body, err.Error())
+		common.RenderError(w, ctx.RequestID, ctx.ErrorCode, err)
+		return
+	}
+
+	response, err := job.SimulateJob(&ctx, jobInfo)
+	if err != nil {
+		ctx.Logging().Errorf("simulate job failed. error:%s", err.Error())
+		common.RenderError(w, ctx.RequestID, ctx.ErrorCode, err)
+		return
+	}
+	common.Render(w, http.StatusOK, response)
+}
+
+// AdoptJobs adopt existing workloads as jobs
+// @Summary 纳管已有的工作负载
+// @Description 扫描队列所在namespace中匹配标签选择器的PaddleJob、TFJob和Deployment，导入为作业并同步状态，仅root用户可操作
+// @Id adoptJobs
+// @tags Job
+// @Accept  json
+// @Produce json
+// @Param request body job.AdoptJobsRequest true "纳管工作负载的请求"
+// @Success 200 {object} job.AdoptJobsResponse "纳管工作负载的响应"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Router /job/adopt [POST]
+func (jr *JobRouter) AdoptJobs(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+
+	var request job.AdoptJobsRequest
+	if err := common.BindJSON(r, &request); err != nil {
+		ctx.ErrorCode = common.MalformedJSON
+		logger.LoggerForRequest(&ctx).Errorf("parsing request body failed:%+v. error:%s", r.Body, err.Error())
+		common.RenderError(w, ctx.RequestID, ctx.ErrorCode, err)
+		return
+	}
+
+	response, err := job.AdoptJobs(&ctx, request)
+	if err != nil {
+		ctx.Logging().Errorf("adopt jobs failed. error:%s", err.Error())
+		common.RenderError(w, ctx.RequestID, ctx.ErrorCode, err)
+		return
+	}
+	common.Render(w, http.StatusOK, response)
+}
+
 // DeleteJob delete job
 // @Summary 删除作业
 // @Description 删除作业
@@ -245,6 +394,50 @@ func (jr *JobRouter) StopJob(w http.ResponseWriter, r *http.Request) {
 	common.RenderStatus(w, http.StatusOK)
 }
 
+// ApproveJob approve job waiting for approval
+// @Summary 审批通过作业
+// @Description 审批通过申请资源超过阈值的作业，仅root用户可用
+// @Id ApproveJob
+// @tags Job
+// @Accept  json
+// @Produce json
+// @Param jobID path string true "作业ID"
+// @Success 200 {string} "审批通过作业的响应"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Router /job/{jobID}?action=approve [PUT]
+func (jr *JobRouter) ApproveJob(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	jobID := chi.URLParam(r, util.ParamKeyJobID)
+	if err := job.ApproveJob(&ctx, jobID); err != nil {
+		ctx.ErrorMessage = fmt.Sprintf("approve job failed, err: %v", err)
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, ctx.ErrorMessage)
+		return
+	}
+	common.RenderStatus(w, http.StatusOK)
+}
+
+// RejectJob reject job waiting for approval
+// @Summary 拒绝作业
+// @Description 拒绝申请资源超过阈值的作业，仅root用户可用
+// @Id RejectJob
+// @tags Job
+// @Accept  json
+// @Produce json
+// @Param jobID path string true "作业ID"
+// @Success 200 {string} "拒绝作业的响应"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Router /job/{jobID}?action=reject [PUT]
+func (jr *JobRouter) RejectJob(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	jobID := chi.URLParam(r, util.ParamKeyJobID)
+	if err := job.RejectJob(&ctx, jobID); err != nil {
+		ctx.ErrorMessage = fmt.Sprintf("reject job failed, err: %v", err)
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, ctx.ErrorMessage)
+		return
+	}
+	common.RenderStatus(w, http.StatusOK)
+}
+
 // UpdateJob update job
 // @Summary 更新作业
 // @Description 更新作业
@@ -264,7 +457,7 @@ func (jr *JobRouter) UpdateJob(w http.ResponseWriter, r *http.Request) {
 	if err := common.BindJSON(r, &request); err != nil {
 		ctx.ErrorCode = common.MalformedJSON
 		logger.LoggerForRequest(&ctx).Errorf("parsing request body failed: %v. err: %s", r.Body, err.Error())
-		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		common.RenderError(w, ctx.RequestID, ctx.ErrorCode, err)
 		return
 	}
 	request.JobID = jobID
@@ -283,7 +476,7 @@ func (jr *JobRouter) UpdateJob(w http.ResponseWriter, r *http.Request) {
 		ctx.ErrorCode = common.InvalidArguments
 		err := fmt.Errorf("the priorty %s is invalid", request.Priority)
 		ctx.Logging().Errorf("update job failed, err: %v", err)
-		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		common.RenderError(w, ctx.RequestID, ctx.ErrorCode, err)
 		return
 	}
 
@@ -304,6 +497,7 @@ func (jr *JobRouter) UpdateJob(w http.ResponseWriter, r *http.Request) {
 // @Accept  json
 // @Produce json
 // @Param status query string false "作业状态过滤"
+// @Param project query string false "项目名称过滤，返回提交到项目队列中的作业"
 // @Param maxKeys query int false "每页包含的最大数量，缺省值为50"
 // @Param marker query string false "批量获取列表的查询的起始位置，是一个由系统生成的字符串"
 // @Success 200 {object} job.ListJobResponse "获取作业列表的响应"
@@ -338,6 +532,7 @@ func (jr *JobRouter) ListJob(writer http.ResponseWriter, request *http.Request)
 		}
 	}
 	queue := request.URL.Query().Get(util.QueryKeyQueue)
+	projectName := request.URL.Query().Get(util.QueryKeyProject)
 	labelsStr := request.URL.Query().Get(util.QueryKeyLabels)
 	labels := make(map[string]string)
 	if labelsStr != "" {
@@ -358,6 +553,7 @@ func (jr *JobRouter) ListJob(writer http.ResponseWriter, request *http.Request)
 		Queue:     queue,
 		StartTime: startTime,
 		Labels:    labels,
+		Project:   projectName,
 		Timestamp: timestamp,
 		Marker:    marker,
 		MaxKeys:   maxKeys,
@@ -365,7 +561,7 @@ func (jr *JobRouter) ListJob(writer http.ResponseWriter, request *http.Request)
 	response, err := job.ListJob(&ctx, listJobRequest)
 	if err != nil {
 		ctx.Logging().Errorf("list job failed, error:%s", err.Error())
-		common.RenderErrWithMessage(writer, ctx.RequestID, ctx.ErrorCode, err.Error())
+		common.RenderError(writer, ctx.RequestID, ctx.ErrorCode, err)
 		return
 	}
 	common.Render(writer, http.StatusOK, response)
@@ -389,7 +585,69 @@ func (jr *JobRouter) GetJob(writer http.ResponseWriter, request *http.Request) {
 	response, err := job.GetJob(&ctx, jobID)
 	if err != nil {
 		ctx.Logging().Errorf("jobID[%s] get failed. error:%s.", jobID, err.Error())
-		common.RenderErrWithMessage(writer, ctx.RequestID, ctx.ErrorCode, err.Error())
+		common.RenderError(writer, ctx.RequestID, ctx.ErrorCode, err)
+		return
+	}
+	common.Render(writer, http.StatusOK, response)
+}
+
+// ListJobArtifacts
+// @Summary 获取作业输出产物
+// @Description 获取作业声明的输出产物及其收集状态、文件清单
+// @Id listJobArtifacts
+// @tags Job
+// @Accept  json
+// @Produce json
+// @Param jobID path string true "作业ID"
+// @Success 200 {object} job.ListJobArtifactsResponse "作业输出产物"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /job/{jobID}/artifacts [GET]
+func (jr *JobRouter) ListJobArtifacts(writer http.ResponseWriter, request *http.Request) {
+	ctx := common.GetRequestContext(request)
+	jobID := chi.URLParam(request, util.ParamKeyJobID)
+	response, err := job.ListJobArtifacts(&ctx, jobID)
+	if err != nil {
+		ctx.Logging().Errorf("list artifacts of job[%s] failed. error:%s.", jobID, err.Error())
+		common.RenderError(writer, ctx.RequestID, ctx.ErrorCode, err)
+		return
+	}
+	common.Render(writer, http.StatusOK, response)
+}
+
+// WatchJob
+// @Summary 等待作业状态变化
+// @Description 作业状态与status不同时立即返回，否则最多等待timeoutSeconds秒后返回当前详情
+// @Id WatchJob
+// @tags Job
+// @Accept  json
+// @Produce json
+// @Param jobID path string true "作业ID"
+// @Param status query string false "客户端已知的作业状态"
+// @Param timeoutSeconds query int false "最长等待时间，默认30秒，最大60秒"
+// @Success 200 {object} job.GetJobResponse "作业详情"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /job/{jobID}/watch [GET]
+func (jr *JobRouter) WatchJob(writer http.ResponseWriter, request *http.Request) {
+	ctx := common.GetRequestContext(request)
+	jobID := chi.URLParam(request, util.ParamKeyJobID)
+	timeout := defaultWatchJobTimeout
+	if value := request.URL.Query().Get(util.QueryKeyTimeoutSeconds); value != "" {
+		seconds, err := strconv.Atoi(value)
+		if err != nil || seconds <= 0 {
+			ctx.ErrorCode = common.InvalidURI
+			common.RenderErrWithMessage(writer, ctx.RequestID, ctx.ErrorCode,
+				fmt.Sprintf("timeoutSeconds[%s] should be a positive integer", value))
+			return
+		}
+		timeout = time.Duration(seconds) * time.Second
+	}
+	status := request.URL.Query().Get(util.QueryKeyStatus)
+	response, err := job.WatchJob(&ctx, jobID, status, timeout, request.Context().Done())
+	if err != nil {
+		ctx.Logging().Errorf("jobID[%s] watch failed. error:%s.", jobID, err.Error())
+		common.RenderError(writer, ctx.RequestID, ctx.ErrorCode, err)
 		return
 	}
 	common.Render(writer, http.StatusOK, response)
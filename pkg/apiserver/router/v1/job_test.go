@@ -18,12 +18,16 @@ package v1
 
 import (
 	"fmt"
+	"net/http"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/controller/job"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/router/util"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/config"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/resources"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
@@ -365,3 +369,45 @@ func TestDeleteJob(t *testing.T) {
 		})
 	}
 }
+
+func TestGetJobV2(t *testing.T) {
+	router, _, baseURL := MockInitJob(t)
+	res, err := PerformPostRequest(router, baseURL+"/job/single", MockCreateJobRequest)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.Code)
+	config.GlobalServerConfig.ApiServer.DeprecatedVersions = map[string]config.APIDeprecation{
+		util.APIVersionV1: {Since: "2026-10-01", Sunset: "2027-10-01", Successor: util.APIVersionV2},
+	}
+	defer func() { config.GlobalServerConfig.ApiServer.DeprecatedVersions = nil }()
+
+	// v1 keeps working with deprecation headers
+	res, err = PerformGetRequest(router, baseURL+"/job/"+MockJobID)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "Thu, 01 Oct 2026 00:00:00 GMT", res.Header().Get("Deprecation"))
+	assert.Equal(t, "Fri, 01 Oct 2027 00:00:00 GMT", res.Header().Get("Sunset"))
+	assert.Equal(t, `</api/paddleflow/v2/job/111>; rel="successor-version"`, res.Header().Get("Link"))
+	v1Response := job.GetJobResponse{}
+	assert.NoError(t, ParseBody(res.Body, &v1Response))
+	assert.Equal(t, "mockImage", v1Response.Members[0].Image)
+
+	// v2 returns job detail in new format
+	baseURLV2 := util.PaddleflowRouterPrefix + util.PaddleflowRouterVersionV2
+	res, err = PerformGetRequest(router, baseURLV2+"/job/"+MockJobID)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Empty(t, res.Header().Get("Deprecation"))
+	detail := job.JobDetail{}
+	assert.NoError(t, ParseBody(res.Body, &detail))
+	assert.Equal(t, MockJobID, detail.Metadata.ID)
+	assert.Equal(t, MockQueueName, detail.Spec.SchedulingPolicy.Queue)
+	assert.Equal(t, "mockImage", detail.Spec.Members[0].Image)
+	assert.Equal(t, string(schema.StatusJobInit), detail.Status.Phase)
+	_, err = time.Parse(time.RFC3339, detail.Metadata.CreateTime)
+	assert.NoError(t, err)
+
+	// other apis are the same as v1
+	res, err = PerformGetRequest(router, baseURLV2+"/job/"+MockJobID+"/artifacts")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.Code)
+}
@@ -17,8 +17,11 @@ limitations under the License.
 package v1
 
 import (
+	"fmt"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/go-chi/chi"
 	log "github.com/sirupsen/logrus"
@@ -26,11 +29,17 @@ import (
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
 	runLog "github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/controller/log"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/router/util"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
 )
 
 type LogRouter struct {
 }
 
+const (
+	logTaskIDHeader = "X-PF-Task-ID"
+	logJobIDHeader  = "X-PF-Job-ID"
+)
+
 func (lr *LogRouter) Name() string {
 	return "LogRouter"
 }
@@ -38,6 +47,10 @@ func (lr *LogRouter) Name() string {
 func (lr *LogRouter) AddRouter(r chi.Router) {
 	log.Info("add pipeline router")
 	r.Get("/log/run/{runID}", lr.getRunLog)
+	r.Get("/log/run/{runID}/step/{stepName}/stream", lr.streamRunStepLog)
+	r.Get("/log/job/{jobID}/stream", lr.streamJobLog)
+	r.Get("/log/job/{jobID}/metrics", lr.getJobMetrics)
+	r.Post("/log/search", lr.searchJobLog)
 }
 
 // getRunLog
@@ -115,3 +128,169 @@ func (lr *LogRouter) getRunLog(writer http.ResponseWriter, request *http.Request
 
 	common.Render(writer, http.StatusOK, response)
 }
+
+// streamJobLog
+// @Summary 流式获取作业日志
+// @Description 以chunked方式输出作业中一个任务的日志，follow为true时持续输出直到任务结束或连接断开，响应头X-PF-Task-ID为任务ID
+// @Id streamJobLog
+// @tags Log
+// @Produce plain
+// @Param jobID path string true "作业ID"
+// @Param taskID query string false "任务ID，默认为名称最小的任务"
+// @Param follow query bool false "是否持续输出"
+// @Param tailLines query int false "只输出最后的行数"
+// @Success 200 {string} string "日志内容"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /log/job/{jobID}/stream [GET]
+func (lr *LogRouter) streamJobLog(writer http.ResponseWriter, request *http.Request) {
+	ctx := common.GetRequestContext(request)
+	jobID := chi.URLParam(request, util.ParamKeyJobID)
+	streamRequest, err := parseStreamLogRequest(request)
+	if err != nil {
+		common.RenderErrWithMessage(writer, ctx.RequestID, common.InvalidURI, err.Error())
+		return
+	}
+	stream, taskID, err := runLog.StreamJobLog(&ctx, request.Context(), jobID, streamRequest)
+	if err != nil {
+		common.RenderError(writer, ctx.RequestID, ctx.ErrorCode, err)
+		return
+	}
+	writeLogStream(writer, &ctx, stream, jobID, taskID)
+}
+
+// streamRunStepLog
+// @Summary 按节点名称流式获取run中节点的日志
+// @Description 与/log/job/{jobID}/stream相同，节点通过名称指定，节点有多个作业时选择最新创建的作业，响应头X-PF-Job-ID为作业ID，X-PF-Task-ID为任务ID
+// @Id streamRunStepLog
+// @tags Log
+// @Produce plain
+// @Param runID path string true "运行ID"
+// @Param stepName path string true "节点名称"
+// @Param taskID query string false "任务ID，默认为名称最小的任务"
+// @Param follow query bool false "是否持续输出"
+// @Param tailLines query int false "只输出最后的行数"
+// @Success 200 {string} string "日志内容"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /log/run/{runID}/step/{stepName}/stream [GET]
+func (lr *LogRouter) streamRunStepLog(writer http.ResponseWriter, request *http.Request) {
+	ctx := common.GetRequestContext(request)
+	runID := chi.URLParam(request, util.ParamKeyRunID)
+	stepName := chi.URLParam(request, util.ParamKeyStepName)
+	streamRequest, err := parseStreamLogRequest(request)
+	if err != nil {
+		common.RenderErrWithMessage(writer, ctx.RequestID, common.InvalidURI, err.Error())
+		return
+	}
+	stream, jobID, taskID, err := runLog.StreamRunStepLog(&ctx, request.Context(), runID, stepName, streamRequest)
+	if err != nil {
+		common.RenderError(writer, ctx.RequestID, ctx.ErrorCode, err)
+		return
+	}
+	writeLogStream(writer, &ctx, stream, jobID, taskID)
+}
+
+func parseStreamLogRequest(request *http.Request) (runLog.StreamJobLogRequest, error) {
+	query := request.URL.Query()
+	streamRequest := runLog.StreamJobLogRequest{
+		TaskID: query.Get(util.QueryKeyTaskID),
+	}
+	var err error
+	if value := query.Get(util.QueryKeyFollow); value != "" {
+		if streamRequest.Follow, err = strconv.ParseBool(value); err != nil {
+			return streamRequest, fmt.Errorf("follow[%s] should be bool", value)
+		}
+	}
+	if value := query.Get(util.QueryKeyTailLines); value != "" {
+		if streamRequest.TailLines, err = strconv.ParseInt(value, 10, 64); err != nil || streamRequest.TailLines < 0 {
+			return streamRequest, fmt.Errorf("tailLines[%s] should be a non-negative integer", value)
+		}
+	}
+	return streamRequest, nil
+}
+
+// writeLogStream 以chunked方式输出日志流，直到日志流结束或连接断开
+func writeLogStream(writer http.ResponseWriter, ctx *logger.RequestContext, stream io.ReadCloser, jobID, taskID string) {
+	defer stream.Close()
+
+	writer.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	writer.Header().Set(logJobIDHeader, jobID)
+	writer.Header().Set(logTaskIDHeader, taskID)
+	writer.WriteHeader(http.StatusOK)
+	flusher, _ := writer.(http.Flusher)
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := stream.Read(buf)
+		if n > 0 {
+			if _, werr := writer.Write(buf[:n]); werr != nil {
+				return
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		if err != nil {
+			// 已经开始写响应，出错时只能中断连接
+			if err != io.EOF {
+				ctx.Logging().Errorf("stream log of job[%s] task[%s] err: %v", jobID, taskID, err)
+			}
+			return
+		}
+	}
+}
+
+// getJobMetrics
+// @Summary 获取作业日志中的指标
+// @Description 作业日志中形如"PF_METRIC loss=0.12 step=100"的行会被定期解析为指标，step为同一行指标共用的步数，返回全部历史值
+// @Id getJobMetrics
+// @tags Log
+// @Produce json
+// @Param jobID path string true "作业ID"
+// @Param metricKeys query string false "指标过滤"
+// @Success 200 {object} log.GetJobMetricsResponse "作业的指标"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /log/job/{jobID}/metrics [GET]
+func (lr *LogRouter) getJobMetrics(writer http.ResponseWriter, request *http.Request) {
+	ctx := common.GetRequestContext(request)
+	jobID := chi.URLParam(request, util.ParamKeyJobID)
+	metricKeys := make([]string, 0)
+	if keys := request.URL.Query().Get(util.QueryKeyMetricKeys); keys != "" {
+		metricKeys = strings.Split(keys, common.SeparatorComma)
+	}
+	response, err := runLog.GetJobMetrics(&ctx, jobID, metricKeys)
+	if err != nil {
+		common.RenderError(writer, ctx.RequestID, ctx.ErrorCode, err)
+		return
+	}
+	common.Render(writer, http.StatusOK, response)
+}
+
+// searchJobLog
+// @Summary 搜索作业日志
+// @Description 在run或者标签选中的所有作业的任务日志中搜索匹配正则pattern的行，返回匹配行及所属作业、节点和任务，只能搜索集群中仍保留的日志
+// @Id searchJobLog
+// @tags Log
+// @Accept  json
+// @Produce json
+// @Param request body log.SearchJobLogRequest true "搜索条件"
+// @Success 200 {object} log.SearchJobLogResponse "匹配的日志行"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /log/search [POST]
+func (lr *LogRouter) searchJobLog(writer http.ResponseWriter, request *http.Request) {
+	ctx := common.GetRequestContext(request)
+	var searchRequest runLog.SearchJobLogRequest
+	if err := common.BindJSON(request, &searchRequest); err != nil {
+		ctx.Logging().Errorf("search job log failed parsing request body. error:%s.", err.Error())
+		common.RenderErrWithMessage(writer, ctx.RequestID, common.MalformedJSON, err.Error())
+		return
+	}
+	response, err := runLog.SearchJobLog(&ctx, request.Context(), searchRequest)
+	if err != nil {
+		common.RenderError(writer, ctx.RequestID, ctx.ErrorCode, err)
+		return
+	}
+	common.Render(writer, http.StatusOK, response)
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package v1
 
 import (
+	"fmt"
 	"net/http"
 
 	"github.com/go-chi/chi"
@@ -38,10 +39,13 @@ func (pr *PipelineRouter) AddRouter(r chi.Router) {
 	log.Info("add pipeline router")
 	r.Post("/pipeline", pr.createPipeline)
 	r.Get("/pipeline", pr.listPipeline)
+	r.Post("/pipeline/validate", pr.validatePipeline)
 	r.Post("/pipeline/{pipelineID}", pr.updatePipeline)
 	r.Get("/pipeline/{pipelineID}", pr.getPipeline)
 	r.Delete("/pipeline/{pipelineID}", pr.deletePipeline)
 	r.Get("/pipeline/{pipelineID}/{pipelineVersionID}", pr.getPipelineVersion)
+	r.Put("/pipeline/{pipelineID}/{pipelineVersionID}", pr.updatePipelineVersion)
+	r.Get("/pipeline/{pipelineID}/{pipelineVersionID}/diff", pr.diffPipelineVersion)
 	r.Delete("/pipeline/{pipelineID}/{pipelineVersionID}", pr.deletePipelineVersion)
 }
 
@@ -78,6 +82,38 @@ func (pr *PipelineRouter) createPipeline(w http.ResponseWriter, r *http.Request)
 	common.Render(w, http.StatusCreated, response)
 }
 
+// validatePipeline
+// @Summary 校验工作流
+// @Description 静态校验工作流yaml，一次性返回所有错误，不创建任何资源
+// @Id validatePipeline
+// @tags Pipeline
+// @Accept  json
+// @Produce json
+// @Param request body pipeline.ValidatePipelineRequest true "校验工作流请求"
+// @Success 200 {object} pipeline.ValidatePipelineResponse "校验工作流响应"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /pipeline/validate [POST]
+func (pr *PipelineRouter) validatePipeline(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	var request pipeline.ValidatePipelineRequest
+	if err := common.BindJSON(r, &request); err != nil {
+		logger.LoggerForRequest(&ctx).Errorf(
+			"validate pipeline failed parsing request body:%+v. error:%v", r.Body, err)
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+
+	response, err := pipeline.ValidatePipeline(&ctx, request)
+	if err != nil {
+		logger.LoggerForRequest(&ctx).Errorf(
+			"validate pipeline failed. request:%v error:%v", request, err)
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusOK, response)
+}
+
 // listPipeline
 // @Summary 获取工作流列表
 // @Description 获取工作流列表
@@ -88,6 +124,7 @@ func (pr *PipelineRouter) createPipeline(w http.ResponseWriter, r *http.Request)
 // @Param userFilter query string false "(root用户)username过滤"
 // @Param fsFilter query string false "fsname过滤"
 // @Param nameFilter query string false "工作流名称过滤"
+// @Param project query string false "项目名称过滤"
 // @Param maxKeys query int false "每页包含的最大数量，缺省值为50"
 // @Param marker query string false "批量获取列表的查询的起始位置，是一个由系统生成的字符串"
 // @Success 200 {object} pipeline.ListPipelineResponse "获取工作流列表的响应"
@@ -114,7 +151,8 @@ func (pr *PipelineRouter) listPipeline(w http.ResponseWriter, r *http.Request) {
 	logger.LoggerForRequest(&ctx).Debugf(
 		"user[%s] listPipeline marker:[%s] maxKeys:[%d] userFilter:[%v]",
 		ctx.UserName, marker, maxKeys, userFilter)
-	listPipelineResponse, err := pipeline.ListPipeline(&ctx, marker, maxKeys, userFilter, nameFilter)
+	projectName := r.URL.Query().Get(util.QueryKeyProject)
+	listPipelineResponse, err := pipeline.ListPipeline(&ctx, marker, maxKeys, userFilter, nameFilter, projectName)
 	if err != nil {
 		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
 		return
@@ -273,3 +311,68 @@ func (pr *PipelineRouter) deletePipelineVersion(w http.ResponseWriter, r *http.R
 	}
 	common.RenderStatus(w, http.StatusOK)
 }
+
+// updatePipelineVersion
+// @Summary 对pipeline version执行操作，目前支持rollback
+// @Description 回滚时以指定版本的yaml创建一个新的pipeline version
+// @Id updatePipelineVersion
+// @tags Pipeline
+// @Accept  json
+// @Produce json
+// @Param pipelineID path string true "工作流ID"
+// @Param pipelineVersionID path string true "工作流版本ID"
+// @Param action query string true "操作类型，rollback"
+// @Success 200 {object} pipeline.RollbackPipelineResponse "回滚工作流的响应"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /pipeline/{pipelineID}/{pipelineVersionID} [PUT]
+func (pr *PipelineRouter) updatePipelineVersion(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	pipelineID := chi.URLParam(r, util.ParamKeyPipelineID)
+	pipelineVersionID := chi.URLParam(r, util.ParamKeyPipelineVersionID)
+	action := r.URL.Query().Get(util.QueryKeyAction)
+
+	switch action {
+	case util.QueryActionRollback:
+		response, err := pipeline.RollbackPipeline(&ctx, pipelineID, pipelineVersionID)
+		if err != nil {
+			ctx.Logging().Errorf("rollback pipeline[%s] to version[%s] failed. error:%s", pipelineID, pipelineVersionID, err.Error())
+			common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+			return
+		}
+		common.Render(w, http.StatusOK, response)
+	default:
+		err := fmt.Errorf("invalid action[%s] for update pipeline version", action)
+		ctx.Logging().Errorln(err.Error())
+		common.RenderErrWithMessage(w, ctx.RequestID, common.InvalidURI, err.Error())
+	}
+}
+
+// diffPipelineVersion
+// @Summary 对比两个pipeline version的yaml
+// @Description 对比两个pipeline version的yaml，未指定baseVersionID时与上一个版本对比
+// @Id diffPipelineVersion
+// @tags Pipeline
+// @Accept  json
+// @Produce json
+// @Param pipelineID path string true "工作流ID"
+// @Param pipelineVersionID path string true "工作流版本ID"
+// @Param baseVersionID query string false "作为对比基准的版本ID"
+// @Success 200 {object} pipeline.DiffPipelineVersionResponse "版本对比结果"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /pipeline/{pipelineID}/{pipelineVersionID}/diff [GET]
+func (pr *PipelineRouter) diffPipelineVersion(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	pipelineID := chi.URLParam(r, util.ParamKeyPipelineID)
+	pipelineVersionID := chi.URLParam(r, util.ParamKeyPipelineVersionID)
+	baseVersionID := r.URL.Query().Get(util.QueryKeyBaseVersionID)
+
+	response, err := pipeline.DiffPipelineVersion(&ctx, pipelineID, pipelineVersionID, baseVersionID)
+	if err != nil {
+		ctx.Logging().Errorf("diff pipeline[%s] version[%s] failed. error:%s", pipelineID, pipelineVersionID, err.Error())
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusOK, response)
+}
@@ -0,0 +1,239 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/controller/project"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/router/util"
+)
+
+type ProjectRouter struct {
+}
+
+func (pr *ProjectRouter) Name() string {
+	return "ProjectRouter"
+}
+
+func (pr *ProjectRouter) AddRouter(r chi.Router) {
+	log.Info("add project router")
+	r.Post("/project", pr.createProject)
+	r.Get("/project", pr.listProject)
+	r.Get("/project/{projectName}", pr.getProject)
+	r.Put("/project/{projectName}", pr.updateProject)
+	r.Delete("/project/{projectName}", pr.deleteProject)
+	r.Post("/project/{projectName}/member", pr.addProjectMember)
+	r.Delete("/project/{projectName}/member", pr.removeProjectMember)
+}
+
+// createProject
+// @Summary 创建项目
+// @Description 创建项目，需要root权限
+// @Id createProject
+// @tags Project
+// @Accept  json
+// @Produce json
+// @Param request body project.CreateProjectRequest true "创建项目请求"
+// @Success 200 {object} project.CreateProjectResponse "创建项目响应"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /project [POST]
+func (pr *ProjectRouter) createProject(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	var request project.CreateProjectRequest
+	if err := common.BindJSON(r, &request); err != nil {
+		ctx.Logging().Errorf("createProject bindjson failed. error:%s", err.Error())
+		common.RenderErr(w, ctx.RequestID, common.MalformedJSON)
+		return
+	}
+	response, err := project.CreateProject(&ctx, &request)
+	if err != nil {
+		ctx.Logging().Errorf("create project failed. request:%+v error:%s", request, err.Error())
+		common.RenderError(w, ctx.RequestID, ctx.ErrorCode, err)
+		return
+	}
+	common.Render(w, http.StatusOK, response)
+}
+
+// listProject
+// @Summary 获取项目列表
+// @Description 获取项目列表，非root用户只返回自己所属的项目
+// @Id listProject
+// @tags Project
+// @Accept  json
+// @Produce json
+// @Param maxKeys query int false "每页包含的最大数量，缺省值为50"
+// @Param marker query string false "批量获取列表的查询的起始位置，是一个由系统生成的字符串"
+// @Success 200 {object} project.ListProjectResponse "获取项目列表的响应"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /project [GET]
+func (pr *ProjectRouter) listProject(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	marker := r.URL.Query().Get(util.QueryKeyMarker)
+	maxKeys, err := util.GetQueryMaxKeys(&ctx, r)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, common.InvalidURI, err.Error())
+		return
+	}
+	response, err := project.ListProject(&ctx, marker, maxKeys)
+	if err != nil {
+		ctx.Logging().Errorf("list project failed. error:%s", err.Error())
+		common.RenderError(w, ctx.RequestID, ctx.ErrorCode, err)
+		return
+	}
+	common.Render(w, http.StatusOK, response)
+}
+
+// getProject
+// @Summary 获取项目详情
+// @Description 获取项目详情，包括成员及队列资源使用情况
+// @Id getProject
+// @tags Project
+// @Accept  json
+// @Produce json
+// @Param projectName path string true "项目名称"
+// @Success 200 {object} project.GetProjectResponse "项目详情"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /project/{projectName} [GET]
+func (pr *ProjectRouter) getProject(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	projectName := chi.URLParam(r, util.ParamKeyProjectName)
+	response, err := project.GetProject(&ctx, projectName)
+	if err != nil {
+		ctx.Logging().Errorf("get project[%s] failed. error:%s", projectName, err.Error())
+		common.RenderError(w, ctx.RequestID, ctx.ErrorCode, err)
+		return
+	}
+	common.Render(w, http.StatusOK, response)
+}
+
+// updateProject
+// @Summary 更新项目
+// @Description 项目管理员可以更新描述，修改配额需要root权限
+// @Id updateProject
+// @tags Project
+// @Accept  json
+// @Produce json
+// @Param projectName path string true "项目名称"
+// @Param request body project.UpdateProjectRequest true "更新项目请求"
+// @Success 200 {string} string "成功更新项目的响应码"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /project/{projectName} [PUT]
+func (pr *ProjectRouter) updateProject(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	projectName := chi.URLParam(r, util.ParamKeyProjectName)
+	var request project.UpdateProjectRequest
+	if err := common.BindJSON(r, &request); err != nil {
+		ctx.Logging().Errorf("updateProject bindjson failed. error:%s", err.Error())
+		common.RenderErr(w, ctx.RequestID, common.MalformedJSON)
+		return
+	}
+	if err := project.UpdateProject(&ctx, projectName, &request); err != nil {
+		ctx.Logging().Errorf("update project[%s] failed. error:%s", projectName, err.Error())
+		common.RenderError(w, ctx.RequestID, ctx.ErrorCode, err)
+		return
+	}
+	common.RenderStatus(w, http.StatusOK)
+}
+
+// deleteProject
+// @Summary 删除项目
+// @Description 删除项目及其成员关系，项目中的资源不会被删除
+// @Id deleteProject
+// @tags Project
+// @Accept  json
+// @Produce json
+// @Param projectName path string true "项目名称"
+// @Success 200 {string} string "成功删除项目的响应码"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /project/{projectName} [DELETE]
+func (pr *ProjectRouter) deleteProject(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	projectName := chi.URLParam(r, util.ParamKeyProjectName)
+	if err := project.DeleteProject(&ctx, projectName); err != nil {
+		ctx.Logging().Errorf("delete project[%s] failed. error:%s", projectName, err.Error())
+		common.RenderError(w, ctx.RequestID, ctx.ErrorCode, err)
+		return
+	}
+	common.RenderStatus(w, http.StatusOK)
+}
+
+// addProjectMember
+// @Summary 添加项目成员
+// @Description 向项目中添加用户、队列、存储或工作流，添加队列需要root权限
+// @Id addProjectMember
+// @tags Project
+// @Accept  json
+// @Produce json
+// @Param projectName path string true "项目名称"
+// @Param request body project.ProjectMemberRequest true "项目成员"
+// @Success 200 {string} string "成功添加成员的响应码"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /project/{projectName}/member [POST]
+func (pr *ProjectRouter) addProjectMember(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	projectName := chi.URLParam(r, util.ParamKeyProjectName)
+	var request project.ProjectMemberRequest
+	if err := common.BindJSON(r, &request); err != nil {
+		ctx.Logging().Errorf("addProjectMember bindjson failed. error:%s", err.Error())
+		common.RenderErr(w, ctx.RequestID, common.MalformedJSON)
+		return
+	}
+	if err := project.AddProjectMember(&ctx, projectName, &request); err != nil {
+		ctx.Logging().Errorf("add member to project[%s] failed. error:%s", projectName, err.Error())
+		common.RenderError(w, ctx.RequestID, ctx.ErrorCode, err)
+		return
+	}
+	common.RenderStatus(w, http.StatusOK)
+}
+
+// removeProjectMember
+// @Summary 移除项目成员
+// @Description 从项目中移除用户、队列、存储或工作流
+// @Id removeProjectMember
+// @tags Project
+// @Accept  json
+// @Produce json
+// @Param projectName path string true "项目名称"
+// @Param resourceType query string true "资源类型，user/queue/fs/pipeline"
+// @Param resourceID query string true "用户名、队列名、存储ID或工作流ID"
+// @Success 200 {string} string "成功移除成员的响应码"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /project/{projectName}/member [DELETE]
+func (pr *ProjectRouter) removeProjectMember(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	projectName := chi.URLParam(r, util.ParamKeyProjectName)
+	resourceType := r.URL.Query().Get(util.QueryResourceType)
+	resourceID := r.URL.Query().Get(util.QueryResourceID)
+	if err := project.RemoveProjectMember(&ctx, projectName, resourceType, resourceID); err != nil {
+		ctx.Logging().Errorf("remove %s[%s] from project[%s] failed. error:%s", resourceType, resourceID, projectName, err.Error())
+		common.RenderError(w, ctx.RequestID, ctx.ErrorCode, err)
+		return
+	}
+	common.RenderStatus(w, http.StatusOK)
+}
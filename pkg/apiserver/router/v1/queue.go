@@ -85,6 +85,7 @@ func (qr *QueueRouter) createQueue(w http.ResponseWriter, r *http.Request) {
 // @Accept  json
 // @Produce json
 // @Param name query string false "队列名称过滤"
+// @Param project query string false "项目名称过滤"
 // @Param maxKeys query int false "每页包含的最大数量，缺省值为50"
 // @Param marker query string false "批量获取列表的查询的起始位置，是一个由系统生成的字符串"
 // @Success 200 {object} queue.ListQueueResponse "获取队列列表的响应"
@@ -101,10 +102,11 @@ func (qr *QueueRouter) listQueue(w http.ResponseWriter, r *http.Request) {
 	}
 
 	name := r.URL.Query().Get(util.QueryKeyName)
+	projectName := r.URL.Query().Get(util.QueryKeyProject)
 	ctx.Logging().Debugf(
-		"ListQueue marker:[%s] maxKeys:[%d] name:[%s]",
-		marker, maxKeys, name)
-	listQueueResponse, err := queue.ListQueue(&ctx, marker, maxKeys, name)
+		"ListQueue marker:[%s] maxKeys:[%d] name:[%s] project:[%s]",
+		marker, maxKeys, name, projectName)
+	listQueueResponse, err := queue.ListQueue(&ctx, marker, maxKeys, name, projectName)
 	if err != nil {
 		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, ctx.ErrorMessage)
 		return
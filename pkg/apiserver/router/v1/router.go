@@ -22,6 +22,7 @@ import (
 
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/middleware"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/router/util"
+	v2 "github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/router/v2"
 )
 
 type IRouter interface {
@@ -51,25 +52,47 @@ func RegisterRouters(r *chi.Mux, debugMode bool) {
 	// route group
 	pathPrefix := util.PaddleflowRouterPrefix + util.PaddleflowRouterVersionV1
 	r.Route(pathPrefix, func(apiV1Router chi.Router) {
+		apiV1Router.Use(middleware.APIVersion(util.APIVersionV1, pathPrefix))
 		if !debugMode {
 			apiV1Router.Use(middleware.BaseAuth)
 		}
-		AddRouter(apiV1Router, &GrantRouter{})
-		AddRouter(apiV1Router, &QueueRouter{})
-		AddRouter(apiV1Router, &FlavourRouter{})
-		AddRouter(apiV1Router, &RunRouter{})
-		AddRouter(apiV1Router, &PipelineRouter{})
-		AddRouter(apiV1Router, &ScheduleRouter{})
-		AddRouter(apiV1Router, &UserRouter{})
-		AddRouter(apiV1Router, &LinkRouter{})
-		AddRouter(apiV1Router, &PFSRouter{})
-		AddRouter(apiV1Router, &ClusterRouter{})
-		AddRouter(apiV1Router, &TrackRouter{})
-		AddRouter(apiV1Router, &LogRouter{})
-		AddRouter(apiV1Router, &JobRouter{})
-		AddRouter(apiV1Router, &StatisticsRouter{})
-		AddRouter(apiV1Router, &VersionRouter{})
+		addRouters(apiV1Router)
 	})
+	// v2 serves all v1 apis, except those whose request or response are changed incompatibly
+	pathPrefixV2 := util.PaddleflowRouterPrefix + util.PaddleflowRouterVersionV2
+	r.Route(pathPrefixV2, func(apiV2Router chi.Router) {
+		apiV2Router.Use(middleware.APIVersion(util.APIVersionV2, pathPrefixV2))
+		if !debugMode {
+			apiV2Router.Use(middleware.BaseAuth)
+		}
+		addRouters(apiV2Router)
+		v2.AddRouters(apiV2Router)
+	})
+}
+
+// addRouters adds v1 apis to router of api version
+func addRouters(r chi.Router) {
+	AddRouter(r, &GrantRouter{})
+	AddRouter(r, &ProjectRouter{})
+	AddRouter(r, &QueueRouter{})
+	AddRouter(r, &FlavourRouter{})
+	AddRouter(r, &RunRouter{})
+	AddRouter(r, &PipelineRouter{})
+	AddRouter(r, &ScheduleRouter{})
+	AddRouter(r, &UserRouter{})
+	AddRouter(r, &LinkRouter{})
+	AddRouter(r, &PFSRouter{})
+	AddRouter(r, &ClusterRouter{})
+	AddRouter(r, &TrackRouter{})
+	AddRouter(r, &LogRouter{})
+	AddRouter(r, &JobRouter{})
+	AddRouter(r, &StatisticsRouter{})
+	AddRouter(r, &VisualizationRouter{})
+	AddRouter(r, &DatasetRouter{})
+	AddRouter(r, &AlertRouter{})
+	AddRouter(r, &ImageBuildRouter{})
+	AddRouter(r, &VersionRouter{})
+	AddRouter(r, &ConfigRouter{})
 }
 
 func AddRouter(r chi.Router, router IRouter) {
@@ -136,7 +136,7 @@ func CreateTestUser(ctx *logger.RequestContext, username, password string) (stri
 		fmt.Printf("CreateTestUser failed creating user. err:%v\n", err)
 		return "", err
 	}
-	token, err := middleware.GenerateToken(username, encrypted)
+	token, err := middleware.GenerateToken(ctx, username, encrypted, "", "")
 	if err != nil {
 		fmt.Printf("CreateTestUser failed generating token. err:%v\n", err)
 	}
@@ -22,6 +22,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/go-chi/chi"
 	log "github.com/sirupsen/logrus"
@@ -47,6 +48,13 @@ func (rr *RunRouter) AddRouter(r chi.Router) {
 	r.Post("/runjson", rr.createRunByJson)
 	r.Get("/run", rr.listRun)
 	r.Get("/run/{runID}", rr.getRunByID)
+	r.Get("/run/{runID}/dag", rr.getRunDag)
+	r.Get("/run/{runID}/jobs", rr.listRunJob)
+	r.Get("/run/{runID}/events", rr.listRunEvents)
+	r.Post("/run/{runID}/approve", rr.approveRunJob)
+	r.Post("/run/{runID}/tracking", rr.logRunTracking)
+	r.Get("/run/{runID}/tracking", rr.getRunTracking)
+	r.Get("/run/tracking/compare", rr.compareRunTracking)
 	r.Put("/run/{runID}", rr.updateRun)
 	r.Delete("/run/{runID}", rr.deleteRun)
 }
@@ -235,9 +243,200 @@ func (rr *RunRouter) getRunByID(w http.ResponseWriter, r *http.Request) {
 		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
 		return
 	}
+	if usage, err := pipeline.GetRunUsage(&runInfo, time.Now()); err != nil {
+		ctx.Logging().Warnf("get usage of run[%s] failed. error: %v", runID, err)
+	} else {
+		runInfo.Usage = &usage
+	}
 	common.Render(w, http.StatusOK, runInfo)
 }
 
+// getRunDag
+// @Summary 获取运行的DAG结构
+// @Description 获取运行的DAG结构，包括节点状态、耗时、依赖关系及artifact传递关系，用于前端渲染
+// @Id getRunDag
+// @tags Run
+// @Accept  json
+// @Produce json
+// @Param runID path string true "运行ID"
+// @Success 200 {object} pipeline.GetRunDagResponse "运行DAG结构"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /run/{runID}/dag [GET]
+func (rr *RunRouter) getRunDag(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	runID := chi.URLParam(r, util.ParamKeyRunID)
+	response, err := pipeline.GetRunDag(&ctx, runID)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusOK, response)
+}
+
+// listRunJob
+// @Summary 获取运行创建的作业
+// @Description 获取运行中各节点对应的作业及作业状态，按作业创建时间排序
+// @Id listRunJob
+// @tags Run
+// @Accept  json
+// @Produce json
+// @Param runID path string true "运行ID"
+// @Success 200 {object} pipeline.ListRunJobResponse "节点与作业的对应关系"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /run/{runID}/jobs [GET]
+func (rr *RunRouter) listRunJob(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	runID := chi.URLParam(r, util.ParamKeyRunID)
+	response, err := pipeline.ListRunJob(&ctx, runID)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusOK, response)
+}
+
+// listRunEvents
+// @Summary 获取运行的事件
+// @Description 汇总运行及其各节点的创建、开始、重试、命中缓存、结束等事件，按时间排序
+// @Id listRunEvents
+// @tags Run
+// @Accept  json
+// @Produce json
+// @Param runID path string true "运行ID"
+// @Success 200 {object} pipeline.ListRunEventsResponse "运行的事件"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /run/{runID}/events [GET]
+func (rr *RunRouter) listRunEvents(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	runID := chi.URLParam(r, util.ParamKeyRunID)
+	response, err := pipeline.ListRunEvents(&ctx, runID)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusOK, response)
+}
+
+// approveRunJob
+// @Summary 审批运行中的审批节点
+// @Description 批准或拒绝运行中正在等待审批的节点，批准后节点成功，拒绝后节点失败
+// @Id approveRunJob
+// @tags Run
+// @Accept  json
+// @Produce json
+// @Param runID path string true "运行ID"
+// @Param request body pipeline.ApproveRunJobRequest true "审批请求"
+// @Success 200 "审批成功"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 403 {object} common.ErrorResponse "403"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /run/{runID}/approve [POST]
+func (rr *RunRouter) approveRunJob(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	runID := chi.URLParam(r, util.ParamKeyRunID)
+	var request pipeline.ApproveRunJobRequest
+	if err := common.BindJSON(r, &request); err != nil {
+		ctx.Logging().Errorf("approve job of run[%s] failed parsing request body:%+v. error:%s", runID, r.Body, err.Error())
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	if err := pipeline.ApproveRunJob(&ctx, runID, request); err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.RenderStatus(w, http.StatusOK)
+}
+
+// logRunTracking
+// @Summary 上报运行的指标、参数与标签
+// @Description 作业通过环境变量PF_TRACKING_URI及PF_TRACKING_TOKEN上报指标、参数与标签
+// @Id logRunTracking
+// @tags Run
+// @Accept  json
+// @Produce json
+// @Param runID path string true "运行ID"
+// @Param request body pipeline.LogRunTrackingRequest true "上报请求"
+// @Success 200 "上报成功"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /run/{runID}/tracking [POST]
+func (rr *RunRouter) logRunTracking(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	runID := chi.URLParam(r, util.ParamKeyRunID)
+	var request pipeline.LogRunTrackingRequest
+	if err := common.BindJSON(r, &request); err != nil {
+		ctx.Logging().Errorf("log tracking data of run[%s] failed parsing request body:%+v. error:%s", runID, r.Body, err.Error())
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	if err := pipeline.LogRunTracking(&ctx, runID, request); err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.RenderStatus(w, http.StatusOK)
+}
+
+// getRunTracking
+// @Summary 获取运行上报的指标、参数与标签
+// @Description 获取运行上报的指标、参数与标签，指标返回全部历史值
+// @Id getRunTracking
+// @tags Run
+// @Accept  json
+// @Produce json
+// @Param runID path string true "运行ID"
+// @Param metricKeys query string false "指标过滤"
+// @Success 200 {object} pipeline.GetRunTrackingResponse "运行的tracking数据"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /run/{runID}/tracking [GET]
+func (rr *RunRouter) getRunTracking(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	runID := chi.URLParam(r, util.ParamKeyRunID)
+	metricKeys := make([]string, 0)
+	if keys := r.URL.Query().Get(util.QueryKeyMetricKeys); keys != "" {
+		metricKeys = strings.Split(keys, common.SeparatorComma)
+	}
+	response, err := pipeline.GetRunTracking(&ctx, runID, metricKeys)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusOK, response)
+}
+
+// compareRunTracking
+// @Summary 对比多个运行的指标与参数
+// @Description 对比多个运行的指标与参数，指标取最后上报的值
+// @Id compareRunTracking
+// @tags Run
+// @Accept  json
+// @Produce json
+// @Param runFilter query string true "运行ID列表，以逗号分隔"
+// @Param metricKeys query string false "指标过滤"
+// @Success 200 {object} pipeline.CompareRunTrackingResponse "对比结果"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /run/tracking/compare [GET]
+func (rr *RunRouter) compareRunTracking(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	runIDs, metricKeys := make([]string, 0), make([]string, 0)
+	if ids := r.URL.Query().Get(util.QueryKeyRunFilter); ids != "" {
+		runIDs = strings.Split(ids, common.SeparatorComma)
+	}
+	if keys := r.URL.Query().Get(util.QueryKeyMetricKeys); keys != "" {
+		metricKeys = strings.Split(keys, common.SeparatorComma)
+	}
+	response, err := pipeline.CompareRunTracking(&ctx, runIDs, metricKeys)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusOK, response)
+}
+
 // updateRun
 // @Summary 修改运行
 // @Description 修改运行
@@ -282,7 +481,7 @@ func (rr *RunRouter) updateRun(w http.ResponseWriter, r *http.Request) {
 			ctx.ErrorCode = common.InternalError
 		}
 	case util.QueryActionRetry:
-		runID, err = pipeline.RetryRun(&ctx, runID)
+		runID, err = pipeline.RetryRun(&ctx, runID, request)
 	default:
 		ctx.ErrorCode = common.InvalidURI
 		err = fmt.Errorf("invalid action[%s] for UpdateRun", action)
@@ -17,6 +17,7 @@ limitations under the License.
 package v1
 
 import (
+	"fmt"
 	"net/http"
 
 	"github.com/go-chi/chi"
@@ -39,7 +40,7 @@ func (sr *ScheduleRouter) AddRouter(r chi.Router) {
 	r.Post("/schedule", sr.createSchedule)
 	r.Get("/schedule", sr.listSchedule)
 	r.Get("/schedule/{scheduleID}", sr.getSchedule)
-	r.Put("/schedule/{scheduleID}", sr.stopSchedule)
+	r.Put("/schedule/{scheduleID}", sr.updateSchedule)
 	r.Delete("/schedule/{scheduleID}", sr.deleteSchedule)
 }
 
@@ -144,14 +145,27 @@ func (sr *ScheduleRouter) getSchedule(w http.ResponseWriter, r *http.Request) {
 	common.Render(w, http.StatusOK, getScheduleResponse)
 }
 
-func (sr *ScheduleRouter) stopSchedule(w http.ResponseWriter, r *http.Request) {
+// updateSchedule 根据action参数停止、暂停或恢复schedule，未指定action时默认为停止
+func (sr *ScheduleRouter) updateSchedule(w http.ResponseWriter, r *http.Request) {
 	ctx := common.GetRequestContext(r)
 	scheduleID := chi.URLParam(r, util.ParamKeyScheduleID)
-	logger.LoggerForRequest(&ctx).Debugf("stop schedule id:%v", scheduleID)
-
-	err := pipeline.StopSchedule(&ctx, scheduleID)
+	action := r.URL.Query().Get(util.QueryKeyAction)
+	logger.LoggerForRequest(&ctx).Debugf("update schedule id:%v, action:%s", scheduleID, action)
+
+	var err error
+	switch action {
+	case "", util.QueryActionStop:
+		err = pipeline.StopSchedule(&ctx, scheduleID)
+	case util.QueryActionPause:
+		err = pipeline.PauseSchedule(&ctx, scheduleID)
+	case util.QueryActionResume:
+		err = pipeline.ResumeSchedule(&ctx, scheduleID)
+	default:
+		ctx.ErrorCode = common.InvalidURI
+		err = fmt.Errorf("invalid action[%s] for update schedule", action)
+	}
 	if err != nil {
-		ctx.Logging().Errorf("stop schedule: %s failed. error:%s", scheduleID, err.Error())
+		ctx.Logging().Errorf("update schedule: %s with action[%s] failed. error:%s", scheduleID, action, err.Error())
 		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
 		return
 	}
@@ -19,6 +19,7 @@ package v1
 import (
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/go-chi/chi"
 	log "github.com/sirupsen/logrus"
@@ -28,6 +29,8 @@ import (
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/router/util"
 )
 
+const defaultUsageRange = 7 * 24 * time.Hour
+
 type StatisticsRouter struct{}
 
 func (sr *StatisticsRouter) Name() string {
@@ -39,6 +42,12 @@ func (sr *StatisticsRouter) AddRouter(r chi.Router) {
 
 	r.Get("/statistics/job/{jobID}", sr.getJobStatistics)
 	r.Get("/statistics/jobDetail/{jobID}", sr.getJobDetailStatistics)
+	r.Get("/statistics/queue/{queueName}/top", sr.getQueueJobTop)
+	r.Get("/statistics/costcenter", sr.getCostCenterReport)
+	r.Get("/statistics/jobStatus", sr.getJobStatusTrend)
+	r.Get("/statistics/queueUtilization", sr.getQueueUtilization)
+	r.Get("/statistics/topUsers", sr.getTopUsers)
+	r.Get("/statistics/imageFailure", sr.getImageFailureRate)
 
 }
 
@@ -107,6 +116,209 @@ func (sr *StatisticsRouter) getJobDetailStatistics(writer http.ResponseWriter, r
 	common.Render(writer, http.StatusOK, response)
 }
 
+// getQueueJobTop
+// @Summary 获取队列中运行作业的实时资源用量
+// @Description 返回队列中运行作业当前的cpu核数、内存、gpu利用率及显存用量，服务端定期从监控刷新，非root用户只能看到自己的作业
+// @Id getQueueJobTop
+// @tags Statistics
+// @Produce json
+// @Param queueName path string true "队列名称"
+// @Param sortBy query string false "排序字段，cpu/memory/gpu/gpuMemory，默认cpu"
+// @Success 200 {object} statistics.QueueJobTopResponse "作业用量列表"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /statistics/queue/{queueName}/top [GET]
+func (sr *StatisticsRouter) getQueueJobTop(writer http.ResponseWriter, request *http.Request) {
+	ctx := common.GetRequestContext(request)
+	queueName := chi.URLParam(request, util.ParamKeyQueueName)
+	sortBy := request.URL.Query().Get(util.QueryKeySortBy)
+	response, err := statistics.GetQueueJobTop(&ctx, queueName, sortBy)
+	if err != nil {
+		ctx.Logging().Errorf("queue[%s] get job top failed. error:%s.", queueName, err.Error())
+		common.RenderErrWithMessage(writer, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(writer, http.StatusOK, response)
+}
+
+// getCostCenterReport
+// @Summary 获取各cost-center的GPU卡时
+// @Description 按作业的cost-center标签汇总指定月份内作业运行的GPU卡时，仅root用户可用
+// @Id getCostCenterReport
+// @tags Statistics
+// @Produce json
+// @Param month query string false "月份，格式为2006-01，默认当月"
+// @Success 200 {object} statistics.CostCenterReportResponse "各cost-center的GPU卡时"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /statistics/costcenter [GET]
+func (sr *StatisticsRouter) getCostCenterReport(writer http.ResponseWriter, request *http.Request) {
+	ctx := common.GetRequestContext(request)
+	month := request.URL.Query().Get(util.QueryKeyMonth)
+	response, err := statistics.GetCostCenterReport(&ctx, month)
+	if err != nil {
+		ctx.Logging().Errorf("get cost center report of month[%s] failed. error:%s.", month, err.Error())
+		common.RenderErrWithMessage(writer, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(writer, http.StatusOK, response)
+}
+
+// getJobStatusTrend
+// @Summary 获取作业状态随时间的分布
+// @Description 从小时汇总表中读取各时间段排队、运行、成功、失败及终止的作业数，非root用户必须指定有权限的队列
+// @Id getJobStatusTrend
+// @tags Statistics
+// @Produce json
+// @Param start query int false "开始时间，unix秒，默认7天前"
+// @Param end query int false "结束时间，unix秒，默认当前时间"
+// @Param interval query string false "时间粒度，hour/day，默认hour"
+// @Param queue query string false "队列名称"
+// @Success 200 {object} statistics.JobStatusTrendResponse "各时间段的作业状态分布"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /statistics/jobStatus [GET]
+func (sr *StatisticsRouter) getJobStatusTrend(writer http.ResponseWriter, request *http.Request) {
+	ctx := common.GetRequestContext(request)
+	query, err := parseUsageQuery(request)
+	if err != nil {
+		ctx.Logging().Errorf("invalid request param, error:%s.", err.Error())
+		common.RenderErrWithMessage(writer, ctx.RequestID, common.InvalidURI, err.Error())
+		return
+	}
+	response, err := statistics.GetJobStatusTrend(&ctx, query)
+	if err != nil {
+		ctx.Logging().Errorf("get job status trend failed. error:%s.", err.Error())
+		common.RenderErrWithMessage(writer, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(writer, http.StatusOK, response)
+}
+
+// getQueueUtilization
+// @Summary 获取队列资源利用率趋势
+// @Description 从小时汇总表中读取各队列每个时间段的GPU卡时、CPU核时及占队列最大资源的比例，非root用户必须指定有权限的队列
+// @Id getQueueUtilization
+// @tags Statistics
+// @Produce json
+// @Param start query int false "开始时间，unix秒，默认7天前"
+// @Param end query int false "结束时间，unix秒，默认当前时间"
+// @Param interval query string false "时间粒度，hour/day，默认hour"
+// @Param queue query string false "队列名称"
+// @Success 200 {object} statistics.QueueUtilizationResponse "各队列的资源利用率"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /statistics/queueUtilization [GET]
+func (sr *StatisticsRouter) getQueueUtilization(writer http.ResponseWriter, request *http.Request) {
+	ctx := common.GetRequestContext(request)
+	query, err := parseUsageQuery(request)
+	if err != nil {
+		ctx.Logging().Errorf("invalid request param, error:%s.", err.Error())
+		common.RenderErrWithMessage(writer, ctx.RequestID, common.InvalidURI, err.Error())
+		return
+	}
+	response, err := statistics.GetQueueUtilization(&ctx, query)
+	if err != nil {
+		ctx.Logging().Errorf("get queue utilization failed. error:%s.", err.Error())
+		common.RenderErrWithMessage(writer, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(writer, http.StatusOK, response)
+}
+
+// getTopUsers
+// @Summary 获取GPU卡时最多的用户
+// @Description 从小时汇总表中按用户汇总时间范围内的GPU卡时，仅root用户可用
+// @Id getTopUsers
+// @tags Statistics
+// @Produce json
+// @Param start query int false "开始时间，unix秒，默认7天前"
+// @Param end query int false "结束时间，unix秒，默认当前时间"
+// @Param limit query int false "返回的用户数，默认10"
+// @Success 200 {object} statistics.TopUsersResponse "用户GPU卡时列表"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /statistics/topUsers [GET]
+func (sr *StatisticsRouter) getTopUsers(writer http.ResponseWriter, request *http.Request) {
+	ctx := common.GetRequestContext(request)
+	query, err := parseUsageQuery(request)
+	if err != nil {
+		ctx.Logging().Errorf("invalid request param, error:%s.", err.Error())
+		common.RenderErrWithMessage(writer, ctx.RequestID, common.InvalidURI, err.Error())
+		return
+	}
+	response, err := statistics.GetTopUsers(&ctx, query)
+	if err != nil {
+		ctx.Logging().Errorf("get top users failed. error:%s.", err.Error())
+		common.RenderErrWithMessage(writer, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(writer, http.StatusOK, response)
+}
+
+// getImageFailureRate
+// @Summary 获取各镜像的作业失败率
+// @Description 从小时汇总表中按镜像汇总时间范围内结束及失败的作业数，按失败率倒序返回，仅root用户可用
+// @Id getImageFailureRate
+// @tags Statistics
+// @Produce json
+// @Param start query int false "开始时间，unix秒，默认7天前"
+// @Param end query int false "结束时间，unix秒，默认当前时间"
+// @Param limit query int false "返回的镜像数，默认10"
+// @Success 200 {object} statistics.ImageFailureResponse "镜像失败率列表"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /statistics/imageFailure [GET]
+func (sr *StatisticsRouter) getImageFailureRate(writer http.ResponseWriter, request *http.Request) {
+	ctx := common.GetRequestContext(request)
+	query, err := parseUsageQuery(request)
+	if err != nil {
+		ctx.Logging().Errorf("invalid request param, error:%s.", err.Error())
+		common.RenderErrWithMessage(writer, ctx.RequestID, common.InvalidURI, err.Error())
+		return
+	}
+	response, err := statistics.GetImageFailureRate(&ctx, query)
+	if err != nil {
+		ctx.Logging().Errorf("get image failure rate failed. error:%s.", err.Error())
+		common.RenderErrWithMessage(writer, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(writer, http.StatusOK, response)
+}
+
+// parseUsageQuery 解析统计接口的查询参数，默认查询最近7天
+func parseUsageQuery(request *http.Request) (statistics.UsageQuery, error) {
+	values := request.URL.Query()
+	query := statistics.UsageQuery{
+		End:       time.Now(),
+		Interval:  values.Get(util.QueryKeyInterval),
+		QueueName: values.Get(util.QueryKeyQueue),
+	}
+	if endStr := values.Get(util.ParamKeyEnd); endStr != "" {
+		end, err := strconv.ParseInt(endStr, 10, 64)
+		if err != nil || end < 0 {
+			return query, common.InvalidStatisticsParams(util.ParamKeyEnd)
+		}
+		query.End = time.Unix(end, 0)
+	}
+	query.Start = query.End.Add(-defaultUsageRange)
+	if startStr := values.Get(util.ParamKeyStart); startStr != "" {
+		start, err := strconv.ParseInt(startStr, 10, 64)
+		if err != nil || start < 0 {
+			return query, common.InvalidStatisticsParams(util.ParamKeyStart)
+		}
+		query.Start = time.Unix(start, 0)
+	}
+	if limitStr := values.Get(util.QueryKeyLimit); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			return query, common.InvalidStatisticsParams(util.QueryKeyLimit)
+		}
+		query.Limit = limit
+	}
+	return query, nil
+}
+
 func validateStatisticsParam(start, end, step int64) error {
 	if start > end {
 		return common.InvalidStartEndParams()
@@ -27,6 +27,7 @@ import (
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/middleware"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/router/util"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/config"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
 )
 
 type UserRouter struct{}
@@ -38,10 +39,22 @@ func (ur *UserRouter) Name() string {
 func (ur *UserRouter) AddRouter(r chi.Router) {
 	log.Info("add user router")
 	r.Post("/login", ur.login)
+	r.Post("/user/password/forgot", ur.forgotPassword)
+	r.Post("/user/password/reset", ur.resetPassword)
 	r.Post("/user", ur.createUser)
 	r.Delete("/user/{username}", ur.deleteUser)
 	r.Put("/user/{username}", ur.updateUser)
 	r.Get("/user", ur.listUser)
+	r.Get("/user/{username}/preference", ur.getUserPreference)
+	r.Put("/user/{username}/preference", ur.updateUserPreference)
+	r.Delete("/user/{username}/preference", ur.deleteUserPreference)
+	r.Get("/user/{username}/quota", ur.getUserQuota)
+	r.Put("/user/{username}/quota", ur.updateUserQuota)
+	r.Delete("/user/{username}/quota", ur.deleteUserQuota)
+	r.Get("/user/{username}/session", ur.listUserSessions)
+	r.Delete("/user/{username}/session", ur.revokeUserSessions)
+	r.Delete("/user/{username}/session/{sessionID}", ur.revokeUserSession)
+	r.Get("/impersonation/audit", ur.listImpersonationAudit)
 
 }
 
@@ -74,7 +87,7 @@ func (ur *UserRouter) login(w http.ResponseWriter, r *http.Request) {
 		common.RenderErr(w, ctx.RequestID, ctx.ErrorCode)
 		return
 	}
-	token, err := middleware.GenerateToken(u.Name, u.Password)
+	token, err := middleware.GenerateToken(&ctx, u.Name, u.Password, common.ClientIP(r), r.UserAgent())
 	if err != nil {
 		ctx.Logging().Errorf(
 			"generate token failed. username:%v error:%s", req.UserName, err.Error())
@@ -107,11 +120,11 @@ func (ur *UserRouter) createUser(w http.ResponseWriter, r *http.Request) {
 		common.RenderErr(w, ctx.RequestID, common.MalformedJSON)
 		return
 	}
-	response, err := user.CreateUser(&ctx, userInfo.UserName, userInfo.Password)
+	response, err := user.CreateUser(&ctx, userInfo.UserName, userInfo.Password, userInfo.Email)
 	if err != nil {
 		ctx.Logging().Errorf(
 			"Create user failed. error:%s", err.Error())
-		common.RenderErr(w, ctx.RequestID, ctx.ErrorCode)
+		renderUserErr(w, &ctx, err)
 		return
 	}
 	common.Render(w, http.StatusOK, response)
@@ -164,15 +177,91 @@ func (ur *UserRouter) updateUser(w http.ResponseWriter, r *http.Request) {
 		common.RenderErr(w, ctx.RequestID, common.MalformedJSON)
 		return
 	}
+	if pd.Email != "" {
+		if err := user.UpdateUserEmail(&ctx, userName, pd.Email); err != nil {
+			ctx.Logging().Errorf("update user's email failed. userName:%s, error:%s", userName, err.Error())
+			common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+			return
+		}
+		if pd.Password == "" {
+			common.RenderStatus(w, http.StatusOK)
+			return
+		}
+	}
 	err = user.UpdateUser(&ctx, userName, pd.Password)
 	if err != nil {
 		ctx.Logging().Errorf("update user's password failed. userName:%s, error:%s", userName, err.Error())
-		common.RenderErr(w, ctx.RequestID, ctx.ErrorCode)
+		renderUserErr(w, &ctx, err)
+		return
+	}
+	common.RenderStatus(w, http.StatusOK)
+}
+
+// forgotPassword
+// @Summary 申请重置密码
+// @Description 生成重置密码token并发送到用户邮箱，无需登录；用户不存在或未设置邮箱时同样返回成功
+// @Id forgotPassword
+// @tags User
+// @Accept  json
+// @Produce json
+// @Param request body user.ForgotPasswordRequest true "申请重置密码请求"
+// @Success 200 {string} string "成功的响应码"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /user/password/forgot [POST]
+func (ur *UserRouter) forgotPassword(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	var request user.ForgotPasswordRequest
+	if err := common.BindJSON(r, &request); err != nil {
+		ctx.Logging().Errorf("forgot password bind json failed. error:%s", err.Error())
+		common.RenderErr(w, ctx.RequestID, common.MalformedJSON)
+		return
+	}
+	if err := user.ForgotPassword(&ctx, request.UserName); err != nil {
+		ctx.Logging().Errorf("forgot password failed. userName:%s, error:%s", request.UserName, err.Error())
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
 		return
 	}
 	common.RenderStatus(w, http.StatusOK)
 }
 
+// resetPassword
+// @Summary 重置密码
+// @Description 使用邮件中的token或原密码设置新密码，无需登录；原密码方式用于过期密码的轮换
+// @Id resetPassword
+// @tags User
+// @Accept  json
+// @Produce json
+// @Param request body user.ResetPasswordRequest true "重置密码请求"
+// @Success 200 {string} string "成功的响应码"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /user/password/reset [POST]
+func (ur *UserRouter) resetPassword(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	var request user.ResetPasswordRequest
+	if err := common.BindJSON(r, &request); err != nil {
+		ctx.Logging().Errorf("reset password bind json failed. error:%s", err.Error())
+		common.RenderErr(w, ctx.RequestID, common.MalformedJSON)
+		return
+	}
+	if err := user.ResetPassword(&ctx, request); err != nil {
+		ctx.Logging().Errorf("reset password failed. userName:%s, error:%s", request.UserName, err.Error())
+		renderUserErr(w, &ctx, err)
+		return
+	}
+	common.RenderStatus(w, http.StatusOK)
+}
+
+// renderUserErr 密码复杂度由配置决定，返回具体的校验失败原因
+func renderUserErr(w http.ResponseWriter, ctx *logger.RequestContext, err error) {
+	if ctx.ErrorCode == common.UserPasswordWeak {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.RenderErr(w, ctx.RequestID, ctx.ErrorCode)
+}
+
 // listUser
 // @Summary 获取用户列表
 // @Description 获取用户列表
@@ -181,6 +270,7 @@ func (ur *UserRouter) updateUser(w http.ResponseWriter, r *http.Request) {
 // @Accept  json
 // @Produce json
 // @Param user query string false "用户名称过滤"
+// @Param project query string false "项目名称过滤，项目管理员可以列出项目中的用户"
 // @Param maxKeys query int false "每页包含的最大数量，缺省值为50"
 // @Param marker query string false "批量获取列表的查询的起始位置，是一个由系统生成的字符串"
 // @Success 200 {object} user.ListUserResponse "获取用户列表的响应"
@@ -200,9 +290,10 @@ func (ur *UserRouter) listUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	projectName := r.URL.Query().Get(util.QueryKeyProject)
 	ctx.Logging().Debugf(
-		"list users marker:[%s] maxKeys:[%d] ", marker, maxKeys)
-	listUserResponse, err := user.ListUser(&ctx, marker, maxKeys)
+		"list users marker:[%s] maxKeys:[%d] project:[%s]", marker, maxKeys, projectName)
+	listUserResponse, err := user.ListUser(&ctx, marker, maxKeys, projectName)
 	if err != nil {
 		common.RenderErr(w, ctx.RequestID, ctx.ErrorCode)
 		return
@@ -220,3 +311,261 @@ func (ur *UserRouter) getUser(w http.ResponseWriter, r *http.Request, username s
 	}
 	common.Render(w, http.StatusOK, response)
 }
+
+// getUserPreference
+// @Summary 获取用户的默认设置
+// @Description 获取用户创建作业时默认使用的队列、套餐、镜像和存储，用户只能获取自己的，root可以获取所有用户的
+// @Id getUserPreference
+// @tags User
+// @Produce json
+// @Param username path string true "用户名称"
+// @Success 200 {object} model.UserPreference "用户的默认设置"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /user/{username}/preference [GET]
+func (ur *UserRouter) getUserPreference(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	userName := chi.URLParam(r, util.QueryKeyUserName)
+	response, err := user.GetUserPreference(&ctx, userName)
+	if err != nil {
+		ctx.Logging().Errorf("get user preference failed. error:%s", err.Error())
+		common.RenderError(w, ctx.RequestID, ctx.ErrorCode, err)
+		return
+	}
+	common.Render(w, http.StatusOK, response)
+}
+
+// updateUserPreference
+// @Summary 设置用户的默认设置
+// @Description 覆盖用户的默认设置，创建单机作业时未填写的队列、套餐、镜像和存储使用这里的值
+// @Id updateUserPreference
+// @tags User
+// @Accept  json
+// @Produce json
+// @Param username path string true "用户名称"
+// @Param request body user.UserPreferenceRequest true "默认设置"
+// @Success 200 {object} model.UserPreference "用户的默认设置"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /user/{username}/preference [PUT]
+func (ur *UserRouter) updateUserPreference(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	userName := chi.URLParam(r, util.QueryKeyUserName)
+	var request user.UserPreferenceRequest
+	if err := common.BindJSON(r, &request); err != nil {
+		ctx.Logging().Errorf("update user preference bind json failed. error:%s", err.Error())
+		common.RenderErrWithMessage(w, ctx.RequestID, common.MalformedJSON, err.Error())
+		return
+	}
+	response, err := user.UpdateUserPreference(&ctx, userName, request)
+	if err != nil {
+		ctx.Logging().Errorf("update user preference failed. error:%s", err.Error())
+		common.RenderError(w, ctx.RequestID, ctx.ErrorCode, err)
+		return
+	}
+	common.Render(w, http.StatusOK, response)
+}
+
+// deleteUserPreference
+// @Summary 清除用户的默认设置
+// @Description 清除用户的默认设置
+// @Id deleteUserPreference
+// @tags User
+// @Produce json
+// @Param username path string true "用户名称"
+// @Success 200 {string} string "成功清除的响应码"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /user/{username}/preference [DELETE]
+func (ur *UserRouter) deleteUserPreference(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	userName := chi.URLParam(r, util.QueryKeyUserName)
+	if err := user.DeleteUserPreference(&ctx, userName); err != nil {
+		ctx.Logging().Errorf("delete user preference failed. error:%s", err.Error())
+		common.RenderError(w, ctx.RequestID, ctx.ErrorCode, err)
+		return
+	}
+	common.RenderStatus(w, http.StatusOK)
+}
+
+// getUserQuota
+// @Summary 获取用户配额
+// @Description 获取用户的最大并发作业数、最大GPU卡数和作业最长运行时间，用户只能获取自己的，root可以获取所有用户的
+// @Id getUserQuota
+// @tags User
+// @Produce json
+// @Param username path string true "用户名称"
+// @Success 200 {object} model.UserQuota "用户配额"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /user/{username}/quota [GET]
+func (ur *UserRouter) getUserQuota(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	userName := chi.URLParam(r, util.QueryKeyUserName)
+	response, err := user.GetUserQuota(&ctx, userName)
+	if err != nil {
+		ctx.Logging().Errorf("get user quota failed. error:%s", err.Error())
+		common.RenderError(w, ctx.RequestID, ctx.ErrorCode, err)
+		return
+	}
+	common.Render(w, http.StatusOK, response)
+}
+
+// updateUserQuota
+// @Summary 设置用户配额
+// @Description 覆盖用户配额，与队列容量无关，字段为0表示不限制，仅root可以设置
+// @Id updateUserQuota
+// @tags User
+// @Accept  json
+// @Produce json
+// @Param username path string true "用户名称"
+// @Param request body user.UserQuotaRequest true "用户配额"
+// @Success 200 {object} model.UserQuota "用户配额"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 403 {object} common.ErrorResponse "403"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /user/{username}/quota [PUT]
+func (ur *UserRouter) updateUserQuota(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	userName := chi.URLParam(r, util.QueryKeyUserName)
+	var request user.UserQuotaRequest
+	if err := common.BindJSON(r, &request); err != nil {
+		ctx.Logging().Errorf("update user quota bind json failed. error:%s", err.Error())
+		common.RenderErrWithMessage(w, ctx.RequestID, common.MalformedJSON, err.Error())
+		return
+	}
+	response, err := user.UpdateUserQuota(&ctx, userName, request)
+	if err != nil {
+		ctx.Logging().Errorf("update user quota failed. error:%s", err.Error())
+		common.RenderError(w, ctx.RequestID, ctx.ErrorCode, err)
+		return
+	}
+	common.Render(w, http.StatusOK, response)
+}
+
+// deleteUserQuota
+// @Summary 清除用户配额
+// @Description 清除用户配额，仅root可以清除
+// @Id deleteUserQuota
+// @tags User
+// @Produce json
+// @Param username path string true "用户名称"
+// @Success 200 {string} string "成功清除的响应码"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 403 {object} common.ErrorResponse "403"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /user/{username}/quota [DELETE]
+func (ur *UserRouter) deleteUserQuota(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	userName := chi.URLParam(r, util.QueryKeyUserName)
+	if err := user.DeleteUserQuota(&ctx, userName); err != nil {
+		ctx.Logging().Errorf("delete user quota failed. error:%s", err.Error())
+		common.RenderError(w, ctx.RequestID, ctx.ErrorCode, err)
+		return
+	}
+	common.RenderStatus(w, http.StatusOK)
+}
+
+// listUserSessions
+// @Summary 获取用户的有效会话
+// @Description 列出用户未撤销且未过期的登录会话，用户只能获取自己的，root可以获取所有用户的
+// @Id listUserSessions
+// @tags User
+// @Produce json
+// @Param username path string true "用户名称"
+// @Success 200 {object} user.ListSessionResponse "用户的有效会话"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /user/{username}/session [GET]
+func (ur *UserRouter) listUserSessions(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	userName := chi.URLParam(r, util.QueryKeyUserName)
+	response, err := user.ListSessions(&ctx, userName)
+	if err != nil {
+		ctx.Logging().Errorf("list user sessions failed. error:%s", err.Error())
+		common.RenderError(w, ctx.RequestID, ctx.ErrorCode, err)
+		return
+	}
+	common.Render(w, http.StatusOK, response)
+}
+
+// revokeUserSessions
+// @Summary 撤销用户的全部会话
+// @Description 撤销用户的全部会话，已签发的token立即失效，包括当前请求使用的token
+// @Id revokeUserSessions
+// @tags User
+// @Produce json
+// @Param username path string true "用户名称"
+// @Success 200 {object} user.RevokeSessionResponse "撤销的会话数量"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /user/{username}/session [DELETE]
+func (ur *UserRouter) revokeUserSessions(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	userName := chi.URLParam(r, util.QueryKeyUserName)
+	response, err := user.RevokeUserSessions(&ctx, userName)
+	if err != nil {
+		ctx.Logging().Errorf("revoke user sessions failed. error:%s", err.Error())
+		common.RenderError(w, ctx.RequestID, ctx.ErrorCode, err)
+		return
+	}
+	common.Render(w, http.StatusOK, response)
+}
+
+// revokeUserSession
+// @Summary 撤销用户的单个会话
+// @Description 撤销用户的单个会话，该会话的token立即失效
+// @Id revokeUserSession
+// @tags User
+// @Produce json
+// @Param username path string true "用户名称"
+// @Param sessionID path string true "会话ID"
+// @Success 200 {string} string "成功撤销的响应码"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 404 {object} common.ErrorResponse "404"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /user/{username}/session/{sessionID} [DELETE]
+func (ur *UserRouter) revokeUserSession(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	userName := chi.URLParam(r, util.QueryKeyUserName)
+	sessionID := chi.URLParam(r, util.ParamKeySessionID)
+	if err := user.RevokeSession(&ctx, userName, sessionID); err != nil {
+		ctx.Logging().Errorf("revoke user session failed. error:%s", err.Error())
+		common.RenderError(w, ctx.RequestID, ctx.ErrorCode, err)
+		return
+	}
+	common.RenderStatus(w, http.StatusOK)
+}
+
+// listImpersonationAudit
+// @Summary 获取模拟用户请求的审计记录
+// @Description root通过Impersonate-User请求头以其他用户身份发起的请求都会被记录，按时间倒序返回，仅root可以查看
+// @Id listImpersonationAudit
+// @tags User
+// @Produce json
+// @Param operator query string false "发起请求的管理员"
+// @Param user query string false "被模拟的用户"
+// @Param marker query string false "查询起始条目加密条码"
+// @Param maxKeys query string false "每页条数"
+// @Success 200 {object} user.ListImpersonationAuditResponse "审计记录"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /impersonation/audit [GET]
+func (ur *UserRouter) listImpersonationAudit(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	marker := r.URL.Query().Get(util.QueryKeyMarker)
+	maxKeys, err := util.GetQueryMaxKeys(&ctx, r)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, common.InvalidURI, err.Error())
+		return
+	}
+	operator := r.URL.Query().Get(util.QueryKeyOperator)
+	userName := r.URL.Query().Get(util.QueryKeyUser)
+	response, err := user.ListImpersonationAudit(&ctx, marker, maxKeys, operator, userName)
+	if err != nil {
+		ctx.Logging().Errorf("list impersonation audit failed. error:%s", err.Error())
+		common.RenderError(w, ctx.RequestID, ctx.ErrorCode, err)
+		return
+	}
+	common.Render(w, http.StatusOK, response)
+}
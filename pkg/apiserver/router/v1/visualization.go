@@ -0,0 +1,178 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/controller/visualization"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/router/util"
+)
+
+type VisualizationRouter struct{}
+
+func (vr *VisualizationRouter) Name() string {
+	return "VisualizationRouter"
+}
+
+func (vr *VisualizationRouter) AddRouter(r chi.Router) {
+	log.Info("add visualization router")
+	r.Post("/visualization", vr.createVisualization)
+	r.Get("/visualization", vr.listVisualization)
+	r.Get("/visualization/{visualizationID}", vr.getVisualization)
+	r.Put("/visualization/{visualizationID}", vr.updateVisualization)
+	r.Delete("/visualization/{visualizationID}", vr.deleteVisualization)
+}
+
+// createVisualization
+// @Summary 创建可视化服务
+// @Description 启动挂载作业日志目录的TensorBoard，返回访问地址
+// @Id createVisualization
+// @tags Visualization
+// @Accept  json
+// @Produce json
+// @Param request body visualization.CreateVisualizationRequest true "创建可视化服务请求"
+// @Success 201 {object} visualization.CreateVisualizationResponse "创建可视化服务响应"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /visualization [POST]
+func (vr *VisualizationRouter) createVisualization(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	var request visualization.CreateVisualizationRequest
+	if err := common.BindJSON(r, &request); err != nil {
+		ctx.Logging().Errorf("create visualization failed parsing request body:%+v. error:%s", r.Body, err.Error())
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	response, err := visualization.CreateVisualization(&ctx, request)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusCreated, response)
+}
+
+// listVisualization
+// @Summary 获取可视化服务列表
+// @Description 获取可视化服务列表
+// @Id listVisualization
+// @tags Visualization
+// @Accept  json
+// @Produce json
+// @Param marker query string false "查询起始位置"
+// @Param maxKeys query int false "每页条数"
+// @Param jobID query string false "作业ID过滤"
+// @Success 200 {object} visualization.ListVisualizationResponse "可视化服务列表"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /visualization [GET]
+func (vr *VisualizationRouter) listVisualization(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	maxKeys, err := util.GetQueryMaxKeys(&ctx, r)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	marker := r.URL.Query().Get(util.QueryKeyMarker)
+	jobID := r.URL.Query().Get(util.ParamKeyJobID)
+	response, err := visualization.ListVisualization(&ctx, marker, maxKeys, jobID)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusOK, response)
+}
+
+// getVisualization
+// @Summary 获取可视化服务详情
+// @Description 获取可视化服务详情
+// @Id getVisualization
+// @tags Visualization
+// @Accept  json
+// @Produce json
+// @Param visualizationID path string true "可视化服务ID"
+// @Success 200 {object} model.Visualization "可视化服务详情"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /visualization/{visualizationID} [GET]
+func (vr *VisualizationRouter) getVisualization(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	id := chi.URLParam(r, util.ParamKeyVisualizationID)
+	response, err := visualization.GetVisualization(&ctx, id)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusOK, response)
+}
+
+// updateVisualization
+// @Summary 停止可视化服务
+// @Description 停止可视化服务并回收集群资源
+// @Id updateVisualization
+// @tags Visualization
+// @Accept  json
+// @Produce json
+// @Param visualizationID path string true "可视化服务ID"
+// @Param action query string true "修改动作"
+// @Success 200 "停止成功"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /visualization/{visualizationID} [PUT]
+func (vr *VisualizationRouter) updateVisualization(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	id := chi.URLParam(r, util.ParamKeyVisualizationID)
+	action := r.URL.Query().Get(util.QueryKeyAction)
+	if action != util.QueryActionStop {
+		ctx.ErrorCode = common.InvalidURI
+		err := fmt.Errorf("invalid action[%s] for update visualization", action)
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	if err := visualization.StopVisualization(&ctx, id); err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.RenderStatus(w, http.StatusOK)
+}
+
+// deleteVisualization
+// @Summary 删除可视化服务
+// @Description 删除可视化服务，运行中的服务会先被停止
+// @Id deleteVisualization
+// @tags Visualization
+// @Accept  json
+// @Produce json
+// @Param visualizationID path string true "可视化服务ID"
+// @Success 200 "删除成功"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /visualization/{visualizationID} [DELETE]
+func (vr *VisualizationRouter) deleteVisualization(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	id := chi.URLParam(r, util.ParamKeyVisualizationID)
+	if err := visualization.DeleteVisualization(&ctx, id); err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.RenderStatus(w, http.StatusOK)
+}
@@ -0,0 +1,66 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/controller/job"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/router/util"
+)
+
+// JobRouter is v2 job api router
+type JobRouter struct{}
+
+// Name indicate name of job router
+func (jr *JobRouter) Name() string {
+	return "JobRouter"
+}
+
+// AddRouter add job router to root router
+func (jr *JobRouter) AddRouter(r chi.Router) {
+	log.Info("add v2 job router")
+	r.Get("/job/{jobID}", jr.GetJob)
+}
+
+// GetJob
+// @Summary 获取作业详情
+// @Description 获取作业详情，字段按元数据、规格、状态分组
+// @Id getJobV2
+// @tags Job
+// @Accept  json
+// @Produce json
+// @Param jobID path string true "作业ID"
+// @Success 200 {object} job.JobDetail "作业详情"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /job/{jobID} [GET]
+func (jr *JobRouter) GetJob(writer http.ResponseWriter, request *http.Request) {
+	ctx := common.GetRequestContext(request)
+	jobID := chi.URLParam(request, util.ParamKeyJobID)
+	response, err := job.GetJobDetail(&ctx, jobID)
+	if err != nil {
+		ctx.Logging().Errorf("jobID[%s] get failed. error:%s.", jobID, err.Error())
+		common.RenderError(writer, ctx.RequestID, ctx.ErrorCode, err)
+		return
+	}
+	common.Render(writer, http.StatusOK, response)
+}
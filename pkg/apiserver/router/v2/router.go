@@ -0,0 +1,43 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"github.com/go-chi/chi"
+	"github.com/sirupsen/logrus"
+)
+
+type IRouter interface {
+	Name() string
+	AddRouter(r chi.Router)
+}
+
+// @title PaddleFlow API
+// @version 2.0
+// @description v2 apis of PaddleFlow server, apis not listed here are the same as v1.
+
+// @BasePath /api/paddleflow/v2
+
+// AddRouters adds apis which are changed incompatibly in v2, and replaces v1 apis with the same path
+func AddRouters(r chi.Router) {
+	AddRouter(r, &JobRouter{})
+}
+
+func AddRouter(r chi.Router, router IRouter) {
+	logrus.Infof("Add v2 router[%s]", router.Name())
+	router.AddRouter(r)
+}
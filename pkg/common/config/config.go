@@ -21,7 +21,10 @@ import (
 
 	apiv1 "k8s.io/api/core/v1"
 
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/envelope"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/http/outbound"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/uuid"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/trace_logger"
 )
 
@@ -39,8 +42,28 @@ var (
 	DefaultClusterName = "default-cluster"
 	// DefaultQueueName for default queue in single cluster
 	DefaultQueueName = "default-queue"
+	// DefaultCostCenterLabel is the job label which records cost center
+	DefaultCostCenterLabel = "cost-center"
+	// DefaultNodeFailureRequeueLimit is the max times of a job requeued due to node failure
+	DefaultNodeFailureRequeueLimit = 3
+	// DefaultJobSyncShards is the number of job sync shards on each cluster
+	DefaultJobSyncShards = 1
+	// DefaultJobDispatchBatchSize is the max number of pending jobs of a queue read from db in each job loop
+	DefaultJobDispatchBatchSize = 500
+	// DefaultJobDispatchConcurrency is the number of jobs of a queue submitted to cluster concurrently
+	DefaultJobDispatchConcurrency = 1
+	// DefaultClusterDispatchConcurrency is the max number of jobs submitted to a cluster concurrently
+	DefaultClusterDispatchConcurrency = 16
+	// DefaultAutoscalePendingSeconds is the time a job pends before cluster autoscaler is signaled
+	DefaultAutoscalePendingSeconds = 300
+	// DefaultProvisioningClass is the provisioning class of provisioning requests created for pending jobs
+	DefaultProvisioningClass = "best-effort-atomic-scale-up.autoscaling.x-k8s.io"
+	// DefaultRunSharedVolumeSize is the size of shared volume of pipeline run
+	DefaultRunSharedVolumeSize = "10Gi"
 	// DefaultNamespace for default namespace of default queue in single cluster
 	DefaultNamespace = "default"
+	// DefaultTrackingTokenExpirationHour is the valid time of tracking token of runs
+	DefaultTrackingTokenExpirationHour = 168
 )
 
 type ServerConfig struct {
@@ -53,6 +76,17 @@ type ServerConfig struct {
 	ImageConf ImageConfig                    `yaml:"imageRepository"`
 	Monitor   PrometheusConfig               `yaml:"monitor"`
 	Metrics   MetricsConfig                  `yaml:"metrics"`
+
+	Notification  NotificationConfig  `yaml:"notification"`
+	Visualization VisualizationConfig `yaml:"visualization"`
+	ImageBuild    ImageBuildConfig    `yaml:"imageBuild"`
+	Engine        EngineConfig        `yaml:"workflowEngine"`
+	Export        ExportConfig        `yaml:"export"`
+	EventBus      EventBusConfig      `yaml:"eventBus"`
+	Auth          AuthConfig          `yaml:"auth"`
+	Encryption    envelope.Config     `yaml:"encryption"`
+	IDGenerator   uuid.Config         `yaml:"idGenerator"`
+	Outbound      outbound.Config     `yaml:"outbound"`
 }
 
 type StorageConfig struct {
@@ -60,7 +94,7 @@ type StorageConfig struct {
 	Host                                 string `yaml:"host"`
 	Port                                 string `yaml:"port"`
 	User                                 string `yaml:"user"`
-	Password                             string `yaml:"password"`
+	Password                             string `yaml:"password" json:"-"`
 	Database                             string `yaml:"database"`
 	ConnectTimeoutInSeconds              int    `yaml:"connectTimeoutInSeconds,omitempty"`
 	LockTimeoutInMilliseconds            int    `yaml:"lockTimeoutInMilliseconds,omitempty"`
@@ -75,6 +109,30 @@ type ApiServerConfig struct {
 	Host                string `yaml:"host"`
 	Port                int    `yaml:"port"`
 	TokenExpirationHour int    `yaml:"tokenExpirationHour"`
+	// DeprecatedVersions 已弃用的API版本，key为版本号如v1，弃用版本的响应中携带Deprecation、Sunset及Link头
+	DeprecatedVersions map[string]APIDeprecation `yaml:"deprecatedVersions"`
+	// TrackingTokenSecret 签发run维度tracking token的密钥，每个部署单独配置，为空时使用启动时随机生成的密钥
+	TrackingTokenSecret string `yaml:"trackingTokenSecret" json:"-"`
+	// TrackingTokenExpirationHour tracking token的有效期，默认168小时
+	TrackingTokenExpirationHour int `yaml:"trackingTokenExpirationHour"`
+}
+
+// GetTrackingTokenExpiration 返回tracking token的有效期
+func (c *ApiServerConfig) GetTrackingTokenExpiration() time.Duration {
+	if c.TrackingTokenExpirationHour <= 0 {
+		return time.Duration(DefaultTrackingTokenExpirationHour) * time.Hour
+	}
+	return time.Duration(c.TrackingTokenExpirationHour) * time.Hour
+}
+
+// APIDeprecation API版本的弃用信息，时间格式为2006-01-02
+type APIDeprecation struct {
+	// Since 弃用时间，为空时Deprecation头为true
+	Since string `yaml:"since"`
+	// Sunset 停止服务时间，为空时不返回Sunset头
+	Sunset string `yaml:"sunset"`
+	// Successor 替代版本，如v2，响应的Link头指向替代版本中的同一路径
+	Successor string `yaml:"successor"`
 }
 
 type JobConfig struct {
@@ -90,6 +148,213 @@ type JobConfig struct {
 	// DefaultJobYamlPath defines file path that stores all default templates in one yaml
 	DefaultJobYamlPath string `yaml:"defaultJobYamlPath"`
 	IsSingleCluster    bool   `yaml:"isSingleCluster"`
+	// CodePackageImage is the image of init containers which unpack job code packages, which needs sh and tar
+	CodePackageImage string `yaml:"codePackageImage"`
+	// Defaults are used by jobs of users who have no preference on the same field
+	Defaults JobDefaults `yaml:"defaults"`
+	// Hooks are invoked before dispatch and after completion of each job
+	Hooks JobHooksConfig `yaml:"hooks"`
+	// Approval requires jobs requesting large resources to be approved by admin before dispatch
+	Approval JobApprovalConfig `yaml:"approval"`
+	// Policy defines admission policies of jobs
+	Policy JobPolicyConfig `yaml:"policy"`
+	// NodeFailureRequeueLimit is the max times of a job requeued when its pods are lost due to node failure,
+	// 0 means DefaultNodeFailureRequeueLimit, and negative value disables requeue
+	NodeFailureRequeueLimit int `yaml:"nodeFailureRequeueLimit"`
+	// Sync defines concurrency of job status sync on each cluster
+	Sync JobSyncConfig `yaml:"sync"`
+	// Dispatch defines batching and concurrency of submitting pending jobs to clusters
+	Dispatch JobDispatchConfig `yaml:"dispatch"`
+	// StatusMappings maps phases or conditions of job objects to job status, which take precedence over builtin ones
+	StatusMappings []JobStatusMapping `yaml:"statusMappings"`
+	// RunSharedVolume defines defaults of the shared volume of pipeline runs
+	RunSharedVolume RunSharedVolumeConfig `yaml:"runSharedVolume"`
+	// Autoscale signals cluster autoscalers to add nodes for jobs pending due to insufficient resources
+	Autoscale JobAutoscaleConfig `yaml:"autoscale"`
+}
+
+const (
+	// AutoscaleSignalPodCondition 将等待调度的pod标记为Unschedulable，由cluster autoscaler扩容
+	AutoscaleSignalPodCondition = "podCondition"
+	// AutoscaleSignalProvisioningRequest 为等待调度的pod创建ProvisioningRequest
+	AutoscaleSignalProvisioningRequest = "provisioningRequest"
+)
+
+// JobAutoscaleConfig 作业因资源不足等待时通知集群扩容的配置，Signal为空且未配置扩容钩子时不通知
+type JobAutoscaleConfig struct {
+	// Signal 通知cluster autoscaler的方式，podCondition或provisioningRequest
+	Signal string `yaml:"signal"`
+	// PendingSeconds 作业等待超过该时间后通知扩容，默认300秒
+	PendingSeconds int `yaml:"pendingSeconds"`
+	// ProvisioningClass ProvisioningRequest的provisioningClassName
+	ProvisioningClass string `yaml:"provisioningClass"`
+}
+
+// GetPendingTime returns the time a job pends before cluster autoscaler is signaled
+func (c JobAutoscaleConfig) GetPendingTime() time.Duration {
+	if c.PendingSeconds <= 0 {
+		return time.Duration(DefaultAutoscalePendingSeconds) * time.Second
+	}
+	return time.Duration(c.PendingSeconds) * time.Second
+}
+
+// GetProvisioningClass returns provisioning class of provisioning requests
+func (c JobAutoscaleConfig) GetProvisioningClass() string {
+	if c.ProvisioningClass == "" {
+		return DefaultProvisioningClass
+	}
+	return c.ProvisioningClass
+}
+
+// RunSharedVolumeConfig pipeline run级共享卷的默认配置，run中未指定时使用
+type RunSharedVolumeConfig struct {
+	// Size 共享卷容量，默认为10Gi
+	Size string `yaml:"size"`
+	// StorageClass 共享卷使用的StorageClass，需支持ReadWriteMany，为空时使用集群默认的StorageClass
+	StorageClass string `yaml:"storageClass"`
+}
+
+// GetSize returns default size of run shared volume
+func (c RunSharedVolumeConfig) GetSize() string {
+	if c.Size == "" {
+		return DefaultRunSharedVolumeSize
+	}
+	return c.Size
+}
+
+// JobSyncConfig 集群作业状态同步的并发配置，作业事件按作业ID哈希分配到各分片，每个分片由一个协程顺序处理
+type JobSyncConfig struct {
+	// Shards 分片数量，默认为1
+	Shards int `yaml:"shards"`
+	// ShardQPS 每个分片每秒处理的事件数上限，0表示不限制
+	ShardQPS float32 `yaml:"shardQPS"`
+	// ShardBurst 每个分片的突发处理上限，默认与ShardQPS相同
+	ShardBurst int `yaml:"shardBurst"`
+}
+
+// GetShards returns number of sync shards
+func (c JobSyncConfig) GetShards() int {
+	if c.Shards <= 0 {
+		return DefaultJobSyncShards
+	}
+	return c.Shards
+}
+
+// JobDispatchConfig 作业下发配置，用于在大量作业同时提交时限制数据库读取和集群提交的压力
+type JobDispatchConfig struct {
+	// BatchSize 每个作业循环中每个队列从数据库读取的待下发作业数上限，内存中待下发作业数达到该值时不再读取
+	BatchSize int `yaml:"batchSize"`
+	// Concurrency 每个队列同时提交到集群的作业数
+	Concurrency int `yaml:"concurrency"`
+	// ClusterConcurrency 每个集群同时提交的作业数上限
+	ClusterConcurrency int `yaml:"clusterConcurrency"`
+}
+
+// GetBatchSize returns max number of pending jobs of a queue read in each job loop
+func (c JobDispatchConfig) GetBatchSize() int {
+	if c.BatchSize <= 0 {
+		return DefaultJobDispatchBatchSize
+	}
+	return c.BatchSize
+}
+
+// GetConcurrency returns number of jobs of a queue submitted concurrently
+func (c JobDispatchConfig) GetConcurrency() int {
+	if c.Concurrency <= 0 {
+		return DefaultJobDispatchConcurrency
+	}
+	return c.Concurrency
+}
+
+// GetClusterConcurrency returns max number of jobs submitted to a cluster concurrently
+func (c JobDispatchConfig) GetClusterConcurrency() int {
+	if c.ClusterConcurrency <= 0 {
+		return DefaultClusterDispatchConcurrency
+	}
+	return c.ClusterConcurrency
+}
+
+// JobStatusMapping 作业对象状态到PaddleFlow作业状态的映射，用于支持状态定义不同的operator，无需修改代码
+type JobStatusMapping struct {
+	// APIVersion Kind 作业对象的类型，如kubeflow.org/v1、MPIJob
+	APIVersion string `yaml:"apiVersion"`
+	Kind       string `yaml:"kind"`
+	// PhasePath 作业阶段字段的路径，以.分隔，如status.phase
+	PhasePath string `yaml:"phasePath"`
+	// ConditionsPath 作业conditions字段的路径，如status.conditions，取最后一个status为True且在Phases中的condition
+	ConditionsPath string `yaml:"conditionsPath"`
+	// MessagePath 作业状态信息字段的路径，为空时使用condition的message
+	MessagePath string `yaml:"messagePath"`
+	// Phases 阶段或condition类型到作业状态的映射，作业状态取值为pending、running、succeeded、failed或terminated
+	Phases map[string]string `yaml:"phases"`
+}
+
+// GetNodeFailureRequeueLimit returns max times of a job requeued due to node failure
+func (c JobConfig) GetNodeFailureRequeueLimit() int {
+	if c.NodeFailureRequeueLimit == 0 {
+		return DefaultNodeFailureRequeueLimit
+	}
+	if c.NodeFailureRequeueLimit < 0 {
+		return 0
+	}
+	return c.NodeFailureRequeueLimit
+}
+
+// JobPolicyConfig 作业准入策略
+type JobPolicyConfig struct {
+	// RequireCostCenter 要求每个作业设置cost-center标签
+	RequireCostCenter bool `yaml:"requireCostCenter"`
+	// CostCenterLabel cost-center标签名，默认为cost-center
+	CostCenterLabel string `yaml:"costCenterLabel"`
+	// CostCenters 允许的cost-center取值，为空时不限制
+	CostCenters []string `yaml:"costCenters"`
+}
+
+// GetCostCenterLabel returns label name of cost center
+func (p JobPolicyConfig) GetCostCenterLabel() string {
+	if p.CostCenterLabel == "" {
+		return DefaultCostCenterLabel
+	}
+	return p.CostCenterLabel
+}
+
+// JobApprovalConfig 申请资源超过阈值的作业需要管理员审批后才能调度，阈值为0表示不限制
+type JobApprovalConfig struct {
+	MaxGPUs int64 `yaml:"maxGPUs"`
+	// MaxCPU 单位为核
+	MaxCPU int64 `yaml:"maxCPU"`
+	// Webhooks 作业等待审批时通知审批人
+	Webhooks []string `yaml:"webhooks"`
+}
+
+// JobHooksConfig 作业调度前、结束后、溢出到其他集群前以及等待扩容时调用的钩子
+type JobHooksConfig struct {
+	PreDispatch    []JobHook `yaml:"preDispatch"`
+	PostCompletion []JobHook `yaml:"postCompletion"`
+	// DataStaging 作业溢出到其他集群前同步作业所需的存储路径
+	DataStaging []JobHook `yaml:"dataStaging"`
+	// ScaleUp 作业因资源不足等待时调用，用于扩容云厂商的节点组
+	ScaleUp []JobHook `yaml:"scaleUp"`
+}
+
+// JobHook 可执行程序或HTTP地址，作业json分别通过stdin或POST请求体传入
+type JobHook struct {
+	Name string `yaml:"name"`
+	// Exec 可执行程序及其参数，与URL二选一
+	Exec []string `yaml:"exec"`
+	URL  string   `yaml:"url"`
+	// TimeoutSeconds 默认10秒
+	TimeoutSeconds int `yaml:"timeoutSeconds"`
+	// FailurePolicy 调度前钩子调用失败时的处理方式，Fail（默认）暂缓调度作业，Ignore忽略失败
+	FailurePolicy string `yaml:"failurePolicy"`
+}
+
+// JobDefaults defines default queue/flavour/image/fs of jobs
+type JobDefaults struct {
+	Queue      string `yaml:"queue"`
+	Flavour    string `yaml:"flavour"`
+	Image      string `yaml:"image"`
+	FileSystem string `yaml:"fs"`
 }
 
 type FsServerConf struct {
@@ -100,6 +365,12 @@ type FsServerConf struct {
 	MountPodIntervalTime time.Duration `yaml:"mountPodIntervalTime"`
 	// ServicePort is used to call paddleflow api-server in k8s, the default is the same as ApiServerConfig.Port
 	ServicePort int `yaml:"servicePort"`
+	// DataLoadImage is the image of cache data load pods, which needs sh, find, cat and wc
+	DataLoadImage string `yaml:"dataLoadImage"`
+	// DataTransferImage is the image of fs transfer pods, which needs rclone as entrypoint command
+	DataTransferImage string `yaml:"dataTransferImage"`
+	// MountInfoSecret stores fs and cache config in a secret referenced by pv nodePublishSecretRef, instead of base64 pv attributes
+	MountInfoSecret bool `yaml:"mountInfoSecret"`
 }
 
 type ReclaimConfig struct {
@@ -114,7 +385,7 @@ type ImageConfig struct {
 	Server           string `yaml:"server"`
 	Namespace        string `yaml:"namespace"`
 	Username         string `yaml:"username"`
-	Password         string `yaml:"password"`
+	Password         string `yaml:"password" json:"-"`
 	Concurrency      int    `yaml:"concurrency"`
 	RemoveLocalImage bool   `yaml:"removeLocalImage"`
 }
@@ -128,3 +399,139 @@ type MetricsConfig struct {
 	Port   int  `yaml:"port"`
 	Enable bool `yaml:"enable"`
 }
+
+// NotificationConfig run结束及告警时发送通知的全局配置
+type NotificationConfig struct {
+	SMTP SMTPConfig `yaml:"smtp"`
+	// 发送通知的超时时间
+	TimeoutSeconds int `yaml:"timeoutSeconds"`
+	// 默认的通知配置，pipeline和run中都未配置通知时使用，告警规则未配置通知渠道时也使用该配置
+	Events   []string `yaml:"events"`
+	Emails   []string `yaml:"emails"`
+	Slack    []string `yaml:"slack"`
+	Webhooks []string `yaml:"webhooks"`
+}
+
+// VisualizationConfig TensorBoard等可视化服务的配置
+type VisualizationConfig struct {
+	TensorBoardImage string `yaml:"tensorboardImage"`
+	// ServiceType 可视化服务的service类型，NodePort或ClusterIP
+	ServiceType string `yaml:"serviceType"`
+	// AccessHost NodePort类型时用于拼接访问地址的节点地址
+	AccessHost        string `yaml:"accessHost"`
+	DefaultTTLSeconds int    `yaml:"defaultTTLSeconds"`
+	MaxTTLSeconds     int    `yaml:"maxTTLSeconds"`
+	// CheckIntervalSeconds 回收过期服务的检查间隔
+	CheckIntervalSeconds int `yaml:"checkIntervalSeconds"`
+}
+
+// ImageBuildConfig 镜像构建服务的配置
+type ImageBuildConfig struct {
+	// Registry 构建出的镜像推送的仓库地址，如registry.example.com/paddleflow，为空时使用imageRepository的server/namespace
+	Registry string `yaml:"registry"`
+	// PushSecret 队列namespace中dockerconfigjson类型的secret名称，用于推送镜像
+	PushSecret string `yaml:"pushSecret"`
+	// Insecure 仓库使用http或自签名证书
+	Insecure     bool   `yaml:"insecure"`
+	BuilderImage string `yaml:"builderImage"`
+	// InitImage 写入Dockerfile等构建上下文的init容器镜像，需要sh
+	InitImage            string `yaml:"initImage"`
+	CheckIntervalSeconds int    `yaml:"checkIntervalSeconds"`
+}
+
+// AuthConfig 用户密码复杂度、登录失败锁定及密码轮换策略
+type AuthConfig struct {
+	// 密码复杂度，至少包含一个数字和一个小写字母，最短长度默认为6
+	PasswordMinLength      int  `yaml:"passwordMinLength"`
+	PasswordRequireUpper   bool `yaml:"passwordRequireUpper"`
+	PasswordRequireSpecial bool `yaml:"passwordRequireSpecial"`
+	// PasswordMaxAgeDays 密码有效期，过期后需要重置密码，0表示不限制
+	PasswordMaxAgeDays int `yaml:"passwordMaxAgeDays"`
+	// MaxFailedLogins 连续登录失败达到该次数后锁定账号LockoutMinutes分钟，0表示不锁定
+	MaxFailedLogins int `yaml:"maxFailedLogins"`
+	LockoutMinutes  int `yaml:"lockoutMinutes"`
+	// ResetTokenTTLMinutes 重置密码token的有效期，token通过notification.smtp发送到用户邮箱
+	ResetTokenTTLMinutes int `yaml:"resetTokenTTLMinutes"`
+	// ResetURL 门户的重置密码页面地址，邮件中会附带?username=xx&token=xx，为空时邮件中只包含token
+	ResetURL string `yaml:"resetURL"`
+	// AllowImpersonationWrite root通过Impersonate-User请求头模拟其他用户时，是否允许非GET请求，默认只读
+	AllowImpersonationWrite bool `yaml:"allowImpersonationWrite"`
+}
+
+// EngineConfig pipeline run执行引擎的配置
+type EngineConfig struct {
+	// Name 执行引擎，可选internal（内置DAG调度）或argo，为空时使用internal
+	Name string           `yaml:"name"`
+	Argo ArgoEngineConfig `yaml:"argo"`
+}
+
+// ArgoEngineConfig 使用Argo Workflows执行run时的配置
+type ArgoEngineConfig struct {
+	// Queue step未指定队列时，argo workflow提交到的队列
+	Queue string `yaml:"queue"`
+	// ServiceAccount argo workflow pod使用的serviceAccount，为空时使用namespace默认值
+	ServiceAccount       string `yaml:"serviceAccount"`
+	CheckIntervalSeconds int    `yaml:"checkIntervalSeconds"`
+}
+
+// ExportConfig 定期把作业、run及用量记录增量导出到外部数仓
+type ExportConfig struct {
+	Enable          bool `yaml:"enable"`
+	IntervalSeconds int  `yaml:"intervalSeconds"`
+	// BatchSize 每个文件或每次发送的最大记录数
+	BatchSize int `yaml:"batchSize"`
+	// Format 导出到s3的文件格式，可选csv或json（每行一条记录），为空时使用csv
+	Format string `yaml:"format"`
+	// Sink 导出目标，可选s3或kafka
+	Sink  string               `yaml:"sink"`
+	S3    ExportS3Config       `yaml:"s3"`
+	Kafka KafkaRestProxyConfig `yaml:"kafka"`
+}
+
+// ExportS3Config 文件写入 s3://Bucket/Prefix/<记录类型>/dt=<日期>/ 下
+type ExportS3Config struct {
+	Endpoint       string `yaml:"endpoint"`
+	Region         string `yaml:"region"`
+	Bucket         string `yaml:"bucket"`
+	Prefix         string `yaml:"prefix"`
+	AccessKey      string `yaml:"accessKey"`
+	SecretKey      string `yaml:"secretKey" json:"-"`
+	ForcePathStyle bool   `yaml:"forcePathStyle"`
+}
+
+// KafkaRestProxyConfig 通过Kafka REST Proxy发送消息，topic为TopicPrefix加记录或资源类型
+type KafkaRestProxyConfig struct {
+	RestProxy   string `yaml:"restProxy"`
+	TopicPrefix string `yaml:"topicPrefix"`
+}
+
+// EventBusConfig 把作业、run、队列及存储的状态变化事件发布到消息总线，至少投递一次
+type EventBusConfig struct {
+	Enable bool `yaml:"enable"`
+	// Type 消息总线类型，可选kafka或nats
+	Type string `yaml:"type"`
+	// IntervalSeconds 发布待发送事件的周期，为0时使用5秒
+	IntervalSeconds int `yaml:"intervalSeconds"`
+	BatchSize       int `yaml:"batchSize"`
+	// RetentionHours 已发布事件的保留时间，为0时使用168小时
+	RetentionHours int                  `yaml:"retentionHours"`
+	Kafka          KafkaRestProxyConfig `yaml:"kafka"`
+	NATS           NATSConfig           `yaml:"nats"`
+}
+
+// NATSConfig 事件发布到subject SubjectPrefix加资源类型
+type NATSConfig struct {
+	Address        string `yaml:"address"`
+	Username       string `yaml:"username"`
+	Password       string `yaml:"password" json:"-"`
+	SubjectPrefix  string `yaml:"subjectPrefix"`
+	TimeoutSeconds int    `yaml:"timeoutSeconds"`
+}
+
+type SMTPConfig struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password" json:"-"`
+	From     string `yaml:"from"`
+}
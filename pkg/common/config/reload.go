@@ -0,0 +1,130 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultConfigWatchInterval is the interval of checking whether config file is changed, such as ConfigMap updated by helm
+var DefaultConfigWatchInterval = 10 * time.Second
+
+var (
+	reloadLock     sync.Mutex
+	reloadHandlers []ReloadHandler
+	reloadStatus   ReloadStatus
+)
+
+// ReloadHandler is called after server config is reloaded
+type ReloadHandler func(conf *ServerConfig)
+
+// ReloadStatus 配置热更新状态
+type ReloadStatus struct {
+	ConfigFile     string    `json:"configFile"`
+	LastReloadTime time.Time `json:"lastReloadTime,omitempty"`
+	LastError      string    `json:"lastError,omitempty"`
+}
+
+// RegisterReloadHandler registers handler for modules which cache values of server config
+func RegisterReloadHandler(handler ReloadHandler) {
+	reloadLock.Lock()
+	defer reloadLock.Unlock()
+	reloadHandlers = append(reloadHandlers, handler)
+}
+
+// GetReloadStatus returns status of the last reload
+func GetReloadStatus() ReloadStatus {
+	reloadLock.Lock()
+	defer reloadLock.Unlock()
+	status := reloadStatus
+	status.ConfigFile = serverDefaultConfPath
+	return status
+}
+
+// ReloadConfig 重新读取配置文件，替换job配置中支持热更新的部分，包括作业默认值、同步周期、队列缓存、调度器等，
+// 其他配置需要重启后生效。GlobalServerConfig被整体替换，已获取的旧配置不受影响
+func ReloadConfig() error {
+	reloadLock.Lock()
+	defer reloadLock.Unlock()
+	if GlobalServerConfig == nil {
+		return fmt.Errorf("server config is not initialized")
+	}
+	conf := &ServerConfig{}
+	if err := InitConfigFromYaml(conf, serverDefaultConfPath); err != nil {
+		reloadStatus.LastError = err.Error()
+		log.Errorf("reload server config from %s failed, err: %v", serverDefaultConfPath, err)
+		return err
+	}
+	newConf := *GlobalServerConfig
+	newConf.Job = reloadableJobConfig(GlobalServerConfig.Job, conf.Job)
+	GlobalServerConfig = &newConf
+	reloadStatus.LastReloadTime = time.Now()
+	reloadStatus.LastError = ""
+	log.Infof("server config is reloaded, job config: %s", PrettyFormat(newConf.Job))
+
+	for _, handler := range reloadHandlers {
+		handler(&newConf)
+	}
+	return nil
+}
+
+// reloadableJobConfig returns job config reloaded, and fields which need restart are kept
+func reloadableJobConfig(current, reloaded JobConfig) JobConfig {
+	reloaded.DefaultJobYamlPath = current.DefaultJobYamlPath
+	reloaded.IsSingleCluster = current.IsSingleCluster
+	reloaded.SyncClusterQueue = current.SyncClusterQueue
+	return reloaded
+}
+
+// WatchServerConfig reloads server config when SIGHUP is received or config file is changed
+func WatchServerConfig(stopChan chan struct{}) {
+	hupChan := make(chan os.Signal, 1)
+	signal.Notify(hupChan, syscall.SIGHUP)
+	defer signal.Stop(hupChan)
+
+	content, err := os.ReadFile(serverDefaultConfPath)
+	if err != nil {
+		log.Warnf("read server config %s failed, err: %v", serverDefaultConfPath, err)
+	}
+	for {
+		select {
+		case <-hupChan:
+			log.Infof("SIGHUP received, reload server config")
+			_ = ReloadConfig()
+		case <-time.After(DefaultConfigWatchInterval):
+			// files of ConfigMap volume are replaced by symlink, so compare the content
+			newContent, err := os.ReadFile(serverDefaultConfPath)
+			if err != nil || bytes.Equal(content, newContent) {
+				continue
+			}
+			content = newContent
+			log.Infof("server config %s is changed, reload it", serverDefaultConfPath)
+			_ = ReloadConfig()
+		case <-stopChan:
+			log.Info("server config watcher exit")
+			return
+		}
+	}
+}
@@ -0,0 +1,100 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReloadConfig(t *testing.T) {
+	confPath := filepath.Join(t.TempDir(), "paddleserver.yaml")
+	oldPath, oldConf := serverDefaultConfPath, GlobalServerConfig
+	defer func() {
+		serverDefaultConfPath, GlobalServerConfig = oldPath, oldConf
+	}()
+	serverDefaultConfPath = confPath
+
+	content := `
+database:
+  password: secret
+job:
+  schedulerName: volcano
+  jobLoopPeriod: 1
+  isSingleCluster: true
+`
+	assert.NoError(t, os.WriteFile(confPath, []byte(content), 0644))
+	GlobalServerConfig = &ServerConfig{}
+	assert.NoError(t, InitConfigFromYaml(GlobalServerConfig, confPath))
+	current := GlobalServerConfig
+
+	var reloaded *ServerConfig
+	RegisterReloadHandler(func(conf *ServerConfig) {
+		reloaded = conf
+	})
+	// isSingleCluster needs restart
+	content = `
+database:
+  password: secret
+job:
+  schedulerName: default-scheduler
+  jobLoopPeriod: 5
+  isSingleCluster: false
+  defaults:
+    queue: default-queue
+`
+	assert.NoError(t, os.WriteFile(confPath, []byte(content), 0644))
+	assert.NoError(t, ReloadConfig())
+	assert.Equal(t, GlobalServerConfig, reloaded)
+	assert.Equal(t, "default-scheduler", GlobalServerConfig.Job.SchedulerName)
+	assert.Equal(t, 5, GlobalServerConfig.Job.JobLoopPeriod)
+	assert.Equal(t, "default-queue", GlobalServerConfig.Job.Defaults.Queue)
+	assert.True(t, GlobalServerConfig.Job.IsSingleCluster)
+	// config got before reload is not changed
+	assert.Equal(t, "volcano", current.Job.SchedulerName)
+	status := GetReloadStatus()
+	assert.Equal(t, confPath, status.ConfigFile)
+	assert.False(t, status.LastReloadTime.IsZero())
+	assert.NotContains(t, string(PrettyFormat(GlobalServerConfig)), "secret")
+
+	// invalid config is not applied
+	assert.NoError(t, os.WriteFile(confPath, []byte("job: [invalid"), 0644))
+	assert.Error(t, ReloadConfig())
+	assert.Equal(t, "default-scheduler", GlobalServerConfig.Job.SchedulerName)
+	assert.NotEmpty(t, GetReloadStatus().LastError)
+
+	// config file changed is watched
+	oldInterval := DefaultConfigWatchInterval
+	DefaultConfigWatchInterval = 50 * time.Millisecond
+	defer func() {
+		DefaultConfigWatchInterval = oldInterval
+	}()
+	stopChan := make(chan struct{})
+	defer close(stopChan)
+	go WatchServerConfig(stopChan)
+	time.Sleep(20 * time.Millisecond)
+	assert.NoError(t, os.WriteFile(confPath, []byte("job:\n  schedulerName: watched\n"), 0644))
+	assert.Eventually(t, func() bool {
+		reloadLock.Lock()
+		defer reloadLock.Unlock()
+		return GlobalServerConfig.Job.SchedulerName == "watched"
+	}, 2*time.Second, 20*time.Millisecond)
+}
@@ -18,6 +18,7 @@ package consts
 
 const (
 	MetricCpuUsageRate    = "cpu_usage_rate"
+	MetricCpuUsage        = "cpu_usage"
 	MetricMemoryUsageRate = "memory_usage_rate"
 	MetricMemoryUsage     = "memory_usage"
 	MetricDiskUsage       = "disk_usage"
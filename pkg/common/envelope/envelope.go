@@ -0,0 +1,297 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package envelope
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+const (
+	ProviderLocal = "local"
+	ProviderKMS   = "kms"
+
+	// prefix 加密后的数据格式为 pfenc:v1:<主密钥ID>:<加密的数据密钥>:<加密的数据>
+	prefix     = "pfenc:v1:"
+	dekSize    = 32
+	masterSize = 32
+)
+
+// Config 集群凭证等敏感字段的信封加密配置，每个字段使用随机的数据密钥加密，数据密钥再由主密钥加密，
+// 未配置activeKey时不加密
+type Config struct {
+	// Provider 主密钥的提供方，local（默认）使用keys中的主密钥，kms使用外部KMS服务
+	Provider string `yaml:"provider"`
+	// ActiveKey 加密新数据使用的主密钥ID，轮转密钥时修改为新密钥ID，并保留旧密钥用于解密
+	ActiveKey string `yaml:"activeKey"`
+	// Keys local主密钥，key为密钥ID，value为base64编码的32字节密钥，不会打印到日志中
+	Keys map[string]string `yaml:"keys" json:"-"`
+	// KMS 外部KMS服务，provider为kms时必须设置
+	KMS KMSConfig `yaml:"kms"`
+}
+
+// KMSConfig 外部KMS服务，通过 POST {endpoint}/encrypt 和 POST {endpoint}/decrypt 加解密数据密钥
+type KMSConfig struct {
+	Endpoint string `yaml:"endpoint"`
+	// Token 请求KMS时通过Authorization头传递的Bearer token
+	Token string `yaml:"token" json:"-"`
+	// TimeoutSeconds 默认10秒
+	TimeoutSeconds int `yaml:"timeoutSeconds"`
+}
+
+// KeyProvider 使用主密钥加解密数据密钥
+type KeyProvider interface {
+	WrapKey(keyID string, dek []byte) ([]byte, error)
+	UnwrapKey(keyID string, wrapped []byte) ([]byte, error)
+}
+
+// Encrypter 信封加密，activeKey为空时不加密
+type Encrypter struct {
+	activeKey string
+	provider  KeyProvider
+}
+
+var (
+	mu     sync.RWMutex
+	global = &Encrypter{}
+)
+
+// Init 按配置初始化全局的加密器
+func Init(conf Config) error {
+	encrypter, err := NewEncrypter(conf)
+	if err != nil {
+		return err
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	global = encrypter
+	return nil
+}
+
+func current() *Encrypter {
+	mu.RLock()
+	defer mu.RUnlock()
+	return global
+}
+
+// NewEncrypter 创建加密器，local主密钥必须为base64编码的32字节密钥
+func NewEncrypter(conf Config) (*Encrypter, error) {
+	encrypter := &Encrypter{activeKey: conf.ActiveKey}
+	if strings.Contains(conf.ActiveKey, ":") {
+		return nil, fmt.Errorf("active key %s must not contain ':'", conf.ActiveKey)
+	}
+	switch conf.Provider {
+	case "", ProviderLocal:
+		keys := make(localProvider)
+		for id, encoded := range conf.Keys {
+			if strings.Contains(id, ":") {
+				return nil, fmt.Errorf("master key %s must not contain ':'", id)
+			}
+			key, err := base64.StdEncoding.DecodeString(encoded)
+			if err != nil {
+				return nil, fmt.Errorf("decode master key %s failed: %v", id, err)
+			}
+			if len(key) != masterSize {
+				return nil, fmt.Errorf("master key %s must be %d bytes, but got %d", id, masterSize, len(key))
+			}
+			keys[id] = key
+		}
+		if conf.ActiveKey != "" && keys[conf.ActiveKey] == nil {
+			return nil, fmt.Errorf("active key %s is not found in keys", conf.ActiveKey)
+		}
+		encrypter.provider = keys
+	case ProviderKMS:
+		if conf.KMS.Endpoint == "" {
+			return nil, fmt.Errorf("endpoint of kms is not set")
+		}
+		encrypter.provider = newKMSProvider(conf.KMS)
+	default:
+		return nil, fmt.Errorf("encryption provider %s is not supported", conf.Provider)
+	}
+	return encrypter, nil
+}
+
+// IsEncrypted 数据是否为加密后的格式
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, prefix)
+}
+
+// Encrypt 使用全局加密器加密数据
+func Encrypt(plaintext string) (string, error) {
+	return current().Encrypt(plaintext)
+}
+
+// Decrypt 使用全局加密器解密数据
+func Decrypt(value string) (string, error) {
+	return current().Decrypt(value)
+}
+
+// Rotate 使用全局加密器轮转数据
+func Rotate(value string) (string, bool, error) {
+	return current().Rotate(value)
+}
+
+// Encrypt 使用随机的数据密钥加密数据，并用当前主密钥加密数据密钥；空数据、已加密的数据或未启用加密时原样返回
+func (e *Encrypter) Encrypt(plaintext string) (string, error) {
+	if e.activeKey == "" || plaintext == "" || IsEncrypted(plaintext) {
+		return plaintext, nil
+	}
+	dek := make([]byte, dekSize)
+	if _, err := rand.Read(dek); err != nil {
+		return "", err
+	}
+	ciphertext, err := seal(dek, []byte(plaintext))
+	if err != nil {
+		return "", err
+	}
+	wrapped, err := e.provider.WrapKey(e.activeKey, dek)
+	if err != nil {
+		return "", fmt.Errorf("wrap data key with master key %s failed: %v", e.activeKey, err)
+	}
+	return format(e.activeKey, wrapped, ciphertext), nil
+}
+
+// Decrypt 解密数据，未加密的数据原样返回，兼容启用加密前保存的数据
+func (e *Encrypter) Decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+	keyID, wrapped, ciphertext, err := parse(value)
+	if err != nil {
+		return "", err
+	}
+	if e.provider == nil {
+		return "", fmt.Errorf("encryption is not configured, cannot decrypt data with master key %s", keyID)
+	}
+	dek, err := e.provider.UnwrapKey(keyID, wrapped)
+	if err != nil {
+		return "", fmt.Errorf("unwrap data key with master key %s failed: %v", keyID, err)
+	}
+	plaintext, err := open(dek, ciphertext)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// Rotate 将数据的数据密钥改用当前主密钥加密，未加密的数据会被加密，返回的bool表示数据是否变化
+func (e *Encrypter) Rotate(value string) (string, bool, error) {
+	if e.activeKey == "" || value == "" {
+		return value, false, nil
+	}
+	if !IsEncrypted(value) {
+		encrypted, err := e.Encrypt(value)
+		return encrypted, err == nil, err
+	}
+	keyID, wrapped, ciphertext, err := parse(value)
+	if err != nil {
+		return "", false, err
+	}
+	if keyID == e.activeKey {
+		return value, false, nil
+	}
+	dek, err := e.provider.UnwrapKey(keyID, wrapped)
+	if err != nil {
+		return "", false, fmt.Errorf("unwrap data key with master key %s failed: %v", keyID, err)
+	}
+	if wrapped, err = e.provider.WrapKey(e.activeKey, dek); err != nil {
+		return "", false, fmt.Errorf("wrap data key with master key %s failed: %v", e.activeKey, err)
+	}
+	return format(e.activeKey, wrapped, ciphertext), true, nil
+}
+
+func format(keyID string, wrapped, ciphertext []byte) string {
+	return prefix + keyID + ":" + base64.StdEncoding.EncodeToString(wrapped) + ":" +
+		base64.StdEncoding.EncodeToString(ciphertext)
+}
+
+func parse(value string) (string, []byte, []byte, error) {
+	parts := strings.Split(strings.TrimPrefix(value, prefix), ":")
+	if len(parts) != 3 {
+		return "", nil, nil, fmt.Errorf("encrypted data is malformed")
+	}
+	wrapped, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("decode data key failed: %v", err)
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("decode encrypted data failed: %v", err)
+	}
+	return parts[0], wrapped, ciphertext, nil
+}
+
+// seal 使用AES-GCM加密，随机nonce放在密文前
+func seal(key, plaintext []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func open(key, ciphertext []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, fmt.Errorf("encrypted data is too short")
+	}
+	nonce := ciphertext[:gcm.NonceSize()]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt data failed: %v", err)
+	}
+	return plaintext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// localProvider 使用配置中的主密钥加密数据密钥
+type localProvider map[string][]byte
+
+func (p localProvider) WrapKey(keyID string, dek []byte) ([]byte, error) {
+	key, ok := p[keyID]
+	if !ok {
+		return nil, fmt.Errorf("master key %s is not found", keyID)
+	}
+	return seal(key, dek)
+}
+
+func (p localProvider) UnwrapKey(keyID string, wrapped []byte) ([]byte, error) {
+	key, ok := p[keyID]
+	if !ok {
+		return nil, fmt.Errorf("master key %s is not found", keyID)
+	}
+	return open(key, wrapped)
+}
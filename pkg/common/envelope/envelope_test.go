@@ -0,0 +1,136 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package envelope
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newKey(b byte) string {
+	return base64.StdEncoding.EncodeToString([]byte(strings.Repeat(string(b), masterSize)))
+}
+
+func TestNewEncrypter(t *testing.T) {
+	_, err := NewEncrypter(Config{ActiveKey: "k1"})
+	assert.Error(t, err)
+	_, err = NewEncrypter(Config{ActiveKey: "k1", Keys: map[string]string{"k1": "short"}})
+	assert.Error(t, err)
+	_, err = NewEncrypter(Config{ActiveKey: "k1", Keys: map[string]string{"k1": base64.StdEncoding.EncodeToString([]byte("short"))}})
+	assert.Error(t, err)
+	_, err = NewEncrypter(Config{ActiveKey: "k:1", Keys: map[string]string{"k:1": newKey('a')}})
+	assert.Error(t, err)
+	_, err = NewEncrypter(Config{Provider: ProviderKMS})
+	assert.Error(t, err)
+	_, err = NewEncrypter(Config{Provider: "vault"})
+	assert.Error(t, err)
+	_, err = NewEncrypter(Config{ActiveKey: "k1", Keys: map[string]string{"k1": newKey('a')}})
+	assert.NoError(t, err)
+}
+
+func TestEncryptAndRotate(t *testing.T) {
+	credential := "YXBpVmVyc2lvbjogdjEKa2luZDogQ29uZmln"
+	// encryption is disabled without active key
+	disabled, err := NewEncrypter(Config{})
+	assert.NoError(t, err)
+	value, err := disabled.Encrypt(credential)
+	assert.NoError(t, err)
+	assert.Equal(t, credential, value)
+
+	keys := map[string]string{"k1": newKey('a')}
+	e1, err := NewEncrypter(Config{ActiveKey: "k1", Keys: keys})
+	assert.NoError(t, err)
+	encrypted, err := e1.Encrypt(credential)
+	assert.NoError(t, err)
+	assert.True(t, IsEncrypted(encrypted))
+	assert.NotContains(t, encrypted, credential)
+	// encrypted data is not encrypted twice
+	again, err := e1.Encrypt(encrypted)
+	assert.NoError(t, err)
+	assert.Equal(t, encrypted, again)
+	plaintext, err := e1.Decrypt(encrypted)
+	assert.NoError(t, err)
+	assert.Equal(t, credential, plaintext)
+	// data saved before encryption is enabled
+	plaintext, err = e1.Decrypt(credential)
+	assert.NoError(t, err)
+	assert.Equal(t, credential, plaintext)
+	_, err = disabled.Decrypt(encrypted)
+	assert.Error(t, err)
+
+	// rotate to k2, and k1 is kept for decryption
+	keys["k2"] = newKey('b')
+	e2, err := NewEncrypter(Config{ActiveKey: "k2", Keys: keys})
+	assert.NoError(t, err)
+	rotated, changed, err := e2.Rotate(encrypted)
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.True(t, strings.HasPrefix(rotated, prefix+"k2:"))
+	_, changed, err = e2.Rotate(rotated)
+	assert.NoError(t, err)
+	assert.False(t, changed)
+	plaintext, err = e2.Decrypt(rotated)
+	assert.NoError(t, err)
+	assert.Equal(t, credential, plaintext)
+	_, err = e1.Decrypt(rotated)
+	assert.Error(t, err)
+	// plaintext is encrypted by rotation
+	rotated, changed, err = e2.Rotate(credential)
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.True(t, IsEncrypted(rotated))
+
+	_, err = e2.Decrypt(prefix + "k2:malformed")
+	assert.Error(t, err)
+}
+
+func TestKMSProvider(t *testing.T) {
+	// fake kms xors data key with a byte derived from key id
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		request := kmsRequest{}
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		data := make([]byte, len(request.Data))
+		for i, b := range request.Data {
+			data[i] = b ^ request.KeyID[0]
+		}
+		_ = json.NewEncoder(w).Encode(kmsResponse{Data: data})
+	}))
+	defer server.Close()
+
+	e, err := NewEncrypter(Config{Provider: ProviderKMS, ActiveKey: "kms-key", KMS: KMSConfig{Endpoint: server.URL, Token: "token"}})
+	assert.NoError(t, err)
+	encrypted, err := e.Encrypt("credential")
+	assert.NoError(t, err)
+	plaintext, err := e.Decrypt(encrypted)
+	assert.NoError(t, err)
+	assert.Equal(t, "credential", plaintext)
+
+	e, err = NewEncrypter(Config{Provider: ProviderKMS, ActiveKey: "kms-key", KMS: KMSConfig{Endpoint: server.URL}})
+	assert.NoError(t, err)
+	_, err = e.Encrypt("credential")
+	assert.Error(t, err)
+}
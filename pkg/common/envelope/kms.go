@@ -0,0 +1,101 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package envelope
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const defaultKMSTimeout = 10 * time.Second
+
+type kmsRequest struct {
+	KeyID string `json:"keyID"`
+	// Data base64编码的数据密钥或加密后的数据密钥
+	Data []byte `json:"data"`
+}
+
+type kmsResponse struct {
+	Data []byte `json:"data"`
+}
+
+// kmsProvider 由外部KMS服务使用主密钥加解密数据密钥，主密钥不离开KMS
+type kmsProvider struct {
+	endpoint string
+	token    string
+	client   *http.Client
+}
+
+func newKMSProvider(conf KMSConfig) *kmsProvider {
+	timeout := defaultKMSTimeout
+	if conf.TimeoutSeconds > 0 {
+		timeout = time.Duration(conf.TimeoutSeconds) * time.Second
+	}
+	return &kmsProvider{
+		endpoint: strings.TrimSuffix(conf.Endpoint, "/"),
+		token:    conf.Token,
+		client:   &http.Client{Timeout: timeout},
+	}
+}
+
+func (p *kmsProvider) WrapKey(keyID string, dek []byte) ([]byte, error) {
+	return p.call("encrypt", keyID, dek)
+}
+
+func (p *kmsProvider) UnwrapKey(keyID string, wrapped []byte) ([]byte, error) {
+	return p.call("decrypt", keyID, wrapped)
+}
+
+func (p *kmsProvider) call(action, keyID string, data []byte) ([]byte, error) {
+	body, err := json.Marshal(kmsRequest{KeyID: keyID, Data: data})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodPost, p.endpoint+"/"+action, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.token != "" {
+		req.Header.Set("Authorization", "Bearer "+p.token)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	output, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("kms %s returns status code %d: %s", action, resp.StatusCode, strings.TrimSpace(string(output)))
+	}
+	response := kmsResponse{}
+	if err = json.Unmarshal(output, &response); err != nil {
+		return nil, err
+	}
+	if len(response.Data) == 0 {
+		return nil, fmt.Errorf("kms %s returns empty data", action)
+	}
+	return response.Data, nil
+}
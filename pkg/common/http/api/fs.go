@@ -64,6 +64,8 @@ type FsResponse struct {
 	SubPath       string            `json:"subPath"`
 	Username      string            `json:"username"`
 	Properties    map[string]string `json:"properties"`
+	CapacityQuota int64             `json:"capacityQuota"`
+	InodeQuota    int64             `json:"inodeQuota"`
 }
 
 type LinkResponse struct {
@@ -22,6 +22,10 @@ const (
 	EINVALID_HTTP_REQUEST = "InvalidHTTPRequest"
 	EMALFORMED_JSON       = "MalformedJSON"
 	EPRECONDITION_FAILED  = "PreconditionFailed"
+	ERECORD_NOT_FOUND     = "RecordNotFound"
+	EJOB_NOT_FOUND        = "JobNotFound"
+	ERESOURCE_CONFLICT    = "ResourceConflict"
+	ESERVICE_UNAVAILABLE  = "ServiceUnavailable"
 )
 
 type PFServiceError struct {
@@ -29,6 +33,9 @@ type PFServiceError struct {
 	Message    string
 	RequestId  string
 	StatusCode int
+	Details    map[string]string
+	// Retriable 服务端标记该请求可以原样重试
+	Retriable bool
 }
 
 func (b *PFServiceError) Error() string {
@@ -39,5 +46,10 @@ func (b *PFServiceError) Error() string {
 }
 
 func NewPFServiceError(code, msg, reqId string, status int) *PFServiceError {
-	return &PFServiceError{code, msg, reqId, status}
+	return &PFServiceError{
+		Code:       code,
+		Message:    msg,
+		RequestId:  reqId,
+		StatusCode: status,
+	}
 }
@@ -0,0 +1,162 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package outbound 服务端代用户发起的http请求（如pipeline的http节点、通知webhook），
+// 只允许访问管理员配置的域名，并拒绝连接回环、内网及链路本地地址，防止SSRF
+package outbound
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const maxRedirects = 5
+
+// Config 出站请求的限制
+type Config struct {
+	// AllowedHosts 允许访问的域名，支持*.example.com匹配子域名，为空时允许所有公网域名
+	AllowedHosts []string `yaml:"allowedHosts"`
+	// AllowedCIDRs 允许访问的内网网段，如内部的部署服务，默认拒绝所有回环、内网及链路本地地址
+	AllowedCIDRs []string `yaml:"allowedCIDRs"`
+}
+
+type policy struct {
+	hosts []string
+	cidrs []*net.IPNet
+}
+
+var (
+	mu            sync.RWMutex
+	currentPolicy = &policy{}
+)
+
+// Init 设置全局的出站请求限制
+func Init(conf Config) error {
+	p := &policy{}
+	for _, host := range conf.AllowedHosts {
+		if host = strings.ToLower(strings.TrimSpace(host)); host != "" {
+			p.hosts = append(p.hosts, host)
+		}
+	}
+	for _, cidr := range conf.AllowedCIDRs {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return fmt.Errorf("allowed cidr %s of outbound is invalid: %v", cidr, err)
+		}
+		p.cidrs = append(p.cidrs, ipNet)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	currentPolicy = p
+	return nil
+}
+
+func getPolicy() *policy {
+	mu.RLock()
+	defer mu.RUnlock()
+	return currentPolicy
+}
+
+// CheckURL 检查url为http或https，且域名在允许访问的列表中
+func CheckURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("url %s is invalid: %v", rawURL, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("url %s should start with http:// or https://", rawURL)
+	}
+	if u.Hostname() == "" {
+		return fmt.Errorf("host of url %s is empty", rawURL)
+	}
+	if !getPolicy().allowHost(u.Hostname()) {
+		return fmt.Errorf("host %s is not in the allowed hosts", u.Hostname())
+	}
+	return nil
+}
+
+func (p *policy) allowHost(host string) bool {
+	if len(p.hosts) == 0 {
+		return true
+	}
+	host = strings.ToLower(host)
+	for _, allowed := range p.hosts {
+		if allowed == host {
+			return true
+		}
+		if strings.HasPrefix(allowed, "*.") && strings.HasSuffix(host, allowed[1:]) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *policy) allowIP(ip net.IP) bool {
+	for _, ipNet := range p.cidrs {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified())
+}
+
+// control 在域名解析之后、建立连接之前检查目标地址，避免DNS重绑定绕过检查
+func control(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !getPolicy().allowIP(ip) {
+		return fmt.Errorf("connecting to address %s is not allowed", host)
+	}
+	return nil
+}
+
+// NewClient 返回出站请求使用的http client，不使用环境变量中的代理，重定向的地址同样需要通过检查
+func NewClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 30 * time.Second,
+		Control: control,
+	}
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return dialer.DialContext(ctx, network, addr)
+		},
+		TLSHandshakeTimeout: 10 * time.Second,
+		MaxIdleConns:        10,
+		IdleConnTimeout:     90 * time.Second,
+	}
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return errors.New("stopped after too many redirects")
+			}
+			return CheckURL(req.URL.String())
+		},
+	}
+}
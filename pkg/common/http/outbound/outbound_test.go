@@ -0,0 +1,67 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package outbound
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckURL(t *testing.T) {
+	assert.NoError(t, Init(Config{}))
+	assert.NoError(t, CheckURL("https://hooks.slack.com/services/xxx"))
+	assert.Error(t, CheckURL("ftp://example.com"))
+	assert.Error(t, CheckURL("http:///path"))
+
+	assert.NoError(t, Init(Config{AllowedHosts: []string{"*.example.com", "ci.internal"}}))
+	defer Init(Config{})
+	assert.NoError(t, CheckURL("http://deploy.example.com/run"))
+	assert.NoError(t, CheckURL("http://CI.internal:8080/"))
+	assert.Error(t, CheckURL("http://example.com.evil.io/"))
+	assert.Error(t, CheckURL("http://169.254.169.254/latest/meta-data"))
+
+	assert.Error(t, Init(Config{AllowedCIDRs: []string{"10.0.0.0"}}))
+}
+
+func TestClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	// 默认拒绝连接回环地址
+	assert.NoError(t, Init(Config{}))
+	_, err := NewClient(time.Second).Get(server.URL)
+	assert.Error(t, err)
+
+	assert.NoError(t, Init(Config{AllowedCIDRs: []string{"127.0.0.0/8"}}))
+	defer Init(Config{})
+	resp, err := NewClient(time.Second).Get(server.URL)
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	p := getPolicy()
+	assert.False(t, p.allowIP(net.ParseIP("10.1.2.3")))
+	assert.False(t, p.allowIP(net.ParseIP("169.254.169.254")))
+	assert.False(t, p.allowIP(net.ParseIP("::1")))
+	assert.True(t, p.allowIP(net.ParseIP("8.8.8.8")))
+}
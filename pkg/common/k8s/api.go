@@ -20,9 +20,11 @@ import (
 	"fmt"
 	"sync"
 
+	"github.com/ghodss/yaml"
 	log "github.com/sirupsen/logrus"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/discovery/cached/memory"
@@ -36,6 +38,7 @@ import (
 
 var (
 	PodGVK       = schema.GroupVersionKind{Group: "", Version: "v1", Kind: "Pod"}
+	NodeGVK      = schema.GroupVersionKind{Group: "", Version: "v1", Kind: "Node"}
 	VCJobGVK     = schema.GroupVersionKind{Group: "batch.volcano.sh", Version: "v1alpha1", Kind: "Job"}
 	PodGroupGVK  = schema.GroupVersionKind{Group: "scheduling.volcano.sh", Version: "v1beta1", Kind: "PodGroup"}
 	VCQueueGVK   = schema.GroupVersionKind{Group: "scheduling.volcano.sh", Version: "v1beta1", Kind: "Queue"}
@@ -50,6 +53,15 @@ var (
 	XGBoostJobGVK = schema.GroupVersionKind{Group: "kubeflow.org", Version: "v1", Kind: "XGBoostJob"}
 	RayJobGVK     = schema.GroupVersionKind{Group: "ray.io", Version: "v1alpha1", Kind: "RayJob"}
 
+	// DeploymentGVK ServiceGVK HPAGVK defines GVK for serving job
+	DeploymentGVK = schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+	ServiceGVK    = schema.GroupVersionKind{Group: "", Version: "v1", Kind: "Service"}
+	HPAGVK        = schema.GroupVersionKind{Group: "autoscaling", Version: "v2beta2", Kind: "HorizontalPodAutoscaler"}
+
+	// PodTemplateGVK ProvisioningRequestGVK defines GVK for requesting capacity from cluster autoscaler
+	PodTemplateGVK         = schema.GroupVersionKind{Group: "", Version: "v1", Kind: "PodTemplate"}
+	ProvisioningRequestGVK = schema.GroupVersionKind{Group: "autoscaling.x-k8s.io", Version: "v1beta1", Kind: "ProvisioningRequest"}
+
 	// ArgoWorkflowGVK defines GVK for argo Workflow
 	ArgoWorkflowGVK = schema.GroupVersionKind{Group: "argoproj.io", Version: "v1alpha1", Kind: "Workflow"}
 
@@ -77,6 +89,9 @@ func GetJobFrameworkVersion(jobType commomschema.JobType, framework commomschema
 	if jobType == commomschema.TypeWorkflow {
 		return commomschema.NewFrameworkVersion(ArgoWorkflowGVK.Kind, ArgoWorkflowGVK.GroupVersion().String())
 	}
+	if jobType == commomschema.TypeServing {
+		return commomschema.NewFrameworkVersion(DeploymentGVK.Kind, DeploymentGVK.GroupVersion().String())
+	}
 	var gvk schema.GroupVersionKind
 	switch framework {
 	case commomschema.FrameworkStandalone:
@@ -99,6 +114,18 @@ func GetJobFrameworkVersion(jobType commomschema.JobType, framework commomschema
 	return commomschema.NewFrameworkVersion(gvk.Kind, gvk.GroupVersion().String())
 }
 
+// GetCustomJobFrameworkVersion returns framework version of custom job, which is apiVersion and kind of its extension template
+func GetCustomJobFrameworkVersion(extensionTemplate []byte) (commomschema.FrameworkVersion, error) {
+	typeMeta := metav1.TypeMeta{}
+	if err := yaml.Unmarshal(extensionTemplate, &typeMeta); err != nil {
+		return commomschema.FrameworkVersion{}, fmt.Errorf("parse extension template failed, err: %v", err)
+	}
+	if typeMeta.APIVersion == "" || typeMeta.Kind == "" {
+		return commomschema.FrameworkVersion{}, fmt.Errorf("apiVersion and kind of extension template are required")
+	}
+	return commomschema.NewFrameworkVersion(typeMeta.Kind, typeMeta.APIVersion), nil
+}
+
 func GetJobTypeAndFramework(gvk schema.GroupVersionKind) (commomschema.JobType, commomschema.Framework) {
 	switch gvk {
 	case PodGVK:
@@ -117,6 +144,8 @@ func GetJobTypeAndFramework(gvk schema.GroupVersionKind) (commomschema.JobType,
 		return commomschema.TypeDistributed, commomschema.FrameworkMPI
 	case RayJobGVK:
 		return commomschema.TypeDistributed, commomschema.FrameworkRay
+	case DeploymentGVK:
+		return commomschema.TypeServing, commomschema.FrameworkStandalone
 	default:
 		log.Errorf("GroupVersionKind %s is not support", gvk)
 		return "", ""
@@ -285,6 +314,8 @@ func GetJobGVK(jobType commomschema.JobType, framework commomschema.Framework) (
 		gvk, err = getDistributedJobGVK(framework)
 	case commomschema.TypeWorkflow:
 		gvk = ArgoWorkflowGVK
+	case commomschema.TypeServing:
+		gvk = DeploymentGVK
 	default:
 		err = fmt.Errorf("job type %s is not supported", jobType)
 	}
@@ -0,0 +1,83 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8s
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+)
+
+var (
+	// CronJobGVK PodDisruptionBudgetGVK defines GVK for resources whose api version changes between kubernetes versions
+	CronJobGVK             = schema.GroupVersionKind{Group: "batch", Version: "v1", Kind: "CronJob"}
+	PodDisruptionBudgetGVK = schema.GroupVersionKind{Group: "policy", Version: "v1", Kind: "PodDisruptionBudget"}
+
+	// compatibleVersions contains the versions whose spec is compatible for the same kind, the first one is
+	// the canonical version used by PaddleFlow, and others are listed by preference
+	compatibleVersions = map[schema.GroupKind][]string{
+		CronJobGVK.GroupKind():             {"v1", "v1beta1"},
+		PodDisruptionBudgetGVK.GroupKind(): {"v1", "v1beta1"},
+		HPAGVK.GroupKind():                 {HPAGVK.Version, "v2"},
+		RayJobGVK.GroupKind():              {RayJobGVK.Version, "v1"},
+	}
+)
+
+// CanonicalGVK returns the version used by PaddleFlow for the kind of gvk, objects listed from clusters
+// which serve another compatible version are normalized by it
+func CanonicalGVK(gvk schema.GroupVersionKind) schema.GroupVersionKind {
+	versions, find := compatibleVersions[gvk.GroupKind()]
+	if !find || len(versions) == 0 {
+		return gvk
+	}
+	for _, version := range versions {
+		if version == gvk.Version {
+			return gvk.GroupKind().WithVersion(versions[0])
+		}
+	}
+	return gvk
+}
+
+// ResolveServedGVK detects the version of gvk served by cluster. The requested version is used when it is served,
+// otherwise the compatible versions are tried in order
+func ResolveServedGVK(discoveryClient discovery.DiscoveryInterface, gvk schema.GroupVersionKind) (schema.GroupVersionKind, error) {
+	candidates := []string{gvk.Version}
+	for _, version := range compatibleVersions[gvk.GroupKind()] {
+		if version != gvk.Version {
+			candidates = append(candidates, version)
+		}
+	}
+	for _, version := range candidates {
+		target := gvk.GroupKind().WithVersion(version)
+		resources, err := discoveryClient.ServerResourcesForGroupVersion(target.GroupVersion().String())
+		if err != nil {
+			log.Debugf("group version %s is not served, err: %v", target.GroupVersion(), err)
+			continue
+		}
+		for _, resource := range resources.APIResources {
+			if resource.Kind == gvk.Kind {
+				if version != gvk.Version {
+					log.Infof("%s is not served by cluster, use compatible version %s", gvk.String(), target.String())
+				}
+				return target, nil
+			}
+		}
+	}
+	return gvk, fmt.Errorf("none of versions %v of %s is served by cluster", candidates, gvk.GroupKind().String())
+}
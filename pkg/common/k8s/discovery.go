@@ -64,6 +64,20 @@ var DiscoveryHandlerFunc = http.HandlerFunc(func(w http.ResponseWriter, req *htt
 				{Name: "rayjobs", Namespaced: true, Kind: "RayJob"},
 			},
 		}
+	case "/apis/apps/v1":
+		obj = &metav1.APIResourceList{
+			GroupVersion: "apps/v1",
+			APIResources: []metav1.APIResource{
+				{Name: "deployments", Namespaced: true, Kind: "Deployment"},
+			},
+		}
+	case "/apis/autoscaling/v2beta2":
+		obj = &metav1.APIResourceList{
+			GroupVersion: "autoscaling/v2beta2",
+			APIResources: []metav1.APIResource{
+				{Name: "horizontalpodautoscalers", Namespaced: true, Kind: "HorizontalPodAutoscaler"},
+			},
+		}
 	case "/api/v1":
 		obj = &metav1.APIResourceList{
 			GroupVersion: "v1",
@@ -71,6 +85,7 @@ var DiscoveryHandlerFunc = http.HandlerFunc(func(w http.ResponseWriter, req *htt
 				{Name: "pods", Namespaced: true, Kind: "Pod"},
 				{Name: "namespaces", Namespaced: false, Kind: "Namespace"},
 				{Name: "configmaps", Namespaced: true, Kind: "ConfigMap"},
+				{Name: "services", Namespaced: true, Kind: "Service"},
 			},
 		}
 	case "/api":
@@ -124,6 +139,18 @@ var DiscoveryHandlerFunc = http.HandlerFunc(func(w http.ResponseWriter, req *htt
 						{GroupVersion: "ray.io/v1alpha1", Version: "v1alpha1"},
 					},
 				},
+				{
+					Name: "apps",
+					Versions: []metav1.GroupVersionForDiscovery{
+						{GroupVersion: "apps/v1", Version: "v1"},
+					},
+				},
+				{
+					Name: "autoscaling",
+					Versions: []metav1.GroupVersionForDiscovery{
+						{GroupVersion: "autoscaling/v2beta2", Version: "v2beta2"},
+					},
+				},
 			},
 		}
 	default:
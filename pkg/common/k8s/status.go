@@ -95,6 +95,44 @@ func GetTaskStatus(podStatus *v1.PodStatus) (schema.TaskStatus, error) {
 	return status, nil
 }
 
+const (
+	// PodReasonNodeLost is set by node controller when node of the pod is unreachable
+	PodReasonNodeLost = "NodeLost"
+	// PodReasonDeletedByNodeController is set when pod is evicted by node controller
+	PodReasonDeletedByNodeController = "DeletedByNodeController"
+	// PodReasonDeletionByTaintManager is set when pod is evicted by taint manager, such as node not ready
+	PodReasonDeletionByTaintManager = "DeletionByTaintManager"
+	// PodReasonDeletionByPodGC is set when pod is deleted by pod gc as its node is deleted
+	PodReasonDeletionByPodGC = "DeletionByPodGC"
+	// PodConditionDisruptionTarget is the condition of pods which are about to be deleted due to a disruption
+	PodConditionDisruptionTarget v1.PodConditionType = "DisruptionTarget"
+)
+
+// GetNodeFailureReason returns reason if the pod is lost due to node failure rather than user code,
+// and returns empty string otherwise
+func GetNodeFailureReason(podStatus *v1.PodStatus) string {
+	if podStatus == nil || podStatus.Phase == v1.PodSucceeded {
+		return ""
+	}
+	isNodeFailure := func(reason string) bool {
+		switch reason {
+		case PodReasonNodeLost, PodReasonDeletedByNodeController, PodReasonDeletionByTaintManager,
+			PodReasonDeletionByPodGC:
+			return true
+		}
+		return false
+	}
+	if isNodeFailure(podStatus.Reason) {
+		return fmt.Sprintf("%s: %s", podStatus.Reason, podStatus.Message)
+	}
+	for _, cond := range podStatus.Conditions {
+		if cond.Type == PodConditionDisruptionTarget && cond.Status == v1.ConditionTrue && isNodeFailure(cond.Reason) {
+			return fmt.Sprintf("%s: %s", cond.Reason, cond.Message)
+		}
+	}
+	return ""
+}
+
 // SparkAppStatus get spark application status, message from interface{}, and covert to JobStatus
 func SparkAppStatus(obj interface{}) (StatusInfo, error) {
 	status, err := ConvertToStatus(obj, SparkAppGVK)
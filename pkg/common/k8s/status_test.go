@@ -23,6 +23,7 @@ import (
 
 	paddlejobv1 "github.com/paddleflow/paddle-operator/api/v1"
 	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	batchv1alpha1 "volcano.sh/apis/pkg/apis/batch/v1alpha1"
@@ -459,3 +460,63 @@ func TestKubeflowJobStatus(t *testing.T) {
 		})
 	}
 }
+
+func TestGetNodeFailureReason(t *testing.T) {
+	testCases := []struct {
+		name      string
+		podStatus *v1.PodStatus
+		expected  string
+	}{
+		{
+			name: "node lost",
+			podStatus: &v1.PodStatus{
+				Phase:   v1.PodRunning,
+				Reason:  PodReasonNodeLost,
+				Message: "Node node-1 which was running pod pod-1 is unresponsive",
+			},
+			expected: "NodeLost: Node node-1 which was running pod pod-1 is unresponsive",
+		},
+		{
+			name: "disruption by taint manager",
+			podStatus: &v1.PodStatus{
+				Phase: v1.PodFailed,
+				Conditions: []v1.PodCondition{
+					{
+						Type:    PodConditionDisruptionTarget,
+						Status:  v1.ConditionTrue,
+						Reason:  PodReasonDeletionByTaintManager,
+						Message: "Taint manager: deleting due to NoExecute taint",
+					},
+				},
+			},
+			expected: "DeletionByTaintManager: Taint manager: deleting due to NoExecute taint",
+		},
+		{
+			name: "preempted by scheduler",
+			podStatus: &v1.PodStatus{
+				Phase: v1.PodFailed,
+				Conditions: []v1.PodCondition{
+					{
+						Type:   PodConditionDisruptionTarget,
+						Status: v1.ConditionTrue,
+						Reason: "PreemptionByScheduler",
+					},
+				},
+			},
+			expected: "",
+		},
+		{
+			name: "user code failed",
+			podStatus: &v1.PodStatus{
+				Phase: v1.PodFailed,
+			},
+			expected: "",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, GetNodeFailureReason(tc.podStatus))
+		})
+	}
+}
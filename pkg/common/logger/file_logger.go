@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"time"
 
@@ -77,7 +78,16 @@ func InitFileLogger(logger *log.Logger, logConf *LogConfig) error {
 	logger.SetReportCaller(true)
 
 	if strings.EqualFold(logConf.Formatter, "json") {
-		logger.SetFormatter(&log.JSONFormatter{})
+		// one json object per line for log aggregation systems, fields such as RequestID and JobID are top level keys
+		logger.SetFormatter(&log.JSONFormatter{
+			TimestampFormat: time.RFC3339Nano,
+			FieldMap: log.FieldMap{
+				log.FieldKeyFile: "file",
+			},
+			CallerPrettyfier: func(frame *runtime.Frame) (string, string) {
+				return "", fmt.Sprintf("%s:%d", frame.File, frame.Line)
+			},
+		})
 	} else if strings.EqualFold(logConf.Formatter, "text") {
 		logger.SetFormatter(&log.TextFormatter{})
 	} else {
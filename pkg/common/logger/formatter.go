@@ -18,6 +18,7 @@ package logger
 
 import (
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -58,12 +59,19 @@ func (f *Formatter) Format(entry *log.Entry) ([]byte, error) {
 
 	level := strings.ToUpper(entry.Level.String())
 	output = strings.Replace(output, "%lvl%", level, 1)
-	if entry.Caller.File != "" {
+	if entry.Caller != nil && entry.Caller.File != "" {
 		output = strings.Replace(output, "%file%", fmt.Sprintf("%s:%d", entry.Caller.File, entry.Caller.Line), 1)
 	}
 
+	// fields are sorted by key, so that the same fields are in the same order in each line
+	keys := make([]string, 0, len(entry.Data))
+	for k := range entry.Data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
 	customFields := ""
-	for k, val := range entry.Data {
+	for _, k := range keys {
+		val := entry.Data[k]
 		switch v := val.(type) {
 		case string:
 			customFields += fmt.Sprintf("[%s:%s]", k, v)
@@ -21,6 +21,16 @@ import (
 	"google.golang.org/grpc/codes"
 )
 
+// 结构化日志字段名，各层日志使用相同的字段以便按请求、用户、作业、队列检索
+const (
+	FieldRequestID = "RequestID"
+	FieldUserName  = "UserName"
+	FieldJobID     = "JobID"
+	FieldQueueID   = "QueueID"
+	FieldClusterID = "ClusterID"
+	FieldRunID     = "RunID"
+)
+
 type RequestContext struct {
 	RequestID    string
 	UserID       string
@@ -28,31 +38,52 @@ type RequestContext struct {
 	GrpcCode     codes.Code
 	ErrorCode    string
 	ErrorMessage string
+	// JobID QueueID are attached to logs of request when the request operates on a job or queue
+	JobID   string
+	QueueID string
 }
 
 func (ctx *RequestContext) Logging() *log.Entry {
-	return log.WithFields(log.Fields{
-		"RequestID": ctx.RequestID,
-		"UserName":  ctx.UserName,
-	})
+	return LoggerForRequest(ctx)
 }
 
 func LoggerForRequest(ctx *RequestContext) *log.Entry {
-	return log.WithFields(log.Fields{
-		"RequestID": ctx.RequestID,
-		"UserName":  ctx.UserName,
-	})
+	fields := log.Fields{
+		FieldRequestID: ctx.RequestID,
+		FieldUserName:  ctx.UserName,
+	}
+	if ctx.JobID != "" {
+		fields[FieldJobID] = ctx.JobID
+	}
+	if ctx.QueueID != "" {
+		fields[FieldQueueID] = ctx.QueueID
+	}
+	return log.WithFields(fields)
 }
 
 func LoggerForJob(jobID string) *log.Entry {
 	return log.WithFields(log.Fields{
-		"JobID": jobID,
+		FieldJobID: jobID,
 	})
 }
 
+// LoggerForQueueJob returns logger with fields of job, and empty fields are omitted
+func LoggerForQueueJob(jobID, queueID, userName string) *log.Entry {
+	fields := log.Fields{
+		FieldJobID: jobID,
+	}
+	if queueID != "" {
+		fields[FieldQueueID] = queueID
+	}
+	if userName != "" {
+		fields[FieldUserName] = userName
+	}
+	return log.WithFields(fields)
+}
+
 func LoggerForRun(runID string) *log.Entry {
 	return log.WithFields(log.Fields{
-		"RunID": runID,
+		FieldRunID: runID,
 	})
 }
 
@@ -0,0 +1,51 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logger
+
+import (
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoggerForRequest(t *testing.T) {
+	ctx := &RequestContext{RequestID: "request-1", UserName: "root"}
+	entry := ctx.Logging()
+	assert.Equal(t, log.Fields{FieldRequestID: "request-1", FieldUserName: "root"}, entry.Data)
+
+	ctx.JobID, ctx.QueueID = "job-1", "queue-1"
+	entry = ctx.Logging()
+	assert.Equal(t, "job-1", entry.Data[FieldJobID])
+	assert.Equal(t, "queue-1", entry.Data[FieldQueueID])
+
+	entry = LoggerForQueueJob("job-1", "", "user1")
+	assert.Equal(t, log.Fields{FieldJobID: "job-1", FieldUserName: "user1"}, entry.Data)
+}
+
+func TestFormatterFieldsOrder(t *testing.T) {
+	formatter := &Formatter{LogFormat: "%customFields% %msg%"}
+	entry := LoggerForQueueJob("job-1", "queue-1", "user1").WithField(FieldClusterID, "cluster-1")
+	entry.Message = "submit job"
+	entry.Time = time.Now()
+	for i := 0; i < 10; i++ {
+		output, err := formatter.Format(entry)
+		assert.NoError(t, err)
+		assert.Equal(t, "[ClusterID:cluster-1][JobID:job-1][QueueID:queue-1][UserName:user1] submit job", string(output))
+	}
+}
@@ -25,6 +25,14 @@ const (
 	KubernetesType = "Kubernetes"
 )
 
+// 节点池的节点标签，由PaddleFlow在节点加入节点池时添加
+const (
+	NodePoolLabel        = "paddleflow.org/node-pool"
+	NodePoolGPUTypeLabel = "paddleflow.org/gpu-type"
+	NodePoolZoneLabel    = "paddleflow.org/zone"
+	NodePoolTeamLabel    = "paddleflow.org/dedicated-team"
+)
+
 // ClientOptions used to build rest config.
 type ClientOptions struct {
 	Master string
@@ -17,6 +17,8 @@ limitations under the License.
 package schema
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"path"
 	"strings"
 )
@@ -39,36 +41,135 @@ const (
 
 	FsMetaMemory = "mem"
 	FsMetaDisk   = "disk"
+	// redis/etcd中的元数据由多个挂载pod共享
+	FsMetaRedis = "redis"
+	FsMetaEtcd  = "etcd"
+
+	// 数据缓存超过容量上限时的淘汰策略
+	FsCacheEvictLRU = "lru"
+	FsCacheEvictLFU = "lfu"
+	FsCacheEvictTTL = "ttl"
+
+	// 挂载pod的共享方式：shared为节点上所有使用该存储的pod共享一个挂载pod，
+	// dedicated为每个使用该存储的pod单独创建挂载pod，缓存资源互不影响
+	FsMountPodShared    = "shared"
+	FsMountPodDedicated = "dedicated"
+
+	// 磁盘缓存块的压缩算法，s2与lz4同类，压缩率低于zstd，cpu开销更小
+	FsCacheCompressionZstd = "zstd"
+	FsCacheCompressionS2   = "s2"
+	// FsCacheKeySecretPrefix 加密磁盘缓存块的密钥存放在挂载pod命名空间下以此为前缀的secret中，由KMS或管理员按存储写入
+	FsCacheKeySecretPrefix = "pfs-cache-key-"
+	FsCacheKeySecretItem   = "key"
 
 	FuseKeyFsInfo = "fs-info"
 
 	LabelKeyFsID             = "fsID"
 	LabelKeyCacheID          = "cacheID"
 	LabelKeyNodeName         = "nodename"
+	LabelKeyServer           = "server"
 	LabelKeyUsedSize         = "usedSize"
 	AnnotationKeyCacheDir    = "cacheDir"
+	AnnotationKeyCacheStats  = "cacheStats"
 	AnnotationKeyMTime       = "modifiedTime"
 	AnnotationKeyMountPrefix = "mount-"
 
 	EnvKeyMountPodName = "POD_NAME"
 	EnvKeyNamespace    = "NAMESPACE"
+	// 挂载pod访问所属apiserver的凭证，来自该apiserver对应的secret
+	EnvKeyServerUserName = "PFS_USER_NAME"
+	EnvKeyServerPassword = "PFS_PASSWORD"
+
+	// FsServerSecretPrefix 多个apiserver共用集群时，各apiserver的访问凭证存放在挂载pod命名空间下以此为前缀的secret中
+	FsServerSecretPrefix       = "pfs-server-"
+	FsServerSecretItemUserName = "username"
+	FsServerSecretItemPassword = "password"
+
+	// FsMountInfoSecretPrefix 开启mountInfoSecret时，存储及缓存配置存放在以此为前缀的secret中，不再以base64写入pv
+	FsMountInfoSecretPrefix = "pfs-mount-info-"
+	// EnvKeyFsInfo 挂载pod从secret读取存储配置，避免写入pod的启动命令
+	EnvKeyFsInfo = "PFS_FS_INFO"
 
 	MountPodNamespace = "paddleflow"
 )
 
+// CacheStats 挂载pod的缓存命中统计，由cache-worker从pfs-fuse的metrics汇总后写入pod注解，均为累计值
+type CacheStats struct {
+	Hits      int64 `json:"hits"`
+	Misses    int64 `json:"misses"`
+	HitBytes  int64 `json:"hitBytes"`
+	MissBytes int64 `json:"missBytes"`
+	Evictions int64 `json:"evictions"`
+}
+
 func IsValidFsMetaDriver(metaDriver string) bool {
 	switch metaDriver {
-	case FsMetaDisk, FsMetaMemory:
+	case FsMetaDisk, FsMetaMemory, FsMetaRedis, FsMetaEtcd:
 		return true
 	default:
 		return false
 	}
 }
 
+func IsSharedFsMetaDriver(metaDriver string) bool {
+	return metaDriver == FsMetaRedis || metaDriver == FsMetaEtcd
+}
+
+func IsValidFsMountPodPolicy(policy string) bool {
+	switch policy {
+	case FsMountPodShared, FsMountPodDedicated:
+		return true
+	default:
+		return false
+	}
+}
+
+func IsValidFsCacheCompression(compression string) bool {
+	switch compression {
+	case "", FsCacheCompressionZstd, FsCacheCompressionS2:
+		return true
+	default:
+		return false
+	}
+}
+
+func IsValidFsCacheEvictPolicy(policy string) bool {
+	switch policy {
+	case FsCacheEvictLRU, FsCacheEvictLFU, FsCacheEvictTTL:
+		return true
+	default:
+		return false
+	}
+}
+
+// ServerID apiserver地址的摘要，地址中的':'等字符不能用于label和secret名称
+func ServerID(server string) string {
+	sum := sha256.Sum256([]byte(server))
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// ServerSecretName 存放apiserver访问凭证的secret名称，每个apiserver的凭证相互隔离
+func ServerSecretName(server string) string {
+	return FsServerSecretPrefix + ServerID(server)
+}
+
+// MountInfoSecretName 存放存储及缓存配置的secret名称，多个apiserver共用集群时按apiserver区分
+func MountInfoSecretName(fsID, server string) string {
+	if server == "" {
+		return FsMountInfoSecretPrefix + fsID
+	}
+	return FsMountInfoSecretPrefix + fsID + "-" + ServerID(server)
+}
+
 func GetBindSource(fsID string) string {
 	return path.Join(FusePodMntDir, fsID, "storage")
 }
 
+// GetDedicatedBindSource 独占挂载pod的挂载点，按使用该存储的pod区分
+func GetDedicatedBindSource(fsID, workPodUID string) string {
+	return path.Join(FusePodMntDir, fsID, workPodUID, "storage")
+}
+
 func ConcatenatePVName(namespace, fsID string) string {
 	pvName := strings.Replace(PVNameTemplate, FSIDFormat, fsID, -1)
 	pvName = strings.Replace(pvName, NameSpaceFormat, namespace, -1)
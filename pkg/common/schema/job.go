@@ -47,6 +47,21 @@ const (
 	EnvMountPath  = "PF_MOUNT_PATH"
 
 	EnvJobRestartPolicy = "PF_JOB_RESTART_POLICY"
+	// EnvJobDatasets records datasets used by job, such as name1@v1,name2@v3
+	EnvJobDatasets = "PF_JOB_DATASETS"
+
+	// distributed job discovery env, PF_NODE_RANK and PF_REPLICA_INDEX are computed from pod name when container starts
+	EnvNodes          = "PF_NODES"
+	EnvNodeRank       = "PF_NODE_RANK"
+	EnvNodeRankOffset = "PF_NODE_RANK_OFFSET"
+	EnvReplicaIndex   = "PF_REPLICA_INDEX"
+	EnvRole           = "PF_ROLE"
+	EnvRoleReplicas   = "PF_ROLE_REPLICAS"
+	EnvMasterAddr     = "PF_MASTER_ADDR"
+	EnvMasterPort     = "PF_MASTER_PORT"
+	EnvPodName        = "PF_POD_NAME"
+	// EnvServiceFormat is the service DNS of each role, such as PF_WORKER_SERVICE
+	EnvServiceFormat = "PF_%s_SERVICE"
 
 	// EnvJobModePS env
 	EnvJobModePS          = "PS"
@@ -74,6 +89,16 @@ const (
 	EnvJobExecutorReplicas = "PF_JOB_EXECUTOR_REPLICAS"
 	EnvJobExecutorFlavour  = "PF_JOB_EXECUTOR_FLAVOUR"
 
+	// serving job env
+	EnvServingMinReplicas = "PF_SERVING_MIN_REPLICAS"
+	EnvServingMaxReplicas = "PF_SERVING_MAX_REPLICAS"
+	EnvServingMetric      = "PF_SERVING_METRIC"
+	EnvServingTargetValue = "PF_SERVING_TARGET_VALUE"
+	EnvServingHealthPath  = "PF_SERVING_HEALTH_PATH"
+	// ServingMetricQPS and ServingMetricGPU are the metrics used by serving job to autoscale
+	ServingMetricQPS = "qps"
+	ServingMetricGPU = "gpu"
+
 	// TODO move to framework
 	TypeVcJob     JobType = "vcjob"
 	TypeSparkJob  JobType = "spark"
@@ -89,6 +114,8 @@ const (
 	StatusJobTerminated  JobStatus = "terminated"
 	StatusJobCancelled   JobStatus = "cancelled"
 	StatusJobSkipped     JobStatus = "skipped"
+	// 申请资源超过审批阈值的作业，管理员审批通过后转为init
+	StatusJobPendingApproval JobStatus = "pendingApproval"
 
 	StatusTaskPending   TaskStatus = "pending"
 	StatusTaskRunning   TaskStatus = "running"
@@ -105,6 +132,7 @@ const (
 	TypeSingle      JobType = "single"
 	TypeDistributed JobType = "distributed"
 	TypeWorkflow    JobType = "workflow"
+	TypeServing     JobType = "serving"
 
 	FrameworkSpark      Framework = "spark"
 	FrameworkMPI        Framework = "mpi"
@@ -114,6 +142,8 @@ const (
 	FrameworkMXNet      Framework = "mxnet"
 	FrameworkRay        Framework = "ray"
 	FrameworkStandalone Framework = "standalone"
+	// FrameworkCustom is framework of job whose kind is defined by its extension template
+	FrameworkCustom Framework = "custom"
 
 	ListenerTypeJob   = "job"
 	ListenerTypeTask  = "task"
@@ -138,6 +168,7 @@ const (
 	JobIDLabel        = "paddleflow-job-id"
 	JobTTLSeconds     = "padleflow/job-ttl-seconds"
 	JobLabelFramework = "paddleflow-job-framework"
+	JobReplicaLabel   = "paddleflow-job-replica-type"
 
 	VolcanoJobNameLabel  = "volcano.sh/job-name"
 	QueueLabelKey        = "volcano.sh/queue-name"
@@ -196,6 +227,11 @@ func IsImmutableJobStatus(status JobStatus) bool {
 	}
 }
 
+// JobFinalStatus 返回全部不可变的作业状态
+func JobFinalStatus() []JobStatus {
+	return []JobStatus{StatusJobSucceeded, StatusJobFailed, StatusJobTerminated, StatusJobSkipped, StatusJobCancelled}
+}
+
 type PFJobConf interface {
 	GetName() string
 	GetEnv() map[string]string
@@ -206,6 +242,7 @@ type PFJobConf interface {
 
 	GetFileSystem() FileSystem
 	GetExtraFS() []FileSystem
+	GetSharedVolume() *RunSharedVolume
 	GetArgs() []string
 
 	GetPriority() string
@@ -244,12 +281,27 @@ type Conf struct {
 	// 存储资源
 	FileSystem      FileSystem   `json:"fs,omitempty"`
 	ExtraFileSystem []FileSystem `json:"extraFS,omitempty"`
+	// 临时存储，随作业释放
+	EphemeralVolumes []EphemeralVolume `json:"ephemeralVolumes,omitempty"`
+	// pipeline run级共享卷，随run释放
+	SharedVolume *RunSharedVolume `json:"sharedVolume,omitempty"`
+	// 代码包，作业启动前解压到工作目录
+	CodePackage *CodePackage `json:"codePackage,omitempty"`
+	// 输出产物，作业结束后由服务端记录其文件清单
+	OutputArtifacts []OutputArtifact `json:"outputArtifacts,omitempty"`
 	// 计算资源
 	Flavour   Flavour `json:"flavour,omitempty"`
 	Priority  string  `json:"priority"`
 	ClusterID string  `json:"clusterID"`
 	QueueID   string  `json:"queueID"`
 	QueueName string  `json:"queueName,omitempty"`
+	// 队列绑定的节点池，作业只运行在节点池的节点上
+	NodePool string `json:"nodePool,omitempty"`
+	// 队列优先级提升后在集群上生效的优先级
+	EffectivePriority string `json:"effectivePriority,omitempty"`
+	// 并发组，同组作业同时运行的个数上限
+	ConcurrencyGroup string `json:"concurrencyGroup,omitempty"`
+	ConcurrencyLimit int    `json:"concurrencyLimit,omitempty"`
 	// 运行时需要的参数
 	Labels      map[string]string `json:"labels"`
 	Annotations map[string]string `json:"annotations"`
@@ -271,6 +323,69 @@ type FileSystem struct {
 	ReadOnly  bool   `json:"readOnly,omitempty"`
 }
 
+// EphemeralVolume 作业级临时卷，用于shuffle、临时文件等场景，生命周期与作业一致
+// StorageClass为空时使用emptyDir，Size作为其sizeLimit；否则按StorageClass动态创建PVC
+type EphemeralVolume struct {
+	Name         string `json:"name"`
+	MountPath    string `json:"mountPath"`
+	Size         string `json:"size,omitempty"`
+	Medium       string `json:"medium,omitempty"`
+	StorageClass string `json:"storageClass,omitempty"`
+}
+
+const (
+	// EphemeralMediumMemory emptyDir使用tmpfs
+	EphemeralMediumMemory = "Memory"
+)
+
+// CodePackage 客户端将本地工作目录打包(tar.gz)上传到存储中，作业启动前由init容器解压到WorkDir，
+// 并以WorkDir作为作业的工作目录。FsName须为作业挂载的存储之一，Path为代码包在存储中的路径
+type CodePackage struct {
+	FsName  string `json:"fsName"`
+	Path    string `json:"path"`
+	WorkDir string `json:"workDir,omitempty"`
+}
+
+// OutputArtifact 作业声明的输出产物，FsName须为作业挂载的存储之一，Path为产物在该挂载目录下的相对路径，
+// 可以是文件或目录
+type OutputArtifact struct {
+	Name   string `json:"name"`
+	FsName string `json:"fsName"`
+	Path   string `json:"path"`
+}
+
+const (
+	// DefaultCodeWorkDir 代码包默认解压目录
+	DefaultCodeWorkDir = "/home/paddleflow/code"
+	// DefaultCodePackageImage 解压代码包的init容器镜像，需要sh和tar
+	DefaultCodePackageImage = "busybox:1.35"
+	// CodePackageVolumeName 存放解压后代码的emptyDir卷名称
+	CodePackageVolumeName = "pf-code"
+)
+
+// RunSharedVolume pipeline run级共享卷，run内所有step挂载同一个PVC，用于step间传递中间数据，run结束后删除。
+// Size、StorageClass为空时使用服务端配置的默认值
+type RunSharedVolume struct {
+	RunID        string `json:"runID"`
+	ClaimName    string `json:"claimName"`
+	Size         string `json:"size,omitempty"`
+	StorageClass string `json:"storageClass,omitempty"`
+}
+
+const (
+	// RunSharedVolumeName 共享卷在pod中的卷名称
+	RunSharedVolumeName = "pf-run-shared"
+	// RunSharedVolumeMountPath 共享卷在各step容器中的挂载路径
+	RunSharedVolumeMountPath = "/home/paddleflow/shared"
+	// RunSharedVolumeLabel 共享卷PVC上记录所属run的标签
+	RunSharedVolumeLabel = "paddleflow-run-id"
+)
+
+// RunSharedVolumeClaimName 返回run共享卷的PVC名称
+func RunSharedVolumeClaimName(runID string) string {
+	return "pf-shared-" + strings.ToLower(runID)
+}
+
 type FrameworkVersion struct {
 	Framework  string `json:"framework"`
 	APIVersion string `json:"apiVersion"`
@@ -307,6 +422,22 @@ func (c *Conf) GetExtraFS() []FileSystem {
 	return c.ExtraFileSystem
 }
 
+func (c *Conf) GetEphemeralVolumes() []EphemeralVolume {
+	return c.EphemeralVolumes
+}
+
+func (c *Conf) GetSharedVolume() *RunSharedVolume {
+	return c.SharedVolume
+}
+
+func (c *Conf) GetCodePackage() *CodePackage {
+	return c.CodePackage
+}
+
+func (c *Conf) GetOutputArtifacts() []OutputArtifact {
+	return c.OutputArtifacts
+}
+
 func (c *Conf) GetArgs() []string {
 	return c.Args
 }
@@ -347,6 +478,15 @@ func (c *Conf) SetQueueName(queueName string) {
 	c.QueueName = queueName
 }
 
+func (c *Conf) GetNodePool() string {
+	return c.NodePool
+}
+
+// SetNodePool set node pool of queue
+func (c *Conf) SetNodePool(nodePool string) {
+	c.NodePool = nodePool
+}
+
 func (c *Conf) GetUserName() string {
 	c.preCheckEnv()
 	return c.Env[EnvJobUserName]
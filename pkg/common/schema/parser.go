@@ -18,8 +18,12 @@ package schema
 
 import (
 	"fmt"
+	"net/http"
+	"regexp"
 	"strconv"
 	"strings"
+
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 type Parser struct {
@@ -28,6 +32,9 @@ type Parser struct {
 // 该函数将请求体解析成WorkflowSource，
 // 该函数未完成全局替换操作
 func (p *Parser) ParseWorkflowSource(bodyMap map[string]interface{}, wfs *WorkflowSource) error {
+	if err := p.ExpandStepTemplates(bodyMap); err != nil {
+		return err
+	}
 	for key, value := range bodyMap {
 		if value == nil {
 			continue
@@ -142,6 +149,48 @@ func (p *Parser) ParseWorkflowSource(bodyMap map[string]interface{}, wfs *Workfl
 				return err
 			}
 			wfs.FsOptions = fsOptions
+		case "notification":
+			value, ok := value.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("[notification] of workflow should be map[string]interface{} type")
+			}
+			notification := Notification{}
+			if err := p.ParseNotification(value, &notification); err != nil {
+				return err
+			}
+			wfs.Notification = &notification
+		case "shared_volume":
+			value, ok := value.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("[shared_volume] of workflow should be map[string]interface{} type")
+			}
+			sharedVolume := SharedVolume{}
+			if err := p.ParseSharedVolume(value, &sharedVolume); err != nil {
+				return err
+			}
+			wfs.SharedVolume = &sharedVolume
+		case "limits":
+			value, ok := value.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("[limits] of workflow should be map[string]interface{} type")
+			}
+			limits := RunLimits{}
+			if err := p.ParseRunLimits(value, &limits); err != nil {
+				return err
+			}
+			wfs.Limits = &limits
+		case "artifact_retention":
+			value, ok := value.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("[artifact_retention] of workflow should be map[string]interface{} type")
+			}
+			retention := ArtifactRetention{}
+			if err := p.ParseArtifactRetention(value, &retention); err != nil {
+				return err
+			}
+			wfs.ArtifactRetention = &retention
+		case StepTemplatesKey:
+			// 节点模板已经在ExpandStepTemplates中展开
 		default:
 			return fmt.Errorf("workflow has no attribute [%s]", key)
 		}
@@ -149,6 +198,113 @@ func (p *Parser) ParseWorkflowSource(bodyMap map[string]interface{}, wfs *Workfl
 	return nil
 }
 
+func (p *Parser) ParseNotification(notificationMap map[string]interface{}, notification *Notification) error {
+	for key, value := range notificationMap {
+		if value == nil {
+			continue
+		}
+		listValue, ok := value.([]interface{})
+		if !ok {
+			return fmt.Errorf("[notification.%s] should be list of string type", key)
+		}
+		strList := make([]string, 0, len(listValue))
+		for _, item := range listValue {
+			item, ok := item.(string)
+			if !ok {
+				return fmt.Errorf("[notification.%s] should be list of string type", key)
+			}
+			strList = append(strList, item)
+		}
+		switch key {
+		case "events":
+			notification.Events = strList
+		case "emails":
+			notification.Emails = strList
+		case "slack":
+			notification.Slack = strList
+		case "webhooks":
+			notification.Webhooks = strList
+		default:
+			return fmt.Errorf("[notification] of workflow has no attribute [%s]", key)
+		}
+	}
+	return notification.Validate()
+}
+
+func (p *Parser) ParseSharedVolume(volumeMap map[string]interface{}, sharedVolume *SharedVolume) error {
+	for key, value := range volumeMap {
+		strValue, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("[shared_volume.%s] should be string type", key)
+		}
+		switch key {
+		case "size":
+			if _, err := resource.ParseQuantity(strValue); err != nil {
+				return fmt.Errorf("[shared_volume.size] is invalid, error: %s", err.Error())
+			}
+			sharedVolume.Size = strValue
+		case "storage_class":
+			sharedVolume.StorageClass = strValue
+		default:
+			return fmt.Errorf("[shared_volume] of workflow has no attribute [%s]", key)
+		}
+	}
+	return nil
+}
+
+func (p *Parser) ParseRunLimits(limitsMap map[string]interface{}, limits *RunLimits) error {
+	for key, value := range limitsMap {
+		switch key {
+		case "timeout":
+			value, ok := value.(string)
+			if !ok {
+				return fmt.Errorf("[limits.timeout] should be string type, such as 48h")
+			}
+			limits.Timeout = value
+		case "max_gpu_hours":
+			switch value := value.(type) {
+			case int64:
+				limits.MaxGPUHours = float64(value)
+			case float64:
+				limits.MaxGPUHours = value
+			default:
+				return fmt.Errorf("[limits.max_gpu_hours] should be number type")
+			}
+		default:
+			return fmt.Errorf("[limits] of workflow has no attribute [%s]", key)
+		}
+	}
+	return limits.Validate()
+}
+
+func (p *Parser) ParseArtifactRetention(retentionMap map[string]interface{}, retention *ArtifactRetention) error {
+	for key, value := range retentionMap {
+		var count int
+		switch value := value.(type) {
+		case int64:
+			count = int(value)
+		case float64:
+			if value != float64(int(value)) {
+				return fmt.Errorf("[artifact_retention.%s] should be int type", key)
+			}
+			count = int(value)
+		case int:
+			count = value
+		default:
+			return fmt.Errorf("[artifact_retention.%s] should be int type", key)
+		}
+		switch key {
+		case "keep_runs":
+			retention.KeepRuns = count
+		case "keep_days":
+			retention.KeepDays = count
+		default:
+			return fmt.Errorf("[artifact_retention] of workflow has no attribute [%s]", key)
+		}
+	}
+	return retention.Validate()
+}
+
 func (p *Parser) ParseComponents(entryPoints map[string]interface{}) (map[string]Component, error) {
 	components := map[string]Component{}
 	for name, component := range entryPoints {
@@ -287,6 +443,22 @@ func (p *Parser) ParseStep(params map[string]interface{}, step *WorkflowSourceSt
 				return fmt.Errorf("[docker_env] in step should be string type")
 			}
 			step.DockerEnv = value
+		case "queue", "flavour":
+			// 节点可以使用与run中其他节点不同的队列及套餐，与env中的PF_JOB_QUEUE_NAME、PF_JOB_FLAVOUR等价，后者优先级更高
+			value, ok := value.(string)
+			if !ok {
+				return fmt.Errorf("[%s] in step should be string type", key)
+			}
+			envKey := EnvJobQueueName
+			if key == "flavour" {
+				envKey = EnvJobFlavour
+			}
+			if step.Env == nil {
+				step.Env = map[string]string{}
+			}
+			if _, ok := step.Env[envKey]; !ok {
+				step.Env[envKey] = value
+			}
 		case "cache":
 			cache := Cache{}
 			value, ok := value.(map[string]interface{})
@@ -311,6 +483,23 @@ func (p *Parser) ParseStep(params map[string]interface{}, step *WorkflowSourceSt
 						return fmt.Errorf("[reference.component] in step should be string type")
 					}
 					reference.Component = refValue
+				case "pipeline":
+					refValue, ok := refValue.(string)
+					if !ok {
+						return fmt.Errorf("[reference.pipeline] in step should be string type")
+					}
+					reference.Pipeline = refValue
+				case "pipeline_version":
+					switch refValue := refValue.(type) {
+					case string:
+						reference.PipelineVersion = refValue
+					case int64:
+						reference.PipelineVersion = strconv.FormatInt(refValue, 10)
+					case float64:
+						reference.PipelineVersion = strconv.FormatInt(int64(refValue), 10)
+					default:
+						return fmt.Errorf("[reference.pipeline_version] in step should be string type")
+					}
 				default:
 					return fmt.Errorf("[reference] of step has no attribute [%s]", refKey)
 				}
@@ -332,6 +521,36 @@ func (p *Parser) ParseStep(params map[string]interface{}, step *WorkflowSourceSt
 				}
 				step.ExtraFS = append(step.ExtraFS, fsMount)
 			}
+		case "retry":
+			value, ok := value.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("[retry] in step should be map type")
+			}
+			retry := Retry{}
+			if err := p.ParseRetry(value, &retry); err != nil {
+				return fmt.Errorf("parse [retry] in step failed, error: %s", err.Error())
+			}
+			step.Retry = retry
+		case "http":
+			value, ok := value.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("[http] in step should be map type")
+			}
+			httpStep := HTTPStep{}
+			if err := p.ParseHTTPStep(value, &httpStep); err != nil {
+				return fmt.Errorf("parse [http] in step failed, error: %s", err.Error())
+			}
+			step.HTTP = &httpStep
+		case "approval":
+			value, ok := value.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("[approval] in step should be map type")
+			}
+			approval := ApprovalStep{}
+			if err := p.ParseApprovalStep(value, &approval); err != nil {
+				return fmt.Errorf("parse [approval] in step failed, error: %s", err.Error())
+			}
+			step.Approval = &approval
 		case "type":
 			value, ok := value.(string)
 			if !ok {
@@ -348,6 +567,9 @@ func (p *Parser) ParseStep(params map[string]interface{}, step *WorkflowSourceSt
 			return fmt.Errorf("step has no attribute [%s]", key)
 		}
 	}
+	if step.HTTP != nil && step.Approval != nil {
+		return fmt.Errorf("[http] and [approval] cannot be set in the same step")
+	}
 	return nil
 }
 
@@ -493,6 +715,172 @@ func (p *Parser) ParseCache(cacheMap map[string]interface{}, cache *Cache) error
 	return nil
 }
 
+func (p *Parser) ParseRetry(retryMap map[string]interface{}, retry *Retry) error {
+	for key, value := range retryMap {
+		if value == nil {
+			continue
+		}
+		switch key {
+		case "limit":
+			switch value := value.(type) {
+			case int64:
+				retry.Limit = int(value)
+			case float64:
+				// 兼容由json.Unmarshal得到的值
+				retry.Limit = int(value)
+			default:
+				return fmt.Errorf("[retry.limit] should be int type")
+			}
+		case "backoff":
+			switch value := value.(type) {
+			case int64:
+				retry.Backoff = int(value)
+			case float64:
+				retry.Backoff = int(value)
+			default:
+				return fmt.Errorf("[retry.backoff] should be int type")
+			}
+		default:
+			return fmt.Errorf("[retry] has no attribute [%s]", key)
+		}
+	}
+	return nil
+}
+
+func (p *Parser) ParseHTTPStep(httpMap map[string]interface{}, httpStep *HTTPStep) error {
+	for key, value := range httpMap {
+		if value == nil {
+			continue
+		}
+		switch key {
+		case "url":
+			value, ok := value.(string)
+			if !ok {
+				return fmt.Errorf("[http.url] should be string type")
+			}
+			httpStep.URL = value
+		case "method":
+			value, ok := value.(string)
+			if !ok {
+				return fmt.Errorf("[http.method] should be string type")
+			}
+			httpStep.Method = strings.ToUpper(value)
+		case "headers":
+			value, ok := value.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("[http.headers] should be map type")
+			}
+			httpStep.Headers = map[string]string{}
+			for name, header := range value {
+				header, ok := header.(string)
+				if !ok {
+					return fmt.Errorf("value of header[%s] should be string type", name)
+				}
+				httpStep.Headers[name] = header
+			}
+		case "body":
+			value, ok := value.(string)
+			if !ok {
+				return fmt.Errorf("[http.body] should be string type")
+			}
+			httpStep.Body = value
+		case "timeout":
+			switch value := value.(type) {
+			case int64:
+				httpStep.Timeout = int(value)
+			case float64:
+				httpStep.Timeout = int(value)
+			default:
+				return fmt.Errorf("[http.timeout] should be int type")
+			}
+		case "expected_status":
+			value, ok := value.([]interface{})
+			if !ok {
+				return fmt.Errorf("[http.expected_status] should be list type")
+			}
+			for _, code := range value {
+				switch code := code.(type) {
+				case int64:
+					httpStep.ExpectedStatus = append(httpStep.ExpectedStatus, int(code))
+				case float64:
+					httpStep.ExpectedStatus = append(httpStep.ExpectedStatus, int(code))
+				default:
+					return fmt.Errorf("each code in [http.expected_status] should be int type")
+				}
+			}
+		case "success_pattern":
+			value, ok := value.(string)
+			if !ok {
+				return fmt.Errorf("[http.success_pattern] should be string type")
+			}
+			if _, err := regexp.Compile(value); err != nil {
+				return fmt.Errorf("[http.success_pattern] is not a valid regexp: %v", err)
+			}
+			httpStep.SuccessPattern = value
+		default:
+			return fmt.Errorf("[http] has no attribute [%s]", key)
+		}
+	}
+
+	if httpStep.URL == "" {
+		return fmt.Errorf("[http.url] should not be empty")
+	}
+	switch httpStep.Method {
+	case "":
+		httpStep.Method = http.MethodGet
+	case http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodHead:
+	default:
+		return fmt.Errorf("[http.method] [%s] is not supported", httpStep.Method)
+	}
+	if httpStep.Timeout < 0 {
+		return fmt.Errorf("[http.timeout] should not be negative")
+	}
+	return nil
+}
+
+func (p *Parser) ParseApprovalStep(approvalMap map[string]interface{}, approval *ApprovalStep) error {
+	for key, value := range approvalMap {
+		if value == nil {
+			continue
+		}
+		switch key {
+		case "message":
+			value, ok := value.(string)
+			if !ok {
+				return fmt.Errorf("[approval.message] should be string type")
+			}
+			approval.Message = value
+		case "approvers":
+			value, ok := value.([]interface{})
+			if !ok {
+				return fmt.Errorf("[approval.approvers] should be list type")
+			}
+			for _, approver := range value {
+				approver, ok := approver.(string)
+				if !ok {
+					return fmt.Errorf("each approver in [approval.approvers] should be string type")
+				}
+				approval.Approvers = append(approval.Approvers, approver)
+			}
+		case "timeout":
+			switch value := value.(type) {
+			case int64:
+				approval.Timeout = int(value)
+			case float64:
+				approval.Timeout = int(value)
+			default:
+				return fmt.Errorf("[approval.timeout] should be int type")
+			}
+			if approval.Timeout < 0 {
+				return fmt.Errorf("[approval.timeout] should not be negative")
+			}
+		default:
+			return fmt.Errorf("[approval] has no attribute [%s]", key)
+		}
+	}
+	return nil
+}
+
 func (p *Parser) ParseFsScope(fsMap map[string]interface{}, fs *FsScope) error {
 	for key, value := range fsMap {
 		switch key {
@@ -643,6 +1031,57 @@ func (p *Parser) TransJsonMap2Yaml(jsonMap map[string]interface{}) error {
 			}
 			jsonMap["fs_options"] = value
 			delete(jsonMap, "fsOptions")
+		case "stepTemplates":
+			if err := p.transJsonSubComp2Yaml(value, "stepTemplates"); err != nil {
+				return err
+			}
+			jsonMap[StepTemplatesKey] = value
+			delete(jsonMap, "stepTemplates")
+		case "limits":
+			if limitsMap, ok := value.(map[string]interface{}); ok {
+				if maxGPUHours, ok := limitsMap["maxGPUHours"]; ok {
+					limitsMap["max_gpu_hours"] = maxGPUHours
+					delete(limitsMap, "maxGPUHours")
+				}
+			}
+		case "artifactRetention":
+			if retentionMap, ok := value.(map[string]interface{}); ok {
+				for jsonKey, yamlKey := range map[string]string{"keepRuns": "keep_runs", "keepDays": "keep_days"} {
+					if v, ok := retentionMap[jsonKey]; ok {
+						retentionMap[yamlKey] = v
+						delete(retentionMap, jsonKey)
+					}
+				}
+			}
+			jsonMap["artifact_retention"] = value
+			delete(jsonMap, "artifactRetention")
+		case "sharedVolume":
+			if volumeMap, ok := value.(map[string]interface{}); ok {
+				if storageClass, ok := volumeMap["storageClass"]; ok {
+					volumeMap["storage_class"] = storageClass
+					delete(volumeMap, "storageClass")
+				}
+			}
+			jsonMap["shared_volume"] = value
+			delete(jsonMap, "sharedVolume")
+		case "http":
+			if httpMap, ok := value.(map[string]interface{}); ok {
+				if codes, ok := httpMap["expectedStatus"]; ok {
+					httpMap["expected_status"] = codes
+					delete(httpMap, "expectedStatus")
+				}
+				if pattern, ok := httpMap["successPattern"]; ok {
+					httpMap["success_pattern"] = pattern
+					delete(httpMap, "successPattern")
+				}
+			}
+		case "reference":
+			if refMap, ok := value.(map[string]interface{}); ok {
+				if version, ok := refMap["pipelineVersion"]; ok {
+					refMap["pipeline_version"] = version
+					delete(refMap, "pipelineVersion")
+				}
+			}
 		}
 	}
 	return nil
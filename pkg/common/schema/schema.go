@@ -58,6 +58,16 @@ type JobView struct {
 	JobMessage  string            `json:"jobMessage"`
 	CacheRunID  string            `json:"cacheRunID"`
 	CacheJobID  string            `json:"cacheJobID"`
+	Attempts    []JobAttempt      `json:"attempts"`
+}
+
+// JobAttempt 记录节点重试前，每一次失败运行的 job 信息
+type JobAttempt struct {
+	JobID     string    `json:"jobID"`
+	Status    JobStatus `json:"status"`
+	StartTime string    `json:"startTime"`
+	EndTime   string    `json:"endTime"`
+	Message   string    `json:"message"`
 }
 
 func (j JobView) GetComponentName() string {
@@ -0,0 +1,133 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schema
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	// StepTemplatesKey 全局的节点模板字段
+	StepTemplatesKey = "step_templates"
+	// StepTemplateKey 节点引用模板的字段
+	StepTemplateKey = "template"
+)
+
+// stepTemplateFields 节点模板中可以定义的字段，其中parameters和env按key与节点合并，其余字段仅在节点未定义时生效
+var stepTemplateFields = map[string]bool{
+	"docker_env": true,
+	"command":    true,
+	"parameters": true,
+	"env":        true,
+	"artifacts":  true,
+	"cache":      true,
+	"extra_fs":   true,
+	"retry":      true,
+}
+
+// ExpandStepTemplates 将entry_points、post_process、components中引用了模板的节点展开为完整的节点定义，
+// 展开后节点的template字段会被删除，因此重复调用不会产生影响
+func (p *Parser) ExpandStepTemplates(bodyMap map[string]interface{}) error {
+	templates := map[string]map[string]interface{}{}
+	if value, ok := bodyMap[StepTemplatesKey]; ok && value != nil {
+		templatesMap, ok := value.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("[%s] of workflow should be map type", StepTemplatesKey)
+		}
+		for name, template := range templatesMap {
+			templateMap, ok := template.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("step template [%s] should be map type", name)
+			}
+			for key := range templateMap {
+				if !stepTemplateFields[key] {
+					return fmt.Errorf("step template [%s] has no attribute [%s]", name, key)
+				}
+			}
+			templates[name] = templateMap
+		}
+	}
+
+	for _, field := range []string{"entry_points", "post_process", "components"} {
+		if value, ok := bodyMap[field].(map[string]interface{}); ok {
+			if err := expandComponentsTemplates(value, templates); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func expandComponentsTemplates(components map[string]interface{}, templates map[string]map[string]interface{}) error {
+	for name, component := range components {
+		compMap, ok := component.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if subComponents, ok := compMap["entry_points"].(map[string]interface{}); ok {
+			if err := expandComponentsTemplates(subComponents, templates); err != nil {
+				return err
+			}
+			continue
+		}
+		value, ok := compMap[StepTemplateKey]
+		if !ok {
+			continue
+		}
+		templateName, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("[template] in step [%s] should be string type", name)
+		}
+		if _, ok := compMap["reference"]; ok {
+			return fmt.Errorf("step [%s] can not set both [template] and [reference]", name)
+		}
+		template, ok := templates[templateName]
+		if !ok {
+			return fmt.Errorf("step template [%s] used by step [%s] is not defined in [%s]",
+				templateName, name, StepTemplatesKey)
+		}
+		mergeStepTemplate(compMap, template)
+		delete(compMap, StepTemplateKey)
+	}
+	return nil
+}
+
+// mergeStepTemplate 节点中定义的字段优先于模板
+func mergeStepTemplate(step, template map[string]interface{}) {
+	for key, value := range template {
+		stepValue, ok := step[key]
+		if !ok || stepValue == nil {
+			step[key] = runtime.DeepCopyJSONValue(value)
+			continue
+		}
+		if key != "parameters" && key != "env" {
+			continue
+		}
+		stepMap, ok1 := stepValue.(map[string]interface{})
+		templateMap, ok2 := value.(map[string]interface{})
+		if !ok1 || !ok2 {
+			continue
+		}
+		for k, v := range templateMap {
+			if _, ok := stepMap[k]; !ok {
+				stepMap[k] = runtime.DeepCopyJSONValue(v)
+			}
+		}
+	}
+}
@@ -20,9 +20,12 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"net/mail"
 	"reflect"
 	"strings"
+	"time"
 
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/http/outbound"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
 	"gopkg.in/yaml.v2"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -43,6 +46,14 @@ const (
 	FailureStrategyFailFast = "fail_fast"
 	FailureStrategyContinue = "continue"
 
+	MaxRetryBackoff = time.Hour
+
+	StepTypeJob      = "job"
+	StepTypeHTTP     = "http"
+	StepTypeApproval = "approval"
+
+	DefaultHTTPStepTimeout = 30 * time.Second
+
 	EnvDockerEnv = "dockerEnv"
 
 	FsPrefix = "fs-"
@@ -146,6 +157,10 @@ type WorkflowSourceStep struct {
 	Cache        Cache                  `yaml:"cache"             json:"cache"`
 	Reference    Reference              `yaml:"reference"         json:"reference"`
 	ExtraFS      []FsMount              `yaml:"extra_fs"          json:"extraFS"`
+	Retry        Retry                  `yaml:"retry"             json:"retry"`
+
+	HTTP     *HTTPStep     `yaml:"http,omitempty"     json:"http,omitempty"`
+	Approval *ApprovalStep `yaml:"approval,omitempty" json:"approval,omitempty"`
 }
 
 func (s *WorkflowSourceStep) GetName() string {
@@ -197,6 +212,18 @@ func (s *WorkflowSourceStep) UpdateLoopArguemt(loopArgument interface{}) {
 	s.LoopArgument = loopArgument
 }
 
+// GetStepType 返回节点的运行方式，未设置http与approval的节点均以容器作业的方式运行
+func (s *WorkflowSourceStep) GetStepType() string {
+	switch {
+	case s.HTTP != nil:
+		return StepTypeHTTP
+	case s.Approval != nil:
+		return StepTypeApproval
+	default:
+		return StepTypeJob
+	}
+}
+
 func (s *WorkflowSourceStep) UpdateDeps(deps string) {
 	s.Deps = deps
 }
@@ -292,6 +319,9 @@ func (s *WorkflowSourceStep) DeepCopy() Component {
 		Cache:        s.Cache,
 		Reference:    s.Reference,
 		ExtraFS:      fsMount,
+		Retry:        s.Retry,
+		HTTP:         s.HTTP.DeepCopy(),
+		Approval:     s.Approval.DeepCopy(),
 	}
 
 	return ns
@@ -456,12 +486,147 @@ func (d *WorkflowSourceDag) DeepCopy() Component {
 }
 
 type RunOptions struct {
-	FSUsername string
-	StopForce  bool
+	FSUsername      string
+	StopForce       bool
+	FailureStrategy string
+	Notification    *Notification `json:",omitempty"`
+	Limits          *RunLimits    `json:",omitempty"`
+	// Engine 创建run时使用的执行引擎，resume/stop时沿用，为空表示内置引擎
+	Engine string `json:",omitempty"`
+}
+
+// Notification run结束时发送通知的配置，优先级：run > pipeline > 全局配置
+type Notification struct {
+	// 触发通知的run状态，如 succeeded、failed、terminated，为空表示所有终态均会通知
+	Events   []string `yaml:"events,omitempty"   json:"events,omitempty"`
+	Emails   []string `yaml:"emails,omitempty"   json:"emails,omitempty"`
+	Slack    []string `yaml:"slack,omitempty"    json:"slack,omitempty"` // slack incoming webhook url
+	Webhooks []string `yaml:"webhooks,omitempty" json:"webhooks,omitempty"`
+}
+
+// Validate 检查通知的邮箱地址，以及slack和webhook地址是否在允许访问的域名中
+func (n *Notification) Validate() error {
+	if n == nil {
+		return nil
+	}
+	for _, email := range n.Emails {
+		address, err := mail.ParseAddress(email)
+		if err != nil || address.Address != email {
+			return fmt.Errorf("[notification.emails] email %s is invalid", email)
+		}
+	}
+	for key, urls := range map[string][]string{"slack": n.Slack, "webhooks": n.Webhooks} {
+		for _, url := range urls {
+			if err := outbound.CheckURL(url); err != nil {
+				return fmt.Errorf("[notification.%s] is invalid, error: %s", key, err.Error())
+			}
+		}
+	}
+	return nil
+}
+
+// RunLimits run的运行时长及资源消耗上限，超出任一上限的run会被停止，优先级：run > pipeline
+type RunLimits struct {
+	// Timeout run的最长运行时间，如48h、30m，为空表示不限制
+	Timeout string `yaml:"timeout,omitempty"       json:"timeout,omitempty"`
+	// MaxGPUHours run中所有作业消耗的GPU卡时上限，0表示不限制
+	MaxGPUHours float64 `yaml:"max_gpu_hours,omitempty" json:"maxGPUHours,omitempty"`
+}
+
+func (l *RunLimits) Validate() error {
+	if l == nil {
+		return nil
+	}
+	if l.Timeout != "" {
+		timeout, err := time.ParseDuration(l.Timeout)
+		if err != nil {
+			return fmt.Errorf("[limits.timeout] is invalid, error: %s", err.Error())
+		}
+		if timeout <= 0 {
+			return fmt.Errorf("[limits.timeout] should be positive")
+		}
+	}
+	if l.MaxGPUHours < 0 {
+		return fmt.Errorf("[limits.max_gpu_hours] should not be negative")
+	}
+	return nil
+}
+
+// GetTimeout 返回run的最长运行时间，0表示不限制
+func (l *RunLimits) GetTimeout() time.Duration {
+	if l == nil || l.Timeout == "" {
+		return 0
+	}
+	timeout, err := time.ParseDuration(l.Timeout)
+	if err != nil {
+		return 0
+	}
+	return timeout
+}
+
+// ArtifactRetention pipeline输出产物的保留策略，同一pipeline的已结束run中超出保留范围的产物会被清理
+type ArtifactRetention struct {
+	// KeepRuns 保留最近N次run的产物，0表示不按次数清理
+	KeepRuns int `yaml:"keep_runs,omitempty" json:"keepRuns,omitempty"`
+	// KeepDays 保留最近M天内结束的run的产物，0表示不按时间清理
+	KeepDays int `yaml:"keep_days,omitempty" json:"keepDays,omitempty"`
+}
+
+func (r *ArtifactRetention) Validate() error {
+	if r == nil {
+		return nil
+	}
+	if r.KeepRuns < 0 {
+		return fmt.Errorf("[artifact_retention.keep_runs] should not be negative")
+	}
+	if r.KeepDays < 0 {
+		return fmt.Errorf("[artifact_retention.keep_days] should not be negative")
+	}
+	return nil
+}
+
+// IsEnabled 是否设置了任一保留上限
+func (r *ArtifactRetention) IsEnabled() bool {
+	return r != nil && (r.KeepRuns > 0 || r.KeepDays > 0)
+}
+
+// RunUsage run已经运行的时长及其作业消耗的GPU卡时
+type RunUsage struct {
+	ElapsedSeconds int64   `json:"elapsedSeconds"`
+	GPUHours       float64 `json:"gpuHours"`
+}
+
+// SharedVolume run级共享卷配置，run内所有step均挂载该卷，run结束后释放
+type SharedVolume struct {
+	Size         string `yaml:"size,omitempty"          json:"size,omitempty"`
+	StorageClass string `yaml:"storage_class,omitempty" json:"storageClass,omitempty"`
+}
+
+// ShouldNotify 判断run在该状态结束时是否需要发送通知
+func (n *Notification) ShouldNotify(status string) bool {
+	if n == nil {
+		return false
+	}
+	if len(n.Emails) == 0 && len(n.Slack) == 0 && len(n.Webhooks) == 0 {
+		return false
+	}
+	if len(n.Events) == 0 {
+		return true
+	}
+	for _, event := range n.Events {
+		if event == status {
+			return true
+		}
+	}
+	return false
 }
 
 type Reference struct {
 	Component string `yaml:"component" json:"component"`
+
+	// 引用其他pipeline作为子工作流，创建run时会被展开为dag
+	Pipeline        string `yaml:"pipeline,omitempty"         json:"pipeline,omitempty"`
+	PipelineVersion string `yaml:"pipeline_version,omitempty" json:"pipelineVersion,omitempty"` // 为空时使用最新版本
 }
 
 type Cache struct {
@@ -476,6 +641,91 @@ type FsScope struct {
 	Path string `yaml:"path"          json:"path"`
 }
 
+// Retry 为节点级别的重试配置，Limit 为失败后的最大重试次数，Backoff 为首次重试前的等待秒数，后续每次重试等待时间翻倍
+type Retry struct {
+	Limit   int `yaml:"limit"           json:"limit"`
+	Backoff int `yaml:"backoff"         json:"backoff"` // seconds
+}
+
+// GetBackoff 返回第 attempt 次重试（从 1 开始）前需要等待的时间，最长不超过 MaxRetryBackoff
+func (r Retry) GetBackoff(attempt int) time.Duration {
+	if r.Backoff <= 0 || attempt <= 0 {
+		return 0
+	}
+	backoff := time.Duration(r.Backoff) * time.Second
+	for i := 1; i < attempt; i++ {
+		backoff *= 2
+		if backoff >= MaxRetryBackoff {
+			return MaxRetryBackoff
+		}
+	}
+	if backoff > MaxRetryBackoff {
+		return MaxRetryBackoff
+	}
+	return backoff
+}
+
+// HTTPStep 为调用外部接口的节点，不创建容器作业，根据响应的状态码与响应体判断节点是否成功
+type HTTPStep struct {
+	URL            string            `yaml:"url"                       json:"url"`
+	Method         string            `yaml:"method,omitempty"          json:"method,omitempty"`
+	Headers        map[string]string `yaml:"headers,omitempty"         json:"headers,omitempty"`
+	Body           string            `yaml:"body,omitempty"            json:"body,omitempty"`
+	Timeout        int               `yaml:"timeout,omitempty"         json:"timeout,omitempty"` // seconds
+	ExpectedStatus []int             `yaml:"expected_status,omitempty" json:"expectedStatus,omitempty"`
+	SuccessPattern string            `yaml:"success_pattern,omitempty" json:"successPattern,omitempty"` // 响应体需匹配的正则
+}
+
+func (h *HTTPStep) DeepCopy() *HTTPStep {
+	if h == nil {
+		return nil
+	}
+	nh := *h
+	nh.Headers = map[string]string{}
+	for name, value := range h.Headers {
+		nh.Headers[name] = value
+	}
+	nh.ExpectedStatus = append([]int{}, h.ExpectedStatus...)
+	return &nh
+}
+
+// GetTimeout 返回单次请求的超时时间，未设置时为 DefaultHTTPStepTimeout
+func (h *HTTPStep) GetTimeout() time.Duration {
+	if h.Timeout <= 0 {
+		return DefaultHTTPStepTimeout
+	}
+	return time.Duration(h.Timeout) * time.Second
+}
+
+// IsExpectedStatus 未设置 expected_status 时，2xx 均视为成功
+func (h *HTTPStep) IsExpectedStatus(code int) bool {
+	if len(h.ExpectedStatus) == 0 {
+		return code >= 200 && code < 300
+	}
+	for _, expected := range h.ExpectedStatus {
+		if code == expected {
+			return true
+		}
+	}
+	return false
+}
+
+// ApprovalStep 为人工审批节点，节点会一直等待，直到通过审批接口批准或拒绝；Timeout 内未审批则节点失败
+type ApprovalStep struct {
+	Message   string   `yaml:"message,omitempty"   json:"message,omitempty"`
+	Approvers []string `yaml:"approvers,omitempty" json:"approvers,omitempty"` // 为空时仅run的创建者与root可以审批
+	Timeout   int      `yaml:"timeout,omitempty"   json:"timeout,omitempty"`   // seconds, 0 表示不超时
+}
+
+func (a *ApprovalStep) DeepCopy() *ApprovalStep {
+	if a == nil {
+		return nil
+	}
+	na := *a
+	na.Approvers = append([]string{}, a.Approvers...)
+	return &na
+}
+
 type FailureOptions struct {
 	Strategy string `yaml:"strategy"     json:"strategy"`
 }
@@ -504,6 +754,12 @@ type WorkflowSource struct {
 	FailureOptions FailureOptions                 `yaml:"failure_options"    json:"failureOptions"`
 	PostProcess    map[string]*WorkflowSourceStep `yaml:"post_process"       json:"postProcess"`
 	FsOptions      FsOptions                      `yaml:"fs_options"         json:"fsOptions"`
+
+	Notification *Notification `yaml:"notification,omitempty" json:"notification,omitempty"`
+	SharedVolume *SharedVolume `yaml:"shared_volume,omitempty" json:"sharedVolume,omitempty"`
+	Limits       *RunLimits    `yaml:"limits,omitempty"        json:"limits,omitempty"`
+
+	ArtifactRetention *ArtifactRetention `yaml:"artifact_retention,omitempty" json:"artifactRetention,omitempty"`
 }
 
 func (wfs *WorkflowSource) UnmarshalJSON(data []byte) error {
@@ -856,6 +1112,10 @@ func (wfs *WorkflowSource) TransToRunYamlRaw() (runYamlRaw string, err error) {
 		FailureOptions FailureOptions                 `yaml:"failure_options"`
 		PostProcess    map[string]*WorkflowSourceStep `yaml:"post_process"`
 		FsOptions      FsOptions                      `yaml:"fs_options"`
+		Notification   *Notification                  `yaml:"notification,omitempty"`
+		SharedVolume   *SharedVolume                  `yaml:"shared_volume,omitempty"`
+		Limits         *RunLimits                     `yaml:"limits,omitempty"`
+		Retention      *ArtifactRetention             `yaml:"artifact_retention,omitempty"`
 	}
 
 	wf := workflow{
@@ -868,6 +1128,10 @@ func (wfs *WorkflowSource) TransToRunYamlRaw() (runYamlRaw string, err error) {
 		FailureOptions: wfs.FailureOptions,
 		PostProcess:    wfs.PostProcess,
 		FsOptions:      wfs.FsOptions,
+		Notification:   wfs.Notification,
+		SharedVolume:   wfs.SharedVolume,
+		Limits:         wfs.Limits,
+		Retention:      wfs.ArtifactRetention,
 	}
 
 	runYaml, err := yaml.Marshal(wf)
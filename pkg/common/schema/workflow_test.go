@@ -22,8 +22,11 @@ import (
 	"fmt"
 	"io/ioutil"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/http/outbound"
 )
 
 const runYamlPath = "../../apiserver/controller/pipeline/testcase/run_dag.yaml"
@@ -108,3 +111,298 @@ func TestTransToRunYamlRaw(t *testing.T) {
 	assert.Contains(t, newWfs.PostProcess, "post")
 	assert.Equal(t, len(wfs.EntryPoints.EntryPoints), len(newWfs.EntryPoints.EntryPoints))
 }
+
+func TestParseStepRetry(t *testing.T) {
+	p := Parser{}
+	step := WorkflowSourceStep{}
+	err := p.ParseStep(map[string]interface{}{
+		"command": "echo retry",
+		"retry": map[string]interface{}{
+			"limit":   int64(3),
+			"backoff": float64(10),
+		},
+	}, &step)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, step.Retry.Limit)
+	assert.Equal(t, 10, step.Retry.Backoff)
+
+	newStep := step.DeepCopy().(*WorkflowSourceStep)
+	assert.Equal(t, step.Retry, newStep.Retry)
+
+	err = p.ParseStep(map[string]interface{}{
+		"retry": map[string]interface{}{
+			"times": int64(3),
+		},
+	}, &step)
+	assert.NotNil(t, err)
+
+	err = p.ParseStep(map[string]interface{}{
+		"retry": map[string]interface{}{
+			"limit": "3",
+		},
+	}, &step)
+	assert.NotNil(t, err)
+}
+
+func TestRetryGetBackoff(t *testing.T) {
+	retry := Retry{Limit: 3, Backoff: 10}
+	assert.Equal(t, time.Duration(0), retry.GetBackoff(0))
+	assert.Equal(t, 10*time.Second, retry.GetBackoff(1))
+	assert.Equal(t, 20*time.Second, retry.GetBackoff(2))
+	assert.Equal(t, 40*time.Second, retry.GetBackoff(3))
+	assert.Equal(t, MaxRetryBackoff, retry.GetBackoff(20))
+
+	retry = Retry{Limit: 3}
+	assert.Equal(t, time.Duration(0), retry.GetBackoff(2))
+}
+
+func TestParseNotification(t *testing.T) {
+	runYaml := `name: notify
+entry_points:
+  main:
+    command: "echo main"
+notification:
+  events: [failed]
+  webhooks: ["http://example.com/hook"]
+`
+	wfs, err := GetWorkflowSource([]byte(runYaml))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"failed"}, wfs.Notification.Events)
+	assert.Equal(t, []string{"http://example.com/hook"}, wfs.Notification.Webhooks)
+	assert.True(t, wfs.Notification.ShouldNotify("failed"))
+	assert.False(t, wfs.Notification.ShouldNotify("succeeded"))
+
+	p := Parser{}
+	err = p.ParseNotification(map[string]interface{}{"sms": []interface{}{"123"}}, &Notification{})
+	assert.NotNil(t, err)
+	err = p.ParseNotification(map[string]interface{}{"emails": "a@example.com"}, &Notification{})
+	assert.NotNil(t, err)
+}
+
+func TestNotificationValidate(t *testing.T) {
+	assert.Nil(t, (&Notification{Emails: []string{"a@example.com"}, Webhooks: []string{"https://example.com/hook"}}).Validate())
+	assert.NotNil(t, (&Notification{Emails: []string{"a@example.com\r\nBcc: b@example.com"}}).Validate())
+	assert.NotNil(t, (&Notification{Emails: []string{"Bob <b@example.com>"}}).Validate())
+	assert.NotNil(t, (&Notification{Slack: []string{"ftp://example.com/hook"}}).Validate())
+
+	assert.Nil(t, outbound.Init(outbound.Config{AllowedHosts: []string{"hooks.slack.com"}}))
+	defer outbound.Init(outbound.Config{})
+	assert.Nil(t, (&Notification{Slack: []string{"https://hooks.slack.com/services/xxx"}}).Validate())
+	assert.NotNil(t, (&Notification{Webhooks: []string{"http://169.254.169.254/latest"}}).Validate())
+}
+
+func TestParseSharedVolume(t *testing.T) {
+	runYaml := `name: shared
+entry_points:
+  main:
+    command: "echo main"
+shared_volume:
+  size: 20Gi
+  storage_class: cfs
+`
+	wfs, err := GetWorkflowSource([]byte(runYaml))
+	assert.Nil(t, err)
+	assert.Equal(t, "20Gi", wfs.SharedVolume.Size)
+	assert.Equal(t, "cfs", wfs.SharedVolume.StorageClass)
+
+	p := Parser{}
+	err = p.ParseSharedVolume(map[string]interface{}{"size": "abc"}, &SharedVolume{})
+	assert.NotNil(t, err)
+	err = p.ParseSharedVolume(map[string]interface{}{"mount_path": "/mnt"}, &SharedVolume{})
+	assert.NotNil(t, err)
+	assert.Equal(t, "pf-shared-run-000001", RunSharedVolumeClaimName("run-000001"))
+}
+
+func TestExpandStepTemplates(t *testing.T) {
+	runYaml := `name: templates
+docker_env: python:3.7
+step_templates:
+  train:
+    docker_env: paddlepaddle/paddle:2.4.0
+    command: "python train.py --lr {{lr}} --epoch {{epoch}}"
+    parameters:
+      lr: 0.1
+      epoch: 10
+    env:
+      PF_JOB_FLAVOUR: flavour1
+      PF_JOB_QUEUE_NAME: train-queue
+entry_points:
+  train-small:
+    template: train
+    parameters:
+      lr: 0.01
+  train-large:
+    template: train
+    deps: train-small
+    env:
+      PF_JOB_FLAVOUR: flavour2
+  dag:
+    entry_points:
+      train-sub:
+        template: train
+        command: "python train_sub.py --lr {{lr}}"
+`
+	wfs, err := GetWorkflowSource([]byte(runYaml))
+	assert.Nil(t, err)
+
+	small := wfs.EntryPoints.EntryPoints["train-small"].(*WorkflowSourceStep)
+	assert.Equal(t, "paddlepaddle/paddle:2.4.0", small.DockerEnv)
+	assert.Equal(t, "python train.py --lr {{lr}} --epoch {{epoch}}", small.Command)
+	assert.Equal(t, 0.01, small.Parameters["lr"])
+	assert.Equal(t, int64(10), small.Parameters["epoch"])
+	assert.Equal(t, "flavour1", small.Env["PF_JOB_FLAVOUR"])
+
+	large := wfs.EntryPoints.EntryPoints["train-large"].(*WorkflowSourceStep)
+	assert.Equal(t, 0.1, large.Parameters["lr"])
+	assert.Equal(t, "flavour2", large.Env["PF_JOB_FLAVOUR"])
+	assert.Equal(t, "train-queue", large.Env["PF_JOB_QUEUE_NAME"])
+	assert.Equal(t, []string{"train-small"}, large.GetDeps())
+
+	dag := wfs.EntryPoints.EntryPoints["dag"].(*WorkflowSourceDag)
+	sub := dag.EntryPoints["train-sub"].(*WorkflowSourceStep)
+	assert.Equal(t, "python train_sub.py --lr {{lr}}", sub.Command)
+	assert.Equal(t, "paddlepaddle/paddle:2.4.0", sub.DockerEnv)
+
+	// 修改展开后的节点不影响其他节点
+	small.Parameters["epoch"] = int64(1)
+	assert.Equal(t, int64(10), large.Parameters["epoch"])
+
+	p := Parser{}
+	err = p.ExpandStepTemplates(map[string]interface{}{
+		"entry_points": map[string]interface{}{
+			"main": map[string]interface{}{"template": "missing"},
+		},
+	})
+	assert.NotNil(t, err)
+	err = p.ExpandStepTemplates(map[string]interface{}{
+		"step_templates": map[string]interface{}{
+			"train": map[string]interface{}{"deps": "main"},
+		},
+	})
+	assert.NotNil(t, err)
+	err = p.ExpandStepTemplates(map[string]interface{}{
+		"step_templates": map[string]interface{}{
+			"train": map[string]interface{}{"command": "echo"},
+		},
+		"entry_points": map[string]interface{}{
+			"main": map[string]interface{}{"template": "train", "reference": map[string]interface{}{"component": "c"}},
+		},
+	})
+	assert.NotNil(t, err)
+}
+
+func TestParseRunLimits(t *testing.T) {
+	runYaml := `name: limits
+entry_points:
+  main:
+    command: "echo main"
+limits:
+  timeout: 48h
+  max_gpu_hours: 100
+`
+	wfs, err := GetWorkflowSource([]byte(runYaml))
+	assert.Nil(t, err)
+	assert.Equal(t, 48*time.Hour, wfs.Limits.GetTimeout())
+	assert.Equal(t, float64(100), wfs.Limits.MaxGPUHours)
+
+	p := Parser{}
+	err = p.ParseRunLimits(map[string]interface{}{"timeout": "2d"}, &RunLimits{})
+	assert.NotNil(t, err)
+	err = p.ParseRunLimits(map[string]interface{}{"max_gpu_hours": "100"}, &RunLimits{})
+	assert.NotNil(t, err)
+	err = p.ParseRunLimits(map[string]interface{}{"max_cpu_hours": int64(1)}, &RunLimits{})
+	assert.NotNil(t, err)
+}
+
+func TestParseHTTPAndApprovalStep(t *testing.T) {
+	runYaml := `name: promote
+entry_points:
+  notify:
+    http:
+      url: "http://example.com/deploy?model={{model}}"
+      method: post
+      headers:
+        Content-Type: application/json
+      body: '{"stage": "prod"}'
+      timeout: 10
+      expected_status: [200, 201]
+      success_pattern: '"ok":\s*true'
+    parameters:
+      model: resnet
+  gate:
+    deps: notify
+    approval:
+      message: "promote to prod?"
+      approvers: [alice, bob]
+      timeout: 3600
+`
+	wfs, err := GetWorkflowSource([]byte(runYaml))
+	assert.Nil(t, err)
+
+	notify := wfs.EntryPoints.EntryPoints["notify"].(*WorkflowSourceStep)
+	assert.Equal(t, StepTypeHTTP, notify.GetStepType())
+	assert.Equal(t, "POST", notify.HTTP.Method)
+	assert.Equal(t, 10*time.Second, notify.HTTP.GetTimeout())
+	assert.True(t, notify.HTTP.IsExpectedStatus(201))
+	assert.False(t, notify.HTTP.IsExpectedStatus(204))
+
+	gate := wfs.EntryPoints.EntryPoints["gate"].(*WorkflowSourceStep)
+	assert.Equal(t, StepTypeApproval, gate.GetStepType())
+	assert.Equal(t, []string{"alice", "bob"}, gate.Approval.Approvers)
+	assert.Equal(t, 3600, gate.Approval.Timeout)
+
+	newGate := gate.DeepCopy().(*WorkflowSourceStep)
+	newGate.Approval.Approvers[0] = "carol"
+	assert.Equal(t, "alice", gate.Approval.Approvers[0])
+
+	defaultHTTP := HTTPStep{}
+	assert.Equal(t, DefaultHTTPStepTimeout, defaultHTTP.GetTimeout())
+	assert.True(t, defaultHTTP.IsExpectedStatus(204))
+	assert.False(t, defaultHTTP.IsExpectedStatus(302))
+
+	p := Parser{}
+	err = p.ParseStep(map[string]interface{}{
+		"http":     map[string]interface{}{"url": "http://example.com"},
+		"approval": map[string]interface{}{},
+	}, &WorkflowSourceStep{})
+	assert.NotNil(t, err)
+	err = p.ParseHTTPStep(map[string]interface{}{"method": "GET"}, &HTTPStep{})
+	assert.NotNil(t, err)
+	err = p.ParseHTTPStep(map[string]interface{}{"url": "http://example.com", "method": "TRACE"}, &HTTPStep{})
+	assert.NotNil(t, err)
+	err = p.ParseHTTPStep(map[string]interface{}{"url": "http://example.com", "success_pattern": "("}, &HTTPStep{})
+	assert.NotNil(t, err)
+	err = p.ParseApprovalStep(map[string]interface{}{"timeout": int64(-1)}, &ApprovalStep{})
+	assert.NotNil(t, err)
+}
+
+func TestParseArtifactRetention(t *testing.T) {
+	runYaml := `name: retention
+entry_points:
+  main:
+    command: "echo main"
+artifact_retention:
+  keep_runs: 5
+  keep_days: 30
+`
+	wfs, err := GetWorkflowSource([]byte(runYaml))
+	assert.Nil(t, err)
+	assert.Equal(t, 5, wfs.ArtifactRetention.KeepRuns)
+	assert.Equal(t, 30, wfs.ArtifactRetention.KeepDays)
+	assert.True(t, wfs.ArtifactRetention.IsEnabled())
+
+	p := Parser{}
+	jsonMap := map[string]interface{}{"artifactRetention": map[string]interface{}{"keepRuns": float64(3)}}
+	assert.Nil(t, p.TransJsonMap2Yaml(jsonMap))
+	retention := ArtifactRetention{}
+	assert.Nil(t, p.ParseArtifactRetention(jsonMap["artifact_retention"].(map[string]interface{}), &retention))
+	assert.Equal(t, 3, retention.KeepRuns)
+
+	err = p.ParseArtifactRetention(map[string]interface{}{"keep_runs": int64(-1)}, &ArtifactRetention{})
+	assert.NotNil(t, err)
+	err = p.ParseArtifactRetention(map[string]interface{}{"keep_days": "7"}, &ArtifactRetention{})
+	assert.NotNil(t, err)
+	err = p.ParseArtifactRetention(map[string]interface{}{"keep_hours": int64(1)}, &ArtifactRetention{})
+	assert.NotNil(t, err)
+	assert.False(t, (*ArtifactRetention)(nil).IsEnabled())
+}
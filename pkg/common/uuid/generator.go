@@ -0,0 +1,190 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package uuid
+
+import (
+	"crypto/rand"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	GeneratorUUID      = "uuid"
+	GeneratorSnowflake = "snowflake"
+	GeneratorULID      = "ulid"
+
+	// encoding is lowercase crockford base32, whose characters are in ascending order, so that encoded ids keep the order
+	encoding = "0123456789abcdefghjkmnpqrstvwxyz"
+
+	snowflakeNodeBits = 10
+	snowflakeSeqBits  = 12
+	snowflakeMaxNode  = 1<<snowflakeNodeBits - 1
+	snowflakeMaxSeq   = 1<<snowflakeSeqBits - 1
+	// snowflakeLength is length of 63 bits snowflake id encoded in base32
+	snowflakeLength = 13
+	// ulidTimeLength is length of 48 bits millisecond timestamp encoded in base32
+	ulidTimeLength = 10
+)
+
+// snowflakeEpoch 2020-01-01 00:00:00 UTC
+var snowflakeEpoch = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Config ID生成配置，snowflake和ulid生成按时间有序的ID，作业等资源按ID排序即近似按创建时间排序，
+// ID长度不足以容纳时间戳时（如队列ID）仍使用随机ID
+type Config struct {
+	// Generator 可选uuid（默认）、snowflake、ulid
+	Generator string `yaml:"generator"`
+	// NodeID snowflake的节点ID，取值[0, 1023]，多个服务端实例需设置不同的值，为0时根据主机名生成
+	NodeID int64 `yaml:"nodeID"`
+}
+
+// Generator generates the part of id after prefix with length
+type Generator interface {
+	Generate(length int) string
+}
+
+var (
+	mu        sync.RWMutex
+	generator Generator = uuidGenerator{}
+)
+
+// Init sets the global id generator by config
+func Init(conf Config) error {
+	g, err := NewGenerator(conf)
+	if err != nil {
+		return err
+	}
+	SetGenerator(g)
+	return nil
+}
+
+// NewGenerator creates id generator by config
+func NewGenerator(conf Config) (Generator, error) {
+	switch conf.Generator {
+	case "", GeneratorUUID:
+		return uuidGenerator{}, nil
+	case GeneratorSnowflake:
+		nodeID := conf.NodeID
+		if nodeID == 0 {
+			nodeID = hostNodeID()
+		}
+		if nodeID < 0 || nodeID > snowflakeMaxNode {
+			return nil, fmt.Errorf("nodeID of snowflake must be in [0, %d], got %d", snowflakeMaxNode, nodeID)
+		}
+		return &snowflakeGenerator{nodeID: nodeID}, nil
+	case GeneratorULID:
+		return &ulidGenerator{}, nil
+	default:
+		return nil, fmt.Errorf("id generator %s is not supported, only support %s, %s and %s",
+			conf.Generator, GeneratorUUID, GeneratorSnowflake, GeneratorULID)
+	}
+}
+
+// SetGenerator replaces the global id generator
+func SetGenerator(g Generator) {
+	mu.Lock()
+	defer mu.Unlock()
+	generator = g
+}
+
+func getGenerator() Generator {
+	mu.RLock()
+	defer mu.RUnlock()
+	return generator
+}
+
+func hostNodeID() int64 {
+	hostname, _ := os.Hostname()
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(hostname))
+	return int64(h.Sum32() % (snowflakeMaxNode + 1))
+}
+
+// uuidGenerator generates random ids
+type uuidGenerator struct{}
+
+func (uuidGenerator) Generate(length int) string {
+	return randomID(length)
+}
+
+// snowflakeGenerator generates ids from millisecond timestamp, node id and sequence,
+// and ids shorter than snowflakeLength are random
+type snowflakeGenerator struct {
+	mutex    sync.Mutex
+	nodeID   int64
+	lastTime int64
+	sequence int64
+}
+
+func (g *snowflakeGenerator) Generate(length int) string {
+	if length < snowflakeLength {
+		return randomID(length)
+	}
+	return encode(uint64(g.next()), snowflakeLength) + randomID(length-snowflakeLength)
+}
+
+func (g *snowflakeGenerator) next() int64 {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	now := time.Since(snowflakeEpoch).Milliseconds()
+	// keep ids increasing when clock goes backwards
+	if now < g.lastTime {
+		now = g.lastTime
+	}
+	if now == g.lastTime {
+		g.sequence = (g.sequence + 1) & snowflakeMaxSeq
+		if g.sequence == 0 {
+			// sequence is exhausted in this millisecond, borrow the next one
+			now++
+		}
+	} else {
+		g.sequence = 0
+	}
+	g.lastTime = now
+	return now<<(snowflakeNodeBits+snowflakeSeqBits) | g.nodeID<<snowflakeSeqBits | g.sequence
+}
+
+// ulidGenerator generates ids from millisecond timestamp followed by random characters,
+// and ids shorter than ulidTimeLength are random
+type ulidGenerator struct{}
+
+func (g *ulidGenerator) Generate(length int) string {
+	if length < ulidTimeLength {
+		return randomID(length)
+	}
+	random := make([]byte, length-ulidTimeLength)
+	if _, err := rand.Read(random); err != nil {
+		return encode(uint64(time.Now().UnixMilli()), ulidTimeLength) + randomID(length-ulidTimeLength)
+	}
+	for i := range random {
+		random[i] = encoding[random[i]%32]
+	}
+	return encode(uint64(time.Now().UnixMilli()), ulidTimeLength) + string(random)
+}
+
+// encode encodes value in base32 with fixed length
+func encode(value uint64, length int) string {
+	buf := make([]byte, length)
+	for i := length - 1; i >= 0; i-- {
+		buf[i] = encoding[value&31]
+		value >>= 5
+	}
+	return string(buf)
+}
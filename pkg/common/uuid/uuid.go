@@ -35,6 +35,11 @@ func GenerateIDWithLength(Prefix string, Len int) string {
 	if Len > uuidMaxLength {
 		Len = uuidMaxLength
 	}
-	uuidStr := strings.ToLower(Prefix) + "-" + strings.ReplaceAll(uuid.NewString(), "-", "")[:Len]
+	uuidStr := strings.ToLower(Prefix) + "-" + getGenerator().Generate(Len)
 	return uuidStr
 }
+
+// randomID returns random hex string with length, which is no more than uuidMaxLength
+func randomID(length int) string {
+	return strings.ReplaceAll(uuid.NewString(), "-", "")[:length]
+}
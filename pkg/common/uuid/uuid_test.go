@@ -30,3 +30,31 @@ func TestGenerateID(t *testing.T) {
 	fmt.Printf("jobID:%s\n", jobID)
 	assert.NotNil(t, jobID)
 }
+
+func TestSortableGenerator(t *testing.T) {
+	defer SetGenerator(uuidGenerator{})
+	for _, name := range []string{GeneratorSnowflake, GeneratorULID} {
+		assert.NoError(t, Init(Config{Generator: name, NodeID: 1}))
+		last, lastTime := "", ""
+		for i := 0; i < 10000; i++ {
+			id := GenerateIDWithLength("job", JobIDLength)
+			assert.Equal(t, len("job-")+JobIDLength, len(id))
+			assert.Regexp(t, "^job-[0-9a-z]+$", id)
+			if name == GeneratorSnowflake {
+				// snowflake ids of the same node are strictly increasing
+				assert.Greater(t, id, last)
+			}
+			// ids are ordered by their timestamp part
+			idTime := id[:len("job-")+ulidTimeLength]
+			assert.GreaterOrEqual(t, idTime, lastTime)
+			last, lastTime = id, idTime
+		}
+		// ids too short for timestamp are random
+		assert.Equal(t, len("queue-")+8, len(GenerateID("queue")))
+	}
+
+	_, err := NewGenerator(Config{Generator: GeneratorSnowflake, NodeID: 1024})
+	assert.Error(t, err)
+	_, err = NewGenerator(Config{Generator: "unknown"})
+	assert.Error(t, err)
+}
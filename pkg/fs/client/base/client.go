@@ -75,6 +75,8 @@ func (c *_Client) GetFSMeta() (common.FSMeta, error) {
 		ServerAddress: fsResponseMeta.ServerAddress,
 		SubPath:       fsResponseMeta.SubPath,
 		Properties:    fsResponseMeta.Properties,
+		CapacityQuota: fsResponseMeta.CapacityQuota,
+		InodeQuota:    fsResponseMeta.InodeQuota,
 	}
 	return fsMeta, nil
 }
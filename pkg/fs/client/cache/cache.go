@@ -44,13 +44,41 @@ type DataCacheClient interface {
 	clean()
 }
 
+// NewDataCache 配置了MemSize时使用内存缓存热点块，配置了磁盘缓存时与磁盘组成两级缓存。
+// 配置了PeerAddr时，本地未命中的块先从其他节点拉取
 func NewDataCache(config Config) DataCacheClient {
-	if config.CachePath == "" || config.CachePath == "/" || config.Expire == 0 {
-		return nil
+	client := newLocalDataCache(config)
+	if client == nil || config.PeerAddr == "" || config.PeerRegistry == nil {
+		return client
 	}
-	config.CachePath = filepath.Join(config.CachePath, config.FsID)
-	// currently, supports file client only
-	return newFileClient(config)
+	return newPeerClient(client, config)
+}
+
+func newLocalDataCache(config Config) DataCacheClient {
+	var disk DataCacheClient
+	if config.CachePath != "" && config.CachePath != "/" && config.Expire != 0 {
+		diskConfig := config
+		diskConfig.CachePath = filepath.Join(config.CachePath, config.FsID)
+		disk = newFileClient(diskConfig)
+		if disk != nil && (config.Compression != "" || len(config.EncryptKey) > 0) {
+			codec, err := newBlockCodec(config.Compression, config.EncryptKey)
+			if err != nil {
+				// 不能按要求编码时不使用磁盘缓存，避免明文落盘
+				log.Errorf("new cache block codec failed, disk cache disabled: %v", err)
+				disk = nil
+			} else {
+				disk = &codecDataCache{disk: disk, codec: codec}
+			}
+		}
+	}
+	if config.MemSize <= 0 {
+		return disk
+	}
+	mem := newMemClient(config)
+	if disk == nil {
+		return mem
+	}
+	return &tieredDataCache{mem: mem, disk: disk}
 }
 
 type rCache struct {
@@ -182,6 +210,10 @@ func (r *rCache) ReadAt(buf []byte, off int64) (n int, err error) {
 	if err == nil {
 		n, err = r.readFromReadAhead(off, buf)
 		log.Debugf("readFromReadAhead n is %v err %v", n, err)
+		if r.store.client != nil && err == nil {
+			cacheMiss.Inc()
+			cacheMissBytes.Add(float64(n))
+		}
 		return
 	} else {
 		log.Errorf("read ahead err is %v", err)
@@ -204,6 +236,10 @@ func (r *rCache) key(index int) string {
 	if !ok {
 		r.store.Lock()
 		keyID = uuid.NewString()
+		if r.store.conf.PeerAddr != "" {
+			// 节点间共享缓存时，同一文件在各节点上的块key需一致
+			keyID = fmt.Sprintf("%x_%d", utils.KeyHash(r.id), r.length)
+		}
 		r.store.meta[r.id] = keyID
 		r.store.Unlock()
 	}
@@ -21,13 +21,16 @@ import (
 	"bytes"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	log "github.com/sirupsen/logrus"
 
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/utils"
 )
 
@@ -41,6 +44,9 @@ var _ DataCacheClient = &fileDataCache{}
 type cacheItem struct {
 	size    int64
 	expTime time.Time
+	// atime和hits供lru/lfu淘汰使用，读取时原子更新
+	atime int64
+	hits  int64
 }
 
 type fileDataCache struct {
@@ -50,12 +56,19 @@ type fileDataCache struct {
 	used     int64
 	expire   time.Duration
 	keys     sync.Map
+	// maxSize 缓存块总大小上限，0表示只受磁盘容量限制
+	maxSize     int64
+	cachedSize  int64
+	evictPolicy string
+	evictLock   sync.Mutex
 }
 
 func newFileClient(config Config) DataCacheClient {
 	d := &fileDataCache{
-		dir:    config.CachePath,
-		expire: config.Expire,
+		dir:         config.CachePath,
+		expire:      config.Expire,
+		maxSize:     config.MaxSize,
+		evictPolicy: config.EvictPolicy,
 	}
 
 	if err := os.MkdirAll(config.CachePath, 0755); err != nil {
@@ -102,6 +115,11 @@ func (c *fileDataCache) load(key string) (ReadCloser, bool) {
 	if err != nil {
 		return nil, false
 	}
+	if value, ok := c.keys.Load(key); ok {
+		item := value.(*cacheItem)
+		atomic.StoreInt64(&item.atime, time.Now().UnixNano())
+		atomic.AddInt64(&item.hits, 1)
+	}
 	return f, true
 }
 
@@ -110,13 +128,21 @@ func (c *fileDataCache) save(key string, buf []byte) {
 		return
 	}
 	cacheSize := int64(len(buf))
+	if c.maxSize > 0 && cacheSize > c.maxSize {
+		cacheDrops.Inc()
+		return
+	}
+	if c.overLimit(cacheSize) {
+		c.evict(cacheSize)
+	}
 	if c.used+cacheSize >= c.capacity {
 		// todo：clean支持带参数，释放多少容量。
 		c.clean()
 	}
 
 	// 清理之后还是没有足够的容量，则跳过
-	if c.used+cacheSize >= c.capacity {
+	if c.used+cacheSize >= c.capacity || c.overLimit(cacheSize) {
+		cacheDrops.Inc()
 		return
 	}
 	path := c.cachePath(key)
@@ -147,33 +173,100 @@ func (c *fileDataCache) save(key string, buf []byte) {
 		return
 	}
 
+	if old, ok := c.keys.Load(key); ok {
+		atomic.AddInt64(&c.cachedSize, -old.(*cacheItem).size)
+	}
+	now := time.Now()
 	c.keys.Store(key, &cacheItem{
-		expTime: time.Now().Add(c.expire),
+		expTime: now.Add(c.expire),
 		size:    cacheSize,
+		atime:   now.UnixNano(),
 	})
+	atomic.AddInt64(&c.cachedSize, cacheSize)
+	cacheWrites.Inc()
+	cacheWriteBytes.Add(float64(cacheSize))
 	return
 }
 
 func (c *fileDataCache) delete(key string) {
 	path := c.cachePath(key)
-	_, ok := c.load(key)
-	if ok {
-		c.keys.Delete(key)
+	if value, ok := c.keys.LoadAndDelete(key); ok {
+		atomic.AddInt64(&c.cachedSize, -value.(*cacheItem).size)
 	}
 	if path != "" {
 		go os.Remove(path)
 	}
 }
 
+// overLimit 写入size字节后缓存块总大小是否超过上限
+func (c *fileDataCache) overLimit(size int64) bool {
+	return c.maxSize > 0 && atomic.LoadInt64(&c.cachedSize)+size > c.maxSize
+}
+
+type evictCandidate struct {
+	key     string
+	atime   int64
+	hits    int64
+	expTime time.Time
+}
+
+// evict 按淘汰策略删除缓存块，直到能再写入need字节
+func (c *fileDataCache) evict(need int64) {
+	if c.maxSize <= 0 {
+		return
+	}
+	c.evictLock.Lock()
+	defer c.evictLock.Unlock()
+	if !c.overLimit(need) {
+		return
+	}
+	var candidates []evictCandidate
+	c.keys.Range(func(key, value interface{}) bool {
+		item := value.(*cacheItem)
+		candidates = append(candidates, evictCandidate{
+			key:     key.(string),
+			atime:   atomic.LoadInt64(&item.atime),
+			hits:    atomic.LoadInt64(&item.hits),
+			expTime: item.expTime,
+		})
+		return true
+	})
+	sort.Slice(candidates, func(i, j int) bool {
+		return c.evictBefore(candidates[i], candidates[j])
+	})
+	for _, candidate := range candidates {
+		if !c.overLimit(need) {
+			return
+		}
+		c.delete(candidate.key)
+		cacheEvicts.Inc()
+	}
+}
+
+func (c *fileDataCache) evictBefore(a, b evictCandidate) bool {
+	switch c.evictPolicy {
+	case schema.FsCacheEvictLFU:
+		if a.hits != b.hits {
+			return a.hits < b.hits
+		}
+	case schema.FsCacheEvictTTL:
+		return a.expTime.Before(b.expTime)
+	}
+	return a.atime < b.atime
+}
+
 func (c *fileDataCache) clean() {
 	// 1. 首先清理掉已过期文件
 	c.keys.Range(func(key, value interface{}) bool {
 		cache := value.(*cacheItem)
 		if time.Since(cache.expTime) >= 0 {
 			c.delete(key.(string))
+			cacheEvicts.Inc()
 		}
 		return true
 	})
+	// 过期清理后仍超过容量上限时按策略淘汰
+	c.evict(0)
 
 	cacheDir := filepath.Join(c.dir, CacheDir)
 	if c.dir == "/" || c.dir == "" {
@@ -0,0 +1,89 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+)
+
+func newTestFileCache(t *testing.T, policy string) *fileDataCache {
+	return &fileDataCache{
+		dir:         t.TempDir(),
+		capacity:    1 << 40,
+		expire:      time.Hour,
+		maxSize:     3,
+		evictPolicy: policy,
+	}
+}
+
+func loadAndClose(c *fileDataCache, key string) bool {
+	f, ok := c.load(key)
+	if ok {
+		f.Close()
+	}
+	return ok
+}
+
+func TestFileDataCacheEvict(t *testing.T) {
+	// lru: 淘汰最久未读取的块
+	c := newTestFileCache(t, schema.FsCacheEvictLRU)
+	c.save("a", []byte("a"))
+	c.save("b", []byte("b"))
+	c.save("c", []byte("c"))
+	time.Sleep(time.Millisecond)
+	assert.True(t, loadAndClose(c, "a"))
+	c.save("d", []byte("d"))
+	assert.Equal(t, int64(3), c.cachedSize)
+	assert.True(t, loadAndClose(c, "a"))
+	assert.False(t, loadAndClose(c, "b"))
+	assert.True(t, loadAndClose(c, "d"))
+
+	// lfu: 淘汰读取次数最少的块
+	c = newTestFileCache(t, schema.FsCacheEvictLFU)
+	c.save("a", []byte("a"))
+	c.save("b", []byte("b"))
+	c.save("c", []byte("c"))
+	loadAndClose(c, "a")
+	loadAndClose(c, "c")
+	c.save("d", []byte("d"))
+	assert.False(t, loadAndClose(c, "b"))
+	assert.True(t, loadAndClose(c, "a"))
+
+	// ttl: 淘汰最早过期的块
+	c = newTestFileCache(t, schema.FsCacheEvictTTL)
+	c.save("a", []byte("a"))
+	c.expire = 2 * time.Hour
+	c.save("b", []byte("b"))
+	c.expire = 30 * time.Minute
+	c.save("c", []byte("c"))
+	c.save("d", []byte("d"))
+	assert.False(t, loadAndClose(c, "c"))
+	assert.True(t, loadAndClose(c, "a"))
+
+	// 超过上限的块直接丢弃
+	c.save("big", []byte("toolarge"))
+	assert.False(t, loadAndClose(c, "big"))
+	assert.Equal(t, int64(3), c.cachedSize)
+
+	c.delete("a")
+	assert.Equal(t, int64(2), c.cachedSize)
+}
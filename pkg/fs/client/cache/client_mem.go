@@ -0,0 +1,172 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"bytes"
+	"container/list"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var _ DataCacheClient = &memDataCache{}
+
+type memItem struct {
+	key     string
+	data    []byte
+	expTime time.Time
+}
+
+// memDataCache 进程内存中的块缓存，按LRU淘汰，用于缓存热点块
+type memDataCache struct {
+	sync.Mutex
+	capacity int64
+	used     int64
+	expire   time.Duration
+	lru      *list.List
+	items    map[string]*list.Element
+}
+
+func newMemClient(config Config) *memDataCache {
+	return &memDataCache{
+		capacity: config.MemSize,
+		expire:   config.Expire,
+		lru:      list.New(),
+		items:    make(map[string]*list.Element),
+	}
+}
+
+type memReadCloser struct {
+	*bytes.Reader
+}
+
+func (r memReadCloser) Close() error {
+	return nil
+}
+
+func (c *memDataCache) load(key string) (ReadCloser, bool) {
+	c.Lock()
+	defer c.Unlock()
+	elem, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	item := elem.Value.(*memItem)
+	if c.expire > 0 && time.Until(item.expTime) <= 0 {
+		c.removeElement(elem)
+		return nil, false
+	}
+	c.lru.MoveToFront(elem)
+	return memReadCloser{bytes.NewReader(item.data)}, true
+}
+
+func (c *memDataCache) save(key string, buf []byte) {
+	size := int64(len(buf))
+	if size > c.capacity {
+		return
+	}
+	// 调用方会复用buf，需要拷贝
+	data := make([]byte, size)
+	copy(data, buf)
+
+	c.Lock()
+	defer c.Unlock()
+	if elem, ok := c.items[key]; ok {
+		c.removeElement(elem)
+	}
+	for c.used+size > c.capacity {
+		c.removeElement(c.lru.Back())
+		cacheMemEvicts.Inc()
+	}
+	c.items[key] = c.lru.PushFront(&memItem{key: key, data: data, expTime: time.Now().Add(c.expire)})
+	c.used += size
+}
+
+func (c *memDataCache) delete(key string) {
+	c.Lock()
+	defer c.Unlock()
+	if elem, ok := c.items[key]; ok {
+		c.removeElement(elem)
+	}
+}
+
+func (c *memDataCache) clean() {
+	if c.expire <= 0 {
+		return
+	}
+	c.Lock()
+	defer c.Unlock()
+	for elem := c.lru.Back(); elem != nil; {
+		prev := elem.Prev()
+		if time.Until(elem.Value.(*memItem).expTime) <= 0 {
+			c.removeElement(elem)
+		}
+		elem = prev
+	}
+}
+
+func (c *memDataCache) removeElement(elem *list.Element) {
+	item := c.lru.Remove(elem).(*memItem)
+	delete(c.items, item.key)
+	c.used -= int64(len(item.data))
+}
+
+// tieredDataCache 内存+本地磁盘两级缓存。读取时先查内存，磁盘命中的块提升到内存；
+// 写入时同时写两级，内存淘汰的块仍可从磁盘读取
+type tieredDataCache struct {
+	mem  *memDataCache
+	disk DataCacheClient
+}
+
+var _ DataCacheClient = &tieredDataCache{}
+
+func (c *tieredDataCache) load(key string) (ReadCloser, bool) {
+	if reader, ok := c.mem.load(key); ok {
+		cacheMemHits.Inc()
+		return reader, true
+	}
+	reader, ok := c.disk.load(key)
+	if !ok {
+		return nil, false
+	}
+	data, err := ioutil.ReadAll(reader)
+	reader.Close()
+	if err != nil {
+		log.Debugf("tiered cache read disk block[%s] err: %v", key, err)
+		return nil, false
+	}
+	c.mem.save(key, data)
+	return memReadCloser{bytes.NewReader(data)}, true
+}
+
+func (c *tieredDataCache) save(key string, buf []byte) {
+	c.mem.save(key, buf)
+	c.disk.save(key, buf)
+}
+
+func (c *tieredDataCache) delete(key string) {
+	c.mem.delete(key)
+	c.disk.delete(key)
+}
+
+func (c *tieredDataCache) clean() {
+	c.mem.clean()
+	c.disk.clean()
+}
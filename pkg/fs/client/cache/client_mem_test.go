@@ -0,0 +1,98 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+)
+
+func readAll(t *testing.T, c DataCacheClient, key string) (string, bool) {
+	r, ok := c.load(key)
+	if !ok {
+		return "", false
+	}
+	defer r.Close()
+	data, err := ioutil.ReadAll(r)
+	assert.NoError(t, err)
+	return string(data), true
+}
+
+func TestMemDataCache(t *testing.T) {
+	c := newMemClient(Config{MemSize: 4})
+	c.save("a", []byte("aa"))
+	c.save("b", []byte("bb"))
+	// 读取a后b成为最久未使用的块
+	_, ok := readAll(t, c, "a")
+	assert.True(t, ok)
+	c.save("c", []byte("cc"))
+	assert.Equal(t, int64(4), c.used)
+	_, ok = readAll(t, c, "b")
+	assert.False(t, ok)
+	data, ok := readAll(t, c, "a")
+	assert.True(t, ok)
+	assert.Equal(t, "aa", data)
+
+	// 超过容量的块不缓存
+	c.save("d", []byte("ddddd"))
+	_, ok = readAll(t, c, "d")
+	assert.False(t, ok)
+
+	c.delete("a")
+	assert.Equal(t, int64(2), c.used)
+
+	// 过期
+	c = newMemClient(Config{MemSize: 4, Expire: time.Millisecond})
+	c.save("a", []byte("aa"))
+	time.Sleep(2 * time.Millisecond)
+	c.clean()
+	assert.Equal(t, int64(0), c.used)
+}
+
+func TestTieredDataCache(t *testing.T) {
+	disk := newTestFileCache(t, schema.FsCacheEvictLRU)
+	disk.maxSize = 0
+	c := &tieredDataCache{mem: newMemClient(Config{MemSize: 2}), disk: disk}
+	c.save("a", []byte("aa"))
+	c.save("b", []byte("bb"))
+	// a已从内存淘汰，从磁盘读取并提升到内存
+	_, ok := c.mem.load("a")
+	assert.False(t, ok)
+	data, ok := readAll(t, c, "a")
+	assert.True(t, ok)
+	assert.Equal(t, "aa", data)
+	_, ok = c.mem.load("a")
+	assert.True(t, ok)
+
+	c.delete("a")
+	_, ok = readAll(t, c, "a")
+	assert.False(t, ok)
+	data, ok = readAll(t, c, "b")
+	assert.True(t, ok)
+	assert.Equal(t, "bb", data)
+}
+
+func TestNewDataCacheTiered(t *testing.T) {
+	assert.Nil(t, NewDataCache(Config{}))
+	_, ok := NewDataCache(Config{MemSize: 1024}).(*memDataCache)
+	assert.True(t, ok)
+}
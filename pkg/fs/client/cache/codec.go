@@ -0,0 +1,192 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/klauspost/compress/s2"
+	"github.com/klauspost/compress/zstd"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+)
+
+// 缓存块头部，用于识别编码方式，开启编码前写入的块读取时当作未命中
+var blockMagic = []byte("PFSC")
+
+const (
+	blockAlgNone byte = iota
+	blockAlgZstd
+	blockAlgS2
+)
+
+var (
+	zstdEncoder, _ = zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	zstdDecoder, _ = zstd.NewReader(nil)
+)
+
+// blockCodec 磁盘缓存块的压缩与加密，先压缩再加密
+type blockCodec struct {
+	alg  byte
+	aead cipher.AEAD
+}
+
+func newBlockCodec(compression string, key []byte) (*blockCodec, error) {
+	c := &blockCodec{}
+	switch compression {
+	case "":
+		c.alg = blockAlgNone
+	case schema.FsCacheCompressionZstd:
+		c.alg = blockAlgZstd
+	case schema.FsCacheCompressionS2:
+		c.alg = blockAlgS2
+	default:
+		return nil, fmt.Errorf("cache compression[%s] not supported", compression)
+	}
+	if len(key) > 0 {
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cache encrypt key: %v", err)
+		}
+		if c.aead, err = cipher.NewGCM(block); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func (c *blockCodec) encode(buf []byte) ([]byte, error) {
+	data := make([]byte, 0, len(buf)+len(blockMagic)+1)
+	data = append(data, blockMagic...)
+	data = append(data, c.alg)
+	switch c.alg {
+	case blockAlgZstd:
+		data = zstdEncoder.EncodeAll(buf, data)
+	case blockAlgS2:
+		data = append(data, s2.Encode(nil, buf)...)
+	default:
+		data = append(data, buf...)
+	}
+	if c.aead == nil {
+		return data, nil
+	}
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return c.aead.Seal(nonce, nonce, data, nil), nil
+}
+
+func (c *blockCodec) decode(data []byte) ([]byte, error) {
+	if c.aead != nil {
+		nonceSize := c.aead.NonceSize()
+		if len(data) < nonceSize {
+			return nil, fmt.Errorf("encrypted block too short")
+		}
+		plain, err := c.aead.Open(nil, data[:nonceSize], data[nonceSize:], nil)
+		if err != nil {
+			return nil, err
+		}
+		data = plain
+	}
+	header := len(blockMagic) + 1
+	if len(data) < header || !bytes.Equal(data[:len(blockMagic)], blockMagic) {
+		return nil, fmt.Errorf("unknown block format")
+	}
+	payload := data[header:]
+	switch data[header-1] {
+	case blockAlgNone:
+		return payload, nil
+	case blockAlgZstd:
+		return zstdDecoder.DecodeAll(payload, nil)
+	case blockAlgS2:
+		return s2.Decode(nil, payload)
+	default:
+		return nil, fmt.Errorf("unknown block compression[%d]", data[header-1])
+	}
+}
+
+// ParseEncryptKey 解析密钥文件内容，支持base64编码或原始的16/24/32字节密钥
+func ParseEncryptKey(content []byte) ([]byte, error) {
+	trimmed := strings.TrimSpace(string(content))
+	if key, err := base64.StdEncoding.DecodeString(trimmed); err == nil && validKeyLen(len(key)) {
+		return key, nil
+	}
+	if validKeyLen(len(content)) {
+		return content, nil
+	}
+	return nil, fmt.Errorf("cache encrypt key should be 16, 24 or 32 bytes, or base64 of them")
+}
+
+func validKeyLen(n int) bool {
+	return n == 16 || n == 24 || n == 32
+}
+
+// codecDataCache 写入磁盘前对缓存块压缩、加密，读取时还原，内存中的块保持明文
+type codecDataCache struct {
+	disk  DataCacheClient
+	codec *blockCodec
+}
+
+var _ DataCacheClient = &codecDataCache{}
+
+func (c *codecDataCache) load(key string) (ReadCloser, bool) {
+	reader, ok := c.disk.load(key)
+	if !ok {
+		return nil, false
+	}
+	data, err := ioutil.ReadAll(reader)
+	reader.Close()
+	if err != nil {
+		log.Debugf("codec cache read block[%s] err: %v", key, err)
+		return nil, false
+	}
+	plain, err := c.codec.decode(data)
+	if err != nil {
+		// 密钥或压缩方式变更前写入的块无法还原，删除后从后端重新读取
+		log.Warnf("codec cache decode block[%s] err: %v", key, err)
+		c.disk.delete(key)
+		return nil, false
+	}
+	return memReadCloser{bytes.NewReader(plain)}, true
+}
+
+func (c *codecDataCache) save(key string, buf []byte) {
+	data, err := c.codec.encode(buf)
+	if err != nil {
+		log.Errorf("codec cache encode block[%s] err: %v", key, err)
+		return
+	}
+	c.disk.save(key, data)
+}
+
+func (c *codecDataCache) delete(key string) {
+	c.disk.delete(key)
+}
+
+func (c *codecDataCache) clean() {
+	c.disk.clean()
+}
@@ -0,0 +1,102 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+)
+
+func TestBlockCodec(t *testing.T) {
+	key := bytes.Repeat([]byte("k"), 32)
+	buf := bytes.Repeat([]byte("paddleflow"), 1000)
+	for _, compression := range []string{"", schema.FsCacheCompressionZstd, schema.FsCacheCompressionS2} {
+		for _, k := range [][]byte{nil, key} {
+			codec, err := newBlockCodec(compression, k)
+			assert.NoError(t, err)
+			data, err := codec.encode(buf)
+			assert.NoError(t, err)
+			if compression != "" {
+				assert.Less(t, len(data), len(buf))
+			}
+			if k != nil {
+				assert.False(t, bytes.Contains(data, []byte("paddleflow")))
+			}
+			plain, err := codec.decode(data)
+			assert.NoError(t, err)
+			assert.Equal(t, buf, plain)
+		}
+	}
+
+	// 明文块或密钥不一致时无法还原
+	codec, err := newBlockCodec(schema.FsCacheCompressionZstd, key)
+	assert.NoError(t, err)
+	_, err = codec.decode(buf)
+	assert.Error(t, err)
+	other, err := newBlockCodec(schema.FsCacheCompressionZstd, bytes.Repeat([]byte("o"), 32))
+	assert.NoError(t, err)
+	data, err := other.encode(buf)
+	assert.NoError(t, err)
+	_, err = codec.decode(data)
+	assert.Error(t, err)
+
+	_, err = newBlockCodec("lzma", nil)
+	assert.Error(t, err)
+	_, err = newBlockCodec("", []byte("short"))
+	assert.Error(t, err)
+}
+
+func TestParseEncryptKey(t *testing.T) {
+	key := bytes.Repeat([]byte("k"), 16)
+	parsed, err := ParseEncryptKey([]byte(base64.StdEncoding.EncodeToString(key) + "\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, key, parsed)
+	parsed, err = ParseEncryptKey(key)
+	assert.NoError(t, err)
+	assert.Equal(t, key, parsed)
+	_, err = ParseEncryptKey([]byte("short"))
+	assert.Error(t, err)
+}
+
+func TestCodecDataCache(t *testing.T) {
+	disk := newTestFileCache(t, schema.FsCacheEvictLRU)
+	disk.maxSize = 0
+	codec, err := newBlockCodec(schema.FsCacheCompressionS2, bytes.Repeat([]byte("k"), 32))
+	assert.NoError(t, err)
+	c := &codecDataCache{disk: disk, codec: codec}
+	c.save("a", []byte("aa"))
+	data, ok := readAll(t, c, "a")
+	assert.True(t, ok)
+	assert.Equal(t, "aa", data)
+
+	// 磁盘上不是明文
+	raw, err := ioutil.ReadFile(disk.cachePath("a"))
+	assert.NoError(t, err)
+	assert.NotEqual(t, "aa", string(raw))
+
+	// 开启编码前写入的块当作未命中并删除
+	disk.save("b", []byte("bb"))
+	_, ok = readAll(t, c, "b")
+	assert.False(t, ok)
+	assert.False(t, disk.exist("b"))
+}
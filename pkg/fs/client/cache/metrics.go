@@ -17,11 +17,26 @@ limitations under the License.
 package cache
 
 import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
 	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
 
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/monitor"
 )
 
+const (
+	// StatsFileName 缓存命中统计文件，位于缓存根目录下，挂载pod中的cache-worker读取后上报
+	StatsFileName      = "cache-stats.json"
+	statsDumpInterval  = 10 * time.Second
+	statsFileTmpSuffix = ".tmp"
+)
+
 func registerMetrics() {
 	_ = prometheus.Register(cacheHits)
 	_ = prometheus.Register(cacheHitBytes)
@@ -32,8 +47,13 @@ func registerMetrics() {
 	_ = prometheus.Register(cacheWriteBytes)
 	_ = prometheus.Register(cacheDrops)
 	_ = prometheus.Register(cacheEvicts)
+	_ = prometheus.Register(cacheMemHits)
+	_ = prometheus.Register(cacheMemEvicts)
+	_ = prometheus.Register(cachePeerHits)
+	_ = prometheus.Register(cachePeerHitBytes)
 	_ = prometheus.Register(cacheReadHist)
 	_ = prometheus.Register(cacheWriteHist)
+	_ = prometheus.Register(cachePrefetches)
 }
 
 var (
@@ -51,6 +71,9 @@ var (
 	}, func() float64 {
 		hitCnt := monitor.GetMetricValue(cacheHits)
 		missCnt := monitor.GetMetricValue(cacheMiss)
+		if hitCnt+missCnt == 0 {
+			return 0
+		}
 		return hitCnt / (hitCnt + missCnt)
 	})
 	cacheWrites = prometheus.NewCounter(prometheus.CounterOpts{
@@ -65,6 +88,22 @@ var (
 		Name: "blockcache_evicts",
 		Help: "evicted cache blocks",
 	})
+	cacheMemHits = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "blockcache_mem_hits",
+		Help: "read from cached block in memory tier",
+	})
+	cacheMemEvicts = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "blockcache_mem_evicts",
+		Help: "evicted cache blocks in memory tier",
+	})
+	cachePeerHits = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "blockcache_peer_hits",
+		Help: "read cache blocks fetched from other nodes",
+	})
+	cachePeerHitBytes = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "blockcache_peer_hit_bytes",
+		Help: "read bytes of cache blocks fetched from other nodes",
+	})
 	cacheHitBytes = prometheus.NewCounter(prometheus.CounterOpts{
 		Name: "blockcache_hit_bytes",
 		Help: "read bytes from cached block",
@@ -77,6 +116,10 @@ var (
 		Name: "blockcache_write_bytes",
 		Help: "write bytes of cached block",
 	})
+	cachePrefetches = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "blockcache_prefetches",
+		Help: "blocks prefetched into cache by sequential read",
+	})
 	cacheReadHist = prometheus.NewHistogram(prometheus.HistogramOpts{
 		Name:    "blockcache_read_hist_seconds",
 		Help:    "read cached block latency distribution",
@@ -88,3 +131,52 @@ var (
 		Buckets: prometheus.ExponentialBuckets(0.00001, 2, 20),
 	})
 )
+
+// Stats 当前进程的缓存命中统计
+func Stats() schema.CacheStats {
+	return schema.CacheStats{
+		Hits:      int64(monitor.GetMetricValue(cacheHits)),
+		Misses:    int64(monitor.GetMetricValue(cacheMiss)),
+		HitBytes:  int64(monitor.GetMetricValue(cacheHitBytes)),
+		MissBytes: int64(monitor.GetMetricValue(cacheMissBytes)),
+		Evictions: int64(monitor.GetMetricValue(cacheEvicts)),
+	}
+}
+
+// DumpStats 将缓存命中统计写入cacheDir下的统计文件，先写临时文件再rename，避免读到不完整的内容
+func DumpStats(cacheDir string) error {
+	data, err := json.Marshal(Stats())
+	if err != nil {
+		return err
+	}
+	statsFile := filepath.Join(cacheDir, StatsFileName)
+	tmp := statsFile + statsFileTmpSuffix
+	if err = ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp, statsFile); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+func DumpStatsLoop(cacheDir string) {
+	for {
+		if err := DumpStats(cacheDir); err != nil {
+			log.Errorf("dump cache stats to dir[%s] failed: %v", cacheDir, err)
+		}
+		time.Sleep(statsDumpInterval)
+	}
+}
+
+// ReadStats 读取cacheDir下的缓存命中统计文件，文件不存在时返回os.ErrNotExist
+func ReadStats(cacheDir string) (schema.CacheStats, error) {
+	stats := schema.CacheStats{}
+	data, err := ioutil.ReadFile(filepath.Join(cacheDir, StatsFileName))
+	if err != nil {
+		return stats, err
+	}
+	err = json.Unmarshal(data, &stats)
+	return stats, err
+}
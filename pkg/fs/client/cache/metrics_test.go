@@ -0,0 +1,46 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDumpStats(t *testing.T) {
+	cacheDir := t.TempDir()
+	_, err := ReadStats(cacheDir)
+	assert.True(t, os.IsNotExist(err))
+
+	before := Stats()
+	cacheHits.Inc()
+	cacheHitBytes.Add(1024)
+	cacheMiss.Inc()
+	cacheMissBytes.Add(4096)
+	cacheEvicts.Inc()
+	assert.Nil(t, DumpStats(cacheDir))
+
+	stats, err := ReadStats(cacheDir)
+	assert.Nil(t, err)
+	assert.Equal(t, before.Hits+1, stats.Hits)
+	assert.Equal(t, before.Misses+1, stats.Misses)
+	assert.Equal(t, before.HitBytes+1024, stats.HitBytes)
+	assert.Equal(t, before.MissBytes+4096, stats.MissBytes)
+	assert.Equal(t, before.Evictions+1, stats.Evictions)
+}
@@ -0,0 +1,195 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/client/kv"
+)
+
+const (
+	peerRegistryPrefix = "peers/"
+	peerBlockPath      = "/blocks"
+	peerFetchTimeout   = 3 * time.Second
+)
+
+// blockRegistry 记录缓存块在哪些节点上，存放在缓存元数据kv中。
+// 只有使用多个节点共享的kv driver时，其他节点才能发现本节点缓存的块
+type blockRegistry struct {
+	client kv.KvClient
+}
+
+func (r *blockRegistry) prefix(key string) string {
+	return peerRegistryPrefix + key + "/"
+}
+
+func (r *blockRegistry) lookup(key string) []string {
+	var addrs []string
+	err := r.client.Txn(func(txn kv.KvTxn) error {
+		values, err := txn.ScanValues([]byte(r.prefix(key)))
+		if err != nil {
+			return err
+		}
+		for k := range values {
+			addrs = append(addrs, strings.TrimPrefix(k, r.prefix(key)))
+		}
+		return nil
+	})
+	if err != nil {
+		log.Debugf("peer registry lookup block[%s] err: %v", key, err)
+	}
+	return addrs
+}
+
+func (r *blockRegistry) register(key, addr string) {
+	err := r.client.Txn(func(txn kv.KvTxn) error {
+		return txn.Set([]byte(r.prefix(key)+addr), []byte{})
+	})
+	if err != nil {
+		log.Debugf("peer registry register block[%s] err: %v", key, err)
+	}
+}
+
+func (r *blockRegistry) unregister(key, addr string) {
+	err := r.client.Txn(func(txn kv.KvTxn) error {
+		return txn.Dels([]byte(r.prefix(key) + addr))
+	})
+	if err != nil {
+		log.Debugf("peer registry unregister block[%s] err: %v", key, err)
+	}
+}
+
+// peerDataCache 本地缓存未命中时，先从缓存了该块的其他节点拉取，再回退到ufs
+type peerDataCache struct {
+	local      DataCacheClient
+	addr       string
+	registry   *blockRegistry
+	httpClient *http.Client
+}
+
+var _ DataCacheClient = &peerDataCache{}
+
+func newPeerClient(local DataCacheClient, config Config) *peerDataCache {
+	c := &peerDataCache{
+		local:      local,
+		addr:       advertiseAddr(config.PeerAddr),
+		registry:   &blockRegistry{client: config.PeerRegistry},
+		httpClient: &http.Client{Timeout: peerFetchTimeout},
+	}
+	go c.serve(config.PeerAddr)
+	return c
+}
+
+// advertiseAddr 未指定host时使用本机第一个非回环的ipv4地址，挂载pod使用hostNetwork，即节点ip
+func advertiseAddr(addr string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || host != "" {
+		return addr
+	}
+	ifAddrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return addr
+	}
+	for _, ifAddr := range ifAddrs {
+		if ipNet, ok := ifAddr.(*net.IPNet); ok && !ipNet.IP.IsLoopback() && ipNet.IP.To4() != nil {
+			return net.JoinHostPort(ipNet.IP.String(), port)
+		}
+	}
+	return addr
+}
+
+func (c *peerDataCache) serve(listen string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc(peerBlockPath, c.handleBlock)
+	log.Infof("serve cached blocks for peers on %s, advertise %s", listen, c.addr)
+	if err := http.ListenAndServe(listen, mux); err != nil {
+		log.Errorf("serve cached blocks for peers on %s failed: %v", listen, err)
+	}
+}
+
+func (c *peerDataCache) handleBlock(w http.ResponseWriter, req *http.Request) {
+	key := req.URL.Query().Get("key")
+	reader, ok := c.local.load(key)
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	defer reader.Close()
+	if _, err := io.Copy(w, reader); err != nil {
+		log.Debugf("send block[%s] to peer[%s] err: %v", key, req.RemoteAddr, err)
+	}
+}
+
+func (c *peerDataCache) fetch(addr, key string) ([]byte, error) {
+	resp, err := c.httpClient.Get(fmt.Sprintf("http://%s%s?key=%s", addr, peerBlockPath, url.QueryEscape(key)))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("peer response status %d", resp.StatusCode)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+func (c *peerDataCache) load(key string) (ReadCloser, bool) {
+	if reader, ok := c.local.load(key); ok {
+		return reader, true
+	}
+	for _, addr := range c.registry.lookup(key) {
+		if addr == c.addr {
+			continue
+		}
+		data, err := c.fetch(addr, key)
+		if err != nil {
+			// 对端块已淘汰或节点不可用，清理登记
+			log.Debugf("fetch block[%s] from peer[%s] err: %v", key, addr, err)
+			c.registry.unregister(key, addr)
+			continue
+		}
+		cachePeerHits.Inc()
+		cachePeerHitBytes.Add(float64(len(data)))
+		c.save(key, data)
+		return memReadCloser{bytes.NewReader(data)}, true
+	}
+	return nil, false
+}
+
+func (c *peerDataCache) save(key string, buf []byte) {
+	c.local.save(key, buf)
+	c.registry.register(key, c.addr)
+}
+
+func (c *peerDataCache) delete(key string) {
+	c.local.delete(key)
+	c.registry.unregister(key, c.addr)
+}
+
+func (c *peerDataCache) clean() {
+	c.local.clean()
+}
@@ -0,0 +1,73 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/client/kv"
+)
+
+func newTestPeer(t *testing.T, registry *blockRegistry) *peerDataCache {
+	c := &peerDataCache{
+		local:      newMemClient(Config{MemSize: 1024}),
+		registry:   registry,
+		httpClient: http.DefaultClient,
+	}
+	server := httptest.NewServer(http.HandlerFunc(c.handleBlock))
+	t.Cleanup(server.Close)
+	c.addr = strings.TrimPrefix(server.URL, "http://")
+	return c
+}
+
+func TestPeerDataCache(t *testing.T) {
+	client, err := kv.NewBadgerClient(kv.Config{Driver: kv.MemType})
+	assert.NoError(t, err)
+	registry := &blockRegistry{client: client}
+	peerA := newTestPeer(t, registry)
+	peerB := newTestPeer(t, registry)
+
+	peerA.save("blocks/1/a_0", []byte("aaa"))
+	assert.Equal(t, []string{peerA.addr}, registry.lookup("blocks/1/a_0"))
+
+	// b本地未命中，从a拉取后缓存到本地并登记
+	data, ok := readAll(t, peerB, "blocks/1/a_0")
+	assert.True(t, ok)
+	assert.Equal(t, "aaa", data)
+	_, ok = peerB.local.load("blocks/1/a_0")
+	assert.True(t, ok)
+	assert.Equal(t, 2, len(registry.lookup("blocks/1/a_0")))
+
+	// a上的块已失效，拉取失败后清理登记
+	registry.register("blocks/1/b_0", peerA.addr)
+	_, ok = readAll(t, peerB, "blocks/1/b_0")
+	assert.False(t, ok)
+	assert.Equal(t, 0, len(registry.lookup("blocks/1/b_0")))
+
+	peerA.delete("blocks/1/a_0")
+	assert.Equal(t, []string{peerB.addr}, registry.lookup("blocks/1/a_0"))
+}
+
+func TestAdvertiseAddr(t *testing.T) {
+	assert.Equal(t, "10.0.0.1:28790", advertiseAddr("10.0.0.1:28790"))
+	assert.True(t, strings.HasSuffix(advertiseAddr(":28790"), ":28790"))
+}
@@ -0,0 +1,152 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"io"
+	"path"
+	"sync"
+	"syscall"
+
+	"github.com/panjf2000/ants/v2"
+	log "github.com/sirupsen/logrus"
+
+	ufs "github.com/PaddlePaddle/PaddleFlow/pkg/fs/client/ufs"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/client/utils"
+)
+
+const defaultPrefetchWorkers = 4
+
+// ReadPattern 记录单个打开文件的读取位置，用于识别顺序读。
+// 顺序读时预读窗口逐次加倍直到PrefetchBlocks，出现随机读时清零
+type ReadPattern struct {
+	nextOff int64
+	window  int
+	// fetched 已提交预取的块号上界(不含)，避免重复提交
+	fetched int
+}
+
+func (p *ReadPattern) observe(off int64, size int, maxBlocks int) int {
+	if off == p.nextOff {
+		p.window = utils.Min(utils.Max(p.window*2, 1), maxBlocks)
+	} else {
+		p.window, p.fetched = 0, 0
+	}
+	p.nextOff = off + int64(size)
+	return p.window
+}
+
+// prefetcher 由固定数量的协程将块异步读入数据缓存，协程全忙时丢弃本次预取，下次顺序读时重新提交
+type prefetcher struct {
+	store   *store
+	pool    *ants.Pool
+	mu      sync.Mutex
+	pending map[string]struct{}
+}
+
+func newPrefetcher(s *store) *prefetcher {
+	workers := s.conf.PrefetchWorkers
+	if workers <= 0 {
+		workers = defaultPrefetchWorkers
+	}
+	pool, err := ants.NewPool(workers, ants.WithNonblocking(true))
+	if err != nil {
+		log.Errorf("new prefetch pool failed, prefetch disabled: %v", err)
+		return nil
+	}
+	return &prefetcher{store: s, pool: pool, pending: make(map[string]struct{})}
+}
+
+// submit 返回false表示协程池已满
+func (p *prefetcher) submit(r *rCache, index int) bool {
+	key := r.key(index)
+	p.mu.Lock()
+	if _, ok := p.pending[key]; ok {
+		p.mu.Unlock()
+		return true
+	}
+	p.pending[key] = struct{}{}
+	p.mu.Unlock()
+
+	err := p.pool.Submit(func() {
+		defer func() {
+			p.mu.Lock()
+			delete(p.pending, key)
+			p.mu.Unlock()
+		}()
+		p.fetch(r, index, key)
+	})
+	if err != nil {
+		p.mu.Lock()
+		delete(p.pending, key)
+		p.mu.Unlock()
+		return false
+	}
+	return true
+}
+
+func (p *prefetcher) fetch(r *rCache, index int, key string) {
+	if reader, ok := p.store.client.load(key); ok {
+		reader.Close()
+		return
+	}
+	blockSize := p.store.conf.BlockSize
+	off := index * blockSize
+	size := utils.Min(blockSize, r.length-off)
+	if size <= 0 {
+		return
+	}
+	reader, err := r.ufs.Get(r.id, syscall.O_RDONLY, int64(off), int64(size))
+	if err != nil {
+		log.Debugf("prefetch %s block[%d] err: %v", r.id, index, err)
+		return
+	}
+	defer reader.Close()
+	buf := make([]byte, size)
+	n, err := io.ReadFull(reader, buf)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		log.Debugf("prefetch %s block[%d] read err: %v", r.id, index, err)
+		return
+	}
+	r.setCache(index, buf, n)
+	cachePrefetches.Inc()
+}
+
+// Prefetch 根据本次读取判断是否顺序读，是则异步预取后续窗口内的块到数据缓存
+func (store *store) Prefetch(name string, length int, flags uint32, ufs ufs.UnderFileStorage,
+	pattern *ReadPattern, off int64, size int) {
+	if store.prefetcher == nil || pattern == nil {
+		return
+	}
+	window := pattern.observe(off, size, store.conf.PrefetchBlocks)
+	if window == 0 || size == 0 {
+		return
+	}
+	r := &rCache{id: path.Clean(name), length: length, store: store, flags: flags, ufs: ufs}
+	blockSize := store.conf.BlockSize
+	start := r.index(int(off+int64(size)-1)) + 1
+	if start < pattern.fetched {
+		start = pattern.fetched
+	}
+	end := r.index(int(off+int64(size)-1)) + window
+	for index := start; index <= end && index*blockSize < length; index++ {
+		if !store.prefetcher.submit(r, index) {
+			break
+		}
+		pattern.fetched = index + 1
+	}
+}
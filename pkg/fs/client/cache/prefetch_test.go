@@ -0,0 +1,91 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	ufs "github.com/PaddlePaddle/PaddleFlow/pkg/fs/client/ufs"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/common"
+)
+
+func TestReadPattern(t *testing.T) {
+	p := &ReadPattern{}
+	assert.Equal(t, 1, p.observe(0, 10, 8))
+	assert.Equal(t, 2, p.observe(10, 10, 8))
+	assert.Equal(t, 4, p.observe(20, 10, 8))
+	assert.Equal(t, 8, p.observe(30, 10, 8))
+	assert.Equal(t, 8, p.observe(40, 10, 8))
+	// 随机读清零窗口
+	p.fetched = 5
+	assert.Equal(t, 0, p.observe(100, 10, 8))
+	assert.Equal(t, 0, p.fetched)
+	assert.Equal(t, 1, p.observe(110, 10, 8))
+}
+
+func TestPrefetch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "prefetch")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	data := make([]byte, 10*4+2)
+	for i := range data {
+		data[i] = byte('a' + i%26)
+	}
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "file"), data, 0644))
+	localFs, err := ufs.NewLocalFileSystem(map[string]interface{}{common.SubPath: dir})
+	assert.NoError(t, err)
+
+	s := NewCacheStore(Config{BlockSize: 4, MemSize: 1024, PrefetchBlocks: 4, PrefetchWorkers: 2}).(*store)
+	assert.NotNil(t, s.prefetcher)
+	pattern := &ReadPattern{}
+	r := &rCache{id: "/file", length: len(data), store: s}
+
+	// 第一次顺序读预取1个块
+	s.Prefetch("/file", len(data), 0, localFs, pattern, 0, 4)
+	assert.Equal(t, 2, pattern.fetched)
+	s.Prefetch("/file", len(data), 0, localFs, pattern, 4, 4)
+	assert.Equal(t, 4, pattern.fetched)
+	assert.Eventually(t, func() bool {
+		for index := 1; index < 4; index++ {
+			if _, ok := s.client.load(r.key(index)); !ok {
+				return false
+			}
+		}
+		return true
+	}, time.Second, 10*time.Millisecond)
+	content, ok := readAll(t, s.client, r.key(2))
+	assert.True(t, ok)
+	assert.Equal(t, string(data[8:12]), content)
+
+	// 窗口不超过文件末尾
+	s.Prefetch("/file", len(data), 0, localFs, pattern, 8, 32)
+	assert.Equal(t, 11, pattern.fetched)
+	assert.Eventually(t, func() bool {
+		content, ok := readAll(t, s.client, r.key(10))
+		return ok && content == string(data[40:])
+	}, time.Second, 10*time.Millisecond)
+
+	// 未配置数据缓存时不预取
+	s = NewCacheStore(Config{BlockSize: 4, PrefetchBlocks: 4}).(*store)
+	assert.Nil(t, s.prefetcher)
+}
@@ -43,6 +43,7 @@ type Store interface {
 		buffers ReadBufferMap, bufferPool *BufferPool, seqReadAmount uint64) Reader
 	NewWriter(name string, length int, ufsFh ufs.FileHandle) Writer
 	InvalidateCache(name string, length int) error
+	Prefetch(name string, length int, flags uint32, ufs ufs.UnderFileStorage, pattern *ReadPattern, off int64, size int)
 }
 
 type ReadCloser interface {
@@ -56,12 +57,28 @@ type Config struct {
 	BlockSize    int
 	MaxReadAhead int
 	Expire       time.Duration
+	// MaxSize 磁盘缓存块总大小上限(byte)，0表示不限制
+	MaxSize     int64
+	EvictPolicy string
+	// MemSize 内存缓存层容量(byte)，0表示不使用内存缓存
+	MemSize int64
+	// PeerAddr 不为空时开启节点间缓存共享，在该地址上为其他节点提供缓存块
+	PeerAddr     string
+	PeerRegistry kv.KvClient
+	// Compression 磁盘缓存块的压缩算法，为空不压缩
+	Compression string
+	// EncryptKey 不为空时使用AES-GCM加密磁盘缓存块
+	EncryptKey []byte
+	// PrefetchBlocks 顺序读时最多预取的块数，0表示不预取，需要配置数据缓存
+	PrefetchBlocks  int
+	PrefetchWorkers int
 }
 
 type store struct {
-	conf   Config
-	meta   map[string]string
-	client DataCacheClient
+	conf       Config
+	meta       map[string]string
+	client     DataCacheClient
+	prefetcher *prefetcher
 	sync.RWMutex
 }
 
@@ -74,6 +91,9 @@ func NewCacheStore(config Config) Store {
 		meta: make(map[string]string, 100),
 	}
 	cacheStore.client = NewDataCache(config)
+	if config.PrefetchBlocks > 0 && cacheStore.client != nil {
+		cacheStore.prefetcher = newPrefetcher(cacheStore)
+	}
 	log.Debugf("metrics register NewCacheStore")
 	registerMetrics()
 	return cacheStore
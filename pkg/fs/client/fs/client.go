@@ -90,6 +90,8 @@ type FSClient interface {
 	Size(path string) (int64, error)
 	Chmod(path string, fm os.FileMode) error
 	Chown(name string, uid, gid int) error
+	GetXAttr(path, attr string) ([]byte, error)
+	SetXAttr(path, attr string, value []byte) error
 	Walk(root string, walkFn filepath.WalkFunc) error
 	Stat(path string) (os.FileInfo, error)
 }
@@ -22,6 +22,7 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"syscall"
 	"testing"
 	"time"
 
@@ -778,3 +779,120 @@ func TestFile_Readdirnames(t *testing.T) {
 		})
 	}
 }
+
+func TestFSQuota(t *testing.T) {
+	os.RemoveAll("./mock")
+	os.MkdirAll("./mock", 0755)
+	defer os.RemoveAll("./mock")
+	testFsMeta := common.FSMeta{
+		UfsType: common.LocalType,
+		Properties: map[string]string{
+			common.RootKey: "./mock",
+		},
+		SubPath:       "./mock",
+		CapacityQuota: 10,
+		InodeQuota:    2,
+	}
+	vfsConfig := vfs.InitConfig(
+		vfs.WithMetaConfig(meta.Config{
+			Config: kv.Config{
+				Driver: kv.MemType,
+			},
+		}),
+	)
+	client, err := NewFileSystem(testFsMeta, nil, true, false, "", vfsConfig)
+	assert.Nil(t, err)
+	// 等待挂载时的用量扫描完成
+	time.Sleep(200 * time.Millisecond)
+
+	flags := os.O_RDWR | os.O_CREATE | os.O_TRUNC
+	writer, err := client.Create("quota", uint32(flags), 0666)
+	assert.Nil(t, err)
+	_, err = writer.Write([]byte("12345678"))
+	assert.Nil(t, err)
+	_, err = writer.Write([]byte("12345678"))
+	assert.ErrorIs(t, err, syscall.ENOSPC)
+	writer.Close()
+
+	assert.Nil(t, client.Mkdir("dir1", 0755))
+	assert.NotNil(t, client.Mkdir("dir2", 0755))
+
+	assert.Nil(t, client.Unlink("quota"))
+	assert.Nil(t, client.Mkdir("dir2", 0755))
+}
+
+func TestFSWriteBack(t *testing.T) {
+	os.RemoveAll("./mock")
+	os.RemoveAll("./mock-wb")
+	os.MkdirAll("./mock", 0755)
+	defer os.RemoveAll("./mock")
+	defer os.RemoveAll("./mock-wb")
+
+	// 模拟崩溃前已close但未上传的文件
+	os.MkdirAll("./mock-wb", 0755)
+	assert.Nil(t, os.WriteFile("./mock-wb/pending.data", []byte("recovered"), 0600))
+	assert.Nil(t, os.WriteFile("./mock-wb/pending.json", []byte(`{"id":"pending","path":"/recovered","size":9}`), 0600))
+	assert.Nil(t, os.WriteFile("./mock-wb/orphan.data", []byte("orphan"), 0600))
+
+	testFsMeta := common.FSMeta{
+		UfsType: common.LocalType,
+		Properties: map[string]string{
+			common.RootKey: "./mock",
+		},
+		SubPath: "./mock",
+	}
+	vfsConfig := vfs.InitConfig(
+		vfs.WithMetaConfig(meta.Config{
+			Config: kv.Config{
+				Driver: kv.MemType,
+			},
+		}),
+		vfs.WithWriteBack(vfs.WriteBackConfig{Dir: "./mock-wb"}),
+	)
+	client, err := NewFileSystem(testFsMeta, nil, true, false, "", vfsConfig)
+	assert.Nil(t, err)
+
+	flags := os.O_RDWR | os.O_CREATE | os.O_TRUNC
+	writer, err := client.Create("fsync", uint32(flags), 0666)
+	assert.Nil(t, err)
+	_, err = writer.Write([]byte("checkpoint"))
+	assert.Nil(t, err)
+	// fsync返回时数据已写入ufs
+	assert.Nil(t, writer.Sync())
+	data, err := os.ReadFile("./mock/fsync")
+	assert.Nil(t, err)
+	assert.Equal(t, "checkpoint", string(data))
+	writer.Close()
+
+	writer, err = client.Create("async", uint32(flags), 0666)
+	assert.Nil(t, err)
+	_, err = writer.Write([]byte("async data"))
+	assert.Nil(t, err)
+	assert.Nil(t, writer.Close())
+	info, err := client.Stat("async")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(10), info.Size())
+	reader, err := client.Open("async")
+	assert.Nil(t, err)
+	buf := make([]byte, 20)
+	n, err := reader.Read(buf)
+	assert.Nil(t, err)
+	assert.Equal(t, "async data", string(buf[:n]))
+	reader.Close()
+
+	assert.Nil(t, client.Rename("async", "renamed"))
+
+	assert.Eventually(t, func() bool {
+		recovered, err1 := os.ReadFile("./mock/recovered")
+		renamed, err2 := os.ReadFile("./mock/renamed")
+		return err1 == nil && err2 == nil && string(recovered) == "recovered" && string(renamed) == "async data"
+	}, 5*time.Second, 50*time.Millisecond)
+	_, err = os.Stat("./mock/async")
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat("./mock-wb/orphan.data")
+	assert.True(t, os.IsNotExist(err))
+	assert.Eventually(t, func() bool {
+		entries, _ := os.ReadDir("./mock-wb")
+		return len(entries) == 0
+	}, 5*time.Second, 50*time.Millisecond)
+}
@@ -41,6 +41,9 @@ type FileSystem struct {
 	cache  *metaCache
 }
 
+// maxXAttrSize 读取扩展属性的长度上限
+const maxXAttrSize = 64 * 1024
+
 var collectorOnce sync.Once
 
 func collectorRegister() {
@@ -167,6 +170,33 @@ func (fs *FileSystem) Chown(name string, uid, gid int) error {
 	return nil
 }
 
+func (fs *FileSystem) GetXAttr(name, attr string) ([]byte, error) {
+	name = path.Clean(name)
+	ctx := meta.NewEmptyContext()
+	_, ino, sysErr := fs.lookup(ctx, name, true)
+	if utils.IsError(sysErr) {
+		return nil, sysErr
+	}
+	value, err := fs.vfs.GetXAttr(ctx, ino, attr, maxXAttrSize)
+	if utils.IsError(err) {
+		return nil, err
+	}
+	return value, nil
+}
+
+func (fs *FileSystem) SetXAttr(name, attr string, value []byte) error {
+	name = path.Clean(name)
+	ctx := meta.NewEmptyContext()
+	_, ino, sysErr := fs.lookup(ctx, name, true)
+	if utils.IsError(sysErr) {
+		return sysErr
+	}
+	if err := fs.vfs.SetXAttr(ctx, ino, attr, value, 0); utils.IsError(err) {
+		return err
+	}
+	return nil
+}
+
 func (fs *FileSystem) Stat(path_ string) (os.FileInfo, error) {
 	path_ = path.Clean(path_)
 	ctx := meta.NewEmptyContext()
@@ -226,6 +226,14 @@ func (c *MockClient) Chown(name string, uid, gid int) error {
 	return os.Chown(name, uid, gid)
 }
 
+func (c *MockClient) GetXAttr(path, attr string) ([]byte, error) {
+	return nil, syscall.ENOSYS
+}
+
+func (c *MockClient) SetXAttr(path, attr string, value []byte) error {
+	return syscall.ENOSYS
+}
+
 func (c *MockClient) Walk(root string, walkFn filepath.WalkFunc) error {
 	return nil
 }
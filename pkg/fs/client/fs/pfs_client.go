@@ -454,6 +454,14 @@ func (c *PFSClient) Chown(name string, uid, gid int) error {
 	return c.pfs.Chown(name, uid, gid)
 }
 
+func (c *PFSClient) GetXAttr(path, attr string) ([]byte, error) {
+	return c.pfs.GetXAttr(path, attr)
+}
+
+func (c *PFSClient) SetXAttr(path, attr string, value []byte) error {
+	return c.pfs.SetXAttr(path, attr, value)
+}
+
 func (c *PFSClient) Walk(root string, walkFn filepath.WalkFunc) error {
 	info, err := c.pfs.Stat(root)
 	if err != nil {
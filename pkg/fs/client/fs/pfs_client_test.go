@@ -1493,3 +1493,154 @@ func clean() {
 	_ = os.RemoveAll("./mock")
 	_ = os.RemoveAll("./mock-cache")
 }
+
+func TestLinkAndXAttr(t *testing.T) {
+	clean()
+	defer clean()
+	client := getTestFSClient(t).(*PFSClient)
+	_, err := client.CreateFile("/f1", []byte("hello"))
+	assert.Equal(t, nil, err)
+
+	v := client.pfs.vfs
+	ctx := meta.NewEmptyContext()
+	_, rootIno, errno := client.pfs.lookup(ctx, "/", false)
+	assert.Equal(t, syscall.Errno(0), errno)
+	_, ino, errno := client.pfs.lookup(ctx, "/f1", false)
+	assert.Equal(t, syscall.Errno(0), errno)
+
+	// hard link
+	entry, errno := v.Link(ctx, ino, rootIno, "f2")
+	assert.Equal(t, syscall.Errno(0), errno)
+	assert.Equal(t, uint64(2), entry.Attr.Nlink)
+	reader, err := client.Open("/f2")
+	assert.Equal(t, nil, err)
+	content, err := ioutil.ReadAll(reader)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "hello", string(content))
+	_, errno = v.Link(ctx, ino, rootIno, "f2")
+	assert.Equal(t, syscall.EEXIST, errno)
+	err = client.Remove("/f1")
+	assert.Equal(t, nil, err)
+	stat, err := client.Stat("/f2")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, int64(5), stat.Size())
+
+	// mtime
+	_, ino, errno = client.pfs.lookup(ctx, "/f2", false)
+	assert.Equal(t, syscall.Errno(0), errno)
+	mtime := time.Now().Add(-time.Hour).Unix()
+	_, errno = v.SetAttr(ctx, ino, meta.FATTR_MTIME, 0, 0, 0, 0, mtime, 0, 0, 0)
+	assert.Equal(t, syscall.Errno(0), errno)
+	fi, err := os.Stat("./mock/f2")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, mtime, fi.ModTime().Unix())
+
+	// xattr
+	errno = v.SetXAttr(ctx, ino, "user.k", []byte("value"), 0)
+	if errno == syscall.ENOTSUP || errno == syscall.EOPNOTSUPP {
+		t.Skip("xattr not supported by local file system")
+	}
+	assert.Equal(t, syscall.Errno(0), errno)
+	data, errno := v.GetXAttr(ctx, ino, "user.k", 0)
+	assert.Equal(t, syscall.ERANGE, errno)
+	assert.Equal(t, 5, len(data))
+	data, errno = v.GetXAttr(ctx, ino, "user.k", 64)
+	assert.Equal(t, syscall.Errno(0), errno)
+	assert.Equal(t, "value", string(data))
+	data, errno = v.ListXAttr(ctx, ino, 64)
+	assert.Equal(t, syscall.Errno(0), errno)
+	assert.Equal(t, "user.k\x00", string(data))
+	errno = v.RemoveXAttr(ctx, ino, "user.k")
+	assert.Equal(t, syscall.Errno(0), errno)
+	_, errno = v.GetXAttr(ctx, ino, "user.k", 64)
+	assert.Equal(t, syscall.ENODATA, errno)
+}
+
+func TestAtimeMode(t *testing.T) {
+	clean()
+	defer clean()
+	os.MkdirAll("./mock", 0755)
+	testFsMeta := common.FSMeta{
+		UfsType: common.LocalType,
+		Properties: map[string]string{
+			common.RootKey: "./mock",
+		},
+		SubPath: "./mock",
+	}
+	vfsConfig := vfs.InitConfig(
+		vfs.WithMetaConfig(meta.Config{
+			AttrCacheExpire:  10 * time.Second,
+			EntryCacheExpire: 10 * time.Second,
+			AtimeMode:        meta.AtimeModeRelatime,
+			Config: kv.Config{
+				Driver: kv.MemType,
+			},
+		}),
+	)
+	pfs, err := NewFileSystem(testFsMeta, nil, true, false, "", vfsConfig)
+	assert.Equal(t, nil, err)
+	client := PFSClient{pfs: pfs}
+	_, err = client.CreateFile("/f1", []byte("hello"))
+	assert.Equal(t, nil, err)
+
+	ctx := meta.NewEmptyContext()
+	_, ino, errno := client.pfs.lookup(ctx, "/f1", false)
+	assert.Equal(t, syscall.Errno(0), errno)
+	old := time.Now().Add(-48 * time.Hour)
+	_, errno = pfs.vfs.SetAttr(ctx, ino, meta.FATTR_ATIME|meta.FATTR_MTIME, 0, 0, 0,
+		old.Unix(), old.Unix(), 0, 0, 0)
+	assert.Equal(t, syscall.Errno(0), errno)
+
+	// relatime下atime超过一天未更新，读取时更新
+	reader, err := client.Open("/f1")
+	assert.Equal(t, nil, err)
+	reader.Close()
+	fi, err := os.Stat("./mock/f1")
+	assert.Equal(t, nil, err)
+	atime := time.Unix(fi.Sys().(*syscall.Stat_t).Atim.Unix())
+	assert.True(t, atime.After(old.Add(time.Hour)))
+	assert.Equal(t, old.Unix(), fi.ModTime().Unix())
+}
+
+func TestNegativeEntryCache(t *testing.T) {
+	clean()
+	defer clean()
+	os.MkdirAll("./mock", 0755)
+	testFsMeta := common.FSMeta{
+		UfsType: common.LocalType,
+		Properties: map[string]string{
+			common.RootKey: "./mock",
+		},
+		SubPath: "./mock",
+	}
+	vfsConfig := vfs.InitConfig(
+		vfs.WithMetaConfig(meta.Config{
+			AttrCacheExpire:     10 * time.Second,
+			EntryCacheExpire:    10 * time.Second,
+			NegEntryCacheExpire: 10 * time.Second,
+			Config: kv.Config{
+				Driver: kv.MemType,
+			},
+		}),
+	)
+	pfs, err := NewFileSystem(testFsMeta, nil, true, false, "", vfsConfig)
+	assert.Equal(t, nil, err)
+	client := PFSClient{pfs: pfs}
+
+	ctx := meta.NewEmptyContext()
+	_, _, errno := client.pfs.lookup(ctx, "/f1", false)
+	assert.Equal(t, syscall.ENOENT, errno)
+	// ristretto异步写入
+	time.Sleep(100 * time.Millisecond)
+
+	// 绕过客户端创建的文件在负缓存过期前不可见
+	assert.Equal(t, nil, os.WriteFile("./mock/f1", []byte("hello"), 0644))
+	_, _, errno = client.pfs.lookup(ctx, "/f1", false)
+	assert.Equal(t, syscall.ENOENT, errno)
+
+	// 通过客户端创建后负缓存失效
+	_, err = client.CreateFile("/f1", []byte("world"))
+	assert.Equal(t, nil, err)
+	_, _, errno = client.pfs.lookup(ctx, "/f1", false)
+	assert.Equal(t, syscall.Errno(0), errno)
+}
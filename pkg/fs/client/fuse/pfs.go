@@ -18,6 +18,7 @@ package fuse
 
 import (
 	"os"
+	"syscall"
 	"time"
 
 	"github.com/hanwen/go-fuse/v2/fuse"
@@ -162,7 +163,15 @@ func (fs *PFS) Rename(cancel <-chan struct{}, input *fuse.RenameIn, oldName stri
 }
 
 func (fs *PFS) Link(cancel <-chan struct{}, input *fuse.LinkIn, filename string, out *fuse.EntryOut) fuse.Status {
-	return fuse.ENOSYS
+	log.Debugf("pfs POSIX Link: input[%+v] filename[%s]", *input, filename)
+	ctx := meta.NewContext(cancel, input.Uid, input.Pid, input.Gid)
+	entry, code := vfs.GetVFS().Link(ctx, vfs.Ino(input.Oldnodeid), vfs.Ino(input.NodeId), filename)
+	if code != 0 {
+		return fuse.Status(code)
+	}
+	fs.replyEntry(entry, out)
+	log.Debugf("pfs POSIX Link out is [%+v]", *out)
+	return fuse.OK
 }
 
 func (fs *PFS) Symlink(cancel <-chan struct{}, header *fuse.InHeader, pointedTo string, linkName string, out *fuse.EntryOut) fuse.Status {
@@ -189,11 +198,14 @@ func (fs *PFS) GetXAttr(cancel <-chan struct{}, header *fuse.InHeader, attr stri
 	log.Debugf("pfs POSIX GetXAttr: header[%+v] attr[%s] dest[%s]", *header, attr, string(dest))
 	ctx := meta.NewContext(cancel, header.Uid, header.Pid, header.Gid)
 	value, code := vfs.GetVFS().GetXAttr(ctx, vfs.Ino(header.NodeId), attr, uint32(len(dest)))
+	if code == syscall.ERANGE {
+		// 缓冲区不足或查询长度时返回所需的大小
+		return uint32(len(value)), fuse.ERANGE
+	}
 	if code != 0 {
 		return 0, fuse.Status(code)
 	}
-	copy(dest, value)
-	return uint32(len(dest)), fuse.Status(code)
+	return uint32(copy(dest, value)), fuse.OK
 }
 
 // ListXAttr lists extended attributes as '\0' delimited byte
@@ -203,11 +215,14 @@ func (fs *PFS) ListXAttr(cancel <-chan struct{}, header *fuse.InHeader, dest []b
 	log.Debugf("pfs POSIX ListXAttr: header[%+v] dest[%s]", *header, string(dest))
 	ctx := meta.NewContext(cancel, header.Uid, header.Pid, header.Gid)
 	value, code := vfs.GetVFS().ListXAttr(ctx, vfs.Ino(header.NodeId), uint32(len(dest)))
+	if code == syscall.ERANGE {
+		// 缓冲区不足或查询长度时返回所需的大小
+		return uint32(len(value)), fuse.ERANGE
+	}
 	if code != 0 {
 		return 0, fuse.Status(code)
 	}
-	copy(dest, value)
-	return uint32(len(dest)), fuse.Status(code)
+	return uint32(copy(dest, value)), fuse.OK
 }
 
 // SetAttr writes an extended attribute.
@@ -361,6 +376,10 @@ func (fs *PFS) ReadDirPlus(cancel <-chan struct{}, input *fuse.ReadIn, out *fuse
 		if eo == nil {
 			break
 		}
+		// 只有mode的目录项交给内核再lookup
+		if e.Attr.Type != 0 {
+			fs.replyEntry(e, eo)
+		}
 	}
 	return fuse.Status(code)
 }
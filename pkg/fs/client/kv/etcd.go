@@ -0,0 +1,190 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kv
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const etcdTimeout = 10 * time.Second
+
+type etcdKeyValue struct {
+	Key         []byte `json:"key"`
+	Value       []byte `json:"value"`
+	ModRevision int64  `json:"mod_revision,string"`
+}
+
+type etcdRangeRequest struct {
+	Key      []byte `json:"key"`
+	RangeEnd []byte `json:"range_end,omitempty"`
+}
+
+type etcdRangeResponse struct {
+	Kvs []etcdKeyValue `json:"kvs"`
+}
+
+type etcdPutRequest struct {
+	Key   []byte `json:"key"`
+	Value []byte `json:"value"`
+}
+
+type etcdDeleteRequest struct {
+	Key []byte `json:"key"`
+}
+
+type etcdCompare struct {
+	Key         []byte `json:"key"`
+	Target      string `json:"target"`
+	Result      string `json:"result"`
+	ModRevision int64  `json:"mod_revision,string"`
+}
+
+type etcdRequestOp struct {
+	RequestPut         *etcdPutRequest    `json:"request_put,omitempty"`
+	RequestDeleteRange *etcdDeleteRequest `json:"request_delete_range,omitempty"`
+}
+
+type etcdTxnRequest struct {
+	Compare []etcdCompare   `json:"compare"`
+	Success []etcdRequestOp `json:"success"`
+}
+
+type etcdTxnResponse struct {
+	Succeeded bool `json:"succeeded"`
+}
+
+// etcdClient 通过etcd v3的grpc-gateway(json)接口访问，多个endpoint依次尝试
+type etcdClient struct {
+	endpoints  []string
+	httpClient *http.Client
+}
+
+// NewEtcdClient address格式为etcd://host1:2379,host2:2379
+func NewEtcdClient(config Config) (KvClient, error) {
+	address := strings.TrimPrefix(config.Address, EtcdType+"://")
+	if address == "" || strings.Contains(address, "://") {
+		return nil, fmt.Errorf("invalid etcd address[%s], should be etcd://host1:2379,host2:2379", config.Address)
+	}
+	c := &etcdClient{httpClient: &http.Client{Timeout: etcdTimeout}}
+	for _, endpoint := range strings.Split(address, ",") {
+		if endpoint = strings.TrimSpace(endpoint); endpoint != "" {
+			c.endpoints = append(c.endpoints, "http://"+endpoint)
+		}
+	}
+	if err := c.post("/v3/kv/range", &etcdRangeRequest{Key: []byte(config.FsID)}, &etcdRangeResponse{}); err != nil {
+		return nil, err
+	}
+	return &remoteClient{name: EtcdType, prefix: config.FsID + "/", begin: c.begin}, nil
+}
+
+func (c *etcdClient) post(path string, req, resp interface{}) error {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	for _, endpoint := range c.endpoints {
+		var httpResp *http.Response
+		httpResp, err = c.httpClient.Post(endpoint+path, "application/json", bytes.NewReader(body))
+		if err != nil {
+			continue
+		}
+		data, _ := ioutil.ReadAll(httpResp.Body)
+		httpResp.Body.Close()
+		if httpResp.StatusCode != http.StatusOK {
+			err = fmt.Errorf("etcd %s %s: status %d, %s", endpoint, path, httpResp.StatusCode, string(data))
+			continue
+		}
+		return json.Unmarshal(data, resp)
+	}
+	return err
+}
+
+func (c *etcdClient) begin() (remoteTxn, error) {
+	return &etcdTxn{client: c, revisions: make(map[string]int64)}, nil
+}
+
+// etcdTxn 记录读取时key的mod_revision，提交时比较，不存在的key版本为0
+type etcdTxn struct {
+	client    *etcdClient
+	revisions map[string]int64
+}
+
+func (t *etcdTxn) get(key []byte) ([]byte, error) {
+	resp := &etcdRangeResponse{}
+	if err := t.client.post("/v3/kv/range", &etcdRangeRequest{Key: key}, resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Kvs) == 0 {
+		t.revisions[string(key)] = 0
+		return nil, nil
+	}
+	t.revisions[string(key)] = resp.Kvs[0].ModRevision
+	return resp.Kvs[0].Value, nil
+}
+
+// prefixEnd 前缀范围查询的结束key，即前缀最后一个非0xff字节加1
+func prefixEnd(prefix []byte) []byte {
+	end := append([]byte{}, prefix...)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	return []byte{0}
+}
+
+func (t *etcdTxn) scan(prefix []byte) (map[string][]byte, error) {
+	resp := &etcdRangeResponse{}
+	if err := t.client.post("/v3/kv/range", &etcdRangeRequest{Key: prefix, RangeEnd: prefixEnd(prefix)}, resp); err != nil {
+		return nil, err
+	}
+	result := make(map[string][]byte, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		result[string(kv.Key)] = kv.Value
+	}
+	return result, nil
+}
+
+func (t *etcdTxn) commit(puts map[string][]byte, dels map[string]bool) (bool, error) {
+	if len(puts) == 0 && len(dels) == 0 {
+		return true, nil
+	}
+	req := &etcdTxnRequest{}
+	for key, revision := range t.revisions {
+		req.Compare = append(req.Compare, etcdCompare{Key: []byte(key), Target: "MOD", Result: "EQUAL", ModRevision: revision})
+	}
+	for key, value := range puts {
+		req.Success = append(req.Success, etcdRequestOp{RequestPut: &etcdPutRequest{Key: []byte(key), Value: value}})
+	}
+	for key := range dels {
+		req.Success = append(req.Success, etcdRequestOp{RequestDeleteRange: &etcdDeleteRequest{Key: []byte(key)}})
+	}
+	resp := &etcdTxnResponse{}
+	if err := t.client.post("/v3/kv/txn", req, resp); err != nil {
+		return false, err
+	}
+	return resp.Succeeded, nil
+}
+
+func (t *etcdTxn) close() {}
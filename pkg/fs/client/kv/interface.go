@@ -16,11 +16,20 @@ limitations under the License.
 
 package kv
 
+import "fmt"
+
+const (
+	RedisType = "redis"
+	EtcdType  = "etcd"
+)
+
 type Config struct {
 	FsID      string
 	Driver    string
 	CachePath string
 	Capacity  int64
+	// Address redis/etcd等共享driver的访问地址
+	Address string
 }
 
 type KvTxn interface {
@@ -37,3 +46,59 @@ type KvClient interface {
 	Name() string
 	Txn(f func(KvTxn) error) error
 }
+
+func NewClient(config Config) (KvClient, error) {
+	switch config.Driver {
+	case MemType, DiskType:
+		return NewBadgerClient(config)
+	case RedisType:
+		return NewRedisClient(config)
+	case EtcdType:
+		return NewEtcdClient(config)
+	default:
+		return nil, fmt.Errorf("not found meta driver name %s", config.Driver)
+	}
+}
+
+// IsShared redis/etcd中的数据由多个挂载pod共享，挂载时不会清空
+func IsShared(driver string) bool {
+	return driver == RedisType || driver == EtcdType
+}
+
+// Copy 将src中当前fs的全部数据复制到dst，用于在不同driver之间迁移元数据
+func Copy(src, dst KvClient, batch int) (int, error) {
+	var values map[string][]byte
+	err := src.Txn(func(txn KvTxn) error {
+		var err error
+		values, err = txn.ScanValues(nil)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	copied := 0
+	for len(keys) > 0 {
+		n := batch
+		if n <= 0 || n > len(keys) {
+			n = len(keys)
+		}
+		err = dst.Txn(func(txn KvTxn) error {
+			for _, key := range keys[:n] {
+				if err := txn.Set([]byte(key), values[key]); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return copied, err
+		}
+		copied += n
+		keys = keys[n:]
+	}
+	return copied, nil
+}
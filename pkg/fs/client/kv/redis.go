@@ -0,0 +1,328 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kv
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	redisDialTimeout = 5 * time.Second
+	redisIOTimeout   = 10 * time.Second
+	redisPoolSize    = 16
+	redisScanCount   = "1000"
+)
+
+type redisError string
+
+func (e redisError) Error() string {
+	return "redis: " + string(e)
+}
+
+// redisConn 使用RESP协议与redis通信
+type redisConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func (c *redisConn) do(args ...[]byte) (interface{}, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&buf, "$%d\r\n", len(arg))
+		buf.Write(arg)
+		buf.WriteString("\r\n")
+	}
+	c.conn.SetDeadline(time.Now().Add(redisIOTimeout))
+	if _, err := c.conn.Write(buf.Bytes()); err != nil {
+		return nil, err
+	}
+	return c.readReply()
+}
+
+func (c *redisConn) readLine() (string, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return "", fmt.Errorf("redis: invalid reply line %q", line)
+	}
+	return line[:len(line)-2], nil
+}
+
+// readReply 返回string/redisError/int64/[]byte/[]interface{}，nil表示空值
+func (c *redisConn) readReply() (interface{}, error) {
+	line, err := c.readLine()
+	if err != nil {
+		return nil, err
+	}
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return redisError(line[1:]), nil
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		data := make([]byte, n+2)
+		if _, err = io.ReadFull(c.r, data); err != nil {
+			return nil, err
+		}
+		return data[:n], nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		items := make([]interface{}, n)
+		for i := range items {
+			if items[i], err = c.readReply(); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("redis: unknown reply %q", line)
+}
+
+// call 执行命令，错误回复转换为error
+func (c *redisConn) call(args ...string) (interface{}, error) {
+	bargs := make([][]byte, len(args))
+	for i, arg := range args {
+		bargs[i] = []byte(arg)
+	}
+	reply, err := c.do(bargs...)
+	if err != nil {
+		return nil, err
+	}
+	if e, ok := reply.(redisError); ok {
+		return nil, e
+	}
+	return reply, nil
+}
+
+type redisClient struct {
+	addr     string
+	password string
+	db       int
+	pool     chan *redisConn
+}
+
+// NewRedisClient address格式为redis://[:password@]host:port[/db]
+func NewRedisClient(config Config) (KvClient, error) {
+	u, err := url.Parse(config.Address)
+	if err != nil || u.Scheme != RedisType || u.Host == "" {
+		return nil, fmt.Errorf("invalid redis address[%s], should be redis://[:password@]host:port[/db]", config.Address)
+	}
+	c := &redisClient{addr: u.Host, pool: make(chan *redisConn, redisPoolSize)}
+	if password, ok := u.User.Password(); ok {
+		c.password = password
+	}
+	if db := strings.TrimPrefix(u.Path, "/"); db != "" {
+		if c.db, err = strconv.Atoi(db); err != nil {
+			return nil, fmt.Errorf("invalid redis db[%s]", db)
+		}
+	}
+	conn, err := c.getConn()
+	if err != nil {
+		return nil, err
+	}
+	c.putConn(conn)
+	return &remoteClient{name: RedisType, prefix: config.FsID + ":", begin: c.begin}, nil
+}
+
+func (c *redisClient) getConn() (*redisConn, error) {
+	select {
+	case conn := <-c.pool:
+		return conn, nil
+	default:
+	}
+	netConn, err := net.DialTimeout("tcp", c.addr, redisDialTimeout)
+	if err != nil {
+		return nil, err
+	}
+	conn := &redisConn{conn: netConn, r: bufio.NewReader(netConn)}
+	if c.password != "" {
+		if _, err = conn.call("AUTH", c.password); err != nil {
+			netConn.Close()
+			return nil, err
+		}
+	}
+	if c.db != 0 {
+		if _, err = conn.call("SELECT", strconv.Itoa(c.db)); err != nil {
+			netConn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+func (c *redisClient) putConn(conn *redisConn) {
+	select {
+	case c.pool <- conn:
+	default:
+		conn.conn.Close()
+	}
+}
+
+func (c *redisClient) begin() (remoteTxn, error) {
+	conn, err := c.getConn()
+	if err != nil {
+		return nil, err
+	}
+	return &redisTxn{client: c, conn: conn}, nil
+}
+
+// redisTxn 读取前WATCH对应key，提交时使用MULTI/EXEC，被其他客户端修改时EXEC返回空
+type redisTxn struct {
+	client  *redisClient
+	conn    *redisConn
+	broken  bool
+	watched bool
+}
+
+func (t *redisTxn) call(args ...string) (interface{}, error) {
+	reply, err := t.conn.call(args...)
+	if err != nil {
+		if _, ok := err.(redisError); !ok {
+			t.broken = true
+		}
+	}
+	return reply, err
+}
+
+func (t *redisTxn) get(key []byte) ([]byte, error) {
+	if _, err := t.call("WATCH", string(key)); err != nil {
+		return nil, err
+	}
+	t.watched = true
+	reply, err := t.call("GET", string(key))
+	if err != nil || reply == nil {
+		return nil, err
+	}
+	return reply.([]byte), nil
+}
+
+// redisMatchEscape 转义glob特殊字符，key中可能包含任意字节
+func redisMatchEscape(prefix []byte) string {
+	var b strings.Builder
+	for _, c := range prefix {
+		switch c {
+		case '*', '?', '[', ']', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+func (t *redisTxn) scan(prefix []byte) (map[string][]byte, error) {
+	var keys []string
+	cursor := "0"
+	for {
+		reply, err := t.call("SCAN", cursor, "MATCH", redisMatchEscape(prefix)+"*", "COUNT", redisScanCount)
+		if err != nil {
+			return nil, err
+		}
+		items, ok := reply.([]interface{})
+		if !ok || len(items) != 2 {
+			return nil, fmt.Errorf("redis: invalid scan reply")
+		}
+		cursor = string(items[0].([]byte))
+		for _, key := range items[1].([]interface{}) {
+			keys = append(keys, string(key.([]byte)))
+		}
+		if cursor == "0" {
+			break
+		}
+	}
+	result := make(map[string][]byte, len(keys))
+	if len(keys) == 0 {
+		return result, nil
+	}
+	reply, err := t.call(append([]string{"MGET"}, keys...)...)
+	if err != nil {
+		return nil, err
+	}
+	for i, value := range reply.([]interface{}) {
+		// scan与mget之间被删除的key
+		if value != nil {
+			result[keys[i]] = value.([]byte)
+		}
+	}
+	return result, nil
+}
+
+func (t *redisTxn) commit(puts map[string][]byte, dels map[string]bool) (bool, error) {
+	if len(puts) == 0 && len(dels) == 0 {
+		return true, nil
+	}
+	ok, err := t.exec(puts, dels)
+	if err != nil {
+		// MULTI中途失败，连接状态未知，不再复用
+		t.broken = true
+	}
+	return ok, err
+}
+
+func (t *redisTxn) exec(puts map[string][]byte, dels map[string]bool) (bool, error) {
+	if _, err := t.call("MULTI"); err != nil {
+		return false, err
+	}
+	for key, value := range puts {
+		if _, err := t.call("SET", key, string(value)); err != nil {
+			return false, err
+		}
+	}
+	for key := range dels {
+		if _, err := t.call("DEL", key); err != nil {
+			return false, err
+		}
+	}
+	reply, err := t.call("EXEC")
+	t.watched = false
+	if err != nil {
+		return false, err
+	}
+	return reply != nil, nil
+}
+
+func (t *redisTxn) close() {
+	if !t.broken && t.watched {
+		if _, err := t.call("UNWATCH"); err != nil {
+			t.broken = true
+		}
+	}
+	if t.broken {
+		t.conn.conn.Close()
+		return
+	}
+	t.client.putConn(t.conn)
+}
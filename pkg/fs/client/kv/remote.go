@@ -0,0 +1,182 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kv
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const remoteTxnRetries = 50
+
+// remoteTxn 远端kv(redis/etcd)上的一次事务，读取时记录key的版本，提交时版本变化则返回false
+type remoteTxn interface {
+	get(key []byte) ([]byte, error)
+	scan(prefix []byte) (map[string][]byte, error)
+	commit(puts map[string][]byte, dels map[string]bool) (bool, error)
+	close()
+}
+
+// remoteClient 多个挂载pod共享的kv，不同fs的数据以fsID作为key前缀区分
+type remoteClient struct {
+	name   string
+	prefix string
+	begin  func() (remoteTxn, error)
+}
+
+func (c *remoteClient) Name() string {
+	return c.name
+}
+
+// Txn 写入先缓存在本地，f执行成功后一次性提交，与其他挂载pod冲突时重新执行f
+func (c *remoteClient) Txn(f func(txn KvTxn) error) error {
+	for i := 0; i < remoteTxnRetries; i++ {
+		rt, err := c.begin()
+		if err != nil {
+			return err
+		}
+		txn := &bufferedTxn{
+			remote: rt,
+			prefix: c.prefix,
+			puts:   make(map[string][]byte),
+			dels:   make(map[string]bool),
+		}
+		if err = f(txn); err == nil {
+			err = txn.err
+		}
+		if err != nil {
+			rt.close()
+			log.Debugf("%s txn err is %v", c.name, err)
+			return err
+		}
+		ok, err := rt.commit(txn.puts, txn.dels)
+		rt.close()
+		if err != nil {
+			log.Debugf("%s txn commit err %v", c.name, err)
+			return err
+		}
+		if ok {
+			return nil
+		}
+		log.Debugf("%s txn conflict, retry %d", c.name, i+1)
+		time.Sleep(time.Duration(rand.Intn(10)+1) * time.Millisecond)
+	}
+	return fmt.Errorf("%s txn conflict after %d retries", c.name, remoteTxnRetries)
+}
+
+type bufferedTxn struct {
+	remote remoteTxn
+	prefix string
+	puts   map[string][]byte
+	dels   map[string]bool
+	// err KvTxn的Get等接口不返回错误，记录下来使事务失败
+	err error
+}
+
+var _ KvTxn = &bufferedTxn{}
+
+func (t *bufferedTxn) key(key []byte) string {
+	return t.prefix + string(key)
+}
+
+func (t *bufferedTxn) setErr(err error) {
+	if t.err == nil {
+		t.err = err
+	}
+}
+
+func (t *bufferedTxn) Get(key []byte) []byte {
+	k := t.key(key)
+	if value, ok := t.puts[k]; ok {
+		return value
+	}
+	if t.dels[k] {
+		return nil
+	}
+	value, err := t.remote.get([]byte(k))
+	if err != nil {
+		log.Debugf("get key %s with err %v", string(key), err)
+		t.setErr(err)
+		return nil
+	}
+	return value
+}
+
+func (t *bufferedTxn) Set(key, value []byte) error {
+	k := t.key(key)
+	t.puts[k] = append([]byte{}, value...)
+	delete(t.dels, k)
+	return nil
+}
+
+func (t *bufferedTxn) Dels(keys ...[]byte) error {
+	for _, key := range keys {
+		k := t.key(key)
+		delete(t.puts, k)
+		t.dels[k] = true
+	}
+	return nil
+}
+
+func (t *bufferedTxn) ScanValues(prefix []byte) (map[string][]byte, error) {
+	fullPrefix := t.key(prefix)
+	values, err := t.remote.scan([]byte(fullPrefix))
+	if err != nil {
+		t.setErr(err)
+		return nil, err
+	}
+	result := make(map[string][]byte, len(values))
+	for k, v := range values {
+		if !t.dels[k] {
+			result[strings.TrimPrefix(k, t.prefix)] = v
+		}
+	}
+	for k, v := range t.puts {
+		if strings.HasPrefix(k, fullPrefix) {
+			result[strings.TrimPrefix(k, t.prefix)] = v
+		}
+	}
+	return result, nil
+}
+
+func (t *bufferedTxn) Exist(prefix []byte) bool {
+	values, err := t.ScanValues(prefix)
+	return err == nil && len(values) > 0
+}
+
+func (t *bufferedTxn) Append(key []byte, value []byte) []byte {
+	newValue := append(append([]byte{}, t.Get(key)...), value...)
+	_ = t.Set(key, newValue)
+	return newValue
+}
+
+func (t *bufferedTxn) IncrBy(key []byte, value int64) int64 {
+	var number int64
+	buf := t.Get(key)
+	if len(buf) > 0 {
+		number = parseCounter(buf)
+	}
+	if value != 0 {
+		number += value
+		_ = t.Set(key, packCounter(number))
+	}
+	return number
+}
@@ -0,0 +1,171 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kv
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeEtcd 模拟etcd v3 json接口中的range与txn
+type fakeEtcd struct {
+	sync.Mutex
+	revision int64
+	kvs      map[string]etcdKeyValue
+}
+
+func (f *fakeEtcd) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.Lock()
+	defer f.Unlock()
+	switch r.URL.Path {
+	case "/v3/kv/range":
+		req := etcdRangeRequest{}
+		json.NewDecoder(r.Body).Decode(&req)
+		resp := etcdRangeResponse{}
+		for key, kv := range f.kvs {
+			if key == string(req.Key) || (req.RangeEnd != nil && key >= string(req.Key) && key < string(req.RangeEnd)) {
+				resp.Kvs = append(resp.Kvs, kv)
+			}
+		}
+		json.NewEncoder(w).Encode(resp)
+	case "/v3/kv/txn":
+		req := etcdTxnRequest{}
+		json.NewDecoder(r.Body).Decode(&req)
+		for _, cmp := range req.Compare {
+			if f.kvs[string(cmp.Key)].ModRevision != cmp.ModRevision {
+				json.NewEncoder(w).Encode(etcdTxnResponse{Succeeded: false})
+				return
+			}
+		}
+		f.revision++
+		for _, op := range req.Success {
+			if op.RequestPut != nil {
+				f.kvs[string(op.RequestPut.Key)] = etcdKeyValue{Key: op.RequestPut.Key, Value: op.RequestPut.Value, ModRevision: f.revision}
+			} else {
+				delete(f.kvs, string(op.RequestDeleteRange.Key))
+			}
+		}
+		json.NewEncoder(w).Encode(etcdTxnResponse{Succeeded: true})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func newTestEtcd(t *testing.T, fsID string) (KvClient, *fakeEtcd) {
+	fake := &fakeEtcd{kvs: make(map[string]etcdKeyValue)}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	client, err := NewClient(Config{FsID: fsID, Driver: EtcdType,
+		Address: "etcd://127.0.0.1:1," + strings.TrimPrefix(server.URL, "http://")})
+	assert.NoError(t, err)
+	return client, fake
+}
+
+func TestEtcdClient(t *testing.T) {
+	client, fake := newTestEtcd(t, "fs-root-a")
+	assert.Equal(t, EtcdType, client.Name())
+
+	err := client.Txn(func(txn KvTxn) error {
+		assert.NoError(t, txn.Set([]byte("d1"), []byte("v1")))
+		assert.NoError(t, txn.Set([]byte("d2"), []byte("v2")))
+		assert.NoError(t, txn.Set([]byte("e1"), []byte("v3")))
+		// 事务内可以读到未提交的写入
+		assert.Equal(t, []byte("v1"), txn.Get([]byte("d1")))
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(fake.kvs))
+	_, ok := fake.kvs["fs-root-a/d1"]
+	assert.True(t, ok)
+
+	err = client.Txn(func(txn KvTxn) error {
+		assert.NoError(t, txn.Dels([]byte("d2")))
+		values, err := txn.ScanValues([]byte("d"))
+		assert.NoError(t, err)
+		assert.Equal(t, map[string][]byte{"d1": []byte("v1")}, values)
+		assert.False(t, txn.Exist([]byte("f")))
+		assert.Equal(t, int64(2), txn.IncrBy([]byte("counter"), 2))
+		return nil
+	})
+	assert.NoError(t, err)
+
+	// 其他挂载pod在读取后修改了key，事务重试
+	retries := 0
+	err = client.Txn(func(txn KvTxn) error {
+		n := txn.IncrBy([]byte("counter"), 1)
+		if retries == 0 {
+			fake.Lock()
+			kv := fake.kvs["fs-root-a/counter"]
+			kv.ModRevision += 100
+			fake.kvs["fs-root-a/counter"] = kv
+			fake.Unlock()
+		}
+		retries++
+		assert.Equal(t, int64(3), n)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, retries)
+
+	// 迁移到另一个etcd
+	dst, dstFake := newTestEtcd(t, "fs-root-a")
+	copied, err := Copy(client, dst, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, copied)
+	assert.Equal(t, []byte("v3"), dstFake.kvs["fs-root-a/e1"].Value)
+}
+
+func TestPrefixEnd(t *testing.T) {
+	assert.Equal(t, []byte("ab"), prefixEnd([]byte("aa")))
+	assert.Equal(t, []byte("b"), prefixEnd([]byte{'a', 0xff}))
+	assert.Equal(t, []byte{0}, prefixEnd([]byte{0xff}))
+}
+
+func TestRedisReply(t *testing.T) {
+	server, client := net.Pipe()
+	defer client.Close()
+	conn := &redisConn{conn: client, r: bufio.NewReader(client)}
+	go func() {
+		r := bufio.NewReader(server)
+		// 读取请求 *2 $3 GET $1 k
+		for i := 0; i < 5; i++ {
+			r.ReadString('\n')
+		}
+		server.Write([]byte("*3\r\n$2\r\nv1\r\n$-1\r\n:7\r\n"))
+		for i := 0; i < 3; i++ {
+			r.ReadString('\n')
+		}
+		server.Write([]byte("-ERR wrong\r\n"))
+	}()
+	reply, err := conn.do([]byte("GET"), []byte("k"))
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{[]byte("v1"), nil, int64(7)}, reply)
+	_, err = conn.call("PING")
+	assert.Equal(t, redisError("ERR wrong"), err)
+
+	assert.Equal(t, `a\*b\[\]\\`, redisMatchEscape([]byte(`a*b[]\`)))
+	_, err = NewClient(Config{Driver: RedisType, Address: "127.0.0.1:6379"})
+	assert.Error(t, err)
+}
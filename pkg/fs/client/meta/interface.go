@@ -90,6 +90,23 @@ type Config struct {
 	AttrCacheSize      uint64
 	EntryAttrCacheSize uint64
 	PathCacheExpire    time.Duration
+	// NegEntryCacheExpire 不存在的目录项的缓存时间，0表示不缓存
+	NegEntryCacheExpire time.Duration
+	// AtimeMode 文件被读取时atime的更新策略，默认noatime
+	AtimeMode string
+}
+
+const (
+	// AtimeModeNoatime 读取文件时不更新atime
+	AtimeModeNoatime = "noatime"
+	// AtimeModeRelatime atime早于mtime/ctime或超过一天未更新时才更新
+	AtimeModeRelatime = "relatime"
+	// AtimeModeStrictatime 每次读取文件都更新atime
+	AtimeModeStrictatime = "strictatime"
+)
+
+func IsValidAtimeMode(mode string) bool {
+	return mode == "" || mode == AtimeModeNoatime || mode == AtimeModeRelatime || mode == AtimeModeStrictatime
 }
 
 // Meta is a interface for a meta service for file system.
@@ -138,8 +155,8 @@ type Meta interface {
 	// Rename move an entry from a source directory to another with given name.
 	// The targeted entry will be overwrited if it's a file or empty directory.
 	Rename(ctx *Context, parentSrc Ino, nameSrc string, parentDst Ino, nameDst string, flags uint32, inode *Ino, attr *Attr) (string, string, syscall.Errno)
-	// Link creates an entry for node.
-	Link(ctx *Context, inodeSrc, parent Ino, name string, attr *Attr) syscall.Errno
+	// Link creates a hard link of node in a directory with given name.
+	Link(ctx *Context, inodeSrc, parent Ino, name string, inode *Ino, attr *Attr) syscall.Errno
 	// Readdir returns all entries for given directory, which include attributes if plus is true.
 	Readdir(ctx *Context, inode Ino, entries *[]*Entry) syscall.Errno
 	// Create creates a file in a directory with given name.
@@ -81,6 +81,12 @@ type kvMeta struct {
 
 	pathCache   *ristretto.Cache
 	pathTimeOut time.Duration
+
+	// negEntryCache 缓存不存在的目录项，避免重复访问ufs
+	negEntryCache   *ristretto.Cache
+	negEntryTimeOut time.Duration
+
+	atimeMode string
 }
 
 type entryItem struct {
@@ -113,6 +119,7 @@ func newKvMeta(fsMeta common.FSMeta, links map[string]common.FSMeta, config Conf
 		client:       client,
 		attrTimeOut:  config.AttrCacheExpire,
 		entryTimeOut: config.EntryCacheExpire,
+		atimeMode:    config.AtimeMode,
 	}
 	ufs, err := newUFS(fsMeta)
 	if err != nil {
@@ -135,6 +142,18 @@ func newKvMeta(fsMeta common.FSMeta, links map[string]common.FSMeta, config Conf
 		m.pathCache = pathCache
 		m.pathTimeOut = config.PathCacheExpire
 	}
+	if config.NegEntryCacheExpire > 0 {
+		negEntryCache, err := ristretto.NewCache(&ristretto.Config{
+			NumCounters: 1e7,
+			MaxCost:     1 << 20,
+			BufferItems: 64,
+		})
+		if err != nil {
+			return nil, err
+		}
+		m.negEntryCache = negEntryCache
+		m.negEntryTimeOut = config.NegEntryCacheExpire
+	}
 
 	return m, nil
 }
@@ -169,7 +188,7 @@ func newUFS(fsMeta common.FSMeta) (ufslib.UnderFileStorage, error) {
 		properties[common.NameNodeAddress] = fsMeta.ServerAddress
 	case common.HDFSWithKerberosType:
 		properties[common.NameNodeAddress] = fsMeta.ServerAddress
-	case common.SFTPType, common.CFSType, common.GlusterFSType:
+	case common.SFTPType, common.CFSType, common.GlusterFSType, common.NFSType, common.CephFSType:
 		properties[common.Address] = fsMeta.ServerAddress
 	}
 	return ufslib.NewUFS(fsMeta.UfsType, properties)
@@ -179,8 +198,8 @@ func newClient(config kv.Config) (kv.KvClient, error) {
 	var client kv.KvClient
 	var err error
 	switch config.Driver {
-	case kv.DiskType, kv.MemType:
-		client, err = kv.NewBadgerClient(config)
+	case kv.DiskType, kv.MemType, kv.RedisType, kv.EtcdType:
+		client, err = kv.NewClient(config)
 	default:
 		return nil, fmt.Errorf("unknown meta client")
 	}
@@ -462,6 +481,29 @@ func (m *kvMeta) getPathCache(inode Ino) (interface{}, bool) {
 	return m.pathCache.Get(m.pathKey(inode))
 }
 
+func (m *kvMeta) setNegEntryCache(parent Ino, name string) {
+	if m.negEntryCache == nil {
+		return
+	}
+	m.negEntryCache.SetWithTTL(string(m.entryKey(parent, name)), true, 1, m.negEntryTimeOut)
+}
+
+// delNegEntryCache 本地创建文件或目录后使对应的负缓存失效
+func (m *kvMeta) delNegEntryCache(parent Ino, name string) {
+	if m.negEntryCache == nil {
+		return
+	}
+	m.negEntryCache.Del(string(m.entryKey(parent, name)))
+}
+
+func (m *kvMeta) isNegEntryCached(parent Ino, name string) bool {
+	if m.negEntryCache == nil {
+		return false
+	}
+	_, ok := m.negEntryCache.Get(string(m.entryKey(parent, name)))
+	return ok
+}
+
 // absolutePath by inode, and ensure that the absolute path is obtained in the same transaction
 func (m *kvMeta) absolutePath(inode Ino, tx kv.KvTxn) string {
 	var builder strings.Builder
@@ -604,6 +646,10 @@ func (m *kvMeta) Lookup(ctx *Context, parent Ino, name string) (Ino, *Attr, sysc
 	if err != nil {
 		return 0, nil, syscall.EIO
 	}
+	// 本地已有的目录项优先于负缓存
+	if entry == nil && m.isNegEntryCached(parent, name) {
+		return 0, nil, syscall.ENOENT
+	}
 	var inode Ino
 	attr := &Attr{}
 	inodeItem_ := &inodeItem{}
@@ -627,6 +673,9 @@ func (m *kvMeta) Lookup(ctx *Context, parent Ino, name string) (Ino, *Attr, sysc
 		now := time.Now()
 		if err != nil {
 			log.Debugf("[vfs-lookup] Lookup GetAttr failed: %v with path[%s] name[%s] and absolutePath[%s]", err, path, name, absolutePath)
+			if utils.IfNotExist(err) {
+				m.setNegEntryCache(parent, name)
+			}
 			return err
 		}
 		if isLink {
@@ -725,6 +774,8 @@ func (m *kvMeta) SetAttr(ctx *Context, inode Ino, set uint32, attr *Attr) (strin
 	var isLink bool
 	var prefix string
 	var path string
+	req := *attr
+	setTime := set&(FATTR_ATIME|FATTR_MTIME|FATTR_ATIME_NOW|FATTR_MTIME_NOW|FATTR_CTIME) != 0
 	err := m.txn(func(tx kv.KvTxn) error {
 		absolutePath = m.absolutePath(inode, tx)
 		ufs_, isLink, prefix, path = m.GetUFS(absolutePath)
@@ -736,30 +787,35 @@ func (m *kvMeta) SetAttr(ctx *Context, inode Ino, set uint32, attr *Attr) (strin
 			if isLink {
 				ufsAttr.FixLinkPrefix(prefix)
 			}
-			attr.FromFileInfo(ufsAttr)
-			cur.attr = *attr
-		} else {
-			*attr = cur.attr
+			cur.attr.FromFileInfo(ufsAttr)
 		}
 		if set&FATTR_UID != 0 || set&FATTR_GID != 0 {
 			log.Debugf("set uid %+v", set)
-			cur.attr.Uid = attr.Uid
-			cur.attr.Gid = attr.Gid
+			cur.attr.Uid = req.Uid
+			cur.attr.Gid = req.Gid
 		}
 		if set&FATTR_MODE != 0 {
 			log.Debugf("set mode %+v", set)
-			cur.attr.Mode = attr.Mode
+			cur.attr.Mode = cur.attr.Mode&syscall.S_IFMT | req.Mode&^syscall.S_IFMT
 		}
-		if set&FATTR_ATIME != 0 || set&FATTR_MTIME != 0 || set&FATTR_CTIME != 0 {
+		if setTime {
 			log.Debugf("set time %+v", set)
-			cur.attr.Atime = attr.Atime
-			cur.attr.Atimensec = attr.Atimensec
-			cur.attr.Ctime = attr.Ctime
-			cur.attr.Ctimensec = attr.Ctimensec
+			now := time.Now()
+			if set&FATTR_ATIME_NOW != 0 {
+				cur.attr.Atime, cur.attr.Atimensec = now.Unix(), uint32(now.Nanosecond())
+			} else if set&FATTR_ATIME != 0 {
+				cur.attr.Atime, cur.attr.Atimensec = req.Atime, req.Atimensec
+			}
+			if set&FATTR_MTIME_NOW != 0 {
+				cur.attr.Mtime, cur.attr.Mtimensec = now.Unix(), uint32(now.Nanosecond())
+			} else if set&FATTR_MTIME != 0 {
+				cur.attr.Mtime, cur.attr.Mtimensec = req.Mtime, req.Mtimensec
+			}
+			cur.attr.Ctime, cur.attr.Ctimensec = now.Unix(), uint32(now.Nanosecond())
 		}
 		if set&FATTR_SIZE != 0 {
 			log.Debugf("set size %+v", set)
-			cur.attr.Size = attr.Size
+			cur.attr.Size = req.Size
 		}
 		log.Debugf("set attr info is %+v", cur)
 		err := tx.Set(m.inodeKey(inode), m.marshalInode(&cur))
@@ -768,35 +824,35 @@ func (m *kvMeta) SetAttr(ctx *Context, inode Ino, set uint32, attr *Attr) (strin
 		}
 		return nil
 	})
+	if err != nil {
+		return "", utils.ToSyscallErrno(err)
+	}
+	*attr = cur.attr
 	if set&FATTR_UID != 0 || set&FATTR_GID != 0 {
-		if err = ufs_.Chown(path, attr.Uid, attr.Gid); err != nil {
+		if err = ufs_.Chown(path, cur.attr.Uid, cur.attr.Gid); err != nil {
 			return "", utils.ToSyscallErrno(err)
 		}
 	}
 
 	if set&FATTR_MODE != 0 {
-		if err = ufs_.Chmod(path, attr.Mode); err != nil {
+		if err = ufs_.Chmod(path, cur.attr.Mode&07777); err != nil {
 			return "", utils.ToSyscallErrno(err)
 		}
 	}
 	// s3未实现utimes函数，创建文件时存在报错：setting times of ‘xx’: Function not implemented。因此这里忽略enosys报错
-	if set&FATTR_ATIME != 0 || set&FATTR_MTIME != 0 || set&FATTR_CTIME != 0 {
-		atime := time.Unix(attr.Atime, int64(attr.Atimensec))
-		ctime := time.Unix(attr.Ctime, int64(attr.Ctimensec))
-		if err = ufs_.Utimens(path, &atime, &ctime); err != nil {
+	if setTime {
+		atime := time.Unix(cur.attr.Atime, int64(cur.attr.Atimensec))
+		mtime := time.Unix(cur.attr.Mtime, int64(cur.attr.Mtimensec))
+		if err = ufs_.Utimens(path, &atime, &mtime); err != nil {
 			return "", utils.ToSyscallErrno(err)
 		}
 	}
 
 	if set&FATTR_SIZE != 0 {
-		if err = ufs_.Truncate(path, attr.Size); err != nil {
+		if err = ufs_.Truncate(path, cur.attr.Size); err != nil {
 			return "", utils.ToSyscallErrno(err)
 		}
 	}
-
-	if err != nil {
-		return "", utils.ToSyscallErrno(err)
-	}
 	m.setPathCache(inode, &cur)
 	return absolutePath, syscall.F_OK
 }
@@ -818,6 +874,7 @@ func (m *kvMeta) Symlink(ctx *Context, parent Ino, name string, path string, ino
 }
 
 func (m *kvMeta) Mknod(ctx *Context, parent Ino, name string, _type uint8, mode, cumask uint32, rdev uint32, inode *Ino, attr *Attr) syscall.Errno {
+	defer m.delNegEntryCache(parent, name)
 	insertInodeItem_ := &inodeItem{}
 	if attr == nil {
 		attr = &Attr{}
@@ -897,6 +954,7 @@ func (m *kvMeta) Mknod(ctx *Context, parent Ino, name string, _type uint8, mode,
 }
 
 func (m *kvMeta) Mkdir(ctx *Context, parent Ino, name string, mode uint32, cumask uint16, inode *Ino, attr *Attr) syscall.Errno {
+	defer m.delNegEntryCache(parent, name)
 	insertInodeItem_ := &inodeItem{}
 	if attr == nil {
 		attr = &Attr{}
@@ -1089,6 +1147,7 @@ func (m *kvMeta) Rmdir(ctx *Context, parent Ino, name string) syscall.Errno {
 }
 
 func (m *kvMeta) Rename(ctx *Context, parentSrc Ino, nameSrc string, parentDst Ino, nameDst string, flags uint32, inode *Ino, attr *Attr) (string, string, syscall.Errno) {
+	defer m.delNegEntryCache(parentDst, nameDst)
 	var pathDst string
 	var pathSrc string
 	srcAttr := &inodeItem{}
@@ -1171,8 +1230,104 @@ func (m *kvMeta) Rename(ctx *Context, parentSrc Ino, nameSrc string, parentDst I
 	return pathSrc, pathDst, syscall.F_OK
 }
 
-func (m *kvMeta) Link(ctx *Context, inodeSrc, parent Ino, name string, attr *Attr) syscall.Errno {
-	return syscall.ENOSYS
+func (m *kvMeta) Link(ctx *Context, inodeSrc, parent Ino, name string, inode *Ino, attr *Attr) syscall.Errno {
+	log.Debugf("kv meta link inode[%v] to parent[%v] name[%s]", inodeSrc, parent, name)
+	defer m.delNegEntryCache(parent, name)
+	var srcPath, dstPath string
+	err := m.txn(func(tx kv.KvTxn) error {
+		a := tx.Get(m.inodeKey(parent))
+		if a == nil {
+			return syscall.ENOENT
+		}
+		var pInodeItem inodeItem
+		m.parseInode(a, &pInodeItem)
+		if pInodeItem.attr.Type != TypeDirectory {
+			return syscall.ENOTDIR
+		}
+		if tx.Get(m.inodeKey(inodeSrc)) == nil {
+			return syscall.ENOENT
+		}
+		if tx.Get(m.entryKey(parent, name)) != nil {
+			return syscall.EEXIST
+		}
+		srcPath = m.absolutePath(inodeSrc, tx)
+		dstPath = filepath.Join(m.absolutePath(parent, tx), name)
+		return nil
+	})
+	if err != nil {
+		return utils.ToSyscallErrno(err)
+	}
+	_, _, srcPrefix, src := m.GetUFS(srcPath)
+	ufs_, isLink, prefix, dst := m.GetUFS(dstPath)
+	// 硬链接不能跨越不同的存储
+	if srcPrefix != prefix {
+		return syscall.EXDEV
+	}
+	if err = ufs_.Link(src, dst); err != nil {
+		log.Errorf("kv meta link src[%s] dst[%s] err %v", srcPath, dstPath, err)
+		return utils.ToSyscallErrno(err)
+	}
+	info, err := ufs_.GetAttr(dst)
+	if err != nil {
+		return utils.ToSyscallErrno(err)
+	}
+	if isLink {
+		info.FixLinkPrefix(prefix)
+	}
+	attr.FromFileInfo(info)
+
+	ino, err := m.nextInode()
+	if err != nil {
+		return utils.ToSyscallErrno(err)
+	}
+	*inode = ino
+	now := time.Now()
+	insertInodeItem_ := &inodeItem{
+		attr:      *attr,
+		parentIno: parent,
+		name:      []byte(name),
+		expire:    now.Add(m.attrTimeOut).Unix(),
+	}
+	err = m.txn(func(tx kv.KvTxn) error {
+		a := tx.Get(m.inodeKey(parent))
+		if a == nil {
+			return syscall.ENOENT
+		}
+		var pInodeItem inodeItem
+		m.parseInode(a, &pInodeItem)
+		pInodeItem.attr.Mtime = now.Unix()
+		pInodeItem.attr.Mtimensec = uint32(now.Nanosecond())
+		pInodeItem.attr.Ctime = now.Unix()
+		pInodeItem.attr.Ctimensec = uint32(now.Nanosecond())
+		if err := tx.Set(m.inodeKey(parent), m.marshalInode(&pInodeItem)); err != nil {
+			return err
+		}
+		// 源文件与新文件共享数据，链接数与ctime保持一致
+		if buf := tx.Get(m.inodeKey(inodeSrc)); buf != nil {
+			srcInodeItem := &inodeItem{}
+			m.parseInode(buf, srcInodeItem)
+			srcInodeItem.attr.Nlink = attr.Nlink
+			srcInodeItem.attr.Ctime = attr.Ctime
+			srcInodeItem.attr.Ctimensec = attr.Ctimensec
+			if err := tx.Set(m.inodeKey(inodeSrc), m.marshalInode(srcInodeItem)); err != nil {
+				return err
+			}
+		}
+		insertEntryItem_ := &entryItem{
+			ino:  ino,
+			mode: attr.Mode,
+		}
+		if err := tx.Set(m.entryKey(parent, name), m.marshalEntry(insertEntryItem_)); err != nil {
+			return err
+		}
+		return tx.Set(m.inodeKey(ino), m.marshalInode(insertInodeItem_))
+	})
+	if err != nil {
+		return utils.ToSyscallErrno(err)
+	}
+	m.delsPathCache(inodeSrc)
+	m.setPathCache(ino, insertInodeItem_)
+	return syscall.F_OK
 }
 
 func (m *kvMeta) Readdir(ctx *Context, inode Ino, entries *[]*Entry) syscall.Errno {
@@ -1212,6 +1367,11 @@ func (m *kvMeta) Readdir(ctx *Context, inode Ino, entries *[]*Entry) syscall.Err
 					Name: name[prefix:],
 					Attr: &Attr{Mode: childEntryItem.mode},
 				}
+				// 属性缓存未过期时返回完整属性，readdirplus可直接回复内核，避免逐个lookup
+				childInodeItem := &inodeItem{}
+				if m.getAttrFromCacheWithNoExpired(childEntryItem.ino, childInodeItem) {
+					*en.Attr = childInodeItem.attr
+				}
 				*entries = append(*entries, en)
 			}
 			return syscall.F_OK
@@ -1353,6 +1513,7 @@ func (m *kvMeta) Readdir(ctx *Context, inode Ino, entries *[]*Entry) syscall.Err
 
 func (m *kvMeta) Create(ctx *Context, parent Ino, name string, mode uint32, cumask uint16, flags uint32, inode *Ino, attr *Attr) (ufslib.UnderFileStorage, string, syscall.Errno) {
 	log.Debugf("kv meta create parent[%v] name[%s]", parent, name)
+	defer m.delNegEntryCache(parent, name)
 	ino, err := m.nextInode()
 	*inode = ino
 	if err != nil {
@@ -1459,6 +1620,7 @@ func (m *kvMeta) Open(ctx *Context, inode Ino, flags uint32, attr *Attr) (ufslib
 	var newPath string
 	var isLink bool
 	var prefix string
+	var touchAtime bool
 	err := m.txn(func(tx kv.KvTxn) error {
 		absolutePath := m.absolutePath(inode, tx)
 		ufs_, isLink, prefix, newPath = m.GetUFS(absolutePath)
@@ -1468,6 +1630,7 @@ func (m *kvMeta) Open(ctx *Context, inode Ino, flags uint32, attr *Attr) (ufslib
 			m.parseInode(a, inodeItem_)
 			if !m.inodeItemExpired(*inodeItem_) {
 				log.Debugf("open inodeItem cache %+v and attr %+v", *inodeItem_, inodeItem_.attr)
+				touchAtime = m.updateAtime(flags, &inodeItem_.attr, time.Now())
 				*attr = inodeItem_.attr
 				inodeItem_.fileHandles += 1
 				err := tx.Set(m.inodeKey(inode), m.marshalInode(inodeItem_))
@@ -1490,6 +1653,7 @@ func (m *kvMeta) Open(ctx *Context, inode Ino, flags uint32, attr *Attr) (ufslib
 		now := time.Now()
 		attr.FromFileInfo(info)
 		m.modifyTime(&(inodeItem_.attr), attr)
+		touchAtime = m.updateAtime(flags, attr, now)
 		inodeItem_.attr = *attr
 		inodeItem_.expire = now.Add(m.attrTimeOut).Unix()
 		inodeItem_.fileHandles += 1
@@ -1499,10 +1663,40 @@ func (m *kvMeta) Open(ctx *Context, inode Ino, flags uint32, attr *Attr) (ufslib
 	if err != nil {
 		return nil, "", utils.ToSyscallErrno(err)
 	}
+	if touchAtime {
+		atime := time.Unix(attr.Atime, int64(attr.Atimensec))
+		mtime := time.Unix(attr.Mtime, int64(attr.Mtimensec))
+		// 更新后端atime失败不影响打开文件
+		if err = ufs_.Utimens(newPath, &atime, &mtime); err != nil {
+			log.Debugf("kv meta open: update atime of path[%s] failed: %v", newPath, err)
+		}
+	}
 	m.setPathCache(inode, inodeItem_)
 	return ufs_, newPath, syscall.F_OK
 }
 
+// updateAtime 以读方式打开文件时按atime策略更新attr中的atime，返回是否发生了更新
+func (m *kvMeta) updateAtime(flags uint32, attr *Attr, now time.Time) bool {
+	if flags&syscall.O_ACCMODE == syscall.O_WRONLY || attr.Type == TypeDirectory {
+		return false
+	}
+	atime := time.Unix(attr.Atime, int64(attr.Atimensec))
+	switch m.atimeMode {
+	case AtimeModeStrictatime:
+	case AtimeModeRelatime:
+		mtime := time.Unix(attr.Mtime, int64(attr.Mtimensec))
+		ctime := time.Unix(attr.Ctime, int64(attr.Ctimensec))
+		if atime.After(mtime) && atime.After(ctime) && now.Sub(atime) < 24*time.Hour {
+			return false
+		}
+	default:
+		return false
+	}
+	attr.Atime = now.Unix()
+	attr.Atimensec = uint32(now.Nanosecond())
+	return true
+}
+
 func (m *kvMeta) Close(ctx *Context, inode Ino) syscall.Errno {
 	err := m.txn(func(tx kv.KvTxn) error {
 		updateInodeItem := &inodeItem{}
@@ -1547,19 +1741,62 @@ func (m *kvMeta) CopyFileRange(ctx *Context, fin Ino, offIn uint64, fout Ino, of
 }
 
 func (m *kvMeta) GetXattr(ctx *Context, inode Ino, attribute string, vbuff *[]byte) syscall.Errno {
-	return syscall.ENOSYS
+	ufs_, path, errno := m.inodeUFS(inode)
+	if utils.IsError(errno) {
+		return errno
+	}
+	value, err := ufs_.GetXAttr(path, attribute)
+	if err != nil {
+		return utils.ToSyscallErrno(err)
+	}
+	*vbuff = value
+	return syscall.F_OK
 }
 
 func (m *kvMeta) ListXattr(ctx *Context, inode Ino, dbuff *[]string) syscall.Errno {
-	return syscall.ENOSYS
+	ufs_, path, errno := m.inodeUFS(inode)
+	if utils.IsError(errno) {
+		return errno
+	}
+	attrs, err := ufs_.ListXAttr(path)
+	if err != nil {
+		return utils.ToSyscallErrno(err)
+	}
+	*dbuff = attrs
+	return syscall.F_OK
 }
 
 func (m *kvMeta) SetXattr(ctx *Context, inode Ino, name string, value []byte, flags uint32) syscall.Errno {
-	return syscall.ENOSYS
+	ufs_, path, errno := m.inodeUFS(inode)
+	if utils.IsError(errno) {
+		return errno
+	}
+	return utils.ToSyscallErrno(ufs_.SetXAttr(path, name, value, int(flags)))
 }
 
 func (m *kvMeta) RemoveXattr(ctx *Context, inode Ino, name string) syscall.Errno {
-	return syscall.ENOSYS
+	ufs_, path, errno := m.inodeUFS(inode)
+	if utils.IsError(errno) {
+		return errno
+	}
+	return utils.ToSyscallErrno(ufs_.RemoveXAttr(path, name))
+}
+
+// inodeUFS 返回inode所在的ufs及其在ufs中的路径
+func (m *kvMeta) inodeUFS(inode Ino) (ufslib.UnderFileStorage, string, syscall.Errno) {
+	var absolutePath string
+	err := m.txn(func(tx kv.KvTxn) error {
+		if tx.Get(m.inodeKey(inode)) == nil {
+			return syscall.ENOENT
+		}
+		absolutePath = m.absolutePath(inode, tx)
+		return nil
+	})
+	if err != nil {
+		return nil, "", utils.ToSyscallErrno(err)
+	}
+	ufs_, _, _, path := m.GetUFS(absolutePath)
+	return ufs_, path, syscall.F_OK
 }
 
 func (m *kvMeta) Flock(ctx *Context, inode Ino, owner uint64, ltype uint32, block bool) syscall.Errno {
@@ -0,0 +1,437 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ufs
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	fsCommon "github.com/PaddlePaddle/PaddleFlow/pkg/fs/common"
+)
+
+const (
+	ABSAPIVersion       = "2020-10-02"
+	ABSEndpointTemplate = "https://%s.blob.core.windows.net"
+	// block blob: 单个文件最多50000个block
+	ABSMaxBlockNum       = 50000
+	ABSCopyPollInterval  = 500 * time.Millisecond
+	absCopyStatusPending = "pending"
+	absCopyStatusSuccess = "success"
+	absBlobTypeBlockBlob = "BlockBlob"
+	absHeaderErrorCode   = "x-ms-error-code"
+	absHeaderCopyStatus  = "x-ms-copy-status"
+	absHeaderRange       = "x-ms-range"
+)
+
+// absClient 基于Azure Blob REST API的最小客户端，支持SharedKey与SAS两种认证方式
+type absClient struct {
+	endpoint   string
+	account    string
+	accountKey []byte
+	sasToken   url.Values
+	container  string
+	httpClient *http.Client
+}
+
+type absBlob struct {
+	Name       string `xml:"Name"`
+	Properties struct {
+		LastModified  string `xml:"Last-Modified"`
+		ContentLength int64  `xml:"Content-Length"`
+	} `xml:"Properties"`
+}
+
+type absListResult struct {
+	XMLName xml.Name `xml:"EnumerationResults"`
+	Blobs   struct {
+		Blob       []absBlob `xml:"Blob"`
+		BlobPrefix []struct {
+			Name string `xml:"Name"`
+		} `xml:"BlobPrefix"`
+	} `xml:"Blobs"`
+	NextMarker string `xml:"NextMarker"`
+}
+
+type absBlockList struct {
+	XMLName xml.Name `xml:"BlockList"`
+	Latest  []string `xml:"Latest"`
+}
+
+func newABSClient(endpoint, account, accountKey, sasToken, container string) (*absClient, error) {
+	client := &absClient{
+		endpoint:   strings.TrimSuffix(endpoint, Delimiter),
+		account:    account,
+		container:  container,
+		httpClient: &http.Client{Timeout: objectHTTPTimeout},
+	}
+	if accountKey != "" {
+		key, err := base64.StdEncoding.DecodeString(accountKey)
+		if err != nil {
+			return nil, fmt.Errorf("decode azure account key failed: %v", err)
+		}
+		client.accountKey = key
+	} else if sasToken != "" {
+		values, err := url.ParseQuery(strings.TrimPrefix(sasToken, "?"))
+		if err != nil {
+			return nil, fmt.Errorf("parse azure sas token failed: %v", err)
+		}
+		client.sasToken = values
+	} else {
+		return nil, fmt.Errorf("azure blob %s or %s must be provided", fsCommon.AccountKey, fsCommon.SASToken)
+	}
+	return client, nil
+}
+
+func (c *absClient) escapedPath(blob string) string {
+	u := &url.URL{Path: Delimiter + c.container}
+	if blob != "" {
+		u.Path += Delimiter + blob
+	}
+	return u.EscapedPath()
+}
+
+// sign 按照SharedKey规则计算签名 https://learn.microsoft.com/rest/api/storageservices/authorize-with-shared-key
+func (c *absClient) sign(method, escapedPath string, query url.Values, header http.Header) string {
+	contentLength := header.Get("Content-Length")
+	if contentLength == "0" {
+		contentLength = ""
+	}
+	var msHeaders []string
+	for key := range header {
+		lowerKey := strings.ToLower(key)
+		if strings.HasPrefix(lowerKey, "x-ms-") {
+			msHeaders = append(msHeaders, lowerKey+":"+strings.TrimSpace(header.Get(key)))
+		}
+	}
+	sort.Strings(msHeaders)
+
+	var resource strings.Builder
+	resource.WriteString(Delimiter + c.account + escapedPath)
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		values := append([]string{}, query[key]...)
+		sort.Strings(values)
+		resource.WriteString("\n" + strings.ToLower(key) + ":" + strings.Join(values, ","))
+	}
+
+	stringToSign := strings.Join([]string{
+		method,
+		header.Get("Content-Encoding"),
+		header.Get("Content-Language"),
+		contentLength,
+		header.Get("Content-MD5"),
+		header.Get("Content-Type"),
+		"", // Date, use x-ms-date instead
+		header.Get("If-Modified-Since"),
+		header.Get("If-Match"),
+		header.Get("If-None-Match"),
+		header.Get("If-Unmodified-Since"),
+		header.Get("Range"),
+	}, "\n") + "\n"
+	for _, h := range msHeaders {
+		stringToSign += h + "\n"
+	}
+	stringToSign += resource.String()
+
+	mac := hmac.New(sha256.New, c.accountKey)
+	mac.Write([]byte(stringToSign))
+	return fmt.Sprintf("SharedKey %s:%s", c.account, base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+}
+
+func (c *absClient) do(method, blob string, query url.Values, header http.Header, body []byte) (*http.Response, error) {
+	if query == nil {
+		query = url.Values{}
+	}
+	if header == nil {
+		header = http.Header{}
+	}
+	escapedPath := c.escapedPath(blob)
+	header.Set("x-ms-date", time.Now().UTC().Format(http.TimeFormat))
+	header.Set("x-ms-version", ABSAPIVersion)
+	header.Set("Content-Length", strconv.Itoa(len(body)))
+
+	fullQuery := url.Values{}
+	for key, values := range query {
+		fullQuery[key] = values
+	}
+	if c.accountKey != nil {
+		header.Set("Authorization", c.sign(method, escapedPath, query, header))
+	} else {
+		for key, values := range c.sasToken {
+			fullQuery[key] = values
+		}
+	}
+
+	reqURL := c.endpoint + escapedPath
+	if encoded := fullQuery.Encode(); encoded != "" {
+		reqURL += "?" + encoded
+	}
+	req, err := http.NewRequest(method, reqURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header = header
+	req.ContentLength = int64(len(body))
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+		return nil, &objectStorageError{StatusCode: resp.StatusCode, Code: resp.Header.Get(absHeaderErrorCode)}
+	}
+	return resp, nil
+}
+
+func (c *absClient) doAndClose(method, blob string, query url.Values, header http.Header, body []byte) (http.Header, error) {
+	resp, err := c.do(method, blob, query, header, body)
+	if err != nil {
+		return nil, err
+	}
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+	return resp.Header, nil
+}
+
+func (c *absClient) containerExists() (bool, error) {
+	_, err := c.doAndClose(http.MethodHead, "", url.Values{"restype": {"container"}}, nil, nil)
+	if err != nil {
+		if isObjectNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// listBlobs 服务端分页，marker为空表示第一页，返回的NextMarker为空表示没有下一页
+func (c *absClient) listBlobs(prefix, delimiter, marker string, maxResults int) (*absListResult, error) {
+	query := url.Values{
+		"restype":    {"container"},
+		"comp":       {"list"},
+		"maxresults": {strconv.Itoa(maxResults)},
+	}
+	if prefix != "" {
+		query.Set("prefix", prefix)
+	}
+	if delimiter != "" {
+		query.Set("delimiter", delimiter)
+	}
+	if marker != "" {
+		query.Set("marker", marker)
+	}
+	resp, err := c.do(http.MethodGet, "", query, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	result := &absListResult{}
+	if err := xml.NewDecoder(resp.Body).Decode(result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *absClient) getProperties(blob string) (size int64, mtime time.Time, err error) {
+	header, err := c.doAndClose(http.MethodHead, blob, nil, nil, nil)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	size, _ = strconv.ParseInt(header.Get("Content-Length"), 10, 64)
+	mtime, _ = http.ParseTime(header.Get("Last-Modified"))
+	return size, mtime, nil
+}
+
+func (c *absClient) getBlob(blob string, off, limit int64) (io.ReadCloser, error) {
+	header := http.Header{}
+	if limit > 0 {
+		header.Set(absHeaderRange, fmt.Sprintf("bytes=%d-%d", off, off+limit-1))
+	} else if off > 0 {
+		header.Set(absHeaderRange, fmt.Sprintf("bytes=%d-", off))
+	}
+	resp, err := c.do(http.MethodGet, blob, nil, header, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func (c *absClient) putBlob(blob string, data []byte) error {
+	header := http.Header{}
+	header.Set("x-ms-blob-type", absBlobTypeBlockBlob)
+	_, err := c.doAndClose(http.MethodPut, blob, nil, header, data)
+	return err
+}
+
+func (c *absClient) putBlock(blob, blockID string, data []byte) error {
+	query := url.Values{"comp": {"block"}, "blockid": {blockID}}
+	_, err := c.doAndClose(http.MethodPut, blob, query, nil, data)
+	return err
+}
+
+func (c *absClient) putBlockList(blob string, blockIDs []string) error {
+	body, err := xml.Marshal(absBlockList{Latest: blockIDs})
+	if err != nil {
+		return err
+	}
+	body = append([]byte(xml.Header), body...)
+	_, err = c.doAndClose(http.MethodPut, blob, url.Values{"comp": {"blocklist"}}, nil, body)
+	return err
+}
+
+// copyBlob 同一存储账户内的拷贝通常同步完成，pending时轮询直到拷贝结束
+func (c *absClient) copyBlob(src, dst string) error {
+	source := c.endpoint + c.escapedPath(src)
+	if c.sasToken != nil {
+		source += "?" + c.sasToken.Encode()
+	}
+	header := http.Header{}
+	header.Set("x-ms-copy-source", source)
+	respHeader, err := c.doAndClose(http.MethodPut, dst, nil, header, nil)
+	if err != nil {
+		return err
+	}
+	status := respHeader.Get(absHeaderCopyStatus)
+	for status == absCopyStatusPending {
+		time.Sleep(ABSCopyPollInterval)
+		respHeader, err = c.doAndClose(http.MethodHead, dst, nil, nil, nil)
+		if err != nil {
+			return err
+		}
+		status = respHeader.Get(absHeaderCopyStatus)
+	}
+	if status != "" && status != absCopyStatusSuccess {
+		return fmt.Errorf("azure blob copy [%s] -> [%s] status: %s", src, dst, status)
+	}
+	return nil
+}
+
+func (c *absClient) deleteBlob(blob string) error {
+	_, err := c.doAndClose(http.MethodDelete, blob, nil, nil, nil)
+	return err
+}
+
+var _ objectClient = &absClient{}
+
+func (c *absClient) bucketExists() (bool, error) {
+	return c.containerExists()
+}
+
+func (c *absClient) list(prefix, delimiter, marker string, maxKeys int) (*objectListResult, error) {
+	blobs, err := c.listBlobs(prefix, delimiter, marker, maxKeys)
+	if err != nil {
+		return nil, err
+	}
+	result := &objectListResult{NextMarker: blobs.NextMarker}
+	for _, blob := range blobs.Blobs.Blob {
+		mtime, _ := http.ParseTime(blob.Properties.LastModified)
+		result.Objects = append(result.Objects, objectInfo{Key: blob.Name, Size: blob.Properties.ContentLength, Mtime: mtime})
+	}
+	for _, dir := range blobs.Blobs.BlobPrefix {
+		result.Prefixes = append(result.Prefixes, dir.Name)
+	}
+	return result, nil
+}
+
+func (c *absClient) head(key string) (int64, time.Time, error) {
+	return c.getProperties(key)
+}
+
+func (c *absClient) get(key string, off, limit int64) (io.ReadCloser, error) {
+	return c.getBlob(key, off, limit)
+}
+
+func (c *absClient) put(key string, data []byte) error {
+	return c.putBlob(key, data)
+}
+
+func (c *absClient) copy(src, dst string) error {
+	return c.copyBlob(src, dst)
+}
+
+func (c *absClient) delete(key string) error {
+	return c.deleteBlob(key)
+}
+
+func (c *absClient) maxParts() int64 {
+	return ABSMaxBlockNum
+}
+
+// createMultipart block blob不需要初始化，未提交的block由服务端自动回收
+func (c *absClient) createMultipart(key string) (string, error) {
+	return "", nil
+}
+
+func (c *absClient) uploadPart(key, uploadID string, partNum int64, data []byte) (string, error) {
+	// block id需要等长
+	blockID := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%08d", partNum)))
+	if err := c.putBlock(key, blockID, data); err != nil {
+		return "", err
+	}
+	return blockID, nil
+}
+
+func (c *absClient) completeMultipart(key, uploadID string, partIDs []string) error {
+	return c.putBlockList(key, partIDs)
+}
+
+func (c *absClient) abortMultipart(key, uploadID string) error {
+	return nil
+}
+
+func NewABSFileSystem(properties map[string]interface{}) (UnderFileStorage, error) {
+	account := propertyString(properties, fsCommon.AccountName)
+	container := strings.TrimSuffix(propertyString(properties, fsCommon.Bucket), Delimiter)
+	if account == "" || container == "" {
+		return nil, fmt.Errorf("azure blob %s and container must be provided", fsCommon.AccountName)
+	}
+	endpoint := propertyString(properties, fsCommon.Endpoint)
+	if endpoint == "" {
+		endpoint = fmt.Sprintf(ABSEndpointTemplate, account)
+	}
+	client, err := newABSClient(endpoint, account, decryptSecret(propertyString(properties, fsCommon.AccountKey)),
+		decryptSecret(propertyString(properties, fsCommon.SASToken)), container)
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("new abs fs endpoint[%s] account[%s] container[%s] subPath[%s]", endpoint, account, container,
+		propertyString(properties, fsCommon.SubPath))
+	return newObjectFileSystem(fsCommon.ABSType, client, properties)
+}
+
+func init() {
+	RegisterUFS(fsCommon.ABSType, NewABSFileSystem)
+}
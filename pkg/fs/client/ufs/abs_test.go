@@ -0,0 +1,255 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ufs
+
+import (
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/common"
+)
+
+const (
+	mockABSAccount   = "account"
+	mockABSContainer = "container"
+)
+
+// mockABSServer 内存中模拟azure blob的REST接口
+type mockABSServer struct {
+	sync.Mutex
+	blobs  map[string][]byte
+	blocks map[string][]byte
+}
+
+func (m *mockABSServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.Lock()
+	defer m.Unlock()
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "SharedKey "+mockABSAccount+":") {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	query := r.URL.Query()
+	blob := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/"+mockABSContainer), "/")
+	switch {
+	case query.Get("restype") == "container" && query.Get("comp") == "list":
+		m.list(w, query)
+	case query.Get("restype") == "container":
+		w.WriteHeader(http.StatusOK)
+	case r.Method == http.MethodPut && query.Get("comp") == "block":
+		data, _ := ioutil.ReadAll(r.Body)
+		m.blocks[blob+query.Get("blockid")] = data
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == http.MethodPut && query.Get("comp") == "blocklist":
+		var list absBlockList
+		body, _ := ioutil.ReadAll(r.Body)
+		xml.Unmarshal(body, &list)
+		var data []byte
+		for _, id := range list.Latest {
+			data = append(data, m.blocks[blob+id]...)
+		}
+		m.blobs[blob] = data
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == http.MethodPut && r.Header.Get("x-ms-copy-source") != "":
+		src := r.Header.Get("x-ms-copy-source")
+		src = strings.TrimPrefix(src[strings.Index(src, "/"+mockABSContainer+"/"):], "/"+mockABSContainer+"/")
+		m.blobs[blob] = m.blobs[src]
+		w.Header().Set(absHeaderCopyStatus, absCopyStatusSuccess)
+		w.WriteHeader(http.StatusAccepted)
+	case r.Method == http.MethodPut:
+		data, _ := ioutil.ReadAll(r.Body)
+		m.blobs[blob] = data
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == http.MethodHead || r.Method == http.MethodGet:
+		data, ok := m.blobs[blob]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if rng := r.Header.Get(absHeaderRange); rng != "" {
+			var start, end int
+			fmt.Sscanf(rng, "bytes=%d-%d", &start, &end)
+			data = data[start : end+1]
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+		w.WriteHeader(http.StatusOK)
+		if r.Method == http.MethodGet {
+			w.Write(data)
+		}
+	case r.Method == http.MethodDelete:
+		if _, ok := m.blobs[blob]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		delete(m.blobs, blob)
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+func (m *mockABSServer) list(w http.ResponseWriter, query url.Values) {
+	prefix, delimiter := query.Get("prefix"), query.Get("delimiter")
+	maxResults, _ := strconv.Atoi(query.Get("maxresults"))
+	start, _ := strconv.Atoi(query.Get("marker"))
+
+	names := make([]string, 0)
+	seen := map[string]bool{}
+	for name := range m.blobs {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		if delimiter != "" {
+			if idx := strings.Index(name[len(prefix):], delimiter); idx >= 0 {
+				name = name[:len(prefix)+idx+1]
+			}
+		}
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	result := absListResult{}
+	end := start + maxResults
+	if end < len(names) {
+		result.NextMarker = strconv.Itoa(end)
+	} else {
+		end = len(names)
+	}
+	for _, name := range names[start:end] {
+		if delimiter != "" && strings.HasSuffix(name, delimiter) && name != prefix {
+			result.Blobs.BlobPrefix = append(result.Blobs.BlobPrefix, struct {
+				Name string `xml:"Name"`
+			}{Name: name})
+			continue
+		}
+		blob := absBlob{Name: name}
+		blob.Properties.ContentLength = int64(len(m.blobs[name]))
+		blob.Properties.LastModified = time.Now().UTC().Format(http.TimeFormat)
+		result.Blobs.Blob = append(result.Blobs.Blob, blob)
+	}
+	data, _ := xml.Marshal(result)
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+func newMockABSFileSystem(t *testing.T) (UnderFileStorage, *mockABSServer, func()) {
+	mock := &mockABSServer{blobs: map[string][]byte{}, blocks: map[string][]byte{}}
+	server := httptest.NewServer(mock)
+	fs, err := NewABSFileSystem(map[string]interface{}{
+		common.Endpoint:    server.URL,
+		common.AccountName: mockABSAccount,
+		common.AccountKey:  base64.StdEncoding.EncodeToString([]byte("key")),
+		common.Bucket:      mockABSContainer,
+		common.SubPath:     "/data",
+	})
+	assert.NoError(t, err)
+	return fs, mock, server.Close
+}
+
+func TestABS(t *testing.T) {
+	fs, mock, closeFn := newMockABSFileSystem(t)
+	defer closeFn()
+	defer os.RemoveAll("./tmp")
+
+	assert.NoError(t, fs.Mkdir("/dir", 0755))
+	fh, err := fs.Create("/dir/hello", uint32(os.O_WRONLY|os.O_CREATE), 0644)
+	assert.NoError(t, err)
+	_, err = fh.Write([]byte("hello world"), 0)
+	assert.NoError(t, err)
+	assert.NoError(t, fh.Flush())
+	fh.Release()
+	assert.Equal(t, []byte("hello world"), mock.blobs["data/dir/hello"])
+
+	attr, err := fs.GetAttr("/dir/hello")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(11), attr.Size)
+	assert.False(t, attr.IsDir)
+	attr, err = fs.GetAttr("/dir")
+	assert.NoError(t, err)
+	assert.True(t, attr.IsDir)
+	_, err = fs.GetAttr("/notexist")
+	assert.Equal(t, syscall.ENOENT, err)
+
+	fh, err = fs.Open("/dir/hello", uint32(os.O_RDONLY), 11)
+	assert.NoError(t, err)
+	buf := make([]byte, 5)
+	n, err := fh.Read(buf, 6)
+	assert.NoError(t, err)
+	assert.Equal(t, "world", string(buf[:n]))
+
+	// 超过一页的列举
+	for i := 0; i < MaxKeys+5; i++ {
+		mock.blobs[fmt.Sprintf("data/dir/f%04d", i)] = []byte("x")
+	}
+	entries, err := fs.ReadDir("/dir")
+	assert.NoError(t, err)
+	assert.Equal(t, MaxKeys+6, len(entries))
+
+	assert.Equal(t, syscall.ENOTEMPTY, fs.Rmdir("/dir"))
+	for i := 0; i < MaxKeys+5; i++ {
+		assert.NoError(t, fs.Unlink(fmt.Sprintf("/dir/f%04d", i)))
+	}
+
+	assert.NoError(t, fs.Rename("/dir", "/newdir"))
+	assert.Equal(t, []byte("hello world"), mock.blobs["data/newdir/hello"])
+	_, ok := mock.blobs["data/dir/hello"]
+	assert.False(t, ok)
+
+	assert.NoError(t, fs.Unlink("/newdir/hello"))
+	assert.NoError(t, fs.Rmdir("/newdir"))
+	entries, err = fs.ReadDir("/")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(entries))
+}
+
+func TestABSBlockUpload(t *testing.T) {
+	fs, mock, closeFn := newMockABSFileSystem(t)
+	defer closeFn()
+	defer os.RemoveAll("./tmp")
+
+	fh, err := fs.Create("/big", uint32(os.O_WRONLY|os.O_CREATE), 0644)
+	assert.NoError(t, err)
+	absFh := fh.(*objectFileHandle)
+	data := make([]byte, 3*ObjectDefaultPartSize+10)
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+	_, err = absFh.writeTmpfile.WriteAt(data, 0)
+	assert.NoError(t, err)
+	assert.NoError(t, absFh.uploadParts(int64(len(data))))
+	assert.Equal(t, data, mock.blobs["data/big"])
+	assert.Equal(t, 4, len(mock.blocks))
+	fh.Release()
+
+	assert.Equal(t, int64(ObjectDefaultPartSize), objectPartSize(ObjectDefaultPartSize, ObjectDefaultPartSize, ABSMaxBlockNum))
+	assert.Equal(t, int64(2*ObjectDefaultPartSize), objectPartSize(2*ObjectDefaultPartSize*ABSMaxBlockNum, ObjectDefaultPartSize, ABSMaxBlockNum))
+}
@@ -0,0 +1,152 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ufs
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	fsCommon "github.com/PaddlePaddle/PaddleFlow/pkg/fs/common"
+)
+
+const (
+	COSEndpointTemplate = "cos.%s.myqcloud.com"
+	COSSignExpire       = time.Hour
+	cosHeaderPrefix     = "x-cos-"
+)
+
+// cosSigner 腾讯云cos签名 https://cloud.tencent.com/document/product/436/7778
+type cosSigner struct {
+	secretID  string
+	secretKey string
+}
+
+// cosEscape 与cos sdk一致，除字母数字和-_.!~*'()外都需要编码
+func cosEscape(s string) string {
+	var builder strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || strings.IndexByte("-_.!~*'()", c) >= 0 {
+			builder.WriteByte(c)
+			continue
+		}
+		builder.WriteString(fmt.Sprintf("%%%02X", c))
+	}
+	return builder.String()
+}
+
+// cosKeyValues 返回按key排序的参与签名的key列表以及key=value列表
+func cosKeyValues(values map[string]string) (string, string) {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, key+"="+cosEscape(values[key]))
+	}
+	return strings.Join(keys, ";"), strings.Join(pairs, "&")
+}
+
+func hmacSHA1Hex(key, data string) string {
+	mac := hmac.New(sha1.New, []byte(key))
+	mac.Write([]byte(data))
+	return fmt.Sprintf("%x", mac.Sum(nil))
+}
+
+func (s *cosSigner) authorization(req *http.Request, keyTime string) string {
+	params := map[string]string{}
+	for name, values := range req.URL.Query() {
+		value := ""
+		if len(values) > 0 {
+			value = values[0]
+		}
+		params[cosEscape(strings.ToLower(name))] = value
+	}
+	// 只对host与x-cos-*签名，其他header在传输过程中可能被代理修改
+	headers := map[string]string{"host": req.URL.Host}
+	for name := range req.Header {
+		lowerName := strings.ToLower(name)
+		if strings.HasPrefix(lowerName, cosHeaderPrefix) {
+			headers[cosEscape(lowerName)] = req.Header.Get(name)
+		}
+	}
+	paramList, httpParameters := cosKeyValues(params)
+	headerList, httpHeaders := cosKeyValues(headers)
+
+	httpString := strings.ToLower(req.Method) + "\n" + req.URL.Path + "\n" + httpParameters + "\n" + httpHeaders + "\n"
+	stringToSign := fmt.Sprintf("sha1\n%s\n%x\n", keyTime, sha1.Sum([]byte(httpString)))
+	signature := hmacSHA1Hex(hmacSHA1Hex(s.secretKey, keyTime), stringToSign)
+	return fmt.Sprintf("q-sign-algorithm=sha1&q-ak=%s&q-sign-time=%s&q-key-time=%s&q-header-list=%s&q-url-param-list=%s&q-signature=%s",
+		s.secretID, keyTime, keyTime, headerList, paramList, signature)
+}
+
+func (s *cosSigner) sign(req *http.Request, key string) {
+	now := time.Now()
+	keyTime := fmt.Sprintf("%d;%d", now.Unix()-60, now.Add(COSSignExpire).Unix())
+	req.Header.Set("Authorization", s.authorization(req, keyTime))
+}
+
+func newCOSClient(endpoint, bucket, secretID, secretKey string) (*restObjectClient, error) {
+	baseURL, err := virtualHostedURL(endpoint, bucket)
+	if err != nil {
+		return nil, err
+	}
+	signer := &cosSigner{secretID: secretID, secretKey: secretKey}
+	// 拷贝源格式为<bucket-appid>.cos.<region>.myqcloud.com/<key>
+	sourceHost := strings.SplitN(baseURL, "://", 2)[1]
+	copySource := func(key string) string {
+		return sourceHost + escapeObjectKey(key)
+	}
+	return newRestObjectClient(baseURL, cosHeaderPrefix, signer, copySource), nil
+}
+
+// NewCOSFileSystem 腾讯云cos，bucket格式为<bucketName>-<appid>，endpoint未指定时根据region生成
+func NewCOSFileSystem(properties map[string]interface{}) (UnderFileStorage, error) {
+	bucket := strings.TrimSuffix(propertyString(properties, fsCommon.Bucket), Delimiter)
+	secretID := propertyString(properties, fsCommon.AccessKey)
+	secretKey := decryptSecret(propertyString(properties, fsCommon.SecretKey))
+	if bucket == "" || secretID == "" || secretKey == "" {
+		return nil, fmt.Errorf("cos bucket, %s and %s must be provided", fsCommon.AccessKey, fsCommon.SecretKey)
+	}
+	endpoint := propertyString(properties, fsCommon.Endpoint)
+	if endpoint == "" {
+		region := propertyString(properties, fsCommon.Region)
+		if region == "" {
+			return nil, fmt.Errorf("cos %s or %s must be provided", fsCommon.Endpoint, fsCommon.Region)
+		}
+		endpoint = fmt.Sprintf(COSEndpointTemplate, region)
+	}
+	client, err := newCOSClient(endpoint, bucket, secretID, secretKey)
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("new cos fs endpoint[%s] bucket[%s] subPath[%s]", endpoint, bucket, propertyString(properties, fsCommon.SubPath))
+	return newObjectFileSystem(fsCommon.COSType, client, properties)
+}
+
+func init() {
+	RegisterUFS(fsCommon.COSType, NewCOSFileSystem)
+}
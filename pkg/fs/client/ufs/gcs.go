@@ -0,0 +1,52 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ufs
+
+import (
+	fsCommon "github.com/PaddlePaddle/PaddleFlow/pkg/fs/common"
+)
+
+const (
+	GCSDefaultEndpoint = "https://storage.googleapis.com"
+	GCSDefaultRegion   = "auto"
+)
+
+// NewGCSFileSystem 通过GCS的XML互操作接口访问，accessKey/secretKey为GCS的HMAC密钥。
+// 分页列举与分片上传复用s3的实现
+func NewGCSFileSystem(properties map[string]interface{}) (UnderFileStorage, error) {
+	gcsProperties := make(map[string]interface{}, len(properties)+2)
+	for key, value := range properties {
+		gcsProperties[key] = value
+	}
+	if propertyString(properties, fsCommon.Endpoint) == "" {
+		gcsProperties[fsCommon.Endpoint] = GCSDefaultEndpoint
+	}
+	if propertyString(properties, fsCommon.Region) == "" {
+		gcsProperties[fsCommon.Region] = GCSDefaultRegion
+	}
+	fs, err := NewS3FileSystem(gcsProperties)
+	if err != nil {
+		return nil, err
+	}
+	s3fs := fs.(*s3FileSystem)
+	s3fs.ufsType = fsCommon.GCSType
+	return s3fs, nil
+}
+
+func init() {
+	RegisterUFS(fsCommon.GCSType, NewGCSFileSystem)
+}
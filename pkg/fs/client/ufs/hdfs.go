@@ -23,6 +23,7 @@ import (
 	"os/user"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 	"sync"
 	"syscall"
@@ -570,41 +571,43 @@ func (fh *hdfsFileHandle) Allocate(off uint64, size uint64, mode uint32) error {
 }
 
 func NewHdfsFileSystem(properties map[string]interface{}) (UnderFileStorage, error) {
-	nameNodeAddress := properties[common.NameNodeAddress].(string)
 	options := hdfs.ClientOptions{
-		Addresses: strings.Split(nameNodeAddress, ","),
+		Addresses: strings.Split(propertyString(properties, common.NameNodeAddress), ","),
+		User:      propertyString(properties, common.UserKey),
 	}
-	options.User = properties[common.UserKey].(string)
+	return newHdfsFileSystem(options, properties)
+}
+
+func newHdfsFileSystem(options hdfs.ClientOptions, properties map[string]interface{}) (UnderFileStorage, error) {
 	cli, err := hdfs.NewClient(options)
 	if err != nil {
 		return nil, err
 	}
 
-	subpath, ok := properties[common.SubPath]
-	if !ok {
+	subpath := propertyString(properties, common.SubPath)
+	if subpath == "" {
 		subpath = "/"
 	} else {
-		subpath := subpath.(string)
 		// If dirname is already a directory, MkdirAll does nothing and returns nil.
 		if err := cli.MkdirAll(subpath, os.FileMode(0644)); err != nil {
 			return nil, err
 		}
 	}
 
-	blockSize, ok := properties[common.BlockSizeKey].(int64)
-	if !ok {
-		blockSize = DefaultBlockSize
+	blockSize, err := propertyInt64(properties, common.BlockSizeKey, DefaultBlockSize)
+	if err != nil {
+		return nil, err
 	}
-	replication, ok := properties[common.ReplicationKey].(int)
-	if !ok {
-		replication = DefaultReplication
+	replication, err := propertyInt64(properties, common.ReplicationKey, DefaultReplication)
+	if err != nil {
+		return nil, err
 	}
 
 	fs := &hdfsFileSystem{
 		client:      cli,
 		blockSize:   blockSize,
-		replication: replication,
-		subpath:     subpath.(string),
+		replication: int(replication),
+		subpath:     subpath,
 	}
 	runtime.SetFinalizer(fs, func(fs *hdfsFileSystem) {
 		fs.client.Close()
@@ -612,6 +615,33 @@ func NewHdfsFileSystem(properties map[string]interface{}) (UnderFileStorage, err
 	return fs, nil
 }
 
+// propertyString fs的properties来自PropertiesMap，值为string，缺省时返回空字符串
+func propertyString(properties map[string]interface{}, key string) string {
+	value, _ := properties[key].(string)
+	return value
+}
+
+// propertyInt64 兼容数值类型与PropertiesMap中的字符串类型，缺省时返回defaultValue
+func propertyInt64(properties map[string]interface{}, key string, defaultValue int64) (int64, error) {
+	switch value := properties[key].(type) {
+	case int64:
+		return value, nil
+	case int:
+		return int64(value), nil
+	case string:
+		if value == "" {
+			return defaultValue, nil
+		}
+		result, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || result <= 0 {
+			return 0, fmt.Errorf("property %s[%s] should be a positive integer", key, value)
+		}
+		return result, nil
+	default:
+		return defaultValue, nil
+	}
+}
+
 func init() {
 	RegisterUFS(common.HDFSType, NewHdfsFileSystem)
 }
@@ -18,7 +18,7 @@ package ufs
 
 import (
 	"encoding/base64"
-	"runtime"
+	"fmt"
 	"strings"
 
 	"github.com/colinmarc/hdfs/v2"
@@ -40,6 +40,8 @@ type KerberosConf struct {
 }
 
 const (
+	DefaultDataTransferProtection = "integrity"
+
 	Krb5ConfTemplate = `[libdefaults]
 default_realm = %s
 dns_lookup_realm = false
@@ -59,14 +61,22 @@ clockskew = 120
 )
 
 func buildKerberosConf(properties map[string]interface{}) (*KerberosConf, error) {
-	return &KerberosConf{
-		Realm:                  properties[common.Realm].(string),
-		Kdc:                    properties[common.Kdc].(string),
-		Principal:              properties[common.Principal].(string),
-		NameNodePrincipal:      properties[common.NameNodePrincipal].(string),
-		DataTransferProtection: properties[common.DataTransferProtection].(string),
-		KeyTabData:             properties[common.KeyTabData].(string),
-	}, nil
+	conf := &KerberosConf{
+		Realm:                  propertyString(properties, common.Realm),
+		Kdc:                    propertyString(properties, common.Kdc),
+		Principal:              propertyString(properties, common.Principal),
+		NameNodePrincipal:      propertyString(properties, common.NameNodePrincipal),
+		DataTransferProtection: propertyString(properties, common.DataTransferProtection),
+		KeyTabData:             propertyString(properties, common.KeyTabData),
+	}
+	if conf.Realm == "" || conf.Kdc == "" || conf.Principal == "" || conf.NameNodePrincipal == "" || conf.KeyTabData == "" {
+		return nil, fmt.Errorf("kerberos hdfs properties %s, %s, %s, %s and %s must be provided",
+			common.Realm, common.Kdc, common.Principal, common.NameNodePrincipal, common.KeyTabData)
+	}
+	if conf.DataTransferProtection == "" {
+		conf.DataTransferProtection = DefaultDataTransferProtection
+	}
+	return conf, nil
 }
 
 func NewKerberosClientWithKeyTab(kerberosConf *KerberosConf) (*krb.Client, error) {
@@ -119,56 +129,21 @@ func NewKerberosClientWithKeyTab(kerberosConf *KerberosConf) (*krb.Client, error
 }
 
 func NewHdfsWithKerberosFileSystem(properties map[string]interface{}) (UnderFileStorage, error) {
-	nameNodeAddress := properties[common.NameNodeAddress].(string)
 	options := hdfs.ClientOptions{
-		Addresses: strings.Split(nameNodeAddress, ","),
+		Addresses: strings.Split(propertyString(properties, common.NameNodeAddress), ","),
 	}
-	krbConfig, _ := buildKerberosConf(properties)
-	if krbClient, err := NewKerberosClientWithKeyTab(krbConfig); err == nil {
-		options.KerberosClient = krbClient
-		options.KerberosServicePrincipleName = strings.Split(krbConfig.NameNodePrincipal, "@")[0]
-		options.DataTransferProtection = krbConfig.DataTransferProtection
-		if options.DataTransferProtection == "" {
-			options.DataTransferProtection = "integrity"
-		}
-	} else {
+	krbConfig, err := buildKerberosConf(properties)
+	if err != nil {
 		return nil, err
 	}
-
-	cli, err := hdfs.NewClient(options)
+	krbClient, err := NewKerberosClientWithKeyTab(krbConfig)
 	if err != nil {
 		return nil, err
 	}
-
-	subpath, ok := properties[common.SubPath]
-	if !ok {
-		subpath = "/"
-	} else {
-		// create empty subpath
-		if err := cli.MkdirAll(subpath.(string), 0644); err != nil {
-			return nil, err
-		}
-	}
-
-	blockSize, ok := properties[common.BlockSizeKey].(int64)
-	if !ok {
-		blockSize = DefaultBlockSize
-	}
-	replication, ok := properties[common.ReplicationKey].(int)
-	if !ok {
-		replication = DefaultReplication
-	}
-
-	fs := &hdfsFileSystem{
-		client:      cli,
-		blockSize:   blockSize,
-		replication: replication,
-		subpath:     subpath.(string),
-	}
-	runtime.SetFinalizer(fs, func(fs *hdfsFileSystem) {
-		fs.client.Close()
-	})
-	return fs, nil
+	options.KerberosClient = krbClient
+	options.KerberosServicePrincipleName = strings.Split(krbConfig.NameNodePrincipal, "@")[0]
+	options.DataTransferProtection = krbConfig.DataTransferProtection
+	return newHdfsFileSystem(options, properties)
 }
 
 // HDFS with Kerberos
@@ -92,3 +92,30 @@ func TestHdfsWithKerberos(t *testing.T) {
 	assert.NoError(t, err)
 	testFsOp(t, fs)
 }
+
+func TestHdfsProperties(t *testing.T) {
+	properties := map[string]interface{}{
+		common.BlockSizeKey: "134217728",
+	}
+	blockSize, err := propertyInt64(properties, common.BlockSizeKey, DefaultBlockSize)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(134217728), blockSize)
+	replication, err := propertyInt64(properties, common.ReplicationKey, DefaultReplication)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(DefaultReplication), replication)
+	properties[common.ReplicationKey] = "-1"
+	_, err = propertyInt64(properties, common.ReplicationKey, DefaultReplication)
+	assert.Error(t, err)
+
+	_, err = buildKerberosConf(map[string]interface{}{common.Realm: "EXAMPLE.COM"})
+	assert.Error(t, err)
+	conf, err := buildKerberosConf(map[string]interface{}{
+		common.Realm:             "EXAMPLE.COM",
+		common.Kdc:               "127.0.0.1",
+		common.Principal:         "user@EXAMPLE.COM",
+		common.NameNodePrincipal: "nn/host@EXAMPLE.COM",
+		common.KeyTabData:        "dGVzdA==",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultDataTransferProtection, conf.DataTransferProtection)
+}
@@ -27,6 +27,9 @@ import (
 const (
 	TypeFile      = 1 // type for regular file
 	TypeDirectory = 2 // type for directory
+
+	// XAttrStorageClass 设置该扩展属性可将对象转换为指定的存储类型，仅对象存储支持
+	XAttrStorageClass = "user.paddleflow.storage-class"
 )
 
 // under file storage interface, copy from pathfs.FileSystem,
@@ -17,7 +17,7 @@ limitations under the License.
 package ufs
 
 import (
-	"fmt"
+	"strings"
 	"syscall"
 	"time"
 
@@ -77,25 +77,57 @@ func (fs *localFileSystem) Access(name string, mode, callerUid, callerGid uint32
 
 // Extended attributes.
 func (fs *localFileSystem) GetXAttr(name string, attribute string) (data []byte, err error) {
-	var dest []byte
-	if _, err := syscall.Getxattr(fs.GetPath(name), attribute, dest); err != nil {
-		return nil, err
+	path := fs.GetPath(name)
+	for {
+		// 先获取属性值的长度，分配空间后再读取，期间属性被修改时重试
+		size, err := syscall.Getxattr(path, attribute, nil)
+		if err != nil {
+			return nil, err
+		}
+		dest := make([]byte, size)
+		if size == 0 {
+			return dest, nil
+		}
+		size, err = syscall.Getxattr(path, attribute, dest)
+		if err == syscall.ERANGE {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return dest[:size], nil
 	}
-	return dest, nil
 }
 
 func (fs *localFileSystem) ListXAttr(name string) (attributes []string, err error) {
+	path := fs.GetPath(name)
 	var dest []byte
-	if _, err := syscall.Listxattr(fs.GetPath(name), dest); err != nil {
-		return nil, err
+	for {
+		size, err := syscall.Listxattr(path, nil)
+		if err != nil {
+			return nil, err
+		}
+		if size == 0 {
+			return attributes, nil
+		}
+		dest = make([]byte, size)
+		size, err = syscall.Listxattr(path, dest)
+		if err == syscall.ERANGE {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		dest = dest[:size]
+		break
 	}
-
-	for d := range dest {
-		attributes = append(attributes, fmt.Sprint(d))
+	// 属性名以'\0'分隔
+	for _, attr := range strings.Split(string(dest), "\x00") {
+		if attr != "" {
+			attributes = append(attributes, attr)
+		}
 	}
-
-	err = nil
-	return
+	return attributes, nil
 }
 
 func (fs *localFileSystem) RemoveXAttr(name string, attr string) error {
@@ -107,18 +139,11 @@ func (fs *localFileSystem) SetXAttr(name string, attr string, data []byte, flags
 }
 
 func (fs *localFileSystem) Utimens(name string, Atime *time.Time, Mtime *time.Time) error {
-	var tv []syscall.Timeval
-
-	tv = append(tv, syscall.Timeval{
-		Sec:  int64(Atime.Second()),
-		Usec: Atime.Unix(),
-	})
-
-	tv = append(tv, syscall.Timeval{
-		Sec:  int64(Mtime.Second()),
-		Usec: Mtime.Unix(),
-	})
-	return syscall.Utimes(fs.GetPath(name), tv)
+	ts := []syscall.Timespec{
+		syscall.NsecToTimespec(Atime.UnixNano()),
+		syscall.NsecToTimespec(Mtime.UnixNano()),
+	}
+	return syscall.UtimesNano(fs.GetPath(name), ts)
 }
 
 func (f *localFileHandle) Allocate(off uint64, sz uint64, mode uint32) error {
@@ -213,9 +213,16 @@ func NewLocalMountFileSystem(properties map[string]interface{}) (UnderFileStorag
 	subpath := properties[common.SubPath].(string)
 
 	switch mountType {
-	case common.GlusterFSType:
-		sourcePath = addr + ":" + subpath
-		args = []string{"-t", "glusterfs"}
+	case common.GlusterFSType, common.NFSType, common.CephFSType:
+		stringProperties := make(map[string]string, len(properties))
+		for key := range properties {
+			stringProperties[key] = propertyString(properties, key)
+		}
+		sourcePath, args, err = utils.KernelMountArgs(mountType, addr, subpath, stringProperties)
+		if err != nil {
+			os.Remove(localPath)
+			return nil, err
+		}
 	case common.CFSType:
 		sourcePath = filepath.Join(addr, subpath) + "/"
 		args = []string{"-t", "nfs4", "-o", cfsMountParam}
@@ -253,5 +260,7 @@ func NewLocalMountFileSystem(properties map[string]interface{}) (UnderFileStorag
 
 func init() {
 	RegisterUFS(common.GlusterFSType, NewLocalMountFileSystem)
+	RegisterUFS(common.NFSType, NewLocalMountFileSystem)
+	RegisterUFS(common.CephFSType, NewLocalMountFileSystem)
 	RegisterUFS(common.CFSType, NewLocalFileSystem)
 }
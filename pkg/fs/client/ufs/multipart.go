@@ -0,0 +1,219 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ufs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	fsCommon "github.com/PaddlePaddle/PaddleFlow/pkg/fs/common"
+)
+
+const (
+	MPUDefaultConcurrency = 8
+	MPUMaxConcurrency     = 128
+	mpuRetryInterval      = 500 * time.Millisecond
+)
+
+// multipartConfig 分片上传参数，可通过fs的properties按fs调整
+type multipartConfig struct {
+	partSize    int64
+	concurrency int
+	// bufferPoolSize 分片缓冲池的总大小，限制上传时读入内存的数据量
+	bufferPoolSize int64
+}
+
+func defaultMultipartConfig() multipartConfig {
+	return multipartConfig{
+		partSize:       ObjectDefaultPartSize,
+		concurrency:    MPUDefaultConcurrency,
+		bufferPoolSize: 2 * MPUDefaultConcurrency * ObjectDefaultPartSize,
+	}
+}
+
+// ValidateMultipartProperties 检查fs的分片上传参数，未设置的项使用默认值
+func ValidateMultipartProperties(properties map[string]string) error {
+	props := make(map[string]interface{}, len(properties))
+	for key, value := range properties {
+		props[key] = value
+	}
+	_, err := parseMultipartConfig(props)
+	return err
+}
+
+func parseMultipartConfig(properties map[string]interface{}) (multipartConfig, error) {
+	conf := defaultMultipartConfig()
+	if value := propertyString(properties, fsCommon.MultipartPartSize); value != "" {
+		size, err := parseQuantityBytes(value)
+		if err != nil || size < MPUMinPartSize || size > MPUMaxPartSize {
+			return conf, fmt.Errorf("property %s[%s] should be between 5Mi and 5Gi", fsCommon.MultipartPartSize, value)
+		}
+		conf.partSize = size
+	}
+	concurrency, err := propertyInt64(properties, fsCommon.MultipartConcurrency, MPUDefaultConcurrency)
+	if err != nil {
+		return conf, err
+	}
+	if concurrency > MPUMaxConcurrency {
+		return conf, fmt.Errorf("property %s[%d] should not exceed %d", fsCommon.MultipartConcurrency, concurrency, MPUMaxConcurrency)
+	}
+	conf.concurrency = int(concurrency)
+	conf.bufferPoolSize = 2 * int64(conf.concurrency) * conf.partSize
+	if value := propertyString(properties, fsCommon.MultipartBufferPoolSize); value != "" {
+		size, err := parseQuantityBytes(value)
+		if err != nil || size < conf.partSize {
+			return conf, fmt.Errorf("property %s[%s] should not be less than part size %d", fsCommon.MultipartBufferPoolSize, value, conf.partSize)
+		}
+		conf.bufferPoolSize = size
+	}
+	return conf, nil
+}
+
+func parseQuantityBytes(value string) (int64, error) {
+	q, err := resource.ParseQuantity(value)
+	if err != nil {
+		return 0, err
+	}
+	return q.Value(), nil
+}
+
+// partSizeFor 分片数超过存储允许的上限时放大分片
+func (c multipartConfig) partSizeFor(fileSize, maxParts int64) int64 {
+	return objectPartSize(fileSize, c.partSize, maxParts)
+}
+
+// partBufferPool 分片缓冲池，同一个fs上所有文件的分片上传共享，持有的缓冲区总量不超过bufferPoolSize
+type partBufferPool struct {
+	bufSize int64
+	tokens  chan struct{}
+	pool    sync.Pool
+}
+
+func newPartBufferPool(conf multipartConfig) *partBufferPool {
+	count := conf.bufferPoolSize / conf.partSize
+	if count < 1 {
+		count = 1
+	}
+	p := &partBufferPool{
+		bufSize: conf.partSize,
+		tokens:  make(chan struct{}, count),
+	}
+	p.pool.New = func() interface{} {
+		return make([]byte, p.bufSize)
+	}
+	return p
+}
+
+// get 缓冲池耗尽时阻塞，分片大于默认大小(超大文件)时单独分配，但同样占用缓冲池配额
+func (p *partBufferPool) get(ctx context.Context, size int64) ([]byte, error) {
+	select {
+	case p.tokens <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if size > p.bufSize {
+		return make([]byte, size), nil
+	}
+	return p.pool.Get().([]byte)[:size], nil
+}
+
+func (p *partBufferPool) put(buf []byte) {
+	if int64(cap(buf)) == p.bufSize {
+		p.pool.Put(buf[:cap(buf)])
+	}
+	<-p.tokens
+}
+
+type filePart struct {
+	num  int64
+	data []byte
+}
+
+// uploadFileParts 顺序读取本地临时文件的分片放入缓冲池，由concurrency个协程并发上传，分片编号从1开始。
+// 读取与上传重叠进行，任一分片重试后仍失败时停止读取与上传
+func uploadFileParts(file *os.File, fileSize, partSize int64, conf multipartConfig, pool *partBufferPool,
+	upload func(partNum int64, data []byte) error) error {
+	partCnt := (fileSize + partSize - 1) / partSize
+	group, ctx := errgroup.WithContext(context.Background())
+	parts := make(chan filePart)
+	group.Go(func() error {
+		defer close(parts)
+		for i := int64(0); i < partCnt; i++ {
+			start, length := i*partSize, partSize
+			if start+length > fileSize {
+				length = fileSize - start
+			}
+			buf, err := pool.get(ctx, length)
+			if err != nil {
+				return err
+			}
+			if _, err := file.ReadAt(buf, start); err != nil && err != io.EOF {
+				pool.put(buf)
+				return err
+			}
+			select {
+			case parts <- filePart{num: i + 1, data: buf}:
+			case <-ctx.Done():
+				pool.put(buf)
+				return ctx.Err()
+			}
+		}
+		return nil
+	})
+	for i := 0; i < conf.concurrency; i++ {
+		group.Go(func() error {
+			for part := range parts {
+				if ctx.Err() != nil {
+					pool.put(part.data)
+					continue
+				}
+				err := retryUploadPart(func() error { return upload(part.num, part.data) })
+				pool.put(part.data)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+	return group.Wait()
+}
+
+// retryUploadPart 上传失败时按指数退避重试，共尝试MPURetryTimes次
+func retryUploadPart(fn func() error) error {
+	var err error
+	interval := mpuRetryInterval
+	for retryNum := 0; retryNum < MPURetryTimes; retryNum++ {
+		if err = fn(); err == nil {
+			return nil
+		}
+		log.Warnf("upload part failed, retryNum[%d] err: %v", retryNum, err)
+		if retryNum < MPURetryTimes-1 {
+			time.Sleep(interval)
+			interval *= 2
+		}
+	}
+	return err
+}
@@ -0,0 +1,124 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ufs
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	fsCommon "github.com/PaddlePaddle/PaddleFlow/pkg/fs/common"
+)
+
+func TestParseMultipartConfig(t *testing.T) {
+	conf, err := parseMultipartConfig(map[string]interface{}{})
+	assert.NoError(t, err)
+	assert.Equal(t, defaultMultipartConfig(), conf)
+
+	conf, err = parseMultipartConfig(map[string]interface{}{
+		fsCommon.MultipartPartSize:       "64Mi",
+		fsCommon.MultipartConcurrency:    "16",
+		fsCommon.MultipartBufferPoolSize: "2Gi",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(64*1024*1024), conf.partSize)
+	assert.Equal(t, 16, conf.concurrency)
+	assert.Equal(t, int64(2*1024*1024*1024), conf.bufferPoolSize)
+	assert.Equal(t, 32, cap(newPartBufferPool(conf).tokens))
+
+	// 未设置缓冲池大小时按并发数的两倍分片计算
+	conf, err = parseMultipartConfig(map[string]interface{}{fsCommon.MultipartConcurrency: "4"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(8*ObjectDefaultPartSize), conf.bufferPoolSize)
+
+	invalid := []map[string]string{
+		{fsCommon.MultipartPartSize: "1Mi"},
+		{fsCommon.MultipartPartSize: "6Gi"},
+		{fsCommon.MultipartPartSize: "abc"},
+		{fsCommon.MultipartConcurrency: "0"},
+		{fsCommon.MultipartConcurrency: "1000"},
+		{fsCommon.MultipartBufferPoolSize: "1Mi"},
+	}
+	for _, properties := range invalid {
+		assert.Error(t, ValidateMultipartProperties(properties), fmt.Sprintf("%v", properties))
+	}
+	assert.NoError(t, ValidateMultipartProperties(map[string]string{fsCommon.MultipartPartSize: "5Mi"}))
+
+	assert.Equal(t, int64(ObjectDefaultPartSize), defaultMultipartConfig().partSizeFor(100, MPUMaxPartNum))
+	assert.Equal(t, int64(2*ObjectDefaultPartSize), defaultMultipartConfig().partSizeFor(2*ObjectDefaultPartSize*MPUMaxPartNum, MPUMaxPartNum))
+}
+
+func TestUploadFileParts(t *testing.T) {
+	file, err := ioutil.TempFile("", "mpu")
+	assert.NoError(t, err)
+	defer os.Remove(file.Name())
+	defer file.Close()
+	const partSize = 1024
+	data := make([]byte, 10*partSize+10)
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+	_, err = file.Write(data)
+	assert.NoError(t, err)
+
+	conf := multipartConfig{partSize: partSize, concurrency: 3, bufferPoolSize: 4 * partSize}
+	pool := newPartBufferPool(conf)
+	var mu sync.Mutex
+	var running, maxRunning, failures int32
+	parts := map[int64][]byte{}
+	err = uploadFileParts(file, int64(len(data)), partSize, conf, pool, func(partNum int64, buf []byte) error {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		mu.Lock()
+		defer mu.Unlock()
+		if n > maxRunning {
+			maxRunning = n
+		}
+		// 第3个分片首次上传失败，重试后成功
+		if partNum == 3 && failures == 0 {
+			failures++
+			return fmt.Errorf("mock upload failure")
+		}
+		parts[partNum] = append([]byte(nil), buf...)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 11, len(parts))
+	assert.LessOrEqual(t, maxRunning, int32(3))
+	var uploaded []byte
+	for i := int64(1); i <= 11; i++ {
+		uploaded = append(uploaded, parts[i]...)
+	}
+	assert.Equal(t, data, uploaded)
+	// 缓冲区全部归还
+	assert.Equal(t, 0, len(pool.tokens))
+
+	// 重试后仍失败时返回错误
+	err = uploadFileParts(file, int64(len(data)), partSize, conf, pool, func(partNum int64, buf []byte) error {
+		if partNum == 2 {
+			return fmt.Errorf("mock upload failure")
+		}
+		return nil
+	})
+	assert.Error(t, err)
+	assert.Equal(t, 0, len(pool.tokens))
+}
@@ -0,0 +1,709 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ufs
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hanwen/go-fuse/v2/fuse"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/client/base"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/client/utils"
+	fsCommon "github.com/PaddlePaddle/PaddleFlow/pkg/fs/common"
+)
+
+const (
+	ObjectDefaultPartSize   = 8 * 1024 * 1024
+	objectRenameChildrenMax = 1000
+	objectDirSize           = 4096
+	objectHTTPTimeout       = 10 * time.Minute
+)
+
+type objectStorageError struct {
+	StatusCode int
+	Code       string
+}
+
+func (e *objectStorageError) Error() string {
+	return fmt.Sprintf("object storage request failed, status[%d] code[%s]", e.StatusCode, e.Code)
+}
+
+func isObjectNotFound(err error) bool {
+	objErr, ok := err.(*objectStorageError)
+	return ok && objErr.StatusCode == http.StatusNotFound
+}
+
+type objectInfo struct {
+	Key   string
+	Size  int64
+	Mtime time.Time
+}
+
+type objectListResult struct {
+	Objects  []objectInfo
+	Prefixes []string
+	// NextMarker 为空表示没有下一页
+	NextMarker string
+}
+
+// objectClient 对象存储的最小操作集合，签名、分页和分片上传上的差异由各厂商的实现处理
+type objectClient interface {
+	bucketExists() (bool, error)
+	list(prefix, delimiter, marker string, maxKeys int) (*objectListResult, error)
+	head(key string) (size int64, mtime time.Time, err error)
+	get(key string, off, limit int64) (io.ReadCloser, error)
+	put(key string, data []byte) error
+	copy(src, dst string) error
+	delete(key string) error
+	// 分片上传，maxParts为单个对象允许的最大分片数
+	maxParts() int64
+	createMultipart(key string) (uploadID string, err error)
+	uploadPart(key, uploadID string, partNum int64, data []byte) (partID string, err error)
+	completeMultipart(key, uploadID string, partIDs []string) error
+	abortMultipart(key, uploadID string) error
+}
+
+// objectFileSystem 基于objectClient的通用对象存储文件系统，目录以"/"结尾的空对象表示
+type objectFileSystem struct {
+	ufsType     string
+	client      objectClient
+	subpath     string
+	dirMode     int
+	fileMode    int
+	defaultTime time.Time
+	mpu         multipartConfig
+	bufferPool  *partBufferPool
+}
+
+var _ UnderFileStorage = &objectFileSystem{}
+
+func newObjectFileSystem(ufsType string, client objectClient, properties map[string]interface{}) (*objectFileSystem, error) {
+	dirMode, fileMode, err := objectStorageModes(properties)
+	if err != nil {
+		return nil, err
+	}
+	mpu, err := parseMultipartConfig(properties)
+	if err != nil {
+		return nil, err
+	}
+	exist, err := client.bucketExists()
+	if err != nil {
+		log.Errorf("%s check bucket err: %v", ufsType, err)
+		return nil, err
+	}
+	if !exist {
+		return nil, fmt.Errorf("bucket of %s fs not exist", ufsType)
+	}
+
+	Owner, Group = "root", "root"
+	if owner := propertyString(properties, fsCommon.Owner); owner != "" {
+		Owner = owner
+	}
+	if group := propertyString(properties, fsCommon.Group); group != "" {
+		Group = group
+	}
+	return &objectFileSystem{
+		ufsType:     ufsType,
+		client:      client,
+		subpath:     tidySubpath(propertyString(properties, fsCommon.SubPath)),
+		dirMode:     dirMode,
+		fileMode:    fileMode,
+		defaultTime: time.Now(),
+		mpu:         mpu,
+		bufferPool:  newPartBufferPool(mpu),
+	}, nil
+}
+
+// Used for pretty printing.
+func (fs *objectFileSystem) String() string {
+	return fs.ufsType
+}
+
+// getFullPath 对象名称不以"/"开头，目录以"/"结尾
+func (fs *objectFileSystem) getFullPath(name string) string {
+	name = toS3Path(name)
+	path := strings.TrimPrefix(filepath.Join(fs.subpath, name), Delimiter)
+	if strings.HasSuffix(name, Delimiter) && path != "" {
+		path += Delimiter
+	}
+	return path
+}
+
+func (fs *objectFileSystem) fileAttr(name, path string, size int64, mtime time.Time, isDir bool) *base.FileInfo {
+	aTime := fuse.UtimeToTimespec(&mtime)
+	mode := syscall.S_IFREG | fs.fileMode
+	if isDir {
+		size = objectDirSize
+		mode = syscall.S_IFDIR | fs.dirMode
+	}
+	uid := uint32(utils.LookupUser(Owner))
+	gid := uint32(utils.LookupGroup(Group))
+	st := fillStat(1, uint32(mode), uid, gid, size, objectDirSize, size/512, aTime, aTime, aTime)
+	return &base.FileInfo{
+		Name:  name,
+		Path:  path,
+		Size:  size,
+		Mtime: uint64(mtime.Unix()),
+		IsDir: isDir,
+		Owner: Owner,
+		Group: Group,
+		Mode:  utils.StatModeToFileMode(mode),
+		Sys:   st,
+	}
+}
+
+// isDirExist 对象存储没有真正的目录，目录标记对象或者前缀下存在对象都视为目录存在
+func (fs *objectFileSystem) isDirExist(name string) (bool, error) {
+	prefix := fs.getFullPath(toDirPath(toS3Path(name)))
+	result, err := fs.client.list(prefix, "", "", 1)
+	if err != nil {
+		return false, err
+	}
+	return len(result.Objects) > 0, nil
+}
+
+func (fs *objectFileSystem) GetAttr(name string) (*base.FileInfo, error) {
+	log.Tracef("%s getAttr: name[%s]", fs.ufsType, name)
+	name = toS3Path(name)
+	if name == "" || name == Delimiter {
+		return fs.fileAttr("", "", objectDirSize, fs.defaultTime, true), nil
+	}
+	path := fs.getFullPath(name)
+	size, mtime, err := fs.client.head(path)
+	if err == nil {
+		return fs.fileAttr(name, path, size, mtime, strings.HasSuffix(path, Delimiter)), nil
+	}
+	if !isObjectNotFound(err) {
+		log.Errorf("%s getAttr: name[%s] head err: %v", fs.ufsType, name, err)
+		return nil, err
+	}
+	exist, err := fs.isDirExist(name)
+	if err != nil {
+		return nil, err
+	}
+	if !exist {
+		return nil, syscall.ENOENT
+	}
+	return fs.fileAttr(name, fs.getFullPath(toDirPath(name)), objectDirSize, fs.defaultTime, true), nil
+}
+
+func (fs *objectFileSystem) Chmod(name string, mode uint32) error {
+	// 对象存储不支持chmod，返回报错会导致tar解压报错，因此直接跳过
+	return nil
+}
+
+func (fs *objectFileSystem) Chown(name string, uid uint32, gid uint32) error {
+	return nil
+}
+
+func (fs *objectFileSystem) Utimens(name string, atime *time.Time, mtime *time.Time) error {
+	return nil
+}
+
+func (fs *objectFileSystem) Truncate(name string, size uint64) error {
+	log.Tracef("%s truncate: name[%s] size[%d] do not impl. use fh", fs.ufsType, name, size)
+	return nil
+}
+
+func (fs *objectFileSystem) Access(name string, mode, callerUid, callerGid uint32) error {
+	return nil
+}
+
+func (fs *objectFileSystem) Link(oldName string, newName string) error {
+	return syscall.ENOSYS
+}
+
+func (fs *objectFileSystem) Mkdir(name string, mode uint32) error {
+	log.Tracef("%s mkdir: name[%s]", fs.ufsType, name)
+	path := fs.getFullPath(toDirPath(toS3Path(name)))
+	if err := fs.client.put(path, nil); err != nil {
+		log.Errorf("%s mkdir: put[%s] err: %v", fs.ufsType, path, err)
+		return err
+	}
+	return nil
+}
+
+func (fs *objectFileSystem) Mknod(name string, mode uint32, dev uint32) error {
+	return syscall.ENOSYS
+}
+
+// listAll 递归列出前缀下的所有对象
+func (fs *objectFileSystem) listAll(prefix string) ([]objectInfo, error) {
+	var objects []objectInfo
+	marker := ""
+	for {
+		result, err := fs.client.list(prefix, "", marker, MaxKeys)
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, result.Objects...)
+		if len(objects) > objectRenameChildrenMax {
+			return nil, syscall.E2BIG
+		}
+		marker = result.NextMarker
+		if marker == "" {
+			return objects, nil
+		}
+	}
+}
+
+func (fs *objectFileSystem) renameObject(src, dst string) error {
+	if err := fs.client.copy(src, dst); err != nil {
+		log.Errorf("%s rename: copy [%s] -> [%s] err: %v", fs.ufsType, src, dst, err)
+		return err
+	}
+	if err := fs.client.delete(src); err != nil && !isObjectNotFound(err) {
+		log.Errorf("%s rename: delete [%s] err: %v", fs.ufsType, src, err)
+		return err
+	}
+	return nil
+}
+
+func (fs *objectFileSystem) Rename(oldName, newName string) error {
+	oldName, newName = toS3Path(oldName), toS3Path(newName)
+	log.Tracef("%s rename: [%s]->[%s]", fs.ufsType, oldName, newName)
+	oldAttr, err := fs.GetAttr(oldName)
+	if err != nil {
+		return err
+	}
+	newAttr, err := fs.GetAttr(newName)
+	if err != nil && err != syscall.ENOENT {
+		return err
+	}
+	if !oldAttr.IsDir {
+		if newAttr != nil && newAttr.IsDir {
+			return syscall.EISDIR
+		}
+		return fs.renameObject(fs.getFullPath(oldName), fs.getFullPath(newName))
+	}
+	if newAttr != nil && !newAttr.IsDir {
+		return syscall.ENOTDIR
+	}
+
+	oldPrefix := fs.getFullPath(toDirPath(oldName))
+	newPrefix := fs.getFullPath(toDirPath(newName))
+	objects, err := fs.listAll(oldPrefix)
+	if err != nil {
+		return err
+	}
+	group := new(errgroup.Group)
+	for _, object := range objects {
+		src := object.Key
+		group.Go(func() error {
+			return fs.renameObject(src, newPrefix+strings.TrimPrefix(src, oldPrefix))
+		})
+	}
+	return group.Wait()
+}
+
+func (fs *objectFileSystem) Rmdir(name string) error {
+	log.Tracef("%s rmdir: %s", fs.ufsType, name)
+	prefix := fs.getFullPath(toDirPath(toS3Path(name)))
+	result, err := fs.client.list(prefix, "", "", 2)
+	if err != nil {
+		return err
+	}
+	for _, object := range result.Objects {
+		if object.Key != prefix {
+			return syscall.ENOTEMPTY
+		}
+	}
+	if err := fs.client.delete(prefix); err != nil && !isObjectNotFound(err) {
+		log.Errorf("%s rmdir: name[%s] delete err: %v", fs.ufsType, name, err)
+		return err
+	}
+	return nil
+}
+
+func (fs *objectFileSystem) Unlink(name string) error {
+	log.Tracef("%s unlink: %s", fs.ufsType, name)
+	if err := fs.client.delete(fs.getFullPath(name)); err != nil {
+		if isObjectNotFound(err) {
+			return syscall.ENOENT
+		}
+		log.Errorf("%s unlink: name[%s] delete err: %v", fs.ufsType, name, err)
+		return err
+	}
+	return nil
+}
+
+func (fs *objectFileSystem) GetXAttr(name string, attribute string) (data []byte, err error) {
+	return nil, syscall.ENOSYS
+}
+
+func (fs *objectFileSystem) ListXAttr(name string) (attributes []string, err error) {
+	return nil, syscall.ENOSYS
+}
+
+func (fs *objectFileSystem) RemoveXAttr(name string, attr string) error {
+	return syscall.ENOSYS
+}
+
+func (fs *objectFileSystem) SetXAttr(name string, attr string, data []byte, flags int) error {
+	return syscall.ENOSYS
+}
+
+func (fs *objectFileSystem) Open(name string, flags uint32, size uint64) (FileHandle, error) {
+	log.Tracef("%s open: name[%s] flags[%d]", fs.ufsType, name, flags)
+	fh := &objectFileHandle{
+		name: name,
+		path: fs.getFullPath(name),
+		size: size,
+		fs:   fs,
+	}
+	accMode := flags & syscall.O_ACCMODE
+	if accMode == syscall.O_RDWR || accMode == syscall.O_WRONLY {
+		if err := fh.openForWrite(); err != nil {
+			return nil, err
+		}
+	}
+	return fh, nil
+}
+
+func (fs *objectFileSystem) Create(name string, flags, mode uint32) (FileHandle, error) {
+	log.Tracef("%s create: name[%s] flags[%d], mode[%d]", fs.ufsType, name, flags, mode)
+	if flags&syscall.O_CREAT == 0 && flags&syscall.O_EXCL == 0 {
+		return nil, syscall.ENOSYS
+	}
+	fh := &objectFileHandle{
+		name: name,
+		path: fs.getFullPath(name),
+		fs:   fs,
+	}
+	if err := fh.openForWrite(); err != nil {
+		return nil, err
+	}
+	// 空文件在Flush时也需要写入
+	fh.writeDirty = true
+	return fh, nil
+}
+
+func (fs *objectFileSystem) ReadDir(name string) ([]DirEntry, error) {
+	log.Tracef("%s readDir: name[%s]", fs.ufsType, name)
+	prefix := fs.getFullPath(toDirPath(toS3Path(name)))
+	uid := uint32(utils.LookupUser(Owner))
+	gid := uint32(utils.LookupGroup(Group))
+	stream := make([]DirEntry, 0)
+	marker := ""
+	for {
+		result, err := fs.client.list(prefix, Delimiter, marker, MaxKeys)
+		if err != nil {
+			log.Errorf("%s readDir: name[%s] list err: %v", fs.ufsType, name, err)
+			return nil, err
+		}
+		for _, object := range result.Objects {
+			// 目录标记对象
+			if object.Key == prefix {
+				continue
+			}
+			stream = append(stream, DirEntry{
+				Attr: &Attr{
+					Type:  TypeFile,
+					Size:  uint64(object.Size),
+					Mode:  uint32(syscall.S_IFREG | fs.fileMode),
+					Mtime: object.Mtime.Unix(),
+					Uid:   uid,
+					Gid:   gid,
+				},
+				Name: strings.TrimPrefix(object.Key, prefix),
+			})
+		}
+		for _, dir := range result.Prefixes {
+			stream = append(stream, DirEntry{
+				Attr: &Attr{
+					Type:  TypeDirectory,
+					Size:  objectDirSize,
+					Mode:  uint32(syscall.S_IFDIR | fs.dirMode),
+					Mtime: fs.defaultTime.Unix(),
+					Uid:   uid,
+					Gid:   gid,
+				},
+				Name: strings.TrimSuffix(strings.TrimPrefix(dir, prefix), Delimiter),
+			})
+		}
+		marker = result.NextMarker
+		if marker == "" {
+			return stream, nil
+		}
+	}
+}
+
+func (fs *objectFileSystem) Symlink(value string, linkName string) error {
+	return syscall.ENOSYS
+}
+
+func (fs *objectFileSystem) Readlink(name string) (string, error) {
+	return "", syscall.ENOSYS
+}
+
+func (fs *objectFileSystem) Get(name string, flags uint32, off, limit int64) (io.ReadCloser, error) {
+	log.Tracef("%s get: name[%s] off[%d] limit[%d] ", fs.ufsType, name, off, limit)
+	body, err := fs.client.get(fs.getFullPath(name), off, limit)
+	if err != nil {
+		log.Errorf("%s get: name[%s] off[%d] limit[%d] err: %v ", fs.ufsType, name, off, limit, err)
+		return nil, err
+	}
+	return body, nil
+}
+
+func (fs *objectFileSystem) Put(name string, reader io.Reader) error {
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	return fs.client.put(fs.getFullPath(name), data)
+}
+
+func (fs *objectFileSystem) StatFs(name string) *base.StatfsOut {
+	// 256 T
+	return &base.StatfsOut{
+		Blocks:  0x1000000,
+		Bfree:   0x1000000,
+		Bavail:  0x1000000,
+		Ffree:   0x1000000,
+		Bsize:   0x1000000,
+		NameLen: 1023,
+	}
+}
+
+type objectFileHandle struct {
+	name         string
+	path         string
+	size         uint64
+	fs           *objectFileSystem
+	writeTmpfile *os.File
+	canWrite     chan struct{}
+	mu           sync.Mutex
+	writeDirty   bool
+}
+
+var _ FileHandle = &objectFileHandle{}
+
+// openForWrite 对象存储不支持随机写，写入先落到本地临时文件，Flush时整体上传
+func (fh *objectFileHandle) openForWrite() error {
+	os.MkdirAll(TmpPath, 0755)
+	tmpfile, err := ioutil.TempFile(TmpPath, uuid.New().String())
+	if err != nil {
+		return syscall.ENOSYS
+	}
+	// 临时文件创建后删除，但是fd仍存在可使用
+	defer os.Remove(tmpfile.Name())
+	fh.writeTmpfile = tmpfile
+	if fh.size == 0 {
+		return nil
+	}
+	body, err := fh.fs.client.get(fh.path, 0, 0)
+	if err != nil {
+		log.Errorf("%s openForWrite: get[%s] err: %v", fh.fs.ufsType, fh.path, err)
+		return err
+	}
+	fh.canWrite = make(chan struct{})
+	go func() {
+		defer close(fh.canWrite)
+		defer body.Close()
+		if _, err := io.Copy(fh.writeTmpfile, body); err != nil {
+			log.Errorf("%s openForWrite: fh.name[%s] copy err: %v", fh.fs.ufsType, fh.name, err)
+		}
+	}()
+	return nil
+}
+
+func (fh *objectFileHandle) waitWritable() {
+	if fh.canWrite != nil {
+		<-fh.canWrite
+	}
+}
+
+func (fh *objectFileHandle) Read(buf []byte, off uint64) (int, error) {
+	log.Tracef("%s read: fh.name[%s] len[%d] off[%d]", fh.fs.ufsType, fh.name, len(buf), off)
+	if off >= fh.size || len(buf) == 0 {
+		return 0, nil
+	}
+	limit := uint64(len(buf))
+	if off+limit > fh.size {
+		limit = fh.size - off
+	}
+	body, err := fh.fs.client.get(fh.path, int64(off), int64(limit))
+	if err != nil {
+		log.Errorf("%s read: get[%s] err: %v", fh.fs.ufsType, fh.name, err)
+		return 0, err
+	}
+	defer body.Close()
+	n, err := io.ReadFull(body, buf[:limit])
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return 0, err
+	}
+	return n, nil
+}
+
+func (fh *objectFileHandle) Write(data []byte, offset uint64) (uint32, error) {
+	log.Tracef("%s write: fh.name[%s] offset[%d] length[%d]", fh.fs.ufsType, fh.name, offset, len(data))
+	if fh.writeTmpfile == nil {
+		return 0, syscall.EBADF
+	}
+	fh.waitWritable()
+	fh.mu.Lock()
+	defer fh.mu.Unlock()
+	n, err := fh.writeTmpfile.WriteAt(data, int64(offset))
+	if err != nil {
+		log.Errorf("%s write: fh.name[%s] WriteAt err: %v", fh.fs.ufsType, fh.name, err)
+		return 0, err
+	}
+	fh.writeDirty = true
+	return uint32(n), nil
+}
+
+func (fh *objectFileHandle) Flush() error {
+	return fh.upload()
+}
+
+func (fh *objectFileHandle) Release() {
+	if err := fh.upload(); err != nil {
+		log.Errorf("%s release: fh.name[%s] upload err: %v", fh.fs.ufsType, fh.name, err)
+	}
+	if fh.writeTmpfile != nil {
+		fh.writeTmpfile.Close()
+		fh.writeTmpfile = nil
+	}
+}
+
+func (fh *objectFileHandle) Fsync(flags int) error {
+	return nil
+}
+
+func (fh *objectFileHandle) Truncate(size uint64) error {
+	log.Tracef("%s truncate: fh.name[%s], size[%d]", fh.fs.ufsType, fh.name, size)
+	if fh.writeTmpfile == nil {
+		return syscall.EBADF
+	}
+	fh.waitWritable()
+	if err := fh.writeTmpfile.Truncate(int64(size)); err != nil {
+		return err
+	}
+	fh.writeDirty = true
+	return fh.upload()
+}
+
+func (fh *objectFileHandle) Allocate(off, size uint64, mode uint32) error {
+	return nil
+}
+
+// upload 小文件一次写入，大文件分片并发上传
+func (fh *objectFileHandle) upload() error {
+	if !fh.writeDirty || fh.writeTmpfile == nil {
+		return nil
+	}
+	fh.mu.Lock()
+	defer fh.mu.Unlock()
+	fInfo, err := fh.writeTmpfile.Stat()
+	if err != nil {
+		return err
+	}
+	fileSize := fInfo.Size()
+	if fileSize <= MPUThreshold {
+		data := make([]byte, fileSize)
+		if _, err := fh.writeTmpfile.ReadAt(data, 0); err != nil && err != io.EOF {
+			return err
+		}
+		if err := fh.fs.client.put(fh.path, data); err != nil {
+			log.Errorf("%s upload: put[%s] err: %v", fh.fs.ufsType, fh.path, err)
+			return err
+		}
+	} else if err := fh.uploadParts(fileSize); err != nil {
+		log.Errorf("%s upload: fh.name[%s] uploadParts err: %v", fh.fs.ufsType, fh.name, err)
+		return err
+	}
+	fh.size = uint64(fileSize)
+	fh.writeDirty = false
+	return nil
+}
+
+func objectPartSize(fileSize, partSize, maxParts int64) int64 {
+	if fileSize > partSize*maxParts {
+		partSize = (fileSize + maxParts - 1) / maxParts
+	}
+	return partSize
+}
+
+func (fh *objectFileHandle) uploadParts(fileSize int64) error {
+	client := fh.fs.client
+	partSize := fh.fs.mpu.partSizeFor(fileSize, client.maxParts())
+	partNum := (fileSize + partSize - 1) / partSize
+	uploadID, err := client.createMultipart(fh.path)
+	if err != nil {
+		return err
+	}
+	partIDs := make([]string, partNum)
+	err = uploadFileParts(fh.writeTmpfile, fileSize, partSize, fh.fs.mpu, fh.fs.bufferPool, func(partNum int64, data []byte) error {
+		partID, err := client.uploadPart(fh.path, uploadID, partNum, data)
+		if err != nil {
+			log.Errorf("%s uploadPart: fh.name[%s] part[%d] err: %v", fh.fs.ufsType, fh.name, partNum, err)
+			return err
+		}
+		partIDs[partNum-1] = partID
+		return nil
+	})
+	if err != nil {
+		if abortErr := client.abortMultipart(fh.path, uploadID); abortErr != nil {
+			log.Errorf("%s abortMultipart: fh.name[%s] err: %v", fh.fs.ufsType, fh.name, abortErr)
+		}
+		return err
+	}
+	return client.completeMultipart(fh.path, uploadID, partIDs)
+}
+
+// objectStorageModes 对象存储没有权限位，使用dirMode/fileMode指定挂载后的权限
+func objectStorageModes(properties map[string]interface{}) (dirMode, fileMode int, err error) {
+	dirMode, fileMode = DefaultDirMode, DefaultFileMode
+	if value, ok := properties[fsCommon.DirMode].(string); ok && value != "" {
+		if dirMode, err = strconv.Atoi(value); err != nil {
+			return 0, 0, err
+		}
+	}
+	if value, ok := properties[fsCommon.FileMode].(string); ok && value != "" {
+		if fileMode, err = strconv.Atoi(value); err != nil {
+			return 0, 0, err
+		}
+	}
+	return dirMode, fileMode, nil
+}
+
+// decryptSecret 密钥在apiserver中加密存储，解密失败时认为是明文
+func decryptSecret(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	plain, err := common.AesDecrypt(secret, common.AESEncryptKey)
+	if err != nil {
+		return secret
+	}
+	return plain
+}
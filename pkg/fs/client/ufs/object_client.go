@@ -0,0 +1,332 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ufs
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// oss/cos单个对象最多10000个分片
+	restMaxPartNum      = 10000
+	restEncodingTypeURL = "url"
+)
+
+// restSigner 不同厂商的请求签名，key为未编码的对象名称
+type restSigner interface {
+	sign(req *http.Request, key string)
+}
+
+// restObjectClient oss/cos的REST接口与s3的ListObjects(v1)、分片上传接口基本一致，
+// 差异在于签名算法、自定义header前缀以及拷贝源的格式
+type restObjectClient struct {
+	// baseURL virtual hosted风格的访问地址，如https://bucket.oss-cn-beijing.aliyuncs.com
+	baseURL      string
+	headerPrefix string
+	copySource   func(key string) string
+	signer       restSigner
+	httpClient   *http.Client
+}
+
+type restListResult struct {
+	XMLName      xml.Name `xml:"ListBucketResult"`
+	EncodingType string   `xml:"EncodingType"`
+	IsTruncated  bool     `xml:"IsTruncated"`
+	NextMarker   string   `xml:"NextMarker"`
+	Contents     []struct {
+		Key          string `xml:"Key"`
+		LastModified string `xml:"LastModified"`
+		Size         int64  `xml:"Size"`
+	} `xml:"Contents"`
+	CommonPrefixes []struct {
+		Prefix string `xml:"Prefix"`
+	} `xml:"CommonPrefixes"`
+}
+
+type restErrorResult struct {
+	Code string `xml:"Code"`
+}
+
+type restInitiateMultipartResult struct {
+	UploadId string `xml:"UploadId"`
+}
+
+type restCompletePart struct {
+	PartNumber int64  `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+type restCompleteMultipart struct {
+	XMLName xml.Name           `xml:"CompleteMultipartUpload"`
+	Parts   []restCompletePart `xml:"Part"`
+}
+
+func newRestObjectClient(baseURL, headerPrefix string, signer restSigner, copySource func(key string) string) *restObjectClient {
+	return &restObjectClient{
+		baseURL:      strings.TrimSuffix(baseURL, Delimiter),
+		headerPrefix: headerPrefix,
+		copySource:   copySource,
+		signer:       signer,
+		httpClient:   &http.Client{Timeout: objectHTTPTimeout},
+	}
+}
+
+func escapeObjectKey(key string) string {
+	return (&url.URL{Path: Delimiter + key}).EscapedPath()
+}
+
+// encodeSubresource 子资源参数(如uploads)没有值，编码为"uploads"而不是"uploads="
+func encodeSubresource(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		value := query.Get(key)
+		if value == "" {
+			pairs = append(pairs, url.QueryEscape(key))
+			continue
+		}
+		pairs = append(pairs, url.QueryEscape(key)+"="+url.QueryEscape(value))
+	}
+	return strings.Join(pairs, "&")
+}
+
+func (c *restObjectClient) do(method, key string, query url.Values, header http.Header, body []byte) (*http.Response, error) {
+	reqURL := c.baseURL + escapeObjectKey(key)
+	if encoded := encodeSubresource(query); encoded != "" {
+		reqURL += "?" + encoded
+	}
+	req, err := http.NewRequest(method, reqURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	req.ContentLength = int64(len(body))
+	c.signer.sign(req, key)
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		defer resp.Body.Close()
+		errResult := &restErrorResult{}
+		data, _ := ioutil.ReadAll(resp.Body)
+		xml.Unmarshal(data, errResult)
+		return nil, &objectStorageError{StatusCode: resp.StatusCode, Code: errResult.Code}
+	}
+	return resp, nil
+}
+
+func (c *restObjectClient) doAndClose(method, key string, query url.Values, header http.Header, body []byte) (http.Header, error) {
+	resp, err := c.do(method, key, query, header, body)
+	if err != nil {
+		return nil, err
+	}
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+	return resp.Header, nil
+}
+
+func (c *restObjectClient) doXML(method, key string, query url.Values, body []byte, result interface{}) error {
+	resp, err := c.do(method, key, query, nil, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return xml.NewDecoder(resp.Body).Decode(result)
+}
+
+var _ objectClient = &restObjectClient{}
+
+func (c *restObjectClient) bucketExists() (bool, error) {
+	if _, err := c.list("", "", "", 1); err != nil {
+		if isObjectNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// list 使用ListObjects(v1)分页。对象名称可能包含xml不允许的字符，因此指定encoding-type=url。
+// 未指定delimiter时服务端不一定返回NextMarker，此时以本页最后一个key作为下一页的marker
+func (c *restObjectClient) list(prefix, delimiter, marker string, maxKeys int) (*objectListResult, error) {
+	query := url.Values{
+		"encoding-type": {restEncodingTypeURL},
+		"max-keys":      {strconv.Itoa(maxKeys)},
+	}
+	if prefix != "" {
+		query.Set("prefix", prefix)
+	}
+	if delimiter != "" {
+		query.Set("delimiter", delimiter)
+	}
+	if marker != "" {
+		query.Set("marker", marker)
+	}
+	listResult := &restListResult{}
+	if err := c.doXML(http.MethodGet, "", query, nil, listResult); err != nil {
+		return nil, err
+	}
+	decode := func(s string) string {
+		if listResult.EncodingType != restEncodingTypeURL {
+			return s
+		}
+		if decoded, err := url.PathUnescape(s); err == nil {
+			return decoded
+		}
+		return s
+	}
+
+	result := &objectListResult{}
+	lastKey := ""
+	for _, content := range listResult.Contents {
+		mtime, _ := time.Parse(time.RFC3339, content.LastModified)
+		key := decode(content.Key)
+		result.Objects = append(result.Objects, objectInfo{Key: key, Size: content.Size, Mtime: mtime})
+		if key > lastKey {
+			lastKey = key
+		}
+	}
+	for _, commonPrefix := range listResult.CommonPrefixes {
+		dir := decode(commonPrefix.Prefix)
+		result.Prefixes = append(result.Prefixes, dir)
+		if dir > lastKey {
+			lastKey = dir
+		}
+	}
+	if listResult.IsTruncated {
+		result.NextMarker = decode(listResult.NextMarker)
+		if result.NextMarker == "" {
+			result.NextMarker = lastKey
+		}
+	}
+	return result, nil
+}
+
+func (c *restObjectClient) head(key string) (int64, time.Time, error) {
+	header, err := c.doAndClose(http.MethodHead, key, nil, nil, nil)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	size, _ := strconv.ParseInt(header.Get("Content-Length"), 10, 64)
+	mtime, _ := http.ParseTime(header.Get("Last-Modified"))
+	return size, mtime, nil
+}
+
+func (c *restObjectClient) get(key string, off, limit int64) (io.ReadCloser, error) {
+	header := http.Header{}
+	if limit > 0 {
+		header.Set("Range", fmt.Sprintf("bytes=%d-%d", off, off+limit-1))
+	} else if off > 0 {
+		header.Set("Range", fmt.Sprintf("bytes=%d-", off))
+	}
+	resp, err := c.do(http.MethodGet, key, nil, header, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func (c *restObjectClient) put(key string, data []byte) error {
+	_, err := c.doAndClose(http.MethodPut, key, nil, nil, data)
+	return err
+}
+
+func (c *restObjectClient) copy(src, dst string) error {
+	header := http.Header{}
+	header.Set(c.headerPrefix+"copy-source", c.copySource(src))
+	_, err := c.doAndClose(http.MethodPut, dst, nil, header, nil)
+	return err
+}
+
+func (c *restObjectClient) delete(key string) error {
+	_, err := c.doAndClose(http.MethodDelete, key, nil, nil, nil)
+	return err
+}
+
+func (c *restObjectClient) maxParts() int64 {
+	return restMaxPartNum
+}
+
+func (c *restObjectClient) createMultipart(key string) (string, error) {
+	result := &restInitiateMultipartResult{}
+	if err := c.doXML(http.MethodPost, key, url.Values{"uploads": {""}}, nil, result); err != nil {
+		return "", err
+	}
+	return result.UploadId, nil
+}
+
+func (c *restObjectClient) uploadPart(key, uploadID string, partNum int64, data []byte) (string, error) {
+	query := url.Values{"partNumber": {strconv.FormatInt(partNum, 10)}, "uploadId": {uploadID}}
+	header, err := c.doAndClose(http.MethodPut, key, query, nil, data)
+	if err != nil {
+		return "", err
+	}
+	return header.Get("ETag"), nil
+}
+
+func (c *restObjectClient) completeMultipart(key, uploadID string, partIDs []string) error {
+	complete := restCompleteMultipart{}
+	for i, etag := range partIDs {
+		complete.Parts = append(complete.Parts, restCompletePart{PartNumber: int64(i + 1), ETag: etag})
+	}
+	body, err := xml.Marshal(complete)
+	if err != nil {
+		return err
+	}
+	_, err = c.doAndClose(http.MethodPost, key, url.Values{"uploadId": {uploadID}}, nil, body)
+	return err
+}
+
+func (c *restObjectClient) abortMultipart(key, uploadID string) error {
+	_, err := c.doAndClose(http.MethodDelete, key, url.Values{"uploadId": {uploadID}}, nil, nil)
+	return err
+}
+
+// virtualHostedURL 根据endpoint和bucket拼接virtual hosted风格的访问地址，endpoint未指定协议时默认https
+func virtualHostedURL(endpoint, bucket string) (string, error) {
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "https://" + endpoint
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+	if u.Host == "" {
+		return "", fmt.Errorf("invalid endpoint[%s]", endpoint)
+	}
+	if !strings.HasPrefix(u.Host, bucket+".") {
+		u.Host = bucket + "." + u.Host
+	}
+	return u.Scheme + "://" + u.Host, nil
+}
@@ -0,0 +1,281 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ufs
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// mockRestServer 内存中模拟oss/cos的ListObjects(v1)与分片上传接口。
+// 未指定delimiter时不返回NextMarker，与服务端行为一致
+type mockRestServer struct {
+	sync.Mutex
+	authPrefix   string
+	headerPrefix string
+	objects      map[string][]byte
+	parts        map[string][]byte
+	uploads      int
+}
+
+func (m *mockRestServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.Lock()
+	defer m.Unlock()
+	if !strings.HasPrefix(r.Header.Get("Authorization"), m.authPrefix) {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	query := r.URL.Query()
+	key := strings.TrimPrefix(r.URL.Path, "/")
+	_, isInit := query["uploads"]
+	switch {
+	case key == "" && r.Method == http.MethodGet:
+		m.list(w, query)
+	case r.Method == http.MethodPost && isInit:
+		m.uploads++
+		w.Write([]byte(fmt.Sprintf("<InitiateMultipartUploadResult><UploadId>upload%d</UploadId></InitiateMultipartUploadResult>", m.uploads)))
+	case r.Method == http.MethodPut && query.Get("uploadId") != "":
+		data, _ := ioutil.ReadAll(r.Body)
+		etag := fmt.Sprintf("\"%s-%s\"", query.Get("uploadId"), query.Get("partNumber"))
+		m.parts[etag] = data
+		w.Header().Set("ETag", etag)
+	case r.Method == http.MethodPost && query.Get("uploadId") != "":
+		complete := restCompleteMultipart{}
+		body, _ := ioutil.ReadAll(r.Body)
+		xml.Unmarshal(body, &complete)
+		var data []byte
+		for _, part := range complete.Parts {
+			data = append(data, m.parts[part.ETag]...)
+		}
+		m.objects[key] = data
+	case r.Method == http.MethodPut && r.Header.Get(m.headerPrefix+"copy-source") != "":
+		src := r.Header.Get(m.headerPrefix + "copy-source")
+		src, _ = url.PathUnescape(src[strings.LastIndex(src, "/"+mockRestPrefix)+1:])
+		m.objects[key] = m.objects[src]
+	case r.Method == http.MethodPut:
+		data, _ := ioutil.ReadAll(r.Body)
+		m.objects[key] = data
+	case r.Method == http.MethodHead || r.Method == http.MethodGet:
+		data, ok := m.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte("<Error><Code>NoSuchKey</Code></Error>"))
+			return
+		}
+		if rng := r.Header.Get("Range"); rng != "" {
+			var start, end int
+			fmt.Sscanf(rng, "bytes=%d-%d", &start, &end)
+			data = data[start : end+1]
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+		if r.Method == http.MethodGet {
+			w.Write(data)
+		}
+	case r.Method == http.MethodDelete:
+		delete(m.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (m *mockRestServer) list(w http.ResponseWriter, query url.Values) {
+	prefix, delimiter, marker := query.Get("prefix"), query.Get("delimiter"), query.Get("marker")
+	maxKeys, _ := strconv.Atoi(query.Get("max-keys"))
+
+	names := make([]string, 0)
+	seen := map[string]bool{}
+	for name := range m.objects {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		if delimiter != "" {
+			if idx := strings.Index(name[len(prefix):], delimiter); idx >= 0 {
+				name = name[:len(prefix)+idx+1]
+			}
+		}
+		if name > marker && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	result := restListResult{EncodingType: query.Get("encoding-type")}
+	if len(names) > maxKeys {
+		names = names[:maxKeys]
+		result.IsTruncated = true
+		if delimiter != "" {
+			result.NextMarker = url.PathEscape(names[maxKeys-1])
+		}
+	}
+	for _, name := range names {
+		if delimiter != "" && strings.HasSuffix(name, delimiter) && name != prefix {
+			result.CommonPrefixes = append(result.CommonPrefixes, struct {
+				Prefix string `xml:"Prefix"`
+			}{Prefix: url.PathEscape(name)})
+			continue
+		}
+		result.Contents = append(result.Contents, struct {
+			Key          string `xml:"Key"`
+			LastModified string `xml:"LastModified"`
+			Size         int64  `xml:"Size"`
+		}{Key: url.PathEscape(name), LastModified: time.Now().UTC().Format(time.RFC3339), Size: int64(len(m.objects[name]))})
+	}
+	data, _ := xml.Marshal(result)
+	w.Write(data)
+}
+
+const mockRestPrefix = "data/"
+
+func newMockRestFileSystem(t *testing.T, ufsType string) (*objectFileSystem, *mockRestServer, func()) {
+	mock := &mockRestServer{objects: map[string][]byte{}, parts: map[string][]byte{}}
+	server := httptest.NewServer(mock)
+	var client *restObjectClient
+	switch ufsType {
+	case "oss":
+		mock.authPrefix, mock.headerPrefix = "OSS ak:", ossHeaderPrefix
+		client = newRestObjectClient(server.URL, ossHeaderPrefix, &ossSigner{bucket: "bucket", accessKey: "ak", secretKey: "sk"},
+			func(key string) string { return "/bucket" + escapeObjectKey(key) })
+	case "cos":
+		mock.authPrefix, mock.headerPrefix = "q-sign-algorithm=sha1&q-ak=ak&", cosHeaderPrefix
+		client = newRestObjectClient(server.URL, cosHeaderPrefix, &cosSigner{secretID: "ak", secretKey: "sk"},
+			func(key string) string { return "bucket-125.cos.ap-beijing.myqcloud.com" + escapeObjectKey(key) })
+	}
+	fs, err := newObjectFileSystem(ufsType, client, map[string]interface{}{"subpath": "/data"})
+	assert.NoError(t, err)
+	return fs, mock, server.Close
+}
+
+func TestRestObjectFileSystem(t *testing.T) {
+	defer os.RemoveAll("./tmp")
+	for _, ufsType := range []string{"oss", "cos"} {
+		fs, mock, closeFn := newMockRestFileSystem(t, ufsType)
+		assert.Equal(t, ufsType, fs.String())
+
+		assert.NoError(t, fs.Mkdir("/dir", 0755))
+		fh, err := fs.Create("/dir/hello world", uint32(os.O_WRONLY|os.O_CREATE), 0644)
+		assert.NoError(t, err)
+		_, err = fh.Write([]byte("hello world"), 0)
+		assert.NoError(t, err)
+		fh.Release()
+		assert.Equal(t, []byte("hello world"), mock.objects["data/dir/hello world"])
+
+		attr, err := fs.GetAttr("/dir/hello world")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(11), attr.Size)
+		_, err = fs.GetAttr("/notexist")
+		assert.Equal(t, syscall.ENOENT, err)
+
+		fh, err = fs.Open("/dir/hello world", uint32(os.O_RDONLY), 11)
+		assert.NoError(t, err)
+		buf := make([]byte, 5)
+		n, err := fh.Read(buf, 6)
+		assert.NoError(t, err)
+		assert.Equal(t, "world", string(buf[:n]))
+
+		// 超过一页的列举，未返回NextMarker时以最后一个key续页
+		for i := 0; i < MaxKeys+5; i++ {
+			mock.objects[fmt.Sprintf("data/dir/sub/f%04d", i)] = []byte("x")
+		}
+		count, marker := 0, ""
+		for {
+			result, err := fs.client.list("data/dir/sub/", "", marker, 100)
+			assert.NoError(t, err)
+			count += len(result.Objects)
+			if marker = result.NextMarker; marker == "" {
+				break
+			}
+		}
+		assert.Equal(t, MaxKeys+5, count)
+		entries, err := fs.ReadDir("/dir/sub")
+		assert.NoError(t, err)
+		assert.Equal(t, MaxKeys+5, len(entries))
+		entries, err = fs.ReadDir("/dir")
+		assert.NoError(t, err)
+		assert.Equal(t, 2, len(entries))
+		for i := 0; i < MaxKeys+5; i++ {
+			delete(mock.objects, fmt.Sprintf("data/dir/sub/f%04d", i))
+		}
+
+		assert.NoError(t, fs.Rename("/dir", "/newdir"))
+		assert.Equal(t, []byte("hello world"), mock.objects["data/newdir/hello world"])
+		_, ok := mock.objects["data/dir/hello world"]
+		assert.False(t, ok)
+
+		// 分片上传
+		fh, err = fs.Create("/big", uint32(os.O_WRONLY|os.O_CREATE), 0644)
+		assert.NoError(t, err)
+		objectFh := fh.(*objectFileHandle)
+		data := make([]byte, 2*ObjectDefaultPartSize+10)
+		for i := range data {
+			data[i] = byte(i % 251)
+		}
+		_, err = objectFh.writeTmpfile.WriteAt(data, 0)
+		assert.NoError(t, err)
+		assert.NoError(t, objectFh.uploadParts(int64(len(data))))
+		assert.Equal(t, data, mock.objects["data/big"])
+		assert.Equal(t, 3, len(mock.parts))
+		fh.Release()
+		closeFn()
+	}
+}
+
+func TestOSSStringToSign(t *testing.T) {
+	signer := &ossSigner{bucket: "bucket", accessKey: "ak", secretKey: "sk"}
+	req, _ := http.NewRequest(http.MethodPut, "https://bucket.oss-cn-beijing.aliyuncs.com/dir/a%20b?uploadId=id&partNumber=1", nil)
+	req.Header.Set("Date", "Thu, 17 Nov 2005 18:49:58 GMT")
+	req.Header.Set("X-Oss-Meta-Author", "foo")
+	req.Header.Set("Content-Type", "text/html")
+	expected := "PUT\n\ntext/html\nThu, 17 Nov 2005 18:49:58 GMT\nx-oss-meta-author:foo\n/bucket/dir/a b?partNumber=1&uploadId=id"
+	assert.Equal(t, expected, signer.stringToSign(req, "dir/a b"))
+
+	// 列举参数不参与签名
+	req, _ = http.NewRequest(http.MethodGet, "https://bucket.oss-cn-beijing.aliyuncs.com/?prefix=a&max-keys=1", nil)
+	assert.Equal(t, "GET\n\n\n\n/bucket/", signer.stringToSign(req, ""))
+}
+
+func TestCOSAuthorization(t *testing.T) {
+	signer := &cosSigner{secretID: "ak", secretKey: "sk"}
+	req, _ := http.NewRequest(http.MethodGet, "https://bucket-125.cos.ap-beijing.myqcloud.com/?prefix=a%2Fb&max-keys=1", nil)
+	auth := signer.authorization(req, "1557989151;1557996351")
+	assert.True(t, strings.HasPrefix(auth, "q-sign-algorithm=sha1&q-ak=ak&q-sign-time=1557989151;1557996351&q-key-time=1557989151;1557996351"))
+	assert.Contains(t, auth, "&q-header-list=host&q-url-param-list=max-keys;prefix&q-signature=")
+
+	assert.Equal(t, "a%2Fb%20c-_.!~*'()", cosEscape("a/b c-_.!~*'()"))
+}
+
+func TestVirtualHostedURL(t *testing.T) {
+	baseURL, err := virtualHostedURL("oss-cn-beijing.aliyuncs.com", "bucket")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://bucket.oss-cn-beijing.aliyuncs.com", baseURL)
+	baseURL, err = virtualHostedURL("http://bucket-125.cos.ap-beijing.myqcloud.com", "bucket-125")
+	assert.NoError(t, err)
+	assert.Equal(t, "http://bucket-125.cos.ap-beijing.myqcloud.com", baseURL)
+}
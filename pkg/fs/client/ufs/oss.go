@@ -0,0 +1,137 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ufs
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	fsCommon "github.com/PaddlePaddle/PaddleFlow/pkg/fs/common"
+)
+
+const (
+	OSSEndpointTemplate = "oss-%s.aliyuncs.com"
+	ossHeaderPrefix     = "x-oss-"
+)
+
+// ossSubresources 参与签名的子资源，列举参数(prefix、marker等)不参与签名
+var ossSubresources = map[string]bool{
+	"uploads":    true,
+	"uploadId":   true,
+	"partNumber": true,
+}
+
+// ossSigner 阿里云oss V1签名 https://help.aliyun.com/document_detail/31951.html
+type ossSigner struct {
+	bucket    string
+	accessKey string
+	secretKey string
+}
+
+func (s *ossSigner) stringToSign(req *http.Request, key string) string {
+	var ossHeaders []string
+	for name := range req.Header {
+		lowerName := strings.ToLower(name)
+		if strings.HasPrefix(lowerName, ossHeaderPrefix) {
+			ossHeaders = append(ossHeaders, lowerName+":"+strings.TrimSpace(req.Header.Get(name)))
+		}
+	}
+	sort.Strings(ossHeaders)
+
+	resource := "/" + s.bucket + "/" + key
+	var subresources []string
+	for name, values := range req.URL.Query() {
+		if !ossSubresources[name] {
+			continue
+		}
+		if len(values) == 0 || values[0] == "" {
+			subresources = append(subresources, name)
+		} else {
+			subresources = append(subresources, name+"="+values[0])
+		}
+	}
+	if len(subresources) > 0 {
+		sort.Strings(subresources)
+		resource += "?" + strings.Join(subresources, "&")
+	}
+
+	var builder strings.Builder
+	builder.WriteString(req.Method + "\n")
+	builder.WriteString(req.Header.Get("Content-MD5") + "\n")
+	builder.WriteString(req.Header.Get("Content-Type") + "\n")
+	builder.WriteString(req.Header.Get("Date") + "\n")
+	for _, header := range ossHeaders {
+		builder.WriteString(header + "\n")
+	}
+	builder.WriteString(resource)
+	return builder.String()
+}
+
+func (s *ossSigner) sign(req *http.Request, key string) {
+	req.Header.Set("Date", time.Now().UTC().Format(http.TimeFormat))
+	mac := hmac.New(sha1.New, []byte(s.secretKey))
+	mac.Write([]byte(s.stringToSign(req, key)))
+	req.Header.Set("Authorization", fmt.Sprintf("OSS %s:%s", s.accessKey, base64.StdEncoding.EncodeToString(mac.Sum(nil))))
+}
+
+func newOSSClient(endpoint, bucket, accessKey, secretKey string) (*restObjectClient, error) {
+	baseURL, err := virtualHostedURL(endpoint, bucket)
+	if err != nil {
+		return nil, err
+	}
+	signer := &ossSigner{bucket: bucket, accessKey: accessKey, secretKey: secretKey}
+	copySource := func(key string) string {
+		return "/" + bucket + escapeObjectKey(key)
+	}
+	return newRestObjectClient(baseURL, ossHeaderPrefix, signer, copySource), nil
+}
+
+// NewOSSFileSystem 阿里云oss，endpoint未指定时根据region生成，如oss-cn-beijing.aliyuncs.com
+func NewOSSFileSystem(properties map[string]interface{}) (UnderFileStorage, error) {
+	bucket := strings.TrimSuffix(propertyString(properties, fsCommon.Bucket), Delimiter)
+	accessKey := propertyString(properties, fsCommon.AccessKey)
+	secretKey := decryptSecret(propertyString(properties, fsCommon.SecretKey))
+	if bucket == "" || accessKey == "" || secretKey == "" {
+		return nil, fmt.Errorf("oss bucket, %s and %s must be provided", fsCommon.AccessKey, fsCommon.SecretKey)
+	}
+	endpoint := propertyString(properties, fsCommon.Endpoint)
+	if endpoint == "" {
+		region := propertyString(properties, fsCommon.Region)
+		if region == "" {
+			return nil, fmt.Errorf("oss %s or %s must be provided", fsCommon.Endpoint, fsCommon.Region)
+		}
+		endpoint = fmt.Sprintf(OSSEndpointTemplate, strings.TrimPrefix(region, "oss-"))
+	}
+	client, err := newOSSClient(endpoint, bucket, accessKey, secretKey)
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("new oss fs endpoint[%s] bucket[%s] subPath[%s]", endpoint, bucket, propertyString(properties, fsCommon.SubPath))
+	return newObjectFileSystem(fsCommon.OSSType, client, properties)
+}
+
+func init() {
+	RegisterUFS(fsCommon.OSSType, NewOSSFileSystem)
+}
@@ -26,7 +26,6 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
-	"strconv"
 	"strings"
 	"sync"
 	"syscall"
@@ -54,9 +53,8 @@ const (
 	TmpPath          = "./tmp/pfs/"
 	MaxFileSize      = 5 * 1024 * 1024 * 1024 * 1024 // s3: support upto 5 TiB file size
 	// mpu
-	MPURetryTimes   = 2
+	MPURetryTimes   = 3
 	MPUThreshold    = 200 * 1024 * 1024      // customized for performance
-	MPUChunkSize    = 1 * 1024 * 1024 * 1024 // chunk size 1 GiB
 	MPUMinPartSize  = 5 * 1024 * 1024        // s3: Each part must be at least 5 MB ~ 5 GB in size (except for the last part)
 	MPUMaxPartSize  = 5 * 1024 * 1024 * 1024 // s3: Each part must be at least 5 MB ~ 5 GB in size (except for the last part)
 	MPUMaxPartNum   = 10000                  // s3: between 1~10,000
@@ -68,6 +66,8 @@ var Owner string
 var Group string
 
 type s3FileSystem struct {
+	// ufsType s3协议兼容的存储类型，如s3、gcs
+	ufsType     string
 	bucket      string
 	subpath     string // bucket:subpath/name
 	dirMode     int
@@ -76,14 +76,15 @@ type s3FileSystem struct {
 	s3          *s3.S3
 	defaultTime time.Time
 	sync.Mutex
-	chunkPool *sync.Pool
+	mpu        multipartConfig
+	bufferPool *partBufferPool
 }
 
 var _ UnderFileStorage = &s3FileSystem{}
 
 // Used for pretty printing.
 func (fs *s3FileSystem) String() string {
-	return fsCommon.S3Type
+	return fs.ufsType
 }
 
 func (fs *s3FileSystem) getFullPath(name string) string {
@@ -609,21 +610,42 @@ func (fs *s3FileSystem) renameChildren(srcName, dstName string) (err error) {
 		}
 	}
 	log.Debugf("rename copies %v", copied)
+	err = fs.deleteObjects(copied)
+	if err != nil {
+		log.Errorf("s3 renameChildren: [%s]->[%s] deleteObjects err: %v", srcName, dstName, err)
+	}
+	return err
+}
+
+func (fs *s3FileSystem) deleteObjects(keys []string) error {
+	// gcs的xml接口不支持批量删除，逐个删除
+	if fs.ufsType == fsCommon.GCSType {
+		group := new(errgroup.Group)
+		for i := range keys {
+			key := keys[i]
+			group.Go(func() error {
+				_, err := fs.s3.DeleteObject(&s3.DeleteObjectInput{
+					Bucket: &fs.bucket,
+					Key:    &key,
+				})
+				return err
+			})
+		}
+		return group.Wait()
+	}
+
 	var items s3.Delete
-	var objs = make([]*s3.ObjectIdentifier, len(copied))
+	var objs = make([]*s3.ObjectIdentifier, len(keys))
 
-	for i, _ := range copied {
-		objs[i] = &s3.ObjectIdentifier{Key: &copied[i]}
+	for i, _ := range keys {
+		objs[i] = &s3.ObjectIdentifier{Key: &keys[i]}
 	}
 	items.SetObjects(objs)
 
-	_, err = fs.s3.DeleteObjects(&s3.DeleteObjectsInput{
+	_, err := fs.s3.DeleteObjects(&s3.DeleteObjectsInput{
 		Bucket: &fs.bucket,
 		Delete: &items,
 	})
-	if err != nil {
-		log.Errorf("s3 renameChildren: [%s]->[%s] s3.DeleteObjects err: %v", srcName, dstName, err)
-	}
 	return err
 }
 
@@ -720,7 +742,21 @@ func (fs *s3FileSystem) Unlink(name string) error {
 
 // // Extended attributes.
 func (fs *s3FileSystem) GetXAttr(name string, attribute string) (data []byte, err error) {
-	return nil, syscall.ENOSYS
+	if attribute != XAttrStorageClass {
+		return nil, syscall.ENOSYS
+	}
+	key := fs.getFullPath(name)
+	object, err := fs.s3.HeadObject(&s3.HeadObjectInput{Bucket: &fs.bucket, Key: &key})
+	if err != nil {
+		log.Errorf("s3 GetXAttr: name[%s] s3.HeadObject failed: %v", name, err)
+		return nil, err
+	}
+	// 标准存储的对象不返回StorageClass
+	storageClass := s3.StorageClassStandard
+	if object.StorageClass != nil {
+		storageClass = *object.StorageClass
+	}
+	return []byte(storageClass), nil
 }
 
 func (fs *s3FileSystem) ListXAttr(name string) (attributes []string, err error) {
@@ -732,7 +768,28 @@ func (fs *s3FileSystem) RemoveXAttr(name string, attr string) error {
 }
 
 func (fs *s3FileSystem) SetXAttr(name string, attr string, data []byte, flags int) error {
-	return syscall.ENOSYS
+	if attr != XAttrStorageClass {
+		return syscall.ENOSYS
+	}
+	return fs.setStorageClass(name, string(data))
+}
+
+// setStorageClass 通过复制对象自身转换其存储类型，对象元数据保持不变
+func (fs *s3FileSystem) setStorageClass(name, storageClass string) error {
+	key := fs.getFullPath(name)
+	source := fs.bucket + Delimiter + key
+	request := &s3.CopyObjectInput{
+		Bucket:            &fs.bucket,
+		Key:               &key,
+		CopySource:        &source,
+		StorageClass:      aws.String(storageClass),
+		MetadataDirective: aws.String(s3.MetadataDirectiveCopy),
+	}
+	if _, err := fs.s3.CopyObject(request); err != nil {
+		log.Errorf("s3 setStorageClass: name[%s] storageClass[%s] s3.CopyObject failed: %v", name, storageClass, err)
+		return err
+	}
+	return nil
 }
 
 func (fs *s3FileSystem) getOpenFlags(name string, flags uint32) int {
@@ -1062,92 +1119,15 @@ func (fh *s3FileHandle) serialMPUTillEnd() error {
 		return err
 	}
 	fileSize := fInfo.Size()
-	log.Tracef("s3 mpu: fh.name[%s], fileSize[%d]", fh.name, fileSize)
-	partSize, chunkSize, _ := fh.partAndChunkSize(fileSize)
-	chunkCnt := fileSize / chunkSize
-	chunkLeftover := fileSize % chunkSize
-	if chunkLeftover != 0 {
-		chunkCnt++
-	}
-	log.Tracef("s3 mpu: fh.name[%s], chunkCnt: %d, chunkLeftover: %d",
-		fh.name, chunkCnt, chunkLeftover)
-
-	partCnt := fileSize / partSize
-	partLeftover := fileSize % partSize
-	if partLeftover != 0 {
-		partCnt++
-	}
+	partSize := fh.fs.mpu.partSizeFor(fileSize, MPUMaxPartNum)
+	partCnt := (fileSize + partSize - 1) / partSize
+	log.Tracef("s3 mpu: fh.name[%s], fileSize[%d], partSize[%d], partCnt[%d], concurrency[%d]",
+		fh.name, fileSize, partSize, partCnt, fh.fs.mpu.concurrency)
 
 	fh.mpuInfo.lastPartNum = partCnt
 	fh.mpuInfo.partsETag = make([]*string, partCnt)
-	chunkEG := new(errgroup.Group)
-
-	log.Tracef("s3 mpu: fh.name[%s], fileSize[%d], chunkCnt[%d], partCnt[%d]",
-		fh.name, fileSize, chunkCnt, partCnt)
-	for i := int64(0); i < chunkCnt; i++ {
-		// read tmp file to chunks
-		chunkBuf := fh.fs.chunkPool.Get().([]byte)
-		// resize chunk buffer for the last chunk to avoid EOF error
-		if chunkLeftover != 0 && i == chunkCnt-1 {
-			chunkBuf = chunkBuf[:chunkLeftover]
-		} else {
-			chunkBuf = chunkBuf[:cap(chunkBuf)]
-		}
-		chunkNum := i
-		chunkEG.Go(func() error {
-			if err := fh.readChunkAndMPU(fileSize, chunkNum, chunkBuf); err != nil {
-				log.Errorf("s3 multipartUploadFile: readChunkAndMPU error: %v", err)
-				return err
-			}
-			return nil
-		})
-	}
-	if err := chunkEG.Wait(); err != nil {
-		log.Errorf("s3 multipartUploadFile: chunkEG.Wait() error: %v", err)
-		return err
-	}
-	return nil
-}
-
-func (fh *s3FileHandle) readChunkAndMPU(fileSize, chunkNum int64, chunk []byte) error {
-	defer func() {
-		fh.fs.chunkPool.Put(chunk)
-	}()
-	partSize, chunkSize, partsPerChunk := fh.partAndChunkSize(fileSize)
-
-	// read from file to chunk buffer
-	_, err := fh.writeTmpfile.ReadAt(chunk, chunkNum*chunkSize)
-	if err != nil {
-		log.Errorf("s3 mpu upload: fh.name[%s], failed reading temp file chunkNum: %d. err:%v", fh.name, chunkNum, err)
-		return err
-	}
-	partCnt := int64(len(chunk)) / partSize
-	if len(chunk)%int(partSize) != 0 {
-		partCnt += 1
-	}
-	mpuEG := new(errgroup.Group)
-	log.Tracef("s3 mpu readFileAndUploadChunks: fh.name[%s], chunkNum: %d, chunkLength: %d, partCnt: %d, partSize: %d",
-		fh.name, chunkNum, len(chunk), partCnt, partSize)
-	// partNum := lastPartNum + 1; partNum <= partCnt; partNum++
-	for i := int64(1); i <= partCnt; i++ {
-		partNum := partsPerChunk*chunkNum + i
-		start := (i - 1) * partSize
-		end := start + partSize
-		if end > int64(len(chunk)) {
-			end = int64(len(chunk))
-		}
-		mpuEG.Go(func() error {
-			if err := fh.multipartUpload(partNum, chunk[start:end]); err != nil {
-				log.Errorf("s3 readFileMPU: fh.name[%s], multipartUpload[%d] err: %v",
-					fh.name, partNum, err)
-				return err
-			}
-			return nil
-		})
-	}
-
-	if err := mpuEG.Wait(); err != nil {
-		log.Errorf("s3 multipartUploadFile: mpuEG.Wait() chunkNum[%d] error: %v", chunkNum, err)
+	if err := uploadFileParts(fh.writeTmpfile, fileSize, partSize, fh.fs.mpu, fh.fs.bufferPool, fh.multipartUpload); err != nil {
+		log.Errorf("s3 serialMPUTillEnd: fh.name[%s] upload parts err: %v", fh.name, err)
 		return err
 	}
 	return nil
@@ -1302,30 +1282,6 @@ func (fh *s3FileHandle) Allocate(off, size uint64, mode uint32) error {
 	return nil
 }
 
-func (fh *s3FileHandle) partAndChunkSize(fileSize int64) (partSize int64, chunkSize int64, partsPerChunk int64) {
-	chunkSize = MPUChunkSize // chunk size = 1 GiB
-	const MiB int64 = 1024 * 1024
-	const GiB int64 = 1024 * 1024 * 1024
-	if fileSize <= 8*GiB { // fileSize <= 8 GiB
-		// 8 MiB, 128 parts/chunk, total: 0 ~ 1,000 parts & chunks <= 8
-		partSize, partsPerChunk = 8*MiB, 128
-	} else if fileSize <= 256*GiB { // fileSize 8 GiB ~ 256 GiB
-		// 64 MiB, 16 parts/chunk, total: 125 ~ 4,000 parts & 8 ~ 256 chunks
-		partSize, partsPerChunk = 64*MiB, 16
-	} else if fileSize <= 2*1024*GiB { // fileSize 256 GiB ~ 2 TiB
-		// 512 MiB, 2 parts/chunk, total: 500 ~ 4000 parts & 256 ~ 2000 chunks
-		partSize, partsPerChunk = 512*MiB, 2
-	} else { // fileSize 2.5 TiB ~ 5 Tib
-		// 1 GiB, 1 parts/chunk, total: 2500 ~ 5000 parts & 2500 ~ 5000 chunks
-		partSize, partsPerChunk = 1*GiB, 1
-	}
-	if chunkSize%partSize != 0 || chunkSize/partSize != partsPerChunk {
-		log.Errorf("not valid partSize: %d or chunkSize: %d", partSize, chunkSize)
-		return 0, 0, 0
-	}
-	return partSize, chunkSize, partsPerChunk
-}
-
 func tidySubpath(subpath string) string {
 	for strings.HasPrefix(subpath, Delimiter) {
 		subpath = strings.TrimPrefix(subpath, Delimiter)
@@ -1347,25 +1303,13 @@ func NewS3FileSystem(properties map[string]interface{}) (UnderFileStorage, error
 	bucket := properties[fsCommon.Bucket].(string)
 	region := properties[fsCommon.Region].(string)
 	subpath := properties[fsCommon.SubPath].(string)
-	dirMode_, ok := properties[fsCommon.DirMode].(string)
-	var dirMode, fileMode int
-	var err error
-	if ok {
-		dirMode, err = strconv.Atoi(dirMode_)
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		dirMode = DefaultDirMode
+	dirMode, fileMode, err := objectStorageModes(properties)
+	if err != nil {
+		return nil, err
 	}
-	fileMode_, ok := properties[fsCommon.FileMode].(string)
-	if ok {
-		fileMode, err = strconv.Atoi(fileMode_)
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		fileMode = DefaultFileMode
+	mpu, err := parseMultipartConfig(properties)
+	if err != nil {
+		return nil, err
 	}
 
 	endpoint = strings.TrimSuffix(endpoint, Delimiter)
@@ -1414,6 +1358,7 @@ func NewS3FileSystem(properties map[string]interface{}) (UnderFileStorage, error
 	}
 
 	fs := &s3FileSystem{
+		ufsType:     fsCommon.S3Type,
 		bucket:      bucket,
 		subpath:     tidySubpath(subpath),
 		dirMode:     dirMode,
@@ -1421,9 +1366,8 @@ func NewS3FileSystem(properties map[string]interface{}) (UnderFileStorage, error
 		sess:        sess,
 		s3:          s3.New(sess),
 		defaultTime: time.Now(),
-		chunkPool: &sync.Pool{New: func() interface{} {
-			return make([]byte, MPUChunkSize)
-		}},
+		mpu:         mpu,
+		bufferPool:  newPartBufferPool(mpu),
 	}
 
 	exist, err := fs.isBucketExists(bucket)
@@ -1513,21 +1457,16 @@ func (fh *s3FileHandle) multipartUpload(partNum int64, data []byte) error {
 		PartNumber: aws.Int64(partNum),
 		UploadId:   fh.mpuInfo.uploadID,
 	}
-	// retry up to 3 times if upload a mpu failed
-	var err error
-	var resp *s3.UploadPartOutput
-	for retryNum := 0; retryNum < MPURetryTimes; retryNum++ {
-		mpu.Body = bytes.NewReader(data)
-		resp, err = fh.fs.s3.UploadPart(&mpu)
-		if err != nil {
-			log.Errorf("s3 mpu upload: fh.name[%s], upload part[%v] failed. err: %v. retryNum[%d]", fh.name, mpu, err, retryNum)
-		} else {
-			log.Tracef("s3 mpu upload: fh.name[%s], uploaded partNum: %d, eTag:%s, retryNum[%d]", fh.name, partNum, *resp.ETag, retryNum)
-			fh.mpuInfo.partsETag[partNum-1] = resp.ETag
-			return nil
-		}
+	// 失败重试由uploadFileParts处理
+	mpu.Body = bytes.NewReader(data)
+	resp, err := fh.fs.s3.UploadPart(&mpu)
+	if err != nil {
+		log.Errorf("s3 mpu upload: fh.name[%s], upload part[%d] failed. err: %v", fh.name, partNum, err)
+		return err
 	}
-	return err
+	log.Tracef("s3 mpu upload: fh.name[%s], uploaded partNum: %d, eTag:%s", fh.name, partNum, *resp.ETag)
+	fh.mpuInfo.partsETag[partNum-1] = resp.ETag
+	return nil
 }
 
 func (fh *s3FileHandle) multipartCommit() error {
@@ -46,6 +46,12 @@ func ToSyscallErrno(err error) syscall.Errno {
 		return errno
 	}
 
+	if linkError, ok := err.(*os.LinkError); ok {
+		if errno, ok := linkError.Err.(syscall.Errno); ok {
+			return errno
+		}
+	}
+
 	if pathError, ok := err.(*os.PathError); ok {
 		if pathError.Err == os.ErrNotExist {
 			return syscall.ENOENT
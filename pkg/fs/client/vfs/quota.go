@@ -0,0 +1,152 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vfs
+
+import (
+	"path"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/client/base"
+	ufslib "github.com/PaddlePaddle/PaddleFlow/pkg/fs/client/ufs"
+)
+
+// QuotaRescanInterval 定期重新扫描ufs校正用量，其他节点上的写入也会在重新扫描后计入
+var QuotaRescanInterval = 10 * time.Minute
+
+// quota 存储的容量与inode配额，0表示不限制。
+// 用量在挂载时扫描ufs得到，之后随写入、创建与删除增量更新，扫描完成前不做限制
+type quota struct {
+	capacity   int64
+	inodes     int64
+	usedSpace  int64
+	usedInodes int64
+	ready      int32
+}
+
+func newQuota(capacity, inodes int64) *quota {
+	if capacity <= 0 && inodes <= 0 {
+		return nil
+	}
+	return &quota{capacity: capacity, inodes: inodes}
+}
+
+func (q *quota) isReady() bool {
+	return q != nil && atomic.LoadInt32(&q.ready) == 1
+}
+
+// checkSpace 写入delta字节后超过容量配额时返回ENOSPC
+func (q *quota) checkSpace(delta int64) syscall.Errno {
+	if !q.isReady() || q.capacity <= 0 || delta <= 0 {
+		return syscall.F_OK
+	}
+	if atomic.LoadInt64(&q.usedSpace)+delta > q.capacity {
+		return syscall.ENOSPC
+	}
+	return syscall.F_OK
+}
+
+// checkInode 新建文件或目录后超过inode配额时返回ENOSPC
+func (q *quota) checkInode() syscall.Errno {
+	if !q.isReady() || q.inodes <= 0 {
+		return syscall.F_OK
+	}
+	if atomic.LoadInt64(&q.usedInodes)+1 > q.inodes {
+		return syscall.ENOSPC
+	}
+	return syscall.F_OK
+}
+
+func (q *quota) update(spaceDelta, inodeDelta int64) {
+	if q == nil {
+		return
+	}
+	if used := atomic.AddInt64(&q.usedSpace, spaceDelta); used < 0 {
+		atomic.StoreInt64(&q.usedSpace, 0)
+	}
+	if used := atomic.AddInt64(&q.usedInodes, inodeDelta); used < 0 {
+		atomic.StoreInt64(&q.usedInodes, 0)
+	}
+}
+
+func (q *quota) usage() (space, inodes int64) {
+	return atomic.LoadInt64(&q.usedSpace), atomic.LoadInt64(&q.usedInodes)
+}
+
+// scan 遍历ufs统计用量，不包含根目录
+func (q *quota) scan(ufs ufslib.UnderFileStorage) error {
+	var space, inodes int64
+	dirs := []string{"/"}
+	for len(dirs) > 0 {
+		dir := dirs[len(dirs)-1]
+		dirs = dirs[:len(dirs)-1]
+		entries, err := ufs.ReadDir(dir)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			inodes++
+			if entry.Attr.Type == ufslib.TypeDirectory {
+				dirs = append(dirs, path.Join(dir, entry.Name))
+				continue
+			}
+			space += int64(entry.Attr.Size)
+		}
+	}
+	atomic.StoreInt64(&q.usedSpace, space)
+	atomic.StoreInt64(&q.usedInodes, inodes)
+	atomic.StoreInt32(&q.ready, 1)
+	return nil
+}
+
+func (q *quota) scanLoop(ufs ufslib.UnderFileStorage) {
+	for {
+		if err := q.scan(ufs); err != nil {
+			log.Errorf("quota scan ufs failed: %v", err)
+		} else {
+			space, inodes := q.usage()
+			log.Infof("quota scan finished, used space[%d] inodes[%d]", space, inodes)
+		}
+		time.Sleep(QuotaRescanInterval)
+	}
+}
+
+// statFs df展示配额及其剩余量
+func (q *quota) statFs(st *base.StatfsOut) {
+	if q == nil {
+		return
+	}
+	space, inodes := q.usage()
+	if q.capacity > 0 && st.Bsize > 0 {
+		st.Blocks = uint64(q.capacity) / uint64(st.Bsize)
+		free := uint64(0)
+		if q.capacity > space {
+			free = uint64(q.capacity-space) / uint64(st.Bsize)
+		}
+		st.Bfree, st.Bavail = free, free
+	}
+	if q.inodes > 0 {
+		st.Files = uint64(q.inodes)
+		st.Ffree = 0
+		if q.inodes > inodes {
+			st.Ffree = uint64(q.inodes - inodes)
+		}
+	}
+}
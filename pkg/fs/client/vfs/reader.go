@@ -39,7 +39,7 @@ type DataReader interface {
 	Open(inode Ino, length uint64, ufs ufslib.UnderFileStorage, path string) (FileReader, error)
 }
 
-func NewDataReader(m meta.Meta, blockSize int, store cache.Store) DataReader {
+func NewDataReader(m meta.Meta, blockSize int, store cache.Store, wb *writeBack) DataReader {
 	bufferPool := cache.BufferPool{}
 	r := &dataReader{
 		m:          m,
@@ -47,6 +47,7 @@ func NewDataReader(m meta.Meta, blockSize int, store cache.Store) DataReader {
 		store:      store,
 		blockSize:  blockSize,
 		bufferPool: bufferPool.Init(blockSize),
+		writeBack:  wb,
 	}
 	return r
 }
@@ -66,6 +67,7 @@ type fileReader struct {
 	streamReader  io.ReadCloser
 	seqReadAmount uint64
 	readBufOffset uint64
+	readPattern   cache.ReadPattern
 }
 
 type dataReader struct {
@@ -75,12 +77,24 @@ type dataReader struct {
 	store      cache.Store
 	bufferPool *cache.BufferPool
 	blockSize  int
+	writeBack  *writeBack
 }
 
 func (fh *fileReader) Read(buf []byte, off uint64) (int, syscall.Errno) {
 	fh.Lock()
 	defer fh.Unlock()
 	log.Debugf("fileReader len[%d] off[%d] path[%s] length[%d]", len(buf), off, fh.path, fh.length)
+	if fh.reader.writeBack != nil {
+		// 未上传的数据从本地暂存读取
+		n, ok, err := fh.reader.writeBack.readAt(fh.path, buf, off)
+		if ok {
+			if err != nil {
+				log.Errorf("write back read err: %v", err)
+				return 0, syscall.EBADF
+			}
+			return n, syscall.F_OK
+		}
+	}
 	if off >= fh.length || len(buf) == 0 {
 		return 0, syscall.F_OK
 	}
@@ -89,6 +103,7 @@ func (fh *fileReader) Read(buf []byte, off uint64) (int, syscall.Errno) {
 	var nread int
 	bufSize := len(buf)
 	if fh.reader.store != nil {
+		readOff := off
 		reader := fh.reader.store.NewReader(fh.path, int(fh.length),
 			fh.flags, fh.ufs, fh.buffersCache, fh.reader.bufferPool, fh.seqReadAmount)
 		for bytesRead < bufSize {
@@ -120,6 +135,7 @@ func (fh *fileReader) Read(buf []byte, off uint64) (int, syscall.Errno) {
 				break
 			}
 		}
+		fh.reader.store.Prefetch(fh.path, int(fh.length), fh.flags, fh.ufs, &fh.readPattern, int64(readOff), bytesRead)
 	} else {
 		if fh.fd == nil {
 			log.Debug("fd is empty")
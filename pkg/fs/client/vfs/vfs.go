@@ -18,6 +18,7 @@ package vfs
 
 import (
 	"os"
+	"path/filepath"
 	"sync"
 	"syscall"
 	"time"
@@ -51,12 +52,15 @@ type VFS struct {
 	Meta       meta.Meta
 	Store      cache.Store
 	registry   *prometheus.Registry
+	quota      *quota
+	writeBack  *writeBack
 }
 
 type Config struct {
-	Cache *cache.Config
-	owner *Owner
-	Meta  *meta.Config
+	Cache     *cache.Config
+	owner     *Owner
+	Meta      *meta.Config
+	WriteBack *WriteBackConfig
 }
 
 type Owner struct {
@@ -107,6 +111,12 @@ func WithDataCacheConfig(data cache.Config) Option {
 	}
 }
 
+func WithWriteBack(wb WriteBackConfig) Option {
+	return func(config *Config) {
+		config.WriteBack = &wb
+	}
+}
+
 func InitVFS(fsMeta common.FSMeta, links map[string]common.FSMeta, global bool,
 	config *Config, registry *prometheus.Registry) (*VFS, error) {
 	log.Infof("InitVFS fsMeta %+v config %+v", fsMeta, config)
@@ -136,8 +146,18 @@ func InitVFS(fsMeta common.FSMeta, links map[string]common.FSMeta, global bool,
 		blockSize = config.Cache.BlockSize
 	}
 	vfs.Store = store
-	vfs.reader = NewDataReader(vfs.Meta, blockSize, store)
-	vfs.writer = NewDataWriter(vfs.Meta, blockSize, store)
+	if config.WriteBack != nil && config.WriteBack.Dir != "" {
+		wbConfig := *config.WriteBack
+		wbConfig.Dir = filepath.Join(wbConfig.Dir, fsMeta.ID)
+		ufs, _, _, _ := vfs.getUFS("/")
+		vfs.writeBack, err = newWriteBack(wbConfig, ufs)
+		if err != nil {
+			log.Errorf("new write back failed: %v", err)
+			return nil, err
+		}
+	}
+	vfs.reader = NewDataReader(vfs.Meta, blockSize, store, vfs.writeBack)
+	vfs.writer = NewDataWriter(vfs.Meta, blockSize, store, vfs.writeBack)
 	vfs.handleMap = make(map[Ino][]*handle)
 	vfs.nextfh = 1
 
@@ -149,6 +169,11 @@ func InitVFS(fsMeta common.FSMeta, links map[string]common.FSMeta, global bool,
 		return nil, err
 	}
 	initInternalNodes()
+	vfs.quota = newQuota(fsMeta.CapacityQuota, fsMeta.InodeQuota)
+	if vfs.quota != nil {
+		ufs, _, _, _ := vfs.getUFS("/")
+		go vfs.quota.scanLoop(ufs)
+	}
 	log.Debugf("Init VFS: %+v", vfs)
 	return vfs, nil
 }
@@ -190,6 +215,7 @@ func (v *VFS) Lookup(ctx *meta.Context, parent Ino, name string) (entry *meta.En
 	if utils.IsError(err) {
 		return nil, err
 	}
+	v.stagedAttr(inode, attr)
 	log.Debugf("vfs lookup inode[%x] from meta: attr[%+v] ", inode, *attr)
 	entry = &meta.Entry{Ino: inode, Attr: attr}
 	return entry, err
@@ -208,6 +234,7 @@ func (v *VFS) GetAttr(ctx *meta.Context, ino Ino) (entry *meta.Entry, err syscal
 	if utils.IsError(err) {
 		return nil, err
 	}
+	v.stagedAttr(ino, attr)
 	log.Debugf("vfs getattr: %+v", *attr)
 	entry = &meta.Entry{Ino: ino, Attr: attr}
 	return entry, err
@@ -216,6 +243,22 @@ func (v *VFS) GetAttr(ctx *meta.Context, ino Ino) (entry *meta.Entry, err syscal
 func (v *VFS) SetAttr(ctx *meta.Context, ino Ino, set, mode, uid, gid uint32, atime, mtime int64, atimensec, mtimensec uint32, size uint64) (entry *meta.Entry, err syscall.Errno) {
 	log.Tracef("vfs setAttr: ino[%d], set[%d], mode[%d], uid[%d], gid[%d], size[%d]", ino, set, mode, uid, gid, size)
 
+	var growth int64
+	if set&meta.FATTR_SIZE != 0 {
+		if growth, err = v.checkGrowth(ctx, ino, size); utils.IsError(err) {
+			return entry, err
+		}
+	}
+
+	// 先上传未完成的数据，避免上传时覆盖truncate的结果
+	if set&meta.FATTR_SIZE != 0 && v.writeBack != nil {
+		if path := v.stagedPath(ino); path != "" {
+			if drainErr := v.writeBack.drain(path); drainErr != nil {
+				return entry, utils.ToSyscallErrno(drainErr)
+			}
+		}
+	}
+
 	// only truncate opened files
 	if set&meta.FATTR_SIZE != 0 {
 		fhs := v.findAllHandle(ino)
@@ -244,6 +287,7 @@ func (v *VFS) SetAttr(ctx *meta.Context, ino Ino, set, mode, uid, gid uint32, at
 	if utils.IsError(err) {
 		return entry, err
 	}
+	v.quota.update(growth, 0)
 	if v.Store != nil {
 		delCacheErr := v.Store.InvalidateCache(path, int(size))
 		if delCacheErr != nil {
@@ -284,7 +328,13 @@ func (v *VFS) Mknod(ctx *meta.Context, parent Ino, name string, mode uint32, rde
 		err = syscall.EPERM
 		return
 	}
+	if err = v.quota.checkInode(); utils.IsError(err) {
+		return
+	}
 	err = v.Meta.Mknod(ctx, parent, name, _type, mode&07777, 0, rdev, &ino, attr)
+	if !utils.IsError(err) {
+		v.quota.update(0, 1)
+	}
 	entry = &meta.Entry{Ino: ino, Attr: attr}
 	return
 }
@@ -292,18 +342,45 @@ func (v *VFS) Mknod(ctx *meta.Context, parent Ino, name string, mode uint32, rde
 func (v *VFS) Mkdir(ctx *meta.Context, parent Ino, name string, mode uint32, cumask uint16) (entry *meta.Entry, err syscall.Errno) {
 	var ino Ino
 	attr := &Attr{}
+	if err = v.quota.checkInode(); utils.IsError(err) {
+		return
+	}
 	err = v.Meta.Mkdir(ctx, parent, name, mode, cumask, &ino, attr)
+	if !utils.IsError(err) {
+		v.quota.update(0, 1)
+	}
 	entry = &meta.Entry{Ino: ino, Attr: attr}
 	return
 }
 
 func (v *VFS) Unlink(ctx *meta.Context, parent Ino, name string) (err syscall.Errno) {
+	var size int64
+	if v.quota != nil {
+		if _, attr, lookupErr := v.Meta.Lookup(ctx, parent, name); !utils.IsError(lookupErr) {
+			size = int64(attr.Size)
+		}
+	}
+	var stagedPath string
+	if v.writeBack != nil && v.writeBack.pending() {
+		if path := v.stagedPath(parent); path != "" {
+			stagedPath = filepath.Join(path, name)
+		}
+	}
 	err = v.Meta.Unlink(ctx, parent, name)
+	if !utils.IsError(err) {
+		v.quota.update(-size, -1)
+		if stagedPath != "" {
+			v.writeBack.discard(stagedPath)
+		}
+	}
 	return err
 }
 
 func (v *VFS) Rmdir(ctx *meta.Context, parent Ino, name string) (err syscall.Errno) {
 	err = v.Meta.Rmdir(ctx, parent, name)
+	if !utils.IsError(err) {
+		v.quota.update(0, -1)
+	}
 	return err
 }
 
@@ -322,6 +399,13 @@ func (v *VFS) Rename(ctx *meta.Context, parent Ino, name string, newparent Ino,
 	if utils.IsError(err) {
 		return err
 	}
+	if v.writeBack != nil && v.writeBack.pending() {
+		ufs, _, _, srcPath := v.getUFS(src)
+		if ufs == v.writeBack.ufs {
+			_, _, _, dstPath := v.getUFS(dst)
+			v.writeBack.rename(srcPath, dstPath)
+		}
+	}
 	if v.Store != nil {
 		delCacheErr := v.Store.InvalidateCache(src, int(attr.Size))
 		if delCacheErr != nil {
@@ -343,7 +427,18 @@ func (v *VFS) Link(ctx *meta.Context, ino Ino, newparent Ino, newname string) (e
 		err = syscall.EPERM
 		return
 	}
-	return nil, syscall.ENOSYS
+	var newIno Ino
+	attr := &Attr{}
+	if err = v.quota.checkInode(); utils.IsError(err) {
+		return
+	}
+	err = v.Meta.Link(ctx, ino, newparent, newname, &newIno, attr)
+	if utils.IsError(err) {
+		return
+	}
+	v.quota.update(0, 1)
+	entry = &meta.Entry{Ino: newIno, Name: newname, Attr: attr}
+	return
 }
 
 func (v *VFS) Symlink(ctx *meta.Context, path string, parent Ino, name string) (entry *meta.Entry, err syscall.Errno) {
@@ -370,7 +465,7 @@ func (v *VFS) GetXAttr(ctx *meta.Context, ino Ino, name string, size uint32) (da
 		return
 	}
 	err = v.Meta.GetXattr(ctx, ino, name, &data)
-	if size > 0 && len(data) > int(size) {
+	if !utils.IsError(err) && len(data) > int(size) {
 		err = syscall.ERANGE
 	}
 	return
@@ -390,7 +485,7 @@ func (v *VFS) ListXAttr(ctx *meta.Context, ino Ino, size uint32) (data []byte, e
 		data = append(data, value...)
 		data = append(data, 0)
 	}
-	if size > 0 && len(data) > int(size) {
+	if !utils.IsError(err) && len(data) > int(size) {
 		err = syscall.ERANGE
 	}
 
@@ -421,10 +516,14 @@ func (v *VFS) RemoveXAttr(ctx *meta.Context, ino Ino, name string) (err syscall.
 func (v *VFS) Create(ctx *meta.Context, parent Ino, name string, mode uint32, cumask uint16, flags uint32) (entry *meta.Entry, fh uint64, err syscall.Errno) {
 	var ino Ino
 	attr := &Attr{}
+	if err = v.quota.checkInode(); utils.IsError(err) {
+		return
+	}
 	ufs, path, err := v.Meta.Create(ctx, parent, name, mode, cumask, flags, &ino, attr)
 	if utils.IsError(err) {
 		return
 	}
+	v.quota.update(0, 1)
 	entry = &meta.Entry{Ino: ino, Attr: attr}
 	fh, errHandle := v.newFileHandle(ino, attr.Size, flags, ufs, path)
 	if errHandle != nil {
@@ -468,6 +567,11 @@ func (v *VFS) Open(ctx *meta.Context, ino Ino, flags uint32) (entry *meta.Entry,
 	if utils.IsError(err) {
 		return
 	}
+	if v.writeBack != nil && ufs == v.writeBack.ufs {
+		if size, ok := v.writeBack.size(path); ok {
+			attr.Size = uint64(size)
+		}
+	}
 	var errOpen error
 	fh, errOpen = v.newFileHandle(ino, attr.Size, flags, ufs, path)
 	if errOpen != nil {
@@ -545,13 +649,19 @@ func (v *VFS) Write(ctx *meta.Context, ino Ino, buf []byte, off, fh uint64) (err
 		err = syscall.EACCES
 		return
 	}
-	// todo:: 对写入的文件大小加上限制
 	// todo:: 限制并发写的情况
+	growth, err := v.checkGrowth(ctx, ino, off+uint64(len(buf)))
+	if utils.IsError(err) {
+		return err
+	}
 	err = h.writer.Write(buf, off)
 	if utils.IsError(err) {
 		return err
 	}
 	err = v.Meta.Write(ctx, ino, uint32(off), len(buf))
+	if !utils.IsError(err) {
+		v.quota.update(growth, 0)
+	}
 	return err
 }
 
@@ -662,6 +772,7 @@ func (v *VFS) StatFs(ctx *meta.Context) (*base.StatfsOut, syscall.Errno) {
 	if utils.IsError(err) {
 		return &base.StatfsOut{}, err
 	}
+	v.quota.statFs(statFs)
 	return statFs, syscall.F_OK
 }
 
@@ -683,10 +794,55 @@ func (v *VFS) Truncate(ctx *meta.Context, ino Ino, size, fh uint64) (err syscall
 		return
 	}
 
+	growth, err := v.checkGrowth(ctx, ino, size)
+	if utils.IsError(err) {
+		return err
+	}
 	err = h.writer.Truncate(size)
 	if utils.IsError(err) {
 		log.Debugf("vfs truncate: h.writer.Truncate err")
 		return err
 	}
-	return v.Meta.Truncate(ctx, ino, size)
+	err = v.Meta.Truncate(ctx, ino, size)
+	if !utils.IsError(err) {
+		v.quota.update(growth, 0)
+	}
+	return err
+}
+
+// stagedPath 返回inode在write-back所用ufs上的路径，不在该ufs上时返回空
+func (v *VFS) stagedPath(ino Ino) string {
+	ufs, _, _, path := v.getUFS(v.Meta.InoToPath(ino))
+	if ufs != v.writeBack.ufs {
+		return ""
+	}
+	return path
+}
+
+// stagedAttr 文件有未上传的数据时，以本地暂存的大小为准
+func (v *VFS) stagedAttr(ino Ino, attr *Attr) {
+	if v.writeBack == nil || !v.writeBack.pending() || attr.Type == meta.TypeDirectory {
+		return
+	}
+	if path := v.stagedPath(ino); path != "" {
+		if size, ok := v.writeBack.size(path); ok {
+			attr.Size = uint64(size)
+		}
+	}
+}
+
+// checkGrowth 计算文件大小变为newSize后的用量变化，超过容量配额时返回ENOSPC
+func (v *VFS) checkGrowth(ctx *meta.Context, ino Ino, newSize uint64) (int64, syscall.Errno) {
+	if v.quota == nil {
+		return 0, syscall.F_OK
+	}
+	attr := &Attr{}
+	if err := v.Meta.GetAttr(ctx, ino, attr); utils.IsError(err) {
+		return 0, err
+	}
+	growth := int64(newSize) - int64(attr.Size)
+	if growth <= 0 {
+		return growth, syscall.F_OK
+	}
+	return growth, v.quota.checkSpace(growth)
 }
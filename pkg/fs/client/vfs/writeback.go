@@ -0,0 +1,492 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vfs
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+
+	ufslib "github.com/PaddlePaddle/PaddleFlow/pkg/fs/client/ufs"
+)
+
+const (
+	writeBackDataSuffix    = ".data"
+	writeBackJournalSuffix = ".json"
+	writeBackUploadBufSize = 4 * 1024 * 1024
+	writeBackRetryInterval = 5 * time.Second
+	writeBackWorkers       = 4
+)
+
+type WriteBackConfig struct {
+	// Dir 本地暂存目录，需与挂载pod生命周期无关(如hostPath)，才能在崩溃后恢复
+	Dir string
+	// DirtyLimit 未上传数据总量上限(byte)，超过后close时同步上传，0表示不限制
+	DirtyLimit int64
+}
+
+// writeBackRecord 日志中记录的暂存文件，close时落盘，上传成功后删除
+type writeBackRecord struct {
+	ID   string `json:"id"`
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+}
+
+type stagedFile struct {
+	writeBackRecord
+	sync.Mutex
+	data *os.File
+	// version 每次close/fsync时递增，uploaded为已上传的版本
+	version  int64
+	uploaded int64
+	queued   bool
+	// modified 上次写入日志后有新的写入
+	modified bool
+	// closed 写入句柄已释放，上传完成后即可清理
+	closed bool
+}
+
+func (f *stagedFile) dirty() bool {
+	return f.version > f.uploaded
+}
+
+// writeBack 写回模式：新建文件的写入先落到本地暂存目录，close后异步上传到ufs，
+// fsync或未上传数据超过上限时同步上传。close时写入日志，进程崩溃重启后继续上传已close的文件
+type writeBack struct {
+	dir        string
+	dirtyLimit int64
+	ufs        ufslib.UnderFileStorage
+	lock       sync.Mutex
+	files      map[string]*stagedFile
+	queue      chan *stagedFile
+}
+
+func newWriteBack(config WriteBackConfig, ufs ufslib.UnderFileStorage) (*writeBack, error) {
+	if err := os.MkdirAll(config.Dir, 0755); err != nil {
+		return nil, err
+	}
+	wb := &writeBack{
+		dir:        config.Dir,
+		dirtyLimit: config.DirtyLimit,
+		ufs:        ufs,
+		files:      make(map[string]*stagedFile),
+		queue:      make(chan *stagedFile, 1024),
+	}
+	for i := 0; i < writeBackWorkers; i++ {
+		go wb.uploadLoop()
+	}
+	if err := wb.recover(); err != nil {
+		return nil, err
+	}
+	return wb, nil
+}
+
+// recover 重新上传日志中记录的文件，没有日志的暂存数据对应的文件未close过，直接删除
+func (wb *writeBack) recover() error {
+	entries, err := ioutil.ReadDir(wb.dir)
+	if err != nil {
+		return err
+	}
+	journaled := make(map[string]bool)
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), writeBackJournalSuffix) {
+			continue
+		}
+		data, err := ioutil.ReadFile(filepath.Join(wb.dir, entry.Name()))
+		record := writeBackRecord{}
+		if err == nil {
+			err = json.Unmarshal(data, &record)
+		}
+		if err != nil {
+			log.Errorf("write back: invalid journal[%s]: %v", entry.Name(), err)
+			continue
+		}
+		file, err := os.OpenFile(wb.dataPath(record.ID), os.O_RDWR, 0600)
+		if err != nil {
+			log.Errorf("write back: open data of journal[%s] failed: %v", entry.Name(), err)
+			continue
+		}
+		journaled[record.ID] = true
+		f := &stagedFile{writeBackRecord: record, data: file, version: 1, closed: true}
+		if old, ok := wb.files[record.Path]; ok {
+			// 同一路径只保留一份，暂存的先后无法区分时以后处理的为准
+			wb.remove(old)
+		}
+		wb.files[record.Path] = f
+		log.Infof("write back: recover file[%s] size[%d]", record.Path, record.Size)
+	}
+	for _, entry := range entries {
+		id := strings.TrimSuffix(entry.Name(), writeBackDataSuffix)
+		if strings.HasSuffix(entry.Name(), writeBackDataSuffix) && !journaled[id] {
+			os.Remove(filepath.Join(wb.dir, entry.Name()))
+		}
+	}
+	files := make([]*stagedFile, 0, len(wb.files))
+	for _, f := range wb.files {
+		f.queued = true
+		files = append(files, f)
+	}
+	go func() {
+		for _, f := range files {
+			wb.queue <- f
+		}
+	}()
+	return nil
+}
+
+func (wb *writeBack) dataPath(id string) string {
+	return filepath.Join(wb.dir, id+writeBackDataSuffix)
+}
+
+func (wb *writeBack) journalPath(id string) string {
+	return filepath.Join(wb.dir, id+writeBackJournalSuffix)
+}
+
+// open 为新建的文件创建暂存数据，同一路径有未上传的数据时先同步上传
+func (wb *writeBack) open(path string) (*stagedFile, error) {
+	if err := wb.drain(path); err != nil {
+		return nil, err
+	}
+	id := uuid.NewString()
+	file, err := os.OpenFile(wb.dataPath(id), os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return nil, err
+	}
+	f := &stagedFile{writeBackRecord: writeBackRecord{ID: id, Path: path}, data: file}
+	wb.lock.Lock()
+	wb.files[path] = f
+	wb.lock.Unlock()
+	return f, nil
+}
+
+func (wb *writeBack) get(path string) *stagedFile {
+	wb.lock.Lock()
+	defer wb.lock.Unlock()
+	return wb.files[path]
+}
+
+func (wb *writeBack) write(f *stagedFile, data []byte, offset uint64) error {
+	f.Lock()
+	defer f.Unlock()
+	if _, err := f.data.WriteAt(data, int64(offset)); err != nil {
+		return err
+	}
+	if end := int64(offset) + int64(len(data)); end > f.Size {
+		f.Size = end
+	}
+	f.modified = true
+	return nil
+}
+
+func (wb *writeBack) truncate(f *stagedFile, size uint64) error {
+	f.Lock()
+	defer f.Unlock()
+	if err := f.data.Truncate(int64(size)); err != nil {
+		return err
+	}
+	f.Size = int64(size)
+	f.modified = true
+	return nil
+}
+
+// readAt 路径有暂存数据时从本地读取，ok为false表示需从ufs读取
+func (wb *writeBack) readAt(path string, buf []byte, off uint64) (n int, ok bool, err error) {
+	f := wb.get(path)
+	if f == nil {
+		return 0, false, nil
+	}
+	f.Lock()
+	defer f.Unlock()
+	if int64(off) >= f.Size {
+		return 0, true, nil
+	}
+	if remain := f.Size - int64(off); int64(len(buf)) > remain {
+		buf = buf[:remain]
+	}
+	n, err = f.data.ReadAt(buf, int64(off))
+	if err == io.EOF {
+		err = nil
+	}
+	return n, true, err
+}
+
+// size 路径有暂存数据时返回暂存的大小，ufs上的文件在上传前大小不正确
+func (wb *writeBack) size(path string) (int64, bool) {
+	f := wb.get(path)
+	if f == nil {
+		return 0, false
+	}
+	f.Lock()
+	defer f.Unlock()
+	return f.Size, true
+}
+
+func (wb *writeBack) pending() bool {
+	wb.lock.Lock()
+	defer wb.lock.Unlock()
+	return len(wb.files) > 0
+}
+
+func (wb *writeBack) dirtyBytes() int64 {
+	wb.lock.Lock()
+	files := make([]*stagedFile, 0, len(wb.files))
+	for _, f := range wb.files {
+		files = append(files, f)
+	}
+	wb.lock.Unlock()
+	var dirty int64
+	for _, f := range files {
+		f.Lock()
+		if f.dirty() {
+			dirty += f.Size
+		}
+		f.Unlock()
+	}
+	return dirty
+}
+
+// commit 暂存数据落盘并写入日志，之后进程崩溃也不会丢失
+func (wb *writeBack) commit(f *stagedFile) error {
+	f.Lock()
+	defer f.Unlock()
+	if err := f.data.Sync(); err != nil {
+		return err
+	}
+	data, err := json.Marshal(f.writeBackRecord)
+	if err != nil {
+		return err
+	}
+	tmp := wb.journalPath(f.ID) + ".tmp"
+	if err = ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp, wb.journalPath(f.ID)); err != nil {
+		return err
+	}
+	f.version++
+	f.modified = false
+	return nil
+}
+
+// flush close时调用，未上传数据超过上限时同步上传，否则加入上传队列
+func (wb *writeBack) flush(f *stagedFile) error {
+	if err := wb.commit(f); err != nil {
+		return err
+	}
+	if wb.dirtyLimit > 0 && wb.dirtyBytes() > wb.dirtyLimit {
+		return wb.upload(f)
+	}
+	wb.enqueue(f)
+	return nil
+}
+
+// fsync 同步上传，返回时数据已写入ufs
+func (wb *writeBack) fsync(f *stagedFile) error {
+	if err := wb.commit(f); err != nil {
+		return err
+	}
+	return wb.upload(f)
+}
+
+func (wb *writeBack) release(f *stagedFile) {
+	f.Lock()
+	modified := f.modified || f.version == 0
+	f.Unlock()
+	if modified {
+		if err := wb.flush(f); err != nil {
+			log.Errorf("write back: flush file[%s] on release failed: %v", f.Path, err)
+		}
+	}
+	f.Lock()
+	f.closed = true
+	f.Unlock()
+	wb.cleanup(f)
+}
+
+func (wb *writeBack) enqueue(f *stagedFile) {
+	f.Lock()
+	if f.queued {
+		f.Unlock()
+		return
+	}
+	f.queued = true
+	f.Unlock()
+	wb.queue <- f
+}
+
+func (wb *writeBack) uploadLoop() {
+	for f := range wb.queue {
+		f.Lock()
+		f.queued = false
+		f.Unlock()
+		if err := wb.upload(f); err != nil {
+			log.Errorf("write back: upload file[%s] failed: %v, retry later", f.Path, err)
+			go func(f *stagedFile) {
+				time.Sleep(writeBackRetryInterval)
+				wb.enqueue(f)
+			}(f)
+		}
+	}
+}
+
+// upload 上传暂存数据的当前版本，上传期间阻塞对该文件的写入
+func (wb *writeBack) upload(f *stagedFile) error {
+	f.Lock()
+	if !f.dirty() || wb.get(f.Path) != f {
+		f.Unlock()
+		return nil
+	}
+	version := f.version
+	err := uploadToUFS(wb.ufs, f.Path, f.data, f.Size)
+	if err == nil {
+		f.uploaded = version
+	}
+	f.Unlock()
+	if err != nil {
+		return err
+	}
+	log.Debugf("write back: uploaded file[%s] size[%d]", f.Path, f.Size)
+	wb.cleanup(f)
+	return nil
+}
+
+func uploadToUFS(ufs ufslib.UnderFileStorage, path string, src io.ReaderAt, size int64) error {
+	fh, err := ufs.Create(path, uint32(os.O_WRONLY|os.O_CREATE|os.O_TRUNC), 0644)
+	if err != nil {
+		return err
+	}
+	defer fh.Release()
+	buf := make([]byte, writeBackUploadBufSize)
+	for off := int64(0); off < size; {
+		n, err := src.ReadAt(buf, off)
+		if n > 0 {
+			if int64(n) > size-off {
+				n = int(size - off)
+			}
+			if _, werr := fh.Write(buf[:n], uint64(off)); werr != nil {
+				return werr
+			}
+			off += int64(n)
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return fh.Flush()
+}
+
+// cleanup 已释放且已上传的文件删除暂存数据和日志
+func (wb *writeBack) cleanup(f *stagedFile) {
+	f.Lock()
+	done := f.closed && !f.dirty()
+	f.Unlock()
+	if !done {
+		return
+	}
+	wb.lock.Lock()
+	if wb.files[f.Path] == f {
+		delete(wb.files, f.Path)
+	}
+	wb.lock.Unlock()
+	wb.remove(f)
+}
+
+func (wb *writeBack) remove(f *stagedFile) {
+	f.data.Close()
+	os.Remove(wb.dataPath(f.ID))
+	os.Remove(wb.journalPath(f.ID))
+}
+
+// drain 同步上传路径上未上传的数据，用于重新打开写入、rename之前
+func (wb *writeBack) drain(path string) error {
+	f := wb.get(path)
+	if f == nil {
+		return nil
+	}
+	if err := wb.upload(f); err != nil {
+		log.Errorf("write back: drain file[%s] failed: %v", path, err)
+		return syscall.EIO
+	}
+	return nil
+}
+
+// rename 未上传的数据随文件或所在目录改名，之后上传到新路径
+func (wb *writeBack) rename(src, dst string) {
+	moved := make(map[string]*stagedFile)
+	wb.lock.Lock()
+	old, replaced := wb.files[dst]
+	if replaced {
+		delete(wb.files, dst)
+	}
+	for p, f := range wb.files {
+		if p != src && !strings.HasPrefix(p, src+"/") {
+			continue
+		}
+		delete(wb.files, p)
+		moved[dst+strings.TrimPrefix(p, src)] = f
+	}
+	for p, f := range moved {
+		wb.files[p] = f
+	}
+	wb.lock.Unlock()
+	if replaced {
+		old.Lock()
+		wb.remove(old)
+		old.Unlock()
+	}
+	// 持有wb.lock时不能获取文件锁，upload持锁顺序相反
+	for p, f := range moved {
+		f.Lock()
+		f.Path = p
+		committed := f.version > 0
+		f.Unlock()
+		if !committed {
+			continue
+		}
+		if err := wb.commit(f); err != nil {
+			log.Errorf("write back: rename file[%s] journal failed: %v", f.Path, err)
+		}
+		wb.enqueue(f)
+	}
+}
+
+// discard 文件被删除，丢弃未上传的数据
+func (wb *writeBack) discard(path string) {
+	wb.lock.Lock()
+	f, ok := wb.files[path]
+	if ok {
+		delete(wb.files, path)
+	}
+	wb.lock.Unlock()
+	if ok {
+		f.Lock()
+		wb.remove(f)
+		f.Unlock()
+	}
+}
@@ -45,12 +45,13 @@ type DataWriter interface {
 	// Truncate(path string, length uint64)
 }
 
-func NewDataWriter(m meta.Meta, blockSize int, store cache.Store) DataWriter {
+func NewDataWriter(m meta.Meta, blockSize int, store cache.Store, wb *writeBack) DataWriter {
 	w := &dataWriter{
 		m:         m,
 		files:     make(map[Ino]*fileWriter),
 		store:     store,
 		blockSize: blockSize,
+		writeBack: wb,
 	}
 	return w
 }
@@ -65,11 +66,16 @@ type fileWriter struct {
 
 	// TODO: 先用base.FileHandle跑通流程，后续修改ufs接口
 	fd ufslib.FileHandle
+	// staged 开启write-back时写入本地暂存，异步上传ufs
+	staged *stagedFile
 }
 
 func (f *fileWriter) Fallocate(size int64, off int64, mode uint32) syscall.Errno {
 	f.Lock()
 	defer f.Unlock()
+	if f.staged != nil {
+		return syscall.F_OK
+	}
 	return utils.ToSyscallErrno(f.fd.Allocate(uint64(off), uint64(size), mode))
 }
 
@@ -86,7 +92,11 @@ func (f *fileWriter) Write(data []byte, offset uint64) syscall.Errno {
 			return syscall.EBADF
 		}
 	}
-	_, err = f.fd.Write(data, offset)
+	if f.staged != nil {
+		err = f.writer.writeBack.write(f.staged, data, offset)
+	} else {
+		_, err = f.fd.Write(data, offset)
+	}
 	if err != nil {
 		log.Errorf("ufs write err: %v", err)
 		return syscall.EBADF
@@ -105,6 +115,9 @@ func (f *fileWriter) Flush() syscall.Errno {
 			return syscall.EBADF
 		}
 	}
+	if f.staged != nil {
+		return utils.ToSyscallErrno(f.writer.writeBack.flush(f.staged))
+	}
 	// todo:: 需要加一个超时和重试
 	return utils.ToSyscallErrno(f.fd.Flush())
 }
@@ -112,6 +125,9 @@ func (f *fileWriter) Flush() syscall.Errno {
 func (f *fileWriter) Fsync(fd int) syscall.Errno {
 	f.Lock()
 	defer f.Unlock()
+	if f.staged != nil {
+		return utils.ToSyscallErrno(f.writer.writeBack.fsync(f.staged))
+	}
 	// todo:: 需要加一个超时和重试
 	return utils.ToSyscallErrno(f.fd.Fsync(fd))
 }
@@ -122,10 +138,17 @@ func (f *fileWriter) Close() {
 
 func (f *fileWriter) release() {
 	delete(f.writer.files, f.inode)
+	if f.staged != nil {
+		f.writer.writeBack.release(f.staged)
+		return
+	}
 	f.fd.Release()
 }
 
 func (f *fileWriter) Truncate(size uint64) syscall.Errno {
+	if f.staged != nil {
+		return utils.ToSyscallErrno(f.writer.writeBack.truncate(f.staged, size))
+	}
 	return utils.ToSyscallErrno(f.fd.Truncate(size))
 }
 
@@ -136,20 +159,35 @@ type dataWriter struct {
 	files     map[Ino]*fileWriter
 	store     cache.Store
 	blockSize int
+	writeBack *writeBack
 }
 
 func (w *dataWriter) Open(inode Ino, length uint64, ufs ufslib.UnderFileStorage, path string) (FileWriter, error) {
-	fd, err := ufs.Open(path, syscall.O_WRONLY, length)
-	if err != nil {
-		return nil, err
-	}
 	f := &fileWriter{
 		writer: w,
 		inode:  inode,
 		path:   path,
 		length: length,
 		ufs:    ufs,
-		fd:     fd,
+	}
+	if w.writeBack != nil {
+		// 只暂存新建或清空的文件，其余情况先上传未完成的数据再直接写ufs
+		if length == 0 && ufs == w.writeBack.ufs {
+			staged, err := w.writeBack.open(path)
+			if err != nil {
+				return nil, err
+			}
+			f.staged = staged
+		} else if err := w.writeBack.drain(path); err != nil {
+			return nil, err
+		}
+	}
+	if f.staged == nil {
+		fd, err := ufs.Open(path, syscall.O_WRONLY, length)
+		if err != nil {
+			return nil, err
+		}
+		f.fd = fd
 	}
 	w.Lock()
 	w.files[inode] = f
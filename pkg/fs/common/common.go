@@ -25,6 +25,12 @@ const (
 	MockType             = "mock"
 	CFSType              = "cfs"
 	GlusterFSType        = "glusterfs"
+	ABSType              = "abs"
+	NFSType              = "nfs"
+	CephFSType           = "cephfs"
+	GCSType              = "gcs"
+	OSSType              = "oss"
+	COSType              = "cos"
 
 	// common
 	Owner = "owner"
@@ -60,6 +66,22 @@ const (
 	DirMode            = "dirMode"
 	FileMode           = "fileMode"
 
+	// 对象存储分片上传参数，分片大小与缓冲池大小为k8s quantity格式，如64Mi
+	MultipartPartSize       = "multipartPartSize"
+	MultipartConcurrency    = "multipartConcurrency"
+	MultipartBufferPoolSize = "multipartBufferPoolSize"
+
+	// 内核挂载的存储(nfs/cephfs/glusterfs)的挂载参数，多个参数以逗号分隔
+	MountOptions = "mountOptions"
+	// cephfs properties
+	CephUser   = "cephUser"
+	CephSecret = "cephSecret"
+
+	// azure blob properties, container记录在bucket中
+	AccountName = "accountName"
+	AccountKey  = "accountKey"
+	SASToken    = "sasToken"
+
 	// sftp properties
 	Address  = "address"
 	Password = "password"
@@ -90,4 +112,7 @@ type FSMeta struct {
 	Properties    map[string]string
 	// type: fs 表示是默认的后端存储；link 表示是外部存储
 	Type string
+	// 容量与inode配额，0表示不限制
+	CapacityQuota int64
+	InodeQuota    int64
 }
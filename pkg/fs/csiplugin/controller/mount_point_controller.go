@@ -33,7 +33,6 @@ import (
 	"k8s.io/client-go/util/workqueue"
 
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
-	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/common"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/csiplugin/mount"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/utils"
 )
@@ -50,6 +49,9 @@ type pvParams struct {
 	fsID    string
 	fsInfo  string
 	fsCache string
+	server  string
+	// secretRef 开启mountInfoSecret时存储配置不在pv属性中，重新挂载时从secret读取
+	secretRef *v1.SecretReference
 }
 
 // MountPointController will check the status of the mount point and remount unconnected mount point
@@ -84,6 +86,7 @@ func Initialize(nodeID string, masterNodesAware bool) *MountPointController {
 		return nil
 	}
 
+	registerMetrics()
 	sharedInformers := informers.NewSharedInformerFactory(k8sClient, 0)
 	pvInformer := sharedInformers.Core().V1().PersistentVolumes()
 
@@ -191,7 +194,9 @@ func (m *MountPointController) UpdatePodMap() error {
 	}
 
 	podMap := make(map[string]v1.Pod)
+	nodePodUIDs := make(map[string]bool)
 	for _, pod := range pods.Items {
+		nodePodUIDs[string(pod.UID)] = true
 		if pod.Status.Phase != v1.PodRunning && pod.Status.Phase != v1.PodPending {
 			continue
 		}
@@ -200,10 +205,15 @@ func (m *MountPointController) UpdatePodMap() error {
 	m.podMap = podMap
 	m.removePods = sync.Map{}
 
+	// release refs of pods which are gone from the node, so that mount pods would not leak
+	if err := mount.CleanStaleRefs(client, nodePodUIDs); err != nil {
+		log.Errorf("clean stale mount pod refs failed: %v", err)
+	}
+
 	pvs, err := client.ListPersistentVolume(metav1.ListOptions{})
 	for _, pv := range pvs.Items {
 		if pv.Spec.CSI != nil && pv.Spec.CSI.Driver == "paddleflowstorage" {
-			m.pvParamsMap[pv.Name] = buildPfsPvParams(pv.Spec.CSI.VolumeAttributes)
+			m.pvParamsMap[pv.Name] = buildPfsPvParams(pv.Spec.CSI)
 		}
 	}
 	return nil
@@ -231,9 +241,13 @@ func (m *MountPointController) Stop() {
 func (m *MountPointController) handleRunningPod(pod v1.Pod, updateMounts bool) {
 	podVolumeMounts := getPodVolumeMounts(&pod)
 	for _, volumeMount := range podVolumeMounts {
-		if err := m.CheckAndRemountVolumeMount(volumeMount); err != nil {
+		remounted, err := m.CheckAndRemountVolumeMount(volumeMount)
+		if err != nil {
 			log.Errorf("check and remount volume mount[%v] failed: %s", volumeMount, err)
 		}
+		if remounted {
+			m.recordRemount(&pod, volumeMount, err)
+		}
 
 		if updateMounts {
 			if err := m.UpdateMounts(volumeMount); err != nil {
@@ -244,31 +258,34 @@ func (m *MountPointController) handleRunningPod(pod v1.Pod, updateMounts bool) {
 	}
 }
 
-func (m *MountPointController) CheckAndRemountVolumeMount(volumeMount volumeMountInfo) error {
+// CheckAndRemountVolumeMount 挂载点断开时重新挂载，返回是否进行了重新挂载（包括重新挂载失败）
+func (m *MountPointController) CheckAndRemountVolumeMount(volumeMount volumeMountInfo) (bool, error) {
 	// TODO(dongzezhao) get mountParameters from volumeMountInfo
 	pvParams_, ok := m.pvParamsMap[volumeMount.VolumeName]
 	if !ok {
 		log.Errorf("get pfs parameters [%s] not exist", volumeMount.VolumeName)
-		return fmt.Errorf("get pfs parameters [%s] not exist", volumeMount.VolumeName)
+		return false, fmt.Errorf("get pfs parameters [%s] not exist", volumeMount.VolumeName)
 	}
 
 	// pods need to restore source mount path mountpoints
 	mountPath := utils.GetVolumeBindMountPathByPod(volumeMount.PodUID, volumeMount.VolumeName)
-	mountInfo, err := mount.ConstructMountInfo(pvParams_.fsInfo, pvParams_.fsCache, mountPath, nil, volumeMount.ReadOnly)
+	mountInfo, err := constructMountInfo(pvParams_, mountPath, volumeMount.ReadOnly)
 	if err != nil {
-		err := fmt.Errorf("ConstructMountInfo from pvParams: %+v failed: %v", pvParams_, err)
+		err := fmt.Errorf("ConstructMountInfo of fs[%s] failed: %v", pvParams_.fsID, err)
 		log.Errorf(err.Error())
-		return err
+		return false, err
 	}
 
-	if checkIfNeedRemount(mountPath) {
-		if err := remount(volumeMount, mountInfo); err != nil {
-			err := fmt.Errorf("remount info: %+v failed: %v", mountInfo, err)
-			log.Errorf(err.Error())
-			return err
-		}
+	if !checkIfNeedRemount(mountPath) {
+		return false, nil
 	}
-	return nil
+	remounted, err := remount(volumeMount, mountInfo)
+	if err != nil {
+		err := fmt.Errorf("remount info: %+v failed: %v", mountInfo, err)
+		log.Errorf(err.Error())
+		return true, err
+	}
+	return remounted, nil
 }
 
 func waitForBindSourceReady(bindSource string) bool {
@@ -299,13 +316,13 @@ func checkIfNeedRemount(path string) bool {
 	return false
 }
 
-func remount(volumeMount volumeMountInfo, mountInfo mount.Info) error {
+func remount(volumeMount volumeMountInfo, mountInfo mount.Info) (bool, error) {
 	log.Tracef("remount: mountInfo %+v", mountInfo)
 
-	if !mountInfo.FS.IndependentMountProcess && mountInfo.FS.Type != common.GlusterFSType {
-		// wait for source path ready
-		if !waitForBindSourceReady(schema.GetBindSource(mountInfo.FS.ID)) {
-			return nil
+	if !mountInfo.FS.IndependentMountProcess && !utils.IsKernelMountType(mountInfo.FS.Type) {
+		// wait for source path ready, mount pod is restarted by kubelet after fuse crashes
+		if !waitForBindSourceReady(mountInfo.SourcePath) {
+			return false, nil
 		}
 	} else {
 		// mount source path
@@ -319,47 +336,58 @@ func remount(volumeMount volumeMountInfo, mountInfo mount.Info) error {
 				if err := utils.ManualUnmount(mountInfo.SourcePath); err != nil {
 					err := fmt.Errorf("process remount[%s] failed when ManualUnmount source path %s. err: %v",
 						mountInfo.FS.ID, mountInfo.SourcePath, err)
-					return err
+					return false, err
 				}
 				// mount source path
 				output, err := utils.ExecCmdWithTimeout(mountInfo.Cmd, mountInfo.Args)
 				if err != nil {
 					log.Errorf("remount: process exec mount cmd failed: [%v], output[%v]", err, string(output))
-					return err
+					return false, err
 				}
 			}
 		case false:
 			if err != nil {
 				err := fmt.Errorf("fs[%s] source path[%s] not mp and err: %v", mountInfo.FS.ID, mountInfo.SourcePath, err)
-				return err
+				return false, err
 			} else {
 				// mount source path
 				output, err := utils.ExecCmdWithTimeout(mountInfo.Cmd, mountInfo.Args)
 				if err != nil {
 					log.Errorf("remount: process exec mount cmd failed: [%v], output[%v]", err, string(output))
-					return err
+					return false, err
 				}
 			}
 		}
 	}
 
+	// the broken bind mount still holds the dead fuse connection, unmount it before binding again
+	if err := utils.ManualUnmount(mountInfo.TargetPath); err != nil {
+		log.Errorf("remount: unmount broken target path[%s] failed: %v", mountInfo.TargetPath, err)
+		return false, err
+	}
 	// bind source path to mount path
 	output, err := utils.ExecMountBind(mountInfo.SourcePath, mountInfo.TargetPath, mountInfo.ReadOnly)
 	if err != nil {
 		log.Errorf("remount: pod exec mount bind cmd failed: %v, output[%s]", err, string(output))
-		return err
+		return false, err
 	}
 
 	// todo subpath need recovery
 	log.Debugf("volumeMount info %+v", volumeMount)
 	for _, subPath := range volumeMount.SubPaths {
+		if isMountPoint, _ := utils.IsMountPoint(subPath.TargetPath); isMountPoint {
+			if err := utils.ManualUnmount(subPath.TargetPath); err != nil {
+				log.Errorf("remount: unmount broken subPath[%s] failed: %v", subPath.TargetPath, err)
+				return false, err
+			}
+		}
 		output, err := utils.ExecMountBind(subPath.SourcePath, subPath.TargetPath, subPath.ReadOnly)
 		if err != nil {
 			log.Errorf("exec mount cmd failed: %v, output[%s]", err, string(output))
-			return err
+			return false, err
 		}
 	}
-	return nil
+	return true, nil
 }
 
 // UpdateMounts update mount
@@ -402,17 +430,41 @@ func (m *MountPointController) pvAddedUpdated(obj interface{}) {
 
 	// update pv
 	if pv.Spec.StorageClassName == "paddleflowstorage" {
-		m.pvParamsMap[pv.Name] = buildPfsPvParams(pv.Spec.CSI.VolumeAttributes)
+		m.pvParamsMap[pv.Name] = buildPfsPvParams(pv.Spec.CSI)
 	}
 }
 
-func buildPfsPvParams(params map[string]string) pvParams {
+func buildPfsPvParams(csi *v1.CSIPersistentVolumeSource) pvParams {
+	params := csi.VolumeAttributes
 	fsID := params[schema.PFSID]
 	fsInfo := params[schema.PFSInfo]
 	fsCache := params[schema.PFSCache]
 	return pvParams{
-		fsID:    fsID,
-		fsInfo:  fsInfo,
-		fsCache: fsCache,
+		fsID:      fsID,
+		fsInfo:    fsInfo,
+		fsCache:   fsCache,
+		server:    params[schema.PFSServer],
+		secretRef: csi.NodePublishSecretRef,
+	}
+}
+
+// constructMountInfo 根据pv参数构造挂载信息，存储配置在secret中时通过k8s client读取
+func constructMountInfo(params pvParams, mountPath string, readOnly bool) (mount.Info, error) {
+	if params.fsInfo != "" || params.secretRef == nil {
+		return mount.ConstructMountInfo(params.fsInfo, params.fsCache, params.server, mountPath, nil, readOnly)
+	}
+	k8sClient, err := utils.GetK8sClient()
+	if err != nil {
+		return mount.Info{}, err
+	}
+	secret, err := k8sClient.GetSecret(params.secretRef.Namespace, params.secretRef.Name)
+	if err != nil {
+		return mount.Info{}, fmt.Errorf("get mount info secret[%s/%s] failed: %v",
+			params.secretRef.Namespace, params.secretRef.Name, err)
+	}
+	secrets := make(map[string]string, len(secret.Data))
+	for k, v := range secret.Data {
+		secrets[k] = string(v)
 	}
+	return mount.ConstructMountInfoFromSecret(secrets, params.server, mountPath, nil, readOnly)
 }
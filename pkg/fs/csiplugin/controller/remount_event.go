@@ -0,0 +1,97 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/utils"
+)
+
+const (
+	EventReasonRemounted     = "PFSRemounted"
+	EventReasonRemountFailed = "PFSRemountFailed"
+	eventSourceComponent     = "paddleflow-csi-plugin"
+
+	remountResultSucceeded = "succeeded"
+	remountResultFailed    = "failed"
+)
+
+var remountTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "pfs_csi_remount_total",
+	Help: "remount of broken pfs mount points in work pods",
+}, []string{"fs_id", "result"})
+
+func registerMetrics() {
+	_ = prometheus.Register(remountTotal)
+}
+
+// recordRemount 挂载点断开重新挂载后，在使用该存储的pod上记录事件并计数，便于发现fuse进程异常
+func (m *MountPointController) recordRemount(pod *v1.Pod, volumeMount volumeMountInfo, remountErr error) {
+	event := buildRemountEvent(pod, volumeMount, m.nodeID, remountErr)
+	result := remountResultSucceeded
+	if remountErr != nil {
+		result = remountResultFailed
+	}
+	remountTotal.WithLabelValues(volumeMount.PFSID, result).Inc()
+
+	k8sClient, err := utils.GetK8sClient()
+	if err != nil {
+		log.Errorf("recordRemount: get k8s client failed: %v", err)
+		return
+	}
+	if _, err = k8sClient.CreateEvent(event); err != nil {
+		log.Errorf("recordRemount: create event for pod[%s/%s] failed: %v", pod.Namespace, pod.Name, err)
+	}
+}
+
+func buildRemountEvent(pod *v1.Pod, volumeMount volumeMountInfo, nodeID string, remountErr error) *v1.Event {
+	now := metav1.NewTime(time.Now())
+	event := &v1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: pod.Name + ".",
+			Namespace:    pod.Namespace,
+		},
+		InvolvedObject: v1.ObjectReference{
+			Kind:      "Pod",
+			Namespace: pod.Namespace,
+			Name:      pod.Name,
+			UID:       pod.UID,
+		},
+		Type:   v1.EventTypeNormal,
+		Reason: EventReasonRemounted,
+		Message: fmt.Sprintf("volume[%s] of fs[%s] was disconnected and has been remounted",
+			volumeMount.VolumeName, volumeMount.PFSID),
+		Source:         v1.EventSource{Component: eventSourceComponent, Host: nodeID},
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+	}
+	if remountErr != nil {
+		event.Type = v1.EventTypeWarning
+		event.Reason = EventReasonRemountFailed
+		event.Message = fmt.Sprintf("volume[%s] of fs[%s] remount failed: %v",
+			volumeMount.VolumeName, volumeMount.PFSID, remountErr)
+	}
+	return event
+}
@@ -17,6 +17,8 @@ limitations under the License.
 package csiconfig
 
 import (
+	"fmt"
+
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -70,13 +72,14 @@ func GeneratePodTemplate() *corev1.Pod {
 	}
 }
 
-func ParsePodResources(cpuLimit, memoryLimit string) (corev1.ResourceRequirements, error) {
+// ParsePodResources 未设置的资源使用默认值，request超过默认limit时limit随之提高
+func ParsePodResources(cpuLimit, memoryLimit, cpuRequest, memoryRequest string) (corev1.ResourceRequirements, error) {
 	podResource := corev1.ResourceRequirements{
 		Limits: map[corev1.ResourceName]resource.Quantity{
 			corev1.ResourceCPU:    resource.MustParse(defaultMountPodCpuLimit),
 			corev1.ResourceMemory: resource.MustParse(defaultMountPodMemLimit),
 		},
-		// Requests must be 0 so that scheduler can correctly calculate resource usage
+		// Requests default to 0 so that scheduler can correctly calculate resource usage
 		Requests: map[corev1.ResourceName]resource.Quantity{
 			corev1.ResourceCPU:    resource.MustParse(defaultMountPodCpuRequest),
 			corev1.ResourceMemory: resource.MustParse(defaultMountPodMemRequest),
@@ -94,5 +97,30 @@ func ParsePodResources(cpuLimit, memoryLimit string) (corev1.ResourceRequirement
 			return corev1.ResourceRequirements{}, err
 		}
 	}
+	if err = setRequest(podResource, corev1.ResourceCPU, cpuRequest, cpuLimit == ""); err != nil {
+		return corev1.ResourceRequirements{}, err
+	}
+	if err = setRequest(podResource, corev1.ResourceMemory, memoryRequest, memoryLimit == ""); err != nil {
+		return corev1.ResourceRequirements{}, err
+	}
 	return podResource, nil
 }
+
+func setRequest(podResource corev1.ResourceRequirements, name corev1.ResourceName, request string, defaultLimit bool) error {
+	if request == "" {
+		return nil
+	}
+	quantity, err := resource.ParseQuantity(request)
+	if err != nil {
+		return err
+	}
+	limit := podResource.Limits[name]
+	if quantity.Cmp(limit) > 0 {
+		if !defaultLimit {
+			return fmt.Errorf("%s request[%s] is greater than limit[%s]", name, request, limit.String())
+		}
+		podResource.Limits[name] = quantity
+	}
+	podResource.Requests[name] = quantity
+	return nil
+}
@@ -22,6 +22,7 @@ import (
 	"path/filepath"
 
 	"github.com/container-storage-interface/spec/lib/go/csi"
+	"github.com/kubernetes-csi/csi-lib-utils/protosanitizer"
 	"github.com/kubernetes-csi/drivers/pkg/csi-common"
 	log "github.com/sirupsen/logrus"
 	"golang.org/x/net/context"
@@ -29,7 +30,6 @@ import (
 	"google.golang.org/grpc/status"
 
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
-	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/common"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/csiplugin/csiconfig"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/csiplugin/mount"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/utils"
@@ -53,7 +53,7 @@ func (ns *nodeServer) NodeGetCapabilities(ctx context.Context, req *csi.NodeGetC
 
 func (ns *nodeServer) NodePublishVolume(ctx context.Context,
 	req *csi.NodePublishVolumeRequest) (*csi.NodePublishVolumeResponse, error) {
-	log.Infof("Node publish volume request [%+v]", *req)
+	log.Infof("Node publish volume request [%s]", protosanitizer.StripSecrets(req))
 	targetPath := req.GetTargetPath()
 	if exist, err := utils.Exist(targetPath); err != nil {
 		log.Errorf("check path[%s] exist failed: %v", targetPath, err)
@@ -75,8 +75,15 @@ func (ns *nodeServer) NodePublishVolume(ctx context.Context,
 		return nil, err
 	}
 
-	mountInfo, err := mount.ConstructMountInfo(volumeContext[schema.PFSInfo], volumeContext[schema.PFSCache],
-		targetPath, k8sClient, req.GetReadonly())
+	var mountInfo mount.Info
+	if volumeContext[schema.PFSInfo] == "" && len(req.GetSecrets()) > 0 {
+		// 存储配置由kubelet从pv的nodePublishSecretRef读取后传入
+		mountInfo, err = mount.ConstructMountInfoFromSecret(req.GetSecrets(), volumeContext[schema.PFSServer],
+			targetPath, k8sClient, req.GetReadonly())
+	} else {
+		mountInfo, err = mount.ConstructMountInfo(volumeContext[schema.PFSInfo], volumeContext[schema.PFSCache],
+			volumeContext[schema.PFSServer], targetPath, k8sClient, req.GetReadonly())
+	}
 	if err != nil {
 		log.Errorf("ConstructMountInfo err: %v", err)
 		return nil, status.Error(codes.Internal, err.Error())
@@ -96,11 +103,9 @@ func (ns *nodeServer) NodeUnpublishVolume(ctx context.Context,
 	mountInfo := mount.Info{
 		TargetPath: targetPath,
 	}
-	if err := mount.PodUnmount(req.VolumeId, mountInfo); err != nil {
-		log.Errorf("[UMount]: volumeID[%s] and targetPath[%s] with err: %s", req.VolumeId, mountInfo.TargetPath, err.Error())
-		return nil, err
-	}
 
+	// unmount target path before releasing the ref of mount pod, so that the mount pod
+	// would never be recycled while the volume is still bound into the work pod
 	pathsToCleanup := []string{targetPath}
 	// clean source path for process mount
 	// pod mount no source path to clean, and is ignored in the func
@@ -111,6 +116,10 @@ func (ns *nodeServer) NodeUnpublishVolume(ctx context.Context,
 		return nil, status.Error(codes.Internal, err.Error())
 	}
 
+	if err := mount.PodUnmount(req.VolumeId, mountInfo); err != nil {
+		log.Errorf("[UMount]: volumeID[%s] and targetPath[%s] with err: %s", req.VolumeId, mountInfo.TargetPath, err.Error())
+		return nil, err
+	}
 	return &csi.NodeUnpublishVolumeResponse{}, nil
 }
 
@@ -134,7 +143,7 @@ func (ns *nodeServer) NodeExpandVolume(ctx context.Context,
 
 func mountVolume(volumeID string, mountInfo mount.Info) error {
 	log.Infof("mountVolume: indepedentMp:%t, readOnly:%t", mountInfo.FS.IndependentMountProcess, mountInfo.ReadOnly)
-	if !mountInfo.FS.IndependentMountProcess && mountInfo.FS.Type != common.GlusterFSType {
+	if !mountInfo.FS.IndependentMountProcess && !utils.IsKernelMountType(mountInfo.FS.Type) {
 		// business pods use a separate source path
 		if err := mount.PFSMount(volumeID, mountInfo); err != nil {
 			log.Errorf("MountThroughPod err: %v", err)
@@ -18,11 +18,12 @@ package mount
 
 import (
 	"fmt"
+	"path"
 	"path/filepath"
-	"strings"
 
 	log "github.com/sirupsen/logrus"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/common"
@@ -42,6 +43,8 @@ type Info struct {
 	CacheConfig model.FSCacheConfig
 	FS          model.FileSystem
 	FSBase64Str string
+	// Server 存储所属apiserver的地址，多个apiserver可以共用一个集群
+	Server      string
 	TargetPath  string
 	SourcePath  string
 	Cmd         string
@@ -49,9 +52,25 @@ type Info struct {
 	ReadOnly    bool
 	K8sClient   utils.Client
 	PodResource corev1.ResourceRequirements
+
+	// MountInfoSecret 存储配置来自secret时，挂载pod同样从secret读取，不写入pod的启动命令
+	MountInfoSecret bool
+}
+
+func ConstructMountInfo(fsInfoBase64, fsCacheBase64, server, targetPath string, k8sClient utils.Client, readOnly bool) (Info, error) {
+	return constructMountInfo(fsInfoBase64, fsCacheBase64, server, targetPath, k8sClient, readOnly, false)
 }
 
-func ConstructMountInfo(fsInfoBase64, fsCacheBase64, targetPath string, k8sClient utils.Client, readOnly bool) (Info, error) {
+// ConstructMountInfoFromSecret 存储及缓存配置来自pv的nodePublishSecretRef，避免凭证写入pv
+func ConstructMountInfoFromSecret(secrets map[string]string, server, targetPath string, k8sClient utils.Client, readOnly bool) (Info, error) {
+	if secrets[schema.PFSInfo] == "" {
+		return Info{}, fmt.Errorf("mount info secret has no %s", schema.PFSInfo)
+	}
+	return constructMountInfo(secrets[schema.PFSInfo], secrets[schema.PFSCache], server, targetPath, k8sClient, readOnly, true)
+}
+
+func constructMountInfo(fsInfoBase64, fsCacheBase64, server, targetPath string, k8sClient utils.Client,
+	readOnly, fromSecret bool) (Info, error) {
 	// FS info
 	fs, err := utils.ProcessFSInfo(fsInfoBase64)
 	if err != nil {
@@ -69,17 +88,23 @@ func ConstructMountInfo(fsInfoBase64, fsCacheBase64, targetPath string, k8sClien
 	}
 
 	info := Info{
-		CacheConfig: cacheConfig,
-		FS:          fs,
-		FSBase64Str: fsInfoBase64,
-		TargetPath:  targetPath,
-		ReadOnly:    readOnly,
-		K8sClient:   k8sClient,
+		CacheConfig:     cacheConfig,
+		FS:              fs,
+		FSBase64Str:     fsInfoBase64,
+		Server:          server,
+		MountInfoSecret: fromSecret,
+		TargetPath:      targetPath,
+		ReadOnly:        readOnly,
+		K8sClient:       k8sClient,
 	}
 
-	if !fs.IndependentMountProcess && fs.Type != common.GlusterFSType {
+	if !fs.IndependentMountProcess && !utils.IsKernelMountType(fs.Type) {
 		info.SourcePath = schema.GetBindSource(info.FS.ID)
-		info.PodResource, err = csiconfig.ParsePodResources(cacheConfig.Resource.CpuLimit, cacheConfig.Resource.MemoryLimit)
+		if info.dedicated() {
+			info.SourcePath = schema.GetDedicatedBindSource(info.FS.ID, info.workPodUID())
+		}
+		rcs := cacheConfig.Resource
+		info.PodResource, err = csiconfig.ParsePodResources(rcs.CpuLimit, rcs.MemoryLimit, rcs.CpuRequest, rcs.MemoryRequest)
 		if err != nil {
 			err := fmt.Errorf("ParsePodResources: %+v err: %v", cacheConfig.Resource, err)
 			log.Errorf(err.Error())
@@ -92,9 +117,42 @@ func ConstructMountInfo(fsInfoBase64, fsCacheBase64, targetPath string, k8sClien
 	return info, nil
 }
 
+func (mountInfo *Info) workPodUID() string {
+	return utils.GetPodUIDFromTargetPath(mountInfo.TargetPath)
+}
+
+// dedicated 独占模式下每个使用该存储的pod有各自的挂载pod，无法获取pod uid时退回共享模式
+func (mountInfo *Info) dedicated() bool {
+	return mountInfo.CacheConfig.MountPodPolicy == schema.FsMountPodDedicated && mountInfo.workPodUID() != ""
+}
+
+// MountPodName 共享模式下同一节点上的存储共用一个挂载pod，独占模式下挂载pod名称带上使用方pod的uid
+func (mountInfo *Info) MountPodName(volumeID string) string {
+	if mountInfo.dedicated() {
+		return GeneratePodNameByVolumeID(volumeID) + "-" + mountInfo.workPodUID()
+	}
+	return GeneratePodNameByVolumeID(volumeID)
+}
+
+// mountSubPath 挂载pod中挂载点在宿主机挂载目录下的子路径，与SourcePath对应
+func (mountInfo *Info) mountSubPath() string {
+	if mountInfo.dedicated() {
+		return path.Join(mountInfo.FS.ID, mountInfo.workPodUID())
+	}
+	return mountInfo.FS.ID
+}
+
+// hostCacheDir 独占模式下各挂载pod使用缓存目录下的独立子目录，避免缓存相互干扰
+func (mountInfo *Info) hostCacheDir() string {
+	if mountInfo.CacheConfig.CacheDir != "" && mountInfo.dedicated() {
+		return path.Join(mountInfo.CacheConfig.CacheDir, mountInfo.workPodUID())
+	}
+	return mountInfo.CacheConfig.CacheDir
+}
+
 func (mountInfo *Info) cmdAndArgs() (string, []string) {
-	if mountInfo.FS.Type == common.GlusterFSType {
-		return mountName, mountInfo.glusterArgs()
+	if utils.IsKernelMountType(mountInfo.FS.Type) {
+		return mountName, mountInfo.kernelMountArgs()
 	} else if mountInfo.FS.IndependentMountProcess {
 		return PfsFuseIndependentMountProcessCMDName, mountInfo.processMountArgs()
 	} else {
@@ -102,10 +160,14 @@ func (mountInfo *Info) cmdAndArgs() (string, []string) {
 	}
 }
 
-func (mountInfo *Info) glusterArgs() (args []string) {
-	args = append(args, "-t", mountInfo.FS.Type,
-		strings.Join([]string{mountInfo.FS.ServerAddress, mountInfo.FS.SubPath}, ":"), mountInfo.SourcePath)
-	return args
+// kernelMountArgs glusterfs/nfs/cephfs由内核直接挂载，用户指定的mountOptions透传给mount命令
+func (mountInfo *Info) kernelMountArgs() (args []string) {
+	source, args, err := utils.KernelMountArgs(mountInfo.FS.Type, mountInfo.FS.ServerAddress,
+		mountInfo.FS.SubPath, mountInfo.FS.PropertiesMap)
+	if err != nil {
+		log.Errorf("kernelMountArgs of fs[%s] failed: %v", mountInfo.FS.ID, err)
+	}
+	return append(args, source, mountInfo.SourcePath)
 }
 
 func (mountInfo *Info) processMountArgs() (args []string) {
@@ -131,10 +193,13 @@ func (mountInfo *Info) cachePathArgs(independentProcess bool) (args []string) {
 	}
 	hasCache := false
 	if mountInfo.CacheConfig.CacheDir != "" {
-		hasCache = true
-		args = append(args, fmt.Sprintf("--%s=%s", "data-cache-path", cacheDir+DataCacheDir))
+		if dataCacheArgs := mountInfo.dataCacheArgs(cacheDir, independentProcess); len(dataCacheArgs) > 0 {
+			hasCache = true
+			args = append(args, dataCacheArgs...)
+		}
 	}
 	if mountInfo.CacheConfig.MetaDriver != schema.FsMetaMemory &&
+		!schema.IsSharedFsMetaDriver(mountInfo.CacheConfig.MetaDriver) &&
 		mountInfo.CacheConfig.CacheDir != "" {
 		hasCache = true
 		args = append(args, fmt.Sprintf("--%s=%s", "meta-cache-path", cacheDir+MetaCacheDir))
@@ -143,13 +208,54 @@ func (mountInfo *Info) cachePathArgs(independentProcess bool) (args []string) {
 	if hasCache && mountInfo.CacheConfig.CleanCache {
 		args = append(args, "--clean-cache=true")
 	}
+	if mountInfo.CacheConfig.WriteBack && mountInfo.CacheConfig.CacheDir != "" {
+		args = append(args, mountInfo.writeBackArgs(cacheDir)...)
+	}
+	return args
+}
+
+// dataCacheArgs 磁盘数据缓存目录及缓存块的压缩、加密方式
+func (mountInfo *Info) dataCacheArgs(cacheDir string, independentProcess bool) (args []string) {
+	if mountInfo.CacheConfig.CacheEncryption && independentProcess {
+		// 独立进程挂载时无法挂载密钥secret，不使用磁盘数据缓存，避免明文落盘
+		log.Warnf("fs[%s] cache encryption is not supported by independent fuse process, disk data cache disabled",
+			mountInfo.FS.ID)
+		return nil
+	}
+	args = append(args, fmt.Sprintf("--%s=%s", "data-cache-path", cacheDir+DataCacheDir))
+	if mountInfo.CacheConfig.CacheCompression != "" {
+		args = append(args, fmt.Sprintf("--%s=%s", "data-cache-compression", mountInfo.CacheConfig.CacheCompression))
+	}
+	if mountInfo.CacheConfig.CacheEncryption {
+		args = append(args, fmt.Sprintf("--%s=%s", "data-cache-key-file",
+			FusePodCacheKeyDir+"/"+schema.FsCacheKeySecretItem))
+	}
+	return args
+}
+
+// writeBackArgs write-back暂存目录与未上传数据上限
+func (mountInfo *Info) writeBackArgs(cacheDir string) (args []string) {
+	args = append(args, fmt.Sprintf("--%s=%s", "write-back-path", cacheDir+WriteBackDir))
+	if limit := mountInfo.CacheConfig.WriteBackDirtyLimit; limit != "" {
+		size, err := resource.ParseQuantity(limit)
+		if err != nil {
+			log.Errorf("fs[%s] writeBackDirtyLimit[%s] is invalid: %v", mountInfo.FS.ID, limit, err)
+		} else {
+			args = append(args, fmt.Sprintf("--%s=%d", "write-back-dirty-limit", size.Value()))
+		}
+	}
 	return args
 }
 
 func (mountInfo *Info) commonOptions() []string {
 	var options []string
 	options = append(options, fmt.Sprintf("--%s=%s", "fs-id", mountInfo.FS.ID))
-	options = append(options, fmt.Sprintf("--%s=%s", "fs-info", mountInfo.FSBase64Str))
+	if !mountInfo.MountInfoSecret || mountInfo.FS.IndependentMountProcess {
+		options = append(options, fmt.Sprintf("--%s=%s", "fs-info", mountInfo.FSBase64Str))
+	}
+	if mountInfo.Server != "" {
+		options = append(options, fmt.Sprintf("--%s=%s", "server", mountInfo.Server))
+	}
 
 	if mountInfo.ReadOnly {
 		options = append(options, fmt.Sprintf("--%s=%s", "mount-options", ReadOnly))
@@ -161,6 +267,11 @@ func (mountInfo *Info) commonOptions() []string {
 	if mountInfo.CacheConfig.MetaDriver != "" {
 		options = append(options, fmt.Sprintf("--%s=%s", "meta-cache-driver", mountInfo.CacheConfig.MetaDriver))
 	}
+	if mountInfo.CacheConfig.MetaAddress != "" {
+		options = append(options, fmt.Sprintf("--%s=%s", "meta-cache-address", mountInfo.CacheConfig.MetaAddress))
+	}
+	options = append(options, mountInfo.dataCacheLimitOptions()...)
+	options = append(options, mountInfo.metaCacheOptions()...)
 	if mountInfo.CacheConfig.ExtraConfigMap != nil {
 		for configName, item := range mountInfo.CacheConfig.ExtraConfigMap {
 			options = append(options, fmt.Sprintf("--%s=%s", configName, item))
@@ -170,8 +281,8 @@ func (mountInfo *Info) commonOptions() []string {
 		options = append(options, "--log-level=debug")
 	}
 
-	// s3 default mount permission
-	if mountInfo.FS.Type == common.S3Type {
+	// object storage default mount permission
+	if isObjectStorage(mountInfo.FS.Type) {
 		if mountInfo.FS.PropertiesMap[common.FileMode] != "" {
 			options = append(options, fmt.Sprintf("--%s=%s", "file-mode", mountInfo.FS.PropertiesMap[common.FileMode]))
 		} else {
@@ -186,6 +297,57 @@ func (mountInfo *Info) commonOptions() []string {
 	return options
 }
 
+// dataCacheLimitOptions 数据缓存的过期时间、容量上限、淘汰策略、内存缓存大小与顺序读预取，放在extraConfig之前，extraConfig中同名参数优先
+func (mountInfo *Info) dataCacheLimitOptions() []string {
+	var options []string
+	cacheConfig := mountInfo.CacheConfig
+	if cacheConfig.CacheTTL != "" {
+		options = append(options, fmt.Sprintf("--%s=%s", "data-cache-expire", cacheConfig.CacheTTL))
+	}
+	if cacheConfig.MaxCacheSize != "" {
+		size, err := resource.ParseQuantity(cacheConfig.MaxCacheSize)
+		if err != nil {
+			log.Errorf("fs[%s] maxCacheSize[%s] is invalid: %v", mountInfo.FS.ID, cacheConfig.MaxCacheSize, err)
+		} else {
+			options = append(options, fmt.Sprintf("--%s=%d", "data-cache-capacity", size.Value()))
+		}
+	}
+	if cacheConfig.EvictionPolicy != "" {
+		options = append(options, fmt.Sprintf("--%s=%s", "data-cache-evict-policy", cacheConfig.EvictionPolicy))
+	}
+	if cacheConfig.MemCacheSize != "" {
+		size, err := resource.ParseQuantity(cacheConfig.MemCacheSize)
+		if err != nil {
+			log.Errorf("fs[%s] memCacheSize[%s] is invalid: %v", mountInfo.FS.ID, cacheConfig.MemCacheSize, err)
+		} else {
+			options = append(options, fmt.Sprintf("--%s=%d", "data-cache-mem-size", size.Value()))
+		}
+	}
+	if cacheConfig.PrefetchBlocks > 0 {
+		options = append(options, fmt.Sprintf("--%s=%d", "data-prefetch-blocks", cacheConfig.PrefetchBlocks))
+		if cacheConfig.PrefetchWorkers > 0 {
+			options = append(options, fmt.Sprintf("--%s=%d", "data-prefetch-workers", cacheConfig.PrefetchWorkers))
+		}
+	}
+	return options
+}
+
+// metaCacheOptions 元数据属性、目录列表与不存在目录项的缓存时间，放在extraConfig之前，extraConfig中同名参数优先
+func (mountInfo *Info) metaCacheOptions() []string {
+	var options []string
+	cacheConfig := mountInfo.CacheConfig
+	if cacheConfig.AttrCacheTTL != "" {
+		options = append(options, fmt.Sprintf("--%s=%s", "meta-cache-expire", cacheConfig.AttrCacheTTL))
+	}
+	if cacheConfig.EntryCacheTTL != "" {
+		options = append(options, fmt.Sprintf("--%s=%s", "entry-cache-expire", cacheConfig.EntryCacheTTL))
+	}
+	if cacheConfig.NegativeCacheTTL != "" {
+		options = append(options, fmt.Sprintf("--%s=%s", "negative-entry-cache-expire", cacheConfig.NegativeCacheTTL))
+	}
+	return options
+}
+
 func (mountInfo *Info) CacheWorkerCmd() string {
 	cmd := CacheWorkerBin + " --podCachePath="
 	if mountInfo.CacheConfig.CacheDir != "" {
@@ -193,3 +355,11 @@ func (mountInfo *Info) CacheWorkerCmd() string {
 	}
 	return cmd
 }
+
+func isObjectStorage(fsType string) bool {
+	switch fsType {
+	case common.S3Type, common.GCSType, common.ABSType, common.OSSType, common.COSType:
+		return true
+	}
+	return false
+}
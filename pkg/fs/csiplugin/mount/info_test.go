@@ -58,7 +58,7 @@ func TestKubeRuntimePVAndPVC(t *testing.T) {
 	assert.Nil(t, err)
 	fsCacheBase64 := base64.StdEncoding.EncodeToString(fsCacheStr)
 
-	mountInfo, err := ConstructMountInfo(fsBase64, fsCacheBase64, "target", utils.GetFakeK8sClient(), false)
+	mountInfo, err := ConstructMountInfo(fsBase64, fsCacheBase64, "", "target", utils.GetFakeK8sClient(), false)
 	assert.Nil(t, err)
 	assert.Equal(t, fsBase64, mountInfo.FSBase64Str)
 	assert.Equal(t, fsCache.CacheDir, mountInfo.CacheConfig.CacheDir)
@@ -71,7 +71,7 @@ func TestKubeRuntimePVAndPVC(t *testing.T) {
 	fsCacheStr, err = json.Marshal(fsCache)
 	assert.Nil(t, err)
 	fsCacheBase64 = base64.StdEncoding.EncodeToString(fsCacheStr)
-	mountInfo, err = ConstructMountInfo(fsBase64, fsCacheBase64, "target", utils.GetFakeK8sClient(), false)
+	mountInfo, err = ConstructMountInfo(fsBase64, fsCacheBase64, "", "target", utils.GetFakeK8sClient(), false)
 	assert.Nil(t, err)
 	assert.Equal(t, "", mountInfo.CacheConfig.CacheDir)
 	assert.Equal(t, "", mountInfo.CacheConfig.FsID)
@@ -131,6 +131,35 @@ func TestInfo_MountCmdArgs(t *testing.T) {
 		ServerAddress: "127.0.0.1",
 	}
 
+	nfs := model.FileSystem{
+		Model: model.Model{
+			ID: "fs-root-nfs",
+		},
+		UserName:      "root",
+		Name:          "nfs",
+		Type:          common.NFSType,
+		SubPath:       "/export/data",
+		ServerAddress: "127.0.0.1",
+		PropertiesMap: map[string]string{
+			common.MountOptions: "vers=4.1,hard,timeo=600",
+		},
+	}
+
+	cephFS := model.FileSystem{
+		Model: model.Model{
+			ID: "fs-root-cephfs",
+		},
+		UserName:      "root",
+		Name:          "cephfs",
+		Type:          common.CephFSType,
+		SubPath:       "/volumes/data",
+		ServerAddress: "10.0.0.1:6789,10.0.0.2:6789",
+		PropertiesMap: map[string]string{
+			common.CephUser:   "admin",
+			common.CephSecret: "cephsecret",
+		},
+	}
+
 	fsInde := model.FileSystem{
 		Model: model.Model{
 			ID:        "fs-root-testfs",
@@ -203,6 +232,24 @@ func TestInfo_MountCmdArgs(t *testing.T) {
 			},
 			want: "mount -t glusterfs 127.0.0.1:default-volume " + sourcePath,
 		},
+		{
+			name: "test-nfs",
+			fields: fields{
+				FS:          nfs,
+				CacheConfig: fsCache,
+				TargetPath:  targetPath,
+			},
+			want: "mount -t nfs -o vers=4.1,hard,timeo=600 127.0.0.1:/export/data " + sourcePath,
+		},
+		{
+			name: "test-cephfs",
+			fields: fields{
+				FS:          cephFS,
+				CacheConfig: fsCache,
+				TargetPath:  targetPath,
+			},
+			want: "mount -t ceph -o name=admin,secret=cephsecret 10.0.0.1:6789,10.0.0.2:6789:/volumes/data " + sourcePath,
+		},
 		{
 			name: "test-pfs-fuse-no-cache",
 			fields: fields{
@@ -226,6 +273,23 @@ func TestInfo_MountCmdArgs(t *testing.T) {
 				"--data-cache-path=" + FusePodCachePath + DataCacheDir + " " +
 				"--meta-cache-path=" + FusePodCachePath + MetaCacheDir,
 		},
+		{
+			name: "test-pfs-fuse-redis-meta",
+			fields: fields{
+				FS: fs,
+				CacheConfig: model.FSCacheConfig{
+					FsID:        fs.ID,
+					CacheDir:    "/data/paddleflow-FS/mnt",
+					MetaDriver:  "redis",
+					MetaAddress: "redis://127.0.0.1:6379/1",
+				},
+				TargetPath: targetPath,
+			},
+			want: "/home/paddleflow/pfs-fuse mount --mount-point=/home/paddleflow/mnt/storage " +
+				"--fs-id=fs-root-testfs --fs-info=" + fsBase64 + " --meta-cache-driver=redis --meta-cache-address=redis://127.0.0.1:6379/1 " +
+				"--file-mode=0644 --dir-mode=0755 " +
+				"--data-cache-path=" + FusePodCachePath + DataCacheDir,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -237,7 +301,7 @@ func TestInfo_MountCmdArgs(t *testing.T) {
 			assert.Nil(t, err)
 			fsCacheBase64 := base64.StdEncoding.EncodeToString(fsCacheStr)
 
-			mountInfo, err := ConstructMountInfo(fsBase64, fsCacheBase64, tt.fields.TargetPath, utils.GetFakeK8sClient(), tt.fields.ReadOnly)
+			mountInfo, err := ConstructMountInfo(fsBase64, fsCacheBase64, "", tt.fields.TargetPath, utils.GetFakeK8sClient(), tt.fields.ReadOnly)
 			assert.Nil(t, err)
 
 			got := mountInfo.Cmd + " " + strings.Join(mountInfo.Args, " ")
@@ -247,3 +311,112 @@ func TestInfo_MountCmdArgs(t *testing.T) {
 		})
 	}
 }
+
+func TestInfo_dataCacheLimitOptions(t *testing.T) {
+	mountInfo := Info{
+		FS: model.FileSystem{Model: model.Model{ID: "fs-root-testfs"}},
+		CacheConfig: model.FSCacheConfig{
+			CacheDir:       "/data/paddleflow-FS/mnt",
+			MaxCacheSize:   "1Gi",
+			EvictionPolicy: "lfu",
+			CacheTTL:       "24h",
+			MemCacheSize:   "512Mi",
+		},
+	}
+	assert.Equal(t, []string{"--data-cache-expire=24h", "--data-cache-capacity=1073741824",
+		"--data-cache-evict-policy=lfu", "--data-cache-mem-size=536870912"}, mountInfo.dataCacheLimitOptions())
+
+	mountInfo.CacheConfig = model.FSCacheConfig{MaxCacheSize: "invalid"}
+	assert.Equal(t, 0, len(mountInfo.dataCacheLimitOptions()))
+
+	mountInfo.CacheConfig = model.FSCacheConfig{MemCacheSize: "512Mi", PrefetchBlocks: 8, PrefetchWorkers: 2}
+	assert.Equal(t, []string{"--data-cache-mem-size=536870912", "--data-prefetch-blocks=8", "--data-prefetch-workers=2"},
+		mountInfo.dataCacheLimitOptions())
+}
+
+func TestInfo_metaCacheOptions(t *testing.T) {
+	mountInfo := Info{
+		FS: model.FileSystem{Model: model.Model{ID: "fs-root-testfs"}},
+		CacheConfig: model.FSCacheConfig{
+			AttrCacheTTL:     "10s",
+			EntryCacheTTL:    "1m",
+			NegativeCacheTTL: "3s",
+		},
+	}
+	assert.Equal(t, []string{"--meta-cache-expire=10s", "--entry-cache-expire=1m", "--negative-entry-cache-expire=3s"},
+		mountInfo.metaCacheOptions())
+
+	mountInfo.CacheConfig = model.FSCacheConfig{}
+	assert.Equal(t, 0, len(mountInfo.metaCacheOptions()))
+}
+
+func TestInfo_writeBackArgs(t *testing.T) {
+	mountInfo := Info{
+		FS: model.FileSystem{Model: model.Model{ID: "fs-root-testfs"}},
+		CacheConfig: model.FSCacheConfig{
+			CacheDir:            "/data/paddleflow-FS/mnt",
+			MetaDriver:          "mem",
+			WriteBack:           true,
+			WriteBackDirtyLimit: "1Gi",
+		},
+	}
+	assert.Equal(t, []string{"--data-cache-path=" + FusePodCachePath + DataCacheDir,
+		"--write-back-path=" + FusePodCachePath + WriteBackDir, "--write-back-dirty-limit=1073741824"},
+		mountInfo.cachePathArgs(false))
+	assert.Equal(t, []string{"--data-cache-path=/data/paddleflow-FS/mnt" + DataCacheDir,
+		"--write-back-path=/data/paddleflow-FS/mnt" + WriteBackDir, "--write-back-dirty-limit=1073741824"},
+		mountInfo.cachePathArgs(true))
+
+	mountInfo.CacheConfig.CacheDir = ""
+	assert.Equal(t, 0, len(mountInfo.cachePathArgs(false)))
+}
+
+func TestInfo_dedicatedMountPod(t *testing.T) {
+	mountInfo := Info{
+		FS:         model.FileSystem{Model: model.Model{ID: "fs-root-testfs"}},
+		TargetPath: testTargetPath,
+		CacheConfig: model.FSCacheConfig{
+			CacheDir: "/data/paddleflow-FS/mnt",
+		},
+	}
+	assert.Equal(t, GeneratePodNameByVolumeID("pfs-fs-root-testfs-default-pv"), mountInfo.MountPodName("pfs-fs-root-testfs-default-pv"))
+	assert.Equal(t, "fs-root-testfs", mountInfo.mountSubPath())
+	assert.Equal(t, "/data/paddleflow-FS/mnt", mountInfo.hostCacheDir())
+
+	mountInfo.CacheConfig.MountPodPolicy = "dedicated"
+	assert.Equal(t, GeneratePodNameByVolumeID("pfs-fs-root-testfs-default-pv")+"-abc", mountInfo.MountPodName("pfs-fs-root-testfs-default-pv"))
+	assert.Equal(t, "fs-root-testfs/abc", mountInfo.mountSubPath())
+	assert.Equal(t, "/data/paddleflow-FS/mnt/abc", mountInfo.hostCacheDir())
+
+	// no work pod uid in target path, fall back to shared mount pod
+	mountInfo.TargetPath = "/invalid/target/path"
+	assert.Equal(t, "fs-root-testfs", mountInfo.mountSubPath())
+}
+
+func TestInfo_dataCacheArgs(t *testing.T) {
+	mountInfo := Info{
+		FS: model.FileSystem{Model: model.Model{ID: "fs-root-testfs"}},
+		CacheConfig: model.FSCacheConfig{
+			CacheDir:         "/data/paddleflow-FS/mnt",
+			MetaDriver:       "mem",
+			CacheCompression: "zstd",
+			CacheEncryption:  true,
+		},
+	}
+	assert.Equal(t, []string{"--data-cache-path=" + FusePodCachePath + DataCacheDir, "--data-cache-compression=zstd",
+		"--data-cache-key-file=" + FusePodCacheKeyDir + "/key"}, mountInfo.cachePathArgs(false))
+	// 独立进程无法挂载密钥，不使用磁盘数据缓存
+	assert.Equal(t, 0, len(mountInfo.cachePathArgs(true)))
+
+	mountInfo.TargetPath = testTargetPath
+	pod, err := buildMountPod("aaaaa", mountInfo)
+	assert.Nil(t, err)
+	found := false
+	for _, volume := range pod.Spec.Volumes {
+		if volume.Name == VolumesKeyCacheKey {
+			found = true
+			assert.Equal(t, "pfs-cache-key-fs-root-testfs", volume.Secret.SecretName)
+		}
+	}
+	assert.True(t, found)
+}
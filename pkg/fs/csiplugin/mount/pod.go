@@ -27,6 +27,7 @@ import (
 	"google.golang.org/grpc/status"
 	k8sCore "k8s.io/api/core/v1"
 	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/csiplugin/csiconfig"
@@ -38,44 +39,57 @@ const (
 	VolumesKeyMount     = "pfs-mount"
 	VolumesKeyDataCache = "data-cache"
 	VolumesKeyMetaCache = "meta-cache"
+	VolumesKeyWriteBack = "write-back"
+	VolumesKeyCacheKey  = "cache-key"
 
 	FusePodMountPoint = schema.FusePodMntDir + "/storage"
 	FusePodCachePath  = "/home/paddleflow/pfs-cache"
 	DataCacheDir      = "/data-cache"
 	MetaCacheDir      = "/meta-cache"
+	WriteBackDir      = "/write-back"
 	CacheWorkerBin    = "/home/paddleflow/cache-worker"
+	// FusePodCacheKeyDir 加密磁盘缓存块的密钥secret在挂载pod中的挂载目录
+	FusePodCacheKeyDir = "/home/paddleflow/pfs-cache-key"
+
+	livenessStatTimeout = 10
 
 	ContainerNameCacheWorker = "cache-worker"
 	ContainerNamePfsMount    = "pfs-mount"
 )
 
-var umountLock sync.RWMutex
+// refLock 挂载pod上的引用（annotation）是整体替换更新的，增删引用需要串行执行
+var refLock sync.Mutex
 
 func PodUnmount(volumeID string, mountInfo Info) error {
-	podName := GeneratePodNameByVolumeID(volumeID)
-	log.Infof("PodUnmount pod name is %s", podName)
-	umountLock.Lock()
-	defer umountLock.Unlock()
+	refLock.Lock()
+	defer refLock.Unlock()
 
 	k8sClient, err := utils.GetK8sClient()
 	if err != nil {
 		log.Errorf("PodUnmount: Get k8s client failed: %v", err)
 		return err
 	}
-	pod, err := k8sClient.GetPod(csiconfig.Namespace, podName)
-	if err != nil && !k8sErrors.IsNotFound(err) {
-		log.Errorf("PodUnmount: Get pod %s err: %v", podName, err)
-		return err
-	}
-	// if mount pod not exists. might be process mount
-	if pod == nil {
-		//log.Infof("PodUnmount: Mount pod %s not exists.", podName)
-		return nil
-	}
-
 	workPodUID := utils.GetPodUIDFromTargetPath(mountInfo.TargetPath)
+	// unmount request has no cache config, try shared mount pod first and then dedicated one
+	podNames := []string{GeneratePodNameByVolumeID(volumeID)}
 	if workPodUID != "" {
-		return removeRef(k8sClient, pod, workPodUID)
+		podNames = append(podNames, GeneratePodNameByVolumeID(volumeID)+"-"+workPodUID)
+	}
+	for _, podName := range podNames {
+		log.Infof("PodUnmount pod name is %s", podName)
+		pod, err := k8sClient.GetPod(csiconfig.Namespace, podName)
+		if err != nil && !k8sErrors.IsNotFound(err) {
+			log.Errorf("PodUnmount: Get pod %s err: %v", podName, err)
+			return err
+		}
+		// if mount pod not exists. might be process mount
+		if pod == nil {
+			continue
+		}
+		if workPodUID != "" {
+			return removeRef(k8sClient, pod, workPodUID)
+		}
+		return nil
 	}
 	return nil
 }
@@ -86,41 +100,101 @@ func PFSMount(volumeID string, mountInfo Info) error {
 		log.Errorf("PodMount: info: %+v err: %v", mountInfo, err)
 		return err
 	}
-	return waitUtilPodReady(mountInfo.K8sClient, GeneratePodNameByVolumeID(volumeID))
+	return waitUtilPodReady(mountInfo.K8sClient, mountInfo.MountPodName(volumeID))
 }
 
 func createOrUpdatePod(volumeID string, mountInfo Info) error {
-	podName := GeneratePodNameByVolumeID(volumeID)
+	podName := mountInfo.MountPodName(volumeID)
 	log.Infof("pod name is %s", podName)
 	for i := 0; i < 120; i++ {
-		// wait for old pod deleted
-		oldPod, errGetPod := mountInfo.K8sClient.GetPod(csiconfig.Namespace, podName)
-		if errGetPod != nil {
-			if k8sErrors.IsNotFound(errGetPod) {
-				// mount pod not exist, create
-				log.Infof("createOrAddRef: Need to create pod %s.", podName)
-				if createPodErr := createMountPod(mountInfo.K8sClient, volumeID, mountInfo); createPodErr != nil {
-					return createPodErr
-				}
-			} else {
-				// unexpect error
-				log.Errorf("createOrAddRef: Get pod %s err: %v", podName, errGetPod)
-				return errGetPod
-			}
-		} else if oldPod.DeletionTimestamp != nil {
-			// mount pod deleting. wait for 1 min.
-			log.Infof("createOrAddRef: wait for old mount pod deleted.")
-			time.Sleep(time.Millisecond * 500)
-			continue
-		} else {
-			// mount pod exist, update annotation
-			return addRef(mountInfo.K8sClient, oldPod, mountInfo.TargetPath)
+		done, err := createOrAddRef(podName, volumeID, mountInfo)
+		if err != nil || done {
+			return err
 		}
+		// mount pod deleting. wait for 1 min.
+		time.Sleep(time.Millisecond * 500)
 	}
 	return status.Errorf(codes.Internal, "Mount %v failed: mount pod %s has been deleting for 1 min",
 		mountInfo.FS.ID, podName)
 }
 
+// createOrAddRef 挂载pod不存在时创建，存在时增加引用；挂载pod正在删除时返回done=false等待重试
+func createOrAddRef(podName, volumeID string, mountInfo Info) (done bool, err error) {
+	refLock.Lock()
+	defer refLock.Unlock()
+	oldPod, errGetPod := mountInfo.K8sClient.GetPod(csiconfig.Namespace, podName)
+	if errGetPod != nil {
+		if k8sErrors.IsNotFound(errGetPod) {
+			// mount pod not exist, create
+			log.Infof("createOrAddRef: Need to create pod %s.", podName)
+			return true, createMountPod(mountInfo.K8sClient, volumeID, mountInfo)
+		}
+		// unexpect error
+		log.Errorf("createOrAddRef: Get pod %s err: %v", podName, errGetPod)
+		return false, errGetPod
+	}
+	if oldPod.DeletionTimestamp != nil {
+		log.Infof("createOrAddRef: wait for old mount pod deleted.")
+		return false, nil
+	}
+	// mount pod exist, update annotation
+	return true, addRef(mountInfo.K8sClient, oldPod, mountInfo.TargetPath)
+}
+
+// CleanStaleRefs 移除本节点挂载pod上已不在节点上运行、且挂载点已卸载的pod的引用。
+// csi-node重启期间错过的卸载请求会使引用残留，导致挂载pod无法被回收
+func CleanStaleRefs(c utils.Client, activePodUIDs map[string]bool) error {
+	label := csiconfig.PodTypeKey + "=" + csiconfig.PodMount + "," + schema.LabelKeyNodeName + "=" + csiconfig.NodeName
+	pods, err := c.ListPods(csiconfig.Namespace, metav1.ListOptions{LabelSelector: label})
+	if err != nil {
+		log.Errorf("CleanStaleRefs: list mount pods err: %v", err)
+		return err
+	}
+	for _, po := range pods.Items {
+		if err = cleanPodStaleRefs(c, po.Name, activePodUIDs); err != nil {
+			log.Errorf("CleanStaleRefs: mount pod[%s] err: %v", po.Name, err)
+		}
+	}
+	return nil
+}
+
+func cleanPodStaleRefs(c utils.Client, podName string, activePodUIDs map[string]bool) error {
+	refLock.Lock()
+	defer refLock.Unlock()
+	pod, err := c.GetPod(csiconfig.Namespace, podName)
+	if err != nil {
+		if k8sErrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if pod.DeletionTimestamp != nil {
+		return nil
+	}
+	removed := false
+	for key, targetPath := range pod.Annotations {
+		if !strings.HasPrefix(key, schema.AnnotationKeyMountPrefix) {
+			continue
+		}
+		workPodUID := strings.TrimPrefix(key, schema.AnnotationKeyMountPrefix)
+		if activePodUIDs[workPodUID] {
+			continue
+		}
+		// still mounted, wait for kubelet to unpublish the volume
+		if isMountPoint, _ := utils.IsMountPoint(targetPath); isMountPoint {
+			continue
+		}
+		log.Infof("mount pod[%s] remove stale ref of pod[%s] with target path[%s]", podName, workPodUID, targetPath)
+		delete(pod.Annotations, key)
+		removed = true
+	}
+	if !removed {
+		return nil
+	}
+	pod.Annotations[schema.AnnotationKeyMTime] = time.Now().Format(model.TimeFormat)
+	return c.PatchPodAnnotation(pod)
+}
+
 func addRef(c utils.Client, pod *k8sCore.Pod, targetPath string) error {
 	err := buildAnnotation(pod, targetPath)
 	if err != nil {
@@ -163,6 +237,12 @@ func createMountPod(k8sClient utils.Client, volumeID string, mountInfo Info) err
 		log.Errorf("buildMountPod[%s] err: %v", mountInfo.FS.ID, err)
 		return err
 	}
+	if mountInfo.MountInfoSecret {
+		if err = applyFsInfoSecret(k8sClient, mountInfo); err != nil {
+			log.Errorf("createMountPod apply fs info secret for fsID %s err: %v", mountInfo.FS.ID, err)
+			return err
+		}
+	}
 	log.Debugf("creating mount pod: %+v\n", *mountPod)
 	_, err = k8sClient.CreatePod(mountPod)
 	if err != nil {
@@ -174,20 +254,31 @@ func createMountPod(k8sClient utils.Client, volumeID string, mountInfo Info) err
 
 func buildMountPod(volumeID string, mountInfo Info) (*k8sCore.Pod, error) {
 	pod := csiconfig.GeneratePodTemplate()
-	pod.Name = GeneratePodNameByVolumeID(volumeID)
+	pod.Name = mountInfo.MountPodName(volumeID)
 	// annotate mount point & modified time
 	err := buildAnnotation(pod, mountInfo.TargetPath)
 	if err != nil {
 		return nil, err
 	}
 	// build volumes & containers
-	pod.Spec.Volumes = generatePodVolumes(mountInfo.CacheConfig.CacheDir)
+	pod.Spec.Volumes = generatePodVolumes(mountInfo.hostCacheDir(), mountInfo.CacheConfig.WriteBack)
+	if mountInfo.CacheConfig.CacheDir != "" && mountInfo.CacheConfig.CacheEncryption {
+		pod.Spec.Volumes = append(pod.Spec.Volumes, cacheKeyVolume(mountInfo.FS.ID))
+	}
 	pod.Spec.Containers[0] = buildMountContainer(baseContainer(pod.Name, mountInfo.PodResource), mountInfo)
 	pod.Spec.Containers[1] = buildCacheWorkerContainer(baseContainer(pod.Name, mountInfo.PodResource), mountInfo)
 
 	// label for pod listing
 	pod.Labels[schema.LabelKeyFsID] = mountInfo.FS.ID
 	pod.Labels[schema.LabelKeyNodeName] = csiconfig.NodeName
+	if mountInfo.Server != "" {
+		// apiserver只管理自己的挂载pod，访问凭证只来自该apiserver的secret
+		pod.Labels[schema.LabelKeyServer] = schema.ServerID(mountInfo.Server)
+		pod.Spec.Containers[0].Env = append(pod.Spec.Containers[0].Env, serverCredentialEnvs(mountInfo.Server)...)
+	}
+	if mountInfo.MountInfoSecret {
+		pod.Spec.Containers[0].Env = append(pod.Spec.Containers[0].Env, fsInfoEnv(mountInfo))
+	}
 	// labels for cache stats
 	pod.Labels[schema.LabelKeyCacheID] = model.CacheID(csiconfig.ClusterID,
 		csiconfig.NodeName, mountInfo.CacheConfig.CacheDir, mountInfo.FS.ID)
@@ -286,9 +377,11 @@ func baseContainer(podName string, podResource k8sCore.ResourceRequirements) k8s
 
 func buildMountContainer(mountContainer k8sCore.Container, mountInfo Info) k8sCore.Container {
 	mountContainer.Name = ContainerNamePfsMount
+	// fuse进程异常退出后容器重启，先卸载残留的挂载点再重新挂载
+	umountStale := "umount -l " + FusePodMountPoint + " >/dev/null 2>&1;"
 	mkdir := "mkdir -p " + FusePodMountPoint + ";"
 
-	cmd := mkdir + mountInfo.Cmd + " " + strings.Join(mountInfo.Args, " ")
+	cmd := umountStale + mkdir + mountInfo.Cmd + " " + strings.Join(mountInfo.Args, " ")
 	mountContainer.Command = []string{"sh", "-c", cmd}
 	statCmd := "stat -c %i " + FusePodMountPoint
 	mountContainer.ReadinessProbe = &k8sCore.Probe{
@@ -299,6 +392,17 @@ func buildMountContainer(mountContainer k8sCore.Container, mountInfo Info) k8sCo
 		InitialDelaySeconds: 1,
 		PeriodSeconds:       1,
 	}
+	// fuse进程卡住或挂载点断开时stat失败，由kubelet重启挂载容器
+	mountContainer.LivenessProbe = &k8sCore.Probe{
+		Handler: k8sCore.Handler{
+			Exec: &k8sCore.ExecAction{Command: []string{"sh", "-c", fmt.Sprintf(
+				"timeout %d %s >/dev/null", livenessStatTimeout, statCmd)},
+			}},
+		InitialDelaySeconds: 10,
+		PeriodSeconds:       10,
+		TimeoutSeconds:      livenessStatTimeout + 5,
+		FailureThreshold:    3,
+	}
 	mountContainer.Lifecycle = &k8sCore.Lifecycle{
 		PreStop: &k8sCore.Handler{
 			Exec: &k8sCore.ExecAction{Command: []string{"sh", "-c", fmt.Sprintf(
@@ -311,7 +415,7 @@ func buildMountContainer(mountContainer k8sCore.Container, mountInfo Info) k8sCo
 		{
 			Name:             VolumesKeyMount,
 			MountPath:        schema.FusePodMntDir,
-			SubPath:          mountInfo.FS.ID,
+			SubPath:          mountInfo.mountSubPath(),
 			MountPropagation: &mp,
 		},
 	}
@@ -327,12 +431,92 @@ func buildMountContainer(mountContainer k8sCore.Container, mountInfo Info) k8sCo
 			MountPropagation: &mp,
 		}
 		volumeMounts = append(volumeMounts, dataCacheVM, metaCacheVM)
+		if mountInfo.CacheConfig.WriteBack {
+			volumeMounts = append(volumeMounts, k8sCore.VolumeMount{
+				Name:             VolumesKeyWriteBack,
+				MountPath:        FusePodCachePath + WriteBackDir,
+				MountPropagation: &mp,
+			})
+		}
+		if mountInfo.CacheConfig.CacheEncryption {
+			volumeMounts = append(volumeMounts, k8sCore.VolumeMount{
+				Name:      VolumesKeyCacheKey,
+				MountPath: FusePodCacheKeyDir,
+				ReadOnly:  true,
+			})
+		}
 	}
 	mountContainer.VolumeMounts = volumeMounts
 	return mountContainer
 }
 
-func generatePodVolumes(cacheDir string) []k8sCore.Volume {
+// serverCredentialEnvs apiserver的访问凭证，secret不存在时不设置，挂载pod仍可启动
+func serverCredentialEnvs(server string) []k8sCore.EnvVar {
+	optional := true
+	secretEnv := func(name, item string) k8sCore.EnvVar {
+		return k8sCore.EnvVar{
+			Name: name,
+			ValueFrom: &k8sCore.EnvVarSource{
+				SecretKeyRef: &k8sCore.SecretKeySelector{
+					LocalObjectReference: k8sCore.LocalObjectReference{Name: schema.ServerSecretName(server)},
+					Key:                  item,
+					Optional:             &optional,
+				},
+			},
+		}
+	}
+	return []k8sCore.EnvVar{
+		secretEnv(schema.EnvKeyServerUserName, schema.FsServerSecretItemUserName),
+		secretEnv(schema.EnvKeyServerPassword, schema.FsServerSecretItemPassword),
+	}
+}
+
+// applyFsInfoSecret 将存储配置写入挂载pod命名空间下的secret，挂载pod通过环境变量读取
+func applyFsInfoSecret(k8sClient utils.Client, mountInfo Info) error {
+	secret := &k8sCore.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      schema.MountInfoSecretName(mountInfo.FS.ID, mountInfo.Server),
+			Namespace: csiconfig.Namespace,
+		},
+		StringData: map[string]string{schema.PFSInfo: mountInfo.FSBase64Str},
+	}
+	_, err := k8sClient.CreateSecret(secret)
+	if k8sErrors.IsAlreadyExists(err) {
+		_, err = k8sClient.UpdateSecret(secret)
+	}
+	return err
+}
+
+func fsInfoEnv(mountInfo Info) k8sCore.EnvVar {
+	return k8sCore.EnvVar{
+		Name: schema.EnvKeyFsInfo,
+		ValueFrom: &k8sCore.EnvVarSource{
+			SecretKeyRef: &k8sCore.SecretKeySelector{
+				LocalObjectReference: k8sCore.LocalObjectReference{
+					Name: schema.MountInfoSecretName(mountInfo.FS.ID, mountInfo.Server),
+				},
+				Key: schema.PFSInfo,
+			},
+		},
+	}
+}
+
+// cacheKeyVolume 存储的缓存加密密钥，secret不存在时挂载pod无法启动，避免缓存块以明文写入磁盘
+func cacheKeyVolume(fsID string) k8sCore.Volume {
+	return k8sCore.Volume{
+		Name: VolumesKeyCacheKey,
+		VolumeSource: k8sCore.VolumeSource{
+			Secret: &k8sCore.SecretVolumeSource{
+				SecretName: schema.FsCacheKeySecretPrefix + fsID,
+				Items: []k8sCore.KeyToPath{
+					{Key: schema.FsCacheKeySecretItem, Path: schema.FsCacheKeySecretItem},
+				},
+			},
+		},
+	}
+}
+
+func generatePodVolumes(cacheDir string, writeBack bool) []k8sCore.Volume {
 	typeDir := k8sCore.HostPathDirectoryOrCreate
 	volumes := []k8sCore.Volume{
 		{
@@ -367,6 +551,18 @@ func generatePodVolumes(cacheDir string) []k8sCore.Volume {
 			},
 		}
 		volumes = append(volumes, dataCacheVolume, metaCacheVolume)
+		// write-back暂存数据，不随clean-cache清理，挂载pod重建后继续上传
+		if writeBack {
+			volumes = append(volumes, k8sCore.Volume{
+				Name: VolumesKeyWriteBack,
+				VolumeSource: k8sCore.VolumeSource{
+					HostPath: &k8sCore.HostPathVolumeSource{
+						Path: cacheDir + WriteBackDir,
+						Type: &typeDir,
+					},
+				},
+			})
+		}
 	}
 	return volumes
 }
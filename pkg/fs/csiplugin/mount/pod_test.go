@@ -19,6 +19,7 @@ package mount
 import (
 	"encoding/base64"
 	"encoding/json"
+	"strings"
 	"testing"
 	"time"
 
@@ -118,7 +119,7 @@ func TestPFSMountWithCache(t *testing.T) {
 	assert.Nil(t, err)
 	fsCacheBase64 := base64.StdEncoding.EncodeToString(fsCacheStr)
 
-	info, err := ConstructMountInfo(fsBase64, fsCacheBase64, testTargetPath, fakeClientSet, false)
+	info, err := ConstructMountInfo(fsBase64, fsCacheBase64, "", testTargetPath, fakeClientSet, false)
 	assert.Nil(t, err)
 
 	patch1 := ApplyFunc(isPodReady, func(pod *k8sCore.Pod) bool {
@@ -154,13 +155,58 @@ func TestPFSMountWithCache(t *testing.T) {
 			assert.Equal(t, GeneratePodNameByVolumeID(tt.args.volumeID), newPod.Name)
 			assert.Equal(t, csiconfig.Namespace, newPod.Namespace)
 			assert.Equal(t, testTargetPath, newPod.Annotations[schema.AnnotationKeyMountPrefix+utils.GetPodUIDFromTargetPath(testTargetPath)])
-			assert.Equal(t, "mkdir -p /home/paddleflow/mnt/storage;"+
+			assert.Equal(t, "umount -l /home/paddleflow/mnt/storage >/dev/null 2>&1;mkdir -p /home/paddleflow/mnt/storage;"+
 				"/home/paddleflow/pfs-fuse mount --mount-point="+FusePodMountPoint+" --fs-id=fs-root-testfs --fs-info="+fsBase64+
 				" --block-size=4096 --meta-cache-driver=disk --file-mode=0644 --dir-mode=0755"+
 				" --data-cache-path="+FusePodCachePath+DataCacheDir+
 				" --meta-cache-path="+FusePodCachePath+MetaCacheDir, newPod.Spec.Containers[0].Command[2])
+			assert.NotNil(t, newPod.Spec.Containers[0].LivenessProbe)
 		})
 	}
+
+	server := "127.0.0.1:8999"
+	info, err = ConstructMountInfo(fsBase64, fsCacheBase64, server, testTargetPath, fakeClientSet, false)
+	assert.Nil(t, err)
+	pod, err := buildMountPod("bbbbb", info)
+	assert.Nil(t, err)
+	assert.Equal(t, schema.ServerID(server), pod.Labels[schema.LabelKeyServer])
+	assert.Contains(t, pod.Spec.Containers[0].Command[2], "--server="+server)
+	envs := map[string]string{}
+	for _, env := range pod.Spec.Containers[0].Env {
+		if env.ValueFrom != nil && env.ValueFrom.SecretKeyRef != nil {
+			envs[env.Name] = env.ValueFrom.SecretKeyRef.Name
+		}
+	}
+	assert.Equal(t, schema.ServerSecretName(server), envs[schema.EnvKeyServerUserName])
+	assert.Equal(t, schema.ServerSecretName(server), envs[schema.EnvKeyServerPassword])
+
+	// mount info from secret
+	secrets := map[string]string{schema.PFSInfo: fsBase64, schema.PFSCache: fsCacheBase64}
+	info, err = ConstructMountInfoFromSecret(secrets, server, testTargetPath, fakeClientSet, false)
+	assert.Nil(t, err)
+	assert.NotContains(t, strings.Join(info.Args, " "), "--fs-info")
+	err = createMountPod(fakeClientSet, "ccccc", info)
+	assert.Nil(t, err)
+	pod, err = fakeClientSet.GetPod(csiconfig.Namespace, info.MountPodName("ccccc"))
+	assert.Nil(t, err)
+	var fsInfoEnv *k8sCore.EnvVar
+	for i, env := range pod.Spec.Containers[0].Env {
+		if env.Name == schema.EnvKeyFsInfo {
+			fsInfoEnv = &pod.Spec.Containers[0].Env[i]
+		}
+	}
+	assert.NotNil(t, fsInfoEnv)
+	secretName := schema.MountInfoSecretName(fs.ID, server)
+	assert.Equal(t, secretName, fsInfoEnv.ValueFrom.SecretKeyRef.Name)
+	secret, err := fakeClientSet.GetSecret(csiconfig.Namespace, secretName)
+	assert.Nil(t, err)
+	assert.Equal(t, fsBase64, secret.StringData[schema.PFSInfo])
+	// update existing secret
+	err = applyFsInfoSecret(fakeClientSet, info)
+	assert.Nil(t, err)
+
+	_, err = ConstructMountInfoFromSecret(map[string]string{}, server, testTargetPath, fakeClientSet, false)
+	assert.NotNil(t, err)
 }
 
 func Test_addRef(t *testing.T) {
@@ -225,3 +271,40 @@ func Test_addRef(t *testing.T) {
 		})
 	}
 }
+
+func TestCleanStaleRefs(t *testing.T) {
+	csiconfig.Namespace = "default"
+	csiconfig.NodeName = "node1"
+	fakeClientSet := utils.GetFakeK8sClient()
+	mountPod := &k8sCore.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "pfs-node1-fs-root-stale",
+			Namespace: "default",
+			Labels: map[string]string{
+				csiconfig.PodTypeKey:    csiconfig.PodMount,
+				schema.LabelKeyNodeName: "node1",
+			},
+			Annotations: map[string]string{
+				schema.AnnotationKeyMountPrefix + "abc": testTargetPath,
+				schema.AnnotationKeyMountPrefix + "def": testTargetPath2,
+				schema.AnnotationKeyMTime:               time.Now().Format(model.TimeFormat),
+			},
+		},
+	}
+	_, err := fakeClientSet.CreatePod(mountPod)
+	assert.Nil(t, err)
+
+	err = CleanStaleRefs(fakeClientSet, map[string]bool{"abc": true})
+	assert.Nil(t, err)
+	newPod, err := fakeClientSet.GetPod("default", mountPod.Name)
+	assert.Nil(t, err)
+	assert.Equal(t, testTargetPath, newPod.Annotations[schema.AnnotationKeyMountPrefix+"abc"])
+	_, ok := newPod.Annotations[schema.AnnotationKeyMountPrefix+"def"]
+	assert.False(t, ok)
+
+	// no stale refs, nothing changed
+	err = CleanStaleRefs(fakeClientSet, map[string]bool{"abc": true})
+	assert.Nil(t, err)
+	newPod, _ = fakeClientSet.GetPod("default", mountPod.Name)
+	assert.Equal(t, 2, len(newPod.Annotations))
+}
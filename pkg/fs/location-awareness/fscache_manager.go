@@ -17,7 +17,9 @@ limitations under the License.
 package location_awareness
 
 import (
+	"encoding/json"
 	"math/rand"
+	"os"
 	"strconv"
 	"time"
 
@@ -25,6 +27,7 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/client/cache"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/utils"
 )
 
@@ -56,9 +59,45 @@ func PatchCacheStatsLoop(k8sClient utils.Client, podNamespace, podName, podCache
 		if err != nil {
 			log.Errorf("PatchPodLabel %+v err[%v]", pod.ObjectMeta.Labels, err)
 		}
+		if podCachePath != "" {
+			if err = patchCacheStats(k8sClient, podNamespace, podName, podCachePath); err != nil {
+				log.Errorf("patch cache stats of pod[%s] err[%v]", podName, err)
+			}
+		}
 
 		select {
 		case <-time.After(time.Duration(15+rand.Intn(10)) * time.Second):
 		}
 	}
 }
+
+type patchStringValue struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	Value string `json:"value"`
+}
+
+// patchCacheStats 将pfs-fuse写入缓存目录的命中统计更新到pod注解。
+// 只修改cacheStats一个key，避免覆盖csi同时写入的挂载引用注解
+func patchCacheStats(k8sClient utils.Client, podNamespace, podName, podCachePath string) error {
+	stats, err := cache.ReadStats(podCachePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	statsBytes, err := json.Marshal(stats)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal([]patchStringValue{{
+		Op:    "add",
+		Path:  "/metadata/annotations/" + schema.AnnotationKeyCacheStats,
+		Value: string(statsBytes),
+	}})
+	if err != nil {
+		return err
+	}
+	return k8sClient.PatchPod(podNamespace, podName, payload)
+}
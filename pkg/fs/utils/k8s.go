@@ -39,6 +39,7 @@ type Client interface {
 	ProxyGetPods(nodeID string) (result *corev1.PodList, err error)
 	CreatePod(pod *corev1.Pod) (*corev1.Pod, error)
 	GetPod(namespace, name string) (*corev1.Pod, error)
+	ListPods(namespace string, listOptions metav1.ListOptions) (*corev1.PodList, error)
 	PatchPod(namespace, name string, data []byte) error
 	UpdatePod(namespace string, pod *corev1.Pod) (*corev1.Pod, error)
 	DeletePod(pod *corev1.Pod) error
@@ -57,6 +58,12 @@ type Client interface {
 	// ns
 	GetNamespace(namespace string, getOptions metav1.GetOptions) (*corev1.Namespace, error)
 	ListNamespaces(listOptions metav1.ListOptions) (*corev1.NamespaceList, error)
+	// event
+	CreateEvent(event *corev1.Event) (*corev1.Event, error)
+	// secret
+	GetSecret(namespace, name string) (*corev1.Secret, error)
+	CreateSecret(secret *corev1.Secret) (*corev1.Secret, error)
+	UpdateSecret(secret *corev1.Secret) (*corev1.Secret, error)
 }
 
 type k8sClient struct {
@@ -159,6 +166,10 @@ func (c *k8sClient) GetPod(namespace, name string) (*corev1.Pod, error) {
 	return mntPod, nil
 }
 
+func (c *k8sClient) ListPods(namespace string, listOptions metav1.ListOptions) (*corev1.PodList, error) {
+	return c.CoreV1().Pods(namespace).List(context.TODO(), listOptions)
+}
+
 type PatchMapValue struct {
 	Op    string            `json:"op"`
 	Path  string            `json:"path"`
@@ -253,3 +264,19 @@ func (c *k8sClient) GetNamespace(namespace string, getOptions metav1.GetOptions)
 func (c *k8sClient) ListNamespaces(listOptions metav1.ListOptions) (*corev1.NamespaceList, error) {
 	return c.CoreV1().Namespaces().List(context.TODO(), listOptions)
 }
+
+func (c *k8sClient) CreateEvent(event *corev1.Event) (*corev1.Event, error) {
+	return c.CoreV1().Events(event.Namespace).Create(context.TODO(), event, metav1.CreateOptions{})
+}
+
+func (c *k8sClient) GetSecret(namespace, name string) (*corev1.Secret, error) {
+	return c.CoreV1().Secrets(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+}
+
+func (c *k8sClient) CreateSecret(secret *corev1.Secret) (*corev1.Secret, error) {
+	return c.CoreV1().Secrets(secret.Namespace).Create(context.TODO(), secret, metav1.CreateOptions{})
+}
+
+func (c *k8sClient) UpdateSecret(secret *corev1.Secret) (*corev1.Secret, error) {
+	return c.CoreV1().Secrets(secret.Namespace).Update(context.TODO(), secret, metav1.UpdateOptions{})
+}
@@ -18,9 +18,12 @@ package utils
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"runtime"
 	"strconv"
 	"strings"
 	"syscall"
@@ -28,6 +31,9 @@ import (
 
 	log "github.com/sirupsen/logrus"
 	"k8s.io/utils/mount"
+
+	apiCommon "github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/common"
 )
 
 const (
@@ -101,6 +107,9 @@ func IsLikelyMountPoint(path string) (bool, error) {
 }
 
 func IsMountPoint(path string) (bool, error) {
+	if runtime.GOOS == "darwin" {
+		return isMountPointByStat(path)
+	}
 	output, err := ExecCmdWithTimeout(MountPointCmdName, []string{path})
 	if err != nil {
 		if strings.Contains(string(output), IsNotMountPoint) ||
@@ -118,6 +127,23 @@ func IsMountPoint(path string) (bool, error) {
 	return true, nil
 }
 
+// isMountPointByStat macOS没有mountpoint命令，根据路径与父目录是否位于同一设备判断
+func isMountPointByStat(path string) (bool, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		// fuse进程退出后挂载点无法访问
+		if errors.Is(err, syscall.ENOTCONN) || errors.Is(err, syscall.ENXIO) {
+			return true, err
+		}
+		return false, err
+	}
+	parent, err := os.Stat(filepath.Dir(filepath.Clean(path)))
+	if err != nil {
+		return false, err
+	}
+	return info.Sys().(*syscall.Stat_t).Dev != parent.Sys().(*syscall.Stat_t).Dev, nil
+}
+
 func CleanUpMountPoint(path string) error {
 	// If extensiveMountPointCheck=false, IsLikelyNotMountPoint method will be used,
 	// which cannot recognize a mount point generated by linux mount bind command.
@@ -241,6 +267,49 @@ func ExecMount(sourcePath, targetPath string, args []string) ([]byte, error) {
 	return ExecCmdWithTimeout(cmdName, args)
 }
 
+// IsKernelMountType 由内核直接挂载的存储类型，不经过pfs-fuse
+func IsKernelMountType(fsType string) bool {
+	switch fsType {
+	case common.GlusterFSType, common.NFSType, common.CephFSType:
+		return true
+	default:
+		return false
+	}
+}
+
+// KernelMountArgs 返回内核挂载的source和mount参数(不含挂载点)
+func KernelMountArgs(fsType, serverAddress, subPath string, properties map[string]string) (string, []string, error) {
+	var options []string
+	source := serverAddress + ":" + subPath
+	switch fsType {
+	case common.GlusterFSType:
+	case common.NFSType:
+	case common.CephFSType:
+		if properties[common.CephUser] != "" {
+			options = append(options, "name="+properties[common.CephUser])
+		}
+		if properties[common.CephSecret] != "" {
+			// secret在apiserver中加密存储，解密失败时认为是明文
+			secret, err := apiCommon.AesDecrypt(properties[common.CephSecret], apiCommon.AESEncryptKey)
+			if err != nil {
+				secret = properties[common.CephSecret]
+			}
+			options = append(options, "secret="+secret)
+		}
+		fsType = "ceph"
+	default:
+		return "", nil, fmt.Errorf("fs type[%s] is not mounted by kernel", fsType)
+	}
+	if properties[common.MountOptions] != "" {
+		options = append(options, properties[common.MountOptions])
+	}
+	args := []string{"-t", fsType}
+	if len(options) > 0 {
+		args = append(args, "-o", strings.Join(options, ","))
+	}
+	return source, args, nil
+}
+
 func GetFileInode(path string) (uint64, error) {
 	fi, err := os.Stat(path)
 	if err != nil {
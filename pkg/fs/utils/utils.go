@@ -26,6 +26,8 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/middleware"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
@@ -56,6 +58,9 @@ const (
 	DefaultUpdateIntervalTime    = 15
 	DefaultUID                   = 601
 	DefaultGID                   = 601
+
+	rootTokenTTL     = time.Hour
+	rootTokenRefresh = 10 * time.Minute
 )
 
 // GetVolumeMountPath default value: /var/lib/kubelet/pods/{podUID}/volumes/{volumePluginName}/{volumeName}/mount
@@ -198,16 +203,39 @@ func GetMountPointCheckIntervalTime() int {
 	return intervalInt
 }
 
+// rootToken 缓存的内部root token，避免每次调用都创建新的会话
+type rootToken struct {
+	token    string
+	password string
+	expireAt time.Time
+}
+
+var (
+	rootTokenMu    sync.Mutex
+	rootTokenCache rootToken
+)
+
+// GetRootToken 返回服务内部使用的root token。token有效期为 rootTokenTTL，
+// 过期前 rootTokenRefresh 或root密码变更后重新签发，过期的会话由会话清理任务回收
 func GetRootToken(ctx *logger.RequestContext) (string, error) {
 	u, err := storage.Auth.GetUserByName(ctx, common.RootKey)
 	if err != nil {
 		return "", err
 	}
-	token, err := middleware.GenerateToken(u.Name, u.Password)
+	rootTokenMu.Lock()
+	defer rootTokenMu.Unlock()
+	now := time.Now()
+	if rootTokenCache.token != "" && rootTokenCache.password == u.Password &&
+		now.Add(rootTokenRefresh).Before(rootTokenCache.expireAt) {
+		return rootTokenCache.token, nil
+	}
+	expireAt := now.Add(rootTokenTTL)
+	token, err := middleware.GenerateInternalToken(ctx, u.Name, u.Password, "paddleflow-internal", expireAt)
 	if err != nil {
 		return "", err
 	}
-	return token, err
+	rootTokenCache = rootToken{token: token, password: u.Password, expireAt: expireAt}
+	return token, nil
 }
 
 func GetRandID(randNum int) string {
@@ -25,7 +25,11 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/fs/common"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage/driver"
 )
 
 func TestGetVolumeMountPath(t *testing.T) {
@@ -187,3 +191,34 @@ func TestGetPodUIDFromTargetPath(t *testing.T) {
 		})
 	}
 }
+
+func TestGetRootToken(t *testing.T) {
+	driver.InitMockDB()
+	ctx := &logger.RequestContext{}
+	root := model.User{UserInfo: model.UserInfo{Name: common.RootKey, Password: "encrypted"}}
+	assert.NoError(t, storage.Auth.CreateUser(ctx, &root))
+
+	token, err := GetRootToken(ctx)
+	assert.NoError(t, err)
+	// 复用缓存的token，不重复创建会话
+	cached, err := GetRootToken(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, token, cached)
+	sessions, err := storage.Auth.ListActiveSessions(ctx, common.RootKey)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(sessions))
+	assert.NotNil(t, sessions[0].ExpireAt)
+	assert.True(t, sessions[0].ExpireAt.Before(time.Now().Add(rootTokenTTL+time.Minute)))
+
+	// 临近过期时重新签发
+	rootTokenCache.expireAt = time.Now().Add(rootTokenRefresh / 2)
+	refreshed, err := GetRootToken(ctx)
+	assert.NoError(t, err)
+	assert.NotEqual(t, token, refreshed)
+
+	// root密码变更后重新签发
+	assert.NoError(t, storage.Auth.UpdateUser(ctx, common.RootKey, "changed"))
+	changed, err := GetRootToken(ctx)
+	assert.NoError(t, err)
+	assert.NotEqual(t, refreshed, changed)
+}
@@ -77,6 +77,8 @@ type PFJob struct {
 
 	WaitingTime *time.Duration
 	CreateTime  time.Time
+	// EnqueueTime is the time when job is read into job queue of job manager
+	EnqueueTime time.Time
 	StartTime   time.Time
 	EndTIme     time.Time
 }
@@ -165,6 +167,8 @@ type TaskSyncInfo struct {
 	PodStatus  interface{}
 	Action     schema.ActionType
 	RetryTimes int
+	// NodeFailureReason is not empty when the pod is lost due to node failure
+	NodeFailureReason string
 }
 
 // FinishedJobInfo contains gc job info
@@ -18,6 +18,7 @@ package api
 
 import (
 	"sync"
+	"sync/atomic"
 )
 
 type JobQueue struct {
@@ -25,7 +26,9 @@ type JobQueue struct {
 	StopCh   chan struct{}
 	Queue    *QueueInfo
 	jobExist sync.Map
-	Jobs     *PriorityQueue
+	// marked is number of jobs in jobExist, which are waiting in queue or being submitted
+	marked int32
+	Jobs   *PriorityQueue
 }
 
 func NewJobQueue(q *QueueInfo) *JobQueue {
@@ -44,15 +47,29 @@ func (qj *JobQueue) GetName() string {
 	return name
 }
 
-func (qj *JobQueue) Insert(job *PFJob) {
+// Insert adds job to queue, and returns false if job is already in queue or being submitted
+func (qj *JobQueue) Insert(job *PFJob) bool {
 	if qj.Jobs != nil && job != nil {
 		qj.Lock()
 		defer qj.Unlock()
 		if _, exist := qj.jobExist.Load(job.ID); !exist {
 			qj.jobExist.Store(job.ID, struct{}{})
+			atomic.AddInt32(&qj.marked, 1)
 			qj.Jobs.Push(job)
+			return true
 		}
 	}
+	return false
+}
+
+// Len returns number of jobs waiting in queue
+func (qj *JobQueue) Len() int {
+	if qj.Jobs == nil {
+		return 0
+	}
+	qj.RLock()
+	defer qj.RUnlock()
+	return qj.Jobs.Len()
 }
 
 func (qj *JobQueue) GetJob() (*PFJob, bool) {
@@ -69,7 +86,14 @@ func (qj *JobQueue) GetJob() (*PFJob, bool) {
 }
 
 func (qj *JobQueue) DeleteMark(jobID string) {
-	qj.jobExist.Delete(jobID)
+	if _, exist := qj.jobExist.LoadAndDelete(jobID); exist {
+		atomic.AddInt32(&qj.marked, -1)
+	}
+}
+
+// Marked returns number of jobs waiting in queue or being submitted
+func (qj *JobQueue) Marked() int {
+	return int(atomic.LoadInt32(&qj.marked))
 }
 
 // JobQueues the collect of JobQueue
@@ -18,6 +18,7 @@ package api
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/sirupsen/logrus"
 
@@ -48,6 +49,8 @@ type QueueInfo struct {
 	// SortPolicy for queue job
 	SortPolicyNames []string
 	SortPolicies    []SortPolicy
+	// PriorityAging raises priority of waiting jobs
+	PriorityAging *model.PriorityAging
 	// Location for queue affinity
 	Location map[string]string
 
@@ -63,7 +66,9 @@ type QueueInfo struct {
 	UsedResources *resources.Resource
 }
 
+// NewQueueInfo 队列配置了容量时间段时，使用当前时间段的容量
 func NewQueueInfo(q model.Queue) *QueueInfo {
+	maxResources, minResources := q.EffectiveResources(time.Now())
 	return &QueueInfo{
 		UID:             QueueID(q.ID),
 		Name:            q.Name,
@@ -72,8 +77,9 @@ func NewQueueInfo(q model.Queue) *QueueInfo {
 		Status:          q.Status,
 		SortPolicyNames: q.SchedulingPolicy,
 		SortPolicies:    NewRegistry(q.SchedulingPolicy),
-		MaxResources:    q.MaxResources,
-		MinResources:    q.MinResources,
+		PriorityAging:   q.PriorityAging,
+		MaxResources:    maxResources,
+		MinResources:    minResources,
 		Location:        q.Location,
 	}
 }
@@ -0,0 +1,257 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/config"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+)
+
+const (
+	EventPreDispatch    = "preDispatch"
+	EventPostCompletion = "postCompletion"
+	EventDataStaging    = "dataStaging"
+	EventScaleUp        = "scaleUp"
+
+	// ActionAllow 允许调度作业
+	ActionAllow = "allow"
+	// ActionWait 暂缓调度，作业保持init状态，在下一轮调度时再次调用钩子
+	ActionWait = "wait"
+	// ActionReject 拒绝调度，作业置为failed
+	ActionReject = "reject"
+
+	FailurePolicyFail   = "Fail"
+	FailurePolicyIgnore = "Ignore"
+
+	defaultHookTimeout = 10 * time.Second
+	maxHookOutputSize  = 1 << 20
+)
+
+// Request 传给钩子的内容
+type Request struct {
+	Event string     `json:"event"`
+	Job   *model.Job `json:"job"`
+	// TargetQueue 和 FileSystems 仅用于数据预置钩子，为作业溢出的目标队列和需要同步的存储
+	TargetQueue *model.Queue        `json:"targetQueue,omitempty"`
+	FileSystems []schema.FileSystem `json:"fileSystems,omitempty"`
+	// ScaleUp 仅用于扩容钩子，为作业等待调度的pod及所需的资源
+	ScaleUp *ScaleUpRequest `json:"scaleUp,omitempty"`
+}
+
+// ScaleUpRequest 作业在集群中等待调度的pod，Requests为这些pod请求的资源总量
+type ScaleUpRequest struct {
+	ClusterName string            `json:"clusterName"`
+	Namespace   string            `json:"namespace"`
+	NodePool    string            `json:"nodePool,omitempty"`
+	Pods        int               `json:"pods"`
+	Requests    map[string]string `json:"requests"`
+}
+
+// Response 调度前钩子的返回，输出为空时视为allow
+type Response struct {
+	Action  string `json:"action"`
+	Message string `json:"message,omitempty"`
+}
+
+// PreDispatch 依次调用调度前钩子，任一钩子返回wait或reject时不再调用后续钩子
+func PreDispatch(job *model.Job) Response {
+	for _, hook := range hooksConfig().PreDispatch {
+		output, err := invoke(hook, Request{Event: EventPreDispatch, Job: job})
+		if err != nil {
+			log.Errorf("invoke pre-dispatch hook %s for job %s failed, err: %v", hook.Name, job.ID, err)
+			if hook.FailurePolicy == FailurePolicyIgnore {
+				continue
+			}
+			return Response{Action: ActionWait, Message: fmt.Sprintf("pre-dispatch hook %s failed: %v", hook.Name, err)}
+		}
+		response, err := parseResponse(output)
+		if err != nil {
+			log.Errorf("parse output of pre-dispatch hook %s for job %s failed, err: %v", hook.Name, job.ID, err)
+			if hook.FailurePolicy == FailurePolicyIgnore {
+				continue
+			}
+			return Response{Action: ActionWait, Message: fmt.Sprintf("pre-dispatch hook %s returns invalid output: %v", hook.Name, err)}
+		}
+		if response.Action != ActionAllow {
+			log.Infof("pre-dispatch hook %s returns %s for job %s, message: %s", hook.Name, response.Action, job.ID, response.Message)
+			return response
+		}
+	}
+	return Response{Action: ActionAllow}
+}
+
+// NotifyJobFinished 作业由非终态进入终态时，异步调用结束后钩子
+func NotifyJobFinished(jobID string, preStatus, status schema.JobStatus) {
+	hooks := hooksConfig().PostCompletion
+	if len(hooks) == 0 || schema.IsImmutableJobStatus(preStatus) || !schema.IsImmutableJobStatus(status) {
+		return
+	}
+	go func() {
+		job, err := storage.Job.GetUnscopedJobByID(jobID)
+		if err != nil {
+			log.Errorf("get job %s for post-completion hooks failed, err: %v", jobID, err)
+			return
+		}
+		for _, hook := range hooks {
+			if _, err = invoke(hook, Request{Event: EventPostCompletion, Job: &job}); err != nil {
+				log.Errorf("invoke post-completion hook %s for job %s failed, err: %v", hook.Name, jobID, err)
+			}
+		}
+	}()
+}
+
+// StageData 作业溢出到目标队列所在集群前依次调用数据预置钩子，任一钩子失败时返回错误，作业暂不溢出
+func StageData(job *model.Job, targetQueue *model.Queue) error {
+	request := Request{Event: EventDataStaging, Job: job, TargetQueue: targetQueue, FileSystems: jobFileSystems(job)}
+	for _, hook := range hooksConfig().DataStaging {
+		if _, err := invoke(hook, request); err != nil {
+			log.Errorf("invoke data-staging hook %s for job %s failed, err: %v", hook.Name, job.ID, err)
+			if hook.FailurePolicy == FailurePolicyIgnore {
+				continue
+			}
+			return fmt.Errorf("data-staging hook %s failed: %v", hook.Name, err)
+		}
+	}
+	return nil
+}
+
+// ScaleUp 依次调用扩容钩子，FailurePolicy为Fail的钩子失败时返回错误
+func ScaleUp(job *model.Job, scaleUp *ScaleUpRequest) error {
+	var errs []string
+	for _, hook := range hooksConfig().ScaleUp {
+		if _, err := invoke(hook, Request{Event: EventScaleUp, Job: job, ScaleUp: scaleUp}); err != nil {
+			log.Errorf("invoke scale-up hook %s for job %s failed, err: %v", hook.Name, job.ID, err)
+			if hook.FailurePolicy != FailurePolicyIgnore {
+				errs = append(errs, fmt.Sprintf("scale-up hook %s failed: %v", hook.Name, err))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// jobFileSystems 返回作业及其成员挂载的全部存储，存储和子路径相同的只保留一个
+func jobFileSystems(job *model.Job) []schema.FileSystem {
+	var fileSystems []schema.FileSystem
+	seen := make(map[string]bool)
+	add := func(conf *schema.Conf) {
+		for _, fs := range conf.GetAllFileSystem() {
+			key := fs.Name + ":" + fs.SubPath
+			if fs.Name == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+			fileSystems = append(fileSystems, fs)
+		}
+	}
+	if job.Config != nil {
+		add(job.Config)
+	}
+	for i := range job.Members {
+		add(&job.Members[i].Conf)
+	}
+	return fileSystems
+}
+
+func hooksConfig() config.JobHooksConfig {
+	if config.GlobalServerConfig == nil {
+		return config.JobHooksConfig{}
+	}
+	return config.GlobalServerConfig.Job.Hooks
+}
+
+func parseResponse(output []byte) (Response, error) {
+	response := Response{Action: ActionAllow}
+	if len(bytes.TrimSpace(output)) == 0 {
+		return response, nil
+	}
+	if err := json.Unmarshal(output, &response); err != nil {
+		return response, err
+	}
+	switch response.Action {
+	case "":
+		response.Action = ActionAllow
+	case ActionAllow, ActionWait, ActionReject:
+	default:
+		return response, fmt.Errorf("unknown action %s", response.Action)
+	}
+	return response, nil
+}
+
+// invoke 调用钩子并返回其输出，exec钩子退出码非0或HTTP钩子返回非2xx时视为失败
+func invoke(hook config.JobHook, request Request) ([]byte, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	timeout := defaultHookTimeout
+	if hook.TimeoutSeconds > 0 {
+		timeout = time.Duration(hook.TimeoutSeconds) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	switch {
+	case len(hook.Exec) > 0:
+		var stdout, stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, hook.Exec[0], hook.Exec[1:]...)
+		cmd.Stdin = bytes.NewReader(body)
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		if err = cmd.Run(); err != nil {
+			return nil, fmt.Errorf("%v, stderr: %s", err, strings.TrimSpace(stderr.String()))
+		}
+		return stdout.Bytes(), nil
+	case hook.URL != "":
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		output, err := io.ReadAll(io.LimitReader(resp.Body, maxHookOutputSize))
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+			return nil, fmt.Errorf("response status code %d", resp.StatusCode)
+		}
+		return output, nil
+	default:
+		return nil, fmt.Errorf("neither exec nor url is set")
+	}
+}
@@ -0,0 +1,123 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hook
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/config"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+)
+
+func TestPreDispatch(t *testing.T) {
+	var received Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&received)
+		if received.Job.UserName == "denied" {
+			_, _ = w.Write([]byte(`{"action": "reject", "message": "not approved"}`))
+		}
+	}))
+	defer server.Close()
+	job := &model.Job{ID: "job-hook", UserName: "user1"}
+
+	testCases := []struct {
+		name     string
+		hooks    []config.JobHook
+		userName string
+		action   string
+	}{
+		{
+			name:   "no hooks",
+			action: ActionAllow,
+		},
+		{
+			name:     "http hook allows job with empty output",
+			hooks:    []config.JobHook{{Name: "approval", URL: server.URL}},
+			userName: "user1",
+			action:   ActionAllow,
+		},
+		{
+			name:     "http hook rejects job",
+			hooks:    []config.JobHook{{Name: "approval", URL: server.URL}},
+			userName: "denied",
+			action:   ActionReject,
+		},
+		{
+			name:   "exec hook holds job",
+			hooks:  []config.JobHook{{Name: "exec", Exec: []string{"sh", "-c", `cat > /dev/null; echo '{"action": "wait"}'`}}},
+			action: ActionWait,
+		},
+		{
+			name:   "failed hook holds job",
+			hooks:  []config.JobHook{{Name: "exec", Exec: []string{"sh", "-c", "exit 1"}}},
+			action: ActionWait,
+		},
+		{
+			name:   "failed hook is ignored",
+			hooks:  []config.JobHook{{Name: "exec", Exec: []string{"sh", "-c", "exit 1"}, FailurePolicy: FailurePolicyIgnore}},
+			action: ActionAllow,
+		},
+		{
+			name:   "invalid action",
+			hooks:  []config.JobHook{{Name: "exec", Exec: []string{"sh", "-c", `echo '{"action": "approve"}'`}}},
+			action: ActionWait,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config.GlobalServerConfig = &config.ServerConfig{}
+			config.GlobalServerConfig.Job.Hooks.PreDispatch = tc.hooks
+			job.UserName = tc.userName
+			response := PreDispatch(job)
+			assert.Equal(t, tc.action, response.Action)
+		})
+	}
+	assert.Equal(t, EventPreDispatch, received.Event)
+	assert.Equal(t, "job-hook", received.Job.ID)
+}
+
+func TestStageData(t *testing.T) {
+	var received Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&received)
+	}))
+	defer server.Close()
+	job := &model.Job{ID: "job-burst", Config: &schema.Conf{
+		FileSystem:      schema.FileSystem{Name: "fs1", SubPath: "data"},
+		ExtraFileSystem: []schema.FileSystem{{Name: "fs2"}},
+	}, Members: []schema.Member{{Conf: schema.Conf{FileSystem: schema.FileSystem{Name: "fs1", SubPath: "data"}}}}}
+	targetQueue := &model.Queue{Name: "cloud-queue", ClusterId: "cluster-cloud"}
+
+	config.GlobalServerConfig = &config.ServerConfig{}
+	config.GlobalServerConfig.Job.Hooks.DataStaging = []config.JobHook{{Name: "sync", URL: server.URL}}
+	assert.NoError(t, StageData(job, targetQueue))
+	assert.Equal(t, EventDataStaging, received.Event)
+	assert.Equal(t, "cloud-queue", received.TargetQueue.Name)
+	assert.Equal(t, []schema.FileSystem{{Name: "fs1", SubPath: "data"}, {Name: "fs2"}}, received.FileSystems)
+
+	// failed hook prevents bursting unless it is ignored
+	config.GlobalServerConfig.Job.Hooks.DataStaging = []config.JobHook{{Name: "exec", Exec: []string{"sh", "-c", "exit 1"}}}
+	assert.Error(t, StageData(job, targetQueue))
+	config.GlobalServerConfig.Job.Hooks.DataStaging[0].FailurePolicy = FailurePolicyIgnore
+	assert.NoError(t, StageData(job, targetQueue))
+}
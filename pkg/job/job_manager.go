@@ -17,6 +17,7 @@ limitations under the License.
 package job
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"sync"
@@ -24,10 +25,14 @@ import (
 
 	"github.com/bluele/gcache"
 	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/config"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/job/api"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/job/hook"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/job/runtime"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/job/runtime_v2"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/metrics"
@@ -54,11 +59,21 @@ type JobManagerImpl struct {
 	// activeQueueJobs is a method for listing jobs on active queue
 	// deprecated
 	activeQueueJobs QueueJobsFunc
+	// configLock protects periods and queue cache, which are changed when server config is reloaded
+	configLock      sync.RWMutex
 	queueExpireTime time.Duration
 	queueCache      gcache.Cache
+	queueCacheSize  int
 
-	listQueueInitJobs func(string) []model.Job
+	listQueueInitJobs func(string, int64, int) []model.Job
+	listJobQueueIDs   func(schema.JobStatus) []string
 	jobLoopPeriod     time.Duration
+	dispatchConf      config.JobDispatchConfig
+	// initJobCursors is the pk of the last init job read of each queue, so that jobs held in init status
+	// do not stop newer jobs from being read
+	initJobCursors map[api.QueueID]int64
+	// dispatchLocks serializes checking and submitting jobs of the same user with quota or concurrency group
+	dispatchLocks sync.Map
 
 	// jobQueues contains JobQueue for jobs in queue
 	jobQueues api.JobQueues
@@ -73,39 +88,78 @@ func NewJobManagerImpl() (*JobManagerImpl, error) {
 	manager := &JobManagerImpl{
 		clusterRuntimes: NewClusterRuntimes(),
 		jobQueues:       api.NewJobQueues(),
+		initJobCursors:  make(map[api.QueueID]int64),
 	}
 	return manager, nil
 
 }
 
 func (m *JobManagerImpl) init() {
-	// init queue cache
-	cacheSize := config.GlobalServerConfig.Job.QueueCacheSize
+	m.applyConfig(config.GlobalServerConfig.Job)
+	// periods and queue cache take effect without restart
+	config.RegisterReloadHandler(func(conf *config.ServerConfig) {
+		m.applyConfig(conf.Job)
+	})
+}
+
+func (m *JobManagerImpl) applyConfig(jobConf config.JobConfig) {
+	cacheSize := jobConf.QueueCacheSize
 	if cacheSize < defaultCacheSize {
 		cacheSize = defaultCacheSize
 	}
-	expireTime := config.GlobalServerConfig.Job.QueueExpireTime
+	expireTime := jobConf.QueueExpireTime
 	if expireTime < defaultExpireTime {
 		expireTime = defaultExpireTime
 	}
-	clusterSyncTime := config.GlobalServerConfig.Job.ClusterSyncPeriod
+	clusterSyncTime := jobConf.ClusterSyncPeriod
 	if clusterSyncTime < defaultExpireTime {
 		clusterSyncTime = defaultExpireTime
 	}
-	jobLoopPeriod := config.GlobalServerConfig.Job.JobLoopPeriod
+	jobLoopPeriod := jobConf.JobLoopPeriod
 	if jobLoopPeriod < defaultJobLoop {
 		jobLoopPeriod = defaultJobLoop
 	}
-	m.queueCache = gcache.New(cacheSize).LRU().Build()
+	m.configLock.Lock()
+	defer m.configLock.Unlock()
+	if m.queueCache == nil || m.queueCacheSize != cacheSize {
+		m.queueCache = gcache.New(cacheSize).LRU().Build()
+		m.queueCacheSize = cacheSize
+	}
 	m.queueExpireTime = time.Duration(expireTime) * time.Second
 	m.jobLoopPeriod = time.Duration(jobLoopPeriod) * time.Second
 	m.clusterSyncPeriod = time.Duration(clusterSyncTime) * time.Second
+	m.dispatchConf = jobConf.Dispatch
+}
+
+func (m *JobManagerImpl) getJobLoopPeriod() time.Duration {
+	m.configLock.RLock()
+	defer m.configLock.RUnlock()
+	return m.jobLoopPeriod
+}
+
+func (m *JobManagerImpl) getClusterSyncPeriod() time.Duration {
+	m.configLock.RLock()
+	defer m.configLock.RUnlock()
+	return m.clusterSyncPeriod
+}
+
+func (m *JobManagerImpl) getDispatchConf() config.JobDispatchConfig {
+	m.configLock.RLock()
+	defer m.configLock.RUnlock()
+	return m.dispatchConf
+}
+
+func (m *JobManagerImpl) getQueueCache() (gcache.Cache, time.Duration) {
+	m.configLock.RLock()
+	defer m.configLock.RUnlock()
+	return m.queueCache, m.queueExpireTime
 }
 
 func (m *JobManagerImpl) Start(activeClusters ActiveClustersFunc, activeQueueJobs QueueJobsFunc) {
 	m.activeClusters = activeClusters
 	m.activeQueueJobs = activeQueueJobs
 	m.listQueueInitJobs = storage.Job.ListQueueInitJob
+	m.listJobQueueIDs = storage.Job.ListJobQueueIDs
 	/// init config for job manager
 	m.init()
 	// start job manager
@@ -151,7 +205,7 @@ func (m *JobManagerImpl) startRuntime() {
 				go m.Run(runtimeSvc, cr.StopCh, clusterID)
 			}
 		}
-		time.Sleep(m.clusterSyncPeriod)
+		time.Sleep(m.getClusterSyncPeriod())
 	}
 }
 
@@ -181,42 +235,72 @@ func (m *JobManagerImpl) Run(runtimeService runtime.RuntimeService, stopCh <-cha
 func (m *JobManagerImpl) pJobProcessLoop() {
 	log.Infof("start job process loop ...")
 	for {
-		jobs := storage.Job.ListJobByStatus(schema.StatusJobInit)
 		startTime := time.Now()
-		for idx, job := range jobs {
-			// TODO: batch insert group by queue
-			queueID := api.QueueID(job.QueueID)
-			cQueue, find := m.GetQueue(queueID)
-			if !find {
-				m.stopQueueSubmit(queueID)
-				log.Warnf("get queue from cache failed, stop queue submit")
-				continue
-			}
-			// add more metric
-			qInfo := cQueue.Queue
-			pfJob, err := api.NewJobInfo(&jobs[idx])
-			if err != nil {
-				continue
-			}
+		batchSize := m.getDispatchConf().GetBatchSize()
+		// read init jobs queue by queue, so that a burst of jobs in one queue does not block other queues
+		queueIDs := m.listJobQueueIDs(schema.StatusJobInit)
+		total := 0
+		for _, queueID := range queueIDs {
+			total += m.enqueueQueueJobs(api.QueueID(queueID), batchSize, startTime)
+		}
+		elapsedTime := time.Since(startTime)
+		if jobLoopPeriod := m.getJobLoopPeriod(); elapsedTime < jobLoopPeriod {
+			time.Sleep(jobLoopPeriod - elapsedTime)
+		}
+		log.Debugf("total queue %d, enqueued job %d, job loop elapsed time: %s", len(queueIDs), total, elapsedTime)
+	}
+}
 
-			jobQueue, find := m.jobQueues.Get(queueID)
-			if !find {
-				jobQueue = api.NewJobQueue(qInfo)
-				m.jobQueues.Insert(queueID, jobQueue)
-				go m.pSubmitQueueJob(jobQueue, cQueue.ClusterRuntime)
-			}
+// enqueueQueueJobs reads at most batchSize init jobs of queue into its job queue, and returns number of new jobs
+func (m *JobManagerImpl) enqueueQueueJobs(queueID api.QueueID, batchSize int, startTime time.Time) int {
+	cQueue, find := m.GetQueue(queueID)
+	if !find {
+		m.stopQueueSubmit(queueID)
+		log.Warnf("get queue %s from cache failed, stop queue submit", queueID)
+		return 0
+	}
+	qInfo := cQueue.Queue
+	jobQueue, find := m.jobQueues.Get(queueID)
+	if !find {
+		jobQueue = api.NewJobQueue(qInfo)
+		m.jobQueues.Insert(queueID, jobQueue)
+		go m.pSubmitQueueJob(jobQueue, cQueue.ClusterRuntime)
+	}
+	// backpressure, skip reading db until jobs in memory are submitted
+	if jobQueue.Len() >= batchSize {
+		log.Debugf("queue %s has %d jobs waiting for submit, skip reading init jobs", qInfo.Name, jobQueue.Len())
+		return 0
+	}
 
-			// enqueue job
-			jobQueue.Insert(pfJob)
-			// add job time point
-			metrics.Job.AddTimestamp(pfJob.ID, metrics.T3, time.Now())
+	// read init jobs page by page, and start from the oldest job again after the last page,
+	// so that jobs held by user quota, concurrency group or hooks do not block newer jobs
+	limit := batchSize - jobQueue.Len()
+	jobs := m.listQueueInitJobs(string(queueID), m.initJobCursors[queueID], limit)
+	if len(jobs) < limit {
+		delete(m.initJobCursors, queueID)
+	} else {
+		m.initJobCursors[queueID] = jobs[len(jobs)-1].Pk
+	}
+	count := 0
+	for idx, job := range jobs {
+		pfJob, err := api.NewJobInfo(&jobs[idx])
+		if err != nil {
+			continue
 		}
-		elapsedTime := time.Since(startTime)
-		if elapsedTime < m.jobLoopPeriod {
-			time.Sleep(m.jobLoopPeriod - elapsedTime)
+		// order jobs by the priority after aging
+		priority := qInfo.PriorityAging.EffectivePriority(job.Config.Priority, startTime.Sub(job.CreatedAt))
+		pfJob.Priority = int32(model.JobPriorityLevel(priority))
+		pfJob.EnqueueTime = time.Now()
+
+		// enqueue job
+		if jobQueue.Insert(pfJob) {
+			count++
+			metrics.ObserveJobDispatchLatency(qInfo.Name, metrics.JobDispatchStageEnqueue, pfJob.EnqueueTime.Sub(job.CreatedAt))
 		}
-		log.Debugf("total job %d, job loop elapsed time: %s", len(jobs), elapsedTime)
+		// add job time point
+		metrics.Job.AddTimestamp(pfJob.ID, metrics.T3, time.Now())
 	}
+	return count
 }
 
 func (m *JobManagerImpl) pSubmitQueueJob(jobQueue *api.JobQueue, clusterRuntime *ClusterRuntimeInfo) {
@@ -232,26 +316,53 @@ func (m *JobManagerImpl) pSubmitQueueJob(jobQueue *api.JobQueue, clusterRuntime
 			log.Infof("exit submit job loop for queue %s ...", name)
 			return
 		default:
-			startTime := time.Now()
-			// dequeue job
-			job, ok := jobQueue.GetJob()
-			if ok {
-				metrics.Job.AddTimestamp(job.ID, metrics.T4, time.Now())
-				log.Infof("Entering submit %s job in queue %s", job.ID, name)
-				// get enqueue job
-				m.submitJob(clusterRuntime, job)
-				metrics.Job.AddTimestamp(job.ID, metrics.T5, time.Now())
-				jobQueue.DeleteMark(job.ID)
-				log.Infof("Leaving submit %s job in queue %s, total elapsed time: %s", job.ID, name, time.Since(startTime))
-			} else {
+			// dequeue a batch of jobs, which are submitted concurrently
+			concurrency := m.getDispatchConf().GetConcurrency()
+			var jobs []*api.PFJob
+			for len(jobs) < concurrency {
+				job, ok := jobQueue.GetJob()
+				if !ok {
+					break
+				}
+				jobs = append(jobs, job)
+			}
+			if len(jobs) == 0 {
 				// TODO: add to config
 				// time.Sleep(m.jobLoopPeriod)
 				time.Sleep(200 * time.Millisecond)
+				continue
 			}
+			wg := sync.WaitGroup{}
+			for _, job := range jobs {
+				wg.Add(1)
+				go func(job *api.PFJob) {
+					defer wg.Done()
+					m.submitQueueJob(jobQueue, clusterRuntime, job)
+				}(job)
+			}
+			wg.Wait()
 		}
 	}
 }
 
+func (m *JobManagerImpl) submitQueueJob(jobQueue *api.JobQueue, clusterRuntime *ClusterRuntimeInfo, job *api.PFJob) {
+	name := jobQueue.GetName()
+	startTime := time.Now()
+	metrics.Job.AddTimestamp(job.ID, metrics.T4, startTime)
+	if !job.EnqueueTime.IsZero() {
+		metrics.ObserveJobDispatchLatency(name, metrics.JobDispatchStageWait, startTime.Sub(job.EnqueueTime))
+	}
+	log.Infof("Entering submit %s job in queue %s", job.ID, name)
+	// limit jobs submitted to the cluster concurrently
+	clusterRuntime.acquireSubmit()
+	m.submitJob(clusterRuntime, job)
+	clusterRuntime.releaseSubmit()
+	metrics.Job.AddTimestamp(job.ID, metrics.T5, time.Now())
+	metrics.ObserveJobDispatchLatency(name, metrics.JobDispatchStageSubmit, time.Since(startTime))
+	jobQueue.DeleteMark(job.ID)
+	log.Infof("Leaving submit %s job in queue %s, total elapsed time: %s", job.ID, name, time.Since(startTime))
+}
+
 func (m *JobManagerImpl) submitJob(clusterRuntime *ClusterRuntimeInfo, job *api.PFJob) {
 	if clusterRuntime == nil || job == nil {
 		log.Errorf("submit job to cluster failed, err: clusterRuntime or job is nil")
@@ -266,22 +377,59 @@ func (m *JobManagerImpl) submitJob(clusterRuntime *ClusterRuntimeInfo, job *api.
 
 // submitJob submit a job to cluster
 func (m *JobManagerImpl) submitJobV1(jobSubmit func(*api.PFJob) error, jobInfo *api.PFJob) {
-	log.Infof("begin to submit job %s to cluster", jobInfo.ID)
+	jobLogger := logger.LoggerForQueueJob(jobInfo.ID, string(jobInfo.QueueID), jobInfo.UserName)
+	jobLogger.Infof("begin to submit job to cluster")
 	startTime := time.Now()
 	job, err := storage.Job.GetJobByID(jobInfo.ID)
 	if err != nil {
-		log.Errorf("get job %s from database failed, err: %v", job.ID, err)
+		jobLogger.Errorf("get job from database failed, err: %v", err)
 		return
 	}
 	// check job status before create job on cluster
 	if job.Status == schema.StatusJobInit {
+		// quota of user is looked up once and shared by the lock and the quota check
+		var quota *model.UserQuota
+		if quota, err = getUserQuota(job.UserName); err != nil {
+			jobLogger.Infof("job is not submitted to cluster, get quota of user failed, err: %v", err)
+			return
+		}
+		// checking limits and updating job status are atomic for jobs of the same user
+		unlock := m.lockDispatch(&job, quota)
+		defer unlock()
+		// job is held in init status until the user has enough quota
+		if err = checkUserQuota(&job, quota); err != nil {
+			jobLogger.Infof("job is not submitted to cluster, err: %v", err)
+			return
+		}
+		// job is held in init status until other jobs in its concurrency group finish
+		if err = checkConcurrencyGroup(&job); err != nil {
+			jobLogger.Infof("job is not submitted to cluster, err: %v", err)
+			return
+		}
+		// job is held in init status or rejected by pre-dispatch hooks
+		if response := hook.PreDispatch(&job); response.Action != hook.ActionAllow {
+			if response.Action == hook.ActionReject {
+				msg := fmt.Sprintf("job is rejected by pre-dispatch hook: %s", response.Message)
+				if dbErr := storage.Job.UpdateJobStatus(jobInfo.ID, msg, schema.StatusJobFailed); dbErr != nil {
+					jobLogger.Errorf("update job status to [%s] failed, err: %v", schema.StatusJobFailed, dbErr)
+				}
+				hook.NotifyJobFinished(jobInfo.ID, job.Status, schema.StatusJobFailed)
+			}
+			return
+		}
 		var jobStatus schema.JobStatus
 		var msg string
 		err = jobSubmit(jobInfo)
-		if err != nil {
+		if err != nil && k8serrors.IsAlreadyExists(err) {
+			// job was created on cluster before server restart, adopt it
+			msg = "job already exists on cluster, adopt it."
+			jobLogger.Warnln(msg)
+			trace_logger.KeyWithUpdate(jobInfo.ID).Infof(msg)
+			jobStatus = schema.StatusJobPending
+		} else if err != nil {
 			// new job failed, update db and skip this job
 			msg = fmt.Sprintf("submit job to cluster failed, err: %s", err)
-			log.Errorln(msg)
+			jobLogger.Errorln(msg)
 			trace_logger.KeyWithUpdate(jobInfo.ID).Errorf(msg)
 			jobStatus = schema.StatusJobFailed
 		} else {
@@ -292,13 +440,71 @@ func (m *JobManagerImpl) submitJobV1(jobSubmit func(*api.PFJob) error, jobInfo *
 		// new job failed, update db and skip this job
 		if dbErr := storage.Job.UpdateJobStatus(jobInfo.ID, msg, jobStatus); dbErr != nil {
 			errMsg := fmt.Sprintf("update job[%s] status to [%s] failed, err: %v", jobInfo.ID, schema.StatusJobFailed, dbErr)
-			log.Errorf(errMsg)
+			jobLogger.Errorf(errMsg)
 			trace_logger.KeyWithUpdate(jobInfo.ID).Errorf(errMsg)
 		}
-		log.Infof("submit job %s to cluster elasped time %s", jobInfo.ID, time.Since(startTime))
+		hook.NotifyJobFinished(jobInfo.ID, job.Status, jobStatus)
+		jobLogger.Infof("submit job to cluster elasped time %s", time.Since(startTime))
 	} else {
-		log.Errorf("job %s is already submit to cluster, skip it", job.ID)
+		jobLogger.Errorf("job is already submit to cluster, skip it")
+	}
+}
+
+// lockDispatch locks jobs of the same user when user quota or concurrency group is set, and returns the unlock func
+func (m *JobManagerImpl) lockDispatch(job *model.Job, quota *model.UserQuota) func() {
+	if job.ConcurrencyGroup == "" && !hasUserQuota(quota) {
+		return func() {}
+	}
+	lock, _ := m.dispatchLocks.LoadOrStore(job.UserName, &sync.Mutex{})
+	mutex := lock.(*sync.Mutex)
+	mutex.Lock()
+	return mutex.Unlock
+}
+
+// getUserQuota returns the cached quota of user, nil when the user has no quota
+func getUserQuota(userName string) (*model.UserQuota, error) {
+	quota, err := storage.Auth.GetCachedUserQuota(&logger.RequestContext{}, userName)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
 	}
+	return &quota, nil
+}
+
+func hasUserQuota(quota *model.UserQuota) bool {
+	return quota != nil && (quota.MaxConcurrentJobs != 0 || quota.MaxGPUs != 0)
+}
+
+// checkUserQuota checks concurrent jobs and gpus of user before submitting job to cluster
+func checkUserQuota(job *model.Job, quota *model.UserQuota) error {
+	if !hasUserQuota(quota) {
+		return nil
+	}
+	activeJobs := storage.Job.ListUserJob(job.UserName, []schema.JobStatus{schema.StatusJobPending,
+		schema.StatusJobRunning, schema.StatusJobTerminating})
+	return quota.CheckDispatch(job, activeJobs)
+}
+
+// checkConcurrencyGroup checks the active jobs of user in the same concurrency group before submitting job to cluster
+func checkConcurrencyGroup(job *model.Job) error {
+	if job.ConcurrencyGroup == "" || job.Config == nil {
+		return nil
+	}
+	limit := job.Config.ConcurrencyLimit
+	if limit <= 0 {
+		limit = 1
+	}
+	active, err := storage.Job.CountConcurrencyGroupJob(job.UserName, job.ConcurrencyGroup, []schema.JobStatus{
+		schema.StatusJobPending, schema.StatusJobRunning, schema.StatusJobTerminating})
+	if err != nil {
+		return err
+	}
+	if active >= int64(limit) {
+		return fmt.Errorf("%d jobs of concurrency group %s are active, limit is %d", active, job.ConcurrencyGroup, limit)
+	}
+	return nil
 }
 
 func (m *JobManagerImpl) stopClusterQueueSubmit(clusterID api.ClusterID) {
@@ -347,6 +553,8 @@ func (m *JobManagerImpl) startRuntimeV2() {
 					continue
 				}
 				log.Infof("Create new runtime with cluster <%s>", cluster.ID)
+				// reconcile jobs before the cluster runtime is available to submit jobs
+				reconcileClusterJobs(cluster.ID, runtimeSvc.Client())
 
 				cr := NewClusterRuntimeV2Info(cluster.Name, runtimeSvc)
 				m.clusterRuntimes.Store(clusterID, cr)
@@ -354,7 +562,7 @@ func (m *JobManagerImpl) startRuntimeV2() {
 				go runtimeSvc.SyncController(cr.StopCh)
 			}
 		}
-		time.Sleep(m.clusterSyncPeriod)
+		time.Sleep(m.getClusterSyncPeriod())
 	}
 }
 
@@ -369,7 +577,8 @@ type clusterQueue struct {
 func (m *JobManagerImpl) GetQueue(queueID api.QueueID) (*clusterQueue, bool) {
 	// check whether queue is exist or not
 	var err error
-	value, err := m.queueCache.GetIFPresent(queueID)
+	queueCache, expireTime := m.getQueueCache()
+	value, err := queueCache.GetIFPresent(queueID)
 	if err == nil {
 		return value.(*clusterQueue), true
 	}
@@ -398,7 +607,7 @@ func (m *JobManagerImpl) GetQueue(queueID api.QueueID) (*clusterQueue, bool) {
 		Queue:          queueInfo,
 		ClusterRuntime: cRuntime,
 	}
-	err = m.queueCache.SetWithExpire(queueID, cq, m.queueExpireTime)
+	err = queueCache.SetWithExpire(queueID, cq, expireTime)
 	if err != nil {
 		log.Warningf("set cache for queue %s failed, err: %s", queueID, err)
 	}
@@ -411,6 +620,8 @@ type ClusterRuntimeInfo struct {
 	StopCh       chan struct{}
 	RuntimeSvc   runtime.RuntimeService
 	RuntimeV2Svc runtime_v2.RuntimeService
+	// submitSem limits jobs submitted to the cluster concurrently
+	submitSem chan struct{}
 }
 
 func NewClusterRuntimeInfo(name string, r runtime.RuntimeService) *ClusterRuntimeInfo {
@@ -418,6 +629,7 @@ func NewClusterRuntimeInfo(name string, r runtime.RuntimeService) *ClusterRuntim
 		Name:       name,
 		StopCh:     make(chan struct{}),
 		RuntimeSvc: r,
+		submitSem:  make(chan struct{}, clusterDispatchConcurrency()),
 	}
 }
 
@@ -426,6 +638,26 @@ func NewClusterRuntimeV2Info(name string, r runtime_v2.RuntimeService) *ClusterR
 		Name:         name,
 		StopCh:       make(chan struct{}),
 		RuntimeV2Svc: r,
+		submitSem:    make(chan struct{}, clusterDispatchConcurrency()),
+	}
+}
+
+func clusterDispatchConcurrency() int {
+	if config.GlobalServerConfig == nil {
+		return config.DefaultClusterDispatchConcurrency
+	}
+	return config.GlobalServerConfig.Job.Dispatch.GetClusterConcurrency()
+}
+
+func (cr *ClusterRuntimeInfo) acquireSubmit() {
+	if cr.submitSem != nil {
+		cr.submitSem <- struct{}{}
+	}
+}
+
+func (cr *ClusterRuntimeInfo) releaseSubmit() {
+	if cr.submitSem != nil {
+		<-cr.submitSem
 	}
 }
 
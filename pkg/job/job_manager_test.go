@@ -17,12 +17,16 @@ limitations under the License.
 package job
 
 import (
+	"database/sql"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/config"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/job/api"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/storage/driver"
@@ -60,3 +64,137 @@ func TestJobManager(t *testing.T) {
 		})
 	}
 }
+
+func TestCheckUserQuota(t *testing.T) {
+	driver.InitMockDB()
+	ctx := &logger.RequestContext{UserName: "alice"}
+	gpuMember := schema.Member{
+		Replicas: 1,
+		Conf: schema.Conf{Flavour: schema.Flavour{ResourceInfo: schema.ResourceInfo{
+			CPU: "4", Mem: "8Gi", ScalarResources: schema.ScalarResourcesType{"nvidia.com/gpu": "2"}}}},
+	}
+	job := &model.Job{ID: "job-new", UserName: "alice", Status: schema.StatusJobInit, Members: []schema.Member{gpuMember}}
+	// no quota
+	quota, err := getUserQuota("alice")
+	assert.NoError(t, err)
+	assert.Nil(t, quota)
+	assert.NoError(t, checkUserQuota(job, quota))
+
+	running := &model.Job{ID: "job-running", UserName: "alice", Status: schema.StatusJobRunning, Members: []schema.Member{gpuMember}}
+	assert.NoError(t, storage.Job.CreateJob(running))
+	assert.NoError(t, storage.Auth.SaveUserQuota(ctx, &model.UserQuota{UserName: "alice", MaxConcurrentJobs: 1}))
+	quota, err = getUserQuota("alice")
+	assert.NoError(t, err)
+	assert.Error(t, checkUserQuota(job, quota))
+
+	assert.NoError(t, storage.Auth.SaveUserQuota(ctx, &model.UserQuota{UserName: "alice", MaxConcurrentJobs: 2, MaxGPUs: 3}))
+	quota, err = getUserQuota("alice")
+	assert.NoError(t, err)
+	assert.Error(t, checkUserQuota(job, quota))
+
+	assert.NoError(t, storage.Auth.SaveUserQuota(ctx, &model.UserQuota{UserName: "alice", MaxConcurrentJobs: 2, MaxGPUs: 4}))
+	quota, err = getUserQuota("alice")
+	assert.NoError(t, err)
+	assert.NoError(t, checkUserQuota(job, quota))
+
+	// timeout is checked by the activate time of job
+	quota = &model.UserQuota{UserName: "alice", MaxJobDuration: 60}
+	now := time.Now()
+	assert.False(t, quota.IsJobTimeout(running, now))
+	running.ActivatedAt = sql.NullTime{Time: now.Add(-2 * time.Minute), Valid: true}
+	assert.True(t, quota.IsJobTimeout(running, now))
+}
+
+func TestCheckConcurrencyGroup(t *testing.T) {
+	driver.InitMockDB()
+	job := &model.Job{ID: "job-new", UserName: "alice", Status: schema.StatusJobInit, ConcurrencyGroup: "retrain",
+		Config: &schema.Conf{ConcurrencyGroup: "retrain"}}
+	assert.NoError(t, checkConcurrencyGroup(job))
+
+	running := &model.Job{ID: "job-running", UserName: "alice", Status: schema.StatusJobRunning, ConcurrencyGroup: "retrain"}
+	assert.NoError(t, storage.Job.CreateJob(running))
+	// jobs of other users or other groups do not conflict
+	other := &model.Job{ID: "job-other", UserName: "bob", Status: schema.StatusJobRunning, ConcurrencyGroup: "retrain"}
+	assert.NoError(t, storage.Job.CreateJob(other))
+	assert.Error(t, checkConcurrencyGroup(job))
+
+	job.Config.ConcurrencyLimit = 2
+	assert.NoError(t, checkConcurrencyGroup(job))
+
+	job.ConcurrencyGroup, job.Config.ConcurrencyLimit = "eval", 0
+	assert.NoError(t, checkConcurrencyGroup(job))
+}
+
+func TestEnqueueQueueJobs(t *testing.T) {
+	driver.InitMockDB()
+	config.GlobalServerConfig = &config.ServerConfig{}
+	m, err := NewJobManagerImpl()
+	assert.NoError(t, err)
+	m.applyConfig(config.JobConfig{Dispatch: config.JobDispatchConfig{BatchSize: 2}})
+
+	queueID := api.QueueID("queue-1")
+	queue := &model.Queue{Model: model.Model{ID: string(queueID)}, Name: "queue-1", Status: schema.StatusQueueOpen}
+	queueCache, expireTime := m.getQueueCache()
+	assert.NoError(t, queueCache.SetWithExpire(queueID, &clusterQueue{
+		Queue:          api.NewQueueInfo(*queue),
+		ClusterRuntime: NewClusterRuntimeV2Info("test-cluster", nil),
+	}, expireTime))
+	jobQueue := api.NewJobQueue(api.NewQueueInfo(*queue))
+	m.jobQueues.Insert(queueID, jobQueue)
+
+	for _, jobID := range []string{"job-1", "job-2", "job-3"} {
+		assert.NoError(t, storage.Job.CreateJob(&model.Job{ID: jobID, QueueID: string(queueID),
+			Status: schema.StatusJobInit, Config: &schema.Conf{}}))
+	}
+	m.listQueueInitJobs = storage.Job.ListQueueInitJob
+	m.listJobQueueIDs = storage.Job.ListJobQueueIDs
+	assert.Equal(t, []string{string(queueID)}, m.listJobQueueIDs(schema.StatusJobInit))
+
+	batchSize := m.getDispatchConf().GetBatchSize()
+	assert.Equal(t, 2, m.enqueueQueueJobs(queueID, batchSize, time.Now()))
+	assert.Equal(t, 2, jobQueue.Len())
+	// jobs in memory reach batch size, and no more jobs are read
+	assert.Equal(t, 0, m.enqueueQueueJobs(queueID, batchSize, time.Now()))
+
+	job, ok := jobQueue.GetJob()
+	assert.True(t, ok)
+	assert.False(t, job.EnqueueTime.IsZero())
+	// jobs being submitted are not enqueued again
+	assert.Equal(t, 1, m.enqueueQueueJobs(queueID, batchSize, time.Now()))
+	assert.Equal(t, 2, jobQueue.Len())
+
+	// jobs held in init status are read again after the last page
+	jobQueue.DeleteMark(job.ID)
+	for jobQueue.Len() > 0 {
+		job, _ = jobQueue.GetJob()
+		jobQueue.DeleteMark(job.ID)
+	}
+	assert.Equal(t, 0, m.enqueueQueueJobs(queueID, batchSize, time.Now()))
+	assert.Equal(t, 2, m.enqueueQueueJobs(queueID, batchSize, time.Now()))
+}
+
+func TestLockDispatch(t *testing.T) {
+	driver.InitMockDB()
+	m, err := NewJobManagerImpl()
+	assert.NoError(t, err)
+
+	// jobs without quota or concurrency group are not serialized
+	job := &model.Job{ID: "job-1", UserName: "alice"}
+	m.lockDispatch(job, nil)
+	m.lockDispatch(job, &model.UserQuota{UserName: "alice"})()
+
+	job.ConcurrencyGroup = "retrain"
+	unlock := m.lockDispatch(job, nil)
+	locked := make(chan struct{})
+	go func() {
+		m.lockDispatch(&model.Job{ID: "job-2", UserName: "alice"}, &model.UserQuota{UserName: "alice", MaxGPUs: 4})()
+		close(locked)
+	}()
+	select {
+	case <-locked:
+		t.Fatal("jobs of the same user are dispatched concurrently")
+	case <-time.After(100 * time.Millisecond):
+	}
+	unlock()
+	<-locked
+}
@@ -0,0 +1,120 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package job
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/job/hook"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/job/runtime_v2/framework"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+)
+
+// reconcileStatus are job status in database, which need to be reconciled with jobs on cluster
+var reconcileStatus = []schema.JobStatus{
+	schema.StatusJobInit,
+	schema.StatusJobPending,
+	schema.StatusJobRunning,
+	schema.StatusJobTerminating,
+}
+
+// reconcileClusterJobs reconciles jobs in database with jobs on cluster before jobs are submitted to the cluster,
+// so that jobs are not stuck when server is restarted during job dispatching.
+// 1. init job which is already created on cluster is adopted, and its status is synced by job controller later;
+// 2. pending, running or terminating job which is not found on cluster is marked as failed or terminated;
+// 3. requeuing job which is not found on cluster is requeued;
+// 4. init job which is not created on cluster is submitted by job process loop.
+func reconcileClusterJobs(clusterID string, runtimeClient framework.RuntimeClientInterface) {
+	if runtimeClient == nil {
+		return
+	}
+	adopted, orphaned := 0, 0
+	for _, q := range storage.Queue.ListQueuesByCluster(clusterID) {
+		for _, job := range storage.Job.ListQueueJob(q.ID, reconcileStatus) {
+			switch reconcileJob(clusterID, runtimeClient, &job) {
+			case reconcileAdopted:
+				adopted++
+			case reconcileOrphaned:
+				orphaned++
+			}
+		}
+	}
+	log.Infof("reconcile jobs on cluster %s finished, adopted %d jobs, orphaned %d jobs", clusterID, adopted, orphaned)
+}
+
+type reconcileResult int
+
+const (
+	reconcileSkipped reconcileResult = iota
+	reconcileAdopted
+	reconcileOrphaned
+)
+
+func reconcileJob(clusterID string, runtimeClient framework.RuntimeClientInterface, job *model.Job) reconcileResult {
+	// sub jobs are managed by their parent job, and burst jobs are reconciled by the cluster they are burst to
+	if job.ParentJob != "" || job.Config == nil {
+		return reconcileSkipped
+	}
+	if jobClusterID := job.Config.GetClusterID(); jobClusterID != "" && jobClusterID != clusterID {
+		return reconcileSkipped
+	}
+	namespace := job.Config.GetNamespace()
+	fwVersion := framework.GetJobFrameworkVersion(runtimeClient, schema.JobType(job.Type), job.Framework,
+		[]byte(job.ExtensionTemplate))
+	_, err := runtimeClient.Get(namespace, job.ID, fwVersion)
+	if err != nil && !k8serrors.IsNotFound(err) {
+		log.Warnf("get %s job %s/%s on cluster %s failed, skip reconcile, err: %v", fwVersion, namespace, job.ID, clusterID, err)
+		return reconcileSkipped
+	}
+	exist := err == nil
+
+	var status schema.JobStatus
+	var msg string
+	switch {
+	case exist && job.Status == schema.StatusJobInit:
+		status, msg = schema.StatusJobPending, "job is adopted from cluster after server restart"
+	case !exist && job.Requeuing:
+		msg = "job is requeued after server restart"
+		if err = storage.Job.RequeueJob(job.ID, msg); err != nil {
+			log.Errorf("requeue job %s failed, err: %v", job.ID, err)
+			return reconcileSkipped
+		}
+		log.Infof("job %s is not found on cluster %s, %s", job.ID, clusterID, msg)
+		return reconcileOrphaned
+	case !exist && job.Status != schema.StatusJobInit:
+		// the status of terminating job is turned to terminated when updating
+		status, msg = schema.StatusJobFailed, fmt.Sprintf("job is not found on cluster after server restart, pre status %s", job.Status)
+	default:
+		return reconcileSkipped
+	}
+	newStatus, err := storage.Job.UpdateJob(job.ID, status, nil, nil, msg)
+	if err != nil {
+		log.Errorf("update status of job %s to %s failed, err: %v", job.ID, status, err)
+		return reconcileSkipped
+	}
+	hook.NotifyJobFinished(job.ID, job.Status, newStatus)
+	log.Infof("reconcile job %s on cluster %s, status from %s to %s", job.ID, clusterID, job.Status, newStatus)
+	if exist {
+		return reconcileAdopted
+	}
+	return reconcileOrphaned
+}
@@ -0,0 +1,98 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package job
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/config"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/k8s"
+	pfschema "github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/job/runtime_v2/client"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/storage/driver"
+)
+
+func TestReconcileClusterJobs(t *testing.T) {
+	config.GlobalServerConfig = &config.ServerConfig{}
+	driver.InitMockDB()
+	server := httptest.NewServer(k8s.DiscoveryHandlerFunc)
+	defer server.Close()
+	runtimeClient := client.NewFakeKubeRuntimeClient(server)
+	clusterID := runtimeClient.ClusterID()
+
+	queue := &model.Queue{Model: model.Model{ID: "queue-1"}, Name: "queue-1", ClusterId: clusterID}
+	assert.NoError(t, storage.DB.Create(queue).Error)
+
+	newJob := func(id string, status pfschema.JobStatus, onCluster bool, jobClusterID string) *model.Job {
+		conf := &pfschema.Conf{}
+		conf.SetNamespace("default")
+		conf.SetClusterID(jobClusterID)
+		job := &model.Job{
+			ID:        id,
+			Type:      string(pfschema.TypeSingle),
+			Framework: pfschema.FrameworkStandalone,
+			QueueID:   queue.ID,
+			Status:    status,
+			Config:    conf,
+		}
+		assert.NoError(t, storage.Job.CreateJob(job))
+		if onCluster {
+			pod := &unstructured.Unstructured{}
+			pod.SetGroupVersionKind(schema.GroupVersionKind{Version: "v1", Kind: "Pod"})
+			pod.SetNamespace("default")
+			pod.SetName(id)
+			_, err := runtimeClient.DynamicClient.Resource(schema.GroupVersionResource{Version: "v1", Resource: "pods"}).
+				Namespace("default").Create(context.TODO(), pod, metav1.CreateOptions{})
+			assert.NoError(t, err)
+		}
+		return job
+	}
+	newJob("job-init-dispatched", pfschema.StatusJobInit, true, "")
+	newJob("job-init", pfschema.StatusJobInit, false, "")
+	newJob("job-running", pfschema.StatusJobRunning, true, "")
+	newJob("job-running-lost", pfschema.StatusJobRunning, false, "")
+	newJob("job-terminating-lost", pfschema.StatusJobTerminating, false, "")
+	requeuing := newJob("job-requeuing", pfschema.StatusJobRunning, false, "")
+	assert.NoError(t, storage.DB.Model(requeuing).Update("requeuing", true).Error)
+	newJob("job-burst", pfschema.StatusJobRunning, false, "other-cluster")
+
+	reconcileClusterJobs(clusterID, runtimeClient)
+
+	expected := map[string]pfschema.JobStatus{
+		"job-init-dispatched":  pfschema.StatusJobPending,
+		"job-init":             pfschema.StatusJobInit,
+		"job-running":          pfschema.StatusJobRunning,
+		"job-running-lost":     pfschema.StatusJobFailed,
+		"job-terminating-lost": pfschema.StatusJobTerminated,
+		"job-requeuing":        pfschema.StatusJobInit,
+		"job-burst":            pfschema.StatusJobRunning,
+	}
+	for id, status := range expected {
+		job, err := storage.Job.GetJobByID(id)
+		assert.NoError(t, err)
+		assert.Equal(t, status, job.Status, id)
+	}
+}
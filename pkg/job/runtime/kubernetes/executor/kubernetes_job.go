@@ -740,6 +740,16 @@ func generateVolumes(fileSystem []schema.FileSystem) []corev1.Volume {
 		return vs
 	}
 
+	// 同一个存储的所有挂载均为只读时，pvc才以只读方式挂载，csi据此以只读方式挂载存储
+	readOnly := make(map[string]bool)
+	for _, fs := range fileSystem {
+		if ro, ok := readOnly[fs.Name]; ok {
+			readOnly[fs.Name] = ro && fs.ReadOnly
+		} else {
+			readOnly[fs.Name] = fs.ReadOnly
+		}
+	}
+
 	for _, fs := range fileSystem {
 		volume := corev1.Volume{
 			Name: fs.Name,
@@ -756,6 +766,7 @@ func generateVolumes(fileSystem []schema.FileSystem) []corev1.Volume {
 			volume.VolumeSource = corev1.VolumeSource{
 				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
 					ClaimName: schema.ConcatenatePVCName(fs.ID),
+					ReadOnly:  readOnly[fs.Name],
 				},
 			}
 		}
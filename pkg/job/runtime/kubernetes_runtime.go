@@ -615,7 +615,7 @@ func (kr *KubeRuntime) CreatePV(namespace, fsID string) (string, error) {
 		log.Errorf(err.Error())
 		return "", err
 	}
-	if err := kr.buildPV(newPV, fsID); err != nil {
+	if err := kr.buildPV(newPV, namespace, fsID); err != nil {
 		log.Errorf(err.Error())
 		return "", err
 	}
@@ -626,7 +626,7 @@ func (kr *KubeRuntime) CreatePV(namespace, fsID string) (string, error) {
 	return pv.Name, nil
 }
 
-func (kr *KubeRuntime) buildPV(pv *apiv1.PersistentVolume, fsID string) error {
+func (kr *KubeRuntime) buildPV(pv *apiv1.PersistentVolume, namespace, fsID string) error {
 	// filesystem
 	fs, err := storage.Filesystem.GetFileSystemWithFsID(fsID)
 	if err != nil {
@@ -654,15 +654,51 @@ func (kr *KubeRuntime) buildPV(pv *apiv1.PersistentVolume, fsID string) error {
 		return retErr
 	}
 
-	// set VolumeAttributes
+	// set VolumeAttributes, copier shares the csi source with the default pv, so copy it before writing
+	pv.Spec.CSI = pv.Spec.CSI.DeepCopy()
 	pv.Spec.CSI.VolumeHandle = pv.Name
 	pv.Spec.CSI.VolumeAttributes[schema.PFSID] = fsID
 	pv.Spec.CSI.VolumeAttributes[schema.PFSClusterID] = kr.cluster.ID
-	pv.Spec.CSI.VolumeAttributes[schema.PFSInfo] = base64.StdEncoding.EncodeToString(fsStr)
-	pv.Spec.CSI.VolumeAttributes[schema.PFSCache] = base64.StdEncoding.EncodeToString(fsCacheConfigStr)
+	// csi插件据此区分共用集群的多个apiserver
+	pv.Spec.CSI.VolumeAttributes[schema.PFSServer] = config.GetServiceAddress()
+	fsInfo := base64.StdEncoding.EncodeToString(fsStr)
+	fsCache := base64.StdEncoding.EncodeToString(fsCacheConfigStr)
+	if !config.GlobalServerConfig.Fs.MountInfoSecret {
+		pv.Spec.CSI.VolumeAttributes[schema.PFSInfo] = fsInfo
+		pv.Spec.CSI.VolumeAttributes[schema.PFSCache] = fsCache
+		return nil
+	}
+	// 存储配置中含有访问凭证，写入secret后由kubelet在NodePublishVolume时传给csi插件
+	secretName := schema.MountInfoSecretName(fsID, config.GetServiceAddress())
+	if err := kr.applyMountInfoSecret(namespace, secretName, fsInfo, fsCache); err != nil {
+		retErr := fmt.Errorf("create PV apply mount info secret[%s/%s] err: %v", namespace, secretName, err)
+		log.Errorf(retErr.Error())
+		return retErr
+	}
+	pv.Spec.CSI.NodePublishSecretRef = &apiv1.SecretReference{Name: secretName, Namespace: namespace}
 	return nil
 }
 
+// applyMountInfoSecret 创建或更新存放存储及缓存配置的secret
+func (kr *KubeRuntime) applyMountInfoSecret(namespace, name, fsInfo, fsCache string) error {
+	secret := &apiv1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		StringData: map[string]string{
+			schema.PFSInfo:  fsInfo,
+			schema.PFSCache: fsCache,
+		},
+	}
+	secrets := kr.clientset.CoreV1().Secrets(namespace)
+	_, err := secrets.Create(context.TODO(), secret, metav1.CreateOptions{})
+	if k8serrors.IsAlreadyExists(err) {
+		_, err = secrets.Update(context.TODO(), secret, metav1.UpdateOptions{})
+	}
+	return err
+}
+
 func (kr *KubeRuntime) CreatePVC(namespace, fsId, pv string) error {
 	pvc := config.DefaultPVC
 	pvcName := schema.ConcatenatePVCName(fsId)
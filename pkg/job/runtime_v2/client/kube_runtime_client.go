@@ -71,6 +71,8 @@ type KubeRuntimeClient struct {
 	ClusterInfo *pfschema.Cluster
 	// GVKToGVR contains GroupVersionKind map to GroupVersionResource
 	GVKToGVR sync.Map
+	// servedGVK contains canonical GroupVersionKind map to the compatible one served by cluster
+	servedGVK sync.Map
 
 	// JobInformerMap contains GroupVersionKind and informer for different kubernetes job
 	JobInformerMap map[schema.GroupVersionKind]cache.SharedIndexInformer
@@ -157,7 +159,7 @@ func (krc *KubeRuntimeClient) registerJobListener(workQueue workqueue.RateLimiti
 	}
 	for fv, jobPlugin := range jobPlugins {
 		gvk := frameworkVersionToGVK(fv)
-		gvrMap, err := krc.GetGVR(gvk)
+		_, gvrMap, err := krc.getServedGVR(gvk)
 		if err != nil {
 			log.Warnf("on %s, cann't find GroupVersionKind %s, err: %v", krc.Cluster(), gvk.String(), err)
 		} else {
@@ -213,7 +215,7 @@ func (krc *KubeRuntimeClient) registerQueueListener(workQueue workqueue.RateLimi
 	}
 	for fv, plugin := range queuePlugins {
 		gvk := frameworkVersionToGVK(fv)
-		gvrMap, err := krc.GetGVR(gvk)
+		_, gvrMap, err := krc.getServedGVR(gvk)
 		if err != nil {
 			log.Warnf("on %s, cann't find GroupVersionKind %s, err: %v", krc.Cluster(), gvk.String(), err)
 		} else {
@@ -289,6 +291,25 @@ func (krc *KubeRuntimeClient) GetGVR(gvk schema.GroupVersionKind) (meta.RESTMapp
 	return krc.findGVR(&gvk)
 }
 
+// getServedGVR returns the GroupVersionKind and GroupVersionResource served by cluster for gvk,
+// resources are rendered with the served version when cluster does not support the canonical one
+func (krc *KubeRuntimeClient) getServedGVR(gvk schema.GroupVersionKind) (schema.GroupVersionKind, meta.RESTMapping, error) {
+	servedGVK := gvk
+	if value, ok := krc.servedGVK.Load(gvk.String()); ok {
+		servedGVK = value.(schema.GroupVersionKind)
+	} else if _, ok = krc.GVKToGVR.Load(gvk.String()); !ok {
+		resolved, err := k8s.ResolveServedGVK(krc.DiscoveryClient, gvk)
+		if err != nil {
+			log.Warnf("on %s, resolve served version of %s failed, err: %v", krc.Cluster(), gvk.String(), err)
+		} else {
+			servedGVK = resolved
+			krc.servedGVK.Store(gvk.String(), servedGVK)
+		}
+	}
+	gvrMap, err := krc.GetGVR(servedGVK)
+	return servedGVK, gvrMap, err
+}
+
 func (krc *KubeRuntimeClient) findGVR(gvk *schema.GroupVersionKind) (meta.RESTMapping, error) {
 	// DiscoveryClient queries API server about the resources
 	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(krc.DiscoveryClient))
@@ -310,7 +331,7 @@ func (krc *KubeRuntimeClient) Get(namespace string, name string, fv pfschema.Fra
 	if krc == nil {
 		return nil, fmt.Errorf("dynamic client is nil")
 	}
-	gvrMap, err := krc.GetGVR(gvk)
+	gvk, gvrMap, err := krc.getServedGVR(gvk)
 	if err != nil {
 		return nil, err
 	}
@@ -326,13 +347,35 @@ func (krc *KubeRuntimeClient) Get(namespace string, name string, fv pfschema.Fra
 	return obj, err
 }
 
+func (krc *KubeRuntimeClient) List(namespace string, fv pfschema.FrameworkVersion, listOptions v1.ListOptions) (interface{}, error) {
+	gvk := frameworkVersionToGVK(fv)
+	log.Debugf("executor begin to list kubernetes resource[%s]. ns:[%s] selector:[%s]", gvk.String(), namespace, listOptions.LabelSelector)
+	if krc == nil {
+		return nil, fmt.Errorf("dynamic client is nil")
+	}
+	gvk, gvrMap, err := krc.getServedGVR(gvk)
+	if err != nil {
+		return nil, err
+	}
+	var list *unstructured.UnstructuredList
+	if gvrMap.Scope.Name() == meta.RESTScopeNameNamespace {
+		list, err = krc.DynamicClient.Resource(gvrMap.Resource).Namespace(namespace).List(context.TODO(), listOptions)
+	} else {
+		list, err = krc.DynamicClient.Resource(gvrMap.Resource).List(context.TODO(), listOptions)
+	}
+	if err != nil {
+		log.Errorf("list kubernetes %s resource in namespace[%s] failed. error:[%s]", gvk.String(), namespace, err.Error())
+	}
+	return list, err
+}
+
 func (krc *KubeRuntimeClient) Create(resource interface{}, fv pfschema.FrameworkVersion) error {
 	gvk := frameworkVersionToGVK(fv)
 	log.Debugf("executor begin to create kuberentes resource[%s]", gvk.String())
 	if krc == nil {
 		return fmt.Errorf("dynamic client is nil")
 	}
-	gvrMap, err := krc.GetGVR(gvk)
+	gvk, gvrMap, err := krc.getServedGVR(gvk)
 	if err != nil {
 		return err
 	}
@@ -369,7 +412,7 @@ func (krc *KubeRuntimeClient) Delete(namespace string, name string, fv pfschema.
 	deleteOptions := v1.DeleteOptions{
 		PropagationPolicy: &propagationPolicy,
 	}
-	gvrMap, err := krc.GetGVR(gvk)
+	gvk, gvrMap, err := krc.getServedGVR(gvk)
 	if err != nil {
 		return err
 	}
@@ -392,7 +435,7 @@ func (krc *KubeRuntimeClient) Patch(namespace, name string, fv pfschema.Framewor
 	}
 	patchType := types.StrategicMergePatchType
 	patchOptions := v1.PatchOptions{}
-	gvrMap, err := krc.GetGVR(gvk)
+	gvk, gvrMap, err := krc.getServedGVR(gvk)
 	if err != nil {
 		return err
 	}
@@ -413,7 +456,7 @@ func (krc *KubeRuntimeClient) Update(resource interface{}, fv pfschema.Framework
 	if krc == nil {
 		return fmt.Errorf("dynamic client is nil")
 	}
-	gvrMap, err := krc.GetGVR(gvk)
+	gvk, gvrMap, err := krc.getServedGVR(gvk)
 	if err != nil {
 		return err
 	}
@@ -17,7 +17,9 @@ limitations under the License.
 package client
 
 import (
+	"context"
 	"encoding/json"
+	"net/http"
 	"net/http/httptest"
 	"testing"
 
@@ -126,3 +128,82 @@ func TestExecutor(t *testing.T) {
 	err = runtimeClient.Delete(namespace, name, frameworkVersion)
 	assert.Equal(t, nil, err)
 }
+
+func TestCompatibleVersion(t *testing.T) {
+	// cluster only serves autoscaling/v2 and ray.io/v1
+	servedGroupVersions := map[string]metav1.APIResourceList{
+		"/apis/autoscaling/v2": {
+			GroupVersion: "autoscaling/v2",
+			APIResources: []metav1.APIResource{
+				{Name: "horizontalpodautoscalers", Namespaced: true, Kind: "HorizontalPodAutoscaler"},
+			},
+		},
+		"/apis/ray.io/v1": {
+			GroupVersion: "ray.io/v1",
+			APIResources: []metav1.APIResource{
+				{Name: "rayjobs", Namespaced: true, Kind: "RayJob"},
+			},
+		},
+	}
+	var server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var obj interface{}
+		if resources, find := servedGroupVersions[req.URL.Path]; find {
+			obj = resources
+		} else if req.URL.Path == "/apis" {
+			obj = metav1.APIGroupList{
+				Groups: []metav1.APIGroup{
+					{
+						Name:     "autoscaling",
+						Versions: []metav1.GroupVersionForDiscovery{{GroupVersion: "autoscaling/v2", Version: "v2"}},
+					},
+					{
+						Name:     "ray.io",
+						Versions: []metav1.GroupVersionForDiscovery{{GroupVersion: "ray.io/v1", Version: "v1"}},
+					},
+				},
+			}
+		} else if req.URL.Path == "/api" {
+			obj = metav1.APIVersions{Versions: []string{"v1"}}
+		} else {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		output, _ := json.Marshal(obj)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(output)
+	}))
+	defer server.Close()
+	runtimeClient := newFakeKubeRuntimeClient(server)
+
+	name, namespace := "rayjob", "default"
+	frameworkVersion := KubeFrameworkVersion(k8s.RayJobGVK)
+	obj := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"namespace": namespace,
+				"name":      name,
+			},
+		},
+	}
+	err := runtimeClient.Create(obj, frameworkVersion)
+	assert.NoError(t, err)
+	// rayjob is rendered with ray.io/v1
+	servedGVR := k8s.RayJobGVK.GroupKind().WithVersion("v1").GroupVersion().WithResource("rayjobs")
+	created, err := runtimeClient.DynamicClient.Resource(servedGVR).Namespace(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "ray.io/v1", created.GetAPIVersion())
+	// objects from cluster are normalized to canonical version
+	assert.Equal(t, k8s.RayJobGVK, k8s.CanonicalGVK(created.GroupVersionKind()))
+	_, err = runtimeClient.Get(namespace, name, frameworkVersion)
+	assert.NoError(t, err)
+	err = runtimeClient.Delete(namespace, name, frameworkVersion)
+	assert.NoError(t, err)
+
+	// hpa autoscaling/v2beta2 is rendered with autoscaling/v2
+	servedGVK, _, err := runtimeClient.getServedGVR(k8s.HPAGVK)
+	assert.NoError(t, err)
+	assert.Equal(t, "autoscaling/v2", servedGVK.GroupVersion().String())
+	// no compatible version is served
+	_, _, err = runtimeClient.getServedGVR(k8s.PaddleJobGVK)
+	assert.Error(t, err)
+}
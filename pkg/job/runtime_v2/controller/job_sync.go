@@ -27,8 +27,10 @@ import (
 	"k8s.io/client-go/util/workqueue"
 
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/config"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
 	pfschema "github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/job/api"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/job/hook"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/job/runtime_v2/framework"
 	_ "github.com/PaddlePaddle/PaddleFlow/pkg/job/runtime_v2/job"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/metrics"
@@ -50,6 +52,9 @@ type JobSync struct {
 	taskQueue workqueue.RateLimitingInterface
 	//  waitedCleanQueue contains jobs to be deleted
 	waitedCleanQueue workqueue.DelayingInterface
+	// jobShards taskShards contain events dispatched from jobQueue and taskQueue, which are processed concurrently
+	jobShards  *syncShards
+	taskShards *syncShards
 }
 
 func NewJobSync() *JobSync {
@@ -69,6 +74,9 @@ func (j *JobSync) Initialize(runtimeClient framework.RuntimeClientInterface) err
 	j.jobQueue = workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
 	j.taskQueue = workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
 	j.waitedCleanQueue = workqueue.NewDelayingQueue()
+	syncConf := config.GlobalServerConfig.Job.Sync
+	j.jobShards = newSyncShards(runtimeClient.ClusterID(), syncKindJob, syncConf)
+	j.taskShards = newSyncShards(runtimeClient.ClusterID(), syncKindTask, syncConf)
 
 	// Register job listeners
 	err := j.runtimeClient.RegisterListener(pfschema.ListenerTypeJob, j.jobQueue)
@@ -99,41 +107,67 @@ func (j *JobSync) Run(stopCh <-chan struct{}) {
 	}
 
 	j.preHandleTerminatingJob()
-	go wait.Until(j.runJobWorker, 0, stopCh)
-	go wait.Until(j.runTaskWorker, 0, stopCh)
+	log.Infof("%s runs with %d shards", j.Name(), len(j.jobShards.shards))
+	go wait.Until(j.dispatchJobs, 0, stopCh)
+	go wait.Until(j.dispatchTasks, 0, stopCh)
+	for i := range j.jobShards.shards {
+		jobShard, taskShard := j.jobShards.shards[i], j.taskShards.shards[i]
+		go wait.Until(func() { j.runJobWorker(jobShard) }, 0, stopCh)
+		go wait.Until(func() { j.runTaskWorker(taskShard) }, 0, stopCh)
+	}
 	go wait.Until(j.runJobGCWorker, 0, stopCh)
+	go func() {
+		<-stopCh
+		j.jobShards.shutDown()
+		j.taskShards.shutDown()
+	}()
+}
+
+// dispatchJobs dispatches job events from listeners to shards
+func (j *JobSync) dispatchJobs() {
+	for {
+		obj, shutdown := j.jobQueue.Get()
+		if shutdown {
+			return
+		}
+		jobSyncInfo := obj.(*api.JobSyncInfo)
+		j.jobShards.add(jobSyncInfo.ID, jobSyncInfo)
+		j.jobQueue.Forget(obj)
+		j.jobQueue.Done(obj)
+	}
 }
 
-func (j *JobSync) runJobWorker() {
-	for j.processJobWorkItem() {
+func (j *JobSync) runJobWorker(shard *syncShard) {
+	for j.processJobWorkItem(shard) {
 	}
 }
 
-func (j *JobSync) processJobWorkItem() bool {
-	obj, shutdown := j.jobQueue.Get()
+func (j *JobSync) processJobWorkItem(shard *syncShard) bool {
+	obj, shutdown := j.jobShards.get(shard)
 	if shutdown {
 		return false
 	}
 	jobSyncInfo := obj.(*api.JobSyncInfo)
-	log.Debugf("process job sync. jobID: %s", jobSyncInfo.ID)
-	defer j.jobQueue.Done(jobSyncInfo)
+	jobLogger := j.jobLogger(jobSyncInfo.ID)
+	jobLogger.Debugf("process job sync on shard %d", shard.index)
+	defer shard.queue.Done(jobSyncInfo)
 
 	if err := j.syncJobStatus(jobSyncInfo); err != nil {
-		log.Errorf("sync job status failed. jobID: %s err: %s", jobSyncInfo.ID, err.Error())
+		jobLogger.Errorf("sync job status failed. err: %s", err.Error())
 		if jobSyncInfo.RetryTimes < DefaultSyncRetryTimes {
 			jobSyncInfo.RetryTimes += 1
-			j.jobQueue.AddRateLimited(jobSyncInfo)
+			shard.queue.AddRateLimited(jobSyncInfo)
 		}
-		j.jobQueue.Forget(jobSyncInfo)
+		shard.queue.Forget(jobSyncInfo)
 		return true
 	}
 
-	j.jobQueue.Forget(jobSyncInfo)
+	shard.queue.Forget(jobSyncInfo)
 	return true
 }
 
 func (j *JobSync) syncJobStatus(jobSyncInfo *api.JobSyncInfo) error {
-	log.Infof("begin syncJobStatus jobID: %s, action: %s", jobSyncInfo.ID, jobSyncInfo.Action)
+	j.jobLogger(jobSyncInfo.ID).Infof("begin syncJobStatus, action: %s", jobSyncInfo.Action)
 	switch jobSyncInfo.Action {
 	case pfschema.Create:
 		j.gcFinishedJob(jobSyncInfo)
@@ -150,7 +184,8 @@ func (j *JobSync) syncJobStatus(jobSyncInfo *api.JobSyncInfo) error {
 }
 
 func (j *JobSync) doCreateAction(jobSyncInfo *api.JobSyncInfo) error {
-	log.Infof("do create action, job sync info: %s", jobSyncInfo.String())
+	jobLogger := j.jobLogger(jobSyncInfo.ID)
+	jobLogger.Infof("do create action, job sync info: %s", jobSyncInfo.String())
 	_, err := storage.Job.GetJobByID(jobSyncInfo.ID)
 	if err == nil {
 		return j.doUpdateAction(jobSyncInfo)
@@ -160,7 +195,7 @@ func (j *JobSync) doCreateAction(jobSyncInfo *api.JobSyncInfo) error {
 		// check weather parent job is exist or not
 		parentJob, err := storage.Job.GetJobByID(jobSyncInfo.ParentJobID)
 		if err != nil {
-			log.Errorf("get parent job %s failed, err: %v", jobSyncInfo.ParentJobID, err)
+			jobLogger.Errorf("get parent job %s failed, err: %v", jobSyncInfo.ParentJobID, err)
 			return err
 		}
 		// get job type and framework from FrameworkVersion
@@ -182,7 +217,7 @@ func (j *JobSync) doCreateAction(jobSyncInfo *api.JobSyncInfo) error {
 			ParentJob:     jobSyncInfo.ParentJobID,
 		}
 		if err = storage.Job.CreateJob(job); err != nil {
-			log.Errorf("In %s, craete job %v failed, err: %v", j.Name(), job, err)
+			jobLogger.Errorf("In %s, craete job %v failed, err: %v", j.Name(), job, err)
 			return err
 		}
 	}
@@ -190,18 +225,37 @@ func (j *JobSync) doCreateAction(jobSyncInfo *api.JobSyncInfo) error {
 }
 
 func (j *JobSync) doDeleteAction(jobSyncInfo *api.JobSyncInfo) error {
-	log.Infof("do delete action, job sync info are as follows. %s", jobSyncInfo.String())
-	if _, err := storage.Job.UpdateJob(jobSyncInfo.ID, pfschema.StatusJobTerminated, jobSyncInfo.RuntimeInfo,
-		jobSyncInfo.RuntimeStatus, "job is terminated"); err != nil {
-		log.Errorf("sync job status failed. jobID: %s, err: %s", jobSyncInfo.ID, err.Error())
+	jobLogger := j.jobLogger(jobSyncInfo.ID)
+	jobLogger.Infof("do delete action, job sync info are as follows. %s", jobSyncInfo.String())
+	job, err := storage.Job.GetJobByID(jobSyncInfo.ID)
+	if err == nil && job.Requeuing && job.Status != pfschema.StatusJobTerminating {
+		// job on cluster is deleted for requeue, and job manager will submit it again
+		msg := fmt.Sprintf("job is requeued due to node failure, attempt %d", job.RequeueTimes)
+		if j.isBurstJob(&job) {
+			msg = fmt.Sprintf("job is burst from queue %s to queue %s", job.BurstFromQueue, job.Config.GetQueueName())
+		}
+		jobLogger.Infof("requeue job, %s", msg)
+		return storage.Job.RequeueJob(job.ID, msg)
+	}
+	if err == nil && j.isBurstJob(&job) {
+		jobLogger.Infof("job is burst to other cluster, skip deletion of the job on cluster")
+		return nil
+	}
+	preStatus := job.Status
+	status, err := storage.Job.UpdateJob(jobSyncInfo.ID, pfschema.StatusJobTerminated, jobSyncInfo.RuntimeInfo,
+		jobSyncInfo.RuntimeStatus, "job is terminated")
+	if err != nil {
+		jobLogger.Errorf("sync job status failed. err: %s", err.Error())
 		return err
 	}
+	hook.NotifyJobFinished(jobSyncInfo.ID, preStatus, status)
 	return nil
 }
 
 func (j *JobSync) doUpdateAction(jobSyncInfo *api.JobSyncInfo) error {
-	log.Infof("do update action. jobID: %s, action: %s, status: %s, message: %s",
-		jobSyncInfo.ID, jobSyncInfo.Action, jobSyncInfo.Status, jobSyncInfo.Message)
+	jobLogger := j.jobLogger(jobSyncInfo.ID)
+	jobLogger.Infof("do update action. action: %s, status: %s, message: %s",
+		jobSyncInfo.Action, jobSyncInfo.Status, jobSyncInfo.Message)
 
 	// add time point
 	if pfschema.IsImmutableJobStatus(jobSyncInfo.Status) {
@@ -210,20 +264,33 @@ func (j *JobSync) doUpdateAction(jobSyncInfo *api.JobSyncInfo) error {
 		})
 	}
 
-	if _, err := storage.Job.UpdateJob(jobSyncInfo.ID, jobSyncInfo.Status, jobSyncInfo.RuntimeInfo,
-		jobSyncInfo.RuntimeStatus, jobSyncInfo.Message); err != nil {
-		log.Errorf("update job failed. jobID: %s, err: %s", jobSyncInfo.ID, err.Error())
+	job, err := storage.Job.GetJobByID(jobSyncInfo.ID)
+	if err == nil && job.Requeuing {
+		jobLogger.Infof("job is requeuing, skip status %s of the deleting job on cluster", jobSyncInfo.Status)
+		return nil
+	}
+	if err == nil && j.isBurstJob(&job) {
+		jobLogger.Infof("job is burst to other cluster, skip status %s on cluster", jobSyncInfo.Status)
+		return nil
+	}
+	preStatus := job.Status
+	status, err := storage.Job.UpdateJob(jobSyncInfo.ID, jobSyncInfo.Status, jobSyncInfo.RuntimeInfo,
+		jobSyncInfo.RuntimeStatus, jobSyncInfo.Message)
+	if err != nil {
+		jobLogger.Errorf("update job failed. err: %s", err.Error())
 		return err
 	}
+	hook.NotifyJobFinished(jobSyncInfo.ID, preStatus, status)
 	return nil
 }
 
 func (j *JobSync) doTerminateAction(jobSyncInfo *api.JobSyncInfo) error {
-	log.Infof("do terminate action. jobID: %s, action: %s, status: %s, message: %s",
-		jobSyncInfo.ID, jobSyncInfo.Action, jobSyncInfo.Status, jobSyncInfo.Message)
+	jobLogger := j.jobLogger(jobSyncInfo.ID)
+	jobLogger.Infof("do terminate action. action: %s, status: %s, message: %s",
+		jobSyncInfo.Action, jobSyncInfo.Status, jobSyncInfo.Message)
 	job, err := storage.Job.GetJobByID(jobSyncInfo.ID)
 	if err != nil {
-		log.Infof("do terminate action. jobID: %s not found", jobSyncInfo.ID)
+		jobLogger.Infof("do terminate action. job not found")
 		return nil
 	}
 	if job.Status != pfschema.StatusJobPending {
@@ -231,43 +298,62 @@ func (j *JobSync) doTerminateAction(jobSyncInfo *api.JobSyncInfo) error {
 	}
 	err = j.runtimeClient.Delete(jobSyncInfo.ID, jobSyncInfo.Namespace, jobSyncInfo.FrameworkVersion)
 	if err != nil {
-		log.Errorf("do terminate action failed. jobID[%s] error:[%s]", jobSyncInfo.ID, err.Error())
+		jobLogger.Errorf("do terminate action failed. error:[%s]", err.Error())
 	}
 	return err
 }
 
-func (j *JobSync) runTaskWorker() {
-	for j.processTaskWorkItem() {
+// jobLogger returns logger with job id and cluster id
+func (j *JobSync) jobLogger(jobID string) *log.Entry {
+	return logger.LoggerForJob(jobID).WithField(logger.FieldClusterID, j.runtimeClient.ClusterID())
+}
+
+// dispatchTasks dispatches task events to shards by job id of task
+func (j *JobSync) dispatchTasks() {
+	for {
+		obj, shutdown := j.taskQueue.Get()
+		if shutdown {
+			return
+		}
+		taskSyncInfo := obj.(*api.TaskSyncInfo)
+		j.taskShards.add(taskSyncInfo.JobID, taskSyncInfo)
+		j.taskQueue.Forget(obj)
+		j.taskQueue.Done(obj)
 	}
 }
 
-func (j *JobSync) processTaskWorkItem() bool {
-	obj, shutdown := j.taskQueue.Get()
+func (j *JobSync) runTaskWorker(shard *syncShard) {
+	for j.processTaskWorkItem(shard) {
+	}
+}
+
+func (j *JobSync) processTaskWorkItem(shard *syncShard) bool {
+	obj, shutdown := j.taskShards.get(shard)
 	if shutdown {
 		return false
 	}
 	taskSyncInfo := obj.(*api.TaskSyncInfo)
 	log.Debugf("process task sync. task name: %s/%s, id: %s", taskSyncInfo.Namespace, taskSyncInfo.Name, taskSyncInfo.ID)
-	defer j.taskQueue.Done(taskSyncInfo)
+	defer shard.queue.Done(taskSyncInfo)
 
 	if err := j.syncTaskStatus(taskSyncInfo); err != nil {
 		log.Errorf("sync task status failed. taskID: %s, err: %s", taskSyncInfo.ID, err.Error())
 		if taskSyncInfo.RetryTimes < DefaultSyncRetryTimes {
 			taskSyncInfo.RetryTimes += 1
-			j.taskQueue.AddRateLimited(taskSyncInfo)
+			shard.queue.AddRateLimited(taskSyncInfo)
 		}
-		j.taskQueue.Forget(taskSyncInfo)
+		shard.queue.Forget(taskSyncInfo)
 		return true
 	}
 
-	j.taskQueue.Forget(taskSyncInfo)
+	shard.queue.Forget(taskSyncInfo)
 	return true
 }
 
 func (j *JobSync) syncTaskStatus(taskSyncInfo *api.TaskSyncInfo) error {
 	name := taskSyncInfo.Name
 	namespace := taskSyncInfo.Namespace
-	_, err := storage.Job.GetJobByID(taskSyncInfo.JobID)
+	job, err := storage.Job.GetJobByID(taskSyncInfo.JobID)
 	if err != nil {
 		log.Warnf("update task %s/%s status failed, job %s for task not found", namespace, name, taskSyncInfo.JobID)
 		return err
@@ -295,9 +381,55 @@ func (j *JobSync) syncTaskStatus(taskSyncInfo *api.TaskSyncInfo) error {
 		log.Errorf("update task %s/%s status in database failed, err %v", namespace, name, err)
 		return err
 	}
+	if taskSyncInfo.NodeFailureReason != "" {
+		return j.requeueJob(&job, taskSyncInfo)
+	}
 	return nil
 }
 
+// isBurstJob returns true when the job is burst from this cluster to other cluster
+func (j *JobSync) isBurstJob(job *model.Job) bool {
+	return job.BurstFromQueue != "" && job.Config != nil && job.Config.GetClusterID() != "" &&
+		job.Config.GetClusterID() != j.runtimeClient.ClusterID()
+}
+
+// requeueJob deletes job on cluster when its pod is lost due to node failure, and the job is requeued
+// after the deletion is observed in doDeleteAction
+func (j *JobSync) requeueJob(job *model.Job, taskSyncInfo *api.TaskSyncInfo) error {
+	// sub jobs are managed by their parent job
+	if job.ParentJob != "" {
+		return nil
+	}
+	attempt := job.RequeueTimes
+	if !job.Requeuing {
+		limit := config.GlobalServerConfig.Job.GetNodeFailureRequeueLimit()
+		reason := fmt.Sprintf("pod %s/%s on node %s is lost, reason %s", taskSyncInfo.Namespace,
+			taskSyncInfo.Name, taskSyncInfo.NodeName, taskSyncInfo.NodeFailureReason)
+		marked, err := storage.Job.MarkJobRequeuing(job.ID, reason, limit)
+		if err != nil {
+			return err
+		}
+		if !marked {
+			log.Infof("job %s is not requeued, requeue times %d, limit %d", job.ID, job.RequeueTimes, limit)
+			return nil
+		}
+		log.Infof("job %s is requeuing, %s", job.ID, reason)
+		attempt++
+	}
+	namespace := job.Config.GetNamespace()
+	fwVersion := framework.GetJobFrameworkVersion(j.runtimeClient, pfschema.JobType(job.Type), job.Framework,
+		[]byte(job.ExtensionTemplate))
+	err := j.runtimeClient.Delete(namespace, job.ID, fwVersion)
+	if err != nil && k8serrors.IsNotFound(err) {
+		msg := fmt.Sprintf("job is requeued due to node failure, attempt %d", attempt)
+		return storage.Job.RequeueJob(job.ID, msg)
+	}
+	if err != nil {
+		log.Errorf("delete %s job %s/%s for requeue failed, err: %v", fwVersion, namespace, job.ID, err)
+	}
+	return err
+}
+
 func (j *JobSync) preHandleTerminatingJob() {
 	queues := storage.Queue.ListQueuesByCluster(j.runtimeClient.ClusterID())
 	if len(queues) == 0 {
@@ -312,7 +444,8 @@ func (j *JobSync) preHandleTerminatingJob() {
 	for _, job := range jobs {
 		name := job.ID
 		namespace := job.Config.GetNamespace()
-		fwVersion := j.runtimeClient.JobFrameworkVersion(pfschema.JobType(job.Type), job.Framework)
+		fwVersion := framework.GetJobFrameworkVersion(j.runtimeClient, pfschema.JobType(job.Type), job.Framework,
+			[]byte(job.ExtensionTemplate))
 
 		log.Debugf("pre handle terminating job, get %s job %s/%s from cluster", fwVersion, namespace, name)
 		_, err := j.runtimeClient.Get(namespace, name, fwVersion)
@@ -18,12 +18,16 @@ package controller
 
 import (
 	"net/http/httptest"
+	"reflect"
+	"strconv"
 	"testing"
 	"time"
 
+	"github.com/agiledragon/gomonkey/v2"
 	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	v1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -38,6 +42,7 @@ import (
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/config"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/k8s"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/job/api"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/job/runtime_v2/client"
 	_ "github.com/PaddlePaddle/PaddleFlow/pkg/job/runtime_v2/job"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
@@ -370,3 +375,171 @@ func TestTaskSync(t *testing.T) {
 		})
 	}
 }
+
+func TestRequeueJobOnNodeFailure(t *testing.T) {
+	jobID := "job-requeue"
+	config.GlobalServerConfig = &config.ServerConfig{
+		Job: config.JobConfig{
+			NodeFailureRequeueLimit: 1,
+		},
+	}
+	driver.InitMockDB()
+	err := storage.Job.CreateJob(&model.Job{
+		ID:     jobID,
+		Status: schema.StatusJobRunning,
+		Type:   string(schema.TypeSingle),
+		Config: &schema.Conf{
+			Env: map[string]string{
+				schema.EnvJobNamespace: "default",
+			},
+		},
+	})
+	assert.Equal(t, nil, err)
+
+	c := newFakeJobSyncController()
+	var kubeClient *client.KubeRuntimeClient
+	patch := gomonkey.ApplyMethod(reflect.TypeOf(kubeClient), "Delete",
+		func(_ *client.KubeRuntimeClient, namespace, name string, fv schema.FrameworkVersion) error {
+			return k8serrors.NewNotFound(v1.Resource("pods"), name)
+		})
+	defer patch.Reset()
+	taskInfo := &api.TaskSyncInfo{
+		ID:                "task-uid-1",
+		Name:              jobID,
+		Namespace:         "default",
+		JobID:             jobID,
+		NodeName:          "node-1",
+		Status:            schema.StatusTaskFailed,
+		Action:            schema.Update,
+		NodeFailureReason: "NodeLost: Node node-1 which was running pod job-requeue is unresponsive",
+	}
+	// job on cluster is not found, so it is requeued at once
+	err = c.syncTaskStatus(taskInfo)
+	assert.Equal(t, nil, err)
+	job, err := storage.Job.GetJobByID(jobID)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, schema.StatusJobInit, job.Status)
+	assert.Equal(t, 1, job.RequeueTimes)
+	assert.False(t, job.Requeuing)
+	assert.Contains(t, job.RequeueReason, "NodeLost")
+	assert.Equal(t, "job is requeued due to node failure, attempt 1", job.Message)
+
+	// requeue limit is reached
+	err = storage.Job.UpdateJobStatus(jobID, "", schema.StatusJobRunning)
+	assert.Equal(t, nil, err)
+	err = c.syncTaskStatus(taskInfo)
+	assert.Equal(t, nil, err)
+	job, err = storage.Job.GetJobByID(jobID)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, schema.StatusJobRunning, job.Status)
+	assert.Equal(t, 1, job.RequeueTimes)
+}
+
+func TestRequeuingJobSync(t *testing.T) {
+	jobID := "job-requeuing"
+	config.GlobalServerConfig = &config.ServerConfig{}
+	driver.InitMockDB()
+	err := storage.Job.CreateJob(&model.Job{
+		ID:     jobID,
+		Status: schema.StatusJobRunning,
+		Type:   string(schema.TypeSingle),
+	})
+	assert.Equal(t, nil, err)
+	marked, err := storage.Job.MarkJobRequeuing(jobID, "pod default/job-requeuing is lost", 3)
+	assert.Equal(t, nil, err)
+	assert.True(t, marked)
+	// job is marked only once
+	marked, err = storage.Job.MarkJobRequeuing(jobID, "pod default/job-requeuing is lost", 3)
+	assert.Equal(t, nil, err)
+	assert.False(t, marked)
+
+	c := newFakeJobSyncController()
+	// status of the deleting job on cluster is skipped
+	err = c.doUpdateAction(&api.JobSyncInfo{ID: jobID, Status: schema.StatusJobFailed, Action: schema.Update})
+	assert.Equal(t, nil, err)
+	job, err := storage.Job.GetJobByID(jobID)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, schema.StatusJobRunning, job.Status)
+
+	err = c.doDeleteAction(&api.JobSyncInfo{ID: jobID, Action: schema.Delete})
+	assert.Equal(t, nil, err)
+	job, err = storage.Job.GetJobByID(jobID)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, schema.StatusJobInit, job.Status)
+	assert.Equal(t, 1, job.RequeueTimes)
+	assert.False(t, job.Requeuing)
+}
+
+func TestBurstJobSync(t *testing.T) {
+	jobID := "job-burst"
+	config.GlobalServerConfig = &config.ServerConfig{}
+	driver.InitMockDB()
+	conf := &schema.Conf{QueueID: "queue-onprem", QueueName: "onprem-queue", ClusterID: "cluster-123"}
+	err := storage.Job.CreateJob(&model.Job{
+		ID:      jobID,
+		QueueID: "queue-onprem",
+		Status:  schema.StatusJobPending,
+		Type:    string(schema.TypeSingle),
+		Config:  conf,
+	})
+	assert.Equal(t, nil, err)
+	cloudConf := &schema.Conf{QueueID: "queue-cloud", QueueName: "cloud-queue", ClusterID: "cluster-cloud"}
+	marked, err := storage.Job.MarkJobBursting(jobID, "onprem-queue", "queue-cloud", cloudConf, nil)
+	assert.Equal(t, nil, err)
+	assert.True(t, marked)
+
+	c := newFakeJobSyncController()
+	err = c.doDeleteAction(&api.JobSyncInfo{ID: jobID, Action: schema.Delete})
+	assert.Equal(t, nil, err)
+	job, err := storage.Job.GetJobByID(jobID)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, schema.StatusJobInit, job.Status)
+	assert.Equal(t, "queue-cloud", job.QueueID)
+	assert.Equal(t, 0, job.RequeueTimes)
+	assert.Contains(t, job.Message, "burst from queue onprem-queue to queue cloud-queue")
+
+	// stale status of the job on the cluster it is burst from is skipped
+	err = c.doUpdateAction(&api.JobSyncInfo{ID: jobID, Status: schema.StatusJobFailed, Action: schema.Update})
+	assert.Equal(t, nil, err)
+	job, err = storage.Job.GetJobByID(jobID)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, schema.StatusJobInit, job.Status)
+}
+
+func TestSyncShards(t *testing.T) {
+	ss := newSyncShards("test-cluster", syncKindJob, config.JobSyncConfig{Shards: 4})
+	assert.Equal(t, 4, len(ss.shards))
+	// events of the same job are dispatched to the same shard
+	assert.Equal(t, ss.shard("job-1").index, ss.shard("job-1").index)
+
+	used := map[int]bool{}
+	for i := 0; i < 64; i++ {
+		jobID := "job-" + strconv.Itoa(i)
+		ss.add(jobID, &api.JobSyncInfo{ID: jobID})
+		used[ss.shard(jobID).index] = true
+	}
+	assert.Greater(t, len(used), 1)
+
+	total := 0
+	for _, shard := range ss.shards {
+		for shard.queue.Len() > 0 {
+			obj, shutdown := ss.get(shard)
+			assert.False(t, shutdown)
+			jobSyncInfo := obj.(*api.JobSyncInfo)
+			assert.Equal(t, shard.index, ss.shard(jobSyncInfo.ID).index)
+			shard.queue.Done(obj)
+			total++
+		}
+		assert.Equal(t, 0, len(shard.enqueueTime))
+	}
+	assert.Equal(t, 64, total)
+
+	ss.shutDown()
+	_, shutdown := ss.get(ss.shards[0])
+	assert.True(t, shutdown)
+
+	// shards is 1 by default
+	ss = newSyncShards("test-cluster", syncKindTask, config.JobSyncConfig{})
+	assert.Equal(t, 1, len(ss.shards))
+	assert.Nil(t, ss.shards[0].limiter)
+}
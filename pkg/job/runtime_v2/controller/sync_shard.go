@@ -0,0 +1,127 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/util/flowcontrol"
+	"k8s.io/client-go/util/workqueue"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/config"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/metrics"
+)
+
+const (
+	syncKindJob  = "job"
+	syncKindTask = "task"
+)
+
+// syncShard contains events processed by one worker
+type syncShard struct {
+	index int
+	queue workqueue.RateLimitingInterface
+	// limiter limits processing rate of the shard, nil means no limit
+	limiter flowcontrol.RateLimiter
+
+	mutex sync.Mutex
+	// enqueueTime records when event is dispatched to shard, which is used to calculate lag
+	enqueueTime map[interface{}]time.Time
+}
+
+// syncShards dispatches sync events to shards by hash of job id, so that events of the same job are processed in order,
+// and events of different jobs are processed concurrently
+type syncShards struct {
+	clusterID string
+	kind      string
+	shards    []*syncShard
+}
+
+func newSyncShards(clusterID, kind string, conf config.JobSyncConfig) *syncShards {
+	ss := &syncShards{
+		clusterID: clusterID,
+		kind:      kind,
+		shards:    make([]*syncShard, conf.GetShards()),
+	}
+	burst := conf.ShardBurst
+	if burst <= 0 {
+		burst = int(conf.ShardQPS) + 1
+	}
+	for i := range ss.shards {
+		shard := &syncShard{
+			index:       i,
+			queue:       workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+			enqueueTime: make(map[interface{}]time.Time),
+		}
+		if conf.ShardQPS > 0 {
+			shard.limiter = flowcontrol.NewTokenBucketRateLimiter(conf.ShardQPS, burst)
+		}
+		ss.shards[i] = shard
+	}
+	return ss
+}
+
+// shard returns the shard of job
+func (ss *syncShards) shard(jobID string) *syncShard {
+	if len(ss.shards) == 1 {
+		return ss.shards[0]
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(jobID))
+	return ss.shards[h.Sum32()%uint32(len(ss.shards))]
+}
+
+// add dispatches event of job to its shard
+func (ss *syncShards) add(jobID string, item interface{}) {
+	shard := ss.shard(jobID)
+	shard.mutex.Lock()
+	if _, find := shard.enqueueTime[item]; !find {
+		shard.enqueueTime[item] = time.Now()
+	}
+	shard.mutex.Unlock()
+	shard.queue.Add(item)
+	metrics.SetJobSyncShard(ss.clusterID, ss.kind, shard.index, metrics.JobSyncShardTypeDepth, float64(shard.queue.Len()))
+}
+
+// get returns next event of shard, and waits if the rate limit of shard is reached
+func (ss *syncShards) get(shard *syncShard) (interface{}, bool) {
+	item, shutdown := shard.queue.Get()
+	if shutdown {
+		return nil, true
+	}
+	if shard.limiter != nil {
+		shard.limiter.Accept()
+	}
+	shard.mutex.Lock()
+	enqueueTime, find := shard.enqueueTime[item]
+	delete(shard.enqueueTime, item)
+	shard.mutex.Unlock()
+	if find {
+		metrics.SetJobSyncShard(ss.clusterID, ss.kind, shard.index, metrics.JobSyncShardTypeLagSeconds, time.Since(enqueueTime).Seconds())
+	}
+	metrics.SetJobSyncShard(ss.clusterID, ss.kind, shard.index, metrics.JobSyncShardTypeDepth, float64(shard.queue.Len()))
+	metrics.AddJobSyncShard(ss.clusterID, ss.kind, shard.index, metrics.JobSyncShardTypeProcessed, 1)
+	return item, false
+}
+
+func (ss *syncShards) shutDown() {
+	for _, shard := range ss.shards {
+		shard.queue.ShutDown()
+	}
+}
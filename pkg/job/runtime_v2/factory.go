@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"sync"
 
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/envelope"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/job/api"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/job/runtime_v2/framework"
@@ -61,18 +62,23 @@ type RuntimeService interface {
 
 var PFRuntimeMap sync.Map
 
-func newClusterConfig(cluster model.ClusterInfo) schema.Cluster {
+// newClusterConfig decrypts credential of cluster, which is only decrypted here
+func newClusterConfig(cluster model.ClusterInfo) (schema.Cluster, error) {
+	credential, err := envelope.Decrypt(cluster.Credential)
+	if err != nil {
+		return schema.Cluster{}, fmt.Errorf("decrypt credential of cluster[%s] failed, err: %v", cluster.Name, err)
+	}
 	return schema.Cluster{
 		Name: cluster.Name,
 		ID:   cluster.ID,
 		Type: cluster.ClusterType,
 		ClientOpt: schema.ClientOptions{
 			Master: cluster.Endpoint,
-			Config: cluster.Credential,
+			Config: credential,
 			QPS:    1000,
 			Burst:  1000,
 		},
-	}
+	}, nil
 }
 
 func UpdateRuntime(clusterInfo model.ClusterInfo) error {
@@ -91,8 +97,10 @@ func GetOrCreateRuntime(clusterInfo model.ClusterInfo) (RuntimeService, error) {
 // CreateRuntime create RuntimeService and stored in Cache
 func CreateRuntime(clusterInfo model.ClusterInfo) (RuntimeService, error) {
 	var runtimeSvc RuntimeService
-	var err error
-	cluster := newClusterConfig(clusterInfo)
+	cluster, err := newClusterConfig(clusterInfo)
+	if err != nil {
+		return nil, err
+	}
 	switch cluster.Type {
 	case schema.LocalType:
 		//runtimeSvc = NewLocalRuntime(cluster)
@@ -19,8 +19,11 @@ package framework
 import (
 	"context"
 
+	log "github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/util/workqueue"
 
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/k8s"
 	pfschema "github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/job/api"
 )
@@ -71,6 +74,8 @@ type RuntimeClientInterface interface {
 
 	Get(namespace string, name string, fv pfschema.FrameworkVersion) (interface{}, error)
 
+	List(namespace string, fv pfschema.FrameworkVersion, listOptions metav1.ListOptions) (interface{}, error)
+
 	Create(resource interface{}, fv pfschema.FrameworkVersion) error
 
 	Delete(namespace string, name string, fv pfschema.FrameworkVersion) error
@@ -91,3 +96,16 @@ type RuntimeClientInterface interface {
 
 	JobFrameworkVersion(jobType pfschema.JobType, fw pfschema.Framework) pfschema.FrameworkVersion
 }
+
+// GetJobFrameworkVersion returns framework version of job, and custom job uses apiVersion and kind of its extension template
+func GetJobFrameworkVersion(runtimeClient RuntimeClientInterface, jobType pfschema.JobType, fw pfschema.Framework,
+	extensionTemplate []byte) pfschema.FrameworkVersion {
+	if fw == pfschema.FrameworkCustom {
+		fwVersion, err := k8s.GetCustomJobFrameworkVersion(extensionTemplate)
+		if err != nil {
+			log.Warnf("get framework version of custom job failed, err: %v", err)
+		}
+		return fwVersion
+	}
+	return runtimeClient.JobFrameworkVersion(jobType, fw)
+}
@@ -0,0 +1,221 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package custom
+
+import (
+	"context"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer/yaml"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/config"
+	pfschema "github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/job/api"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/job/runtime_v2/client"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/job/runtime_v2/framework"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/job/runtime_v2/job/util/kuberuntime"
+)
+
+// KubeCustomJob is a struct that runs job of any kind defined by its extension template,
+// and the status of job is converted by status mappings in server config
+type KubeCustomJob struct {
+	GVK              schema.GroupVersionKind
+	frameworkVersion pfschema.FrameworkVersion
+	runtimeClient    framework.RuntimeClientInterface
+	jobQueue         workqueue.RateLimitingInterface
+}
+
+// NewPlugin returns job plugin of custom job with GroupVersionKind gvk
+func NewPlugin(gvk schema.GroupVersionKind) framework.JobPlugin {
+	return func(kubeClient framework.RuntimeClientInterface) framework.JobInterface {
+		return &KubeCustomJob{
+			runtimeClient:    kubeClient,
+			GVK:              gvk,
+			frameworkVersion: client.KubeFrameworkVersion(gvk),
+		}
+	}
+}
+
+// RegisterPlugins registers plugins for kinds which have status mappings in server config, and builtin plugins are kept
+func RegisterPlugins() {
+	if config.GlobalServerConfig == nil {
+		return
+	}
+	for _, mapping := range config.GlobalServerConfig.Job.StatusMappings {
+		gvk := schema.FromAPIVersionAndKind(mapping.APIVersion, mapping.Kind)
+		if gvk.Kind == "" || gvk.Version == "" {
+			log.Warnf("invalid kind %s/%s of status mapping, skip it", mapping.APIVersion, mapping.Kind)
+			continue
+		}
+		fwVersion := client.KubeFrameworkVersion(gvk)
+		if _, found := framework.GetJobPlugin(pfschema.KubernetesType, fwVersion); found {
+			continue
+		}
+		log.Infof("register plugin for custom job %s", gvk.String())
+		framework.RegisterJobPlugin(pfschema.KubernetesType, fwVersion, NewPlugin(gvk))
+	}
+}
+
+func (cj *KubeCustomJob) String(name string) string {
+	return fmt.Sprintf("%s job %s on %s", cj.GVK.String(), name, cj.runtimeClient.Cluster())
+}
+
+func (cj *KubeCustomJob) Submit(ctx context.Context, job *api.PFJob) error {
+	if job == nil {
+		return fmt.Errorf("job is nil")
+	}
+	jobName := job.NamespacedName()
+	if len(job.ExtensionTemplate) == 0 {
+		return fmt.Errorf("cannot create %s without extension template", cj.String(jobName))
+	}
+	customJob := &unstructured.Unstructured{}
+	dec := yaml.NewDecodingSerializer(unstructured.UnstructuredJSONScheme)
+	if _, _, err := dec.Decode(job.ExtensionTemplate, nil, customJob); err != nil {
+		log.Errorf("decode extension template of %s failed, err: %v", cj.String(jobName), err)
+		return err
+	}
+	if customJob.GroupVersionKind() != cj.GVK {
+		return fmt.Errorf("expect GroupVersionKind is %s, but got %s", cj.GVK.String(), customJob.GroupVersionKind().String())
+	}
+	// set metadata field, including name, namespace, labels and annotations of PaddleFlow job
+	metadata := &metav1.ObjectMeta{
+		Labels:      customJob.GetLabels(),
+		Annotations: customJob.GetAnnotations(),
+	}
+	kuberuntime.BuildJobMetadata(metadata, job)
+	customJob.SetName(metadata.Name)
+	customJob.SetNamespace(metadata.Namespace)
+	customJob.SetLabels(metadata.Labels)
+	customJob.SetAnnotations(metadata.Annotations)
+
+	log.Debugf("begin to create %s, job info: %v", cj.String(jobName), customJob)
+	if err := cj.runtimeClient.Create(customJob, cj.frameworkVersion); err != nil {
+		log.Errorf("create %s failed, err %v", cj.String(jobName), err)
+		return err
+	}
+	return nil
+}
+
+func (cj *KubeCustomJob) Stop(ctx context.Context, job *api.PFJob) error {
+	if job == nil {
+		return fmt.Errorf("job is nil")
+	}
+	jobName := job.NamespacedName()
+	log.Infof("begin to stop %s", cj.String(jobName))
+	if err := cj.runtimeClient.Delete(job.Namespace, job.ID, cj.frameworkVersion); err != nil {
+		log.Errorf("stop %s failed, err: %v", cj.String(jobName), err)
+		return err
+	}
+	return nil
+}
+
+func (cj *KubeCustomJob) Update(ctx context.Context, job *api.PFJob) error {
+	if job == nil {
+		return fmt.Errorf("job is nil")
+	}
+	jobName := job.NamespacedName()
+	log.Infof("begin to update %s", cj.String(jobName))
+	if err := kuberuntime.UpdateKubeJob(job, cj.runtimeClient, cj.frameworkVersion); err != nil {
+		log.Errorf("update %s failed, err: %v", cj.String(jobName), err)
+		return err
+	}
+	return nil
+}
+
+func (cj *KubeCustomJob) Delete(ctx context.Context, job *api.PFJob) error {
+	if job == nil {
+		return fmt.Errorf("job is nil")
+	}
+	jobName := job.NamespacedName()
+	log.Infof("begin to delete %s ", cj.String(jobName))
+	if err := cj.runtimeClient.Delete(job.Namespace, job.ID, cj.frameworkVersion); err != nil {
+		log.Errorf("delete %s failed, err %v", cj.String(jobName), err)
+		return err
+	}
+	return nil
+}
+
+func (cj *KubeCustomJob) GetLog(ctx context.Context, jobLogRequest pfschema.JobLogRequest) (pfschema.JobLogInfo, error) {
+	// TODO: add get log logic
+	return pfschema.JobLogInfo{}, nil
+}
+
+func (cj *KubeCustomJob) AddEventListener(ctx context.Context, listenerType string, jobQueue workqueue.RateLimitingInterface, listener interface{}) error {
+	var err error
+	switch listenerType {
+	case pfschema.ListenerTypeJob:
+		err = cj.addJobEventListener(ctx, jobQueue, listener)
+	default:
+		err = fmt.Errorf("listenerType %s is not supported", listenerType)
+	}
+	return err
+}
+
+func (cj *KubeCustomJob) addJobEventListener(ctx context.Context, jobQueue workqueue.RateLimitingInterface, listener interface{}) error {
+	if jobQueue == nil || listener == nil {
+		return fmt.Errorf("add job event listener failed, err: listener is nil")
+	}
+	cj.jobQueue = jobQueue
+	informer := listener.(cache.SharedIndexInformer)
+	informer.AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: kuberuntime.ResponsibleForJob,
+		Handler: cache.ResourceEventHandlerFuncs{
+			AddFunc:    cj.addJob,
+			UpdateFunc: cj.updateJob,
+			DeleteFunc: cj.deleteJob,
+		},
+	})
+	return nil
+}
+
+func (cj *KubeCustomJob) addJob(obj interface{}) {
+	jobSyncInfo, err := kuberuntime.JobAddFunc(obj, cj.JobStatus)
+	if err != nil {
+		return
+	}
+	cj.jobQueue.Add(jobSyncInfo)
+}
+
+func (cj *KubeCustomJob) updateJob(old, new interface{}) {
+	jobSyncInfo, err := kuberuntime.JobUpdateFunc(old, new, cj.JobStatus)
+	if err != nil {
+		return
+	}
+	cj.jobQueue.Add(jobSyncInfo)
+}
+
+func (cj *KubeCustomJob) deleteJob(obj interface{}) {
+	jobSyncInfo, err := kuberuntime.JobDeleteFunc(obj, cj.JobStatus)
+	if err != nil {
+		return
+	}
+	cj.jobQueue.Add(jobSyncInfo)
+}
+
+// JobStatus get the statusInfo of custom job, which is called when no status mapping is matched,
+// and the job is regarded as pending
+func (cj *KubeCustomJob) JobStatus(obj interface{}) (api.StatusInfo, error) {
+	return api.StatusInfo{
+		Status: pfschema.StatusJobPending,
+	}, nil
+}
@@ -0,0 +1,132 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package custom
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/config"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/k8s"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/job/api"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/job/runtime_v2/client"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/job/runtime_v2/framework"
+)
+
+var extMPIJobYaml = `
+apiVersion: kubeflow.org/v1
+kind: MPIJob
+metadata:
+  name: mpi-test
+  labels:
+    app: mpi
+spec:
+  slotsPerWorker: 1
+  mpiReplicaSpecs:
+    Launcher:
+      replicas: 1
+    Worker:
+      replicas: 2
+`
+
+func TestCustomJob(t *testing.T) {
+	config.GlobalServerConfig = &config.ServerConfig{}
+	config.GlobalServerConfig.Job.StatusMappings = []config.JobStatusMapping{
+		{
+			APIVersion:     "kubeflow.org/v1",
+			Kind:           "MPIJob",
+			ConditionsPath: "status.conditions",
+			Phases:         map[string]string{"Running": "running"},
+		},
+	}
+	defer framework.CleanupJobPlugins(schema.KubernetesType)
+	RegisterPlugins()
+	fwVersion := client.KubeFrameworkVersion(k8s.MPIJobGVK)
+	_, found := framework.GetJobPlugin(schema.KubernetesType, fwVersion)
+	assert.True(t, found)
+
+	var server = httptest.NewServer(k8s.DiscoveryHandlerFunc)
+	defer server.Close()
+	kubeRuntimeClient := client.NewFakeKubeRuntimeClient(server)
+
+	tests := []struct {
+		caseName  string
+		jobObj    *api.PFJob
+		expectErr error
+	}{
+		{
+			caseName: "create custom job",
+			jobObj: &api.PFJob{
+				ID:                "custom-test1",
+				Namespace:         "default",
+				JobType:           schema.TypeDistributed,
+				Framework:         schema.FrameworkCustom,
+				QueueName:         "default-queue",
+				ExtensionTemplate: []byte(extMPIJobYaml),
+			},
+			expectErr: nil,
+		},
+		{
+			caseName: "kind mismatched",
+			jobObj: &api.PFJob{
+				ID:                "custom-test2",
+				Namespace:         "default",
+				JobType:           schema.TypeDistributed,
+				Framework:         schema.FrameworkCustom,
+				ExtensionTemplate: []byte("apiVersion: kubeflow.org/v1\nkind: TFJob\n"),
+			},
+			expectErr: fmt.Errorf("expect GroupVersionKind is kubeflow.org/v1, Kind=MPIJob, but got kubeflow.org/v1, Kind=TFJob"),
+		},
+		{
+			caseName: "no extension template",
+			jobObj: &api.PFJob{
+				ID:        "custom-test3",
+				Namespace: "default",
+				JobType:   schema.TypeDistributed,
+				Framework: schema.FrameworkCustom,
+			},
+			expectErr: fmt.Errorf("cannot create kubeflow.org/v1, Kind=MPIJob job default/custom-test3 on cluster default-cluster with type Kubernetes without extension template"),
+		},
+	}
+
+	customJob := NewPlugin(k8s.MPIJobGVK)(kubeRuntimeClient)
+	for _, test := range tests {
+		t.Run(test.caseName, func(t *testing.T) {
+			err := customJob.Submit(context.TODO(), test.jobObj)
+			assert.Equal(t, test.expectErr, err)
+			if err != nil {
+				return
+			}
+			obj, err := kubeRuntimeClient.Get(test.jobObj.Namespace, test.jobObj.ID, fwVersion)
+			assert.NoError(t, err)
+			jobObj := obj.(*unstructured.Unstructured)
+			assert.Equal(t, test.jobObj.ID, jobObj.GetName())
+			assert.Equal(t, "mpi", jobObj.GetLabels()["app"])
+			assert.Equal(t, test.jobObj.ID, jobObj.GetLabels()[schema.JobIDLabel])
+			assert.Equal(t, test.jobObj.QueueName, jobObj.GetLabels()[schema.QueueLabelKey])
+
+			err = customJob.Stop(context.TODO(), test.jobObj)
+			assert.NoError(t, err)
+		})
+	}
+}
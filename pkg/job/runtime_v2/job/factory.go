@@ -23,6 +23,7 @@ import (
 	"github.com/PaddlePaddle/PaddleFlow/pkg/job/runtime_v2/job/paddle"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/job/runtime_v2/job/pytorch"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/job/runtime_v2/job/ray"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/job/runtime_v2/job/serving"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/job/runtime_v2/job/single"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/job/runtime_v2/job/spark"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/job/runtime_v2/job/tensorflow"
@@ -37,5 +38,6 @@ func init() {
 	framework.RegisterJobPlugin(pfschema.KubernetesType, spark.KubeSparkFwVersion, spark.New)
 	framework.RegisterJobPlugin(pfschema.KubernetesType, ray.KubeRayFwVersion, ray.New)
 	framework.RegisterJobPlugin(pfschema.KubernetesType, argoworkflow.KubeArgoWorkflowFwVersion, argoworkflow.New)
+	framework.RegisterJobPlugin(pfschema.KubernetesType, serving.KubeServingFwVersion, serving.New)
 	// TODO: add more plugins
 }
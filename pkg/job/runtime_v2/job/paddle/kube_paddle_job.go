@@ -85,12 +85,13 @@ func (pj *KubePaddleJob) Submit(ctx context.Context, job *api.PFJob) error {
 		return err
 	}
 	// build job spec field
+	var replicas []kuberuntime.DistributedReplica
 	if job.IsCustomYaml {
 		// set custom PaddleJob Spec from user
 		err = pj.customPaddleJob(pdj, job)
 	} else {
 		// set builtin PaddleJob Spec
-		err = pj.builtinPaddleJob(pdj, job)
+		replicas, err = pj.builtinPaddleJob(pdj, job)
 	}
 	if err != nil {
 		log.Errorf("build %s spec failed, err %v", pj.String(jobName), err)
@@ -102,6 +103,10 @@ func (pj *KubePaddleJob) Submit(ctx context.Context, job *api.PFJob) error {
 		log.Errorf("create %s failed, err %v", pj.String(jobName), err)
 		return err
 	}
+	if err = kuberuntime.CreateDiscoveryServices(pj.runtimeClient, job, replicas, pj.frameworkVersion); err != nil {
+		log.Errorf("create discovery services for %s failed, err %v", pj.String(jobName), err)
+		return err
+	}
 	return nil
 }
 
@@ -169,35 +174,52 @@ func (pj *KubePaddleJob) buildSchedulingPolicy(pdjSpec *paddlejobv1.PaddleJobSpe
 	return nil
 }
 
-func (pj *KubePaddleJob) builtinPaddleJob(pdj *paddlejobv1.PaddleJob, job *api.PFJob) error {
+// builtinPaddleJob set build-in PaddleJob spec, and returns replicas which need discovery services
+func (pj *KubePaddleJob) builtinPaddleJob(pdj *paddlejobv1.PaddleJob, job *api.PFJob) ([]kuberuntime.DistributedReplica, error) {
 	if pdj == nil || job == nil {
-		return fmt.Errorf("PaddleJob or PFJob is nil")
+		return nil, fmt.Errorf("PaddleJob or PFJob is nil")
 	}
 	jobName := job.NamespacedName()
 	// build job tasks
 	var minAvailable int32
+	var replicas []kuberuntime.DistributedReplica
 	minResources := resources.EmptyResource()
 	for _, task := range job.Tasks {
 		var err error
+		var resourceSpec *paddlejobv1.ResourceSpec
+		var replicaType string
 		switch task.Role {
 		case pfschema.RolePServer:
 			// patch parameter server
-			err = pj.patchPaddleTask(pdj.Spec.PS, task, job.ID)
+			resourceSpec, replicaType = pdj.Spec.PS, paddlejobv1.ResourcePS
+			err = pj.patchPaddleTask(resourceSpec, task, job.ID)
 		case pfschema.RolePWorker, pfschema.RoleWorker:
 			// patch worker
-			err = pj.patchPaddleTask(pdj.Spec.Worker, task, job.ID)
+			resourceSpec, replicaType = pdj.Spec.Worker, paddlejobv1.ResourceWorker
+			err = pj.patchPaddleTask(resourceSpec, task, job.ID)
 		default:
 			err = fmt.Errorf("role %s is not supported", task.Role)
 		}
 		if err != nil {
 			log.Errorf("build task for paddle job with role %s failed, err: %v", task.Role, err)
-			return err
+			return nil, err
+		}
+		if resourceSpec != nil {
+			replicas = append(replicas, kuberuntime.DistributedReplica{
+				Role:        task.Role,
+				ReplicaType: replicaType,
+				Replicas:    resourceSpec.Replicas,
+				Port:        task.Port,
+				Template:    &resourceSpec.Template,
+				// paddle operator labels pods with pod name
+				FirstPodLabels: map[string]string{paddlejobv1.ResourceName: fmt.Sprintf("%s-%s-0", job.ID, replicaType)},
+			})
 		}
 		// calculate min resources
 		taskResources, err := resources.NewResourceFromMap(task.Flavour.ToMap())
 		if err != nil {
 			log.Errorf("parse resources for %s task failed, err: %v", pj.String(jobName), err)
-			return err
+			return nil, err
 		}
 		taskResources.Multi(task.Replicas)
 		minResources.Add(taskResources)
@@ -209,7 +231,10 @@ func (pj *KubePaddleJob) builtinPaddleJob(pdj *paddlejobv1.PaddleJob, job *api.P
 		pdj.Spec.SchedulingPolicy.MinAvailable = &minAvailable
 		pdj.Spec.SchedulingPolicy.MinResources = k8s.NewResourceList(minResources)
 	}
-	return nil
+	// inject discovery env for distributed training
+	kuberuntime.SortDistributedReplicas(replicas)
+	kuberuntime.BuildDistributedEnv(job, replicas)
+	return replicas, nil
 }
 
 // patchPaddleTask patch info into task of paddle job
@@ -28,6 +28,7 @@ import (
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/job/api"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/job/runtime_v2/client"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/job/runtime_v2/job/util/kuberuntime"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/storage/driver"
 )
 
@@ -100,6 +101,12 @@ func TestPyTorchJob_CreateJob(t *testing.T) {
 				} else {
 					t.Logf("obj=%#v", jobObj)
 				}
+				// discovery services for master and worker
+				for _, name := range []string{kuberuntime.MasterServiceName(test.jobObj.ID),
+					kuberuntime.DiscoveryServiceName(test.jobObj.ID, "Worker")} {
+					_, err = kubeRuntimeClient.Get(test.jobObj.Namespace, name, kuberuntime.ServiceFwVersion)
+					assert.NoError(t, err)
+				}
 			}
 		})
 	}
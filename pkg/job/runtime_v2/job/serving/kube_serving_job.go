@@ -0,0 +1,464 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package serving
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	log "github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2beta2"
+	v1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/k8s"
+	pfschema "github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/job/api"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/job/runtime_v2/client"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/job/runtime_v2/framework"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/job/runtime_v2/job/util/kuberuntime"
+)
+
+const (
+	// QPSMetricName and GPUMetricName are the pod metrics exposed by custom metrics adapter
+	QPSMetricName = "requests_per_second"
+	GPUMetricName = "DCGM_FI_DEV_GPU_UTIL"
+
+	defaultQPSTarget = 100
+	defaultGPUTarget = 80
+)
+
+var (
+	JobGVK               = k8s.DeploymentGVK
+	KubeServingFwVersion = client.KubeFrameworkVersion(JobGVK)
+	ServiceFwVersion     = client.KubeFrameworkVersion(k8s.ServiceGVK)
+	HPAFwVersion         = client.KubeFrameworkVersion(k8s.HPAGVK)
+)
+
+// ServingPolicy defines replicas and autoscaling policy of serving job
+type ServingPolicy struct {
+	MinReplicas int32
+	MaxReplicas int32
+	Metric      string
+	TargetValue int64
+	HealthPath  string
+	Port        int
+}
+
+// NewServingPolicy parse serving policy from job conf
+func NewServingPolicy(conf *pfschema.Conf, port int) (ServingPolicy, error) {
+	policy := ServingPolicy{
+		MinReplicas: 1,
+		Metric:      conf.GetEnvValue(pfschema.EnvServingMetric),
+		HealthPath:  conf.GetEnvValue(pfschema.EnvServingHealthPath),
+		Port:        port,
+	}
+	if value := conf.GetEnvValue(pfschema.EnvServingMinReplicas); value != "" {
+		replicas, err := strconv.ParseInt(value, 10, 32)
+		if err != nil {
+			return policy, fmt.Errorf("parse min replicas %s failed, err: %v", value, err)
+		}
+		policy.MinReplicas = int32(replicas)
+	}
+	policy.MaxReplicas = policy.MinReplicas
+	if value := conf.GetEnvValue(pfschema.EnvServingMaxReplicas); value != "" {
+		replicas, err := strconv.ParseInt(value, 10, 32)
+		if err != nil {
+			return policy, fmt.Errorf("parse max replicas %s failed, err: %v", value, err)
+		}
+		policy.MaxReplicas = int32(replicas)
+	}
+	if value := conf.GetEnvValue(pfschema.EnvServingTargetValue); value != "" {
+		target, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return policy, fmt.Errorf("parse target value %s failed, err: %v", value, err)
+		}
+		policy.TargetValue = target
+	}
+	return policy, nil
+}
+
+// AutoScaling returns true if serving job need a HorizontalPodAutoscaler
+func (p ServingPolicy) AutoScaling() bool {
+	return p.MaxReplicas > p.MinReplicas
+}
+
+// KubeServingJob is an executor struct that runs a model serving job
+type KubeServingJob struct {
+	GVK              schema.GroupVersionKind
+	frameworkVersion pfschema.FrameworkVersion
+	runtimeClient    framework.RuntimeClientInterface
+	jobQueue         workqueue.RateLimitingInterface
+}
+
+func New(kubeClient framework.RuntimeClientInterface) framework.JobInterface {
+	return &KubeServingJob{
+		runtimeClient:    kubeClient,
+		GVK:              JobGVK,
+		frameworkVersion: KubeServingFwVersion,
+	}
+}
+
+func (sj *KubeServingJob) String(name string) string {
+	return fmt.Sprintf("%s job %s on %s", sj.GVK.String(), name, sj.runtimeClient.Cluster())
+}
+
+func (sj *KubeServingJob) Submit(ctx context.Context, job *api.PFJob) error {
+	if job == nil {
+		return fmt.Errorf("job is nil")
+	}
+	jobName := job.NamespacedName()
+	log.Debugf("begin to create %s", sj.String(jobName))
+	if len(job.Tasks) != 1 {
+		return fmt.Errorf("create %s failed, serving job must have one member", sj.String(jobName))
+	}
+	policy, err := NewServingPolicy(&job.Conf, job.Tasks[0].Port)
+	if err != nil {
+		log.Errorf("get serving policy for %s failed, err: %v", sj.String(jobName), err)
+		return err
+	}
+
+	deployment := &appsv1.Deployment{}
+	if err = kuberuntime.CreateKubeJobFromYaml(deployment, sj.GVK, job); err != nil {
+		log.Errorf("create %s failed, err %v", sj.String(jobName), err)
+		return err
+	}
+	// set metadata field
+	kuberuntime.BuildJobMetadata(&deployment.ObjectMeta, job)
+	deployment.Labels[pfschema.JobLabelFramework] = string(pfschema.FrameworkStandalone)
+	// build job spec field
+	if job.IsCustomYaml {
+		err = sj.customServingJob(deployment, job)
+	} else {
+		err = sj.builtinServingJob(deployment, job, policy)
+	}
+	if err != nil {
+		log.Errorf("build %s spec failed, err %v", sj.String(jobName), err)
+		return err
+	}
+	log.Debugf("begin to create %s, deployment: %v", sj.String(jobName), deployment)
+	if err = sj.runtimeClient.Create(deployment, sj.frameworkVersion); err != nil {
+		log.Errorf("create %s failed, err %v", sj.String(jobName), err)
+		return err
+	}
+	created := []pfschema.FrameworkVersion{sj.frameworkVersion}
+	// create service to expose the endpoint of serving job
+	if policy.Port > 0 {
+		if err = sj.runtimeClient.Create(buildService(job, policy), ServiceFwVersion); err != nil {
+			log.Errorf("create service for %s failed, err %v", sj.String(jobName), err)
+			sj.rollbackServingJob(job, created)
+			return err
+		}
+		created = append(created, ServiceFwVersion)
+	}
+	// create hpa to scale serving job between min and max replicas
+	if policy.AutoScaling() {
+		if err = sj.runtimeClient.Create(buildHPA(job, policy), HPAFwVersion); err != nil {
+			log.Errorf("create hpa for %s failed, err %v", sj.String(jobName), err)
+			sj.rollbackServingJob(job, created)
+			return err
+		}
+	}
+	return nil
+}
+
+// rollbackServingJob delete resources created by this submission in reverse order, so that a failed
+// submission does not leave an orphaned deployment behind
+func (sj *KubeServingJob) rollbackServingJob(job *api.PFJob, created []pfschema.FrameworkVersion) {
+	for i := len(created) - 1; i >= 0; i-- {
+		err := sj.runtimeClient.Delete(job.Namespace, job.ID, created[i])
+		if err != nil && !k8serrors.IsNotFound(err) {
+			log.Errorf("rollback %s of %s failed, err %v", created[i], sj.String(job.NamespacedName()), err)
+		}
+	}
+}
+
+func (sj *KubeServingJob) customServingJob(deployment *appsv1.Deployment, job *api.PFJob) error {
+	if deployment == nil || job == nil {
+		return fmt.Errorf("deployment or PFJob is nil")
+	}
+	setSelector(deployment, job.ID)
+	return nil
+}
+
+func (sj *KubeServingJob) builtinServingJob(deployment *appsv1.Deployment, job *api.PFJob, policy ServingPolicy) error {
+	if deployment == nil || job == nil {
+		return fmt.Errorf("deployment or PFJob is nil")
+	}
+	task := job.Tasks[0]
+	if task.Name == "" {
+		task.Name = job.ID
+	}
+	replicas := policy.MinReplicas
+	deployment.Spec.Replicas = &replicas
+	setSelector(deployment, job.ID)
+	if len(job.QueueName) > 0 {
+		deployment.Spec.Template.Annotations = map[string]string{pfschema.QueueLabelKey: job.QueueName}
+	}
+	if err := kuberuntime.BuildPodTemplateSpec(&deployment.Spec.Template, job.ID, &task); err != nil {
+		return err
+	}
+	podSpec := &deployment.Spec.Template.Spec
+	// pods of deployment must always be restarted
+	podSpec.RestartPolicy = v1.RestartPolicyAlways
+	if policy.Port > 0 && len(podSpec.Containers) > 0 {
+		container := &podSpec.Containers[0]
+		container.Ports = []v1.ContainerPort{{Name: "serving", ContainerPort: int32(policy.Port)}}
+		if policy.HealthPath != "" {
+			container.ReadinessProbe = &v1.Probe{
+				Handler: v1.Handler{
+					HTTPGet: &v1.HTTPGetAction{
+						Path: policy.HealthPath,
+						Port: intstr.FromInt(policy.Port),
+					},
+				},
+				PeriodSeconds: 10,
+			}
+		}
+	}
+	return nil
+}
+
+func setSelector(deployment *appsv1.Deployment, jobID string) {
+	if deployment.Spec.Selector == nil {
+		deployment.Spec.Selector = &metav1.LabelSelector{}
+	}
+	if deployment.Spec.Selector.MatchLabels == nil {
+		deployment.Spec.Selector.MatchLabels = make(map[string]string)
+	}
+	deployment.Spec.Selector.MatchLabels[pfschema.JobIDLabel] = jobID
+	if deployment.Spec.Template.Labels == nil {
+		deployment.Spec.Template.Labels = make(map[string]string)
+	}
+	deployment.Spec.Template.Labels[pfschema.JobIDLabel] = jobID
+}
+
+func buildService(job *api.PFJob, policy ServingPolicy) *v1.Service {
+	return &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      job.ID,
+			Namespace: job.Namespace,
+			Labels: map[string]string{
+				pfschema.JobOwnerLabel: pfschema.JobOwnerValue,
+				pfschema.JobIDLabel:    job.ID,
+			},
+		},
+		Spec: v1.ServiceSpec{
+			Selector: map[string]string{pfschema.JobIDLabel: job.ID},
+			Ports: []v1.ServicePort{
+				{
+					Name:       "serving",
+					Port:       int32(policy.Port),
+					TargetPort: intstr.FromInt(policy.Port),
+				},
+			},
+		},
+	}
+}
+
+func buildHPA(job *api.PFJob, policy ServingPolicy) *autoscalingv2.HorizontalPodAutoscaler {
+	minReplicas := policy.MinReplicas
+	hpa := &autoscalingv2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      job.ID,
+			Namespace: job.Namespace,
+			Labels: map[string]string{
+				pfschema.JobOwnerLabel: pfschema.JobOwnerValue,
+				pfschema.JobIDLabel:    job.ID,
+			},
+		},
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{
+				APIVersion: JobGVK.GroupVersion().String(),
+				Kind:       JobGVK.Kind,
+				Name:       job.ID,
+			},
+			MinReplicas: &minReplicas,
+			MaxReplicas: policy.MaxReplicas,
+		},
+	}
+	metricName, target := QPSMetricName, int64(defaultQPSTarget)
+	if policy.Metric == pfschema.ServingMetricGPU {
+		metricName, target = GPUMetricName, defaultGPUTarget
+	}
+	if policy.TargetValue > 0 {
+		target = policy.TargetValue
+	}
+	targetValue := resource.NewQuantity(target, resource.DecimalSI)
+	hpa.Spec.Metrics = []autoscalingv2.MetricSpec{
+		{
+			Type: autoscalingv2.PodsMetricSourceType,
+			Pods: &autoscalingv2.PodsMetricSource{
+				Metric: autoscalingv2.MetricIdentifier{Name: metricName},
+				Target: autoscalingv2.MetricTarget{
+					Type:         autoscalingv2.AverageValueMetricType,
+					AverageValue: targetValue,
+				},
+			},
+		},
+	}
+	return hpa
+}
+
+func (sj *KubeServingJob) Stop(ctx context.Context, job *api.PFJob) error {
+	if job == nil {
+		return fmt.Errorf("job is nil")
+	}
+	jobName := job.NamespacedName()
+	log.Infof("begin to stop %s", sj.String(jobName))
+	if err := sj.deleteServingJob(job); err != nil {
+		log.Errorf("stop %s failed, err: %v", sj.String(jobName), err)
+		return err
+	}
+	return nil
+}
+
+func (sj *KubeServingJob) Update(ctx context.Context, job *api.PFJob) error {
+	if job == nil {
+		return fmt.Errorf("job is nil")
+	}
+	jobName := job.NamespacedName()
+	log.Infof("begin to update %s", sj.String(jobName))
+	if err := kuberuntime.UpdateKubeJob(job, sj.runtimeClient, sj.frameworkVersion); err != nil {
+		log.Errorf("update %s failed, err: %v", sj.String(jobName), err)
+		return err
+	}
+	return nil
+}
+
+func (sj *KubeServingJob) Delete(ctx context.Context, job *api.PFJob) error {
+	if job == nil {
+		return fmt.Errorf("job is nil")
+	}
+	jobName := job.NamespacedName()
+	log.Infof("begin to delete %s ", sj.String(jobName))
+	if err := sj.deleteServingJob(job); err != nil {
+		log.Errorf("delete %s failed, err %v", sj.String(jobName), err)
+		return err
+	}
+	return nil
+}
+
+// deleteServingJob delete hpa, service and deployment of serving job
+func (sj *KubeServingJob) deleteServingJob(job *api.PFJob) error {
+	for _, fv := range []pfschema.FrameworkVersion{HPAFwVersion, ServiceFwVersion} {
+		err := sj.runtimeClient.Delete(job.Namespace, job.ID, fv)
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return sj.runtimeClient.Delete(job.Namespace, job.ID, sj.frameworkVersion)
+}
+
+func (sj *KubeServingJob) GetLog(ctx context.Context, jobLogRequest pfschema.JobLogRequest) (pfschema.JobLogInfo, error) {
+	// TODO: add get log logic
+	return pfschema.JobLogInfo{}, nil
+}
+
+func (sj *KubeServingJob) AddEventListener(ctx context.Context, listenerType string, jobQueue workqueue.RateLimitingInterface, listener interface{}) error {
+	var err error
+	switch listenerType {
+	case pfschema.ListenerTypeJob:
+		err = sj.addJobEventListener(ctx, jobQueue, listener)
+	default:
+		err = fmt.Errorf("listenerType %s is not supported", listenerType)
+	}
+	return err
+}
+
+func (sj *KubeServingJob) addJobEventListener(ctx context.Context, jobQueue workqueue.RateLimitingInterface, listener interface{}) error {
+	if jobQueue == nil || listener == nil {
+		return fmt.Errorf("add job event listener failed, err: listener is nil")
+	}
+	sj.jobQueue = jobQueue
+	informer := listener.(cache.SharedIndexInformer)
+	informer.AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: kuberuntime.ResponsibleForJob,
+		Handler: cache.ResourceEventHandlerFuncs{
+			AddFunc:    sj.addJob,
+			UpdateFunc: sj.updateJob,
+			DeleteFunc: sj.deleteJob,
+		},
+	})
+	return nil
+}
+
+func (sj *KubeServingJob) addJob(obj interface{}) {
+	jobSyncInfo, err := kuberuntime.JobAddFunc(obj, sj.JobStatus)
+	if err != nil {
+		return
+	}
+	sj.jobQueue.Add(jobSyncInfo)
+}
+
+func (sj *KubeServingJob) updateJob(old, new interface{}) {
+	jobSyncInfo, err := kuberuntime.JobUpdateFunc(old, new, sj.JobStatus)
+	if err != nil {
+		return
+	}
+	sj.jobQueue.Add(jobSyncInfo)
+}
+
+func (sj *KubeServingJob) deleteJob(obj interface{}) {
+	jobSyncInfo, err := kuberuntime.JobDeleteFunc(obj, sj.JobStatus)
+	if err != nil {
+		return
+	}
+	sj.jobQueue.Add(jobSyncInfo)
+}
+
+// JobStatus get serving job status, message from interface{}, and covert to JobStatus
+func (sj *KubeServingJob) JobStatus(obj interface{}) (api.StatusInfo, error) {
+	unObj := obj.(*unstructured.Unstructured)
+	// convert to Deployment struct
+	job := &appsv1.Deployment{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(unObj.Object, job); err != nil {
+		log.Errorf("convert unstructured object [%+v] to %s deployment failed. error: %s", obj, sj.GVK.String(), err.Error())
+		return api.StatusInfo{}, err
+	}
+	state, msg := getJobStatus(&job.Status)
+	log.Infof("Serving job status: %s", state)
+	return api.StatusInfo{
+		OriginStatus: fmt.Sprintf("%d/%d", job.Status.AvailableReplicas, job.Status.Replicas),
+		Status:       state,
+		Message:      msg,
+	}, nil
+}
+
+func getJobStatus(jobStatus *appsv1.DeploymentStatus) (pfschema.JobStatus, string) {
+	for _, cond := range jobStatus.Conditions {
+		if cond.Type == appsv1.DeploymentProgressing && cond.Status == v1.ConditionFalse {
+			return pfschema.StatusJobFailed, fmt.Sprintf("serving is failed, reason: %s, message: %s", cond.Reason, cond.Message)
+		}
+	}
+	if jobStatus.AvailableReplicas > 0 {
+		return pfschema.StatusJobRunning, fmt.Sprintf("serving is available, %d/%d replicas are ready",
+			jobStatus.AvailableReplicas, jobStatus.Replicas)
+	}
+	return pfschema.StatusJobPending, fmt.Sprintf("serving is pending, %d/%d replicas are ready",
+		jobStatus.AvailableReplicas, jobStatus.Replicas)
+}
@@ -0,0 +1,218 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package serving
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2beta2"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/config"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/k8s"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/job/api"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/job/runtime_v2/client"
+)
+
+func mockServingJob(env map[string]string) *api.PFJob {
+	return &api.PFJob{
+		ID:        "job-serving-0001",
+		Namespace: "default",
+		JobType:   schema.TypeServing,
+		Framework: schema.FrameworkStandalone,
+		UserName:  "root",
+		QueueName: "mockQueueName",
+		Conf: schema.Conf{
+			Name: "serving",
+			Env:  env,
+		},
+		Tasks: []schema.Member{
+			{
+				Replicas: 1,
+				Role:     schema.RoleWorker,
+				Conf: schema.Conf{
+					Name:    "serving",
+					Command: "python -m paddle_serving_server.serve --port 8080",
+					Image:   "paddlepaddle/serving:latest",
+					Port:    8080,
+					Flavour: schema.Flavour{Name: "mockFlavourName", ResourceInfo: schema.ResourceInfo{CPU: "1", Mem: "1Gi"}},
+				},
+			},
+		},
+	}
+}
+
+func TestServingJob_Submit(t *testing.T) {
+	config.GlobalServerConfig = &config.ServerConfig{}
+	config.GlobalServerConfig.Job.SchedulerName = "testSchedulerName"
+	defaultJobYamlPath := "../../../../../config/server/default/job/job_template.yaml"
+	config.InitJobTemplate(defaultJobYamlPath)
+
+	var server = httptest.NewServer(k8s.DiscoveryHandlerFunc)
+	defer server.Close()
+	kubeRuntimeClient := client.NewFakeKubeRuntimeClient(server)
+
+	servingJob := New(kubeRuntimeClient)
+	// job without member
+	err := servingJob.Submit(context.TODO(), &api.PFJob{JobType: schema.TypeServing})
+	assert.Error(t, err)
+
+	pfJob := mockServingJob(map[string]string{
+		schema.EnvServingMinReplicas: "2",
+		schema.EnvServingMaxReplicas: "4",
+		schema.EnvServingMetric:      schema.ServingMetricGPU,
+		schema.EnvServingTargetValue: "60",
+		schema.EnvServingHealthPath:  "/health",
+	})
+	err = servingJob.Submit(context.TODO(), pfJob)
+	assert.NoError(t, err)
+
+	// check deployment
+	obj, err := kubeRuntimeClient.Get(pfJob.Namespace, pfJob.ID, KubeServingFwVersion)
+	assert.NoError(t, err)
+	deployment := &appsv1.Deployment{}
+	err = runtime.DefaultUnstructuredConverter.FromUnstructured(obj.(*unstructured.Unstructured).Object, deployment)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), *deployment.Spec.Replicas)
+	assert.Equal(t, pfJob.ID, deployment.Spec.Selector.MatchLabels[schema.JobIDLabel])
+	assert.Equal(t, pfJob.ID, deployment.Spec.Template.Labels[schema.JobIDLabel])
+	assert.Equal(t, v1.RestartPolicyAlways, deployment.Spec.Template.Spec.RestartPolicy)
+	container := deployment.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, int32(8080), container.Ports[0].ContainerPort)
+	assert.Equal(t, "/health", container.ReadinessProbe.HTTPGet.Path)
+
+	// check service
+	_, err = kubeRuntimeClient.Get(pfJob.Namespace, pfJob.ID, ServiceFwVersion)
+	assert.NoError(t, err)
+
+	// check hpa
+	obj, err = kubeRuntimeClient.Get(pfJob.Namespace, pfJob.ID, HPAFwVersion)
+	assert.NoError(t, err)
+	hpa := &autoscalingv2.HorizontalPodAutoscaler{}
+	err = runtime.DefaultUnstructuredConverter.FromUnstructured(obj.(*unstructured.Unstructured).Object, hpa)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), *hpa.Spec.MinReplicas)
+	assert.Equal(t, int32(4), hpa.Spec.MaxReplicas)
+	assert.Equal(t, GPUMetricName, hpa.Spec.Metrics[0].Pods.Metric.Name)
+	assert.Equal(t, int64(60), hpa.Spec.Metrics[0].Pods.Target.AverageValue.Value())
+
+	// stop serving job
+	err = servingJob.Stop(context.TODO(), pfJob)
+	assert.NoError(t, err)
+	_, err = kubeRuntimeClient.Get(pfJob.Namespace, pfJob.ID, KubeServingFwVersion)
+	assert.Error(t, err)
+	_, err = kubeRuntimeClient.Get(pfJob.Namespace, pfJob.ID, HPAFwVersion)
+	assert.Error(t, err)
+}
+
+func TestServingJob_SubmitWithoutAutoScaling(t *testing.T) {
+	config.GlobalServerConfig = &config.ServerConfig{}
+	defaultJobYamlPath := "../../../../../config/server/default/job/job_template.yaml"
+	config.InitJobTemplate(defaultJobYamlPath)
+
+	var server = httptest.NewServer(k8s.DiscoveryHandlerFunc)
+	defer server.Close()
+	kubeRuntimeClient := client.NewFakeKubeRuntimeClient(server)
+
+	servingJob := New(kubeRuntimeClient)
+	pfJob := mockServingJob(map[string]string{})
+	err := servingJob.Submit(context.TODO(), pfJob)
+	assert.NoError(t, err)
+	_, err = kubeRuntimeClient.Get(pfJob.Namespace, pfJob.ID, HPAFwVersion)
+	assert.Error(t, err)
+	// delete serving job without hpa
+	err = servingJob.Delete(context.TODO(), pfJob)
+	assert.NoError(t, err)
+}
+
+func TestServingJob_SubmitRollback(t *testing.T) {
+	config.GlobalServerConfig = &config.ServerConfig{}
+	defaultJobYamlPath := "../../../../../config/server/default/job/job_template.yaml"
+	config.InitJobTemplate(defaultJobYamlPath)
+
+	var server = httptest.NewServer(k8s.DiscoveryHandlerFunc)
+	defer server.Close()
+	kubeRuntimeClient := client.NewFakeKubeRuntimeClient(server)
+
+	servingJob := New(kubeRuntimeClient)
+	pfJob := mockServingJob(map[string]string{})
+	policy, err := NewServingPolicy(&pfJob.Conf, pfJob.Tasks[0].Port)
+	assert.NoError(t, err)
+	// service already exists, create service failed
+	err = kubeRuntimeClient.Create(buildService(pfJob, policy), ServiceFwVersion)
+	assert.NoError(t, err)
+
+	err = servingJob.Submit(context.TODO(), pfJob)
+	assert.Error(t, err)
+	// deployment created by this submission is deleted, the existing service is kept
+	_, err = kubeRuntimeClient.Get(pfJob.Namespace, pfJob.ID, KubeServingFwVersion)
+	assert.Error(t, err)
+	_, err = kubeRuntimeClient.Get(pfJob.Namespace, pfJob.ID, ServiceFwVersion)
+	assert.NoError(t, err)
+}
+
+func TestServingJob_JobStatus(t *testing.T) {
+	testCases := []struct {
+		name       string
+		status     appsv1.DeploymentStatus
+		wantStatus schema.JobStatus
+	}{
+		{
+			name:       "pending",
+			status:     appsv1.DeploymentStatus{Replicas: 2},
+			wantStatus: schema.StatusJobPending,
+		},
+		{
+			name:       "running",
+			status:     appsv1.DeploymentStatus{Replicas: 2, AvailableReplicas: 1},
+			wantStatus: schema.StatusJobRunning,
+		},
+		{
+			name: "failed",
+			status: appsv1.DeploymentStatus{
+				Replicas: 2,
+				Conditions: []appsv1.DeploymentCondition{
+					{
+						Type:   appsv1.DeploymentProgressing,
+						Status: v1.ConditionFalse,
+						Reason: "ProgressDeadlineExceeded",
+					},
+				},
+			},
+			wantStatus: schema.StatusJobFailed,
+		},
+	}
+
+	servingJob := &KubeServingJob{GVK: JobGVK}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			deployment := &appsv1.Deployment{Status: tc.status}
+			obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(deployment)
+			assert.NoError(t, err)
+			statusInfo, err := servingJob.JobStatus(&unstructured.Unstructured{Object: obj})
+			assert.NoError(t, err)
+			assert.Equal(t, tc.wantStatus, statusInfo.Status)
+		})
+	}
+}
@@ -75,6 +75,7 @@ func (pj *KubeTFJob) Submit(ctx context.Context, job *api.PFJob) error {
 	}
 
 	var err error
+	var replicas []kuberuntime.DistributedReplica
 	// set metadata field
 	kuberuntime.BuildJobMetadata(&tfjob.ObjectMeta, job)
 	// set spec field
@@ -83,7 +84,7 @@ func (pj *KubeTFJob) Submit(ctx context.Context, job *api.PFJob) error {
 		err = pj.customTFJobSpec(&tfjob.Spec, job)
 	} else {
 		// set builtin TFJob Spec
-		err = pj.builtinTFJobSpec(&tfjob.Spec, job)
+		replicas, err = pj.builtinTFJobSpec(&tfjob.Spec, job)
 	}
 	if err != nil {
 		log.Errorf("build %s spec failed, err %v", pj.String(jobName), err)
@@ -95,19 +96,24 @@ func (pj *KubeTFJob) Submit(ctx context.Context, job *api.PFJob) error {
 		log.Errorf("create %s failed, err %v", pj.String(jobName), err)
 		return err
 	}
+	if err = kuberuntime.CreateDiscoveryServices(pj.runtimeClient, job, replicas, pj.frameworkVersion); err != nil {
+		log.Errorf("create discovery services for %s failed, err %v", pj.String(jobName), err)
+		return err
+	}
 	return nil
 }
 
-// builtinTFJobSpec set build-in TFJob spec
-func (pj *KubeTFJob) builtinTFJobSpec(tfJobSpec *tfv1.TFJobSpec, job *api.PFJob) error {
+// builtinTFJobSpec set build-in TFJob spec, and returns replicas which need discovery services
+func (pj *KubeTFJob) builtinTFJobSpec(tfJobSpec *tfv1.TFJobSpec, job *api.PFJob) ([]kuberuntime.DistributedReplica, error) {
 	if job == nil {
-		return fmt.Errorf("job is nil")
+		return nil, fmt.Errorf("job is nil")
 	}
 	jobName := job.NamespacedName()
 	log.Debugf("patch %s spec:%#v", pj.String(jobName), tfJobSpec)
 	// TODO: set ElasticPolicy for TFJob
 	// set TFReplicaSpecs
 	minResources := resources.EmptyResource()
+	var replicas []kuberuntime.DistributedReplica
 	for _, task := range job.Tasks {
 		// tf parameter server for distributed training
 		replicaType := tfv1.TFReplicaTypePS
@@ -118,24 +124,37 @@ func (pj *KubeTFJob) builtinTFJobSpec(tfJobSpec *tfv1.TFJobSpec, job *api.PFJob)
 		}
 		replicaSpec, ok := tfJobSpec.TFReplicaSpecs[replicaType]
 		if !ok {
-			return fmt.Errorf("replica type %s for %s is not supported", replicaType, pj.String(jobName))
+			return nil, fmt.Errorf("replica type %s for %s is not supported", replicaType, pj.String(jobName))
 		}
 		if err := kuberuntime.KubeflowReplicaSpec(replicaSpec, job.ID, &task); err != nil {
 			log.Errorf("build %s RepilcaSpec for %s failed, err: %v", replicaType, pj.String(jobName), err)
-			return err
+			return nil, err
 		}
+		replicas = append(replicas, kuberuntime.DistributedReplica{
+			Role:        task.Role,
+			ReplicaType: string(replicaType),
+			Replicas:    task.Replicas,
+			Port:        task.Port,
+			Template:    &replicaSpec.Template,
+			// kubeflow operator labels pods with replica index
+			FirstPodLabels: map[string]string{kubeflowv1.ReplicaIndexLabel: "0"},
+		})
 		// calculate job minResources
 		taskResources, err := resources.NewResourceFromMap(task.Flavour.ToMap())
 		if err != nil {
 			log.Errorf("parse resources for %s task failed, err: %v", pj.String(jobName), err)
-			return err
+			return nil, err
 		}
 		taskResources.Multi(task.Replicas)
 		minResources.Add(taskResources)
 	}
+	// inject discovery env for distributed training
+	kuberuntime.SortDistributedReplicas(replicas)
+	kuberuntime.BuildDistributedEnv(job, replicas)
 	// set RunPolicy
 	resourceList := k8s.NewResourceList(minResources)
-	return kuberuntime.KubeflowRunPolicy(&tfJobSpec.RunPolicy, &resourceList, job.Conf.GetQueueName(), job.Conf.GetPriority())
+	err := kuberuntime.KubeflowRunPolicy(&tfJobSpec.RunPolicy, &resourceList, job.Conf.GetQueueName(), job.Conf.GetPriority())
+	return replicas, err
 }
 
 // customTFJobSpec set custom TFJob Spec
@@ -0,0 +1,233 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kuberuntime
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/k8s"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/job/api"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/job/runtime_v2/client"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/job/runtime_v2/framework"
+)
+
+const (
+	// DefaultMasterPort 未指定端口时PF_MASTER_PORT的默认值
+	DefaultMasterPort = 29500
+	masterPortName    = "pf-master"
+)
+
+var ServiceFwVersion = client.KubeFrameworkVersion(k8s.ServiceGVK)
+
+// DistributedReplica 分布式作业中一种角色的pod模板。operator按 {jobID}-{replicaType}-{index} 命名pod，
+// 节点编号按replicas的顺序依次分配，FirstPodLabels为operator给该角色0号pod设置的标签，用于选择master节点
+type DistributedReplica struct {
+	Role           schema.MemberRole
+	ReplicaType    string
+	Replicas       int
+	Port           int
+	Template       *corev1.PodTemplateSpec
+	FirstPodLabels map[string]string
+}
+
+// SortDistributedReplicas 按master、parameter server、worker的顺序排列，节点编号与PF_MASTER_ADDR据此确定
+func SortDistributedReplicas(replicas []DistributedReplica) {
+	isWorker := func(role schema.MemberRole) bool {
+		return role == schema.RoleWorker || role == schema.RolePWorker
+	}
+	sort.SliceStable(replicas, func(i, j int) bool {
+		return !isWorker(replicas[i].Role) && isWorker(replicas[j].Role)
+	})
+}
+
+// DiscoveryServiceName 角色对应的headless service名称
+func DiscoveryServiceName(jobID, replicaType string) string {
+	return fmt.Sprintf("%s-%s", jobID, strings.ToLower(replicaType))
+}
+
+// MasterServiceName 指向master节点的headless service名称
+func MasterServiceName(jobID string) string {
+	return fmt.Sprintf("%s-%s", jobID, masterPortName)
+}
+
+func discoveryServiceDNS(job *api.PFJob, replicaType string) string {
+	return fmt.Sprintf("%s.%s.svc", DiscoveryServiceName(job.ID, replicaType), job.Namespace)
+}
+
+// BuildDistributedEnv 为各角色的pod注入节点发现的环境变量，用户设置的同名环境变量优先
+func BuildDistributedEnv(job *api.PFJob, replicas []DistributedReplica) {
+	if job == nil || len(replicas) == 0 {
+		return
+	}
+	nodes := 0
+	for _, replica := range replicas {
+		nodes += replica.Replicas
+	}
+	master := replicas[0]
+	masterPort := master.Port
+	if masterPort <= 0 {
+		masterPort = DefaultMasterPort
+	}
+	commonEnvs := map[string]string{
+		schema.EnvNodes:      strconv.Itoa(nodes),
+		schema.EnvMasterAddr: fmt.Sprintf("%s.%s.svc", MasterServiceName(job.ID), job.Namespace),
+		schema.EnvMasterPort: strconv.Itoa(masterPort),
+	}
+	for _, replica := range replicas {
+		envName := fmt.Sprintf(schema.EnvServiceFormat, strings.ToUpper(replica.ReplicaType))
+		commonEnvs[envName] = discoveryServiceDNS(job, replica.ReplicaType)
+	}
+
+	offset := 0
+	for _, replica := range replicas {
+		if replica.Template == nil {
+			offset += replica.Replicas
+			continue
+		}
+		if replica.Template.Labels == nil {
+			replica.Template.Labels = make(map[string]string)
+		}
+		replica.Template.Labels[schema.JobReplicaLabel] = strings.ToLower(replica.ReplicaType)
+		podSpec := &replica.Template.Spec
+		envs := map[string]string{
+			schema.EnvRole:           string(replica.Role),
+			schema.EnvRoleReplicas:   strconv.Itoa(replica.Replicas),
+			schema.EnvNodeRankOffset: strconv.Itoa(offset),
+		}
+		for key, value := range commonEnvs {
+			envs[key] = value
+		}
+		for i := range podSpec.Containers {
+			container := &podSpec.Containers[i]
+			container.Env = BuildEnvVars(container.Env, envs)
+			container.Env = appendEnvIfAbsent(container.Env, []corev1.EnvVar{{
+				Name:      schema.EnvPodName,
+				ValueFrom: &corev1.EnvVarSource{FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.name"}},
+			}})
+		}
+		if len(podSpec.Containers) > 0 {
+			patchRankCommand(&podSpec.Containers[0])
+		}
+		offset += replica.Replicas
+	}
+}
+
+// patchRankCommand 容器启动时由pod名称末尾的序号计算PF_REPLICA_INDEX和PF_NODE_RANK
+func patchRankCommand(container *corev1.Container) {
+	command := container.Command
+	if len(command) != 3 || command[0] != "sh" || command[1] != "-c" {
+		log.Warnf("command of container %s is not started by sh -c, skip setting %s", container.Name, schema.EnvNodeRank)
+		return
+	}
+	prefix := fmt.Sprintf("export %s=${%s##*-}; export %s=$((%s + %s)); ", schema.EnvReplicaIndex, schema.EnvPodName,
+		schema.EnvNodeRank, schema.EnvNodeRankOffset, schema.EnvReplicaIndex)
+	if strings.HasPrefix(command[2], prefix) {
+		return
+	}
+	container.Command[2] = prefix + command[2]
+}
+
+// CreateDiscoveryServices 为master节点和各角色创建headless service，service的owner为作业对象，随作业一同删除
+func CreateDiscoveryServices(runtimeClient framework.RuntimeClientInterface, job *api.PFJob,
+	replicas []DistributedReplica, jobFwVersion schema.FrameworkVersion) error {
+	if len(replicas) == 0 {
+		return nil
+	}
+	obj, err := runtimeClient.Get(job.Namespace, job.ID, jobFwVersion)
+	if err != nil {
+		return fmt.Errorf("get job %s/%s failed, err: %v", job.Namespace, job.ID, err)
+	}
+	owner, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return fmt.Errorf("job %s/%s is not unstructured object", job.Namespace, job.ID)
+	}
+	ownerRef := metav1.OwnerReference{
+		APIVersion: owner.GetAPIVersion(),
+		Kind:       owner.GetKind(),
+		Name:       owner.GetName(),
+		UID:        owner.GetUID(),
+	}
+	masterPort := replicas[0].Port
+	if masterPort <= 0 {
+		masterPort = DefaultMasterPort
+	}
+	services := []*corev1.Service{buildMasterService(job, replicas[0], masterPort, ownerRef)}
+	for _, replica := range replicas {
+		services = append(services, buildDiscoveryService(job, replica, masterPort, ownerRef))
+	}
+	for _, svc := range services {
+		err = runtimeClient.Create(svc, ServiceFwVersion)
+		if k8serrors.IsAlreadyExists(err) {
+			log.Infof("discovery service %s/%s already exists", svc.Namespace, svc.Name)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("create discovery service %s/%s failed, err: %v", svc.Namespace, svc.Name, err)
+		}
+	}
+	return nil
+}
+
+func buildMasterService(job *api.PFJob, master DistributedReplica, masterPort int, ownerRef metav1.OwnerReference) *corev1.Service {
+	svc := buildDiscoveryService(job, master, masterPort, ownerRef)
+	svc.Name = MasterServiceName(job.ID)
+	for key, value := range master.FirstPodLabels {
+		svc.Spec.Selector[key] = value
+	}
+	return svc
+}
+
+func buildDiscoveryService(job *api.PFJob, replica DistributedReplica, masterPort int, ownerRef metav1.OwnerReference) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      DiscoveryServiceName(job.ID, replica.ReplicaType),
+			Namespace: job.Namespace,
+			Labels: map[string]string{
+				schema.JobOwnerLabel: schema.JobOwnerValue,
+				schema.JobIDLabel:    job.ID,
+			},
+			OwnerReferences: []metav1.OwnerReference{ownerRef},
+		},
+		Spec: corev1.ServiceSpec{
+			ClusterIP: corev1.ClusterIPNone,
+			Selector: map[string]string{
+				schema.JobIDLabel:      job.ID,
+				schema.JobReplicaLabel: strings.ToLower(replica.ReplicaType),
+			},
+			// 节点在就绪前就需要相互发现
+			PublishNotReadyAddresses: true,
+			Ports: []corev1.ServicePort{
+				{
+					Name:       masterPortName,
+					Port:       int32(masterPort),
+					TargetPort: intstr.FromInt(masterPort),
+				},
+			},
+		},
+	}
+}
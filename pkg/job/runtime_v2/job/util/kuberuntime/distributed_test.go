@@ -0,0 +1,125 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kuberuntime
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/k8s"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/job/api"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/job/runtime_v2/client"
+)
+
+func envValue(envs []corev1.EnvVar, name string) string {
+	for _, env := range envs {
+		if env.Name == name {
+			return env.Value
+		}
+	}
+	return ""
+}
+
+func newReplicaTemplate(command string, env map[string]string) *corev1.PodTemplateSpec {
+	return &corev1.PodTemplateSpec{
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{Command: []string{"sh", "-c", command}, Env: generateEnvVars(env)},
+			},
+		},
+	}
+}
+
+func TestBuildDistributedEnv(t *testing.T) {
+	job := &api.PFJob{ID: "job-dist", Namespace: "default"}
+	replicas := []DistributedReplica{
+		{Role: schema.RoleWorker, ReplicaType: "Worker", Replicas: 3,
+			Template: newReplicaTemplate("python train.py", map[string]string{schema.EnvMasterPort: "6000"})},
+		{Role: schema.RoleMaster, ReplicaType: "Master", Replicas: 1, Port: 23456,
+			Template: newReplicaTemplate("python train.py", nil)},
+	}
+	SortDistributedReplicas(replicas)
+	assert.Equal(t, schema.RoleMaster, replicas[0].Role)
+
+	BuildDistributedEnv(job, replicas)
+	master, worker := replicas[0].Template, replicas[1].Template
+	assert.Equal(t, "master", master.Labels[schema.JobReplicaLabel])
+	masterEnvs := master.Spec.Containers[0].Env
+	assert.Equal(t, "4", envValue(masterEnvs, schema.EnvNodes))
+	assert.Equal(t, "0", envValue(masterEnvs, schema.EnvNodeRankOffset))
+	assert.Equal(t, "job-dist-pf-master.default.svc", envValue(masterEnvs, schema.EnvMasterAddr))
+	assert.Equal(t, "23456", envValue(masterEnvs, schema.EnvMasterPort))
+	assert.Equal(t, "job-dist-worker.default.svc", envValue(masterEnvs, "PF_WORKER_SERVICE"))
+
+	workerEnvs := worker.Spec.Containers[0].Env
+	assert.Equal(t, "1", envValue(workerEnvs, schema.EnvNodeRankOffset))
+	assert.Equal(t, "3", envValue(workerEnvs, schema.EnvRoleReplicas))
+	assert.Equal(t, string(schema.RoleWorker), envValue(workerEnvs, schema.EnvRole))
+	// env set by user is kept
+	assert.Equal(t, "6000", envValue(workerEnvs, schema.EnvMasterPort))
+	command := worker.Spec.Containers[0].Command[2]
+	assert.True(t, strings.HasPrefix(command, "export PF_REPLICA_INDEX=${PF_POD_NAME##*-};"))
+	assert.True(t, strings.HasSuffix(command, "python train.py"))
+
+	// command is patched only once
+	BuildDistributedEnv(job, replicas)
+	assert.Equal(t, command, worker.Spec.Containers[0].Command[2])
+}
+
+func TestCreateDiscoveryServices(t *testing.T) {
+	var server = httptest.NewServer(k8s.DiscoveryHandlerFunc)
+	defer server.Close()
+	runtimeClient := client.NewFakeKubeRuntimeClient(server)
+
+	job := &api.PFJob{ID: "job-dist", Namespace: "default"}
+	jobFwVersion := client.KubeFrameworkVersion(k8s.PyTorchJobGVK)
+	owner := &unstructured.Unstructured{}
+	owner.SetName(job.ID)
+	owner.SetNamespace(job.Namespace)
+	owner.SetUID("uid-1")
+	assert.NoError(t, runtimeClient.Create(owner, jobFwVersion))
+
+	replicas := []DistributedReplica{
+		{Role: schema.RoleMaster, ReplicaType: "Master", Replicas: 1,
+			FirstPodLabels: map[string]string{"training.kubeflow.org/replica-index": "0"}},
+		{Role: schema.RoleWorker, ReplicaType: "Worker", Replicas: 2},
+	}
+	assert.NoError(t, CreateDiscoveryServices(runtimeClient, job, replicas, jobFwVersion))
+	// services already exist
+	assert.NoError(t, CreateDiscoveryServices(runtimeClient, job, replicas, jobFwVersion))
+
+	obj, err := runtimeClient.Get(job.Namespace, MasterServiceName(job.ID), ServiceFwVersion)
+	assert.NoError(t, err)
+	svc := obj.(*unstructured.Unstructured)
+	assert.Equal(t, "uid-1", string(svc.GetOwnerReferences()[0].UID))
+	clusterIP, _, _ := unstructured.NestedString(svc.Object, "spec", "clusterIP")
+	assert.Equal(t, corev1.ClusterIPNone, clusterIP)
+	selector, _, _ := unstructured.NestedStringMap(svc.Object, "spec", "selector")
+	assert.Equal(t, "0", selector["training.kubeflow.org/replica-index"])
+	assert.Equal(t, "master", selector[schema.JobReplicaLabel])
+
+	obj, err = runtimeClient.Get(job.Namespace, DiscoveryServiceName(job.ID, "Worker"), ServiceFwVersion)
+	assert.NoError(t, err)
+	selector, _, _ = unstructured.NestedStringMap(obj.(*unstructured.Unstructured).Object, "spec", "selector")
+	assert.Equal(t, "worker", selector[schema.JobReplicaLabel])
+}
@@ -61,11 +61,11 @@ func GetParentJobID(obj *unstructured.Unstructured) string {
 
 func JobAddFunc(obj interface{}, getStatusFunc api.GetStatusFunc) (*api.JobSyncInfo, error) {
 	jobObj := obj.(*unstructured.Unstructured)
-	gvk := jobObj.GroupVersionKind()
+	gvk := k8s.CanonicalGVK(jobObj.GroupVersionKind())
 
 	log.Infof("begin add %s job. jobName: %s, namespace: %s", gvk.String(), jobObj.GetName(), jobObj.GetNamespace())
 	// get job status
-	statusInfo, err := getStatusFunc(obj)
+	statusInfo, err := WithStatusMapping(getStatusFunc)(obj)
 	if err != nil {
 		return nil, err
 	}
@@ -102,13 +102,14 @@ func JobUpdateFunc(old, new interface{}, getStatusFunc api.GetStatusFunc) (*api.
 	oldObj := old.(*unstructured.Unstructured)
 	newObj := new.(*unstructured.Unstructured)
 	// get job id
-	gvk := newObj.GroupVersionKind()
+	gvk := k8s.CanonicalGVK(newObj.GroupVersionKind())
 	labels := newObj.GetLabels()
 	jobID := labels[schema.JobIDLabel]
 	log.Infof("update %s job, jobName: %s, namespace: %s, jobID: %s",
 		gvk.String(), newObj.GetName(), newObj.GetNamespace(), jobID)
 
 	// get job status
+	getStatusFunc = WithStatusMapping(getStatusFunc)
 	oldStatusInfo, err := getStatusFunc(old)
 	if err != nil {
 		return nil, err
@@ -150,12 +151,12 @@ func JobUpdateFunc(old, new interface{}, getStatusFunc api.GetStatusFunc) (*api.
 func JobDeleteFunc(obj interface{}, getStatusFunc api.GetStatusFunc) (*api.JobSyncInfo, error) {
 	jobObj := obj.(*unstructured.Unstructured)
 	// get job id and GroupVersionKind
-	gvk := jobObj.GroupVersionKind()
+	gvk := k8s.CanonicalGVK(jobObj.GroupVersionKind())
 	labels := jobObj.GetLabels()
 	jobID := labels[schema.JobIDLabel]
 	log.Infof("delete %s job. jobName: %s, namespace: %s, jobID: %s", gvk.String(), jobObj.GetName(), jobObj.GetNamespace(), jobID)
 	// get job status
-	statusInfo, err := getStatusFunc(obj)
+	statusInfo, err := WithStatusMapping(getStatusFunc)(obj)
 	if err != nil {
 		log.Errorf("get job status failed, and jobID: %s, error: %s", jobID, err)
 		return nil, err
@@ -311,15 +312,16 @@ func TaskUpdateFunc(obj interface{}, action schema.ActionType, taskQueue workque
 	message := k8s.GetTaskMessage(&pod.Status)
 
 	taskInfo := &api.TaskSyncInfo{
-		ID:        string(uid),
-		Name:      name,
-		Namespace: namespace,
-		JobID:     jobName,
-		NodeName:  pod.Spec.NodeName,
-		Status:    taskStatus,
-		Message:   message,
-		PodStatus: pod.Status,
-		Action:    action,
+		ID:                string(uid),
+		Name:              name,
+		Namespace:         namespace,
+		JobID:             jobName,
+		NodeName:          pod.Spec.NodeName,
+		Status:            taskStatus,
+		Message:           message,
+		PodStatus:         pod.Status,
+		Action:            action,
+		NodeFailureReason: k8s.GetNodeFailureReason(&pod.Status),
 	}
 	taskQueue.Add(taskInfo)
 	log.Infof("%s event for task %s/%s enqueue, job: %s", action, namespace, name, jobName)
@@ -26,6 +26,7 @@ import (
 	kubeflowv1 "github.com/kubeflow/common/pkg/apis/common/v1"
 	log "github.com/sirupsen/logrus"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -66,14 +67,14 @@ func getDefaultTemplate(framework schema.Framework, jobType schema.JobType, jobM
 	jobTemplateName := ""
 
 	//the footer comment of all type job as the follow:
-	//  single -> single-job, workflow -> workflow-job,
+	//  single -> single-job, workflow -> workflow-job, serving -> serving-job
 	//  spark -> spark-job, ray -> ray-job
 	//  paddle with ps mode -> paddle-ps-job
 	//  paddle with collective mode -> paddle-collective-job
 	//  tensorflow with ps mode -> tensorflow-ps-job
 	//  pytorch with ps mode -> pytorch-ps-job
 	switch jobType {
-	case schema.TypeSingle, schema.TypeWorkflow:
+	case schema.TypeSingle, schema.TypeWorkflow, schema.TypeServing:
 		jobTemplateName = fmt.Sprintf("%s-job", jobType)
 	case schema.TypeDistributed:
 		if framework == schema.FrameworkSpark || framework == schema.FrameworkRay {
@@ -122,7 +123,8 @@ func CreateKubeJobFromYaml(jobEntity interface{}, groupVersionKind kubeschema.Gr
 	}
 	parsedGVK := unstructuredObj.GroupVersionKind()
 	log.Debugf("unstructuredObj=%v, GroupVersionKind=[%v]", unstructuredObj, parsedGVK)
-	if parsedGVK.String() != groupVersionKind.String() {
+	// template with compatible version is allowed, it is rendered by the version served by cluster
+	if k8s.CanonicalGVK(parsedGVK).String() != groupVersionKind.String() {
 		err := fmt.Errorf("expect GroupVersionKind is %s, but got %s", groupVersionKind.String(), parsedGVK.String())
 		log.Errorf("Decode from yamlFile[%s] failed! err:[%v]\n", string(job.ExtensionTemplate), err)
 		return err
@@ -215,6 +217,10 @@ func BuildPodSpec(podSpec *corev1.PodSpec, task schema.Member) error {
 	// fill volumes
 	fileSystems := task.Conf.GetAllFileSystem()
 	podSpec.Volumes = BuildVolumes(podSpec.Volumes, fileSystems)
+	podSpec.Volumes = BuildEphemeralVolumes(podSpec.Volumes, task.Conf.GetEphemeralVolumes())
+	podSpec.Volumes = BuildSharedVolume(podSpec.Volumes, task.Conf.GetSharedVolume())
+	// fill code package
+	BuildCodePackage(podSpec, task.Conf.GetCodePackage())
 	// fill affinity
 	if len(fileSystems) != 0 {
 		var fsIDs []string
@@ -226,6 +232,8 @@ func BuildPodSpec(podSpec *corev1.PodSpec, task schema.Member) error {
 			return err
 		}
 	}
+	// fill node selector of node pool
+	buildNodePoolSelector(podSpec, task.Conf.GetNodePool())
 	// fill restartPolicy
 	patchRestartPolicy(podSpec, task)
 	// build containers
@@ -247,6 +255,17 @@ func buildPriorityAndScheduler(podSpec *corev1.PodSpec, priorityName string) err
 	return nil
 }
 
+// buildNodePoolSelector 队列绑定节点池时，作业只调度到节点池的节点上
+func buildNodePoolSelector(podSpec *corev1.PodSpec, nodePool string) {
+	if nodePool == "" {
+		return
+	}
+	if podSpec.NodeSelector == nil {
+		podSpec.NodeSelector = make(map[string]string)
+	}
+	podSpec.NodeSelector[schema.NodePoolLabel] = nodePool
+}
+
 func BuildPod(pod *corev1.Pod, task schema.Member) error {
 	if pod == nil {
 		return fmt.Errorf("build pod failed, err: podSpec is nil")
@@ -260,6 +279,10 @@ func BuildPod(pod *corev1.Pod, task schema.Member) error {
 	// fill volumes
 	fileSystems := task.Conf.GetAllFileSystem()
 	pod.Spec.Volumes = BuildVolumes(pod.Spec.Volumes, fileSystems)
+	pod.Spec.Volumes = BuildEphemeralVolumes(pod.Spec.Volumes, task.Conf.GetEphemeralVolumes())
+	pod.Spec.Volumes = BuildSharedVolume(pod.Spec.Volumes, task.Conf.GetSharedVolume())
+	// fill code package
+	BuildCodePackage(&pod.Spec, task.Conf.GetCodePackage())
 	// fill fs affinity
 	if len(fileSystems) != 0 {
 		var fsIDs []string
@@ -271,6 +294,8 @@ func BuildPod(pod *corev1.Pod, task schema.Member) error {
 			return err
 		}
 	}
+	// fill node selector of node pool
+	buildNodePoolSelector(&pod.Spec, task.Conf.GetNodePool())
 	// fill restartPolicy
 	patchRestartPolicy(&pod.Spec, task)
 
@@ -389,6 +414,18 @@ func fillContainer(container *corev1.Container, podName string, task schema.Memb
 	container.Env = BuildEnvVars(container.Env, task.Env)
 	// fill volumeMount
 	container.VolumeMounts = BuildVolumeMounts(container.VolumeMounts, filesystems)
+	container.VolumeMounts = appendMountsIfAbsent(container.VolumeMounts,
+		generateEphemeralVolumeMounts(task.Conf.GetEphemeralVolumes()))
+	if task.Conf.GetSharedVolume() != nil {
+		container.VolumeMounts = appendMountsIfAbsent(container.VolumeMounts, []corev1.VolumeMount{
+			{Name: schema.RunSharedVolumeName, MountPath: schema.RunSharedVolumeMountPath},
+		})
+	}
+	if codePackage := task.Conf.GetCodePackage(); codePackage != nil {
+		container.VolumeMounts = appendMountsIfAbsent(container.VolumeMounts, []corev1.VolumeMount{
+			{Name: schema.CodePackageVolumeName, MountPath: codePackage.WorkDir},
+		})
+	}
 
 	log.Debugf("fillContainer completed: pod[%s]-container[%s]", podName, container.Name)
 	return nil
@@ -403,6 +440,12 @@ func getWorkDir(task *schema.Member, fileSystems []schema.FileSystem, envs map[s
 	if len(envs) == 0 {
 		envs = make(map[string]string)
 	}
+	// code package is unpacked to its workDir, which is used as workdir of job
+	if task != nil && task.Conf.GetCodePackage() != nil {
+		workdir := task.Conf.GetCodePackage().WorkDir
+		envs[schema.EnvJobWorkDir] = workdir
+		return workdir
+	}
 	// check workdir, which exist only if there is more than one file system and env.'EnvMountPath' is not NONE
 	hasWorkDir := len(fileSystems) != 0 && strings.ToUpper(envs[schema.EnvMountPath]) != "NONE"
 	if !hasWorkDir {
@@ -499,6 +542,114 @@ func BuildVolumeMounts(volumeMounts []corev1.VolumeMount, fileSystem []schema.Fi
 	return appendMountsIfAbsent(volumeMounts, generateVolumeMounts(fileSystem))
 }
 
+// BuildEphemeralVolumes convert job ephemeral volumes to kubernetes volumes, which are released with pods
+func BuildEphemeralVolumes(volumes []corev1.Volume, ephemeralVolumes []schema.EphemeralVolume) []corev1.Volume {
+	return appendVolumesIfAbsent(volumes, generateEphemeralVolumes(ephemeralVolumes))
+}
+
+// generateEphemeralVolumes use emptyDir by default, and generic ephemeral volume if storageClass is set
+func generateEphemeralVolumes(ephemeralVolumes []schema.EphemeralVolume) []corev1.Volume {
+	var vs []corev1.Volume
+	for _, ev := range ephemeralVolumes {
+		volume := corev1.Volume{
+			Name: ev.Name,
+		}
+		if ev.StorageClass != "" {
+			storageClass := ev.StorageClass
+			volume.VolumeSource = corev1.VolumeSource{
+				Ephemeral: &corev1.EphemeralVolumeSource{
+					VolumeClaimTemplate: &corev1.PersistentVolumeClaimTemplate{
+						Spec: corev1.PersistentVolumeClaimSpec{
+							AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+							StorageClassName: &storageClass,
+							Resources: corev1.ResourceRequirements{
+								Requests: corev1.ResourceList{
+									corev1.ResourceStorage: resource.MustParse(ev.Size),
+								},
+							},
+						},
+					},
+				},
+			}
+		} else {
+			emptyDir := &corev1.EmptyDirVolumeSource{
+				Medium: corev1.StorageMedium(ev.Medium),
+			}
+			if ev.Size != "" {
+				sizeLimit := resource.MustParse(ev.Size)
+				emptyDir.SizeLimit = &sizeLimit
+			}
+			volume.VolumeSource = corev1.VolumeSource{
+				EmptyDir: emptyDir,
+			}
+		}
+		vs = append(vs, volume)
+	}
+	return vs
+}
+
+func generateEphemeralVolumeMounts(ephemeralVolumes []schema.EphemeralVolume) []corev1.VolumeMount {
+	var vms []corev1.VolumeMount
+	for _, ev := range ephemeralVolumes {
+		vms = append(vms, corev1.VolumeMount{
+			Name:      ev.Name,
+			MountPath: ev.MountPath,
+		})
+	}
+	return vms
+}
+
+// BuildSharedVolume add the shared pvc of pipeline run, which is created before job submitted
+func BuildSharedVolume(volumes []corev1.Volume, sharedVolume *schema.RunSharedVolume) []corev1.Volume {
+	if sharedVolume == nil {
+		return volumes
+	}
+	return appendVolumesIfAbsent(volumes, []corev1.Volume{
+		{
+			Name: schema.RunSharedVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+					ClaimName: sharedVolume.ClaimName,
+				},
+			},
+		},
+	})
+}
+
+// BuildCodePackage add an emptyDir volume and an init container, which unpacks code package from file system into it
+func BuildCodePackage(podSpec *corev1.PodSpec, codePackage *schema.CodePackage) {
+	if podSpec == nil || codePackage == nil {
+		return
+	}
+	podSpec.Volumes = appendVolumesIfAbsent(podSpec.Volumes, []corev1.Volume{
+		{
+			Name:         schema.CodePackageVolumeName,
+			VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+		},
+	})
+	initContainerName := schema.CodePackageVolumeName + "-init"
+	for _, c := range podSpec.InitContainers {
+		if c.Name == initContainerName {
+			return
+		}
+	}
+	image := schema.DefaultCodePackageImage
+	if config.GlobalServerConfig != nil && config.GlobalServerConfig.Job.CodePackageImage != "" {
+		image = config.GlobalServerConfig.Job.CodePackageImage
+	}
+	srcPath := "/pf-code-src"
+	command := fmt.Sprintf("tar -xzf %s -C %s", filepath.Join(srcPath, codePackage.Path), codePackage.WorkDir)
+	podSpec.InitContainers = append(podSpec.InitContainers, corev1.Container{
+		Name:    initContainerName,
+		Image:   image,
+		Command: []string{"sh", "-c", command},
+		VolumeMounts: []corev1.VolumeMount{
+			{Name: codePackage.FsName, MountPath: srcPath, ReadOnly: true},
+			{Name: schema.CodePackageVolumeName, MountPath: codePackage.WorkDir},
+		},
+	})
+}
+
 // appendVolumesIfAbsent append newElements if not exist in volumes
 // if job with tasks, it should be like
 // `Volumes = appendVolumesIfAbsent(Volumes, generateVolumes(taskFs))`
@@ -537,6 +688,16 @@ func generateVolumes(fileSystem []schema.FileSystem) []corev1.Volume {
 		return vs
 	}
 
+	// 同一个存储的所有挂载均为只读时，pvc才以只读方式挂载，csi据此以只读方式挂载存储
+	readOnly := make(map[string]bool)
+	for _, fs := range fileSystem {
+		if ro, ok := readOnly[fs.Name]; ok {
+			readOnly[fs.Name] = ro && fs.ReadOnly
+		} else {
+			readOnly[fs.Name] = fs.ReadOnly
+		}
+	}
+
 	for _, fs := range fileSystem {
 		volume := corev1.Volume{
 			Name: fs.Name,
@@ -553,6 +714,7 @@ func generateVolumes(fileSystem []schema.FileSystem) []corev1.Volume {
 			volume.VolumeSource = corev1.VolumeSource{
 				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
 					ClaimName: schema.ConcatenatePVCName(fs.ID),
+					ReadOnly:  readOnly[fs.Name],
 				},
 			}
 		}
@@ -147,3 +147,114 @@ func TestBuildPodSpec(t *testing.T) {
 		})
 	}
 }
+
+func TestBuildNodePoolSelector(t *testing.T) {
+	podSpec := &corev1.PodSpec{}
+	buildNodePoolSelector(podSpec, "")
+	assert.Nil(t, podSpec.NodeSelector)
+
+	podSpec.NodeSelector = map[string]string{"kubernetes.io/os": "linux"}
+	buildNodePoolSelector(podSpec, "a100")
+	assert.Equal(t, map[string]string{"kubernetes.io/os": "linux", schema.NodePoolLabel: "a100"}, podSpec.NodeSelector)
+}
+
+func TestGenerateVolumesReadOnly(t *testing.T) {
+	fileSystems := []schema.FileSystem{
+		{ID: "fs-root-data", Name: "data", Type: "s3", MountPath: "/mnt/data", SubPath: "train", ReadOnly: true},
+		{ID: "fs-root-data", Name: "data", Type: "s3", MountPath: "/mnt/eval", SubPath: "eval", ReadOnly: true},
+		{ID: "fs-root-work", Name: "work", Type: "s3", MountPath: "/mnt/work", ReadOnly: true},
+		{ID: "fs-root-work", Name: "work", Type: "s3", MountPath: "/mnt/output"},
+	}
+	volumes := appendVolumesIfAbsent(nil, generateVolumes(fileSystems))
+	assert.Equal(t, 2, len(volumes))
+	assert.True(t, volumes[0].PersistentVolumeClaim.ReadOnly)
+	assert.False(t, volumes[1].PersistentVolumeClaim.ReadOnly)
+
+	volumeMounts := generateVolumeMounts(fileSystems)
+	assert.Equal(t, 4, len(volumeMounts))
+	assert.Equal(t, "train", volumeMounts[0].SubPath)
+	assert.True(t, volumeMounts[0].ReadOnly)
+	assert.False(t, volumeMounts[3].ReadOnly)
+}
+
+func TestGenerateEphemeralVolumes(t *testing.T) {
+	ephemeralVolumes := []schema.EphemeralVolume{
+		{Name: "shuffle", MountPath: "/mnt/shuffle", Size: "10Gi"},
+		{Name: "shm", MountPath: "/dev/shm", Medium: schema.EphemeralMediumMemory},
+		{Name: "scratch", MountPath: "/mnt/scratch", Size: "100Gi", StorageClass: "local-ssd"},
+	}
+	volumes := BuildEphemeralVolumes(nil, ephemeralVolumes)
+	assert.Equal(t, 3, len(volumes))
+	assert.Equal(t, "10Gi", volumes[0].EmptyDir.SizeLimit.String())
+	assert.Equal(t, corev1.StorageMediumMemory, volumes[1].EmptyDir.Medium)
+	assert.Nil(t, volumes[1].EmptyDir.SizeLimit)
+	claimSpec := volumes[2].Ephemeral.VolumeClaimTemplate.Spec
+	assert.Equal(t, "local-ssd", *claimSpec.StorageClassName)
+	storage := claimSpec.Resources.Requests[corev1.ResourceStorage]
+	assert.Equal(t, "100Gi", storage.String())
+
+	volumeMounts := generateEphemeralVolumeMounts(ephemeralVolumes)
+	assert.Equal(t, 3, len(volumeMounts))
+	assert.Equal(t, "/dev/shm", volumeMounts[1].MountPath)
+}
+
+func TestBuildSharedVolume(t *testing.T) {
+	task := schema.Member{
+		Conf: schema.Conf{
+			Command: "python train.py",
+			SharedVolume: &schema.RunSharedVolume{
+				RunID:     "run-000001",
+				ClaimName: schema.RunSharedVolumeClaimName("run-000001"),
+			},
+		},
+	}
+	volumes := BuildSharedVolume(nil, task.Conf.GetSharedVolume())
+	volumes = BuildSharedVolume(volumes, task.Conf.GetSharedVolume())
+	assert.Equal(t, 1, len(volumes))
+	assert.Equal(t, "pf-shared-run-000001", volumes[0].PersistentVolumeClaim.ClaimName)
+	assert.Equal(t, 0, len(BuildSharedVolume(nil, nil)))
+
+	container := &corev1.Container{}
+	err := fillContainer(container, "test", task)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(container.VolumeMounts))
+	assert.Equal(t, schema.RunSharedVolumeMountPath, container.VolumeMounts[0].MountPath)
+}
+
+func TestBuildCodePackage(t *testing.T) {
+	config.GlobalServerConfig = &config.ServerConfig{}
+	task := schema.Member{
+		Conf: schema.Conf{
+			Command: "python train.py",
+			FileSystem: schema.FileSystem{
+				ID:        "fs-root-data",
+				Name:      "data",
+				MountPath: "/home/work/data",
+			},
+			CodePackage: &schema.CodePackage{
+				FsName:  "data",
+				Path:    "/.paddleflow/code/abc.tar.gz",
+				WorkDir: "/home/paddleflow/code",
+			},
+		},
+	}
+	podSpec := &corev1.PodSpec{}
+	BuildCodePackage(podSpec, task.Conf.GetCodePackage())
+	// build twice should not duplicate volumes or init containers
+	BuildCodePackage(podSpec, task.Conf.GetCodePackage())
+	assert.Equal(t, 1, len(podSpec.Volumes))
+	assert.NotNil(t, podSpec.Volumes[0].EmptyDir)
+	assert.Equal(t, 1, len(podSpec.InitContainers))
+	initContainer := podSpec.InitContainers[0]
+	assert.Equal(t, schema.DefaultCodePackageImage, initContainer.Image)
+	assert.Equal(t, "tar -xzf /pf-code-src/.paddleflow/code/abc.tar.gz -C /home/paddleflow/code", initContainer.Command[2])
+	assert.Equal(t, "data", initContainer.VolumeMounts[0].Name)
+	assert.True(t, initContainer.VolumeMounts[0].ReadOnly)
+
+	container := &corev1.Container{}
+	err := fillContainer(container, "test", task)
+	assert.NoError(t, err)
+	assert.Equal(t, "cd /home/paddleflow/code; python train.py", container.Command[2])
+	assert.Equal(t, 2, len(container.VolumeMounts))
+	assert.Equal(t, schema.CodePackageVolumeName, container.VolumeMounts[1].Name)
+}
@@ -0,0 +1,118 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kuberuntime
+
+import (
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/config"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/job/api"
+)
+
+// mappableJobStatus are job status which can be mapped from phases of job objects
+var mappableJobStatus = map[schema.JobStatus]bool{
+	schema.StatusJobPending:    true,
+	schema.StatusJobRunning:    true,
+	schema.StatusJobSucceeded:  true,
+	schema.StatusJobFailed:     true,
+	schema.StatusJobTerminated: true,
+}
+
+// WithStatusMapping returns GetStatusFunc which maps job status by status mappings in server config at first,
+// and falls back to getStatusFunc when no mapping is matched
+func WithStatusMapping(getStatusFunc api.GetStatusFunc) api.GetStatusFunc {
+	return func(obj interface{}) (api.StatusInfo, error) {
+		if jobObj, ok := obj.(*unstructured.Unstructured); ok {
+			if statusInfo, found := MapJobStatus(jobObj); found {
+				return statusInfo, nil
+			}
+		}
+		if getStatusFunc == nil {
+			return api.StatusInfo{}, fmt.Errorf("no status mapping for job object")
+		}
+		return getStatusFunc(obj)
+	}
+}
+
+// MapJobStatus maps status of job object by status mappings in server config, and returns false if not matched
+func MapJobStatus(obj *unstructured.Unstructured) (api.StatusInfo, bool) {
+	if obj == nil || config.GlobalServerConfig == nil {
+		return api.StatusInfo{}, false
+	}
+	for _, mapping := range config.GlobalServerConfig.Job.StatusMappings {
+		if mapping.Kind != obj.GetKind() || mapping.APIVersion != obj.GetAPIVersion() {
+			continue
+		}
+		phase, msg, found := getMappingPhase(obj, mapping)
+		if !found {
+			continue
+		}
+		status := schema.JobStatus(mapping.Phases[phase])
+		if !mappableJobStatus[status] {
+			log.Warnf("phase %s of %s %s is mapped to invalid job status %s, skip it", phase,
+				obj.GetAPIVersion(), obj.GetKind(), status)
+			continue
+		}
+		if mapping.MessagePath != "" {
+			msg, _, _ = unstructured.NestedString(obj.Object, splitPath(mapping.MessagePath)...)
+		}
+		return api.StatusInfo{
+			OriginStatus: phase,
+			Status:       status,
+			Message:      msg,
+		}, true
+	}
+	return api.StatusInfo{}, false
+}
+
+// getMappingPhase returns phase of job object which is defined in mapping, and message of matched condition
+func getMappingPhase(obj *unstructured.Unstructured, mapping config.JobStatusMapping) (string, string, bool) {
+	if mapping.PhasePath != "" {
+		phase, found, _ := unstructured.NestedString(obj.Object, splitPath(mapping.PhasePath)...)
+		if _, ok := mapping.Phases[phase]; found && ok {
+			return phase, "", true
+		}
+	}
+	if mapping.ConditionsPath != "" {
+		conditions, _, _ := unstructured.NestedSlice(obj.Object, splitPath(mapping.ConditionsPath)...)
+		for i := len(conditions) - 1; i >= 0; i-- {
+			cond, ok := conditions[i].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			condType, _, _ := unstructured.NestedString(cond, "type")
+			condStatus, found, _ := unstructured.NestedString(cond, "status")
+			if found && condStatus != "True" {
+				continue
+			}
+			if _, ok = mapping.Phases[condType]; ok {
+				msg, _, _ := unstructured.NestedString(cond, "message")
+				return condType, msg, true
+			}
+		}
+	}
+	return "", "", false
+}
+
+func splitPath(path string) []string {
+	return strings.Split(strings.Trim(path, "."), ".")
+}
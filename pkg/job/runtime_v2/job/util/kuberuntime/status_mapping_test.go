@@ -0,0 +1,116 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kuberuntime
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/config"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/job/api"
+)
+
+func newMPIJob(status map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "kubeflow.org/v1",
+			"kind":       "MPIJob",
+			"metadata": map[string]interface{}{
+				"namespace": "default",
+				"name":      "test-job",
+			},
+			"status": status,
+		}}
+}
+
+func TestWithStatusMapping(t *testing.T) {
+	config.GlobalServerConfig = &config.ServerConfig{
+		Job: config.JobConfig{
+			StatusMappings: []config.JobStatusMapping{
+				{
+					APIVersion:     "kubeflow.org/v1",
+					Kind:           "MPIJob",
+					PhasePath:      "status.phase",
+					ConditionsPath: "status.conditions",
+					Phases: map[string]string{
+						"Created":   "pending",
+						"Running":   "running",
+						"Succeeded": "succeeded",
+						"Failed":    "failed",
+						"Unknown":   "unknown",
+					},
+				},
+			},
+		},
+	}
+	defer func() {
+		config.GlobalServerConfig = nil
+	}()
+	fallback := func(obj interface{}) (api.StatusInfo, error) {
+		return api.StatusInfo{}, fmt.Errorf("not mapped")
+	}
+
+	testCases := []struct {
+		name    string
+		obj     *unstructured.Unstructured
+		status  schema.JobStatus
+		message string
+		err     error
+	}{
+		{
+			name:   "map by phase",
+			obj:    newMPIJob(map[string]interface{}{"phase": "Running"}),
+			status: schema.StatusJobRunning,
+		},
+		{
+			name: "map by last true condition",
+			obj: newMPIJob(map[string]interface{}{
+				"conditions": []interface{}{
+					map[string]interface{}{"type": "Running", "status": "True"},
+					map[string]interface{}{"type": "Failed", "status": "True", "message": "worker exited"},
+					map[string]interface{}{"type": "Restarting", "status": "True"},
+					map[string]interface{}{"type": "Succeeded", "status": "False"},
+				},
+			}),
+			status:  schema.StatusJobFailed,
+			message: "worker exited",
+		},
+		{
+			name: "invalid job status",
+			obj:  newMPIJob(map[string]interface{}{"phase": "Unknown"}),
+			err:  fmt.Errorf("not mapped"),
+		},
+		{
+			name: "no mapping matched",
+			obj:  newMPIJob(map[string]interface{}{}),
+			err:  fmt.Errorf("not mapped"),
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			statusInfo, err := WithStatusMapping(fallback)(testCase.obj)
+			assert.Equal(t, testCase.err, err)
+			assert.Equal(t, testCase.status, statusInfo.Status)
+			assert.Equal(t, testCase.message, statusInfo.Message)
+		})
+	}
+}
@@ -22,12 +22,15 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 
 	"github.com/jinzhu/copier"
 	log "github.com/sirupsen/logrus"
 	"gorm.io/gorm"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
@@ -40,6 +43,7 @@ import (
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/config"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/k8s"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/resources"
 	pfschema "github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/job/api"
@@ -47,6 +51,7 @@ import (
 	"github.com/PaddlePaddle/PaddleFlow/pkg/job/runtime_v2/controller"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/job/runtime_v2/framework"
 	_ "github.com/PaddlePaddle/PaddleFlow/pkg/job/runtime_v2/job"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/job/runtime_v2/job/custom"
 	_ "github.com/PaddlePaddle/PaddleFlow/pkg/job/runtime_v2/queue"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/trace_logger"
@@ -127,8 +132,9 @@ func (kr *KubeRuntime) SubmitJob(job *api.PFJob) error {
 	// add trace log point
 	jobID := job.ID
 	traceLogger := trace_logger.KeyWithUpdate(jobID)
+	jobLogger := logger.LoggerForQueueJob(jobID, string(job.QueueID), job.UserName).WithField(logger.FieldClusterID, kr.cluster.ID)
 	msg := fmt.Sprintf("submit job[%v] to cluster[%s] queue[%s]", job.ID, kr.cluster.ID, job.QueueID)
-	log.Infof(msg)
+	jobLogger.Infof(msg)
 	traceLogger.Infof(msg)
 	// prepare kubernetes storage
 	traceLogger.Infof("prepare kubernetes storage")
@@ -138,34 +144,40 @@ func (kr *KubeRuntime) SubmitJob(job *api.PFJob) error {
 	}
 	for _, fs := range jobFileSystems {
 		if fs.Type == pfschema.PFSTypeLocal {
-			log.Infof("skip create pv/pvc, fs type is local")
+			jobLogger.Infof("skip create pv/pvc, fs type is local")
 			continue
 		}
 		fsID := common.ID(job.UserName, fs.Name)
 		pvName, err := kr.CreatePV(job.Namespace, fsID)
 		if err != nil {
-			log.Errorf("create pv for job[%s] failed, err: %v", job.ID, err)
+			jobLogger.Errorf("create pv failed, err: %v", err)
 			return err
 		}
 		msg = fmt.Sprintf("SubmitJob CreatePV fsID=%s pvName=%s", fsID, pvName)
-		log.Infof(msg)
+		jobLogger.Infof(msg)
 		traceLogger.Infof(msg)
 		err = kr.CreatePVC(job.Namespace, fsID, pvName)
 		if err != nil {
-			log.Errorf("create pvc for job[%s] failed, err: %v", job.ID, err)
+			jobLogger.Errorf("create pvc failed, err: %v", err)
+			return err
+		}
+	}
+	if sharedVolume := getSharedVolume(job); sharedVolume != nil {
+		if err := kr.CreateSharedPVC(job.Namespace, sharedVolume); err != nil {
+			jobLogger.Errorf("create shared pvc %s failed, err: %v", sharedVolume.ClaimName, err)
 			return err
 		}
 	}
 	// submit job
 	traceLogger.Infof("submit kubernetes job")
-	fwVersion := kr.Client().JobFrameworkVersion(job.JobType, job.Framework)
+	fwVersion := framework.GetJobFrameworkVersion(kr.Client(), job.JobType, job.Framework, job.ExtensionTemplate)
 	err := kr.Job(fwVersion).Submit(context.TODO(), job)
 	if err != nil {
-		log.Warnf("create kubernetes job[%s] failed, err: %v", job.Name, err)
+		jobLogger.Warnf("create kubernetes job[%s] failed, err: %v", job.Name, err)
 		return err
 	}
 	traceLogger.Infof("submit kubernetes job[%s] successful", job.ID)
-	log.Debugf("submit kubernetes job[%s] successful", jobID)
+	jobLogger.Debugf("submit kubernetes job successful")
 	return nil
 }
 
@@ -173,7 +185,7 @@ func (kr *KubeRuntime) StopJob(job *api.PFJob) error {
 	if job == nil {
 		return fmt.Errorf("stop job failed, job is nil")
 	}
-	fwVersion := kr.Client().JobFrameworkVersion(job.JobType, job.Framework)
+	fwVersion := framework.GetJobFrameworkVersion(kr.Client(), job.JobType, job.Framework, job.ExtensionTemplate)
 	return kr.Job(fwVersion).Stop(context.TODO(), job)
 }
 
@@ -181,7 +193,7 @@ func (kr *KubeRuntime) UpdateJob(job *api.PFJob) error {
 	if job == nil {
 		return fmt.Errorf("update job failed, job is nil")
 	}
-	fwVersion := kr.Client().JobFrameworkVersion(job.JobType, job.Framework)
+	fwVersion := framework.GetJobFrameworkVersion(kr.Client(), job.JobType, job.Framework, job.ExtensionTemplate)
 	return kr.Job(fwVersion).Update(context.TODO(), job)
 }
 
@@ -189,7 +201,7 @@ func (kr *KubeRuntime) DeleteJob(job *api.PFJob) error {
 	if job == nil {
 		return fmt.Errorf("delete job failed, job is nil")
 	}
-	fwVersion := kr.Client().JobFrameworkVersion(job.JobType, job.Framework)
+	fwVersion := framework.GetJobFrameworkVersion(kr.Client(), job.JobType, job.Framework, job.ExtensionTemplate)
 	return kr.Job(fwVersion).Delete(context.TODO(), job)
 }
 
@@ -248,6 +260,8 @@ func (kr *KubeRuntime) Queue(fwVersion pfschema.FrameworkVersion) framework.Queu
 
 func (kr *KubeRuntime) SyncController(stopCh <-chan struct{}) {
 	log.Infof("start job/queue controller on %s", kr.String())
+	// register plugins of custom jobs before job listeners are registered
+	custom.RegisterPlugins()
 	jobController := controller.NewJobSync()
 	err := jobController.Initialize(kr.kubeClient)
 	if err != nil {
@@ -350,6 +364,30 @@ func (kr *KubeRuntime) GetObject(namespace, name string, gvk schema.GroupVersion
 	return resourceObj, nil
 }
 
+// ListObjects lists kubernetes resources of gvk in namespace
+func (kr *KubeRuntime) ListObjects(namespace string, gvk schema.GroupVersionKind, listOptions metav1.ListOptions) ([]unstructured.Unstructured, error) {
+	log.Debugf("list kubernetes %s resources in namespace %s", gvk.String(), namespace)
+	resourceList, err := kr.kubeClient.List(namespace, client.KubeFrameworkVersion(gvk), listOptions)
+	if err != nil {
+		log.Errorf("list kubernetes %s resources in namespace %s failed, err: %v", gvk.String(), namespace, err)
+		return nil, err
+	}
+	list, ok := resourceList.(*unstructured.UnstructuredList)
+	if !ok || list == nil {
+		return nil, fmt.Errorf("unexpected list type %T of kubernetes %s resources", resourceList, gvk.String())
+	}
+	return list.Items, nil
+}
+
+func (kr *KubeRuntime) PatchObject(namespace, name string, gvk schema.GroupVersionKind, data []byte) error {
+	log.Infof("patch kubernetes %s resource: %s/%s", gvk.String(), namespace, name)
+	if err := kr.kubeClient.Patch(namespace, name, client.KubeFrameworkVersion(gvk), data); err != nil {
+		log.Errorf("patch kubernetes %s resource %s/%s failed, err: %v", gvk.String(), namespace, name, err)
+		return err
+	}
+	return nil
+}
+
 func (kr *KubeRuntime) DeleteObject(namespace, name string, gvk schema.GroupVersionKind) error {
 	log.Infof("delete kubernetes %s resource: %s/%s", gvk.String(), namespace, name)
 	if err := kr.kubeClient.Delete(namespace, name, client.KubeFrameworkVersion(gvk)); err != nil {
@@ -378,7 +416,7 @@ func (kr *KubeRuntime) CreatePV(namespace, fsID string) (string, error) {
 		log.Errorf(err.Error())
 		return "", err
 	}
-	if err := kr.buildPV(newPV, fsID); err != nil {
+	if err := kr.buildPV(newPV, namespace, fsID); err != nil {
 		log.Errorf(err.Error())
 		return "", err
 	}
@@ -389,7 +427,7 @@ func (kr *KubeRuntime) CreatePV(namespace, fsID string) (string, error) {
 	return pv.Name, nil
 }
 
-func (kr *KubeRuntime) buildPV(pv *corev1.PersistentVolume, fsID string) error {
+func (kr *KubeRuntime) buildPV(pv *corev1.PersistentVolume, namespace, fsID string) error {
 	// filesystem
 	fs, err := storage.Filesystem.GetFileSystemWithFsID(fsID)
 	if err != nil {
@@ -417,15 +455,51 @@ func (kr *KubeRuntime) buildPV(pv *corev1.PersistentVolume, fsID string) error {
 		return retErr
 	}
 
-	// set VolumeAttributes
+	// set VolumeAttributes, copier shares the csi source with the default pv, so copy it before writing
+	pv.Spec.CSI = pv.Spec.CSI.DeepCopy()
 	pv.Spec.CSI.VolumeHandle = pv.Name
 	pv.Spec.CSI.VolumeAttributes[pfschema.PFSID] = fsID
 	pv.Spec.CSI.VolumeAttributes[pfschema.PFSClusterID] = kr.cluster.ID
-	pv.Spec.CSI.VolumeAttributes[pfschema.PFSInfo] = base64.StdEncoding.EncodeToString(fsStr)
-	pv.Spec.CSI.VolumeAttributes[pfschema.PFSCache] = base64.StdEncoding.EncodeToString(fsCacheConfigStr)
+	// csi插件据此区分共用集群的多个apiserver
+	pv.Spec.CSI.VolumeAttributes[pfschema.PFSServer] = config.GetServiceAddress()
+	fsInfo := base64.StdEncoding.EncodeToString(fsStr)
+	fsCache := base64.StdEncoding.EncodeToString(fsCacheConfigStr)
+	if !config.GlobalServerConfig.Fs.MountInfoSecret {
+		pv.Spec.CSI.VolumeAttributes[pfschema.PFSInfo] = fsInfo
+		pv.Spec.CSI.VolumeAttributes[pfschema.PFSCache] = fsCache
+		return nil
+	}
+	// 存储配置中含有访问凭证，写入secret后由kubelet在NodePublishVolume时传给csi插件
+	secretName := pfschema.MountInfoSecretName(fsID, config.GetServiceAddress())
+	if err := kr.applyMountInfoSecret(namespace, secretName, fsInfo, fsCache); err != nil {
+		retErr := fmt.Errorf("create PV apply mount info secret[%s/%s] err: %v", namespace, secretName, err)
+		log.Errorf(retErr.Error())
+		return retErr
+	}
+	pv.Spec.CSI.NodePublishSecretRef = &corev1.SecretReference{Name: secretName, Namespace: namespace}
 	return nil
 }
 
+// applyMountInfoSecret 创建或更新存放存储及缓存配置的secret
+func (kr *KubeRuntime) applyMountInfoSecret(namespace, name, fsInfo, fsCache string) error {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		StringData: map[string]string{
+			pfschema.PFSInfo:  fsInfo,
+			pfschema.PFSCache: fsCache,
+		},
+	}
+	secrets := kr.clientset().CoreV1().Secrets(namespace)
+	_, err := secrets.Create(context.TODO(), secret, metav1.CreateOptions{})
+	if k8serrors.IsAlreadyExists(err) {
+		_, err = secrets.Update(context.TODO(), secret, metav1.UpdateOptions{})
+	}
+	return err
+}
+
 func (kr *KubeRuntime) CreatePVC(namespace, fsId, pv string) error {
 	pvc := config.DefaultPVC
 	pvcName := pfschema.ConcatenatePVCName(fsId)
@@ -450,13 +524,72 @@ func (kr *KubeRuntime) CreatePVC(namespace, fsId, pv string) error {
 	return nil
 }
 
+// getSharedVolume returns shared volume of pipeline run, which is same for all tasks of job
+func getSharedVolume(job *api.PFJob) *pfschema.RunSharedVolume {
+	if sharedVolume := job.Conf.GetSharedVolume(); sharedVolume != nil {
+		return sharedVolume
+	}
+	for _, task := range job.Tasks {
+		if sharedVolume := task.Conf.GetSharedVolume(); sharedVolume != nil {
+			return sharedVolume
+		}
+	}
+	return nil
+}
+
+// CreateSharedPVC create the ReadWriteMany pvc shared by steps of pipeline run, and skip if it exists
+func (kr *KubeRuntime) CreateSharedPVC(namespace string, sharedVolume *pfschema.RunSharedVolume) error {
+	if _, err := kr.getPersistentVolumeClaim(namespace, sharedVolume.ClaimName, metav1.GetOptions{}); err == nil {
+		return nil
+	} else if !k8serrors.IsNotFound(err) {
+		return err
+	}
+	volumeConf := config.GlobalServerConfig.Job.RunSharedVolume
+	size := sharedVolume.Size
+	if size == "" {
+		size = volumeConf.GetSize()
+	}
+	quantity, err := resource.ParseQuantity(size)
+	if err != nil {
+		return fmt.Errorf("invalid size %s of shared volume, err: %v", size, err)
+	}
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      sharedVolume.ClaimName,
+			Namespace: namespace,
+			Labels: map[string]string{
+				pfschema.RunSharedVolumeLabel: sharedVolume.RunID,
+			},
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteMany},
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: quantity,
+				},
+			},
+		},
+	}
+	storageClass := sharedVolume.StorageClass
+	if storageClass == "" {
+		storageClass = volumeConf.StorageClass
+	}
+	if storageClass != "" {
+		pvc.Spec.StorageClassName = &storageClass
+	}
+	if _, err = kr.createPersistentVolumeClaim(namespace, pvc); err != nil && !k8serrors.IsAlreadyExists(err) {
+		return err
+	}
+	return nil
+}
+
 func (kr *KubeRuntime) GetJobLog(jobLogRequest pfschema.JobLogRequest) (pfschema.JobLogInfo, error) {
 	jobLogInfo := pfschema.JobLogInfo{
 		JobID: jobLogRequest.JobID,
 	}
 	labelSelector := metav1.LabelSelector{}
 	switch pfschema.JobType(jobLogRequest.JobType) {
-	case pfschema.TypeSingle, pfschema.TypeDistributed, pfschema.TypeWorkflow:
+	case pfschema.TypeSingle, pfschema.TypeDistributed, pfschema.TypeWorkflow, pfschema.TypeServing:
 		labelSelector.MatchLabels = map[string]string{
 			pfschema.JobIDLabel: jobLogRequest.JobID,
 		}
@@ -523,6 +656,10 @@ func (kr *KubeRuntime) DeletePersistentVolumeClaim(namespace string, name string
 	return kr.clientset().CoreV1().PersistentVolumeClaims(namespace).Delete(context.TODO(), name, deleteOptions)
 }
 
+func (kr *KubeRuntime) DeleteSecret(namespace, name string, deleteOptions metav1.DeleteOptions) error {
+	return kr.clientset().CoreV1().Secrets(namespace).Delete(context.TODO(), name, deleteOptions)
+}
+
 func (kr *KubeRuntime) getPersistentVolumeClaim(namespace, name string, getOptions metav1.GetOptions) (*corev1.
 	PersistentVolumeClaim, error) {
 	return kr.clientset().CoreV1().PersistentVolumeClaims(namespace).Get(context.TODO(), name, getOptions)
@@ -540,6 +677,43 @@ func (kr *KubeRuntime) DeletePod(namespace, name string) error {
 	return kr.clientset().CoreV1().Pods(namespace).Delete(context.TODO(), name, metav1.DeleteOptions{})
 }
 
+func (kr *KubeRuntime) CreatePod(pod *corev1.Pod) error {
+	_, err := kr.clientset().CoreV1().Pods(pod.Namespace).Create(context.TODO(), pod, metav1.CreateOptions{})
+	return err
+}
+
+// GetPodLogTail 返回pod日志的最后tailLines行
+func (kr *KubeRuntime) GetPodLogTail(namespace, name string, tailLines int64) (string, error) {
+	logOptions := &corev1.PodLogOptions{TailLines: &tailLines}
+	data, err := kr.clientset().CoreV1().Pods(namespace).GetLogs(name, logOptions).DoRaw(context.TODO())
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// StreamPodLog 流式读取pod日志，logOptions.Follow为true时持续输出直到ctx结束或容器退出，调用方负责关闭
+func (kr *KubeRuntime) StreamPodLog(ctx context.Context, namespace, name string, logOptions *corev1.PodLogOptions) (io.ReadCloser, error) {
+	return kr.clientset().CoreV1().Pods(namespace).GetLogs(name, logOptions).Stream(ctx)
+}
+
+func (kr *KubeRuntime) CreateDeployment(deploy *appsv1.Deployment) error {
+	_, err := kr.clientset().AppsV1().Deployments(deploy.Namespace).Create(context.TODO(), deploy, metav1.CreateOptions{})
+	return err
+}
+
+func (kr *KubeRuntime) DeleteDeployment(namespace, name string) error {
+	return kr.clientset().AppsV1().Deployments(namespace).Delete(context.TODO(), name, metav1.DeleteOptions{})
+}
+
+func (kr *KubeRuntime) CreateService(svc *corev1.Service) (*corev1.Service, error) {
+	return kr.clientset().CoreV1().Services(svc.Namespace).Create(context.TODO(), svc, metav1.CreateOptions{})
+}
+
+func (kr *KubeRuntime) DeleteService(namespace, name string) error {
+	return kr.clientset().CoreV1().Services(namespace).Delete(context.TODO(), name, metav1.DeleteOptions{})
+}
+
 func (kr *KubeRuntime) getNodeQuotaListImpl(subQuotaFn func(r *resources.Resource, pod *corev1.Pod) error) (
 	pfschema.QuotaSummary, []pfschema.NodeQuotaInfo, error) {
 	result := []pfschema.NodeQuotaInfo{}
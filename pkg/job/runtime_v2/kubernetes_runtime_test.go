@@ -239,6 +239,56 @@ func TestKubeRuntimePVAndPVC(t *testing.T) {
 	// delete pv
 	err = kubeRuntime.DeletePersistentVolume(pv, metav1.DeleteOptions{})
 	assert.Equal(t, nil, err)
+
+	// mount info in secret
+	config.GlobalServerConfig.Fs.MountInfoSecret = true
+	namespace = "secret"
+	pv, err = kubeRuntime.CreatePV(namespace, fsID)
+	assert.Equal(t, nil, err)
+	pvObj, err := kubeRuntime.getPersistentVolume(pv, metav1.GetOptions{})
+	assert.Equal(t, nil, err)
+	assert.Empty(t, pvObj.Spec.CSI.VolumeAttributes[schema.PFSInfo])
+	assert.NotNil(t, pvObj.Spec.CSI.NodePublishSecretRef)
+	secret, err := kubeRuntime.clientset().CoreV1().Secrets(namespace).Get(context.TODO(),
+		pvObj.Spec.CSI.NodePublishSecretRef.Name, metav1.GetOptions{})
+	assert.Equal(t, nil, err)
+	assert.NotEmpty(t, secret.StringData[schema.PFSInfo])
+	assert.NotEmpty(t, secret.StringData[schema.PFSCache])
+	err = kubeRuntime.DeleteSecret(namespace, secret.Name, metav1.DeleteOptions{})
+	assert.Equal(t, nil, err)
+}
+
+func TestKubeRuntimeSharedPVC(t *testing.T) {
+	var server = httptest.NewServer(k8s.DiscoveryHandlerFunc)
+	defer server.Close()
+	kubeClient := newFakeKubeRuntimeClient(server)
+	kubeRuntime := &KubeRuntime{
+		cluster:    schema.Cluster{Name: "test-cluster", Type: "Kubernetes"},
+		kubeClient: kubeClient,
+	}
+	config.GlobalServerConfig = &config.ServerConfig{}
+	config.GlobalServerConfig.Job.RunSharedVolume.StorageClass = "cfs"
+
+	namespace := "default"
+	sharedVolume := &schema.RunSharedVolume{
+		RunID:     "run-000001",
+		ClaimName: schema.RunSharedVolumeClaimName("run-000001"),
+	}
+	err := kubeRuntime.CreateSharedPVC(namespace, sharedVolume)
+	assert.NoError(t, err)
+	// create again when pvc exists
+	err = kubeRuntime.CreateSharedPVC(namespace, sharedVolume)
+	assert.NoError(t, err)
+	pvc, err := kubeRuntime.getPersistentVolumeClaim(namespace, sharedVolume.ClaimName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, []corev1.PersistentVolumeAccessMode{corev1.ReadWriteMany}, pvc.Spec.AccessModes)
+	assert.Equal(t, "cfs", *pvc.Spec.StorageClassName)
+	assert.Equal(t, "run-000001", pvc.Labels[schema.RunSharedVolumeLabel])
+	size := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+	assert.Equal(t, config.DefaultRunSharedVolumeSize, size.String())
+
+	err = kubeRuntime.CreateSharedPVC(namespace, &schema.RunSharedVolume{ClaimName: "pf-shared-run-2", Size: "abc"})
+	assert.Error(t, err)
 }
 
 func TestKubeRuntimeObjectOperation(t *testing.T) {
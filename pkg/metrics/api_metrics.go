@@ -0,0 +1,58 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// APIRequests 各版本API的请求数，route为路由模板，如/api/paddleflow/v1/job/{jobID}
+var APIRequests = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: MetricAPIRequests,
+		Help: toHelp(MetricAPIRequests),
+	},
+	[]string{VersionLabel, MethodLabel, RouteLabel, CodeLabel},
+)
+
+// APIRequestLatency 各版本API的请求耗时
+var APIRequestLatency = prometheus.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Name:    MetricAPIRequestLatency,
+		Help:    toHelp(MetricAPIRequestLatency),
+		Buckets: prometheus.DefBuckets,
+	},
+	[]string{VersionLabel, MethodLabel, RouteLabel},
+)
+
+// ObserveAPIRequest records request of api version
+func ObserveAPIRequest(version, method, route string, code int, d time.Duration) {
+	APIRequests.With(prometheus.Labels{
+		VersionLabel: version,
+		MethodLabel:  method,
+		RouteLabel:   route,
+		CodeLabel:    strconv.Itoa(code),
+	}).Inc()
+	APIRequestLatency.With(prometheus.Labels{
+		VersionLabel: version,
+		MethodLabel:  method,
+		RouteLabel:   route,
+	}).Observe(d.Seconds())
+}
@@ -21,9 +21,17 @@ import (
 )
 
 const (
-	MetricJobTime    = "pf_metric_job_time"
-	MetricQueueInfo  = "pf_metric_queue_info"
-	MetricJobGPUInfo = "pf_metric_job_gpu_info"
+	MetricJobTime       = "pf_metric_job_time"
+	MetricQueueInfo     = "pf_metric_queue_info"
+	MetricJobGPUInfo    = "pf_metric_job_gpu_info"
+	MetricFsCache       = "pf_metric_fs_cache_info"
+	MetricFsReplication = "pf_metric_fs_replication_info"
+	MetricJobSyncShard  = "pf_metric_job_sync_shard_info"
+	// MetricJobDispatchLatency is histogram of seconds taken by each stage of job dispatch
+	MetricJobDispatchLatency = "pf_metric_job_dispatch_latency_seconds"
+	// MetricAPIRequests MetricAPIRequestLatency are requests and latency of apis by version
+	MetricAPIRequests       = "pf_metric_api_requests_total"
+	MetricAPIRequestLatency = "pf_metric_api_request_duration_seconds"
 )
 
 func toHelp(name string) string {
@@ -41,4 +49,15 @@ const (
 	ResourceLabel       = "resource"
 	TypeLabel           = "type"
 	BaiduGpuIndexLabel  = "baidu_com_gpu_idx"
+	FsIDLabel           = "fsID"
+	NodeNameLabel       = "nodename"
+	ReplicaFsIDLabel    = "replicaFsID"
+	ClusterIDLabel      = "clusterID"
+	ShardLabel          = "shard"
+	KindLabel           = "kind"
+	StageLabel          = "stage"
+	VersionLabel        = "version"
+	MethodLabel         = "method"
+	RouteLabel          = "route"
+	CodeLabel           = "code"
 )
@@ -0,0 +1,87 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	FsCacheTypeUsedSize       = "usedSize"
+	FsCacheTypeHits           = "hits"
+	FsCacheTypeMisses         = "misses"
+	FsCacheTypeHitBytes       = "hitBytes"
+	FsCacheTypeMissBytes      = "missBytes"
+	FsCacheTypeHitRate        = "hitRate"
+	FsCacheTypeEvictions      = "evictions"
+	FsCacheTypeReadThroughput = "readThroughput"
+)
+
+// FsCacheMetricCollector 导出各存储在各节点上的缓存用量与命中统计
+type FsCacheMetricCollector struct {
+	fsCacheInfo *prometheus.GaugeVec
+	listFsCache ListFsCacheFunc
+}
+
+func NewFsCacheMetricsCollector(fsCacheFunc ListFsCacheFunc) *FsCacheMetricCollector {
+	return &FsCacheMetricCollector{
+		fsCacheInfo: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: MetricFsCache,
+				Help: toHelp(MetricFsCache),
+			},
+			[]string{FsIDLabel, NodeNameLabel, TypeLabel},
+		),
+		listFsCache: fsCacheFunc,
+	}
+}
+
+func (f *FsCacheMetricCollector) Describe(descs chan<- *prometheus.Desc) {
+	f.fsCacheInfo.Describe(descs)
+}
+
+func (f *FsCacheMetricCollector) Collect(metrics chan<- prometheus.Metric) {
+	f.update()
+	f.fsCacheInfo.Collect(metrics)
+}
+
+func (f *FsCacheMetricCollector) update() {
+	// 缓存记录会随挂载pod回收而删除，每次重新生成
+	f.fsCacheInfo.Reset()
+	if f.listFsCache == nil {
+		return
+	}
+	for _, cache := range f.listFsCache() {
+		values := map[string]float64{
+			FsCacheTypeUsedSize:       float64(cache.UsedSize),
+			FsCacheTypeHits:           float64(cache.CacheHits),
+			FsCacheTypeMisses:         float64(cache.CacheMisses),
+			FsCacheTypeHitBytes:       float64(cache.HitBytes),
+			FsCacheTypeMissBytes:      float64(cache.MissBytes),
+			FsCacheTypeHitRate:        cache.HitRate(),
+			FsCacheTypeEvictions:      float64(cache.Evictions),
+			FsCacheTypeReadThroughput: float64(cache.ReadThroughput),
+		}
+		for typ, value := range values {
+			f.fsCacheInfo.With(prometheus.Labels{
+				FsIDLabel:     cache.FsID,
+				NodeNameLabel: cache.NodeName,
+				TypeLabel:     typ,
+			}).Set(value)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	FsReplicationTypePendingFiles    = "pendingFiles"
+	FsReplicationTypePendingBytes    = "pendingBytes"
+	FsReplicationTypeLagSeconds      = "lagSeconds"
+	FsReplicationTypeReplicatedFiles = "replicatedFiles"
+	FsReplicationTypeReplicatedBytes = "replicatedBytes"
+)
+
+// FsReplicationMetricCollector 导出各存储异步复制的延迟与复制量
+type FsReplicationMetricCollector struct {
+	fsReplicationInfo *prometheus.GaugeVec
+	listReplication   ListFsReplicationFunc
+}
+
+func NewFsReplicationMetricsCollector(replicationFunc ListFsReplicationFunc) *FsReplicationMetricCollector {
+	return &FsReplicationMetricCollector{
+		fsReplicationInfo: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: MetricFsReplication,
+				Help: toHelp(MetricFsReplication),
+			},
+			[]string{FsIDLabel, ReplicaFsIDLabel, StatusLabel, TypeLabel},
+		),
+		listReplication: replicationFunc,
+	}
+}
+
+func (f *FsReplicationMetricCollector) Describe(descs chan<- *prometheus.Desc) {
+	f.fsReplicationInfo.Describe(descs)
+}
+
+func (f *FsReplicationMetricCollector) Collect(metrics chan<- prometheus.Metric) {
+	f.update()
+	f.fsReplicationInfo.Collect(metrics)
+}
+
+func (f *FsReplicationMetricCollector) update() {
+	// 复制关系会被删除或切换状态，每次重新生成
+	f.fsReplicationInfo.Reset()
+	if f.listReplication == nil {
+		return
+	}
+	for _, replication := range f.listReplication() {
+		values := map[string]float64{
+			FsReplicationTypePendingFiles:    float64(replication.PendingFiles),
+			FsReplicationTypePendingBytes:    float64(replication.PendingBytes),
+			FsReplicationTypeLagSeconds:      float64(replication.LagSeconds),
+			FsReplicationTypeReplicatedFiles: float64(replication.ReplicatedFiles),
+			FsReplicationTypeReplicatedBytes: float64(replication.ReplicatedBytes),
+		}
+		for typ, value := range values {
+			f.fsReplicationInfo.With(prometheus.Labels{
+				FsIDLabel:        replication.FsID,
+				ReplicaFsIDLabel: replication.ReplicaFsID,
+				StatusLabel:      replication.Status,
+				TypeLabel:        typ,
+			}).Set(value)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// JobDispatchStageEnqueue is from job created to job read into memory queue
+	JobDispatchStageEnqueue = "enqueue"
+	// JobDispatchStageWait is from job read into memory queue to job dequeued for submitting
+	JobDispatchStageWait = "wait"
+	// JobDispatchStageSubmit is time of submitting job to cluster, including waiting for cluster concurrency
+	JobDispatchStageSubmit = "submit"
+)
+
+// JobDispatchLatency 作业下发各阶段耗时
+var JobDispatchLatency = prometheus.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Name:    MetricJobDispatchLatency,
+		Help:    toHelp(MetricJobDispatchLatency),
+		Buckets: prometheus.ExponentialBuckets(0.01, 4, 10),
+	},
+	[]string{QueueNameLabel, StageLabel},
+)
+
+// ObserveJobDispatchLatency records time taken by stage of job dispatch
+func ObserveJobDispatchLatency(queueName, stage string, d time.Duration) {
+	JobDispatchLatency.With(prometheus.Labels{
+		QueueNameLabel: queueName,
+		StageLabel:     stage,
+	}).Observe(d.Seconds())
+}
@@ -51,3 +51,5 @@ type Timestamps interface {
 
 type ListQueueFunc func() []model.Queue
 type ListJobFunc func() []model.Job
+type ListFsCacheFunc func() []model.FSCache
+type ListFsReplicationFunc func() []model.FsReplication
@@ -0,0 +1,57 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	JobSyncShardTypeDepth      = "depth"
+	JobSyncShardTypeLagSeconds = "lagSeconds"
+	JobSyncShardTypeProcessed  = "processed"
+)
+
+// JobSyncShard 导出各集群作业/任务同步分片的队列长度、最近处理事件的等待时间及已处理事件数，kind为job或task
+var JobSyncShard = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: MetricJobSyncShard,
+		Help: toHelp(MetricJobSyncShard),
+	},
+	[]string{ClusterIDLabel, KindLabel, ShardLabel, TypeLabel},
+)
+
+// SetJobSyncShard sets value of shard metric
+func SetJobSyncShard(clusterID, kind string, shard int, typ string, value float64) {
+	JobSyncShard.With(jobSyncShardLabels(clusterID, kind, shard, typ)).Set(value)
+}
+
+// AddJobSyncShard adds value to shard metric
+func AddJobSyncShard(clusterID, kind string, shard int, typ string, value float64) {
+	JobSyncShard.With(jobSyncShardLabels(clusterID, kind, shard, typ)).Add(value)
+}
+
+func jobSyncShardLabels(clusterID, kind string, shard int, typ string) prometheus.Labels {
+	return prometheus.Labels{
+		ClusterIDLabel: clusterID,
+		KindLabel:      kind,
+		ShardLabel:     strconv.Itoa(shard),
+		TypeLabel:      typ,
+	}
+}
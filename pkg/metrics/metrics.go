@@ -46,7 +46,8 @@ func InitMetrics() {
 	//PromAPIClient = apiClient
 }
 
-func initRegistry(queueFunc ListQueueFunc, jobFunc ListJobFunc) {
+func initRegistry(queueFunc ListQueueFunc, jobFunc ListJobFunc, fsCacheFunc ListFsCacheFunc,
+	replicationFunc ListFsReplicationFunc) {
 	if Job == nil {
 		panic("metrics not initialized")
 	}
@@ -55,10 +56,16 @@ func initRegistry(queueFunc ListQueueFunc, jobFunc ListJobFunc) {
 	queueCollector := NewQueueMetricsCollector(queueFunc)
 	registry.MustRegister(jobCollector)
 	registry.MustRegister(queueCollector)
+	registry.MustRegister(NewFsCacheMetricsCollector(fsCacheFunc))
+	registry.MustRegister(NewFsReplicationMetricsCollector(replicationFunc))
+	registry.MustRegister(JobSyncShard)
+	registry.MustRegister(JobDispatchLatency)
+	registry.MustRegister(APIRequests, APIRequestLatency)
 }
 
-func StartMetricsService(port int, queueFunc ListQueueFunc, jobFunc ListJobFunc) string {
-	initRegistry(queueFunc, jobFunc)
+func StartMetricsService(port int, queueFunc ListQueueFunc, jobFunc ListJobFunc, fsCacheFunc ListFsCacheFunc,
+	replicationFunc ListFsReplicationFunc) string {
+	initRegistry(queueFunc, jobFunc, fsCacheFunc, replicationFunc)
 	if port == 0 {
 		port = DefaultMetricPort
 	}
@@ -0,0 +1,78 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// AlertChannels 告警通知渠道，均为空时使用全局通知配置
+type AlertChannels struct {
+	Emails   []string `json:"emails,omitempty"`
+	Slack    []string `json:"slack,omitempty"`
+	Webhooks []string `json:"webhooks,omitempty"`
+}
+
+// AlertRule 管理员配置的告警规则，指标满足条件并持续ForSeconds后发送告警，条件不再满足时发送恢复通知
+type AlertRule struct {
+	Pk     int64  `json:"-"        gorm:"primaryKey;autoIncrement;not null"`
+	ID     string `json:"id"       gorm:"type:varchar(60);uniqueIndex;not null"`
+	Name   string `json:"name"     gorm:"type:varchar(128);uniqueIndex;not null"`
+	Metric string `json:"metric"   gorm:"type:varchar(64);not null"`
+	// Target 队列名、用户名或存储ID，为空时对所有对象分别评估
+	Target    string  `json:"target"    gorm:"type:varchar(255)"`
+	Operator  string  `json:"operator"  gorm:"type:varchar(8);not null"`
+	Threshold float64 `json:"threshold"`
+	// ForSeconds 条件持续满足的时长，为0时首次满足即告警
+	ForSeconds int `json:"forSeconds"`
+	// WindowSeconds 失败率等比例类指标的统计窗口
+	WindowSeconds int           `json:"windowSeconds"`
+	Channels      AlertChannels `json:"channels"    gorm:"-"`
+	RawChannels   string        `json:"-"           gorm:"column:channels;type:text"`
+	Enabled       bool          `json:"enabled"`
+	Description   string        `json:"description" gorm:"type:varchar(1024)"`
+	CreatedBy     string        `json:"createdBy"   gorm:"type:varchar(60)"`
+	CreatedAt     time.Time     `json:"createTime"`
+	UpdatedAt     time.Time     `json:"updateTime"`
+}
+
+func (AlertRule) TableName() string {
+	return "alert_rule"
+}
+
+func (r *AlertRule) BeforeSave(tx *gorm.DB) error {
+	channels, err := json.Marshal(r.Channels)
+	if err != nil {
+		return err
+	}
+	r.RawChannels = string(channels)
+	return nil
+}
+
+func (r *AlertRule) AfterFind(tx *gorm.DB) error {
+	if r.RawChannels != "" {
+		if err := json.Unmarshal([]byte(r.RawChannels), &r.Channels); err != nil {
+			log.Errorf("alert rule[%s] json unmarshal channels failed, error: %s", r.Name, err.Error())
+			return err
+		}
+	}
+	return nil
+}
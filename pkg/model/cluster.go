@@ -21,6 +21,8 @@ import (
 
 	log "github.com/sirupsen/logrus"
 	"gorm.io/gorm"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/envelope"
 )
 
 const (
@@ -40,7 +42,7 @@ type ClusterInfo struct {
 	ClusterType      string   `gorm:"column:cluster_type" json:"clusterType"` // 集群类型，比如Kubernetes/Local
 	Version          string   `gorm:"column:version" json:"version"`          // 集群版本，比如v1.16
 	Status           string   `gorm:"column:status" json:"status"`            // 集群状态，可选值为online, offline
-	Credential       string   `gorm:"column:credential" json:"credential"`    // 用于存储集群的凭证信息，比如k8s的kube_config配置
+	Credential       string   `gorm:"column:credential" json:"credential"`    // 用于存储集群的凭证信息，比如k8s的kube_config配置，保存时加密
 	Setting          string   `gorm:"column:setting" json:"setting"`          // 存储额外配置信息
 	RawNamespaceList string   `gorm:"column:namespace_list" json:"-"`         // 命名空间列表，json类型，如["ns1", "ns2"]
 	NamespaceList    []string `gorm:"-" json:"namespaceList"`                 // 命名空间列表，json类型，如["ns1", "ns2"]
@@ -73,6 +75,13 @@ func (clusterInfo *ClusterInfo) BeforeSave(*gorm.DB) error {
 		}
 		clusterInfo.RawNamespaceList = string(namespaceList)
 	}
+	// credential is only decrypted by runtime factory
+	credential, err := envelope.Encrypt(clusterInfo.Credential)
+	if err != nil {
+		log.Errorf("encrypt credential of cluster[%s] failed: %v", clusterInfo.Name, err)
+		return err
+	}
+	clusterInfo.Credential = credential
 	return nil
 }
 
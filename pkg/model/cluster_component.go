@@ -0,0 +1,41 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import "time"
+
+const (
+	ComponentInstalling = "installing"
+	ComponentInstalled  = "installed"
+	ComponentFailed     = "failed"
+)
+
+// ClusterComponent PaddleFlow安装到集群中的组件及其安装状态
+type ClusterComponent struct {
+	Pk        int64     `json:"-"          gorm:"primaryKey;autoIncrement"`
+	ClusterID string    `json:"clusterId"  gorm:"type:varchar(60);uniqueIndex:idx_cluster_component"`
+	Name      string    `json:"name"       gorm:"type:varchar(64);uniqueIndex:idx_cluster_component"`
+	Version   string    `json:"version"    gorm:"type:varchar(128)"`
+	Status    string    `json:"status"     gorm:"type:varchar(32)"`
+	Message   string    `json:"message"    gorm:"type:text"`
+	CreatedAt time.Time `json:"createTime"`
+	UpdatedAt time.Time `json:"updateTime"`
+}
+
+func (ClusterComponent) TableName() string {
+	return "cluster_component"
+}
@@ -0,0 +1,110 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// Dataset 将存储中的目录注册为命名数据集，同一用户下名称唯一
+type Dataset struct {
+	Pk            int64     `json:"-"             gorm:"primaryKey;autoIncrement;not null"`
+	ID            string    `json:"id"            gorm:"type:varchar(60);uniqueIndex;not null"`
+	Name          string    `json:"name"          gorm:"type:varchar(128);uniqueIndex:idx_dataset_user_name;not null"`
+	UserName      string    `json:"userName"      gorm:"type:varchar(60);uniqueIndex:idx_dataset_user_name;not null"`
+	FsID          string    `json:"-"             gorm:"type:varchar(200);not null"`
+	FsName        string    `json:"fsName"        gorm:"type:varchar(200);not null"`
+	Path          string    `json:"path"          gorm:"type:varchar(1024);not null"`
+	Description   string    `json:"description"   gorm:"type:varchar(1024)"`
+	LatestVersion string    `json:"latestVersion" gorm:"type:varchar(32)"`
+	CreatedAt     time.Time `json:"createTime"`
+	UpdatedAt     time.Time `json:"updateTime"`
+}
+
+func (Dataset) TableName() string {
+	return "dataset"
+}
+
+// DatasetFile 数据集快照中单个文件的摘要
+type DatasetFile struct {
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+	Hash string `json:"hash"`
+}
+
+// DatasetVersionPrefix 数据集版本号的前缀，版本号为 v1、v2 ...
+const DatasetVersionPrefix = "v"
+
+// DatasetVersion 数据集版本，通过文件摘要清单记录数据集在某一时刻的内容
+type DatasetVersion struct {
+	Pk           int64         `json:"-"            gorm:"primaryKey;autoIncrement;not null"`
+	DatasetID    string        `json:"datasetID"    gorm:"type:varchar(60);uniqueIndex:idx_dataset_version;not null"`
+	Version      string        `json:"version"      gorm:"type:varchar(32);uniqueIndex:idx_dataset_version;not null"`
+	Digest       string        `json:"digest"       gorm:"type:varchar(64);not null"`
+	FileCount    int64         `json:"fileCount"`
+	TotalSize    int64         `json:"totalSize"`
+	Description  string        `json:"description"  gorm:"type:varchar(1024)"`
+	Manifest     []DatasetFile `json:"manifest,omitempty" gorm:"-"`
+	ManifestJson string        `json:"-"            gorm:"column:manifest;type:longtext"`
+	CreatedAt    time.Time     `json:"createTime"`
+}
+
+func (DatasetVersion) TableName() string {
+	return "dataset_version"
+}
+
+func (dv *DatasetVersion) BeforeSave(tx *gorm.DB) error {
+	if len(dv.Manifest) != 0 {
+		manifestJson, err := json.Marshal(dv.Manifest)
+		if err != nil {
+			return err
+		}
+		dv.ManifestJson = string(manifestJson)
+	}
+	return nil
+}
+
+func (dv *DatasetVersion) AfterFind(tx *gorm.DB) error {
+	if len(dv.ManifestJson) > 0 {
+		var manifest []DatasetFile
+		if err := json.Unmarshal([]byte(dv.ManifestJson), &manifest); err != nil {
+			log.Errorf("dataset[%s] version[%s] json unmarshal manifest failed, error: %s", dv.DatasetID, dv.Version, err.Error())
+			return err
+		}
+		dv.Manifest = manifest
+	}
+	return nil
+}
+
+// NextDatasetVersion 返回已有版本中最大序号+1的版本号
+func NextDatasetVersion(versions []string) string {
+	var max int64
+	for _, version := range versions {
+		seq, err := strconv.ParseInt(strings.TrimPrefix(version, DatasetVersionPrefix), 10, 64)
+		if err == nil && seq > max {
+			max = seq
+		}
+	}
+	return fmt.Sprintf("%s%d", DatasetVersionPrefix, max+1)
+}
@@ -0,0 +1,47 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"database/sql"
+	"time"
+)
+
+const (
+	EventCreated       = "created"
+	EventStatusChanged = "status_changed"
+	EventDeleted       = "deleted"
+)
+
+// ResourceEvent 资源状态变化事件，与资源变更在同一事务中写入，发布到消息总线后标记为已发布
+type ResourceEvent struct {
+	Pk             int64        `json:"-"              gorm:"primaryKey;autoIncrement"`
+	ID             string       `json:"id"             gorm:"type:varchar(64);uniqueIndex"`
+	ResourceType   string       `json:"resourceType"   gorm:"type:varchar(32)"`
+	ResourceID     string       `json:"resourceID"     gorm:"type:varchar(64)"`
+	Type           string       `json:"type"           gorm:"type:varchar(32)"`
+	Status         string       `json:"status"         gorm:"type:varchar(32)"`
+	PreviousStatus string       `json:"previousStatus" gorm:"type:varchar(32)"`
+	UserName       string       `json:"userName"       gorm:"type:varchar(60)"`
+	Published      bool         `json:"published"      gorm:"index"`
+	CreatedAt      time.Time    `json:"createTime"`
+	PublishedAt    sql.NullTime `json:"-"`
+}
+
+func (ResourceEvent) TableName() string {
+	return "resource_event"
+}
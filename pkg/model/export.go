@@ -0,0 +1,33 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"time"
+)
+
+// ExportWatermark 每类导出记录已导出到的位置，记录按(时间, pk)排序，下次从该位置之后继续导出
+type ExportWatermark struct {
+	Name      string    `json:"name"      gorm:"type:varchar(64);primaryKey"`
+	Time      time.Time `json:"time"`
+	RecordPk  int64     `json:"recordPk"`
+	UpdatedAt time.Time `json:"updateTime"`
+}
+
+func (ExportWatermark) TableName() string {
+	return "export_watermark"
+}
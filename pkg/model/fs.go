@@ -38,6 +38,9 @@ type FileSystem struct {
 	PropertiesMap           map[string]string `json:"properties" gorm:"-"`
 	UserName                string            `json:"userName"`
 	IndependentMountProcess bool              `json:"independentMountProcess"`
+	// CapacityQuota 容量配额(字节)，InodeQuota 文件与目录总数配额，0表示不限制
+	CapacityQuota int64 `json:"capacityQuota" gorm:"column:capacity_quota;default:0"`
+	InodeQuota    int64 `json:"inodeQuota" gorm:"column:inode_quota;default:0"`
 }
 
 func (FileSystem) TableName() string {
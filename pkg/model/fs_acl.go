@@ -0,0 +1,46 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"time"
+)
+
+const (
+	FsAclTableName = "fs_acl"
+
+	FsGranteeUser  = "user"
+	FsGranteeQueue = "queue"
+
+	FsPermissionRead      = "r"
+	FsPermissionReadWrite = "rw"
+)
+
+// FsAcl 文件系统所有者授予其他用户或队列的访问权限
+type FsAcl struct {
+	Pk          int64     `json:"-"           gorm:"primaryKey;autoIncrement;not null"`
+	FsID        string    `json:"-"           gorm:"type:varchar(200);uniqueIndex:idx_fs_acl_grantee;not null"`
+	GranteeType string    `json:"granteeType" gorm:"type:varchar(32);uniqueIndex:idx_fs_acl_grantee;not null"`
+	GranteeName string    `json:"granteeName" gorm:"type:varchar(255);uniqueIndex:idx_fs_acl_grantee;not null"`
+	Permission  string    `json:"permission"  gorm:"type:varchar(32);not null"`
+	CreatedAt   time.Time `json:"createTime"`
+	UpdatedAt   time.Time `json:"updateTime"`
+}
+
+func (FsAcl) TableName() string {
+	return FsAclTableName
+}
@@ -27,23 +27,37 @@ import (
 const FsCacheTableName = "fs_cache"
 
 type FSCache struct {
-	PK          int64          `json:"-" gorm:"primaryKey;autoIncrement"`
-	CacheID     string         `json:"cacheID" gorm:"type:varchar(36);column:cache_id"`
-	CacheHashID string         `json:"cacheHashID" gorm:"type:varchar(36);column:cache_hash_id"`
-	FsID        string         `json:"fsID" gorm:"type:varchar(36);column:fs_id"`
-	CacheDir    string         `json:"cacheDir" gorm:"type:varchar(4096);column:cache_dir"`
-	NodeName    string         `json:"nodename" gorm:"type:varchar(256);column:nodename"`
-	UsedSize    int            `json:"usedSize" gorm:"type:bigint(20);column:usedsize"`
-	ClusterID   string         `json:"-"   gorm:"column:cluster_id;default:''"`
-	CreatedAt   time.Time      `json:"-"`
-	UpdatedAt   time.Time      `json:"-"`
-	DeletedAt   gorm.DeletedAt `json:"-"`
+	PK             int64          `json:"-" gorm:"primaryKey;autoIncrement"`
+	CacheID        string         `json:"cacheID" gorm:"type:varchar(36);column:cache_id"`
+	CacheHashID    string         `json:"cacheHashID" gorm:"type:varchar(36);column:cache_hash_id"`
+	FsID           string         `json:"fsID" gorm:"type:varchar(36);column:fs_id"`
+	CacheDir       string         `json:"cacheDir" gorm:"type:varchar(4096);column:cache_dir"`
+	NodeName       string         `json:"nodename" gorm:"type:varchar(256);column:nodename"`
+	UsedSize       int            `json:"usedSize" gorm:"type:bigint(20);column:usedsize"`
+	CacheHits      int64          `json:"cacheHits" gorm:"column:cache_hits;default:0"`
+	CacheMisses    int64          `json:"cacheMisses" gorm:"column:cache_misses;default:0"`
+	HitBytes       int64          `json:"hitBytes" gorm:"column:hit_bytes;default:0"`
+	MissBytes      int64          `json:"missBytes" gorm:"column:miss_bytes;default:0"`
+	Evictions      int64          `json:"evictions" gorm:"column:evictions;default:0"`
+	ReadThroughput int64          `json:"readThroughput" gorm:"column:read_throughput;default:0"` // byte/s
+	ClusterID      string         `json:"-"   gorm:"column:cluster_id;default:''"`
+	CreatedAt      time.Time      `json:"-"`
+	UpdatedAt      time.Time      `json:"-"`
+	DeletedAt      gorm.DeletedAt `json:"-"`
 }
 
 func (c *FSCache) TableName() string {
 	return FsCacheTableName
 }
 
+// HitRate 缓存读命中率，无读取时为0
+func (c *FSCache) HitRate() float64 {
+	if c.CacheHits+c.CacheMisses == 0 {
+		return 0
+	}
+	return float64(c.CacheHits) / float64(c.CacheHits+c.CacheMisses)
+}
+
 func (c *FSCache) BeforeSave(*gorm.DB) error {
 	if c.CacheID == "" {
 		c.CacheID = CacheID(c.ClusterID, c.NodeName, c.CacheDir, c.FsID)
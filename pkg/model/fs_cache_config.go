@@ -31,7 +31,22 @@ type FSCacheConfig struct {
 	CacheDir                string                 `json:"cacheDir"`
 	Quota                   int                    `json:"quota"`
 	MetaDriver              string                 `json:"metaDriver"`
+	MetaAddress             string                 `json:"metaAddress"`
 	BlockSize               int                    `json:"blockSize"`
+	MaxCacheSize            string                 `json:"maxCacheSize"`
+	EvictionPolicy          string                 `json:"evictionPolicy"`
+	CacheTTL                string                 `json:"cacheTTL"             gorm:"column:cache_ttl"`
+	MemCacheSize            string                 `json:"memCacheSize"`
+	WriteBack               bool                   `json:"writeBack"`
+	WriteBackDirtyLimit     string                 `json:"writeBackDirtyLimit"`
+	MountPodPolicy          string                 `json:"mountPodPolicy"`
+	CacheCompression        string                 `json:"cacheCompression"`
+	CacheEncryption         bool                   `json:"cacheEncryption"`
+	PrefetchBlocks          int                    `json:"prefetchBlocks"`
+	PrefetchWorkers         int                    `json:"prefetchWorkers"`
+	AttrCacheTTL            string                 `json:"attrCacheTTL"         gorm:"column:attr_cache_ttl"`
+	EntryCacheTTL           string                 `json:"entryCacheTTL"        gorm:"column:entry_cache_ttl"`
+	NegativeCacheTTL        string                 `json:"negativeCacheTTL"     gorm:"column:negative_cache_ttl"`
 	Debug                   bool                   `json:"debug"`
 	CleanCache              bool                   `json:"cleanCache"`
 	Resource                ResourceLimit          `json:"resource"             gorm:"-"`
@@ -50,8 +65,10 @@ type FSCacheConfig struct {
 }
 
 type ResourceLimit struct {
-	CpuLimit    string `json:"cpuLimit"`
-	MemoryLimit string `json:"memoryLimit"`
+	CpuLimit      string `json:"cpuLimit"`
+	MemoryLimit   string `json:"memoryLimit"`
+	CpuRequest    string `json:"cpuRequest"`
+	MemoryRequest string `json:"memoryRequest"`
 }
 
 func (s *FSCacheConfig) TableName() string {
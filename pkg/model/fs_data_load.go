@@ -0,0 +1,127 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const (
+	FsDataLoadTableName = "fs_data_load"
+
+	DataLoadStatusPending    = "pending"
+	DataLoadStatusRunning    = "running"
+	DataLoadStatusSucceeded  = "succeeded"
+	DataLoadStatusFailed     = "failed"
+	DataLoadStatusTerminated = "terminated"
+)
+
+// FSDataLoad 缓存预热任务，在指定节点上读取存储中的数据，使其进入节点的本地缓存
+type FSDataLoad struct {
+	Pk        int64  `json:"-"         gorm:"primaryKey;autoIncrement;not null"`
+	ID        string `json:"id"        gorm:"type:varchar(60);uniqueIndex;not null"`
+	UserName  string `json:"userName"  gorm:"type:varchar(60);not null"`
+	FsID      string `json:"-"         gorm:"type:varchar(200);index"`
+	FsName    string `json:"fsName"    gorm:"type:varchar(200)"`
+	ClusterID string `json:"-"         gorm:"type:varchar(60)"`
+	Namespace string `json:"namespace" gorm:"type:varchar(64)"`
+	// Paths 需要预热的路径，支持通配符，相对于存储根目录
+	PathsJson string   `json:"-"     gorm:"column:paths;type:text"`
+	Paths     []string `json:"paths" gorm:"-"`
+	// Nodes 各节点的预热进度
+	NodesJson string               `json:"-"     gorm:"column:nodes;type:text"`
+	Nodes     []DataLoadNodeStatus `json:"nodes" gorm:"-"`
+	// TotalFiles TotalBytes 单个节点需要预热的文件数与数据量，统计完成前为-1
+	TotalFiles int64     `json:"totalFiles"`
+	TotalBytes int64     `json:"totalBytes"`
+	Status     string    `json:"status"  gorm:"type:varchar(32);index"`
+	Message    string    `json:"message" gorm:"type:text"`
+	CreatedAt  time.Time `json:"createTime"`
+	UpdatedAt  time.Time `json:"updateTime"`
+}
+
+// DataLoadNodeStatus 单个节点上预热pod的状态及已读取的数据量
+type DataLoadNodeStatus struct {
+	NodeName    string `json:"nodeName"`
+	PodName     string `json:"podName"`
+	Phase       string `json:"phase"`
+	LoadedFiles int64  `json:"loadedFiles"`
+	LoadedBytes int64  `json:"loadedBytes"`
+}
+
+func (FSDataLoad) TableName() string {
+	return FsDataLoadTableName
+}
+
+// Progress 所有节点已读取数据量占总量的百分比，总量未知时返回0
+func (d *FSDataLoad) Progress() float64 {
+	if d.Status == DataLoadStatusSucceeded {
+		return 100
+	}
+	if d.TotalBytes <= 0 || len(d.Nodes) == 0 {
+		return 0
+	}
+	var loaded int64
+	for _, node := range d.Nodes {
+		loaded += node.LoadedBytes
+	}
+	progress := float64(loaded) * 100 / float64(d.TotalBytes*int64(len(d.Nodes)))
+	if progress > 100 {
+		progress = 100
+	}
+	return progress
+}
+
+func (d *FSDataLoad) AfterFind(*gorm.DB) error {
+	if d.PathsJson != "" {
+		if err := json.Unmarshal([]byte(d.PathsJson), &d.Paths); err != nil {
+			log.Errorf("json Unmarshal paths[%s] failed: %v", d.PathsJson, err)
+			return err
+		}
+	}
+	if d.NodesJson != "" {
+		if err := json.Unmarshal([]byte(d.NodesJson), &d.Nodes); err != nil {
+			log.Errorf("json Unmarshal nodes[%s] failed: %v", d.NodesJson, err)
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *FSDataLoad) BeforeSave(*gorm.DB) error {
+	if d.Paths != nil {
+		paths, err := json.Marshal(d.Paths)
+		if err != nil {
+			log.Errorf("json Marshal paths[%v] failed: %v", d.Paths, err)
+			return err
+		}
+		d.PathsJson = string(paths)
+	}
+	if d.Nodes != nil {
+		nodes, err := json.Marshal(d.Nodes)
+		if err != nil {
+			log.Errorf("json Marshal nodes[%v] failed: %v", d.Nodes, err)
+			return err
+		}
+		d.NodesJson = string(nodes)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"time"
+)
+
+const (
+	FsLifecycleRuleTableName = "fs_lifecycle_rule"
+
+	// LifecycleActionArchive 将超过天数未修改的文件转换为归档存储类型，仅对象存储支持
+	LifecycleActionArchive = "archive"
+	// LifecycleActionDelete 删除超过天数未修改的文件，常用于清理临时目录
+	LifecycleActionDelete = "delete"
+)
+
+// FsLifecycleRule 文件系统的生命周期规则，由后台FsLifecycleController定期执行。
+// 对象存储不记录访问时间，以文件的修改时间判断是否为冷数据
+type FsLifecycleRule struct {
+	Pk       int64  `json:"-"        gorm:"primaryKey;autoIncrement;not null"`
+	FsID     string `json:"-"        gorm:"type:varchar(200);uniqueIndex:idx_fs_lifecycle_rule;not null"`
+	Name     string `json:"name"     gorm:"type:varchar(200);uniqueIndex:idx_fs_lifecycle_rule;not null"`
+	FsName   string `json:"fsName"   gorm:"type:varchar(200)"`
+	UserName string `json:"userName" gorm:"type:varchar(60)"`
+	// Path 规则作用的目录，为文件系统内的绝对路径
+	Path         string `json:"path"                   gorm:"type:varchar(4096)"`
+	Action       string `json:"action"                 gorm:"type:varchar(32)"`
+	Days         int    `json:"days"`
+	StorageClass string `json:"storageClass,omitempty" gorm:"type:varchar(64)"`
+	// DryRun 为true时只统计命中的文件并生成报告，不执行转换或删除
+	DryRun bool `json:"dryRun"`
+	LifecycleReport
+	LastRunAt *time.Time `json:"lastRunAt,omitempty"`
+	Message   string     `json:"message" gorm:"type:text"`
+	CreatedAt time.Time  `json:"createTime"`
+	UpdatedAt time.Time  `json:"updateTime"`
+}
+
+// LifecycleReport 最近一次执行规则的结果，dry-run时Processed为0
+type LifecycleReport struct {
+	MatchedFiles   int64 `json:"matchedFiles"`
+	MatchedBytes   int64 `json:"matchedBytes"`
+	ProcessedFiles int64 `json:"processedFiles"`
+	ProcessedBytes int64 `json:"processedBytes"`
+}
+
+func (FsLifecycleRule) TableName() string {
+	return FsLifecycleRuleTableName
+}
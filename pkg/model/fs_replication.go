@@ -0,0 +1,61 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"time"
+)
+
+const (
+	FsReplicationTableName = "fs_replication"
+
+	// ReplicationStatusActive 后台定期将主存储中新增或变化的文件复制到副本存储
+	ReplicationStatusActive = "active"
+	// ReplicationStatusFailedOver 主存储与副本存储的存储地址已互换，暂停复制
+	ReplicationStatusFailedOver = "failedOver"
+)
+
+// FsReplication 文件系统的跨区域副本，副本为同一用户的另一个文件系统，通常指向其他区域的bucket
+type FsReplication struct {
+	Pk            int64  `json:"-"             gorm:"primaryKey;autoIncrement;not null"`
+	FsID          string `json:"-"             gorm:"type:varchar(200);uniqueIndex;not null"`
+	FsName        string `json:"fsName"        gorm:"type:varchar(200)"`
+	UserName      string `json:"userName"      gorm:"type:varchar(60)"`
+	ReplicaFsID   string `json:"-"             gorm:"type:varchar(200);uniqueIndex;not null"`
+	ReplicaFsName string `json:"replicaFsName" gorm:"type:varchar(200)"`
+	Status        string `json:"status"        gorm:"type:varchar(32)"`
+	ReplicationStats
+	// LastSyncedAt 该时间之前写入主存储的文件均已复制到副本
+	LastSyncedAt *time.Time `json:"lastSyncedAt,omitempty"`
+	Message      string     `json:"message" gorm:"type:text"`
+	CreatedAt    time.Time  `json:"createTime"`
+	UpdatedAt    time.Time  `json:"updateTime"`
+}
+
+// ReplicationStats 最近一轮复制后的延迟统计及累计复制量
+type ReplicationStats struct {
+	PendingFiles int64 `json:"pendingFiles"`
+	PendingBytes int64 `json:"pendingBytes"`
+	// LagSeconds 最早一个未复制文件的修改时间距今的秒数，没有未复制文件时为0
+	LagSeconds      int64 `json:"lagSeconds"`
+	ReplicatedFiles int64 `json:"replicatedFiles"`
+	ReplicatedBytes int64 `json:"replicatedBytes"`
+}
+
+func (FsReplication) TableName() string {
+	return FsReplicationTableName
+}
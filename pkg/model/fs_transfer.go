@@ -0,0 +1,94 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"time"
+)
+
+const (
+	FsTransferTableName = "fs_transfer"
+
+	TransferStatusPending   = "pending"
+	TransferStatusRunning   = "running"
+	TransferStatusSucceeded = "succeeded"
+	TransferStatusFailed    = "failed"
+	// TransferStatusTerminated 被用户停止，可以恢复
+	TransferStatusTerminated = "terminated"
+
+	// TransferModeCopy 只复制新增或变化的文件，TransferModeSync 还会删除目标路径下源路径中不存在的文件
+	TransferModeCopy = "copy"
+	TransferModeSync = "sync"
+)
+
+// FSTransfer 存储间数据迁移任务，由迁移pod同时挂载源存储与目标存储并复制数据
+type FSTransfer struct {
+	Pk          int64  `json:"-"           gorm:"primaryKey;autoIncrement;not null"`
+	ID          string `json:"id"          gorm:"type:varchar(60);uniqueIndex;not null"`
+	UserName    string `json:"userName"    gorm:"type:varchar(60);not null"`
+	SrcFsID     string `json:"-"           gorm:"type:varchar(200);index"`
+	SrcFsName   string `json:"srcFsName"   gorm:"type:varchar(200)"`
+	SrcPath     string `json:"srcPath"     gorm:"type:varchar(1024)"`
+	DstFsID     string `json:"-"           gorm:"type:varchar(200);index"`
+	DstFsName   string `json:"dstFsName"   gorm:"type:varchar(200)"`
+	DstPath     string `json:"dstPath"     gorm:"type:varchar(1024)"`
+	ClusterID   string `json:"-"           gorm:"type:varchar(60)"`
+	Namespace   string `json:"namespace"   gorm:"type:varchar(64)"`
+	Mode        string `json:"mode"        gorm:"type:varchar(16)"`
+	Parallelism int    `json:"parallelism"`
+	// BandwidthLimit 每秒传输的数据量上限，如100Mi，为空时不限制
+	BandwidthLimit string `json:"bandwidthLimit" gorm:"type:varchar(32)"`
+	// Checksum 根据校验和而不是大小与修改时间判断文件是否需要复制
+	Checksum bool `json:"checksum"`
+	// Attempt 每次恢复任务时递增，已复制且未变化的文件不会重复复制
+	Attempt int    `json:"attempt"`
+	PodName string `json:"podName" gorm:"type:varchar(128)"`
+	TransferStats
+	Status    string    `json:"status"  gorm:"type:varchar(32);index"`
+	Message   string    `json:"message" gorm:"type:text"`
+	CreatedAt time.Time `json:"createTime"`
+	UpdatedAt time.Time `json:"updateTime"`
+}
+
+// TransferStats 迁移pod当前一次执行的统计，TotalBytes随扫描进行增长
+type TransferStats struct {
+	TransferredFiles int64 `json:"transferredFiles"`
+	TransferredBytes int64 `json:"transferredBytes"`
+	TotalFiles       int64 `json:"totalFiles"`
+	TotalBytes       int64 `json:"totalBytes"`
+	CheckedFiles     int64 `json:"checkedFiles"`
+	Errors           int64 `json:"errors"`
+}
+
+func (FSTransfer) TableName() string {
+	return FsTransferTableName
+}
+
+// Progress 已传输数据量占总量的百分比，总量未知时返回0
+func (t *FSTransfer) Progress() float64 {
+	if t.Status == TransferStatusSucceeded {
+		return 100
+	}
+	if t.TotalBytes <= 0 {
+		return 0
+	}
+	progress := float64(t.TransferredBytes) * 100 / float64(t.TotalBytes)
+	if progress > 100 {
+		progress = 100
+	}
+	return progress
+}
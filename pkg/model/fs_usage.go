@@ -0,0 +1,43 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"time"
+)
+
+const (
+	FsUsageTableName = "fs_usage"
+	// FsUsageRootPath 整个文件系统的用量记录，其余记录为各一级目录的用量
+	FsUsageRootPath = "/"
+)
+
+// FsUsage 后台扫描得到的文件系统或其一级目录的用量，同一次扫描的记录ScanTime相同，保留历史记录用于计算增长
+type FsUsage struct {
+	Pk        int64     `json:"-"         gorm:"primaryKey;autoIncrement;not null"`
+	FsID      string    `json:"-"         gorm:"type:varchar(200);index:idx_fs_usage_scan;not null"`
+	Path      string    `json:"path"      gorm:"type:varchar(1024);not null"`
+	Size      int64     `json:"size"`
+	Files     int64     `json:"files"`
+	Dirs      int64     `json:"dirs"`
+	ScanTime  time.Time `json:"scanTime"  gorm:"index:idx_fs_usage_scan"`
+	CreatedAt time.Time `json:"-"`
+}
+
+func (FsUsage) TableName() string {
+	return FsUsageTableName
+}
@@ -0,0 +1,86 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const (
+	ImageBuildTableName = "image_build"
+
+	ImageBuildStatusPending    = "pending"
+	ImageBuildStatusRunning    = "running"
+	ImageBuildStatusSucceeded  = "succeeded"
+	ImageBuildStatusFailed     = "failed"
+	ImageBuildStatusTerminated = "terminated"
+)
+
+// ImageBuild 镜像构建任务，在队列所在集群中运行构建pod，由Dockerfile或基础镜像加pip依赖构建镜像并推送到仓库
+type ImageBuild struct {
+	Pk        int64  `json:"-"         gorm:"primaryKey;autoIncrement;not null"`
+	ID        string `json:"id"        gorm:"type:varchar(60);uniqueIndex;not null"`
+	UserName  string `json:"userName"  gorm:"type:varchar(60);not null"`
+	Name      string `json:"name"      gorm:"type:varchar(128)"`
+	Tag       string `json:"tag"       gorm:"type:varchar(128)"`
+	QueueName string `json:"queueName" gorm:"type:varchar(255)"`
+	ClusterID string `json:"-"         gorm:"type:varchar(60)"`
+	Namespace string `json:"namespace" gorm:"type:varchar(64)"`
+	// Dockerfile 与BaseImage二选一，指定BaseImage时由服务端生成Dockerfile
+	Dockerfile string `json:"dockerfile" gorm:"type:text"`
+	BaseImage  string `json:"baseImage"  gorm:"type:varchar(512)"`
+	// Requirements pip依赖，写入构建上下文中的requirements.txt
+	RequirementsJson string   `json:"-"            gorm:"column:requirements;type:text"`
+	Requirements     []string `json:"requirements" gorm:"-"`
+	// Image 构建完成后推送的镜像地址，可直接用于作业的image
+	Image     string    `json:"image"   gorm:"type:varchar(512)"`
+	PodName   string    `json:"-"       gorm:"type:varchar(128)"`
+	Status    string    `json:"status"  gorm:"type:varchar(32);index"`
+	Message   string    `json:"message" gorm:"type:text"`
+	CreatedAt time.Time `json:"createTime"`
+	UpdatedAt time.Time `json:"updateTime"`
+}
+
+func (ImageBuild) TableName() string {
+	return ImageBuildTableName
+}
+
+func (b *ImageBuild) AfterFind(*gorm.DB) error {
+	if b.RequirementsJson != "" {
+		if err := json.Unmarshal([]byte(b.RequirementsJson), &b.Requirements); err != nil {
+			log.Errorf("json Unmarshal requirements[%s] failed: %v", b.RequirementsJson, err)
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *ImageBuild) BeforeSave(*gorm.DB) error {
+	if b.Requirements != nil {
+		requirements, err := json.Marshal(b.Requirements)
+		if err != nil {
+			log.Errorf("json Marshal requirements[%v] failed: %v", b.Requirements, err)
+			return err
+		}
+		b.RequirementsJson = string(requirements)
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"time"
+)
+
+// ImpersonationAudit root以其他用户身份发起的请求的审计记录
+type ImpersonationAudit struct {
+	Pk        int64  `json:"-" gorm:"primaryKey;autoIncrement"`
+	RequestID string `json:"requestID" gorm:"type:varchar(60);default:''"`
+	// Operator 实际发起请求的管理员，UserName 被模拟的用户
+	Operator   string    `json:"operator" gorm:"type:varchar(60);index"`
+	UserName   string    `json:"userName" gorm:"type:varchar(60);index"`
+	Method     string    `json:"method" gorm:"type:varchar(16)"`
+	Path       string    `json:"path" gorm:"type:varchar(1024)"`
+	StatusCode int       `json:"statusCode"`
+	ClientIP   string    `json:"clientIP" gorm:"type:varchar(64);default:''"`
+	CreatedAt  time.Time `json:"createTime"`
+}
+
+func (ImpersonationAudit) TableName() string {
+	return "impersonation_audit"
+}
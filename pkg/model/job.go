@@ -50,6 +50,13 @@ type Job struct {
 	Members           []schema.Member     `json:"members" gorm:"-"`
 	ExtensionTemplate string              `json:"-" gorm:"type:text"`
 	ParentJob         string              `json:"-" gorm:"type:varchar(60)"`
+	RunID             string              `json:"runID,omitempty" gorm:"type:varchar(60);index:idx_run_id;default:''"`
+	StepName          string              `json:"stepName,omitempty" gorm:"type:varchar(512);default:''"`
+	ConcurrencyGroup  string              `json:"-" gorm:"type:varchar(255);index:idx_concurrency_group;default:''"`
+	RequeueTimes      int                 `json:"requeueTimes,omitempty" gorm:"default:0"`
+	RequeueReason     string              `json:"requeueReason,omitempty" gorm:"type:varchar(1024);default:''"`
+	Requeuing         bool                `json:"-" gorm:"default:false"`
+	BurstFromQueue    string              `json:"burstFromQueue,omitempty" gorm:"type:varchar(255);default:''"`
 	CreatedAt         time.Time           `json:"createTime"`
 	ActivatedAt       sql.NullTime        `json:"activateTime"`
 	UpdatedAt         time.Time           `json:"updateTime,omitempty"`
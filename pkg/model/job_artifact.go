@@ -0,0 +1,79 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const (
+	JobArtifactPending   = "pending"
+	JobArtifactCollected = "collected"
+	JobArtifactFailed    = "failed"
+	// JobArtifactSkipped 作业未成功结束，不收集产物
+	JobArtifactSkipped = "skipped"
+)
+
+// JobArtifact 作业声明的输出产物，作业创建时以pending状态记录，作业结束后收集其文件清单
+type JobArtifact struct {
+	Pk           int64         `json:"-"            gorm:"primaryKey;autoIncrement;not null"`
+	JobID        string        `json:"jobID"        gorm:"type:varchar(60);uniqueIndex:idx_job_artifact;not null"`
+	Name         string        `json:"name"         gorm:"type:varchar(128);uniqueIndex:idx_job_artifact;not null"`
+	FsID         string        `json:"-"            gorm:"type:varchar(200);not null"`
+	FsName       string        `json:"fsName"       gorm:"type:varchar(200);not null"`
+	Path         string        `json:"path"         gorm:"type:varchar(1024);not null"` // 产物在存储中的路径
+	Status       string        `json:"status"       gorm:"type:varchar(32);index:idx_job_artifact_status;not null"`
+	Digest       string        `json:"digest"       gorm:"type:varchar(64)"`
+	FileCount    int64         `json:"fileCount"`
+	TotalSize    int64         `json:"totalSize"`
+	Message      string        `json:"message"      gorm:"type:text"`
+	Manifest     []DatasetFile `json:"manifest,omitempty" gorm:"-"`
+	ManifestJson string        `json:"-"            gorm:"column:manifest;type:longtext"`
+	CreatedAt    time.Time     `json:"createTime"`
+	UpdatedAt    time.Time     `json:"updateTime"`
+}
+
+func (JobArtifact) TableName() string {
+	return "job_artifact"
+}
+
+func (ja *JobArtifact) BeforeSave(tx *gorm.DB) error {
+	if len(ja.Manifest) != 0 {
+		manifestJson, err := json.Marshal(ja.Manifest)
+		if err != nil {
+			return err
+		}
+		ja.ManifestJson = string(manifestJson)
+	}
+	return nil
+}
+
+func (ja *JobArtifact) AfterFind(tx *gorm.DB) error {
+	if len(ja.ManifestJson) > 0 {
+		var manifest []DatasetFile
+		if err := json.Unmarshal([]byte(ja.ManifestJson), &manifest); err != nil {
+			log.Errorf("job[%s] artifact[%s] json unmarshal manifest failed, error: %s", ja.JobID, ja.Name, err.Error())
+			return err
+		}
+		ja.Manifest = manifest
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"time"
+)
+
+// JobMetric 从作业日志中以PF_METRIC开头的行解析出的指标，用于绘制训练曲线
+type JobMetric struct {
+	Pk        int64     `json:"-"         gorm:"primaryKey;autoIncrement;not null"`
+	JobID     string    `json:"jobID"     gorm:"type:varchar(60);not null;index:idx_job_metric"`
+	TaskID    string    `json:"taskID"    gorm:"type:varchar(255);not null;index:idx_job_metric"`
+	Key       string    `json:"key"       gorm:"type:varchar(256);not null"`
+	Value     float64   `json:"value"     gorm:"not null"`
+	Step      int64     `json:"step"      gorm:"not null;default:0"`
+	Timestamp int64     `json:"timestamp" gorm:"not null;default:0"` // 日志行的时间，纳秒
+	CreatedAt time.Time `json:"-"`
+}
+
+func (JobMetric) TableName() string {
+	return "job_metric"
+}
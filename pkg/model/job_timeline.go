@@ -0,0 +1,37 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import "time"
+
+const (
+	// TimelineScaleTriggered 作业因资源不足等待，已通知集群扩容
+	TimelineScaleTriggered = "ScaleTriggered"
+)
+
+// JobTimelineEvent 作业时间线上的事件，记录PaddleFlow对作业执行的非状态变化操作
+type JobTimelineEvent struct {
+	Pk        int64     `json:"-"          gorm:"primaryKey;autoIncrement"`
+	JobID     string    `json:"jobID"      gorm:"type:varchar(60);index:idx_job_timeline_job;not null"`
+	Type      string    `json:"type"       gorm:"type:varchar(32);not null"`
+	Message   string    `json:"message"    gorm:"type:text"`
+	CreatedAt time.Time `json:"createTime"`
+}
+
+func (JobTimelineEvent) TableName() string {
+	return "job_timeline"
+}
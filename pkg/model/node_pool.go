@@ -0,0 +1,78 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+)
+
+// NodePool 集群中一组打了相同标签的节点，队列绑定节点池后作业只运行在节点池的节点上
+type NodePool struct {
+	Pk          int64     `json:"-"           gorm:"primaryKey;autoIncrement"`
+	ID          string    `json:"id"          gorm:"type:varchar(60);uniqueIndex"`
+	Name        string    `json:"name"        gorm:"type:varchar(64);uniqueIndex:idx_node_pool_name"`
+	ClusterID   string    `json:"clusterId"   gorm:"type:varchar(60);uniqueIndex:idx_node_pool_name"`
+	GPUType     string    `json:"gpuType"     gorm:"column:gpu_type;type:varchar(64)"`
+	Zone        string    `json:"zone"        gorm:"type:varchar(64)"`
+	Team        string    `json:"team"        gorm:"type:varchar(64)"`
+	Description string    `json:"description" gorm:"type:varchar(1024)"`
+	RawNodes    string    `json:"-"           gorm:"column:nodes;type:text"`
+	Nodes       []string  `json:"nodes"       gorm:"-"`
+	CreatedAt   time.Time `json:"createTime"`
+	UpdatedAt   time.Time `json:"updateTime"`
+}
+
+func (NodePool) TableName() string {
+	return "node_pool"
+}
+
+// Labels 节点池的节点需要的标签
+func (pool *NodePool) Labels() map[string]string {
+	labels := map[string]string{schema.NodePoolLabel: pool.Name}
+	if pool.GPUType != "" {
+		labels[schema.NodePoolGPUTypeLabel] = pool.GPUType
+	}
+	if pool.Zone != "" {
+		labels[schema.NodePoolZoneLabel] = pool.Zone
+	}
+	if pool.Team != "" {
+		labels[schema.NodePoolTeamLabel] = pool.Team
+	}
+	return labels
+}
+
+func (pool *NodePool) BeforeSave(*gorm.DB) error {
+	nodes, err := json.Marshal(pool.Nodes)
+	if err != nil {
+		return err
+	}
+	pool.RawNodes = string(nodes)
+	return nil
+}
+
+func (pool *NodePool) AfterFind(*gorm.DB) error {
+	pool.Nodes = []string{}
+	if pool.RawNodes == "" {
+		return nil
+	}
+	return json.Unmarshal([]byte(pool.RawNodes), &pool.Nodes)
+}
@@ -0,0 +1,85 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/resources"
+)
+
+const (
+	ProjectRoleAdmin  = "admin"
+	ProjectRoleMember = "member"
+)
+
+// Project 项目（租户），将用户、队列、存储和工作流组织在一起，由项目管理员管理
+type Project struct {
+	Pk              int64               `json:"-" gorm:"primaryKey;autoIncrement"`
+	Name            string              `json:"name" gorm:"type:varchar(255);uniqueIndex"`
+	Description     string              `json:"description" gorm:"type:varchar(1024);default:''"`
+	RawMaxResources string              `json:"-" gorm:"column:max_resources;type:text"`
+	MaxResources    *resources.Resource `json:"maxResources,omitempty" gorm:"-"`
+	CreatedAt       time.Time           `json:"createTime"`
+	UpdatedAt       time.Time           `json:"updateTime"`
+}
+
+func (Project) TableName() string {
+	return "project"
+}
+
+func (p *Project) AfterFind(*gorm.DB) error {
+	if p.RawMaxResources != "" {
+		p.MaxResources = resources.EmptyResource()
+		if err := json.Unmarshal([]byte(p.RawMaxResources), p.MaxResources); err != nil {
+			log.Errorf("json Unmarshal MaxResources[%s] failed: %v", p.RawMaxResources, err)
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *Project) BeforeSave(*gorm.DB) error {
+	p.RawMaxResources = ""
+	if p.MaxResources != nil && len(p.MaxResources.Resources) != 0 {
+		maxResourcesJson, err := json.Marshal(p.MaxResources)
+		if err != nil {
+			log.Errorf("json Marshal MaxResources[%v] failed: %v", p.MaxResources, err)
+			return err
+		}
+		p.RawMaxResources = string(maxResourcesJson)
+	}
+	return nil
+}
+
+// ProjectMember 项目成员，ResourceType 为 user/queue/fs/pipeline，Role 仅对用户有效
+type ProjectMember struct {
+	Pk           int64     `json:"-" gorm:"primaryKey;autoIncrement"`
+	ProjectName  string    `json:"projectName" gorm:"type:varchar(255);uniqueIndex:idx_project_member"`
+	ResourceType string    `json:"resourceType" gorm:"type:varchar(36);uniqueIndex:idx_project_member"`
+	ResourceID   string    `json:"resourceID" gorm:"type:varchar(255);uniqueIndex:idx_project_member"`
+	Role         string    `json:"role,omitempty" gorm:"type:varchar(36);default:''"`
+	CreatedAt    time.Time `json:"createTime"`
+}
+
+func (ProjectMember) TableName() string {
+	return "project_member"
+}
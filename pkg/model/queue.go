@@ -18,11 +18,13 @@ package model
 
 import (
 	"encoding/json"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/resources"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
 )
 
 type Queue struct {
@@ -44,6 +46,17 @@ type Queue struct {
 	SchedulingPolicy    []string       `json:"schedulingPolicy,omitempty" gorm:"-"`
 	Status              string         `json:"status"`
 	DeletedAt           gorm.DeletedAt `json:"-" gorm:"index"`
+	// 等待中作业的优先级提升策略
+	RawPriorityAging string         `json:"-" gorm:"column:priority_aging;type:varchar(255)"`
+	PriorityAging    *PriorityAging `json:"priorityAging,omitempty" gorm:"-"`
+	// 集群资源不足时等待中作业的溢出策略
+	RawBurstPolicy string       `json:"-" gorm:"column:burst_policy;type:text"`
+	BurstPolicy    *BurstPolicy `json:"burstPolicy,omitempty" gorm:"-"`
+	// 绑定的节点池，队列的作业只运行在节点池的节点上
+	NodePool string `json:"nodePool,omitempty" gorm:"column:node_pool;type:varchar(64);default:''"`
+	// 按时间段调整的队列容量
+	RawCapacitySchedule string            `json:"-" gorm:"column:capacity_schedule;type:text"`
+	CapacitySchedule    *CapacitySchedule `json:"capacitySchedule,omitempty" gorm:"-"`
 
 	UsedResources *resources.Resource `json:"usedResources,omitempty" gorm:"-"`
 	IdleResources *resources.Resource `json:"idleResources,omitempty" gorm:"-"`
@@ -98,6 +111,36 @@ func (queue *Queue) AfterFind(*gorm.DB) error {
 			return err
 		}
 	}
+	if queue.RawPriorityAging != "" {
+		priorityAging := &PriorityAging{}
+		if err := json.Unmarshal([]byte(queue.RawPriorityAging), priorityAging); err != nil {
+			log.Errorf("json Unmarshal PriorityAging[%s] failed: %v", queue.RawPriorityAging, err)
+			return err
+		}
+		if priorityAging.Interval > 0 {
+			queue.PriorityAging = priorityAging
+		}
+	}
+	if queue.RawBurstPolicy != "" {
+		burstPolicy := &BurstPolicy{}
+		if err := json.Unmarshal([]byte(queue.RawBurstPolicy), burstPolicy); err != nil {
+			log.Errorf("json Unmarshal BurstPolicy[%s] failed: %v", queue.RawBurstPolicy, err)
+			return err
+		}
+		if burstPolicy.Queue != "" {
+			queue.BurstPolicy = burstPolicy
+		}
+	}
+	if queue.RawCapacitySchedule != "" {
+		capacitySchedule := &CapacitySchedule{}
+		if err := json.Unmarshal([]byte(queue.RawCapacitySchedule), capacitySchedule); err != nil {
+			log.Errorf("json Unmarshal CapacitySchedule[%s] failed: %v", queue.RawCapacitySchedule, err)
+			return err
+		}
+		if len(capacitySchedule.Windows) != 0 {
+			queue.CapacitySchedule = capacitySchedule
+		}
+	}
 	return nil
 }
 
@@ -140,7 +183,120 @@ func (queue *Queue) BeforeSave(*gorm.DB) error {
 		}
 		queue.RawSchedulingPolicy = string(schedulingPolicyJson)
 	}
+	if queue.PriorityAging != nil {
+		priorityAgingJson, err := json.Marshal(queue.PriorityAging)
+		if err != nil {
+			log.Errorf("json Marshal PriorityAging[%v] failed: %v", queue.PriorityAging, err)
+			return err
+		}
+		queue.RawPriorityAging = string(priorityAgingJson)
+	}
+	if queue.BurstPolicy != nil {
+		burstPolicyJson, err := json.Marshal(queue.BurstPolicy)
+		if err != nil {
+			log.Errorf("json Marshal BurstPolicy[%v] failed: %v", queue.BurstPolicy, err)
+			return err
+		}
+		queue.RawBurstPolicy = string(burstPolicyJson)
+	}
+	if queue.CapacitySchedule != nil {
+		capacityScheduleJson, err := json.Marshal(queue.CapacitySchedule)
+		if err != nil {
+			log.Errorf("json Marshal CapacitySchedule[%v] failed: %v", queue.CapacitySchedule, err)
+			return err
+		}
+		queue.RawCapacitySchedule = string(capacityScheduleJson)
+	}
 	log.Debugf("queue[%s] BeforeSave finished, queue:%#v", queue.Name, queue)
 
 	return nil
-}
\ No newline at end of file
+}
+
+// PriorityAging 等待中的作业按等待时间逐级提升优先级，防止低优先级作业一直得不到调度
+type PriorityAging struct {
+	// Interval 作业每等待Interval秒提升一级优先级，为0表示不提升
+	Interval int64 `json:"interval"`
+	// MaxPriority 提升后的最高优先级，默认为HIGH
+	MaxPriority string `json:"maxPriority,omitempty"`
+}
+
+// DefaultBurstWaitSeconds 作业默认等待5分钟后溢出
+const DefaultBurstWaitSeconds = 300
+
+// BurstPolicy 本地集群资源不足时，等待调度的作业溢出到其他集群（如公有云集群）的队列，
+// 溢出前调用数据预置钩子同步作业所需的存储路径
+type BurstPolicy struct {
+	// Queue 溢出的目标队列，必须属于其他集群，为空表示不溢出
+	Queue string `json:"queue"`
+	// WaitSeconds 作业等待超过WaitSeconds秒仍未调度时溢出，默认300
+	WaitSeconds int64 `json:"waitSeconds,omitempty"`
+	// Labels 只有包含全部标签的作业才允许溢出，为空表示所有作业
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// GetWaitTime 返回作业溢出前的等待时间
+func (bp *BurstPolicy) GetWaitTime() time.Duration {
+	if bp.WaitSeconds <= 0 {
+		return DefaultBurstWaitSeconds * time.Second
+	}
+	return time.Duration(bp.WaitSeconds) * time.Second
+}
+
+// IsEligible 作业是否包含溢出策略要求的全部标签
+func (bp *BurstPolicy) IsEligible(labels map[string]string) bool {
+	for k, v := range bp.Labels {
+		if labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// jobPriorities 作业优先级从低到高排列
+var jobPriorities = []string{
+	schema.EnvJobVeryLowPriority,
+	schema.EnvJobLowPriority,
+	schema.EnvJobNormalPriority,
+	schema.EnvJobHighPriority,
+	schema.EnvJobVeryHighPriority,
+}
+
+// JobPriorityLevel 返回优先级的等级，越大越优先，无法识别的优先级按NORMAL处理
+func JobPriorityLevel(priority string) int {
+	for level, p := range jobPriorities {
+		if p == priority {
+			return level
+		}
+	}
+	return JobPriorityLevel(schema.EnvJobNormalPriority)
+}
+
+// IsValidJobPriority 是否为合法的作业优先级
+func IsValidJobPriority(priority string) bool {
+	for _, p := range jobPriorities {
+		if p == priority {
+			return true
+		}
+	}
+	return false
+}
+
+// EffectivePriority 返回作业等待waitingTime后的优先级，不会低于原优先级
+func (pa *PriorityAging) EffectivePriority(priority string, waitingTime time.Duration) string {
+	if pa == nil || pa.Interval <= 0 || waitingTime <= 0 {
+		return priority
+	}
+	maxPriority := pa.MaxPriority
+	if maxPriority == "" {
+		maxPriority = schema.EnvJobHighPriority
+	}
+	level, maxLevel := JobPriorityLevel(priority), JobPriorityLevel(maxPriority)
+	if level >= maxLevel {
+		return priority
+	}
+	level += int(waitingTime / (time.Duration(pa.Interval) * time.Second))
+	if level > maxLevel {
+		level = maxLevel
+	}
+	return jobPriorities[level]
+}
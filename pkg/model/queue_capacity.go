@@ -0,0 +1,175 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/resources"
+)
+
+const capacityClockFormat = "15:04"
+
+// CapacitySchedule 队列按时间段调整容量，如夜间64卡、白天16卡，便于研究和生产共享GPU资源池。
+// 不在任何时间段内时使用队列的基础容量
+type CapacitySchedule struct {
+	// Timezone 时间段所在的时区，如Asia/Shanghai，默认为服务所在时区
+	Timezone string `json:"timezone,omitempty"`
+	// Windows 按顺序匹配，多个时间段重叠时使用第一个
+	Windows []CapacityWindow `json:"windows"`
+}
+
+// CapacityWindow 一个时间段内队列的容量
+type CapacityWindow struct {
+	Name string `json:"name,omitempty"`
+	// Start 和 End 格式为HH:MM，End不大于Start时表示跨越零点，如22:00-08:00
+	Start string `json:"start"`
+	End   string `json:"end"`
+	// Weekdays 生效的星期，如Mon、Sat，按时间段开始的日期判断，为空表示每天
+	Weekdays     []string            `json:"weekdays,omitempty"`
+	MaxResources *resources.Resource `json:"maxResources"`
+	// MinResources 为空时使用队列的基础minResources
+	MinResources *resources.Resource `json:"minResources,omitempty"`
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// Validate 检查时区、时间段格式以及时间段内的资源
+func (cs *CapacitySchedule) Validate() error {
+	if _, err := time.LoadLocation(cs.Timezone); err != nil {
+		return fmt.Errorf("timezone %s of capacity schedule is invalid", cs.Timezone)
+	}
+	for _, window := range cs.Windows {
+		if _, err := time.Parse(capacityClockFormat, window.Start); err != nil {
+			return fmt.Errorf("start %s of capacity window %s is invalid, HH:MM is required", window.Start, window.Name)
+		}
+		if _, err := time.Parse(capacityClockFormat, window.End); err != nil {
+			return fmt.Errorf("end %s of capacity window %s is invalid, HH:MM is required", window.End, window.Name)
+		}
+		for _, day := range window.Weekdays {
+			if _, ok := weekdays[strings.ToLower(day)]; !ok {
+				return fmt.Errorf("weekday %s of capacity window %s is invalid", day, window.Name)
+			}
+		}
+		if window.MaxResources == nil || window.MaxResources.IsNegative() {
+			return fmt.Errorf("maxResources of capacity window %s is required and must not be negative", window.Name)
+		}
+		if window.MinResources != nil {
+			if window.MinResources.IsNegative() {
+				return fmt.Errorf("minResources of capacity window %s must not be negative", window.Name)
+			}
+			if !window.MinResources.LessEqual(window.MaxResources) {
+				return fmt.Errorf("minResources of capacity window %s is larger than maxResources", window.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// ActiveWindow 返回now所在的时间段，不在任何时间段内时返回nil
+func (cs *CapacitySchedule) ActiveWindow(now time.Time) *CapacityWindow {
+	if location, err := time.LoadLocation(cs.Timezone); err == nil {
+		now = now.In(location)
+	}
+	for i := range cs.Windows {
+		if cs.Windows[i].contains(now) {
+			return &cs.Windows[i]
+		}
+	}
+	return nil
+}
+
+func (w *CapacityWindow) contains(now time.Time) bool {
+	start, err := time.Parse(capacityClockFormat, w.Start)
+	if err != nil {
+		return false
+	}
+	end, err := time.Parse(capacityClockFormat, w.End)
+	if err != nil {
+		return false
+	}
+	startMinute := start.Hour()*60 + start.Minute()
+	endMinute := end.Hour()*60 + end.Minute()
+	minute := now.Hour()*60 + now.Minute()
+
+	day := now.Weekday()
+	if startMinute < endMinute {
+		if minute < startMinute || minute >= endMinute {
+			return false
+		}
+	} else if minute < endMinute {
+		// 跨越零点的时间段，按开始的日期判断星期
+		day = now.AddDate(0, 0, -1).Weekday()
+	} else if minute < startMinute {
+		return false
+	}
+
+	if len(w.Weekdays) == 0 {
+		return true
+	}
+	for _, weekday := range w.Weekdays {
+		if weekdays[strings.ToLower(weekday)] == day {
+			return true
+		}
+	}
+	return false
+}
+
+// EffectiveResources 返回队列在now时刻的容量，不在任何时间段内时返回队列的基础容量
+func (queue *Queue) EffectiveResources(now time.Time) (*resources.Resource, *resources.Resource) {
+	if queue.CapacitySchedule == nil {
+		return queue.MaxResources, queue.MinResources
+	}
+	window := queue.CapacitySchedule.ActiveWindow(now)
+	if window == nil {
+		return queue.MaxResources, queue.MinResources
+	}
+	if window.MinResources != nil {
+		return window.MaxResources, window.MinResources
+	}
+	return window.MaxResources, queue.MinResources
+}
+
+// PeakMaxResources 返回队列基础容量和各时间段容量中每种资源的最大值，
+// 超出当前容量但不超出峰值的作业可以提交，等待容量较大的时间段调度
+func (queue *Queue) PeakMaxResources() *resources.Resource {
+	if queue.MaxResources == nil || queue.CapacitySchedule == nil {
+		return queue.MaxResources
+	}
+	peak := queue.MaxResources.Clone()
+	for _, window := range queue.CapacitySchedule.Windows {
+		if window.MaxResources == nil {
+			continue
+		}
+		for name, quantity := range window.MaxResources.Resources {
+			if quantity > peak.Resources[name] {
+				peak.Resources[name] = quantity
+			}
+		}
+	}
+	return peak
+}
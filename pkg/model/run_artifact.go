@@ -19,6 +19,7 @@ type ArtifactEvent struct {
 	Type         string         `json:"type"                 gorm:"type:varchar(16);not null"`
 	ArtifactName string         `json:"artifactName"         gorm:"type:varchar(32);not null"`
 	Meta         string         `json:"meta"                 gorm:"type:text;size:65535"`
+	Pruned       bool           `json:"pruned"               gorm:"default:false;not null"` // 产物文件已按保留策略清理
 	CreateTime   string         `json:"createTime"           gorm:"-"`
 	UpdateTime   string         `json:"updateTime,omitempty" gorm:"-"`
 	CreatedAt    time.Time      `json:"-"`
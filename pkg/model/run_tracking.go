@@ -0,0 +1,58 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"time"
+)
+
+const (
+	RunParamTypeParam = "param"
+	RunParamTypeTag   = "tag"
+)
+
+// RunMetric 作业运行过程中上报的指标，同一个key可以按step上报多次
+type RunMetric struct {
+	Pk        int64     `json:"-"         gorm:"primaryKey;autoIncrement;not null"`
+	RunID     string    `json:"runID"     gorm:"type:varchar(60);not null;index"`
+	JobID     string    `json:"jobID"     gorm:"type:varchar(60)"`
+	Key       string    `json:"key"       gorm:"type:varchar(256);not null"`
+	Value     float64   `json:"value"     gorm:"not null"`
+	Step      int64     `json:"step"      gorm:"not null;default:0"`
+	Timestamp int64     `json:"timestamp" gorm:"not null;default:0"` // 毫秒
+	CreatedAt time.Time `json:"-"`
+}
+
+func (RunMetric) TableName() string {
+	return "run_metric"
+}
+
+// RunParam 作业上报的参数(param)与标签(tag)，同一个run内key唯一，重复上报以最后一次为准
+type RunParam struct {
+	Pk        int64     `json:"-"     gorm:"primaryKey;autoIncrement;not null"`
+	RunID     string    `json:"runID" gorm:"type:varchar(60);not null;index"`
+	JobID     string    `json:"jobID" gorm:"type:varchar(60)"`
+	Type      string    `json:"type"  gorm:"type:varchar(16);not null"`
+	Key       string    `json:"key"   gorm:"type:varchar(256);not null"`
+	Value     string    `json:"value" gorm:"type:text;size:65535"`
+	CreatedAt time.Time `json:"-"`
+	UpdatedAt time.Time `json:"-"`
+}
+
+func (RunParam) TableName() string {
+	return "run_param"
+}
@@ -0,0 +1,77 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"time"
+)
+
+// 以下为统计汇总任务按小时写入的汇总表，Bucket为小时的起始时间，统计接口只读汇总表而不扫描job表
+
+// QueueHourlyStat 队列每小时的作业状态及资源用量
+type QueueHourlyStat struct {
+	Pk        int64     `json:"-" gorm:"primaryKey;autoIncrement"`
+	Bucket    time.Time `json:"time" gorm:"uniqueIndex:idx_queue_bucket"`
+	QueueName string    `json:"queueName" gorm:"type:varchar(255);uniqueIndex:idx_queue_bucket"`
+	// PendingJobs/RunningJobs 为小时结束时处于排队/运行中的作业数
+	PendingJobs int `json:"pendingJobs"`
+	RunningJobs int `json:"runningJobs"`
+	// 以下为该小时内结束的作业数
+	SucceededJobs  int `json:"succeededJobs"`
+	FailedJobs     int `json:"failedJobs"`
+	TerminatedJobs int `json:"terminatedJobs"`
+	// GPUHours/CPUHours 作业申请的资源乘以该小时内的运行时长
+	GPUHours float64 `json:"gpuHours"`
+	CPUHours float64 `json:"cpuHours"`
+	// CapacityGPUs/CapacityCPU 汇总时队列的最大资源
+	CapacityGPUs float64   `json:"capacityGPUs" gorm:"column:capacity_gpus"`
+	CapacityCPU  float64   `json:"capacityCPU"`
+	CreatedAt    time.Time `json:"-"`
+}
+
+func (QueueHourlyStat) TableName() string {
+	return "stat_queue_hourly"
+}
+
+// UserHourlyStat 用户每小时使用的GPU卡时
+type UserHourlyStat struct {
+	Pk       int64     `json:"-" gorm:"primaryKey;autoIncrement"`
+	Bucket   time.Time `json:"time" gorm:"uniqueIndex:idx_user_bucket"`
+	UserName string    `json:"userName" gorm:"type:varchar(60);uniqueIndex:idx_user_bucket"`
+	GPUHours float64   `json:"gpuHours"`
+	// JobCount 该小时内运行过的作业数
+	JobCount  int       `json:"jobCount"`
+	CreatedAt time.Time `json:"-"`
+}
+
+func (UserHourlyStat) TableName() string {
+	return "stat_user_hourly"
+}
+
+// ImageHourlyStat 镜像每小时结束的作业数及失败数
+type ImageHourlyStat struct {
+	Pk           int64     `json:"-" gorm:"primaryKey;autoIncrement"`
+	Bucket       time.Time `json:"time" gorm:"uniqueIndex:idx_image_bucket"`
+	Image        string    `json:"image" gorm:"type:varchar(255);uniqueIndex:idx_image_bucket"`
+	FinishedJobs int       `json:"finishedJobs"`
+	FailedJobs   int       `json:"failedJobs"`
+	CreatedAt    time.Time `json:"-"`
+}
+
+func (ImageHourlyStat) TableName() string {
+	return "stat_image_hourly"
+}
@@ -33,6 +33,14 @@ type User struct {
 	UpdatedAt time.Time      `json:"-"`
 	DeletedAt gorm.DeletedAt `json:"-"`
 	UserInfo  `gorm:"embedded"`
+	Email     string `json:"email,omitempty" gorm:"column:email;default:''"`
+
+	// 登录失败锁定、密码轮换及重置相关的状态
+	FailedLogins       int        `json:"-"                            gorm:"column:failed_logins;default:0"`
+	LockedUntil        *time.Time `json:"lockedUntil,omitempty"        gorm:"column:locked_until"`
+	PasswordUpdatedAt  *time.Time `json:"passwordUpdateTime,omitempty" gorm:"column:password_updated_at"`
+	ResetTokenHash     string     `json:"-"                            gorm:"column:reset_token_hash;default:''"`
+	ResetTokenExpireAt *time.Time `json:"-"                            gorm:"column:reset_token_expire_at"`
 }
 
 func (User) TableName() string {
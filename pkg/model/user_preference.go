@@ -0,0 +1,37 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"time"
+)
+
+// UserPreference 用户创建作业时的默认设置，请求中未填写的字段使用这里的值
+type UserPreference struct {
+	Pk         int64     `json:"-" gorm:"primaryKey;autoIncrement"`
+	UserName   string    `json:"userName" gorm:"type:varchar(60);uniqueIndex"`
+	Queue      string    `json:"queue" gorm:"type:varchar(255);default:''"`
+	Flavour    string    `json:"flavour" gorm:"type:varchar(255);default:''"`
+	Image      string    `json:"image" gorm:"type:varchar(512);default:''"`
+	FileSystem string    `json:"fs" gorm:"column:fs;type:varchar(255);default:''"`
+	CreatedAt  time.Time `json:"createTime"`
+	UpdatedAt  time.Time `json:"updateTime"`
+}
+
+func (UserPreference) TableName() string {
+	return "user_preference"
+}
@@ -0,0 +1,100 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/resources"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+)
+
+// UserQuota 用户级别的作业限制，与队列容量无关，字段为0表示不限制
+type UserQuota struct {
+	Pk                int64  `json:"-" gorm:"primaryKey;autoIncrement"`
+	UserName          string `json:"userName" gorm:"type:varchar(60);uniqueIndex"`
+	MaxConcurrentJobs int    `json:"maxConcurrentJobs" gorm:"default:0"`
+	MaxGPUs           int64  `json:"maxGPUs" gorm:"column:max_gpus;default:0"`
+	// MaxJobDuration 作业最长运行时间，单位秒，超时的作业会被停止
+	MaxJobDuration int64     `json:"maxJobDuration" gorm:"default:0"`
+	CreatedAt      time.Time `json:"createTime"`
+	UpdatedAt      time.Time `json:"updateTime"`
+}
+
+func (UserQuota) TableName() string {
+	return "user_quota"
+}
+
+// CheckJob 检查作业本身是否超出用户配额，超出的作业永远无法运行
+func (q *UserQuota) CheckJob(job *Job) error {
+	if q.MaxGPUs > 0 {
+		if gpus := JobGPUs(job); gpus > q.MaxGPUs {
+			return fmt.Errorf("job requests %d gpus, exceeds max gpus %d of user[%s]", gpus, q.MaxGPUs, q.UserName)
+		}
+	}
+	return nil
+}
+
+// CheckDispatch 检查用户已提交到集群的作业加上当前作业是否超出配额
+func (q *UserQuota) CheckDispatch(job *Job, activeJobs []Job) error {
+	if q.MaxConcurrentJobs > 0 && len(activeJobs) >= q.MaxConcurrentJobs {
+		return fmt.Errorf("user[%s] already has %d active jobs, max concurrent jobs is %d",
+			q.UserName, len(activeJobs), q.MaxConcurrentJobs)
+	}
+	if q.MaxGPUs > 0 {
+		gpus := JobGPUs(job)
+		for i := range activeJobs {
+			gpus += JobGPUs(&activeJobs[i])
+		}
+		if gpus > q.MaxGPUs {
+			return fmt.Errorf("user[%s] would use %d gpus, exceeds max gpus %d", q.UserName, gpus, q.MaxGPUs)
+		}
+	}
+	return nil
+}
+
+// IsJobTimeout 作业运行时间是否超过用户的最长运行时间
+func (q *UserQuota) IsJobTimeout(job *Job, now time.Time) bool {
+	if q.MaxJobDuration <= 0 || !job.ActivatedAt.Valid {
+		return false
+	}
+	return now.Sub(job.ActivatedAt.Time) > time.Duration(q.MaxJobDuration)*time.Second
+}
+
+// JobGPUs 作业所有成员申请的GPU卡数之和，名称中包含gpu的扩展资源都计为GPU
+func JobGPUs(job *Job) int64 {
+	members := job.Members
+	if len(members) == 0 && job.Config != nil {
+		members = []schema.Member{{Replicas: 1, Conf: *job.Config}}
+	}
+	var gpus int64
+	for _, member := range members {
+		for name, value := range member.Flavour.ScalarResources {
+			if !strings.Contains(strings.ToLower(string(name)), "gpu") {
+				continue
+			}
+			quantity, err := resources.ParseQuantity(value)
+			if err != nil {
+				continue
+			}
+			gpus += int64(quantity) * int64(member.Replicas)
+		}
+	}
+	return gpus
+}
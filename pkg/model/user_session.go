@@ -0,0 +1,48 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"time"
+)
+
+// UserSession 登录签发的token对应的会话，token撤销或过期后会话失效
+type UserSession struct {
+	Pk        int64  `json:"-" gorm:"primaryKey;autoIncrement"`
+	ID        string `json:"id" gorm:"type:varchar(60);uniqueIndex"`
+	UserName  string `json:"userName" gorm:"type:varchar(60);index"`
+	ClientIP  string `json:"clientIP" gorm:"type:varchar(64);default:''"`
+	UserAgent string `json:"userAgent" gorm:"type:varchar(256);default:''"`
+	// ExpireAt 为空表示token永不过期
+	ExpireAt     *time.Time `json:"expireTime,omitempty"`
+	LastActiveAt time.Time  `json:"lastActiveTime"`
+	RevokedAt    *time.Time `json:"revokeTime,omitempty"`
+	CreatedAt    time.Time  `json:"createTime"`
+	UpdatedAt    time.Time  `json:"-"`
+}
+
+func (UserSession) TableName() string {
+	return "user_session"
+}
+
+// IsActive 会话未被撤销且未过期
+func (s *UserSession) IsActive(now time.Time) bool {
+	if s.RevokedAt != nil {
+		return false
+	}
+	return s.ExpireAt == nil || now.Before(*s.ExpireAt)
+}
@@ -0,0 +1,53 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"time"
+)
+
+const (
+	VisualizationTypeTensorBoard = "tensorboard"
+
+	VisualizationStatusRunning    = "running"
+	VisualizationStatusTerminated = "terminated"
+	VisualizationStatusFailed     = "failed"
+)
+
+// Visualization 可视化服务，如挂载作业日志目录的TensorBoard
+type Visualization struct {
+	Pk        int64     `json:"-"          gorm:"primaryKey;autoIncrement;not null"`
+	ID        string    `json:"id"         gorm:"type:varchar(60);uniqueIndex;not null"`
+	Type      string    `json:"type"       gorm:"type:varchar(32);not null"`
+	UserName  string    `json:"userName"   gorm:"type:varchar(60);not null"`
+	JobID     string    `json:"jobID"      gorm:"type:varchar(60);index"`
+	FsID      string    `json:"-"          gorm:"type:varchar(200)"`
+	FsName    string    `json:"fsName"     gorm:"type:varchar(200)"`
+	LogDir    string    `json:"logDir"     gorm:"type:varchar(1024)"`
+	ClusterID string    `json:"-"          gorm:"type:varchar(60)"`
+	Namespace string    `json:"namespace"  gorm:"type:varchar(64)"`
+	Status    string    `json:"status"     gorm:"type:varchar(32);index"`
+	URL       string    `json:"url"        gorm:"type:varchar(256)"`
+	Message   string    `json:"message"    gorm:"type:text"`
+	ExpiredAt time.Time `json:"expiredAt"`
+	CreatedAt time.Time `json:"createTime"`
+	UpdatedAt time.Time `json:"updateTime"`
+}
+
+func (Visualization) TableName() string {
+	return "visualization"
+}
@@ -18,6 +18,7 @@ package monitor
 
 const (
 	QueryCPUUsageRateQl = "sum(rate(container_cpu_usage_seconds_total{image!=\"\", pod=~\"%s\"}[1m])) by (pod) / sum(container_spec_cpu_quota{image!=\"\", pod=~\"%s\"} / 100000) by (pod)"
+	QueryCPUUsageQl     = "sum(rate(container_cpu_usage_seconds_total{image!=\"\", pod=~\"%s\"}[1m])) by (pod)"
 	QueryMEMUsageRateQl = "sum(container_memory_working_set_bytes{image!=\"\", pod=~\"%s\"}) by (pod) / sum(container_spec_memory_limit_bytes{image!=\"\", pod=~\"%s\"}) by (pod)"
 	QueryMEMUsageQl     = "sum(container_memory_working_set_bytes{image!=\"\", pod=~\"%s\"}) by (pod)"
 	QueryNetReceiveQl   = "sum(rate(container_network_receive_bytes_total{image!=\"\", pod=~\"%s\"}[1m])) by (pod)"
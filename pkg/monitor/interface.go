@@ -23,4 +23,6 @@ import (
 type MetricInterface interface {
 	GetJobAvgMetrics(metricName, jobID string) (float64, error)
 	GetJobSequenceMetrics(metricName, jobID string, start, end, step int64) (model.Value, error)
+	// GetTasksInstantMetrics 查询一组 pod 当前时刻的指标，返回 pod 名到指标值的映射
+	GetTasksInstantMetrics(metricName string, podNames []string) (map[string]float64, error)
 }
@@ -108,10 +108,38 @@ func (km *KubernetesMetric) GetJobSequenceMetrics(metricName, jobID string, star
 	return result, nil
 }
 
+func (km *KubernetesMetric) GetTasksInstantMetrics(metricName string, podNames []string) (map[string]float64, error) {
+	values := make(map[string]float64)
+	if len(podNames) == 0 {
+		return values, nil
+	}
+	queryPromql := getQuerySqlByMetric(metricName, strings.Join(podNames, "|"))
+	if queryPromql == "" {
+		return nil, fmt.Errorf("metric[%s] is not support", metricName)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	result, _, err := km.PrometheusClientAPI.Query(ctx, queryPromql, time.Now())
+	if err != nil {
+		log.Errorf("metric[%s] prometheus query api error %s", metricName, err.Error())
+		return nil, err
+	}
+	data, ok := result.(model.Vector)
+	if !ok {
+		return nil, fmt.Errorf("convert result to vector failed")
+	}
+	for _, sample := range data {
+		values[string(sample.Metric["pod"])] = float64(sample.Value)
+	}
+	return values, nil
+}
+
 func getQuerySqlByMetric(metricName, podNames string) string {
 	switch metricName {
 	case consts.MetricCpuUsageRate:
 		return fmt.Sprintf(QueryCPUUsageRateQl, podNames, podNames)
+	case consts.MetricCpuUsage:
+		return fmt.Sprintf(QueryCPUUsageQl, podNames)
 	case consts.MetricMemoryUsageRate:
 		return fmt.Sprintf(QueryMEMUsageRateQl, podNames, podNames)
 	case consts.MetricMemoryUsage:
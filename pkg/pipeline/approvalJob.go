@@ -0,0 +1,193 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/uuid"
+)
+
+const ApprovalJobIDPrefix = "approval"
+
+// 等待审批的作业，key 为 jobID；服务重启后，节点恢复时会重新注册
+var pendingApprovals sync.Map
+
+type ApprovalDecision struct {
+	Approved bool
+	UserName string
+	Comment  string
+}
+
+// GetPendingApproval 获取正在等待审批的作业
+func GetPendingApproval(jobID string) (*ApprovalJob, bool) {
+	value, ok := pendingApprovals.Load(jobID)
+	if !ok {
+		return nil, false
+	}
+	return value.(*ApprovalJob), true
+}
+
+// ----------------------------------------------------------------------------
+// Approval Job: 人工审批节点，不创建容器作业
+// ----------------------------------------------------------------------------
+type ApprovalJob struct {
+	BaseJob
+	approval     *schema.ApprovalStep
+	eventChannel chan<- WorkflowEvent
+	decisionChan chan ApprovalDecision
+	decideOnce   sync.Once
+	stopChan     chan struct{}
+	stopOnce     sync.Once
+}
+
+func NewApprovalJob(name string, approval *schema.ApprovalStep, eventChannel chan<- WorkflowEvent) *ApprovalJob {
+	return &ApprovalJob{
+		BaseJob:      *NewBaseJob(name),
+		approval:     approval,
+		eventChannel: eventChannel,
+		decisionChan: make(chan ApprovalDecision, 1),
+		stopChan:     make(chan struct{}),
+	}
+}
+
+// NewApprovalJobWithJobView 用于服务重启后恢复节点，超时时间仍从节点第一次开始等待时计算
+func NewApprovalJobWithJobView(view *schema.JobView, approval *schema.ApprovalStep,
+	eventChannel chan<- WorkflowEvent) *ApprovalJob {
+	aj := NewApprovalJob(view.Name, approval, eventChannel)
+	aj.ID = view.JobID
+	aj.Command = view.Command
+	aj.Parameters = view.Parameters
+	aj.Artifacts = view.Artifacts
+	aj.Env = view.Env
+	aj.StartTime = view.StartTime
+	aj.Status = schema.StatusJobRunning
+	return aj
+}
+
+func (aj *ApprovalJob) Update(cmd string, params map[string]string, envs map[string]string,
+	artifacts *schema.Artifacts) {
+	if cmd != "" {
+		aj.Command = cmd
+	}
+
+	if params != nil {
+		aj.Parameters = params
+	}
+
+	if envs != nil {
+		aj.Env = envs
+	}
+
+	if artifacts != nil {
+		aj.Artifacts = *artifacts
+	}
+}
+
+func (aj *ApprovalJob) Validate() error {
+	if aj.approval == nil {
+		return fmt.Errorf("approval config of job[%s] is empty", aj.Name)
+	}
+	return nil
+}
+
+func (aj *ApprovalJob) Start() (string, error) {
+	aj.ID = uuid.GenerateIDWithLength(ApprovalJobIDPrefix, uuid.JobIDLength)
+	aj.StartTime = time.Now().Format("2006-01-02 15:04:05")
+
+	go aj.Watch()
+	return aj.ID, nil
+}
+
+func (aj *ApprovalJob) Stop() error {
+	aj.stopOnce.Do(func() {
+		close(aj.stopChan)
+	})
+	return nil
+}
+
+func (aj *ApprovalJob) Check() (schema.JobStatus, error) {
+	if aj.ID == "" {
+		return "", fmt.Errorf("job not started, id is empty!")
+	}
+	return aj.Status, nil
+}
+
+// Approvers 返回节点配置的审批人，为空时由调用方决定默认审批人
+func (aj *ApprovalJob) Approvers() []string {
+	return aj.approval.Approvers
+}
+
+// Decide 提交审批结果，每个作业只接受一次审批
+func (aj *ApprovalJob) Decide(decision ApprovalDecision) error {
+	decided := false
+	aj.decideOnce.Do(func() {
+		aj.decisionChan <- decision
+		decided = true
+	})
+	if !decided {
+		return fmt.Errorf("job[%s] has already been approved or rejected", aj.ID)
+	}
+	return nil
+}
+
+// Watch 等待审批结果、超时或者节点被终止
+func (aj *ApprovalJob) Watch() {
+	pendingApprovals.Store(aj.ID, aj)
+	defer pendingApprovals.Delete(aj.ID)
+
+	msg := "waiting for approval"
+	if aj.approval.Message != "" {
+		msg = fmt.Sprintf("%s: %s", msg, aj.approval.Message)
+	}
+	aj.updateStatus(schema.StatusJobRunning, msg, aj.eventChannel)
+
+	var timeout <-chan time.Time
+	if aj.approval.Timeout > 0 {
+		deadline := time.Now().Add(time.Duration(aj.approval.Timeout) * time.Second)
+		if startTime, err := time.ParseInLocation("2006-01-02 15:04:05", aj.StartTime, time.Local); err == nil {
+			deadline = startTime.Add(time.Duration(aj.approval.Timeout) * time.Second)
+		}
+		timer := time.NewTimer(time.Until(deadline))
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case decision := <-aj.decisionChan:
+		if decision.Approved {
+			aj.updateStatus(schema.StatusJobSucceeded, decisionMessage("approved", decision), aj.eventChannel)
+		} else {
+			aj.updateStatus(schema.StatusJobFailed, decisionMessage("rejected", decision), aj.eventChannel)
+		}
+	case <-timeout:
+		aj.updateStatus(schema.StatusJobFailed,
+			fmt.Sprintf("approval timeout after %d seconds", aj.approval.Timeout), aj.eventChannel)
+	case <-aj.stopChan:
+		aj.updateStatus(schema.StatusJobTerminated, "approval is cancelled", aj.eventChannel)
+	}
+}
+
+func decisionMessage(action string, decision ApprovalDecision) string {
+	if decision.Comment == "" {
+		return fmt.Sprintf("%s by %s", action, decision.UserName)
+	}
+	return fmt.Sprintf("%s by %s: %s", action, decision.UserName, decision.Comment)
+}
@@ -0,0 +1,86 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+)
+
+func TestApprovalJob(t *testing.T) {
+	ch := make(chan WorkflowEvent, 10)
+
+	// approved
+	job := NewApprovalJob("run-000001-gate", &schema.ApprovalStep{Message: "promote?"}, ch)
+	assert.Nil(t, job.Validate())
+	id, err := job.Start()
+	assert.Nil(t, err)
+	event := <-ch
+	assert.Equal(t, schema.StatusJobRunning, event.Extra["status"])
+	assert.Contains(t, event.Message, "promote?")
+
+	pending, ok := GetPendingApproval(id)
+	assert.True(t, ok)
+	assert.Nil(t, pending.Decide(ApprovalDecision{Approved: true, UserName: "alice", Comment: "lgtm"}))
+	assert.NotNil(t, pending.Decide(ApprovalDecision{Approved: false, UserName: "bob"}))
+	event = waitJobFinished(t, ch)
+	assert.Equal(t, schema.StatusJobSucceeded, event.Extra["status"])
+	assert.Equal(t, "approved by alice: lgtm", event.Message)
+	_, ok = GetPendingApproval(id)
+	assert.False(t, ok)
+
+	// rejected
+	job = NewApprovalJob("run-000001-gate", &schema.ApprovalStep{}, ch)
+	id, _ = job.Start()
+	<-ch
+	pending, _ = GetPendingApproval(id)
+	assert.Nil(t, pending.Decide(ApprovalDecision{Approved: false, UserName: "bob"}))
+	event = waitJobFinished(t, ch)
+	assert.Equal(t, schema.StatusJobFailed, event.Extra["status"])
+	assert.Equal(t, "rejected by bob", event.Message)
+
+	// timeout
+	job = NewApprovalJob("run-000001-gate", &schema.ApprovalStep{Timeout: 1}, ch)
+	job.Start()
+	event = waitJobFinished(t, ch)
+	assert.Equal(t, schema.StatusJobFailed, event.Extra["status"])
+	assert.Contains(t, event.Message, "timeout")
+
+	// resumed job keeps the original deadline
+	view := &schema.JobView{
+		JobID:     "approval-resumed",
+		Name:      "run-000001-gate",
+		StartTime: time.Now().Add(-time.Hour).Format("2006-01-02 15:04:05"),
+	}
+	job = NewApprovalJobWithJobView(view, &schema.ApprovalStep{Timeout: 60}, ch)
+	go job.Watch()
+	event = waitJobFinished(t, ch)
+	assert.Equal(t, schema.StatusJobFailed, event.Extra["status"])
+
+	// stopped
+	job = NewApprovalJob("run-000001-gate", &schema.ApprovalStep{}, ch)
+	job.Start()
+	<-ch
+	assert.Nil(t, job.Stop())
+	assert.Nil(t, job.Stop())
+	event = waitJobFinished(t, ch)
+	assert.Equal(t, schema.StatusJobTerminated, event.Extra["status"])
+}
@@ -26,6 +26,7 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/handler"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/config"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
 )
 
@@ -77,6 +78,15 @@ func GetOutputArtifactEnvName(atfName string) string {
 	return "PF_OUTPUT_ARTIFACT_" + strings.ToUpper(atfName)
 }
 
+// GetTrackingURI 作业上报指标、参数的地址，未配置server地址时返回空
+func GetTrackingURI(runID string) string {
+	if config.GlobalServerConfig == nil || config.GlobalServerConfig.ApiServer.Host == "" {
+		return ""
+	}
+	apiServer := config.GlobalServerConfig.ApiServer
+	return fmt.Sprintf("http://%s:%d/api/paddleflow/v1/run/%s/tracking", apiServer.Host, apiServer.Port, runID)
+}
+
 func TopologicalSort(components map[string]schema.Component) ([]string, error) {
 	// unsorted: unsorted graph
 	// if we have dag:
@@ -26,6 +26,10 @@ const (
 	SysParamNamePFUserName     = "PF_USER_NAME"
 	SysParamNamePFLoopArgument = "PF_LOOP_ARGUMENT"
 
+	// 作业上报指标、参数所用的地址与凭证
+	EnvNamePFTrackingURI   = "PF_TRACKING_URI"
+	EnvNamePFTrackingToken = "PF_TRACKING_TOKEN"
+
 	PF_PARENT        = "PF_PARENT"
 	PF_LOOP_ARGUMENT = "PF_LOOP_ARGUMENT"
 
@@ -39,6 +43,7 @@ const (
 	ParamTypePath   = "path"
 	ParamTypeInt    = "int"
 	ParamTypeList   = "list"
+	ParamTypeBool   = "bool"
 
 	WfParallelismDefault = 10
 	WfParallelismMaximum = 20
@@ -50,6 +55,8 @@ const (
 	FieldOutputArtifacts = "outputArtifacts"
 	FieldCondition       = "condition"
 	FieldLoopArguemt     = "loop_argument"
+	FieldHTTP            = "http"
+	FieldHTTPURL         = "http_url"
 
 	CacheStrategyConservative = "conservative"
 	CacheStrategyAggressive   = "aggressive"
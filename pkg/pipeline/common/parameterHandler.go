@@ -18,6 +18,7 @@ package common
 
 import (
 	"fmt"
+	"math"
 	"reflect"
 	"regexp"
 	"strings"
@@ -27,9 +28,35 @@ import (
 	"github.com/mitchellh/mapstructure"
 )
 
+// DictParam dict形式的参数，除类型和默认值外，还可以声明校验规则
+// - Choices: 可选值列表
+// - Min/Max: 取值范围，仅对int/float类型有效
+// - Pattern: 正则表达式，仅对string/path类型有效
 type DictParam struct {
 	Type    string
 	Default interface{}
+	Choices []interface{}
+	Min     *float64
+	Max     *float64
+	Pattern string
+}
+
+// 未声明校验规则时，与只有Type、Default两个字段时的格式保持一致
+func (p DictParam) String() string {
+	res := fmt.Sprintf("{Type:%s Default:%v", p.Type, p.Default)
+	if len(p.Choices) > 0 {
+		res += fmt.Sprintf(" Choices:%v", p.Choices)
+	}
+	if p.Min != nil {
+		res += fmt.Sprintf(" Min:%v", *p.Min)
+	}
+	if p.Max != nil {
+		res += fmt.Sprintf(" Max:%v", *p.Max)
+	}
+	if p.Pattern != "" {
+		res += fmt.Sprintf(" Pattern:%s", p.Pattern)
+	}
+	return res + "}"
 }
 
 func (p *DictParam) From(origin interface{}) error {
@@ -55,6 +82,10 @@ func UnsupportedPathParamError(param interface{}, paramName string) error {
 	return fmt.Errorf("invalid path value[%s] in parameter[%s]", param, paramName)
 }
 
+func InvalidDictParamValueError(param interface{}, paramName string, reason string) error {
+	return fmt.Errorf("invalid value[%v] for param[%s]: %s", param, paramName, reason)
+}
+
 func MismatchRegexError(param, regex string) error {
 	return fmt.Errorf("param[%s] mismatches regex pattern[%s]", param, regex)
 }
@@ -324,7 +355,7 @@ func (s *ComponentParamChecker) solveParamValue(compName string, paramName strin
 
 	// 参数值检查
 	switch param := param.(type) {
-	case float32, float64, int, int64:
+	case float32, float64, int, int64, bool:
 		return param, nil
 	case []interface{}:
 		if err := CheckListParam(param); err != nil {
@@ -527,6 +558,18 @@ func CheckDictParam(dict DictParam, paramName string, realVal interface{}) (inte
 		realVal = dict.Default
 	}
 
+	realVal, err := checkDictParamType(dict, paramName, realVal)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkDictParamRules(dict, paramName, realVal); err != nil {
+		return nil, err
+	}
+	return realVal, nil
+}
+
+func checkDictParamType(dict DictParam, paramName string, realVal interface{}) (interface{}, error) {
 	switch dict.Type {
 	case ParamTypeString:
 		_, ok := realVal.(string)
@@ -552,7 +595,16 @@ func CheckDictParam(dict DictParam, paramName string, realVal interface{}) (inte
 		if ok1 || ok2 || ok3 {
 			return realVal, nil
 		}
+		// 通过接口传入的参数经过json反序列化后，整数也是float64类型
+		if floatVal, ok := realVal.(float64); ok && floatVal == math.Trunc(floatVal) {
+			return int64(floatVal), nil
+		}
 		return nil, InvalidParamTypeError(realVal, ParamTypeInt)
+	case ParamTypeBool:
+		if _, ok := realVal.(bool); ok {
+			return realVal, nil
+		}
+		return nil, InvalidParamTypeError(realVal, ParamTypeBool)
 	case ParamTypeList:
 		_, ok1 := realVal.([]float32)
 		_, ok2 := realVal.([]float64)
@@ -563,6 +615,14 @@ func CheckDictParam(dict DictParam, paramName string, realVal interface{}) (inte
 		if ok1 || ok2 || ok3 || ok4 || ok5 || ok6 {
 			return realVal, nil
 		}
+		// yaml或json反序列化得到的list为[]interface{}类型
+		if listVal, ok := realVal.([]interface{}); ok {
+			if err := CheckListParam(listVal); err != nil {
+				return nil, err
+			}
+			return realVal, nil
+		}
+		return nil, InvalidParamTypeError(realVal, ParamTypeList)
 	case ParamTypePath:
 		realValStr, ok := realVal.(string)
 		if !ok {
@@ -578,5 +638,48 @@ func CheckDictParam(dict DictParam, paramName string, realVal interface{}) (inte
 	default:
 		return nil, UnsupportedDictParamTypeError(dict.Type, paramName, dict)
 	}
-	return nil, fmt.Errorf("check dict param failed in end")
+}
+
+// 根据dict参数中声明的choices/min/max/pattern规则校验参数值，调用前需要保证参数值类型已经校验过
+func checkDictParamRules(dict DictParam, paramName string, realVal interface{}) error {
+	if len(dict.Choices) > 0 {
+		found := false
+		for _, choice := range dict.Choices {
+			// yaml与json反序列化得到的数值类型可能不同，这里统一转换为字符串比较
+			if fmt.Sprint(choice) == fmt.Sprint(realVal) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return InvalidDictParamValueError(realVal, paramName, fmt.Sprintf("should be one of %v", dict.Choices))
+		}
+	}
+
+	if dict.Min != nil || dict.Max != nil {
+		if dict.Type != ParamTypeInt && dict.Type != ParamTypeFloat {
+			return fmt.Errorf("min/max in dict param[%s] only support type[%s] and [%s]", paramName, ParamTypeInt, ParamTypeFloat)
+		}
+		floatVal := reflect.ValueOf(realVal).Convert(reflect.TypeOf(float64(0))).Float()
+		if dict.Min != nil && floatVal < *dict.Min {
+			return InvalidDictParamValueError(realVal, paramName, fmt.Sprintf("should not be less than %v", *dict.Min))
+		}
+		if dict.Max != nil && floatVal > *dict.Max {
+			return InvalidDictParamValueError(realVal, paramName, fmt.Sprintf("should not be greater than %v", *dict.Max))
+		}
+	}
+
+	if dict.Pattern != "" {
+		if dict.Type != ParamTypeString && dict.Type != ParamTypePath {
+			return fmt.Errorf("pattern in dict param[%s] only support type[%s] and [%s]", paramName, ParamTypeString, ParamTypePath)
+		}
+		reg, err := regexp.Compile(dict.Pattern)
+		if err != nil {
+			return fmt.Errorf("pattern[%s] in dict param[%s] is invalid: %v", dict.Pattern, paramName, err)
+		}
+		if !reg.MatchString(realVal.(string)) {
+			return InvalidDictParamValueError(realVal, paramName, MismatchRegexError(realVal.(string), dict.Pattern).Error())
+		}
+	}
+	return nil
 }
@@ -0,0 +1,192 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/http/outbound"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/uuid"
+)
+
+const (
+	HTTPJobIDPrefix = "http"
+
+	// 只读取响应体的前 1MB 用于匹配 success_pattern
+	httpJobMaxBodySize = 1 << 20
+)
+
+// ----------------------------------------------------------------------------
+// HTTP Job: 调用外部接口，不创建容器作业
+// ----------------------------------------------------------------------------
+type HTTPJob struct {
+	BaseJob
+	httpStep     *schema.HTTPStep
+	eventChannel chan<- WorkflowEvent
+	ctx          context.Context
+	cancel       context.CancelFunc
+}
+
+// httpStep 为 stepRuntime 中节点的 http 配置，其中的模板会在 Start 前由 stepRuntime 完成替换
+func NewHTTPJob(name string, httpStep *schema.HTTPStep, eventChannel chan<- WorkflowEvent) *HTTPJob {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &HTTPJob{
+		BaseJob:      *NewBaseJob(name),
+		httpStep:     httpStep,
+		eventChannel: eventChannel,
+		ctx:          ctx,
+		cancel:       cancel,
+	}
+}
+
+// NewHTTPJobWithJobView 用于服务重启后恢复节点，恢复后会重新发起请求
+func NewHTTPJobWithJobView(view *schema.JobView, httpStep *schema.HTTPStep, eventChannel chan<- WorkflowEvent) *HTTPJob {
+	hj := NewHTTPJob(view.Name, httpStep, eventChannel)
+	hj.ID = view.JobID
+	hj.Command = view.Command
+	hj.Parameters = view.Parameters
+	hj.Artifacts = view.Artifacts
+	hj.Env = view.Env
+	hj.StartTime = view.StartTime
+	hj.Status = schema.StatusJobRunning
+	return hj
+}
+
+func (hj *HTTPJob) Update(cmd string, params map[string]string, envs map[string]string,
+	artifacts *schema.Artifacts) {
+	if cmd != "" {
+		hj.Command = cmd
+	}
+
+	if params != nil {
+		hj.Parameters = params
+	}
+
+	if envs != nil {
+		hj.Env = envs
+	}
+
+	if artifacts != nil {
+		hj.Artifacts = *artifacts
+	}
+}
+
+func (hj *HTTPJob) Validate() error {
+	if hj.httpStep == nil {
+		return fmt.Errorf("http config of job[%s] is empty", hj.Name)
+	}
+	if _, err := url.ParseRequestURI(hj.httpStep.URL); err != nil {
+		return fmt.Errorf("url[%s] of http job[%s] is invalid: %v", hj.httpStep.URL, hj.Name, err)
+	}
+	if err := outbound.CheckURL(hj.httpStep.URL); err != nil {
+		return fmt.Errorf("url of http job[%s] is not allowed: %v", hj.Name, err)
+	}
+	if hj.httpStep.SuccessPattern != "" {
+		if _, err := regexp.Compile(hj.httpStep.SuccessPattern); err != nil {
+			return fmt.Errorf("success_pattern of http job[%s] is invalid: %v", hj.Name, err)
+		}
+	}
+	return nil
+}
+
+func (hj *HTTPJob) Start() (string, error) {
+	hj.ID = uuid.GenerateIDWithLength(HTTPJobIDPrefix, uuid.JobIDLength)
+	hj.StartTime = time.Now().Format("2006-01-02 15:04:05")
+
+	go hj.Watch()
+	return hj.ID, nil
+}
+
+func (hj *HTTPJob) Stop() error {
+	hj.cancel()
+	return nil
+}
+
+func (hj *HTTPJob) Check() (schema.JobStatus, error) {
+	if hj.ID == "" {
+		return "", fmt.Errorf("job not started, id is empty!")
+	}
+	return hj.Status, nil
+}
+
+// Watch 发起请求并根据响应更新节点状态
+func (hj *HTTPJob) Watch() {
+	hj.updateStatus(schema.StatusJobRunning, fmt.Sprintf("calling %s %s", hj.httpStep.Method, hj.httpStep.URL),
+		hj.eventChannel)
+
+	status, msg := hj.call()
+	hj.updateStatus(status, msg, hj.eventChannel)
+}
+
+func (hj *HTTPJob) call() (schema.JobStatus, string) {
+	ctx, cancel := context.WithTimeout(hj.ctx, hj.httpStep.GetTimeout())
+	defer cancel()
+
+	// 模板替换后的地址需要重新检查
+	if err := outbound.CheckURL(hj.httpStep.URL); err != nil {
+		return schema.StatusJobFailed, err.Error()
+	}
+	var body io.Reader
+	if hj.httpStep.Body != "" {
+		body = strings.NewReader(hj.httpStep.Body)
+	}
+	req, err := http.NewRequestWithContext(ctx, hj.httpStep.Method, hj.httpStep.URL, body)
+	if err != nil {
+		return schema.StatusJobFailed, fmt.Sprintf("build http request failed: %v", err)
+	}
+	for name, value := range hj.httpStep.Headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := outbound.NewClient(hj.httpStep.GetTimeout()).Do(req)
+	if err != nil {
+		if hj.ctx.Err() != nil {
+			return schema.StatusJobTerminated, "http request is cancelled"
+		}
+		return schema.StatusJobFailed, fmt.Sprintf("http request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, httpJobMaxBodySize))
+	if err != nil {
+		return schema.StatusJobFailed, fmt.Sprintf("read http response failed: %v", err)
+	}
+	return hj.evaluate(resp.StatusCode, string(respBody))
+}
+
+// evaluate 根据状态码与 success_pattern 判断请求是否成功，响应体不写入节点 message
+func (hj *HTTPJob) evaluate(code int, body string) (schema.JobStatus, string) {
+	if !hj.httpStep.IsExpectedStatus(code) {
+		return schema.StatusJobFailed, fmt.Sprintf("unexpected http status code %d", code)
+	}
+	if hj.httpStep.SuccessPattern != "" {
+		matched, err := regexp.MatchString(hj.httpStep.SuccessPattern, body)
+		if err != nil || !matched {
+			return schema.StatusJobFailed, fmt.Sprintf("response with http status code %d does not match success_pattern[%s]",
+				code, hj.httpStep.SuccessPattern)
+		}
+	}
+	return schema.StatusJobSucceeded, fmt.Sprintf("http status code %d", code)
+}
@@ -0,0 +1,123 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/http/outbound"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+)
+
+func waitJobFinished(t *testing.T, ch chan WorkflowEvent) WorkflowEvent {
+	for {
+		select {
+		case event := <-ch:
+			status := event.Extra["status"].(schema.JobStatus)
+			if status != schema.StatusJobRunning {
+				return event
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("wait job finished timeout")
+		}
+	}
+}
+
+func TestHTTPJob(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		switch r.URL.Path {
+		case "/ok":
+			assert.Equal(t, http.MethodPost, r.Method)
+			assert.Equal(t, "token", r.Header.Get("Authorization"))
+			assert.Equal(t, `{"stage":"prod"}`, string(body))
+			w.Write([]byte(`{"ok": true}`))
+		case "/not-ready":
+			w.Write([]byte(`{"ok": false}`))
+		case "/slow":
+			time.Sleep(2 * time.Second)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	// 默认拒绝访问回环地址
+	ch := make(chan WorkflowEvent, 10)
+	job := NewHTTPJob("run-000001-notify", &schema.HTTPStep{URL: server.URL + "/ok", Method: http.MethodGet}, ch)
+	job.Start()
+	event := waitJobFinished(t, ch)
+	assert.Equal(t, schema.StatusJobFailed, event.Extra["status"])
+
+	assert.Nil(t, outbound.Init(outbound.Config{AllowedCIDRs: []string{"127.0.0.0/8"}}))
+	defer outbound.Init(outbound.Config{})
+	httpStep := &schema.HTTPStep{
+		URL:            server.URL + "/ok",
+		Method:         http.MethodPost,
+		Headers:        map[string]string{"Authorization": "token"},
+		Body:           `{"stage":"prod"}`,
+		SuccessPattern: `"ok":\s*true`,
+	}
+	job = NewHTTPJob("run-000001-notify", httpStep, ch)
+	assert.Nil(t, job.Validate())
+	id, err := job.Start()
+	assert.Nil(t, err)
+	assert.Contains(t, id, HTTPJobIDPrefix)
+	event = waitJobFinished(t, ch)
+	assert.Equal(t, schema.StatusJobSucceeded, event.Extra["status"])
+	assert.True(t, job.Succeeded())
+
+	// response does not match success_pattern
+	httpStep = &schema.HTTPStep{URL: server.URL + "/not-ready", Method: http.MethodGet, SuccessPattern: `"ok":\s*true`}
+	job = NewHTTPJob("run-000001-notify", httpStep, ch)
+	job.Start()
+	event = waitJobFinished(t, ch)
+	assert.Equal(t, schema.StatusJobFailed, event.Extra["status"])
+	assert.NotContains(t, event.Message, `"ok": false`)
+
+	// unexpected status code
+	httpStep = &schema.HTTPStep{URL: server.URL + "/error", Method: http.MethodGet}
+	job = NewHTTPJob("run-000001-notify", httpStep, ch)
+	job.Start()
+	event = waitJobFinished(t, ch)
+	assert.Equal(t, schema.StatusJobFailed, event.Extra["status"])
+	assert.Contains(t, event.Message, "500")
+
+	// stopped while calling
+	httpStep = &schema.HTTPStep{URL: server.URL + "/slow", Method: http.MethodGet}
+	job = NewHTTPJob("run-000001-notify", httpStep, ch)
+	job.Start()
+	time.Sleep(100 * time.Millisecond)
+	assert.Nil(t, job.Stop())
+	event = waitJobFinished(t, ch)
+	assert.Equal(t, schema.StatusJobTerminated, event.Extra["status"])
+
+	// invalid url
+	job = NewHTTPJob("run-000001-notify", &schema.HTTPStep{URL: "ftp://example.com", Method: http.MethodGet}, ch)
+	assert.NotNil(t, job.Validate())
+
+	// host not allowed
+	assert.Nil(t, outbound.Init(outbound.Config{AllowedHosts: []string{"*.example.com"}}))
+	job = NewHTTPJob("run-000001-notify", &schema.HTTPStep{URL: "http://169.254.169.254/", Method: http.MethodGet}, ch)
+	assert.NotNil(t, job.Validate())
+}
@@ -24,6 +24,7 @@ import (
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/controller/job"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
+	pplcommon "github.com/PaddlePaddle/PaddleFlow/pkg/pipeline/common"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/storage"
 )
 
@@ -71,6 +72,7 @@ type PaddleFlowJob struct {
 	Image        string
 	mainFS       *schema.FsMount
 	extraFS      []schema.FsMount
+	sharedVolume *schema.SharedVolume
 	eventChannel chan<- WorkflowEvent
 }
 
@@ -110,6 +112,11 @@ func NewPaddleFlowJobWithJobView(view *schema.JobView, image string, eventChanne
 	return &pfj
 }
 
+// 设置run级共享卷，为nil时不挂载
+func (pfj *PaddleFlowJob) setSharedVolume(sharedVolume *schema.SharedVolume) {
+	pfj.sharedVolume = sharedVolume
+}
+
 // 发起作业接口
 func (pfj *PaddleFlowJob) Update(cmd string, params map[string]string, envs map[string]string,
 	artifacts *schema.Artifacts) {
@@ -166,6 +173,18 @@ func (pfj *PaddleFlowJob) generateJobConf() schema.Conf {
 		queueName = pfj.Env["PF_JOB_QUEUE_NAME"]
 	}
 
+	// run内所有step共用一个以runID命名的PVC
+	var sharedVolume *schema.RunSharedVolume
+	if pfj.sharedVolume != nil {
+		runID := pfj.Env[pplcommon.SysParamNamePFRunID]
+		sharedVolume = &schema.RunSharedVolume{
+			RunID:        runID,
+			ClaimName:    schema.RunSharedVolumeClaimName(runID),
+			Size:         pfj.sharedVolume.Size,
+			StorageClass: pfj.sharedVolume.StorageClass,
+		}
+	}
+
 	conf := schema.Conf{
 		Name:            pfj.Name,
 		Env:             pfj.Env,
@@ -175,6 +194,7 @@ func (pfj *PaddleFlowJob) generateJobConf() schema.Conf {
 		QueueName:       queueName,
 		Priority:        priority,
 		FileSystem:      fs,
+		SharedVolume:    sharedVolume,
 	}
 
 	return conf
@@ -288,40 +308,58 @@ func (pfj *PaddleFlowJob) Watch() {
 	}
 }
 
-func (pfj *PaddleFlowJob) Succeeded() bool {
-	return pfj.Status == schema.StatusJobSucceeded
+func (bj *BaseJob) Succeeded() bool {
+	return bj.Status == schema.StatusJobSucceeded
 }
 
-func (pfj *PaddleFlowJob) Failed() bool {
-	return pfj.Status == schema.StatusJobFailed
+func (bj *BaseJob) Failed() bool {
+	return bj.Status == schema.StatusJobFailed
 }
 
-func (pfj *PaddleFlowJob) Terminated() bool {
-	return pfj.Status == schema.StatusJobTerminated
+func (bj *BaseJob) Terminated() bool {
+	return bj.Status == schema.StatusJobTerminated
 }
 
-func (pfj *PaddleFlowJob) Skipped() bool {
-	return pfj.Status == schema.StatusJobSkipped
+func (bj *BaseJob) Skipped() bool {
+	return bj.Status == schema.StatusJobSkipped
 }
 
-func (pfj *PaddleFlowJob) Cancelled() bool {
-	return pfj.Status == schema.StatusJobCancelled
+func (bj *BaseJob) Cancelled() bool {
+	return bj.Status == schema.StatusJobCancelled
 }
 
-func (pfj *PaddleFlowJob) NotEnded() bool {
-	return pfj.Status == "" || pfj.Status == schema.StatusJobTerminating || pfj.Status == schema.StatusJobRunning || pfj.Status == schema.StatusJobPending
+func (bj *BaseJob) NotEnded() bool {
+	return bj.Status == "" || bj.Status == schema.StatusJobTerminating || bj.Status == schema.StatusJobRunning || bj.Status == schema.StatusJobPending
 }
 
-func (pfj *PaddleFlowJob) Started() bool {
-	return pfj.Status != ""
+func (bj *BaseJob) Started() bool {
+	return bj.Status != ""
 }
 
-func (pfj *PaddleFlowJob) Job() BaseJob {
-	return pfj.BaseJob
+func (bj *BaseJob) Job() BaseJob {
+	return *bj
 }
 
-func (pfj *PaddleFlowJob) JobID() string {
-	return pfj.ID
+func (bj *BaseJob) JobID() string {
+	return bj.ID
+}
+
+// 更新非容器作业（http、approval）的状态，并通过 event 同步给 stepRuntime
+func (bj *BaseJob) updateStatus(status schema.JobStatus, msg string, eventChannel chan<- WorkflowEvent) {
+	extra := map[string]interface{}{
+		"status":    status,
+		"preStatus": bj.Status,
+		"jobid":     bj.ID,
+		"message":   msg,
+	}
+	bj.Status = status
+	bj.Message = msg
+	if !bj.NotEnded() {
+		bj.EndTime = time.Now().Format("2006-01-02 15:04:05")
+	}
+
+	wfe := NewWorkflowEvent(WfEventJobUpdate, msg, extra)
+	eventChannel <- *wfe
 }
 
 // ----------------------------------------------------------------------------
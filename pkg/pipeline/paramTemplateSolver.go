@@ -19,6 +19,7 @@ package pipeline
 import (
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"reflect"
 	"regexp"
 	"strings"
@@ -181,10 +182,11 @@ func (isv *innerSolver) resolveTemplate(tplString string, fieldType string, forC
 		// 1、 尝试按照 Parameter / sysParameter tpl 的方式进行解析，此时如果tpl为 artifact 模板，肯定会报错
 		value, err = isv.resloveParameterTemplate(tpl, fieldType)
 		if err != nil {
-			// env 字段中只允许引用parameter/sysParameter 模板
-			if fieldType == FieldEnv {
-				err = fmt.Errorf("cannot not resolve Template[%s] for %s[%s] in env field. "+
-					"only support parameter template in env field", isv.Component.GetType(), tpl[0], isv.runtimeName)
+			// env 与 http 字段中只允许引用parameter/sysParameter 模板
+			if fieldType == FieldEnv || fieldType == FieldHTTP || fieldType == FieldHTTPURL {
+				err = fmt.Errorf("cannot not resolve Template[%s] for %s[%s] in %s field. "+
+					"only support parameter template in %s field", isv.Component.GetType(), tpl[0], isv.runtimeName,
+					fieldType, fieldType)
 				return "", err
 			}
 
@@ -201,6 +203,10 @@ func (isv *innerSolver) resolveTemplate(tplString string, fieldType string, forC
 			return value, nil
 		} else {
 			valueString := fmt.Sprintf("%v", value)
+			if fieldType == FieldHTTPURL {
+				// 参数值替换到url中时需要转义，避免改变url的路径、查询参数或域名
+				valueString = strings.ReplaceAll(url.QueryEscape(valueString), "+", "%20")
+			}
 			tplString = strings.Replace(tplString, tpl[0], valueString, -1)
 		}
 	}
@@ -240,6 +246,37 @@ func (isv *innerSolver) resolveCommand(forCache bool) error {
 	return nil
 }
 
+// resolveHTTP: 替换 http 节点中 url、headers 与 body 的模板
+func (isv *innerSolver) resolveHTTP() error {
+	// 调用方需要保证此时的 component 是一个设置了 http 的 Step
+	httpStep := isv.Component.(*schema.WorkflowSourceStep).HTTP
+
+	resolve := func(value, fieldType string) (string, error) {
+		newValue, err := isv.resolveTemplate(value, fieldType, false)
+		if err != nil {
+			return "", err
+		}
+		return newValue.(string), nil
+	}
+
+	var err error
+	if httpStep.URL, err = resolve(httpStep.URL, FieldHTTPURL); err != nil {
+		return err
+	}
+	if httpStep.Body, err = resolve(httpStep.Body, FieldHTTP); err != nil {
+		return err
+	}
+	for name, value := range httpStep.Headers {
+		if httpStep.Headers[name], err = resolve(value, FieldHTTP); err != nil {
+			return err
+		}
+	}
+
+	isv.logger.Infof("after resolve template, the http url of %s[%s] is: %v", isv.Component.GetType(),
+		isv.Component.GetName(), httpStep.URL)
+	return nil
+}
+
 func (isv *innerSolver) resolveCondition() error {
 	condition := isv.Component.GetCondition()
 	newCondition, err := isv.resolveTemplate(condition, FieldCondition, false)
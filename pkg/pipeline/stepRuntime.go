@@ -25,6 +25,7 @@ import (
 	"time"
 
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/config"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
 	. "github.com/PaddlePaddle/PaddleFlow/pkg/pipeline/common"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/trace_logger"
@@ -38,6 +39,12 @@ type StepRuntime struct {
 	CacheRunID        string
 	CacheJobID        string
 
+	// 节点失败后重试的历史记录
+	attempts []schema.JobAttempt
+
+	// 是否处于等待重试的状态
+	retrying bool
+
 	// 需要避免在终止的同时在 创建 job 的情况，导致数据不一致
 	processJobLock sync.Mutex
 }
@@ -57,9 +64,7 @@ func NewStepRuntime(name, fullName string, step *schema.WorkflowSourceStep, seq
 	}
 
 	jobName := generateJobName(config.runID, step.GetName(), seq)
-	job := NewPaddleFlowJob(jobName, srt.getWorkFlowStep().DockerEnv, srt.receiveEventChildren,
-		srt.runConfig.mainFS, srt.getWorkFlowStep().ExtraFS)
-	srt.job = job
+	srt.job = srt.newJob(jobName)
 
 	srt.logger.Infof("step[%s] of runid[%s] before starting job: param[%s], env[%s], command[%s], artifacts[%s], deps[%s], "+
 		"extraFS[%v]", srt.getName(), srt.runID, step.Parameters, step.Env, step.Command,
@@ -88,6 +93,43 @@ func newStepRuntimeWithStatus(name, fullName string, step *schema.WorkflowSource
 	return srt
 }
 
+// newJob 根据节点类型创建对应的作业：容器作业、http 调用或者人工审批
+func (srt *StepRuntime) newJob(jobName string) Job {
+	step := srt.getWorkFlowStep()
+	switch step.GetStepType() {
+	case schema.StepTypeHTTP:
+		return NewHTTPJob(jobName, step.HTTP, srt.receiveEventChildren)
+	case schema.StepTypeApproval:
+		return NewApprovalJob(jobName, step.Approval, srt.receiveEventChildren)
+	default:
+		job := NewPaddleFlowJob(jobName, step.DockerEnv, srt.receiveEventChildren,
+			srt.runConfig.mainFS, step.ExtraFS)
+		job.setSharedVolume(srt.runConfig.SharedVolume)
+		return job
+	}
+}
+
+func (srt *StepRuntime) newJobWithJobView(view *schema.JobView) Job {
+	step := srt.getWorkFlowStep()
+	switch step.GetStepType() {
+	case schema.StepTypeHTTP:
+		return NewHTTPJobWithJobView(view, step.HTTP, srt.receiveEventChildren)
+	case schema.StepTypeApproval:
+		return NewApprovalJobWithJobView(view, step.Approval, srt.receiveEventChildren)
+	default:
+		job := NewPaddleFlowJobWithJobView(view, step.DockerEnv, srt.receiveEventChildren,
+			srt.runConfig.mainFS, step.ExtraFS)
+		job.setSharedVolume(srt.runConfig.SharedVolume)
+		return job
+	}
+}
+
+// cacheEnabled 只有容器作业支持 cache
+func (srt *StepRuntime) cacheEnabled() bool {
+	step := srt.getWorkFlowStep()
+	return step.Cache.Enable && step.GetStepType() == schema.StepTypeJob
+}
+
 func (srt *StepRuntime) getWorkFlowStep() *schema.WorkflowSourceStep {
 	step := srt.getComponent().(*schema.WorkflowSourceStep)
 	return step
@@ -222,10 +264,10 @@ func (srt *StepRuntime) Resume(view *schema.JobView) {
 
 	defer srt.catchPanic()
 
-	srt.job = NewPaddleFlowJobWithJobView(view, srt.getWorkFlowStep().DockerEnv,
-		srt.receiveEventChildren, srt.runConfig.mainFS, srt.getWorkFlowStep().ExtraFS)
+	srt.job = srt.newJobWithJobView(view)
 
 	srt.pk = view.PK
+	srt.attempts = append([]schema.JobAttempt{}, view.Attempts...)
 	err := srt.updateStatus(view.Status)
 	if err != nil {
 		errMsg := fmt.Sprintf("set the sysparams for dag[%s] failed: %s", srt.name, err.Error())
@@ -309,6 +351,13 @@ func (srt *StepRuntime) updateJob(forCacheFingerprint bool) error {
 		return err
 	}
 
+	// 替换 http 节点的 url、headers 与 body
+	if srt.getWorkFlowStep().HTTP != nil {
+		if err := srt.innerSolver.resolveHTTP(); err != nil {
+			return err
+		}
+	}
+
 	var params = make(map[string]string)
 	for paramName, paramValue := range srt.GetParameters() {
 		params[paramName] = fmt.Sprintf("%v", paramValue)
@@ -344,6 +393,17 @@ func (srt *StepRuntime) updateJob(forCacheFingerprint bool) error {
 		for atfName, atfValue := range srt.GetArtifacts().Output {
 			newEnvs[GetOutputArtifactEnvName(atfName)] = GetArtifactMountPath(srt.runConfig.mainFS, atfValue)
 		}
+
+		// 作业可以通过 PF_TRACKING_URI 上报run维度的指标、参数与标签
+		if trackingURI := GetTrackingURI(srt.runID); trackingURI != "" {
+			expireAt := time.Now().Add(config.GlobalServerConfig.ApiServer.GetTrackingTokenExpiration())
+			token, err := common.GenerateTrackingToken(srt.runID, srt.userName, expireAt)
+			if err != nil {
+				return err
+			}
+			newEnvs[EnvNamePFTrackingURI] = trackingURI
+			newEnvs[EnvNamePFTrackingToken] = token
+		}
 	}
 
 	srt.job.Update(srt.getWorkFlowStep().Command, params, newEnvs, &artifacts)
@@ -539,10 +599,10 @@ func (srt *StepRuntime) logCache() error {
 	_, err := srt.callbacks.LogCacheCb(req)
 	if err != nil {
 		return fmt.Errorf("log cache for job[%s], step[%s] with runid[%s] failed: %s",
-			srt.job.(*PaddleFlowJob).ID, srt.name, srt.runID, err.Error())
+			srt.job.JobID(), srt.name, srt.runID, err.Error())
 	} else {
 		InfoMsg := fmt.Sprintf("log cache for job[%s], step[%s] with runid[%s] success",
-			srt.job.(*PaddleFlowJob).ID, srt.name, srt.runID)
+			srt.job.JobID(), srt.name, srt.runID)
 		srt.logger.Infof(InfoMsg)
 		return nil
 	}
@@ -602,7 +662,7 @@ func (srt *StepRuntime) startJob() (err error) {
 		return err
 	}
 
-	if srt.getWorkFlowStep().Cache.Enable {
+	if srt.cacheEnabled() {
 		err = srt.logCache()
 		if err != nil {
 			// 如果 cache 信息存储失败，只打印日志，不做额外处理
@@ -626,7 +686,7 @@ func (srt *StepRuntime) Execute() {
 	srt.logger.Infof(logMsg)
 	logMsg = ""
 	// 1、 查看是否命中cache
-	if srt.getWorkFlowStep().Cache.Enable {
+	if srt.cacheEnabled() {
 		cachedFound, err := srt.checkCached()
 		if err != nil {
 			logMsg = fmt.Sprintf("check cache for step[%s] with runid[%s] failed: [%s]",
@@ -711,12 +771,26 @@ func (srt *StepRuntime) Execute() {
 		return
 	}
 
-	srt.logger.Infof("step[%s] of runid[%s]: jobID[%s]", srt.name, srt.runID, srt.job.(*PaddleFlowJob).ID)
+	srt.logger.Infof("step[%s] of runid[%s]: jobID[%s]", srt.name, srt.runID, srt.job.JobID())
 
 	srt.logInputArtifact()
 }
 
 func (srt *StepRuntime) stopWithMsg(msg string) {
+	if srt.retrying {
+		// 此时上一次运行的 job 已经失败，新的 job 还未发起，因此直接将状态置为 terminated 即可
+		srt.retrying = false
+		err := srt.updateStatus(StatusRuntimeTerminated)
+		if err != nil {
+			srt.logger.Errorf(err.Error())
+		}
+
+		stopMsg := fmt.Sprintf("step[%s] is stopped while waiting for retry: %s", srt.name, msg)
+		view := srt.newJobView(stopMsg)
+		srt.syncToApiServerAndParent(WfEventJobUpdate, &view, stopMsg)
+		return
+	}
+
 	if srt.job.JobID() == "" {
 		// 此时说明还没有创建job，因此直接将状态置为 failed，并通过事件进行同步即可
 		var msg string
@@ -739,7 +813,7 @@ func (srt *StepRuntime) stopWithMsg(msg string) {
 	for {
 		if srt.done {
 			logMsg = fmt.Sprintf("job[%s] step[%s] with runid[%s] has finished, no need to stop",
-				srt.job.(*PaddleFlowJob).ID, srt.name, srt.runID)
+				srt.job.JobID(), srt.name, srt.runID)
 			srt.logger.Infof(logMsg)
 			return
 		}
@@ -747,7 +821,7 @@ func (srt *StepRuntime) stopWithMsg(msg string) {
 		err := srt.job.Stop()
 		if err != nil {
 			ErrMsg := fmt.Sprintf("stop job[%s] for step[%s] with runid[%s] failed [%d] times: [%s]",
-				srt.job.(*PaddleFlowJob).ID, srt.component.GetName(), srt.runID, tryCount, err.Error())
+				srt.job.JobID(), srt.component.GetName(), srt.runID, tryCount, err.Error())
 			srt.logger.Errorf(ErrMsg)
 
 			view := srt.newJobView(ErrMsg)
@@ -764,12 +838,12 @@ func (srt *StepRuntime) stopWithMsg(msg string) {
 // 步骤监控
 func (srt *StepRuntime) processEventFromJob(event WorkflowEvent) {
 	logMsg := fmt.Sprintf("receive event from job[%s] of step[%s]: \n%v",
-		srt.job.(*PaddleFlowJob).ID, srt.name, event)
+		srt.job.JobID(), srt.name, event)
 	srt.logger.Infof(logMsg)
 
 	if event.isJobWatchErr() {
 		ErrMsg := fmt.Sprintf("receive watch error of job[%s] for step[%s] with errmsg:[%s]",
-			srt.job.(*PaddleFlowJob).ID, srt.name, event.Message)
+			srt.job.JobID(), srt.name, event.Message)
 		srt.logger.Errorf(ErrMsg)
 
 		// 对于 WatchErr, 目前不需要传递父节点
@@ -785,11 +859,17 @@ func (srt *StepRuntime) processEventFromJob(event WorkflowEvent) {
 		extra, ok := event.getJobUpdate()
 		if ok {
 			logMsg = fmt.Sprintf("receive watch update of job[%s] step[%s] with runid[%s], with errmsg:[%s], extra[%s]",
-				srt.job.(*PaddleFlowJob).ID, srt.name, srt.runID, event.Message, event.Extra)
+				srt.job.JobID(), srt.name, srt.runID, event.Message, event.Extra)
 			srt.logger.Infof(logMsg)
 		}
 
-		err := srt.updateStatus(extra["status"].(RuntimeStatus))
+		status := extra["status"].(RuntimeStatus)
+		if status == StatusRuntimeFailed && srt.canRetry() {
+			srt.retry(event.Message)
+			return
+		}
+
+		err := srt.updateStatus(status)
 		if err != nil {
 			srt.logger.Errorf(err.Error())
 		}
@@ -798,6 +878,69 @@ func (srt *StepRuntime) processEventFromJob(event WorkflowEvent) {
 	}
 }
 
+// canRetry: 判断节点失败后是否还可以重试
+func (srt *StepRuntime) canRetry() bool {
+	if srt.ctx.Err() != nil || srt.failureOpitonsCtx.Err() != nil {
+		return false
+	}
+	return len(srt.attempts) < srt.getWorkFlowStep().Retry.Limit
+}
+
+// retry: 记录本次失败的 job 信息，并在 backoff 时间后重新发起一个新的 job
+func (srt *StepRuntime) retry(msg string) {
+	job := srt.job.Job()
+	srt.attempts = append(srt.attempts, schema.JobAttempt{
+		JobID:     job.ID,
+		Status:    StatusRuntimeFailed,
+		StartTime: job.StartTime,
+		EndTime:   time.Now().Format("2006-01-02 15:04:05"),
+		Message:   msg,
+	})
+	srt.retrying = true
+
+	retryCount := len(srt.attempts)
+	backoff := srt.getWorkFlowStep().Retry.GetBackoff(retryCount)
+	retryMsg := fmt.Sprintf("job[%s] of step[%s] failed, retry %d/%d after %s", job.ID, srt.name,
+		retryCount, srt.getWorkFlowStep().Retry.Limit, backoff)
+	srt.logger.Infof(retryMsg)
+
+	view := srt.newJobView(retryMsg)
+	srt.syncToApiServerAndParent(WfEventJobUpdate, &view, retryMsg)
+
+	go func() {
+		select {
+		case <-srt.ctx.Done():
+			return
+		case <-srt.failureOpitonsCtx.Done():
+			return
+		case <-time.After(backoff):
+		}
+
+		defer srt.processJobLock.Unlock()
+		srt.processJobLock.Lock()
+		defer srt.catchPanic()
+
+		// 等待期间节点可能已经被终止
+		if !srt.retrying || srt.done {
+			return
+		}
+		srt.retrying = false
+
+		newJob := srt.newJob(job.Name)
+		newJob.Update(job.Command, job.Parameters, job.Env, &job.Artifacts)
+		srt.job = newJob
+
+		if _, err := srt.job.Start(); err != nil {
+			errMsg := fmt.Sprintf("start job for step[%s] with runid[%s] failed when retrying: [%s]",
+				srt.name, srt.runID, err.Error())
+			srt.logger.Errorf(errMsg)
+			srt.processStartAbnormalStatus(errMsg, StatusRuntimeFailed)
+			return
+		}
+		srt.logger.Infof("step[%s] of runid[%s] retried with jobID[%s]", srt.name, srt.runID, srt.job.JobID())
+	}()
+}
+
 func (srt *StepRuntime) newJobView(msg string) schema.JobView {
 	step := srt.getWorkFlowStep()
 	params := map[string]string{}
@@ -831,6 +974,7 @@ func (srt *StepRuntime) newJobView(msg string) schema.JobView {
 		LoopSeq:     srt.loopSeq,
 		Artifacts:   *newArt,
 		ExtraFS:     srt.getWorkFlowStep().ExtraFS,
+		Attempts:    append([]schema.JobAttempt{}, srt.attempts...),
 	}
 
 	return view
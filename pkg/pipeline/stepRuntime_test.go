@@ -240,7 +240,9 @@ func TestUpdateJob(t *testing.T) {
 		if stepName == "data-preprocess" {
 			assert.Equal(t, 2, len(srt.job.Job().Parameters))
 
-			assert.Equal(t, 2+sysNum+2, len(srt.job.Job().Env)) // 4 env + 6 sys param + 2 artifact
+			assert.Equal(t, 2+sysNum+2+2, len(srt.job.Job().Env)) // 4 env + 6 sys param + 2 artifact + 2 tracking
+			assert.Contains(t, srt.job.Job().Env, pplcommon.EnvNamePFTrackingURI)
+			assert.Contains(t, srt.job.Job().Env, pplcommon.EnvNamePFTrackingToken)
 
 			assert.Contains(t, srt.job.Job().Artifacts.Output, "train_data")
 			assert.Contains(t, srt.job.Job().Artifacts.Output, "validate_data")
@@ -265,7 +267,7 @@ func TestUpdateJob(t *testing.T) {
 			assert.Equal(t, "0.66", srt.job.Job().Parameters["p4"])
 			assert.Equal(t, "/path/to/anywhere", srt.job.Job().Parameters["p5"])
 
-			assert.Equal(t, 5+sysNum+2, len(srt.job.Job().Env)) // 5 env + 5 sys param + 2 artifact
+			assert.Equal(t, 5+sysNum+2+2, len(srt.job.Job().Env)) // 5 env + 5 sys param + 2 artifact + 2 tracking
 
 			// input artifact 替换为上游节点的output artifact
 			// 实际运行中上游节点的output artifact一定是非空的（因为已经运行了），但是在这个测试case里，上游节点没有生成output artifact，所以是空字符串
@@ -288,7 +290,7 @@ func TestUpdateJob(t *testing.T) {
 			assert.Contains(t, srt.job.Job().Parameters, "refSystem")
 			assert.Equal(t, "run-000001", srt.job.Job().Parameters["refSystem"])
 
-			assert.Equal(t, 4+sysNum+2, len(srt.job.Job().Env)) // 4 env + 6 sys param + 2 artifact
+			assert.Equal(t, 4+sysNum+2+2, len(srt.job.Job().Env)) // 4 env + 6 sys param + 2 artifact + 2 tracking
 			assert.Contains(t, srt.job.Job().Env, "PF_JOB_QUEUE")
 			assert.Contains(t, srt.job.Job().Env, "PF_JOB_PRIORITY")
 			assert.Contains(t, srt.job.Job().Env, "test_env_1")
@@ -850,3 +852,46 @@ func TestStop(t *testing.T) {
 	assert.Equal(t, 0, srt.CurrentParallelism())
 	assert.True(t, stoped)
 }
+
+func TestStepRuntimeJobType(t *testing.T) {
+	handler.NewFsHandlerWithServer = handler.MockerNewFsHandlerWithServer
+	rf := mockRunConfigForComponentRuntime()
+	rf.callbacks = mockCbs
+	failctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	st := &schema.WorkflowSourceStep{
+		Name:       "notify",
+		Parameters: map[string]interface{}{"model": "resnet 50&stage=prod"},
+		Env:        map[string]string{},
+		HTTP: &schema.HTTPStep{
+			URL:     "http://example.com/deploy?model={{model}}&run={{PF_RUN_ID}}",
+			Method:  "POST",
+			Headers: map[string]string{"X-Model": "{{model}}"},
+			Body:    `{"model": "{{model}}"}`,
+		},
+	}
+	srt := NewStepRuntime("a.entrypoint."+st.Name, "a.entrypoint."+st.Name, st, 0, context.Background(), failctx,
+		make(chan<- WorkflowEvent), rf, "0")
+	_, ok := srt.job.(*HTTPJob)
+	assert.True(t, ok)
+	assert.False(t, srt.cacheEnabled())
+
+	assert.Nil(t, srt.setSysParams())
+	assert.Nil(t, srt.updateJob(false))
+	assert.Equal(t, "http://example.com/deploy?model=resnet%2050%26stage%3Dprod&run="+rf.runID, st.HTTP.URL)
+	assert.Equal(t, "resnet 50&stage=prod", st.HTTP.Headers["X-Model"])
+	assert.Equal(t, `{"model": "resnet 50&stage=prod"}`, st.HTTP.Body)
+
+	st = &schema.WorkflowSourceStep{
+		Name:     "gate",
+		Env:      map[string]string{},
+		Approval: &schema.ApprovalStep{},
+		Cache:    schema.Cache{Enable: true},
+	}
+	srt = NewStepRuntime("a.entrypoint."+st.Name, "a.entrypoint."+st.Name, st, 0, context.Background(), failctx,
+		make(chan<- WorkflowEvent), rf, "0")
+	_, ok = srt.job.(*ApprovalJob)
+	assert.True(t, ok)
+	assert.False(t, srt.cacheEnabled())
+}
@@ -126,6 +126,11 @@ func (bwf *BaseWorkflow) checkAttrRecursively(components map[string]schema.Compo
 			if strings.HasSuffix(step.DockerEnv, ".tar") {
 				return fmt.Errorf("image as tar file is not supported for now")
 			}
+
+			// retry
+			if step.Retry.Limit < 0 || step.Retry.Backoff < 0 {
+				return fmt.Errorf("[retry.limit] and [retry.backoff] of step[%s] should not be negative", name)
+			}
 		} else {
 			return fmt.Errorf("component is not dag or step")
 		}
@@ -666,6 +671,31 @@ func (bwf *BaseWorkflow) checkParams() error {
 	return nil
 }
 
+// ResolvedParameters 返回各节点校验后的参数值，key为 <节点全名>.<参数名>
+// dict形式的参数已被替换为接口传入的值或默认值，因此需要在validate之后调用；被disabled的节点不会被校验，因此不返回
+func (bwf *BaseWorkflow) ResolvedParameters() map[string]interface{} {
+	resolved := map[string]interface{}{}
+	addParams := func(compName string, params map[string]interface{}) {
+		if isDisabled, err := bwf.Source.IsDisabled(compName); err != nil || isDisabled {
+			return
+		}
+		for paramName, value := range params {
+			resolved[compName+"."+paramName] = value
+		}
+	}
+
+	for name, dag := range bwf.runtimeDags {
+		addParams(name, dag.Parameters)
+	}
+	for name, step := range bwf.runtimeSteps {
+		addParams(name, step.Parameters)
+	}
+	for name, step := range bwf.postProcess {
+		addParams(name, step.Parameters)
+	}
+	return resolved
+}
+
 func (bwf *BaseWorkflow) replaceRunParam(param string, val interface{}) error {
 	/*
 		replaceRunParam 用传入的parameter参数值，替换yaml中的parameter默认参数值
@@ -752,9 +782,11 @@ func replaceNodeParam(nodes map[string]schema.Component, compsAndParam []string,
 
 			dictParam := DictParam{}
 			if err := dictParam.From(orgVal); err == nil {
-				if _, err := CheckDictParam(dictParam, paramName, value); err != nil {
+				checkedVal, err := CheckDictParam(dictParam, paramName, value)
+				if err != nil {
 					return false, err
 				}
+				value = checkedVal
 			}
 			dag.Parameters[paramName] = value
 		} else if step, ok := comp.(*schema.WorkflowSourceStep); ok {
@@ -765,9 +797,11 @@ func replaceNodeParam(nodes map[string]schema.Component, compsAndParam []string,
 
 			dictParam := DictParam{}
 			if err := dictParam.From(orgVal); err == nil {
-				if _, err := CheckDictParam(dictParam, paramName, value); err != nil {
+				checkedVal, err := CheckDictParam(dictParam, paramName, value)
+				if err != nil {
 					return false, err
 				}
+				value = checkedVal
 			}
 			step.Parameters[paramName] = value
 		} else {
@@ -786,13 +820,16 @@ func replaceAllNodeParam(entryPoints map[string]schema.Component, paramName stri
 	for _, node := range entryPoints {
 		if dag, ok := node.(*schema.WorkflowSourceDag); ok {
 			if orgVal, ok := dag.Parameters[paramName]; ok {
+				realVal := value
 				dictParam := DictParam{}
 				if err := dictParam.From(orgVal); err == nil {
-					if _, err := CheckDictParam(dictParam, paramName, value); err != nil {
+					checkedVal, err := CheckDictParam(dictParam, paramName, value)
+					if err != nil {
 						return false, err
 					}
+					realVal = checkedVal
 				}
-				dag.Parameters[paramName] = value
+				dag.Parameters[paramName] = realVal
 				isReplace = true
 			}
 			isReplaceSub, err := replaceAllNodeParam(dag.EntryPoints, paramName, value)
@@ -802,13 +839,16 @@ func replaceAllNodeParam(entryPoints map[string]schema.Component, paramName stri
 			isReplace = isReplace || isReplaceSub
 		} else if step, ok := node.(*schema.WorkflowSourceStep); ok {
 			if orgVal, ok := step.Parameters[paramName]; ok {
+				realVal := value
 				dictParam := DictParam{}
 				if err := dictParam.From(orgVal); err == nil {
-					if _, err := CheckDictParam(dictParam, paramName, value); err != nil {
+					checkedVal, err := CheckDictParam(dictParam, paramName, value)
+					if err != nil {
 						return false, err
 					}
+					realVal = checkedVal
 				}
-				step.Parameters[paramName] = value
+				step.Parameters[paramName] = realVal
 				isReplace = true
 			}
 		}
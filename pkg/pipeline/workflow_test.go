@@ -200,6 +200,101 @@ func TestValidateWorkflowParam(t *testing.T) {
 	assert.Equal(t, err.Error(), errMsg)
 }
 
+func TestValidateWorkflow__DictParamRules(t *testing.T) {
+	testCase := loadcase(runYamlPath)
+	wfs, err := schema.GetWorkflowSource([]byte(testCase))
+	assert.Nil(t, err)
+
+	extra := GetExtra()
+	bwf := NewBaseWorkflow(wfs, "", nil, extra)
+	params := bwf.Source.EntryPoints.EntryPoints["main"].GetParameters()
+
+	// bool 类型
+	params["dict"] = map[string]interface{}{"type": "bool", "default": true}
+	err = mockValidate(&bwf)
+	assert.Nil(t, err)
+
+	params["dict"] = map[string]interface{}{"type": "bool", "default": "true"}
+	err = mockValidate(&bwf)
+	assert.NotNil(t, err)
+	assert.Equal(t, pplcommon.InvalidParamTypeError("true", "bool").Error(), err.Error())
+
+	// choices
+	params["dict"] = map[string]interface{}{"type": "string", "default": "adam", "choices": []interface{}{"sgd", "adam"}}
+	err = mockValidate(&bwf)
+	assert.Nil(t, err)
+
+	params["dict"] = map[string]interface{}{"type": "string", "default": "rmsprop", "choices": []interface{}{"sgd", "adam"}}
+	err = mockValidate(&bwf)
+	assert.NotNil(t, err)
+	assert.Equal(t, "invalid value[rmsprop] for param[dict]: should be one of [sgd adam]", err.Error())
+
+	// min/max
+	params["dict"] = map[string]interface{}{"type": "int", "default": int64(10), "min": 1, "max": 100}
+	err = mockValidate(&bwf)
+	assert.Nil(t, err)
+
+	params["dict"] = map[string]interface{}{"type": "float", "default": 0.5, "min": 1}
+	err = mockValidate(&bwf)
+	assert.NotNil(t, err)
+	assert.Equal(t, "invalid value[0.5] for param[dict]: should not be less than 1", err.Error())
+
+	params["dict"] = map[string]interface{}{"type": "string", "default": "a", "max": 1}
+	err = mockValidate(&bwf)
+	assert.NotNil(t, err)
+	assert.Equal(t, "min/max in dict param[dict] only support type[int] and [float]", err.Error())
+
+	// pattern
+	params["dict"] = map[string]interface{}{"type": "string", "default": "v1.0", "pattern": "^v[0-9.]+$"}
+	err = mockValidate(&bwf)
+	assert.Nil(t, err)
+
+	params["dict"] = map[string]interface{}{"type": "string", "default": "latest", "pattern": "^v[0-9.]+$"}
+	err = mockValidate(&bwf)
+	assert.NotNil(t, err)
+	assert.Equal(t, "invalid value[latest] for param[dict]: param[latest] mismatches regex pattern[^v[0-9.]+$]", err.Error())
+}
+
+func TestValidateWorkflow__RunParamTypes(t *testing.T) {
+	testCase := loadcase(runYamlPath)
+	wfs, err := schema.GetWorkflowSource([]byte(testCase))
+	assert.Nil(t, err)
+	wfs.EntryPoints.EntryPoints["main"].GetParameters()["epoch"] = map[string]interface{}{"type": "int", "default": int64(1), "max": 10}
+	wfs.EntryPoints.EntryPoints["main"].GetParameters()["layers"] = map[string]interface{}{"type": "list", "default": []interface{}{1, 2}}
+
+	// 通过接口传入的参数经过json反序列化，整数为float64类型，list为[]interface{}类型
+	params := map[string]interface{}{
+		"epoch":  float64(5),
+		"layers": []interface{}{float64(3), float64(4)},
+	}
+	bwf := NewBaseWorkflow(wfs, "", params, GetExtra())
+	err = mockValidate(&bwf)
+	assert.Nil(t, err)
+
+	resolved := bwf.ResolvedParameters()
+	assert.Equal(t, int64(5), resolved["main.epoch"])
+	assert.Equal(t, []interface{}{float64(3), float64(4)}, resolved["main.layers"])
+	assert.Equal(t, "dictparam", resolved["main.p3"])
+	assert.Equal(t, 0.66, resolved["main.p4"])
+
+	// 非整数值以及超出范围的值
+	wfs, err = schema.GetWorkflowSource([]byte(testCase))
+	assert.Nil(t, err)
+	wfs.EntryPoints.EntryPoints["main"].GetParameters()["epoch"] = map[string]interface{}{"type": "int", "default": int64(1), "max": 10}
+	bwf = NewBaseWorkflow(wfs, "", map[string]interface{}{"main.epoch": 1.5}, GetExtra())
+	err = mockValidate(&bwf)
+	assert.NotNil(t, err)
+	assert.Equal(t, pplcommon.InvalidParamTypeError(1.5, "int").Error(), err.Error())
+
+	wfs, err = schema.GetWorkflowSource([]byte(testCase))
+	assert.Nil(t, err)
+	wfs.EntryPoints.EntryPoints["main"].GetParameters()["epoch"] = map[string]interface{}{"type": "int", "default": int64(1), "max": 10}
+	bwf = NewBaseWorkflow(wfs, "", map[string]interface{}{"main.epoch": float64(20)}, GetExtra())
+	err = mockValidate(&bwf)
+	assert.NotNil(t, err)
+	assert.Equal(t, "invalid value[20] for param[epoch]: should not be greater than 10", err.Error())
+}
+
 func TestValidateWorkflow__DictParam(t *testing.T) {
 	testCase := loadcase(runYamlPath)
 	wfs, err := schema.GetWorkflowSource([]byte(testCase))
@@ -0,0 +1,93 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+)
+
+type AlertStore struct {
+	db *gorm.DB
+}
+
+func newAlertStore(db *gorm.DB) *AlertStore {
+	return &AlertStore{db: db}
+}
+
+func (as *AlertStore) CreateAlertRule(logEntry *log.Entry, rule *model.AlertRule) error {
+	logEntry.Debugf("begin create alert rule: %+v", rule)
+	tx := as.db.Model(&model.AlertRule{}).Create(rule)
+	if tx.Error != nil {
+		logEntry.Errorf("create alert rule failed. error:%v", tx.Error)
+		return tx.Error
+	}
+	return nil
+}
+
+func (as *AlertStore) GetAlertRule(logEntry *log.Entry, name string) (model.AlertRule, error) {
+	logEntry.Debugf("begin get alert rule[%s]", name)
+	var rule model.AlertRule
+	tx := as.db.Model(&model.AlertRule{}).Where("name = ?", name).First(&rule)
+	if tx.Error != nil {
+		logEntry.Errorf("get alert rule[%s] failed. error:%v", name, tx.Error)
+		return model.AlertRule{}, tx.Error
+	}
+	return rule, nil
+}
+
+// ListAlertRule 按创建顺序分页列出告警规则，enabledOnly为true时只返回启用的规则
+func (as *AlertStore) ListAlertRule(logEntry *log.Entry, pk int64, maxKeys int, enabledOnly bool) ([]model.AlertRule, error) {
+	logEntry.Debugf("begin list alert rule. pk:%d, maxKeys:%d, enabledOnly:%v", pk, maxKeys, enabledOnly)
+	tx := as.db.Model(&model.AlertRule{}).Where("pk > ?", pk)
+	if enabledOnly {
+		tx = tx.Where("enabled = ?", true)
+	}
+	if maxKeys > 0 {
+		tx = tx.Limit(maxKeys)
+	}
+	var rules []model.AlertRule
+	tx = tx.Order("pk").Find(&rules)
+	if tx.Error != nil {
+		logEntry.Errorf("list alert rule failed. error:%v", tx.Error)
+		return nil, tx.Error
+	}
+	return rules, nil
+}
+
+// UpdateAlertRule 覆盖保存告警规则的全部字段
+func (as *AlertStore) UpdateAlertRule(logEntry *log.Entry, rule *model.AlertRule) error {
+	logEntry.Debugf("begin update alert rule: %+v", rule)
+	tx := as.db.Save(rule)
+	if tx.Error != nil {
+		logEntry.Errorf("update alert rule[%s] failed. error:%v", rule.Name, tx.Error)
+		return tx.Error
+	}
+	return nil
+}
+
+func (as *AlertStore) DeleteAlertRule(logEntry *log.Entry, name string) error {
+	logEntry.Debugf("begin delete alert rule[%s]", name)
+	tx := as.db.Where("name = ?", name).Delete(&model.AlertRule{})
+	if tx.Error != nil {
+		logEntry.Errorf("delete alert rule[%s] failed. error:%v", name, tx.Error)
+		return tx.Error
+	}
+	return nil
+}
@@ -17,7 +17,11 @@ limitations under the License.
 package storage
 
 import (
+	"errors"
+	"time"
+
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
@@ -27,10 +31,12 @@ import (
 
 type AuthStore struct {
 	db *gorm.DB
+	// quotaCache contains user quotas by user name, including users without quota
+	quotaCache *lookupCache
 }
 
 func newAuthStore(db *gorm.DB) *AuthStore {
-	return &AuthStore{db: db}
+	return &AuthStore{db: db, quotaCache: newLookupCache()}
 }
 
 // ============================================================= table user ============================================================= //
@@ -46,9 +52,17 @@ func (as *AuthStore) CreateUser(ctx *logger.RequestContext, user *model.User) er
 	return nil
 }
 
+// UpdateUser 更新密码，同时解除锁定并使未使用的重置token失效
 func (as *AuthStore) UpdateUser(ctx *logger.RequestContext, userName, password string) error {
 	ctx.Logging().Debugf("model update user's password, userName:%v.", userName)
-	err := as.db.Model(&model.User{}).Where("name = ?", userName).UpdateColumn("password", password).Error
+	err := as.db.Model(&model.User{}).Where("name = ?", userName).UpdateColumns(map[string]interface{}{
+		"password":              password,
+		"password_updated_at":   time.Now(),
+		"failed_logins":         0,
+		"locked_until":          nil,
+		"reset_token_hash":      "",
+		"reset_token_expire_at": nil,
+	}).Error
 	if err != nil {
 		ctx.Logging().Errorf("model update password failed . userName:%v, error:%s ",
 			userName, err)
@@ -56,12 +70,48 @@ func (as *AuthStore) UpdateUser(ctx *logger.RequestContext, userName, password s
 	return err
 }
 
-func (as *AuthStore) ListUser(ctx *logger.RequestContext, pk int64, maxKey int) ([]model.User, error) {
+func (as *AuthStore) UpdateUserEmail(ctx *logger.RequestContext, userName, email string) error {
+	ctx.Logging().Debugf("model update user's email, userName:%v.", userName)
+	err := as.db.Model(&model.User{}).Where("name = ?", userName).UpdateColumn("email", email).Error
+	if err != nil {
+		ctx.Logging().Errorf("model update email failed. userName:%v, error:%s", userName, err)
+	}
+	return err
+}
+
+// UpdateUserLoginFailures 记录连续登录失败次数，lockedUntil非空时锁定账号
+func (as *AuthStore) UpdateUserLoginFailures(ctx *logger.RequestContext, userName string, failedLogins int, lockedUntil *time.Time) error {
+	err := as.db.Model(&model.User{}).Where("name = ?", userName).UpdateColumns(map[string]interface{}{
+		"failed_logins": failedLogins,
+		"locked_until":  lockedUntil,
+	}).Error
+	if err != nil {
+		ctx.Logging().Errorf("model update login failures failed. userName:%v, error:%s", userName, err)
+	}
+	return err
+}
+
+// UpdateUserResetToken 保存重置密码token的摘要及过期时间，新token会覆盖未使用的旧token
+func (as *AuthStore) UpdateUserResetToken(ctx *logger.RequestContext, userName, tokenHash string, expireAt *time.Time) error {
+	err := as.db.Model(&model.User{}).Where("name = ?", userName).UpdateColumns(map[string]interface{}{
+		"reset_token_hash":      tokenHash,
+		"reset_token_expire_at": expireAt,
+	}).Error
+	if err != nil {
+		ctx.Logging().Errorf("model update reset token failed. userName:%v, error:%s", userName, err)
+	}
+	return err
+}
+
+func (as *AuthStore) ListUser(ctx *logger.RequestContext, pk int64, maxKey int, project string) ([]model.User, error) {
 	ctx.Logging().Debugf("model begin list user.")
 	var userList []model.User
 	query := DB.Where(&model.User{})
 	query.Where("name != ?", UserROOT)
 	query.Where("pk > ?", pk)
+	if project != "" {
+		query.Where("name IN (?)", projectResourceQuery(as.db, project, common.ResourceTypeUser))
+	}
 	if maxKey > 0 {
 		query.Limit(maxKey)
 	}
@@ -158,7 +208,14 @@ func (as *AuthStore) HasAccessToResource(ctx *logger.RequestContext, resourceTyp
 	if num > 0 {
 		return true
 	}
-	return false
+	// 项目成员可以访问项目中的资源
+	tx = as.db.Model(&model.ProjectMember{}).Where("resource_type = ? and resource_id = ? and project_name IN (?)",
+		resourceType, resourceID, userProjectQuery(as.db, ctx.UserName)).Count(&num)
+	if tx.Error != nil {
+		ctx.Logging().Errorf("deny access to resourceID[%s] resourceType[%s].", resourceID, resourceType)
+		return false
+	}
+	return num > 0
 }
 
 func (as *AuthStore) DeleteGrantByUserName(ctx *logger.RequestContext, userName string) error {
@@ -211,3 +268,229 @@ func (as *AuthStore) GetLastGrant(ctx *logger.RequestContext) (model.Grant, erro
 	}
 	return grant, nil
 }
+
+// ============================================================= table user_preference ============================================================= //
+
+func (as *AuthStore) GetUserPreference(ctx *logger.RequestContext, userName string) (model.UserPreference, error) {
+	ctx.Logging().Debugf("model begin get user preference. userName:%s. ", userName)
+	var preference model.UserPreference
+	tx := as.db.Model(&model.UserPreference{}).Where("user_name = ?", userName).First(&preference)
+	if tx.Error != nil {
+		return model.UserPreference{}, tx.Error
+	}
+	return preference, nil
+}
+
+// SaveUserPreference 创建或覆盖用户的默认设置
+func (as *AuthStore) SaveUserPreference(ctx *logger.RequestContext, preference *model.UserPreference) error {
+	ctx.Logging().Debugf("model begin save user preference. preference:%+v. ", preference)
+	tx := as.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"queue", "flavour", "image", "fs", "updated_at"}),
+	}).Create(preference)
+	if tx.Error != nil {
+		ctx.Logging().Errorf("model save user preference failed. userName:%s, error:%s", preference.UserName, tx.Error.Error())
+		return tx.Error
+	}
+	return nil
+}
+
+func (as *AuthStore) DeleteUserPreference(ctx *logger.RequestContext, userName string) error {
+	ctx.Logging().Debugf("model begin delete user preference. userName:%s. ", userName)
+	tx := as.db.Where("user_name = ?", userName).Delete(&model.UserPreference{})
+	if tx.Error != nil {
+		ctx.Logging().Errorf("model delete user preference failed. userName:%s, error:%s", userName, tx.Error.Error())
+		return tx.Error
+	}
+	return nil
+}
+
+// ============================================================= table user_quota ============================================================= //
+
+func (as *AuthStore) GetUserQuota(ctx *logger.RequestContext, userName string) (model.UserQuota, error) {
+	ctx.Logging().Debugf("model begin get user quota. userName:%s. ", userName)
+	var quota model.UserQuota
+	tx := as.db.Model(&model.UserQuota{}).Where("user_name = ?", userName).First(&quota)
+	if tx.Error != nil {
+		return model.UserQuota{}, tx.Error
+	}
+	return quota, nil
+}
+
+// GetCachedUserQuota 从缓存获取用户配额，未设置配额的用户同样被缓存，返回RecordNotFound错误
+func (as *AuthStore) GetCachedUserQuota(ctx *logger.RequestContext, userName string) (model.UserQuota, error) {
+	value, err := as.quotaCache.get(userName, func() (interface{}, error) {
+		quota, err := as.GetUserQuota(ctx, userName)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return (*model.UserQuota)(nil), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &quota, nil
+	})
+	if err != nil {
+		return model.UserQuota{}, err
+	}
+	quota := value.(*model.UserQuota)
+	if quota == nil {
+		return model.UserQuota{}, gorm.ErrRecordNotFound
+	}
+	return *quota, nil
+}
+
+// ListUserQuotaWithDuration 列出设置了最长运行时间的用户配额
+func (as *AuthStore) ListUserQuotaWithDuration(ctx *logger.RequestContext) ([]model.UserQuota, error) {
+	ctx.Logging().Debugf("model begin list user quota with max job duration.")
+	var quotas []model.UserQuota
+	tx := as.db.Model(&model.UserQuota{}).Where("max_job_duration > 0").Find(&quotas)
+	if tx.Error != nil {
+		ctx.Logging().Errorf("model list user quota failed. error:%s", tx.Error.Error())
+		return nil, tx.Error
+	}
+	return quotas, nil
+}
+
+// SaveUserQuota 创建或覆盖用户配额
+func (as *AuthStore) SaveUserQuota(ctx *logger.RequestContext, quota *model.UserQuota) error {
+	ctx.Logging().Debugf("model begin save user quota. quota:%+v. ", quota)
+	defer as.quotaCache.purge()
+	tx := as.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"max_concurrent_jobs", "max_gpus", "max_job_duration", "updated_at"}),
+	}).Create(quota)
+	if tx.Error != nil {
+		ctx.Logging().Errorf("model save user quota failed. userName:%s, error:%s", quota.UserName, tx.Error.Error())
+		return tx.Error
+	}
+	return nil
+}
+
+func (as *AuthStore) DeleteUserQuota(ctx *logger.RequestContext, userName string) error {
+	ctx.Logging().Debugf("model begin delete user quota. userName:%s. ", userName)
+	defer as.quotaCache.purge()
+	tx := as.db.Where("user_name = ?", userName).Delete(&model.UserQuota{})
+	if tx.Error != nil {
+		ctx.Logging().Errorf("model delete user quota failed. userName:%s, error:%s", userName, tx.Error.Error())
+		return tx.Error
+	}
+	return nil
+}
+
+// ============================================================= table user_session ============================================================= //
+
+func (as *AuthStore) CreateSession(ctx *logger.RequestContext, session *model.UserSession) error {
+	ctx.Logging().Debugf("model begin create session. userName:%s, session:%s", session.UserName, session.ID)
+	tx := as.db.Model(&model.UserSession{}).Create(session)
+	if tx.Error != nil {
+		ctx.Logging().Errorf("model create session failed. session:%s, error:%s", session.ID, tx.Error.Error())
+		return tx.Error
+	}
+	return nil
+}
+
+func (as *AuthStore) GetSession(ctx *logger.RequestContext, sessionID string) (model.UserSession, error) {
+	ctx.Logging().Debugf("model begin get session. session:%s", sessionID)
+	var session model.UserSession
+	tx := as.db.Model(&model.UserSession{}).Where("id = ?", sessionID).First(&session)
+	if tx.Error != nil {
+		return model.UserSession{}, tx.Error
+	}
+	return session, nil
+}
+
+// ListActiveSessions 列出用户未撤销且未过期的会话，按最近活跃时间倒序
+func (as *AuthStore) ListActiveSessions(ctx *logger.RequestContext, userName string) ([]model.UserSession, error) {
+	ctx.Logging().Debugf("model begin list sessions. userName:%s", userName)
+	var sessions []model.UserSession
+	tx := as.db.Model(&model.UserSession{}).
+		Where("user_name = ? AND revoked_at IS NULL AND (expire_at IS NULL OR expire_at > ?)", userName, time.Now()).
+		Order("last_active_at DESC").Find(&sessions)
+	if tx.Error != nil {
+		ctx.Logging().Errorf("model list sessions failed. userName:%s, error:%s", userName, tx.Error.Error())
+		return nil, tx.Error
+	}
+	return sessions, nil
+}
+
+func (as *AuthStore) UpdateSessionActiveTime(ctx *logger.RequestContext, sessionID string, activeAt time.Time) error {
+	tx := as.db.Model(&model.UserSession{}).Where("id = ?", sessionID).
+		UpdateColumn("last_active_at", activeAt)
+	if tx.Error != nil {
+		ctx.Logging().Errorf("model update session active time failed. session:%s, error:%s", sessionID, tx.Error.Error())
+		return tx.Error
+	}
+	return nil
+}
+
+func (as *AuthStore) RevokeSession(ctx *logger.RequestContext, sessionID string) error {
+	ctx.Logging().Debugf("model begin revoke session. session:%s", sessionID)
+	tx := as.db.Model(&model.UserSession{}).Where("id = ? AND revoked_at IS NULL", sessionID).
+		Updates(map[string]interface{}{"revoked_at": time.Now(), "updated_at": time.Now()})
+	if tx.Error != nil {
+		ctx.Logging().Errorf("model revoke session failed. session:%s, error:%s", sessionID, tx.Error.Error())
+		return tx.Error
+	}
+	return nil
+}
+
+// RevokeUserSessions 撤销用户的全部会话，exceptID不为空时保留该会话
+func (as *AuthStore) RevokeUserSessions(ctx *logger.RequestContext, userName, exceptID string) (int64, error) {
+	ctx.Logging().Debugf("model begin revoke sessions of user. userName:%s", userName)
+	query := as.db.Model(&model.UserSession{}).Where("user_name = ? AND revoked_at IS NULL", userName)
+	if exceptID != "" {
+		query = query.Where("id <> ?", exceptID)
+	}
+	tx := query.Updates(map[string]interface{}{"revoked_at": time.Now(), "updated_at": time.Now()})
+	if tx.Error != nil {
+		ctx.Logging().Errorf("model revoke sessions of user failed. userName:%s, error:%s", userName, tx.Error.Error())
+		return 0, tx.Error
+	}
+	return tx.RowsAffected, nil
+}
+
+// DeleteExpiredSessions 清理撤销或过期时间早于before的会话
+func (as *AuthStore) DeleteExpiredSessions(ctx *logger.RequestContext, before time.Time) error {
+	tx := as.db.Where("revoked_at < ? OR expire_at < ?", before, before).Delete(&model.UserSession{})
+	if tx.Error != nil {
+		ctx.Logging().Errorf("model delete expired sessions failed. error:%s", tx.Error.Error())
+		return tx.Error
+	}
+	return nil
+}
+
+// ============================================================= table impersonation_audit ============================================================= //
+
+func (as *AuthStore) CreateImpersonationAudit(ctx *logger.RequestContext, audit *model.ImpersonationAudit) error {
+	tx := as.db.Model(&model.ImpersonationAudit{}).Create(audit)
+	if tx.Error != nil {
+		ctx.Logging().Errorf("model create impersonation audit failed. operator:%s, userName:%s, error:%s",
+			audit.Operator, audit.UserName, tx.Error.Error())
+		return tx.Error
+	}
+	return nil
+}
+
+// ListImpersonationAudit 按时间倒序列出审计记录，pk大于0时只返回更早的记录
+func (as *AuthStore) ListImpersonationAudit(ctx *logger.RequestContext, pk int64, maxKeys int, operator, userName string) ([]model.ImpersonationAudit, error) {
+	ctx.Logging().Debugf("model begin list impersonation audit. operator:%s, userName:%s", operator, userName)
+	query := as.db.Model(&model.ImpersonationAudit{})
+	if pk > 0 {
+		query = query.Where("pk < ?", pk)
+	}
+	if operator != "" {
+		query = query.Where("operator = ?", operator)
+	}
+	if userName != "" {
+		query = query.Where("user_name = ?", userName)
+	}
+	if maxKeys > 0 {
+		query = query.Limit(maxKeys)
+	}
+	var audits []model.ImpersonationAudit
+	if err := query.Order("pk DESC").Find(&audits).Error; err != nil {
+		ctx.Logging().Errorf("model list impersonation audit failed. error:%s", err.Error())
+		return nil, err
+	}
+	return audits, nil
+}
@@ -21,6 +21,7 @@ import (
 
 	log "github.com/sirupsen/logrus"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/uuid"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
@@ -148,6 +149,16 @@ func (cs *ClusterStore) UpdateCluster(clusterId string, clusterInfo *model.Clust
 	return nil
 }
 
+// UpdateClusterCredential 更新集群的凭证，不经过BeforeSave，credential需要是加密后的数据
+func (cs *ClusterStore) UpdateClusterCredential(clusterId string, credential string) error {
+	err := cs.db.Table("cluster_info").Where("id = ?", clusterId).UpdateColumn("credential", credential).Error
+	if err != nil {
+		log.Errorf("update credential of cluster failed. clusterId:%s, error:%s", clusterId, err.Error())
+		return err
+	}
+	return nil
+}
+
 func (cs *ClusterStore) ActiveClusters() []model.ClusterInfo {
 	tx := cs.db.Table("cluster_info").Where("deleted_at = '' ")
 
@@ -158,3 +169,26 @@ func (cs *ClusterStore) ActiveClusters() []model.ClusterInfo {
 	}
 	return clusterList
 }
+
+// SaveClusterComponent 保存集群组件的版本和安装状态，同一集群的同名组件已存在时更新
+func (cs *ClusterStore) SaveClusterComponent(component *model.ClusterComponent) error {
+	tx := cs.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "cluster_id"}, {Name: "name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"version", "status", "message", "updated_at"}),
+	}).Create(component)
+	if tx.Error != nil {
+		log.Errorf("save component %s of cluster %s failed. error:%s", component.Name, component.ClusterID, tx.Error.Error())
+		return tx.Error
+	}
+	return nil
+}
+
+func (cs *ClusterStore) ListClusterComponents(clusterId string) ([]model.ClusterComponent, error) {
+	var components []model.ClusterComponent
+	tx := cs.db.Where("cluster_id = ?", clusterId).Order("name").Find(&components)
+	if tx.Error != nil {
+		log.Errorf("list components of cluster %s failed. error:%s", clusterId, tx.Error.Error())
+		return nil, tx.Error
+	}
+	return components, nil
+}
@@ -0,0 +1,147 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+)
+
+type DatasetStore struct {
+	db *gorm.DB
+}
+
+func newDatasetStore(db *gorm.DB) *DatasetStore {
+	return &DatasetStore{db: db}
+}
+
+func (ds *DatasetStore) CreateDataset(logEntry *log.Entry, dataset *model.Dataset) error {
+	logEntry.Debugf("begin create dataset: %+v", dataset)
+	tx := ds.db.Model(&model.Dataset{}).Create(dataset)
+	if tx.Error != nil {
+		logEntry.Errorf("create dataset failed. error:%v", tx.Error)
+		return tx.Error
+	}
+	return nil
+}
+
+func (ds *DatasetStore) GetDataset(logEntry *log.Entry, userName, name string) (model.Dataset, error) {
+	logEntry.Debugf("begin get dataset[%s] of user[%s]", name, userName)
+	var dataset model.Dataset
+	tx := ds.db.Model(&model.Dataset{}).Where("user_name = ? AND name = ?", userName, name).First(&dataset)
+	if tx.Error != nil {
+		logEntry.Errorf("get dataset[%s] of user[%s] failed. error:%v", name, userName, tx.Error)
+		return model.Dataset{}, tx.Error
+	}
+	return dataset, nil
+}
+
+// ListDataset 非root用户只能看到自己注册的数据集
+func (ds *DatasetStore) ListDataset(logEntry *log.Entry, pk int64, maxKeys int, userName, fsName string) ([]model.Dataset, error) {
+	logEntry.Debugf("begin list dataset. pk:%d, maxKeys:%d, userName:%s, fsName:%s", pk, maxKeys, userName, fsName)
+	tx := ds.db.Model(&model.Dataset{}).Where("pk > ?", pk)
+	if !common.IsRootUser(userName) {
+		tx = tx.Where("user_name = ?", userName)
+	}
+	if fsName != "" {
+		tx = tx.Where("fs_name = ?", fsName)
+	}
+	if maxKeys > 0 {
+		tx = tx.Limit(maxKeys)
+	}
+	var datasets []model.Dataset
+	tx = tx.Order("pk").Find(&datasets)
+	if tx.Error != nil {
+		logEntry.Errorf("list dataset failed. error:%v", tx.Error)
+		return nil, tx.Error
+	}
+	return datasets, nil
+}
+
+// DeleteDataset 删除数据集及其全部版本
+func (ds *DatasetStore) DeleteDataset(logEntry *log.Entry, id string) error {
+	logEntry.Debugf("begin delete dataset[%s]", id)
+	return ds.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("dataset_id = ?", id).Delete(&model.DatasetVersion{}).Error; err != nil {
+			logEntry.Errorf("delete versions of dataset[%s] failed. error:%v", id, err)
+			return err
+		}
+		if err := tx.Where("id = ?", id).Delete(&model.Dataset{}).Error; err != nil {
+			logEntry.Errorf("delete dataset[%s] failed. error:%v", id, err)
+			return err
+		}
+		return nil
+	})
+}
+
+// CreateDatasetVersion 锁定数据集后按已有版本的最大序号+1生成版本号，创建版本并更新数据集的最新版本
+func (ds *DatasetStore) CreateDatasetVersion(logEntry *log.Entry, version *model.DatasetVersion) error {
+	logEntry.Debugf("begin create version of dataset[%s]", version.DatasetID)
+	return ds.db.Transaction(func(tx *gorm.DB) error {
+		var dataset model.Dataset
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", version.DatasetID).
+			First(&dataset).Error; err != nil {
+			logEntry.Errorf("lock dataset[%s] failed. error:%v", version.DatasetID, err)
+			return err
+		}
+		var versions []string
+		if err := tx.Model(&model.DatasetVersion{}).Where("dataset_id = ?", version.DatasetID).
+			Pluck("version", &versions).Error; err != nil {
+			logEntry.Errorf("list versions of dataset[%s] failed. error:%v", version.DatasetID, err)
+			return err
+		}
+		version.Version = model.NextDatasetVersion(versions)
+		if err := tx.Model(&model.DatasetVersion{}).Create(version).Error; err != nil {
+			logEntry.Errorf("create version[%s] of dataset[%s] failed. error:%v", version.Version, version.DatasetID, err)
+			return err
+		}
+		if err := tx.Model(&model.Dataset{}).Where("id = ?", version.DatasetID).
+			Update("latest_version", version.Version).Error; err != nil {
+			logEntry.Errorf("update latest version of dataset[%s] failed. error:%v", version.DatasetID, err)
+			return err
+		}
+		return nil
+	})
+}
+
+func (ds *DatasetStore) GetDatasetVersion(logEntry *log.Entry, datasetID, version string) (model.DatasetVersion, error) {
+	logEntry.Debugf("begin get version[%s] of dataset[%s]", version, datasetID)
+	var datasetVersion model.DatasetVersion
+	tx := ds.db.Model(&model.DatasetVersion{}).Where("dataset_id = ? AND version = ?", datasetID, version).First(&datasetVersion)
+	if tx.Error != nil {
+		logEntry.Errorf("get version[%s] of dataset[%s] failed. error:%v", version, datasetID, tx.Error)
+		return model.DatasetVersion{}, tx.Error
+	}
+	return datasetVersion, nil
+}
+
+// ListDatasetVersion 列出数据集的全部版本，不包含文件清单
+func (ds *DatasetStore) ListDatasetVersion(logEntry *log.Entry, datasetID string) ([]model.DatasetVersion, error) {
+	logEntry.Debugf("begin list versions of dataset[%s]", datasetID)
+	var versions []model.DatasetVersion
+	tx := ds.db.Model(&model.DatasetVersion{}).Omit("manifest").Where("dataset_id = ?", datasetID).
+		Order("pk").Find(&versions)
+	if tx.Error != nil {
+		logEntry.Errorf("list versions of dataset[%s] failed. error:%v", datasetID, tx.Error)
+		return nil, tx.Error
+	}
+	return versions, nil
+}
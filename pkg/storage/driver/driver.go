@@ -206,6 +206,11 @@ func createDatabaseTables(db *gorm.DB) error {
 		&models.Schedule{},
 		&models.RunCache{},
 		&model.ArtifactEvent{},
+		&model.RunMetric{},
+		&model.RunParam{},
+		&model.Visualization{},
+		&model.Dataset{},
+		&model.DatasetVersion{},
 		&model.User{},
 		&models.Run{},
 		&models.RunJob{},
@@ -216,11 +221,35 @@ func createDatabaseTables(db *gorm.DB) error {
 		&model.Job{},
 		&model.JobTask{},
 		&model.JobLabel{},
+		&model.JobMetric{},
+		&model.JobArtifact{},
+		&model.JobTimelineEvent{},
 		&model.ClusterInfo{},
 		&model.Image{},
 		&model.FileSystem{},
 		&model.Link{},
 		&model.FSCacheConfig{},
 		&model.FSCache{},
+		&model.FSDataLoad{},
+		&model.FSTransfer{},
+		&model.FsUsage{},
+		&model.FsAcl{},
+		&model.FsReplication{},
+		&model.FsLifecycleRule{},
+		&model.ImageBuild{},
+		&model.UserPreference{},
+		&model.Project{},
+		&model.ProjectMember{},
+		&model.UserQuota{},
+		&model.UserSession{},
+		&model.ImpersonationAudit{},
+		&model.QueueHourlyStat{},
+		&model.UserHourlyStat{},
+		&model.ImageHourlyStat{},
+		&model.AlertRule{},
+		&model.ExportWatermark{},
+		&model.ResourceEvent{},
+		&model.ClusterComponent{},
+		&model.NodePool{},
 	)
 }
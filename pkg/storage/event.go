@@ -0,0 +1,79 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/uuid"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+)
+
+const eventIDLength = 16
+
+// eventsEnabled 开启事件总线后为1，未开启时不记录事件
+var eventsEnabled int32
+
+// EnableEvents 开始记录资源状态变化事件
+func EnableEvents() {
+	atomic.StoreInt32(&eventsEnabled, 1)
+}
+
+type EventStore struct {
+	db *gorm.DB
+}
+
+func newEventStore(db *gorm.DB) *EventStore {
+	return &EventStore{db: db}
+}
+
+// RecordEvent 在资源变更的事务tx中写入状态变化事件，未开启事件总线时不记录
+func RecordEvent(tx *gorm.DB, event *model.ResourceEvent) error {
+	if atomic.LoadInt32(&eventsEnabled) == 0 {
+		return nil
+	}
+	if event.Type == model.EventStatusChanged && event.Status == event.PreviousStatus {
+		return nil
+	}
+	event.ID = uuid.GenerateIDWithLength(common.PrefixEvent, eventIDLength)
+	return tx.Create(event).Error
+}
+
+// ListUnpublished 按写入顺序列出至多limit条未发布的事件
+func (es *EventStore) ListUnpublished(limit int) ([]model.ResourceEvent, error) {
+	var events []model.ResourceEvent
+	tx := es.db.Where("published = ?", false).Order("pk").Limit(limit).Find(&events)
+	return events, tx.Error
+}
+
+func (es *EventStore) MarkPublished(pks []int64, publishedAt time.Time) error {
+	if len(pks) == 0 {
+		return nil
+	}
+	return es.db.Model(&model.ResourceEvent{}).Where("pk IN ?", pks).
+		Updates(map[string]interface{}{"published": true, "published_at": publishedAt}).Error
+}
+
+// DeletePublishedBefore 删除t之前已发布的事件，返回删除的数量
+func (es *EventStore) DeletePublishedBefore(t time.Time) (int64, error) {
+	tx := es.db.Where("published = ? AND published_at < ?", true, t).Delete(&model.ResourceEvent{})
+	return tx.RowsAffected, tx.Error
+}
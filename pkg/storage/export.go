@@ -0,0 +1,58 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+)
+
+type ExportStore struct {
+	db *gorm.DB
+}
+
+func newExportStore(db *gorm.DB) *ExportStore {
+	return &ExportStore{db: db}
+}
+
+// GetWatermark 返回导出水位，尚未导出过时返回零值
+func (es *ExportStore) GetWatermark(name string) (model.ExportWatermark, error) {
+	watermark := model.ExportWatermark{Name: name}
+	tx := es.db.Where("name = ?", name).Limit(1).Find(&watermark)
+	if tx.Error != nil {
+		return model.ExportWatermark{}, tx.Error
+	}
+	return watermark, nil
+}
+
+func (es *ExportStore) SaveWatermark(watermark *model.ExportWatermark) error {
+	return es.db.Save(watermark).Error
+}
+
+// ListAfterWatermark 按(timeColumn, pk)顺序列出table中水位之后、早于end的至多limit条记录，包含已删除的记录
+func (es *ExportStore) ListAfterWatermark(table, timeColumn string, watermark model.ExportWatermark,
+	end time.Time, limit int, records interface{}) error {
+	return es.db.Unscoped().Table(table).
+		Where(fmt.Sprintf("%s > ? OR (%s = ? AND pk > ?)", timeColumn, timeColumn),
+			watermark.Time, watermark.Time, watermark.RecordPk).
+		Where(fmt.Sprintf("%s < ?", timeColumn), end).
+		Order(fmt.Sprintf("%s, pk", timeColumn)).Limit(limit).Find(records).Error
+}
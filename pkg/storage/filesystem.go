@@ -22,6 +22,7 @@ import (
 
 	"gorm.io/gorm"
 
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
 )
 
@@ -40,7 +41,8 @@ func (fss *FilesystemStore) CreatFileSystem(fs *model.FileSystem) error {
 		if err := tx.Create(fs).Error; err != nil {
 			return err
 		}
-		return nil
+		return RecordEvent(tx, &model.ResourceEvent{ResourceType: common.ResourceTypeFs, ResourceID: fs.ID,
+			Type: model.EventCreated, UserName: fs.UserName})
 	})
 }
 
@@ -54,14 +56,38 @@ func (fss *FilesystemStore) DeleteFileSystem(tx *gorm.DB, id string) error {
 	if tx == nil {
 		tx = fss.db
 	}
-	return tx.Delete(&model.FileSystem{Model: model.Model{ID: id}}).Error
+	if err := tx.Delete(&model.FileSystem{Model: model.Model{ID: id}}).Error; err != nil {
+		return err
+	}
+	return RecordEvent(tx, &model.ResourceEvent{ResourceType: common.ResourceTypeFs, ResourceID: id, Type: model.EventDeleted})
+}
+
+// UpdateFileSystemTarget updates the storage address of fs, which is used to switch fs to its replica
+func (fss *FilesystemStore) UpdateFileSystemTarget(tx *gorm.DB, fs *model.FileSystem) error {
+	if tx == nil {
+		tx = fss.db
+	}
+	propertiesJson, err := json.Marshal(fs.PropertiesMap)
+	if err != nil {
+		return err
+	}
+	return tx.Model(&model.FileSystem{}).Where("id = ?", fs.ID).Updates(map[string]interface{}{
+		"type":                      fs.Type,
+		"server_address":            fs.ServerAddress,
+		"subpath":                   fs.SubPath,
+		"properties":                string(propertiesJson),
+		"independent_mount_process": fs.IndependentMountProcess,
+	}).Error
 }
 
 // ListFileSystem get file systems with marker and limit sort by create_at desc
-func (fss *FilesystemStore) ListFileSystem(limit int, userName, marker, fsName string) ([]model.FileSystem, error) {
+func (fss *FilesystemStore) ListFileSystem(limit int, userName, marker, fsName, project string) ([]model.FileSystem, error) {
 	var fileSystems []model.FileSystem
-	result := fss.db.Where(&model.FileSystem{UserName: userName, Name: fsName}).Where(fmt.Sprintf(QueryLess, CreatedAt, "'"+marker+"'")).
-		Order(fmt.Sprintf(" %s %s ", CreatedAt, DESC)).Limit(limit).Find(&fileSystems)
+	tx := fss.db.Where(&model.FileSystem{UserName: userName, Name: fsName}).Where(fmt.Sprintf(QueryLess, CreatedAt, "'"+marker+"'"))
+	if project != "" {
+		tx = tx.Where("id IN (?)", projectResourceQuery(fss.db, project, common.ResourceTypeFs))
+	}
+	result := tx.Order(fmt.Sprintf(" %s %s ", CreatedAt, DESC)).Limit(limit).Find(&fileSystems)
 	return fileSystems, result.Error
 }
 
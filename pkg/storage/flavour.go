@@ -24,6 +24,7 @@ import (
 	"gorm.io/gorm"
 
 	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/uuid"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
 )
@@ -37,10 +38,12 @@ flavour.created_at as created_at, flavour.updated_at as updated_at, flavour.dele
 
 type FlavourStore struct {
 	db *gorm.DB
+	// cache contains flavours by name, which is purged when any flavour is changed
+	cache *lookupCache
 }
 
 func newFlavourStore(db *gorm.DB) *FlavourStore {
-	return &FlavourStore{db: db}
+	return &FlavourStore{db: db, cache: newLookupCache()}
 }
 
 // CreateFlavour create flavour
@@ -50,6 +53,7 @@ func (fs *FlavourStore) CreateFlavour(flavour *model.Flavour) error {
 		flavour.ID = uuid.GenerateID(common.PrefixFlavour)
 	}
 	flavour.CreatedAt = time.Now()
+	defer fs.cache.purge()
 	tx := fs.db.Table(model.FlavourTableName).Create(flavour)
 	if tx.Error != nil {
 		log.Errorf("create flavour failed. flavour:%v, error:%s", flavour, tx.Error.Error())
@@ -62,6 +66,7 @@ func (fs *FlavourStore) CreateFlavour(flavour *model.Flavour) error {
 // DeleteFlavour delete flavour
 func (fs *FlavourStore) DeleteFlavour(flavourName string) error {
 	log.Infof("begin delete flavour, flavour name:%s", flavourName)
+	defer fs.cache.purge()
 	t := fs.db.Table(model.FlavourTableName).Unscoped().Where("name = ?", flavourName).Delete(&model.Flavour{})
 	if t.Error != nil {
 		log.Errorf("delete flavour failed. flavour name:%s, error:%v", flavourName, t.Error)
@@ -84,6 +89,25 @@ func (fs *FlavourStore) GetFlavour(flavourName string) (model.Flavour, error) {
 	return flavour, nil
 }
 
+// GetCachedFlavour gets flavour from cache, which may be stale for a few seconds if flavour is changed by other servers
+func (fs *FlavourStore) GetCachedFlavour(flavourName string) (model.Flavour, error) {
+	value, err := fs.cache.get(flavourName, func() (interface{}, error) {
+		return fs.GetFlavour(flavourName)
+	})
+	if err != nil {
+		return model.Flavour{}, err
+	}
+	flavour := value.(model.Flavour)
+	if flavour.ScalarResources != nil {
+		scalarResources := make(schema.ScalarResourcesType, len(flavour.ScalarResources))
+		for k, v := range flavour.ScalarResources {
+			scalarResources[k] = v
+		}
+		flavour.ScalarResources = scalarResources
+	}
+	return flavour, nil
+}
+
 // ListFlavour all params is nullable, and support fuzzy query of flavour's name by queryKey
 func (fs *FlavourStore) ListFlavour(pk int64, maxKeys int, clusterID, queryKey string) ([]model.Flavour, error) {
 	log.Debugf("list flavour, pk: %d, maxKeys: %d, clusterID: %s", pk, maxKeys, clusterID)
@@ -116,6 +140,7 @@ func (fs *FlavourStore) ListFlavour(pk int64, maxKeys int, clusterID, queryKey s
 // UpdateFlavour update flavour
 func (fs *FlavourStore) UpdateFlavour(flavour *model.Flavour) error {
 	flavour.UpdatedAt = time.Now()
+	defer fs.cache.purge()
 	tx := fs.db.Model(flavour).Updates(flavour)
 	return tx.Error
 }
@@ -0,0 +1,89 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+)
+
+type FsAclStore struct {
+	db *gorm.DB
+}
+
+func newFsAclStore(db *gorm.DB) *FsAclStore {
+	return &FsAclStore{db: db}
+}
+
+// SaveAcl 同一授权对象重复授权时更新权限
+func (as *FsAclStore) SaveAcl(logEntry *log.Entry, acl *model.FsAcl) error {
+	logEntry.Debugf("begin save acl: %+v", acl)
+	tx := as.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "fs_id"}, {Name: "grantee_type"}, {Name: "grantee_name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"permission", "updated_at"}),
+	}).Create(acl)
+	if tx.Error != nil {
+		logEntry.Errorf("save acl %+v failed. error:%v", acl, tx.Error)
+		return tx.Error
+	}
+	return nil
+}
+
+func (as *FsAclStore) DeleteAcl(logEntry *log.Entry, fsID, granteeType, granteeName string) error {
+	tx := as.db.Where("fs_id = ? AND grantee_type = ? AND grantee_name = ?", fsID, granteeType, granteeName).
+		Delete(&model.FsAcl{})
+	if tx.Error != nil {
+		logEntry.Errorf("delete acl of fs[%s] for %s[%s] failed. error:%v", fsID, granteeType, granteeName, tx.Error)
+		return tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (as *FsAclStore) ListAcl(logEntry *log.Entry, fsID string) ([]model.FsAcl, error) {
+	var acls []model.FsAcl
+	tx := as.db.Model(&model.FsAcl{}).Where("fs_id = ?", fsID).Order("grantee_type, grantee_name").Find(&acls)
+	if tx.Error != nil {
+		logEntry.Errorf("list acl of fs[%s] failed. error:%v", fsID, tx.Error)
+		return nil, tx.Error
+	}
+	return acls, nil
+}
+
+// ListGranteeAcl 用户本身及其提交作业所用队列在fs上的授权
+func (as *FsAclStore) ListGranteeAcl(logEntry *log.Entry, fsID, userName, queueName string) ([]model.FsAcl, error) {
+	var acls []model.FsAcl
+	tx := as.db.Model(&model.FsAcl{}).Where("fs_id = ? AND ((grantee_type = ? AND grantee_name = ?) OR (grantee_type = ? AND grantee_name = ?))",
+		fsID, model.FsGranteeUser, userName, model.FsGranteeQueue, queueName).Find(&acls)
+	if tx.Error != nil {
+		logEntry.Errorf("list acl of fs[%s] for user[%s] queue[%s] failed. error:%v", fsID, userName, queueName, tx.Error)
+		return nil, tx.Error
+	}
+	return acls, nil
+}
+
+func (as *FsAclStore) DeleteFsAcl(tx *gorm.DB, fsID string) error {
+	if tx == nil {
+		tx = as.db
+	}
+	return tx.Where("fs_id = ?", fsID).Delete(&model.FsAcl{}).Error
+}
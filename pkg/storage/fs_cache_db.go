@@ -80,7 +80,10 @@ func (f *DBFSCache) ListNodes(fsIDs []string) ([]string, error) {
 	return nodeList, result.Error
 }
 
+// Update 同步挂载pod上报的缓存信息，用量与命中统计可能为0，因此显式指定更新的列
 func (f *DBFSCache) Update(value *model.FSCache) (int64, error) {
-	result := f.db.Where(&model.FSCache{FsID: value.FsID, CacheID: value.CacheID}).Updates(value)
+	result := f.db.Where(&model.FSCache{FsID: value.FsID, CacheID: value.CacheID}).
+		Select("cache_dir", "nodename", "usedsize", "cache_hits", "cache_misses",
+			"hit_bytes", "miss_bytes", "evictions", "read_throughput").Updates(value)
 	return result.RowsAffected, result.Error
 }
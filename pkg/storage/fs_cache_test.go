@@ -92,6 +92,7 @@ func initMockDB() {
 		&model.Queue{},
 		&model.ClusterInfo{},
 		&model.Grant{},
+		&model.UserQuota{},
 	); err != nil {
 		log.Fatalf("InitMockDB createDatabaseTables error[%s]", err.Error())
 	}
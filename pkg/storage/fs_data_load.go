@@ -0,0 +1,119 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+)
+
+type FsDataLoadStore struct {
+	db *gorm.DB
+}
+
+func newFsDataLoadStore(db *gorm.DB) *FsDataLoadStore {
+	return &FsDataLoadStore{db: db}
+}
+
+func (ds *FsDataLoadStore) CreateDataLoad(logEntry *log.Entry, dataLoad *model.FSDataLoad) error {
+	logEntry.Debugf("begin create data load: %+v", dataLoad)
+	tx := ds.db.Create(dataLoad)
+	if tx.Error != nil {
+		logEntry.Errorf("create data load failed. error:%v", tx.Error)
+		return tx.Error
+	}
+	return nil
+}
+
+func (ds *FsDataLoadStore) GetDataLoad(logEntry *log.Entry, id string) (model.FSDataLoad, error) {
+	logEntry.Debugf("begin get data load[%s]", id)
+	var dataLoad model.FSDataLoad
+	tx := ds.db.Model(&model.FSDataLoad{}).Where("id = ?", id).First(&dataLoad)
+	if tx.Error != nil {
+		logEntry.Errorf("get data load[%s] failed. error:%v", id, tx.Error)
+		return model.FSDataLoad{}, tx.Error
+	}
+	return dataLoad, nil
+}
+
+// UpdateDataLoad 只更新非零值字段，Paths与Nodes在BeforeSave中序列化
+func (ds *FsDataLoadStore) UpdateDataLoad(logEntry *log.Entry, id string, dataLoad *model.FSDataLoad) error {
+	logEntry.Debugf("begin update data load[%s]: %+v", id, dataLoad)
+	tx := ds.db.Model(dataLoad).Where("id = ?", id).Updates(dataLoad)
+	if tx.Error != nil {
+		logEntry.Errorf("update data load[%s] failed. error:%v", id, tx.Error)
+		return tx.Error
+	}
+	return nil
+}
+
+// UpdateDataLoadTotal 总量可能为0，不能通过UpdateDataLoad更新
+func (ds *FsDataLoadStore) UpdateDataLoadTotal(logEntry *log.Entry, id string, totalFiles, totalBytes int64) error {
+	logEntry.Debugf("begin update total of data load[%s]: files[%d] bytes[%d]", id, totalFiles, totalBytes)
+	tx := ds.db.Model(&model.FSDataLoad{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"total_files": totalFiles, "total_bytes": totalBytes})
+	if tx.Error != nil {
+		logEntry.Errorf("update total of data load[%s] failed. error:%v", id, tx.Error)
+		return tx.Error
+	}
+	return nil
+}
+
+func (ds *FsDataLoadStore) DeleteDataLoad(logEntry *log.Entry, id string) error {
+	logEntry.Debugf("begin delete data load[%s]", id)
+	tx := ds.db.Where("id = ?", id).Delete(&model.FSDataLoad{})
+	if tx.Error != nil {
+		logEntry.Errorf("delete data load[%s] failed. error:%v", id, tx.Error)
+		return tx.Error
+	}
+	return nil
+}
+
+// ListDataLoad 非root用户只能看到自己创建的预热任务
+func (ds *FsDataLoadStore) ListDataLoad(logEntry *log.Entry, pk int64, maxKeys int, userName, fsName string) ([]model.FSDataLoad, error) {
+	logEntry.Debugf("begin list data load. pk:%d, maxKeys:%d, userName:%s, fsName:%s", pk, maxKeys, userName, fsName)
+	tx := ds.db.Model(&model.FSDataLoad{}).Where("pk > ?", pk)
+	if !common.IsRootUser(userName) {
+		tx = tx.Where("user_name = ?", userName)
+	}
+	if fsName != "" {
+		tx = tx.Where("fs_name = ?", fsName)
+	}
+	if maxKeys > 0 {
+		tx = tx.Limit(maxKeys)
+	}
+	var dataLoads []model.FSDataLoad
+	tx = tx.Order("pk").Find(&dataLoads)
+	if tx.Error != nil {
+		logEntry.Errorf("list data load failed. error:%v", tx.Error)
+		return nil, tx.Error
+	}
+	return dataLoads, nil
+}
+
+func (ds *FsDataLoadStore) ListDataLoadWithStatus(logEntry *log.Entry, status ...string) ([]model.FSDataLoad, error) {
+	var dataLoads []model.FSDataLoad
+	tx := ds.db.Model(&model.FSDataLoad{}).Where("status IN ?", status).Find(&dataLoads)
+	if tx.Error != nil {
+		logEntry.Errorf("list data load with status%v failed. error:%v", status, tx.Error)
+		return nil, tx.Error
+	}
+	return dataLoads, nil
+}
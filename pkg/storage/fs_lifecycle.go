@@ -0,0 +1,105 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+)
+
+type FsLifecycleStore struct {
+	db *gorm.DB
+}
+
+func newFsLifecycleStore(db *gorm.DB) *FsLifecycleStore {
+	return &FsLifecycleStore{db: db}
+}
+
+func (ls *FsLifecycleStore) CreateLifecycleRule(logEntry *log.Entry, rule *model.FsLifecycleRule) error {
+	logEntry.Debugf("begin create lifecycle rule: %+v", rule)
+	tx := ls.db.Create(rule)
+	if tx.Error != nil {
+		logEntry.Errorf("create lifecycle rule failed. error:%v", tx.Error)
+		return tx.Error
+	}
+	return nil
+}
+
+func (ls *FsLifecycleStore) GetLifecycleRule(logEntry *log.Entry, fsID, name string) (model.FsLifecycleRule, error) {
+	var rule model.FsLifecycleRule
+	tx := ls.db.Model(&model.FsLifecycleRule{}).Where("fs_id = ? AND name = ?", fsID, name).First(&rule)
+	if tx.Error != nil {
+		logEntry.Errorf("get lifecycle rule[%s] of fs[%s] failed. error:%v", name, fsID, tx.Error)
+		return model.FsLifecycleRule{}, tx.Error
+	}
+	return rule, nil
+}
+
+// ListLifecycleRule fsID为空时返回所有文件系统的规则
+func (ls *FsLifecycleStore) ListLifecycleRule(logEntry *log.Entry, fsID string) ([]model.FsLifecycleRule, error) {
+	var rules []model.FsLifecycleRule
+	tx := ls.db.Model(&model.FsLifecycleRule{})
+	if fsID != "" {
+		tx = tx.Where("fs_id = ?", fsID)
+	}
+	if err := tx.Order("pk").Find(&rules).Error; err != nil {
+		logEntry.Errorf("list lifecycle rules of fs[%s] failed. error:%v", fsID, err)
+		return nil, err
+	}
+	return rules, nil
+}
+
+func (ls *FsLifecycleStore) UpdateLifecycleRule(logEntry *log.Entry, fsID, name string, values map[string]interface{}) error {
+	tx := ls.db.Model(&model.FsLifecycleRule{}).Where("fs_id = ? AND name = ?", fsID, name).Updates(values)
+	if tx.Error != nil {
+		logEntry.Errorf("update lifecycle rule[%s] of fs[%s] failed. error:%v", name, fsID, tx.Error)
+		return tx.Error
+	}
+	return nil
+}
+
+// UpdateLifecycleReport 记录一次执行规则的结果
+func (ls *FsLifecycleStore) UpdateLifecycleReport(logEntry *log.Entry, fsID, name string, report model.LifecycleReport,
+	runAt time.Time, message string) error {
+	return ls.UpdateLifecycleRule(logEntry, fsID, name, map[string]interface{}{
+		"matched_files":   report.MatchedFiles,
+		"matched_bytes":   report.MatchedBytes,
+		"processed_files": report.ProcessedFiles,
+		"processed_bytes": report.ProcessedBytes,
+		"last_run_at":     runAt,
+		"message":         message,
+	})
+}
+
+func (ls *FsLifecycleStore) DeleteLifecycleRule(logEntry *log.Entry, fsID, name string) error {
+	logEntry.Debugf("begin delete lifecycle rule[%s] of fs[%s]", name, fsID)
+	tx := ls.db.Where("fs_id = ? AND name = ?", fsID, name).Delete(&model.FsLifecycleRule{})
+	if tx.Error != nil {
+		logEntry.Errorf("delete lifecycle rule[%s] of fs[%s] failed. error:%v", name, fsID, tx.Error)
+		return tx.Error
+	}
+	return nil
+}
+
+// DeleteFsLifecycleRule 删除文件系统时删除其所有生命周期规则
+func (ls *FsLifecycleStore) DeleteFsLifecycleRule(tx *gorm.DB, fsID string) error {
+	return tx.Where("fs_id = ?", fsID).Delete(&model.FsLifecycleRule{}).Error
+}
@@ -0,0 +1,125 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+)
+
+type FsReplicationStore struct {
+	db *gorm.DB
+}
+
+func newFsReplicationStore(db *gorm.DB) *FsReplicationStore {
+	return &FsReplicationStore{db: db}
+}
+
+func (rs *FsReplicationStore) CreateReplication(logEntry *log.Entry, replication *model.FsReplication) error {
+	logEntry.Debugf("begin create replication: %+v", replication)
+	tx := rs.db.Create(replication)
+	if tx.Error != nil {
+		logEntry.Errorf("create replication failed. error:%v", tx.Error)
+		return tx.Error
+	}
+	return nil
+}
+
+func (rs *FsReplicationStore) GetReplication(logEntry *log.Entry, fsID string) (model.FsReplication, error) {
+	logEntry.Debugf("begin get replication of fs[%s]", fsID)
+	var replication model.FsReplication
+	tx := rs.db.Model(&model.FsReplication{}).Where("fs_id = ?", fsID).First(&replication)
+	if tx.Error != nil {
+		logEntry.Errorf("get replication of fs[%s] failed. error:%v", fsID, tx.Error)
+		return model.FsReplication{}, tx.Error
+	}
+	return replication, nil
+}
+
+// GetReplicationByAnyFs 返回fsID作为主存储或副本存储的复制关系
+func (rs *FsReplicationStore) GetReplicationByAnyFs(logEntry *log.Entry, fsID string) (model.FsReplication, error) {
+	var replication model.FsReplication
+	tx := rs.db.Model(&model.FsReplication{}).Where("fs_id = ? OR replica_fs_id = ?", fsID, fsID).First(&replication)
+	if tx.Error != nil {
+		return model.FsReplication{}, tx.Error
+	}
+	return replication, nil
+}
+
+func (rs *FsReplicationStore) UpdateReplicationStatus(tx *gorm.DB, fsID, status, message string) error {
+	if tx == nil {
+		tx = rs.db
+	}
+	return tx.Model(&model.FsReplication{}).Where("fs_id = ?", fsID).Updates(map[string]interface{}{
+		"status":  status,
+		"message": message,
+	}).Error
+}
+
+// UpdateReplicationStats 更新一轮复制后的统计，lastSyncedAt为空时不更新同步时间
+func (rs *FsReplicationStore) UpdateReplicationStats(logEntry *log.Entry, fsID string, stats model.ReplicationStats,
+	lastSyncedAt *time.Time, message string) error {
+	values := map[string]interface{}{
+		"pending_files":    stats.PendingFiles,
+		"pending_bytes":    stats.PendingBytes,
+		"lag_seconds":      stats.LagSeconds,
+		"replicated_files": stats.ReplicatedFiles,
+		"replicated_bytes": stats.ReplicatedBytes,
+		"message":          message,
+	}
+	if lastSyncedAt != nil {
+		values["last_synced_at"] = lastSyncedAt
+	}
+	tx := rs.db.Model(&model.FsReplication{}).Where("fs_id = ?", fsID).Updates(values)
+	if tx.Error != nil {
+		logEntry.Errorf("update stats of replication[%s] failed. error:%v", fsID, tx.Error)
+		return tx.Error
+	}
+	return nil
+}
+
+func (rs *FsReplicationStore) DeleteReplication(logEntry *log.Entry, fsID string) error {
+	logEntry.Debugf("begin delete replication of fs[%s]", fsID)
+	tx := rs.db.Where("fs_id = ?", fsID).Delete(&model.FsReplication{})
+	if tx.Error != nil {
+		logEntry.Errorf("delete replication of fs[%s] failed. error:%v", fsID, tx.Error)
+		return tx.Error
+	}
+	return nil
+}
+
+// DeleteFsReplication 删除文件系统时删除其作为主存储或副本存储的复制关系
+func (rs *FsReplicationStore) DeleteFsReplication(tx *gorm.DB, fsID string) error {
+	return tx.Where("fs_id = ? OR replica_fs_id = ?", fsID, fsID).Delete(&model.FsReplication{}).Error
+}
+
+func (rs *FsReplicationStore) ListReplication(logEntry *log.Entry, status ...string) ([]model.FsReplication, error) {
+	var replications []model.FsReplication
+	tx := rs.db.Model(&model.FsReplication{})
+	if len(status) > 0 {
+		tx = tx.Where("status IN ?", status)
+	}
+	if err := tx.Order("pk").Find(&replications).Error; err != nil {
+		logEntry.Errorf("list replication with status%v failed. error:%v", status, err)
+		return nil, err
+	}
+	return replications, nil
+}
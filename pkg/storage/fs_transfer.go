@@ -0,0 +1,125 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+)
+
+type FsTransferStore struct {
+	db *gorm.DB
+}
+
+func newFsTransferStore(db *gorm.DB) *FsTransferStore {
+	return &FsTransferStore{db: db}
+}
+
+func (ts *FsTransferStore) CreateTransfer(logEntry *log.Entry, transfer *model.FSTransfer) error {
+	logEntry.Debugf("begin create transfer: %+v", transfer)
+	tx := ts.db.Create(transfer)
+	if tx.Error != nil {
+		logEntry.Errorf("create transfer failed. error:%v", tx.Error)
+		return tx.Error
+	}
+	return nil
+}
+
+func (ts *FsTransferStore) GetTransfer(logEntry *log.Entry, id string) (model.FSTransfer, error) {
+	logEntry.Debugf("begin get transfer[%s]", id)
+	var transfer model.FSTransfer
+	tx := ts.db.Model(&model.FSTransfer{}).Where("id = ?", id).First(&transfer)
+	if tx.Error != nil {
+		logEntry.Errorf("get transfer[%s] failed. error:%v", id, tx.Error)
+		return model.FSTransfer{}, tx.Error
+	}
+	return transfer, nil
+}
+
+// UpdateTransfer 只更新非零值字段
+func (ts *FsTransferStore) UpdateTransfer(logEntry *log.Entry, id string, transfer *model.FSTransfer) error {
+	logEntry.Debugf("begin update transfer[%s]: %+v", id, transfer)
+	tx := ts.db.Model(transfer).Where("id = ?", id).Updates(transfer)
+	if tx.Error != nil {
+		logEntry.Errorf("update transfer[%s] failed. error:%v", id, tx.Error)
+		return tx.Error
+	}
+	return nil
+}
+
+// UpdateTransferStats 恢复任务后统计从0开始，不能通过UpdateTransfer更新
+func (ts *FsTransferStore) UpdateTransferStats(logEntry *log.Entry, id string, stats model.TransferStats) error {
+	logEntry.Debugf("begin update stats of transfer[%s]: %+v", id, stats)
+	tx := ts.db.Model(&model.FSTransfer{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"transferred_files": stats.TransferredFiles,
+		"transferred_bytes": stats.TransferredBytes,
+		"total_files":       stats.TotalFiles,
+		"total_bytes":       stats.TotalBytes,
+		"checked_files":     stats.CheckedFiles,
+		"errors":            stats.Errors,
+	})
+	if tx.Error != nil {
+		logEntry.Errorf("update stats of transfer[%s] failed. error:%v", id, tx.Error)
+		return tx.Error
+	}
+	return nil
+}
+
+func (ts *FsTransferStore) DeleteTransfer(logEntry *log.Entry, id string) error {
+	logEntry.Debugf("begin delete transfer[%s]", id)
+	tx := ts.db.Where("id = ?", id).Delete(&model.FSTransfer{})
+	if tx.Error != nil {
+		logEntry.Errorf("delete transfer[%s] failed. error:%v", id, tx.Error)
+		return tx.Error
+	}
+	return nil
+}
+
+// ListTransfer 非root用户只能看到自己创建的迁移任务，fsName匹配源存储或目标存储
+func (ts *FsTransferStore) ListTransfer(logEntry *log.Entry, pk int64, maxKeys int, userName, fsName string) ([]model.FSTransfer, error) {
+	logEntry.Debugf("begin list transfer. pk:%d, maxKeys:%d, userName:%s, fsName:%s", pk, maxKeys, userName, fsName)
+	tx := ts.db.Model(&model.FSTransfer{}).Where("pk > ?", pk)
+	if !common.IsRootUser(userName) {
+		tx = tx.Where("user_name = ?", userName)
+	}
+	if fsName != "" {
+		tx = tx.Where("src_fs_name = ? OR dst_fs_name = ?", fsName, fsName)
+	}
+	if maxKeys > 0 {
+		tx = tx.Limit(maxKeys)
+	}
+	var transfers []model.FSTransfer
+	tx = tx.Order("pk").Find(&transfers)
+	if tx.Error != nil {
+		logEntry.Errorf("list transfer failed. error:%v", tx.Error)
+		return nil, tx.Error
+	}
+	return transfers, nil
+}
+
+func (ts *FsTransferStore) ListTransferWithStatus(logEntry *log.Entry, status ...string) ([]model.FSTransfer, error) {
+	var transfers []model.FSTransfer
+	tx := ts.db.Model(&model.FSTransfer{}).Where("status IN ?", status).Find(&transfers)
+	if tx.Error != nil {
+		logEntry.Errorf("list transfer with status%v failed. error:%v", status, tx.Error)
+		return nil, tx.Error
+	}
+	return transfers, nil
+}
@@ -0,0 +1,104 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+)
+
+type FsUsageStore struct {
+	db *gorm.DB
+}
+
+func newFsUsageStore(db *gorm.DB) *FsUsageStore {
+	return &FsUsageStore{db: db}
+}
+
+// CreateUsages 一次扫描的记录在同一事务中写入，避免读到不完整的扫描结果
+func (us *FsUsageStore) CreateUsages(logEntry *log.Entry, usages []model.FsUsage) error {
+	if len(usages) == 0 {
+		return nil
+	}
+	logEntry.Debugf("begin create %d usages of fs[%s]", len(usages), usages[0].FsID)
+	err := us.db.Transaction(func(tx *gorm.DB) error {
+		return tx.CreateInBatches(usages, 100).Error
+	})
+	if err != nil {
+		logEntry.Errorf("create usages failed. error:%v", err)
+		return err
+	}
+	return nil
+}
+
+// ListUsage 某次扫描的全部记录
+func (us *FsUsageStore) ListUsage(logEntry *log.Entry, fsID string, scanTime time.Time) ([]model.FsUsage, error) {
+	var usages []model.FsUsage
+	tx := us.db.Model(&model.FsUsage{}).Where("fs_id = ? AND scan_time = ?", fsID, scanTime).Order("path").Find(&usages)
+	if tx.Error != nil {
+		logEntry.Errorf("list usage of fs[%s] at [%v] failed. error:%v", fsID, scanTime, tx.Error)
+		return nil, tx.Error
+	}
+	return usages, nil
+}
+
+// ListUsageHistory since之后各次扫描中path的用量，按扫描时间排序
+func (us *FsUsageStore) ListUsageHistory(logEntry *log.Entry, fsID, path string, since time.Time) ([]model.FsUsage, error) {
+	var usages []model.FsUsage
+	tx := us.db.Model(&model.FsUsage{}).Where("fs_id = ? AND path = ? AND scan_time >= ?", fsID, path, since).
+		Order("scan_time").Find(&usages)
+	if tx.Error != nil {
+		logEntry.Errorf("list usage history of fs[%s] path[%s] failed. error:%v", fsID, path, tx.Error)
+		return nil, tx.Error
+	}
+	return usages, nil
+}
+
+// ListLatestUsage 各文件系统最近一次扫描的总用量
+func (us *FsUsageStore) ListLatestUsage(logEntry *log.Entry) ([]model.FsUsage, error) {
+	var usages []model.FsUsage
+	latest := us.db.Model(&model.FsUsage{}).Select("fs_id, MAX(scan_time) AS scan_time").
+		Where("path = ?", model.FsUsageRootPath).Group("fs_id")
+	tx := us.db.Table(model.FsUsageTableName+" AS u").Select("u.*").
+		Joins("JOIN (?) AS l ON u.fs_id = l.fs_id AND u.scan_time = l.scan_time", latest).
+		Where("u.path = ?", model.FsUsageRootPath).Find(&usages)
+	if tx.Error != nil {
+		logEntry.Errorf("list latest usage failed. error:%v", tx.Error)
+		return nil, tx.Error
+	}
+	return usages, nil
+}
+
+func (us *FsUsageStore) DeleteUsageBefore(logEntry *log.Entry, before time.Time) error {
+	tx := us.db.Where("scan_time < ?", before).Delete(&model.FsUsage{})
+	if tx.Error != nil {
+		logEntry.Errorf("delete usage before [%v] failed. error:%v", before, tx.Error)
+		return tx.Error
+	}
+	return nil
+}
+
+func (us *FsUsageStore) DeleteFsUsage(tx *gorm.DB, fsID string) error {
+	if tx == nil {
+		tx = us.db
+	}
+	return tx.Where("fs_id = ?", fsID).Delete(&model.FsUsage{}).Error
+}
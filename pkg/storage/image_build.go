@@ -0,0 +1,107 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+)
+
+type ImageBuildStore struct {
+	db *gorm.DB
+}
+
+func newImageBuildStore(db *gorm.DB) *ImageBuildStore {
+	return &ImageBuildStore{db: db}
+}
+
+func (bs *ImageBuildStore) CreateImageBuild(logEntry *log.Entry, build *model.ImageBuild) error {
+	logEntry.Debugf("begin create image build: %+v", build)
+	tx := bs.db.Create(build)
+	if tx.Error != nil {
+		logEntry.Errorf("create image build failed. error:%v", tx.Error)
+		return tx.Error
+	}
+	return nil
+}
+
+func (bs *ImageBuildStore) GetImageBuild(logEntry *log.Entry, id string) (model.ImageBuild, error) {
+	logEntry.Debugf("begin get image build[%s]", id)
+	var build model.ImageBuild
+	tx := bs.db.Model(&model.ImageBuild{}).Where("id = ?", id).First(&build)
+	if tx.Error != nil {
+		logEntry.Errorf("get image build[%s] failed. error:%v", id, tx.Error)
+		return model.ImageBuild{}, tx.Error
+	}
+	return build, nil
+}
+
+// UpdateImageBuild 只更新非零值字段
+func (bs *ImageBuildStore) UpdateImageBuild(logEntry *log.Entry, id string, build *model.ImageBuild) error {
+	logEntry.Debugf("begin update image build[%s]: %+v", id, build)
+	tx := bs.db.Model(build).Where("id = ?", id).Updates(build)
+	if tx.Error != nil {
+		logEntry.Errorf("update image build[%s] failed. error:%v", id, tx.Error)
+		return tx.Error
+	}
+	return nil
+}
+
+func (bs *ImageBuildStore) DeleteImageBuild(logEntry *log.Entry, id string) error {
+	logEntry.Debugf("begin delete image build[%s]", id)
+	tx := bs.db.Where("id = ?", id).Delete(&model.ImageBuild{})
+	if tx.Error != nil {
+		logEntry.Errorf("delete image build[%s] failed. error:%v", id, tx.Error)
+		return tx.Error
+	}
+	return nil
+}
+
+// ListImageBuild 非root用户只能看到自己创建的构建任务
+func (bs *ImageBuildStore) ListImageBuild(logEntry *log.Entry, pk int64, maxKeys int, userName, name string) ([]model.ImageBuild, error) {
+	logEntry.Debugf("begin list image build. pk:%d, maxKeys:%d, userName:%s, name:%s", pk, maxKeys, userName, name)
+	tx := bs.db.Model(&model.ImageBuild{}).Where("pk > ?", pk)
+	if !common.IsRootUser(userName) {
+		tx = tx.Where("user_name = ?", userName)
+	}
+	if name != "" {
+		tx = tx.Where("name = ?", name)
+	}
+	if maxKeys > 0 {
+		tx = tx.Limit(maxKeys)
+	}
+	var builds []model.ImageBuild
+	tx = tx.Order("pk").Find(&builds)
+	if tx.Error != nil {
+		logEntry.Errorf("list image build failed. error:%v", tx.Error)
+		return nil, tx.Error
+	}
+	return builds, nil
+}
+
+func (bs *ImageBuildStore) ListImageBuildWithStatus(logEntry *log.Entry, status ...string) ([]model.ImageBuild, error) {
+	var builds []model.ImageBuild
+	tx := bs.db.Model(&model.ImageBuild{}).Where("status IN ?", status).Find(&builds)
+	if tx.Error != nil {
+		logEntry.Errorf("list image build with status%v failed. error:%v", status, tx.Error)
+		return nil, tx.Error
+	}
+	return builds, nil
+}
@@ -17,6 +17,8 @@ limitations under the License.
 package storage
 
 import (
+	"time"
+
 	log "github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 
@@ -29,16 +31,35 @@ import (
 var (
 	DB *gorm.DB
 
-	Pipeline   PipelineStoreInterface
-	Filesystem FileSystemStoreInterface
-	FsCache    FsCacheStoreInterface
-	Auth       AuthStoreInterface
-	Cluster    ClusterStoreInterface
-	Flavour    FlavourStoreInterface
-	Queue      QueueStoreInterface
-	Job        JobStoreInterface
-	Image      ImageStoreInterface
-	Artifact   ArtifactStoreInterface
+	Pipeline      PipelineStoreInterface
+	Filesystem    FileSystemStoreInterface
+	FsCache       FsCacheStoreInterface
+	Auth          AuthStoreInterface
+	Cluster       ClusterStoreInterface
+	Flavour       FlavourStoreInterface
+	Queue         QueueStoreInterface
+	Job           JobStoreInterface
+	JobMetric     JobMetricStoreInterface
+	JobArtifact   JobArtifactStoreInterface
+	JobTimeline   JobTimelineStoreInterface
+	Image         ImageStoreInterface
+	Artifact      ArtifactStoreInterface
+	Tracking      RunTrackingStoreInterface
+	Visualization VisualizationStoreInterface
+	Dataset       DatasetStoreInterface
+	FsDataLoad    FsDataLoadStoreInterface
+	FsTransfer    FsTransferStoreInterface
+	FsUsage       FsUsageStoreInterface
+	FsAcl         FsAclStoreInterface
+	FsReplication FsReplicationStoreInterface
+	FsLifecycle   FsLifecycleStoreInterface
+	ImageBuild    ImageBuildStoreInterface
+	Project       ProjectStoreInterface
+	Statistics    StatisticsStoreInterface
+	Alert         AlertStoreInterface
+	Export        ExportStoreInterface
+	Event         EventStoreInterface
+	NodePool      NodePoolStoreInterface
 )
 
 func InitStores(db *gorm.DB) {
@@ -50,9 +71,28 @@ func InitStores(db *gorm.DB) {
 	Cluster = newClusterStore(db)
 	Flavour = newFlavourStore(db)
 	Job = newJobStore(db)
+	JobMetric = newJobMetricStore(db)
+	JobArtifact = newJobArtifactStore(db)
+	JobTimeline = newJobTimelineStore(db)
 	Queue = newQueueStore(db)
 	Image = newImageStore(db)
 	Artifact = newRunArtifactStore(db)
+	Tracking = newRunTrackingStore(db)
+	Visualization = newVisualizationStore(db)
+	Dataset = newDatasetStore(db)
+	FsDataLoad = newFsDataLoadStore(db)
+	FsTransfer = newFsTransferStore(db)
+	FsUsage = newFsUsageStore(db)
+	FsAcl = newFsAclStore(db)
+	FsReplication = newFsReplicationStore(db)
+	FsLifecycle = newFsLifecycleStore(db)
+	ImageBuild = newImageBuildStore(db)
+	Project = newProjectStore(db)
+	Statistics = newStatisticsStore(db)
+	Alert = newAlertStore(db)
+	Export = newExportStore(db)
+	Event = newEventStore(db)
+	NodePool = newNodePoolStore(db)
 }
 
 type ArtifactStoreInterface interface {
@@ -65,6 +105,121 @@ type ArtifactStoreInterface interface {
 	GetLastArtifactEvent(logEntry *log.Entry) (model.ArtifactEvent, error)
 }
 
+type RunTrackingStoreInterface interface {
+	CreateRunMetrics(logEntry *log.Entry, metrics []model.RunMetric) error
+	ListRunMetrics(logEntry *log.Entry, runIDs, keys []string) ([]model.RunMetric, error)
+	SaveRunParams(logEntry *log.Entry, params []model.RunParam) error
+	ListRunParams(logEntry *log.Entry, runIDs []string) ([]model.RunParam, error)
+	DeleteRunTracking(logEntry *log.Entry, runID string) error
+}
+
+type JobMetricStoreInterface interface {
+	CreateJobMetrics(logEntry *log.Entry, metrics []model.JobMetric) error
+	ListJobMetrics(logEntry *log.Entry, jobID string, keys []string) ([]model.JobMetric, error)
+	GetLastJobMetricTimestamp(logEntry *log.Entry, jobID, taskID string) (int64, error)
+	DeleteJobMetrics(logEntry *log.Entry, jobID string) error
+}
+
+type JobTimelineStoreInterface interface {
+	AddJobTimelineEvent(event *model.JobTimelineEvent) error
+	ListJobTimeline(jobID string) ([]model.JobTimelineEvent, error)
+	HasJobTimelineEvent(jobID, eventType string) (bool, error)
+}
+
+type JobArtifactStoreInterface interface {
+	CreateJobArtifacts(logEntry *log.Entry, artifacts []model.JobArtifact) error
+	ListJobArtifacts(logEntry *log.Entry, jobID string) ([]model.JobArtifact, error)
+	ListJobArtifactsByStatus(logEntry *log.Entry, status string, limit int) ([]model.JobArtifact, error)
+	UpdateJobArtifact(logEntry *log.Entry, artifact *model.JobArtifact) error
+	DeleteJobArtifacts(logEntry *log.Entry, jobID string) error
+}
+
+type FsDataLoadStoreInterface interface {
+	CreateDataLoad(logEntry *log.Entry, dataLoad *model.FSDataLoad) error
+	GetDataLoad(logEntry *log.Entry, id string) (model.FSDataLoad, error)
+	UpdateDataLoad(logEntry *log.Entry, id string, dataLoad *model.FSDataLoad) error
+	UpdateDataLoadTotal(logEntry *log.Entry, id string, totalFiles, totalBytes int64) error
+	DeleteDataLoad(logEntry *log.Entry, id string) error
+	ListDataLoad(logEntry *log.Entry, pk int64, maxKeys int, userName, fsName string) ([]model.FSDataLoad, error)
+	ListDataLoadWithStatus(logEntry *log.Entry, status ...string) ([]model.FSDataLoad, error)
+}
+
+type FsTransferStoreInterface interface {
+	CreateTransfer(logEntry *log.Entry, transfer *model.FSTransfer) error
+	GetTransfer(logEntry *log.Entry, id string) (model.FSTransfer, error)
+	UpdateTransfer(logEntry *log.Entry, id string, transfer *model.FSTransfer) error
+	UpdateTransferStats(logEntry *log.Entry, id string, stats model.TransferStats) error
+	DeleteTransfer(logEntry *log.Entry, id string) error
+	ListTransfer(logEntry *log.Entry, pk int64, maxKeys int, userName, fsName string) ([]model.FSTransfer, error)
+	ListTransferWithStatus(logEntry *log.Entry, status ...string) ([]model.FSTransfer, error)
+}
+
+type FsReplicationStoreInterface interface {
+	CreateReplication(logEntry *log.Entry, replication *model.FsReplication) error
+	GetReplication(logEntry *log.Entry, fsID string) (model.FsReplication, error)
+	GetReplicationByAnyFs(logEntry *log.Entry, fsID string) (model.FsReplication, error)
+	UpdateReplicationStatus(tx *gorm.DB, fsID, status, message string) error
+	UpdateReplicationStats(logEntry *log.Entry, fsID string, stats model.ReplicationStats, lastSyncedAt *time.Time, message string) error
+	DeleteReplication(logEntry *log.Entry, fsID string) error
+	DeleteFsReplication(tx *gorm.DB, fsID string) error
+	ListReplication(logEntry *log.Entry, status ...string) ([]model.FsReplication, error)
+}
+
+type FsLifecycleStoreInterface interface {
+	CreateLifecycleRule(logEntry *log.Entry, rule *model.FsLifecycleRule) error
+	GetLifecycleRule(logEntry *log.Entry, fsID, name string) (model.FsLifecycleRule, error)
+	ListLifecycleRule(logEntry *log.Entry, fsID string) ([]model.FsLifecycleRule, error)
+	UpdateLifecycleRule(logEntry *log.Entry, fsID, name string, values map[string]interface{}) error
+	UpdateLifecycleReport(logEntry *log.Entry, fsID, name string, report model.LifecycleReport, runAt time.Time, message string) error
+	DeleteLifecycleRule(logEntry *log.Entry, fsID, name string) error
+	DeleteFsLifecycleRule(tx *gorm.DB, fsID string) error
+}
+
+type FsUsageStoreInterface interface {
+	CreateUsages(logEntry *log.Entry, usages []model.FsUsage) error
+	ListUsage(logEntry *log.Entry, fsID string, scanTime time.Time) ([]model.FsUsage, error)
+	ListUsageHistory(logEntry *log.Entry, fsID, path string, since time.Time) ([]model.FsUsage, error)
+	ListLatestUsage(logEntry *log.Entry) ([]model.FsUsage, error)
+	DeleteUsageBefore(logEntry *log.Entry, before time.Time) error
+	DeleteFsUsage(tx *gorm.DB, fsID string) error
+}
+
+type FsAclStoreInterface interface {
+	SaveAcl(logEntry *log.Entry, acl *model.FsAcl) error
+	DeleteAcl(logEntry *log.Entry, fsID, granteeType, granteeName string) error
+	ListAcl(logEntry *log.Entry, fsID string) ([]model.FsAcl, error)
+	ListGranteeAcl(logEntry *log.Entry, fsID, userName, queueName string) ([]model.FsAcl, error)
+	DeleteFsAcl(tx *gorm.DB, fsID string) error
+}
+
+type ImageBuildStoreInterface interface {
+	CreateImageBuild(logEntry *log.Entry, build *model.ImageBuild) error
+	GetImageBuild(logEntry *log.Entry, id string) (model.ImageBuild, error)
+	UpdateImageBuild(logEntry *log.Entry, id string, build *model.ImageBuild) error
+	DeleteImageBuild(logEntry *log.Entry, id string) error
+	ListImageBuild(logEntry *log.Entry, pk int64, maxKeys int, userName, name string) ([]model.ImageBuild, error)
+	ListImageBuildWithStatus(logEntry *log.Entry, status ...string) ([]model.ImageBuild, error)
+}
+
+type VisualizationStoreInterface interface {
+	CreateVisualization(logEntry *log.Entry, vis *model.Visualization) error
+	GetVisualization(logEntry *log.Entry, id string) (model.Visualization, error)
+	UpdateVisualization(logEntry *log.Entry, id string, vis model.Visualization) error
+	DeleteVisualization(logEntry *log.Entry, id string) error
+	ListVisualization(logEntry *log.Entry, pk int64, maxKeys int, userName, jobID string) ([]model.Visualization, error)
+	ListExpiredVisualization(logEntry *log.Entry, now time.Time) ([]model.Visualization, error)
+}
+
+type DatasetStoreInterface interface {
+	CreateDataset(logEntry *log.Entry, dataset *model.Dataset) error
+	GetDataset(logEntry *log.Entry, userName, name string) (model.Dataset, error)
+	ListDataset(logEntry *log.Entry, pk int64, maxKeys int, userName, fsName string) ([]model.Dataset, error)
+	DeleteDataset(logEntry *log.Entry, id string) error
+	CreateDatasetVersion(logEntry *log.Entry, version *model.DatasetVersion) error
+	GetDatasetVersion(logEntry *log.Entry, datasetID, version string) (model.DatasetVersion, error)
+	ListDatasetVersion(logEntry *log.Entry, datasetID string) ([]model.DatasetVersion, error)
+}
+
 type QueueStoreInterface interface {
 	CreateQueue(queue *model.Queue) error
 	CreateOrUpdateQueue(queue *model.Queue) error
@@ -74,10 +229,12 @@ type QueueStoreInterface interface {
 	DeleteQueue(queueName string) error
 	IsQueueExist(queueName string) bool
 	GetQueueByName(queueName string) (model.Queue, error)
+	GetCachedQueueByName(queueName string) (model.Queue, error)
 	GetQueueByID(queueID string) (model.Queue, error)
-	ListQueue(pk int64, maxKeys int, queueName string, userName string) ([]model.Queue, error)
+	ListQueue(pk int64, maxKeys int, queueName, userName, project string) ([]model.Queue, error)
 	GetLastQueue() (model.Queue, error)
 	ListQueuesByCluster(clusterID string) []model.Queue
+	UpdateQueueNodePool(queueName, nodePool string) error
 	IsQueueInUse(queueID string) (bool, map[string]schema.JobStatus)
 	DeepCopyQueue(queueSrc model.Queue, queueDesc *model.Queue)
 }
@@ -90,13 +247,17 @@ type ClusterStoreInterface interface {
 	GetClusterById(clusterId string) (model.ClusterInfo, error)
 	DeleteCluster(clusterName string) error
 	UpdateCluster(clusterId string, clusterInfo *model.ClusterInfo) error
+	UpdateClusterCredential(clusterId string, credential string) error
 	ActiveClusters() []model.ClusterInfo
+	SaveClusterComponent(component *model.ClusterComponent) error
+	ListClusterComponents(clusterId string) ([]model.ClusterComponent, error)
 }
 
 type FlavourStoreInterface interface {
 	CreateFlavour(flavour *model.Flavour) error
 	DeleteFlavour(flavourName string) error
 	GetFlavour(flavourName string) (model.Flavour, error)
+	GetCachedFlavour(flavourName string) (model.Flavour, error)
 	ListFlavour(pk int64, maxKeys int, clusterID, queryKey string) ([]model.Flavour, error)
 	UpdateFlavour(flavour *model.Flavour) error
 	GetLastFlavour() (model.Flavour, error)
@@ -108,8 +269,8 @@ type PipelineStoreInterface interface {
 	UpdatePipeline(logEntry *log.Entry, ppl *model.Pipeline, pplVersion *model.PipelineVersion) (pplID string, pplVersionID string, err error)
 	GetPipelineByID(id string) (model.Pipeline, error)
 	GetPipeline(name, userName string) (model.Pipeline, error)
-	ListPipeline(pk int64, maxKeys int, userFilter, nameFilter []string) ([]model.Pipeline, error)
-	IsLastPipelinePk(logEntry *log.Entry, pk int64, userFilter, nameFilter []string) (bool, error)
+	ListPipeline(pk int64, maxKeys int, userFilter, nameFilter []string, project string) ([]model.Pipeline, error)
+	IsLastPipelinePk(logEntry *log.Entry, pk int64, userFilter, nameFilter []string, project string) (bool, error)
 	DeletePipeline(logEntry *log.Entry, id string) error
 	// pipeline_version
 	ListPipelineVersion(pipelineID string, pk int64, maxKeys int, fsFilter []string) ([]model.PipelineVersion, error)
@@ -118,6 +279,7 @@ type PipelineStoreInterface interface {
 	GetPipelineVersions(pipelineID string) ([]model.PipelineVersion, error)
 	GetPipelineVersion(pipelineID string, pipelineVersionID string) (model.PipelineVersion, error)
 	GetLastPipelineVersion(pipelineID string) (model.PipelineVersion, error)
+	GetPreviousPipelineVersion(pipelineID string, pk int64) (model.PipelineVersion, error)
 	DeletePipelineVersion(logEntry *log.Entry, pipelineID string, pipelineVersionID string) error
 }
 
@@ -126,7 +288,8 @@ type FileSystemStoreInterface interface {
 	CreatFileSystem(fs *model.FileSystem) error
 	GetFileSystemWithFsID(fsID string) (model.FileSystem, error)
 	DeleteFileSystem(tx *gorm.DB, id string) error
-	ListFileSystem(limit int, userName, marker, fsName string) ([]model.FileSystem, error)
+	UpdateFileSystemTarget(tx *gorm.DB, fs *model.FileSystem) error
+	ListFileSystem(limit int, userName, marker, fsName, project string) ([]model.FileSystem, error)
 	GetSimilarityAddressList(fsType string, ips []string) ([]model.FileSystem, error)
 	// link
 	CreateLink(link *model.Link) error
@@ -159,7 +322,10 @@ type AuthStoreInterface interface {
 	// user
 	CreateUser(ctx *logger.RequestContext, user *model.User) error
 	UpdateUser(ctx *logger.RequestContext, userName, password string) error
-	ListUser(ctx *logger.RequestContext, pk int64, maxKey int) ([]model.User, error)
+	UpdateUserEmail(ctx *logger.RequestContext, userName, email string) error
+	UpdateUserLoginFailures(ctx *logger.RequestContext, userName string, failedLogins int, lockedUntil *time.Time) error
+	UpdateUserResetToken(ctx *logger.RequestContext, userName, tokenHash string, expireAt *time.Time) error
+	ListUser(ctx *logger.RequestContext, pk int64, maxKey int, project string) ([]model.User, error)
 	DeleteUser(ctx *logger.RequestContext, userName string) error
 	GetUserByName(ctx *logger.RequestContext, userName string) (model.User, error)
 	GetLastUser(ctx *logger.RequestContext) (model.User, error)
@@ -172,6 +338,43 @@ type AuthStoreInterface interface {
 	DeleteGrantByResourceID(ctx *logger.RequestContext, resourceID string) error
 	ListGrant(ctx *logger.RequestContext, pk int64, maxKeys int, userName string) ([]model.Grant, error)
 	GetLastGrant(ctx *logger.RequestContext) (model.Grant, error)
+	// user preference
+	GetUserPreference(ctx *logger.RequestContext, userName string) (model.UserPreference, error)
+	SaveUserPreference(ctx *logger.RequestContext, preference *model.UserPreference) error
+	DeleteUserPreference(ctx *logger.RequestContext, userName string) error
+	// user quota
+	GetUserQuota(ctx *logger.RequestContext, userName string) (model.UserQuota, error)
+	GetCachedUserQuota(ctx *logger.RequestContext, userName string) (model.UserQuota, error)
+	ListUserQuotaWithDuration(ctx *logger.RequestContext) ([]model.UserQuota, error)
+	SaveUserQuota(ctx *logger.RequestContext, quota *model.UserQuota) error
+	DeleteUserQuota(ctx *logger.RequestContext, userName string) error
+	// user session
+	CreateSession(ctx *logger.RequestContext, session *model.UserSession) error
+	GetSession(ctx *logger.RequestContext, sessionID string) (model.UserSession, error)
+	ListActiveSessions(ctx *logger.RequestContext, userName string) ([]model.UserSession, error)
+	UpdateSessionActiveTime(ctx *logger.RequestContext, sessionID string, activeAt time.Time) error
+	RevokeSession(ctx *logger.RequestContext, sessionID string) error
+	RevokeUserSessions(ctx *logger.RequestContext, userName, exceptID string) (int64, error)
+	DeleteExpiredSessions(ctx *logger.RequestContext, before time.Time) error
+	// impersonation audit
+	CreateImpersonationAudit(ctx *logger.RequestContext, audit *model.ImpersonationAudit) error
+	ListImpersonationAudit(ctx *logger.RequestContext, pk int64, maxKeys int, operator, userName string) ([]model.ImpersonationAudit, error)
+}
+
+type ProjectStoreInterface interface {
+	// project
+	CreateProject(ctx *logger.RequestContext, project *model.Project) error
+	UpdateProject(ctx *logger.RequestContext, project *model.Project) error
+	DeleteProject(ctx *logger.RequestContext, projectName string) error
+	GetProjectByName(ctx *logger.RequestContext, projectName string) (model.Project, error)
+	ListProject(ctx *logger.RequestContext, pk int64, maxKeys int, userName string) ([]model.Project, error)
+	GetLastProject(ctx *logger.RequestContext, userName string) (model.Project, error)
+	// project member
+	AddProjectMember(ctx *logger.RequestContext, member *model.ProjectMember) error
+	RemoveProjectMember(ctx *logger.RequestContext, projectName, resourceType, resourceID string) error
+	DeleteProjectMemberByResource(tx *gorm.DB, resourceType, resourceID string) error
+	GetProjectMember(ctx *logger.RequestContext, projectName, resourceType, resourceID string) (model.ProjectMember, error)
+	ListProjectMember(ctx *logger.RequestContext, projectName, resourceType, resourceID string) ([]model.ProjectMember, error)
 }
 
 type JobStoreInterface interface {
@@ -185,14 +388,24 @@ type JobStoreInterface interface {
 	UpdateJobConfig(jobId string, conf *schema.Conf) error
 	UpdateJob(jobID string, status schema.JobStatus, runtimeInfo, runtimeStatus interface{}, message string) (schema.JobStatus, error)
 	ListQueueJob(queueID string, status []schema.JobStatus) []model.Job
-	ListQueueInitJob(queueID string) []model.Job
+	ListQueueInitJob(queueID string, afterPk int64, limit int) []model.Job
+	ListJobQueueIDs(status schema.JobStatus) []string
 	ListJobsByQueueIDsAndStatus(queueIDs []string, status schema.JobStatus) []model.Job
 	ListJobByStatus(status schema.JobStatus) []model.Job
+	ListUserJob(userName string, status []schema.JobStatus) []model.Job
+	CountConcurrencyGroupJob(userName, group string, status []schema.JobStatus) (int64, error)
+	MarkJobRequeuing(jobID, reason string, requeueLimit int) (bool, error)
+	RequeueJob(jobID, message string) error
+	MarkJobBursting(jobID, fromQueue, queueID string, conf *schema.Conf, members []schema.Member) (bool, error)
+	RevertJobBursting(job *model.Job) error
 	GetJobsByRunID(runID string, jobID string) ([]model.Job, error)
 	ListJobByUpdateTime(updateTime string) ([]model.Job, error)
+	ListJobActivatedBetween(start, end time.Time) ([]model.Job, error)
+	ListJobActiveAt(t time.Time) ([]model.Job, error)
+	ListJobFinishedBetween(start, end time.Time) ([]model.Job, error)
 	ListJobByParentID(parentID string) ([]model.Job, error)
 	GetLastJob() (model.Job, error)
-	ListJob(pk int64, maxKeys int, queue, status, startTime, timestamp, userFilter string, labels map[string]string) ([]model.Job, error)
+	ListJob(pk int64, maxKeys int, queue, status, startTime, timestamp, userFilter string, labels map[string]string, project string) ([]model.Job, error)
 	// job_lable
 	ListJobIDByLabels(labels map[string]string) ([]string, error)
 	// job_task
@@ -208,3 +421,40 @@ type ImageStoreInterface interface {
 	GetUrlByPFImageID(logEntry *log.Entry, PFImageID string) (string, error)
 	UpdateImage(logEntry *log.Entry, PFImageID string, image model.Image) error
 }
+
+type StatisticsStoreInterface interface {
+	SaveHourlyStats(bucket time.Time, queues []model.QueueHourlyStat, users []model.UserHourlyStat, images []model.ImageHourlyStat) error
+	GetLastStatBucket() (time.Time, error)
+	ListQueueHourlyStats(start, end time.Time, queueNames []string) ([]model.QueueHourlyStat, error)
+	SumUserGPUHours(start, end time.Time, limit int) ([]model.UserHourlyStat, error)
+	SumImageJobs(start, end time.Time) ([]model.ImageHourlyStat, error)
+	DeleteHourlyStatsBefore(t time.Time) error
+}
+
+type AlertStoreInterface interface {
+	CreateAlertRule(logEntry *log.Entry, rule *model.AlertRule) error
+	GetAlertRule(logEntry *log.Entry, name string) (model.AlertRule, error)
+	ListAlertRule(logEntry *log.Entry, pk int64, maxKeys int, enabledOnly bool) ([]model.AlertRule, error)
+	UpdateAlertRule(logEntry *log.Entry, rule *model.AlertRule) error
+	DeleteAlertRule(logEntry *log.Entry, name string) error
+}
+
+type ExportStoreInterface interface {
+	GetWatermark(name string) (model.ExportWatermark, error)
+	SaveWatermark(watermark *model.ExportWatermark) error
+	ListAfterWatermark(table, timeColumn string, watermark model.ExportWatermark, end time.Time, limit int, records interface{}) error
+}
+
+type EventStoreInterface interface {
+	ListUnpublished(limit int) ([]model.ResourceEvent, error)
+	MarkPublished(pks []int64, publishedAt time.Time) error
+	DeletePublishedBefore(t time.Time) (int64, error)
+}
+
+type NodePoolStoreInterface interface {
+	CreateNodePool(pool *model.NodePool) error
+	GetNodePool(clusterID, name string) (model.NodePool, error)
+	ListNodePools(clusterID string) ([]model.NodePool, error)
+	UpdateNodePool(pool *model.NodePool) error
+	DeleteNodePool(clusterID, name string) error
+}
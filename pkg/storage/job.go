@@ -26,6 +26,7 @@ import (
 
 	log "github.com/sirupsen/logrus"
 
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/errors"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
 	"github.com/PaddlePaddle/PaddleFlow/pkg/common/schema"
@@ -47,7 +48,12 @@ func (js *JobStore) CreateJob(job *model.Job) error {
 	if job.ID == "" {
 		job.ID = uuid.GenerateIDWithLength(schema.JobPrefix, uuid.JobIDLength)
 	}
-	err := js.db.Create(job).Error
+	err := js.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(job).Error; err != nil {
+			return err
+		}
+		return RecordEvent(tx, jobEvent(job.ID, job.UserName, model.EventCreated, "", job.Status))
+	})
 	if err == nil {
 		// in case panic
 		var queueName string
@@ -92,11 +98,13 @@ func (js *JobStore) GetJobStatusByID(jobID string) (schema.JobStatus, error) {
 }
 
 func (js *JobStore) DeleteJob(jobID string) error {
-	t := js.db.Table("job").Where("id = ?", jobID).Where("deleted_at = ''").UpdateColumn("deleted_at", time.Now().Format(model.TimeFormat))
-	if t.Error != nil {
-		return t.Error
-	}
-	return nil
+	return js.db.Transaction(func(tx *gorm.DB) error {
+		t := tx.Table("job").Where("id = ?", jobID).Where("deleted_at = ''").UpdateColumn("deleted_at", time.Now().Format(model.TimeFormat))
+		if t.Error != nil || t.RowsAffected == 0 {
+			return t.Error
+		}
+		return RecordEvent(tx, jobEvent(jobID, "", model.EventDeleted, "", ""))
+	})
 }
 
 func (js *JobStore) UpdateJobStatus(jobId, errMessage string, newStatus schema.JobStatus) error {
@@ -109,12 +117,14 @@ func (js *JobStore) UpdateJobStatus(jobId, errMessage string, newStatus schema.J
 	if errMessage != "" {
 		updatedJob.Message = errMessage
 	}
-	log.Infof("update for job %s, updated content [%+v]", jobId, updatedJob)
-	tx := js.db.Model(&model.Job{}).Where("id = ?", jobId).Where("deleted_at = ''").Updates(updatedJob)
-	if tx.Error != nil {
-		return tx.Error
-	}
-	return nil
+	logger.LoggerForJob(jobId).Infof("update for job, updated content [%+v]", updatedJob)
+	return js.db.Transaction(func(tx *gorm.DB) error {
+		t := tx.Model(&model.Job{}).Where("id = ?", jobId).Where("deleted_at = ''").Updates(updatedJob)
+		if t.Error != nil || t.RowsAffected == 0 {
+			return t.Error
+		}
+		return RecordEvent(tx, jobEvent(jobId, job.UserName, model.EventStatusChanged, job.Status, updatedJob.Status))
+	})
 }
 
 func (js *JobStore) UpdateJobConfig(jobId string, conf *schema.Conf) error {
@@ -146,7 +156,7 @@ func jobStatusTransition(jobID string, preStatus, newStatus schema.JobStatus, ms
 			msg = "job is terminated"
 		}
 	}
-	log.Infof("job %s status update from %s to %s", jobID, preStatus, newStatus)
+	logger.LoggerForJob(jobID).Infof("job status update from %s to %s", preStatus, newStatus)
 	return newStatus, msg
 }
 
@@ -182,11 +192,17 @@ func (js *JobStore) UpdateJob(jobID string, status schema.JobStatus, runtimeInfo
 		updatedJob.ActivatedAt.Time = time.Now()
 		updatedJob.ActivatedAt.Valid = true
 	}
-	log.Debugf("update for job %s, updated content [%+v]", jobID, updatedJob)
-	tx := js.db.Table("job").Where("id = ?", jobID).Where("deleted_at = ''").Updates(&updatedJob)
-	if tx.Error != nil {
-		log.Errorf("update job failed, err %v", tx.Error)
-		return "", tx.Error
+	logger.LoggerForJob(jobID).Debugf("update for job, updated content [%+v]", updatedJob)
+	err = js.db.Transaction(func(tx *gorm.DB) error {
+		t := tx.Table("job").Where("id = ?", jobID).Where("deleted_at = ''").Updates(&updatedJob)
+		if t.Error != nil || t.RowsAffected == 0 {
+			return t.Error
+		}
+		return RecordEvent(tx, jobEvent(jobID, job.UserName, model.EventStatusChanged, job.Status, updatedJob.Status))
+	})
+	if err != nil {
+		logger.LoggerForJob(jobID).Errorf("update job failed, err %v", err)
+		return "", err
 	}
 	return updatedJob.Status, nil
 }
@@ -202,8 +218,14 @@ func (js *JobStore) ListQueueJob(queueID string, status []schema.JobStatus) []mo
 	return jobs
 }
 
-func (js *JobStore) ListQueueInitJob(queueID string) []model.Job {
-	db := js.db.Table("job").Where("queue_id = ?", queueID).Where("status = ?", schema.StatusJobInit).Where("deleted_at = ''")
+// ListQueueInitJob lists at most limit init jobs of queue whose pk is larger than afterPk in order of creation,
+// so that callers can page past jobs held in init status, limit <= 0 means no limit
+func (js *JobStore) ListQueueInitJob(queueID string, afterPk int64, limit int) []model.Job {
+	db := js.db.Table("job").Where("queue_id = ?", queueID).Where("status = ?", schema.StatusJobInit).Where("deleted_at = ''").
+		Where("pk > ?", afterPk).Order("pk asc")
+	if limit > 0 {
+		db = db.Limit(limit)
+	}
 
 	var jobs []model.Job
 	err := db.Find(&jobs).Error
@@ -225,6 +247,17 @@ func (js *JobStore) ListJobsByQueueIDsAndStatus(queueIDs []string, status schema
 	return jobs
 }
 
+// ListJobQueueIDs lists ids of queues which have jobs in status
+func (js *JobStore) ListJobQueueIDs(status schema.JobStatus) []string {
+	var queueIDs []string
+	err := js.db.Table("job").Where("status = ?", status).Where("deleted_at = ''").Distinct("queue_id").Pluck("queue_id", &queueIDs).Error
+	if err != nil {
+		log.Errorf("list queues of %s jobs failed, err: %s", status, err.Error())
+		return []string{}
+	}
+	return queueIDs
+}
+
 func (js *JobStore) ListJobByStatus(status schema.JobStatus) []model.Job {
 	db := js.db.Table("job").Where("status = ?", status).Where("deleted_at = ''")
 
@@ -236,9 +269,162 @@ func (js *JobStore) ListJobByStatus(status schema.JobStatus) []model.Job {
 	return jobs
 }
 
+// ListJobActivatedBetween lists jobs, including deleted ones, which are running during [start, end)
+func (js *JobStore) ListJobActivatedBetween(start, end time.Time) ([]model.Job, error) {
+	var jobs []model.Job
+	err := js.db.Table("job").Where("activated_at IS NOT NULL AND activated_at < ?", end).
+		Where("updated_at >= ? OR status IN ?", start, []schema.JobStatus{schema.StatusJobRunning, schema.StatusJobTerminating}).
+		Find(&jobs).Error
+	if err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// ListJobActiveAt lists jobs, including deleted ones, which are created before t and not finished at t
+func (js *JobStore) ListJobActiveAt(t time.Time) ([]model.Job, error) {
+	var jobs []model.Job
+	err := js.db.Table("job").Where("created_at < ?", t).
+		Where("updated_at >= ? OR status NOT IN ?", t, schema.JobFinalStatus()).
+		Find(&jobs).Error
+	if err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// ListJobFinishedBetween lists jobs, including deleted ones, which are finished during [start, end)
+func (js *JobStore) ListJobFinishedBetween(start, end time.Time) ([]model.Job, error) {
+	var jobs []model.Job
+	err := js.db.Table("job").Where("status IN ?", schema.JobFinalStatus()).
+		Where("updated_at >= ? AND updated_at < ?", start, end).
+		Find(&jobs).Error
+	if err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+func (js *JobStore) ListUserJob(userName string, status []schema.JobStatus) []model.Job {
+	db := js.db.Table("job").Where("user_name = ?", userName).Where("status in ?", status).Where("deleted_at = ''")
+
+	var jobs []model.Job
+	if err := db.Find(&jobs).Error; err != nil {
+		log.Errorf("list jobs of user %s with status %v failed, error:%s", userName, status, err.Error())
+		return []model.Job{}
+	}
+	return jobs
+}
+
+// CountConcurrencyGroupJob 统计用户在并发组中处于status状态的作业数
+func (js *JobStore) CountConcurrencyGroupJob(userName, group string, status []schema.JobStatus) (int64, error) {
+	var count int64
+	err := js.db.Table("job").Where("user_name = ? AND concurrency_group = ?", userName, group).
+		Where("status in ?", status).Where("deleted_at = ''").Count(&count).Error
+	if err != nil {
+		log.Errorf("count jobs of user %s in concurrency group %s failed, error:%s", userName, group, err.Error())
+		return 0, err
+	}
+	return count, nil
+}
+
+// MarkJobRequeuing 作业的pod因节点故障丢失时标记作业待重新排队，requeueLimit为重新排队次数上限，
+// 作业已在重新排队或达到上限时返回false
+func (js *JobStore) MarkJobRequeuing(jobID, reason string, requeueLimit int) (bool, error) {
+	tx := js.db.Table("job").Where("id = ?", jobID).Where("deleted_at = ''").
+		Where("status in ?", []schema.JobStatus{schema.StatusJobPending, schema.StatusJobRunning}).
+		Where("requeuing = ? AND requeue_times < ?", false, requeueLimit).
+		Updates(map[string]interface{}{
+			"requeuing":      true,
+			"requeue_times":  gorm.Expr("requeue_times + 1"),
+			"requeue_reason": reason,
+			"message":        fmt.Sprintf("job is requeuing due to node failure, %s", reason),
+		})
+	if tx.Error != nil {
+		logger.LoggerForJob(jobID).Errorf("mark job requeuing failed, error:%s", tx.Error.Error())
+		return false, tx.Error
+	}
+	return tx.RowsAffected > 0, nil
+}
+
+// RequeueJob 集群中的作业删除后将待重新排队的作业恢复为init状态，由job manager重新提交
+func (js *JobStore) RequeueJob(jobID, message string) error {
+	err := js.db.Transaction(func(tx *gorm.DB) error {
+		var job struct {
+			Status   schema.JobStatus
+			UserName string
+		}
+		if err := tx.Table("job").Select("status", "user_name").Where("id = ?", jobID).Limit(1).Scan(&job).Error; err != nil {
+			return err
+		}
+		t := tx.Table("job").Where("id = ?", jobID).Where("deleted_at = ''").Where("requeuing = ?", true).
+			Updates(map[string]interface{}{
+				"status":    schema.StatusJobInit,
+				"requeuing": false,
+				"message":   message,
+			})
+		if t.Error != nil || t.RowsAffected == 0 {
+			return t.Error
+		}
+		return RecordEvent(tx, jobEvent(jobID, job.UserName, model.EventStatusChanged, job.Status, schema.StatusJobInit))
+	})
+	if err != nil {
+		logger.LoggerForJob(jobID).Errorf("requeue job failed, error:%s", err.Error())
+		return err
+	}
+	return nil
+}
+
+// MarkJobBursting 等待调度的作业溢出到其他集群的队列时，更新作业的队列和配置并标记作业待重新排队，
+// 作业已在重新排队、已溢出过或不在pending状态时返回false
+func (js *JobStore) MarkJobBursting(jobID, fromQueue, queueID string, conf *schema.Conf, members []schema.Member) (bool, error) {
+	confJSON, err := json.Marshal(conf)
+	if err != nil {
+		return false, err
+	}
+	membersJSON, err := json.Marshal(members)
+	if err != nil {
+		return false, err
+	}
+	tx := js.db.Table("job").Where("id = ?", jobID).Where("deleted_at = ''").
+		Where("status = ?", schema.StatusJobPending).
+		Where("requeuing = ? AND burst_from_queue = ''", false).
+		Updates(map[string]interface{}{
+			"requeuing":        true,
+			"queue_id":         queueID,
+			"config":           string(confJSON),
+			"members":          string(membersJSON),
+			"burst_from_queue": fromQueue,
+			"message":          fmt.Sprintf("job is bursting from queue %s to queue %s", fromQueue, conf.GetQueueName()),
+		})
+	if tx.Error != nil {
+		logger.LoggerForJob(jobID).Errorf("mark job bursting failed, error:%s", tx.Error.Error())
+		return false, tx.Error
+	}
+	return tx.RowsAffected > 0, nil
+}
+
+// RevertJobBursting 删除本地集群中的作业失败时，恢复作业溢出前的队列和配置
+func (js *JobStore) RevertJobBursting(job *model.Job) error {
+	tx := js.db.Table("job").Where("id = ?", job.ID).Where("deleted_at = ''").Where("requeuing = ?", true).
+		Updates(map[string]interface{}{
+			"requeuing":        false,
+			"queue_id":         job.QueueID,
+			"config":           job.ConfigJson,
+			"members":          job.MembersJson,
+			"burst_from_queue": "",
+			"message":          job.Message,
+		})
+	if tx.Error != nil {
+		logger.LoggerForJob(job.ID).Errorf("revert bursting of job failed, error:%s", tx.Error.Error())
+		return tx.Error
+	}
+	return nil
+}
+
 func (js *JobStore) GetJobsByRunID(runID string, jobID string) ([]model.Job, error) {
 	var jobList []model.Job
-	query := js.db.Table("job").Where("id like ?", "job-"+runID+"-%").Where("deleted_at = ''")
+	query := js.db.Table("job").Where("run_id = ?", runID).Where("deleted_at = ''")
 	if jobID != "" {
 		query = query.Where("id = ?", jobID)
 	}
@@ -280,14 +466,20 @@ func (js *JobStore) GetLastJob() (model.Job, error) {
 	return job, nil
 }
 
-func (js *JobStore) ListJob(pk int64, maxKeys int, queue, status, startTime, timestamp, userFilter string, labels map[string]string) ([]model.Job, error) {
+func (js *JobStore) ListJob(pk int64, maxKeys int, queue, status, startTime, timestamp, userFilter string, labels map[string]string, project string) ([]model.Job, error) {
 	tx := js.db.Table("job").Where("pk > ?", pk).Where("parent_job = ''").Where("deleted_at = ''")
-	if userFilter != "root" {
+	if userFilter != "" && userFilter != "root" {
 		tx = tx.Where("user_name = ?", userFilter)
 	}
 	if queue != "" {
 		tx = tx.Where("queue_id = ?", queue)
 	}
+	if project != "" {
+		// 项目中的作业即提交到项目队列中的作业
+		projectQueues := js.db.Model(&model.Queue{}).Select("id").
+			Where("name IN (?)", projectResourceQuery(js.db, project, common.ResourceTypeQueue))
+		tx = tx.Where("queue_id IN (?)", projectQueues)
+	}
 	if status != "" {
 		tx = tx.Where("status = ?", status)
 	}
@@ -384,3 +576,14 @@ func (js *JobStore) ListByJobID(jobID string) ([]model.JobTask, error) {
 	}
 	return jobList, nil
 }
+
+func jobEvent(jobID, userName, eventType string, preStatus, status schema.JobStatus) *model.ResourceEvent {
+	return &model.ResourceEvent{
+		ResourceType:   common.ResourceTypeJob,
+		ResourceID:     jobID,
+		Type:           eventType,
+		Status:         string(status),
+		PreviousStatus: string(preStatus),
+		UserName:       userName,
+	}
+}
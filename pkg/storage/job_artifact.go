@@ -0,0 +1,91 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+)
+
+type JobArtifactStore struct {
+	db *gorm.DB
+}
+
+func newJobArtifactStore(db *gorm.DB) *JobArtifactStore {
+	return &JobArtifactStore{db: db}
+}
+
+func (as *JobArtifactStore) CreateJobArtifacts(logEntry *log.Entry, artifacts []model.JobArtifact) error {
+	if len(artifacts) == 0 {
+		return nil
+	}
+	logEntry.Debugf("begin create job artifacts: %+v", artifacts)
+	tx := as.db.Model(&model.JobArtifact{}).Create(&artifacts)
+	if tx.Error != nil {
+		logEntry.Errorf("create job artifacts failed. error:%v", tx.Error)
+		return tx.Error
+	}
+	return nil
+}
+
+func (as *JobArtifactStore) ListJobArtifacts(logEntry *log.Entry, jobID string) ([]model.JobArtifact, error) {
+	logEntry.Debugf("begin list artifacts of job[%s]", jobID)
+	var artifacts []model.JobArtifact
+	tx := as.db.Model(&model.JobArtifact{}).Where("job_id = ?", jobID).Order("pk").Find(&artifacts)
+	if tx.Error != nil {
+		logEntry.Errorf("list artifacts of job[%s] failed. error:%v", jobID, tx.Error)
+		return nil, tx.Error
+	}
+	return artifacts, nil
+}
+
+// ListJobArtifactsByStatus 按创建顺序返回指定状态的产物，limit<=0时不限制个数
+func (as *JobArtifactStore) ListJobArtifactsByStatus(logEntry *log.Entry, status string, limit int) ([]model.JobArtifact, error) {
+	var artifacts []model.JobArtifact
+	tx := as.db.Model(&model.JobArtifact{}).Where("status = ?", status).Order("pk")
+	if limit > 0 {
+		tx = tx.Limit(limit)
+	}
+	tx = tx.Find(&artifacts)
+	if tx.Error != nil {
+		logEntry.Errorf("list job artifacts with status[%s] failed. error:%v", status, tx.Error)
+		return nil, tx.Error
+	}
+	return artifacts, nil
+}
+
+func (as *JobArtifactStore) UpdateJobArtifact(logEntry *log.Entry, artifact *model.JobArtifact) error {
+	logEntry.Debugf("begin update artifact[%s] of job[%s], status: %s", artifact.Name, artifact.JobID, artifact.Status)
+	tx := as.db.Save(artifact)
+	if tx.Error != nil {
+		logEntry.Errorf("update artifact[%s] of job[%s] failed. error:%v", artifact.Name, artifact.JobID, tx.Error)
+		return tx.Error
+	}
+	return nil
+}
+
+func (as *JobArtifactStore) DeleteJobArtifacts(logEntry *log.Entry, jobID string) error {
+	logEntry.Debugf("begin delete artifacts of job[%s]", jobID)
+	tx := as.db.Where("job_id = ?", jobID).Delete(&model.JobArtifact{})
+	if tx.Error != nil {
+		logEntry.Errorf("delete artifacts of job[%s] failed. error:%v", jobID, tx.Error)
+		return tx.Error
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+)
+
+type JobMetricStore struct {
+	db *gorm.DB
+}
+
+func newJobMetricStore(db *gorm.DB) *JobMetricStore {
+	return &JobMetricStore{db: db}
+}
+
+func (ms *JobMetricStore) CreateJobMetrics(logEntry *log.Entry, metrics []model.JobMetric) error {
+	if len(metrics) == 0 {
+		return nil
+	}
+	logEntry.Debugf("begin create job metrics: %+v", metrics)
+	tx := ms.db.Model(&model.JobMetric{}).Create(&metrics)
+	if tx.Error != nil {
+		logEntry.Errorf("create job metrics failed. error:%v", tx.Error)
+		return tx.Error
+	}
+	return nil
+}
+
+// ListJobMetrics keys为空时返回全部指标，结果按step与日志时间排序
+func (ms *JobMetricStore) ListJobMetrics(logEntry *log.Entry, jobID string, keys []string) ([]model.JobMetric, error) {
+	logEntry.Debugf("begin list metrics of job[%s]. keys:%v", jobID, keys)
+	var metrics []model.JobMetric
+	tx := ms.db.Model(&model.JobMetric{}).Where("job_id = ?", jobID)
+	if len(keys) > 0 {
+		tx = tx.Where("`key` IN (?)", keys)
+	}
+	tx = tx.Order("step, timestamp, pk").Find(&metrics)
+	if tx.Error != nil {
+		logEntry.Errorf("list metrics of job[%s] failed. error:%v", jobID, tx.Error)
+		return nil, tx.Error
+	}
+	return metrics, nil
+}
+
+// GetLastJobMetricTimestamp 返回任务最后一条指标的日志时间，没有指标时返回0
+func (ms *JobMetricStore) GetLastJobMetricTimestamp(logEntry *log.Entry, jobID, taskID string) (int64, error) {
+	var timestamp int64
+	tx := ms.db.Model(&model.JobMetric{}).Where("job_id = ? AND task_id = ?", jobID, taskID).
+		Select("COALESCE(MAX(timestamp), 0)").Scan(&timestamp)
+	if tx.Error != nil {
+		logEntry.Errorf("get last metric timestamp of job[%s] task[%s] failed. error:%v", jobID, taskID, tx.Error)
+		return 0, tx.Error
+	}
+	return timestamp, nil
+}
+
+func (ms *JobMetricStore) DeleteJobMetrics(logEntry *log.Entry, jobID string) error {
+	logEntry.Debugf("begin delete metrics of job[%s]", jobID)
+	tx := ms.db.Where("job_id = ?", jobID).Delete(&model.JobMetric{})
+	if tx.Error != nil {
+		logEntry.Errorf("delete metrics of job[%s] failed. error:%v", jobID, tx.Error)
+		return tx.Error
+	}
+	return nil
+}
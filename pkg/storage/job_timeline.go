@@ -0,0 +1,49 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"gorm.io/gorm"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+)
+
+type JobTimelineStore struct {
+	db *gorm.DB
+}
+
+func newJobTimelineStore(db *gorm.DB) *JobTimelineStore {
+	return &JobTimelineStore{db: db}
+}
+
+func (ts *JobTimelineStore) AddJobTimelineEvent(event *model.JobTimelineEvent) error {
+	return ts.db.Create(event).Error
+}
+
+// ListJobTimeline 按发生顺序返回作业的时间线事件
+func (ts *JobTimelineStore) ListJobTimeline(jobID string) ([]model.JobTimelineEvent, error) {
+	var events []model.JobTimelineEvent
+	tx := ts.db.Where("job_id = ?", jobID).Order("pk").Find(&events)
+	return events, tx.Error
+}
+
+// HasJobTimelineEvent 作业的时间线上是否有指定类型的事件
+func (ts *JobTimelineStore) HasJobTimelineEvent(jobID, eventType string) (bool, error) {
+	var count int64
+	tx := ts.db.Model(&model.JobTimelineEvent{}).Where("job_id = ? AND type = ?", jobID, eventType).Count(&count)
+	return count > 0, tx.Error
+}
@@ -0,0 +1,58 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"time"
+
+	"github.com/bluele/gcache"
+)
+
+const (
+	lookupCacheSize = 1024
+	// lookupCacheTTL bounds staleness of records changed by other servers
+	lookupCacheTTL = 10 * time.Second
+)
+
+// lookupCache 缓存作业创建等热点路径上读取且很少变化的记录，本服务修改记录时清空缓存，
+// 其他服务修改的记录在过期后生效
+type lookupCache struct {
+	cache gcache.Cache
+}
+
+func newLookupCache() *lookupCache {
+	return &lookupCache{
+		cache: gcache.New(lookupCacheSize).LRU().Expiration(lookupCacheTTL).Build(),
+	}
+}
+
+// get returns cached value of key, or loads and caches it, errors are not cached
+func (c *lookupCache) get(key string, load func() (interface{}, error)) (interface{}, error) {
+	if value, err := c.cache.Get(key); err == nil {
+		return value, nil
+	}
+	value, err := load()
+	if err != nil {
+		return nil, err
+	}
+	_ = c.cache.Set(key, value)
+	return value, nil
+}
+
+func (c *lookupCache) purge() {
+	c.cache.Purge()
+}
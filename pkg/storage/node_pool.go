@@ -0,0 +1,65 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+)
+
+type NodePoolStore struct {
+	db *gorm.DB
+}
+
+func newNodePoolStore(db *gorm.DB) *NodePoolStore {
+	return &NodePoolStore{db: db}
+}
+
+func (ns *NodePoolStore) CreateNodePool(pool *model.NodePool) error {
+	if err := ns.db.Create(pool).Error; err != nil {
+		log.Errorf("create node pool %s failed. error:%s", pool.Name, err.Error())
+		return err
+	}
+	return nil
+}
+
+func (ns *NodePoolStore) GetNodePool(clusterID, name string) (model.NodePool, error) {
+	var pool model.NodePool
+	tx := ns.db.Where("cluster_id = ? AND name = ?", clusterID, name).First(&pool)
+	return pool, tx.Error
+}
+
+func (ns *NodePoolStore) ListNodePools(clusterID string) ([]model.NodePool, error) {
+	var pools []model.NodePool
+	tx := ns.db.Where("cluster_id = ?", clusterID).Order("name").Find(&pools)
+	return pools, tx.Error
+}
+
+func (ns *NodePoolStore) UpdateNodePool(pool *model.NodePool) error {
+	tx := ns.db.Model(pool).Select("gpu_type", "zone", "team", "description", "nodes", "updated_at").Updates(pool)
+	if tx.Error != nil {
+		log.Errorf("update node pool %s failed. error:%s", pool.Name, tx.Error.Error())
+		return tx.Error
+	}
+	return nil
+}
+
+func (ns *NodePoolStore) DeleteNodePool(clusterID, name string) error {
+	return ns.db.Where("cluster_id = ? AND name = ?", clusterID, name).Delete(&model.NodePool{}).Error
+}
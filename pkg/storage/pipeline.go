@@ -122,7 +122,7 @@ func (ps *PipelineStore) GetPipeline(name, userName string) (model.Pipeline, err
 	return ppl, result.Error
 }
 
-func (ps *PipelineStore) ListPipeline(pk int64, maxKeys int, userFilter, nameFilter []string) ([]model.Pipeline, error) {
+func (ps *PipelineStore) ListPipeline(pk int64, maxKeys int, userFilter, nameFilter []string, project string) ([]model.Pipeline, error) {
 	logger.Logger().Debugf("begin list pipeline. ")
 	tx := ps.db.Model(&model.Pipeline{}).Where("pk > ?", pk)
 	if len(userFilter) > 0 {
@@ -131,6 +131,9 @@ func (ps *PipelineStore) ListPipeline(pk int64, maxKeys int, userFilter, nameFil
 	if len(nameFilter) > 0 {
 		tx = tx.Where("name IN (?)", nameFilter)
 	}
+	if project != "" {
+		tx = tx.Where("id IN (?)", projectResourceQuery(ps.db, project, common.ResourceTypePipeline))
+	}
 	if maxKeys > 0 {
 		tx = tx.Limit(maxKeys)
 	}
@@ -144,7 +147,7 @@ func (ps *PipelineStore) ListPipeline(pk int64, maxKeys int, userFilter, nameFil
 	return pplList, nil
 }
 
-func (ps *PipelineStore) IsLastPipelinePk(logEntry *log.Entry, pk int64, userFilter, nameFilter []string) (bool, error) {
+func (ps *PipelineStore) IsLastPipelinePk(logEntry *log.Entry, pk int64, userFilter, nameFilter []string, project string) (bool, error) {
 	logger.Logger().Debugf("begin check isLastPipeline.")
 	tx := ps.db.Model(&model.Pipeline{})
 	if len(userFilter) > 0 {
@@ -153,6 +156,9 @@ func (ps *PipelineStore) IsLastPipelinePk(logEntry *log.Entry, pk int64, userFil
 	if len(nameFilter) > 0 {
 		tx = tx.Where("name IN (?)", nameFilter)
 	}
+	if project != "" {
+		tx = tx.Where("id IN (?)", projectResourceQuery(ps.db, project, common.ResourceTypePipeline))
+	}
 
 	ppl := model.Pipeline{}
 	tx = tx.Last(&ppl)
@@ -246,6 +252,13 @@ func (ps *PipelineStore) GetLastPipelineVersion(pipelineID string) (model.Pipeli
 	return pplVersion, tx.Error
 }
 
+// 获取指定pipeline中，pk小于给定pk的最新版本
+func (ps *PipelineStore) GetPreviousPipelineVersion(pipelineID string, pk int64) (model.PipelineVersion, error) {
+	pplVersion := model.PipelineVersion{}
+	tx := ps.db.Model(&model.PipelineVersion{}).Where("pipeline_id = ?", pipelineID).Where("pk < ?", pk).Last(&pplVersion)
+	return pplVersion, tx.Error
+}
+
 func (ps *PipelineStore) DeletePipelineVersion(logEntry *log.Entry, pipelineID string, pipelineVersionID string) error {
 	logEntry.Debugf("delete pipeline[%s] versionID[%s]", pipelineID, pipelineVersionID)
 	result := ps.db.Model(&model.PipelineVersion{}).Where("pipeline_id = ?", pipelineID).Where("id = ?", pipelineVersionID).Delete(&model.PipelineVersion{})
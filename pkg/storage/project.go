@@ -0,0 +1,193 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/common/logger"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+)
+
+type ProjectStore struct {
+	db *gorm.DB
+}
+
+func newProjectStore(db *gorm.DB) *ProjectStore {
+	return &ProjectStore{db: db}
+}
+
+// projectResourceQuery 返回项目中某类资源ID的子查询，用于列表接口按项目过滤
+func projectResourceQuery(db *gorm.DB, projectName, resourceType string) *gorm.DB {
+	return db.Model(&model.ProjectMember{}).Select("resource_id").
+		Where("project_name = ? AND resource_type = ?", projectName, resourceType)
+}
+
+// userProjectQuery 返回用户所属项目名称的子查询
+func userProjectQuery(db *gorm.DB, userName string) *gorm.DB {
+	return db.Model(&model.ProjectMember{}).Select("project_name").
+		Where("resource_type = ? AND resource_id = ?", common.ResourceTypeUser, userName)
+}
+
+// ============================================================= table project ============================================================= //
+
+func (ps *ProjectStore) CreateProject(ctx *logger.RequestContext, project *model.Project) error {
+	ctx.Logging().Debugf("model begin create project. project:%s ", project.Name)
+	tx := ps.db.Model(&model.Project{}).Create(project)
+	if tx.Error != nil {
+		ctx.Logging().Errorf("model create project failed. project:%s, error:%s", project.Name, tx.Error.Error())
+		return tx.Error
+	}
+	return nil
+}
+
+// UpdateProject 更新项目描述及配额
+func (ps *ProjectStore) UpdateProject(ctx *logger.RequestContext, project *model.Project) error {
+	ctx.Logging().Debugf("model begin update project. project:%s ", project.Name)
+	project.UpdatedAt = time.Now()
+	tx := ps.db.Model(project).Select("description", "max_resources", "updated_at").Updates(project)
+	if tx.Error != nil {
+		ctx.Logging().Errorf("model update project failed. project:%s, error:%s", project.Name, tx.Error.Error())
+		return tx.Error
+	}
+	return nil
+}
+
+// DeleteProject 删除项目及其全部成员关系，项目中的资源本身不受影响
+func (ps *ProjectStore) DeleteProject(ctx *logger.RequestContext, projectName string) error {
+	ctx.Logging().Debugf("model begin delete project. project:%s ", projectName)
+	err := WithTransaction(ps.db, func(tx *gorm.DB) error {
+		if err := tx.Where("project_name = ?", projectName).Delete(&model.ProjectMember{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("name = ?", projectName).Delete(&model.Project{}).Error
+	})
+	if err != nil {
+		ctx.Logging().Errorf("model delete project failed. project:%s, error:%s", projectName, err.Error())
+		return err
+	}
+	return nil
+}
+
+func (ps *ProjectStore) GetProjectByName(ctx *logger.RequestContext, projectName string) (model.Project, error) {
+	ctx.Logging().Debugf("model begin get project. project:%s ", projectName)
+	var project model.Project
+	tx := ps.db.Model(&model.Project{}).Where("name = ?", projectName).First(&project)
+	if tx.Error != nil {
+		ctx.Logging().Errorf("get project failed. project:%s, error:%s", projectName, tx.Error.Error())
+		return model.Project{}, tx.Error
+	}
+	return project, nil
+}
+
+// ListProject 非root用户只能看到自己所属的项目
+func (ps *ProjectStore) ListProject(ctx *logger.RequestContext, pk int64, maxKeys int, userName string) ([]model.Project, error) {
+	ctx.Logging().Debugf("model begin list project. user:%s ", userName)
+	tx := ps.db.Model(&model.Project{}).Where("pk > ?", pk)
+	if !common.IsRootUser(userName) {
+		tx = tx.Where("name IN (?)", userProjectQuery(ps.db, userName))
+	}
+	if maxKeys > 0 {
+		tx = tx.Limit(maxKeys)
+	}
+	var projects []model.Project
+	if err := tx.Find(&projects).Error; err != nil {
+		ctx.Logging().Errorf("list project failed. error:%s", err.Error())
+		return nil, err
+	}
+	return projects, nil
+}
+
+func (ps *ProjectStore) GetLastProject(ctx *logger.RequestContext, userName string) (model.Project, error) {
+	ctx.Logging().Debugf("model get last project. ")
+	tx := ps.db.Model(&model.Project{})
+	if !common.IsRootUser(userName) {
+		tx = tx.Where("name IN (?)", userProjectQuery(ps.db, userName))
+	}
+	project := model.Project{}
+	if err := tx.Last(&project).Error; err != nil {
+		ctx.Logging().Errorf("get last project failed. error:%s", err.Error())
+		return model.Project{}, err
+	}
+	return project, nil
+}
+
+// ============================================================= table project_member ============================================================= //
+
+func (ps *ProjectStore) AddProjectMember(ctx *logger.RequestContext, member *model.ProjectMember) error {
+	ctx.Logging().Debugf("model begin add project member. member:%+v ", member)
+	tx := ps.db.Model(&model.ProjectMember{}).Create(member)
+	if tx.Error != nil {
+		ctx.Logging().Errorf("add project member failed. member:%+v, error:%s", member, tx.Error.Error())
+		return tx.Error
+	}
+	return nil
+}
+
+func (ps *ProjectStore) RemoveProjectMember(ctx *logger.RequestContext, projectName, resourceType, resourceID string) error {
+	ctx.Logging().Debugf("model begin remove project member. project:%s, %s:%s ", projectName, resourceType, resourceID)
+	tx := ps.db.Where("project_name = ? AND resource_type = ? AND resource_id = ?", projectName, resourceType, resourceID).
+		Delete(&model.ProjectMember{})
+	if tx.Error != nil {
+		ctx.Logging().Errorf("remove project member failed. project:%s, %s:%s, error:%s",
+			projectName, resourceType, resourceID, tx.Error.Error())
+		return tx.Error
+	}
+	return nil
+}
+
+// DeleteProjectMemberByResource 资源删除时将其从所有项目中移除，tx 为空时使用默认连接
+func (ps *ProjectStore) DeleteProjectMemberByResource(tx *gorm.DB, resourceType, resourceID string) error {
+	if tx == nil {
+		tx = ps.db
+	}
+	return tx.Where("resource_type = ? AND resource_id = ?", resourceType, resourceID).Delete(&model.ProjectMember{}).Error
+}
+
+func (ps *ProjectStore) GetProjectMember(ctx *logger.RequestContext, projectName, resourceType, resourceID string) (model.ProjectMember, error) {
+	var member model.ProjectMember
+	tx := ps.db.Model(&model.ProjectMember{}).
+		Where("project_name = ? AND resource_type = ? AND resource_id = ?", projectName, resourceType, resourceID).First(&member)
+	if tx.Error != nil {
+		return model.ProjectMember{}, tx.Error
+	}
+	return member, nil
+}
+
+// ListProjectMember 各过滤条件为空时不生效
+func (ps *ProjectStore) ListProjectMember(ctx *logger.RequestContext, projectName, resourceType, resourceID string) ([]model.ProjectMember, error) {
+	ctx.Logging().Debugf("model begin list project member. project:%s, %s:%s ", projectName, resourceType, resourceID)
+	tx := ps.db.Model(&model.ProjectMember{})
+	if projectName != "" {
+		tx = tx.Where("project_name = ?", projectName)
+	}
+	if resourceType != "" {
+		tx = tx.Where("resource_type = ?", resourceType)
+	}
+	if resourceID != "" {
+		tx = tx.Where("resource_id = ?", resourceID)
+	}
+	var members []model.ProjectMember
+	if err := tx.Order("pk").Find(&members).Error; err != nil {
+		ctx.Logging().Errorf("list project member failed. error:%s", err.Error())
+		return nil, err
+	}
+	return members, nil
+}
@@ -36,15 +36,18 @@ const (
 	queueJoinCluster  = "join `cluster_info` on `cluster_info`.id = queue.cluster_id"
 	queueSelectColumn = `queue.pk as pk, queue.id as id, queue.name as name, queue.namespace as namespace, queue.cluster_id as cluster_id,
 cluster_info.name as cluster_name, queue.quota_type as quota_type, queue.max_resources as max_resources, queue.min_resources as min_resources, queue.location as location,
-queue.scheduling_policy as scheduling_policy, queue.status as status, queue.created_at as created_at, queue.updated_at as updated_at, queue.deleted_at as deleted_at`
+queue.scheduling_policy as scheduling_policy, queue.priority_aging as priority_aging, queue.burst_policy as burst_policy, queue.node_pool as node_pool,
+queue.capacity_schedule as capacity_schedule, queue.status as status, queue.created_at as created_at, queue.updated_at as updated_at, queue.deleted_at as deleted_at`
 )
 
 type QueueStore struct {
 	db *gorm.DB
+	// cache contains queues by name, which is purged when any queue is changed
+	cache *lookupCache
 }
 
 func newQueueStore(db *gorm.DB) *QueueStore {
-	return &QueueStore{db: db}
+	return &QueueStore{db: db, cache: newLookupCache()}
 }
 
 func (qs *QueueStore) CreateQueue(queue *model.Queue) error {
@@ -54,11 +57,17 @@ func (qs *QueueStore) CreateQueue(queue *model.Queue) error {
 		queue.ID = uuid.GenerateID(common.PrefixQueue)
 	}
 
-	tx := qs.db.Table("queue").Create(queue)
-	if tx.Error != nil {
+	defer qs.cache.purge()
+	err := qs.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Table("queue").Create(queue).Error; err != nil {
+			return err
+		}
+		return RecordEvent(tx, queueEvent(queue.Name, model.EventCreated, "", queue.Status))
+	})
+	if err != nil {
 		log.Errorf("create queue failed. queue:%v, error:%s",
-			queue, tx.Error.Error())
-		return tx.Error
+			queue, err.Error())
+		return err
 	}
 	return nil
 }
@@ -68,6 +77,7 @@ func (qs *QueueStore) CreateOrUpdateQueue(queue *model.Queue) error {
 	if queue.ID == "" {
 		queue.ID = uuid.GenerateID(common.PrefixQueue)
 	}
+	defer qs.cache.purge()
 	tx := qs.db.Table("queue").Clauses(clause.OnConflict{
 		Columns: []clause.Column{{Name: "name"}},
 		DoUpdates: clause.AssignmentColumns([]string{"status", "namespace", "cluster_id",
@@ -83,6 +93,7 @@ func (qs *QueueStore) CreateOrUpdateQueue(queue *model.Queue) error {
 
 func (qs *QueueStore) UpdateQueue(queue *model.Queue) error {
 	log.Debugf("update queue:[%s], queue:%#v", queue.Name, queue)
+	defer qs.cache.purge()
 	tx := qs.db.Model(queue).Updates(queue)
 	return tx.Error
 }
@@ -93,11 +104,22 @@ func (qs *QueueStore) UpdateQueueStatus(queueName string, queueStatus string) er
 		log.Errorf("Invalid queue status. queueName:[%s] queueStatus:[%s]", queueName, queueStatus)
 		return fmt.Errorf("Invalid queue status. queueName:[%s] queueStatus:[%s]\n", queueName, queueStatus)
 	}
-	tx := qs.db.Table("queue").Where("name = ?", queueName).Update("status", strings.ToLower(queueStatus))
-	if tx.Error != nil {
+	defer qs.cache.purge()
+	err := qs.db.Transaction(func(tx *gorm.DB) error {
+		var preStatus string
+		if err := tx.Table("queue").Select("status").Where("name = ?", queueName).Limit(1).Scan(&preStatus).Error; err != nil {
+			return err
+		}
+		status := strings.ToLower(queueStatus)
+		if err := tx.Table("queue").Where("name = ?", queueName).Update("status", status).Error; err != nil {
+			return err
+		}
+		return RecordEvent(tx, queueEvent(queueName, model.EventStatusChanged, preStatus, status))
+	})
+	if err != nil {
 		log.Errorf("update queue status failed. queueName:[%s], queueStatus:[%s] error:[%s]",
-			queueName, queueStatus, tx.Error.Error())
-		return tx.Error
+			queueName, queueStatus, err.Error())
+		return err
 	}
 	return nil
 }
@@ -107,25 +129,44 @@ func (qs *QueueStore) UpdateQueueInfo(name, status string, max, min *resources.R
 	if err != nil {
 		return err
 	}
+	preStatus := queue.Status
 	if status != "" && common.IsValidQueueStatus(status) {
 		queue.Status = status
 	}
-	if max != nil {
+	// 配置了容量时间段的队列，集群中的配额由paddleflow按时间段设置，不覆盖队列的基础容量
+	if max != nil && queue.CapacitySchedule == nil {
 		queue.MaxResources = max
 	}
-	if min != nil {
+	if min != nil && queue.CapacitySchedule == nil {
 		queue.MinResources = min
 	}
-	tx := qs.db.Table("queue").Where("name = ?", name).Updates(&queue)
-	if tx.Error != nil {
-		log.Errorf("update queue failed, err %v", tx.Error)
-		return tx.Error
+	defer qs.cache.purge()
+	err = qs.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Table("queue").Where("name = ?", name).Updates(&queue).Error; err != nil {
+			return err
+		}
+		return RecordEvent(tx, queueEvent(name, model.EventStatusChanged, preStatus, queue.Status))
+	})
+	if err != nil {
+		log.Errorf("update queue failed, err %v", err)
+		return err
+	}
+	return nil
+}
+
+// UpdateQueueNodePool 绑定队列到节点池，nodePool为空时解除绑定
+func (qs *QueueStore) UpdateQueueNodePool(queueName, nodePool string) error {
+	defer qs.cache.purge()
+	if err := qs.db.Table("queue").Where("name = ?", queueName).Update("node_pool", nodePool).Error; err != nil {
+		log.Errorf("update node pool of queue %s failed. error:%s", queueName, err.Error())
+		return err
 	}
 	return nil
 }
 
 func (qs *QueueStore) DeleteQueue(queueName string) error {
 	log.Infof("begin delete queue. queueName:%s", queueName)
+	defer qs.cache.purge()
 	return qs.db.Transaction(func(tx *gorm.DB) error {
 		t := tx.Table("queue").Unscoped().Where("name = ?", queueName).Delete(&model.Queue{})
 		if t.Error != nil {
@@ -140,7 +181,7 @@ func (qs *QueueStore) DeleteQueue(queueName string) error {
 				queueName, tx.Error.Error())
 			return t.Error
 		}
-		return nil
+		return RecordEvent(tx, queueEvent(queueName, model.EventDeleted, "", ""))
 	})
 }
 
@@ -173,6 +214,19 @@ func (qs *QueueStore) GetQueueByName(queueName string) (model.Queue, error) {
 	return queue, nil
 }
 
+// GetCachedQueueByName gets queue from cache, which may be stale for a few seconds if queue is changed by other servers
+func (qs *QueueStore) GetCachedQueueByName(queueName string) (model.Queue, error) {
+	value, err := qs.cache.get(queueName, func() (interface{}, error) {
+		return qs.GetQueueByName(queueName)
+	})
+	if err != nil {
+		return model.Queue{}, err
+	}
+	queue := model.Queue{}
+	qs.DeepCopyQueue(value.(model.Queue), &queue)
+	return queue, nil
+}
+
 func (qs *QueueStore) GetQueueByID(queueID string) (model.Queue, error) {
 	log.Debugf("begin get queue. queueID:%s", queueID)
 
@@ -187,17 +241,23 @@ func (qs *QueueStore) GetQueueByID(queueID string) (model.Queue, error) {
 	return queue, nil
 }
 
-func (qs *QueueStore) ListQueue(pk int64, maxKeys int, queueName string, userName string) ([]model.Queue, error) {
+func (qs *QueueStore) ListQueue(pk int64, maxKeys int, queueName, userName, project string) ([]model.Queue, error) {
 	log.Debugf("begin list queue. ")
 	var tx *gorm.DB
 	tx = qs.db.Table("queue").Select(queueSelectColumn).Joins(queueJoinCluster).Where("queue.pk > ?", pk)
 	if !common.IsRootUser(userName) {
-		tx = tx.Joins("join `grant` on `grant`.resource_id = queue.name").Where(
-			"`grant`.user_name = ?", userName)
+		// 用户有授权的队列，以及所属项目中的队列
+		granted := qs.db.Model(&model.Grant{}).Select("resource_id").Where("user_name = ?", userName)
+		inProject := qs.db.Model(&model.ProjectMember{}).Select("resource_id").Where("resource_type = ? AND project_name IN (?)",
+			common.ResourceTypeQueue, userProjectQuery(qs.db, userName))
+		tx = tx.Where("queue.name IN (?) OR queue.name IN (?)", granted, inProject)
 	}
 	if !strings.EqualFold(queueName, "") {
 		tx = tx.Where("queue.name = ?", queueName)
 	}
+	if project != "" {
+		tx = tx.Where("queue.name IN (?)", projectResourceQuery(qs.db, project, common.ResourceTypeQueue))
+	}
 
 	if maxKeys > 0 {
 		tx = tx.Limit(maxKeys)
@@ -236,6 +296,7 @@ func (qs *QueueStore) ListQueuesByCluster(clusterID string) []model.Queue {
 func (qs *QueueStore) IsQueueInUse(queueID string) (bool, map[string]schema.JobStatus) {
 	queueInUseJobStatus := []schema.JobStatus{
 		schema.StatusJobInit,
+		schema.StatusJobPendingApproval,
 		schema.StatusJobPending,
 		schema.StatusJobTerminating,
 		schema.StatusJobRunning,
@@ -261,4 +322,17 @@ func (qs *QueueStore) DeepCopyQueue(queueSrc model.Queue, queueDesc *model.Queue
 	queueDesc.RawMaxResources = queueSrc.RawMaxResources
 	queueDesc.RawLocation = queueSrc.RawLocation
 	queueDesc.RawSchedulingPolicy = queueSrc.RawSchedulingPolicy
+	queueDesc.RawPriorityAging = queueSrc.RawPriorityAging
+	queueDesc.RawBurstPolicy = queueSrc.RawBurstPolicy
+	queueDesc.RawCapacitySchedule = queueSrc.RawCapacitySchedule
+}
+
+func queueEvent(queueName, eventType, preStatus, status string) *model.ResourceEvent {
+	return &model.ResourceEvent{
+		ResourceType:   common.ResourceTypeQueue,
+		ResourceID:     queueName,
+		Type:           eventType,
+		Status:         status,
+		PreviousStatus: preStatus,
+	}
 }
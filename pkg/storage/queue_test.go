@@ -182,7 +182,7 @@ func TestListQueue(t *testing.T) {
 	t.Logf("grants=%+v", grants)
 
 	// case1 list queue
-	queueList, err := Queue.ListQueue(0, 0, "", "")
+	queueList, err := Queue.ListQueue(0, 0, "", "", "")
 	if err != nil {
 		ctx.Logging().Errorf("models list queue failed. err:[%s]", err.Error())
 		ctx.ErrorCode = common.InternalError
@@ -191,7 +191,7 @@ func TestListQueue(t *testing.T) {
 
 	// case2 for root
 	ctx = &logger.RequestContext{UserName: mockRootUserName}
-	queueList, err = Queue.ListQueue(0, 0, "", "")
+	queueList, err = Queue.ListQueue(0, 0, "", "", "")
 	if err != nil {
 		ctx.Logging().Errorf("models list queue failed. err:[%s]", err.Error())
 		ctx.ErrorCode = common.InternalError
@@ -208,3 +208,81 @@ func TestGetQueueByName(t *testing.T) {
 	}
 	t.Logf("queue=%+v", queue)
 }
+
+func TestGetCachedQueueByName(t *testing.T) {
+	initMockDB()
+
+	cluster := model.ClusterInfo{Name: "cluster1", ClusterType: schema.KubernetesType, Status: "Status"}
+	assert.NoError(t, Cluster.CreateCluster(&cluster))
+	maxRes, err := resources.NewResourceFromMap(map[string]string{"cpu": "10", "mem": "100G"})
+	assert.NoError(t, err)
+	queue := model.Queue{Name: "queue1", Namespace: "paddleflow", ClusterId: cluster.ID, MaxResources: maxRes,
+		Status: schema.StatusQueueOpen}
+	assert.NoError(t, Queue.CreateQueue(&queue))
+
+	_, err = Queue.GetCachedQueueByName("queue2")
+	assert.Error(t, err)
+	cached, err := Queue.GetCachedQueueByName("queue1")
+	assert.NoError(t, err)
+	assert.Equal(t, schema.StatusQueueOpen, cached.Status)
+	assert.Equal(t, "cluster1", cached.ClusterName)
+	// cached queue is a copy
+	cached.MaxResources.SetResources("cpu", 20000)
+	cached, err = Queue.GetCachedQueueByName("queue1")
+	assert.NoError(t, err)
+	assert.Equal(t, resources.Quantity(10000), cached.MaxResources.CPU())
+
+	// cache is purged when queue is changed
+	assert.NoError(t, Queue.UpdateQueueStatus("queue1", schema.StatusQueueClosed))
+	cached, err = Queue.GetCachedQueueByName("queue1")
+	assert.NoError(t, err)
+	assert.Equal(t, schema.StatusQueueClosed, cached.Status)
+}
+
+func TestGetCachedUserQuota(t *testing.T) {
+	initMockDB()
+	ctx := &logger.RequestContext{UserName: mockRootUserName}
+
+	_, err := Auth.GetCachedUserQuota(ctx, mockUserName)
+	assert.Equal(t, common.RecordNotFound, common.ErrorCodeOf(err, common.InternalError))
+	assert.NoError(t, Auth.SaveUserQuota(ctx, &model.UserQuota{UserName: mockUserName, MaxConcurrentJobs: 2}))
+	quota, err := Auth.GetCachedUserQuota(ctx, mockUserName)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, quota.MaxConcurrentJobs)
+}
+
+// createQueueAndReload creates the queue and reads it back by id
+func createQueueAndReload(t *testing.T, queue model.Queue) model.Queue {
+	initMockDB()
+	cluster := model.ClusterInfo{Name: "cluster1", ClusterType: schema.KubernetesType, Status: "Status"}
+	assert.NoError(t, Cluster.CreateCluster(&cluster))
+	maxRes, err := resources.NewResourceFromMap(map[string]string{"cpu": "10", "mem": "100G"})
+	assert.NoError(t, err)
+	queue.Name = "queue1"
+	queue.Namespace = "paddleflow"
+	queue.ClusterId = cluster.ID
+	queue.MaxResources = maxRes
+	queue.Status = schema.StatusQueueOpen
+	assert.NoError(t, Queue.CreateQueue(&queue))
+
+	reloaded, err := Queue.GetQueueByID(queue.ID)
+	assert.NoError(t, err)
+	return reloaded
+}
+
+func TestQueuePriorityAgingRoundTrip(t *testing.T) {
+	aging := &model.PriorityAging{Interval: 600, MaxPriority: schema.PriorityClassHigh}
+	queue := createQueueAndReload(t, model.Queue{PriorityAging: aging})
+	assert.Equal(t, aging, queue.PriorityAging)
+}
+
+func TestQueueBurstPolicyRoundTrip(t *testing.T) {
+	burst := &model.BurstPolicy{Queue: "cloud-queue", WaitSeconds: 120, Labels: map[string]string{"burst": "true"}}
+	queue := createQueueAndReload(t, model.Queue{BurstPolicy: burst})
+	assert.Equal(t, burst, queue.BurstPolicy)
+}
+
+func TestQueueNodePoolRoundTrip(t *testing.T) {
+	queue := createQueueAndReload(t, model.Queue{NodePool: "gpu-a100"})
+	assert.Equal(t, "gpu-a100", queue.NodePool)
+}
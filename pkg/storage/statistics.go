@@ -0,0 +1,130 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+)
+
+type StatisticsStore struct {
+	db *gorm.DB
+}
+
+func newStatisticsStore(db *gorm.DB) *StatisticsStore {
+	return &StatisticsStore{db: db}
+}
+
+// SaveHourlyStats 覆盖写入一个小时的全部汇总数据，重复汇总同一小时结果不变
+func (ss *StatisticsStore) SaveHourlyStats(bucket time.Time, queues []model.QueueHourlyStat,
+	users []model.UserHourlyStat, images []model.ImageHourlyStat) error {
+	return WithTransaction(ss.db, func(tx *gorm.DB) error {
+		if err := tx.Where("bucket = ?", bucket).Delete(&model.QueueHourlyStat{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("bucket = ?", bucket).Delete(&model.UserHourlyStat{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("bucket = ?", bucket).Delete(&model.ImageHourlyStat{}).Error; err != nil {
+			return err
+		}
+		if len(queues) > 0 {
+			if err := tx.Create(&queues).Error; err != nil {
+				return err
+			}
+		}
+		if len(users) > 0 {
+			if err := tx.Create(&users).Error; err != nil {
+				return err
+			}
+		}
+		if len(images) > 0 {
+			if err := tx.Create(&images).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetLastStatBucket 返回最近一次汇总的小时，没有汇总数据时返回零值
+func (ss *StatisticsStore) GetLastStatBucket() (time.Time, error) {
+	var stat model.QueueHourlyStat
+	tx := ss.db.Model(&model.QueueHourlyStat{}).Order("bucket DESC").Limit(1).Find(&stat)
+	if tx.Error != nil {
+		return time.Time{}, tx.Error
+	}
+	return stat.Bucket, nil
+}
+
+// ListQueueHourlyStats 列出[start, end)内的队列汇总数据，queueNames为空时返回全部队列
+func (ss *StatisticsStore) ListQueueHourlyStats(start, end time.Time, queueNames []string) ([]model.QueueHourlyStat, error) {
+	query := ss.db.Model(&model.QueueHourlyStat{}).Where("bucket >= ? AND bucket < ?", start, end)
+	if len(queueNames) > 0 {
+		query = query.Where("queue_name IN ?", queueNames)
+	}
+	var stats []model.QueueHourlyStat
+	if err := query.Order("bucket").Find(&stats).Error; err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// SumUserGPUHours 按用户汇总[start, end)内的GPU卡时，按卡时倒序返回前limit个用户
+func (ss *StatisticsStore) SumUserGPUHours(start, end time.Time, limit int) ([]model.UserHourlyStat, error) {
+	query := ss.db.Model(&model.UserHourlyStat{}).
+		Select("user_name, SUM(gpu_hours) AS gpu_hours, SUM(job_count) AS job_count").
+		Where("bucket >= ? AND bucket < ?", start, end).
+		Group("user_name").Order("gpu_hours DESC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	var stats []model.UserHourlyStat
+	if err := query.Find(&stats).Error; err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// SumImageJobs 按镜像汇总[start, end)内结束及失败的作业数
+func (ss *StatisticsStore) SumImageJobs(start, end time.Time) ([]model.ImageHourlyStat, error) {
+	var stats []model.ImageHourlyStat
+	err := ss.db.Model(&model.ImageHourlyStat{}).
+		Select("image, SUM(finished_jobs) AS finished_jobs, SUM(failed_jobs) AS failed_jobs").
+		Where("bucket >= ? AND bucket < ?", start, end).
+		Group("image").Find(&stats).Error
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// DeleteHourlyStatsBefore 清理超过保留时间的汇总数据
+func (ss *StatisticsStore) DeleteHourlyStatsBefore(t time.Time) error {
+	return WithTransaction(ss.db, func(tx *gorm.DB) error {
+		if err := tx.Where("bucket < ?", t).Delete(&model.QueueHourlyStat{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("bucket < ?", t).Delete(&model.UserHourlyStat{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("bucket < ?", t).Delete(&model.ImageHourlyStat{}).Error
+	})
+}
@@ -0,0 +1,104 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+)
+
+type RunTrackingStore struct {
+	db *gorm.DB
+}
+
+func newRunTrackingStore(db *gorm.DB) *RunTrackingStore {
+	return &RunTrackingStore{db: db}
+}
+
+func (rs *RunTrackingStore) CreateRunMetrics(logEntry *log.Entry, metrics []model.RunMetric) error {
+	if len(metrics) == 0 {
+		return nil
+	}
+	logEntry.Debugf("begin create run metrics: %+v", metrics)
+	tx := rs.db.Model(&model.RunMetric{}).Create(&metrics)
+	if tx.Error != nil {
+		logEntry.Errorf("create run metrics failed. error:%v", tx.Error)
+		return tx.Error
+	}
+	return nil
+}
+
+// ListRunMetrics keys为空时返回全部指标，结果按step与上报时间排序
+func (rs *RunTrackingStore) ListRunMetrics(logEntry *log.Entry, runIDs, keys []string) ([]model.RunMetric, error) {
+	logEntry.Debugf("begin list run metrics. runIDs:%v, keys:%v", runIDs, keys)
+	var metrics []model.RunMetric
+	tx := rs.db.Model(&model.RunMetric{}).Where("run_id IN (?)", runIDs)
+	if len(keys) > 0 {
+		tx = tx.Where("`key` IN (?)", keys)
+	}
+	tx = tx.Order("step, timestamp, pk").Find(&metrics)
+	if tx.Error != nil {
+		logEntry.Errorf("list run metrics failed. runIDs:%v, error:%v", runIDs, tx.Error)
+		return nil, tx.Error
+	}
+	return metrics, nil
+}
+
+// SaveRunParams 保存param与tag，已存在的key会被覆盖
+func (rs *RunTrackingStore) SaveRunParams(logEntry *log.Entry, params []model.RunParam) error {
+	logEntry.Debugf("begin save run params: %+v", params)
+	return rs.db.Transaction(func(tx *gorm.DB) error {
+		for _, param := range params {
+			where := model.RunParam{RunID: param.RunID, Type: param.Type, Key: param.Key}
+			res := tx.Model(&model.RunParam{}).Where(&where).
+				Assign(model.RunParam{JobID: param.JobID, Value: param.Value}).FirstOrCreate(&model.RunParam{})
+			if res.Error != nil {
+				logEntry.Errorf("save run param[%s] of run[%s] failed. error:%v", param.Key, param.RunID, res.Error)
+				return res.Error
+			}
+		}
+		return nil
+	})
+}
+
+func (rs *RunTrackingStore) ListRunParams(logEntry *log.Entry, runIDs []string) ([]model.RunParam, error) {
+	logEntry.Debugf("begin list run params. runIDs:%v", runIDs)
+	var params []model.RunParam
+	tx := rs.db.Model(&model.RunParam{}).Where("run_id IN (?)", runIDs).Order("pk").Find(&params)
+	if tx.Error != nil {
+		logEntry.Errorf("list run params failed. runIDs:%v, error:%v", runIDs, tx.Error)
+		return nil, tx.Error
+	}
+	return params, nil
+}
+
+func (rs *RunTrackingStore) DeleteRunTracking(logEntry *log.Entry, runID string) error {
+	logEntry.Debugf("begin delete tracking data of run[%s]", runID)
+	return rs.db.Transaction(func(tx *gorm.DB) error {
+		if res := tx.Where("run_id = ?", runID).Delete(&model.RunMetric{}); res.Error != nil {
+			logEntry.Errorf("delete metrics of run[%s] failed. error:%v", runID, res.Error)
+			return res.Error
+		}
+		if res := tx.Where("run_id = ?", runID).Delete(&model.RunParam{}); res.Error != nil {
+			logEntry.Errorf("delete params of run[%s] failed. error:%v", runID, res.Error)
+			return res.Error
+		}
+		return nil
+	})
+}
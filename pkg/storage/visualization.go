@@ -0,0 +1,109 @@
+/*
+Copyright (c) 2022 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+
+	"github.com/PaddlePaddle/PaddleFlow/pkg/apiserver/common"
+	"github.com/PaddlePaddle/PaddleFlow/pkg/model"
+)
+
+type VisualizationStore struct {
+	db *gorm.DB
+}
+
+func newVisualizationStore(db *gorm.DB) *VisualizationStore {
+	return &VisualizationStore{db: db}
+}
+
+func (vs *VisualizationStore) CreateVisualization(logEntry *log.Entry, vis *model.Visualization) error {
+	logEntry.Debugf("begin create visualization: %+v", vis)
+	tx := vs.db.Model(&model.Visualization{}).Create(vis)
+	if tx.Error != nil {
+		logEntry.Errorf("create visualization failed. error:%v", tx.Error)
+		return tx.Error
+	}
+	return nil
+}
+
+func (vs *VisualizationStore) GetVisualization(logEntry *log.Entry, id string) (model.Visualization, error) {
+	logEntry.Debugf("begin get visualization[%s]", id)
+	var vis model.Visualization
+	tx := vs.db.Model(&model.Visualization{}).Where("id = ?", id).First(&vis)
+	if tx.Error != nil {
+		logEntry.Errorf("get visualization[%s] failed. error:%v", id, tx.Error)
+		return model.Visualization{}, tx.Error
+	}
+	return vis, nil
+}
+
+func (vs *VisualizationStore) UpdateVisualization(logEntry *log.Entry, id string, vis model.Visualization) error {
+	logEntry.Debugf("begin update visualization[%s]: %+v", id, vis)
+	tx := vs.db.Model(&model.Visualization{}).Where("id = ?", id).Updates(vis)
+	if tx.Error != nil {
+		logEntry.Errorf("update visualization[%s] failed. error:%v", id, tx.Error)
+		return tx.Error
+	}
+	return nil
+}
+
+func (vs *VisualizationStore) DeleteVisualization(logEntry *log.Entry, id string) error {
+	logEntry.Debugf("begin delete visualization[%s]", id)
+	tx := vs.db.Where("id = ?", id).Delete(&model.Visualization{})
+	if tx.Error != nil {
+		logEntry.Errorf("delete visualization[%s] failed. error:%v", id, tx.Error)
+		return tx.Error
+	}
+	return nil
+}
+
+// ListVisualization 非root用户只能看到自己创建的可视化服务
+func (vs *VisualizationStore) ListVisualization(logEntry *log.Entry, pk int64, maxKeys int, userName, jobID string) ([]model.Visualization, error) {
+	logEntry.Debugf("begin list visualization. pk:%d, maxKeys:%d, userName:%s, jobID:%s", pk, maxKeys, userName, jobID)
+	tx := vs.db.Model(&model.Visualization{}).Where("pk > ?", pk)
+	if !common.IsRootUser(userName) {
+		tx = tx.Where("user_name = ?", userName)
+	}
+	if jobID != "" {
+		tx = tx.Where("job_id = ?", jobID)
+	}
+	if maxKeys > 0 {
+		tx = tx.Limit(maxKeys)
+	}
+	var visList []model.Visualization
+	tx = tx.Order("pk").Find(&visList)
+	if tx.Error != nil {
+		logEntry.Errorf("list visualization failed. error:%v", tx.Error)
+		return nil, tx.Error
+	}
+	return visList, nil
+}
+
+func (vs *VisualizationStore) ListExpiredVisualization(logEntry *log.Entry, now time.Time) ([]model.Visualization, error) {
+	var visList []model.Visualization
+	tx := vs.db.Model(&model.Visualization{}).Where("status = ? AND expired_at < ?",
+		model.VisualizationStatusRunning, now).Find(&visList)
+	if tx.Error != nil {
+		logEntry.Errorf("list expired visualization failed. error:%v", tx.Error)
+		return nil, tx.Error
+	}
+	return visList, nil
+}